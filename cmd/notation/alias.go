@@ -0,0 +1,111 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/notaryproject/notation/internal/experimental"
+	"github.com/notaryproject/notation/internal/ioutil"
+	"github.com/notaryproject/notation/pkg/configutil"
+	"github.com/spf13/cobra"
+)
+
+func aliasCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "alias",
+		Short: "[Experimental] Manage aliases of repositories",
+		Long: `[Experimental] Manage aliases of repositories
+
+An alias is a short name of a repository, usable in place of the repository in the references of the commands "sign", "verify", "list", "inspect", "report badge" and "archive create", followed by a tag or a digest as needed. The aliases are stored in config.json.
+
+Example - Add an alias of a repository:
+  notation alias add prod-api registry.example.com/team/api
+
+Example - Sign an artifact of the repository using the alias:
+  notation sign prod-api@sha256:...
+
+Example - List the aliases:
+  notation alias ls
+
+Example - Delete aliases:
+  notation alias delete <alias_name>...
+`,
+	}
+	command.AddCommand(aliasAddCommand(), aliasListCommand(), aliasDeleteCommand())
+	return command
+}
+
+func aliasAddCommand() *cobra.Command {
+	var name, repository string
+	return &cobra.Command{
+		Use:   "add <alias_name> <registry>/<repository>",
+		Short: "[Experimental] Add or update an alias of a repository",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 2 {
+				return errors.New("expecting an alias name and a repository")
+			}
+			name, repository = args[0], args[1]
+			return nil
+		},
+		PreRunE: experimental.CheckCommandAndWarn,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := configutil.SetAlias(name, repository); err != nil {
+				return err
+			}
+			fmt.Printf("%s: %s\n", name, repository)
+			return nil
+		},
+	}
+}
+
+func aliasListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "[Experimental] List the aliases of repositories",
+		Args:    cobra.NoArgs,
+		PreRunE: experimental.CheckCommandAndWarn,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cliConfig, err := configutil.LoadCLIConfig()
+			if err != nil {
+				return err
+			}
+			return ioutil.PrintAliasMap(os.Stdout, cliConfig.Aliases)
+		},
+	}
+}
+
+func aliasDeleteCommand() *cobra.Command {
+	var names []string
+	return &cobra.Command{
+		Use:   "delete <alias_name>...",
+		Short: "[Experimental] Delete aliases of repositories",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return errors.New("missing alias names")
+			}
+			names = args
+			return nil
+		},
+		PreRunE: experimental.CheckCommandAndWarn,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			for _, name := range names {
+				if err := configutil.RemoveAlias(name); err != nil {
+					return err
+				}
+				fmt.Println(name)
+			}
+			return nil
+		},
+	}
+}
+
+// expandAlias replaces the alias at the beginning of the reference by its
+// repository. References of OCI layouts are never expanded, as they are paths.
+func expandAlias(inputType inputType, reference string) (string, error) {
+	if inputType != inputTypeRegistry {
+		return reference, nil
+	}
+	return configutil.ExpandAlias(reference)
+}
@@ -0,0 +1,25 @@
+package main
+
+import "testing"
+
+func TestAliasAddCommand_Args(t *testing.T) {
+	command := aliasAddCommand()
+	if err := command.Args(command, []string{"prod-api"}); err == nil {
+		t.Fatal("expected error for missing repository")
+	}
+	if err := command.Args(command, []string{"prod-api", "registry.example.com/team/api"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExpandAlias_OCILayout(t *testing.T) {
+	// paths of OCI layouts are never looked up as aliases
+	reference := "hello-world:v1"
+	got, err := expandAlias(inputTypeOCILayout, reference)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != reference {
+		t.Fatalf("expandAlias() = %q, want %q", got, reference)
+	}
+}
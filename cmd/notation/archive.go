@@ -0,0 +1,327 @@
+package main
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	corex509 "github.com/notaryproject/notation-core-go/x509"
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation/internal/archive"
+	"github.com/notaryproject/notation/internal/cmd"
+	"github.com/notaryproject/notation/internal/color"
+	"github.com/notaryproject/notation/internal/experimental"
+	"github.com/notaryproject/notation/internal/osutil"
+	"github.com/notaryproject/notation/internal/policy"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/spf13/cobra"
+)
+
+type archiveCreateOpts struct {
+	cmd.LoggingFlagOpts
+	SecureFlagOpts
+	reference     string
+	tsaURL        string
+	hashAlgorithm string
+	output        string
+}
+
+type archiveRenewOpts struct {
+	cmd.LoggingFlagOpts
+	path          string
+	tsaURL        string
+	hashAlgorithm string
+}
+
+type archiveVerifyOpts struct {
+	path    string
+	tsaRoot string
+}
+
+func archiveCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "archive",
+		Short: "[Experimental] Manage evidence records extending the validity of signatures",
+		Long: `[Experimental] Manage evidence records extending the validity of signatures
+
+An evidence record wraps the signatures of an artifact with a chain of archive timestamps from RFC 3161 timestamp authorities, modeled after the Evidence Record Syntax of RFC 4998. It proves that the signatures existed while their certificates were valid, long after the certificates have expired or the signing keys have been destroyed. The record is renewed periodically without the signing key, before the certificate of the latest timestamp authority expires or its hash algorithm becomes weak.
+
+Example - Archive the signatures of an artifact verified by the trust policy:
+  notation archive create --tsa-url https://timestamp.example.com -o evidence.json <registry>/<repository>@<digest>
+
+Example - Renew an evidence record with a timestamp of another timestamp authority:
+  notation archive renew --tsa-url https://timestamp2.example.com evidence.json
+
+Example - Renew an evidence record with a stronger hash algorithm:
+  notation archive renew --tsa-url https://timestamp.example.com --hash-algorithm sha512 evidence.json
+
+Example - Verify an evidence record:
+  notation archive verify --tsa-root tsa-roots.pem evidence.json
+`,
+	}
+	command.AddCommand(archiveCreateCommand(nil), archiveRenewCommand(nil), archiveVerifyCommand(nil))
+	return command
+}
+
+func archiveCreateCommand(opts *archiveCreateOpts) *cobra.Command {
+	if opts == nil {
+		opts = &archiveCreateOpts{}
+	}
+	command := &cobra.Command{
+		Use:   "create [flags] <reference>",
+		Short: "[Experimental] Archive the signatures of an artifact in an evidence record",
+		Long: `[Experimental] Archive the signatures of an artifact in an evidence record
+
+The signatures of the artifact are verified with the trust policy first, and only the verified signatures are archived with the initial archive timestamp.`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return errors.New("expecting one artifact reference")
+			}
+			opts.reference = args[0]
+			return nil
+		},
+		PreRunE: experimental.CheckCommandAndWarn,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.SecureFlagOpts.applyChangedFlags(cmd.Flags())
+			return runArchiveCreate(cmd, opts)
+		},
+	}
+	opts.LoggingFlagOpts.ApplyFlags(command.Flags())
+	opts.SecureFlagOpts.ApplyFlags(command.Flags())
+	command.Flags().StringVar(&opts.tsaURL, "tsa-url", "", "URL of the RFC 3161 timestamp authority issuing the archive timestamp")
+	command.Flags().StringVar(&opts.hashAlgorithm, "hash-algorithm", "sha256", "hash algorithm of the archive timestamp, options: sha256, sha384, sha512")
+	command.Flags().StringVarP(&opts.output, "output", "o", "", "path of the evidence record to write")
+	command.MarkFlagRequired("tsa-url")
+	command.MarkFlagRequired("output")
+	return command
+}
+
+func archiveRenewCommand(opts *archiveRenewOpts) *cobra.Command {
+	if opts == nil {
+		opts = &archiveRenewOpts{}
+	}
+	command := &cobra.Command{
+		Use:   "renew [flags] <evidence_record>",
+		Short: "[Experimental] Renew an evidence record with a new archive timestamp",
+		Long: `[Experimental] Renew an evidence record with a new archive timestamp
+
+The new archive timestamp covers the previous archive timestamp, or the archived signatures and all previous archive timestamps if the hash algorithm changes. Renew the record before the certificate of the timestamp authority of the latest archive timestamp expires.`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return errors.New("expecting one evidence record")
+			}
+			opts.path = args[0]
+			return nil
+		},
+		PreRunE: experimental.CheckCommandAndWarn,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runArchiveRenew(cmd, opts)
+		},
+	}
+	opts.LoggingFlagOpts.ApplyFlags(command.Flags())
+	command.Flags().StringVar(&opts.tsaURL, "tsa-url", "", "URL of the RFC 3161 timestamp authority issuing the archive timestamp")
+	command.Flags().StringVar(&opts.hashAlgorithm, "hash-algorithm", "", "hash algorithm of the archive timestamp, options: sha256, sha384, sha512. Defaults to the hash algorithm of the latest archive timestamp")
+	command.MarkFlagRequired("tsa-url")
+	return command
+}
+
+func archiveVerifyCommand(opts *archiveVerifyOpts) *cobra.Command {
+	if opts == nil {
+		opts = &archiveVerifyOpts{}
+	}
+	command := &cobra.Command{
+		Use:   "verify [flags] <evidence_record>",
+		Short: "[Experimental] Verify an evidence record",
+		Long: `[Experimental] Verify an evidence record
+
+Each archive timestamp must cover the archived signatures or the previous archive timestamps, and its timestamp authority must chain to the roots of flag "--tsa-root" when the next archive timestamp is issued, or now for the latest one. The archived signatures must be intact and their signing certificates valid at the time of the initial archive timestamp.`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return errors.New("expecting one evidence record")
+			}
+			opts.path = args[0]
+			return nil
+		},
+		PreRunE: experimental.CheckCommandAndWarn,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runArchiveVerify(opts)
+		},
+	}
+	command.Flags().StringVar(&opts.tsaRoot, "tsa-root", "", "path of the PEM or DER file of the trusted root certificates of the timestamp authorities")
+	command.MarkFlagRequired("tsa-root")
+	return command
+}
+
+func runArchiveCreate(command *cobra.Command, opts *archiveCreateOpts) error {
+	// set log level
+	ctx := opts.LoggingFlagOpts.SetLoggerLevel(command.Context())
+
+	hash, err := archive.ParseHashAlgorithm(opts.hashAlgorithm)
+	if err != nil {
+		return err
+	}
+	reference, err := expandAlias(inputTypeRegistry, opts.reference)
+	if err != nil {
+		return err
+	}
+	policyVerifier, err := policy.NewVerifierFromConfigOptions(policy.ConfigOptions{CRLCache: newCRLCache(ctx, false)})
+	if err != nil {
+		return err
+	}
+	warnTrustPolicy("")
+	sigRepo, err := getRemoteRepository(ctx, &opts.SecureFlagOpts, reference)
+	if err != nil {
+		return err
+	}
+	manifestDesc, resolvedRef, err := resolveReference(ctx, inputTypeRegistry, reference, sigRepo, func(ref string, manifestDesc ocispec.Descriptor) {
+		fmt.Fprintf(os.Stderr, "%s Always archive the signatures of an artifact using digest(@sha256:...) rather than a tag(:%s) because resolved digest may not point to the same signed artifact, as tags are mutable.\n", color.Warning(os.Stderr, "Warning:"), ref)
+	})
+	if err != nil {
+		return err
+	}
+	verifyOpts := notation.VerifierVerifyOptions{ArtifactReference: resolvedRef}
+	skip, _, err := policyVerifier.SkipVerify(ctx, verifyOpts)
+	if err != nil {
+		return err
+	}
+	if skip {
+		return fmt.Errorf("the trust policy skips the verification of %s, only verified signatures are archived", resolvedRef)
+	}
+
+	var signatures []archive.Signature
+	err = sigRepo.ListSignatures(ctx, manifestDesc, func(signatureManifests []ocispec.Descriptor) error {
+		for _, sigManifestDesc := range signatureManifests {
+			sigBlob, sigDesc, err := sigRepo.FetchSignatureBlob(ctx, sigManifestDesc)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s unable to fetch signature %s due to error: %v\n", color.Warning(os.Stderr, "Warning:"), sigManifestDesc.Digest, err)
+				continue
+			}
+			verifyOpts.SignatureMediaType = sigDesc.MediaType
+			if _, err := policyVerifier.Verify(ctx, manifestDesc, sigBlob, verifyOpts); err != nil {
+				fmt.Fprintf(os.Stderr, "%s signature %s is not archived as its verification failed: %v\n", color.Warning(os.Stderr, "Warning:"), sigManifestDesc.Digest, err)
+				continue
+			}
+			signatures = append(signatures, archive.Signature{
+				MediaType: sigDesc.MediaType,
+				Envelope:  sigBlob,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(signatures) == 0 {
+		return fmt.Errorf("no verified signature of %s to archive", resolvedRef)
+	}
+	record, err := archive.New(ctx, manifestDesc, signatures, &archive.HTTPTimestamper{URL: opts.tsaURL}, hash)
+	if err != nil {
+		return err
+	}
+	if err := writeEvidenceRecord(opts.output, record); err != nil {
+		return err
+	}
+	fmt.Printf("Archived %d signature(s) of %s at %s in %s\n", len(signatures), resolvedRef, record.ArchiveTimestamps[0].Time.Format(time.RFC3339), opts.output)
+	return nil
+}
+
+func runArchiveRenew(command *cobra.Command, opts *archiveRenewOpts) error {
+	// set log level
+	ctx := opts.LoggingFlagOpts.SetLoggerLevel(command.Context())
+
+	record, err := readEvidenceRecord(opts.path)
+	if err != nil {
+		return err
+	}
+	if len(record.ArchiveTimestamps) == 0 {
+		return fmt.Errorf("evidence record %s has no archive timestamp", opts.path)
+	}
+	hashAlgorithm := opts.hashAlgorithm
+	if hashAlgorithm == "" {
+		hashAlgorithm = record.ArchiveTimestamps[len(record.ArchiveTimestamps)-1].HashAlgorithm
+	}
+	hash, err := archive.ParseHashAlgorithm(hashAlgorithm)
+	if err != nil {
+		return err
+	}
+	renewal, err := record.Renew(ctx, &archive.HTTPTimestamper{URL: opts.tsaURL}, hash)
+	if err != nil {
+		return err
+	}
+	if err := writeEvidenceRecord(opts.path, record); err != nil {
+		return err
+	}
+	latest := record.ArchiveTimestamps[len(record.ArchiveTimestamps)-1]
+	fmt.Printf("Renewed %s with a %s renewal at %s\n", opts.path, renewal, latest.Time.Format(time.RFC3339))
+	return nil
+}
+
+func runArchiveVerify(opts *archiveVerifyOpts) error {
+	record, err := readEvidenceRecord(opts.path)
+	if err != nil {
+		return err
+	}
+	roots, err := readTimestampRoots(opts.tsaRoot)
+	if err != nil {
+		return err
+	}
+	verification, err := record.Verify(roots, time.Now())
+	if err != nil {
+		return fmt.Errorf("evidence record verification failed: %w", err)
+	}
+	fmt.Printf("Successfully verified the evidence record of %s\n", record.Subject.Digest)
+	fmt.Printf("The signatures existed at %s, signed by:\n", verification.ExistedAt.UTC().Format(time.RFC3339))
+	for _, signer := range verification.Signers {
+		fmt.Printf("  %s\n", signer)
+	}
+	fmt.Println("Archive timestamps:")
+	for i, ts := range verification.Timestamps {
+		fmt.Printf("  %d: %s %s at %s by %s\n", i, ts.Renewal, ts.HashAlgorithm, ts.Time.UTC().Format(time.RFC3339), ts.Authority)
+	}
+	latest := verification.Timestamps[len(verification.Timestamps)-1]
+	fmt.Printf("Renew the evidence record before %s\n", latest.NotAfter.UTC().Format(time.RFC3339))
+	return nil
+}
+
+// readTimestampRoots reads the trusted root certificates of the timestamp
+// authorities from the PEM or DER file at path.
+func readTimestampRoots(path string) (*x509.CertPool, error) {
+	rootCerts, err := corex509.ReadCertificateFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the roots of the timestamp authorities: %w", err)
+	}
+	roots := x509.NewCertPool()
+	for _, cert := range rootCerts {
+		roots.AddCert(cert)
+	}
+	return roots, nil
+}
+
+// readEvidenceRecord reads the evidence record at path.
+func readEvidenceRecord(path string) (*archive.Record, error) {
+	recordJSON, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var record archive.Record
+	if err := json.Unmarshal(recordJSON, &record); err != nil {
+		return nil, fmt.Errorf("malformed evidence record %s: %w", path, err)
+	}
+	if record.MediaType != archive.MediaType {
+		return nil, fmt.Errorf("%s is not an evidence record, unexpected media type %q", path, record.MediaType)
+	}
+	return &record, nil
+}
+
+// writeEvidenceRecord writes the evidence record to path, replacing the file
+// atomically so that a failed renewal does not lose the record.
+func writeEvidenceRecord(path string, record *archive.Record) error {
+	recordJSON, err := json.MarshalIndent(record, "", "    ")
+	if err != nil {
+		return err
+	}
+	return osutil.WriteFileAtomic(path, append(recordJSON, '\n'), 0600)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/notation/internal/archive"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+func TestArchiveCreateCommand(t *testing.T) {
+	opts := &archiveCreateOpts{}
+	command := archiveCreateCommand(opts)
+	expected := &archiveCreateOpts{
+		reference:     "ref",
+		tsaURL:        "https://timestamp.example.com",
+		hashAlgorithm: "sha384",
+		output:        "evidence.json",
+	}
+	if err := command.ParseFlags([]string{
+		expected.reference,
+		"--tsa-url", expected.tsaURL,
+		"--hash-algorithm", expected.hashAlgorithm,
+		"-o", expected.output}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := command.Args(command, command.Flags().Args()); err != nil {
+		t.Fatalf("Parse Args failed: %v", err)
+	}
+	if !reflect.DeepEqual(*expected, *opts) {
+		t.Fatalf("Expect archive create opts: %v, got: %v", expected, opts)
+	}
+}
+
+func TestArchiveRenewCommand_DefaultHashAlgorithm(t *testing.T) {
+	opts := &archiveRenewOpts{}
+	command := archiveRenewCommand(opts)
+	if err := command.ParseFlags([]string{"evidence.json", "--tsa-url", "https://timestamp.example.com"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := command.Args(command, command.Flags().Args()); err != nil {
+		t.Fatalf("Parse Args failed: %v", err)
+	}
+	if opts.path != "evidence.json" || opts.hashAlgorithm != "" {
+		t.Fatalf("unexpected archive renew opts: %+v", opts)
+	}
+}
+
+func TestArchiveCommand_Experimental(t *testing.T) {
+	t.Setenv("NOTATION_EXPERIMENTAL", "")
+	command := archiveVerifyCommand(nil)
+	if err := command.PreRunE(command, nil); err == nil {
+		t.Fatal("expected error when experimental features are disabled")
+	}
+}
+
+func TestWriteEvidenceRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "evidence.json")
+	record := &archive.Record{
+		MediaType: archive.MediaType,
+		Version:   archive.Version,
+		Subject:   ocispec.Descriptor{MediaType: ocispec.MediaTypeImageManifest, Digest: digest.FromString("artifact"), Size: 8},
+		Signatures: []archive.Signature{
+			{MediaType: "application/jose+json", Envelope: []byte("envelope")},
+		},
+		ArchiveTimestamps: []archive.ArchiveTimestamp{
+			{Renewal: archive.RenewalInitial, HashAlgorithm: "sha256", Time: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC), Token: []byte("token")},
+		},
+	}
+	if err := writeEvidenceRecord(path, record); err != nil {
+		t.Fatal(err)
+	}
+	got, err := readEvidenceRecord(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(record, got) {
+		t.Fatalf("readEvidenceRecord() = %+v, want %+v", got, record)
+	}
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected no temporary file left, got %d entries", len(entries))
+	}
+
+	if err := os.WriteFile(path, []byte(`{"mediaType":"application/json"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readEvidenceRecord(path); err == nil {
+		t.Fatal("expected error for unexpected media type")
+	}
+}
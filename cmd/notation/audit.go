@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/notaryproject/notation-go"
+	notationregistry "github.com/notaryproject/notation-go/registry"
+	"github.com/notaryproject/notation/cmd/notation/internal/integrity"
+	"github.com/notaryproject/notation/internal/audit"
+	"github.com/notaryproject/notation/internal/chaos"
+	"github.com/notaryproject/notation/internal/cmd"
+	"github.com/notaryproject/notation/internal/color"
+	"github.com/notaryproject/notation/internal/events"
+	"github.com/notaryproject/notation/internal/httputil"
+	"github.com/notaryproject/notation/internal/metrics"
+	"github.com/notaryproject/notation/internal/policy"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/registry"
+)
+
+// runVerifyAllTags verifies every tagged artifact in the repository of
+// opts.reference. The progress is recorded in the checkpoint file, if set, so
+// that an interrupted audit resumes where it left off.
+func runVerifyAllTags(ctx context.Context, opts *verifyOpts, verifier notation.Verifier, policyVerifier *policy.Verifier, pluginConfig map[string]string, maxAttempts int) error {
+	ref, err := registry.ParseReference(opts.reference)
+	if err != nil {
+		return err
+	}
+	if ref.Reference != "" {
+		return errors.New("flag \"--all-tags\" requires a repository reference without tag or digest")
+	}
+	repository := ref.Registry + "/" + ref.Repository
+
+	// the CSV output lists the results of all the tags, including the tags
+	// verified before resuming, and the text messages go to stderr instead
+	var csvWriter *audit.CSVWriter
+	textOut := os.Stdout
+	if opts.outputFormat == cmd.OutputCSV {
+		if csvWriter, err = audit.NewCSVWriter(os.Stdout, repository); err != nil {
+			return err
+		}
+		textOut = os.Stderr
+	}
+
+	start := time.Now()
+	checkpoint, err := auditTags(ctx, ref, opts, verifier, policyVerifier, pluginConfig, maxAttempts, func(entry audit.Entry, resumed bool) error {
+		if csvWriter != nil {
+			if err := csvWriter.Write(entry); err != nil {
+				return err
+			}
+		}
+		// the successes are only listed in the CSV output
+		if !resumed && (csvWriter == nil || entry.Result != audit.ResultSuccess) {
+			printAuditEntry(repository, entry, os.Stdout)
+		}
+		return nil
+	})
+	if opts.metricsTextfile != "" {
+		result := metrics.Result{Repository: repository, Duration: time.Since(start), Err: err}
+		if checkpoint != nil {
+			result.Verified, result.Failed = checkpoint.Count()
+		}
+		if metricsErr := writeMetricsTextfile(opts.metricsTextfile, metrics.OperationVerifyAllTags, []metrics.Result{result}, err); metricsErr != nil {
+			return metricsErr
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	succeeded, failed := checkpoint.Count()
+	fmt.Fprintf(textOut, "Audited %d tags of %s: %d succeeded, %d failed\n", succeeded+failed, repository, succeeded, failed)
+	if failed > 0 {
+		return fmt.Errorf("signature verification failed for %d tags of %s", failed, repository)
+	}
+	return nil
+}
+
+// writeMetricsTextfile writes the results of the batch operation to the
+// metrics textfile at path. If the operation failed with opErr, a failure to
+// write the textfile is only warned about, so that opErr is reported.
+func writeMetricsTextfile(path string, operation metrics.Operation, results []metrics.Result, opErr error) error {
+	err := metrics.WriteTextfile(path, operation, results)
+	if err == nil {
+		return nil
+	}
+	if opErr != nil {
+		fmt.Fprintf(os.Stderr, "%s failed to write metrics textfile: %v\n", color.Warning(os.Stderr, "Warning:"), err)
+		return nil
+	}
+	return fmt.Errorf("failed to write metrics textfile: %w", err)
+}
+
+// auditTags verifies every tagged artifact in the repository of ref, calling
+// report with the result of every tag, including the tags verified
+// successfully before resuming from the checkpoint file of opts, which are
+// reported as resumed. The checkpoint of the audit is returned.
+// The tagged manifests and the signature manifests are read as required by the
+// trust policy extensions of policyVerifier.
+func auditTags(ctx context.Context, ref registry.Reference, opts *verifyOpts, verifier notation.Verifier, policyVerifier *policy.Verifier, pluginConfig map[string]string, maxAttempts int, report func(entry audit.Entry, resumed bool) error) (*audit.Checkpoint, error) {
+	repository := ref.Registry + "/" + ref.Repository
+	remoteRepo, err := getRepositoryClient(ctx, &opts.SecureFlagOpts, ref)
+	if err != nil {
+		return nil, err
+	}
+	remoteRepo.Client = httputil.NewRateLimitedClient(remoteRepo.Client, opts.qps)
+	repo := chaos.NewRepository(notationregistry.NewRepository(remoteRepo), chaos.FromContext(ctx))
+	if policyVerifier.UsesArtifactTypes() {
+		repo = policy.NewRepository(repo, remoteRepo.Manifests())
+	}
+	if policyVerifier.UsesSignatureAnnotations() {
+		repo = policyVerifier.SignatureRepository(repo, remoteRepo.Manifests())
+	}
+	var manifestFetcher content.Fetcher
+	if opts.paranoid {
+		manifestFetcher = remoteRepo.Manifests()
+	}
+
+	checkpoint, err := audit.LoadCheckpoint(opts.checkpoint, repository)
+	if err != nil {
+		return nil, err
+	}
+	if succeeded, failed := checkpoint.Count(); succeeded+failed > 0 {
+		fmt.Fprintf(os.Stderr, "Resuming audit of %s, %d tags already verified, %d failed tags to retry\n", repository, succeeded, failed)
+	}
+
+	var tags []string
+	if err := remoteRepo.Tags(ctx, "", func(page []string) error {
+		tags = append(tags, page...)
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to list tags of %s: %w", repository, err)
+	}
+
+	emitter := events.FromContext(ctx)
+	for i, tag := range tags {
+		emitter.Emit(events.Event{Type: events.TypeProgress, Stage: "verifying", Reference: repository + ":" + tag, Completed: i, Total: len(tags)})
+		// tampering is tracked per tag
+		sigRepo := integrity.NewRepository(repo, manifestFetcher)
+		var entry audit.Entry
+		desc, err := sigRepo.Resolve(ctx, tag)
+		if err != nil {
+			entry = audit.Entry{Tag: tag, Result: audit.ResultFailure, Error: fmt.Sprintf("failed to resolve tag: %v", err)}
+		} else {
+			// tags verified successfully are skipped unless re-pushed
+			if checkpoint.Done(tag, desc.Digest.String()) {
+				entry, _ := checkpoint.Entry(tag)
+				if err := report(entry, true); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			entry = verifyTag(ctx, verifier, sigRepo, repository, tag, desc, pluginConfig, maxAttempts)
+		}
+		resultEvent := events.Event{Type: events.TypeResult, Reference: repository + ":" + tag, Digest: entry.Digest, Result: events.ResultSuccess}
+		if entry.Result != audit.ResultSuccess {
+			resultEvent.Result = events.ResultFailure
+			resultEvent.Error = entry.Error
+		}
+		emitter.Emit(resultEvent)
+		if err := report(entry, false); err != nil {
+			return nil, err
+		}
+		if err := checkpoint.Record(entry); err != nil {
+			return nil, fmt.Errorf("failed to write checkpoint: %w", err)
+		}
+	}
+	return checkpoint, nil
+}
+
+// printAuditEntry prints the result of a tag of the repository, successes to
+// out and failures to stderr.
+func printAuditEntry(repository string, entry audit.Entry, out *os.File) {
+	if entry.Result == audit.ResultSuccess {
+		fmt.Fprintf(out, "%s %s:%s (%s)\n", color.Success(out, "Successfully verified signature for"), repository, entry.Tag, entry.Digest)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "%s %s:%s: %s\n", color.Failure(os.Stderr, "Error:"), repository, entry.Tag, entry.Error)
+}
+
+// verifyTag verifies a single tag of the repository resolved to desc,
+// evaluating at most maxAttempts signatures.
+func verifyTag(ctx context.Context, verifier notation.Verifier, sigRepo *integrity.Repository, repository, tag string, desc ocispec.Descriptor, pluginConfig map[string]string, maxAttempts int) audit.Entry {
+	entry := audit.Entry{Tag: tag, Digest: desc.Digest.String()}
+	artifactRef := repository + "@" + entry.Digest
+	_, outcomes, err := notation.Verify(ctx, sigRepo.Verifier(verifier), sigRepo, notation.VerifyOptions{
+		ArtifactReference:    artifactRef,
+		PluginConfig:         pluginConfig,
+		MaxSignatureAttempts: maxAttempts,
+	})
+	if tamperErr := sigRepo.Err(); tamperErr != nil {
+		err = tamperErr
+	}
+	if err := checkVerificationFailure(outcomes, artifactRef, err); err != nil {
+		entry.Result = audit.ResultFailure
+		entry.Error = err.Error()
+		return entry
+	}
+	entry.Result = audit.ResultSuccess
+	return entry
+}
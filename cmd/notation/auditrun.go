@@ -0,0 +1,297 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/notaryproject/notation/internal/audit"
+	"github.com/notaryproject/notation/internal/cmd"
+	"github.com/notaryproject/notation/internal/color"
+	"github.com/notaryproject/notation/internal/experimental"
+	"github.com/notaryproject/notation/internal/metrics"
+	"github.com/notaryproject/notation/internal/osutil"
+	"github.com/notaryproject/notation/internal/policy"
+	"github.com/spf13/cobra"
+	"oras.land/oras-go/v2/registry"
+)
+
+// timeNow returns the current time, for unit test.
+var timeNow = time.Now
+
+func auditCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "audit",
+		Short: "[Experimental] Audit the signatures of repositories",
+		Long:  "[Experimental] Audit the signatures of repositories",
+	}
+	command.AddCommand(auditRunCommand(nil))
+	return command
+}
+
+type auditRunOpts struct {
+	cmd.LoggingFlagOpts
+	SecureFlagOpts
+	config          string
+	concurrency     int
+	force           bool
+	metricsTextfile string
+}
+
+func auditRunCommand(opts *auditRunOpts) *cobra.Command {
+	if opts == nil {
+		opts = &auditRunOpts{}
+	}
+	command := &cobra.Command{
+		Use:   "run [flags] --config <file>",
+		Short: "[Experimental] Run the audits of a configuration file",
+		Long: `[Experimental] Run the audits of a configuration file
+
+The configuration file, in YAML or JSON, lists the repositories to audit with the trust policy documents verifying them and their schedules, and the outputs of the merged results. Every tagged artifact of each repository is verified, as with "notation verify --all-tags". An audit with a schedule, e.g. "24h", only runs if it has not run within the schedule, according to the state file next to the configuration file, so that a cron job running the command frequently audits each repository at its own pace.
+
+Example configuration file:
+  concurrency: 2
+  audits:
+    - repository: registry.example.com/prod/app
+      policy: prod
+      schedule: 24h
+    - name: staging
+      repository: registry.example.com/staging/app
+      schedule: 168h
+  outputs:
+    - format: csv
+      path: audit.csv
+    - format: json
+      path: "-"
+
+Example - Run the audits which are due:
+  notation audit run --config audit.yaml
+
+Example - Run all the audits regardless of their schedules, 4 repositories at the same time:
+  notation audit run --config audit.yaml --force --concurrency 4
+
+Example - Run the audits which are due and write their outcomes for the textfile collector of the Prometheus node exporter:
+  notation audit run --config audit.yaml --metrics-textfile /var/lib/node_exporter/textfile/notation.prom
+`,
+		Args: cobra.NoArgs,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if opts.config == "" {
+				return errors.New("flag \"--config\" is required")
+			}
+			if opts.concurrency < 0 {
+				return errors.New("flag \"--concurrency\" must not be negative")
+			}
+			if opts.metricsTextfile != "" {
+				if err := metrics.ValidateTextfilePath(opts.metricsTextfile); err != nil {
+					return fmt.Errorf("invalid flag \"--metrics-textfile\": %w", err)
+				}
+			}
+			return experimental.CheckCommandAndWarn(cmd, args)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.SecureFlagOpts.applyChangedFlags(cmd.Flags())
+			return runAuditRun(cmd.Context(), opts)
+		},
+	}
+	opts.LoggingFlagOpts.ApplyFlags(command.Flags())
+	opts.SecureFlagOpts.ApplyFlags(command.Flags())
+	command.Flags().StringVar(&opts.config, "config", "", "audit configuration file in YAML or JSON")
+	command.Flags().IntVar(&opts.concurrency, "concurrency", 0, "maximum number of repositories audited at the same time, overriding \"concurrency\" of the configuration file")
+	command.Flags().BoolVar(&opts.force, "force", false, "run all the audits, even if not due according to their schedules")
+	command.Flags().StringVar(&opts.metricsTextfile, "metrics-textfile", "", "file with the extension \".prom\" to write the numbers of artifacts verified and failed and the duration of every audit run to, in the text format of Prometheus for the textfile collector of the node exporter")
+	return command
+}
+
+func runAuditRun(ctx context.Context, opts *auditRunOpts) error {
+	// set log level
+	ctx = opts.LoggingFlagOpts.SetLoggerLevel(ctx)
+
+	config, err := audit.LoadConfig(opts.config)
+	if err != nil {
+		return err
+	}
+	if opts.concurrency > 0 {
+		config.Concurrency = opts.concurrency
+	}
+	state, err := audit.LoadState(config.StateFile)
+	if err != nil {
+		return err
+	}
+	// the messages go to stderr if the results are written to stdout
+	textOut := os.Stdout
+	if config.WritesToStdout() {
+		textOut = os.Stderr
+	}
+
+	// audits not due are skipped, the others are run concurrently and
+	// recorded as run at the start of the run, so that the schedules do not
+	// drift by the duration of the audits
+	reports := make([]audit.RepositoryReport, len(config.Audits))
+	durations := make([]time.Duration, len(config.Audits))
+	now := timeNow()
+	var wg sync.WaitGroup
+	var stateMu sync.Mutex
+	limit := make(chan struct{}, config.Concurrency)
+	for i, repositoryConfig := range config.Audits {
+		if due, next := state.Due(repositoryConfig, now); !due && !opts.force {
+			reports[i] = audit.RepositoryReport{
+				Name:       repositoryConfig.Name,
+				Repository: repositoryConfig.Repository,
+				Policy:     repositoryConfig.Policy,
+				Result:     audit.ResultSkipped,
+				NextRun:    next.UTC().Format(time.RFC3339),
+			}
+			fmt.Fprintf(textOut, "Skipped audit %s, next run is due at %s\n", repositoryConfig.Name, reports[i].NextRun)
+			continue
+		}
+		wg.Add(1)
+		limit <- struct{}{}
+		go func(i int, repositoryConfig audit.RepositoryConfig) {
+			defer func() {
+				<-limit
+				wg.Done()
+			}()
+			start := time.Now()
+			reports[i] = runRepositoryAudit(ctx, opts, repositoryConfig, textOut)
+			durations[i] = time.Since(start)
+			if reports[i].Error == "" {
+				stateMu.Lock()
+				state.Record(repositoryConfig.Name, now)
+				stateMu.Unlock()
+			}
+		}(i, repositoryConfig)
+	}
+	wg.Wait()
+	if err := state.Save(); err != nil {
+		return fmt.Errorf("failed to write audit state file: %w", err)
+	}
+
+	// merge the results
+	report := &audit.Report{}
+	var skipped int
+	for _, repositoryReport := range reports {
+		report.Add(repositoryReport)
+		if repositoryReport.Result == audit.ResultSkipped {
+			skipped++
+		}
+	}
+	for _, output := range config.Outputs {
+		if err := writeAuditOutput(report, output); err != nil {
+			return err
+		}
+	}
+	failed := report.FailedAudits()
+	if opts.metricsTextfile != "" {
+		var runErr error
+		if failed > 0 {
+			runErr = fmt.Errorf("%d of %d audits failed", failed, len(reports)-skipped)
+		}
+		if err := writeMetricsTextfile(opts.metricsTextfile, metrics.OperationAuditRun, auditMetrics(reports, durations), runErr); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(textOut, "Ran %d audits: %d succeeded, %d failed, %d skipped\n", len(reports)-skipped, len(reports)-skipped-failed, failed, skipped)
+	if failed > 0 {
+		return fmt.Errorf("%d of %d audits failed", failed, len(reports)-skipped)
+	}
+	return nil
+}
+
+// runRepositoryAudit audits every tagged artifact of the repository of
+// repositoryConfig, printing the results of the tags.
+func runRepositoryAudit(ctx context.Context, opts *auditRunOpts, repositoryConfig audit.RepositoryConfig, textOut *os.File) audit.RepositoryReport {
+	report := audit.RepositoryReport{
+		Name:       repositoryConfig.Name,
+		Repository: repositoryConfig.Repository,
+		Policy:     repositoryConfig.Policy,
+		Result:     audit.ResultFailure,
+	}
+	fail := func(err error) audit.RepositoryReport {
+		report.Error = err.Error()
+		fmt.Fprintf(os.Stderr, "%s audit %s: %v\n", color.Failure(os.Stderr, "Error:"), repositoryConfig.Name, err)
+		return report
+	}
+
+	ref, err := registry.ParseReference(repositoryConfig.Repository)
+	if err != nil {
+		return fail(err)
+	}
+	policyVerifier, err := policy.NewVerifierFromConfigOptions(policy.ConfigOptions{
+		Name:     repositoryConfig.Policy,
+		CRLCache: newCRLCache(ctx, false),
+	})
+	if err != nil {
+		return fail(err)
+	}
+	warnPluginConfig(repositoryConfig.PluginConfig)
+	maxAttempts, err := resolveMaxSignatureAttempts(repositoryConfig.MaxSignatureAttempts)
+	if err != nil {
+		return fail(err)
+	}
+
+	verifyOpts := &verifyOpts{SecureFlagOpts: opts.SecureFlagOpts}
+	checkpoint, err := auditTags(ctx, ref, verifyOpts, policyVerifier, policyVerifier, repositoryConfig.PluginConfig, maxAttempts, func(entry audit.Entry, resumed bool) error {
+		printAuditEntry(repositoryConfig.Repository, entry, textOut)
+		return nil
+	})
+	if err != nil {
+		return fail(err)
+	}
+	report.Entries = checkpoint.Entries
+	report.Succeeded, report.Failed = checkpoint.Count()
+	if report.Failed == 0 {
+		report.Result = audit.ResultSuccess
+	}
+	fmt.Fprintf(textOut, "Audited %d tags of %s: %d succeeded, %d failed\n", report.Succeeded+report.Failed, repositoryConfig.Repository, report.Succeeded, report.Failed)
+	return report
+}
+
+// auditMetrics returns the metrics of the audits run, skipping the audits not
+// due.
+func auditMetrics(reports []audit.RepositoryReport, durations []time.Duration) []metrics.Result {
+	var results []metrics.Result
+	for i, report := range reports {
+		if report.Result == audit.ResultSkipped {
+			continue
+		}
+		result := metrics.Result{
+			Name:       report.Name,
+			Repository: report.Repository,
+			Verified:   report.Succeeded,
+			Failed:     report.Failed,
+			Duration:   durations[i],
+		}
+		if report.Error != "" {
+			result.Err = errors.New(report.Error)
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// writeAuditOutput writes the merged results of the audits to the output.
+func writeAuditOutput(report *audit.Report, output audit.OutputConfig) error {
+	var buf bytes.Buffer
+	var err error
+	switch output.Format {
+	case audit.OutputFormatCSV:
+		err = report.WriteCSV(&buf)
+	case audit.OutputFormatJSON:
+		err = report.WriteJSON(&buf)
+	}
+	if err != nil {
+		return err
+	}
+	if output.Path == "-" {
+		_, err := os.Stdout.Write(buf.Bytes())
+		return err
+	}
+	if err := osutil.WriteFile(output.Path, buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write the audit results: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/notation/internal/audit"
+	"github.com/notaryproject/notation/internal/metrics"
+)
+
+func TestAuditRunCommand_BasicArgs(t *testing.T) {
+	opts := &auditRunOpts{}
+	command := auditRunCommand(opts)
+	expected := &auditRunOpts{
+		SecureFlagOpts: SecureFlagOpts{
+			PlainHTTP: true,
+		},
+		config:          "audit.yaml",
+		concurrency:     4,
+		force:           true,
+		metricsTextfile: "notation.prom",
+	}
+	if err := command.ParseFlags([]string{
+		"--config", expected.config,
+		"--plain-http",
+		"--concurrency", "4",
+		"--force",
+		"--metrics-textfile", expected.metricsTextfile}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := command.Args(command, command.Flags().Args()); err != nil {
+		t.Fatalf("Parse Args failed: %v", err)
+	}
+	if !reflect.DeepEqual(opts, expected) {
+		t.Fatalf("Expect audit run opts: %v, got: %v", expected, opts)
+	}
+}
+
+func TestAuditRunCommand_MissingConfig(t *testing.T) {
+	command := auditRunCommand(nil)
+	if err := command.ParseFlags(nil); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := command.PreRunE(command, command.Flags().Args()); err == nil {
+		t.Fatal("PreRunE expected error, but ok")
+	}
+}
+
+func TestAuditMetrics(t *testing.T) {
+	reports := []audit.RepositoryReport{
+		{Name: "prod", Repository: "localhost:5000/prod", Result: audit.ResultFailure, Succeeded: 3, Failed: 1},
+		{Name: "staging", Repository: "localhost:5000/staging", Result: audit.ResultSkipped},
+		{Name: "dev", Repository: "localhost:5000/dev", Result: audit.ResultFailure, Error: "failed to list tags"},
+	}
+	durations := []time.Duration{time.Minute, 0, time.Second}
+	results := auditMetrics(reports, durations)
+	expected := []metrics.Result{
+		{Name: "prod", Repository: "localhost:5000/prod", Verified: 3, Failed: 1, Duration: time.Minute},
+		{Name: "dev", Repository: "localhost:5000/dev", Duration: time.Second, Err: errors.New("failed to list tags")},
+	}
+	if !reflect.DeepEqual(results, expected) {
+		t.Fatalf("expected metrics %+v, got %+v", expected, results)
+	}
+}
+
+func TestRunAuditRun_Schedule(t *testing.T) {
+	configDir := t.TempDir()
+	configPath := filepath.Join(configDir, "audit.yaml")
+	if err := os.WriteFile(configPath, []byte(`
+audits:
+  - name: prod
+    repository: localhost:5000/prod
+    schedule: 24h
+  - name: staging
+    repository: localhost:5000/staging
+    schedule: 168h
+outputs:
+  - format: json
+    path: audit.json
+`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	// both audits ran an hour ago, so they are not due
+	lastRun := time.Date(2023, 4, 20, 8, 0, 0, 0, time.UTC)
+	state, err := audit.LoadState(filepath.Join(configDir, "audit.state.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	state.Record("prod", lastRun)
+	state.Record("staging", lastRun)
+	if err := state.Save(); err != nil {
+		t.Fatal(err)
+	}
+	defer func(oldNow func() time.Time) { timeNow = oldNow }(timeNow)
+	timeNow = func() time.Time { return lastRun.Add(time.Hour) }
+
+	if err := runAuditRun(context.Background(), &auditRunOpts{config: configPath}); err != nil {
+		t.Fatalf("runAuditRun() error = %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(configDir, "audit.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report audit.Report
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatal(err)
+	}
+	expected := []audit.RepositoryReport{
+		{Name: "prod", Repository: "localhost:5000/prod", Result: audit.ResultSkipped, NextRun: "2023-04-21T08:00:00Z"},
+		{Name: "staging", Repository: "localhost:5000/staging", Result: audit.ResultSkipped, NextRun: "2023-04-27T08:00:00Z"},
+	}
+	if !reflect.DeepEqual(report.Audits, expected) {
+		t.Fatalf("expected audits %+v, got %+v", expected, report.Audits)
+	}
+}
@@ -0,0 +1,360 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation-go/dir"
+	"github.com/notaryproject/notation-go/log"
+	"github.com/notaryproject/notation-go/verifier"
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+	"github.com/notaryproject/notation-go/verifier/truststore"
+	"github.com/notaryproject/notation/internal/blob"
+	"github.com/notaryproject/notation/internal/chaincache"
+	"github.com/notaryproject/notation/internal/cmd"
+	"github.com/notaryproject/notation/internal/color"
+	"github.com/notaryproject/notation/internal/envelope"
+	"github.com/notaryproject/notation/internal/experimental"
+	"github.com/notaryproject/notation/internal/metadata"
+	"github.com/notaryproject/notation/internal/osutil"
+	"github.com/notaryproject/notation/internal/pluginproto"
+	"github.com/notaryproject/notation/internal/policy"
+	"github.com/notaryproject/notation/internal/provenance"
+	"github.com/notaryproject/notation/internal/revocation"
+	"github.com/spf13/cobra"
+)
+
+type blobSignOpts struct {
+	cmd.LoggingFlagOpts
+	cmd.SignerFlagOpts
+	expiry             time.Duration
+	pluginConfig       []string
+	userMetadata       []string
+	hashAlgorithm      string
+	mediaType          string
+	signatureDirectory string
+	force              bool
+	blobPath           string
+}
+
+func blobCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "blob",
+		Short: "[Experimental] Sign and verify arbitrary local files",
+		Long: `[Experimental] Sign and verify arbitrary local files
+
+Blobs are local files, such as tarballs, SBOM files or binaries, signed with detached signature envelopes written to files rather than pushed to a registry. Blob signatures are verified against the blob trust policy document "trustpolicy.blob.json" in the notation config directory.
+
+Example - Sign a blob using the default signing key, writing the signature to app.tar.jws.sig in the current directory:
+  notation blob sign app.tar
+
+Example - Verify the signature app.tar.jws.sig of a blob:
+  notation blob verify app.tar
+`,
+	}
+	command.AddCommand(
+		blobSignCommand(nil),
+		blobVerifyCommand(nil),
+		blobPolicyCommand(),
+	)
+	return command
+}
+
+func blobSignCommand(opts *blobSignOpts) *cobra.Command {
+	if opts == nil {
+		opts = &blobSignOpts{}
+	}
+	command := &cobra.Command{
+		Use:   "sign [flags] <blob_path>",
+		Short: "[Experimental] Sign a blob, producing a detached signature envelope",
+		Long: `[Experimental] Sign a blob, producing a detached signature envelope
+
+The signature is written to the file "<blob_name>.<signature_format>.sig" in the signature directory, e.g. "app.tar.jws.sig". The signed payload is the descriptor of the blob, with the media type of flag "--media-type".
+
+Prerequisite: a signing key needs to be configured using the command "notation key".
+
+Example - Sign a blob using the default signing key, with the default JWS envelope:
+  notation blob sign app.tar
+
+Example - Sign a blob using a specified key, with the COSE envelope, writing the signature to a directory:
+  notation blob sign --key <key_name> --signature-format cose --signature-directory ./signatures app.tar
+
+Example - Sign an SBOM with its media type and user metadata, and specify the signature expiry duration, for example 24 hours:
+  notation blob sign --media-type application/spdx+json --user-metadata buildId=42 --expiry 24h sbom.spdx.json
+
+Example - Sign a blob using an on-demand key of a plugin, overwriting the existing signature:
+  notation blob sign --plugin <plugin_name> --id <key_id> --force app.tar
+`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return errors.New("either missing blob path or unnecessary parameters passed")
+			}
+			opts.blobPath = args[0]
+			return nil
+		},
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if opts.hashAlgorithm != "" {
+				if _, err := envelope.ParseHashAlgorithm(opts.hashAlgorithm); err != nil {
+					return err
+				}
+			}
+			return experimental.CheckCommandAndWarn(cmd, args)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runBlobSign(cmd, opts)
+		},
+	}
+	opts.LoggingFlagOpts.ApplyFlags(command.Flags())
+	opts.SignerFlagOpts.ApplyFlagsToCommand(command)
+	cmd.SetPflagExpiry(command.Flags(), &opts.expiry)
+	cmd.SetPflagPluginConfig(command.Flags(), &opts.pluginConfig)
+	cmd.SetPflagUserMetadata(command.Flags(), &opts.userMetadata, cmd.PflagUserMetadataSignUsage)
+	command.Flags().StringVar(&opts.hashAlgorithm, "hash-algorithm", "", fmt.Sprintf("hash algorithm of the signature payload, the signing fails if the signing key does not hash with it. The hash algorithm is determined by the type and the size of the signing key. options: %s", strings.Join(envelope.HashAlgorithmNames(), ", ")))
+	command.Flags().StringVar(&opts.mediaType, "media-type", blob.DefaultMediaType, "media type of the blob")
+	command.Flags().StringVar(&opts.signatureDirectory, "signature-directory", ".", "directory to write the signature to")
+	command.Flags().BoolVar(&opts.force, "force", false, "overwrite the existing signature of the blob")
+	return command
+}
+
+func runBlobSign(command *cobra.Command, cmdOpts *blobSignOpts) error {
+	// set log level
+	ctx := cmdOpts.LoggingFlagOpts.SetLoggerLevel(command.Context())
+
+	mediaType, err := envelope.GetEnvelopeMediaType(cmdOpts.SignatureFormat)
+	if err != nil {
+		return err
+	}
+	sigPath := blob.SignaturePath(cmdOpts.signatureDirectory, cmdOpts.blobPath, cmdOpts.SignatureFormat)
+	if !cmdOpts.force {
+		// fail before signing rather than discarding the signature
+		if _, err := os.Stat(sigPath); err == nil {
+			return fmt.Errorf("signature %s already exists, use flag \"--force\" to overwrite it", sigPath)
+		}
+	}
+	pluginConfig, err := cmd.ParseFlagMap(cmdOpts.pluginConfig, cmd.PflagPluginConfig.Name)
+	if err != nil {
+		return err
+	}
+	warnPluginConfig(pluginConfig)
+	userMetadata, err := cmd.ParseFlagMap(cmdOpts.userMetadata, cmd.PflagUserMetadata.Name)
+	if err != nil {
+		return err
+	}
+	if err := provenance.CheckUserMetadata(userMetadata); err != nil {
+		return err
+	}
+
+	// keys are only allowed to sign within their validity windows, which does
+	// not apply to on-demand keys
+	onDemandKey := cmdOpts.KeyID != "" && cmdOpts.PluginName != "" && cmdOpts.Key == ""
+	if !onDemandKey {
+		if err := checkSigningKeyValidity(cmdOpts.Key); err != nil {
+			return err
+		}
+	}
+
+	// initialize
+	signer, err := cmd.GetSigner(ctx, &cmdOpts.SignerFlagOpts)
+	if err != nil {
+		return err
+	}
+	if cmdOpts.hashAlgorithm != "" {
+		hash, err := envelope.ParseHashAlgorithm(cmdOpts.hashAlgorithm)
+		if err != nil {
+			return err
+		}
+		signer = cmd.NewHashAlgorithmSigner(signer, hash)
+	}
+	desc, err := blob.Descriptor(cmdOpts.blobPath, cmdOpts.mediaType)
+	if err != nil {
+		return err
+	}
+	log.GetLogger(ctx).Infof("Computed blob descriptor: %+v", desc)
+	if !onDemandKey {
+		if err := checkKeyCeremony(cmdOpts.Key, cmdOpts.blobPath+"@"+desc.Digest.String(), time.Now()); err != nil {
+			return err
+		}
+	}
+
+	// core process
+	sig, err := blob.Sign(ctx, signer, desc, userMetadata, notation.SignerSignOptions{
+		SignatureMediaType: mediaType,
+		ExpiryDuration:     cmdOpts.expiry,
+		PluginConfig:       pluginConfig,
+	})
+	if err != nil {
+		return err
+	}
+	if err := osutil.WriteFileWithPermission(sigPath, sig, 0644, cmdOpts.force); err != nil {
+		return fmt.Errorf("failed to write the signature: %w", err)
+	}
+	fmt.Println(color.Success(os.Stdout, "Successfully signed"), cmdOpts.blobPath, "("+desc.Digest.String()+")")
+	fmt.Println("Signature file written to", sigPath)
+	return nil
+}
+
+type blobVerifyOpts struct {
+	cmd.LoggingFlagOpts
+	signature    string
+	pluginConfig []string
+	userMetadata []string
+	mediaType    string
+	blobPath     string
+	refreshCRL   bool
+}
+
+func blobVerifyCommand(opts *blobVerifyOpts) *cobra.Command {
+	if opts == nil {
+		opts = &blobVerifyOpts{}
+	}
+	command := &cobra.Command{
+		Use:   "verify [flags] <blob_path>",
+		Short: "[Experimental] Verify the detached signature of a blob",
+		Long: `[Experimental] Verify the detached signature of a blob
+
+The signature is verified against the statement of the blob trust policy document "trustpolicy.blob.json" applicable to the blob, selected by the scopes of the statements, and its verification level is enforced. The signature is read from the file "<blob_name>.jws.sig" or "<blob_name>.cose.sig" in the current directory, unless flag "--signature" is set.
+
+Prerequisite: a blob trust policy needs to be configured using the command "notation blob policy import".
+
+Example - Verify the signature of a blob:
+  notation blob verify app.tar
+
+Example - Verify a signature file of a blob:
+  notation blob verify --signature ./signatures/app.tar.cose.sig app.tar
+
+Example - Verify the signature of an SBOM, and that it was signed with the user metadata "buildId=42":
+  notation blob verify --media-type application/spdx+json --user-metadata buildId=42 sbom.spdx.json
+`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return errors.New("either missing blob path or unnecessary parameters passed")
+			}
+			opts.blobPath = args[0]
+			return nil
+		},
+		PreRunE: experimental.CheckCommandAndWarn,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runBlobVerify(cmd, opts)
+		},
+	}
+	opts.LoggingFlagOpts.ApplyFlags(command.Flags())
+	command.Flags().StringVar(&opts.signature, "signature", "", "path of the signature file of the blob, defaults to \"<blob_name>.jws.sig\" or \"<blob_name>.cose.sig\" in the current directory")
+	command.Flags().StringVar(&opts.mediaType, "media-type", "", "media type of the blob, defaults to the media type signed by the signature")
+	cmd.SetPflagPluginConfig(command.Flags(), &opts.pluginConfig)
+	cmd.SetPflagUserMetadata(command.Flags(), &opts.userMetadata, cmd.PflagUserMetadataVerifyUsage)
+	command.Flags().BoolVar(&opts.refreshCRL, "refresh-crl", false, "fetch the CRLs of the revocation checks again instead of using the cached CRLs, refreshing the CRL cache")
+	return command
+}
+
+func runBlobVerify(command *cobra.Command, opts *blobVerifyOpts) error {
+	// set log level
+	ctx := opts.LoggingFlagOpts.SetLoggerLevel(command.Context())
+	logger := log.GetLogger(ctx)
+
+	// select the statement of the blob trust policy
+	policyDoc, err := blob.LoadPolicyDocument()
+	if err != nil {
+		return err
+	}
+	if err := policyDoc.Validate(); err != nil {
+		return fmt.Errorf("invalid blob trust policy: %w", err)
+	}
+	statement, err := policyDoc.ApplicableTrustPolicy(opts.blobPath)
+	if err != nil {
+		return err
+	}
+	logger.Infof("Blob trust policy statement %q applies to %s", statement.Name, opts.blobPath)
+
+	// set up verification plugin config.
+	configs, err := cmd.ParseFlagMap(opts.pluginConfig, cmd.PflagPluginConfig.Name)
+	if err != nil {
+		return err
+	}
+	warnPluginConfig(configs)
+
+	// set up user metadata assertions
+	assertions, err := metadata.ParseAssertions(opts.userMetadata, cmd.PflagUserMetadata.Name)
+	if err != nil {
+		return err
+	}
+	verifier, err := newBlobVerifier(policyDoc.TrustPolicyDocument(statement), newCRLCache(ctx, opts.refreshCRL))
+	if err != nil {
+		return err
+	}
+	verifier = metadata.NewVerifier(verifier, assertions)
+
+	// read the signature and the blob
+	sigPath, err := blobSignaturePath(opts.signature, opts.blobPath)
+	if err != nil {
+		return err
+	}
+	sig, err := os.ReadFile(sigPath)
+	if err != nil {
+		return fmt.Errorf("failed to read the signature: %w", err)
+	}
+	mediaType := opts.mediaType
+	if mediaType == "" {
+		if mediaType, err = blob.SignedMediaType(sig); err != nil {
+			return fmt.Errorf("signature verification failed: %w", err)
+		}
+	}
+	desc, err := blob.Descriptor(opts.blobPath, mediaType)
+	if err != nil {
+		return err
+	}
+	logger.Infof("Computed blob descriptor: %+v", desc)
+
+	// core process
+	outcome, err := blob.Verify(ctx, verifier, desc, sig, configs)
+	var outcomes []*notation.VerificationOutcome
+	if outcome != nil {
+		outcomes = append(outcomes, outcome)
+	}
+	if err := checkVerificationFailure(outcomes, opts.blobPath, err); err != nil {
+		return err
+	}
+	reportVerificationSuccess(outcomes, opts.blobPath+"@"+desc.Digest.String(), 0, nil)
+	return nil
+}
+
+// newBlobVerifier returns the verifier of blobs enforcing the trust policy
+// document returned by blob.PolicyDocument.TrustPolicyDocument, checking the
+// revocation status of the certificate chains as for artifacts in registries
+// if experimental is enabled. The CRLs fetched are cached in crlCache, if not
+// nil.
+func newBlobVerifier(policyDoc *trustpolicy.Document, crlCache *revocation.CRLCache) (notation.Verifier, error) {
+	base, err := verifier.New(policyDoc, truststore.NewX509TrustStore(dir.ConfigFS()), pluginproto.NewCLIManager(dir.PluginFS()))
+	if err != nil {
+		return nil, err
+	}
+	if experimental.IsDisabled() {
+		// the revocation checks are experimental
+		return base, nil
+	}
+	trustStoreDigest, err := policy.DigestTrustStore()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read trust store: %w", err)
+	}
+	chainCache := chaincache.New[[]revocation.Result](trustStoreDigest.String(), 0)
+	return revocation.NewVerifier(base, revocation.NewChecker(revocation.Options{CRLCache: crlCache}), chainCache), nil
+}
+
+// blobSignaturePath returns the path of the signature of the blob at
+// blobPath, which is sigPath if set, or the JWS or COSE signature written by
+// "notation blob sign" to the current directory otherwise.
+func blobSignaturePath(sigPath, blobPath string) (string, error) {
+	if sigPath != "" {
+		return sigPath, nil
+	}
+	for _, format := range []string{envelope.JWS, envelope.COSE} {
+		path := blob.SignaturePath(".", blobPath, format)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("no signature is found for %s in the current directory, use flag \"--signature\" to specify the signature file", blobPath)
+}
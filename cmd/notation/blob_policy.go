@@ -0,0 +1,233 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/notaryproject/notation/cmd/notation/internal/cmdutil"
+	"github.com/notaryproject/notation/internal/blob"
+	"github.com/notaryproject/notation/internal/experimental"
+	"github.com/notaryproject/notation/internal/osutil"
+	"github.com/notaryproject/notation/internal/sanity"
+	"github.com/spf13/cobra"
+)
+
+type blobPolicyImportOpts struct {
+	filePath  string
+	confirmed bool
+}
+
+type blobPolicyScopeOpts struct {
+	name   string
+	scopes []string
+}
+
+func blobPolicyCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "policy [command]",
+		Short: "[Experimental] Manage the blob trust policy",
+		Long: `[Experimental] Manage the blob trust policy
+
+The blob trust policy document "trustpolicy.blob.json" in the notation config directory has the same schema as the trust policy document "trustpolicy.json", except that the statements are scoped by path patterns of blobs in field "scopes" instead of registry scopes, e.g. "dist/*.tar" or "*.tar". The scope "*" applies to all the blobs without a more specific statement.
+`,
+	}
+	command.AddCommand(
+		blobPolicyShowCommand(),
+		blobPolicyImportCommand(nil),
+		blobPolicyAddScopeCommand(nil),
+		blobPolicyRemoveScopeCommand(nil),
+	)
+	return command
+}
+
+func blobPolicyShowCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "show [flags]",
+		Short: "[Experimental] Show the blob trust policy",
+		Long: `[Experimental] Show the blob trust policy
+
+Example - Show the blob trust policy:
+  notation blob policy show
+
+Example - Save the blob trust policy to a file:
+  notation blob policy show > blob_policy.json
+`,
+		Args:    cobra.ExactArgs(0),
+		PreRunE: experimental.CheckCommandAndWarn,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runBlobPolicyShow()
+		},
+	}
+}
+
+func runBlobPolicyShow() error {
+	policyPath, err := blob.PolicyPath()
+	if err != nil {
+		return fmt.Errorf("failed to obtain path of blob trust policy file: %w", err)
+	}
+	policyJSON, err := os.ReadFile(policyPath)
+	if err != nil {
+		return fmt.Errorf("failed to load blob trust policy, you may import one via `notation blob policy import <path-to-policy.json>`: %w", err)
+	}
+	doc, err := blob.ParsePolicyDocument(policyJSON)
+	if err == nil {
+		err = doc.Validate()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err.Error())
+		fmt.Fprintf(os.Stderr, "Existing blob trust policy is invalid, you may update or create a new one via `notation blob policy import <path-to-policy.json>`\n")
+		// not returning to show the invalid blob trust policy
+	}
+	_, err = os.Stdout.Write(policyJSON)
+	return err
+}
+
+func blobPolicyImportCommand(opts *blobPolicyImportOpts) *cobra.Command {
+	if opts == nil {
+		opts = &blobPolicyImportOpts{}
+	}
+	command := &cobra.Command{
+		Use:   "import [flags] <file_path>",
+		Short: "[Experimental] Import the blob trust policy from a JSON file",
+		Long: `[Experimental] Import the blob trust policy from a JSON file
+
+Example - Import the blob trust policy from a file:
+  notation blob policy import blob_policy.json
+
+Example - Import the blob trust policy from a file, overwriting the existing one without prompt:
+  notation blob policy import --force blob_policy.json
+`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return errors.New("requires exactly one file path")
+			}
+			opts.filePath = args[0]
+			return nil
+		},
+		PreRunE: experimental.CheckCommandAndWarn,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runBlobPolicyImport(opts)
+		},
+	}
+	command.Flags().BoolVar(&opts.confirmed, "force", false, "override the existing blob trust policy, never prompt")
+	return command
+}
+
+func runBlobPolicyImport(opts *blobPolicyImportOpts) error {
+	// read and validate
+	policyJSON, err := os.ReadFile(opts.filePath)
+	if err != nil {
+		return fmt.Errorf("failed to read blob trust policy file: %w", err)
+	}
+	doc, err := blob.ParsePolicyDocument(policyJSON)
+	if err != nil {
+		return err
+	}
+	if err := doc.Validate(); err != nil {
+		return fmt.Errorf("failed to validate blob trust policy: %w", err)
+	}
+	if findings, err := sanity.ScanJSON(policyJSON); err == nil {
+		sanity.PrintWarnings(os.Stderr, "blob trust policy", findings)
+	}
+
+	// write
+	policyPath, err := blob.PolicyPath()
+	if err != nil {
+		return fmt.Errorf("failed to obtain path of blob trust policy file: %w", err)
+	}
+	if _, err := os.Stat(policyPath); err == nil {
+		confirmed, err := cmdutil.AskForConfirmation(os.Stdin, "The blob trust policy file already exists, do you want to overwrite it?", opts.confirmed)
+		if err != nil || !confirmed {
+			return err
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to read the existing blob trust policy file: %w", err)
+	}
+	if err := osutil.WriteFile(policyPath, policyJSON); err != nil {
+		return fmt.Errorf("failed to write blob trust policy file: %w", err)
+	}
+	_, err = fmt.Fprintln(os.Stdout, "Blob trust policy imported successfully.")
+	return err
+}
+
+func blobPolicyAddScopeCommand(opts *blobPolicyScopeOpts) *cobra.Command {
+	if opts == nil {
+		opts = &blobPolicyScopeOpts{}
+	}
+	return &cobra.Command{
+		Use:   "add-scope <policy_name> <scope>...",
+		Short: "[Experimental] Add scopes to a statement of the blob trust policy",
+		Long: `[Experimental] Add scopes to a statement of the blob trust policy
+
+Scopes are path patterns of blobs, with "/" as path separator, e.g. "dist/*.tar". Patterns without "/" also match the file names of blobs, e.g. "*.tar". A blob matching the scopes of multiple statements is rejected on verification.
+
+Example - Add scopes to the statement "release":
+  notation blob policy add-scope release "dist/*.tar" "*.spdx.json"
+`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) < 2 {
+				return errors.New("missing policy name or scopes")
+			}
+			opts.name = args[0]
+			opts.scopes = args[1:]
+			return nil
+		},
+		PreRunE: experimental.CheckCommandAndWarn,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return updateBlobPolicyScopes(opts, (*blob.PolicyDocument).AddScopes)
+		},
+	}
+}
+
+func blobPolicyRemoveScopeCommand(opts *blobPolicyScopeOpts) *cobra.Command {
+	if opts == nil {
+		opts = &blobPolicyScopeOpts{}
+	}
+	return &cobra.Command{
+		Use:   "remove-scope <policy_name> <scope>...",
+		Short: "[Experimental] Remove scopes from a statement of the blob trust policy",
+		Long: `[Experimental] Remove scopes from a statement of the blob trust policy
+
+A statement must keep at least one scope.
+
+Example - Remove a scope from the statement "release":
+  notation blob policy remove-scope release "*.spdx.json"
+`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) < 2 {
+				return errors.New("missing policy name or scopes")
+			}
+			opts.name = args[0]
+			opts.scopes = args[1:]
+			return nil
+		},
+		PreRunE: experimental.CheckCommandAndWarn,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return updateBlobPolicyScopes(opts, (*blob.PolicyDocument).RemoveScopes)
+		},
+	}
+}
+
+// updateBlobPolicyScopes updates the scopes of a statement of the blob trust
+// policy with update, and saves the blob trust policy if it is still valid.
+func updateBlobPolicyScopes(opts *blobPolicyScopeOpts, update func(doc *blob.PolicyDocument, name string, scopes ...string) error) error {
+	for _, scope := range opts.scopes {
+		if err := blob.ValidateScope(scope); err != nil {
+			return err
+		}
+	}
+	doc, err := blob.LoadPolicyDocument()
+	if err != nil {
+		return err
+	}
+	if err := update(doc, opts.name, opts.scopes...); err != nil {
+		return err
+	}
+	if err := blob.SavePolicyDocument(doc); err != nil {
+		return fmt.Errorf("failed to update blob trust policy: %w", err)
+	}
+	fmt.Printf("Scopes of blob trust policy statement %q: %v\n", opts.name, doc.Get(opts.name).Scopes)
+	return nil
+}
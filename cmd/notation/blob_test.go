@@ -0,0 +1,121 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/notation/internal/blob"
+	"github.com/notaryproject/notation/internal/cmd"
+	"github.com/notaryproject/notation/internal/envelope"
+)
+
+func TestBlobSignCommand_BasicArgs(t *testing.T) {
+	opts := &blobSignOpts{}
+	command := blobSignCommand(opts)
+	expected := &blobSignOpts{
+		SignerFlagOpts: cmd.SignerFlagOpts{
+			Key:             "key",
+			SignatureFormat: envelope.JWS,
+		},
+		mediaType:          blob.DefaultMediaType,
+		signatureDirectory: ".",
+		blobPath:           "app.tar",
+	}
+	if err := command.ParseFlags([]string{
+		expected.blobPath,
+		"--key", expected.Key}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := command.Args(command, command.Flags().Args()); err != nil {
+		t.Fatalf("Parse args failed: %v", err)
+	}
+	if !reflect.DeepEqual(*expected, *opts) {
+		t.Fatalf("Expect blob sign opts: %v, got: %v", expected, opts)
+	}
+}
+
+func TestBlobSignCommand_MoreArgs(t *testing.T) {
+	opts := &blobSignOpts{}
+	command := blobSignCommand(opts)
+	expected := &blobSignOpts{
+		SignerFlagOpts: cmd.SignerFlagOpts{
+			Key:             "key",
+			SignatureFormat: envelope.COSE,
+		},
+		expiry:             24 * time.Hour,
+		userMetadata:       []string{"buildId=42"},
+		mediaType:          "application/spdx+json",
+		signatureDirectory: "signatures",
+		force:              true,
+		blobPath:           "sbom.spdx.json",
+	}
+	if err := command.ParseFlags([]string{
+		expected.blobPath,
+		"--key", expected.Key,
+		"--signature-format", expected.SignatureFormat,
+		"--expiry", expected.expiry.String(),
+		"-m", "buildId=42",
+		"--media-type", expected.mediaType,
+		"--signature-directory", expected.signatureDirectory,
+		"--force"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := command.Args(command, command.Flags().Args()); err != nil {
+		t.Fatalf("Parse args failed: %v", err)
+	}
+	if !reflect.DeepEqual(*expected, *opts) {
+		t.Fatalf("Expect blob sign opts: %v, got: %v", expected, opts)
+	}
+}
+
+func TestBlobSignCommand_MissingArgs(t *testing.T) {
+	command := blobSignCommand(nil)
+	if err := command.ParseFlags(nil); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := command.Args(command, command.Flags().Args()); err == nil {
+		t.Fatal("Parse Args expected error, but ok")
+	}
+}
+
+func TestBlobVerifyCommand_MoreArgs(t *testing.T) {
+	opts := &blobVerifyOpts{}
+	command := blobVerifyCommand(opts)
+	expected := &blobVerifyOpts{
+		signature:    "signatures/sbom.spdx.json.cose.sig",
+		pluginConfig: []string{"key=value"},
+		userMetadata: []string{"buildId=42"},
+		mediaType:    "application/spdx+json",
+		blobPath:     "sbom.spdx.json",
+	}
+	if err := command.ParseFlags([]string{
+		expected.blobPath,
+		"--signature", expected.signature,
+		"--plugin-config", "key=value",
+		"-m", "buildId=42",
+		"--media-type", expected.mediaType}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := command.Args(command, command.Flags().Args()); err != nil {
+		t.Fatalf("Parse args failed: %v", err)
+	}
+	if !reflect.DeepEqual(*expected, *opts) {
+		t.Fatalf("Expect blob verify opts: %v, got: %v", expected, opts)
+	}
+}
+
+func TestBlobPolicyScopeCommand_Args(t *testing.T) {
+	opts := &blobPolicyScopeOpts{}
+	command := blobPolicyAddScopeCommand(opts)
+	if err := command.Args(command, []string{"release"}); err == nil {
+		t.Fatal("Parse Args expected error, but ok")
+	}
+	if err := command.Args(command, []string{"release", "dist/*.tar", "*.zip"}); err != nil {
+		t.Fatalf("Parse args failed: %v", err)
+	}
+	expected := &blobPolicyScopeOpts{name: "release", scopes: []string{"dist/*.tar", "*.zip"}}
+	if !reflect.DeepEqual(*expected, *opts) {
+		t.Fatalf("Expect blob policy scope opts: %v, got: %v", expected, opts)
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/notaryproject/notation-go/log"
+	"github.com/notaryproject/notation/internal/experimental"
+	"github.com/notaryproject/notation/internal/revocation"
+	"github.com/notaryproject/notation/pkg/configutil"
+	"github.com/spf13/cobra"
+)
+
+// cacheCRL is the name of the CRL cache in "notation cache clear".
+const cacheCRL = "crl"
+
+// crlCacheDir returns the CRL cache directory, for unit test.
+var crlCacheDir = revocation.DefaultCRLCacheDir
+
+func cacheCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "cache",
+		Short: "[Experimental] Manage the caches of notation",
+		Long: `[Experimental] Manage the caches of notation
+
+The CRLs fetched by the revocation checks of verifications are cached in the "notation/crl" directory of the user cache directory, and used until they are older than "crlCacheTTL" of config.json, 24 hours by default, or past their next update. The least recently fetched CRLs are evicted beyond "crlCacheMaxSize" of config.json in bytes, 128 MiB by default. The CRLs are not cached if "crlCacheTTL" is "0s".
+
+Example - Clear the cached CRLs:
+  notation cache clear crl
+
+Example - Verify an artifact fetching the CRLs again, refreshing the cached CRLs:
+  notation verify --refresh-crl <registry>/<repository>@<digest>
+`,
+	}
+	command.AddCommand(cacheClearCommand())
+	return command
+}
+
+func cacheClearCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:       "clear crl",
+		Short:     "[Experimental] Clear a cache of notation",
+		ValidArgs: []string{cacheCRL},
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return fmt.Errorf("expecting the cache to clear, options: %s", cacheCRL)
+			}
+			if args[0] != cacheCRL {
+				return fmt.Errorf("unknown cache %q, options: %s", args[0], cacheCRL)
+			}
+			return nil
+		},
+		PreRunE: experimental.CheckCommandAndWarn,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runCacheClearCRL()
+		},
+	}
+}
+
+func runCacheClearCRL() error {
+	cacheDir, err := crlCacheDir()
+	if err != nil {
+		return fmt.Errorf("failed to obtain the CRL cache directory: %w", err)
+	}
+	removed, err := revocation.ClearCRLCache(cacheDir)
+	if err != nil {
+		return fmt.Errorf("failed to clear the CRL cache: %w", err)
+	}
+	fmt.Printf("Cleared %d cached CRLs in %s\n", removed, cacheDir)
+	return nil
+}
+
+// newCRLCache returns the cache of the CRLs fetched by revocation checks,
+// configured by config.json, or nil if the CRLs are not cached. If refresh is
+// true, the CRLs are fetched again and the cached CRLs are replaced.
+func newCRLCache(ctx context.Context, refresh bool) *revocation.CRLCache {
+	logger := log.GetLogger(ctx)
+	opts := revocation.CRLCacheOptions{Refresh: refresh}
+	if config, err := configutil.LoadCLIConfigOnce(); err == nil {
+		if config.CRLCacheTTL != "" {
+			opts.TTL, _ = time.ParseDuration(config.CRLCacheTTL)
+			if opts.TTL <= 0 {
+				return nil
+			}
+		}
+		opts.MaxSize = config.CRLCacheMaxSize
+	}
+	cacheDir, err := crlCacheDir()
+	if err != nil {
+		logger.Debugf("CRL cache disabled: %v", err)
+		return nil
+	}
+	return revocation.NewCRLCache(cacheDir, opts)
+}
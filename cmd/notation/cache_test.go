@@ -0,0 +1,35 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCacheClearCommand_Args(t *testing.T) {
+	command := cacheClearCommand()
+	if err := command.Args(command, []string{"crl"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{nil, {"ocsp"}, {"crl", "crl"}} {
+		if err := command.Args(command, args); err == nil {
+			t.Errorf("expected error for arguments %q", args)
+		}
+	}
+}
+
+func TestRunCacheClearCRL(t *testing.T) {
+	cacheDir := t.TempDir()
+	defer func(old func() (string, error)) { crlCacheDir = old }(crlCacheDir)
+	crlCacheDir = func() (string, error) { return cacheDir, nil }
+	cached := filepath.Join(cacheDir, "0123.crl")
+	if err := os.WriteFile(cached, []byte("crl"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := runCacheClearCRL(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(cached); !os.IsNotExist(err) {
+		t.Fatalf("expected the cached CRL to be removed, got %v", err)
+	}
+}
@@ -0,0 +1,501 @@
+package main
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/notaryproject/notation-go/dir"
+	"github.com/notaryproject/notation/internal/ceremony"
+	"github.com/notaryproject/notation/internal/cmd"
+	"github.com/notaryproject/notation/internal/experimental"
+	"github.com/notaryproject/notation/internal/ioutil"
+	"github.com/notaryproject/notation/internal/osutil"
+	"github.com/notaryproject/notation/pkg/configutil"
+	"github.com/spf13/cobra"
+)
+
+// ceremonyDir is the directory of the signing sessions and the audit trail of
+// the key ceremonies, relative to the notation configuration directory.
+const ceremonyDir = "ceremony"
+
+type keyCeremonyInitOpts struct {
+	cmd.LoggingFlagOpts
+	name      string
+	threshold int
+	approvers []string
+	remove    bool
+}
+
+type keyCeremonyRequestOpts struct {
+	cmd.LoggingFlagOpts
+	name     string
+	duration time.Duration
+	output   string
+}
+
+type keyCeremonyApproveOpts struct {
+	cmd.LoggingFlagOpts
+	request      string
+	approver     string
+	signingKey   string
+	signature    string
+	printPayload bool
+	output       string
+}
+
+type keyCeremonyOpenOpts struct {
+	cmd.LoggingFlagOpts
+	name      string
+	approvals []string
+}
+
+func keyCeremonyCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "ceremony",
+		Short: "[Experimental] Require multi-person approval of signing sessions of high-value keys",
+		Long: `[Experimental] Require multi-person approval of signing sessions of high-value keys
+
+A key requiring a key ceremony only signs within a signing session opened with the approvals of a threshold of its approvers. Each approver approves a session by signing its request with their own private key, e.g. held in a hardware token. Every step of the ceremonies is recorded in a tamper-evident audit trail.
+
+Example - Require the approvals of 2 of 3 approvers before the key signs:
+  notation key ceremony init --threshold 2 --approver alice=alice.crt --approver bob=bob.crt --approver carol=carol.crt <key_name>
+
+Example - Request a signing session of 1 hour:
+  notation key ceremony request --duration 1h --output request.json <key_name>
+
+Example - Approve the request as an approver:
+  notation key ceremony approve --request request.json --approver alice --signing-key alice.key --output alice.approval.json
+
+Example - Open the signing session with the approvals:
+  notation key ceremony open --approval alice.approval.json --approval bob.approval.json <key_name>
+
+Example - Close the signing session:
+  notation key ceremony close <key_name>
+
+Example - Show the audit trail:
+  notation key ceremony audit
+`,
+	}
+	command.AddCommand(keyCeremonyInitCommand(nil), keyCeremonyRequestCommand(nil), keyCeremonyApproveCommand(nil), keyCeremonyOpenCommand(nil), keyCeremonyCloseCommand(), keyCeremonyAuditCommand())
+	return command
+}
+
+func keyCeremonyInitCommand(opts *keyCeremonyInitOpts) *cobra.Command {
+	if opts == nil {
+		opts = &keyCeremonyInitOpts{}
+	}
+	command := &cobra.Command{
+		Use:   "init --threshold <m> --approver <name>=<certificate_path>... [flags] <key_name>",
+		Short: "[Experimental] Require a key ceremony before the key signs",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return errors.New("either missing key name or unnecessary parameters passed")
+			}
+			opts.name = args[0]
+			return nil
+		},
+		PreRunE: experimental.CheckCommandAndWarn,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return initKeyCeremony(opts)
+		},
+	}
+	opts.LoggingFlagOpts.ApplyFlags(command.Flags())
+	command.Flags().IntVar(&opts.threshold, "threshold", 0, "number of distinct approvers required to open a signing session")
+	command.Flags().StringArrayVar(&opts.approvers, "approver", nil, "{name}={path} pairs of the names of the approvers and the paths of their PEM-encoded certificates")
+	command.Flags().BoolVar(&opts.remove, "remove", false, "remove the requirement of a key ceremony")
+	command.MarkFlagsMutuallyExclusive("remove", "threshold")
+	command.MarkFlagsMutuallyExclusive("remove", "approver")
+	return command
+}
+
+func keyCeremonyRequestCommand(opts *keyCeremonyRequestOpts) *cobra.Command {
+	if opts == nil {
+		opts = &keyCeremonyRequestOpts{}
+	}
+	command := &cobra.Command{
+		Use:   "request [flags] <key_name>",
+		Short: "[Experimental] Request a signing session of a key, replacing the current session",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return errors.New("either missing key name or unnecessary parameters passed")
+			}
+			opts.name = args[0]
+			return nil
+		},
+		PreRunE: experimental.CheckCommandAndWarn,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return requestKeyCeremony(opts)
+		},
+	}
+	opts.LoggingFlagOpts.ApplyFlags(command.Flags())
+	command.Flags().DurationVar(&opts.duration, "duration", ceremony.DefaultSessionDuration, fmt.Sprintf("duration of the signing session from the request, at most %s", ceremony.MaxSessionDuration))
+	command.Flags().StringVar(&opts.output, "output", "", "file to write the request to be approved by the approvers, stdout if not set")
+	return command
+}
+
+func keyCeremonyApproveCommand(opts *keyCeremonyApproveOpts) *cobra.Command {
+	if opts == nil {
+		opts = &keyCeremonyApproveOpts{}
+	}
+	command := &cobra.Command{
+		Use:   "approve --request <request_path> --approver <name> [flags]",
+		Short: "[Experimental] Approve the request of a signing session",
+		Long: `[Experimental] Approve the request of a signing session
+
+The approval signs the approval payload of the request with the private key of the approver, read from a PEM-encoded file with flag "--signing-key". For private keys held in hardware tokens, print the approval payload with flag "--print-payload", sign it with the tooling of the token, and pass the signature with flag "--signature". The signature is an ECDSA signature in ASN.1 DER with SHA-256, SHA-384 or SHA-512 for the curves P-256, P-384 and P-521 respectively, an RSASSA-PSS signature with SHA-256 and a salt length equal to the hash length, or an Ed25519 signature.
+
+Example - Approve with a private key file:
+  notation key ceremony approve --request request.json --approver alice --signing-key alice.key --output alice.approval.json
+
+Example - Approve with a signature of the approval payload by a hardware token:
+  notation key ceremony approve --request request.json --approver alice --print-payload > payload.bin
+  notation key ceremony approve --request request.json --approver alice --signature payload.sig --output alice.approval.json
+`,
+		Args:    cobra.NoArgs,
+		PreRunE: experimental.CheckCommandAndWarn,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return approveKeyCeremony(opts)
+		},
+	}
+	opts.LoggingFlagOpts.ApplyFlags(command.Flags())
+	command.Flags().StringVar(&opts.request, "request", "", "file of the request of the signing session")
+	command.Flags().StringVar(&opts.approver, "approver", "", "name of the approver")
+	command.Flags().StringVar(&opts.signingKey, "signing-key", "", "file of the PEM-encoded private key of the approver")
+	command.Flags().StringVar(&opts.signature, "signature", "", "file of the signature of the approval payload by the approver")
+	command.Flags().BoolVar(&opts.printPayload, "print-payload", false, "print the approval payload to sign to stdout")
+	command.Flags().StringVar(&opts.output, "output", "", "file to write the approval, stdout if not set")
+	command.MarkFlagRequired("request")
+	command.MarkFlagRequired("approver")
+	command.MarkFlagsMutuallyExclusive("signing-key", "signature", "print-payload")
+	return command
+}
+
+func keyCeremonyOpenCommand(opts *keyCeremonyOpenOpts) *cobra.Command {
+	if opts == nil {
+		opts = &keyCeremonyOpenOpts{}
+	}
+	command := &cobra.Command{
+		Use:   "open --approval <approval_path>... [flags] <key_name>",
+		Short: "[Experimental] Open the requested signing session of a key with the approvals",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return errors.New("either missing key name or unnecessary parameters passed")
+			}
+			opts.name = args[0]
+			return nil
+		},
+		PreRunE: experimental.CheckCommandAndWarn,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return openKeyCeremony(opts)
+		},
+	}
+	opts.LoggingFlagOpts.ApplyFlags(command.Flags())
+	command.Flags().StringArrayVar(&opts.approvals, "approval", nil, "file of an approval of the request of the signing session")
+	command.MarkFlagRequired("approval")
+	return command
+}
+
+func keyCeremonyCloseCommand() *cobra.Command {
+	var name string
+	return &cobra.Command{
+		Use:   "close <key_name>",
+		Short: "[Experimental] Close the signing session of a key",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return errors.New("either missing key name or unnecessary parameters passed")
+			}
+			name = args[0]
+			return nil
+		},
+		PreRunE: experimental.CheckCommandAndWarn,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := ceremonyStore()
+			if err != nil {
+				return err
+			}
+			session, err := store.Close(name, time.Now())
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Closed signing session %s of key %s\n", session.Request.SessionID, name)
+			return nil
+		},
+	}
+}
+
+func keyCeremonyAuditCommand() *cobra.Command {
+	var name string
+	return &cobra.Command{
+		Use:   "audit [key_name]",
+		Short: "[Experimental] Verify and show the audit trail of the key ceremonies",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 1 {
+				return errors.New("unnecessary parameters passed")
+			}
+			if len(args) == 1 {
+				name = args[0]
+			}
+			return nil
+		},
+		PreRunE: experimental.CheckCommandAndWarn,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := ceremonyStore()
+			if err != nil {
+				return err
+			}
+			records, err := ceremony.ReadAuditTrail(store.AuditTrailPath())
+			if err != nil {
+				return err
+			}
+			if name != "" {
+				var filtered []ceremony.Record
+				for _, record := range records {
+					if record.Key == name {
+						filtered = append(filtered, record)
+					}
+				}
+				records = filtered
+			}
+			return ioutil.PrintAuditTrail(os.Stdout, records)
+		},
+	}
+}
+
+func initKeyCeremony(opts *keyCeremonyInitOpts) error {
+	store, err := ceremonyStore()
+	if err != nil {
+		return err
+	}
+	if opts.remove {
+		if err := configutil.SetKeyCeremony(opts.name, nil); err != nil {
+			return err
+		}
+		if err := store.Configured(opts.name, nil, time.Now()); err != nil {
+			return err
+		}
+		fmt.Printf("%s: key ceremony removed\n", opts.name)
+		return nil
+	}
+
+	approvers, err := cmd.ParseFlagMap(opts.approvers, "approver")
+	if err != nil {
+		return err
+	}
+	keyCeremony := &configutil.KeyCeremony{
+		Threshold: opts.threshold,
+		Approvers: make(map[string]string, len(approvers)),
+	}
+	for name, path := range approvers {
+		certPEM, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read the certificate of approver %q: %w", name, err)
+		}
+		keyCeremony.Approvers[name] = string(certPEM)
+	}
+	policy, err := ceremony.NewPolicy(keyCeremony.Threshold, keyCeremony.Approvers)
+	if err != nil {
+		return err
+	}
+	if err := configutil.SetKeyCeremony(opts.name, keyCeremony); err != nil {
+		return err
+	}
+	if err := store.Configured(opts.name, policy, time.Now()); err != nil {
+		return err
+	}
+	fmt.Printf("%s: requires the approvals of %d of %s\n", opts.name, keyCeremony.Threshold, strings.Join(keyCeremony.ApproverNames(), ", "))
+	return nil
+}
+
+func requestKeyCeremony(opts *keyCeremonyRequestOpts) error {
+	if _, err := loadCeremonyPolicy(opts.name); err != nil {
+		return err
+	}
+	store, err := ceremonyStore()
+	if err != nil {
+		return err
+	}
+	request, err := store.Request(opts.name, opts.duration, time.Now())
+	if err != nil {
+		return err
+	}
+	requestJSON, err := json.MarshalIndent(request, "", "    ")
+	if err != nil {
+		return err
+	}
+	if opts.output == "" {
+		fmt.Println(string(requestJSON))
+		return nil
+	}
+	if err := osutil.WriteFile(opts.output, append(requestJSON, '\n')); err != nil {
+		return err
+	}
+	fmt.Printf("Requested signing session %s of key %s, expiring at %s. Wrote the request to %s\n", request.SessionID, opts.name, request.ExpiresAt.Format(time.RFC3339), opts.output)
+	return nil
+}
+
+func approveKeyCeremony(opts *keyCeremonyApproveOpts) error {
+	requestJSON, err := os.ReadFile(opts.request)
+	if err != nil {
+		return err
+	}
+	request, err := ceremony.ParseRequest(requestJSON)
+	if err != nil {
+		return err
+	}
+	if opts.printPayload {
+		digest, err := request.Digest()
+		if err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(ceremony.Payload(digest, opts.approver))
+		return err
+	}
+
+	var approval *ceremony.Approval
+	switch {
+	case opts.signingKey != "":
+		signer, err := loadApproverKey(opts.signingKey)
+		if err != nil {
+			return err
+		}
+		if approval, err = ceremony.Approve(request, opts.approver, signer); err != nil {
+			return err
+		}
+	case opts.signature != "":
+		signature, err := os.ReadFile(opts.signature)
+		if err != nil {
+			return err
+		}
+		if approval, err = ceremony.NewApproval(request, opts.approver, signature); err != nil {
+			return err
+		}
+	default:
+		return errors.New(`one of flags "--signing-key", "--signature" and "--print-payload" is required`)
+	}
+	approvalJSON, err := json.MarshalIndent(approval, "", "    ")
+	if err != nil {
+		return err
+	}
+	if opts.output == "" {
+		fmt.Println(string(approvalJSON))
+		return nil
+	}
+	if err := osutil.WriteFile(opts.output, append(approvalJSON, '\n')); err != nil {
+		return err
+	}
+	fmt.Printf("Approved signing session %s of key %s as %s. Wrote the approval to %s\n", request.SessionID, request.Key, opts.approver, opts.output)
+	return nil
+}
+
+func openKeyCeremony(opts *keyCeremonyOpenOpts) error {
+	policy, err := loadCeremonyPolicy(opts.name)
+	if err != nil {
+		return err
+	}
+	var approvals []*ceremony.Approval
+	for _, path := range opts.approvals {
+		approvalJSON, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var approval ceremony.Approval
+		if err := json.Unmarshal(approvalJSON, &approval); err != nil {
+			return fmt.Errorf("malformed approval %s: %w", path, err)
+		}
+		approvals = append(approvals, &approval)
+	}
+	store, err := ceremonyStore()
+	if err != nil {
+		return err
+	}
+	session, err := store.Open(opts.name, policy, approvals, time.Now())
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Opened signing session %s of key %s approved by %s, expiring at %s\n", session.Request.SessionID, opts.name, strings.Join(session.Approvers, ", "), session.Request.ExpiresAt.Format(time.RFC3339))
+	return nil
+}
+
+// checkKeyCeremony records in the audit trail that the signing key with the
+// name signs reference, if the key requires a key ceremony. An error is
+// returned if no signing session of the key is open.
+func checkKeyCeremony(name, reference string, now time.Time) error {
+	keyCeremony, err := configutil.LoadKeyCeremony(name)
+	if err != nil || keyCeremony == nil {
+		return err
+	}
+	key, err := configutil.ResolveKey(name)
+	if err != nil {
+		return err
+	}
+	store, err := ceremonyStore()
+	if err != nil {
+		return err
+	}
+	if err := store.RecordSigning(key.Name, reference, now); err != nil {
+		if errors.Is(err, ceremony.ErrNoSession) || errors.Is(err, ceremony.ErrSessionExpired) {
+			return fmt.Errorf("key %q requires the approvals of %d approvers before signing, open a signing session with \"notation key ceremony\": %w", key.Name, keyCeremony.Threshold, err)
+		}
+		return err
+	}
+	return nil
+}
+
+// loadCeremonyPolicy returns the key ceremony required by the signing key
+// with the name.
+func loadCeremonyPolicy(name string) (*ceremony.Policy, error) {
+	keyCeremony, err := configutil.LoadKeyCeremony(name)
+	if err != nil {
+		return nil, err
+	}
+	if keyCeremony == nil {
+		return nil, fmt.Errorf("key %q does not require a key ceremony, run \"notation key ceremony init\" first", name)
+	}
+	return ceremony.NewPolicy(keyCeremony.Threshold, keyCeremony.Approvers)
+}
+
+// ceremonyStore returns the store of the key ceremonies in the notation
+// configuration directory.
+func ceremonyStore() (*ceremony.Store, error) {
+	root, err := dir.ConfigFS().SysPath(ceremonyDir)
+	if err != nil {
+		return nil, err
+	}
+	return ceremony.NewStore(root), nil
+}
+
+// loadApproverKey loads the PEM-encoded private key of an approver.
+func loadApproverKey(path string) (crypto.Signer, error) {
+	keyPEM, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM-encoded private key found in %s", path)
+	}
+	var key any
+	switch block.Type {
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the private key in %s: %w", path, err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+	return signer, nil
+}
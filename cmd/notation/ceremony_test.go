@@ -0,0 +1,96 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/notation-go/dir"
+	"github.com/notaryproject/notation/internal/ceremony"
+)
+
+func TestCheckKeyCeremony(t *testing.T) {
+	defer func(oldDir string) { dir.UserConfigDir = oldDir }(dir.UserConfigDir)
+	dir.UserConfigDir = t.TempDir()
+	signingKeysJSON := `{"default":"release-key","keys":[{"name":"release-key","keyPath":"r.key","certPath":"r.crt"},{"name":"plain-key","keyPath":"p.key","certPath":"p.crt"}]}`
+	if err := os.WriteFile(filepath.Join(dir.UserConfigDir, dir.PathSigningKeys), []byte(signingKeysJSON), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// an approver with a private key file
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "alice"},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(time.Hour),
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPath := filepath.Join(t.TempDir(), "alice.crt")
+	keyPath := filepath.Join(t.TempDir(), "alice.key")
+	if err := os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := initKeyCeremony(&keyCeremonyInitOpts{name: "release-key", threshold: 1, approvers: []string{"alice=" + certPath}}); err != nil {
+		t.Fatal(err)
+	}
+	// keys without key ceremony sign as usual
+	if err := checkKeyCeremony("plain-key", "localhost:5000/app@sha256:abc", now); err != nil {
+		t.Fatalf("checkKeyCeremony() error = %v", err)
+	}
+	// the default key requires an open signing session
+	if err := checkKeyCeremony("", "localhost:5000/app@sha256:abc", now); !errors.Is(err, ceremony.ErrNoSession) {
+		t.Fatalf("expected no signing session, got %v", err)
+	}
+
+	requestPath := filepath.Join(t.TempDir(), "request.json")
+	approvalPath := filepath.Join(t.TempDir(), "alice.approval.json")
+	if err := requestKeyCeremony(&keyCeremonyRequestOpts{name: "release-key", duration: time.Hour, output: requestPath}); err != nil {
+		t.Fatal(err)
+	}
+	if err := approveKeyCeremony(&keyCeremonyApproveOpts{request: requestPath, approver: "alice", signingKey: keyPath, output: approvalPath}); err != nil {
+		t.Fatal(err)
+	}
+	if err := openKeyCeremony(&keyCeremonyOpenOpts{name: "release-key", approvals: []string{approvalPath}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkKeyCeremony("", "localhost:5000/app@sha256:abc", now); err != nil {
+		t.Fatalf("checkKeyCeremony() error = %v", err)
+	}
+
+	store, err := ceremonyStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := ceremony.ReadAuditTrail(store.AuditTrailPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 5 || records[4].Action != ceremony.ActionSigned || records[4].Key != "release-key" {
+		t.Fatalf("unexpected audit trail: %+v", records)
+	}
+}
@@ -16,6 +16,8 @@ func Cmd() *cobra.Command {
 		certShowCommand(nil),
 		certDeleteCommand(nil),
 		certGenerateTestCommand(nil),
+		certExportManifestCommand(nil),
+		certDiffCommand(nil),
 	)
 
 	return command
@@ -0,0 +1,130 @@
+package cert
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/notaryproject/notation/cmd/notation/internal/truststore"
+	"github.com/notaryproject/notation/internal/cmd"
+	"github.com/notaryproject/notation/internal/experimental"
+	"github.com/spf13/cobra"
+)
+
+const (
+	diffFormatText = "text"
+	diffFormatJSON = "json"
+)
+
+type certDiffOpts struct {
+	cmd.LoggingFlagOpts
+	manifest      string
+	otherManifest string
+	format        string
+}
+
+func certDiffCommand(opts *certDiffOpts) *cobra.Command {
+	if opts == nil {
+		opts = &certDiffOpts{}
+	}
+	command := &cobra.Command{
+		Use:   "diff [flags] <manifest> [<other_manifest>]",
+		Short: "[Experimental] Compare the trust store with a trust store manifest",
+		Long: `[Experimental] Compare the trust store with a trust store manifest
+
+The certificates of the trust store manifest, exported with "notation cert export-manifest" on another host, are compared with the certificates in the trust store of this host, or with the certificates of another manifest if given. Certificates are compared by their named stores and SHA-256 thumbprints, so certificate files renamed between hosts are not reported. The command fails if the trust stores differ.
+
+Example - Compare the trust store with the manifest exported on another host:
+  notation cert diff manifest.json
+
+Example - Compare the manifests exported on two hosts in JSON:
+  notation cert diff --format json build-01.json build-02.json
+`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return errors.New("missing trust store manifest")
+			}
+			if len(args) > 2 {
+				return errors.New("diff compares at most two trust store manifests")
+			}
+			opts.manifest = args[0]
+			if len(args) == 2 {
+				opts.otherManifest = args[1]
+			}
+			return nil
+		},
+		PreRunE: experimental.CheckCommandAndWarn,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return diffManifest(cmd.Context(), opts)
+		},
+	}
+	opts.LoggingFlagOpts.ApplyFlags(command.Flags())
+	command.Flags().StringVar(&opts.format, "format", diffFormatText, fmt.Sprintf("format of the differences, options: %q, %q", diffFormatText, diffFormatJSON))
+	return command
+}
+
+func diffManifest(ctx context.Context, opts *certDiffOpts) error {
+	// set log level
+	ctx = opts.LoggingFlagOpts.SetLoggerLevel(ctx)
+
+	// initialize
+	if opts.format != diffFormatText && opts.format != diffFormatJSON {
+		return fmt.Errorf("unsupported format %q, options: %q, %q", opts.format, diffFormatText, diffFormatJSON)
+	}
+	base, err := truststore.ReadManifest(opts.manifest)
+	if err != nil {
+		return err
+	}
+	var target *truststore.Manifest
+	targetName := "the trust store of this host"
+	if opts.otherManifest != "" {
+		if target, err = truststore.ReadManifest(opts.otherManifest); err != nil {
+			return err
+		}
+		targetName = opts.otherManifest
+	} else if target, err = localManifest(ctx); err != nil {
+		return err
+	}
+
+	// core process
+	diff := truststore.DiffManifests(base, target)
+
+	// write out
+	if opts.format == diffFormatJSON {
+		diffJSON, err := json.MarshalIndent(diff, "", "    ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(diffJSON))
+	} else {
+		printManifestDiff(diff, opts.manifest, targetName)
+	}
+	if !diff.Empty() {
+		return fmt.Errorf("%s differs from %s", targetName, opts.manifest)
+	}
+	return nil
+}
+
+func printManifestDiff(diff *truststore.ManifestDiff, baseName, targetName string) {
+	if diff.Empty() {
+		fmt.Printf("No differences between %s and %s\n", baseName, targetName)
+		return
+	}
+	printCertificates := func(header string, certs []truststore.ManifestCertificate) {
+		if len(certs) == 0 {
+			return
+		}
+		fmt.Println(header)
+		for _, cert := range certs {
+			fmt.Printf("  %s/%s\n", cert.Store(), cert.File)
+			fmt.Println("    Subject:", cert.Subject)
+			fmt.Println("    Issuer:", cert.Issuer)
+			fmt.Println("    Valid to:", cert.NotAfter.Format(time.RFC3339))
+			fmt.Println("    SHA256 Thumbprint:", cert.Thumbprint)
+		}
+	}
+	printCertificates(fmt.Sprintf("Only in %s:", baseName), diff.Removed)
+	printCertificates(fmt.Sprintf("Only in %s:", targetName), diff.Added)
+}
@@ -0,0 +1,52 @@
+package cert
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCertExportManifestCommand(t *testing.T) {
+	opts := &certExportManifestOpts{}
+	cmd := certExportManifestCommand(opts)
+	expected := &certExportManifestOpts{
+		output: "manifest.json",
+	}
+	if err := cmd.ParseFlags([]string{
+		"-o", "manifest.json"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if !reflect.DeepEqual(*expected, *opts) {
+		t.Fatalf("Expect cert export-manifest opts: %v, got: %v", expected, opts)
+	}
+}
+
+func TestCertDiffCommand(t *testing.T) {
+	opts := &certDiffOpts{}
+	cmd := certDiffCommand(opts)
+	expected := &certDiffOpts{
+		manifest:      "build-01.json",
+		otherManifest: "build-02.json",
+		format:        "json",
+	}
+	if err := cmd.ParseFlags([]string{
+		"build-01.json", "build-02.json",
+		"--format", "json"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := cmd.Args(cmd, cmd.Flags().Args()); err != nil {
+		t.Fatalf("Parse Args failed: %v", err)
+	}
+	if !reflect.DeepEqual(*expected, *opts) {
+		t.Fatalf("Expect cert diff opts: %v, got: %v", expected, opts)
+	}
+}
+
+func TestCertDiffCommand_MissingArgs(t *testing.T) {
+	cmd := certDiffCommand(nil)
+	if err := cmd.ParseFlags(nil); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := cmd.Args(cmd, cmd.Flags().Args()); err == nil {
+		t.Fatal("Parse Args expected error, but ok")
+	}
+}
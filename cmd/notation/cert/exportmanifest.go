@@ -0,0 +1,90 @@
+package cert
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/notaryproject/notation-go/dir"
+	"github.com/notaryproject/notation-go/log"
+	"github.com/notaryproject/notation/cmd/notation/internal/truststore"
+	"github.com/notaryproject/notation/internal/cmd"
+	"github.com/notaryproject/notation/internal/experimental"
+	"github.com/notaryproject/notation/internal/osutil"
+	"github.com/spf13/cobra"
+)
+
+type certExportManifestOpts struct {
+	cmd.LoggingFlagOpts
+	output string
+}
+
+func certExportManifestCommand(opts *certExportManifestOpts) *cobra.Command {
+	if opts == nil {
+		opts = &certExportManifestOpts{}
+	}
+	command := &cobra.Command{
+		Use:   "export-manifest [flags]",
+		Short: "[Experimental] Export a manifest summarizing the certificates in the trust store",
+		Long: `[Experimental] Export a manifest summarizing the certificates in the trust store
+
+The manifest lists the store type, named store, file name, subject, issuer, expiry and SHA-256 thumbprint of every certificate in the trust store, without the certificates themselves. Compare the manifest with the trust store of another host with "notation cert diff".
+
+Example - Write the manifest of the trust store to stdout:
+  notation cert export-manifest
+
+Example - Write the manifest of the trust store to a file:
+  notation cert export-manifest --output manifest.json
+`,
+		Args:    cobra.NoArgs,
+		PreRunE: experimental.CheckCommandAndWarn,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return exportManifest(cmd.Context(), opts)
+		},
+	}
+	opts.LoggingFlagOpts.ApplyFlags(command.Flags())
+	command.Flags().StringVarP(&opts.output, "output", "o", "", "file to write the manifest to, the manifest is written to stdout if not set")
+	return command
+}
+
+func exportManifest(ctx context.Context, opts *certExportManifestOpts) error {
+	// set log level
+	ctx = opts.LoggingFlagOpts.SetLoggerLevel(ctx)
+
+	// core process
+	manifest, err := localManifest(ctx)
+	if err != nil {
+		return err
+	}
+	manifestJSON, err := json.MarshalIndent(manifest, "", "    ")
+	if err != nil {
+		return err
+	}
+	manifestJSON = append(manifestJSON, '\n')
+
+	// write out
+	if opts.output == "" {
+		_, err := os.Stdout.Write(manifestJSON)
+		return err
+	}
+	if err := osutil.WriteFile(opts.output, manifestJSON); err != nil {
+		return fmt.Errorf("failed to write the manifest: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Exported the manifest of %d certificates to %s\n", len(manifest.Certificates), opts.output)
+	return nil
+}
+
+// localManifest returns the manifest of the trust store of this host.
+func localManifest(ctx context.Context) (*truststore.Manifest, error) {
+	path, err := dir.ConfigFS().SysPath(dir.TrustStoreDir, "x509")
+	if err != nil {
+		return nil, err
+	}
+	log.GetLogger(ctx).Debugln("Summarizing the trust store at path:", path)
+	manifest, err := truststore.NewManifest(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize the trust store, with error: %w", err)
+	}
+	return manifest, nil
+}
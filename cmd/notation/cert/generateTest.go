@@ -1,19 +1,27 @@
 package cert
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/pem"
 	"errors"
 	"fmt"
+	"math/big"
+	"net"
+	"net/url"
+	"strings"
 	"time"
 
-	"github.com/notaryproject/notation-core-go/testhelper"
 	"github.com/notaryproject/notation-go/config"
 	"github.com/notaryproject/notation-go/dir"
 	"github.com/notaryproject/notation/cmd/notation/internal/truststore"
 	"github.com/notaryproject/notation/internal/osutil"
+	"github.com/notaryproject/notation/pkg/configutil"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 )
@@ -28,10 +36,21 @@ var (
 	}
 )
 
+// supported key types of test keys
+const (
+	keyTypeRSA = "rsa"
+	keyTypeEC  = "ec"
+)
+
 type certGenerateTestOpts struct {
-	name      string
-	bits      int
-	isDefault bool
+	name         string
+	keyType      string
+	bits         int
+	subject      string
+	sans         []string
+	expiry       time.Duration
+	intermediate bool
+	isDefault    bool
 }
 
 func certGenerateTestCommand(opts *certGenerateTestOpts) *cobra.Command {
@@ -40,7 +59,7 @@ func certGenerateTestCommand(opts *certGenerateTestOpts) *cobra.Command {
 	}
 	command := &cobra.Command{
 		Use:   "generate-test [flags] <common_name>",
-		Short: "Generate a test RSA key and a corresponding self-signed certificate.",
+		Short: "Generate a test key and a corresponding self-signed certificate.",
 		Args: func(cmd *cobra.Command, args []string) error {
 			if len(args) == 0 {
 				return errors.New("missing certificate common_name")
@@ -48,20 +67,40 @@ func certGenerateTestCommand(opts *certGenerateTestOpts) *cobra.Command {
 			opts.name = args[0]
 			return nil
 		},
-		Long: `Generate a test RSA key and a corresponding self-signed certificate
+		Long: `Generate a test key and a corresponding self-signed certificate
 
 Example - Generate a test RSA key and a corresponding self-signed certificate named "wabbit-networks.io":
   notation cert generate-test "wabbit-networks.io"
 
 Example - Generate a test RSA key and a corresponding self-signed certificate, set RSA key as a default signing key:
   notation cert generate-test --default "wabbit-networks.io"
+
+Example - Generate a test EC P-384 key and a corresponding certificate valid for 30 days with a subject and subject alternative names:
+  notation cert generate-test --key-type ec --bits 384 --expiry 720h --subject "C=US,ST=WA,L=Seattle,O=wabbit-networks" --san wabbit-networks.io --san release@wabbit-networks.io "wabbit-networks.io"
+
+Example - Generate a test RSA key and a corresponding certificate issued by a test intermediate CA, trusting the test root CA:
+  notation cert generate-test --bits 3072 --intermediate "wabbit-networks.io"
 `,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if !cmd.Flags().Changed("bits") {
+				opts.bits = 2048
+				if opts.keyType == keyTypeEC {
+					opts.bits = 256
+				}
+			}
+			return nil
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return generateTestCert(opts)
 		},
 	}
 
-	command.Flags().IntVarP(&opts.bits, "bits", "b", 2048, "RSA key bits")
+	command.Flags().StringVar(&opts.keyType, "key-type", keyTypeRSA, "key type, options: rsa, ec")
+	command.Flags().IntVarP(&opts.bits, "bits", "b", 0, "RSA key bits, options: 2048 (default for RSA), 3072, 4096, or EC curve size, options: 256 (default for EC), 384, 521")
+	command.Flags().StringVar(&opts.subject, "subject", "", "certificate subject in the format of \"C=US,ST=WA,L=Seattle,O=Notary\", the common name is set by <common_name>")
+	command.Flags().StringArrayVar(&opts.sans, "san", nil, "subject alternative name, DNS name, IP address, email address or URI, can be used multiple times")
+	command.Flags().DurationVar(&opts.expiry, "expiry", 24*time.Hour, "validity period of the certificates")
+	command.Flags().BoolVar(&opts.intermediate, "intermediate", false, "issue the certificate from a test intermediate CA and a test root CA, and add the root CA certificate to the trust store")
 	setKeyDefaultFlag(command.Flags(), &opts.isDefault)
 	return command
 }
@@ -72,20 +111,40 @@ func generateTestCert(opts *certGenerateTestOpts) error {
 	if !truststore.IsValidFileName(name) {
 		return errors.New("name needs to follow [a-zA-Z0-9_.-]+ format")
 	}
+	if opts.expiry <= 0 {
+		return errors.New("expiry must be a positive duration")
+	}
+	subject, err := parseSubject(opts.subject)
+	if err != nil {
+		return err
+	}
+	subject.CommonName = name
+	sans, err := parseSANs(opts.sans)
+	if err != nil {
+		return err
+	}
 
-	// generate RSA private key
-	bits := opts.bits
-	fmt.Println("generating RSA Key with", bits, "bits")
-	key, keyBytes, err := generateTestKey(bits)
+	// generate private key
+	fmt.Println("generating", strings.ToUpper(opts.keyType), "Key with", opts.bits, "bits")
+	key, keyBytes, err := generateTestKey(opts.keyType, opts.bits)
 	if err != nil {
 		return err
 	}
 
-	rsaCertTuple, certBytes, err := generateSelfSignedCert(key, name)
+	leafTemplate := newCertTemplate(subject, opts.expiry, false)
+	sans.apply(leafTemplate)
+	var certs []*x509.Certificate
+	if opts.intermediate {
+		certs, err = generateCertChain(leafTemplate, key, opts.keyType, opts.bits)
+	} else {
+		var cert *x509.Certificate
+		cert, err = createCert(leafTemplate, leafTemplate, key.Public(), key)
+		certs = []*x509.Certificate{cert}
+	}
 	if err != nil {
 		return err
 	}
-	fmt.Println("generated certificate expiring on", rsaCertTuple.Cert.NotAfter.Format(time.RFC3339))
+	fmt.Println("generated certificate expiring on", certs[0].NotAfter.Format(time.RFC3339))
 
 	// write private key
 	relativeKeyPath, relativeCertPath := dir.LocalKeyPath(name)
@@ -103,22 +162,32 @@ func generateTestCert(opts *certGenerateTestOpts) error {
 	}
 	fmt.Println("wrote key:", keyPath)
 
-	// write the self-signed certificate
-	if err := osutil.WriteFileWithPermission(certPath, certBytes, 0644, false); err != nil {
+	// write the certificate chain
+	if err := osutil.WriteFileWithPermission(certPath, generateCertPEM(certs...), 0644, false); err != nil {
 		return fmt.Errorf("failed to write certificate file: %v", err)
 	}
 	fmt.Println("wrote certificate:", certPath)
 
+	// the root certificate is trusted
+	trustedCertPath := certPath
+	if opts.intermediate {
+		trustedCertPath = strings.TrimSuffix(certPath, dir.LocalCertificateExtension) + ".root" + dir.LocalCertificateExtension
+		if err := osutil.WriteFileWithPermission(trustedCertPath, generateCertPEM(certs[len(certs)-1]), 0644, false); err != nil {
+			return fmt.Errorf("failed to write root certificate file: %v", err)
+		}
+		fmt.Println("wrote root certificate:", trustedCertPath)
+	}
+
 	// update signingkeys.json config
 	exec := func(s *config.SigningKeys) error {
 		return s.Add(opts.name, keyPath, certPath, opts.isDefault)
 	}
-	if err := config.LoadExecSaveSigningKeys(exec); err != nil {
+	if err := configutil.LoadExecSaveSigningKeys(exec, map[string]string{opts.name: configutil.KeyPurposeTest}); err != nil {
 		return err
 	}
 
 	// Add to the trust store
-	if err := truststore.AddCert(certPath, "ca", name, true); err != nil {
+	if err := truststore.AddCert(trustedCertPath, "ca", name, true); err != nil {
 		return err
 	}
 
@@ -130,8 +199,35 @@ func generateTestCert(opts *certGenerateTestOpts) error {
 	return nil
 }
 
-func generateTestKey(bits int) (*rsa.PrivateKey, []byte, error) {
-	key, err := rsa.GenerateKey(rand.Reader, bits)
+// generateTestKey generates a private key of the key type and size, returning
+// the key and its PKCS #8 PEM encoding.
+func generateTestKey(keyType string, bits int) (crypto.Signer, []byte, error) {
+	var key crypto.Signer
+	var err error
+	switch keyType {
+	case keyTypeRSA:
+		switch bits {
+		case 2048, 3072, 4096:
+		default:
+			return nil, nil, fmt.Errorf("unsupported RSA key size: %d, options: 2048, 3072, 4096", bits)
+		}
+		key, err = rsa.GenerateKey(rand.Reader, bits)
+	case keyTypeEC:
+		var curve elliptic.Curve
+		switch bits {
+		case 256:
+			curve = elliptic.P256()
+		case 384:
+			curve = elliptic.P384()
+		case 521:
+			curve = elliptic.P521()
+		default:
+			return nil, nil, fmt.Errorf("unsupported EC curve size: %d, options: 256, 384, 521", bits)
+		}
+		key, err = ecdsa.GenerateKey(curve, rand.Reader)
+	default:
+		return nil, nil, fmt.Errorf("unsupported key type: %q, options: %s, %s", keyType, keyTypeRSA, keyTypeEC)
+	}
 	if err != nil {
 		return nil, nil, err
 	}
@@ -143,12 +239,160 @@ func generateTestKey(bits int) (*rsa.PrivateKey, []byte, error) {
 	return key, keyPEM, nil
 }
 
-func generateCertPEM(rsaCertTuple *testhelper.RSACertTuple) []byte {
-	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: rsaCertTuple.Cert.Raw})
+func generateCertPEM(certs ...*x509.Certificate) []byte {
+	var certPEM []byte
+	for _, cert := range certs {
+		certPEM = append(certPEM, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})...)
+	}
+	return certPEM
+}
+
+// generateCertChain generates a test root CA and a test intermediate CA with
+// keys of the same type and size as the leaf key, and issues the leaf
+// certificate from the intermediate CA. The chain is returned leaf first.
+func generateCertChain(leafTemplate *x509.Certificate, leafKey crypto.Signer, keyType string, bits int) ([]*x509.Certificate, error) {
+	validity := leafTemplate.NotAfter.Sub(leafTemplate.NotBefore)
+	caSubject := leafTemplate.Subject
+	caSubject.CommonName = leafTemplate.Subject.CommonName + " Test Root CA"
+	rootTemplate := newCertTemplate(caSubject, validity, true)
+	rootTemplate.MaxPathLen = 1
+	rootKey, _, err := generateTestKey(keyType, bits)
+	if err != nil {
+		return nil, err
+	}
+	root, err := createCert(rootTemplate, rootTemplate, rootKey.Public(), rootKey)
+	if err != nil {
+		return nil, err
+	}
+
+	caSubject.CommonName = leafTemplate.Subject.CommonName + " Test Intermediate CA"
+	intermediateTemplate := newCertTemplate(caSubject, validity, true)
+	intermediateTemplate.MaxPathLenZero = true
+	intermediateKey, _, err := generateTestKey(keyType, bits)
+	if err != nil {
+		return nil, err
+	}
+	intermediate, err := createCert(intermediateTemplate, root, intermediateKey.Public(), rootKey)
+	if err != nil {
+		return nil, err
+	}
+
+	leaf, err := createCert(leafTemplate, intermediate, leafKey.Public(), intermediateKey)
+	if err != nil {
+		return nil, err
+	}
+	return []*x509.Certificate{leaf, intermediate, root}, nil
+}
+
+// newCertTemplate returns a certificate template valid from now for the
+// validity period. Non-CA certificates are code signing certificates.
+func newCertTemplate(subject pkix.Name, validity time.Duration, isCA bool) *x509.Certificate {
+	now := time.Now()
+	template := &x509.Certificate{
+		Subject:   subject,
+		NotBefore: now,
+		NotAfter:  now.Add(validity),
+	}
+	if isCA {
+		template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+		template.BasicConstraintsValid = true
+		template.IsCA = true
+	} else {
+		template.KeyUsage = x509.KeyUsageDigitalSignature
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning}
+	}
+	return template
+}
+
+// createCert creates a certificate from the template issued by parent and
+// signed with the private key of parent.
+func createCert(template, parent *x509.Certificate, pub crypto.PublicKey, priv crypto.Signer) (*x509.Certificate, error) {
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 127))
+	if err != nil {
+		return nil, err
+	}
+	template.SerialNumber = serialNumber
+	certBytes, err := x509.CreateCertificate(rand.Reader, template, parent, pub, priv)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create certificate: %w", err)
+	}
+	return x509.ParseCertificate(certBytes)
+}
+
+// parseSubject parses a subject in the format of "C=US,ST=WA,O=Notary".
+// The subject defaults to "C=US,ST=WA,L=Seattle,O=Notary" if empty.
+func parseSubject(subject string) (pkix.Name, error) {
+	if subject == "" {
+		return pkix.Name{
+			Country:      []string{"US"},
+			Province:     []string{"WA"},
+			Locality:     []string{"Seattle"},
+			Organization: []string{"Notary"},
+		}, nil
+	}
+	var name pkix.Name
+	for _, attr := range strings.Split(subject, ",") {
+		key, value, found := strings.Cut(attr, "=")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if !found || value == "" {
+			return pkix.Name{}, fmt.Errorf("malformed subject attribute %q, expecting the format of {type}={value}", attr)
+		}
+		switch strings.ToUpper(key) {
+		case "C":
+			name.Country = append(name.Country, value)
+		case "ST":
+			name.Province = append(name.Province, value)
+		case "L":
+			name.Locality = append(name.Locality, value)
+		case "O":
+			name.Organization = append(name.Organization, value)
+		case "OU":
+			name.OrganizationalUnit = append(name.OrganizationalUnit, value)
+		case "CN":
+			return pkix.Name{}, errors.New("the common name of the subject is set by <common_name>")
+		default:
+			return pkix.Name{}, fmt.Errorf("unsupported subject attribute type %q, options: C, ST, L, O, OU", key)
+		}
+	}
+	return name, nil
+}
+
+// subjectAltNames are the subject alternative names of a certificate.
+type subjectAltNames struct {
+	dnsNames       []string
+	emailAddresses []string
+	ipAddresses    []net.IP
+	uris           []*url.URL
+}
+
+// parseSANs classifies each subject alternative name as an IP address, a URI,
+// an email address or a DNS name.
+func parseSANs(values []string) (subjectAltNames, error) {
+	var sans subjectAltNames
+	for _, value := range values {
+		switch {
+		case value == "":
+			return subjectAltNames{}, errors.New("subject alternative name cannot be empty")
+		case net.ParseIP(value) != nil:
+			sans.ipAddresses = append(sans.ipAddresses, net.ParseIP(value))
+		case strings.Contains(value, "://"):
+			uri, err := url.Parse(value)
+			if err != nil {
+				return subjectAltNames{}, fmt.Errorf("malformed URI subject alternative name %q: %w", value, err)
+			}
+			sans.uris = append(sans.uris, uri)
+		case strings.Contains(value, "@"):
+			sans.emailAddresses = append(sans.emailAddresses, value)
+		default:
+			sans.dnsNames = append(sans.dnsNames, value)
+		}
+	}
+	return sans, nil
 }
 
-// generateTestCert generates a self-signed non-CA certificate
-func generateSelfSignedCert(privateKey *rsa.PrivateKey, name string) (testhelper.RSACertTuple, []byte, error) {
-	rsaCertTuple := testhelper.GetRSASelfSignedCertTupleWithPK(privateKey, name)
-	return rsaCertTuple, generateCertPEM(&rsaCertTuple), nil
+func (sans subjectAltNames) apply(template *x509.Certificate) {
+	template.DNSNames = sans.dnsNames
+	template.EmailAddresses = sans.emailAddresses
+	template.IPAddresses = sans.ipAddresses
+	template.URIs = sans.uris
 }
@@ -1,9 +1,12 @@
 package cert
 
 import (
+	"crypto/x509"
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestCertGenerateCommand(t *testing.T) {
@@ -11,7 +14,9 @@ func TestCertGenerateCommand(t *testing.T) {
 	cmd := certGenerateTestCommand(opts)
 	expected := &certGenerateTestOpts{
 		name:      "name",
+		keyType:   keyTypeRSA,
 		bits:      2048,
+		expiry:    24 * time.Hour,
 		isDefault: true,
 	}
 	if err := cmd.ParseFlags([]string{
@@ -37,3 +42,96 @@ func TestCertGenerateTestCommand_MissingArgs(t *testing.T) {
 		t.Fatal("Parse Args expected error, but ok")
 	}
 }
+
+func TestParseSubject(t *testing.T) {
+	name, err := parseSubject("C=US, ST=WA,L=Seattle,O=wabbit-networks,OU=release")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if name.Organization[0] != "wabbit-networks" || name.OrganizationalUnit[0] != "release" || name.Country[0] != "US" {
+		t.Fatalf("unexpected subject: %v", name)
+	}
+	for _, subject := range []string{"CN=wabbit-networks.io", "O", "X=unknown"} {
+		if _, err := parseSubject(subject); err == nil {
+			t.Fatalf("expected error for subject %q, got nil", subject)
+		}
+	}
+}
+
+func TestParseSANs(t *testing.T) {
+	sans, err := parseSANs([]string{"wabbit-networks.io", "10.0.0.1", "release@wabbit-networks.io", "https://wabbit-networks.io/release"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(sans.dnsNames) != 1 || len(sans.ipAddresses) != 1 || len(sans.emailAddresses) != 1 || len(sans.uris) != 1 {
+		t.Fatalf("unexpected subject alternative names: %+v", sans)
+	}
+}
+
+func TestGenerateTestKey_Unsupported(t *testing.T) {
+	for _, tt := range []struct {
+		keyType string
+		bits    int
+	}{
+		{keyTypeRSA, 1024},
+		{keyTypeEC, 224},
+		{"dsa", 2048},
+	} {
+		if _, _, err := generateTestKey(tt.keyType, tt.bits); err == nil {
+			t.Fatalf("expected error for %s %d, got nil", tt.keyType, tt.bits)
+		}
+	}
+}
+
+func TestGenerateCertChain(t *testing.T) {
+	key, _, err := generateTestKey(keyTypeEC, 384)
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, err := parseSubject("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject.CommonName = "wabbit-networks.io"
+	certs, err := generateCertChain(newCertTemplate(subject, time.Hour, false), key, keyTypeEC, 384)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(certs) != 3 {
+		t.Fatalf("expected 3 certificates, got %d", len(certs))
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(certs[2])
+	intermediates := x509.NewCertPool()
+	intermediates.AddCert(certs[1])
+	if _, err := certs[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	}); err != nil {
+		t.Fatalf("expected valid chain, got %v", err)
+	}
+}
+
+func TestCertGenerateTestCommand_DefaultBits(t *testing.T) {
+	for keyType, want := range map[string]int{keyTypeRSA: 2048, keyTypeEC: 256} {
+		opts := &certGenerateTestOpts{}
+		cmd := certGenerateTestCommand(opts)
+		if err := cmd.ParseFlags([]string{"name", "--key-type", keyType}); err != nil {
+			t.Fatalf("Parse Flag failed: %v", err)
+		}
+		if err := cmd.PreRunE(cmd, cmd.Flags().Args()); err != nil {
+			t.Fatalf("PreRunE failed: %v", err)
+		}
+		if opts.bits != want {
+			t.Fatalf("expected %d bits for %s keys, got %d", want, keyType, opts.bits)
+		}
+	}
+	// the defaults are documented in the usage only once
+	if usage := certGenerateTestCommand(nil).Flags().Lookup("bits").Usage; strings.Count(usage, "default") != 2 {
+		t.Fatalf("unexpected usage of flag --bits: %s", usage)
+	}
+	if usages := certGenerateTestCommand(nil).Flags().FlagUsages(); strings.Contains(usages, "(default 2048)") {
+		t.Fatalf("unexpected default suffix of flag --bits: %s", usages)
+	}
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/notaryproject/notation/cmd/notation/internal/integrity"
+	"github.com/notaryproject/notation/internal/audit"
+	"github.com/notaryproject/notation/internal/cmd"
+	"github.com/notaryproject/notation/internal/color"
+	"github.com/notaryproject/notation/internal/experimental"
+	"github.com/notaryproject/notation/internal/gate"
+	"github.com/notaryproject/notation/internal/osutil"
+	"github.com/notaryproject/notation/internal/policy"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/spf13/cobra"
+	"oras.land/oras-go/v2/content"
+)
+
+// githubStepSummaryEnv is the environment variable of GitHub Actions naming
+// the file of the job summary.
+const githubStepSummaryEnv = "GITHUB_STEP_SUMMARY"
+
+type ciGateOpts struct {
+	cmd.LoggingFlagOpts
+	SecureFlagOpts
+	files           []string
+	refsFromChanged bool
+	base            string
+	includes        []string
+	excludes        []string
+	pluginConfig    []string
+	summaryOut      string
+	// changedFiles lists the files changed since base, defaults to
+	// gitChangedFiles
+	changedFiles func(base string) ([]string, error)
+}
+
+func ciCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "ci",
+		Short: "[Experimental] Gate continuous integration pipelines on signature verification",
+		Long:  "[Experimental] Gate continuous integration pipelines on signature verification",
+	}
+	command.AddCommand(ciGateCommand(nil))
+	return command
+}
+
+func ciGateCommand(opts *ciGateOpts) *cobra.Command {
+	if opts == nil {
+		opts = &ciGateOpts{}
+	}
+	command := &cobra.Command{
+		Use:   "gate [flags] [<manifest>...]",
+		Short: "[Experimental] Verify the images referenced by changed Kubernetes and Compose manifests",
+		Long: `[Experimental] Verify the images referenced by changed Kubernetes and Compose manifests
+
+The image references of the "image" fields of the manifests are verified against the trust policy, and a Markdown summary ready to be posted on the pull request is written. Manifests are either given as arguments or, with flag "--refs-from-changed-files", the files changed in the working tree of the git repository since the base of the pull request. In GitHub Actions, the base defaults to the target branch of the pull request, and the summary is also appended to the job summary.
+
+The command exits with a failure if any image reference fails the verification. Templated references, e.g. "${IMAGE}", are skipped.
+
+Example - Verify the images of the manifests changed by a pull request:
+  notation ci gate --refs-from-changed-files
+
+Example - Verify the images of the manifests under the "deploy" directory changed since the "main" branch:
+  notation ci gate --refs-from-changed-files --base origin/main --include "deploy/**/*.yaml"
+
+Example - Verify the images of the given manifests and write the summary to a file:
+  notation ci gate --summary-out summary.md k8s/deployment.yaml compose.yaml
+`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 && !opts.refsFromChanged {
+				return errors.New("either manifests or flag \"--refs-from-changed-files\" is required")
+			}
+			opts.files = args
+			return nil
+		},
+		PreRunE: experimental.CheckCommandAndWarn,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.SecureFlagOpts.applyChangedFlags(cmd.Flags())
+			return runCIGate(cmd.Context(), opts)
+		},
+	}
+	opts.LoggingFlagOpts.ApplyFlags(command.Flags())
+	opts.SecureFlagOpts.ApplyFlags(command.Flags())
+	cmd.SetPflagPluginConfig(command.Flags(), &opts.pluginConfig)
+	command.Flags().BoolVar(&opts.refsFromChanged, "refs-from-changed-files", false, "verify the images referenced by the manifests changed since the base of the pull request")
+	command.Flags().StringVar(&opts.base, "base", "", "git revision the changes are compared with, defaults to the target branch of the pull request in GitHub Actions, or \"origin/HEAD\"")
+	command.Flags().StringSliceVar(&opts.includes, "include", gate.DefaultIncludes, "glob patterns of the manifests to scan, where \"**\" matches any number of directories")
+	command.Flags().StringSliceVar(&opts.excludes, "exclude", nil, "glob patterns of the manifests not to scan")
+	command.Flags().StringVar(&opts.summaryOut, "summary-out", "", "file to write the Markdown summary to, the summary is written to stdout if not set")
+	return command
+}
+
+func runCIGate(ctx context.Context, opts *ciGateOpts) error {
+	// set log level
+	ctx = opts.LoggingFlagOpts.SetLoggerLevel(ctx)
+
+	files := opts.files
+	if opts.refsFromChanged {
+		changedFiles := opts.changedFiles
+		if changedFiles == nil {
+			changedFiles = gitChangedFiles
+		}
+		changed, err := changedFiles(ciBase(opts.base))
+		if err != nil {
+			return err
+		}
+		files = append(files, changed...)
+	}
+	files = gate.Filter(files, opts.includes, opts.excludes)
+
+	var images []gate.Image
+	for _, file := range files {
+		found, err := extractImagesFromFile(file)
+		if err != nil {
+			return err
+		}
+		images = append(images, found...)
+	}
+	results := gate.Group(images)
+
+	if len(results) > 0 {
+		policyVerifier, err := policy.NewVerifierFromConfigOptions(policy.ConfigOptions{CRLCache: newCRLCache(ctx, false)})
+		if err != nil {
+			return err
+		}
+		warnTrustPolicy("")
+		configs, err := cmd.ParseFlagMap(opts.pluginConfig, cmd.PflagPluginConfig.Name)
+		if err != nil {
+			return err
+		}
+		warnPluginConfig(configs)
+		maxAttempts, err := resolveMaxSignatureAttempts(0)
+		if err != nil {
+			return err
+		}
+		for _, result := range results {
+			verifyGateResult(ctx, opts, policyVerifier, configs, maxAttempts, result)
+			if result.Verdict == gate.VerdictFail {
+				fmt.Fprintf(os.Stderr, "%s %s: %s\n", color.Failure(os.Stderr, "Error:"), result.Reference, result.Detail)
+			}
+		}
+	}
+
+	var summary bytes.Buffer
+	if err := gate.WriteMarkdown(&summary, results); err != nil {
+		return err
+	}
+	if err := writeCISummary(opts.summaryOut, summary.Bytes()); err != nil {
+		return err
+	}
+
+	counts := gate.Count(results)
+	fmt.Fprintf(os.Stderr, "Verified %d image references of %d manifests: %d passed, %d failed, %d skipped\n", len(results), len(files), counts[gate.VerdictPass], counts[gate.VerdictFail], counts[gate.VerdictSkipped])
+	if counts[gate.VerdictFail] > 0 {
+		return fmt.Errorf("signature verification failed for %d image references", counts[gate.VerdictFail])
+	}
+	return nil
+}
+
+// verifyGateResult verifies the image reference of result against the trust
+// policy and records the verdict.
+func verifyGateResult(ctx context.Context, opts *ciGateOpts, policyVerifier *policy.Verifier, configs map[string]string, maxAttempts int, result *gate.Result) {
+	if gate.IsTemplated(result.Reference) {
+		result.Verdict = gate.VerdictSkipped
+		result.Detail = "templated reference"
+		return
+	}
+	reference, err := expandAlias(inputTypeRegistry, result.Reference)
+	if err != nil {
+		result.Verdict, result.Detail = gate.VerdictFail, err.Error()
+		return
+	}
+	reference = gate.Normalize(reference)
+	repo, err := getRepository(ctx, inputTypeRegistry, reference, &opts.SecureFlagOpts)
+	if err != nil {
+		result.Verdict, result.Detail = gate.VerdictFail, err.Error()
+		return
+	}
+	if policyVerifier.UsesArtifactTypes() {
+		var manifestFetcher content.Fetcher
+		manifestFetcher, err = getManifestFetcher(ctx, inputTypeRegistry, reference, &opts.SecureFlagOpts)
+		if err != nil {
+			result.Verdict, result.Detail = gate.VerdictFail, err.Error()
+			return
+		}
+		// trust policy statements are selected by the artifact type
+		repo = policy.NewRepository(repo, manifestFetcher)
+	}
+	sigRepo := integrity.NewRepository(repo, nil)
+	var tagged bool
+	manifestDesc, resolvedRef, err := resolveReference(ctx, inputTypeRegistry, reference, sigRepo, func(string, ocispec.Descriptor) {
+		tagged = true
+	})
+	if err != nil {
+		result.Verdict, result.Detail = gate.VerdictFail, err.Error()
+		return
+	}
+	result.Digest = manifestDesc.Digest.String()
+	repository, _, _ := strings.Cut(resolvedRef, "@")
+	entry := verifyTag(ctx, policyVerifier, sigRepo, repository, "", manifestDesc, configs, maxAttempts)
+	if entry.Result != audit.ResultSuccess {
+		result.Verdict, result.Detail = gate.VerdictFail, entry.Error
+		return
+	}
+	result.Verdict = gate.VerdictPass
+	if tagged {
+		result.Detail = "referenced by a mutable tag, pin the digest to deploy the verified image"
+	}
+}
+
+// ciBase returns the git revision the changes of the pull request are
+// compared with.
+func ciBase(base string) string {
+	if base != "" {
+		return base
+	}
+	if ref := os.Getenv("GITHUB_BASE_REF"); ref != "" {
+		return "origin/" + ref
+	}
+	return "origin/HEAD"
+}
+
+// gitChangedFiles returns the files added, copied, modified or renamed since
+// the merge base of base and HEAD, relative to the working directory.
+func gitChangedFiles(base string) ([]string, error) {
+	var stdout, stderr bytes.Buffer
+	git := exec.Command("git", "diff", "--name-only", "--relative", "--diff-filter=ACMR", "-z", base+"...HEAD")
+	git.Stdout = &stdout
+	git.Stderr = &stderr
+	if err := git.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return nil, fmt.Errorf("failed to list the files changed since %s: %w: %s", base, err, message)
+		}
+		return nil, fmt.Errorf("failed to list the files changed since %s: %w", base, err)
+	}
+	var files []string
+	for _, file := range strings.Split(stdout.String(), "\x00") {
+		if file != "" {
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
+
+func extractImagesFromFile(path string) ([]gate.Image, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	defer file.Close()
+	images, err := gate.ExtractImages(file, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest %s: %w", path, err)
+	}
+	return images, nil
+}
+
+// writeCISummary writes the summary to path, or stdout if path is empty, and
+// appends it to the job summary in GitHub Actions.
+func writeCISummary(path string, summary []byte) error {
+	if path == "" {
+		if _, err := os.Stdout.Write(summary); err != nil {
+			return err
+		}
+	} else if err := osutil.WriteFile(path, summary); err != nil {
+		return fmt.Errorf("failed to write the summary: %w", err)
+	}
+	if stepSummary := os.Getenv(githubStepSummaryEnv); stepSummary != "" {
+		file, err := os.OpenFile(stepSummary, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to write the job summary: %w", err)
+		}
+		defer file.Close()
+		if _, err := file.Write(summary); err != nil {
+			return fmt.Errorf("failed to write the job summary: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/notaryproject/notation/internal/gate"
+)
+
+func TestCIGateCommand_BasicArgs(t *testing.T) {
+	opts := &ciGateOpts{}
+	cmd := ciGateCommand(opts)
+	expected := &ciGateOpts{
+		SecureFlagOpts: SecureFlagOpts{
+			PlainHTTP: true,
+		},
+		files:           []string{"compose.yaml"},
+		refsFromChanged: true,
+		base:            "origin/main",
+		includes:        []string{"deploy/**/*.yaml"},
+		excludes:        []string{"deploy/test/**", "**/kustomization.yaml"},
+		pluginConfig:    []string{"key=value"},
+		summaryOut:      "summary.md",
+	}
+	if err := cmd.ParseFlags([]string{
+		"compose.yaml",
+		"--plain-http",
+		"--refs-from-changed-files",
+		"--base", expected.base,
+		"--include", "deploy/**/*.yaml",
+		"--exclude", "deploy/test/**,**/kustomization.yaml",
+		"--plugin-config", "key=value",
+		"--summary-out", expected.summaryOut}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := cmd.Args(cmd, cmd.Flags().Args()); err != nil {
+		t.Fatalf("Parse Args failed: %v", err)
+	}
+	if !reflect.DeepEqual(opts, expected) {
+		t.Fatalf("Expect ci gate opts: %v, got: %v", expected, opts)
+	}
+}
+
+func TestCIGateCommand_DefaultIncludes(t *testing.T) {
+	opts := &ciGateOpts{}
+	cmd := ciGateCommand(opts)
+	if err := cmd.ParseFlags([]string{"--refs-from-changed-files"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := cmd.Args(cmd, cmd.Flags().Args()); err != nil {
+		t.Fatalf("Parse Args failed: %v", err)
+	}
+	if !reflect.DeepEqual(opts.includes, gate.DefaultIncludes) {
+		t.Fatalf("Expect includes: %v, got: %v", gate.DefaultIncludes, opts.includes)
+	}
+}
+
+func TestCIGateCommand_MissingArgs(t *testing.T) {
+	cmd := ciGateCommand(nil)
+	if err := cmd.ParseFlags(nil); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := cmd.Args(cmd, cmd.Flags().Args()); err == nil {
+		t.Fatal("Parse Args expected error, but ok")
+	}
+}
+
+func TestCIBase(t *testing.T) {
+	t.Setenv("GITHUB_BASE_REF", "")
+	if got := ciBase(""); got != "origin/HEAD" {
+		t.Fatalf("ciBase() = %q, want origin/HEAD", got)
+	}
+	t.Setenv("GITHUB_BASE_REF", "release/1.0")
+	if got := ciBase(""); got != "origin/release/1.0" {
+		t.Fatalf("ciBase() = %q, want origin/release/1.0", got)
+	}
+	if got := ciBase("v1.0.0"); got != "v1.0.0" {
+		t.Fatalf("ciBase() = %q, want v1.0.0", got)
+	}
+}
+
+func TestRunCIGate_NoImages(t *testing.T) {
+	dir := t.TempDir()
+	manifest := filepath.Join(dir, "service.yaml")
+	if err := os.WriteFile(manifest, []byte("kind: Service\nspec:\n  ports:\n    - port: 80\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	stepSummary := filepath.Join(dir, "step-summary.md")
+	t.Setenv(githubStepSummaryEnv, stepSummary)
+	var base string
+	opts := &ciGateOpts{
+		refsFromChanged: true,
+		base:            "origin/main",
+		includes:        gate.DefaultIncludes,
+		summaryOut:      filepath.Join(dir, "summary.md"),
+		changedFiles: func(b string) ([]string, error) {
+			base = b
+			return []string{manifest, filepath.Join(dir, "README.md")}, nil
+		},
+	}
+	if err := runCIGate(context.Background(), opts); err != nil {
+		t.Fatalf("runCIGate() error = %v", err)
+	}
+	if base != "origin/main" {
+		t.Fatalf("changed files listed since %q, want origin/main", base)
+	}
+	for _, path := range []string{opts.summaryOut, stepSummary} {
+		summary, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(summary), "No image references found") {
+			t.Fatalf("unexpected summary in %s: %s", path, summary)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"os"
+	"strings"
 
 	"github.com/spf13/pflag"
 )
@@ -16,7 +17,7 @@ var (
 	flagUsername = &pflag.Flag{
 		Name:      "username",
 		Shorthand: "u",
-		Usage:     "username for registry operations (default to $NOTATION_USERNAME if not specified)",
+		Usage:     "username for registry operations (default to $NOTATION_USERNAME_{HOST}, e.g. $NOTATION_USERNAME_REGISTRY_EXAMPLE_COM, or $NOTATION_USERNAME if not specified)",
 	}
 	setflagUsername = func(fs *pflag.FlagSet, p *string) {
 		fs.StringVarP(p, flagUsername.Name, flagUsername.Shorthand, "", flagUsername.Usage)
@@ -25,7 +26,7 @@ var (
 	flagPassword = &pflag.Flag{
 		Name:      "password",
 		Shorthand: "p",
-		Usage:     "password for registry operations (default to $NOTATION_PASSWORD if not specified)",
+		Usage:     "password for registry operations (default to $NOTATION_PASSWORD_{HOST}, e.g. $NOTATION_PASSWORD_REGISTRY_EXAMPLE_COM, or $NOTATION_PASSWORD if not specified)",
 	}
 	setFlagPassword = func(fs *pflag.FlagSet, p *string) {
 		fs.StringVarP(p, flagPassword.Name, flagPassword.Shorthand, "", flagPassword.Usage)
@@ -39,12 +40,65 @@ var (
 	setFlagPlainHTTP = func(fs *pflag.FlagSet, p *bool) {
 		fs.BoolVar(p, flagPlainHTTP.Name, false, flagPlainHTTP.Usage)
 	}
+
+	flagInsecureRegistry = &pflag.Flag{
+		Name:     "insecure-registry",
+		Usage:    "registry access via HTTPS without verifying the TLS certificate of the registry, the registry must be in \"insecureRegistryAllowList\" of config.json",
+		DefValue: "false",
+	}
+	setFlagInsecureRegistry = func(fs *pflag.FlagSet, p *bool) {
+		fs.BoolVar(p, flagInsecureRegistry.Name, false, flagInsecureRegistry.Usage)
+	}
+
+	flagUserAgent = &pflag.Flag{
+		Name:  "user-agent",
+		Usage: "User-Agent header of the requests to registries, overriding \"userAgent\" of config.json (default \"notation/{version}\")",
+	}
+	setFlagUserAgent = func(fs *pflag.FlagSet, p *string) {
+		fs.StringVar(p, flagUserAgent.Name, "", flagUserAgent.Usage)
+	}
+
+	flagHeader = &pflag.Flag{
+		Name:  "header",
+		Usage: "extra header of the requests to registries in the format of {name}: {value}, e.g. \"X-Tenant-Id: contoso\", overriding the header of the same name of \"registryHeaders\" of config.json, can be used multiple times",
+	}
+	setFlagHeader = func(fs *pflag.FlagSet, p *[]string) {
+		fs.StringArrayVar(p, flagHeader.Name, nil, flagHeader.Usage)
+	}
+
+	flagAnonymous = &pflag.Flag{
+		Name:     "anonymous",
+		Usage:    "access registries without credentials, ignoring the saved credentials, $NOTATION_USERNAME, $NOTATION_PASSWORD, their variables scoped to hosts and \"registryHeaders\" of config.json, and fail if a registry requires authentication",
+		DefValue: "false",
+	}
+	setFlagAnonymous = func(fs *pflag.FlagSet, p *bool) {
+		fs.BoolVar(p, flagAnonymous.Name, false, flagAnonymous.Usage)
+	}
+
+	flagSummaryTrailer = &pflag.Flag{
+		Name:     "summary-trailer",
+		Usage:    "print a final line on stderr in the stable format of \"notation: result={success|failure} signatures={count} duration={seconds}s\", for log scrapers which cannot consume JSON output",
+		DefValue: "false",
+	}
+	setFlagSummaryTrailer = func(fs *pflag.FlagSet, p *bool) {
+		fs.BoolVar(p, flagSummaryTrailer.Name, false, flagSummaryTrailer.Usage)
+	}
 )
 
 type SecureFlagOpts struct {
-	Username  string
-	Password  string
-	PlainHTTP bool
+	Username         string
+	Password         string
+	PlainHTTP        bool
+	InsecureRegistry bool
+	UserAgent        string
+	Headers          []string
+
+	// Anonymous guarantees that no credential is sent to registries.
+	Anonymous bool
+
+	// credentialsFromFlags tells if the credentials are set by the flags
+	// rather than by NOTATION_USERNAME and NOTATION_PASSWORD.
+	credentialsFromFlags bool
 }
 
 // ApplyFlags set flags and their default values for the FlagSet
@@ -52,6 +106,49 @@ func (opts *SecureFlagOpts) ApplyFlags(fs *pflag.FlagSet) {
 	setflagUsername(fs, &opts.Username)
 	setFlagPassword(fs, &opts.Password)
 	setFlagPlainHTTP(fs, &opts.PlainHTTP)
+	setFlagInsecureRegistry(fs, &opts.InsecureRegistry)
+	setFlagUserAgent(fs, &opts.UserAgent)
+	setFlagHeader(fs, &opts.Headers)
 	opts.Username = os.Getenv(defaultUsernameEnv)
 	opts.Password = os.Getenv(defaultPasswordEnv)
 }
+
+// applyChangedFlags records the flags changed in the parsed FlagSet.
+func (opts *SecureFlagOpts) applyChangedFlags(fs *pflag.FlagSet) {
+	opts.credentialsFromFlags = fs.Changed(flagUsername.Name) || fs.Changed(flagPassword.Name)
+}
+
+// credentials returns the username and the password for the registry host.
+// The credentials of the environment variables scoped to the host, e.g.
+// NOTATION_USERNAME_REGISTRY_EXAMPLE_COM and
+// NOTATION_PASSWORD_REGISTRY_EXAMPLE_COM, take precedence over those of
+// NOTATION_USERNAME and NOTATION_PASSWORD, but not over the flags.
+func (opts *SecureFlagOpts) credentials(host string) (string, string) {
+	if opts.credentialsFromFlags {
+		return opts.Username, opts.Password
+	}
+	suffix := hostEnvSuffix(host)
+	username, usernameSet := os.LookupEnv(defaultUsernameEnv + "_" + suffix)
+	password, passwordSet := os.LookupEnv(defaultPasswordEnv + "_" + suffix)
+	if !usernameSet && !passwordSet {
+		return opts.Username, opts.Password
+	}
+	return username, password
+}
+
+// hostEnvSuffix returns the suffix of the environment variables scoped to
+// host, which is host in upper case with the characters other than letters
+// and digits replaced by underscores, e.g. "LOCALHOST_5000" of
+// "localhost:5000".
+func hostEnvSuffix(host string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, host)
+}
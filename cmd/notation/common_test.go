@@ -0,0 +1,83 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+func TestSecureFlagOpts_credentials(t *testing.T) {
+	t.Setenv(defaultUsernameEnv, "user")
+	t.Setenv(defaultPasswordEnv, "password")
+	t.Setenv("NOTATION_USERNAME_REGISTRY_EXAMPLE_COM", "scoped-user")
+	t.Setenv("NOTATION_PASSWORD_REGISTRY_EXAMPLE_COM", "scoped-password")
+	t.Setenv("NOTATION_PASSWORD_LOCALHOST_5000", "token")
+
+	tests := []struct {
+		name         string
+		args         []string
+		host         string
+		wantUsername string
+		wantPassword string
+	}{
+		{
+			name:         "scoped to host",
+			host:         "registry.example.com",
+			wantUsername: "scoped-user",
+			wantPassword: "scoped-password",
+		},
+		{
+			name:         "scoped to host with port",
+			host:         "localhost:5000",
+			wantUsername: "",
+			wantPassword: "token",
+		},
+		{
+			name:         "not scoped",
+			host:         "other.example.com",
+			wantUsername: "user",
+			wantPassword: "password",
+		},
+		{
+			name:         "set by flags",
+			args:         []string{"--username", "flag-user", "--password", "flag-password"},
+			host:         "registry.example.com",
+			wantUsername: "flag-user",
+			wantPassword: "flag-password",
+		},
+		{
+			name:         "set by flags to the values of the environment variables",
+			args:         []string{"--username", "user", "--password", "password"},
+			host:         "registry.example.com",
+			wantUsername: "user",
+			wantPassword: "password",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts SecureFlagOpts
+			fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+			opts.ApplyFlags(fs)
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+			opts.applyChangedFlags(fs)
+			username, password := opts.credentials(tt.host)
+			if username != tt.wantUsername || password != tt.wantPassword {
+				t.Fatalf("credentials() = %q, %q, want %q, %q", username, password, tt.wantUsername, tt.wantPassword)
+			}
+		})
+	}
+}
+
+func TestHostEnvSuffix(t *testing.T) {
+	for host, want := range map[string]string{
+		"registry.example.com": "REGISTRY_EXAMPLE_COM",
+		"localhost:5000":       "LOCALHOST_5000",
+		"my-registry.io":       "MY_REGISTRY_IO",
+	} {
+		if got := hostEnvSuffix(host); got != want {
+			t.Errorf("hostEnvSuffix(%q) = %q, want %q", host, got, want)
+		}
+	}
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"time"
+
+	"github.com/notaryproject/notation-go/dir"
+	"github.com/notaryproject/notation/cmd/notation/internal/cmdutil"
+	"github.com/notaryproject/notation/internal/color"
+	"github.com/notaryproject/notation/internal/configbundle"
+	"github.com/notaryproject/notation/internal/experimental"
+	"github.com/notaryproject/notation/internal/pluginproto"
+	policyext "github.com/notaryproject/notation/internal/policy"
+	"github.com/spf13/cobra"
+)
+
+type configExportOpts struct {
+	path  string
+	force bool
+}
+
+type configImportOpts struct {
+	path      string
+	verify    bool
+	confirmed bool
+}
+
+func configCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "config",
+		Short: "[Experimental] Export and import the configuration for provisioning hosts",
+		Long: `[Experimental] Export and import the configuration for provisioning hosts
+
+A configuration bundle packages the trust policy, the trust stores and config.json, and lists the installed plugins with the SHA-256 digests of their executables, so that new build agents are provisioned identically. Signing keys and credentials are never bundled, and plugins are not installed by importing a bundle.
+
+Example - Export the configuration to a bundle:
+  notation config export bundle.tgz
+
+Example - Configure a host identically to a bundle:
+  notation config import bundle.tgz
+
+Example - Check that a host is configured identically to a bundle:
+  notation config import --verify bundle.tgz
+`,
+	}
+	command.AddCommand(configExportCommand(nil), configImportCommand(nil))
+	return command
+}
+
+func configExportCommand(opts *configExportOpts) *cobra.Command {
+	if opts == nil {
+		opts = &configExportOpts{}
+	}
+	command := &cobra.Command{
+		Use:   "export [flags] <bundle_path>",
+		Short: "[Experimental] Export the configuration to a bundle",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return errors.New("expecting the path of the bundle")
+			}
+			opts.path = args[0]
+			return nil
+		},
+		PreRunE: experimental.CheckCommandAndWarn,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runConfigExport(cmd.Context(), opts)
+		},
+	}
+	command.Flags().BoolVar(&opts.force, "force", false, "overwrite the existing bundle")
+	return command
+}
+
+func configImportCommand(opts *configImportOpts) *cobra.Command {
+	if opts == nil {
+		opts = &configImportOpts{}
+	}
+	command := &cobra.Command{
+		Use:   "import [flags] <bundle_path>",
+		Short: "[Experimental] Configure the host identically to a bundle",
+		Long: `[Experimental] Configure the host identically to a bundle
+
+The trust policy, the trust stores and config.json are replaced by those of the bundle, and the trust store files not in the bundle are removed. The differences are shown and confirmed first. Plugins missing or differing from the bundle are reported, and must be installed separately.
+
+With flag "--verify", the host is not modified, and the command fails if the host differs from the bundle.`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return errors.New("expecting the path of the bundle")
+			}
+			opts.path = args[0]
+			return nil
+		},
+		PreRunE: experimental.CheckCommandAndWarn,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runConfigImport(cmd.Context(), opts)
+		},
+	}
+	command.Flags().BoolVar(&opts.verify, "verify", false, "check that the host is configured identically to the bundle without modifying it, and fail otherwise")
+	command.Flags().BoolVarP(&opts.confirmed, "yes", "y", false, "do not prompt for confirmation")
+	command.MarkFlagsMutuallyExclusive("verify", "yes")
+	return command
+}
+
+func runConfigExport(ctx context.Context, opts *configExportOpts) error {
+	configDir, err := dir.ConfigFS().SysPath("")
+	if err != nil {
+		return err
+	}
+	plugins, err := installedPlugins(ctx)
+	if err != nil {
+		return err
+	}
+	bundle, err := configbundle.Collect(configDir, plugins)
+	if err != nil {
+		return fmt.Errorf("failed to collect the configuration: %w", err)
+	}
+
+	flag := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if opts.force {
+		flag = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	file, err := os.OpenFile(opts.path, flag, 0600)
+	if err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return fmt.Errorf("bundle %s already exists, use flag \"--force\" to overwrite it", opts.path)
+		}
+		return err
+	}
+	if err := bundle.Write(file, time.Now()); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	fmt.Printf("Successfully exported %d configuration files and %d plugins to %s\n", len(bundle.Files), len(bundle.Plugins), opts.path)
+	return nil
+}
+
+func runConfigImport(ctx context.Context, opts *configImportOpts) error {
+	file, err := os.Open(opts.path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	bundle, err := configbundle.Read(file)
+	if err != nil {
+		return fmt.Errorf("failed to read bundle %s: %w", opts.path, err)
+	}
+	configDir, err := dir.ConfigFS().SysPath("")
+	if err != nil {
+		return err
+	}
+	plugins, err := installedPlugins(ctx)
+	if err != nil {
+		return err
+	}
+	host, err := configbundle.Collect(configDir, plugins)
+	if err != nil {
+		return fmt.Errorf("failed to collect the configuration: %w", err)
+	}
+	drifts := bundle.Diff(host)
+
+	if opts.verify {
+		if len(drifts) == 0 {
+			fmt.Printf("The host is configured identically to bundle %s\n", opts.path)
+			return nil
+		}
+		fmt.Printf("The host differs from bundle %s:\n", opts.path)
+		for _, drift := range drifts {
+			fmt.Printf("  %s\n", drift)
+		}
+		return fmt.Errorf("the host differs from bundle %s in %d items", opts.path, len(drifts))
+	}
+
+	var fileDrifts, pluginDrifts []configbundle.Drift
+	for _, drift := range drifts {
+		if drift.Kind == configbundle.KindPlugin {
+			pluginDrifts = append(pluginDrifts, drift)
+		} else {
+			fileDrifts = append(fileDrifts, drift)
+		}
+	}
+	if len(fileDrifts) == 0 {
+		fmt.Println("The configuration is unchanged.")
+	} else {
+		// an invalid trust policy must not replace a working one
+		if policyJSON, ok := bundle.Content(dir.PathTrustPolicy); ok {
+			if diagnostics := policyext.Diagnose(policyJSON); len(diagnostics) > 0 {
+				return fmt.Errorf("trust policy of bundle %s is invalid: %s", opts.path, diagnostics[0])
+			}
+		}
+		fmt.Println("Changes to the configuration:")
+		for _, drift := range fileDrifts {
+			fmt.Printf("  %s\n", drift)
+		}
+		confirmed, err := cmdutil.AskForConfirmation(os.Stdin, "Do you want to overwrite the configuration?", opts.confirmed)
+		if err != nil || !confirmed {
+			return err
+		}
+		if err := bundle.Apply(configDir); err != nil {
+			return fmt.Errorf("failed to import bundle %s: %w", opts.path, err)
+		}
+		fmt.Printf("Successfully imported %d configuration files from %s\n", len(bundle.Files), opts.path)
+	}
+	for _, drift := range pluginDrifts {
+		fmt.Fprintf(os.Stderr, "%s %s, plugins are not installed by importing a bundle\n", color.Warning(os.Stderr, "Warning:"), drift)
+	}
+	return nil
+}
+
+// installedPlugins returns the installed plugins with the SHA-256 digests of
+// their executables.
+func installedPlugins(ctx context.Context) ([]configbundle.Plugin, error) {
+	names, err := pluginproto.NewCLIManager(dir.PluginFS()).List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list plugins: %w", err)
+	}
+	var plugins []configbundle.Plugin
+	for _, name := range names {
+		binPath, err := dir.PluginFS().SysPath(path.Join(name, pluginBinaryName(name)))
+		if err != nil {
+			return nil, err
+		}
+		digest, err := sha256File(binPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash plugin %s: %w", name, err)
+		}
+		plugins = append(plugins, configbundle.Plugin{Name: name, SHA256: digest})
+	}
+	return plugins, nil
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	notationregistry "github.com/notaryproject/notation-go/registry"
+	"github.com/notaryproject/notation/internal/cmd"
+	"github.com/notaryproject/notation/internal/color"
+	"github.com/notaryproject/notation/internal/experimental"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/spf13/cobra"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/registry"
+)
+
+type copyOpts struct {
+	cmd.LoggingFlagOpts
+	SecureFlagOpts
+	reference        string
+	destination      string
+	signatureDigests []string
+}
+
+func copyCommand(opts *copyOpts) *cobra.Command {
+	if opts == nil {
+		opts = &copyOpts{}
+	}
+	command := &cobra.Command{
+		Use:   "copy [flags] <reference> <destination_repository>",
+		Short: "[Experimental] Copy the signatures of an artifact to another repository",
+		Long: `[Experimental] Copy the signatures of an artifact to another repository
+
+The signature manifests of the artifact and their blobs are copied as is, so that their digests and annotations are preserved. The artifact must already exist in the destination repository, e.g. copied by the promotion pipeline, as only its signatures are copied. Signatures already in the destination repository are not copied again.
+
+Example - Copy all the signatures of an artifact to another registry:
+  notation copy <registry>/<repository>@<digest> <destination_registry>/<destination_repository>
+
+Example - Copy the selected signatures of an artifact:
+  notation copy --signature-digest <signature_digest> <registry>/<repository>@<digest> <destination_registry>/<destination_repository>
+`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 2 {
+				return errors.New("expecting an artifact reference and a destination repository")
+			}
+			opts.reference = args[0]
+			opts.destination = args[1]
+			return nil
+		},
+		PreRunE: experimental.CheckCommandAndWarn,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.SecureFlagOpts.applyChangedFlags(cmd.Flags())
+			return runCopy(cmd.Context(), opts)
+		},
+	}
+	opts.LoggingFlagOpts.ApplyFlags(command.Flags())
+	opts.SecureFlagOpts.ApplyFlags(command.Flags())
+	command.Flags().StringArrayVar(&opts.signatureDigests, "signature-digest", nil, "digest of the signature manifest to copy, can be used multiple times. All the signatures are copied if not set")
+	return command
+}
+
+func runCopy(ctx context.Context, opts *copyOpts) error {
+	// set log level
+	ctx = opts.LoggingFlagOpts.SetLoggerLevel(ctx)
+
+	reference, err := expandAlias(inputTypeRegistry, opts.reference)
+	if err != nil {
+		return err
+	}
+	destination, err := expandAlias(inputTypeRegistry, opts.destination)
+	if err != nil {
+		return err
+	}
+	dstRef, err := registry.ParseReference(destination)
+	if err != nil {
+		return err
+	}
+	if dstRef.Reference != "" {
+		return fmt.Errorf("destination %s must be a repository without tag or digest", opts.destination)
+	}
+
+	ref, err := registry.ParseReference(reference)
+	if err != nil {
+		return err
+	}
+	srcRepo, err := getRepositoryClient(ctx, &opts.SecureFlagOpts, ref)
+	if err != nil {
+		return err
+	}
+	applyRegistryCapabilities(ctx, srcRepo)
+	sigRepo := notationregistry.NewRepository(srcRepo)
+	subject, resolvedRef, err := resolveReference(ctx, inputTypeRegistry, reference, sigRepo, func(ref string, manifestDesc ocispec.Descriptor) {
+		fmt.Fprintf(os.Stderr, "%s Always copy the signatures of an artifact using digest(@sha256:...) rather than a tag(:%s) because resolved digest may not point to the same signed artifact, as tags are mutable.\n", color.Warning(os.Stderr, "Warning:"), ref)
+	})
+	if err != nil {
+		return err
+	}
+
+	dstRef.Reference = subject.Digest.String()
+	dstRepo, err := getRepositoryClient(ctx, &opts.SecureFlagOpts, dstRef)
+	if err != nil {
+		return err
+	}
+	applyRegistryCapabilities(ctx, dstRepo)
+	// signatures of an artifact missing in the destination would be dangling
+	if exists, err := dstRepo.Exists(ctx, subject); err != nil {
+		return fmt.Errorf("failed to check artifact %s in the destination: %w", dstRef, err)
+	} else if !exists {
+		return fmt.Errorf("artifact %s does not exist, copy the artifact before its signatures", dstRef)
+	}
+
+	var signatures []ocispec.Descriptor
+	if err := sigRepo.ListSignatures(ctx, subject, func(signatureManifests []ocispec.Descriptor) error {
+		signatures = append(signatures, signatureManifests...)
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to list the signatures of %s: %w", resolvedRef, err)
+	}
+	signatures, err = selectSignatures(signatures, opts.signatureDigests)
+	if err != nil {
+		return err
+	}
+	if len(signatures) == 0 {
+		return fmt.Errorf("no signatures associated with %s", resolvedRef)
+	}
+
+	var copied int
+	for _, sigDesc := range signatures {
+		exists, err := copySignature(ctx, srcRepo, dstRepo, subject, sigDesc)
+		if err != nil {
+			return fmt.Errorf("failed to copy signature %s: %w", sigDesc.Digest, err)
+		}
+		if exists {
+			fmt.Printf("Skipped signature %s already in the destination\n", sigDesc.Digest)
+			continue
+		}
+		fmt.Printf("Copied signature %s\n", sigDesc.Digest)
+		copied++
+	}
+	fmt.Printf("Successfully copied %d signatures of %s to %s\n", copied, resolvedRef, dstRef)
+	return nil
+}
+
+// selectSignatures returns the signature manifests of signatures with the
+// digests, or all of them if digests is empty.
+func selectSignatures(signatures []ocispec.Descriptor, digests []string) ([]ocispec.Descriptor, error) {
+	if len(digests) == 0 {
+		return signatures, nil
+	}
+	index := make(map[digest.Digest]ocispec.Descriptor, len(signatures))
+	for _, sigDesc := range signatures {
+		index[sigDesc.Digest] = sigDesc
+	}
+	var selected []ocispec.Descriptor
+	seen := make(map[digest.Digest]bool)
+	for _, d := range digests {
+		sigDigest, err := digest.Parse(d)
+		if err != nil {
+			return nil, fmt.Errorf("invalid signature digest %q: %w", d, err)
+		}
+		sigDesc, ok := index[sigDigest]
+		if !ok {
+			return nil, fmt.Errorf("signature %s is not associated with the artifact", sigDigest)
+		}
+		if !seen[sigDigest] {
+			seen[sigDigest] = true
+			selected = append(selected, sigDesc)
+		}
+	}
+	return selected, nil
+}
+
+// copySignature copies the signature manifest sigDesc of subject and its
+// blobs from src to dst as is. The subject is not copied. It returns true
+// without copying if the signature manifest already exists in dst.
+func copySignature(ctx context.Context, src content.ReadOnlyStorage, dst content.Storage, subject, sigDesc ocispec.Descriptor) (bool, error) {
+	exists, err := dst.Exists(ctx, sigDesc)
+	if err != nil || exists {
+		return exists, err
+	}
+	return false, oras.CopyGraph(ctx, src, dst, sigDesc, oras.CopyGraphOptions{
+		FindSuccessors: func(ctx context.Context, fetcher content.Fetcher, desc ocispec.Descriptor) ([]ocispec.Descriptor, error) {
+			successors, err := content.Successors(ctx, fetcher, desc)
+			if err != nil {
+				return nil, err
+			}
+			var blobs []ocispec.Descriptor
+			for _, successor := range successors {
+				if successor.Digest != subject.Digest {
+					blobs = append(blobs, successor)
+				}
+			}
+			return blobs, nil
+		},
+	})
+}
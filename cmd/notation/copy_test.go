@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/content/memory"
+)
+
+func TestCopySignature(t *testing.T) {
+	ctx := context.Background()
+	push := func(storage content.Storage, mediaType string, data []byte) ocispec.Descriptor {
+		t.Helper()
+		desc := content.NewDescriptorFromBytes(mediaType, data)
+		if err := storage.Push(ctx, desc, bytes.NewReader(data)); err != nil {
+			t.Fatal(err)
+		}
+		return desc
+	}
+	src, dst := memory.New(), memory.New()
+	subjectJSON := []byte(`{"schemaVersion":2,"mediaType":"application/vnd.oci.image.manifest.v1+json","config":{"mediaType":"application/vnd.oci.empty.v1+json","digest":"sha256:44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a","size":2},"layers":[]}`)
+	subject := push(src, ocispec.MediaTypeImageManifest, subjectJSON)
+
+	configDesc := push(src, "application/vnd.cncf.notary.signature", []byte("{}"))
+	envelopeDesc := push(src, "application/jose+json", []byte("envelope"))
+	manifestJSON, err := json.Marshal(ocispec.Manifest{
+		Versioned:   specs.Versioned{SchemaVersion: 2},
+		MediaType:   ocispec.MediaTypeImageManifest,
+		Config:      configDesc,
+		Layers:      []ocispec.Descriptor{envelopeDesc},
+		Subject:     &subject,
+		Annotations: map[string]string{"io.cncf.notary.x509chain.thumbprint#S256": `["abc"]`},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sigDesc := push(src, ocispec.MediaTypeImageManifest, manifestJSON)
+
+	exists, err := copySignature(ctx, src, dst, subject, sigDesc)
+	if err != nil || exists {
+		t.Fatalf("copySignature() = %v, %v, want false, nil", exists, err)
+	}
+	// the signature manifest is copied as is, but not its subject
+	got, err := content.FetchAll(ctx, dst, sigDesc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, manifestJSON) {
+		t.Fatalf("copied signature manifest = %s, want %s", got, manifestJSON)
+	}
+	for _, desc := range []ocispec.Descriptor{configDesc, envelopeDesc} {
+		if ok, err := dst.Exists(ctx, desc); err != nil || !ok {
+			t.Fatalf("blob %s is not copied", desc.Digest)
+		}
+	}
+	if ok, _ := dst.Exists(ctx, subject); ok {
+		t.Fatal("subject is copied")
+	}
+
+	exists, err = copySignature(ctx, src, dst, subject, sigDesc)
+	if err != nil || !exists {
+		t.Fatalf("copySignature() = %v, %v, want true, nil", exists, err)
+	}
+}
+
+func TestSelectSignatures(t *testing.T) {
+	first := ocispec.Descriptor{Digest: digest.FromString("first")}
+	second := ocispec.Descriptor{Digest: digest.FromString("second")}
+	signatures := []ocispec.Descriptor{first, second}
+
+	if got, err := selectSignatures(signatures, nil); err != nil || !reflect.DeepEqual(got, signatures) {
+		t.Fatalf("selectSignatures() = %v, %v, want all signatures", got, err)
+	}
+	got, err := selectSignatures(signatures, []string{second.Digest.String(), second.Digest.String()})
+	if err != nil || !reflect.DeepEqual(got, []ocispec.Descriptor{second}) {
+		t.Fatalf("selectSignatures() = %v, %v, want the second signature", got, err)
+	}
+	if _, err := selectSignatures(signatures, []string{digest.FromString("other").String()}); err == nil {
+		t.Fatal("expected error for a signature not associated with the artifact")
+	}
+	if _, err := selectSignatures(signatures, []string{"sha256:abc"}); err == nil {
+		t.Fatal("expected error for an invalid digest")
+	}
+}
+
+func TestCopyCommand(t *testing.T) {
+	opts := &copyOpts{}
+	command := copyCommand(opts)
+	if err := command.ParseFlags([]string{"--signature-digest", "sha256:a", "--signature-digest", "sha256:b"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := command.Args(command, []string{"localhost:5000/net-monitor@sha256:abc", "localhost:5001/net-monitor"}); err != nil {
+		t.Fatal(err)
+	}
+	if opts.reference != "localhost:5000/net-monitor@sha256:abc" || opts.destination != "localhost:5001/net-monitor" || !reflect.DeepEqual(opts.signatureDigests, []string{"sha256:a", "sha256:b"}) {
+		t.Fatalf("unexpected copy opts %+v", opts)
+	}
+	if err := command.Args(command, []string{"localhost:5000/net-monitor@sha256:abc"}); err == nil {
+		t.Fatal("expected error for a missing destination")
+	}
+}
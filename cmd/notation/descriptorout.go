@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	notationregistry "github.com/notaryproject/notation-go/registry"
+	"github.com/notaryproject/notation/internal/osutil"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// annotationSignedTag is the annotation of the signature manifest recording
+// the tag the signed artifact was resolved from at signing time. It is not
+// signed, and only tells which tag the digest was signed under.
+const annotationSignedTag = "io.cncf.notary.x-signedTag"
+
+// signatureRecorder wraps a notationregistry.Repository and records the
+// descriptor of the last signature manifest pushed, which notation.Sign does
+// not return.
+type signatureRecorder struct {
+	notationregistry.Repository
+	manifestDesc ocispec.Descriptor
+
+	// annotations are added to the annotations of the signature manifests
+	// pushed.
+	annotations map[string]string
+}
+
+// PushSignature pushes a signature and records the descriptor of its
+// signature manifest.
+func (r *signatureRecorder) PushSignature(ctx context.Context, mediaType string, blob []byte, subject ocispec.Descriptor, annotations map[string]string) (blobDesc, manifestDesc ocispec.Descriptor, err error) {
+	if len(r.annotations) > 0 {
+		merged := make(map[string]string, len(annotations)+len(r.annotations))
+		for k, v := range r.annotations {
+			merged[k] = v
+		}
+		// the annotations of the signature take precedence
+		for k, v := range annotations {
+			merged[k] = v
+		}
+		annotations = merged
+	}
+	blobDesc, manifestDesc, err = r.Repository.PushSignature(ctx, mediaType, blob, subject, annotations)
+	if err == nil {
+		r.manifestDesc = manifestDesc
+	}
+	return blobDesc, manifestDesc, err
+}
+
+// signDescriptors is the output of flag "--descriptor-out" of notation sign,
+// pinning the signed artifact and its signature manifest.
+type signDescriptors struct {
+	// Reference is the reference of the signed artifact.
+	Reference string `json:"reference"`
+
+	// Subject is the descriptor of the signed artifact.
+	Subject ocispec.Descriptor `json:"subject"`
+
+	// Signature is the descriptor of the signature manifest referring to the
+	// subject.
+	Signature ocispec.Descriptor `json:"signature"`
+}
+
+// newSignDescriptors returns the descriptors of the subject and the signature
+// manifest, reduced to the fields identifying the manifests.
+func newSignDescriptors(reference string, subject, signature ocispec.Descriptor) signDescriptors {
+	pin := func(desc ocispec.Descriptor) ocispec.Descriptor {
+		return ocispec.Descriptor{
+			MediaType:    desc.MediaType,
+			Digest:       desc.Digest,
+			Size:         desc.Size,
+			ArtifactType: desc.ArtifactType,
+		}
+	}
+	if signature.ArtifactType == "" {
+		// signature manifests are identified by the notation artifact type
+		// regardless of the manifest type
+		signature.ArtifactType = notationregistry.ArtifactTypeNotation
+	}
+	return signDescriptors{
+		Reference: reference,
+		Subject:   pin(subject),
+		Signature: pin(signature),
+	}
+}
+
+// writeSignDescriptors writes the descriptors in JSON to path.
+func writeSignDescriptors(path string, descriptors signDescriptors) error {
+	descriptorsJSON, err := json.MarshalIndent(descriptors, "", "    ")
+	if err != nil {
+		return err
+	}
+	if err := osutil.WriteFile(path, append(descriptorsJSON, '\n')); err != nil {
+		return fmt.Errorf("failed to write descriptors: %w", err)
+	}
+	return nil
+}
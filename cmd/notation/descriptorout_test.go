@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	notationregistry "github.com/notaryproject/notation-go/registry"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+func TestWriteSignDescriptors(t *testing.T) {
+	subject := ocispec.Descriptor{
+		MediaType:    ocispec.MediaTypeImageManifest,
+		Digest:       digest.FromString("subject"),
+		Size:         528,
+		ArtifactType: ocispec.MediaTypeImageConfig,
+	}
+	signature := ocispec.Descriptor{
+		MediaType:   ocispec.MediaTypeImageManifest,
+		Digest:      digest.FromString("signature"),
+		Size:        733,
+		Annotations: map[string]string{"io.cncf.notary.x509chain.thumbprint#S256": "[]"},
+	}
+	path := filepath.Join(t.TempDir(), "signature.json")
+	reference := "localhost:5000/net-monitor@" + subject.Digest.String()
+	if err := writeSignDescriptors(path, newSignDescriptors(reference, subject, signature)); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{
+    "reference": "localhost:5000/net-monitor@` + subject.Digest.String() + `",
+    "subject": {
+        "mediaType": "application/vnd.oci.image.manifest.v1+json",
+        "digest": "` + subject.Digest.String() + `",
+        "size": 528,
+        "artifactType": "application/vnd.oci.image.config.v1+json"
+    },
+    "signature": {
+        "mediaType": "application/vnd.oci.image.manifest.v1+json",
+        "digest": "` + signature.Digest.String() + `",
+        "size": 733,
+        "artifactType": "` + notationregistry.ArtifactTypeNotation + `"
+    }
+}
+`
+	if string(got) != want {
+		t.Fatalf("writeSignDescriptors() wrote:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+// pushRecordingRepository records the annotations of the signatures pushed.
+type pushRecordingRepository struct {
+	notationregistry.Repository
+	annotations map[string]string
+}
+
+func (r *pushRecordingRepository) PushSignature(ctx context.Context, mediaType string, blob []byte, subject ocispec.Descriptor, annotations map[string]string) (ocispec.Descriptor, ocispec.Descriptor, error) {
+	r.annotations = annotations
+	return ocispec.Descriptor{}, ocispec.Descriptor{Digest: digest.FromString("signature")}, nil
+}
+
+func TestSignatureRecorder_Annotations(t *testing.T) {
+	repo := &pushRecordingRepository{}
+	recorder := &signatureRecorder{
+		Repository:  repo,
+		annotations: map[string]string{annotationSignedTag: "v1", "io.cncf.notary.x509chain.thumbprint#S256": "ignored"},
+	}
+	_, manifestDesc, err := recorder.PushSignature(context.Background(), "application/jose+json", nil, ocispec.Descriptor{}, map[string]string{"io.cncf.notary.x509chain.thumbprint#S256": "[]"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{annotationSignedTag: "v1", "io.cncf.notary.x509chain.thumbprint#S256": "[]"}
+	if !reflect.DeepEqual(repo.annotations, want) {
+		t.Fatalf("pushed annotations = %v, want %v", repo.annotations, want)
+	}
+	if !reflect.DeepEqual(recorder.manifestDesc, manifestDesc) {
+		t.Fatalf("recorded descriptor = %v, want %v", recorder.manifestDesc, manifestDesc)
+	}
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"archive/tar"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/notaryproject/notation-go/log"
+	"github.com/notaryproject/notation/internal/cmd"
+	"github.com/notaryproject/notation/internal/experimental"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/spf13/cobra"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/content/oci"
+)
+
+// ociImageIndexFile is the file name of the index in an OCI image layout.
+const ociImageIndexFile = "index.json"
+
+// tarMagicOffset is the offset of the magic of the ustar format in a tar
+// header block.
+const tarMagicOffset = 257
+
+type digestOpts struct {
+	cmd.LoggingFlagOpts
+	target    string
+	ociLayout bool
+	reference string
+}
+
+func digestCommand(opts *digestOpts) *cobra.Command {
+	if opts == nil {
+		opts = &digestOpts{}
+	}
+	command := &cobra.Command{
+		Use:   "digest [flags] <path>",
+		Short: "Compute the manifest digest of a local artifact",
+		Long: `Compute the OCI manifest digest of a local artifact, so that the reference to sign or verify is known without pushing the artifact first
+
+The path is either a raw manifest file or a tarball of an OCI image layout, e.g. created by "docker save" of Docker 25 or later.
+
+Example - Compute the digest of a raw manifest file:
+  notation digest manifest.json
+
+Example - Compute the digest of the only image in a tarball:
+  notation digest image.tar
+
+Example - Compute the digest of the image tagged "v1" in a tarball:
+  notation digest --reference v1 image.tar
+
+Example - [Experimental] Compute the digest of the image tagged "v1" in an OCI layout folder:
+  export NOTATION_EXPERIMENTAL=1
+  notation digest --oci-layout hello-world:v1
+`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return errors.New("missing path to the artifact")
+			}
+			opts.target = args[0]
+			return nil
+		},
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if opts.ociLayout && opts.reference != "" {
+				return errors.New("--reference cannot be used with --oci-layout, use <path>[:<tag>|@<digest>] instead")
+			}
+			return experimental.CheckFlagsAndWarn(cmd, "oci-layout")
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDigest(cmd.Context(), opts)
+		},
+	}
+	opts.LoggingFlagOpts.ApplyFlags(command.Flags())
+	command.Flags().BoolVar(&opts.ociLayout, "oci-layout", false, "[Experimental] compute the digest of an artifact stored in OCI image layout, referenced by <path>[:<tag>|@<digest>]")
+	command.Flags().StringVar(&opts.reference, "reference", "", "tag or digest of the manifest in a tarball, required if the tarball contains more than one manifest")
+	experimental.HideFlags(command, "oci-layout")
+	return command
+}
+
+func runDigest(ctx context.Context, opts *digestOpts) error {
+	// set log level
+	ctx = opts.LoggingFlagOpts.SetLoggerLevel(ctx)
+
+	var desc ocispec.Descriptor
+	var err error
+	if opts.ociLayout {
+		desc, err = digestOCILayout(ctx, opts.target)
+	} else {
+		desc, err = digestFile(ctx, opts.target, opts.reference)
+	}
+	if err != nil {
+		return err
+	}
+	log.GetLogger(ctx).Infof("Computed manifest descriptor: %+v", desc)
+	fmt.Println(desc.Digest)
+	return nil
+}
+
+// digestOCILayout resolves the manifest descriptor of an artifact stored in
+// OCI image layout, referenced by <path>[:<tag>|@<digest>].
+func digestOCILayout(ctx context.Context, reference string) (ocispec.Descriptor, error) {
+	layoutPath, layoutReference, err := parseOCILayoutReference(reference)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	info, err := os.Stat(layoutPath)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	if !info.IsDir() {
+		return ocispec.Descriptor{}, fmt.Errorf("%s is not a directory, use the tarball without --oci-layout", layoutPath)
+	}
+	store, err := oci.NewFromFS(ctx, os.DirFS(layoutPath))
+	if err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("failed to read OCI image layout %s: %w", layoutPath, err)
+	}
+	return resolveLocalManifest(ctx, store, layoutReference)
+}
+
+// digestFile computes the manifest digest of a raw manifest file or resolves
+// the manifest descriptor of reference in a tarball of an OCI image layout.
+func digestFile(ctx context.Context, path, reference string) (ocispec.Descriptor, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	if info.IsDir() {
+		return ocispec.Descriptor{}, fmt.Errorf("%s is a directory, use --oci-layout for an OCI layout folder", path)
+	}
+	isTar, err := isTarball(path)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	if !isTar {
+		if reference != "" {
+			return ocispec.Descriptor{}, errors.New("--reference can only be used with a tarball")
+		}
+		return digestManifest(path)
+	}
+
+	store, err := oci.NewFromTar(ctx, path)
+	if err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("failed to read OCI image layout from tarball %s: %w. Tarballs created by \"docker save\" before Docker 25 contain no OCI image layout, the manifest digest of such images is only determined when pushed to a registry", path, err)
+	}
+	if reference == "" {
+		if reference, err = soleManifestReference(path); err != nil {
+			return ocispec.Descriptor{}, err
+		}
+	}
+	return resolveLocalManifest(ctx, store, reference)
+}
+
+// digestManifest computes the digest of the raw manifest file at path.
+func digestManifest(path string) (ocispec.Descriptor, error) {
+	manifestJSON, err := os.ReadFile(path)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	var manifest struct {
+		MediaType     string `json:"mediaType"`
+		SchemaVersion int    `json:"schemaVersion"`
+	}
+	if err := json.Unmarshal(manifestJSON, &manifest); err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("%s is neither a manifest nor a tarball: %w", path, err)
+	}
+	if manifest.SchemaVersion != 2 {
+		return ocispec.Descriptor{}, fmt.Errorf("%s is not a manifest: unsupported schemaVersion %d", path, manifest.SchemaVersion)
+	}
+	return ocispec.Descriptor{
+		MediaType: manifest.MediaType,
+		Digest:    digest.FromBytes(manifestJSON),
+		Size:      int64(len(manifestJSON)),
+	}, nil
+}
+
+// soleManifestReference returns the digest of the only manifest in the index
+// of the OCI image layout archived in the tarball at path.
+func soleManifestReference(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	tr := tar.NewReader(f)
+	for {
+		header, err := tr.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return "", fmt.Errorf("no %s found in the tarball", ociImageIndexFile)
+			}
+			return "", err
+		}
+		if strings.TrimPrefix(header.Name, "./") != ociImageIndexFile {
+			continue
+		}
+		var index ocispec.Index
+		if err := json.NewDecoder(tr).Decode(&index); err != nil {
+			return "", fmt.Errorf("malformed %s in the tarball: %w", ociImageIndexFile, err)
+		}
+		switch len(index.Manifests) {
+		case 0:
+			return "", errors.New("no manifest found in the tarball")
+		case 1:
+			return index.Manifests[0].Digest.String(), nil
+		default:
+			return "", fmt.Errorf("found %d manifests in the tarball, use --reference to select one", len(index.Manifests))
+		}
+	}
+}
+
+// resolveLocalManifest resolves reference in store and verifies the content
+// of the manifest against the resolved descriptor.
+func resolveLocalManifest(ctx context.Context, store *oci.ReadOnlyStore, reference string) (ocispec.Descriptor, error) {
+	desc, err := store.Resolve(ctx, reference)
+	if err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("failed to resolve reference %q: %w", reference, err)
+	}
+	rc, err := store.Fetch(ctx, desc)
+	if err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("failed to fetch manifest %s: %w", desc.Digest, err)
+	}
+	defer rc.Close()
+	if _, err := content.ReadAll(rc, desc); err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("failed to verify manifest %s: %w", desc.Digest, err)
+	}
+	return desc, nil
+}
+
+// isTarball returns true if the file at path is in the ustar format.
+func isTarball(path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	header := make([]byte, tarMagicOffset+5)
+	if _, err := io.ReadFull(f, header); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+			return false, nil
+		}
+		return false, err
+	}
+	return string(header[tarMagicOffset:]) == "ustar", nil
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"archive/tar"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+const testManifest = `{"schemaVersion":2,"mediaType":"application/vnd.oci.image.manifest.v1+json","config":{"mediaType":"application/vnd.oci.image.config.v1+json","digest":"sha256:44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a","size":2},"layers":[]}`
+
+// writeTestLayout writes an OCI image layout with the manifests tagged as v1,
+// v2, ... to a new directory and returns the path of the directory.
+func writeTestLayout(t *testing.T, manifests ...string) string {
+	t.Helper()
+	layoutPath := t.TempDir()
+	files := map[string][]byte{
+		ocispec.ImageLayoutFile: []byte(`{"imageLayoutVersion":"1.0.0"}`),
+	}
+	index := ocispec.Index{Manifests: []ocispec.Descriptor{}}
+	index.SchemaVersion = 2
+	for i, manifest := range manifests {
+		desc := ocispec.Descriptor{
+			MediaType: ocispec.MediaTypeImageManifest,
+			Digest:    digest.FromString(manifest),
+			Size:      int64(len(manifest)),
+			Annotations: map[string]string{
+				ocispec.AnnotationRefName: "v" + string(rune('1'+i)),
+			},
+		}
+		index.Manifests = append(index.Manifests, desc)
+		files[filepath.Join("blobs", "sha256", desc.Digest.Encoded())] = []byte(manifest)
+	}
+	indexJSON, err := json.Marshal(index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files["index.json"] = indexJSON
+	for name, content := range files {
+		path := filepath.Join(layoutPath, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, content, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return layoutPath
+}
+
+// writeTestTarball archives the OCI image layout at layoutPath.
+func writeTestTarball(t *testing.T, layoutPath string) string {
+	t.Helper()
+	tarPath := filepath.Join(t.TempDir(), "image.tar")
+	f, err := os.Create(tarPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	tw := tar.NewWriter(f)
+	err = filepath.Walk(layoutPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		name, err := filepath.Rel(layoutPath, path)
+		if err != nil {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if err := tw.WriteHeader(&tar.Header{Name: filepath.ToSlash(name), Mode: 0600, Size: int64(len(content))}); err != nil {
+			return err
+		}
+		_, err = tw.Write(content)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return tarPath
+}
+
+func TestDigestCommand_BasicArgs(t *testing.T) {
+	opts := &digestOpts{}
+	command := digestCommand(opts)
+	expected := &digestOpts{
+		target:    "image.tar",
+		reference: "v1",
+	}
+	if err := command.ParseFlags([]string{
+		expected.target,
+		"--reference", expected.reference}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := command.Args(command, command.Flags().Args()); err != nil {
+		t.Fatalf("Parse Args failed: %v", err)
+	}
+	if *opts != *expected {
+		t.Fatalf("Expect digest opts: %v, got: %v", expected, opts)
+	}
+}
+
+func TestDigestCommand_MissingArgs(t *testing.T) {
+	command := digestCommand(nil)
+	if err := command.ParseFlags(nil); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := command.Args(command, command.Flags().Args()); err == nil {
+		t.Fatal("Parse Args expected error, but ok")
+	}
+}
+
+func TestDigestFile(t *testing.T) {
+	ctx := context.Background()
+	want := digest.FromString(testManifest)
+
+	t.Run("raw manifest", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "manifest.json")
+		if err := os.WriteFile(path, []byte(testManifest), 0600); err != nil {
+			t.Fatal(err)
+		}
+		desc, err := digestFile(ctx, path, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if desc.Digest != want || desc.MediaType != ocispec.MediaTypeImageManifest {
+			t.Fatalf("expected digest %s, got %+v", want, desc)
+		}
+	})
+
+	t.Run("not a manifest", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.json")
+		if err := os.WriteFile(path, []byte(`{"architecture":"amd64"}`), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := digestFile(ctx, path, ""); err == nil {
+			t.Fatal("expected error, got nil")
+		}
+	})
+
+	t.Run("tarball with a single manifest", func(t *testing.T) {
+		tarPath := writeTestTarball(t, writeTestLayout(t, testManifest))
+		desc, err := digestFile(ctx, tarPath, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if desc.Digest != want {
+			t.Fatalf("expected digest %s, got %s", want, desc.Digest)
+		}
+	})
+
+	t.Run("tarball with multiple manifests", func(t *testing.T) {
+		other := `{"schemaVersion":2,"mediaType":"application/vnd.oci.image.manifest.v1+json","config":{"mediaType":"application/vnd.oci.image.config.v1+json","digest":"sha256:44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a","size":2},"layers":[],"annotations":{"a":"b"}}`
+		tarPath := writeTestTarball(t, writeTestLayout(t, other, testManifest))
+		if _, err := digestFile(ctx, tarPath, ""); err == nil {
+			t.Fatal("expected error, got nil")
+		}
+		desc, err := digestFile(ctx, tarPath, "v2")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if desc.Digest != want {
+			t.Fatalf("expected digest %s, got %s", want, desc.Digest)
+		}
+	})
+
+	t.Run("directory", func(t *testing.T) {
+		if _, err := digestFile(ctx, writeTestLayout(t, testManifest), ""); err == nil {
+			t.Fatal("expected error, got nil")
+		}
+	})
+}
+
+func TestDigestOCILayout(t *testing.T) {
+	layoutPath := writeTestLayout(t, testManifest)
+	desc, err := digestOCILayout(context.Background(), layoutPath+":v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := digest.FromString(testManifest); desc.Digest != want {
+		t.Fatalf("expected digest %s, got %s", want, desc.Digest)
+	}
+
+	// tampered manifest content is detected
+	if err := os.WriteFile(filepath.Join(layoutPath, "blobs", "sha256", desc.Digest.Encoded()), []byte(testManifest+" "), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := digestOCILayout(context.Background(), layoutPath+":v1"); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"os"
+
+	"github.com/notaryproject/notation/cmd/notation/internal/dockerplugin"
+	"github.com/notaryproject/notation/internal/ioutil"
+	"github.com/notaryproject/notation/internal/version"
+	"github.com/spf13/cobra"
+)
+
+// dockerPluginMode is true if notation is invoked as the Docker CLI plugin.
+var dockerPluginMode bool
+
+// enableDockerPluginMode sets up the root command to run as the Docker CLI
+// plugin. References in the short form accepted by the Docker CLI are
+// expanded and the credentials saved by the Docker CLI are used.
+func enableDockerPluginMode(root *cobra.Command, args []string) {
+	dockerPluginMode = true
+	root.Use = "docker " + dockerplugin.PluginName
+	root.SetArgs(dockerplugin.PluginArgs(args))
+	root.AddCommand(&cobra.Command{
+		Use:    dockerplugin.MetadataSubcommandName,
+		Hidden: true,
+		Args:   cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return ioutil.PrintObjectAsJSON(dockerplugin.NewMetadata(version.GetVersion()))
+		},
+	})
+	for _, command := range root.Commands() {
+		switch command.Name() {
+		case "sign", "verify", "list", "inspect":
+			normalizeReferenceArg(command)
+		}
+	}
+}
+
+// normalizeReferenceArg expands the reference argument of the command before
+// the argument is validated. References to OCI layouts are kept unchanged.
+func normalizeReferenceArg(command *cobra.Command) {
+	validateArgs := command.Args
+	command.Args = func(cmd *cobra.Command, args []string) error {
+		if len(args) > 0 && !isOCILayoutFlagSet(cmd) {
+			args[0] = dockerplugin.NormalizeReference(args[0])
+		}
+		if validateArgs == nil {
+			return nil
+		}
+		return validateArgs(cmd, args)
+	}
+}
+
+func isOCILayoutFlagSet(cmd *cobra.Command) bool {
+	flag := cmd.Flags().Lookup("oci-layout")
+	return flag != nil && flag.Changed
+}
+
+// isDockerPluginInvocation returns true if the running executable is the
+// Docker CLI plugin.
+func isDockerPluginInvocation() bool {
+	return len(os.Args) > 0 && dockerplugin.IsPluginInvocation(os.Args[0])
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/notaryproject/notation/internal/cmd"
+	"github.com/notaryproject/notation/internal/ioutil"
+	"github.com/notaryproject/notation/internal/policy"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// dryRunOutput is the output of notation verify --dry-run in JSON.
+type dryRunOutput struct {
+	Reference    string `json:"reference"`
+	Digest       string `json:"digest"`
+	MediaType    string `json:"mediaType,omitempty"`
+	ArtifactType string `json:"artifactType,omitempty"`
+
+	// TrustPolicy is the trust policy statement which would be applied.
+	TrustPolicy dryRunPolicyOutput `json:"trustPolicy"`
+}
+
+// dryRunPolicyOutput is the trust policy statement applicable to an artifact
+// and its settings.
+type dryRunPolicyOutput struct {
+	Name                 string                    `json:"name"`
+	MatchedScope         string                    `json:"matchedScope"`
+	ExcludeScopes        []string                  `json:"excludeScopes,omitempty"`
+	ArtifactTypes        []string                  `json:"artifactTypes,omitempty"`
+	VerificationLevel    string                    `json:"verificationLevel"`
+	Checks               []verificationCheckOutput `json:"checks,omitempty"`
+	TrustStores          []string                  `json:"trustStores,omitempty"`
+	TrustedIdentities    []string                  `json:"trustedIdentities,omitempty"`
+	ClockSkewTolerance   string                    `json:"clockSkewTolerance,omitempty"`
+	SignatureAnnotations []string                  `json:"signatureAnnotations,omitempty"`
+	PluginMinVersions    map[string]string         `json:"pluginMinVersions,omitempty"`
+}
+
+// runVerifyDryRun prints how the resolved artifact would be verified, i.e.
+// the applicable trust policy statement, its trust stores and the checks of
+// its verification level, without fetching any signature. intendedRef is the
+// reference selecting the statement.
+func runVerifyDryRun(opts *verifyOpts, policyVerifier *policy.Verifier, manifestDesc ocispec.Descriptor, resolvedRef, intendedRef string) error {
+	explanation, err := policyVerifier.Explain(manifestDesc, intendedRef)
+	if err != nil {
+		return fmt.Errorf("no trust policy statement would be applied to %s: %w", resolvedRef, err)
+	}
+	output := dryRunOutput{
+		Reference:    resolvedRef,
+		Digest:       manifestDesc.Digest.String(),
+		MediaType:    manifestDesc.MediaType,
+		ArtifactType: manifestDesc.ArtifactType,
+		TrustPolicy: dryRunPolicyOutput{
+			Name:                 explanation.Statement,
+			MatchedScope:         explanation.MatchedScope,
+			ExcludeScopes:        explanation.Extension.ExcludeScopes,
+			ArtifactTypes:        explanation.Extension.ArtifactTypes,
+			VerificationLevel:    explanation.VerificationLevel,
+			TrustStores:          explanation.TrustStores,
+			TrustedIdentities:    explanation.TrustedIdentities,
+			SignatureAnnotations: explanation.Extension.SignatureAnnotations,
+			PluginMinVersions:    explanation.Extension.PluginMinVersions,
+		},
+	}
+	for _, check := range explanation.Checks {
+		output.TrustPolicy.Checks = append(output.TrustPolicy.Checks, verificationCheckOutput{
+			Type:   string(check.Type),
+			Action: string(check.Action),
+		})
+	}
+	if explanation.ClockSkewTolerance > 0 {
+		output.TrustPolicy.ClockSkewTolerance = explanation.ClockSkewTolerance.String()
+	}
+	if opts.outputFormat == cmd.OutputJSON {
+		return ioutil.PrintObjectAsJSON(output)
+	}
+	return printDryRun(os.Stdout, output)
+}
+
+// printDryRun prints the output of notation verify --dry-run in text.
+func printDryRun(w io.Writer, output dryRunOutput) error {
+	statement := output.TrustPolicy
+	fmt.Fprintln(w, "Dry run of the verification of", output.Reference)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Trust policy statement:\t%s\n", statement.Name)
+	fmt.Fprintf(tw, "Matched registry scope:\t%s\n", statement.MatchedScope)
+	if len(statement.ExcludeScopes) > 0 {
+		fmt.Fprintf(tw, "Excluded scopes:\t%s\n", strings.Join(statement.ExcludeScopes, ", "))
+	}
+	if len(statement.ArtifactTypes) > 0 {
+		fmt.Fprintf(tw, "Artifact type:\t%s\n", output.ArtifactType)
+	}
+	fmt.Fprintf(tw, "Verification level:\t%s\n", statement.VerificationLevel)
+	if len(statement.Checks) == 0 {
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+		_, err := fmt.Fprintln(w, "Signature verification would be skipped, no signature is fetched.")
+		return err
+	}
+	fmt.Fprintf(tw, "Trust stores:\t%s\n", strings.Join(statement.TrustStores, ", "))
+	fmt.Fprintf(tw, "Trusted identities:\t%s\n", strings.Join(statement.TrustedIdentities, "; "))
+	if statement.ClockSkewTolerance != "" {
+		fmt.Fprintf(tw, "Clock skew tolerance:\t%s\n", statement.ClockSkewTolerance)
+	}
+	if len(statement.SignatureAnnotations) > 0 {
+		fmt.Fprintf(tw, "Signature annotations:\t%s\n", strings.Join(statement.SignatureAnnotations, ", "))
+	}
+	plugins := make([]string, 0, len(statement.PluginMinVersions))
+	for name := range statement.PluginMinVersions {
+		plugins = append(plugins, name)
+	}
+	sort.Strings(plugins)
+	for _, name := range plugins {
+		fmt.Fprintf(tw, "Plugin minimum version:\t%s %s\n", name, statement.PluginMinVersions[name])
+	}
+	fmt.Fprintln(tw, "Checks:")
+	for _, check := range statement.Checks {
+		fmt.Fprintf(tw, "  %s\t%s\n", check.Type, check.Action)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(w, "No signature is fetched or verified in a dry run.")
+	return err
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPrintDryRun(t *testing.T) {
+	output := dryRunOutput{
+		Reference: "localhost:5000/net-monitor@sha256:b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9",
+		Digest:    "sha256:b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9",
+		TrustPolicy: dryRunPolicyOutput{
+			Name:              "wabbit-networks-images",
+			MatchedScope:      "localhost:5000/net-monitor",
+			VerificationLevel: "strict",
+			Checks: []verificationCheckOutput{
+				{Type: "integrity", Action: "enforce"},
+				{Type: "authenticity", Action: "enforce"},
+				{Type: "authenticTimestamp", Action: "enforce"},
+				{Type: "expiry", Action: "enforce"},
+				{Type: "revocation", Action: "enforce"},
+			},
+			TrustStores:       []string{"ca:wabbit-networks"},
+			TrustedIdentities: []string{"*"},
+			PluginMinVersions: map[string]string{"com.example.plugin": "1.2.0"},
+		},
+	}
+	var buf bytes.Buffer
+	if err := printDryRun(&buf, output); err != nil {
+		t.Fatal(err)
+	}
+	want := `Dry run of the verification of localhost:5000/net-monitor@sha256:b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9
+Trust policy statement:  wabbit-networks-images
+Matched registry scope:  localhost:5000/net-monitor
+Verification level:      strict
+Trust stores:            ca:wabbit-networks
+Trusted identities:      *
+Plugin minimum version:  com.example.plugin 1.2.0
+Checks:
+  integrity           enforce
+  authenticity        enforce
+  authenticTimestamp  enforce
+  expiry              enforce
+  revocation          enforce
+No signature is fetched or verified in a dry run.
+`
+	if got := buf.String(); got != want {
+		t.Fatalf("printDryRun() =\n%s\nwant\n%s", got, want)
+	}
+
+	// skipped verifications have no checks
+	output.TrustPolicy = dryRunPolicyOutput{Name: "unsigned", MatchedScope: "*", VerificationLevel: "skip"}
+	buf.Reset()
+	if err := printDryRun(&buf, output); err != nil {
+		t.Fatal(err)
+	}
+	want = `Dry run of the verification of localhost:5000/net-monitor@sha256:b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9
+Trust policy statement:  unsigned
+Matched registry scope:  *
+Verification level:      skip
+Signature verification would be skipped, no signature is fetched.
+`
+	if got := buf.String(); got != want {
+		t.Fatalf("printDryRun() =\n%s\nwant\n%s", got, want)
+	}
+}
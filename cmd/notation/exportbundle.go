@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/notaryproject/notation/internal/cmd"
+	"github.com/notaryproject/notation/internal/color"
+	"github.com/notaryproject/notation/internal/experimental"
+	"github.com/notaryproject/notation/internal/offlinebundle"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/spf13/cobra"
+	"oras.land/oras-go/v2/content"
+)
+
+type exportBundleOpts struct {
+	cmd.LoggingFlagOpts
+	SecureFlagOpts
+	reference string
+	output    string
+	force     bool
+}
+
+func exportBundleCommand(opts *exportBundleOpts) *cobra.Command {
+	if opts == nil {
+		opts = &exportBundleOpts{}
+	}
+	command := &cobra.Command{
+		Use:   "export-bundle [flags] <reference>",
+		Short: "[Experimental] Export an artifact and its signatures to a bundle for offline verification",
+		Long: `[Experimental] Export an artifact and its signatures to a bundle for offline verification
+
+The bundle packages the descriptor and the manifest of the artifact, all its signature manifests and signature envelopes, and the certificate chains of the signatures into a portable archive. The signatures are verified with "notation verify --bundle" without registry access, e.g. in air-gapped environments.
+
+Example - Export an artifact and its signatures to a bundle:
+  notation export-bundle -o net-monitor.bundle.tgz <registry>/<repository>@<digest>
+
+Example - Verify the signatures of the bundle without registry access:
+  notation verify --bundle net-monitor.bundle.tgz
+`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return errors.New("expecting one artifact reference")
+			}
+			opts.reference = args[0]
+			return nil
+		},
+		PreRunE: experimental.CheckCommandAndWarn,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.SecureFlagOpts.applyChangedFlags(cmd.Flags())
+			return runExportBundle(cmd.Context(), opts)
+		},
+	}
+	opts.LoggingFlagOpts.ApplyFlags(command.Flags())
+	opts.SecureFlagOpts.ApplyFlags(command.Flags())
+	command.Flags().StringVarP(&opts.output, "output", "o", "", "path of the bundle to write")
+	command.Flags().BoolVar(&opts.force, "force", false, "overwrite the existing bundle")
+	command.MarkFlagRequired("output")
+	return command
+}
+
+func runExportBundle(ctx context.Context, opts *exportBundleOpts) error {
+	// set log level
+	ctx = opts.LoggingFlagOpts.SetLoggerLevel(ctx)
+
+	reference, err := expandAlias(inputTypeRegistry, opts.reference)
+	if err != nil {
+		return err
+	}
+	sigRepo, err := getRemoteRepository(ctx, &opts.SecureFlagOpts, reference)
+	if err != nil {
+		return err
+	}
+	manifestFetcher, err := getManifestFetcher(ctx, inputTypeRegistry, reference, &opts.SecureFlagOpts)
+	if err != nil {
+		return err
+	}
+	subject, resolvedRef, err := resolveReference(ctx, inputTypeRegistry, reference, sigRepo, func(ref string, manifestDesc ocispec.Descriptor) {
+		fmt.Fprintf(os.Stderr, "%s Always export an artifact using digest(@sha256:...) rather than a tag(:%s) because resolved digest may not point to the same signed artifact, as tags are mutable.\n", color.Warning(os.Stderr, "Warning:"), ref)
+	})
+	if err != nil {
+		return err
+	}
+	manifest, err := content.FetchAll(ctx, manifestFetcher, subject)
+	if err != nil {
+		return fmt.Errorf("failed to fetch the manifest of %s: %w", resolvedRef, err)
+	}
+	bundle, err := offlinebundle.New(resolvedRef, subject, manifest)
+	if err != nil {
+		return err
+	}
+	err = sigRepo.ListSignatures(ctx, subject, func(signatureManifests []ocispec.Descriptor) error {
+		for _, sigManifestDesc := range signatureManifests {
+			sigManifest, err := content.FetchAll(ctx, manifestFetcher, sigManifestDesc)
+			if err != nil {
+				return fmt.Errorf("failed to fetch signature manifest %s: %w", sigManifestDesc.Digest, err)
+			}
+			sigBlob, sigDesc, err := sigRepo.FetchSignatureBlob(ctx, sigManifestDesc)
+			if err != nil {
+				return fmt.Errorf("failed to fetch the signature envelope of signature manifest %s: %w", sigManifestDesc.Digest, err)
+			}
+			if err := bundle.AddSignature(sigManifestDesc, sigManifest, sigDesc, sigBlob); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(bundle.Signatures) == 0 {
+		return fmt.Errorf("no signatures associated with %s", resolvedRef)
+	}
+
+	flag := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if opts.force {
+		flag = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	file, err := os.OpenFile(opts.output, flag, 0644)
+	if err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return fmt.Errorf("bundle %s already exists, use flag \"--force\" to overwrite it", opts.output)
+		}
+		return err
+	}
+	if err := bundle.Write(file, time.Now()); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	fmt.Printf("Successfully exported %s and %d signatures to %s\n", resolvedRef, len(bundle.Signatures), opts.output)
+	return nil
+}
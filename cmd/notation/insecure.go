@@ -0,0 +1,74 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/notaryproject/notation-go/dir"
+	"github.com/notaryproject/notation/pkg/configutil"
+)
+
+// isLoopbackRegistry returns true if the registry is on the local host, whose
+// traffic does not leave the host, e.g. "localhost:5000" or "127.0.0.1:5000".
+func isLoopbackRegistry(registry string) bool {
+	host := registry
+	if h, _, err := net.SplitHostPort(registry); err == nil {
+		host = h
+	}
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(strings.Trim(host, "[]"))
+	return ip != nil && ip.IsLoopback()
+}
+
+// resolveInsecureAccess returns whether the registry is accessed via plain
+// HTTP, and checks that the insecure accesses requested by the flags of opts
+// are allowed for the registry. Registries other than loopback registries must
+// be in the insecure registry allow-list of config.json to be accessed via
+// plain HTTP or without verifying their TLS certificates, and such accesses
+// are warned about.
+func resolveInsecureAccess(opts *SecureFlagOpts, registry string) (bool, error) {
+	// registries in "insecureRegistries" of config.json are explicitly
+	// configured for plain HTTP
+	configuredPlainHTTP := configutil.IsRegistryInsecure(registry)
+	plainHTTP := opts.PlainHTTP || configuredPlainHTTP
+	if !plainHTTP {
+		if host, _, _ := net.SplitHostPort(registry); host == "localhost" {
+			plainHTTP = true
+		}
+	}
+	if isLoopbackRegistry(registry) {
+		return plainHTTP, nil
+	}
+	if (opts.PlainHTTP && !configuredPlainHTTP) || opts.InsecureRegistry {
+		cliConfig, err := configutil.LoadCLIConfigOnce()
+		if err != nil {
+			return false, fmt.Errorf("failed to load %s: %w", dir.PathConfigFile, err)
+		}
+		if !cliConfig.AllowsInsecureRegistry(registry) {
+			flagName := flagPlainHTTP.Name
+			if opts.InsecureRegistry {
+				flagName = flagInsecureRegistry.Name
+			}
+			return false, fmt.Errorf("flag %q is not allowed for registry %q, add the registry to \"insecureRegistryAllowList\" of %s to access it insecurely", "--"+flagName, registry, dir.PathConfigFile)
+		}
+	}
+	if plainHTTP {
+		warnf("registry %s is accessed via plain HTTP, the credentials, artifacts and signatures can be read and modified in transit", registry)
+	} else if opts.InsecureRegistry {
+		warnf("registry %s is accessed without verifying its TLS certificate, the credentials, artifacts and signatures can be read and modified in transit", registry)
+	}
+	return plainHTTP, nil
+}
+
+// newInsecureHTTPClient returns an HTTP client not verifying the TLS
+// certificates of the servers.
+func newInsecureHTTPClient() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	return &http.Client{Transport: transport}
+}
@@ -0,0 +1,64 @@
+package main
+
+import "testing"
+
+func TestIsLoopbackRegistry(t *testing.T) {
+	tests := []struct {
+		registry string
+		want     bool
+	}{
+		{registry: "localhost", want: true},
+		{registry: "localhost:5000", want: true},
+		{registry: "127.0.0.1:5000", want: true},
+		{registry: "[::1]:5000", want: true},
+		{registry: "registry.example.com", want: false},
+		{registry: "10.0.0.1:5000", want: false},
+		{registry: "localhost.example.com:5000", want: false},
+	}
+	for _, tt := range tests {
+		if got := isLoopbackRegistry(tt.registry); got != tt.want {
+			t.Errorf("isLoopbackRegistry(%q) = %v, want %v", tt.registry, got, tt.want)
+		}
+	}
+}
+
+func TestResolveInsecureAccess_Loopback(t *testing.T) {
+	defer func() { printedWarnings = warningLog{} }()
+	printedWarnings = warningLog{}
+
+	plainHTTP, err := resolveInsecureAccess(&SecureFlagOpts{PlainHTTP: true}, "127.0.0.1:5000")
+	if err != nil {
+		t.Fatalf("resolveInsecureAccess() error = %v", err)
+	}
+	if !plainHTTP {
+		t.Fatal("resolveInsecureAccess() = false, want true")
+	}
+	plainHTTP, err = resolveInsecureAccess(&SecureFlagOpts{}, "localhost:5000")
+	if err != nil {
+		t.Fatalf("resolveInsecureAccess() error = %v", err)
+	}
+	if !plainHTTP {
+		t.Fatal("resolveInsecureAccess() = false for localhost, want true")
+	}
+	// traffic to loopback registries does not leave the host
+	if got := printedWarnings.list(); len(got) != 0 {
+		t.Fatalf("warnings = %v, want no warnings", got)
+	}
+}
+
+func TestWarnf(t *testing.T) {
+	defer func() { printedWarnings = warningLog{} }()
+	printedWarnings = warningLog{}
+
+	warnf("registry %s is accessed via plain HTTP", "registry.example.com")
+	warnf("registry %s is accessed via plain HTTP", "registry.example.com")
+	warnf("registry %s is accessed via plain HTTP", "mirror.example.com")
+	want := []string{
+		"registry registry.example.com is accessed via plain HTTP",
+		"registry mirror.example.com is accessed via plain HTTP",
+	}
+	got := printedWarnings.list()
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Fatalf("warnings = %v, want %v", got, want)
+	}
+}
@@ -1,7 +1,10 @@
 package main
 
 import (
+	"context"
 	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
 	b64 "encoding/base64"
 	"encoding/hex"
 	"errors"
@@ -12,26 +15,46 @@ import (
 	"time"
 
 	"github.com/notaryproject/notation-core-go/signature"
+	"github.com/notaryproject/notation-go"
 	"github.com/notaryproject/notation-go/plugin/proto"
 	"github.com/notaryproject/notation-go/registry"
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+	"github.com/notaryproject/notation/internal/archive"
 	"github.com/notaryproject/notation/internal/cmd"
+	"github.com/notaryproject/notation/internal/color"
 	"github.com/notaryproject/notation/internal/envelope"
+	"github.com/notaryproject/notation/internal/experimental"
 	"github.com/notaryproject/notation/internal/ioutil"
+	"github.com/notaryproject/notation/internal/policy"
+	"github.com/notaryproject/notation/internal/provenance"
 	"github.com/notaryproject/notation/internal/tree"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/spf13/cobra"
+	"oras.land/oras-go/v2/content"
 )
 
 type inspectOpts struct {
 	cmd.LoggingFlagOpts
 	SecureFlagOpts
-	reference    string
-	outputFormat string
+	reference        string
+	outputFormat     string
+	keepTagReference bool
+	withPolicy       bool
+	pluginConfig     []string
+	bundle           string
+	ociLayout        bool
+	inputType        inputType
 }
 
 type inspectOutput struct {
+	Reference  string `json:"reference,omitempty"`
 	MediaType  string `json:"mediaType"`
 	Signatures []signatureOutput
+
+	// WarningCount is the number of warnings printed to stderr, e.g. about
+	// registries accessed insecurely, and Warnings are their messages.
+	WarningCount int      `json:"warningCount"`
+	Warnings     []string `json:"warnings,omitempty"`
 }
 
 type signatureOutput struct {
@@ -40,21 +63,71 @@ type signatureOutput struct {
 	SignatureAlgorithm    string              `json:"signatureAlgorithm"`
 	SignedAttributes      map[string]string   `json:"signedAttributes"`
 	UserDefinedAttributes map[string]string   `json:"userDefinedAttributes"`
+	Provenance            map[string]string   `json:"provenance,omitempty"`
 	UnsignedAttributes    map[string]string   `json:"unsignedAttributes"`
 	Certificates          []certificateOutput `json:"certificates"`
+	Timestamp             *timestampOutput    `json:"timestamp,omitempty"`
 	SignedArtifact        ocispec.Descriptor  `json:"signedArtifact"`
+	Policy                *policyOutput       `json:"policy,omitempty"`
+
+	// SignedTag is the tag the artifact was resolved from at signing time,
+	// recorded in the signature manifest by "notation sign --record-tag". It
+	// is not signed.
+	SignedTag string `json:"signedTag,omitempty"`
+}
+
+// timestampOutput is the RFC 3161 timestamp countersignature of a signature,
+// which is only verified if evaluated against the trust policy.
+type timestampOutput struct {
+	// Time is the time the timestamp authority issued the timestamp.
+	Time          string              `json:"time,omitempty"`
+	HashAlgorithm string              `json:"hashAlgorithm,omitempty"`
+	Certificates  []certificateOutput `json:"certificates,omitempty"`
+
+	// Verified is true if the timestamp is verified against the timestamp
+	// trust stores of the trust policy with flag "--policy".
+	Verified bool `json:"verified,omitempty"`
+
+	// Error is the reason why the timestamp cannot be parsed, if any.
+	Error string `json:"error,omitempty"`
 }
 
+// policyOutput is the verdict of the trust policy on a signature.
+type policyOutput struct {
+	// Verdict is "pass", "fail" or "skipped".
+	Verdict           string `json:"verdict"`
+	VerificationLevel string `json:"verificationLevel,omitempty"`
+
+	// FailedCheck is the enforced validation failing the signature, e.g.
+	// "authenticity", if any.
+	FailedCheck string `json:"failedCheck,omitempty"`
+	Error       string `json:"error,omitempty"`
+
+	// Warnings are the failures of the validations that are only logged.
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// verdicts of the trust policy
+const (
+	policyVerdictPass    = "pass"
+	policyVerdictFail    = "fail"
+	policyVerdictSkipped = "skipped"
+)
+
 type certificateOutput struct {
-	SHA1Fingerprint string `json:"SHA1Fingerprint"`
-	IssuedTo        string `json:"issuedTo"`
-	IssuedBy        string `json:"issuedBy"`
-	Expiry          string `json:"expiry"`
+	SHA1Fingerprint   string `json:"SHA1Fingerprint"`
+	SHA256Fingerprint string `json:"SHA256Fingerprint"`
+	IssuedTo          string `json:"issuedTo"`
+	IssuedBy          string `json:"issuedBy"`
+	ValidFrom         string `json:"validFrom"`
+	Expiry            string `json:"expiry"`
 }
 
 func inspectCommand(opts *inspectOpts) *cobra.Command {
 	if opts == nil {
-		opts = &inspectOpts{}
+		opts = &inspectOpts{
+			inputType: inputTypeRegistry, // remote registry by default
+		}
 	}
 	command := &cobra.Command{
 		Use:   "inspect [reference]",
@@ -69,22 +142,55 @@ Example - Inspect signatures on an OCI artifact identified by a tag  (Notation w
 
 Example - Inspect signatures on an OCI artifact identified by a digest and output as json:
   notation inspect --output json <registry>/<repository>@<digest>
+
+Example - Inspect signatures on an OCI artifact identified by a digest and output as yaml:
+  notation inspect --output yaml <registry>/<repository>@<digest>
+
+Example - [Experimental] Inspect signatures on an OCI artifact and evaluate each signature against the trust policy:
+  notation inspect --with-policy <registry>/<repository>@<digest>
+
+Example - [Experimental] Inspect signatures on an OCI artifact referenced in an OCI layout directory or tarball:
+  notation inspect --oci-layout "<oci_layout_path>@<digest>"
+
+Example - [Experimental] Inspect signatures of a bundle exported by "notation export-bundle" without accessing the registry:
+  notation inspect --bundle net-monitor.bundle.tgz
 `,
 		Args: func(cmd *cobra.Command, args []string) error {
 			if len(args) == 0 {
-				return errors.New("missing reference")
+				if opts.bundle == "" {
+					return errors.New("missing reference")
+				}
+				return nil
 			}
 			opts.reference = args[0]
 			return nil
 		},
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if len(opts.pluginConfig) > 0 && !opts.withPolicy {
+				return errors.New("flag \"--plugin-config\" can only be used when flag \"--with-policy\" is set")
+			}
+			if opts.ociLayout {
+				opts.inputType = inputTypeOCILayout
+			}
+			return experimental.CheckFlagsAndWarn(cmd, "with-policy", "bundle", "oci-layout")
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.SecureFlagOpts.applyChangedFlags(cmd.Flags())
 			return runInspect(cmd, opts)
 		},
 	}
 
 	opts.LoggingFlagOpts.ApplyFlags(command.Flags())
 	opts.SecureFlagOpts.ApplyFlags(command.Flags())
-	cmd.SetPflagOutput(command.Flags(), &opts.outputFormat, cmd.PflagOutputUsage)
+	command.Flags().StringVarP(&opts.outputFormat, cmd.PflagOutput.Name, cmd.PflagOutput.Shorthand, cmd.OutputTree, fmt.Sprintf("output format, options: '%s', '%s', '%s'", cmd.OutputTree, cmd.OutputJSON, cmd.OutputYAML))
+	cmd.SetPflagKeepTagReference(command.Flags(), &opts.keepTagReference)
+	command.Flags().BoolVar(&opts.withPolicy, "with-policy", false, "[Experimental] evaluate each signature against the trust policy and show whether it passes, and the failing check otherwise")
+	command.Flags().StringArrayVar(&opts.pluginConfig, "plugin-config", nil, "{key}={value} pairs that are passed as it is to a verification plugin when flag \"--with-policy\" is set, refer plugin's documentation to set appropriate values")
+	command.Flags().StringVar(&opts.bundle, "bundle", "", "[Experimental] file of a bundle exported by \"notation export-bundle\" to inspect the signatures of without accessing the registry, the reference is optional and overrides the reference recorded in the bundle")
+	command.Flags().BoolVar(&opts.ociLayout, "oci-layout", false, "[Experimental] inspect the artifact stored as OCI image layout, in a directory or a tarball")
+	command.MarkFlagsMutuallyExclusive("bundle", "keep-tag-reference")
+	command.MarkFlagsMutuallyExclusive("bundle", "oci-layout")
+	experimental.HideFlags(command, "with-policy", "bundle", "oci-layout")
 	return command
 }
 
@@ -92,29 +198,86 @@ func runInspect(command *cobra.Command, opts *inspectOpts) error {
 	// set log level
 	ctx := opts.LoggingFlagOpts.SetLoggerLevel(command.Context())
 
-	if opts.outputFormat != cmd.OutputJSON && opts.outputFormat != cmd.OutputPlaintext {
+	switch opts.outputFormat {
+	case cmd.OutputTree, cmd.OutputJSON, cmd.OutputYAML:
+	case cmd.OutputPlaintext:
+		// "text" is the former name of the tree output
+		opts.outputFormat = cmd.OutputTree
+	default:
 		return fmt.Errorf("unrecognized output format %s", opts.outputFormat)
 	}
 
 	// initialize
-	reference := opts.reference
-	sigRepo, err := getRemoteRepository(ctx, &opts.SecureFlagOpts, reference)
-	if err != nil {
-		return err
+	var sigRepo registry.Repository
+	var manifestFetcher content.Fetcher
+	var manifestDesc ocispec.Descriptor
+	var reference, resolvedRef string
+	var err error
+	if opts.bundle != "" {
+		// the signatures are served by the bundle
+		bundle, bundleRef, err := openOfflineBundle(opts.bundle, opts.reference)
+		if err != nil {
+			return err
+		}
+		sigRepo, manifestFetcher = bundle.Repository(), bundle
+		manifestDesc, reference, resolvedRef = bundle.Subject, bundleRef, bundleRef
+	} else {
+		if reference, err = expandAlias(opts.inputType, opts.reference); err != nil {
+			return err
+		}
+		if opts.inputType == inputTypeOCILayout {
+			closeLayout, err := openOCILayoutTarball(reference, false)
+			if err != nil {
+				return err
+			}
+			defer closeLayout(false)
+		}
+		if sigRepo, err = getRepository(ctx, opts.inputType, reference, &opts.SecureFlagOpts); err != nil {
+			return err
+		}
 	}
-	manifestDesc, resolvedRef, err := resolveReference(ctx, inputTypeRegistry, reference, sigRepo, func(ref string, manifestDesc ocispec.Descriptor) {
-		fmt.Fprintf(os.Stderr, "Warning: Always inspect the artifact using digest(@sha256:...) rather than a tag(:%s) because resolved digest may not point to the same signed artifact, as tags are mutable.\n", ref)
-	})
-	if err != nil {
-		return err
+	var policyVerifier *policy.Verifier
+	var configs map[string]string
+	if opts.withPolicy {
+		policyVerifier, err = policy.NewVerifierFromConfigOptions(policy.ConfigOptions{CRLCache: newCRLCache(ctx, false)})
+		if err != nil {
+			return err
+		}
+		warnTrustPolicy("")
+		configs, err = cmd.ParseFlagMap(opts.pluginConfig, cmd.PflagPluginConfig.Name)
+		if err != nil {
+			return err
+		}
+		warnPluginConfig(configs)
+		if policyVerifier.UsesArtifactTypes() {
+			// trust policy statements are selected by the artifact type
+			if manifestFetcher == nil {
+				if manifestFetcher, err = getManifestFetcher(ctx, opts.inputType, reference, &opts.SecureFlagOpts); err != nil {
+					return err
+				}
+			}
+			sigRepo = policy.NewRepository(sigRepo, manifestFetcher)
+		}
+	}
+	if opts.bundle == "" {
+		manifestDesc, resolvedRef, err = resolveReference(ctx, opts.inputType, reference, sigRepo, func(ref string, manifestDesc ocispec.Descriptor) {
+			fmt.Fprintf(os.Stderr, "%s Always inspect the artifact using digest(@sha256:...) rather than a tag(:%s) because resolved digest may not point to the same signed artifact, as tags are mutable.\n", color.Warning(os.Stderr, "Warning:"), ref)
+		})
+		if err != nil {
+			return err
+		}
 	}
 	output := inspectOutput{MediaType: manifestDesc.MediaType, Signatures: []signatureOutput{}}
+	if opts.keepTagReference {
+		resolvedRef = keepTagReference(opts.inputType, reference, resolvedRef)
+		output.Reference = resolvedRef
+	}
 	skippedSignatures := false
 	err = sigRepo.ListSignatures(ctx, manifestDesc, func(signatureManifests []ocispec.Descriptor) error {
 		for _, sigManifestDesc := range signatureManifests {
 			sigBlob, sigDesc, err := sigRepo.FetchSignatureBlob(ctx, sigManifestDesc)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "Warning: unable to fetch signature %s due to error: %v\n", sigManifestDesc.Digest.String(), err)
+				fmt.Fprintf(os.Stderr, "%s unable to fetch signature %s due to error: %v\n", color.Warning(os.Stderr, "Warning:"), sigManifestDesc.Digest.String(), err)
 				skippedSignatures = true
 				continue
 			}
@@ -132,6 +295,13 @@ func runInspect(command *cobra.Command, opts *inspectOpts) error {
 				skippedSignatures = true
 				continue
 			}
+			if len(envelopeContent.SignerInfo.UnsignedAttributes.TimestampSignature) == 0 {
+				// the COSE envelope of notation-core-go does not parse the
+				// timestamp
+				if token, err := envelope.TimestampSignature(sigBlob, sigDesc.MediaType); err == nil {
+					envelopeContent.SignerInfo.UnsignedAttributes.TimestampSignature = token
+				}
+			}
 
 			signedArtifactDesc, err := envelope.DescriptorFromSignaturePayload(&envelopeContent.Payload)
 			if err != nil {
@@ -155,13 +325,32 @@ func runInspect(command *cobra.Command, opts *inspectOpts) error {
 				UserDefinedAttributes: signedArtifactDesc.Annotations,
 				UnsignedAttributes:    getUnsignedAttributes(envelopeContent),
 				Certificates:          getCertificates(opts.outputFormat, envelopeContent),
+				Timestamp:             getTimestamp(opts.outputFormat, envelopeContent),
 				SignedArtifact:        *signedArtifactDesc,
+				SignedTag:             sigManifestDesc.Annotations[annotationSignedTag],
 			}
 
 			// clearing annotations from the SignedArtifact field since they're already
 			// displayed as UserDefinedAttributes
 			sig.SignedArtifact.Annotations = nil
 
+			if policyVerifier != nil {
+				sig.Policy = evaluatePolicy(ctx, policyVerifier, manifestDesc, sigBlob, notation.VerifierVerifyOptions{
+					ArtifactReference:  resolvedRef,
+					SignatureMediaType: sigDesc.MediaType,
+					PluginConfig:       configs,
+				})
+				if _, ok := policyVerifier.VerifiedTimestamp(sigBlob); ok && sig.Timestamp != nil {
+					sig.Timestamp.Verified = true
+				}
+			}
+
+			// the toolchain provenance is displayed separately
+			if provenanceAttributes, userDefined := provenance.Split(sig.UserDefinedAttributes); provenanceAttributes != nil {
+				sig.Provenance = provenanceAttributes
+				sig.UserDefinedAttributes = userDefined
+			}
+
 			output.Signatures = append(output.Signatures, sig)
 		}
 		return nil
@@ -171,6 +360,8 @@ func runInspect(command *cobra.Command, opts *inspectOpts) error {
 		return err
 	}
 
+	output.Warnings = printedWarnings.list()
+	output.WarningCount = len(output.Warnings)
 	err = printOutput(opts.outputFormat, resolvedRef, output)
 	if err != nil {
 		return err
@@ -183,8 +374,46 @@ func runInspect(command *cobra.Command, opts *inspectOpts) error {
 	return nil
 }
 
+// evaluatePolicy verifies the signature against the trust policy and returns
+// the verdict.
+func evaluatePolicy(ctx context.Context, verifier notation.Verifier, manifestDesc ocispec.Descriptor, sigBlob []byte, opts notation.VerifierVerifyOptions) *policyOutput {
+	outcome, err := verifier.Verify(ctx, manifestDesc, sigBlob, opts)
+	output := &policyOutput{Verdict: policyVerdictPass}
+	if outcome != nil {
+		if outcome.VerificationLevel != nil {
+			output.VerificationLevel = outcome.VerificationLevel.Name
+		}
+		for _, result := range outcome.VerificationResults {
+			if result.Error == nil {
+				continue
+			}
+			if result.Action == trustpolicy.ActionEnforce {
+				if output.FailedCheck == "" {
+					output.FailedCheck = string(result.Type)
+				}
+				continue
+			}
+			output.Warnings = append(output.Warnings, fmt.Sprintf("%s: %v", result.Type, result.Error))
+		}
+		if err == nil && outcome.EnvelopeContent == nil {
+			// the verification level is skip
+			output.Verdict = policyVerdictSkipped
+			return output
+		}
+	}
+	if err != nil {
+		output.Verdict = policyVerdictFail
+		output.Error = err.Error()
+		var errNoPolicy notation.ErrorNoApplicableTrustPolicy
+		if output.FailedCheck == "" && errors.As(err, &errNoPolicy) {
+			output.FailedCheck = "trustPolicy"
+		}
+	}
+	return output
+}
+
 func logSkippedSignature(sigDesc ocispec.Descriptor, err error) {
-	fmt.Fprintf(os.Stderr, "Warning: Skipping signature %s because of error: %v\n", sigDesc.Digest.String(), err)
+	fmt.Fprintf(os.Stderr, "%s Skipping signature %s because of error: %v\n", color.Warning(os.Stderr, "Warning:"), sigDesc.Digest.String(), err)
 }
 
 func getSignedAttributes(outputFormat string, envContent *signature.EnvelopeContent) map[string]string {
@@ -192,6 +421,7 @@ func getSignedAttributes(outputFormat string, envContent *signature.EnvelopeCont
 		"signingScheme": string(envContent.SignerInfo.SignedAttributes.SigningScheme),
 		"signingTime":   formatTimestamp(outputFormat, envContent.SignerInfo.SignedAttributes.SigningTime),
 		"expiry":        formatTimestamp(outputFormat, envContent.SignerInfo.SignedAttributes.Expiry),
+		"contentType":   envContent.Payload.ContentType,
 	}
 
 	for _, attribute := range envContent.SignerInfo.SignedAttributes.ExtendedAttributes {
@@ -217,7 +447,7 @@ func getUnsignedAttributes(envContent *signature.EnvelopeContent) map[string]str
 
 func formatTimestamp(outputFormat string, t time.Time) string {
 	switch outputFormat {
-	case cmd.OutputJSON:
+	case cmd.OutputJSON, cmd.OutputYAML:
 		return t.Format(time.RFC3339)
 	default:
 		return t.Format(time.ANSIC)
@@ -225,17 +455,41 @@ func formatTimestamp(outputFormat string, t time.Time) string {
 }
 
 func getCertificates(outputFormat string, envContent *signature.EnvelopeContent) []certificateOutput {
+	return getCertificateChain(outputFormat, envContent.SignerInfo.CertificateChain)
+}
+
+// getTimestamp returns the timestamp countersignature of the signature, if
+// any.
+func getTimestamp(outputFormat string, envContent *signature.EnvelopeContent) *timestampOutput {
+	if len(envContent.SignerInfo.UnsignedAttributes.TimestampSignature) == 0 {
+		return nil
+	}
+	token, err := archive.ParseToken(envContent.SignerInfo.UnsignedAttributes.TimestampSignature)
+	if err != nil {
+		return &timestampOutput{Error: err.Error()}
+	}
+	return &timestampOutput{
+		Time:          formatTimestamp(outputFormat, token.GenTime),
+		HashAlgorithm: token.Hash.String(),
+		Certificates:  getCertificateChain(outputFormat, token.Certificates),
+	}
+}
+
+func getCertificateChain(outputFormat string, chain []*x509.Certificate) []certificateOutput {
 	certificates := []certificateOutput{}
 
-	for _, cert := range envContent.SignerInfo.CertificateChain {
+	for _, cert := range chain {
 		h := sha1.Sum(cert.Raw)
 		fingerprint := strings.ToLower(hex.EncodeToString(h[:]))
+		h256 := sha256.Sum256(cert.Raw)
 
 		certificate := certificateOutput{
-			SHA1Fingerprint: fingerprint,
-			IssuedTo:        cert.Subject.String(),
-			IssuedBy:        cert.Issuer.String(),
-			Expiry:          formatTimestamp(outputFormat, cert.NotAfter),
+			SHA1Fingerprint:   fingerprint,
+			SHA256Fingerprint: hex.EncodeToString(h256[:]),
+			IssuedTo:          cert.Subject.String(),
+			IssuedBy:          cert.Issuer.String(),
+			ValidFrom:         formatTimestamp(outputFormat, cert.NotBefore),
+			Expiry:            formatTimestamp(outputFormat, cert.NotAfter),
 		}
 
 		certificates = append(certificates, certificate)
@@ -245,8 +499,11 @@ func getCertificates(outputFormat string, envContent *signature.EnvelopeContent)
 }
 
 func printOutput(outputFormat string, ref string, output inspectOutput) error {
-	if outputFormat == cmd.OutputJSON {
+	switch outputFormat {
+	case cmd.OutputJSON:
 		return ioutil.PrintObjectAsJSON(output)
+	case cmd.OutputYAML:
+		return ioutil.PrintObjectAsYAML(output)
 	}
 
 	fmt.Println("Inspecting all signatures for signed artifact")
@@ -257,6 +514,9 @@ func printOutput(outputFormat string, ref string, output inspectOutput) error {
 		sigNode := cncfSigNode.Add(signature.Digest)
 		sigNode.AddPair("media type", signature.MediaType)
 		sigNode.AddPair("signature algorithm", signature.SignatureAlgorithm)
+		if signature.SignedTag != "" {
+			sigNode.AddPair("signed tag", signature.SignedTag)
+		}
 
 		signedAttributesNode := sigNode.Add("signed attributes")
 		addMapToTree(signedAttributesNode, signature.SignedAttributes)
@@ -264,27 +524,68 @@ func printOutput(outputFormat string, ref string, output inspectOutput) error {
 		userDefinedAttributesNode := sigNode.Add("user defined attributes")
 		addMapToTree(userDefinedAttributesNode, signature.UserDefinedAttributes)
 
+		if len(signature.Provenance) > 0 {
+			provenanceNode := sigNode.Add("provenance")
+			addMapToTree(provenanceNode, signature.Provenance)
+		}
+
 		unsignedAttributesNode := sigNode.Add("unsigned attributes")
 		addMapToTree(unsignedAttributesNode, signature.UnsignedAttributes)
 
 		certListNode := sigNode.Add("certificates")
-		for _, cert := range signature.Certificates {
-			certNode := certListNode.AddPair("SHA1 fingerprint", cert.SHA1Fingerprint)
-			certNode.AddPair("issued to", cert.IssuedTo)
-			certNode.AddPair("issued by", cert.IssuedBy)
-			certNode.AddPair("expiry", cert.Expiry)
+		addCertificatesToTree(certListNode, signature.Certificates)
+
+		if timestamp := signature.Timestamp; timestamp != nil {
+			timestampNode := sigNode.Add("timestamp")
+			if timestamp.Error != "" {
+				timestampNode.AddPair("error", timestamp.Error)
+			} else {
+				timestampNode.AddPair("time", timestamp.Time)
+				if timestamp.Verified {
+					timestampNode.AddPair("verified", "true")
+				}
+				timestampNode.AddPair("hash algorithm", timestamp.HashAlgorithm)
+				addCertificatesToTree(timestampNode.Add("certificates"), timestamp.Certificates)
+			}
 		}
 
 		artifactNode := sigNode.Add("signed artifact")
 		artifactNode.AddPair("media type", signature.SignedArtifact.MediaType)
 		artifactNode.AddPair("digest", signature.SignedArtifact.Digest.String())
 		artifactNode.AddPair("size", strconv.FormatInt(signature.SignedArtifact.Size, 10))
+
+		if signature.Policy != nil {
+			policyNode := sigNode.AddPair("policy verdict", signature.Policy.Verdict)
+			if signature.Policy.VerificationLevel != "" {
+				policyNode.AddPair("verification level", signature.Policy.VerificationLevel)
+			}
+			if signature.Policy.FailedCheck != "" {
+				policyNode.AddPair("failed check", signature.Policy.FailedCheck)
+			}
+			if signature.Policy.Error != "" {
+				policyNode.AddPair("error", signature.Policy.Error)
+			}
+			for _, warning := range signature.Policy.Warnings {
+				policyNode.AddPair("warning", warning)
+			}
+		}
 	}
 
 	root.Print()
 	return nil
 }
 
+func addCertificatesToTree(node *tree.Node, certificates []certificateOutput) {
+	for _, cert := range certificates {
+		certNode := node.AddPair("SHA1 fingerprint", cert.SHA1Fingerprint)
+		certNode.AddPair("SHA256 fingerprint", cert.SHA256Fingerprint)
+		certNode.AddPair("issued to", cert.IssuedTo)
+		certNode.AddPair("issued by", cert.IssuedBy)
+		certNode.AddPair("valid from", cert.ValidFrom)
+		certNode.AddPair("expiry", cert.Expiry)
+	}
+}
+
 func addMapToTree(node *tree.Node, m map[string]string) {
 	if len(m) > 0 {
 		for k, v := range m {
@@ -1,9 +1,25 @@
 package main
 
 import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"reflect"
 	"testing"
+	"time"
 
+	"github.com/notaryproject/notation-core-go/signature"
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
 	"github.com/notaryproject/notation/internal/cmd"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 )
 
 func TestInspectCommand_SecretsFromArgs(t *testing.T) {
@@ -29,7 +45,7 @@ func TestInspectCommand_SecretsFromArgs(t *testing.T) {
 	if err := command.Args(command, command.Flags().Args()); err != nil {
 		t.Fatalf("Parse Args failed: %v", err)
 	}
-	if *opts != *expected {
+	if !reflect.DeepEqual(*opts, *expected) {
 		t.Fatalf("Expect inspect opts: %v, got: %v", expected, opts)
 	}
 }
@@ -55,7 +71,7 @@ func TestInspectCommand_SecretsFromEnv(t *testing.T) {
 	if err := command.Args(command, command.Flags().Args()); err != nil {
 		t.Fatalf("Parse Args failed: %v", err)
 	}
-	if *opts != *expected {
+	if !reflect.DeepEqual(*opts, *expected) {
 		t.Fatalf("Expect inspect opts: %v, got: %v", expected, opts)
 	}
 }
@@ -69,3 +85,178 @@ func TestInspectCommand_MissingArgs(t *testing.T) {
 		t.Fatal("Parse Args expected error, but ok")
 	}
 }
+
+func TestInspectCommand_WithPolicy(t *testing.T) {
+	t.Setenv("NOTATION_EXPERIMENTAL", "1")
+	opts := &inspectOpts{}
+	command := inspectCommand(opts)
+	if err := command.ParseFlags([]string{"ref", "--with-policy", "--plugin-config", "key=value"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := command.PreRunE(command, command.Flags().Args()); err != nil {
+		t.Fatalf("PreRunE failed: %v", err)
+	}
+	if !opts.withPolicy || !reflect.DeepEqual(opts.pluginConfig, []string{"key=value"}) {
+		t.Fatalf("unexpected inspect opts: %+v", opts)
+	}
+
+	opts = &inspectOpts{}
+	command = inspectCommand(opts)
+	if err := command.ParseFlags([]string{"ref", "--plugin-config", "key=value"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := command.PreRunE(command, command.Flags().Args()); err == nil {
+		t.Fatal("expected error of flag \"--plugin-config\" without flag \"--with-policy\"")
+	}
+}
+
+// mockOutcomeVerifier returns the outcome and the error.
+type mockOutcomeVerifier struct {
+	outcome *notation.VerificationOutcome
+	err     error
+}
+
+func (v *mockOutcomeVerifier) Verify(ctx context.Context, desc ocispec.Descriptor, signature []byte, opts notation.VerifierVerifyOptions) (*notation.VerificationOutcome, error) {
+	return v.outcome, v.err
+}
+
+func TestEvaluatePolicy(t *testing.T) {
+	expiryErr := notation.ErrorVerificationFailed{Msg: "signature is expired"}
+	authenticityErr := notation.ErrorVerificationFailed{Msg: "signature is not produced by a trusted signer"}
+	tests := []struct {
+		name     string
+		verifier *mockOutcomeVerifier
+		want     policyOutput
+	}{
+		{
+			name: "pass with logged failure",
+			verifier: &mockOutcomeVerifier{outcome: &notation.VerificationOutcome{
+				EnvelopeContent:   &signature.EnvelopeContent{},
+				VerificationLevel: trustpolicy.LevelPermissive,
+				VerificationResults: []*notation.ValidationResult{
+					{Type: trustpolicy.TypeIntegrity, Action: trustpolicy.ActionEnforce},
+					{Type: trustpolicy.TypeExpiry, Action: trustpolicy.ActionLog, Error: expiryErr},
+				},
+			}},
+			want: policyOutput{Verdict: policyVerdictPass, VerificationLevel: "permissive", Warnings: []string{"expiry: signature is expired"}},
+		},
+		{
+			name: "fail",
+			verifier: &mockOutcomeVerifier{outcome: &notation.VerificationOutcome{
+				VerificationLevel: trustpolicy.LevelStrict,
+				VerificationResults: []*notation.ValidationResult{
+					{Type: trustpolicy.TypeIntegrity, Action: trustpolicy.ActionEnforce},
+					{Type: trustpolicy.TypeAuthenticity, Action: trustpolicy.ActionEnforce, Error: authenticityErr},
+				},
+			}, err: authenticityErr},
+			want: policyOutput{Verdict: policyVerdictFail, VerificationLevel: "strict", FailedCheck: "authenticity", Error: authenticityErr.Error()},
+		},
+		{
+			name:     "skipped",
+			verifier: &mockOutcomeVerifier{outcome: &notation.VerificationOutcome{VerificationLevel: trustpolicy.LevelSkip}},
+			want:     policyOutput{Verdict: policyVerdictSkipped, VerificationLevel: "skip"},
+		},
+		{
+			name:     "no applicable trust policy",
+			verifier: &mockOutcomeVerifier{err: notation.ErrorNoApplicableTrustPolicy{Msg: "artifact is not in scope"}},
+			want:     policyOutput{Verdict: policyVerdictFail, FailedCheck: "trustPolicy", Error: "artifact is not in scope"},
+		},
+		{
+			name:     "other failure",
+			verifier: &mockOutcomeVerifier{outcome: &notation.VerificationOutcome{VerificationLevel: trustpolicy.LevelStrict}, err: errors.New("content descriptor mismatch")},
+			want:     policyOutput{Verdict: policyVerdictFail, VerificationLevel: "strict", Error: "content descriptor mismatch"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := evaluatePolicy(context.Background(), tt.verifier, ocispec.Descriptor{}, nil, notation.VerifierVerifyOptions{})
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Fatalf("evaluatePolicy() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInspectCommand_DefaultOutput(t *testing.T) {
+	opts := &inspectOpts{}
+	command := inspectCommand(opts)
+	if err := command.ParseFlags([]string{"ref"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if opts.outputFormat != cmd.OutputTree {
+		t.Fatalf("expected default output format %q, got %q", cmd.OutputTree, opts.outputFormat)
+	}
+}
+
+func TestGetCertificates(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	notBefore := time.Date(2023, 4, 1, 0, 0, 0, 0, time.UTC)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "wabbit-networks.io"},
+		NotBefore:    notBefore,
+		NotAfter:     notBefore.Add(24 * time.Hour),
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(certDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	envContent := &signature.EnvelopeContent{SignerInfo: signature.SignerInfo{CertificateChain: []*x509.Certificate{cert}}}
+
+	certificates := getCertificates(cmd.OutputJSON, envContent)
+	if len(certificates) != 1 {
+		t.Fatalf("got %d certificates, want 1", len(certificates))
+	}
+	sha256Fingerprint := sha256.Sum256(certDER)
+	want := certificateOutput{
+		SHA1Fingerprint:   certificates[0].SHA1Fingerprint,
+		SHA256Fingerprint: hex.EncodeToString(sha256Fingerprint[:]),
+		IssuedTo:          "CN=wabbit-networks.io",
+		IssuedBy:          "CN=wabbit-networks.io",
+		ValidFrom:         "2023-04-01T00:00:00Z",
+		Expiry:            "2023-04-02T00:00:00Z",
+	}
+	if certificates[0] != want {
+		t.Fatalf("getCertificates() = %+v, want %+v", certificates[0], want)
+	}
+}
+
+func TestGetTimestamp(t *testing.T) {
+	envContent := &signature.EnvelopeContent{}
+	if got := getTimestamp(cmd.OutputJSON, envContent); got != nil {
+		t.Fatalf("getTimestamp() = %+v, want nil for a signature without timestamp", got)
+	}
+	envContent.SignerInfo.UnsignedAttributes.TimestampSignature = []byte("malformed")
+	if got := getTimestamp(cmd.OutputJSON, envContent); got == nil || got.Error == "" {
+		t.Fatalf("getTimestamp() = %+v, want error for a malformed timestamp", got)
+	}
+}
+
+func TestInspectCommand_Bundle(t *testing.T) {
+	opts := &inspectOpts{}
+	command := inspectCommand(opts)
+	if err := command.ParseFlags([]string{"--bundle", "net-monitor.bundle.tgz"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := command.Args(command, command.Flags().Args()); err != nil {
+		t.Fatalf("Parse Args failed: %v", err)
+	}
+	if opts.bundle != "net-monitor.bundle.tgz" || opts.reference != "" {
+		t.Fatalf("unexpected inspect opts: %+v", opts)
+	}
+
+	command = inspectCommand(nil)
+	if err := command.ParseFlags([]string{"ref", "--bundle", "net-monitor.bundle.tgz", "--keep-tag-reference"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := command.ValidateFlagGroups(); err == nil {
+		t.Fatal("expected error of flag \"--bundle\" with flag \"--keep-tag-reference\"")
+	}
+}
@@ -0,0 +1,126 @@
+// Package dockerplugin implements the Docker CLI plugin protocol so that
+// notation can be invoked as "docker notation". Podman has no CLI plugin
+// mechanism and is not supported.
+//
+// Reference: https://github.com/docker/cli/blob/master/cli-plugins/manager/metadata.go
+package dockerplugin
+
+import (
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// PluginName is the name of the Docker CLI plugin, i.e. the subcommand
+	// of the Docker CLI.
+	PluginName = "notation"
+
+	// BinaryName is the name of the executable for the Docker CLI to
+	// discover the plugin.
+	BinaryName = "docker-" + PluginName
+
+	// MetadataSubcommandName is the name of the subcommand the Docker CLI
+	// invokes to get the plugin metadata.
+	MetadataSubcommandName = "docker-cli-plugin-metadata"
+)
+
+// Metadata is the metadata of a Docker CLI plugin.
+type Metadata struct {
+	SchemaVersion    string `json:"SchemaVersion"`
+	Vendor           string `json:"Vendor"`
+	Version          string `json:"Version,omitempty"`
+	ShortDescription string `json:"ShortDescription,omitempty"`
+	URL              string `json:"URL,omitempty"`
+}
+
+// NewMetadata returns the metadata of the notation Docker CLI plugin.
+func NewMetadata(version string) Metadata {
+	return Metadata{
+		SchemaVersion:    "0.1.0",
+		Vendor:           "Notary Project",
+		Version:          version,
+		ShortDescription: "Sign and verify artifacts",
+		URL:              "https://notaryproject.dev",
+	}
+}
+
+// IsPluginInvocation returns true if the executable at path is invoked as the
+// Docker CLI plugin.
+func IsPluginInvocation(path string) bool {
+	name := strings.TrimSuffix(filepath.Base(path), ".exe")
+	return name == BinaryName
+}
+
+// PluginArgs returns the arguments of the notation command from the arguments
+// the Docker CLI invokes the plugin with, e.g. "docker-notation notation
+// verify IMAGE" for "docker notation verify IMAGE".
+func PluginArgs(args []string) []string {
+	if len(args) > 0 && args[0] == PluginName {
+		return args[1:]
+	}
+	return args
+}
+
+// dockerHubRegistryHost is the host serving the registry API of Docker Hub.
+const dockerHubRegistryHost = "registry-1.docker.io"
+
+// RegistryHost returns the host serving the registry API of registry, i.e.
+// "registry-1.docker.io" for Docker Hub, of which the domain "docker.io" of
+// normalized references does not serve the registry API, or registry as is
+// otherwise.
+func RegistryHost(registry string) string {
+	if registry == "docker.io" || registry == "index.docker.io" {
+		return dockerHubRegistryHost
+	}
+	return registry
+}
+
+// registryHostTransport sends the requests to the domains of Docker Hub to the
+// host serving its registry API.
+type registryHostTransport struct {
+	base http.RoundTripper
+}
+
+// NewTransport returns a transport sending the requests to the domains of
+// Docker Hub, e.g. "https://docker.io/v2/", to the host serving its registry
+// API with base, or with http.DefaultTransport if base is nil.
+func NewTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &registryHostTransport{base: base}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *registryHostTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := RegistryHost(req.URL.Host)
+	if host == req.URL.Host {
+		return t.base.RoundTrip(req)
+	}
+	req = req.Clone(req.Context())
+	req.URL.Host = host
+	req.Host = host
+	return t.base.RoundTrip(req)
+}
+
+// NormalizeReference expands a reference in the short form accepted by the
+// Docker CLI to a fully qualified reference, e.g. "alpine:3.17" to
+// "docker.io/library/alpine:3.17".
+func NormalizeReference(reference string) string {
+	domain, remainder, found := strings.Cut(reference, "/")
+	if !found || (!strings.ContainsAny(domain, ".:") && domain != "localhost") {
+		// the reference has no registry
+		if !found {
+			return "docker.io/library/" + reference
+		}
+		return "docker.io/" + reference
+	}
+	if domain == "index.docker.io" {
+		domain = "docker.io"
+	}
+	if domain == "docker.io" && !strings.Contains(remainder, "/") {
+		return domain + "/library/" + remainder
+	}
+	return domain + "/" + remainder
+}
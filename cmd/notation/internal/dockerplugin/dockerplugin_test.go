@@ -0,0 +1,58 @@
+package dockerplugin
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeReference(t *testing.T) {
+	tests := map[string]string{
+		"alpine":                           "docker.io/library/alpine",
+		"alpine:3.17":                      "docker.io/library/alpine:3.17",
+		"acme/app@sha256:abcd":             "docker.io/acme/app@sha256:abcd",
+		"docker.io/alpine:3.17":            "docker.io/library/alpine:3.17",
+		"index.docker.io/acme/app:v1":      "docker.io/acme/app:v1",
+		"localhost/app:v1":                 "localhost/app:v1",
+		"localhost:5000/net-monitor:v1":    "localhost:5000/net-monitor:v1",
+		"myregistry.azurecr.io/app/web:v1": "myregistry.azurecr.io/app/web:v1",
+	}
+	for reference, expected := range tests {
+		if got := NormalizeReference(reference); got != expected {
+			t.Errorf("NormalizeReference(%q) = %q, expected %q", reference, got, expected)
+		}
+	}
+}
+
+func TestRegistryHost(t *testing.T) {
+	for registry, expected := range map[string]string{
+		"docker.io":            "registry-1.docker.io",
+		"index.docker.io":      "registry-1.docker.io",
+		"registry-1.docker.io": "registry-1.docker.io",
+		"localhost:5000":       "localhost:5000",
+	} {
+		if got := RegistryHost(registry); got != expected {
+			t.Errorf("RegistryHost(%q) = %q, expected %q", registry, got, expected)
+		}
+	}
+}
+
+func TestIsPluginInvocation(t *testing.T) {
+	for path, expected := range map[string]bool{
+		"/usr/libexec/docker/cli-plugins/docker-notation": true,
+		"docker-notation.exe":                             true,
+		"/usr/local/bin/notation":                         false,
+	} {
+		if got := IsPluginInvocation(path); got != expected {
+			t.Errorf("IsPluginInvocation(%q) = %v, expected %v", path, got, expected)
+		}
+	}
+}
+
+func TestPluginArgs(t *testing.T) {
+	if got := PluginArgs([]string{"notation", "verify", "alpine"}); !reflect.DeepEqual(got, []string{"verify", "alpine"}) {
+		t.Fatalf("unexpected args: %v", got)
+	}
+	if got := PluginArgs([]string{MetadataSubcommandName}); !reflect.DeepEqual(got, []string{MetadataSubcommandName}) {
+		t.Fatalf("unexpected args: %v", got)
+	}
+}
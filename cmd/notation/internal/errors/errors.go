@@ -25,3 +25,16 @@ func (e ErrorOCILayoutMissingReference) Error() string {
 	}
 	return "reference is missing either digest or tag"
 }
+
+// ErrorTamperDetected is used when content fetched from a registry or an OCI
+// layout does not match the descriptor referencing it.
+type ErrorTamperDetected struct {
+	Msg string
+}
+
+func (e ErrorTamperDetected) Error() string {
+	if e.Msg != "" {
+		return "tamper detected: " + e.Msg
+	}
+	return "tamper detected: content does not match its descriptor"
+}
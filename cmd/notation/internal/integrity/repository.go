@@ -0,0 +1,125 @@
+// Package integrity provides defense in depth against registries and proxies
+// serving content that does not match the descriptors referencing it.
+package integrity
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	notationregistry "github.com/notaryproject/notation-go/registry"
+	notationerrors "github.com/notaryproject/notation/cmd/notation/internal/errors"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+)
+
+// Repository wraps a notationregistry.Repository and checks the content it
+// returns against the descriptors referencing the content.
+//
+// Signature blobs are always checked. If a manifest fetcher is provided, the
+// subject and signature manifests are fetched again and checked as well.
+type Repository struct {
+	notationregistry.Repository
+	manifestFetcher content.Fetcher
+
+	mu  sync.Mutex
+	err error
+}
+
+// NewRepository returns a Repository checking the content returned by repo.
+// manifestFetcher is optional and enables the check of manifests.
+func NewRepository(repo notationregistry.Repository, manifestFetcher content.Fetcher) *Repository {
+	return &Repository{
+		Repository:      repo,
+		manifestFetcher: manifestFetcher,
+	}
+}
+
+// Err returns the first tampering detected, or nil if no tampering has been
+// detected.
+//
+// Callers such as notation.Verify convert errors returned by the repository
+// into their own error types, so the tampering is recorded for the caller to
+// report it as a distinct error.
+func (r *Repository) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// Resolve resolves a reference to a manifest descriptor and checks the
+// manifest content if a manifest fetcher is provided.
+func (r *Repository) Resolve(ctx context.Context, reference string) (ocispec.Descriptor, error) {
+	desc, err := r.Repository.Resolve(ctx, reference)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	if err := r.checkManifest(ctx, desc); err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	return desc, nil
+}
+
+// FetchSignatureBlob returns the signature envelope blob and descriptor for
+// the given signature manifest descriptor after checking both.
+func (r *Repository) FetchSignatureBlob(ctx context.Context, desc ocispec.Descriptor) ([]byte, ocispec.Descriptor, error) {
+	if err := r.checkManifest(ctx, desc); err != nil {
+		return nil, ocispec.Descriptor{}, err
+	}
+	blob, blobDesc, err := r.Repository.FetchSignatureBlob(ctx, desc)
+	if err != nil {
+		return nil, ocispec.Descriptor{}, err
+	}
+	if err := r.check(blobDesc, blob, "signature blob"); err != nil {
+		return nil, ocispec.Descriptor{}, err
+	}
+	return blob, blobDesc, nil
+}
+
+// checkManifest fetches the manifest again and checks it against desc.
+func (r *Repository) checkManifest(ctx context.Context, desc ocispec.Descriptor) error {
+	if r.manifestFetcher == nil {
+		return nil
+	}
+	rc, err := r.manifestFetcher.Fetch(ctx, desc)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	// content.ReadAll verifies the content as well, it is only used to bound
+	// the read by the descriptor size. The check below reports a mismatch as
+	// tampering regardless of the reason.
+	manifest, err := content.ReadAll(rc, desc)
+	if err != nil {
+		return r.record(fmt.Sprintf("manifest %s: %v", desc.Digest, err))
+	}
+	return r.check(desc, manifest, "manifest")
+}
+
+// check verifies that the content matches the size and digest of desc.
+func (r *Repository) check(desc ocispec.Descriptor, data []byte, kind string) error {
+	if int64(len(data)) != desc.Size {
+		return r.record(fmt.Sprintf("%s %s: expected size %d, got %d", kind, desc.Digest, desc.Size, len(data)))
+	}
+	if err := desc.Digest.Validate(); err != nil {
+		return r.record(fmt.Sprintf("%s %s: invalid digest: %v", kind, desc.Digest, err))
+	}
+	if actual := desc.Digest.Algorithm().FromBytes(data); actual != desc.Digest {
+		return r.record(fmt.Sprintf("%s %s: content digest is %s", kind, desc.Digest, actual))
+	}
+	return nil
+}
+
+// record records the first tampering and returns it.
+func (r *Repository) record(msg string) error {
+	err := notationerrors.ErrorTamperDetected{Msg: msg}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err == nil {
+		r.err = err
+	}
+	return err
+}
+
+// compile time check
+var _ notationregistry.Repository = (*Repository)(nil)
@@ -0,0 +1,80 @@
+package integrity
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	notationregistry "github.com/notaryproject/notation-go/registry"
+	notationerrors "github.com/notaryproject/notation/cmd/notation/internal/errors"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+type mockRepository struct {
+	notationregistry.Repository
+	blob     []byte
+	blobDesc ocispec.Descriptor
+}
+
+func (m *mockRepository) FetchSignatureBlob(ctx context.Context, desc ocispec.Descriptor) ([]byte, ocispec.Descriptor, error) {
+	return m.blob, m.blobDesc, nil
+}
+
+type mockFetcher map[digest.Digest][]byte
+
+func (m mockFetcher) Fetch(ctx context.Context, desc ocispec.Descriptor) (io.ReadCloser, error) {
+	return io.NopCloser(bytes.NewReader(m[desc.Digest])), nil
+}
+
+func descriptorOf(data []byte) ocispec.Descriptor {
+	return ocispec.Descriptor{Digest: digest.FromBytes(data), Size: int64(len(data))}
+}
+
+func TestFetchSignatureBlob(t *testing.T) {
+	blob := []byte("envelope")
+	manifest := []byte("manifest")
+	manifestDesc := descriptorOf(manifest)
+
+	t.Run("matching blob", func(t *testing.T) {
+		repo := NewRepository(&mockRepository{blob: blob, blobDesc: descriptorOf(blob)}, nil)
+		if _, _, err := repo.FetchSignatureBlob(context.Background(), manifestDesc); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if err := repo.Err(); err != nil {
+			t.Fatalf("expected no tampering, got %v", err)
+		}
+	})
+
+	t.Run("tampered blob", func(t *testing.T) {
+		repo := NewRepository(&mockRepository{blob: []byte("tampered"), blobDesc: descriptorOf(blob)}, nil)
+		_, _, err := repo.FetchSignatureBlob(context.Background(), manifestDesc)
+		var tamperErr notationerrors.ErrorTamperDetected
+		if !errors.As(err, &tamperErr) {
+			t.Fatalf("expected ErrorTamperDetected, got %v", err)
+		}
+		if !errors.As(repo.Err(), &tamperErr) {
+			t.Fatalf("expected recorded ErrorTamperDetected, got %v", repo.Err())
+		}
+	})
+
+	t.Run("tampered manifest", func(t *testing.T) {
+		fetcher := mockFetcher{manifestDesc.Digest: []byte("tampered")}
+		repo := NewRepository(&mockRepository{blob: blob, blobDesc: descriptorOf(blob)}, fetcher)
+		_, _, err := repo.FetchSignatureBlob(context.Background(), manifestDesc)
+		var tamperErr notationerrors.ErrorTamperDetected
+		if !errors.As(err, &tamperErr) {
+			t.Fatalf("expected ErrorTamperDetected, got %v", err)
+		}
+	})
+
+	t.Run("matching manifest", func(t *testing.T) {
+		fetcher := mockFetcher{manifestDesc.Digest: manifest}
+		repo := NewRepository(&mockRepository{blob: blob, blobDesc: descriptorOf(blob)}, fetcher)
+		if _, _, err := repo.FetchSignatureBlob(context.Background(), manifestDesc); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	})
+}
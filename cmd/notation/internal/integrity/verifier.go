@@ -0,0 +1,81 @@
+package integrity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+	"github.com/notaryproject/notation/internal/envelope"
+	"github.com/notaryproject/notation/internal/skipper"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// Verifier wraps verifier to check that the subject descriptor signed by each
+// signature, i.e. the target artifact of its payload, matches the resolved
+// artifact exactly. A trusted signature signed for another artifact has been
+// attached to the artifact by the registry or a proxy, so the mismatch is
+// recorded as tampering instead of failing the signature only.
+func (r *Repository) Verifier(verifier notation.Verifier) notation.Verifier {
+	return &subjectVerifier{base: verifier, repo: r}
+}
+
+type subjectVerifier struct {
+	base notation.Verifier
+	repo *Repository
+}
+
+// SkipVerify validates whether the verification level is skip.
+func (v *subjectVerifier) SkipVerify(ctx context.Context, opts notation.VerifierVerifyOptions) (bool, *trustpolicy.VerificationLevel, error) {
+	return skipper.SkipVerify(ctx, v.base, opts)
+}
+
+// Verify verifies the signature with the wrapped verifier and checks its
+// subject descriptor against desc.
+func (v *subjectVerifier) Verify(ctx context.Context, desc ocispec.Descriptor, signature []byte, opts notation.VerifierVerifyOptions) (*notation.VerificationOutcome, error) {
+	outcome, err := v.base.Verify(ctx, desc, signature, opts)
+	if outcome == nil || outcome.EnvelopeContent == nil || !trusted(outcome) {
+		// the subject of a signature failing to be verified is not trusted
+		return outcome, err
+	}
+	var payload envelope.Payload
+	if jsonErr := json.Unmarshal(outcome.EnvelopeContent.Payload.Content, &payload); jsonErr != nil {
+		return outcome, err
+	}
+	if mismatch := subjectMismatch(desc, payload.TargetArtifact); mismatch != "" {
+		tamperErr := v.repo.record(fmt.Sprintf("subject of signature does not match the artifact %s: %s", desc.Digest, mismatch))
+		outcome.Error = tamperErr
+		return outcome, tamperErr
+	}
+	return outcome, err
+}
+
+// trusted returns true if no check enforced by the verification level of
+// outcome failed.
+func trusted(outcome *notation.VerificationOutcome) bool {
+	for _, result := range outcome.VerificationResults {
+		if result.Error != nil && result.Action == trustpolicy.ActionEnforce {
+			return false
+		}
+	}
+	return true
+}
+
+// subjectMismatch describes the differences of the digest, size and media
+// type of subject from the artifact of desc, or returns an empty string if
+// they match.
+func subjectMismatch(desc, subject ocispec.Descriptor) string {
+	var mismatches []string
+	if subject.Digest != desc.Digest {
+		mismatches = append(mismatches, fmt.Sprintf("signed digest %s", subject.Digest))
+	}
+	if subject.Size != desc.Size {
+		mismatches = append(mismatches, fmt.Sprintf("signed size %d, expected %d", subject.Size, desc.Size))
+	}
+	if subject.MediaType != desc.MediaType {
+		mismatches = append(mismatches, fmt.Sprintf("signed media type %q, expected %q", subject.MediaType, desc.MediaType))
+	}
+	return strings.Join(mismatches, "; ")
+}
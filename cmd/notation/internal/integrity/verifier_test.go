@@ -0,0 +1,95 @@
+package integrity
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/notaryproject/notation-core-go/signature"
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+	notationerrors "github.com/notaryproject/notation/cmd/notation/internal/errors"
+	"github.com/notaryproject/notation/internal/envelope"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// mockVerifier returns an outcome of a signature signed for subject.
+type mockVerifier struct {
+	subject ocispec.Descriptor
+	err     error
+}
+
+func (v *mockVerifier) Verify(ctx context.Context, desc ocispec.Descriptor, sig []byte, opts notation.VerifierVerifyOptions) (*notation.VerificationOutcome, error) {
+	payload, err := json.Marshal(envelope.Payload{TargetArtifact: v.subject})
+	if err != nil {
+		return nil, err
+	}
+	outcome := &notation.VerificationOutcome{
+		VerificationLevel: trustpolicy.LevelStrict,
+		VerificationResults: []*notation.ValidationResult{
+			{Type: trustpolicy.TypeIntegrity, Action: trustpolicy.ActionEnforce},
+			{Type: trustpolicy.TypeAuthenticity, Action: trustpolicy.ActionEnforce, Error: v.err},
+		},
+		EnvelopeContent: &signature.EnvelopeContent{
+			Payload: signature.Payload{ContentType: envelope.MediaTypePayloadV1, Content: payload},
+		},
+		Error: v.err,
+	}
+	return outcome, v.err
+}
+
+func TestVerifier(t *testing.T) {
+	artifact := []byte("manifest")
+	desc := ocispec.Descriptor{MediaType: ocispec.MediaTypeImageManifest, Digest: digest.FromBytes(artifact), Size: int64(len(artifact))}
+
+	t.Run("matching subject", func(t *testing.T) {
+		repo := NewRepository(&mockRepository{}, nil)
+		if _, err := repo.Verifier(&mockVerifier{subject: desc}).Verify(context.Background(), desc, nil, notation.VerifierVerifyOptions{}); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if err := repo.Err(); err != nil {
+			t.Fatalf("expected no tampering, got %v", err)
+		}
+	})
+
+	mismatches := map[string]ocispec.Descriptor{
+		"digest":     {MediaType: desc.MediaType, Digest: digest.FromString("other"), Size: desc.Size},
+		"size":       {MediaType: desc.MediaType, Digest: desc.Digest, Size: desc.Size + 1},
+		"media type": {MediaType: ocispec.MediaTypeImageIndex, Digest: desc.Digest, Size: desc.Size},
+	}
+	for name, subject := range mismatches {
+		t.Run("mismatching "+name, func(t *testing.T) {
+			repo := NewRepository(&mockRepository{}, nil)
+			outcome, err := repo.Verifier(&mockVerifier{subject: subject}).Verify(context.Background(), desc, nil, notation.VerifierVerifyOptions{})
+			var tamperErr notationerrors.ErrorTamperDetected
+			if !errors.As(err, &tamperErr) {
+				t.Fatalf("expected ErrorTamperDetected, got %v", err)
+			}
+			if !strings.Contains(err.Error(), "signed "+name) {
+				t.Fatalf("expected the %s mismatch to be reported, got %v", name, err)
+			}
+			if outcome.Error != err {
+				t.Fatalf("expected the outcome to fail with %v, got %v", err, outcome.Error)
+			}
+			if !errors.As(repo.Err(), &tamperErr) {
+				t.Fatalf("expected recorded ErrorTamperDetected, got %v", repo.Err())
+			}
+		})
+	}
+
+	t.Run("untrusted signature", func(t *testing.T) {
+		repo := NewRepository(&mockRepository{}, nil)
+		untrusted := errors.New("untrusted signer")
+		subject := mismatches["digest"]
+		_, err := repo.Verifier(&mockVerifier{subject: subject, err: untrusted}).Verify(context.Background(), desc, nil, notation.VerifierVerifyOptions{})
+		if err != untrusted {
+			t.Fatalf("expected %v, got %v", untrusted, err)
+		}
+		if err := repo.Err(); err != nil {
+			t.Fatalf("expected no tampering, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,197 @@
+package truststore
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	corex509 "github.com/notaryproject/notation-core-go/x509"
+	"github.com/notaryproject/notation-go/verifier/truststore"
+)
+
+// ManifestVersion is the version of the trust store manifest format.
+const ManifestVersion = "1.0"
+
+// Manifest summarizes the contents of a trust store, so that the trust stores
+// of two hosts can be compared without copying the certificates.
+type Manifest struct {
+	// Version is the version of the manifest format.
+	Version string `json:"version"`
+
+	// Host is the name of the host the trust store is on.
+	Host string `json:"host,omitempty"`
+
+	// Certificates are the certificates of the trust store, ordered by store
+	// type, named store, file name and position in the file.
+	Certificates []ManifestCertificate `json:"certificates"`
+}
+
+// ManifestCertificate is a certificate in a trust store.
+type ManifestCertificate struct {
+	// StoreType is the trust store type, e.g. "ca".
+	StoreType string `json:"storeType"`
+
+	// NamedStore is the name of the named store.
+	NamedStore string `json:"namedStore"`
+
+	// File is the name of the certificate file in the named store.
+	File string `json:"file"`
+
+	// Subject is the subject of the certificate.
+	Subject string `json:"subject"`
+
+	// Issuer is the issuer of the certificate.
+	Issuer string `json:"issuer"`
+
+	// NotAfter is the expiry time of the certificate.
+	NotAfter time.Time `json:"notAfter"`
+
+	// Thumbprint is the hex-encoded SHA-256 thumbprint of the certificate.
+	Thumbprint string `json:"sha256Thumbprint"`
+}
+
+// Store returns the trust store of the certificate in the format of trust
+// policies, i.e. "{storeType}:{namedStore}".
+func (c ManifestCertificate) Store() string {
+	return c.StoreType + ":" + c.NamedStore
+}
+
+// NewManifest summarizes the x509 trust store at root, i.e. the certificates
+// under root/{storeType}/{namedStore}. Directories other than the named
+// stores of the supported store types are ignored. A missing root results in
+// an empty manifest.
+func NewManifest(root string) (*Manifest, error) {
+	manifest := &Manifest{
+		Version:      ManifestVersion,
+		Certificates: []ManifestCertificate{},
+	}
+	manifest.Host, _ = os.Hostname()
+	for _, storeType := range truststore.Types {
+		storeTypeDir := filepath.Join(root, string(storeType))
+		namedStores, err := os.ReadDir(storeTypeDir)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		for _, namedStore := range namedStores {
+			if !namedStore.IsDir() {
+				continue
+			}
+			certs, err := manifestCertificates(filepath.Join(storeTypeDir, namedStore.Name()), string(storeType), namedStore.Name())
+			if err != nil {
+				return nil, err
+			}
+			manifest.Certificates = append(manifest.Certificates, certs...)
+		}
+	}
+	return manifest, nil
+}
+
+// manifestCertificates returns the certificates of the certificate files
+// directly in the named store at path.
+func manifestCertificates(path, storeType, namedStore string) ([]ManifestCertificate, error) {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	var result []ManifestCertificate
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		certs, err := corex509.ReadCertificateFile(filepath.Join(path, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read certificate file %s of named store %s of type %s: %w", entry.Name(), namedStore, storeType, err)
+		}
+		for _, cert := range certs {
+			sum := sha256.Sum256(cert.Raw)
+			result = append(result, ManifestCertificate{
+				StoreType:  storeType,
+				NamedStore: namedStore,
+				File:       entry.Name(),
+				Subject:    cert.Subject.String(),
+				Issuer:     cert.Issuer.String(),
+				NotAfter:   cert.NotAfter.UTC(),
+				Thumbprint: hex.EncodeToString(sum[:]),
+			})
+		}
+	}
+	return result, nil
+}
+
+// ReadManifest reads the trust store manifest at path.
+func ReadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("malformed trust store manifest %s: %w", path, err)
+	}
+	if manifest.Version != ManifestVersion {
+		return nil, fmt.Errorf("trust store manifest %s has unsupported version %q, expected %q", path, manifest.Version, ManifestVersion)
+	}
+	return &manifest, nil
+}
+
+// ManifestDiff is the difference between two trust store manifests.
+// Certificates are compared by their trust stores and thumbprints, so that
+// renamed certificate files are not reported as differences.
+type ManifestDiff struct {
+	// Added are the certificates only in the target manifest.
+	Added []ManifestCertificate `json:"added"`
+
+	// Removed are the certificates only in the base manifest.
+	Removed []ManifestCertificate `json:"removed"`
+}
+
+// Empty reports whether the manifests have the same certificates.
+func (d *ManifestDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0
+}
+
+// DiffManifests returns the certificates added to and removed from the trust
+// store of the base manifest in the target manifest.
+func DiffManifests(base, target *Manifest) *ManifestDiff {
+	return &ManifestDiff{
+		Added:   subtractCertificates(target.Certificates, base.Certificates),
+		Removed: subtractCertificates(base.Certificates, target.Certificates),
+	}
+}
+
+// subtractCertificates returns the certificates of a not in b, sorted by
+// trust store and file name.
+func subtractCertificates(a, b []ManifestCertificate) []ManifestCertificate {
+	type key struct{ store, thumbprint string }
+	seen := make(map[key]bool)
+	for _, cert := range b {
+		seen[key{cert.Store(), cert.Thumbprint}] = true
+	}
+	result := []ManifestCertificate{}
+	for _, cert := range a {
+		k := key{cert.Store(), cert.Thumbprint}
+		if seen[k] {
+			continue
+		}
+		// a certificate duplicated in a store is reported once
+		seen[k] = true
+		result = append(result, cert)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Store() != result[j].Store() {
+			return result[i].Store() < result[j].Store()
+		}
+		return result[i].File < result[j].File
+	})
+	return result
+}
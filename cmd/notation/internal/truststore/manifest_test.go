@@ -0,0 +1,138 @@
+package truststore
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func copyTestCert(t *testing.T, root, storeType, namedStore, name, testCert string) {
+	t.Helper()
+	data, err := os.ReadFile(filepath.FromSlash("../../../../internal/testdata/" + testCert))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(root, storeType, namedStore)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), data, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNewManifest(t *testing.T) {
+	root := t.TempDir()
+	copyTestCert(t, root, "ca", "acme-rockets", "root.pem", "NotationTestRoot.pem")
+	copyTestCert(t, root, "ca", "acme-rockets", "chain.pem", "CertChain.pem")
+	copyTestCert(t, root, "signingAuthority", "wabbit-networks", "globalsign.der", "GlobalSign.der")
+	copyTestCert(t, root, "unknown", "acme-rockets", "root.pem", "NotationTestRoot.pem")
+
+	manifest, err := NewManifest(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manifest.Version != ManifestVersion {
+		t.Fatalf("expected version %q, got %q", ManifestVersion, manifest.Version)
+	}
+	var files []string
+	for _, cert := range manifest.Certificates {
+		files = append(files, cert.Store()+"/"+cert.File)
+		if len(cert.Thumbprint) != 64 || cert.Subject == "" {
+			t.Fatalf("incomplete certificate summary %+v", cert)
+		}
+	}
+	// CertChain.pem contains two certificates
+	expected := []string{
+		"ca:acme-rockets/chain.pem",
+		"ca:acme-rockets/chain.pem",
+		"ca:acme-rockets/root.pem",
+		"signingAuthority:wabbit-networks/globalsign.der",
+	}
+	if !reflect.DeepEqual(files, expected) {
+		t.Fatalf("expected certificates %v, got %v", expected, files)
+	}
+
+	// missing trust store
+	manifest, err = NewManifest(filepath.Join(root, "missing"))
+	if err != nil || len(manifest.Certificates) != 0 {
+		t.Fatalf("expected empty manifest, got %+v, %v", manifest, err)
+	}
+
+	// invalid certificate file
+	if err := os.WriteFile(filepath.Join(root, "ca", "acme-rockets", "invalid.pem"), []byte("not a certificate"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewManifest(root); err == nil || !strings.Contains(err.Error(), "invalid.pem") {
+		t.Fatalf("expected error for the invalid certificate file, got %v", err)
+	}
+}
+
+func TestReadManifest(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "manifest.json")
+	if err := os.WriteFile(path, []byte(`{"version":"1.0","host":"build-01","certificates":[{"storeType":"ca","namedStore":"acme","file":"root.pem","sha256Thumbprint":"abc"}]}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	manifest, err := ReadManifest(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manifest.Host != "build-01" || len(manifest.Certificates) != 1 || manifest.Certificates[0].Store() != "ca:acme" {
+		t.Fatalf("unexpected manifest %+v", manifest)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"version":"2.0"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadManifest(path); err == nil || !strings.Contains(err.Error(), "unsupported version") {
+		t.Fatalf("expected unsupported version error, got %v", err)
+	}
+	if err := os.WriteFile(path, []byte(`{`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadManifest(path); err == nil || !strings.Contains(err.Error(), "malformed") {
+		t.Fatalf("expected malformed manifest error, got %v", err)
+	}
+}
+
+func TestDiffManifests(t *testing.T) {
+	cert := func(storeType, namedStore, file, thumbprint string) ManifestCertificate {
+		return ManifestCertificate{StoreType: storeType, NamedStore: namedStore, File: file, Thumbprint: thumbprint}
+	}
+	base := &Manifest{Certificates: []ManifestCertificate{
+		cert("ca", "acme", "root.pem", "aa"),
+		cert("ca", "acme", "old.pem", "bb"),
+		cert("ca", "wabbit", "root.pem", "cc"),
+	}}
+	target := &Manifest{Certificates: []ManifestCertificate{
+		// renamed file
+		cert("ca", "acme", "renamed.pem", "aa"),
+		// same certificate in another store
+		cert("signingAuthority", "wabbit", "root.pem", "cc"),
+		cert("ca", "acme", "new.pem", "dd"),
+		cert("ca", "acme", "new-copy.pem", "dd"),
+	}}
+	diff := DiffManifests(base, target)
+	expected := &ManifestDiff{
+		Added: []ManifestCertificate{
+			cert("ca", "acme", "new.pem", "dd"),
+			cert("signingAuthority", "wabbit", "root.pem", "cc"),
+		},
+		Removed: []ManifestCertificate{
+			cert("ca", "acme", "old.pem", "bb"),
+			cert("ca", "wabbit", "root.pem", "cc"),
+		},
+	}
+	if !reflect.DeepEqual(diff, expected) {
+		t.Fatalf("expected diff %+v, got %+v", expected, diff)
+	}
+	if diff.Empty() {
+		t.Fatal("expected non-empty diff")
+	}
+	if diff := DiffManifests(base, base); !diff.Empty() {
+		t.Fatalf("expected empty diff, got %+v", diff)
+	}
+}
@@ -14,9 +14,9 @@ import (
 
 	corex509 "github.com/notaryproject/notation-core-go/x509"
 	"github.com/notaryproject/notation-go/dir"
-	"github.com/notaryproject/notation-go/verifier/truststore"
 	"github.com/notaryproject/notation/cmd/notation/internal/cmdutil"
 	"github.com/notaryproject/notation/internal/osutil"
+	"github.com/notaryproject/notation/internal/policy"
 )
 
 // AddCert adds a single cert file at path to the trust store
@@ -185,9 +185,10 @@ func CheckNonErrNotExistError(err error) error {
 	return nil
 }
 
-// IsValidStoreType checks if storeType is supported
+// IsValidStoreType checks if storeType is supported, including the
+// experimental trust stores of timestamp authorities
 func IsValidStoreType(storeType string) bool {
-	for _, t := range truststore.Types {
+	for _, t := range policy.TrustStoreTypes {
 		if storeType == string(t) {
 			return true
 		}
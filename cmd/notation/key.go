@@ -6,10 +6,23 @@ import (
 	"fmt"
 	"github.com/notaryproject/notation-go/config"
 	"os"
+	"strings"
+	"time"
 
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation-go/dir"
 	"github.com/notaryproject/notation-go/log"
+	"github.com/notaryproject/notation-go/signer"
 	"github.com/notaryproject/notation/internal/cmd"
+	"github.com/notaryproject/notation/internal/color"
 	"github.com/notaryproject/notation/internal/ioutil"
+	"github.com/notaryproject/notation/internal/keychain"
+	"github.com/notaryproject/notation/internal/keyprobe"
+	"github.com/notaryproject/notation/internal/kms"
+	"github.com/notaryproject/notation/internal/pkcs11"
+	"github.com/notaryproject/notation/internal/pluginproto"
+	"github.com/notaryproject/notation/internal/sanity"
+	"github.com/notaryproject/notation/pkg/configutil"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 )
@@ -22,6 +35,30 @@ var (
 	setKeyDefaultFlag = func(fs *pflag.FlagSet, p *bool) {
 		fs.BoolVarP(p, keyDefaultFlag.Name, keyDefaultFlag.Shorthand, false, keyDefaultFlag.Usage)
 	}
+
+	keyPurposeFlag = &pflag.Flag{
+		Name:  "purpose",
+		Usage: fmt.Sprintf("purpose of the key, options: %q, %q. Keys with purpose %q cannot sign artifacts in the production registries configured in config.json", configutil.KeyPurposeProduction, configutil.KeyPurposeTest, configutil.KeyPurposeTest),
+	}
+	setKeyPurposeFlag = func(fs *pflag.FlagSet, p *string) {
+		fs.StringVar(p, keyPurposeFlag.Name, "", keyPurposeFlag.Usage)
+	}
+
+	keyNotBeforeFlag = &pflag.Flag{
+		Name:  "not-before",
+		Usage: "time in RFC 3339 format from which the key is allowed to sign, e.g. 2024-01-01T00:00:00Z",
+	}
+	setKeyNotBeforeFlag = func(fs *pflag.FlagSet, p *string) {
+		fs.StringVar(p, keyNotBeforeFlag.Name, "", keyNotBeforeFlag.Usage)
+	}
+
+	keyNotAfterFlag = &pflag.Flag{
+		Name:  "not-after",
+		Usage: "time in RFC 3339 format after which the key is not allowed to sign, e.g. 2025-01-01T00:00:00Z",
+	}
+	setKeyNotAfterFlag = func(fs *pflag.FlagSet, p *string) {
+		fs.StringVar(p, keyNotAfterFlag.Name, "", keyNotAfterFlag.Usage)
+	}
 )
 
 type keyAddOpts struct {
@@ -30,13 +67,34 @@ type keyAddOpts struct {
 	plugin       string
 	id           string
 	pluginConfig []string
+	allowSecrets bool
 	isDefault    bool
+	purpose      string
+	notBefore    string
+	notAfter     string
+	skipProbe    bool
+	pkcs11       configutil.PKCS11Key
+	kms          configutil.KMSKey
+	awsKMS       string
+	akv          string
+	vault        vaultTransitOpts
+	keychain     configutil.KeychainKey
+}
+
+type keyListOpts struct {
+	cmd.LoggingFlagOpts
+	akv string
 }
 
 type keyUpdateOpts struct {
 	cmd.LoggingFlagOpts
-	name      string
-	isDefault bool
+	name         string
+	isDefault    bool
+	purpose      string
+	notBefore    string
+	notAfter     string
+	notBeforeSet bool
+	notAfterSet  bool
 }
 
 type keyDeleteOpts struct {
@@ -53,17 +111,32 @@ func keyCommand() *cobra.Command {
 Example - Add a key to signing key list:
   notation key add --plugin <plugin_name> --id <key_id> <key_name>
 
+Example - Import a local key and its certificate chain to signing key list:
+  notation key import --key <key_file> --cert <certificate_file> <key_name>
+
 Example - List keys used for signing:
   notation key ls
 
 Example - Update the default signing key:
   notation key set --default <key_name>
 
+Example - List the keys and certificates in an Azure Key Vault, which can be added with flag "--akv":
+  notation key list --akv https://<vault>.vault.azure.net
+
+Example - Describe a signing key in a key management service:
+  notation key describe <key_name>
+
 Example - Delete the key from signing key list:
   notation key delete <key_name>...
+
+Example - [Experimental] Generate an ML-DSA key for post-quantum signatures:
+  notation key generate-mldsa <key_name>
+
+Example - [Experimental] Require the approvals of 2 of 3 approvers before the key signs:
+  notation key ceremony init --threshold 2 --approver alice=alice.crt --approver bob=bob.crt --approver carol=carol.crt <key_name>
 `,
 	}
-	command.AddCommand(keyAddCommand(nil), keyUpdateCommand(nil), keyListCommand(), keyDeleteCommand(nil))
+	command.AddCommand(keyAddCommand(nil), keyImportCommand(nil), keyUpdateCommand(nil), keyListCommand(nil), keyDescribeCommand(nil), keyDeleteCommand(nil), keyGenerateMLDSACommand(nil), keyCeremonyCommand())
 
 	return command
 }
@@ -72,9 +145,44 @@ func keyAddCommand(opts *keyAddOpts) *cobra.Command {
 	if opts == nil {
 		opts = &keyAddOpts{}
 	}
+	long := `Add key to signing key list
+
+Example - Add a key of a plugin:
+  notation key add --plugin <plugin_name> --id <key_id> <key_name>
+`
+	if pkcs11.Supported() {
+		long += `
+Example - Add a key in a PKCS #11 token, e.g. a hardware security module, signing without plugin:
+  export NOTATION_PKCS11_PIN=<user_pin>
+  notation key add --pkcs11-module /usr/lib/softhsm/libsofthsm2.so --pkcs11-slot 0 --pkcs11-label <key_label> <key_name>
+`
+	}
+	if keychain.Supported() {
+		long += `
+Example - Add a signing identity of the macOS Keychain, e.g. of the login keychain or in the Secure Enclave, signing without plugin:
+  notation key add --keychain "<certificate_common_name>" <key_name>
+`
+	}
+	long += `
+Example - Add a key in AWS KMS signing without plugin, with the credentials of the environment, a profile or an IAM role:
+  notation key add --aws-kms alias/<alias> --kms-cert <certificate_file> <key_name>
+
+Example - Add a key in AWS KMS with the certificate chain in an S3 bucket, fetched at every signing so that renewed certificates are picked up:
+  notation key add --aws-kms arn:aws:kms:<region>:<account>:key/<key_id> --kms-cert s3://<bucket>/<object> <key_name>
+
+Example - Add a certificate and its key in Azure Key Vault signing without plugin, with the credentials of the environment, a managed identity or the Azure CLI:
+  notation key add --akv https://<vault>.vault.azure.net/certificates/<certificate> <key_name>
+
+Example - Add a key in the Transit secrets engine of HashiCorp Vault signing without plugin, with the token of the environment variable VAULT_TOKEN or of "vault login":
+  notation key add --vault-addr https://<vault_host>:8200 --vault-key <key> --kms-cert <certificate_file> <key_name>
+
+Example - Add a key in a namespace of HashiCorp Vault, logging in with AppRole and the secret ID of the environment variable VAULT_SECRET_ID:
+  notation key add --vault-addr https://<vault_host>:8200 --vault-namespace <namespace> --vault-role-id <role_id> --vault-key <key> --kms-cert <certificate_file> <key_name>
+`
 	command := &cobra.Command{
 		Use:   "add --plugin <plugin_name> [flags] <key_name>",
 		Short: "Add key to signing key list",
+		Long:  long,
 		Args: func(cmd *cobra.Command, args []string) error {
 			if len(args) != 1 {
 				return errors.New("either missing key name or unnecessary parameters passed")
@@ -87,13 +195,45 @@ func keyAddCommand(opts *keyAddOpts) *cobra.Command {
 		},
 	}
 	opts.LoggingFlagOpts.ApplyFlags(command.Flags())
-	command.Flags().StringVar(&opts.plugin, "plugin", "", "signing plugin name")
-	command.MarkFlagRequired("plugin")
+	alternatives := keySignerFlags()
+	command.Flags().StringVar(&opts.plugin, "plugin", "", fmt.Sprintf("signing plugin name, required unless %s or %s is set", strings.Join(alternatives[:len(alternatives)-1], ", "), alternatives[len(alternatives)-1]))
 
 	command.Flags().StringVar(&opts.id, "id", "", "key id (required if --plugin is set)")
 
 	cmd.SetPflagPluginConfig(command.Flags(), &opts.pluginConfig)
+	command.Flags().BoolVar(&opts.allowSecrets, "allow-plaintext-secrets", false, "allow plaintext secrets, e.g. passwords or tokens, in the plugin config, which is recorded in signingkeys.json")
 	setKeyDefaultFlag(command.Flags(), &opts.isDefault)
+	setKeyPurposeFlag(command.Flags(), &opts.purpose)
+	setKeyNotBeforeFlag(command.Flags(), &opts.notBefore)
+	setKeyNotAfterFlag(command.Flags(), &opts.notAfter)
+	command.Flags().BoolVar(&opts.skipProbe, "skip-validation", false, "skip signing a probe artifact to validate the key against the signature envelope formats, e.g. if the key is not accessible yet")
+	command.Flags().StringVar(&opts.pkcs11.Module, "pkcs11-module", "", "path of the PKCS #11 module of the token of the key, for keys signing without plugin. The user PIN of the token is read from the environment variable "+pkcs11.PINEnv)
+	command.Flags().UintVar(&opts.pkcs11.Slot, "pkcs11-slot", 0, "slot ID of the token of the PKCS #11 key")
+	command.Flags().StringVar(&opts.pkcs11.Label, "pkcs11-label", "", "label of the private key in the PKCS #11 token (required if --pkcs11-module is set)")
+	command.Flags().StringVar(&opts.pkcs11.CertificatePath, "pkcs11-cert", "", "PEM file of the certificate chain of the PKCS #11 key, starting with the signing certificate. The certificate of the token with the label of the key is used if not set")
+	command.Flags().StringVar(&opts.kms.URI, "kms-uri", "", fmt.Sprintf("URI of the key in a key management service, for keys signing without plugin, e.g. awskms:///alias/<alias>, azurekv://<vault>.vault.azure.net/<key>, gcpkms://projects/<project>/locations/<location>/keyRings/<key_ring>/cryptoKeys/<key>/cryptoKeyVersions/<version> or hashivault://<key>. Supported schemes of this build: %s", strings.Join(kms.Supported(), ", ")))
+	command.Flags().StringVar(&opts.awsKMS, "aws-kms", "", "ID, alias name (alias/<alias>) or ARN of the key in AWS KMS, for keys signing without plugin, a shorthand of --kms-uri awskms:///<key>. The credentials are resolved from the environment, the shared config and credentials files, or the IAM role of the ECS task or the EC2 instance. Profiles of IAM Identity Center (SSO) and roles requiring MFA are not supported")
+	command.Flags().StringVar(&opts.akv, "akv", "", "ID of the key or the certificate in Azure Key Vault, for keys signing without plugin, e.g. https://<vault>.vault.azure.net/certificates/<certificate>[/<version>]. The certificate chain is fetched from the certificate of the same name in the vault unless --kms-cert is set")
+	command.Flags().StringVar(&opts.vault.key, "vault-key", "", "name of the key in the Transit secrets engine of HashiCorp Vault, for keys signing without plugin, a shorthand of --kms-uri hashivault://<key>. The token is read from the environment variable VAULT_TOKEN or from \"vault login\", unless --vault-role-id is set")
+	command.Flags().StringVar(&opts.vault.addr, "vault-addr", "", "address of HashiCorp Vault, e.g. https://<vault_host>:8200. The environment variable VAULT_ADDR is read at signing if not set")
+	command.Flags().StringVar(&opts.vault.namespace, "vault-namespace", "", "namespace of the key in HashiCorp Vault Enterprise. The environment variable VAULT_NAMESPACE is read at signing if not set")
+	command.Flags().StringVar(&opts.vault.mount, "vault-mount", "", "path of the Transit secrets engine in HashiCorp Vault, \"transit\" by default")
+	command.Flags().StringVar(&opts.vault.roleID, "vault-role-id", "", "role ID of AppRole to log in to HashiCorp Vault with the secret ID of the environment variable VAULT_SECRET_ID, instead of a token")
+	command.Flags().StringVar(&opts.kms.CertificatePath, "kms-cert", "", "location of the PEM certificate chain of the KMS key, starting with the signing certificate: a file path, or a location in the store of the KMS fetched at signing, e.g. s3://<bucket>/<object> or ssm:///<parameter> for keys in AWS KMS, or azurekv://<vault>.vault.azure.net/certificates/<certificate> for keys in Azure Key Vault (required if --kms-uri, --aws-kms or --vault-key is set)")
+	command.Flags().StringVar(&opts.keychain.Identity, "keychain", "", "common name of the certificate, or SHA-1 fingerprint of the certificate as printed by \"security find-identity\", of a signing identity of the macOS Keychain, for keys signing without plugin. The certificate chain is built from the certificates of the Keychain unless --keychain-cert is set")
+	command.Flags().StringVar(&opts.keychain.CertificatePath, "keychain-cert", "", "PEM file of the certificate chain of the Keychain identity, starting with the signing certificate")
+	command.MarkFlagsMutuallyExclusive("plugin", "pkcs11-module", "kms-uri", "aws-kms", "akv", "vault-key", "keychain")
+	if !pkcs11.Supported() {
+		// rejected by addKey
+		for _, name := range []string{"pkcs11-module", "pkcs11-slot", "pkcs11-label", "pkcs11-cert"} {
+			command.Flags().MarkHidden(name)
+		}
+	}
+	if !keychain.Supported() {
+		// rejected by addKey
+		command.Flags().MarkHidden("keychain")
+		command.Flags().MarkHidden("keychain-cert")
+	}
 
 	return command
 }
@@ -114,25 +254,39 @@ func keyUpdateCommand(opts *keyUpdateOpts) *cobra.Command {
 			return nil
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.notBeforeSet = cmd.Flags().Changed(keyNotBeforeFlag.Name)
+			opts.notAfterSet = cmd.Flags().Changed(keyNotAfterFlag.Name)
 			return updateKey(cmd.Context(), opts)
 		},
 	}
 
 	opts.LoggingFlagOpts.ApplyFlags(command.Flags())
 	setKeyDefaultFlag(command.Flags(), &opts.isDefault)
+	setKeyPurposeFlag(command.Flags(), &opts.purpose)
+	command.Flags().StringVar(&opts.notBefore, keyNotBeforeFlag.Name, "", keyNotBeforeFlag.Usage+", an empty value removes it")
+	command.Flags().StringVar(&opts.notAfter, keyNotAfterFlag.Name, "", keyNotAfterFlag.Usage+", an empty value removes it")
 
 	return command
 }
 
-func keyListCommand() *cobra.Command {
-	return &cobra.Command{
+func keyListCommand(opts *keyListOpts) *cobra.Command {
+	if opts == nil {
+		opts = &keyListOpts{}
+	}
+	command := &cobra.Command{
 		Use:     "list [flags]",
 		Aliases: []string{"ls"},
 		Short:   "List keys used for signing",
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.akv != "" {
+				return listAzureKeyVaultKeys(cmd.Context(), opts)
+			}
 			return listKeys()
 		},
 	}
+	opts.LoggingFlagOpts.ApplyFlags(command.Flags())
+	command.Flags().StringVar(&opts.akv, "akv", "", "URL of an Azure Key Vault, e.g. https://<vault>.vault.azure.net, to list the keys and the certificates in the vault instead of the signing keys")
+	return command
 }
 
 func keyDeleteCommand(opts *keyDeleteOpts) *cobra.Command {
@@ -163,60 +317,398 @@ func addKey(ctx context.Context, opts *keyAddOpts) error {
 	// set log level
 	ctx = opts.LoggingFlagOpts.SetLoggerLevel(ctx)
 
+	if opts.pkcs11 != (configutil.PKCS11Key{}) && !pkcs11.Supported() {
+		return pkcs11.ErrUnsupported
+	}
+	if opts.pkcs11.Module != "" {
+		return addPKCS11Key(ctx, opts)
+	}
+	if opts.keychain != (configutil.KeychainKey{}) {
+		if !keychain.Supported() {
+			return keychain.ErrUnsupported
+		}
+		return addKeychainKey(ctx, opts)
+	}
+	if opts.awsKMS != "" {
+		opts.kms.URI = "awskms:///" + opts.awsKMS
+	}
+	if opts.akv != "" {
+		keyURI, certificateLocation, err := parseAzureKeyVaultID(opts.akv)
+		if err != nil {
+			return err
+		}
+		opts.kms.URI = keyURI
+		if opts.kms.CertificatePath == "" {
+			opts.kms.CertificatePath = certificateLocation
+		}
+	}
+	if opts.vault.isSet() {
+		if opts.kms.URI != "" || opts.plugin != "" {
+			return errors.New("flags \"--vault-addr\", \"--vault-namespace\", \"--vault-mount\" and \"--vault-role-id\" are only supported with flag \"--vault-key\"")
+		}
+		keyURI, err := vaultTransitURI(opts.vault)
+		if err != nil {
+			return err
+		}
+		opts.kms.URI = keyURI
+	}
+	if opts.kms.URI != "" {
+		return addKMSKey(ctx, opts)
+	}
+	if opts.plugin == "" {
+		alternatives := keySignerFlags()
+		return fmt.Errorf("one of flags \"--plugin\", \"%s\" or \"%s\" is required", strings.Join(alternatives[:len(alternatives)-1], "\", \""), alternatives[len(alternatives)-1])
+	}
 	pluginConfig, err := cmd.ParseFlagMap(opts.pluginConfig, cmd.PflagPluginConfig.Name)
 	if err != nil {
 		return err
 	}
+	// the plugin config is recorded in signingkeys.json in plaintext
+	findings := sanity.ScanMap("", pluginConfig)
+	if secrets := sanity.Secrets(findings); len(secrets) > 0 && !opts.allowSecrets {
+		return fmt.Errorf("refusing to record plaintext secrets of the plugin config in signingkeys.json: %s. Configure the plugin to read the secrets from a secret store or the environment, or use flag \"--allow-plaintext-secrets\"", secrets[0])
+	}
+	warnPluginConfig(pluginConfig)
+	var purposes map[string]string
+	if opts.purpose != "" {
+		if err := configutil.ValidateKeyPurpose(opts.purpose); err != nil {
+			return err
+		}
+		purposes = map[string]string{opts.name: opts.purpose}
+	}
+	validity, err := parseKeyAddValidity(opts)
+	if err != nil {
+		return err
+	}
+
+	// fail fast on keys which cannot sign rather than at the first signing
+	var formats []string
+	if !opts.skipProbe {
+		if formats, err = probeKey(ctx, opts.plugin, opts.id, pluginConfig); err != nil {
+			return err
+		}
+	}
 
 	// core process
 	exec := func(s *config.SigningKeys) error {
 		return s.AddPlugin(ctx, opts.name, opts.id, opts.plugin, pluginConfig, opts.isDefault)
 	}
-	if err := config.LoadExecSaveSigningKeys(exec); err != nil {
+	if err := configutil.LoadExecSaveSigningKeys(exec, purposes); err != nil {
 		return err
 	}
+	if validity.NotBefore != nil || validity.NotAfter != nil {
+		if err := configutil.SetKeyValidity(opts.name, validity); err != nil {
+			return err
+		}
+	}
 
 	if opts.isDefault {
 		fmt.Printf("%s: marked as default\n", opts.name)
 	} else {
 		fmt.Println(opts.name)
 	}
+	if len(formats) > 0 {
+		fmt.Printf("Signature envelope formats: %s\n", strings.Join(formats, ", "))
+	}
+
+	return nil
+}
+
+// keySignerFlags returns the flags of "notation key add" setting a key signing
+// without plugin, which are supported by this build of notation.
+func keySignerFlags() []string {
+	var flags []string
+	if pkcs11.Supported() {
+		flags = append(flags, "--pkcs11-module")
+	}
+	flags = append(flags, "--kms-uri", "--aws-kms", "--akv", "--vault-key")
+	if keychain.Supported() {
+		flags = append(flags, "--keychain")
+	}
+	return flags
+}
+
+// addPKCS11Key adds the key in a PKCS #11 token of opts.
+func addPKCS11Key(ctx context.Context, opts *keyAddOpts) error {
+	if err := opts.pkcs11.Validate(); err != nil {
+		return err
+	}
+	openSigner := func() (notation.Signer, func() error, error) {
+		s, err := cmd.NewPKCS11Signer(opts.pkcs11)
+		if err != nil {
+			return nil, nil, err
+		}
+		return s, s.Close, nil
+	}
+	return addKeyWithoutPlugin(ctx, opts, openSigner, func() error {
+		return configutil.AddPKCS11Key(opts.name, opts.pkcs11, opts.purpose, opts.isDefault)
+	})
+}
+
+// addKeychainKey adds the signing identity of the macOS Keychain of opts.
+func addKeychainKey(ctx context.Context, opts *keyAddOpts) error {
+	if opts.keychain.Identity == "" {
+		return errors.New("flag \"--keychain-cert\" is only supported with flag \"--keychain\"")
+	}
+	openSigner := func() (notation.Signer, func() error, error) {
+		s, err := cmd.NewKeychainSigner(opts.keychain)
+		if err != nil {
+			return nil, nil, err
+		}
+		return s, s.Close, nil
+	}
+	return addKeyWithoutPlugin(ctx, opts, openSigner, func() error {
+		return configutil.AddKeychainKey(opts.name, opts.keychain, opts.purpose, opts.isDefault)
+	})
+}
+
+// addKMSKey adds the key in a KMS of opts.
+func addKMSKey(ctx context.Context, opts *keyAddOpts) error {
+	if err := opts.kms.Validate(); err != nil {
+		return err
+	}
+	openSigner := func() (notation.Signer, func() error, error) {
+		s, err := cmd.NewKMSSigner(ctx, opts.kms)
+		if err != nil {
+			return nil, nil, err
+		}
+		return s, func() error { return nil }, nil
+	}
+	return addKeyWithoutPlugin(ctx, opts, openSigner, func() error {
+		return configutil.AddKMSKey(opts.name, opts.kms, opts.purpose, opts.isDefault)
+	})
+}
+
+// addKeyWithoutPlugin adds the key of opts signing without plugin with add,
+// after signing a probe artifact with the signer of openSigner unless the
+// validation is skipped.
+func addKeyWithoutPlugin(ctx context.Context, opts *keyAddOpts, openSigner func() (notation.Signer, func() error, error), add func() error) error {
+	if opts.id != "" || len(opts.pluginConfig) > 0 {
+		return errors.New("flags \"--id\" and \"--plugin-config\" are only supported for keys of plugins")
+	}
+	if opts.purpose != "" {
+		if err := configutil.ValidateKeyPurpose(opts.purpose); err != nil {
+			return err
+		}
+	}
+	validity, err := parseKeyAddValidity(opts)
+	if err != nil {
+		return err
+	}
+
+	// fail fast on keys which cannot sign rather than at the first signing
+	var formats []string
+	if !opts.skipProbe {
+		s, closeSigner, err := openSigner()
+		if err != nil {
+			return err
+		}
+		formats, err = checkProbeResults(ctx, s, nil)
+		closeSigner()
+		if err != nil {
+			return err
+		}
+	}
 
+	// core process
+	if err := add(); err != nil {
+		return err
+	}
+	if validity.NotBefore != nil || validity.NotAfter != nil {
+		if err := configutil.SetKeyValidity(opts.name, validity); err != nil {
+			return err
+		}
+	}
+
+	if opts.isDefault {
+		fmt.Printf("%s: marked as default\n", opts.name)
+	} else {
+		fmt.Println(opts.name)
+	}
+	if len(formats) > 0 {
+		fmt.Printf("Signature envelope formats: %s\n", strings.Join(formats, ", "))
+	}
 	return nil
 }
 
+// parseKeyAddValidity parses and validates the validity window of the key of
+// opts.
+func parseKeyAddValidity(opts *keyAddOpts) (configutil.KeyValidity, error) {
+	var validity configutil.KeyValidity
+	var err error
+	if validity.NotBefore, err = parseKeyValidityTime(keyNotBeforeFlag.Name, opts.notBefore); err != nil {
+		return configutil.KeyValidity{}, err
+	}
+	if validity.NotAfter, err = parseKeyValidityTime(keyNotAfterFlag.Name, opts.notAfter); err != nil {
+		return configutil.KeyValidity{}, err
+	}
+	return validity, validity.Validate()
+}
+
+// probeKey signs a probe artifact with the key of the plugin in each
+// signature envelope format, and returns the formats the key can produce.
+// Formats the key cannot produce are reported as warnings, and the key is
+// rejected if it cannot produce any.
+func probeKey(ctx context.Context, pluginName, keyID string, pluginConfig map[string]string) ([]string, error) {
+	mgr := pluginproto.NewCLIManager(dir.PluginFS())
+	pl, err := mgr.Get(ctx, pluginName)
+	if err != nil {
+		return nil, err
+	}
+	s, err := signer.NewFromPlugin(pl, keyID, pluginConfig)
+	if err != nil {
+		return nil, err
+	}
+	return checkProbeResults(ctx, s, pluginConfig)
+}
+
+// checkProbeResults probes signer and reports the formats it cannot produce.
+func checkProbeResults(ctx context.Context, s notation.Signer, pluginConfig map[string]string) ([]string, error) {
+	results, err := keyprobe.Probe(ctx, s, pluginConfig, keyprobe.Formats)
+	if err != nil {
+		return nil, err
+	}
+	formats := keyprobe.Supported(results)
+	var reasons []string
+	for _, result := range results {
+		if result.Err == nil {
+			log.GetLogger(ctx).Infof("Key can sign in envelope format %s with %s, signing certificate %q", result.Format, result.SignatureAlgorithm, result.Certificate.Subject)
+			continue
+		}
+		reasons = append(reasons, fmt.Sprintf("%s: %v", result.Format, result.Err))
+		if len(formats) > 0 {
+			fmt.Fprintf(os.Stderr, "%s the key cannot sign in envelope format %s: %v\n", color.Warning(os.Stderr, "Warning:"), result.Format, result.Err)
+		}
+	}
+	if len(formats) == 0 {
+		return nil, fmt.Errorf("the key cannot sign in any signature envelope format, use flag \"--skip-validation\" to add it anyway: %s", strings.Join(reasons, "; "))
+	}
+	return formats, nil
+}
+
 func updateKey(ctx context.Context, opts *keyUpdateOpts) error {
 	// set log level
 	ctx = opts.LoggingFlagOpts.SetLoggerLevel(ctx)
 	logger := log.GetLogger(ctx)
 
-	if !opts.isDefault {
-		logger.Warn("--default flag is not set, command did not take effect")
+	updateValidity := opts.notBeforeSet || opts.notAfterSet
+	if !opts.isDefault && opts.purpose == "" && !updateValidity {
+		logger.Warn("none of --default, --purpose, --not-before and --not-after flags is set, command did not take effect")
 		return nil
 	}
+	var purposes map[string]string
+	if opts.purpose != "" {
+		if err := configutil.ValidateKeyPurpose(opts.purpose); err != nil {
+			return err
+		}
+		purposes = map[string]string{opts.name: opts.purpose}
+	}
+	var validity configutil.KeyValidity
+	if updateValidity {
+		validities, err := configutil.LoadKeyValidities()
+		if err != nil {
+			return err
+		}
+		validity = validities[opts.name]
+		if opts.notBeforeSet {
+			if validity.NotBefore, err = parseKeyValidityTime(keyNotBeforeFlag.Name, opts.notBefore); err != nil {
+				return err
+			}
+		}
+		if opts.notAfterSet {
+			if validity.NotAfter, err = parseKeyValidityTime(keyNotAfterFlag.Name, opts.notAfter); err != nil {
+				return err
+			}
+		}
+		if err := validity.Validate(); err != nil {
+			return err
+		}
+	}
 
 	// core process
 	exec := func(s *config.SigningKeys) error {
-		return s.UpdateDefault(opts.name)
+		if opts.isDefault {
+			return s.UpdateDefault(opts.name)
+		}
+		_, err := s.Get(opts.name)
+		return err
 	}
-	if err := config.LoadExecSaveSigningKeys(exec); err != nil {
+	if err := configutil.LoadExecSaveSigningKeys(exec, purposes); err != nil {
 		return err
 	}
+	if updateValidity {
+		if err := configutil.SetKeyValidity(opts.name, validity); err != nil {
+			return err
+		}
+	}
 
 	// write out
-	fmt.Printf("%s: marked as default\n", opts.name)
+	if opts.isDefault {
+		fmt.Printf("%s: marked as default\n", opts.name)
+	}
+	if opts.purpose != "" {
+		fmt.Printf("%s: marked as %s key\n", opts.name, opts.purpose)
+	}
+	if updateValidity {
+		fmt.Printf("%s: valid %s\n", opts.name, formatKeyValidity(validity))
+	}
 	return nil
 }
 
+// parseKeyValidityTime parses the value of the flag with the name in RFC 3339
+// format. nil is returned for an empty value.
+func parseKeyValidityTime(name, value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value of flag \"--%s\", expecting RFC 3339 format, e.g. 2024-01-01T00:00:00Z: %w", name, err)
+	}
+	return &t, nil
+}
+
+// formatKeyValidity formats the validity window of a key for display.
+func formatKeyValidity(validity configutil.KeyValidity) string {
+	notBefore, notAfter := "any time", "any time"
+	if validity.NotBefore != nil {
+		notBefore = validity.NotBefore.Format(time.RFC3339)
+	}
+	if validity.NotAfter != nil {
+		notAfter = validity.NotAfter.Format(time.RFC3339)
+	}
+	return fmt.Sprintf("from %s to %s", notBefore, notAfter)
+}
+
 func listKeys() error {
 	// core process
 	signingKeys, err := config.LoadSigningKeys()
 	if err != nil {
 		return err
 	}
+	purposes, err := configutil.LoadKeyPurposes()
+	if err != nil {
+		return err
+	}
+	validities, err := configutil.LoadKeyValidities()
+	if err != nil {
+		return err
+	}
+	pkcs11Keys, err := configutil.LoadPKCS11Keys()
+	if err != nil {
+		return err
+	}
+	kmsKeys, err := configutil.LoadKMSKeys()
+	if err != nil {
+		return err
+	}
+	keychainKeys, err := configutil.LoadKeychainKeys()
+	if err != nil {
+		return err
+	}
 
 	// write out
-	return ioutil.PrintKeyMap(os.Stdout, signingKeys.Default, signingKeys.Keys)
+	return ioutil.PrintKeyMap(os.Stdout, signingKeys.Default, signingKeys.Keys, purposes, validities, pkcs11Keys, kmsKeys, keychainKeys)
 }
 
 func deleteKeys(ctx context.Context, opts *keyDeleteOpts) error {
@@ -238,7 +730,7 @@ func deleteKeys(ctx context.Context, opts *keyDeleteOpts) error {
 		}
 		return err
 	}
-	if err := config.LoadExecSaveSigningKeys(exec); err != nil {
+	if err := configutil.LoadExecSaveSigningKeys(exec, nil); err != nil {
 		return err
 	}
 
@@ -1,8 +1,30 @@
 package main
 
 import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io/fs"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/notaryproject/notation-go/dir"
+	"github.com/notaryproject/notation/internal/keychain"
+	"github.com/notaryproject/notation/internal/pkcs11"
+	"github.com/notaryproject/notation/pkg/configutil"
 )
 
 func TestKeyAddCommand_BasicArgs(t *testing.T) {
@@ -13,11 +35,148 @@ func TestKeyAddCommand_BasicArgs(t *testing.T) {
 		plugin:       "pluginname",
 		id:           "pluginid",
 		pluginConfig: []string{"pluginconfig"},
+		skipProbe:    true,
 	}
 	if err := cmd.ParseFlags([]string{
 		"--plugin", expected.plugin,
 		"--id", expected.id,
 		"--plugin-config", "pluginconfig",
+		"--skip-validation",
+		expected.name}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := cmd.Args(cmd, cmd.Flags().Args()); err != nil {
+		t.Fatalf("Parse Args failed: %v", err)
+	}
+	if !reflect.DeepEqual(*expected, *opts) {
+		t.Fatalf("Expect key add opts: %v, got: %v", expected, opts)
+	}
+}
+
+func TestKeyAddCommand_PKCS11(t *testing.T) {
+	opts := &keyAddOpts{}
+	cmd := keyAddCommand(opts)
+	expected := &keyAddOpts{
+		name:      "name",
+		isDefault: true,
+		pkcs11: configutil.PKCS11Key{
+			Module:          "/usr/lib/softhsm/libsofthsm2.so",
+			Slot:            3,
+			Label:           "release",
+			CertificatePath: "chain.pem",
+		},
+	}
+	if err := cmd.ParseFlags([]string{
+		"--pkcs11-module", expected.pkcs11.Module,
+		"--pkcs11-slot", "3",
+		"--pkcs11-label", expected.pkcs11.Label,
+		"--pkcs11-cert", expected.pkcs11.CertificatePath,
+		"--default",
+		expected.name}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := cmd.Args(cmd, cmd.Flags().Args()); err != nil {
+		t.Fatalf("Parse Args failed: %v", err)
+	}
+	if !reflect.DeepEqual(*expected, *opts) {
+		t.Fatalf("Expect key add opts: %v, got: %v", expected, opts)
+	}
+}
+
+func TestAddKey_PKCS11(t *testing.T) {
+	defer func(oldDir string) {
+		dir.UserConfigDir = oldDir
+	}(dir.UserConfigDir)
+	dir.UserConfigDir = t.TempDir()
+
+	if !pkcs11.Supported() {
+		pkcs11Key := configutil.PKCS11Key{Module: "/usr/lib/softhsm/libsofthsm2.so", Slot: 1, Label: "release"}
+		if err := addKey(context.Background(), &keyAddOpts{name: "name", pkcs11: pkcs11Key, skipProbe: true}); !errors.Is(err, pkcs11.ErrUnsupported) {
+			t.Fatalf("expected PKCS #11 keys to be unsupported, got %v", err)
+		}
+		t.Skip("PKCS #11 keys are not supported without cgo")
+	}
+	if err := addKey(context.Background(), &keyAddOpts{name: "name"}); err == nil || !strings.Contains(err.Error(), "--pkcs11-module") {
+		t.Fatalf("expected error for a key without plugin or PKCS #11 module, got %v", err)
+	}
+	pkcs11Key := configutil.PKCS11Key{Module: "/usr/lib/softhsm/libsofthsm2.so", Slot: 1, Label: "release"}
+	if err := addKey(context.Background(), &keyAddOpts{name: "name", id: "id", pkcs11: pkcs11Key, skipProbe: true}); err == nil || !strings.Contains(err.Error(), "only supported for keys of plugins") {
+		t.Fatalf("expected error for a key id of a PKCS #11 key, got %v", err)
+	}
+	if err := addKey(context.Background(), &keyAddOpts{name: "name", pkcs11: pkcs11Key, purpose: configutil.KeyPurposeTest, skipProbe: true}); err != nil {
+		t.Fatalf("addKey() error = %v", err)
+	}
+	got, err := configutil.LoadPKCS11Key("name")
+	if err != nil || got == nil || *got != pkcs11Key {
+		t.Fatalf("expected the PKCS #11 key to be added, got %+v, %v", got, err)
+	}
+}
+
+func TestKeyAddCommand_Keychain(t *testing.T) {
+	opts := &keyAddOpts{}
+	cmd := keyAddCommand(opts)
+	expected := &keyAddOpts{
+		name: "name",
+		keychain: configutil.KeychainKey{
+			Identity:        "Developer ID Application: Example",
+			CertificatePath: "chain.pem",
+		},
+	}
+	if err := cmd.ParseFlags([]string{
+		"--keychain", expected.keychain.Identity,
+		"--keychain-cert", expected.keychain.CertificatePath,
+		expected.name}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := cmd.Args(cmd, cmd.Flags().Args()); err != nil {
+		t.Fatalf("Parse Args failed: %v", err)
+	}
+	if !reflect.DeepEqual(*expected, *opts) {
+		t.Fatalf("Expect key add opts: %v, got: %v", expected, opts)
+	}
+}
+
+func TestAddKey_Keychain(t *testing.T) {
+	defer func(oldDir string) {
+		dir.UserConfigDir = oldDir
+	}(dir.UserConfigDir)
+	dir.UserConfigDir = t.TempDir()
+
+	if !keychain.Supported() {
+		keychainKey := configutil.KeychainKey{Identity: "Developer ID Application: Example"}
+		if err := addKey(context.Background(), &keyAddOpts{name: "name", keychain: keychainKey, skipProbe: true}); !errors.Is(err, keychain.ErrUnsupported) {
+			t.Fatalf("expected Keychain identities to be unsupported, got %v", err)
+		}
+		t.Skip("Keychain identities are only supported on macOS with cgo")
+	}
+	if err := addKey(context.Background(), &keyAddOpts{name: "name", keychain: configutil.KeychainKey{CertificatePath: "chain.pem"}}); err == nil || !strings.Contains(err.Error(), "--keychain-cert") {
+		t.Fatalf("expected error for a certificate chain without Keychain identity, got %v", err)
+	}
+	keychainKey := configutil.KeychainKey{Identity: "Developer ID Application: Example"}
+	if err := addKey(context.Background(), &keyAddOpts{name: "name", keychain: keychainKey, skipProbe: true}); err != nil {
+		t.Fatalf("addKey() error = %v", err)
+	}
+	got, err := configutil.LoadKeychainKey("name")
+	if err != nil || got == nil || *got != keychainKey {
+		t.Fatalf("expected the Keychain key to be added, got %+v, %v", got, err)
+	}
+}
+
+func TestKeyAddCommand_KMS(t *testing.T) {
+	opts := &keyAddOpts{}
+	cmd := keyAddCommand(opts)
+	expected := &keyAddOpts{
+		name:    "name",
+		purpose: configutil.KeyPurposeProduction,
+		kms: configutil.KMSKey{
+			URI:             "awskms:///alias/release",
+			CertificatePath: "chain.pem",
+		},
+	}
+	if err := cmd.ParseFlags([]string{
+		"--kms-uri", expected.kms.URI,
+		"--kms-cert", expected.kms.CertificatePath,
+		"--purpose", expected.purpose,
 		expected.name}); err != nil {
 		t.Fatalf("Parse Flag failed: %v", err)
 	}
@@ -29,6 +188,28 @@ func TestKeyAddCommand_BasicArgs(t *testing.T) {
 	}
 }
 
+func TestAddKey_KMS(t *testing.T) {
+	defer func(oldDir string) {
+		dir.UserConfigDir = oldDir
+	}(dir.UserConfigDir)
+	dir.UserConfigDir = t.TempDir()
+
+	kmsKey := configutil.KMSKey{URI: "hashivault://release", CertificatePath: "chain.pem"}
+	if err := addKey(context.Background(), &keyAddOpts{name: "name", kms: configutil.KMSKey{URI: kmsKey.URI}, skipProbe: true}); err == nil || !strings.Contains(err.Error(), "certificate chain") {
+		t.Fatalf("expected error for a KMS key without certificate chain, got %v", err)
+	}
+	if err := addKey(context.Background(), &keyAddOpts{name: "name", kms: configutil.KMSKey{URI: "kms://release", CertificatePath: "chain.pem"}, skipProbe: true}); err == nil || !strings.Contains(err.Error(), "unsupported scheme") {
+		t.Fatalf("expected error for an unsupported KMS key URI, got %v", err)
+	}
+	if err := addKey(context.Background(), &keyAddOpts{name: "name", kms: kmsKey, isDefault: true, skipProbe: true}); err != nil {
+		t.Fatalf("addKey() error = %v", err)
+	}
+	got, err := configutil.LoadKMSKey("")
+	if err != nil || got == nil || *got != kmsKey {
+		t.Fatalf("expected the KMS key to be added as default, got %+v, %v", got, err)
+	}
+}
+
 func TestKeyUpdateCommand_BasicArgs(t *testing.T) {
 	opts := &keyUpdateOpts{}
 	cmd := keyUpdateCommand(opts)
@@ -49,6 +230,29 @@ func TestKeyUpdateCommand_BasicArgs(t *testing.T) {
 	}
 }
 
+func TestKeyUpdateCommand_Validity(t *testing.T) {
+	opts := &keyUpdateOpts{}
+	cmd := keyUpdateCommand(opts)
+	if err := cmd.ParseFlags([]string{
+		"name",
+		"--not-after", "2025-01-01T00:00:00Z",
+		"--not-before", ""}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if opts.notAfter != "2025-01-01T00:00:00Z" || !cmd.Flags().Changed("not-before") {
+		t.Fatalf("unexpected key update opts: %v", opts)
+	}
+	if _, err := parseKeyValidityTime("not-after", opts.notAfter); err != nil {
+		t.Fatalf("expected valid time, got %v", err)
+	}
+	if _, err := parseKeyValidityTime("not-after", "2025-01-01"); err == nil {
+		t.Fatal("expected error for time not in RFC 3339 format")
+	}
+	if got, err := parseKeyValidityTime("not-before", opts.notBefore); err != nil || got != nil {
+		t.Fatalf("expected empty value to remove the time, got %v, %v", got, err)
+	}
+}
+
 func TestKeyUpdateCommand_MissingArgs(t *testing.T) {
 	cmd := keyUpdateCommand(nil)
 	if err := cmd.ParseFlags(nil); err != nil {
@@ -85,3 +289,287 @@ func TestKeyRemoveCommand_MissingArgs(t *testing.T) {
 		t.Fatal("Parse Args expected error, but ok")
 	}
 }
+
+func TestAddKey_RefusesPlaintextSecrets(t *testing.T) {
+	defer func(oldDir string) {
+		dir.UserConfigDir = oldDir
+	}(dir.UserConfigDir)
+	dir.UserConfigDir = t.TempDir()
+
+	opts := &keyAddOpts{
+		name:         "name",
+		plugin:       "pluginname",
+		id:           "pluginid",
+		pluginConfig: []string{"region=us-west-2", "client_secret=s3cr3t"},
+	}
+	err := addKey(context.Background(), opts)
+	if err == nil || !strings.Contains(err.Error(), "--allow-plaintext-secrets") {
+		t.Fatalf("expected plaintext secrets to be refused, got %v", err)
+	}
+	if err != nil && strings.Contains(err.Error(), "s3cr3t") {
+		t.Fatalf("error discloses the secret: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir.UserConfigDir, dir.PathSigningKeys)); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected signingkeys.json not to be written, got %v", err)
+	}
+}
+
+func TestKeyAddCommand_AWSKMS(t *testing.T) {
+	opts := &keyAddOpts{}
+	cmd := keyAddCommand(opts)
+	expected := &keyAddOpts{
+		name:   "name",
+		awsKMS: "alias/release",
+		kms: configutil.KMSKey{
+			CertificatePath: "s3://certs/chain.pem",
+		},
+	}
+	if err := cmd.ParseFlags([]string{
+		"--aws-kms", expected.awsKMS,
+		"--kms-cert", expected.kms.CertificatePath,
+		expected.name}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := cmd.Args(cmd, cmd.Flags().Args()); err != nil {
+		t.Fatalf("Parse Args failed: %v", err)
+	}
+	if !reflect.DeepEqual(*expected, *opts) {
+		t.Fatalf("Expect key add opts: %v, got: %v", expected, opts)
+	}
+	if err := cmd.ParseFlags([]string{"--kms-uri", "awskms:///alias/release"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := cmd.ValidateFlagGroups(); err == nil {
+		t.Fatal("expected error for flags --aws-kms and --kms-uri set together")
+	}
+}
+
+func TestAddKey_AWSKMS(t *testing.T) {
+	defer func(oldDir string) {
+		dir.UserConfigDir = oldDir
+	}(dir.UserConfigDir)
+	dir.UserConfigDir = t.TempDir()
+
+	if err := addKey(context.Background(), &keyAddOpts{name: "name", awsKMS: "alias/release", kms: configutil.KMSKey{CertificatePath: "https://example.com/chain.pem"}, skipProbe: true}); err == nil || !strings.Contains(err.Error(), "unsupported scheme") {
+		t.Fatalf("expected error for an unsupported certificate chain location, got %v", err)
+	}
+	if err := addKey(context.Background(), &keyAddOpts{name: "name", awsKMS: "alias/release", kms: configutil.KMSKey{CertificatePath: "s3://certs/chain.pem"}, skipProbe: true}); err != nil {
+		t.Fatalf("addKey() error = %v", err)
+	}
+	want := configutil.KMSKey{URI: "awskms:///alias/release", CertificatePath: "s3://certs/chain.pem"}
+	got, err := configutil.LoadKMSKey("name")
+	if err != nil || got == nil || *got != want {
+		t.Fatalf("expected the AWS KMS key to be added, got %+v, %v", got, err)
+	}
+}
+
+func TestKeyDescribeCommand_BasicArgs(t *testing.T) {
+	opts := &keyDescribeOpts{}
+	cmd := keyDescribeCommand(opts)
+	expected := &keyDescribeOpts{
+		name:         "name",
+		outputFormat: "json",
+	}
+	if err := cmd.ParseFlags([]string{"--output", expected.outputFormat, expected.name}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := cmd.Args(cmd, cmd.Flags().Args()); err != nil {
+		t.Fatalf("Parse Args failed: %v", err)
+	}
+	if !reflect.DeepEqual(*expected, *opts) {
+		t.Fatalf("Expect key describe opts: %v, got: %v", expected, opts)
+	}
+	if err := cmd.Args(cmd, nil); err == nil {
+		t.Fatal("Parse Args expected error, but ok")
+	}
+}
+
+func TestDescribeKey_NotKMS(t *testing.T) {
+	defer func(oldDir string) {
+		dir.UserConfigDir = oldDir
+	}(dir.UserConfigDir)
+	dir.UserConfigDir = t.TempDir()
+
+	signingKeys := `{"keys":[{"name":"plugin-key","id":"id","pluginName":"plugin"}]}`
+	if err := os.WriteFile(filepath.Join(dir.UserConfigDir, dir.PathSigningKeys), []byte(signingKeys), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := describeKey(context.Background(), &keyDescribeOpts{name: "plugin-key", outputFormat: "text"}); err == nil || !strings.Contains(err.Error(), "not a key in a key management service") {
+		t.Fatalf("expected error for a plugin key, got %v", err)
+	}
+}
+
+func TestDescribeKey_AWSKMS(t *testing.T) {
+	defer func(oldDir string) {
+		dir.UserConfigDir = oldDir
+	}(dir.UserConfigDir)
+	dir.UserConfigDir = t.TempDir()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "release"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPath := filepath.Join(t.TempDir(), "chain.pem")
+	if err := os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	publicKey, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Header.Get("X-Amz-Target") {
+		case "TrentService.GetPublicKey":
+			json.NewEncoder(w).Encode(map[string]any{"PublicKey": publicKey, "KeyUsage": "SIGN_VERIFY"})
+		case "TrentService.DescribeKey":
+			json.NewEncoder(w).Encode(map[string]any{"KeyMetadata": map[string]any{"KeySpec": "ECC_NIST_P256", "KeyState": "Enabled"}})
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+	t.Setenv("AWS_ENDPOINT_URL_KMS", server.URL)
+	t.Setenv("AWS_REGION", "us-east-1")
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+
+	if err := configutil.AddKMSKey("release", configutil.KMSKey{URI: "awskms:///alias/release", CertificatePath: certPath}, "", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := describeKey(context.Background(), &keyDescribeOpts{name: "release", outputFormat: "json"}); err != nil {
+		t.Fatalf("describeKey() error = %v", err)
+	}
+
+	// a certificate chain of another key
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if der, err = x509.CreateCertificate(rand.Reader, template, template, otherKey.Public(), otherKey); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := describeKey(context.Background(), &keyDescribeOpts{name: "release", outputFormat: "text"}); err == nil || !strings.Contains(err.Error(), "cannot sign with its certificate chain") {
+		t.Fatalf("expected error for a certificate chain of another key, got %v", err)
+	}
+}
+
+func TestParseAzureKeyVaultID(t *testing.T) {
+	tests := []struct {
+		id              string
+		wantURI         string
+		wantCertificate string
+		wantErr         bool
+	}{
+		{
+			id:              "https://myvault.vault.azure.net/certificates/release",
+			wantURI:         "azurekv://myvault.vault.azure.net/release",
+			wantCertificate: "azurekv://myvault.vault.azure.net/certificates/release",
+		},
+		{
+			id:              "https://myvault.vault.azure.net/keys/release/0123456789abcdef",
+			wantURI:         "azurekv://myvault.vault.azure.net/release/0123456789abcdef",
+			wantCertificate: "azurekv://myvault.vault.azure.net/certificates/release/0123456789abcdef",
+		},
+		{id: "https://myvault.vault.azure.net/secrets/release", wantErr: true},
+		{id: "https://myvault.vault.azure.net/keys", wantErr: true},
+		{id: "http://myvault.vault.azure.net/keys/release", wantErr: true},
+	}
+	for _, tt := range tests {
+		uri, certificate, err := parseAzureKeyVaultID(tt.id)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("parseAzureKeyVaultID(%q) error = %v, wantErr %v", tt.id, err, tt.wantErr)
+		}
+		if uri != tt.wantURI || certificate != tt.wantCertificate {
+			t.Fatalf("parseAzureKeyVaultID(%q) = %q, %q, want %q, %q", tt.id, uri, certificate, tt.wantURI, tt.wantCertificate)
+		}
+	}
+}
+
+func TestAddKey_AzureKeyVault(t *testing.T) {
+	defer func(oldDir string) {
+		dir.UserConfigDir = oldDir
+	}(dir.UserConfigDir)
+	dir.UserConfigDir = t.TempDir()
+
+	if err := addKey(context.Background(), &keyAddOpts{name: "release", akv: "https://myvault.vault.azure.net/certificates/release", skipProbe: true}); err != nil {
+		t.Fatalf("addKey() error = %v", err)
+	}
+	if err := addKey(context.Background(), &keyAddOpts{name: "archive", akv: "https://myvault.vault.azure.net/keys/archive", kms: configutil.KMSKey{CertificatePath: "archive.pem"}, skipProbe: true}); err != nil {
+		t.Fatalf("addKey() error = %v", err)
+	}
+	for name, want := range map[string]configutil.KMSKey{
+		"release": {URI: "azurekv://myvault.vault.azure.net/release", CertificatePath: "azurekv://myvault.vault.azure.net/certificates/release"},
+		"archive": {URI: "azurekv://myvault.vault.azure.net/archive", CertificatePath: "archive.pem"},
+	} {
+		got, err := configutil.LoadKMSKey(name)
+		if err != nil || got == nil || *got != want {
+			t.Fatalf("expected the Azure Key Vault key %s to be added, got %+v, %v", name, got, err)
+		}
+	}
+}
+
+func TestVaultTransitURI(t *testing.T) {
+	tests := []struct {
+		opts    vaultTransitOpts
+		want    string
+		wantErr bool
+	}{
+		{opts: vaultTransitOpts{key: "release"}, want: "hashivault://release"},
+		{
+			opts: vaultTransitOpts{addr: "https://vault.example.com:8200", key: "release", namespace: "team", mount: "signing", roleID: "role"},
+			want: "hashivault://release?addr=https%3A%2F%2Fvault.example.com%3A8200&mount=signing&namespace=team&role_id=role",
+		},
+		{opts: vaultTransitOpts{addr: "https://vault.example.com:8200"}, wantErr: true},
+		{opts: vaultTransitOpts{addr: "vault.example.com", key: "release"}, wantErr: true},
+		{opts: vaultTransitOpts{key: "team/release"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := vaultTransitURI(tt.opts)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("vaultTransitURI(%+v) error = %v, wantErr %v", tt.opts, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Fatalf("vaultTransitURI(%+v) = %q, want %q", tt.opts, got, tt.want)
+		}
+	}
+}
+
+func TestAddKey_VaultTransit(t *testing.T) {
+	defer func(oldDir string) {
+		dir.UserConfigDir = oldDir
+	}(dir.UserConfigDir)
+	dir.UserConfigDir = t.TempDir()
+
+	opts := &keyAddOpts{
+		name:      "release",
+		vault:     vaultTransitOpts{addr: "https://vault.example.com:8200", key: "release", namespace: "team"},
+		kms:       configutil.KMSKey{CertificatePath: "release.pem"},
+		skipProbe: true,
+	}
+	if err := addKey(context.Background(), opts); err != nil {
+		t.Fatalf("addKey() error = %v", err)
+	}
+	want := configutil.KMSKey{URI: "hashivault://release?addr=https%3A%2F%2Fvault.example.com%3A8200&namespace=team", CertificatePath: "release.pem"}
+	got, err := configutil.LoadKMSKey("release")
+	if err != nil || got == nil || *got != want {
+		t.Fatalf("expected the HashiCorp Vault key to be added, got %+v, %v", got, err)
+	}
+
+	err = addKey(context.Background(), &keyAddOpts{name: "archive", vault: vaultTransitOpts{addr: "https://vault.example.com:8200"}, kms: configutil.KMSKey{CertificatePath: "archive.pem"}, skipProbe: true})
+	if err == nil || !strings.Contains(err.Error(), "--vault-key") {
+		t.Fatalf("expected flag \"--vault-key\" to be required, got %v", err)
+	}
+}
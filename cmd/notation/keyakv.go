@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/notaryproject/notation/internal/kms"
+)
+
+// parseAzureKeyVaultID returns the KMS key URI and the certificate chain
+// location of the ID of a key or a certificate in Azure Key Vault, e.g.
+// "https://myvault.vault.azure.net/certificates/release". Keys created with
+// certificates share the names and the versions of their certificates, so the
+// certificate chain location is that of the certificate of the same name.
+func parseAzureKeyVaultID(id string) (string, string, error) {
+	u, err := url.Parse(id)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid Azure Key Vault ID %q: %w", id, err)
+	}
+	collection, name, _ := strings.Cut(strings.Trim(u.Path, "/"), "/")
+	if u.Scheme != "https" || u.Host == "" || (collection != "keys" && collection != "certificates") || name == "" || strings.Count(name, "/") > 1 {
+		return "", "", fmt.Errorf("invalid Azure Key Vault ID %q, expecting https://{vault_host}/keys/{name}[/{version}] or https://{vault_host}/certificates/{name}[/{version}]", id)
+	}
+	return fmt.Sprintf("azurekv://%s/%s", u.Host, name), fmt.Sprintf("azurekv://%s/certificates/%s", u.Host, name), nil
+}
+
+// listAzureKeyVaultKeys prints the keys and the certificates in the Azure Key
+// Vault of opts, with the IDs to add them with flag "--akv".
+func listAzureKeyVaultKeys(ctx context.Context, opts *keyListOpts) error {
+	// set log level
+	ctx = opts.LoggingFlagOpts.SetLoggerLevel(ctx)
+
+	u, err := url.Parse(opts.akv)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("invalid Azure Key Vault URL %q, expecting https://{vault_host}", opts.akv)
+	}
+	keys, err := kms.List(ctx, "azurekv://"+u.Host)
+	if err != nil {
+		return err
+	}
+
+	// write out
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tENABLED\tID\t")
+	for _, key := range keys {
+		collection := "keys"
+		if key.CertificateLocation != "" {
+			collection = "certificates"
+		}
+		fmt.Fprintf(tw, "%s\t%t\thttps://%s/%s/%s\t\n", key.Name, key.Enabled, u.Host, collection, key.Name)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/notaryproject/notation/internal/cmd"
+	"github.com/notaryproject/notation/internal/color"
+	"github.com/notaryproject/notation/internal/ioutil"
+	"github.com/notaryproject/notation/internal/kms"
+	"github.com/notaryproject/notation/pkg/configutil"
+	"github.com/spf13/cobra"
+)
+
+type keyDescribeOpts struct {
+	cmd.LoggingFlagOpts
+	name         string
+	outputFormat string
+}
+
+// keyDescribeOutput is the description of a signing key in a KMS.
+type keyDescribeOutput struct {
+	Name       string         `json:"name"`
+	URI        string         `json:"uri"`
+	PublicKey  string         `json:"publicKey"`
+	Properties []kms.Property `json:"properties"`
+
+	// CertificateLocation is the location of the certificate chain, and
+	// CertificateChain the certificates fetched from it, or
+	// CertificateError the error fetching them.
+	CertificateLocation string                   `json:"certificateLocation"`
+	CertificateChain    []keyDescribeCertificate `json:"certificateChain,omitempty"`
+	CertificateError    string                   `json:"certificateError,omitempty"`
+
+	// CertificateMatchesKey is true if the public key of the signing
+	// certificate is the public key of the key.
+	CertificateMatchesKey bool `json:"certificateMatchesKey"`
+}
+
+// keyDescribeCertificate is a certificate of the chain of a key.
+type keyDescribeCertificate struct {
+	Subject  string    `json:"subject"`
+	Issuer   string    `json:"issuer"`
+	NotAfter time.Time `json:"notAfter"`
+}
+
+func keyDescribeCommand(opts *keyDescribeOpts) *cobra.Command {
+	if opts == nil {
+		opts = &keyDescribeOpts{}
+	}
+	command := &cobra.Command{
+		Use:   "describe [flags] <key_name>",
+		Short: "Describe a signing key in a key management service",
+		Long: `Describe a signing key in a key management service
+
+The key is described by its key management service, e.g. the key spec and the key state of keys in AWS KMS. The certificate chain of the key is fetched from its location, and the public key of the signing certificate is compared with the public key of the key.
+
+Example - Describe a key in AWS KMS:
+  notation key describe <key_name>
+
+Example - Describe a key and output the description as json:
+  notation key describe --output json <key_name>
+`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return errors.New("either missing key name or unnecessary parameters passed")
+			}
+			opts.name = args[0]
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return describeKey(cmd.Context(), opts)
+		},
+	}
+	opts.LoggingFlagOpts.ApplyFlags(command.Flags())
+	cmd.SetPflagOutput(command.Flags(), &opts.outputFormat, cmd.PflagOutputUsage)
+	return command
+}
+
+func describeKey(ctx context.Context, opts *keyDescribeOpts) error {
+	// set log level
+	ctx = opts.LoggingFlagOpts.SetLoggerLevel(ctx)
+
+	switch opts.outputFormat {
+	case cmd.OutputPlaintext, cmd.OutputJSON:
+	default:
+		return fmt.Errorf("unrecognized output format %s", opts.outputFormat)
+	}
+	kmsKey, err := configutil.LoadKMSKey(opts.name)
+	if err != nil {
+		return err
+	}
+	if kmsKey == nil {
+		return fmt.Errorf("key %s is not a key in a key management service, only keys added with flag \"--kms-uri\", \"--aws-kms\" or \"--akv\" can be described", opts.name)
+	}
+	key, err := kms.Open(ctx, kmsKey.URI)
+	if err != nil {
+		return err
+	}
+	output := keyDescribeOutput{
+		Name:                opts.name,
+		URI:                 kmsKey.URI,
+		PublicKey:           describePublicKey(key.Public()),
+		CertificateLocation: kmsKey.CertificatePath,
+	}
+	if output.Properties, err = kms.Describe(ctx, key); err != nil {
+		return err
+	}
+	if output.Properties == nil {
+		output.Properties = []kms.Property{}
+	}
+	certs, err := kms.FetchCertificateChain(ctx, kmsKey.CertificatePath)
+	if err != nil {
+		output.CertificateError = err.Error()
+	}
+	for _, cert := range certs {
+		output.CertificateChain = append(output.CertificateChain, keyDescribeCertificate{
+			Subject:  cert.Subject.String(),
+			Issuer:   cert.Issuer.String(),
+			NotAfter: cert.NotAfter,
+		})
+	}
+	if len(certs) > 0 {
+		if public, ok := key.Public().(interface{ Equal(crypto.PublicKey) bool }); ok {
+			output.CertificateMatchesKey = public.Equal(certs[0].PublicKey)
+		}
+	}
+
+	if opts.outputFormat == cmd.OutputJSON {
+		if err := ioutil.PrintObjectAsJSON(output); err != nil {
+			return err
+		}
+	} else {
+		printKeyDescription(output)
+	}
+	// the key cannot sign with the certificate chain
+	if !output.CertificateMatchesKey {
+		return fmt.Errorf("key %s cannot sign with its certificate chain at %s", opts.name, kmsKey.CertificatePath)
+	}
+	return nil
+}
+
+// describePublicKey returns the type and the size of public, e.g. "ECDSA
+// P-256".
+func describePublicKey(public crypto.PublicKey) string {
+	switch public := public.(type) {
+	case *rsa.PublicKey:
+		return fmt.Sprintf("RSA %d", public.N.BitLen())
+	case *ecdsa.PublicKey:
+		return "ECDSA " + public.Curve.Params().Name
+	default:
+		return fmt.Sprintf("%T", public)
+	}
+}
+
+// printKeyDescription prints the description of a key in text.
+func printKeyDescription(output keyDescribeOutput) {
+	fmt.Printf("Name: %s\n", output.Name)
+	fmt.Printf("URI: %s\n", output.URI)
+	fmt.Printf("Public key: %s\n", output.PublicKey)
+	for _, property := range output.Properties {
+		fmt.Printf("%s: %s\n", property.Name, property.Value)
+	}
+	fmt.Printf("Certificate chain: %s\n", output.CertificateLocation)
+	if output.CertificateError != "" {
+		fmt.Printf("  %s %s\n", color.Failure(os.Stdout, "Failed:"), output.CertificateError)
+		return
+	}
+	for i, cert := range output.CertificateChain {
+		fmt.Printf("  [%d] %s, expires %s\n", i, cert.Subject, cert.NotAfter.Format(time.RFC3339))
+	}
+	if output.CertificateMatchesKey {
+		fmt.Printf("%s the signing certificate matches the key\n", color.Success(os.Stdout, "Passed:"))
+	} else {
+		fmt.Printf("%s the signing certificate does not match the key\n", color.Failure(os.Stdout, "Failed:"))
+	}
+}
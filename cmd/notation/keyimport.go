@@ -0,0 +1,277 @@
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/notaryproject/notation-go/config"
+	"github.com/notaryproject/notation-go/dir"
+	"github.com/notaryproject/notation-go/signer"
+	"github.com/notaryproject/notation/cmd/notation/internal/truststore"
+	"github.com/notaryproject/notation/internal/cmd"
+	"github.com/notaryproject/notation/internal/color"
+	"github.com/notaryproject/notation/internal/osutil"
+	"github.com/notaryproject/notation/pkg/configutil"
+	"github.com/spf13/cobra"
+)
+
+type keyImportOpts struct {
+	cmd.LoggingFlagOpts
+	name      string
+	keyPath   string
+	certPath  string
+	isDefault bool
+	purpose   string
+	notBefore string
+	notAfter  string
+	skipProbe bool
+}
+
+func keyImportCommand(opts *keyImportOpts) *cobra.Command {
+	if opts == nil {
+		opts = &keyImportOpts{}
+	}
+	command := &cobra.Command{
+		Use:   "import --key <key_file> --cert <certificate_file> [flags] <key_name>",
+		Short: "Import a local key and its certificate chain to signing key list",
+		Long: `Import a local key and its certificate chain to signing key list
+
+The private key is a PEM-encoded RSA or ECDSA key in PKCS #8, PKCS #1 or SEC 1 format, and must not be encrypted. The certificate chain is a PEM file of the signing certificate, followed by the certificates of the issuers up to the root certificate authority. The key must match the signing certificate. The key and the certificate chain are copied into the notation configuration directory, so that the original files can be removed.
+
+Example - Import a key and mark it as default:
+  notation key import --key wabbit-networks.key --cert wabbit-networks.crt --default wabbit-networks
+`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return errors.New("either missing key name or unnecessary parameters passed")
+			}
+			opts.name = args[0]
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return importKey(cmd.Context(), opts)
+		},
+	}
+	opts.LoggingFlagOpts.ApplyFlags(command.Flags())
+	command.Flags().StringVar(&opts.keyPath, "key", "", "PEM file of the private key")
+	command.MarkFlagRequired("key")
+	command.Flags().StringVar(&opts.certPath, "cert", "", "PEM file of the certificate chain, starting with the signing certificate")
+	command.MarkFlagRequired("cert")
+	setKeyDefaultFlag(command.Flags(), &opts.isDefault)
+	setKeyPurposeFlag(command.Flags(), &opts.purpose)
+	setKeyNotBeforeFlag(command.Flags(), &opts.notBefore)
+	setKeyNotAfterFlag(command.Flags(), &opts.notAfter)
+	command.Flags().BoolVar(&opts.skipProbe, "skip-validation", false, "skip signing a probe artifact to validate the key against the signature envelope formats")
+	return command
+}
+
+func importKey(ctx context.Context, opts *keyImportOpts) error {
+	// set log level
+	ctx = opts.LoggingFlagOpts.SetLoggerLevel(ctx)
+
+	if !truststore.IsValidFileName(opts.name) {
+		return errors.New("name needs to follow [a-zA-Z0-9_.-]+ format")
+	}
+	var purposes map[string]string
+	if opts.purpose != "" {
+		if err := configutil.ValidateKeyPurpose(opts.purpose); err != nil {
+			return err
+		}
+		purposes = map[string]string{opts.name: opts.purpose}
+	}
+	var validity configutil.KeyValidity
+	var err error
+	if validity.NotBefore, err = parseKeyValidityTime(keyNotBeforeFlag.Name, opts.notBefore); err != nil {
+		return err
+	}
+	if validity.NotAfter, err = parseKeyValidityTime(keyNotAfterFlag.Name, opts.notAfter); err != nil {
+		return err
+	}
+	if err := validity.Validate(); err != nil {
+		return err
+	}
+
+	// load and check the key pair before anything is written
+	key, err := loadImportKey(opts.keyPath)
+	if err != nil {
+		return err
+	}
+	certs, err := loadImportCertChain(opts.certPath)
+	if err != nil {
+		return err
+	}
+	if err := checkImportKeyPair(key, certs); err != nil {
+		return err
+	}
+	if now := time.Now(); now.Before(certs[0].NotBefore) || now.After(certs[0].NotAfter) {
+		fmt.Fprintf(os.Stderr, "%s the signing certificate is not valid now, it is valid from %s to %s\n", color.Warning(os.Stderr, "Warning:"), certs[0].NotBefore.Format(time.RFC3339), certs[0].NotAfter.Format(time.RFC3339))
+	}
+	signingKeys, err := config.LoadSigningKeys()
+	if err != nil {
+		return err
+	}
+	if _, err := signingKeys.Get(opts.name); err == nil {
+		return fmt.Errorf("signing key %q already exists", opts.name)
+	}
+
+	// fail fast on keys which cannot sign rather than at the first signing
+	var formats []string
+	if !opts.skipProbe {
+		s, err := signer.New(key, certs)
+		if err != nil {
+			return err
+		}
+		if formats, err = checkProbeResults(ctx, s, nil); err != nil {
+			return err
+		}
+	}
+
+	// copy the key pair into the configuration directory
+	keyBytes, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return fmt.Errorf("failed to encode the private key: %w", err)
+	}
+	var certBytes []byte
+	for _, cert := range certs {
+		certBytes = append(certBytes, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})...)
+	}
+	relativeKeyPath, relativeCertPath := dir.LocalKeyPath(opts.name)
+	configFS := dir.ConfigFS()
+	keyPath, err := configFS.SysPath(relativeKeyPath)
+	if err != nil {
+		return err
+	}
+	certPath, err := configFS.SysPath(relativeCertPath)
+	if err != nil {
+		return err
+	}
+	if err := osutil.WriteFileWithPermission(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyBytes}), 0600, false); err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return fmt.Errorf("key file of %q already exists: %s", opts.name, keyPath)
+		}
+		return fmt.Errorf("failed to write key file: %w", err)
+	}
+	if err := osutil.WriteFileWithPermission(certPath, certBytes, 0644, false); err != nil {
+		os.Remove(keyPath)
+		if errors.Is(err, os.ErrExist) {
+			return fmt.Errorf("certificate file of %q already exists: %s", opts.name, certPath)
+		}
+		return fmt.Errorf("failed to write certificate file: %w", err)
+	}
+
+	// core process
+	exec := func(s *config.SigningKeys) error {
+		return s.Add(opts.name, keyPath, certPath, opts.isDefault)
+	}
+	if err := configutil.LoadExecSaveSigningKeys(exec, purposes); err != nil {
+		os.Remove(keyPath)
+		os.Remove(certPath)
+		return err
+	}
+	if validity.NotBefore != nil || validity.NotAfter != nil {
+		if err := configutil.SetKeyValidity(opts.name, validity); err != nil {
+			return err
+		}
+	}
+
+	// write out
+	if opts.isDefault {
+		fmt.Printf("%s: marked as default\n", opts.name)
+	} else {
+		fmt.Println(opts.name)
+	}
+	fmt.Println("wrote key:", keyPath)
+	fmt.Println("wrote certificate:", certPath)
+	if len(formats) > 0 {
+		fmt.Printf("Signature envelope formats: %s\n", strings.Join(formats, ", "))
+	}
+	return nil
+}
+
+// loadImportKey loads the PEM-encoded RSA or ECDSA private key of path, in
+// PKCS #8, PKCS #1 or SEC 1 format.
+func loadImportKey(path string) (crypto.Signer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the private key: %w", err)
+	}
+	var block *pem.Block
+	for rest := data; ; {
+		block, rest = pem.Decode(rest)
+		if block == nil || strings.HasSuffix(block.Type, "PRIVATE KEY") {
+			break
+		}
+	}
+	if block == nil {
+		return nil, fmt.Errorf("no PEM-encoded private key found in %s", path)
+	}
+	if block.Type == "ENCRYPTED PRIVATE KEY" || strings.Contains(block.Headers["Proc-Type"], "ENCRYPTED") {
+		return nil, fmt.Errorf("the private key in %s is encrypted, decrypt it first, e.g. with \"openssl pkey -in %s -out <decrypted_file>\"", path, path)
+	}
+	var key any
+	switch block.Type {
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the private key in %s: %w", path, err)
+	}
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		return key, nil
+	case *ecdsa.PrivateKey:
+		return key, nil
+	}
+	return nil, fmt.Errorf("unsupported private key type %T in %s, expecting an RSA or ECDSA key", key, path)
+}
+
+// loadImportCertChain loads the PEM-encoded certificate chain of path.
+func loadImportCertChain(path string) ([]*x509.Certificate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the certificate chain: %w", err)
+	}
+	var certs []*x509.Certificate
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse certificate %d in %s: %w", len(certs)+1, path, err)
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no PEM-encoded certificate found in %s", path)
+	}
+	return certs, nil
+}
+
+// checkImportKeyPair checks that key matches the signing certificate of
+// certs, and that each certificate of certs is issued by the next one.
+func checkImportKeyPair(key crypto.Signer, certs []*x509.Certificate) error {
+	publicKey, ok := key.Public().(interface{ Equal(crypto.PublicKey) bool })
+	if !ok || !publicKey.Equal(certs[0].PublicKey) {
+		return fmt.Errorf("the private key does not match the signing certificate %q", certs[0].Subject)
+	}
+	for i := 0; i < len(certs)-1; i++ {
+		if err := certs[i].CheckSignatureFrom(certs[i+1]); err != nil {
+			return fmt.Errorf("certificate %q is not issued by the next certificate %q of the chain, the chain must be ordered from the signing certificate to the root certificate: %w", certs[i].Subject, certs[i+1].Subject, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/notation-go/config"
+	"github.com/notaryproject/notation-go/dir"
+)
+
+// writeImportKeyPair writes the PEM-encoded key and a self-signed code
+// signing certificate of key to dir, and returns the paths.
+func writeImportKeyPair(t *testing.T, dir string, key crypto.Signer, keyBlock *pem.Block) (string, string) {
+	t.Helper()
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "import", Organization: []string{"Notary"}, Country: []string{"US"}, Province: []string{"WA"}, Locality: []string{"Seattle"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		BasicConstraintsValid: true,
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPath := filepath.Join(dir, "key.pem")
+	certPath := filepath.Join(dir, "cert.pem")
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(keyBlock), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return keyPath, certPath
+}
+
+func TestKeyImportCommand_BasicArgs(t *testing.T) {
+	opts := &keyImportOpts{}
+	cmd := keyImportCommand(opts)
+	expected := &keyImportOpts{
+		name:      "name",
+		keyPath:   "key.pem",
+		certPath:  "chain.pem",
+		isDefault: true,
+		purpose:   "test",
+	}
+	if err := cmd.ParseFlags([]string{
+		"--key", expected.keyPath,
+		"--cert", expected.certPath,
+		"--default",
+		"--purpose", expected.purpose,
+		expected.name}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := cmd.Args(cmd, cmd.Flags().Args()); err != nil {
+		t.Fatalf("Parse Args failed: %v", err)
+	}
+	if *expected != *opts {
+		t.Fatalf("Expect key import opts: %v, got: %v", expected, opts)
+	}
+}
+
+func TestKeyImportCommand_MissingArgs(t *testing.T) {
+	cmd := keyImportCommand(nil)
+	if err := cmd.ParseFlags([]string{"--key", "key.pem", "--cert", "chain.pem"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := cmd.Args(cmd, cmd.Flags().Args()); err == nil {
+		t.Fatal("Parse Args expected error, but ok")
+	}
+}
+
+func TestImportKey(t *testing.T) {
+	defer func(oldDir string) {
+		dir.UserConfigDir = oldDir
+	}(dir.UserConfigDir)
+	dir.UserConfigDir = t.TempDir()
+
+	// a PKCS #1 key is stored as a PKCS #8 key
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPath, certPath := writeImportKeyPair(t, t.TempDir(), key, &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	opts := &keyImportOpts{name: "imported", keyPath: keyPath, certPath: certPath, isDefault: true}
+	if err := importKey(context.Background(), opts); err != nil {
+		t.Fatalf("importKey() error = %v", err)
+	}
+	signingKeys, err := config.LoadSigningKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	imported, err := signingKeys.Get("imported")
+	if err != nil || imported.X509KeyPair == nil {
+		t.Fatalf("expected the key to be registered, got %+v, %v", imported, err)
+	}
+	if signingKeys.Default == nil || *signingKeys.Default != "imported" {
+		t.Fatalf("expected the key to be marked as default, got %v", signingKeys.Default)
+	}
+	data, err := os.ReadFile(imported.X509KeyPair.KeyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if block, _ := pem.Decode(data); block == nil || block.Type != "PRIVATE KEY" {
+		t.Fatalf("expected a PKCS #8 key, got %q", data)
+	}
+	if info, err := os.Stat(imported.X509KeyPair.KeyPath); err != nil || info.Mode().Perm() != 0600 {
+		t.Fatalf("expected the key file to be private, got %v, %v", info, err)
+	}
+
+	// the name is taken
+	if err := importKey(context.Background(), opts); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Fatalf("expected duplicate key error, got %v", err)
+	}
+}
+
+func TestImportKey_Invalid(t *testing.T) {
+	defer func(oldDir string) {
+		dir.UserConfigDir = oldDir
+	}(dir.UserConfigDir)
+	dir.UserConfigDir = t.TempDir()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKeyBytes, err := x509.MarshalPKCS8PrivateKey(otherKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tempDir := t.TempDir()
+	_, certPath := writeImportKeyPair(t, tempDir, key, &pem.Block{Type: "PRIVATE KEY", Bytes: otherKeyBytes})
+	keyPath := filepath.Join(tempDir, "key.pem")
+	encryptedKeyPath := filepath.Join(tempDir, "encrypted.pem")
+	if err := os.WriteFile(encryptedKeyPath, pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED PRIVATE KEY", Bytes: []byte("encrypted")}), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		opts    *keyImportOpts
+		wantErr string
+	}{
+		{name: "mismatch", opts: &keyImportOpts{name: "imported", keyPath: keyPath, certPath: certPath, skipProbe: true}, wantErr: "does not match the signing certificate"},
+		{name: "encrypted", opts: &keyImportOpts{name: "imported", keyPath: encryptedKeyPath, certPath: certPath}, wantErr: "is encrypted"},
+		{name: "no certificate", opts: &keyImportOpts{name: "imported", keyPath: keyPath, certPath: keyPath}, wantErr: "no PEM-encoded certificate"},
+		{name: "invalid name", opts: &keyImportOpts{name: "../imported", keyPath: keyPath, certPath: certPath}, wantErr: "name needs to follow"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := importKey(context.Background(), tt.opts)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("importKey() error = %v, want %q", err, tt.wantErr)
+			}
+			if _, err := os.Stat(filepath.Join(dir.UserConfigDir, dir.LocalKeysDir)); err == nil {
+				t.Fatal("expected no key to be written")
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// vaultTransitOpts are the flags of a key in the Transit secrets engine of
+// HashiCorp Vault.
+type vaultTransitOpts struct {
+	addr      string
+	key       string
+	namespace string
+	mount     string
+	roleID    string
+}
+
+// isSet returns true if any flag of the Vault key is set.
+func (opts vaultTransitOpts) isSet() bool {
+	return opts != vaultTransitOpts{}
+}
+
+// vaultTransitURI returns the KMS key URI of the Vault key of opts, e.g.
+// "hashivault://release?addr=https%3A%2F%2Fvault.example.com%3A8200". The
+// settings of the flags are recorded as query parameters, so that the key
+// signs without the environment variables, while the token and the secret ID
+// of AppRole are always read from the environment.
+func vaultTransitURI(opts vaultTransitOpts) (string, error) {
+	if opts.key == "" {
+		return "", errors.New("flag \"--vault-key\" is required if any of flags \"--vault-addr\", \"--vault-namespace\", \"--vault-mount\" or \"--vault-role-id\" is set")
+	}
+	if strings.Contains(opts.key, "/") {
+		return "", fmt.Errorf("invalid HashiCorp Vault key name %q", opts.key)
+	}
+	if opts.addr != "" {
+		u, err := url.Parse(opts.addr)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return "", fmt.Errorf("invalid HashiCorp Vault address %q, expecting http[s]://{host}[:{port}]", opts.addr)
+		}
+	}
+	query := url.Values{}
+	for parameter, value := range map[string]string{
+		"addr":      opts.addr,
+		"namespace": opts.namespace,
+		"mount":     opts.mount,
+		"role_id":   opts.roleID,
+	} {
+		if value != "" {
+			query.Set(parameter, value)
+		}
+	}
+	uri := url.URL{Scheme: "hashivault", Host: opts.key, RawQuery: query.Encode()}
+	return uri.String(), nil
+}
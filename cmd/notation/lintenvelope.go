@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/notaryproject/notation/internal/cmd"
+	"github.com/notaryproject/notation/internal/color"
+	"github.com/notaryproject/notation/internal/envelope"
+	"github.com/notaryproject/notation/internal/experimental"
+	"github.com/notaryproject/notation/internal/ioutil"
+	"github.com/notaryproject/notation/internal/lint"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/spf13/cobra"
+)
+
+type lintEnvelopeOpts struct {
+	cmd.LoggingFlagOpts
+	SecureFlagOpts
+	target       string
+	outputFormat string
+}
+
+// lintEnvelopeOutput is the result of linting a signature envelope.
+type lintEnvelopeOutput struct {
+	// Source is the file of the envelope, or the digest of its signature
+	// manifest.
+	Source     string           `json:"source"`
+	MediaType  string           `json:"mediaType,omitempty"`
+	Violations []lint.Violation `json:"violations"`
+}
+
+func lintEnvelopeCommand(opts *lintEnvelopeOpts) *cobra.Command {
+	if opts == nil {
+		opts = &lintEnvelopeOpts{}
+	}
+	command := &cobra.Command{
+		Use:   "lint-envelope [flags] <file|reference>",
+		Short: "[Experimental] Check signature envelopes against the Notary Project signature specification",
+		Long: `[Experimental] Check signature envelopes against the Notary Project signature specification
+
+The envelope is checked for the required signed attributes and their values, the headers marked critical, the allowed unsigned attributes, the ordering of the certificate chain from the signing certificate to the root, and the payload. The signature value is only verified against the signing certificate if no other violation is found. The certificate chain is not verified against any trust store.
+
+If the argument is an existing file, it is linted as a JWS or COSE envelope. Otherwise, it is an artifact reference and all its signature envelopes in the registry are linted. The command fails if any envelope violates the specification.
+
+Example - Lint a signature envelope generated by a plugin:
+  notation lint-envelope signature.jws
+
+Example - Lint all signature envelopes of an artifact in the registry:
+  notation lint-envelope <registry>/<repository>@<digest>
+
+Example - Lint all signature envelopes of an artifact and output the violations as json:
+  notation lint-envelope --output json <registry>/<repository>@<digest>
+`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return errors.New("expecting one signature envelope file or artifact reference")
+			}
+			opts.target = args[0]
+			return nil
+		},
+		PreRunE: experimental.CheckCommandAndWarn,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.SecureFlagOpts.applyChangedFlags(cmd.Flags())
+			return runLintEnvelope(cmd.Context(), opts)
+		},
+	}
+	opts.LoggingFlagOpts.ApplyFlags(command.Flags())
+	opts.SecureFlagOpts.ApplyFlags(command.Flags())
+	cmd.SetPflagOutput(command.Flags(), &opts.outputFormat, cmd.PflagOutputUsage)
+	return command
+}
+
+func runLintEnvelope(ctx context.Context, opts *lintEnvelopeOpts) error {
+	// set log level
+	ctx = opts.LoggingFlagOpts.SetLoggerLevel(ctx)
+
+	switch opts.outputFormat {
+	case cmd.OutputPlaintext, cmd.OutputJSON:
+	default:
+		return fmt.Errorf("unrecognized output format %s", opts.outputFormat)
+	}
+	var outputs []lintEnvelopeOutput
+	var err error
+	if info, statErr := os.Stat(opts.target); statErr == nil && !info.IsDir() {
+		outputs, err = lintEnvelopeFile(opts.target)
+	} else {
+		outputs, err = lintSignatureEnvelopes(ctx, opts)
+	}
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, output := range outputs {
+		if len(output.Violations) > 0 {
+			failed++
+		}
+	}
+	if opts.outputFormat == cmd.OutputJSON {
+		if err := ioutil.PrintObjectAsJSON(outputs); err != nil {
+			return err
+		}
+	} else {
+		printLintEnvelopeOutputs(outputs)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d signature envelopes violate the Notary Project signature specification", failed, len(outputs))
+	}
+	return nil
+}
+
+// lintEnvelopeFile lints the signature envelope in the file at path.
+func lintEnvelopeFile(path string) ([]lintEnvelopeOutput, error) {
+	sig, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signature envelope: %w", err)
+	}
+	output := lintEnvelopeOutput{Source: path, Violations: []lint.Violation{}}
+	mediaType, err := envelope.DetectEnvelopeMediaType(sig)
+	if err != nil {
+		output.Violations = append(output.Violations, lint.Violation{Rule: lint.RuleEncoding, Message: err.Error()})
+		return []lintEnvelopeOutput{output}, nil
+	}
+	output.MediaType = mediaType
+	if output.Violations, err = lintEnvelope(sig, mediaType); err != nil {
+		return nil, err
+	}
+	return []lintEnvelopeOutput{output}, nil
+}
+
+// lintSignatureEnvelopes lints all signature envelopes of the artifact
+// referenced by opts.target in the registry.
+func lintSignatureEnvelopes(ctx context.Context, opts *lintEnvelopeOpts) ([]lintEnvelopeOutput, error) {
+	reference, err := expandAlias(inputTypeRegistry, opts.target)
+	if err != nil {
+		return nil, err
+	}
+	sigRepo, err := getRemoteRepository(ctx, &opts.SecureFlagOpts, reference)
+	if err != nil {
+		return nil, err
+	}
+	subject, resolvedRef, err := resolveReference(ctx, inputTypeRegistry, reference, sigRepo, func(ref string, manifestDesc ocispec.Descriptor) {
+		fmt.Fprintf(os.Stderr, "%s Always lint the signatures of an artifact using digest(@sha256:...) rather than a tag(:%s) because resolved digest may not point to the same signed artifact, as tags are mutable.\n", color.Warning(os.Stderr, "Warning:"), ref)
+	})
+	if err != nil {
+		return nil, err
+	}
+	var outputs []lintEnvelopeOutput
+	err = sigRepo.ListSignatures(ctx, subject, func(signatureManifests []ocispec.Descriptor) error {
+		for _, sigManifestDesc := range signatureManifests {
+			sigBlob, sigDesc, err := sigRepo.FetchSignatureBlob(ctx, sigManifestDesc)
+			if err != nil {
+				return fmt.Errorf("failed to fetch the signature envelope of signature manifest %s: %w", sigManifestDesc.Digest, err)
+			}
+			violations, err := lintEnvelope(sigBlob, sigDesc.MediaType)
+			if err != nil {
+				return fmt.Errorf("signature manifest %s: %w", sigManifestDesc.Digest, err)
+			}
+			outputs = append(outputs, lintEnvelopeOutput{
+				Source:     sigManifestDesc.Digest.String(),
+				MediaType:  sigDesc.MediaType,
+				Violations: violations,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(outputs) == 0 {
+		return nil, fmt.Errorf("no signatures associated with %s", resolvedRef)
+	}
+	return outputs, nil
+}
+
+// lintEnvelope lints the signature envelope sig of mediaType, returning an
+// empty slice rather than nil if there is no violation.
+func lintEnvelope(sig []byte, mediaType string) ([]lint.Violation, error) {
+	violations, err := lint.Envelope(sig, mediaType)
+	if violations == nil {
+		violations = []lint.Violation{}
+	}
+	return violations, err
+}
+
+// printLintEnvelopeOutputs prints the violations of each envelope.
+func printLintEnvelopeOutputs(outputs []lintEnvelopeOutput) {
+	for _, output := range outputs {
+		if len(output.Violations) == 0 {
+			fmt.Printf("%s %s complies with the Notary Project signature specification\n", color.Success(os.Stdout, "Passed:"), output.Source)
+			continue
+		}
+		fmt.Printf("%s %s has %d violations of the Notary Project signature specification:\n", color.Failure(os.Stdout, "Failed:"), output.Source, len(output.Violations))
+		for _, violation := range output.Violations {
+			fmt.Printf("  %s\n", violation)
+		}
+	}
+}
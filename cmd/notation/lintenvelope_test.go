@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/notaryproject/notation/internal/cmd"
+	"github.com/notaryproject/notation/internal/lint"
+)
+
+func TestLintEnvelopeCommand_BasicArgs(t *testing.T) {
+	opts := &lintEnvelopeOpts{}
+	command := lintEnvelopeCommand(opts)
+	expected := &lintEnvelopeOpts{
+		target:       "signature.jws",
+		outputFormat: cmd.OutputJSON,
+	}
+	if err := command.ParseFlags([]string{
+		expected.target,
+		"--output", expected.outputFormat,
+	}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := command.Args(command, command.Flags().Args()); err != nil {
+		t.Fatalf("Parse Args failed: %v", err)
+	}
+	if !reflect.DeepEqual(*expected, *opts) {
+		t.Fatalf("Expect lint-envelope opts: %v, got: %v", expected, opts)
+	}
+}
+
+func TestLintEnvelopeCommand_MissingArgs(t *testing.T) {
+	command := lintEnvelopeCommand(nil)
+	if err := command.ParseFlags(nil); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := command.Args(command, command.Flags().Args()); err == nil {
+		t.Fatal("Parse Args expected error, but ok")
+	}
+}
+
+func TestLintEnvelopeFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "signature.jws")
+	if err := os.WriteFile(path, []byte(`{"protected":"e30","payload":"","signature":"AA"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	outputs, err := lintEnvelopeFile(path)
+	if err != nil {
+		t.Fatalf("lintEnvelopeFile() error = %v", err)
+	}
+	if len(outputs) != 1 || outputs[0].MediaType != "application/jose+json" || len(outputs[0].Violations) == 0 {
+		t.Fatalf("lintEnvelopeFile() = %+v, want violations of the JWS envelope", outputs)
+	}
+
+	// not an envelope at all
+	if err := os.WriteFile(path, []byte("signature"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if outputs, err = lintEnvelopeFile(path); err != nil {
+		t.Fatalf("lintEnvelopeFile() error = %v", err)
+	}
+	if len(outputs) != 1 || len(outputs[0].Violations) != 1 || outputs[0].Violations[0].Rule != lint.RuleEncoding {
+		t.Fatalf("lintEnvelopeFile() = %+v, want an encoding violation", outputs)
+	}
+
+	err = runLintEnvelope(context.Background(), &lintEnvelopeOpts{target: path, outputFormat: cmd.OutputPlaintext})
+	if err == nil || !strings.Contains(err.Error(), "1 of 1 signature envelopes violate") {
+		t.Fatalf("runLintEnvelope() expects violation error, got %v", err)
+	}
+}
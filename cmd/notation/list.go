@@ -2,23 +2,34 @@ package main
 
 import (
 	"context"
+	"encoding/csv"
 	"errors"
 	"fmt"
+	"io"
+	"os"
+	"strconv"
 
 	notationregistry "github.com/notaryproject/notation-go/registry"
 	"github.com/notaryproject/notation/internal/cmd"
 	"github.com/notaryproject/notation/internal/experimental"
+	"github.com/notaryproject/notation/internal/graph"
+	"github.com/notaryproject/notation/internal/slices"
 	"github.com/opencontainers/go-digest"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/spf13/cobra"
+	"oras.land/oras-go/v2/registry"
 )
 
 type listOpts struct {
 	cmd.LoggingFlagOpts
 	SecureFlagOpts
-	reference string
-	ociLayout bool
-	inputType inputType
+	reference        string
+	ociLayout        bool
+	inputType        inputType
+	keepTagReference bool
+	graph            string
+	outputFormat     string
+	bundle           string
 }
 
 func listCommand(opts *listOpts) *cobra.Command {
@@ -27,14 +38,33 @@ func listCommand(opts *listOpts) *cobra.Command {
 			inputType: inputTypeRegistry, // remote registry by default
 		}
 	}
-	cmd := &cobra.Command{
+	command := &cobra.Command{
 		Use:     "list [flags] <reference>",
 		Aliases: []string{"ls"},
 		Short:   "List signatures of the signed artifact",
-		Long:    "List all the signatures associated with signed artifact",
+		Long: `List all the signatures associated with signed artifact
+
+Example - List signatures of an OCI artifact:
+  notation list <registry>/<repository>@<digest>
+
+Example - List signatures of an OCI artifact as CSV:
+  notation list --output csv <registry>/<repository>@<digest> > signatures.csv
+
+Example - Export the graph of an OCI artifact and all its referrers, such as signatures, for graphviz:
+  notation list --graph dot <registry>/<repository>@<digest> | dot -Tsvg > graph.svg
+
+Example - Export the graph of all the tagged artifacts of a repository and their referrers as JSON:
+  notation list --graph json <registry>/<repository>
+
+Example - [Experimental] List signatures of a bundle exported by "notation export-bundle" without accessing the registry:
+  notation list --bundle net-monitor.bundle.tgz
+`,
 		Args: func(cmd *cobra.Command, args []string) error {
 			if len(args) == 0 {
-				return errors.New("no reference specified")
+				if opts.bundle == "" {
+					return errors.New("no reference specified")
+				}
+				return nil
 			}
 			opts.reference = args[0]
 			return nil
@@ -43,23 +73,57 @@ func listCommand(opts *listOpts) *cobra.Command {
 			if opts.ociLayout {
 				opts.inputType = inputTypeOCILayout
 			}
-			return experimental.CheckFlagsAndWarn(cmd, "oci-layout")
+			if opts.graph != "" && !slices.Contains(graph.Formats, graph.Format(opts.graph)) {
+				return fmt.Errorf("unsupported graph format %q, options: %q, %q", opts.graph, graph.FormatDOT, graph.FormatJSON)
+			}
+			return experimental.CheckFlagsAndWarn(cmd, "oci-layout", "bundle")
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.SecureFlagOpts.applyChangedFlags(cmd.Flags())
 			return runList(cmd.Context(), opts)
 		},
 	}
-	opts.LoggingFlagOpts.ApplyFlags(cmd.Flags())
-	opts.SecureFlagOpts.ApplyFlags(cmd.Flags())
-	cmd.Flags().BoolVar(&opts.ociLayout, "oci-layout", false, "[Experimental] list signatures stored in OCI image layout")
-	experimental.HideFlags(cmd, "oci-layout")
-	return cmd
+	opts.LoggingFlagOpts.ApplyFlags(command.Flags())
+	opts.SecureFlagOpts.ApplyFlags(command.Flags())
+	command.Flags().BoolVar(&opts.ociLayout, "oci-layout", false, "[Experimental] list signatures stored in OCI image layout, in a directory or a tarball")
+	cmd.SetPflagKeepTagReference(command.Flags(), &opts.keepTagReference)
+	command.Flags().StringVar(&opts.graph, "graph", "", fmt.Sprintf("export the graph of the artifact and all its referrers instead of listing signatures, options: %q, %q. The graph of all the tagged artifacts is exported for a repository reference without tag or digest", graph.FormatDOT, graph.FormatJSON))
+	cmd.SetPflagOutput(command.Flags(), &opts.outputFormat, fmt.Sprintf("output format, options: '%s', '%s'", cmd.OutputCSV, cmd.OutputPlaintext))
+	command.Flags().StringVar(&opts.bundle, "bundle", "", "[Experimental] file of a bundle exported by \"notation export-bundle\" to list the signatures of without accessing the registry, the reference is optional and overrides the reference recorded in the bundle")
+	command.MarkFlagsMutuallyExclusive("graph", "keep-tag-reference")
+	command.MarkFlagsMutuallyExclusive("graph", "output")
+	for _, flag := range []string{"oci-layout", "graph", "keep-tag-reference"} {
+		command.MarkFlagsMutuallyExclusive("bundle", flag)
+	}
+	experimental.HideFlags(command, "oci-layout", "bundle")
+	return command
 }
 
 func runList(ctx context.Context, opts *listOpts) error {
 	// set log level
 	ctx = opts.LoggingFlagOpts.SetLoggerLevel(ctx)
 
+	if opts.outputFormat != cmd.OutputPlaintext && opts.outputFormat != cmd.OutputCSV {
+		return fmt.Errorf("unrecognized output format %s", opts.outputFormat)
+	}
+	if opts.bundle != "" {
+		return runListBundle(ctx, opts)
+	}
+	var err error
+	if opts.reference, err = expandAlias(opts.inputType, opts.reference); err != nil {
+		return err
+	}
+	if opts.inputType == inputTypeOCILayout {
+		closeLayout, err := openOCILayoutTarball(opts.reference, false)
+		if err != nil {
+			return err
+		}
+		defer closeLayout(false)
+	}
+	if opts.graph != "" {
+		return runListGraph(ctx, opts)
+	}
+
 	// initialize
 	reference := opts.reference
 	sigRepo, err := getRepository(ctx, opts.inputType, reference, &opts.SecureFlagOpts)
@@ -70,10 +134,61 @@ func runList(ctx context.Context, opts *listOpts) error {
 	if err != nil {
 		return err
 	}
+	if opts.keepTagReference {
+		resolvedRef = keepTagReference(opts.inputType, reference, resolvedRef)
+	}
+	if opts.outputFormat == cmd.OutputCSV {
+		return writeSignatureManifestsCSV(ctx, os.Stdout, targetDesc, sigRepo, resolvedRef)
+	}
 	// print all signature manifest digests
 	return printSignatureManifestDigests(ctx, targetDesc, sigRepo, resolvedRef)
 }
 
+// runListBundle lists the signatures of the bundle of opts.bundle without
+// accessing the registry.
+func runListBundle(ctx context.Context, opts *listOpts) error {
+	bundle, ref, err := openOfflineBundle(opts.bundle, opts.reference)
+	if err != nil {
+		return err
+	}
+	if opts.outputFormat == cmd.OutputCSV {
+		return writeSignatureManifestsCSV(ctx, os.Stdout, bundle.Subject, bundle.Repository(), ref)
+	}
+	return printSignatureManifestDigests(ctx, bundle.Subject, bundle.Repository(), ref)
+}
+
+// listCSVHeader is the header of the CSV output of the signature manifests.
+// The columns are stable, new columns are only appended.
+var listCSVHeader = []string{"reference", "signature_digest", "media_type", "artifact_type", "size"}
+
+// writeSignatureManifestsCSV writes the signature manifests of the subject
+// manifest to w as CSV records, one per signature manifest.
+func writeSignatureManifestsCSV(ctx context.Context, w io.Writer, targetDesc ocispec.Descriptor, sigRepo notationregistry.Repository, ref string) error {
+	csvWriter := csv.NewWriter(w)
+	if err := csvWriter.Write(listCSVHeader); err != nil {
+		return err
+	}
+	err := sigRepo.ListSignatures(ctx, targetDesc, func(signatureManifests []ocispec.Descriptor) error {
+		for _, sigManifestDesc := range signatureManifests {
+			if err := csvWriter.Write([]string{
+				ref,
+				sigManifestDesc.Digest.String(),
+				sigManifestDesc.MediaType,
+				sigManifestDesc.ArtifactType,
+				strconv.FormatInt(sigManifestDesc.Size, 10),
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
 // printSignatureManifestDigests returns the signature manifest digests of
 // the subject manifest.
 func printSignatureManifestDigests(ctx context.Context, targetDesc ocispec.Descriptor, sigRepo notationregistry.Repository, ref string) error {
@@ -114,3 +229,54 @@ func printSignatureManifestDigests(ctx context.Context, targetDesc ocispec.Descr
 	}
 	return nil
 }
+
+// runListGraph exports the graph of the artifact of opts.reference and its
+// referrers, or of all the tagged artifacts if opts.reference is a repository.
+func runListGraph(ctx context.Context, opts *listOpts) error {
+	storage, err := getReferrerStorage(ctx, opts.inputType, opts.reference, &opts.SecureFlagOpts)
+	if err != nil {
+		return err
+	}
+	var roots []ocispec.Descriptor
+	tags := map[digest.Digest][]string{}
+	ref, err := registry.ParseReference(opts.reference)
+	if opts.inputType == inputTypeRegistry && err == nil && ref.Reference == "" {
+		// repository reference
+		remoteRepo, err := getRepositoryClient(ctx, &opts.SecureFlagOpts, ref)
+		if err != nil {
+			return err
+		}
+		var tagList []string
+		if err := remoteRepo.Tags(ctx, "", func(page []string) error {
+			tagList = append(tagList, page...)
+			return nil
+		}); err != nil {
+			return fmt.Errorf("failed to list tags of %s: %w", opts.reference, err)
+		}
+		for _, tag := range tagList {
+			desc, err := remoteRepo.Resolve(ctx, tag)
+			if err != nil {
+				return fmt.Errorf("failed to resolve tag %q: %w", tag, err)
+			}
+			if len(tags[desc.Digest]) == 0 {
+				roots = append(roots, desc)
+			}
+			tags[desc.Digest] = append(tags[desc.Digest], tag)
+		}
+	} else {
+		sigRepo, err := getRepository(ctx, opts.inputType, opts.reference, &opts.SecureFlagOpts)
+		if err != nil {
+			return err
+		}
+		desc, _, err := resolveReference(ctx, opts.inputType, opts.reference, sigRepo, nil)
+		if err != nil {
+			return err
+		}
+		roots = append(roots, desc)
+	}
+	g, err := graph.Build(ctx, storage, roots, tags)
+	if err != nil {
+		return err
+	}
+	return g.Write(os.Stdout, graph.Format(opts.graph))
+}
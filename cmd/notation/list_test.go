@@ -1,31 +1,38 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"reflect"
 	"testing"
+
+	"github.com/notaryproject/notation/internal/cmd"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 )
 
 func TestListCommand_SecretsFromArgs(t *testing.T) {
 	opts := &listOpts{}
-	cmd := listCommand(opts)
+	command := listCommand(opts)
 	expected := &listOpts{
-		reference: "ref",
+		reference:    "ref",
+		outputFormat: cmd.OutputPlaintext,
 		SecureFlagOpts: SecureFlagOpts{
 			Password:  "password",
 			PlainHTTP: true,
 			Username:  "user",
 		},
 	}
-	if err := cmd.ParseFlags([]string{
+	if err := command.ParseFlags([]string{
 		"--password", expected.Password,
 		expected.reference,
 		"-u", expected.Username,
 		"--plain-http"}); err != nil {
 		t.Fatalf("Parse Flag failed: %v", err)
 	}
-	if err := cmd.Args(cmd, cmd.Flags().Args()); err != nil {
+	if err := command.Args(command, command.Flags().Args()); err != nil {
 		t.Fatalf("Parse Args failed: %v", err)
 	}
-	if *opts != *expected {
+	if !reflect.DeepEqual(opts, expected) {
 		t.Fatalf("Expect list opts: %v, got: %v", expected, opts)
 	}
 }
@@ -35,21 +42,22 @@ func TestListCommand_SecretsFromEnv(t *testing.T) {
 	t.Setenv(defaultPasswordEnv, "password")
 	opts := &listOpts{}
 	expected := &listOpts{
-		reference: "ref",
+		reference:    "ref",
+		outputFormat: cmd.OutputPlaintext,
 		SecureFlagOpts: SecureFlagOpts{
 			Password: "password",
 			Username: "user",
 		},
 	}
-	cmd := listCommand(opts)
-	if err := cmd.ParseFlags([]string{
+	command := listCommand(opts)
+	if err := command.ParseFlags([]string{
 		expected.reference}); err != nil {
 		t.Fatalf("Parse Flag failed: %v", err)
 	}
-	if err := cmd.Args(cmd, cmd.Flags().Args()); err != nil {
+	if err := command.Args(command, command.Flags().Args()); err != nil {
 		t.Fatalf("Parse Args failed: %v", err)
 	}
-	if *opts != *expected {
+	if !reflect.DeepEqual(opts, expected) {
 		t.Fatalf("Expect list opts: %v, got: %v", expected, opts)
 	}
 }
@@ -63,3 +71,57 @@ func TestListCommand_MissingArgs(t *testing.T) {
 		t.Fatal("Parse Args expected error, but ok")
 	}
 }
+
+func TestListCommand_InvalidGraphFormat(t *testing.T) {
+	cmd := listCommand(nil)
+	if err := cmd.ParseFlags([]string{"ref", "--graph", "yaml"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := cmd.PreRunE(cmd, cmd.Flags().Args()); err == nil {
+		t.Fatal("PreRunE expected error, but ok")
+	}
+}
+
+func TestWriteSignatureManifestsCSV(t *testing.T) {
+	repo := &verifyOutputRepository{
+		signatures: []ocispec.Descriptor{
+			{MediaType: ocispec.MediaTypeImageManifest, ArtifactType: "application/vnd.cncf.notary.signature", Digest: "sha256:aaa", Size: 728},
+			{MediaType: ocispec.MediaTypeImageManifest, Digest: "sha256:bbb", Size: 730},
+		},
+	}
+	var buf bytes.Buffer
+	if err := writeSignatureManifestsCSV(context.Background(), &buf, ocispec.Descriptor{}, repo, "localhost:5000/net-monitor@sha256:ccc"); err != nil {
+		t.Fatal(err)
+	}
+	expected := `reference,signature_digest,media_type,artifact_type,size
+localhost:5000/net-monitor@sha256:ccc,sha256:aaa,application/vnd.oci.image.manifest.v1+json,application/vnd.cncf.notary.signature,728
+localhost:5000/net-monitor@sha256:ccc,sha256:bbb,application/vnd.oci.image.manifest.v1+json,,730
+`
+	if got := buf.String(); got != expected {
+		t.Fatalf("writeSignatureManifestsCSV() = %q, want %q", got, expected)
+	}
+}
+
+func TestListCommand_Bundle(t *testing.T) {
+	opts := &listOpts{}
+	cmd := listCommand(opts)
+	if err := cmd.ParseFlags([]string{"--bundle", "net-monitor.bundle.tgz"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := cmd.Args(cmd, cmd.Flags().Args()); err != nil {
+		t.Fatalf("Parse Args failed: %v", err)
+	}
+	if opts.bundle != "net-monitor.bundle.tgz" || opts.reference != "" {
+		t.Fatalf("unexpected list opts: %+v", opts)
+	}
+
+	for _, flag := range []string{"--oci-layout", "--graph=dot", "--keep-tag-reference"} {
+		cmd := listCommand(nil)
+		if err := cmd.ParseFlags([]string{"ref", "--bundle", "net-monitor.bundle.tgz", flag}); err != nil {
+			t.Fatalf("Parse Flag failed: %v", err)
+		}
+		if err := cmd.ValidateFlagGroups(); err == nil {
+			t.Fatalf("expected error of flag \"--bundle\" with flag %q", flag)
+		}
+	}
+}
@@ -10,6 +10,8 @@ import (
 	"strings"
 
 	"github.com/notaryproject/notation/internal/cmd"
+	"github.com/notaryproject/notation/internal/devicecode"
+	"github.com/notaryproject/notation/internal/experimental"
 	"github.com/notaryproject/notation/pkg/auth"
 	"github.com/spf13/cobra"
 	"golang.org/x/term"
@@ -20,6 +22,9 @@ type loginOpts struct {
 	cmd.LoggingFlagOpts
 	SecureFlagOpts
 	passwordStdin bool
+	deviceCode    bool
+	issuer        string
+	clientID      string
 	server        string
 }
 
@@ -36,7 +41,17 @@ Example - Login with provided username and password:
 	notation login -u <user> -p <password> registry.example.com
 
 Example - Login using $NOTATION_USERNAME $NOTATION_PASSWORD variables:
-	notation login registry.example.com`,
+	notation login registry.example.com
+
+Example - Login using $NOTATION_USERNAME_REGISTRY_EXAMPLE_COM $NOTATION_PASSWORD_REGISTRY_EXAMPLE_COM variables scoped to the host:
+	notation login registry.example.com
+
+Example - Login with credentials scoped to the repositories under a namespace:
+	notation login -u <user> -p <password> registry.example.com/team-a
+
+Example - [Experimental] Login with the OAuth2 device code flow of the identity provider fronting the registry:
+	export NOTATION_EXPERIMENTAL=1
+	notation login --device-code --issuer https://login.example.com --client-id notation registry.example.com`,
 		Args: func(cmd *cobra.Command, args []string) error {
 			if len(args) == 0 {
 				return errors.New("no hostname specified")
@@ -45,18 +60,38 @@ Example - Login using $NOTATION_USERNAME $NOTATION_PASSWORD variables:
 			return nil
 		},
 		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if opts.deviceCode {
+				if opts.issuer == "" || opts.clientID == "" {
+					return errors.New("flags \"--issuer\" and \"--client-id\" are required when flag \"--device-code\" is set")
+				}
+				// credentials from the environment are not used
+				opts.Username, opts.Password = "", ""
+			} else if opts.issuer != "" || opts.clientID != "" {
+				return errors.New("flags \"--issuer\" and \"--client-id\" can only be used when flag \"--device-code\" is set")
+			}
 			if err := readPassword(opts); err != nil {
 				return err
 			}
-			return nil
+			return experimental.CheckFlagsAndWarn(cmd, "device-code", "issuer", "client-id")
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.SecureFlagOpts.applyChangedFlags(cmd.Flags())
+			// the password of stdin takes precedence over the environment
+			opts.credentialsFromFlags = opts.credentialsFromFlags || opts.passwordStdin
 			return runLogin(cmd.Context(), opts)
 		},
 	}
 	opts.LoggingFlagOpts.ApplyFlags(command.Flags())
 	opts.SecureFlagOpts.ApplyFlags(command.Flags())
 	command.Flags().BoolVar(&opts.passwordStdin, "password-stdin", false, "take the password from stdin")
+	command.Flags().BoolVar(&opts.deviceCode, "device-code", false, "[Experimental] log in with the OAuth2 device code flow of the identity provider of flag \"--issuer\", and store the obtained refresh token as the credential")
+	command.Flags().StringVar(&opts.issuer, "issuer", "", "[Experimental] URL of the OpenID Connect issuer of the identity provider, required and can only be used when flag \"--device-code\" is set")
+	command.Flags().StringVar(&opts.clientID, "client-id", "", "[Experimental] client identifier registered with the identity provider, required and can only be used when flag \"--device-code\" is set")
+	for _, name := range []string{"username", "password", "password-stdin"} {
+		command.MarkFlagsMutuallyExclusive("device-code", name)
+	}
+	experimental.HideFlags(command, "device-code", "issuer", "client-id")
+	command.AddCommand(loginRotateCommand(nil))
 	return command
 }
 
@@ -65,12 +100,29 @@ func runLogin(ctx context.Context, opts *loginOpts) error {
 	ctx = opts.LoggingFlagOpts.SetLoggerLevel(ctx)
 
 	// initialize
-	serverAddress := opts.server
+	registryName, namespace, err := auth.SplitServerAddress(opts.server)
+	if err != nil {
+		return err
+	}
+	// credentials are saved in the form looked up when accessing
+	// repositories of the namespace
+	serverAddress := auth.CredentialKey(registryName, namespace)
+
+	if opts.deviceCode {
+		// the refresh token is stored with an empty username
+		opts.Password, err = loginWithDeviceCode(ctx, opts)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !opts.deviceCode {
+		opts.Username, opts.Password = opts.credentials(registryName)
+	}
 
 	// input username and password by prompt
 	reader := bufio.NewReader(os.Stdin)
-	var err error
-	if opts.Username == "" {
+	if opts.Username == "" && !opts.deviceCode {
 		opts.Username, err = readUsernameFromPrompt(reader)
 		if err != nil {
 			return err
@@ -84,11 +136,11 @@ func runLogin(ctx context.Context, opts *loginOpts) error {
 		}
 	}
 
-	if err := validateAuthConfig(ctx, opts, serverAddress); err != nil {
+	if err := validateAuthConfig(ctx, opts, registryName); err != nil {
 		return err
 	}
 
-	nativeStore, err := auth.GetCredentialsStore(ctx, serverAddress)
+	nativeStore, err := auth.GetCredentialsStore(ctx, registryName)
 	if err != nil {
 		return fmt.Errorf("could not get the credentials store: %v", err)
 	}
@@ -106,6 +158,32 @@ func runLogin(ctx context.Context, opts *loginOpts) error {
 	return nil
 }
 
+// loginWithDeviceCode runs the OAuth2 device code flow, prompting the user to
+// authorize notation in a browser, and returns the obtained refresh token.
+func loginWithDeviceCode(ctx context.Context, opts *loginOpts) (string, error) {
+	client, err := devicecode.Discover(ctx, nil, opts.issuer, opts.clientID)
+	if err != nil {
+		return "", err
+	}
+	authorization, err := client.Authorize(ctx)
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprintf(os.Stderr, "To log in, open %s in a browser and enter the code %s\n", authorization.VerificationURI, authorization.UserCode)
+	if authorization.VerificationURIComplete != "" {
+		fmt.Fprintf(os.Stderr, "or open %s\n", authorization.VerificationURIComplete)
+	}
+	fmt.Fprintln(os.Stderr, "Waiting for authorization...")
+	token, err := client.PollToken(ctx, authorization)
+	if err != nil {
+		return "", fmt.Errorf("device code login failed: %w", err)
+	}
+	if token.RefreshToken == "" {
+		return "", errors.New("device code login failed: the identity provider did not issue a refresh token, check that the client is allowed the \"offline_access\" scope")
+	}
+	return token.RefreshToken, nil
+}
+
 func validateAuthConfig(ctx context.Context, opts *loginOpts, serverAddress string) error {
 	registry, err := getRegistryClient(ctx, &opts.SecureFlagOpts, serverAddress)
 	if err != nil {
@@ -1,8 +1,12 @@
 package main
 
 import (
+	"io"
 	"os"
+	"reflect"
 	"testing"
+
+	"github.com/spf13/cobra"
 )
 
 func TestLoginCommand_PasswordFromArgs(t *testing.T) {
@@ -29,7 +33,7 @@ func TestLoginCommand_PasswordFromArgs(t *testing.T) {
 	if err := cmd.PreRunE(cmd, cmd.Flags().Args()); err != nil {
 		t.Fatalf("Get password failed: %v", err)
 	}
-	if *opts != *expected {
+	if !reflect.DeepEqual(opts, expected) {
 		t.Fatalf("Expect login opts: %v, got: %v", expected, opts)
 	}
 }
@@ -73,7 +77,7 @@ func TestLogin_PasswordFromStdin(t *testing.T) {
 	if err := cmd.PreRunE(cmd, cmd.Flags().Args()); err != nil {
 		t.Fatalf("Read password from stdin failed: %v", err)
 	}
-	if *opts != *expected {
+	if !reflect.DeepEqual(opts, expected) {
 		t.Fatalf("Expect login opts: %+v, got: %+v", expected, opts)
 	}
 }
@@ -87,3 +91,53 @@ func TestLoginCommand_MissingArgs(t *testing.T) {
 		t.Fatal("Parse Args expected error, but ok")
 	}
 }
+
+func TestLoginCommand_DeviceCode(t *testing.T) {
+	t.Setenv("NOTATION_EXPERIMENTAL", "1")
+	t.Setenv(defaultUsernameEnv, "user")
+	t.Setenv(defaultPasswordEnv, "password")
+	opts := &loginOpts{}
+	cmd := loginCommand(opts)
+	expected := &loginOpts{
+		deviceCode: true,
+		issuer:     "https://login.example.com",
+		clientID:   "notation",
+		server:     "server",
+	}
+	if err := cmd.ParseFlags([]string{
+		expected.server,
+		"--device-code",
+		"--issuer", expected.issuer,
+		"--client-id", expected.clientID,
+	}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := cmd.Args(cmd, cmd.Flags().Args()); err != nil {
+		t.Fatalf("Parse args failed: %v", err)
+	}
+	if err := cmd.PreRunE(cmd, cmd.Flags().Args()); err != nil {
+		t.Fatalf("PreRunE failed: %v", err)
+	}
+	if !reflect.DeepEqual(opts, expected) {
+		t.Fatalf("Expect login opts: %+v, got: %+v", expected, opts)
+	}
+}
+
+func TestLoginCommand_DeviceCodeInvalidFlags(t *testing.T) {
+	t.Setenv("NOTATION_EXPERIMENTAL", "1")
+	for _, args := range [][]string{
+		{"server", "--device-code"},
+		{"server", "--device-code", "--issuer", "https://login.example.com"},
+		{"server", "--issuer", "https://login.example.com", "--client-id", "notation"},
+		{"server", "--device-code", "--issuer", "https://login.example.com", "--client-id", "notation", "-u", "user"},
+	} {
+		cmd := loginCommand(nil)
+		cmd.SetArgs(args)
+		cmd.SetOut(io.Discard)
+		cmd.SetErr(io.Discard)
+		cmd.RunE = func(*cobra.Command, []string) error { return nil }
+		if err := cmd.Execute(); err == nil {
+			t.Fatalf("expected error for args %v", args)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/notaryproject/notation/internal/cmd"
+	"github.com/notaryproject/notation/internal/color"
+	"github.com/notaryproject/notation/internal/experimental"
+	"github.com/notaryproject/notation/pkg/auth"
+	"github.com/notaryproject/notation/pkg/configutil"
+	"github.com/spf13/cobra"
+	orasauth "oras.land/oras-go/v2/registry/remote/auth"
+)
+
+type loginRotateOpts struct {
+	cmd.LoggingFlagOpts
+	SecureFlagOpts
+	server  string
+	rotator string
+	keepOld bool
+}
+
+func loginRotateCommand(opts *loginRotateOpts) *cobra.Command {
+	if opts == nil {
+		opts = &loginRotateOpts{}
+	}
+	command := &cobra.Command{
+		Use:   "rotate [flags] <server>",
+		Short: "[Experimental] Rotate the stored credentials of a registry",
+		Long: `[Experimental] Rotate the stored credentials of a registry
+
+The stored credentials are exchanged for new ones by the credential rotator of the registry, i.e. the program "notation-credential-rotator-{name}" calling the registry specific APIs. The rotator is configured per registry hostname in "credentialRotators" of config.json, or set with flag "--rotator". The new credentials are checked against the registry before they replace the stored credentials, and the old credentials are revoked afterwards.
+
+Example - Rotate the credentials of a registry with its configured credential rotator:
+  notation login rotate registry.example.com
+
+Example - Rotate the credentials scoped to a namespace with the credential rotator "acme":
+  notation login rotate --rotator acme registry.example.com/team-a`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return errors.New("no hostname specified")
+			}
+			opts.server = args[0]
+			return nil
+		},
+		PreRunE: experimental.CheckCommandAndWarn,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runLoginRotate(cmd.Context(), opts)
+		},
+	}
+	opts.LoggingFlagOpts.ApplyFlags(command.Flags())
+	command.Flags().BoolVar(&opts.PlainHTTP, "plain-http", false, "registry access via plain HTTP")
+	command.Flags().StringVar(&opts.rotator, "rotator", "", "name of the credential rotator, overriding the credential rotator configured for the registry")
+	command.Flags().BoolVar(&opts.keepOld, "keep-old", false, "do not revoke the old credentials after the rotation")
+	return command
+}
+
+func runLoginRotate(ctx context.Context, opts *loginRotateOpts) error {
+	// set log level
+	ctx = opts.LoggingFlagOpts.SetLoggerLevel(ctx)
+
+	// initialize
+	registryName, namespace, err := auth.SplitServerAddress(opts.server)
+	if err != nil {
+		return err
+	}
+	serverAddress := auth.CredentialKey(registryName, namespace)
+	rotatorName, err := resolveCredentialRotator(registryName, opts.rotator)
+	if err != nil {
+		return err
+	}
+	nativeStore, err := auth.GetCredentialsStore(ctx, registryName)
+	if err != nil {
+		return fmt.Errorf("could not get the credentials store: %v", err)
+	}
+	current, err := nativeStore.Get(serverAddress)
+	if err != nil {
+		return fmt.Errorf("failed to get credentials: %v", err)
+	}
+	if current == orasauth.EmptyCredential {
+		return fmt.Errorf("no credentials of %s are stored, log in with \"notation login\" first", serverAddress)
+	}
+
+	// core process
+	return rotateCredential(ctx, opts, nativeStore, auth.NewRotator(ctx, rotatorName), registryName, serverAddress, current)
+}
+
+// rotateCredential exchanges the current credentials of serverAddress for new
+// ones, checks them against the registry, stores them and revokes the current
+// credentials. The stored credentials are left unchanged if any step before
+// the revocation fails.
+func rotateCredential(ctx context.Context, opts *loginRotateOpts, store auth.CredentialStore, rotator auth.CredentialRotator, registryName, serverAddress string, current orasauth.Credential) error {
+	rotated, err := rotator.Rotate(serverAddress, current)
+	if err != nil {
+		return err
+	}
+	if rotated == current {
+		return errors.New("credential rotator returned the current credentials")
+	}
+
+	// the new credentials are only stored once they are accepted by the
+	// registry
+	secureOpts := SecureFlagOpts{PlainHTTP: opts.PlainHTTP}
+	secureOpts.Username, secureOpts.Password = rotated.Username, rotated.Password
+	if rotated.RefreshToken != "" {
+		secureOpts.Password = rotated.RefreshToken
+	}
+	if err := validateAuthConfig(ctx, &loginOpts{SecureFlagOpts: secureOpts}, registryName); err != nil {
+		return fmt.Errorf("the registry does not accept the new credentials, the stored credentials are unchanged: %w", err)
+	}
+	if err := store.Store(serverAddress, rotated); err != nil {
+		return fmt.Errorf("failed to store credentials, the stored credentials are unchanged: %v", err)
+	}
+	if stored, err := store.Get(serverAddress); err != nil || stored != rotated {
+		// the credential helper may not have replaced the credentials as a
+		// whole, so the current credentials are restored
+		if restoreErr := store.Store(serverAddress, current); restoreErr != nil {
+			return fmt.Errorf("failed to store credentials and to restore the old credentials, log in again: %v", restoreErr)
+		}
+		return errors.New("failed to store credentials, the old credentials are restored")
+	}
+	fmt.Println("Rotated credentials of", serverAddress)
+
+	if opts.keepOld {
+		return nil
+	}
+	if err := rotator.Revoke(serverAddress, current); err != nil {
+		fmt.Fprintf(os.Stderr, "%s The old credentials are not revoked: %v\n", color.Warning(os.Stderr, "Warning:"), err)
+		return nil
+	}
+	fmt.Println("Revoked old credentials of", serverAddress)
+	return nil
+}
+
+// resolveCredentialRotator returns the name of the credential rotator of the
+// registry, which is name if set.
+func resolveCredentialRotator(registryName, name string) (string, error) {
+	if name != "" {
+		return name, nil
+	}
+	cliConfig, err := configutil.LoadCLIConfigOnce()
+	if err != nil {
+		return "", fmt.Errorf("failed to load config: %w", err)
+	}
+	if name := cliConfig.CredentialRotators[registryName]; name != "" {
+		return name, nil
+	}
+	return "", fmt.Errorf("no credential rotator is configured for %s, set flag \"--rotator\" or \"credentialRotators\" in config.json", registryName)
+}
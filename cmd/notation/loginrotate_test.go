@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"oras.land/oras-go/v2/registry/remote/auth"
+)
+
+type mapCredentialStore map[string]auth.Credential
+
+func (s mapCredentialStore) Store(serverAddress string, cred auth.Credential) error {
+	s[serverAddress] = cred
+	return nil
+}
+
+func (s mapCredentialStore) Erase(serverAddress string) error {
+	delete(s, serverAddress)
+	return nil
+}
+
+func (s mapCredentialStore) Get(serverAddress string) (auth.Credential, error) {
+	return s[serverAddress], nil
+}
+
+type testRotator struct {
+	rotated   auth.Credential
+	rotateErr error
+	revoked   []auth.Credential
+}
+
+func (r *testRotator) Rotate(serverAddress string, current auth.Credential) (auth.Credential, error) {
+	return r.rotated, r.rotateErr
+}
+
+func (r *testRotator) Revoke(serverAddress string, cred auth.Credential) error {
+	r.revoked = append(r.revoked, cred)
+	return nil
+}
+
+// newBasicAuthRegistry returns a registry accepting only the credentials.
+func newBasicAuthRegistry(t *testing.T, cred auth.Credential) string {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if username, password, ok := r.BasicAuth(); !ok || username != cred.Username || password != cred.Password {
+			w.Header().Set("WWW-Authenticate", `Basic realm="test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u.Host
+}
+
+func TestLoginRotateCommand(t *testing.T) {
+	opts := &loginRotateOpts{}
+	command := loginRotateCommand(opts)
+	if err := command.ParseFlags([]string{"registry.example.com", "--rotator", "acme", "--keep-old"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := command.Args(command, command.Flags().Args()); err != nil {
+		t.Fatalf("Parse args failed: %v", err)
+	}
+	expected := &loginRotateOpts{server: "registry.example.com", rotator: "acme", keepOld: true}
+	if !reflect.DeepEqual(opts, expected) {
+		t.Fatalf("Expect login rotate opts: %v, got: %v", expected, opts)
+	}
+}
+
+func TestRotateCredential(t *testing.T) {
+	current := auth.Credential{Username: "user", Password: "old"}
+	rotated := auth.Credential{Username: "user", Password: "new"}
+	registryName := newBasicAuthRegistry(t, rotated)
+	opts := &loginRotateOpts{SecureFlagOpts: SecureFlagOpts{PlainHTTP: true}}
+
+	t.Run("rotated", func(t *testing.T) {
+		store := mapCredentialStore{registryName: current}
+		rotator := &testRotator{rotated: rotated}
+		if err := rotateCredential(context.Background(), opts, store, rotator, registryName, registryName, current); err != nil {
+			t.Fatal(err)
+		}
+		if store[registryName] != rotated {
+			t.Fatalf("expected the new credentials to be stored, got %v", store[registryName])
+		}
+		if len(rotator.revoked) != 1 || rotator.revoked[0] != current {
+			t.Fatalf("expected the old credentials to be revoked, got %v", rotator.revoked)
+		}
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		store := mapCredentialStore{registryName: current}
+		rotator := &testRotator{rotated: auth.Credential{Username: "user", Password: "wrong"}}
+		err := rotateCredential(context.Background(), opts, store, rotator, registryName, registryName, current)
+		if err == nil || !strings.Contains(err.Error(), "does not accept the new credentials") {
+			t.Fatalf("expected the new credentials to be rejected, got %v", err)
+		}
+		if store[registryName] != current || len(rotator.revoked) != 0 {
+			t.Fatalf("expected the old credentials to be kept, got %v, revoked %v", store[registryName], rotator.revoked)
+		}
+	})
+
+	t.Run("rotator failure", func(t *testing.T) {
+		store := mapCredentialStore{registryName: current}
+		rotator := &testRotator{rotateErr: errors.New("quota exceeded")}
+		if err := rotateCredential(context.Background(), opts, store, rotator, registryName, registryName, current); err == nil {
+			t.Fatal("expected error, got nil")
+		}
+		if store[registryName] != current {
+			t.Fatalf("expected the old credentials to be kept, got %v", store[registryName])
+		}
+	})
+
+	t.Run("keep old", func(t *testing.T) {
+		store := mapCredentialStore{registryName: current}
+		rotator := &testRotator{rotated: rotated}
+		keepOpts := *opts
+		keepOpts.keepOld = true
+		if err := rotateCredential(context.Background(), &keepOpts, store, rotator, registryName, registryName, current); err != nil {
+			t.Fatal(err)
+		}
+		if len(rotator.revoked) != 0 {
+			t.Fatalf("expected the old credentials not to be revoked, got %v", rotator.revoked)
+		}
+	})
+}
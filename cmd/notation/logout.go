@@ -42,8 +42,12 @@ func runLogout(ctx context.Context, opts *logoutOpts) error {
 	ctx = opts.LoggingFlagOpts.SetLoggerLevel(ctx)
 
 	// initialize
-	serverAddress := opts.server
-	nativeStore, err := auth.GetCredentialsStore(ctx, serverAddress)
+	registryName, namespace, err := auth.SplitServerAddress(opts.server)
+	if err != nil {
+		return err
+	}
+	serverAddress := auth.CredentialKey(registryName, namespace)
+	nativeStore, err := auth.GetCredentialsStore(ctx, registryName)
 	if err != nil {
 		return err
 	}
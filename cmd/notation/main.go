@@ -1,33 +1,68 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"os"
+	"time"
 
 	"github.com/notaryproject/notation/cmd/notation/cert"
 	"github.com/notaryproject/notation/cmd/notation/policy"
+	"github.com/notaryproject/notation/internal/color"
+	"github.com/notaryproject/notation/internal/trailer"
 	"github.com/spf13/cobra"
 )
 
 func main() {
+	var colorMode string
+	policyCommand := policy.Cmd()
+	policyCommand.AddCommand(policyDriftCommand(nil))
 	cmd := &cobra.Command{
 		Use:          "notation",
 		Short:        "Notation - a tool to sign and verify artifacts",
 		SilenceUsage: true,
+		// errors are printed in color below
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return color.SetMode(colorMode)
+		},
 	}
+	cmd.PersistentFlags().StringVar(&colorMode, "color", color.ModeAuto, fmt.Sprintf("colorize the output, options: %q, %q, %q. Colors are disabled if the NO_COLOR environment variable is set to a non-empty value and the mode is %q", color.ModeAuto, color.ModeAlways, color.ModeNever, color.ModeAuto))
 	cmd.AddCommand(
 		signCommand(nil),
 		verifyCommand(nil),
 		listCommand(nil),
 		cert.Cmd(),
-		policy.Cmd(),
+		policyCommand,
 		keyCommand(),
 		pluginCommand(),
 		loginCommand(nil),
 		logoutCommand(nil),
 		versionCommand(),
 		inspectCommand(nil),
+		digestCommand(nil),
+		reportCommand(),
+		aliasCommand(),
+		archiveCommand(),
+		ciCommand(),
+		selfUpdateCommand(nil),
+		blobCommand(),
+		configCommand(),
+		copyCommand(nil),
+		exportBundleCommand(nil),
+		cacheCommand(),
+		auditCommand(),
+		lintEnvelopeCommand(nil),
 	)
-	if err := cmd.Execute(); err != nil {
+	if isDockerPluginInvocation() {
+		enableDockerPluginMode(cmd, os.Args[1:])
+	}
+	// the summary trailer of commands enabling it is the final line on stderr
+	summary := trailer.New(time.Now())
+	if err := cmd.ExecuteContext(trailer.WithSummary(context.Background(), summary)); err != nil {
+		fmt.Fprintln(os.Stderr, color.Failure(os.Stderr, "Error:"), err)
+		summary.Write(os.Stderr, err, time.Now())
 		os.Exit(1)
 	}
+	summary.Write(os.Stderr, nil, time.Now())
 }
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
@@ -11,8 +12,10 @@ import (
 	"github.com/notaryproject/notation-go/log"
 	notationregistry "github.com/notaryproject/notation-go/registry"
 	notationerrors "github.com/notaryproject/notation/cmd/notation/internal/errors"
+	"github.com/notaryproject/notation/internal/platform"
 	"github.com/opencontainers/go-digest"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
 	"oras.land/oras-go/v2/registry"
 )
 
@@ -38,12 +41,12 @@ func resolveReference(ctx context.Context, inputType inputType, reference string
 		if err != nil {
 			return ocispec.Descriptor{}, "", fmt.Errorf("failed to resolve user input reference: %w", err)
 		}
-		layoutPathInfo, err := os.Stat(layoutPath)
+		layoutPathInfo, err := os.Stat(ociLayoutDir(layoutPath))
 		if err != nil {
 			return ocispec.Descriptor{}, "", fmt.Errorf("failed to resolve user input reference: %w", err)
 		}
 		if !layoutPathInfo.IsDir() {
-			return ocispec.Descriptor{}, "", errors.New("failed to resolve user input reference: input path is not a dir")
+			return ocispec.Descriptor{}, "", errors.New("failed to resolve user input reference: input path is neither a dir nor a tarball")
 		}
 		tagOrDigestRef = layoutReference
 		resolvedRef = layoutPath
@@ -78,6 +81,70 @@ func resolveArtifactDigestReference(reference, policyScope string) string {
 	return reference
 }
 
+// resolvePlatform resolves the manifest of the platform in the image index
+// described by indexDesc, which reference resolves to. Returns the descriptor
+// of the platform manifest and resolvedRef with the digest of the platform
+// manifest.
+func resolvePlatform(ctx context.Context, inputType inputType, reference string, opts *SecureFlagOpts, indexDesc ocispec.Descriptor, resolvedRef, platformFlag string) (ocispec.Descriptor, string, error) {
+	want, err := platform.Parse(platformFlag)
+	if err != nil {
+		return ocispec.Descriptor{}, "", err
+	}
+	fetcher, err := getManifestFetcher(ctx, inputType, reference, opts)
+	if err != nil {
+		return ocispec.Descriptor{}, "", err
+	}
+	manifestDesc, err := platform.Select(ctx, fetcher, indexDesc, want)
+	if err != nil {
+		return ocispec.Descriptor{}, "", fmt.Errorf("failed to resolve platform %s: %w", platform.String(want), err)
+	}
+	log.GetLogger(ctx).Infof("Resolved platform %s of image index %s to manifest %s", platform.String(want), indexDesc.Digest, manifestDesc.Digest)
+	name, _, _ := strings.Cut(resolvedRef, "@")
+	return manifestDesc, name + "@" + manifestDesc.Digest.String(), nil
+}
+
+// keepTagReference returns resolvedRef with the tag of the user input
+// reference kept before the digest, i.e. <repository>:<tag>@<digest>.
+// resolvedRef is returned unchanged if the user input reference is a digest
+// reference.
+func keepTagReference(inputType inputType, reference, resolvedRef string) string {
+	tag := referenceTag(inputType, reference)
+	if tag == "" {
+		return resolvedRef
+	}
+	name, dgst, found := strings.Cut(resolvedRef, "@")
+	if !found {
+		return resolvedRef
+	}
+	return name + ":" + tag + "@" + dgst
+}
+
+// referenceTag returns the tag of the user input reference, or an empty
+// string if the reference is a digest reference or cannot be parsed.
+func referenceTag(inputType inputType, reference string) string {
+	var tagOrDigestRef string
+	switch inputType {
+	case inputTypeRegistry:
+		ref, err := registry.ParseReference(reference)
+		if err != nil {
+			return ""
+		}
+		tagOrDigestRef = ref.Reference
+	case inputTypeOCILayout:
+		_, layoutReference, err := parseOCILayoutReference(reference)
+		if err != nil {
+			return ""
+		}
+		tagOrDigestRef = layoutReference
+	default:
+		return ""
+	}
+	if _, err := digest.Parse(tagOrDigestRef); err == nil {
+		return ""
+	}
+	return tagOrDigestRef
+}
+
 // parseOCILayoutReference parses the raw in format of <path>[:<tag>|@<digest>].
 // Returns the path to the OCI layout and the reference (tag or digest).
 func parseOCILayoutReference(raw string) (string, string, error) {
@@ -119,3 +186,52 @@ func getManifestDescriptor(ctx context.Context, reference string, sigRepo notati
 	logger.Infof("Reference %s resolved to manifest descriptor: %+v", reference, manifestDesc)
 	return manifestDesc, nil
 }
+
+// fetchManifestAnnotations fetches the manifest of desc given user input type
+// and user input reference, and returns its annotations.
+func fetchManifestAnnotations(ctx context.Context, inputType inputType, reference string, opts *SecureFlagOpts, desc ocispec.Descriptor) (map[string]string, error) {
+	fetcher, err := getManifestFetcher(ctx, inputType, reference, opts)
+	if err != nil {
+		return nil, err
+	}
+	manifestJSON, err := content.FetchAll(ctx, fetcher, desc)
+	if err != nil {
+		return nil, err
+	}
+	// image manifests, image indexes and artifact manifests all have
+	// annotations at the top level
+	var manifest struct {
+		Annotations map[string]string `json:"annotations"`
+	}
+	if err := json.Unmarshal(manifestJSON, &manifest); err != nil {
+		return nil, fmt.Errorf("malformed manifest: %w", err)
+	}
+	return manifest.Annotations, nil
+}
+
+// fetchManifestArtifactType fetches the manifest of desc given user input type
+// and user input reference, and returns its artifact type as reported by the
+// referrers API of the OCI distribution spec, i.e. the artifactType field or
+// else the media type of the config. Image indexes without the artifactType
+// field have no artifact type.
+func fetchManifestArtifactType(ctx context.Context, inputType inputType, reference string, opts *SecureFlagOpts, desc ocispec.Descriptor) (string, error) {
+	fetcher, err := getManifestFetcher(ctx, inputType, reference, opts)
+	if err != nil {
+		return "", err
+	}
+	manifestJSON, err := content.FetchAll(ctx, fetcher, desc)
+	if err != nil {
+		return "", err
+	}
+	var manifest struct {
+		ArtifactType string              `json:"artifactType"`
+		Config       *ocispec.Descriptor `json:"config"`
+	}
+	if err := json.Unmarshal(manifestJSON, &manifest); err != nil {
+		return "", fmt.Errorf("malformed manifest: %w", err)
+	}
+	if manifest.ArtifactType == "" && manifest.Config != nil {
+		return manifest.Config.MediaType, nil
+	}
+	return manifest.ArtifactType, nil
+}
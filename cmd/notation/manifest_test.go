@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/content/oci"
+)
+
+func TestKeepTagReference(t *testing.T) {
+	const dgst = "sha256:b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"
+	tests := []struct {
+		inputType   inputType
+		reference   string
+		resolvedRef string
+		expected    string
+	}{
+		{inputTypeRegistry, "localhost:5000/net-monitor:v1", "localhost:5000/net-monitor@" + dgst, "localhost:5000/net-monitor:v1@" + dgst},
+		{inputTypeRegistry, "localhost:5000/net-monitor@" + dgst, "localhost:5000/net-monitor@" + dgst, "localhost:5000/net-monitor@" + dgst},
+		{inputTypeOCILayout, "hello-world:v1", "hello-world@" + dgst, "hello-world:v1@" + dgst},
+		{inputTypeOCILayout, "hello-world@" + dgst, "hello-world@" + dgst, "hello-world@" + dgst},
+	}
+	for _, tt := range tests {
+		if got := keepTagReference(tt.inputType, tt.reference, tt.resolvedRef); got != tt.expected {
+			t.Errorf("keepTagReference(%q) = %q, expected %q", tt.reference, got, tt.expected)
+		}
+	}
+}
+
+func TestReferenceTag(t *testing.T) {
+	const dgst = "sha256:b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"
+	tests := []struct {
+		inputType inputType
+		reference string
+		expected  string
+	}{
+		{inputTypeRegistry, "localhost:5000/net-monitor:v1", "v1"},
+		{inputTypeRegistry, "localhost:5000/net-monitor@" + dgst, ""},
+		{inputTypeRegistry, "localhost:5000/net-monitor", ""},
+		{inputTypeOCILayout, "hello-world:v1", "v1"},
+		{inputTypeOCILayout, "hello-world@" + dgst, ""},
+	}
+	for _, tt := range tests {
+		if got := referenceTag(tt.inputType, tt.reference); got != tt.expected {
+			t.Errorf("referenceTag(%q) = %q, expected %q", tt.reference, got, tt.expected)
+		}
+	}
+}
+
+func TestResolvePlatform(t *testing.T) {
+	ctx := context.Background()
+	layoutPath := t.TempDir()
+	store, err := oci.New(layoutPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var manifests []ocispec.Descriptor
+	for _, p := range []ocispec.Platform{{OS: "linux", Architecture: "amd64"}, {OS: "linux", Architecture: "arm64"}} {
+		manifestJSON := []byte(`{"schemaVersion":2,"mediaType":"application/vnd.oci.image.manifest.v1+json","config":{"mediaType":"application/vnd.oci.empty.v1+json","digest":"sha256:44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a","size":2},"layers":[],"annotations":{"platform":"` + p.Architecture + `"}}`)
+		desc := content.NewDescriptorFromBytes(ocispec.MediaTypeImageManifest, manifestJSON)
+		if err := store.Push(ctx, desc, bytes.NewReader(manifestJSON)); err != nil {
+			t.Fatal(err)
+		}
+		platform := p
+		desc.Platform = &platform
+		manifests = append(manifests, desc)
+	}
+	indexJSON, err := json.Marshal(ocispec.Index{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: ocispec.MediaTypeImageIndex,
+		Manifests: manifests,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	indexDesc := content.NewDescriptorFromBytes(ocispec.MediaTypeImageIndex, indexJSON)
+	if err := store.Push(ctx, indexDesc, bytes.NewReader(indexJSON)); err != nil {
+		t.Fatal(err)
+	}
+
+	reference := layoutPath + ":v1"
+	desc, resolvedRef, err := resolvePlatform(ctx, inputTypeOCILayout, reference, &SecureFlagOpts{}, indexDesc, layoutPath+"@"+indexDesc.Digest.String(), "linux/arm64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if desc.Digest != manifests[1].Digest {
+		t.Fatalf("resolvePlatform() = %v, want %v", desc.Digest, manifests[1].Digest)
+	}
+	if want := layoutPath + "@" + manifests[1].Digest.String(); resolvedRef != want {
+		t.Fatalf("resolvePlatform() resolved reference = %q, want %q", resolvedRef, want)
+	}
+	if _, _, err := resolvePlatform(ctx, inputTypeOCILayout, reference, &SecureFlagOpts{}, indexDesc, layoutPath+"@"+indexDesc.Digest.String(), "linux/s390x"); err == nil {
+		t.Fatal("expected error for a platform not in the image index")
+	}
+}
+
+func TestFetchManifestAnnotations(t *testing.T) {
+	ctx := context.Background()
+	layoutPath := t.TempDir()
+	store, err := oci.New(layoutPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifestJSON := []byte(`{"schemaVersion":2,"mediaType":"application/vnd.oci.image.manifest.v1+json","config":{"mediaType":"application/vnd.oci.empty.v1+json","digest":"sha256:44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a","size":2},"layers":[],"annotations":{"org.opencontainers.image.revision":"3f8a2c1"}}`)
+	desc := content.NewDescriptorFromBytes(ocispec.MediaTypeImageManifest, manifestJSON)
+	if err := store.Push(ctx, desc, bytes.NewReader(manifestJSON)); err != nil {
+		t.Fatal(err)
+	}
+	annotations, err := fetchManifestAnnotations(ctx, inputTypeOCILayout, layoutPath+"@"+desc.Digest.String(), &SecureFlagOpts{}, desc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(annotations) != 1 || annotations["org.opencontainers.image.revision"] != "3f8a2c1" {
+		t.Fatalf("fetchManifestAnnotations() = %v", annotations)
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/notaryproject/notation/internal/ocilayout"
+)
+
+// ociLayoutTarballs maps the paths of the OCI layout tarballs and the docker
+// save archives opened by openOCILayoutTarball to the directories they are
+// extracted to.
+var ociLayoutTarballs = map[string]string{}
+
+// openOCILayoutTarball extracts the OCI layout of the user input reference
+// in format of <path>[:<tag>|@<digest>] to a temporary directory if the path
+// is a tarball, compressed or not, so that the layout is accessed as a
+// directory by ociLayoutDir while the reference keeps the path of the
+// tarball. The returned function removes the directory, writing the tarball
+// back first if save is set, e.g. after signing. It does nothing if the path
+// is a directory.
+//
+// If dockerArchive is set, a docker save archive created by Docker before 25
+// without an OCI layout is converted to the format of Docker 25 and later,
+// which is an OCI layout, and written back in that format on save.
+func openOCILayoutTarball(reference string, dockerArchive bool) (func(save bool) error, error) {
+	layoutPath, _, err := parseOCILayoutReference(reference)
+	if err != nil {
+		return nil, err
+	}
+	isTarball, compression, err := ocilayout.DetectTarball(layoutPath)
+	if dockerArchive && err == nil && !isTarball {
+		return nil, fmt.Errorf("%s is not a docker save archive", layoutPath)
+	}
+	if err != nil || !isTarball {
+		// errors are reported when the layout is accessed
+		return func(bool) error { return nil }, nil
+	}
+	if _, ok := ociLayoutTarballs[layoutPath]; ok {
+		return nil, fmt.Errorf("OCI layout tarball %s is already opened", layoutPath)
+	}
+	dir, err := os.MkdirTemp("", "notation-oci-layout-")
+	if err != nil {
+		return nil, err
+	}
+	if err := ocilayout.Extract(layoutPath, compression, dir); err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("failed to extract OCI layout tarball %s: %w", layoutPath, err)
+	}
+	if err := convertDockerArchive(layoutPath, dir, dockerArchive); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	ociLayoutTarballs[layoutPath] = dir
+	return func(save bool) error {
+		defer func() {
+			delete(ociLayoutTarballs, layoutPath)
+			os.RemoveAll(dir)
+		}()
+		if !save {
+			return nil
+		}
+		if err := ocilayout.Pack(dir, layoutPath, compression); err != nil {
+			return fmt.Errorf("failed to write OCI layout tarball %s: %w", layoutPath, err)
+		}
+		return nil
+	}, nil
+}
+
+// ociLayoutDir returns the directory of the OCI layout at layoutPath, which
+// is the directory the tarball is extracted to if opened by
+// openOCILayoutTarball.
+func ociLayoutDir(layoutPath string) string {
+	if dir, ok := ociLayoutTarballs[layoutPath]; ok {
+		return dir
+	}
+	return layoutPath
+}
+
+// convertDockerArchive converts the docker save archive at archivePath
+// extracted to dir to an OCI layout if it has none and dockerArchive is set.
+func convertDockerArchive(archivePath, dir string, dockerArchive bool) error {
+	isDockerArchive, err := ocilayout.IsDockerArchive(dir)
+	if err != nil || !isDockerArchive {
+		return err
+	}
+	if !dockerArchive {
+		return fmt.Errorf("%s is a docker save archive without an OCI layout, use flag \"--docker-archive\" of \"notation sign\" or \"notation verify\" to convert it", archivePath)
+	}
+	if err := ocilayout.ConvertDockerArchive(dir); err != nil {
+		return fmt.Errorf("failed to convert docker save archive %s: %w", archivePath, err)
+	}
+	return nil
+}
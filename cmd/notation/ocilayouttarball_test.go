@@ -0,0 +1,61 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/notaryproject/notation/internal/ocilayout"
+)
+
+func TestOpenOCILayoutTarball(t *testing.T) {
+	layoutPath := t.TempDir()
+	if err := os.WriteFile(filepath.Join(layoutPath, "index.json"), []byte(`{"schemaVersion":2,"manifests":[]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tarball := filepath.Join(t.TempDir(), "hello-world.tar.gz")
+	if err := ocilayout.Pack(layoutPath, tarball, ocilayout.CompressionGzip); err != nil {
+		t.Fatal(err)
+	}
+
+	// directories are accessed as they are
+	closeLayout, err := openOCILayoutTarball(layoutPath+":v1", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dir := ociLayoutDir(layoutPath); dir != layoutPath {
+		t.Fatalf("ociLayoutDir() = %q, want %q", dir, layoutPath)
+	}
+	if err := closeLayout(true); err != nil {
+		t.Fatal(err)
+	}
+
+	// tarballs are extracted and written back on save
+	closeLayout, err = openOCILayoutTarball(tarball+":v1", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := ociLayoutDir(tarball)
+	if dir == tarball {
+		t.Fatal("expected the tarball to be extracted")
+	}
+	if err := os.WriteFile(filepath.Join(dir, "oci-layout"), []byte(`{"imageLayoutVersion":"1.0.0"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := closeLayout(true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Fatalf("expected the extracted directory to be removed, got %v", err)
+	}
+	if got := ociLayoutDir(tarball); got != tarball {
+		t.Fatalf("ociLayoutDir() after close = %q, want %q", got, tarball)
+	}
+	extracted := t.TempDir()
+	if err := ocilayout.Extract(tarball, ocilayout.CompressionGzip, extracted); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(extracted, "oci-layout")); err != nil {
+		t.Fatalf("expected the change to be written back: %v", err)
+	}
+}
@@ -1,27 +1,61 @@
 package main
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"os"
+	"path"
 	"text/tabwriter"
 
 	"github.com/notaryproject/notation-go/dir"
 	"github.com/notaryproject/notation-go/plugin"
 	"github.com/notaryproject/notation-go/plugin/proto"
+	"github.com/notaryproject/notation/internal/cmd"
+	"github.com/notaryproject/notation/internal/ioutil"
+	"github.com/notaryproject/notation/internal/pluginproto"
 	"github.com/spf13/cobra"
 )
 
+type pluginListOpts struct {
+	outputFormat string
+}
+
+// pluginListOutput is the JSON output of the plugin list command.
+type pluginListOutput struct {
+	Plugins []pluginOutput `json:"plugins"`
+}
+
+// pluginOutput describes an installed plugin.
+type pluginOutput struct {
+	Name                      string             `json:"name"`
+	Path                      string             `json:"path"`
+	SHA256                    string             `json:"sha256,omitempty"`
+	Description               string             `json:"description,omitempty"`
+	Version                   string             `json:"version,omitempty"`
+	URL                       string             `json:"url,omitempty"`
+	SupportedContractVersions []string           `json:"supportedContractVersions,omitempty"`
+	Capabilities              []proto.Capability `json:"capabilities,omitempty"`
+	RespondsToMetadata        bool               `json:"respondsToMetadata"`
+	Error                     string             `json:"error,omitempty"`
+}
+
 func pluginCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "plugin",
 		Short: "Manage plugins",
 	}
-	cmd.AddCommand(pluginListCommand())
+	cmd.AddCommand(pluginListCommand(nil))
 	return cmd
 }
 
-func pluginListCommand() *cobra.Command {
-	return &cobra.Command{
+func pluginListCommand(opts *pluginListOpts) *cobra.Command {
+	if opts == nil {
+		opts = &pluginListOpts{}
+	}
+	command := &cobra.Command{
 		Use:     "list [flags]",
 		Aliases: []string{"ls"},
 		Short:   "List installed plugins",
@@ -29,20 +63,37 @@ func pluginListCommand() *cobra.Command {
 
 Example - List installed Notation plugins:
   notation plugin ls
+
+Example - List installed Notation plugins with binary paths and SHA-256 digests as json:
+  notation plugin ls --output json
 `,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return listPlugins(cmd)
+			return listPlugins(cmd, opts)
 		},
 	}
+	cmd.SetPflagOutput(command.Flags(), &opts.outputFormat, cmd.PflagOutputUsage)
+	return command
 }
 
-func listPlugins(command *cobra.Command) error {
-	mgr := plugin.NewCLIManager(dir.PluginFS())
+func listPlugins(command *cobra.Command, opts *pluginListOpts) error {
+	if opts.outputFormat != cmd.OutputJSON && opts.outputFormat != cmd.OutputPlaintext {
+		return fmt.Errorf("unrecognized output format %s", opts.outputFormat)
+	}
+
+	mgr := pluginproto.NewCLIManager(dir.PluginFS())
 	pluginNames, err := mgr.List(command.Context())
 	if err != nil {
 		return err
 	}
 
+	if opts.outputFormat == cmd.OutputJSON {
+		output := pluginListOutput{Plugins: []pluginOutput{}}
+		for _, n := range pluginNames {
+			output.Plugins = append(output.Plugins, describePlugin(command.Context(), mgr, n))
+		}
+		return ioutil.PrintObjectAsJSON(output)
+	}
+
 	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
 	fmt.Fprintln(tw, "NAME\tDESCRIPTION\tVERSION\tCAPABILITIES\tERROR\t")
 
@@ -62,3 +113,56 @@ func listPlugins(command *cobra.Command) error {
 	}
 	return tw.Flush()
 }
+
+// describePlugin returns the binary path, the SHA-256 digest of the binary
+// and the metadata of the plugin. Errors are recorded in the output, so that
+// broken plugins are listed as well.
+func describePlugin(ctx context.Context, mgr plugin.Manager, name string) pluginOutput {
+	output := pluginOutput{Name: name}
+	binPath, err := dir.PluginFS().SysPath(path.Join(name, pluginBinaryName(name)))
+	if err != nil {
+		output.Error = err.Error()
+		return output
+	}
+	output.Path = binPath
+	if output.SHA256, err = sha256File(binPath); err != nil {
+		output.Error = err.Error()
+		return output
+	}
+	pl, err := mgr.Get(ctx, name)
+	if err != nil {
+		output.Error = err.Error()
+		return output
+	}
+	metadata, err := pl.GetMetadata(ctx, &proto.GetMetadataRequest{})
+	if err != nil {
+		output.Error = err.Error()
+		return output
+	}
+	output.RespondsToMetadata = true
+	output.Description = metadata.Description
+	output.Version = metadata.Version
+	output.URL = metadata.URL
+	output.SupportedContractVersions = metadata.SupportedContractVersions
+	output.Capabilities = metadata.Capabilities
+	return output
+}
+
+// pluginBinaryName returns the file name of the executable of the plugin.
+func pluginBinaryName(name string) string {
+	return pluginproto.BinaryName(name)
+}
+
+// sha256File returns the hex encoded SHA-256 digest of the file at path.
+func sha256File(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
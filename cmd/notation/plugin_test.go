@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/notaryproject/notation-go/dir"
+	"github.com/notaryproject/notation-go/plugin"
+)
+
+func TestPluginListCommand_Output(t *testing.T) {
+	opts := &pluginListOpts{}
+	command := pluginListCommand(opts)
+	if err := command.ParseFlags([]string{"--output", "json"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if opts.outputFormat != "json" {
+		t.Fatalf("Expect output format json, got: %s", opts.outputFormat)
+	}
+}
+
+func TestDescribePlugin(t *testing.T) {
+	userLibexecDir := dir.UserLibexecDir
+	dir.UserLibexecDir = t.TempDir()
+	defer func() {
+		dir.UserLibexecDir = userLibexecDir
+	}()
+	pluginDir := filepath.Join(dir.UserLibexecDir, dir.PathPlugins, "foo")
+	if err := os.MkdirAll(pluginDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	binPath := filepath.Join(pluginDir, pluginBinaryName("foo"))
+	if err := os.WriteFile(binPath, []byte("not an executable"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	output := describePlugin(context.Background(), plugin.NewCLIManager(dir.PluginFS()), "foo")
+	if output.Path != binPath {
+		t.Fatalf("expected path %s, got %s", binPath, output.Path)
+	}
+	// sha256 of "not an executable"
+	if want := "110a2dfc6452c9ca98122f9eb2ff9db94b51b127f537432baf82f2744fbb90d6"; output.SHA256 != want {
+		t.Fatalf("expected SHA-256 digest %s, got %s", want, output.SHA256)
+	}
+	if output.RespondsToMetadata || output.Error == "" {
+		t.Fatalf("expected the plugin not to respond to metadata calls, got %+v", output)
+	}
+}
@@ -0,0 +1,121 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/notaryproject/notation-go/dir"
+	"github.com/notaryproject/notation/cmd/notation/internal/cmdutil"
+	"github.com/notaryproject/notation/internal/osutil"
+)
+
+const (
+	// policyBackupDir is the directory of the trust policy backups in the
+	// notation config directory.
+	policyBackupDir = "trustpolicy.backups"
+
+	// policyBackupTimeFormat is the format of the timestamps in the names of
+	// the backups, which sort in chronological order.
+	policyBackupTimeFormat = "20060102T150405.000000000Z"
+
+	// maxPolicyBackups is the number of backups kept, older backups are
+	// removed.
+	maxPolicyBackups = 10
+)
+
+// replacePolicy replaces the trust policy configuration with policyJSON. If a
+// trust policy configuration exists, the difference is shown and confirmed
+// unless confirmed is true, and the existing configuration is backed up if
+// backup is true. false is returned if the replacement is cancelled or
+// unnecessary.
+func replacePolicy(policyJSON []byte, confirmed, backup bool) (bool, error) {
+	policyPath, err := dir.ConfigFS().SysPath(dir.PathTrustPolicy)
+	if err != nil {
+		return false, fmt.Errorf("failed to obtain path of trust policy file: %w", err)
+	}
+	existingJSON, err := os.ReadFile(policyPath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return false, fmt.Errorf("failed to read the existing trust policy file: %w", err)
+	}
+	if err == nil {
+		diff, err := diffPolicies(existingJSON, policyJSON)
+		switch {
+		case err != nil:
+			fmt.Fprintf(os.Stderr, "Unable to compare with the existing trust policy configuration: %v\n", err)
+		case diff.Empty():
+			fmt.Println("The trust policy configuration is unchanged.")
+			return false, nil
+		default:
+			fmt.Println("Changes to the existing trust policy configuration:")
+			diff.Print(os.Stdout)
+		}
+		confirmed, err := cmdutil.AskForConfirmation(os.Stdin, "Do you want to overwrite the existing trust policy configuration?", confirmed)
+		if err != nil || !confirmed {
+			return false, err
+		}
+		if backup {
+			backupPath, err := backupPolicy(existingJSON, time.Now())
+			if err != nil {
+				return false, fmt.Errorf("failed to back up the existing trust policy file: %w", err)
+			}
+			fmt.Fprintf(os.Stderr, "Existing trust policy configuration backed up to %s\n", backupPath)
+		}
+	}
+	if err := osutil.WriteFile(policyPath, policyJSON); err != nil {
+		return false, fmt.Errorf("failed to write trust policy file: %w", err)
+	}
+	return true, nil
+}
+
+// backupPolicy writes policyJSON to a new backup named by the time t, and
+// removes the oldest backups beyond maxPolicyBackups.
+func backupPolicy(policyJSON []byte, t time.Time) (string, error) {
+	backupPath, err := dir.ConfigFS().SysPath(policyBackupDir, "trustpolicy."+t.UTC().Format(policyBackupTimeFormat)+".json")
+	if err != nil {
+		return "", err
+	}
+	if err := osutil.WriteFileWithPermission(backupPath, policyJSON, 0600, false); err != nil {
+		return "", err
+	}
+	backups, err := listPolicyBackups()
+	if err != nil {
+		return "", err
+	}
+	for len(backups) > maxPolicyBackups {
+		if err := os.Remove(backups[0]); err != nil {
+			return "", err
+		}
+		backups = backups[1:]
+	}
+	return backupPath, nil
+}
+
+// listPolicyBackups returns the paths of the trust policy backups from the
+// oldest to the latest.
+func listPolicyBackups() ([]string, error) {
+	backupDir, err := dir.ConfigFS().SysPath(policyBackupDir)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(backupDir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var backups []string
+	for _, entry := range entries {
+		if entry.Type().IsRegular() && strings.HasPrefix(entry.Name(), "trustpolicy.") && strings.HasSuffix(entry.Name(), ".json") {
+			backups = append(backups, filepath.Join(backupDir, entry.Name()))
+		}
+	}
+	sort.Strings(backups)
+	return backups, nil
+}
@@ -0,0 +1,85 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/notation-go/dir"
+)
+
+func TestReplacePolicy(t *testing.T) {
+	defer func(oldDir string) {
+		dir.UserConfigDir = oldDir
+	}(dir.UserConfigDir)
+	dir.UserConfigDir = t.TempDir()
+	policyPath := filepath.Join(dir.UserConfigDir, dir.PathTrustPolicy)
+
+	first := []byte(`{"version":"1.0","trustPolicies":[{"name":"first"}]}`)
+	second := []byte(`{"version":"1.0","trustPolicies":[{"name":"second"}]}`)
+	third := []byte(`{"version":"1.0","trustPolicies":[{"name":"third"}]}`)
+	for _, policyJSON := range [][]byte{first, second, third} {
+		if replaced, err := replacePolicy(policyJSON, true, true); err != nil || !replaced {
+			t.Fatalf("replacePolicy() = %v, %v", replaced, err)
+		}
+	}
+	if replaced, err := replacePolicy(third, true, true); err != nil || replaced {
+		t.Fatalf("expected unchanged policy not to be replaced, got %v, %v", replaced, err)
+	}
+
+	backups, err := listPolicyBackups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 2 {
+		t.Fatalf("expected 2 backups, got %v", backups)
+	}
+	for i, want := range [][]byte{first, second} {
+		got, err := os.ReadFile(backups[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != string(want) {
+			t.Fatalf("backup %d = %s, want %s", i, got, want)
+		}
+	}
+
+	// rollbacks walk back through the backups
+	for _, want := range [][]byte{second, first} {
+		if err := runRollback(nil, rollbackOpts{confirmed: true}); err != nil {
+			t.Fatal(err)
+		}
+		got, err := os.ReadFile(policyPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != string(want) {
+			t.Fatalf("rolled back policy = %s, want %s", got, want)
+		}
+	}
+	if err := runRollback(nil, rollbackOpts{confirmed: true}); err == nil {
+		t.Fatal("expected error without backups")
+	}
+}
+
+func TestBackupPolicy_Prune(t *testing.T) {
+	defer func(oldDir string) {
+		dir.UserConfigDir = oldDir
+	}(dir.UserConfigDir)
+	dir.UserConfigDir = t.TempDir()
+
+	start := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < maxPolicyBackups+2; i++ {
+		if _, err := backupPolicy([]byte("{}"), start.Add(time.Duration(i)*time.Second)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	backups, err := listPolicyBackups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != maxPolicyBackups || filepath.Base(backups[0]) != "trustpolicy.20240101T000002.000000000Z.json" {
+		t.Fatalf("unexpected backups after pruning: %v", backups)
+	}
+}
@@ -12,6 +12,10 @@ func Cmd() *cobra.Command {
 	command.AddCommand(
 		showCmd(),
 		importCmd(),
+		exportCmd(),
+		rollbackCmd(),
+		validateCmd(),
+		initCmd(),
 	)
 
 	return command
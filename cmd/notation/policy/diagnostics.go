@@ -0,0 +1,28 @@
+package policy
+
+import (
+	"fmt"
+	"io"
+
+	policyext "github.com/notaryproject/notation/internal/policy"
+)
+
+// printDiagnostics prints the problems found in a trust policy document, one
+// per line.
+func printDiagnostics(w io.Writer, diagnostics []policyext.Diagnostic) {
+	fmt.Fprintln(w, "Problems found in the trust policy configuration:")
+	for _, diagnostic := range diagnostics {
+		fmt.Fprintf(w, "  - %s\n", diagnostic)
+	}
+}
+
+// diagnosticsError returns an error with the message msg for the problems
+// found in a trust policy document. A single problem is included in the
+// error, while multiple problems are printed to w.
+func diagnosticsError(w io.Writer, msg string, diagnostics []policyext.Diagnostic) error {
+	if len(diagnostics) == 1 {
+		return fmt.Errorf("%s: %s", msg, diagnostics[0])
+	}
+	printDiagnostics(w, diagnostics)
+	return fmt.Errorf("%s: %d problems found", msg, len(diagnostics))
+}
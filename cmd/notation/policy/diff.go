@@ -0,0 +1,161 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// policyDiff is the structured difference of two trust policy documents,
+// compared statement by statement.
+type policyDiff struct {
+	// Version is the change of the version, empty if unchanged.
+	Version [2]string
+
+	// Added are the names of the added statements.
+	Added []string
+
+	// Removed are the names of the removed statements.
+	Removed []string
+
+	// Changed are the changed fields of the statements changed in place,
+	// indexed by statement name.
+	Changed map[string][]fieldChange
+}
+
+// fieldChange is the change of a field of a trust policy statement. The values
+// are compact JSON, empty if the field is absent.
+type fieldChange struct {
+	Field string
+	Old   string
+	New   string
+}
+
+// rawPolicy is a trust policy document with the fields of the statements kept
+// as JSON, so that extension fields are compared as well.
+type rawPolicy struct {
+	Version       string                       `json:"version"`
+	TrustPolicies []map[string]json.RawMessage `json:"trustPolicies"`
+}
+
+// diffPolicies compares the trust policy documents oldJSON and newJSON.
+func diffPolicies(oldJSON, newJSON []byte) (*policyDiff, error) {
+	var oldDoc, newDoc rawPolicy
+	if err := json.Unmarshal(oldJSON, &oldDoc); err != nil {
+		return nil, fmt.Errorf("failed to parse the existing trust policy configuration: %w", err)
+	}
+	if err := json.Unmarshal(newJSON, &newDoc); err != nil {
+		return nil, fmt.Errorf("failed to parse the new trust policy configuration: %w", err)
+	}
+	diff := &policyDiff{Changed: make(map[string][]fieldChange)}
+	if oldDoc.Version != newDoc.Version {
+		diff.Version = [2]string{oldDoc.Version, newDoc.Version}
+	}
+	oldStatements := indexStatements(oldDoc.TrustPolicies)
+	newStatements := indexStatements(newDoc.TrustPolicies)
+	for name, oldStatement := range oldStatements {
+		newStatement, ok := newStatements[name]
+		if !ok {
+			diff.Removed = append(diff.Removed, name)
+			continue
+		}
+		if changes := diffStatements(oldStatement, newStatement); len(changes) > 0 {
+			diff.Changed[name] = changes
+		}
+	}
+	for name := range newStatements {
+		if _, ok := oldStatements[name]; !ok {
+			diff.Added = append(diff.Added, name)
+		}
+	}
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	return diff, nil
+}
+
+// indexStatements indexes the statements by name.
+func indexStatements(statements []map[string]json.RawMessage) map[string]map[string]json.RawMessage {
+	index := make(map[string]map[string]json.RawMessage, len(statements))
+	for _, statement := range statements {
+		var name string
+		json.Unmarshal(statement["name"], &name)
+		index[name] = statement
+	}
+	return index
+}
+
+// diffStatements returns the changed fields of a statement, sorted by field.
+func diffStatements(oldStatement, newStatement map[string]json.RawMessage) []fieldChange {
+	fields := make(map[string]bool)
+	for field := range oldStatement {
+		fields[field] = true
+	}
+	for field := range newStatement {
+		fields[field] = true
+	}
+	var changes []fieldChange
+	for field := range fields {
+		oldValue, newValue := compactJSON(oldStatement[field]), compactJSON(newStatement[field])
+		if oldValue != newValue {
+			changes = append(changes, fieldChange{Field: field, Old: oldValue, New: newValue})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Field < changes[j].Field
+	})
+	return changes
+}
+
+// compactJSON returns the canonical compact form of a JSON value, with object
+// keys sorted, or an empty string if the value is absent.
+func compactJSON(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	var value any
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return string(raw)
+	}
+	canonical, err := json.Marshal(value)
+	if err != nil {
+		return string(raw)
+	}
+	return string(canonical)
+}
+
+// Empty returns true if the documents are equivalent.
+func (d *policyDiff) Empty() bool {
+	return d.Version == [2]string{} && len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// Print writes the difference to w.
+func (d *policyDiff) Print(w io.Writer) {
+	if d.Version != [2]string{} {
+		fmt.Fprintf(w, "  version: %q -> %q\n", d.Version[0], d.Version[1])
+	}
+	for _, name := range d.Removed {
+		fmt.Fprintf(w, "- statement %q\n", name)
+	}
+	for _, name := range d.Added {
+		fmt.Fprintf(w, "+ statement %q\n", name)
+	}
+	names := make([]string, 0, len(d.Changed))
+	for name := range d.Changed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "~ statement %q\n", name)
+		for _, change := range d.Changed[name] {
+			switch {
+			case change.Old == "":
+				fmt.Fprintf(w, "    + %s: %s\n", change.Field, change.New)
+			case change.New == "":
+				fmt.Fprintf(w, "    - %s: %s\n", change.Field, change.Old)
+			default:
+				fmt.Fprintf(w, "    ~ %s: %s -> %s\n", change.Field, change.Old, change.New)
+			}
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package policy
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestDiffPolicies(t *testing.T) {
+	oldJSON := `{"version":"1.0","trustPolicies":[
+		{"name":"keep","registryScopes":["*"],"signatureVerification":{"level":"strict"},"trustStores":["ca:a"],"trustedIdentities":["*"]},
+		{"name":"change","registryScopes":["r/a"],"signatureVerification":{"level":"strict"},"trustStores":["ca:a"],"trustedIdentities":["*"]},
+		{"name":"remove","registryScopes":["r/b"],"signatureVerification":{"level":"skip"}}]}`
+	newJSON := `{"version":"1.0","trustPolicies":[
+		{"trustedIdentities":["*"],"trustStores":["ca:a"],"signatureVerification":{"level":"strict"},"registryScopes":["*"],"name":"keep"},
+		{"name":"change","registryScopes":["r/a"],"signatureVerification":{"level":"audit"},"trustStores":["ca:a"],"trustedIdentities":["*"],"artifactTypes":["x"]},
+		{"name":"add","registryScopes":["r/c"],"signatureVerification":{"level":"skip"}}]}`
+
+	diff, err := diffPolicies([]byte(oldJSON), []byte(newJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff.Empty() || len(diff.Added) != 1 || diff.Added[0] != "add" || len(diff.Removed) != 1 || diff.Removed[0] != "remove" {
+		t.Fatalf("unexpected diff: %+v", diff)
+	}
+	changes := diff.Changed["change"]
+	if len(diff.Changed) != 1 || len(changes) != 2 || changes[0].Field != "artifactTypes" || changes[0].Old != "" || changes[1].Field != "signatureVerification" {
+		t.Fatalf("unexpected changes: %+v", diff.Changed)
+	}
+
+	var out bytes.Buffer
+	diff.Print(&out)
+	for _, want := range []string{`- statement "remove"`, `+ statement "add"`, `~ statement "change"`, `+ artifactTypes: ["x"]`, `~ signatureVerification: {"level":"strict"} -> {"level":"audit"}`} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("expected diff output to contain %q, got:\n%s", want, out.String())
+		}
+	}
+
+	if diff, err := diffPolicies([]byte(oldJSON), []byte(oldJSON)); err != nil || !diff.Empty() {
+		t.Fatalf("expected empty diff, got %+v, %v", diff, err)
+	}
+	if _, err := diffPolicies([]byte("{"), []byte(newJSON)); err == nil {
+		t.Fatal("expected error for invalid existing policy")
+	}
+}
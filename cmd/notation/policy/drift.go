@@ -0,0 +1,50 @@
+package policy
+
+import "sort"
+
+// StatementDrift is a difference of a trust policy statement of a host from
+// the statement of the same name of a canonical trust policy.
+type StatementDrift struct {
+	// Name is the name of the statement.
+	Name string `json:"name"`
+
+	// Status is "missing" if the statement is missing on the host, "modified"
+	// if its fields differ, or "unexpected" if the host has a statement not
+	// in the canonical trust policy.
+	Status string `json:"status"`
+
+	// Fields are the names of the fields differing, if modified.
+	Fields []string `json:"fields,omitempty"`
+}
+
+// StatementDrifts compares the trust policy document of a host with the
+// canonical document, statement by statement, and returns the drifts sorted
+// by name. A change of the version is reported as the modification of the
+// statement with an empty name.
+func StatementDrifts(canonicalJSON, hostJSON []byte) ([]StatementDrift, error) {
+	diff, err := diffPolicies(canonicalJSON, hostJSON)
+	if err != nil {
+		return nil, err
+	}
+	var drifts []StatementDrift
+	if diff.Version != [2]string{} {
+		drifts = append(drifts, StatementDrift{Status: "modified", Fields: []string{"version"}})
+	}
+	for _, name := range diff.Removed {
+		drifts = append(drifts, StatementDrift{Name: name, Status: "missing"})
+	}
+	for _, name := range diff.Added {
+		drifts = append(drifts, StatementDrift{Name: name, Status: "unexpected"})
+	}
+	for name, changes := range diff.Changed {
+		drift := StatementDrift{Name: name, Status: "modified"}
+		for _, change := range changes {
+			drift.Fields = append(drift.Fields, change.Field)
+		}
+		drifts = append(drifts, drift)
+	}
+	sort.SliceStable(drifts, func(i, j int) bool {
+		return drifts[i].Name < drifts[j].Name
+	})
+	return drifts, nil
+}
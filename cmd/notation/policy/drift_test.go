@@ -0,0 +1,34 @@
+package policy
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStatementDrifts(t *testing.T) {
+	canonicalJSON := `{"version":"1.0","trustPolicies":[
+		{"name":"keep","registryScopes":["*"],"signatureVerification":{"level":"strict"},"trustStores":["ca:a"],"trustedIdentities":["*"]},
+		{"name":"change","registryScopes":["r/a"],"signatureVerification":{"level":"strict"},"trustStores":["ca:a"],"trustedIdentities":["*"]},
+		{"name":"remove","registryScopes":["r/b"],"signatureVerification":{"level":"skip"}}]}`
+	hostJSON := `{"version":"1.0","trustPolicies":[
+		{"name":"keep","registryScopes":["*"],"signatureVerification":{"level":"strict"},"trustStores":["ca:a"],"trustedIdentities":["*"]},
+		{"name":"change","registryScopes":["r/a"],"signatureVerification":{"level":"audit"},"trustStores":["ca:a","ca:b"],"trustedIdentities":["*"]},
+		{"name":"add","registryScopes":["r/c"],"signatureVerification":{"level":"skip"}}]}`
+
+	drifts, err := StatementDrifts([]byte(canonicalJSON), []byte(hostJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []StatementDrift{
+		{Name: "add", Status: "unexpected"},
+		{Name: "change", Status: "modified", Fields: []string{"signatureVerification", "trustStores"}},
+		{Name: "remove", Status: "missing"},
+	}
+	if !reflect.DeepEqual(drifts, want) {
+		t.Fatalf("StatementDrifts() = %+v, want %+v", drifts, want)
+	}
+
+	if drifts, err := StatementDrifts([]byte(canonicalJSON), []byte(canonicalJSON)); err != nil || len(drifts) != 0 {
+		t.Fatalf("expected no drifts, got %+v, %v", drifts, err)
+	}
+}
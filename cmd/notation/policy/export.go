@@ -0,0 +1,70 @@
+package policy
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/notaryproject/notation-go/dir"
+	"github.com/notaryproject/notation/internal/osutil"
+	policyext "github.com/notaryproject/notation/internal/policy"
+	"github.com/spf13/cobra"
+)
+
+type exportOpts struct {
+	output string
+	force  bool
+}
+
+func exportCmd() *cobra.Command {
+	var opts exportOpts
+	command := &cobra.Command{
+		Use:   "export [flags]",
+		Short: "Export trust policy configuration to a JSON file",
+		Long: `Export trust policy configuration to a JSON file.
+
+** This command is in preview and under development. **
+
+Unlike "notation policy show", the trust policy configuration is only exported if it is valid, so that the exported file can be imported by "notation policy import". The problems of malformed statements are printed otherwise.
+
+Example - Export trust policy configuration to a file:
+  notation policy export --output my_policy.json
+
+Example - Export trust policy configuration to standard output:
+  notation policy export
+`,
+		Args: cobra.ExactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runExport(cmd, opts)
+		},
+	}
+	command.Flags().StringVarP(&opts.output, "output", "o", "", "path of the JSON file to export to, standard output if not set")
+	command.Flags().BoolVar(&opts.force, "force", false, "overwrite the existing file")
+	return command
+}
+
+func runExport(command *cobra.Command, opts exportOpts) error {
+	policyPath, err := dir.ConfigFS().SysPath(dir.PathTrustPolicy)
+	if err != nil {
+		return fmt.Errorf("failed to obtain path of trust policy configuration file: %w", err)
+	}
+	policyJSON, err := os.ReadFile(policyPath)
+	if err != nil {
+		return fmt.Errorf("failed to load trust policy configuration, you may import one via `notation policy import <path-to-policy.json>`: %w", err)
+	}
+	if diagnostics := policyext.Diagnose(policyJSON); len(diagnostics) > 0 {
+		return diagnosticsError(os.Stderr, "existing trust policy configuration is invalid", diagnostics)
+	}
+
+	if opts.output == "" {
+		_, err = os.Stdout.Write(policyJSON)
+		return err
+	}
+	if err := osutil.WriteFileWithPermission(opts.output, policyJSON, 0644, opts.force); err != nil {
+		if os.IsExist(err) {
+			return fmt.Errorf("file %s already exists, use flag \"--force\" to overwrite it", opts.output)
+		}
+		return fmt.Errorf("failed to export trust policy configuration: %w", err)
+	}
+	_, err = fmt.Fprintf(os.Stdout, "Trust policy configuration exported to %s.\n", opts.output)
+	return err
+}
@@ -0,0 +1,54 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/notaryproject/notation-go/dir"
+)
+
+func TestRunExport(t *testing.T) {
+	defer func(oldDir string) {
+		dir.UserConfigDir = oldDir
+	}(dir.UserConfigDir)
+	dir.UserConfigDir = t.TempDir()
+	policyPath := filepath.Join(dir.UserConfigDir, dir.PathTrustPolicy)
+	output := filepath.Join(t.TempDir(), "policy.json")
+
+	if err := runExport(nil, exportOpts{output: output}); err == nil {
+		t.Fatal("expected error without trust policy configuration")
+	}
+
+	invalid := []byte(`{"version":"1.0","trustPolicies":[{"name":"a","registryScopes":["*"],"signatureVerification":{"level":"lax"}}]}`)
+	if err := os.WriteFile(policyPath, invalid, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := runExport(nil, exportOpts{output: output}); err == nil {
+		t.Fatal("expected error for invalid trust policy configuration")
+	}
+	if _, err := os.Stat(output); !os.IsNotExist(err) {
+		t.Fatalf("expected invalid trust policy configuration not to be exported, got %v", err)
+	}
+
+	valid := []byte(`{"version":"1.0","trustPolicies":[{"name":"a","registryScopes":["*"],"signatureVerification":{"level":"skip"}}]}`)
+	if err := os.WriteFile(policyPath, valid, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := runExport(nil, exportOpts{output: output}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(valid) {
+		t.Fatalf("exported policy = %s, want %s", got, valid)
+	}
+	if err := runExport(nil, exportOpts{output: output}); err == nil {
+		t.Fatal("expected error for existing file without --force")
+	}
+	if err := runExport(nil, exportOpts{output: output, force: true}); err != nil {
+		t.Fatal(err)
+	}
+}
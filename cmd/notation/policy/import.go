@@ -5,16 +5,16 @@ import (
 	"fmt"
 	"os"
 
-	"github.com/notaryproject/notation-go/dir"
 	"github.com/notaryproject/notation-go/verifier/trustpolicy"
-	"github.com/notaryproject/notation/cmd/notation/internal/cmdutil"
-	"github.com/notaryproject/notation/internal/osutil"
+	policyext "github.com/notaryproject/notation/internal/policy"
+	"github.com/notaryproject/notation/internal/sanity"
 	"github.com/spf13/cobra"
 )
 
 type importOpts struct {
-	filePath string
-	force    bool
+	filePath  string
+	force     bool
+	confirmed bool
 }
 
 func importCmd() *cobra.Command {
@@ -26,8 +26,13 @@ func importCmd() *cobra.Command {
 
 ** This command is in preview and under development. **
 
+The changes to an existing trust policy configuration are shown and confirmed before it is overwritten. The existing configuration is backed up, and restored by "notation policy rollback".
+
 Example - Import trust policy configuration from a file:
   notation policy import my_policy.json
+
+Example - Import trust policy configuration from a file without prompt:
+  notation policy import --yes my_policy.json
 `,
 		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -36,25 +41,11 @@ Example - Import trust policy configuration from a file:
 		},
 	}
 	command.Flags().BoolVar(&opts.force, "force", false, "override the existing trust policy configuration, never prompt")
+	command.Flags().BoolVarP(&opts.confirmed, "yes", "y", false, "do not prompt for confirmation")
 	return command
 }
 
 func runImport(command *cobra.Command, opts importOpts) error {
-	// optional confirmation
-	if !opts.force {
-		if _, err := trustpolicy.LoadDocument(); err == nil {
-			confirmed, err := cmdutil.AskForConfirmation(os.Stdin, "Existing trust policy configuration found, do you want to overwrite it?", opts.force)
-			if err != nil {
-				return err
-			}
-			if !confirmed {
-				return nil
-			}
-		}
-	} else {
-		fmt.Fprintf(os.Stderr, "Warning: existing trust policy configuration file will be overwritten")
-	}
-
 	// read configuration
 	policyJSON, err := os.ReadFile(opts.filePath)
 	if err != nil {
@@ -66,17 +57,17 @@ func runImport(command *cobra.Command, opts importOpts) error {
 	if err = json.Unmarshal(policyJSON, &doc); err != nil {
 		return fmt.Errorf("failed to parse trust policy configuration: %w", err)
 	}
-	if err = doc.Validate(); err != nil {
-		return fmt.Errorf("failed to validate trust policy: %w", err)
+	if diagnostics := policyext.Diagnose(policyJSON); len(diagnostics) > 0 {
+		return diagnosticsError(os.Stderr, "failed to validate trust policy", diagnostics)
+	}
+	if findings, err := sanity.ScanJSON(policyJSON); err == nil {
+		sanity.PrintWarnings(os.Stderr, "trust policy", findings)
 	}
 
 	// write
-	policyPath, err := dir.ConfigFS().SysPath(dir.PathTrustPolicy)
-	if err != nil {
-		return fmt.Errorf("failed to obtain path of trust policy file: %w", err)
-	}
-	if err = osutil.WriteFile(policyPath, policyJSON); err != nil {
-		return fmt.Errorf("failed to write trust policy file: %w", err)
+	replaced, err := replacePolicy(policyJSON, opts.force || opts.confirmed, true)
+	if err != nil || !replaced {
+		return err
 	}
 	_, err = fmt.Fprintln(os.Stdout, "Trust policy configuration imported successfully.")
 	return err
@@ -0,0 +1,279 @@
+package policy
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/notaryproject/notation-go/dir"
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+	"github.com/notaryproject/notation-go/verifier/truststore"
+	policyext "github.com/notaryproject/notation/internal/policy"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+const (
+	defaultInitName              = "default"
+	defaultInitScope             = "*"
+	defaultInitTrustedIdentity   = "*"
+	defaultInitVerificationLevel = "strict"
+)
+
+type initOpts struct {
+	name              string
+	scopes            []string
+	level             string
+	trustStores       []string
+	trustedIdentities []string
+	confirmed         bool
+
+	// interactive is true if the values of the flags not set are prompted
+	// for, i.e. if the standard input is a terminal.
+	interactive bool
+}
+
+func initCmd() *cobra.Command {
+	var opts initOpts
+	command := &cobra.Command{
+		Use:   "init [flags]",
+		Short: "Create a trust policy configuration interactively or from flags",
+		Long: `Create a trust policy configuration with a single statement.
+
+** This command is in preview and under development. **
+
+The statement is built from the registry scopes, the verification level, the trust stores and the trusted identities. When the standard input is a terminal, the values of the flags not set are prompted for, with the existing trust stores listed. Otherwise, or with flag "--yes", the default values are used, and flag "--trust-store" is required unless the verification level is "skip". An existing trust policy configuration is only overwritten after confirmation, and is backed up as on import.
+
+Example - Create a trust policy configuration interactively:
+  notation policy init
+
+Example - Create a trust policy configuration verifying all the artifacts against the trust store "ca:acme-rockets":
+  notation policy init --trust-store ca:acme-rockets
+
+Example - Create a trust policy configuration for two repositories, trusting a single identity:
+  notation policy init --name acme-images --scope registry.acme-rockets.io/software/net-monitor --scope registry.acme-rockets.io/software/net-logger --trust-store ca:acme-rockets --trusted-identity "x509.subject: C=US, ST=WA, L=Seattle, O=acme-rockets.io, CN=SecureBuilder"
+`,
+		Args: cobra.ExactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.interactive = !opts.confirmed && term.IsTerminal(int(os.Stdin.Fd()))
+			return runInit(cmd, opts)
+		},
+	}
+	command.Flags().StringVar(&opts.name, "name", defaultInitName, "name of the trust policy statement")
+	command.Flags().StringArrayVar(&opts.scopes, "scope", []string{defaultInitScope}, "registry scope of the trust policy statement, i.e. a repository, or \"*\" for all the repositories, can be used multiple times")
+	command.Flags().StringVar(&opts.level, "level", defaultInitVerificationLevel, "signature verification level, options: \"strict\", \"permissive\", \"audit\", \"skip\"")
+	command.Flags().StringArrayVar(&opts.trustStores, "trust-store", nil, "trust store of the trust policy statement, in the format of {type}:{name}, e.g. \"ca:acme-rockets\", can be used multiple times")
+	command.Flags().StringArrayVar(&opts.trustedIdentities, "trusted-identity", []string{defaultInitTrustedIdentity}, "trusted identity, e.g. \"x509.subject: C=US, ST=WA, O=acme-rockets.io, CN=SecureBuilder\", or \"*\" for any identity of the trust stores, can be used multiple times")
+	command.Flags().BoolVarP(&opts.confirmed, "yes", "y", false, "do not prompt, use the default values of the flags not set and overwrite the existing trust policy configuration")
+	return command
+}
+
+func runInit(command *cobra.Command, opts initOpts) error {
+	policyPath, err := dir.ConfigFS().SysPath(dir.PathTrustPolicy)
+	if err != nil {
+		return fmt.Errorf("failed to obtain path of trust policy file: %w", err)
+	}
+	_, err = os.Stat(policyPath)
+	exists := err == nil
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to read the existing trust policy file: %w", err)
+	}
+
+	p := &prompter{
+		reader: bufio.NewReader(command.InOrStdin()),
+		writer: command.OutOrStdout(),
+	}
+	if exists && !opts.confirmed {
+		if !opts.interactive {
+			return errors.New("trust policy configuration already exists, use flag \"--yes\" to overwrite it, or \"notation policy import\" to update it")
+		}
+		confirmed, err := p.confirm("A trust policy configuration already exists, do you want to create a new one to overwrite it?")
+		if err != nil || !confirmed {
+			return err
+		}
+	}
+
+	// answer
+	flags := command.Flags()
+	if opts.interactive {
+		if err := p.ask(&opts.name, flags.Changed("name"), "Name of the trust policy statement"); err != nil {
+			return err
+		}
+		if err := p.askList(&opts.scopes, flags.Changed("scope"), ",", "Registry scopes, i.e. repositories separated by commas, or \"*\" for all the repositories"); err != nil {
+			return err
+		}
+		if err := p.ask(&opts.level, flags.Changed("level"), "Signature verification level (strict, permissive, audit, skip)"); err != nil {
+			return err
+		}
+	}
+	if opts.level != trustpolicy.LevelSkip.Name {
+		if opts.interactive {
+			stores, err := listTrustStores()
+			if err != nil {
+				return fmt.Errorf("failed to list the trust stores: %w", err)
+			}
+			prompt := "Trust stores, in the format of {type}:{name} separated by commas"
+			if len(stores) > 0 {
+				prompt = fmt.Sprintf("%s, existing trust stores: %s", prompt, strings.Join(stores, ", "))
+			}
+			if len(stores) == 1 && !flags.Changed("trust-store") {
+				opts.trustStores = stores
+			}
+			if err := p.askList(&opts.trustStores, flags.Changed("trust-store"), ",", prompt); err != nil {
+				return err
+			}
+			if err := p.askList(&opts.trustedIdentities, flags.Changed("trusted-identity"), ";", "Trusted identities separated by semicolons, e.g. \"x509.subject: C=US, ST=WA, O=acme-rockets.io, CN=SecureBuilder\", or \"*\" for any identity of the trust stores"); err != nil {
+				return err
+			}
+		}
+		if len(opts.trustStores) == 0 {
+			return fmt.Errorf("trust stores are required for verification level %q, use flag \"--trust-store\" to set them", opts.level)
+		}
+	}
+
+	// generate and validate
+	policyJSON, err := newInitPolicy(opts)
+	if err != nil {
+		return err
+	}
+	if diagnostics := policyext.Diagnose(policyJSON); len(diagnostics) > 0 {
+		return diagnosticsError(os.Stderr, "failed to create trust policy", diagnostics)
+	}
+	// the trust stores may be populated after the trust policy is created
+	for _, diagnostic := range policyext.Lint(command.Context(), policyJSON, dir.ConfigFS(), time.Now()) {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", diagnostic)
+	}
+
+	// write
+	replaced, err := replacePolicy(policyJSON, true, true)
+	if err != nil || !replaced {
+		return err
+	}
+	_, err = fmt.Fprintf(os.Stdout, "Trust policy configuration created successfully at %s\n", policyPath)
+	return err
+}
+
+// newInitPolicy returns the trust policy configuration with the statement
+// built from opts.
+func newInitPolicy(opts initOpts) ([]byte, error) {
+	statement := trustpolicy.TrustPolicy{
+		Name:           opts.name,
+		RegistryScopes: opts.scopes,
+		SignatureVerification: trustpolicy.SignatureVerification{
+			VerificationLevel: opts.level,
+		},
+	}
+	if opts.level != trustpolicy.LevelSkip.Name {
+		statement.TrustStores = opts.trustStores
+		statement.TrustedIdentities = opts.trustedIdentities
+	}
+	doc := trustpolicy.Document{
+		Version:       "1.0",
+		TrustPolicies: []trustpolicy.TrustPolicy{statement},
+	}
+	return json.MarshalIndent(doc, "", "    ")
+}
+
+// listTrustStores returns the existing x509 trust stores in the format of
+// trust policies, i.e. "{type}:{name}".
+func listTrustStores() ([]string, error) {
+	root, err := dir.ConfigFS().SysPath(dir.TrustStoreDir, "x509")
+	if err != nil {
+		return nil, err
+	}
+	var stores []string
+	for _, storeType := range truststore.Types {
+		entries, err := os.ReadDir(filepath.Join(root, string(storeType)))
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				stores = append(stores, string(storeType)+":"+entry.Name())
+			}
+		}
+	}
+	return stores, nil
+}
+
+// prompter prompts for the values of the flags not set.
+type prompter struct {
+	reader *bufio.Reader
+	writer io.Writer
+}
+
+// readLine prints the prompt and reads a line of the answer.
+func (p *prompter) readLine(prompt string) (string, error) {
+	fmt.Fprint(p.writer, prompt)
+	line, err := p.reader.ReadString('\n')
+	if errors.Is(err, io.EOF) && line == "" {
+		return "", errors.New("unexpected end of input while prompting")
+	}
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// ask prompts for the value unless set by its flag. An empty answer keeps the
+// default value.
+func (p *prompter) ask(value *string, set bool, prompt string) error {
+	if set {
+		return nil
+	}
+	answer, err := p.readLine(fmt.Sprintf("%s [%s]: ", prompt, *value))
+	if err != nil {
+		return err
+	}
+	if answer != "" {
+		*value = answer
+	}
+	return nil
+}
+
+// askList prompts for the values separated by sep unless set by their flag. An
+// empty answer keeps the default values.
+func (p *prompter) askList(values *[]string, set bool, sep string, prompt string) error {
+	if set {
+		return nil
+	}
+	answer, err := p.readLine(fmt.Sprintf("%s [%s]: ", prompt, strings.Join(*values, sep+" ")))
+	if err != nil {
+		return err
+	}
+	if answer == "" {
+		return nil
+	}
+	var result []string
+	for _, value := range strings.Split(answer, sep) {
+		if value = strings.TrimSpace(value); value != "" {
+			result = append(result, value)
+		}
+	}
+	*values = result
+	return nil
+}
+
+// confirm prompts for a confirmation.
+func (p *prompter) confirm(prompt string) (bool, error) {
+	answer, err := p.readLine(prompt + " [y/N] ")
+	if err != nil {
+		return false, err
+	}
+	switch strings.ToLower(answer) {
+	case "y", "yes":
+		return true, nil
+	}
+	fmt.Fprintln(p.writer, "Operation cancelled.")
+	return false, nil
+}
@@ -0,0 +1,143 @@
+package policy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/notaryproject/notation-go/dir"
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+)
+
+func readInitPolicy(t *testing.T) trustpolicy.TrustPolicy {
+	t.Helper()
+	policyJSON, err := os.ReadFile(filepath.Join(dir.UserConfigDir, dir.PathTrustPolicy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc trustpolicy.Document
+	if err := json.Unmarshal(policyJSON, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.TrustPolicies) != 1 {
+		t.Fatalf("got %d statements, want 1", len(doc.TrustPolicies))
+	}
+	return doc.TrustPolicies[0]
+}
+
+func TestRunInit_Flags(t *testing.T) {
+	defer func(oldDir string) {
+		dir.UserConfigDir = oldDir
+	}(dir.UserConfigDir)
+	dir.UserConfigDir = t.TempDir()
+
+	command := initCmd()
+	command.SetContext(context.Background())
+	if err := command.ParseFlags([]string{"--scope", "registry.acme-rockets.io/software/net-monitor"}); err != nil {
+		t.Fatal(err)
+	}
+	opts := initOpts{
+		name:              defaultInitName,
+		scopes:            []string{"registry.acme-rockets.io/software/net-monitor"},
+		level:             defaultInitVerificationLevel,
+		trustedIdentities: []string{defaultInitTrustedIdentity},
+	}
+	if err := runInit(command, opts); err == nil || !strings.Contains(err.Error(), "--trust-store") {
+		t.Fatalf("runInit() error = %v, want error requiring trust stores", err)
+	}
+
+	opts.trustStores = []string{"ca:acme-rockets"}
+	if err := runInit(command, opts); err != nil {
+		t.Fatal(err)
+	}
+	want := trustpolicy.TrustPolicy{
+		Name:                  defaultInitName,
+		RegistryScopes:        []string{"registry.acme-rockets.io/software/net-monitor"},
+		SignatureVerification: trustpolicy.SignatureVerification{VerificationLevel: "strict"},
+		TrustStores:           []string{"ca:acme-rockets"},
+		TrustedIdentities:     []string{"*"},
+	}
+	if got := readInitPolicy(t); !reflect.DeepEqual(got, want) {
+		t.Fatalf("statement = %+v, want %+v", got, want)
+	}
+
+	// existing configurations are only overwritten with confirmation
+	opts.level = "skip"
+	if err := runInit(command, opts); err == nil {
+		t.Fatal("expected error for existing trust policy configuration")
+	}
+	opts.confirmed = true
+	if err := runInit(command, opts); err != nil {
+		t.Fatal(err)
+	}
+	want = trustpolicy.TrustPolicy{
+		Name:                  defaultInitName,
+		RegistryScopes:        []string{"registry.acme-rockets.io/software/net-monitor"},
+		SignatureVerification: trustpolicy.SignatureVerification{VerificationLevel: "skip"},
+	}
+	if got := readInitPolicy(t); !reflect.DeepEqual(got, want) {
+		t.Fatalf("statement = %+v, want %+v", got, want)
+	}
+
+	// invalid answers are rejected
+	opts.level = "lax"
+	if err := runInit(command, opts); err == nil {
+		t.Fatal("expected error for invalid verification level")
+	}
+}
+
+func TestRunInit_Interactive(t *testing.T) {
+	defer func(oldDir string) {
+		dir.UserConfigDir = oldDir
+	}(dir.UserConfigDir)
+	dir.UserConfigDir = t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir.UserConfigDir, dir.TrustStoreDir, "x509", "ca", "acme-rockets"), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	command := initCmd()
+	command.SetContext(context.Background())
+	if err := command.ParseFlags([]string{"--level", "audit"}); err != nil {
+		t.Fatal(err)
+	}
+	var prompts bytes.Buffer
+	command.SetOut(&prompts)
+	command.SetIn(strings.NewReader("acme-images\nregistry.acme-rockets.io/software/net-monitor, registry.acme-rockets.io/software/net-logger\n\nx509.subject: C=US, ST=WA, O=acme-rockets.io, CN=SecureBuilder\n"))
+	opts := initOpts{
+		name:              defaultInitName,
+		scopes:            []string{defaultInitScope},
+		level:             "audit",
+		trustedIdentities: []string{defaultInitTrustedIdentity},
+		interactive:       true,
+	}
+	if err := runInit(command, opts); err != nil {
+		t.Fatal(err)
+	}
+	want := trustpolicy.TrustPolicy{
+		Name:                  "acme-images",
+		RegistryScopes:        []string{"registry.acme-rockets.io/software/net-monitor", "registry.acme-rockets.io/software/net-logger"},
+		SignatureVerification: trustpolicy.SignatureVerification{VerificationLevel: "audit"},
+		TrustStores:           []string{"ca:acme-rockets"},
+		TrustedIdentities:     []string{"x509.subject: C=US, ST=WA, O=acme-rockets.io, CN=SecureBuilder"},
+	}
+	if got := readInitPolicy(t); !reflect.DeepEqual(got, want) {
+		t.Fatalf("statement = %+v, want %+v", got, want)
+	}
+	if strings.Contains(prompts.String(), "verification level") {
+		t.Fatalf("prompted for the verification level set by its flag: %s", prompts.String())
+	}
+	if !strings.Contains(prompts.String(), "existing trust stores: ca:acme-rockets") {
+		t.Fatalf("existing trust stores are not listed: %s", prompts.String())
+	}
+
+	// the input ends before all the values are answered
+	command.SetIn(strings.NewReader("y\n"))
+	if err := runInit(command, opts); err == nil {
+		t.Fatal("expected error for unexpected end of input")
+	}
+}
@@ -0,0 +1,76 @@
+package policy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+	"github.com/notaryproject/notation/internal/color"
+	policyext "github.com/notaryproject/notation/internal/policy"
+	"github.com/spf13/cobra"
+)
+
+type rollbackOpts struct {
+	confirmed bool
+}
+
+func rollbackCmd() *cobra.Command {
+	var opts rollbackOpts
+	command := &cobra.Command{
+		Use:   "rollback [flags]",
+		Short: "Restore the trust policy configuration from the latest backup",
+		Long: `Restore the trust policy configuration from the latest backup.
+
+** This command is in preview and under development. **
+
+A backup is created whenever an existing trust policy configuration is overwritten. The latest backup is removed once restored, so that repeated rollbacks restore older backups. The trust policy configuration replaced by a rollback is not backed up.
+
+Example - Restore the trust policy configuration before the last import:
+  notation policy rollback
+`,
+		Args: cobra.ExactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRollback(cmd, opts)
+		},
+	}
+	command.Flags().BoolVarP(&opts.confirmed, "yes", "y", false, "do not prompt for confirmation")
+	return command
+}
+
+func runRollback(command *cobra.Command, opts rollbackOpts) error {
+	backups, err := listPolicyBackups()
+	if err != nil {
+		return fmt.Errorf("failed to list trust policy backups: %w", err)
+	}
+	if len(backups) == 0 {
+		return errors.New("no backup of the trust policy configuration found")
+	}
+	backupPath := backups[len(backups)-1]
+	policyJSON, err := os.ReadFile(backupPath)
+	if err != nil {
+		return fmt.Errorf("failed to read trust policy backup: %w", err)
+	}
+	var doc trustpolicy.Document
+	if err = json.Unmarshal(policyJSON, &doc); err == nil {
+		err = policyext.ValidateDocument(&doc, policyJSON)
+	}
+	if err != nil {
+		// the backup is restored as it was before being overwritten
+		fmt.Fprintf(os.Stderr, "%s trust policy backup %s is invalid: %v\n", color.Warning(os.Stderr, "Warning:"), filepath.Base(backupPath), err)
+	}
+
+	// the replaced configuration is not backed up, so that repeated rollbacks
+	// walk back through the backups
+	replaced, err := replacePolicy(policyJSON, opts.confirmed, false)
+	if err != nil || !replaced {
+		return err
+	}
+	if err := os.Remove(backupPath); err != nil {
+		return fmt.Errorf("failed to remove restored trust policy backup: %w", err)
+	}
+	_, err = fmt.Fprintf(os.Stdout, "Trust policy configuration restored from backup %s.\n", filepath.Base(backupPath))
+	return err
+}
@@ -1,12 +1,11 @@
 package policy
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
 
 	"github.com/notaryproject/notation-go/dir"
-	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+	policyext "github.com/notaryproject/notation/internal/policy"
 	"github.com/spf13/cobra"
 )
 
@@ -48,12 +47,8 @@ func runShow(command *cobra.Command, opts showOpts) error {
 	if err != nil {
 		return fmt.Errorf("failed to load trust policy configuration, you may import one via `notation policy import <path-to-policy.json>`: %w", err)
 	}
-	var doc trustpolicy.Document
-	if err = json.Unmarshal(policyJSON, &doc); err == nil {
-		err = doc.Validate()
-	}
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %s\n", err.Error())
+	if diagnostics := policyext.Diagnose(policyJSON); len(diagnostics) > 0 {
+		printDiagnostics(os.Stderr, diagnostics)
 		fmt.Fprintf(os.Stderr, "Existing trust policy configuration is invalid, you may update or create a new one via `notation policy import <path-to-policy.json>`\n")
 		// not returning to show the invalid policy configuration
 	}
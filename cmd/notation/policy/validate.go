@@ -0,0 +1,73 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/notaryproject/notation-go/dir"
+	policyext "github.com/notaryproject/notation/internal/policy"
+	"github.com/spf13/cobra"
+)
+
+type validateOpts struct {
+	filePath string
+}
+
+func validateCmd() *cobra.Command {
+	var opts validateOpts
+	command := &cobra.Command{
+		Use:   "validate [flags] [<file_path>]",
+		Short: "Validate trust policy configuration",
+		Long: `Validate trust policy configuration.
+
+** This command is in preview and under development. **
+
+Checks the trust policy configuration for schema errors, overlapping registry scopes, unreachable statements, trust stores which do not exist, trust stores without valid certificates, expired certificates, and unused custom verification levels. The trust stores are read from the notation config directory. Exits with a non-zero code if any problem is found.
+
+Example - Validate current trust policy configuration:
+  notation policy validate
+
+Example - Validate a trust policy configuration file before importing it:
+  notation policy validate my_policy.json
+`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 1 {
+				return errors.New("requires at most one file path")
+			}
+			if len(args) == 1 {
+				opts.filePath = args[0]
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runValidate(cmd, opts)
+		},
+	}
+	return command
+}
+
+func runValidate(command *cobra.Command, opts validateOpts) error {
+	policyPath := opts.filePath
+	if policyPath == "" {
+		var err error
+		policyPath, err = dir.ConfigFS().SysPath(dir.PathTrustPolicy)
+		if err != nil {
+			return fmt.Errorf("failed to obtain path of trust policy configuration file: %w", err)
+		}
+	}
+	policyJSON, err := os.ReadFile(policyPath)
+	if err != nil {
+		if opts.filePath == "" {
+			return fmt.Errorf("failed to load trust policy configuration, you may import one via `notation policy import <path-to-policy.json>`: %w", err)
+		}
+		return fmt.Errorf("failed to read trust policy configuration file: %w", err)
+	}
+
+	if diagnostics := policyext.Lint(command.Context(), policyJSON, dir.ConfigFS(), time.Now()); len(diagnostics) > 0 {
+		return diagnosticsError(os.Stderr, "trust policy configuration is invalid", diagnostics)
+	}
+	_, err = fmt.Fprintln(os.Stdout, "Trust policy configuration is valid.")
+	return err
+}
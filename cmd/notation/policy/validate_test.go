@@ -0,0 +1,43 @@
+package policy
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/notaryproject/notation-go/dir"
+	"github.com/spf13/cobra"
+)
+
+func TestRunValidate(t *testing.T) {
+	defer func(oldDir string) {
+		dir.UserConfigDir = oldDir
+	}(dir.UserConfigDir)
+	dir.UserConfigDir = t.TempDir()
+	command := &cobra.Command{}
+	command.SetContext(context.Background())
+
+	if err := runValidate(command, validateOpts{}); err == nil {
+		t.Fatal("expected error without trust policy configuration")
+	}
+
+	policyPath := filepath.Join(dir.UserConfigDir, dir.PathTrustPolicy)
+	valid := []byte(`{"version":"1.0","trustPolicies":[{"name":"a","registryScopes":["*"],"signatureVerification":{"level":"skip"}}]}`)
+	if err := os.WriteFile(policyPath, valid, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := runValidate(command, validateOpts{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// files are validated against the trust stores of the config directory
+	filePath := filepath.Join(t.TempDir(), "policy.json")
+	missingStore := []byte(`{"version":"1.0","trustPolicies":[{"name":"a","registryScopes":["*"],"signatureVerification":{"level":"strict"},"trustStores":["ca:acme"],"trustedIdentities":["*"]}]}`)
+	if err := os.WriteFile(filePath, missingStore, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := runValidate(command, validateOpts{filePath: filePath}); err == nil {
+		t.Fatal("expected error for missing trust store")
+	}
+}
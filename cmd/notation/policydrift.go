@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/notaryproject/notation-go/dir"
+	"github.com/notaryproject/notation/cmd/notation/policy"
+	"github.com/notaryproject/notation/internal/cmd"
+	"github.com/notaryproject/notation/internal/configbundle"
+	"github.com/notaryproject/notation/internal/experimental"
+	"github.com/notaryproject/notation/internal/ioutil"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/spf13/cobra"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/registry"
+)
+
+// maxPublishedBundleSize is the maximum size of the layer of a published
+// configuration bundle.
+const maxPublishedBundleSize = 64 * 1024 * 1024
+
+type policyDriftOpts struct {
+	cmd.LoggingFlagOpts
+	SecureFlagOpts
+	source       string
+	outputFormat string
+}
+
+// policyDriftOutput is the drift of the trust policy and the trust stores of
+// the host from a published configuration bundle.
+type policyDriftOutput struct {
+	Source string `json:"source"`
+	Digest string `json:"digest"`
+
+	// Files are the drifts of the trust policy and the trust store files.
+	Files []configbundle.Drift `json:"files"`
+
+	// Statements are the drifts of the trust policy statements, if the
+	// trust policy is modified.
+	Statements []policy.StatementDrift `json:"statements"`
+}
+
+func policyDriftCommand(opts *policyDriftOpts) *cobra.Command {
+	if opts == nil {
+		opts = &policyDriftOpts{}
+	}
+	command := &cobra.Command{
+		Use:   "drift --source <reference> [flags]",
+		Short: "[Experimental] Detect drift of the trust policy and the trust stores from a published configuration bundle",
+		Long: `[Experimental] Detect drift of the trust policy and the trust stores from a published configuration bundle
+
+The configuration bundle exported by "notation config export" is published to a registry as the layer of media type "` + configbundle.MediaType + `" of an OCI artifact, as the canonical configuration of a fleet of hosts. The trust policy and the trust stores of the host are compared with those of the bundle, and the command fails if they differ, reporting the missing, modified and unexpected trust store certificates and trust policy statements. The host is never modified, use "notation config import" to apply the bundle.
+
+Example - Publish the canonical configuration with oras:
+  notation config export bundle.tgz
+  oras push <registry>/<repository>:<tag> bundle.tgz:` + configbundle.MediaType + `
+
+Example - Detect drift from the canonical configuration:
+  notation policy drift --source <registry>/<repository>:<tag>
+
+Example - Detect drift and output the report as json:
+  notation policy drift --source <registry>/<repository>@<digest> --output json
+`,
+		Args: cobra.ExactArgs(0),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if opts.source == "" {
+				return errors.New("flag \"--source\" is required")
+			}
+			return experimental.CheckCommandAndWarn(cmd, args)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.SecureFlagOpts.applyChangedFlags(cmd.Flags())
+			return runPolicyDrift(cmd.Context(), opts)
+		},
+	}
+	opts.LoggingFlagOpts.ApplyFlags(command.Flags())
+	opts.SecureFlagOpts.ApplyFlags(command.Flags())
+	command.Flags().StringVar(&opts.source, "source", "", "reference of the OCI artifact of the published configuration bundle")
+	cmd.SetPflagOutput(command.Flags(), &opts.outputFormat, cmd.PflagOutputUsage)
+	return command
+}
+
+func runPolicyDrift(ctx context.Context, opts *policyDriftOpts) error {
+	// set log level
+	ctx = opts.LoggingFlagOpts.SetLoggerLevel(ctx)
+
+	switch opts.outputFormat {
+	case cmd.OutputPlaintext, cmd.OutputJSON:
+	default:
+		return fmt.Errorf("unrecognized output format %s", opts.outputFormat)
+	}
+	source, err := expandAlias(inputTypeRegistry, opts.source)
+	if err != nil {
+		return err
+	}
+	bundle, digest, err := fetchConfigBundle(ctx, &opts.SecureFlagOpts, source)
+	if err != nil {
+		return err
+	}
+	configDir, err := dir.ConfigFS().SysPath("")
+	if err != nil {
+		return err
+	}
+	host, err := configbundle.Collect(configDir, nil)
+	if err != nil {
+		return fmt.Errorf("failed to collect the configuration: %w", err)
+	}
+	output := policyDriftOutput{
+		Source:     source,
+		Digest:     digest,
+		Files:      policyDrifts(bundle, host),
+		Statements: []policy.StatementDrift{},
+	}
+	for _, drift := range output.Files {
+		if drift.Name != dir.PathTrustPolicy || drift.Status != configbundle.StatusModified {
+			continue
+		}
+		bundlePolicy, _ := bundle.Content(dir.PathTrustPolicy)
+		hostPolicy, _ := host.Content(dir.PathTrustPolicy)
+		// a malformed trust policy is reported as a modified file only
+		if statements, err := policy.StatementDrifts(bundlePolicy, hostPolicy); err == nil && statements != nil {
+			output.Statements = statements
+		}
+	}
+
+	if opts.outputFormat == cmd.OutputJSON {
+		if err := ioutil.PrintObjectAsJSON(output); err != nil {
+			return err
+		}
+	} else {
+		printPolicyDrift(os.Stdout, output)
+	}
+	if n := len(output.Files); n > 0 {
+		return fmt.Errorf("the trust policy and the trust stores differ from %s in %d files", source, n)
+	}
+	return nil
+}
+
+// fetchConfigBundle fetches the configuration bundle published as the layer
+// of the OCI artifact of reference, and returns it with the digest of the
+// artifact.
+func fetchConfigBundle(ctx context.Context, opts *SecureFlagOpts, reference string) (*configbundle.Bundle, string, error) {
+	ref, err := registry.ParseReference(reference)
+	if err != nil {
+		return nil, "", err
+	}
+	repo, err := getRepositoryClient(ctx, opts, ref)
+	if err != nil {
+		return nil, "", err
+	}
+	manifestDesc, manifestJSON, err := oras.FetchBytes(ctx, repo, ref.Reference, oras.DefaultFetchBytesOptions)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to fetch the manifest of %s: %w", reference, err)
+	}
+	var manifest ocispec.Manifest
+	if err := json.Unmarshal(manifestJSON, &manifest); err != nil {
+		return nil, "", fmt.Errorf("failed to parse the manifest of %s: %w", reference, err)
+	}
+	var layer *ocispec.Descriptor
+	for i := range manifest.Layers {
+		if manifest.Layers[i].MediaType == configbundle.MediaType {
+			layer = &manifest.Layers[i]
+			break
+		}
+	}
+	if layer == nil {
+		return nil, "", fmt.Errorf("%s is not a configuration bundle: no layer of media type %s", reference, configbundle.MediaType)
+	}
+	if layer.Size > maxPublishedBundleSize {
+		return nil, "", fmt.Errorf("configuration bundle of %s exceeds %d bytes", reference, maxPublishedBundleSize)
+	}
+	data, err := content.FetchAll(ctx, repo, *layer)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to fetch the configuration bundle of %s: %w", reference, err)
+	}
+	bundle, err := configbundle.Read(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read the configuration bundle of %s: %w", reference, err)
+	}
+	return bundle, manifestDesc.Digest.String(), nil
+}
+
+// policyDrifts returns the drifts of the trust policy and the trust store
+// files of host from bundle. The settings of config.json and the plugins are
+// not compared.
+func policyDrifts(bundle, host *configbundle.Bundle) []configbundle.Drift {
+	drifts := []configbundle.Drift{}
+	for _, drift := range bundle.Diff(host) {
+		if drift.Kind == configbundle.KindFile && drift.Name != dir.PathConfigFile {
+			drifts = append(drifts, drift)
+		}
+	}
+	return drifts
+}
+
+// printPolicyDrift prints the drift of the trust policy and the trust stores
+// in text.
+func printPolicyDrift(w io.Writer, output policyDriftOutput) {
+	if len(output.Files) == 0 {
+		fmt.Fprintf(w, "The trust policy and the trust stores are identical to %s@%s\n", output.Source, output.Digest)
+		return
+	}
+	fmt.Fprintf(w, "The trust policy and the trust stores differ from %s@%s:\n", output.Source, output.Digest)
+	for _, drift := range output.Files {
+		if drift.Name == dir.PathTrustPolicy {
+			fmt.Fprintf(w, "  trust policy %s is %s\n", drift.Name, drift.Status)
+			for _, statement := range output.Statements {
+				if statement.Name == "" {
+					fmt.Fprintf(w, "    version is %s\n", statement.Status)
+					continue
+				}
+				fmt.Fprintf(w, "    statement %q is %s", statement.Name, statement.Status)
+				if len(statement.Fields) > 0 {
+					fmt.Fprintf(w, ": %s", strings.Join(statement.Fields, ", "))
+				}
+				fmt.Fprintln(w)
+			}
+			continue
+		}
+		fmt.Fprintf(w, "  certificate %s is %s\n", drift.Name, drift.Status)
+	}
+}
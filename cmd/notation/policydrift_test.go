@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/notation-go/dir"
+	"github.com/notaryproject/notation/internal/configbundle"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+func TestPolicyDrift(t *testing.T) {
+	defer func(oldDir string) { dir.UserConfigDir = oldDir }(dir.UserConfigDir)
+	dir.UserConfigDir = t.TempDir()
+	writeFile := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(dir.UserConfigDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	policyJSON := `{"version":"1.0","trustPolicies":[{"name":"images","registryScopes":["*"],"signatureVerification":{"level":"strict"},"trustStores":["ca:acme"],"trustedIdentities":["*"]}]}`
+	writeFile(dir.PathTrustPolicy, policyJSON)
+	writeFile("truststore/x509/ca/acme/root.crt", "root")
+	writeFile(dir.PathConfigFile, `{}`)
+
+	// publish the canonical configuration
+	bundle, err := configbundle.Collect(dir.UserConfigDir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var bundleData bytes.Buffer
+	if err := bundle.Write(&bundleData, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	layer := ocispec.Descriptor{MediaType: configbundle.MediaType, Digest: digest.FromBytes(bundleData.Bytes()), Size: int64(bundleData.Len())}
+	manifestJSON, err := json.Marshal(ocispec.Manifest{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config:    ocispec.Descriptor{MediaType: "application/vnd.oci.empty.v1+json", Digest: digest.FromBytes([]byte("{}")), Size: 2},
+		Layers:    []ocispec.Descriptor{layer},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifestDigest := digest.FromBytes(manifestJSON)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/config/manifests/v1":
+			w.Header().Set("Content-Type", ocispec.MediaTypeImageManifest)
+			w.Header().Set("Docker-Content-Digest", manifestDigest.String())
+			w.Write(manifestJSON)
+		case "/v2/config/blobs/" + layer.Digest.String():
+			w.Write(bundleData.Bytes())
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	source := "localhost:" + ts.URL[strings.LastIndex(ts.URL, ":")+1:] + "/config:v1"
+	opts := &policyDriftOpts{source: source, outputFormat: "json"}
+
+	// config.json is not compared
+	writeFile(dir.PathConfigFile, `{"insecureRegistries":["localhost:5000"]}`)
+	if err := runPolicyDrift(context.Background(), opts); err != nil {
+		t.Fatalf("runPolicyDrift() error = %v", err)
+	}
+
+	// drift of a trust store certificate and a trust policy statement
+	if err := os.Remove(filepath.Join(dir.UserConfigDir, "truststore", "x509", "ca", "acme", "root.crt")); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(dir.PathTrustPolicy, strings.Replace(policyJSON, `"strict"`, `"audit"`, 1))
+	if err := runPolicyDrift(context.Background(), opts); err == nil || !strings.Contains(err.Error(), "in 2 files") {
+		t.Fatalf("runPolicyDrift() expects drift error, got %v", err)
+	}
+	remote, _, err := fetchConfigBundle(context.Background(), &SecureFlagOpts{}, source)
+	if err != nil {
+		t.Fatalf("fetchConfigBundle() error = %v", err)
+	}
+	host, err := configbundle.Collect(dir.UserConfigDir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	printPolicyDrift(&out, policyDriftOutput{Source: source, Digest: manifestDigest.String(), Files: policyDrifts(remote, host)})
+	for _, want := range []string{"certificate truststore/x509/ca/acme/root.crt is missing", "trust policy trustpolicy.json is modified"} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("expected output to contain %q, got:\n%s", want, out.String())
+		}
+	}
+
+	if _, _, err := fetchConfigBundle(context.Background(), &SecureFlagOpts{}, strings.Replace(source, ":v1", ":missing", 1)); err == nil {
+		t.Fatal("fetchConfigBundle() expects error of a missing artifact")
+	}
+}
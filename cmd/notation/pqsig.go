@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation-go/dir"
+	"github.com/notaryproject/notation-go/log"
+	"github.com/notaryproject/notation/cmd/notation/internal/truststore"
+	"github.com/notaryproject/notation/internal/cmd"
+	"github.com/notaryproject/notation/internal/color"
+	"github.com/notaryproject/notation/internal/experimental"
+	"github.com/notaryproject/notation/internal/osutil"
+	"github.com/notaryproject/notation/internal/pqsig"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/spf13/cobra"
+)
+
+// pqTrustStoreDir is the trust store directory of the trusted ML-DSA public
+// keys, relative to the notation configuration directory.
+const pqTrustStoreDir = dir.TrustStoreDir + "/mldsa"
+
+type keyGenerateMLDSAOpts struct {
+	cmd.LoggingFlagOpts
+	name       string
+	parameters string
+}
+
+func keyGenerateMLDSACommand(opts *keyGenerateMLDSAOpts) *cobra.Command {
+	if opts == nil {
+		opts = &keyGenerateMLDSAOpts{}
+	}
+	command := &cobra.Command{
+		Use:   "generate-mldsa [flags] <key_name>",
+		Short: "[Experimental] Generate an ML-DSA key for post-quantum signatures",
+		Long: `[Experimental] Generate an ML-DSA key for post-quantum signatures
+
+The ML-DSA key signs the payload of the classical signature when flag "--pq-key" of "notation sign" is set.
+
+Example - Generate an ML-DSA-65 key:
+  notation key generate-mldsa <key_name>
+
+Example - Generate an ML-DSA-87 key:
+  notation key generate-mldsa --parameters ML-DSA-87 <key_name>
+`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return errors.New("either missing key name or unnecessary parameters passed")
+			}
+			opts.name = args[0]
+			return nil
+		},
+		PreRunE: experimental.CheckCommandAndWarn,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return generateMLDSAKey(opts)
+		},
+	}
+	opts.LoggingFlagOpts.ApplyFlags(command.Flags())
+	command.Flags().StringVar(&opts.parameters, "parameters", pqsig.DefaultParameters, fmt.Sprintf("ML-DSA parameter set, options: %s", strings.Join(pqsig.Parameters, ", ")))
+	return command
+}
+
+func generateMLDSAKey(opts *keyGenerateMLDSAOpts) error {
+	if !truststore.IsValidFileName(opts.name) {
+		return errors.New("name needs to follow [a-zA-Z0-9_.-]+ format")
+	}
+	key, err := pqsig.GenerateKey(opts.parameters)
+	if err != nil {
+		return err
+	}
+	pub, err := key.Public()
+	if err != nil {
+		return err
+	}
+	keyPath, err := dir.ConfigFS().SysPath(dir.LocalKeysDir, opts.name+pqsig.KeyExtension)
+	if err != nil {
+		return err
+	}
+	pubPath, err := dir.ConfigFS().SysPath(dir.LocalKeysDir, opts.name+pqsig.PublicKeyExtension)
+	if err != nil {
+		return err
+	}
+	if err := osutil.WriteFileWithPermission(keyPath, pqsig.MarshalPrivateKey(key), 0600, false); err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return fmt.Errorf("ML-DSA key %q already exists", opts.name)
+		}
+		return fmt.Errorf("failed to write ML-DSA private key: %w", err)
+	}
+	if err := osutil.WriteFile(pubPath, pqsig.MarshalPublicKey(pub)); err != nil {
+		return fmt.Errorf("failed to write ML-DSA public key: %w", err)
+	}
+	trustPath, err := dir.ConfigFS().SysPath(pqTrustStoreDir)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("generated %s key %q with ID %s\n", key.Parameters, opts.name, pub.ID())
+	fmt.Println("wrote private key:", keyPath)
+	fmt.Println("wrote public key:", pubPath)
+	fmt.Println("copy the public key into", trustPath, "to verify the signatures of the key")
+	return nil
+}
+
+// loadPQKey loads the ML-DSA private key name from the local keys.
+func loadPQKey(name string) (*pqsig.PrivateKey, error) {
+	keyPath, err := dir.ConfigFS().SysPath(dir.LocalKeysDir, name+pqsig.KeyExtension)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ML-DSA key %q: %w", name, err)
+	}
+	key, err := pqsig.ParsePrivateKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ML-DSA key %q: %w", name, err)
+	}
+	return key, nil
+}
+
+// loadTrustedPQKeys loads the trusted ML-DSA public keys from the trust store.
+func loadTrustedPQKeys() ([]*pqsig.PublicKey, error) {
+	trustPath, err := dir.ConfigFS().SysPath(pqTrustStoreDir)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(trustPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var keys []*pqsig.PublicKey
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		path := filepath.Join(trustPath, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		key, err := pqsig.ParsePublicKey(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse ML-DSA public key %q: %w", path, err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// pushPQSignature pushes the ML-DSA signature produced by signer as a
+// referrer of the artifact manifestDesc.
+func pushPQSignature(ctx context.Context, opts *signOpts, signer *pqsig.Signer, manifestDesc ocispec.Descriptor) error {
+	sig := signer.Signature()
+	if sig == nil {
+		return errors.New("no ML-DSA signature is generated")
+	}
+	storage, err := getReferrerStorage(ctx, opts.inputType, opts.reference, &opts.SecureFlagOpts)
+	if err != nil {
+		return err
+	}
+	desc, err := pqsig.Push(ctx, storage, manifestDesc, sig)
+	if err != nil {
+		return err
+	}
+	log.GetLogger(ctx).Infof("Pushed ML-DSA signature manifest %s", desc.Digest)
+	return nil
+}
+
+// verifyPQSignatures verifies the ML-DSA signatures of the artifact
+// manifestDesc accompanying the classical signatures verified in outcomes.
+// Once ML-DSA public keys are trusted, each verified classical signature must
+// be accompanied by an ML-DSA signature of a trusted key, so that the
+// verification fails if the ML-DSA signature is stripped or signed by another
+// key. Without trusted ML-DSA public keys, the ML-DSA signatures are not
+// verified.
+func verifyPQSignatures(ctx context.Context, opts *verifyOpts, manifestDesc ocispec.Descriptor, outcomes []*notation.VerificationOutcome) error {
+	trustedKeys, err := loadTrustedPQKeys()
+	if err != nil {
+		return err
+	}
+	storage, err := getReferrerStorage(ctx, opts.inputType, opts.reference, &opts.SecureFlagOpts)
+	if err != nil {
+		return err
+	}
+	signatures, err := pqsig.Fetch(ctx, storage, manifestDesc)
+	if err != nil {
+		return err
+	}
+	if len(trustedKeys) == 0 {
+		if len(signatures) > 0 {
+			fmt.Fprintln(os.Stderr, color.Warning(os.Stderr, "Warning:"), "ML-DSA signatures are found but not verified as no ML-DSA public key is trusted")
+		}
+		return nil
+	}
+	var payloads [][]byte
+	for _, outcome := range outcomes {
+		if outcome.Error == nil && outcome.EnvelopeContent != nil {
+			payloads = append(payloads, outcome.EnvelopeContent.Payload.Content)
+		}
+	}
+	verified, err := pqsig.VerifyPayloads(signatures, trustedKeys, payloads)
+	if err != nil {
+		return err
+	}
+	for _, sig := range verified {
+		fmt.Printf("Successfully verified %s signature %s with key %s\n", sig.Parameters, sig.Descriptor.Digest, sig.KeyID)
+	}
+	return nil
+}
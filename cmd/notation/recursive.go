@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/notaryproject/notation-go"
+	notationregistry "github.com/notaryproject/notation-go/registry"
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+	"github.com/notaryproject/notation/cmd/notation/internal/integrity"
+	"github.com/notaryproject/notation/internal/color"
+	"github.com/notaryproject/notation/internal/platform"
+	"github.com/notaryproject/notation/internal/policy"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+)
+
+// platformVerifyOutput is the verification result of the manifest of a
+// platform of an image index verified with flag "--recursive".
+type platformVerifyOutput struct {
+	// Platform is the platform of the manifest, e.g. "linux/arm64".
+	Platform  string `json:"platform"`
+	Reference string `json:"reference"`
+	Digest    string `json:"digest"`
+	MediaType string `json:"mediaType,omitempty"`
+
+	// Result is "success", "failure" or "skipped".
+	Result            string                        `json:"result"`
+	Error             string                        `json:"error,omitempty"`
+	ErrorType         string                        `json:"errorType,omitempty"`
+	VerificationLevel string                        `json:"verificationLevel,omitempty"`
+	Signatures        []signatureVerificationOutput `json:"signatures"`
+}
+
+// platformVerification is the verification of the manifest of a platform.
+type platformVerification struct {
+	platform  string
+	reference string
+	desc      ocispec.Descriptor
+	records   []verificationRecord
+	outcomes  []*notation.VerificationOutcome
+	err       error
+}
+
+// output returns the verification result in JSON, with the timestamps
+// verified by policyVerifier.
+func (v platformVerification) output(policyVerifier *policy.Verifier) platformVerifyOutput {
+	output := newVerifyOutput(v.reference, v.desc, nil, v.records, v.outcomes, v.err)
+	output.setVerifiedTimestamps(v.records, policyVerifier)
+	return platformVerifyOutput{
+		Platform:          v.platform,
+		Reference:         output.Reference,
+		Digest:            output.Digest,
+		MediaType:         output.MediaType,
+		Result:            output.Result,
+		Error:             output.Error,
+		ErrorType:         output.ErrorType,
+		VerificationLevel: output.VerificationLevel,
+		Signatures:        output.Signatures,
+	}
+}
+
+// verifyPlatformManifests verifies the signatures of the manifest of every
+// platform of the image index of indexDesc with verifyOpts, reporting each
+// result as it completes, and returns the verifications. The manifests are
+// verified even if the image index or other manifests fail verification, and
+// the returned error is set if any of them fails. resolvedRef and intendedRef
+// are the references of the image index, for output and for selecting the
+// trust policy statement respectively.
+func verifyPlatformManifests(ctx context.Context, opts *verifyOpts, verifier notation.Verifier, repo notationregistry.Repository, manifestFetcher, indexFetcher content.Fetcher, indexDesc ocispec.Descriptor, resolvedRef, intendedRef string, verifyOpts notation.VerifyOptions) ([]platformVerification, error) {
+	manifests, err := platform.List(ctx, indexFetcher, indexDesc)
+	if err != nil {
+		return nil, err
+	}
+	name, _, _ := strings.Cut(resolvedRef, "@")
+	intendedName, _, _ := strings.Cut(intendedRef, "@")
+	var verifications []platformVerification
+	var failed int
+	for _, manifestDesc := range manifests {
+		verification := platformVerification{
+			platform:  platform.String(*manifestDesc.Platform),
+			reference: name + "@" + manifestDesc.Digest.String(),
+			desc:      manifestDesc,
+		}
+		// tampering is tracked per manifest
+		sigRepo := integrity.NewRepository(repo, manifestFetcher)
+		platformOpts := verifyOpts
+		platformOpts.ArtifactReference = intendedName + "@" + manifestDesc.Digest.String()
+		_, verification.outcomes, verification.records, err = verifySignatures(ctx, opts, sigRepo.Verifier(verifier), sigRepo, platformOpts)
+		if tamperErr := sigRepo.Err(); tamperErr != nil {
+			err = tamperErr
+		} else {
+			err = checkVerificationFailure(verification.outcomes, verification.reference, err)
+		}
+		verification.err = err
+		emitVerificationResult(ctx, verification.reference, manifestDesc.Digest.String(), nil, verification.outcomes, err)
+		if !isStructuredOutput(opts.outputFormat) {
+			switch {
+			case err != nil:
+				fmt.Fprintf(os.Stderr, "%s %s (%s): %v\n", color.Failure(os.Stderr, "Error:"), verification.reference, verification.platform, err)
+			case reflect.DeepEqual(verification.outcomes[0].VerificationLevel, trustpolicy.LevelSkip):
+				fmt.Printf("Trust policy is configured to skip signature verification for %s (%s)\n", verification.reference, verification.platform)
+			default:
+				fmt.Printf("%s %s (%s)\n", color.Success(os.Stdout, "Successfully verified signature for"), verification.reference, verification.platform)
+			}
+		}
+		if err != nil {
+			failed++
+		}
+		verifications = append(verifications, verification)
+	}
+	if failed > 0 {
+		return verifications, fmt.Errorf("signature verification failed for %d of %d platform manifests of %s", failed, len(manifests), resolvedRef)
+	}
+	return verifications, nil
+}
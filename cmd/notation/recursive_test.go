@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/notaryproject/notation-go"
+	notationregistry "github.com/notaryproject/notation-go/registry"
+	"github.com/notaryproject/notation/internal/cmd"
+	"github.com/notaryproject/notation/internal/events"
+	"github.com/notaryproject/notation/internal/policy"
+	"github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/content/memory"
+)
+
+// platformRepository resolves the manifests of the platforms by digest and
+// lists a signature per manifest.
+type platformRepository struct {
+	notationregistry.Repository
+	manifests map[string]ocispec.Descriptor
+}
+
+func (r *platformRepository) Resolve(ctx context.Context, reference string) (ocispec.Descriptor, error) {
+	return r.manifests[reference], nil
+}
+
+func (r *platformRepository) ListSignatures(ctx context.Context, desc ocispec.Descriptor, fn func(signatureManifests []ocispec.Descriptor) error) error {
+	return fn([]ocispec.Descriptor{{MediaType: ocispec.MediaTypeImageManifest, Digest: digest.FromString("signature of " + desc.Digest.String()), Size: 1}})
+}
+
+func (r *platformRepository) FetchSignatureBlob(ctx context.Context, desc ocispec.Descriptor) ([]byte, ocispec.Descriptor, error) {
+	blob := []byte(desc.Digest)
+	return blob, ocispec.Descriptor{MediaType: "application/jose+json", Digest: digest.FromBytes(blob), Size: int64(len(blob))}, nil
+}
+
+func TestVerifyPlatformManifests(t *testing.T) {
+	ctx := context.Background()
+	amd64 := ocispec.Descriptor{MediaType: ocispec.MediaTypeImageManifest, Digest: digest.FromString("amd64"), Size: 5, Platform: &ocispec.Platform{OS: "linux", Architecture: "amd64"}}
+	arm64 := ocispec.Descriptor{MediaType: ocispec.MediaTypeImageManifest, Digest: digest.FromString("arm64"), Size: 5, Platform: &ocispec.Platform{OS: "linux", Architecture: "arm64"}}
+	indexJSON, err := json.Marshal(ocispec.Index{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: ocispec.MediaTypeImageIndex,
+		Manifests: []ocispec.Descriptor{amd64, arm64},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	indexDesc := content.NewDescriptorFromBytes(ocispec.MediaTypeImageIndex, indexJSON)
+	store := memory.New()
+	if err := store.Push(ctx, indexDesc, bytes.NewReader(indexJSON)); err != nil {
+		t.Fatal(err)
+	}
+	repo := &platformRepository{manifests: map[string]ocispec.Descriptor{
+		amd64.Digest.String(): amd64,
+		arm64.Digest.String(): arm64,
+	}}
+	// the signature of the arm64 manifest is untrusted
+	verifier := &verifyOutputVerifier{failures: map[string]bool{
+		digest.FromString("signature of " + arm64.Digest.String()).String(): true,
+	}}
+
+	opts := &verifyOpts{outputFormat: cmd.OutputJSON, concurrency: 1}
+	resolvedRef := "localhost:5000/net-monitor@" + indexDesc.Digest.String()
+	verifyOpts := notation.VerifyOptions{ArtifactReference: resolvedRef, MaxSignatureAttempts: 10}
+	platforms, err := verifyPlatformManifests(ctx, opts, verifier, repo, nil, store, indexDesc, resolvedRef, resolvedRef, verifyOpts)
+	if err == nil {
+		t.Fatal("expected error for the failed platform manifest")
+	}
+	if len(platforms) != 2 {
+		t.Fatalf("got %d platform verifications, want 2", len(platforms))
+	}
+
+	got := platforms[0].output(&policy.Verifier{})
+	if got.Platform != "linux/amd64" || got.Result != events.ResultSuccess || got.Reference != "localhost:5000/net-monitor@"+amd64.Digest.String() || len(got.Signatures) != 1 {
+		t.Fatalf("unexpected output of linux/amd64: %+v", got)
+	}
+	got = platforms[1].output(&policy.Verifier{})
+	if got.Platform != "linux/arm64" || got.Result != events.ResultFailure || got.Error == "" {
+		t.Fatalf("unexpected output of linux/arm64: %+v", got)
+	}
+
+	// the results of the platforms follow the result of the image index in
+	// SARIF
+	output := verifyOutput{Reference: resolvedRef, Digest: indexDesc.Digest.String(), Result: events.ResultSuccess}
+	for _, platform := range platforms {
+		output.Platforms = append(output.Platforms, platform.output(&policy.Verifier{}))
+	}
+	var platformResults int
+	for _, result := range newSARIFLog(output).Runs[0].Results {
+		if result.Properties["platform"] != "" {
+			platformResults++
+		}
+	}
+	// a verification result and the results of the checks per platform,
+	// where the arm64 signature fails an additional check
+	if want := (1 + 2) + (1 + 3); platformResults != want {
+		t.Fatalf("got %d SARIF results of the platforms, want %d", platformResults, want)
+	}
+}
@@ -3,18 +3,27 @@ package main
 import (
 	"context"
 	"errors"
-	"net"
+	"fmt"
+	"io/fs"
 	"net/http"
+	"os"
+	"strings"
+	"time"
 
 	"github.com/notaryproject/notation-go/log"
 	notationregistry "github.com/notaryproject/notation-go/registry"
+	"github.com/notaryproject/notation/cmd/notation/internal/dockerplugin"
 	notationerrors "github.com/notaryproject/notation/cmd/notation/internal/errors"
+	"github.com/notaryproject/notation/internal/capability"
+	"github.com/notaryproject/notation/internal/httputil"
 	"github.com/notaryproject/notation/internal/trace"
 	"github.com/notaryproject/notation/internal/version"
 	loginauth "github.com/notaryproject/notation/pkg/auth"
 	"github.com/notaryproject/notation/pkg/configutil"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/sirupsen/logrus"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/content/oci"
 	"oras.land/oras-go/v2/registry"
 	"oras.land/oras-go/v2/registry/remote"
 	"oras.land/oras-go/v2/registry/remote/auth"
@@ -29,6 +38,10 @@ const (
 	inputTypeOCILayout                      // inputType oci-layout
 )
 
+// errAnonymousAuthRequired is returned if a registry requires
+// authentication while no credential may be sent with flag "--anonymous".
+var errAnonymousAuthRequired = errors.New("the registry requires authentication, which is not allowed with flag \"--anonymous\"")
+
 const (
 	zeroDigest = "sha256:0000000000000000000000000000000000000000000000000000000000000000"
 )
@@ -44,7 +57,7 @@ func getRepository(ctx context.Context, inputType inputType, reference string, o
 		if err != nil {
 			return nil, err
 		}
-		return notationregistry.NewOCIRepository(layoutPath, notationregistry.RepositoryOptions{})
+		return notationregistry.NewOCIRepository(ociLayoutDir(layoutPath), notationregistry.RepositoryOptions{})
 	default:
 		return nil, errors.New("unsupported input type")
 	}
@@ -61,7 +74,60 @@ func getRepositoryForSign(ctx context.Context, inputType inputType, reference st
 		if err != nil {
 			return nil, err
 		}
-		return notationregistry.NewOCIRepository(layoutPath, notationregistry.RepositoryOptions{OCIImageManifest: ociImageManifest})
+		return notationregistry.NewOCIRepository(ociLayoutDir(layoutPath), notationregistry.RepositoryOptions{OCIImageManifest: ociImageManifest})
+	default:
+		return nil, errors.New("unsupported input type")
+	}
+}
+
+// getManifestFetcher returns a content.Fetcher fetching manifests given user
+// input type and user input reference, bypassing notationregistry.Repository
+func getManifestFetcher(ctx context.Context, inputType inputType, reference string, opts *SecureFlagOpts) (content.Fetcher, error) {
+	switch inputType {
+	case inputTypeRegistry:
+		ref, err := registry.ParseReference(reference)
+		if err != nil {
+			return nil, err
+		}
+		remoteRepo, err := getRepositoryClient(ctx, opts, ref)
+		if err != nil {
+			return nil, err
+		}
+		return remoteRepo.Manifests(), nil
+	case inputTypeOCILayout:
+		layoutPath, _, err := parseOCILayoutReference(reference)
+		if err != nil {
+			return nil, err
+		}
+		return oci.NewFromFS(ctx, os.DirFS(ociLayoutDir(layoutPath)))
+	default:
+		return nil, errors.New("unsupported input type")
+	}
+}
+
+// referrerStorage is the storage of artifacts and their referrers.
+type referrerStorage interface {
+	content.Storage
+	content.PredecessorFinder
+}
+
+// getReferrerStorage returns the storage of the artifact and its referrers
+// given user input type and user input reference, bypassing
+// notationregistry.Repository
+func getReferrerStorage(ctx context.Context, inputType inputType, reference string, opts *SecureFlagOpts) (referrerStorage, error) {
+	switch inputType {
+	case inputTypeRegistry:
+		ref, err := registry.ParseReference(reference)
+		if err != nil {
+			return nil, err
+		}
+		return getRepositoryClient(ctx, opts, ref)
+	case inputTypeOCILayout:
+		layoutPath, _, err := parseOCILayoutReference(reference)
+		if err != nil {
+			return nil, err
+		}
+		return oci.New(ociLayoutDir(layoutPath))
 	default:
 		return nil, errors.New("unsupported input type")
 	}
@@ -78,9 +144,64 @@ func getRemoteRepository(ctx context.Context, opts *SecureFlagOpts, reference st
 	if err != nil {
 		return nil, err
 	}
+	applyRegistryCapabilities(ctx, remoteRepo)
 	return notationregistry.NewRepository(remoteRepo), nil
 }
 
+// capabilityCachePath returns the path of the cache of the capabilities of
+// registries, for unit test.
+var capabilityCachePath = capability.DefaultPath
+
+// applyRegistryCapabilities hints remoteRepo with the cached capabilities of
+// its registry, and records the capabilities observed while listing
+// referrers, so that repeated invocations do not probe the Referrers API
+// again. Only a registry known not to support the Referrers API is hinted, as
+// probing a registry supporting it costs no extra round trip and a stale hint
+// would disable the fallback to the Referrers tag schema.
+func applyRegistryCapabilities(ctx context.Context, remoteRepo *remote.Repository) {
+	logger := log.GetLogger(ctx)
+	ttl := capability.DefaultTTL
+	if config, err := configutil.LoadCLIConfigOnce(); err == nil && config.RegistryCapabilityCacheTTL != "" {
+		ttl, _ = time.ParseDuration(config.RegistryCapabilityCacheTTL)
+	}
+	authClient, ok := remoteRepo.Client.(*auth.Client)
+	if ttl <= 0 || !ok {
+		return
+	}
+	path, err := capabilityCachePath()
+	if err != nil {
+		logger.Debugf("Registry capability cache disabled: %v", err)
+		return
+	}
+	cache, err := capability.Load(path, ttl)
+	if err != nil {
+		logger.Debugf("Registry capability cache disabled: %v", err)
+		return
+	}
+
+	registryName := remoteRepo.Reference.Registry
+	if caps, ok := cache.Get(registryName); ok {
+		logger.Debugf("Cached capabilities of registry %s probed at %s: Referrers API supported: %t, artifact type filter supported: %t", registryName, caps.ProbedAt.Format(time.RFC3339), caps.ReferrersAPI, caps.ArtifactTypeFilter)
+		if !caps.ReferrersAPI {
+			if err := remoteRepo.SetReferrersCapability(false); err != nil {
+				logger.Debugf("Failed to apply the cached capabilities of registry %s: %v", registryName, err)
+			}
+		}
+	}
+
+	// copy the client so that http.DefaultClient is never modified
+	client := &http.Client{}
+	if authClient.Client != nil {
+		*client = *authClient.Client
+	}
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	client.Transport = cache.Transport(base, registryName)
+	authClient.Client = client
+}
+
 // getRemoteRepositoryForSign returns a registry.Repository for Sign.
 // ociImageManifest denotes the type of manifest used to store signatures during
 // Sign process.
@@ -160,30 +281,30 @@ func setHttpDebugLog(ctx context.Context, authClient *auth.Client) {
 }
 
 func getAuthClient(ctx context.Context, opts *SecureFlagOpts, ref registry.Reference) (*auth.Client, bool, error) {
-	var plainHTTP bool
-
-	if opts.PlainHTTP {
-		plainHTTP = opts.PlainHTTP
-	} else {
-		plainHTTP = configutil.IsRegistryInsecure(ref.Registry)
-		if !plainHTTP {
-			if host, _, _ := net.SplitHostPort(ref.Registry); host == "localhost" {
-				plainHTTP = true
-			}
-		}
-	}
-	cred := auth.Credential{
-		Username: opts.Username,
-		Password: opts.Password,
+	plainHTTP, err := resolveInsecureAccess(opts, ref.Registry)
+	if err != nil {
+		return nil, false, err
 	}
+	var cred auth.Credential
+	cred.Username, cred.Password = opts.credentials(ref.Registry)
 	if cred.Username == "" {
 		cred = auth.Credential{
 			RefreshToken: cred.Password,
 		}
 	}
-	if cred == auth.EmptyCredential {
-		var err error
-		cred, err = getSavedCreds(ctx, ref.Registry)
+	if opts.Anonymous {
+		// only anonymous tokens are fetched from the token services
+		cred = auth.EmptyCredential
+	}
+	if cred == auth.EmptyCredential && dockerPluginMode && !opts.Anonymous {
+		cred, err = loginauth.GetDockerCredential(ctx, ref.Registry)
+		// the Docker config file may not exist
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, false, err
+		}
+	}
+	if cred == auth.EmptyCredential && !opts.Anonymous {
+		cred, err = getSavedCreds(ctx, ref.Registry, ref.Repository)
 		// local registry may not need credentials
 		if err != nil && !errors.Is(err, loginauth.ErrCredentialsConfigNotSet) {
 			return nil, false, err
@@ -202,7 +323,27 @@ func getAuthClient(ctx context.Context, opts *SecureFlagOpts, ref registry.Refer
 		Cache:    auth.NewCache(),
 		ClientID: "notation",
 	}
-	authClient.SetUserAgent("notation/" + version.GetVersion())
+	if opts.InsecureRegistry {
+		authClient.Client = newInsecureHTTPClient()
+	}
+	if err := setRequestHeaders(authClient, opts, ref.Registry); err != nil {
+		return nil, false, err
+	}
+
+	if dockerplugin.RegistryHost(ref.Registry) != ref.Registry {
+		// the domain of Docker Hub in references does not serve the registry
+		// API, while the credentials are looked up by the domain
+		if authClient.Client == nil {
+			authClient.Client = &http.Client{}
+		}
+		authClient.Client.Transport = dockerplugin.NewTransport(authClient.Client.Transport)
+	}
+	if opts.Anonymous {
+		if authClient.Client == nil {
+			authClient.Client = &http.Client{}
+		}
+		authClient.Client.Transport = newAnonymousTransport(authClient.Client.Transport)
+	}
 
 	// update authClient
 	setHttpDebugLog(ctx, authClient)
@@ -210,13 +351,106 @@ func getAuthClient(ctx context.Context, opts *SecureFlagOpts, ref registry.Refer
 	return authClient, plainHTTP, nil
 }
 
-func getSavedCreds(ctx context.Context, serverAddress string) (auth.Credential, error) {
-	nativeStore, err := loginauth.GetCredentialsStore(ctx, serverAddress)
+// setRequestHeaders sets the User-Agent and the extra headers of the requests
+// to the registry, in the format of host[:port], sent by authClient. The flags
+// of opts take precedence over config.json.
+func setRequestHeaders(authClient *auth.Client, opts *SecureFlagOpts, registry string) error {
+	cliConfig, err := configutil.LoadCLIConfigOnce()
+	if err != nil {
+		return err
+	}
+	userAgent := "notation/" + version.GetVersion()
+	if cliConfig.UserAgent != "" {
+		userAgent = cliConfig.UserAgent
+	}
+	if opts.UserAgent != "" {
+		if err := httputil.ValidateUserAgent(opts.UserAgent); err != nil {
+			return fmt.Errorf("invalid flag %q: %w", "--"+flagUserAgent.Name, err)
+		}
+		userAgent = opts.UserAgent
+	}
+	authClient.SetUserAgent(userAgent)
+
+	header := cliConfig.RegistryHeadersOf(registry)
+	if opts.Anonymous {
+		// the headers of config.json may carry credentials, e.g. API keys
+		header = http.Header{}
+	}
+	flagHeaders := http.Header{}
+	for _, h := range opts.Headers {
+		name, value, err := httputil.ParseHeader(h)
+		if err != nil {
+			return fmt.Errorf("invalid flag %q: %w", "--"+flagHeader.Name, err)
+		}
+		flagHeaders.Add(name, value)
+	}
+	httputil.MergeHeader(header, flagHeaders)
+	httputil.MergeHeader(authClient.Header, header)
+	return nil
+}
+
+// anonymousTransport is the transport of the registry clients of flag
+// "--anonymous". It refuses to send credentials other than the anonymous
+// tokens fetched from the token services of the registries, and fails the
+// requests challenged for basic authentication.
+type anonymousTransport struct {
+	base http.RoundTripper
+}
+
+// newAnonymousTransport returns an anonymousTransport sending the requests
+// with base, or http.DefaultTransport if base is nil.
+func newAnonymousTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &anonymousTransport{base: base}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *anonymousTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if scheme, _, _ := strings.Cut(req.Header.Get("Authorization"), " "); scheme != "" && !strings.EqualFold(scheme, "Bearer") {
+		return nil, fmt.Errorf("refused to send credentials of scheme %q with flag \"--anonymous\"", scheme)
+	}
+	if req.Header.Get("Proxy-Authorization") != "" || req.Header.Get("Cookie") != "" {
+		return nil, errors.New("refused to send credentials with flag \"--anonymous\"")
+	}
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		challenge := resp.Header.Get("Www-Authenticate")
+		if scheme, _, _ := strings.Cut(challenge, " "); strings.EqualFold(scheme, "Basic") {
+			// basic authentication always requires credentials
+			resp.Body.Close()
+			return nil, errAnonymousAuthRequired
+		}
+	}
+	return resp, nil
+}
+
+// anonymousAccessError returns errAnonymousAuthRequired wrapping err if err is
+// caused by a registry or its token service denying anonymous access.
+func anonymousAccessError(err error) error {
+	if err == nil || errors.Is(err, errAnonymousAuthRequired) {
+		return err
+	}
+	var errResp *errcode.ErrorResponse
+	if errors.As(err, &errResp) && (errResp.StatusCode == http.StatusUnauthorized || errResp.StatusCode == http.StatusForbidden) {
+		return fmt.Errorf("%w: %v", errAnonymousAuthRequired, err)
+	}
+	return err
+}
+
+// getSavedCreds returns the saved credentials with the narrowest namespace
+// covering the repository, falling back to the credentials of the registry.
+func getSavedCreds(ctx context.Context, registryName, repository string) (auth.Credential, error) {
+	nativeStore, err := loginauth.GetCredentialsStore(ctx, registryName)
 	if err != nil {
 		return auth.EmptyCredential, err
 	}
 
-	return nativeStore.Get(serverAddress)
+	return loginauth.GetScopedCredential(nativeStore, registryName, repository)
 }
 
 func pingReferrersAPI(ctx context.Context, remoteRepo *remote.Repository) error {
@@ -3,14 +3,22 @@ package main
 import (
 	"context"
 	"errors"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 
+	"github.com/notaryproject/notation-go/dir"
+	"github.com/notaryproject/notation/cmd/notation/internal/dockerplugin"
 	notationerrors "github.com/notaryproject/notation/cmd/notation/internal/errors"
+	"github.com/notaryproject/notation/internal/capability"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"oras.land/oras-go/v2/registry/remote"
+	"oras.land/oras-go/v2/registry/remote/auth"
 	"oras.land/oras-go/v2/registry/remote/errcode"
 )
 
@@ -133,3 +141,207 @@ func TestRegistry_pingReferrersAPI_RepositoryNotFound(t *testing.T) {
 		t.Errorf("pingReferrersAPI() expected error: %v, but got: %v", expectedErr, err)
 	}
 }
+
+func TestRegistry_applyRegistryCapabilities(t *testing.T) {
+	defer func(oldDir string) { dir.UserConfigDir = oldDir }(dir.UserConfigDir)
+	dir.UserConfigDir = t.TempDir()
+	defer func(old func() (string, error)) { capabilityCachePath = old }(capabilityCachePath)
+	cachePath := filepath.Join(t.TempDir(), capability.FileName)
+	capabilityCachePath = func() (string, error) { return cachePath, nil }
+
+	var referrersAPICalls int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/v2/test/referrers/") {
+			referrersAPICalls++
+		}
+		// neither the Referrers API nor the referrers tag is found
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer ts.Close()
+	uri, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("invalid test http server: %v", err)
+	}
+	_, port, _ := net.SplitHostPort(uri.Host)
+
+	subject := ocispec.Descriptor{MediaType: ocispec.MediaTypeImageManifest, Digest: zeroDigest}
+	for i := 0; i < 2; i++ {
+		repo, err := getRemoteRepository(context.Background(), &SecureFlagOpts{}, "localhost:"+port+"/test@"+zeroDigest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := repo.ListSignatures(context.Background(), subject, func([]ocispec.Descriptor) error { return nil }); err != nil {
+			t.Fatalf("ListSignatures() error = %v", err)
+		}
+	}
+	// the second listing falls back to the referrers tag schema directly
+	if referrersAPICalls != 1 {
+		t.Fatalf("expected the Referrers API to be probed once, got %d", referrersAPICalls)
+	}
+}
+
+func TestRegistry_setRequestHeaders(t *testing.T) {
+	var got http.Header
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header
+	}))
+	defer ts.Close()
+	uri, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("invalid test http server: %v", err)
+	}
+
+	authClient := &auth.Client{}
+	opts := &SecureFlagOpts{
+		UserAgent: "acme-ci/1.0",
+		Headers:   []string{"X-Tenant-Id: contoso", "traceparent: 00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"},
+	}
+	if err := setRequestHeaders(authClient, opts, uri.Host); err != nil {
+		t.Fatal(err)
+	}
+	req, err := http.NewRequest(http.MethodGet, ts.URL+"/v2/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := authClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if got.Get("User-Agent") != "acme-ci/1.0" || got.Get("X-Tenant-Id") != "contoso" || got.Get("Traceparent") == "" {
+		t.Fatalf("unexpected request headers %v", got)
+	}
+
+	for _, opts := range []*SecureFlagOpts{
+		{Headers: []string{"Authorization: Bearer token"}},
+		{Headers: []string{"X-Tenant-Id"}},
+		{UserAgent: "acme\r\nX-Injected: true"},
+	} {
+		if err := setRequestHeaders(&auth.Client{}, opts, uri.Host); err == nil {
+			t.Errorf("expected error for flags %+v, got nil", opts)
+		}
+	}
+}
+
+func TestRegistry_getAuthClient_Anonymous(t *testing.T) {
+	var sentCredentials []string
+	var basicAuth, denyToken bool
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h := r.Header.Get("Authorization"); h != "" && h != "Bearer anonymous" {
+			sentCredentials = append(sentCredentials, h)
+		}
+		switch {
+		case r.URL.Path == "/token":
+			if denyToken {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(`{"token":"anonymous"}`))
+		case r.Header.Get("Authorization") == "Bearer anonymous":
+			w.Header().Set("Content-Type", ocispec.MediaTypeImageManifest)
+			w.Header().Set("Docker-Content-Digest", zeroDigest)
+			w.Header().Set("Content-Length", "2")
+		case basicAuth:
+			w.Header().Set("Www-Authenticate", `Basic realm="test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+		default:
+			w.Header().Set("Www-Authenticate", `Bearer realm="http://`+r.Host+`/token",service="test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer ts.Close()
+	uri, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("invalid test http server: %v", err)
+	}
+	_, port, _ := net.SplitHostPort(uri.Host)
+	ref := "localhost:" + port + "/test:v1"
+
+	resolve := func() error {
+		// the credentials of $NOTATION_USERNAME and $NOTATION_PASSWORD are
+		// ignored
+		opts := &SecureFlagOpts{Username: "user", Password: "secret", Anonymous: true}
+		repo, err := getRemoteRepository(context.Background(), opts, ref)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = repo.Resolve(context.Background(), ref)
+		return anonymousAccessError(err)
+	}
+
+	// anonymous tokens are fetched from the token service
+	if err := resolve(); err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+
+	// basic authentication always requires credentials
+	basicAuth = true
+	if err := resolve(); !errors.Is(err, errAnonymousAuthRequired) {
+		t.Fatalf("Resolve() error = %v, want %v", err, errAnonymousAuthRequired)
+	}
+
+	// the token service denies anonymous access
+	basicAuth, denyToken = false, true
+	if err := resolve(); !errors.Is(err, errAnonymousAuthRequired) {
+		t.Fatalf("Resolve() error = %v, want %v", err, errAnonymousAuthRequired)
+	}
+
+	if len(sentCredentials) != 0 {
+		t.Fatalf("expected no credentials to be sent, got %v", sentCredentials)
+	}
+}
+
+func TestRegistry_anonymousTransport_RefuseCredentials(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request with headers %v", r.Header)
+	}))
+	defer ts.Close()
+
+	client := &http.Client{Transport: newAnonymousTransport(nil)}
+	for name, value := range map[string]string{
+		"Authorization":       "Basic dXNlcjpzZWNyZXQ=",
+		"Proxy-Authorization": "Basic dXNlcjpzZWNyZXQ=",
+		"Cookie":              "session=secret",
+	} {
+		req, err := http.NewRequest(http.MethodGet, ts.URL+"/v2/", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set(name, value)
+		if resp, err := client.Do(req); err == nil {
+			resp.Body.Close()
+			t.Errorf("expected error for header %s, got nil", name)
+		}
+	}
+}
+
+// roundTripperFunc is an http.RoundTripper of a function.
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestRegistry_getRemoteRepository_DockerHub(t *testing.T) {
+	var requested []string
+	defer func(transport http.RoundTripper) {
+		http.DefaultTransport = transport
+	}(http.DefaultTransport)
+	http.DefaultTransport = roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		requested = append(requested, req.URL.String())
+		return &http.Response{StatusCode: http.StatusNotFound, Header: http.Header{}, Body: http.NoBody, Request: req}, nil
+	})
+
+	reference := dockerplugin.NormalizeReference("alpine:3.17")
+	repo, err := getRemoteRepository(context.Background(), &SecureFlagOpts{Anonymous: true}, reference)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.Resolve(context.Background(), reference); err == nil {
+		t.Fatal("expected error for the missing manifest")
+	}
+	want := "https://registry-1.docker.io/v2/library/alpine/manifests/3.17"
+	if len(requested) != 1 || requested[0] != want {
+		t.Fatalf("expected a request to %s, got %v", want, requested)
+	}
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	notationregistry "github.com/notaryproject/notation-go/registry"
+	"github.com/notaryproject/notation/cmd/notation/internal/integrity"
+	"github.com/notaryproject/notation/internal/audit"
+	"github.com/notaryproject/notation/internal/badge"
+	"github.com/notaryproject/notation/internal/cmd"
+	"github.com/notaryproject/notation/internal/experimental"
+	"github.com/notaryproject/notation/internal/osutil"
+	"github.com/notaryproject/notation/internal/policy"
+	"github.com/notaryproject/notation/internal/slices"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/spf13/cobra"
+	"oras.land/oras-go/v2/registry"
+)
+
+func reportCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "report",
+		Short: "[Experimental] Report the signature status of repositories",
+		Long:  "[Experimental] Report the signature status of repositories",
+	}
+	command.AddCommand(reportBadgeCommand(nil))
+	return command
+}
+
+type reportBadgeOpts struct {
+	cmd.LoggingFlagOpts
+	SecureFlagOpts
+	reference    string
+	pluginConfig []string
+	format       string
+	label        string
+	output       string
+}
+
+func reportBadgeCommand(opts *reportBadgeOpts) *cobra.Command {
+	if opts == nil {
+		opts = &reportBadgeOpts{}
+	}
+	command := &cobra.Command{
+		Use:   "badge [flags] <registry>/<repository>",
+		Short: "[Experimental] Generate a badge of the signature coverage of a repository",
+		Long: `[Experimental] Generate a badge of the signature coverage of a repository
+
+Every tagged artifact of the repository is checked for signatures, and the signed artifacts are verified against the trust policy. The badge shows the number of signed artifacts and the number of artifacts complying with the trust policy.
+
+Example - Generate an SVG badge of a repository:
+  notation report badge --output badge.svg <registry>/<repository>
+
+Example - Generate a JSON badge for the shields.io endpoint:
+  notation report badge --format json --output badge.json <registry>/<repository>
+`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return errors.New("either missing repository reference or unnecessary parameters passed")
+			}
+			opts.reference = args[0]
+			return nil
+		},
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if !slices.Contains(badge.Formats, badge.Format(opts.format)) {
+				return fmt.Errorf("unsupported badge format %q, options: %q, %q", opts.format, badge.FormatSVG, badge.FormatJSON)
+			}
+			return experimental.CheckCommandAndWarn(cmd, args)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.SecureFlagOpts.applyChangedFlags(cmd.Flags())
+			return runReportBadge(cmd.Context(), opts)
+		},
+	}
+	opts.LoggingFlagOpts.ApplyFlags(command.Flags())
+	opts.SecureFlagOpts.ApplyFlags(command.Flags())
+	cmd.SetPflagPluginConfig(command.Flags(), &opts.pluginConfig)
+	command.Flags().StringVar(&opts.format, "format", string(badge.FormatSVG), fmt.Sprintf("format of the badge, options: %q, %q", badge.FormatSVG, badge.FormatJSON))
+	command.Flags().StringVar(&opts.label, "label", badge.DefaultLabel, "text of the left part of the badge")
+	command.Flags().StringVarP(&opts.output, "output", "o", "", "file to write the badge to, the badge is written to stdout if not set")
+	return command
+}
+
+func runReportBadge(ctx context.Context, opts *reportBadgeOpts) error {
+	// set log level
+	ctx = opts.LoggingFlagOpts.SetLoggerLevel(ctx)
+
+	reference, err := expandAlias(inputTypeRegistry, opts.reference)
+	if err != nil {
+		return err
+	}
+	opts.reference = reference
+	ref, err := registry.ParseReference(opts.reference)
+	if err != nil {
+		return err
+	}
+	if ref.Reference != "" {
+		return errors.New("a repository reference without tag or digest is required")
+	}
+	repository := ref.Registry + "/" + ref.Repository
+
+	policyVerifier, err := policy.NewVerifierFromConfigOptions(policy.ConfigOptions{CRLCache: newCRLCache(ctx, false)})
+	if err != nil {
+		return err
+	}
+	warnTrustPolicy("")
+	configs, err := cmd.ParseFlagMap(opts.pluginConfig, cmd.PflagPluginConfig.Name)
+	if err != nil {
+		return err
+	}
+	warnPluginConfig(configs)
+	maxAttempts, err := resolveMaxSignatureAttempts(0)
+	if err != nil {
+		return err
+	}
+
+	remoteRepo, err := getRepositoryClient(ctx, &opts.SecureFlagOpts, ref)
+	if err != nil {
+		return err
+	}
+	var repo notationregistry.Repository = notationregistry.NewRepository(remoteRepo)
+	if policyVerifier.UsesArtifactTypes() {
+		repo = policy.NewRepository(repo, remoteRepo.Manifests())
+	}
+
+	var tags []string
+	if err := remoteRepo.Tags(ctx, "", func(page []string) error {
+		tags = append(tags, page...)
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to list tags of %s: %w", repository, err)
+	}
+
+	report := &badge.Report{Repository: repository}
+	for _, tag := range tags {
+		// tampering is tracked per tag
+		sigRepo := integrity.NewRepository(repo, nil)
+		desc, err := sigRepo.Resolve(ctx, tag)
+		if err != nil {
+			return fmt.Errorf("failed to resolve %s:%s: %w", repository, tag, err)
+		}
+		signed, err := hasSignatures(ctx, sigRepo, desc)
+		if err != nil {
+			return fmt.Errorf("failed to list signatures of %s:%s: %w", repository, tag, err)
+		}
+		compliant := false
+		if signed {
+			entry := verifyTag(ctx, policyVerifier, sigRepo, repository, tag, desc, configs, maxAttempts)
+			compliant = entry.Result == audit.ResultSuccess
+		}
+		report.Add(signed, compliant)
+	}
+
+	var out bytes.Buffer
+	if err := badge.Write(&out, report, opts.label, badge.Format(opts.format)); err != nil {
+		return err
+	}
+	if opts.output == "" {
+		_, err := os.Stdout.Write(out.Bytes())
+		return err
+	}
+	if err := osutil.WriteFile(opts.output, out.Bytes()); err != nil {
+		return fmt.Errorf("failed to write the badge: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Badge of %s written to %s: %s\n", repository, opts.output, report.Message())
+	return nil
+}
+
+// hasSignatures reports whether the artifact of desc has any signature.
+func hasSignatures(ctx context.Context, repo notationregistry.Repository, desc ocispec.Descriptor) (bool, error) {
+	errFound := errors.New("signature found")
+	err := repo.ListSignatures(ctx, desc, func(signatureManifests []ocispec.Descriptor) error {
+		if len(signatureManifests) > 0 {
+			return errFound
+		}
+		return nil
+	})
+	if errors.Is(err, errFound) {
+		return true, nil
+	}
+	return false, err
+}
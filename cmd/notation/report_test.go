@@ -0,0 +1,58 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/notaryproject/notation/internal/badge"
+)
+
+func TestReportBadgeCommand_BasicArgs(t *testing.T) {
+	opts := &reportBadgeOpts{}
+	cmd := reportBadgeCommand(opts)
+	expected := &reportBadgeOpts{
+		reference: "localhost:5000/net-monitor",
+		SecureFlagOpts: SecureFlagOpts{
+			PlainHTTP: true,
+		},
+		pluginConfig: []string{"key=value"},
+		format:       string(badge.FormatJSON),
+		label:        "signatures",
+		output:       "badge.json",
+	}
+	if err := cmd.ParseFlags([]string{
+		expected.reference,
+		"--plain-http",
+		"--plugin-config", "key=value",
+		"--format", expected.format,
+		"--label", expected.label,
+		"-o", expected.output}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := cmd.Args(cmd, cmd.Flags().Args()); err != nil {
+		t.Fatalf("Parse Args failed: %v", err)
+	}
+	if !reflect.DeepEqual(opts, expected) {
+		t.Fatalf("Expect report badge opts: %v, got: %v", expected, opts)
+	}
+}
+
+func TestReportBadgeCommand_MissingArgs(t *testing.T) {
+	cmd := reportBadgeCommand(nil)
+	if err := cmd.ParseFlags(nil); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := cmd.Args(cmd, cmd.Flags().Args()); err == nil {
+		t.Fatal("Parse Args expected error, but ok")
+	}
+}
+
+func TestReportBadgeCommand_InvalidFormat(t *testing.T) {
+	cmd := reportBadgeCommand(nil)
+	if err := cmd.ParseFlags([]string{"ref", "--format", "png"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := cmd.PreRunE(cmd, cmd.Flags().Args()); err == nil {
+		t.Fatal("PreRunE expected error, but ok")
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/notaryproject/notation-go/dir"
+	"github.com/notaryproject/notation/internal/policy"
+	"github.com/notaryproject/notation/internal/sanity"
+)
+
+// warnTrustPolicy warns about embedded secrets, paths in home directories and
+// URLs with insecure or invalid schemes in the trust policy document named
+// name of the notation config directory, or trustpolicy.json if name is empty.
+// Errors reading the document are ignored, as they are reported when it is
+// loaded.
+func warnTrustPolicy(name string) {
+	relPath, err := policy.DocumentPath(name)
+	if err != nil {
+		return
+	}
+	path, err := dir.ConfigFS().SysPath(relPath)
+	if err != nil {
+		return
+	}
+	policyJSON, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	findings, err := sanity.ScanJSON(policyJSON)
+	if err != nil {
+		return
+	}
+	source := "trust policy"
+	if name != "" {
+		source = fmt.Sprintf("trust policy %q", name)
+	}
+	sanity.PrintWarnings(os.Stderr, source, findings)
+}
+
+// warnPluginConfig warns about embedded secrets, paths in home directories and
+// URLs with insecure or invalid schemes in a plugin config.
+func warnPluginConfig(pluginConfig map[string]string) {
+	sanity.PrintWarnings(os.Stderr, "plugin config", sanity.ScanMap("", pluginConfig))
+}
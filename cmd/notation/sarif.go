@@ -0,0 +1,221 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+	"github.com/notaryproject/notation/internal/events"
+	"github.com/notaryproject/notation/internal/version"
+)
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+
+	// sarifRuleVerification is the rule of the result of the artifact, i.e.
+	// whether a signature of the artifact is verified successfully.
+	sarifRuleVerification = "verification"
+
+	// sarifRuleSignature is the rule of the signatures failing verification
+	// without a failing check, e.g. signatures whose envelopes are malformed.
+	sarifRuleSignature = "signature"
+
+	// sarifHelpURI documents the checks of the verification levels.
+	sarifHelpURI = "https://github.com/notaryproject/specifications/blob/main/specs/trust-store-trust-policy.md#signature-verification-details"
+)
+
+// sarifRuleDescriptions are the descriptions of the rules, i.e. the
+// verification of the artifact, the signatures and the checks of the
+// verification levels.
+var sarifRuleDescriptions = map[string]string{
+	sarifRuleVerification:                      "A signature of the artifact is verified successfully against the trust policy.",
+	sarifRuleSignature:                         "The signature is verified successfully against the trust policy.",
+	string(trustpolicy.TypeIntegrity):          "The signature envelope is not corrupted and the artifact is the subject of the signature.",
+	string(trustpolicy.TypeAuthenticity):       "The signature is produced by a trusted identity of the trust stores of the trust policy.",
+	string(trustpolicy.TypeAuthenticTimestamp): "The signature is produced while the signing certificate is valid, as asserted by the signing time or a timestamp.",
+	string(trustpolicy.TypeExpiry):             "The signature has not expired.",
+	string(trustpolicy.TypeRevocation):         "The certificates of the signature are not revoked.",
+}
+
+// sarifLog is the output of notation verify in SARIF 2.1.0, so that the
+// results are ingested by security dashboards, e.g. GitHub code scanning.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string                 `json:"id"`
+	ShortDescription     sarifMessage           `json:"shortDescription"`
+	HelpURI              string                 `json:"helpUri"`
+	DefaultConfiguration sarifRuleConfiguration `json:"defaultConfiguration"`
+}
+
+type sarifRuleConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+// sarifResult is the result of a rule. Kind is "fail", "pass" or
+// "notApplicable", and Level is "none" unless the rule fails.
+type sarifResult struct {
+	RuleID     string            `json:"ruleId"`
+	RuleIndex  int               `json:"ruleIndex"`
+	Kind       string            `json:"kind"`
+	Level      string            `json:"level"`
+	Message    sarifMessage      `json:"message"`
+	Locations  []sarifLocation   `json:"locations"`
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+// sarifLocation locates the results in the artifact, as there is no source
+// file.
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+// newSARIFLog returns the output of the verification in SARIF, with a rule
+// per check of the verification levels and the results of the checks per
+// signature verified, following the result of the artifact.
+func newSARIFLog(output verifyOutput) sarifLog {
+	ruleIDs := []string{sarifRuleVerification, sarifRuleSignature}
+	for _, check := range trustpolicy.ValidationTypes {
+		ruleIDs = append(ruleIDs, string(check))
+	}
+	ruleIndex := make(map[string]int, len(ruleIDs))
+	var rules []sarifRule
+	for i, id := range ruleIDs {
+		ruleIndex[id] = i
+		rules = append(rules, sarifRule{
+			ID:                   id,
+			ShortDescription:     sarifMessage{Text: sarifRuleDescriptions[id]},
+			HelpURI:              sarifHelpURI,
+			DefaultConfiguration: sarifRuleConfiguration{Level: "error"},
+		})
+	}
+
+	newResult := func(reference, ruleID, kind, level, text string, properties map[string]string) sarifResult {
+		return sarifResult{
+			RuleID:    ruleID,
+			RuleIndex: ruleIndex[ruleID],
+			Kind:      kind,
+			Level:     level,
+			Message:   sarifMessage{Text: text},
+			Locations: []sarifLocation{{
+				PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: reference},
+				},
+			}},
+			Properties: properties,
+		}
+	}
+	// appendResults appends the result of an artifact and the results of the
+	// checks of its signatures.
+	var results []sarifResult
+	appendResults := func(reference, digest, result, errorMessage, errorType, verificationLevel, platform string, signatures []signatureVerificationOutput) {
+		properties := map[string]string{"digest": digest}
+		if errorType != "" {
+			properties["errorType"] = errorType
+		}
+		if verificationLevel != "" {
+			properties["verificationLevel"] = verificationLevel
+		}
+		if platform != "" {
+			properties["platform"] = platform
+		}
+		switch result {
+		case events.ResultFailure:
+			results = append(results, newResult(reference, sarifRuleVerification, "fail", "error", errorMessage, properties))
+		case events.ResultSkipped:
+			results = append(results, newResult(reference, sarifRuleVerification, "notApplicable", "none", fmt.Sprintf("Signature verification of %s is skipped", reference), properties))
+		default:
+			results = append(results, newResult(reference, sarifRuleVerification, "pass", "none", fmt.Sprintf("Successfully verified signature for %s", reference), properties))
+		}
+
+		for _, signature := range signatures {
+			properties := map[string]string{
+				"digest":          digest,
+				"signatureDigest": signature.Digest,
+			}
+			if signature.ErrorType != "" {
+				properties["errorType"] = signature.ErrorType
+			}
+			if signature.VerificationLevel != "" {
+				properties["verificationLevel"] = signature.VerificationLevel
+			}
+			if platform != "" {
+				properties["platform"] = platform
+			}
+			failed := false
+			for _, check := range signature.Checks {
+				switch {
+				case check.Error != "":
+					// failures of logged checks do not fail the verification
+					level := "error"
+					if check.Action == string(trustpolicy.ActionLog) {
+						level = "warning"
+					}
+					failed = failed || level == "error"
+					results = append(results, newResult(reference, check.Type, "fail", level, fmt.Sprintf("%s check of signature %s failed: %s", check.Type, signature.Digest, check.Error), properties))
+				case check.Action == string(trustpolicy.ActionSkip):
+					results = append(results, newResult(reference, check.Type, "notApplicable", "none", fmt.Sprintf("%s check of signature %s is skipped", check.Type, signature.Digest), properties))
+				default:
+					results = append(results, newResult(reference, check.Type, "pass", "none", fmt.Sprintf("%s check of signature %s passed", check.Type, signature.Digest), properties))
+				}
+			}
+			if signature.Result == events.ResultFailure && !failed {
+				results = append(results, newResult(reference, sarifRuleSignature, "fail", "error", fmt.Sprintf("signature %s failed verification: %s", signature.Digest, signature.Error), properties))
+			}
+		}
+	}
+	appendResults(output.Reference, output.Digest, output.Result, output.Error, output.ErrorType, output.VerificationLevel, "", output.Signatures)
+	// the manifests of the platforms verified with flag "--recursive" follow
+	// the image index
+	for _, p := range output.Platforms {
+		appendResults(p.Reference, p.Digest, p.Result, p.Error, p.ErrorType, p.VerificationLevel, p.Platform, p.Signatures)
+	}
+
+	return sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs: []sarifRun{{
+			Tool: sarifTool{
+				Driver: sarifDriver{
+					Name:           "notation",
+					Version:        version.GetVersion(),
+					InformationURI: "https://notaryproject.dev",
+					Rules:          rules,
+				},
+			},
+			Results: results,
+		}},
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/notaryproject/notation/internal/events"
+)
+
+func TestNewSARIFLog(t *testing.T) {
+	output := verifyOutput{
+		Reference:         "localhost:5000/net-monitor@sha256:5a07385af4e6b6af81b0ebfd435aedccdfa3507f0609c658209e1aba57159b2b",
+		Digest:            "sha256:5a07385af4e6b6af81b0ebfd435aedccdfa3507f0609c658209e1aba57159b2b",
+		Result:            events.ResultSuccess,
+		VerificationLevel: "strict",
+		Signatures: []signatureVerificationOutput{
+			{
+				Digest: "sha256:untrusted",
+				Result: events.ResultFailure,
+				Error:  "untrusted signer",
+				Checks: []verificationCheckOutput{
+					{Type: "integrity", Action: "enforce"},
+					{Type: "authenticity", Action: "enforce", Error: "untrusted signer"},
+				},
+			},
+			{
+				Digest: "sha256:malformed",
+				Result: events.ResultFailure,
+				Error:  "malformed envelope",
+			},
+			{
+				Digest: "sha256:trusted",
+				Result: events.ResultSuccess,
+				Checks: []verificationCheckOutput{
+					{Type: "integrity", Action: "enforce"},
+					{Type: "expiry", Action: "log", Error: "signature is expired"},
+					{Type: "revocation", Action: "skip"},
+				},
+			},
+		},
+	}
+	log := newSARIFLog(output)
+	if log.Version != sarifVersion || len(log.Runs) != 1 {
+		t.Fatalf("unexpected SARIF log %+v", log)
+	}
+	run := log.Runs[0]
+	if run.Tool.Driver.Name != "notation" || len(run.Tool.Driver.Rules) != 7 {
+		t.Fatalf("unexpected SARIF tool %+v", run.Tool)
+	}
+	for _, rule := range run.Tool.Driver.Rules {
+		if rule.ShortDescription.Text == "" {
+			t.Fatalf("rule %q has no description", rule.ID)
+		}
+	}
+
+	want := []struct {
+		ruleID string
+		kind   string
+		level  string
+	}{
+		{sarifRuleVerification, "pass", "none"},
+		{"integrity", "pass", "none"},
+		{"authenticity", "fail", "error"},
+		{sarifRuleSignature, "fail", "error"},
+		{"integrity", "pass", "none"},
+		{"expiry", "fail", "warning"},
+		{"revocation", "notApplicable", "none"},
+	}
+	if len(run.Results) != len(want) {
+		t.Fatalf("got %d results, want %d: %+v", len(run.Results), len(want), run.Results)
+	}
+	for i, result := range run.Results {
+		if result.RuleID != want[i].ruleID || result.Kind != want[i].kind || result.Level != want[i].level {
+			t.Errorf("results[%d] = {%s %s %s}, want %v", i, result.RuleID, result.Kind, result.Level, want[i])
+		}
+		if rule := run.Tool.Driver.Rules[result.RuleIndex]; rule.ID != result.RuleID {
+			t.Errorf("results[%d] has rule index of rule %q, want %q", i, rule.ID, result.RuleID)
+		}
+		if len(result.Locations) != 1 || result.Locations[0].PhysicalLocation.ArtifactLocation.URI != output.Reference {
+			t.Errorf("results[%d] has locations %+v, want the artifact", i, result.Locations)
+		}
+	}
+	if got := run.Results[2].Properties["signatureDigest"]; got != "sha256:untrusted" {
+		t.Errorf("signatureDigest = %q, want %q", got, "sha256:untrusted")
+	}
+}
+
+func TestNewSARIFLog_Failure(t *testing.T) {
+	output := verifyOutput{
+		Reference: "localhost:5000/net-monitor@sha256:5a07385af4e6b6af81b0ebfd435aedccdfa3507f0609c658209e1aba57159b2b",
+		Result:    events.ResultFailure,
+		Error:     "signature verification failed: no signature is associated with the artifact",
+	}
+	results := newSARIFLog(output).Runs[0].Results
+	if len(results) != 1 {
+		t.Fatalf("got %d results, want 1", len(results))
+	}
+	if result := results[0]; result.RuleID != sarifRuleVerification || result.Kind != "fail" || result.Level != "error" || result.Message.Text != output.Error {
+		t.Fatalf("unexpected result %+v", result)
+	}
+}
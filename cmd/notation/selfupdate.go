@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"crypto"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/notaryproject/notation-go/log"
+	"github.com/notaryproject/notation/internal/cmd"
+	"github.com/notaryproject/notation/internal/experimental"
+	"github.com/notaryproject/notation/internal/selfupdate"
+	"github.com/notaryproject/notation/internal/version"
+	"github.com/spf13/cobra"
+)
+
+type selfUpdateOpts struct {
+	cmd.LoggingFlagOpts
+	version string
+	force   bool
+}
+
+func selfUpdateCommand(opts *selfUpdateOpts) *cobra.Command {
+	if opts == nil {
+		opts = &selfUpdateOpts{}
+	}
+	command := &cobra.Command{
+		Use:   "self-update [flags]",
+		Short: "[Experimental] Update notation to the latest or a pinned release",
+		Long: `[Experimental] Update notation to the latest or a pinned release
+
+The release archive for the current platform is downloaded, the signature of the release checksums is verified against the release trust anchors embedded in notation, the checksum of the archive is verified, and the notation binary is replaced atomically.
+
+Example - Update notation to the latest release:
+  notation self-update
+
+Example - Install the release 1.1.0 of notation:
+  notation self-update --version 1.1.0
+`,
+		Args:    cobra.NoArgs,
+		PreRunE: experimental.CheckCommandAndWarn,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSelfUpdate(cmd.Context(), opts)
+		},
+	}
+	opts.LoggingFlagOpts.ApplyFlags(command.Flags())
+	command.Flags().StringVar(&opts.version, "version", "", "version of the release to install, e.g. 1.1.0, defaults to the latest release")
+	command.Flags().BoolVar(&opts.force, "force", false, "install the release even if it is not newer than the running notation")
+	return command
+}
+
+func runSelfUpdate(ctx context.Context, opts *selfUpdateOpts) error {
+	// set log level
+	ctx = opts.LoggingFlagOpts.SetLoggerLevel(ctx)
+
+	// initialize
+	anchors, err := selfupdate.TrustAnchors()
+	if err != nil {
+		return err
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate the notation binary: %w", err)
+	}
+	if executable, err = filepath.EvalSymlinks(executable); err != nil {
+		return fmt.Errorf("failed to locate the notation binary: %w", err)
+	}
+	client := &selfupdate.Client{UserAgent: "notation/" + version.GetVersion()}
+
+	// core process
+	return selfUpdate(ctx, opts, client, anchors, executable)
+}
+
+// selfUpdate replaces the notation binary at executable with the binary of
+// the release, verified against the trust anchors.
+func selfUpdate(ctx context.Context, opts *selfUpdateOpts, client *selfupdate.Client, anchors []crypto.PublicKey, executable string) error {
+	logger := log.GetLogger(ctx)
+	release, err := client.Release(ctx, opts.version)
+	if err != nil {
+		return err
+	}
+	if !opts.force {
+		switch {
+		case opts.version == "" && !selfupdate.IsNewer(release.Version, version.Version):
+			fmt.Printf("notation %s is up to date, the latest release is %s\n", version.Version, release.Version)
+			return nil
+		case opts.version != "" && !selfupdate.IsNewer(release.Version, version.Version) && !selfupdate.IsNewer(version.Version, release.Version):
+			fmt.Printf("notation %s is already installed\n", version.Version)
+			return nil
+		}
+	}
+
+	// the checksums are verified before any other asset is trusted
+	checksums, err := client.Download(ctx, release, selfupdate.ChecksumsName(release.Version))
+	if err != nil {
+		return err
+	}
+	signature, err := client.Download(ctx, release, selfupdate.SignatureName(release.Version))
+	if err != nil {
+		return err
+	}
+	if err := selfupdate.VerifyChecksums(checksums, signature, anchors); err != nil {
+		return err
+	}
+	logger.Infof("Verified the signature of the checksums of release %s", release.Version)
+
+	archiveName := selfupdate.ArchiveName(release.Version, runtime.GOOS, runtime.GOARCH)
+	archive, err := client.Download(ctx, release, archiveName)
+	if err != nil {
+		return err
+	}
+	if err := selfupdate.VerifyAsset(checksums, archiveName, archive); err != nil {
+		return err
+	}
+	logger.Infof("Verified the checksum of %s", archiveName)
+	binary, err := selfupdate.ExtractBinary(archiveName, archive)
+	if err != nil {
+		return err
+	}
+	if err := selfupdate.Replace(executable, binary); err != nil {
+		return err
+	}
+	fmt.Printf("Updated notation %s at %s to %s\n", version.Version, executable, release.Version)
+	return nil
+}
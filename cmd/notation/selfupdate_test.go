@@ -0,0 +1,163 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/notaryproject/notation/internal/selfupdate"
+)
+
+func TestSelfUpdateCommand_BasicArgs(t *testing.T) {
+	opts := &selfUpdateOpts{}
+	cmd := selfUpdateCommand(opts)
+	expected := &selfUpdateOpts{
+		version: "1.1.0",
+		force:   true,
+	}
+	if err := cmd.ParseFlags([]string{
+		"--version", expected.version,
+		"--force"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := cmd.Args(cmd, cmd.Flags().Args()); err != nil {
+		t.Fatalf("Parse Args failed: %v", err)
+	}
+	if !reflect.DeepEqual(expected, opts) {
+		t.Fatalf("Expect self-update opts: %v, got: %v", expected, opts)
+	}
+}
+
+func TestSelfUpdateCommand_UnexpectedArgs(t *testing.T) {
+	cmd := selfUpdateCommand(nil)
+	if err := cmd.Args(cmd, []string{"1.1.0"}); err == nil {
+		t.Fatal("expected error for unexpected arguments")
+	}
+}
+
+// newReleaseArchive returns the release archive of the current platform
+// containing the notation binary.
+func newReleaseArchive(t *testing.T, binary []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if runtime.GOOS == "windows" {
+		zw := zip.NewWriter(&buf)
+		w, err := zw.Create("notation.exe")
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(binary)
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	if err := tw.WriteHeader(&tar.Header{Name: "notation", Mode: 0755, Size: int64(len(binary)), Typeflag: tar.TypeReg}); err != nil {
+		t.Fatal(err)
+	}
+	tw.Write(binary)
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// newReleaseServer serves the release 1.1.0 with its assets signed by key.
+func newReleaseServer(t *testing.T, key *ecdsa.PrivateKey, binary []byte) *httptest.Server {
+	t.Helper()
+	const releaseVersion = "1.1.0"
+	archiveName := selfupdate.ArchiveName(releaseVersion, runtime.GOOS, runtime.GOARCH)
+	archive := newReleaseArchive(t, binary)
+	archiveDigest := sha256.Sum256(archive)
+	checksums := []byte(fmt.Sprintf("%s  %s\n", hex.EncodeToString(archiveDigest[:]), archiveName))
+	checksumsDigest := sha256.Sum256(checksums)
+	signature, err := ecdsa.SignASN1(rand.Reader, key, checksumsDigest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	assets := map[string][]byte{
+		archiveName:                              archive,
+		selfupdate.ChecksumsName(releaseVersion): checksums,
+		selfupdate.SignatureName(releaseVersion): signature,
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/repos/notaryproject/notation/releases/latest":
+			var list []map[string]string
+			for name := range assets {
+				list = append(list, map[string]string{"name": name, "browser_download_url": "http://" + r.Host + "/download/" + name})
+			}
+			json.NewEncoder(w).Encode(map[string]any{"tag_name": "v" + releaseVersion, "assets": list})
+		case strings.HasPrefix(r.URL.Path, "/download/"):
+			if content, ok := assets[strings.TrimPrefix(r.URL.Path, "/download/")]; ok {
+				w.Write(content)
+				return
+			}
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestSelfUpdate(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := newReleaseServer(t, key, []byte("notation 1.1.0"))
+	client := &selfupdate.Client{BaseURL: server.URL, HTTPClient: server.Client()}
+	executable := filepath.Join(t.TempDir(), "notation")
+	if err := os.WriteFile(executable, []byte("notation 1.0.0"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("untrusted signing key", func(t *testing.T) {
+		otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = selfUpdate(context.Background(), &selfUpdateOpts{}, client, []crypto.PublicKey{&otherKey.PublicKey}, executable)
+		if err == nil || !strings.Contains(err.Error(), "does not match any release trust anchor") {
+			t.Fatalf("expected signature verification error, got %v", err)
+		}
+		if data, _ := os.ReadFile(executable); string(data) != "notation 1.0.0" {
+			t.Fatalf("expected the binary to be unchanged, got %q", data)
+		}
+	})
+
+	t.Run("update", func(t *testing.T) {
+		if err := selfUpdate(context.Background(), &selfUpdateOpts{}, client, []crypto.PublicKey{&key.PublicKey}, executable); err != nil {
+			t.Fatal(err)
+		}
+		if data, _ := os.ReadFile(executable); string(data) != "notation 1.1.0" {
+			t.Fatalf("expected the binary to be updated, got %q", data)
+		}
+	})
+}
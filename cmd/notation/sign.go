@@ -9,11 +9,22 @@ import (
 	"time"
 
 	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation-go/dir"
 	notationregistry "github.com/notaryproject/notation-go/registry"
+	"github.com/notaryproject/notation/internal/archive"
 	"github.com/notaryproject/notation/internal/cmd"
+	"github.com/notaryproject/notation/internal/color"
 	"github.com/notaryproject/notation/internal/envelope"
+	"github.com/notaryproject/notation/internal/events"
 	"github.com/notaryproject/notation/internal/experimental"
+	"github.com/notaryproject/notation/internal/platform"
+	"github.com/notaryproject/notation/internal/pqsig"
+	"github.com/notaryproject/notation/internal/provenance"
+	"github.com/notaryproject/notation/internal/revocation"
+	"github.com/notaryproject/notation/internal/signjob"
 	"github.com/notaryproject/notation/internal/slices"
+	"github.com/notaryproject/notation/internal/trailer"
+	"github.com/notaryproject/notation/pkg/configutil"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/spf13/cobra"
 )
@@ -30,14 +41,29 @@ var supportedSignatureManifest = []string{signatureManifestArtifact, signatureMa
 type signOpts struct {
 	cmd.LoggingFlagOpts
 	cmd.SignerFlagOpts
+	cmd.EventFlagOpts
 	SecureFlagOpts
 	expiry            time.Duration
 	pluginConfig      []string
 	userMetadata      []string
 	reference         string
+	references        []string
+	resume            string
 	signatureManifest string
 	ociLayout         bool
 	inputType         inputType
+	keepTagReference  bool
+	pqKey             string
+	ocspStaple        bool
+	provenance        bool
+	hashAlgorithm     string
+	platform          string
+	descriptorOut     string
+	recordTag         bool
+	dockerArchive     bool
+	timestampURL      string
+	timestampRootCert string
+	summaryTrailer    bool
 }
 
 func signCommand(opts *signOpts) *cobra.Command {
@@ -47,7 +73,7 @@ func signCommand(opts *signOpts) *cobra.Command {
 		}
 	}
 	command := &cobra.Command{
-		Use:   "sign [flags] <reference>",
+		Use:   "sign [flags] <reference>...",
 		Short: "Sign artifacts",
 		Long: `Sign artifacts
 
@@ -74,8 +100,35 @@ Example - [Experimental] Sign an OCI artifact referenced in an OCI layout
 Example - [Experimental] Sign an OCI artifact identified by a tag and referenced in an OCI layout
   notation sign --oci-layout "<oci_layout_path>:<tag>"
 
+Example - [Experimental] Sign an image in an archive created by "docker save", converting the archive to contain an OCI layout if created by Docker before 25
+  notation sign --oci-layout --docker-archive "<archive_path>:<tag>"
+
 Example - [Experimental] Sign an OCI artifact and use OCI artifact manifest to store the signature:
   notation sign --signature-manifest artifact <registry>/<repository>@<digest>
+
+Example - [Experimental] Sign an OCI artifact and push an additional post-quantum ML-DSA signature of the same payload, signed with the key generated by "notation key generate-mldsa":
+  notation sign --pq-key <mldsa_key_name> <registry>/<repository>@<digest>
+
+Example - [Experimental] Sign an OCI artifact and embed the OCSP responses of the signing certificate chain in the signature envelope:
+  notation sign --ocsp-staple --key <key_name> <registry>/<repository>@<digest>
+
+Example - [Experimental] Sign the linux/arm64 manifest of a multi-platform image rather than its image index:
+  notation sign --platform linux/arm64 <registry>/<repository>@<digest>
+
+Example - [Experimental] Sign an OCI artifact and require the signature payload to be hashed with SHA-384, e.g. in CNSA environments:
+  notation sign --hash-algorithm sha384 --key <key_name> <registry>/<repository>@<digest>
+
+Example - [Experimental] Sign an OCI artifact and write the descriptors of the artifact and the signature manifest to a file, e.g. to commit them to a GitOps repository:
+  notation sign --descriptor-out signature.json <registry>/<repository>@<digest>
+
+Example - [Experimental] Sign an OCI artifact identified by a tag and record the tag in the signature manifest, so that the tag it was signed under is known after the tag moves:
+  notation sign --record-tag <registry>/<repository>:<tag>
+
+Example - [Experimental] Sign an OCI artifact and embed an RFC 3161 timestamp of the signature, so that the signature stays valid after the signing certificate expires:
+  notation sign --timestamp-url https://timestamp.example.com --timestamp-root-cert tsa-root.pem <registry>/<repository>@<digest>
+
+Example - [Experimental] Sign multiple OCI artifacts in a job recording its progress in a job state file, so that a failed job resumes without signing the artifacts signed already:
+  notation sign --resume job.json <registry>/<repository>@<digest> <registry>/<repository>@<digest>
 `,
 		Args: func(cmd *cobra.Command, args []string) error {
 			if len(args) == 0 {
@@ -88,9 +141,37 @@ Example - [Experimental] Sign an OCI artifact and use OCI artifact manifest to s
 			if opts.ociLayout {
 				opts.inputType = inputTypeOCILayout
 			}
-			return experimental.CheckFlagsAndWarn(cmd, "signature-manifest", "oci-layout")
+			if opts.dockerArchive && !opts.ociLayout {
+				return errors.New("flag \"--docker-archive\" can only be used when flag \"--oci-layout\" is set")
+			}
+			opts.references = args
+			if opts.hashAlgorithm != "" {
+				if _, err := envelope.ParseHashAlgorithm(opts.hashAlgorithm); err != nil {
+					return err
+				}
+			}
+			if opts.platform != "" {
+				if _, err := platform.Parse(opts.platform); err != nil {
+					return err
+				}
+			}
+			if len(opts.references) > 1 {
+				if opts.descriptorOut != "" {
+					return errors.New("flag \"--descriptor-out\" cannot be used when signing multiple references")
+				}
+				if err := experimental.CheckAndWarn(func() (string, bool) {
+					return fmt.Sprintf("signing multiple references in %q", cmd.CommandPath()), true
+				}); err != nil {
+					return err
+				}
+			}
+			if err := experimental.CheckFlagsAndWarn(cmd, "signature-manifest", "oci-layout", "event-socket", "event-sink", "pq-key", "ocsp-staple", "provenance", "hash-algorithm", "platform", "descriptor-out", "resume", "record-tag", "docker-archive", "timestamp-url", "timestamp-root-cert"); err != nil {
+				return err
+			}
+			return resolveTimestampOpts(cmd, opts)
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.SecureFlagOpts.applyChangedFlags(cmd.Flags())
 			// sanity check
 			if !validateSignatureManifest(opts.signatureManifest) {
 				return fmt.Errorf("signature manifest must be one of the following %v but got %s", supportedSignatureManifest, opts.signatureManifest)
@@ -101,59 +182,367 @@ Example - [Experimental] Sign an OCI artifact and use OCI artifact manifest to s
 	opts.LoggingFlagOpts.ApplyFlags(command.Flags())
 	opts.SignerFlagOpts.ApplyFlagsToCommand(command)
 	opts.SecureFlagOpts.ApplyFlags(command.Flags())
+	opts.EventFlagOpts.ApplyFlags(command.Flags())
 	cmd.SetPflagExpiry(command.Flags(), &opts.expiry)
 	cmd.SetPflagPluginConfig(command.Flags(), &opts.pluginConfig)
 	command.Flags().StringVar(&opts.signatureManifest, "signature-manifest", signatureManifestImage, "[Experimental] manifest type for signature. options: \"image\", \"artifact\"")
 	cmd.SetPflagUserMetadata(command.Flags(), &opts.userMetadata, cmd.PflagUserMetadataSignUsage)
-	command.Flags().BoolVar(&opts.ociLayout, "oci-layout", false, "[Experimental] sign the artifact stored as OCI image layout")
-	experimental.HideFlags(command, "signature-manifest", "oci-layout")
+	command.Flags().BoolVar(&opts.ociLayout, "oci-layout", false, "[Experimental] sign the artifact stored as OCI image layout, in a directory or a tarball")
+	cmd.SetPflagKeepTagReference(command.Flags(), &opts.keepTagReference)
+	command.Flags().StringVar(&opts.pqKey, "pq-key", "", "[Experimental] name of the ML-DSA key generated by \"notation key generate-mldsa\", signing the payload of the signature with a post-quantum signature pushed alongside it")
+	command.Flags().StringVar(&opts.hashAlgorithm, "hash-algorithm", "", fmt.Sprintf("[Experimental] hash algorithm of the signature payload, the signing fails if the signing key does not hash with it. The hash algorithm is determined by the type and the size of the signing key. options: %s", strings.Join(envelope.HashAlgorithmNames(), ", ")))
+	command.Flags().StringVar(&opts.platform, "platform", "", "[Experimental] sign the manifest of the platform in the format of os/arch[/variant], e.g. linux/arm64, selected from the image index the reference resolves to, instead of the image index")
+	command.MarkFlagsMutuallyExclusive("platform", "keep-tag-reference")
+	command.Flags().BoolVar(&opts.provenance, "provenance", false, "[Experimental] record the notation version, the signing plugin and its version, and the fingerprint of the CI environment in the signed payload of the signature")
+	command.Flags().StringVar(&opts.descriptorOut, "descriptor-out", "", "[Experimental] write the OCI descriptors of the signed artifact and the pushed signature manifest in JSON to the file")
+	command.Flags().StringVar(&opts.resume, "resume", "", "[Experimental] job state file recording the references signed successfully, a failed job run again with it only signs the references not signed yet")
+	command.Flags().BoolVar(&opts.dockerArchive, "docker-archive", false, "[Experimental] accept an archive created by \"docker save\" of Docker before 25 as the OCI layout tarball, converting it to the format of Docker 25 and later which contains an OCI layout, can only be used when flag \"--oci-layout\" is set")
+	command.Flags().BoolVar(&opts.recordTag, "record-tag", false, fmt.Sprintf("[Experimental] record the tag the reference is resolved from at signing time in the annotation %q of the signature manifest, ignored for digest references", annotationSignedTag))
+	command.Flags().StringVar(&opts.timestampURL, "timestamp-url", "", "[Experimental] URL of the RFC 3161 timestamp authority countersigning the signature with a timestamp embedded in the signature envelope, defaults to \"timestampURL\" of config.json")
+	command.Flags().StringVar(&opts.timestampRootCert, "timestamp-root-cert", "", "[Experimental] path of the PEM or DER file of the root certificates of the timestamp authority, the timestamp is verified with them before the signature is pushed, defaults to \"timestampRootCert\" of config.json")
+	setFlagSummaryTrailer(command.Flags(), &opts.summaryTrailer)
+	experimental.HideFlags(command, "signature-manifest", "oci-layout", "event-socket", "event-sink", "pq-key", "ocsp-staple", "provenance", "hash-algorithm", "platform", "descriptor-out", "resume", "record-tag", "docker-archive", "timestamp-url", "timestamp-root-cert")
 	return command
 }
 
-func runSign(command *cobra.Command, cmdOpts *signOpts) error {
+func runSign(command *cobra.Command, cmdOpts *signOpts) (err error) {
 	// set log level
 	ctx := cmdOpts.LoggingFlagOpts.SetLoggerLevel(command.Context())
+	if cmdOpts.summaryTrailer {
+		trailer.FromContext(ctx).Enable()
+	}
+
+	// set up event streaming
+	ctx, emitter, err := cmdOpts.EventFlagOpts.OpenEventEmitter(ctx, "sign")
+	if err != nil {
+		return err
+	}
+	defer emitter.Close()
+	var jobReference string
+	if len(cmdOpts.references) == 1 {
+		jobReference = cmdOpts.reference
+	}
+	emitter.Emit(events.Event{Type: events.TypeStarted, Reference: jobReference})
+	defer func() {
+		emitter.Completed(jobReference, err)
+	}()
+
+	for i, reference := range cmdOpts.references {
+		if cmdOpts.references[i], err = expandAlias(cmdOpts.inputType, reference); err != nil {
+			return err
+		}
+	}
+	cmdOpts.reference = cmdOpts.references[0]
+
+	// test keys cannot sign artifacts in production registries, which does not
+	// apply to on-demand keys
+	onDemandKey := cmdOpts.KeyID != "" && cmdOpts.PluginName != "" && cmdOpts.Key == ""
+	if cmdOpts.inputType == inputTypeRegistry && !onDemandKey {
+		for _, reference := range cmdOpts.references {
+			if err := configutil.CheckKeyPurpose(cmdOpts.Key, reference); err != nil {
+				return err
+			}
+		}
+	}
+	// keys are only allowed to sign within their validity windows, which does
+	// not apply to on-demand keys either
+	if !onDemandKey {
+		if err := checkSigningKeyValidity(cmdOpts.Key); err != nil {
+			return err
+		}
+	}
 
 	// initialize
-	signer, err := cmd.GetSigner(ctx, &cmdOpts.SignerFlagOpts)
+	var signer notation.Signer
+	if cmdOpts.ocspStaple {
+		signer, err = cmd.GetOCSPStaplingSigner(ctx, &cmdOpts.SignerFlagOpts, revocation.NewChecker(revocation.Options{}))
+	} else {
+		signer, err = cmd.GetSigner(ctx, &cmdOpts.SignerFlagOpts)
+	}
+	if err != nil {
+		return err
+	}
+	if cmdOpts.hashAlgorithm != "" {
+		hash, err := envelope.ParseHashAlgorithm(cmdOpts.hashAlgorithm)
+		if err != nil {
+			return err
+		}
+		signer = cmd.NewHashAlgorithmSigner(signer, hash)
+	}
+	if cmdOpts.timestampURL != "" {
+		roots, err := readTimestampRoots(cmdOpts.timestampRootCert)
+		if err != nil {
+			return err
+		}
+		signer = cmd.NewTimestampSigner(signer, &archive.HTTPTimestamper{URL: cmdOpts.timestampURL}, roots)
+	}
+	var pqSigner *pqsig.Signer
+	if cmdOpts.pqKey != "" {
+		pqKey, err := loadPQKey(cmdOpts.pqKey)
+		if err != nil {
+			return err
+		}
+		if pqSigner, err = pqsig.NewSigner(signer, pqKey); err != nil {
+			return err
+		}
+		signer = pqSigner
+	}
+	job, err := loadSignJob(cmdOpts, onDemandKey)
 	if err != nil {
 		return err
 	}
+
+	// core process
+	if len(cmdOpts.references) == 1 {
+		_, err := signArtifact(ctx, cmdOpts, cmdOpts.reference, signer, pqSigner, job)
+		return err
+	}
+	// the other references are still signed if a reference fails, and the
+	// failed references are signed again on resume
+	var signed, skipped, failed int
+	for _, reference := range cmdOpts.references {
+		skip, err := signArtifact(ctx, cmdOpts, reference, signer, pqSigner, job)
+		switch {
+		case err != nil:
+			fmt.Fprintf(os.Stderr, "%s %s: %v\n", color.Failure(os.Stderr, "Error:"), reference, err)
+			failed++
+		case skip:
+			skipped++
+		default:
+			signed++
+		}
+	}
+	fmt.Printf("Signed %d of %d references: %d signed, %d already signed by the job, %d failed\n", signed+skipped, len(cmdOpts.references), signed, skipped, failed)
+	if failed > 0 {
+		if cmdOpts.resume != "" {
+			return fmt.Errorf("failed to sign %d references, run the command again with flag \"--resume %s\" to retry them", failed, cmdOpts.resume)
+		}
+		return fmt.Errorf("failed to sign %d references", failed)
+	}
+	return nil
+}
+
+// resolveTimestampOpts sets the timestamp authority of opts to the one of
+// config.json unless flag "--timestamp-url" is set, and checks that the root
+// certificates of the timestamp authority are set along with its URL.
+func resolveTimestampOpts(command *cobra.Command, opts *signOpts) error {
+	if !command.Flags().Changed("timestamp-url") {
+		config, err := configutil.LoadCLIConfigOnce()
+		if err != nil {
+			return err
+		}
+		if config.TimestampURL != "" {
+			if err := experimental.CheckAndWarn(func() (string, bool) {
+				return fmt.Sprintf("timestampURL of %s", dir.PathConfigFile), true
+			}); err != nil {
+				return err
+			}
+			opts.timestampURL = config.TimestampURL
+			if !command.Flags().Changed("timestamp-root-cert") {
+				opts.timestampRootCert = config.TimestampRootCert
+			}
+		}
+	}
+	switch {
+	case opts.timestampURL == "":
+		if opts.timestampRootCert != "" {
+			return errors.New("flag \"--timestamp-root-cert\" can only be used when flag \"--timestamp-url\" is set")
+		}
+		return nil
+	case opts.timestampRootCert == "":
+		return errors.New("the root certificates of the timestamp authority are required to timestamp the signature, set flag \"--timestamp-root-cert\" or \"timestampRootCert\" of config.json")
+	}
+	if err := configutil.ValidateTimestampURL(opts.timestampURL); err != nil {
+		return fmt.Errorf("invalid timestamp authority URL: %w", err)
+	}
+	return nil
+}
+
+// loadSignJob loads the state of the signing job from the file of flag
+// "--resume", or a new state kept in memory if the flag is not set. The job is
+// bound to the signing key, so that it is never resumed with another key.
+func loadSignJob(opts *signOpts, onDemandKey bool) (*signjob.Job, error) {
+	if opts.resume == "" {
+		return signjob.Load("", "")
+	}
+	key := opts.Key
+	switch {
+	case onDemandKey:
+		key = opts.PluginName + ":" + opts.KeyID
+	case key == "":
+		defaultKey, err := configutil.ResolveKey("")
+		if err != nil {
+			return nil, err
+		}
+		key = defaultKey.Name
+	}
+	job, err := signjob.Load(opts.resume, key)
+	if err != nil {
+		return nil, err
+	}
+	if succeeded, failed := job.Count(); succeeded+failed > 0 {
+		fmt.Fprintf(os.Stderr, "Resuming signing job, %d references already signed, %d failed references to retry\n", succeeded, failed)
+	}
+	return job, nil
+}
+
+// signArtifact signs the artifact of reference and records the result in the
+// job. The artifact is skipped if the job has already signed it, i.e. if
+// skipped is true.
+func signArtifact(ctx context.Context, cmdOpts *signOpts, reference string, signer notation.Signer, pqSigner *pqsig.Signer, job *signjob.Job) (skipped bool, err error) {
+	// the helpers read the reference from the options
+	opts := *cmdOpts
+	opts.reference = reference
+	cmdOpts = &opts
+	emitter := events.FromContext(ctx)
+	var manifestDesc ocispec.Descriptor
+	recorder := &signatureRecorder{}
+	defer func() {
+		if skipped {
+			return
+		}
+		entry := signjob.Entry{
+			Reference: reference,
+			Digest:    manifestDesc.Digest.String(),
+			Result:    signjob.ResultSuccess,
+			Signature: recorder.manifestDesc.Digest.String(),
+		}
+		if err != nil {
+			entry.Result = signjob.ResultFailure
+			entry.Error = err.Error()
+		}
+		if recordErr := job.Record(entry); recordErr != nil && err == nil {
+			err = fmt.Errorf("failed to write job state: %w", recordErr)
+		}
+	}()
+
+	if cmdOpts.inputType == inputTypeOCILayout {
+		var closeLayout func(save bool) error
+		closeLayout, err = openOCILayoutTarball(cmdOpts.reference, cmdOpts.dockerArchive)
+		if err != nil {
+			return false, err
+		}
+		defer func() {
+			// the signature is written back to the tarball
+			if closeErr := closeLayout(err == nil && !skipped); closeErr != nil && err == nil {
+				err = closeErr
+			}
+		}()
+	}
 	ociImageManifest := cmdOpts.signatureManifest == signatureManifestImage
 	sigRepo, err := getRepositoryForSign(ctx, cmdOpts.inputType, cmdOpts.reference, &cmdOpts.SecureFlagOpts, ociImageManifest)
 	if err != nil {
-		return err
+		return false, err
 	}
 	signOpts, err := prepareSigningOpts(ctx, cmdOpts, sigRepo)
 	if err != nil {
-		return err
+		return false, err
 	}
 	manifestDesc, resolvedRef, err := resolveReference(ctx, cmdOpts.inputType, cmdOpts.reference, sigRepo, func(ref string, manifestDesc ocispec.Descriptor) {
-		fmt.Fprintf(os.Stderr, "Warning: Always sign the artifact using digest(@sha256:...) rather than a tag(:%s) because tags are mutable and a tag reference can point to a different artifact than the one signed.\n", ref)
+		fmt.Fprintf(os.Stderr, "%s Always sign the artifact using digest(@sha256:...) rather than a tag(:%s) because tags are mutable and a tag reference can point to a different artifact than the one signed.\n", color.Warning(os.Stderr, "Warning:"), ref)
 	})
 	if err != nil {
-		return err
+		return false, err
+	}
+	if cmdOpts.platform != "" {
+		manifestDesc, resolvedRef, err = resolvePlatform(ctx, cmdOpts.inputType, cmdOpts.reference, &cmdOpts.SecureFlagOpts, manifestDesc, resolvedRef, cmdOpts.platform)
+		if err != nil {
+			return false, err
+		}
+	}
+	if cmdOpts.keepTagReference {
+		resolvedRef = keepTagReference(cmdOpts.inputType, cmdOpts.reference, resolvedRef)
+	}
+	if job.Done(reference, manifestDesc.Digest.String()) {
+		emitter.Emit(events.Event{Type: events.TypeResult, Reference: resolvedRef, Digest: manifestDesc.Digest.String(), Result: events.ResultSkipped})
+		fmt.Println("Skipped", resolvedRef, "as it is already signed by the job")
+		return true, nil
+	}
+	recorder.Repository = sigRepo
+	if cmdOpts.recordTag {
+		if tag := referenceTag(cmdOpts.inputType, cmdOpts.reference); tag != "" {
+			recorder.annotations = map[string]string{annotationSignedTag: tag}
+		}
+	}
+	var subjectArtifactType string
+	if cmdOpts.descriptorOut != "" {
+		// the artifact type is fetched before signing, so that a failure does
+		// not leave a signature without descriptors behind
+		if subjectArtifactType, err = fetchManifestArtifactType(ctx, cmdOpts.inputType, cmdOpts.reference, &cmdOpts.SecureFlagOpts, manifestDesc); err != nil {
+			return false, fmt.Errorf("failed to fetch the artifact type of %s: %w", resolvedRef, err)
+		}
 	}
 	signOpts.ArtifactReference = manifestDesc.Digest.String()
+	emitter.Emit(events.Event{Type: events.TypeProgress, Stage: "resolved", Reference: resolvedRef, Digest: manifestDesc.Digest.String()})
 
-	// core process
-	_, err = notation.Sign(ctx, signer, sigRepo, signOpts)
+	// keys requiring a key ceremony only sign within an approved signing
+	// session, which is recorded in the audit trail before signing
+	onDemandKey := cmdOpts.KeyID != "" && cmdOpts.PluginName != "" && cmdOpts.Key == ""
+	if !onDemandKey {
+		if err := checkKeyCeremony(cmdOpts.Key, resolvedRef, time.Now()); err != nil {
+			return false, err
+		}
+	}
+
+	_, err = notation.Sign(ctx, signer, recorder, signOpts)
 	if err != nil {
 		var errorPushSignatureFailed notation.ErrorPushSignatureFailed
 		if errors.As(err, &errorPushSignatureFailed) {
 			if !ociImageManifest {
-				return fmt.Errorf("%v. Possible reason: OCI artifact manifest is not supported. Try removing the flag `--signature-manifest artifact` to store signatures using OCI image manifest", err)
+				return false, fmt.Errorf("%v. Possible reason: OCI artifact manifest is not supported. Try removing the flag `--signature-manifest artifact` to store signatures using OCI image manifest", err)
 			}
 			if strings.Contains(err.Error(), referrersTagSchemaDeleteError) {
-				fmt.Fprintln(os.Stderr, "Warning: Removal of outdated referrers index from remote registry failed. Garbage collection may be required.")
-				// write out
-				fmt.Println("Successfully signed", resolvedRef)
-				return nil
+				fmt.Fprintln(os.Stderr, color.Warning(os.Stderr, "Warning:"), "Removal of outdated referrers index from remote registry failed. Garbage collection may be required.")
+				err = nil
 			}
 		}
+		if err != nil {
+			emitter.Emit(events.Event{Type: events.TypeResult, Reference: resolvedRef, Digest: manifestDesc.Digest.String(), Result: events.ResultFailure, Error: err.Error()})
+			return false, err
+		}
+	}
+	trailer.FromContext(ctx).AddSignatures(1)
+	if pqSigner != nil {
+		if err := pushPQSignature(ctx, cmdOpts, pqSigner, manifestDesc); err != nil {
+			emitter.Emit(events.Event{Type: events.TypeResult, Reference: resolvedRef, Digest: manifestDesc.Digest.String(), Result: events.ResultFailure, Error: err.Error()})
+			return false, err
+		}
+	}
+	emitter.Emit(events.Event{Type: events.TypeResult, Reference: resolvedRef, Digest: manifestDesc.Digest.String(), Result: events.ResultSuccess})
+	fmt.Println(color.Success(os.Stdout, "Successfully signed"), resolvedRef)
+	if cmdOpts.descriptorOut != "" {
+		if recorder.manifestDesc.Digest == "" {
+			// the push is reported as failed if the outdated referrers
+			// index is not removed, without the signature manifest
+			fmt.Fprintln(os.Stderr, color.Warning(os.Stderr, "Warning:"), "Descriptors are not written because the descriptor of the signature manifest is unknown.")
+			return false, nil
+		}
+		subjectDesc := manifestDesc
+		subjectDesc.ArtifactType = subjectArtifactType
+		if err := writeSignDescriptors(cmdOpts.descriptorOut, newSignDescriptors(resolvedRef, subjectDesc, recorder.manifestDesc)); err != nil {
+			return false, err
+		}
+		fmt.Println("Wrote descriptors to", cmdOpts.descriptorOut)
+	}
+	return false, nil
+}
+
+// checkSigningKeyValidity checks that the signing key with the name is within
+// its validity window, warning about an upcoming expiry and about plaintext
+// secrets in its plugin config.
+func checkSigningKeyValidity(name string) error {
+	now := time.Now()
+	validity, err := configutil.CheckKeyValidity(name, now)
+	if err != nil {
 		return err
 	}
-	fmt.Println("Successfully signed", resolvedRef)
+	if validity.ExpiresWithin(now, configutil.KeyExpiryWarningPeriod) {
+		fmt.Fprintf(os.Stderr, "%s The signing key expires at %s, rotate it before then.\n", color.Warning(os.Stderr, "Warning:"), validity.NotAfter.Format(time.RFC3339))
+	}
+	// errors resolving the key are reported when the signer is created
+	if key, err := configutil.ResolveKey(name); err == nil && key.ExternalKey != nil {
+		warnPluginConfig(key.PluginConfig)
+	}
 	return nil
 }
 
@@ -166,10 +555,23 @@ func prepareSigningOpts(ctx context.Context, opts *signOpts, sigRepo notationreg
 	if err != nil {
 		return notation.SignOptions{}, err
 	}
+	warnPluginConfig(pluginConfig)
 	userMetadata, err := cmd.ParseFlagMap(opts.userMetadata, cmd.PflagUserMetadata.Name)
 	if err != nil {
 		return notation.SignOptions{}, err
 	}
+	if err := provenance.CheckUserMetadata(userMetadata); err != nil {
+		return notation.SignOptions{}, err
+	}
+	if opts.provenance {
+		pluginName, pluginVersion, err := cmd.GetSigningPlugin(ctx, &opts.SignerFlagOpts)
+		if err != nil {
+			return notation.SignOptions{}, err
+		}
+		if userMetadata, err = provenance.Merge(userMetadata, provenance.Collect(pluginName, pluginVersion, os.LookupEnv)); err != nil {
+			return notation.SignOptions{}, err
+		}
+	}
 	signOpts := notation.SignOptions{
 		SignerSignOptions: notation.SignerSignOptions{
 			SignatureMediaType: mediaType,
@@ -340,3 +340,110 @@ func TestSignCommand_MissingArgs(t *testing.T) {
 		t.Fatal("Parse Args expected error, but ok")
 	}
 }
+
+func TestSignCommand_EventSocket(t *testing.T) {
+	opts := &signOpts{}
+	command := signCommand(opts)
+	expected := &signOpts{
+		reference: "ref",
+		SignerFlagOpts: cmd.SignerFlagOpts{
+			Key:             "key",
+			SignatureFormat: envelope.JWS,
+		},
+		EventFlagOpts: cmd.EventFlagOpts{
+			EventSocket: "/run/notation/events.sock",
+		},
+		signatureManifest: signatureManifestImage,
+	}
+	if err := command.ParseFlags([]string{
+		expected.reference,
+		"--key", expected.Key,
+		"--event-socket", expected.EventSocket}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := command.Args(command, command.Flags().Args()); err != nil {
+		t.Fatalf("Parse args failed: %v", err)
+	}
+	if !reflect.DeepEqual(*expected, *opts) {
+		t.Fatalf("Expect sign opts: %v, got: %v", expected, opts)
+	}
+}
+
+func TestSignCommand_PQKey(t *testing.T) {
+	opts := &signOpts{}
+	command := signCommand(opts)
+	if err := command.ParseFlags([]string{"ref", "--pq-key", "pq"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if opts.pqKey != "pq" {
+		t.Fatalf("Expect pq key: %q, got: %q", "pq", opts.pqKey)
+	}
+}
+
+func TestSignCommand_HashAlgorithm(t *testing.T) {
+	t.Setenv("NOTATION_EXPERIMENTAL", "1")
+	opts := &signOpts{}
+	command := signCommand(opts)
+	if err := command.ParseFlags([]string{"ref", "--hash-algorithm", "sha384"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := command.PreRunE(command, command.Flags().Args()); err != nil {
+		t.Fatalf("PreRunE failed: %v", err)
+	}
+	if opts.hashAlgorithm != "sha384" {
+		t.Fatalf("Expect hash algorithm: %q, got: %q", "sha384", opts.hashAlgorithm)
+	}
+
+	command = signCommand(nil)
+	if err := command.ParseFlags([]string{"ref", "--hash-algorithm", "md5"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := command.PreRunE(command, command.Flags().Args()); err == nil {
+		t.Fatal("expected error for unsupported hash algorithm")
+	}
+}
+
+func TestSignCommand_DescriptorOut(t *testing.T) {
+	opts := &signOpts{}
+	command := signCommand(opts)
+	if err := command.ParseFlags([]string{"ref", "--descriptor-out", "signature.json"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if opts.descriptorOut != "signature.json" {
+		t.Fatalf("Expect descriptor out: %q, got: %q", "signature.json", opts.descriptorOut)
+	}
+}
+
+func TestSignCommand_Resume(t *testing.T) {
+	t.Setenv("NOTATION_EXPERIMENTAL", "1")
+	opts := &signOpts{}
+	command := signCommand(opts)
+	if err := command.ParseFlags([]string{"ref1", "ref2", "--resume", "job.json"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := command.PreRunE(command, command.Flags().Args()); err != nil {
+		t.Fatalf("PreRunE failed: %v", err)
+	}
+	if opts.resume != "job.json" || !reflect.DeepEqual(opts.references, []string{"ref1", "ref2"}) {
+		t.Fatalf("Expect resume %q and references [ref1 ref2], got: %q and %v", "job.json", opts.resume, opts.references)
+	}
+
+	// the descriptors of multiple references cannot be written to a file
+	command = signCommand(nil)
+	if err := command.ParseFlags([]string{"ref1", "ref2", "--descriptor-out", "signature.json"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := command.PreRunE(command, command.Flags().Args()); err == nil {
+		t.Fatal("expected error for flag --descriptor-out with multiple references")
+	}
+
+	// signing multiple references is experimental
+	t.Setenv("NOTATION_EXPERIMENTAL", "")
+	command = signCommand(nil)
+	if err := command.ParseFlags([]string{"ref1", "ref2"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := command.PreRunE(command, command.Flags().Args()); err == nil {
+		t.Fatal("expected error for multiple references without NOTATION_EXPERIMENTAL")
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/notaryproject/notation/internal/policy"
+	"github.com/notaryproject/notation/internal/revocation"
+	"github.com/spf13/pflag"
+)
+
+// checkTimeouts are the timeouts of the checks of verifications which depend
+// on slow endpoints or plugins, independent of each other so that a slow
+// check does not consume the time of the others.
+type checkTimeouts struct {
+	revocation         time.Duration
+	revocationEndpoint time.Duration
+	timestamp          time.Duration
+	plugin             time.Duration
+}
+
+func (t *checkTimeouts) applyFlags(fs *pflag.FlagSet) {
+	fs.DurationVar(&t.revocation, "revocation-timeout", revocation.DefaultTimeout, "[Experimental] timeout of the revocation check of the certificate chain of a signature")
+	fs.DurationVar(&t.revocationEndpoint, "revocation-endpoint-timeout", revocation.DefaultEndpointTimeout, "[Experimental] timeout of a single request to an OCSP responder or a CRL distribution point, within the timeout of flag \"--revocation-timeout\"")
+	fs.DurationVar(&t.timestamp, "timestamp-timeout", policy.DefaultTimestampTimeout, "[Experimental] timeout of validating the timestamp of a signature against the timestamp trust stores of the trust policy")
+	fs.DurationVar(&t.plugin, "plugin-timeout", 0, "[Experimental] timeout of a verification plugin verifying a signature, the plugin is terminated when exceeded, unlimited if 0")
+}
+
+// validate checks that the timeouts are not negative, and that the
+// revocation checks have time.
+func (t *checkTimeouts) validate() error {
+	for _, flag := range []struct {
+		name    string
+		timeout time.Duration
+	}{
+		{"revocation-timeout", t.revocation},
+		{"revocation-endpoint-timeout", t.revocationEndpoint},
+		{"timestamp-timeout", t.timestamp},
+		{"plugin-timeout", t.plugin},
+	} {
+		if flag.timeout < 0 {
+			return fmt.Errorf("flag %q must not be negative", "--"+flag.name)
+		}
+	}
+	if t.revocation == 0 || t.revocationEndpoint == 0 || t.timestamp == 0 {
+		return fmt.Errorf("flags %q, %q and %q must be positive", "--revocation-timeout", "--revocation-endpoint-timeout", "--timestamp-timeout")
+	}
+	return nil
+}
+
+// apply sets the timeouts of the verifier created with opts.
+func (t *checkTimeouts) apply(opts *policy.ConfigOptions) {
+	opts.RevocationTimeout = t.revocation
+	opts.RevocationEndpointTimeout = t.revocationEndpoint
+	opts.TimestampTimeout = t.timestamp
+	opts.PluginTimeout = t.plugin
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/notation/internal/policy"
+	"github.com/notaryproject/notation/internal/revocation"
+)
+
+// defaultCheckTimeouts are the timeouts of the flags by default.
+var defaultCheckTimeouts = checkTimeouts{
+	revocation:         revocation.DefaultTimeout,
+	revocationEndpoint: revocation.DefaultEndpointTimeout,
+	timestamp:          policy.DefaultTimestampTimeout,
+}
+
+func TestCheckTimeouts(t *testing.T) {
+	verifyOpts := &verifyOpts{}
+	command := verifyCommand(verifyOpts)
+	if err := command.ParseFlags([]string{"--revocation-timeout", "5s", "--plugin-timeout", "30s"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyOpts.timeouts.validate(); err != nil {
+		t.Fatal(err)
+	}
+	var opts policy.ConfigOptions
+	verifyOpts.timeouts.apply(&opts)
+	if opts.RevocationTimeout != 5*time.Second || opts.RevocationEndpointTimeout != 2*time.Second || opts.TimestampTimeout != policy.DefaultTimestampTimeout || opts.PluginTimeout != 30*time.Second {
+		t.Fatalf("unexpected timeouts %+v", opts)
+	}
+
+	for _, tt := range []struct {
+		timeouts checkTimeouts
+		want     string
+	}{
+		{timeouts: checkTimeouts{revocation: -time.Second, revocationEndpoint: time.Second, timestamp: time.Second}, want: "--revocation-timeout"},
+		{timeouts: checkTimeouts{revocation: time.Second, revocationEndpoint: time.Second, timestamp: time.Second, plugin: -time.Second}, want: "--plugin-timeout"},
+		{timeouts: checkTimeouts{revocation: time.Second, timestamp: time.Second}, want: "must be positive"},
+	} {
+		if err := tt.timeouts.validate(); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("validate() of %+v error = %v, want %q", tt.timeouts, err, tt.want)
+		}
+	}
+}
@@ -1,34 +1,87 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"math"
 	"os"
 	"reflect"
+	"time"
 
 	"github.com/notaryproject/notation-go"
-	"github.com/notaryproject/notation-go/verifier"
+	"github.com/notaryproject/notation-go/dir"
+	notationregistry "github.com/notaryproject/notation-go/registry"
 	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+	"github.com/notaryproject/notation/cmd/notation/internal/integrity"
+	"github.com/notaryproject/notation/internal/chaos"
 	"github.com/notaryproject/notation/internal/cmd"
+	"github.com/notaryproject/notation/internal/color"
+	"github.com/notaryproject/notation/internal/events"
+	"github.com/notaryproject/notation/internal/evidence"
 	"github.com/notaryproject/notation/internal/experimental"
 	"github.com/notaryproject/notation/internal/ioutil"
+	"github.com/notaryproject/notation/internal/metadata"
+	"github.com/notaryproject/notation/internal/metrics"
+	"github.com/notaryproject/notation/internal/ocilayout"
+	"github.com/notaryproject/notation/internal/parallel"
+	"github.com/notaryproject/notation/internal/platform"
+	"github.com/notaryproject/notation/internal/policy"
+	"github.com/notaryproject/notation/internal/retry"
+	"github.com/notaryproject/notation/internal/revocation"
+	"github.com/notaryproject/notation/internal/trailer"
+	"github.com/notaryproject/notation/internal/version"
+	"github.com/notaryproject/notation/pkg/configutil"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
 
 	"github.com/spf13/cobra"
 )
 
+// maxSignatureAttempts is the maximum number of signatures evaluated per
+// artifact if neither flag "--max-signature-attempts" nor config.json bounds
+// it, i.e. unlimited.
 const maxSignatureAttempts = math.MaxInt64
 
 type verifyOpts struct {
 	cmd.LoggingFlagOpts
+	cmd.EventFlagOpts
 	SecureFlagOpts
 	reference        string
 	pluginConfig     []string
 	userMetadata     []string
 	ociLayout        bool
 	trustPolicyScope string
+	dockerArchive    bool
 	inputType        inputType
+	useMarker        bool
+	force            bool
+	allTags          bool
+	checkpoint       string
+	metricsTextfile  string
+	qps              float64
+	paranoid         bool
+	keepTagReference bool
+	evidenceOut      string
+	evidenceKey      string
+	envelope         string
+	descriptor       string
+	bundle           string
+	trustStores      []string
+	platform         string
+	recursive        bool
+	policyName       string
+	outputFormat     string
+	clockSkew        time.Duration
+	refreshCRL       bool
+	revocationBundle string
+	timeouts         checkTimeouts
+	maxAttempts      int
+	concurrency      int
+	fetchRetries     int
+	dryRun           bool
+	chaos            chaos.Config
+	summaryTrailer   bool
 }
 
 func verifyCommand(opts *verifyOpts) *cobra.Command {
@@ -55,9 +108,70 @@ Example - [Experimental] Verify a signature on an OCI artifact referenced in an
 
 Example - [Experimental] Verify a signature on an OCI artifact identified by a tag and referenced in an OCI layout using trust policy statement specified by scope.
   notation verify --oci-layout <registry>/<repository>:<tag> --scope <trust_policy_scope>
+
+Example - [Experimental] Verify a signature on an image in an archive created by "docker save" using trust policy statement specified by scope.
+  notation verify --oci-layout --docker-archive "<archive_path>:<tag>" --scope <trust_policy_scope>
+
+Example - [Experimental] Verify a signature on an OCI artifact referenced in an OCI layout and record a verification marker, skipping the verification if the artifact is unchanged since the last recorded verification.
+  notation verify --oci-layout <registry>/<repository>@<digest> --scope <trust_policy_scope> --verification-marker
+
+Example - [Experimental] Verify all tagged artifacts in a repository, limiting registry requests to 5 per second and recording progress in a checkpoint file to resume an interrupted audit.
+  notation verify --all-tags --qps 5 --checkpoint audit.json <registry>/<repository>
+
+Example - [Experimental] Verify all tagged artifacts in a repository and write the numbers of artifacts verified and failed for the textfile collector of the Prometheus node exporter.
+  notation verify --all-tags --metrics-textfile /var/lib/node_exporter/textfile/notation.prom <registry>/<repository>
+
+Example - [Experimental] Verify all tagged artifacts in a repository and export the results as CSV.
+  notation verify --all-tags --output csv <registry>/<repository> > audit.csv
+
+Example - [Experimental] Verify a signature on an OCI artifact, fetching the artifact manifest and signature manifests again to detect tampering by the registry or a proxy.
+  notation verify --paranoid <registry>/<repository>@<digest>
+
+Example - [Experimental] Verify a signature on an OCI artifact and write the verification evidence signed with the key "auditor" for auditors.
+  notation verify --evidence-out evidence.zip --evidence-key auditor <registry>/<repository>@<digest>
+
+Example - [Experimental] Verify a raw signature envelope against an OCI descriptor without accessing the registry, using the trust policy statement of the repository.
+  notation verify --envelope signature.jws --descriptor descriptor.json <registry>/<repository>
+
+Example - [Experimental] Verify the signatures of a bundle exported by "notation export-bundle" without accessing the registry.
+  notation verify --bundle net-monitor.bundle.tgz
+
+Example - [Experimental] Verify a signature on an OCI artifact against candidate root certificates in a local directory instead of the trust store "acme-rootcas" of type "ca".
+  notation verify --trust-store ca:acme-rootcas=./candidate-roots <registry>/<repository>@<digest>
+
+Example - Verify a signature on an OCI artifact without sending any credential to the registry, failing if the registry requires authentication:
+  notation verify --anonymous <registry>/<repository>@<digest>
+
+Example - Verify a signature on an OCI artifact and output the result in JSON, including the outcome of every signature verified:
+  notation verify --output json <registry>/<repository>@<digest>
+
+Example - Verify a signature on an OCI artifact and output the result in SARIF for security dashboards:
+  notation verify --output sarif <registry>/<repository>@<digest> > notation.sarif
+
+Example - [Experimental] Verify a signature on the linux/arm64 manifest of a multi-platform image rather than on its image index.
+  notation verify --platform linux/arm64 <registry>/<repository>@<digest>
+
+Example - [Experimental] Verify a multi-platform image and the manifests of all its platforms:
+  notation verify --recursive <registry>/<repository>@<digest>
+
+Example - Verify a signature on an OCI artifact, evaluating at most 50 of its signatures:
+  notation verify --max-signature-attempts 50 <registry>/<repository>@<digest>
+
+Example - [Experimental] Verify a signature on an OCI artifact with many signatures, verifying up to 8 signatures at the same time:
+  notation verify --concurrency 8 <registry>/<repository>@<digest>
+
+Example - [Experimental] Verify a signature on an OCI artifact on a host whose clock may be off by up to 5 minutes.
+  notation verify --clock-skew-tolerance 5m <registry>/<repository>@<digest>
+
+Example - [Experimental] Show the trust policy statement, trust stores and checks which would verify an OCI artifact, without fetching its signatures.
+  notation verify --dry-run <registry>/<repository>:<tag>
 `,
 		Args: func(cmd *cobra.Command, args []string) error {
 			if len(args) == 0 {
+				if opts.bundle != "" {
+					// the reference recorded in the bundle is verified
+					return nil
+				}
 				return errors.New("missing reference")
 			}
 			opts.reference = args[0]
@@ -67,76 +181,562 @@ Example - [Experimental] Verify a signature on an OCI artifact identified by a t
 			if opts.ociLayout {
 				opts.inputType = inputTypeOCILayout
 			}
-			return experimental.CheckFlagsAndWarn(cmd, "oci-layout", "scope")
+			if opts.dockerArchive && !opts.ociLayout {
+				return errors.New("flag \"--docker-archive\" can only be used when flag \"--oci-layout\" is set")
+			}
+			if opts.useMarker && !opts.ociLayout {
+				return errors.New("flag \"--verification-marker\" can only be used when flag \"--oci-layout\" is set")
+			}
+			if opts.force && !opts.useMarker {
+				return errors.New("flag \"--force\" can only be used when flag \"--verification-marker\" is set")
+			}
+			if !opts.allTags && (opts.checkpoint != "" || opts.qps != 0) {
+				return errors.New("flags \"--checkpoint\" and \"--qps\" can only be used when flag \"--all-tags\" is set")
+			}
+			if opts.metricsTextfile != "" {
+				if !opts.allTags {
+					return errors.New("flag \"--metrics-textfile\" can only be used when flag \"--all-tags\" is set")
+				}
+				if err := metrics.ValidateTextfilePath(opts.metricsTextfile); err != nil {
+					return fmt.Errorf("invalid flag \"--metrics-textfile\": %w", err)
+				}
+			}
+			if opts.platform != "" {
+				if _, err := platform.Parse(opts.platform); err != nil {
+					return err
+				}
+			}
+			if opts.qps < 0 {
+				return errors.New("flag \"--qps\" must not be negative")
+			}
+			if opts.concurrency < 1 {
+				return errors.New("flag \"--concurrency\" must be at least 1")
+			}
+			if opts.maxAttempts < 0 {
+				return errors.New("flag \"--max-signature-attempts\" must not be negative")
+			}
+			if opts.fetchRetries < 0 {
+				return errors.New("flag \"--fetch-retries\" must not be negative")
+			}
+			if opts.refreshCRL && opts.revocationBundle != "" {
+				return errors.New("flag \"--refresh-crl\" cannot be used with flag \"--revocation-bundle\", as no CRL is fetched")
+			}
+			if err := opts.timeouts.validate(); err != nil {
+				return err
+			}
+			if err := policy.ValidateClockSkewTolerance(opts.clockSkew); err != nil {
+				return fmt.Errorf("invalid flag \"--clock-skew-tolerance\": %w", err)
+			}
+			if opts.evidenceKey != "" && opts.evidenceOut == "" {
+				return errors.New("flag \"--evidence-key\" can only be used when flag \"--evidence-out\" is set")
+			}
+			if opts.evidenceOut != "" && opts.evidenceKey == "" {
+				// the evidence must not be signed with the artifact signing
+				// key by accident
+				return errors.New("flag \"--evidence-key\" is required when flag \"--evidence-out\" is set")
+			}
+			return experimental.CheckFlagsAndWarn(cmd, "oci-layout", "scope", "verification-marker", "force", "all-tags", "checkpoint", "metrics-textfile", "qps", "paranoid", "evidence-out", "evidence-key", "envelope", "descriptor", "bundle", "event-socket", "event-sink", "trust-store", "platform", "policy-name", "clock-skew-tolerance", "concurrency", "dry-run", "recursive", "chaos-registry-latency", "chaos-ocsp-failure", "chaos-corrupt-signature", "docker-archive", "refresh-crl", "revocation-bundle", "revocation-timeout", "revocation-endpoint-timeout", "timestamp-timeout", "plugin-timeout", "fetch-retries")
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.SecureFlagOpts.applyChangedFlags(cmd.Flags())
 			return runVerify(cmd, opts)
 		},
 	}
 	opts.LoggingFlagOpts.ApplyFlags(command.Flags())
 	opts.SecureFlagOpts.ApplyFlags(command.Flags())
+	opts.EventFlagOpts.ApplyFlags(command.Flags())
+	setFlagAnonymous(command.Flags(), &opts.Anonymous)
 	command.Flags().StringArrayVar(&opts.pluginConfig, "plugin-config", nil, "{key}={value} pairs that are passed as it is to a plugin, if the verification is associated with a verification plugin, refer plugin documentation to set appropriate values")
 	cmd.SetPflagUserMetadata(command.Flags(), &opts.userMetadata, cmd.PflagUserMetadataVerifyUsage)
-	command.Flags().BoolVar(&opts.ociLayout, "oci-layout", false, "[Experimental] verify the artifact stored as OCI image layout")
+	cmd.SetPflagOutput(command.Flags(), &opts.outputFormat, fmt.Sprintf("output format, options: '%s', '%s', '%s', or '%s' when flag \"--all-tags\" is set", cmd.OutputJSON, cmd.OutputSARIF, cmd.OutputPlaintext, cmd.OutputCSV))
+	command.Flags().IntVar(&opts.maxAttempts, "max-signature-attempts", 0, "maximum number of signatures fetched and evaluated per artifact, overriding \"maxSignatureAttempts\" of config.json, unlimited if neither is set")
+	// the retries are off unless experimental is enabled
+	fetchRetries := 0
+	if !experimental.IsDisabled() {
+		fetchRetries = retry.DefaultMaxRetries
+	}
+	command.Flags().IntVar(&opts.fetchRetries, "fetch-retries", fetchRetries, "[Experimental] maximum number of times a signature manifest or blob failing with a server error or a timeout is fetched again before the signature is reported unverifiable, 0 disables the retries")
+	command.Flags().IntVar(&opts.concurrency, "concurrency", 1, "[Experimental] maximum number of signatures of the artifact fetched and verified at the same time, the first signature in the listing order verified successfully is reported regardless")
+	command.Flags().BoolVar(&opts.ociLayout, "oci-layout", false, "[Experimental] verify the artifact stored as OCI image layout, in a directory or a tarball")
 	command.Flags().StringVar(&opts.trustPolicyScope, "scope", "", "[Experimental] set trust policy scope for artifact verification, required and can only be used when flag \"--oci-layout\" is set")
+	command.Flags().BoolVar(&opts.dockerArchive, "docker-archive", false, "[Experimental] accept an archive created by \"docker save\" of Docker before 25 as the OCI layout tarball, converting it to the format of Docker 25 and later which contains an OCI layout, can only be used when flag \"--oci-layout\" is set")
+	command.Flags().BoolVar(&opts.useMarker, "verification-marker", false, "[Experimental] record successful verification as a marker in the OCI layout index and skip verification if the artifact, its signatures, the trust policy, the trust store and the verification options are unchanged, can only be used when flag \"--oci-layout\" is set")
+	command.Flags().BoolVar(&opts.force, "force", false, "[Experimental] verify the artifact even if an up-to-date verification marker is found, can only be used when flag \"--verification-marker\" is set")
+	command.Flags().BoolVar(&opts.allTags, "all-tags", false, "[Experimental] verify all tagged artifacts in the repository")
+	command.Flags().StringVar(&opts.checkpoint, "checkpoint", "", "[Experimental] file recording the progress of flag \"--all-tags\", an interrupted verification resumes from it")
+	command.Flags().StringVar(&opts.metricsTextfile, "metrics-textfile", "", "[Experimental] file with the extension \".prom\" to write the numbers of artifacts verified and failed and the duration of flag \"--all-tags\" to, in the text format of Prometheus for the textfile collector of the node exporter")
+	command.Flags().Float64Var(&opts.qps, "qps", 0, "[Experimental] maximum number of registry requests per second when flag \"--all-tags\" is set, no limit if 0")
+	cmd.SetPflagKeepTagReference(command.Flags(), &opts.keepTagReference)
+	command.Flags().BoolVar(&opts.paranoid, "paranoid", false, "[Experimental] fetch the artifact manifest and signature manifests again and check them against their descriptors, signature blobs are always checked")
+	command.Flags().StringVar(&opts.evidenceOut, "evidence-out", "", "[Experimental] write the verification evidence as a zip archive to the file after a successful verification")
+	command.Flags().StringVar(&opts.evidenceKey, "evidence-key", "", "[Experimental] name of the key signing the summary of the verification evidence, required if flag \"--evidence-out\" is set. Use a dedicated key rather than an artifact signing key, so that the evidence is not mistaken for an artifact signature")
+	command.Flags().StringVar(&opts.envelope, "envelope", "", "[Experimental] file of a raw signature envelope to verify against the descriptor of flag \"--descriptor\" without accessing the registry, the reference is the repository of the artifact for selecting the trust policy statement")
+	command.Flags().StringVar(&opts.bundle, "bundle", "", "[Experimental] file of a bundle exported by \"notation export-bundle\" to verify the signatures of without accessing the registry, the reference is optional and selects the trust policy statement by its repository instead of the reference recorded in the bundle")
+	command.Flags().StringVar(&opts.descriptor, "descriptor", "", "[Experimental] file of the OCI descriptor in JSON of the artifact signed by the envelope of flag \"--envelope\"")
+	command.Flags().StringArrayVar(&opts.trustStores, "trust-store", nil, "[Experimental] {type}:{name}={dir} pairs that read the certificates of the named trust store from the directory instead of the trust store in the notation config directory for this verification, e.g. ca:acme-rootcas=./candidate-roots")
+	command.Flags().StringVar(&opts.policyName, "policy-name", "", "[Experimental] name of the trust policy document in the \"trustpolicy.d\" directory of the notation config directory to verify against, e.g. \"prod\" for \"trustpolicy.d/prod.json\", instead of \"trustpolicy.json\"")
+	command.Flags().DurationVar(&opts.clockSkew, "clock-skew-tolerance", 0, fmt.Sprintf("[Experimental] duration by which the clock of this host may be off when checking the expiry of signatures and the validity of their certificates, at most %v, overriding the \"clockSkewTolerance\" of the trust policy statements, e.g. 5m", policy.MaxClockSkewTolerance))
+	command.Flags().BoolVar(&opts.refreshCRL, "refresh-crl", false, "[Experimental] fetch the CRLs of the revocation checks again instead of using the cached CRLs, refreshing the CRL cache")
+	command.Flags().StringVar(&opts.revocationBundle, "revocation-bundle", "", "[Experimental] directory of CRLs (*.crl) and OCSP responses (*.ocsp) downloaded in advance, checking the revocation status of the certificates against them without requests to OCSP responders and CRL distribution points, for network-isolated environments")
+	opts.timeouts.applyFlags(command.Flags())
+	setFlagSummaryTrailer(command.Flags(), &opts.summaryTrailer)
+	command.Flags().BoolVar(&opts.dryRun, "dry-run", false, "[Experimental] resolve the reference and print the trust policy statement, trust stores and checks which would be applied, without fetching or verifying any signature")
+	command.Flags().StringVar(&opts.platform, "platform", "", "[Experimental] verify the manifest of the platform in the format of os/arch[/variant], e.g. linux/arm64, selected from the image index the reference resolves to, instead of the image index")
+	command.Flags().BoolVar(&opts.recursive, "recursive", false, "[Experimental] if the reference resolves to an image index, also verify the signatures of the manifest of every platform of the image index and report the result per platform")
+	// chaos mode is for testing integrations only, so it is never shown
+	command.Flags().DurationVar(&opts.chaos.RegistryLatency, "chaos-registry-latency", 0, "[Experimental] inject the latency into every registry request, for testing integrations")
+	command.Flags().BoolVar(&opts.chaos.OCSPFailure, "chaos-ocsp-failure", false, "[Experimental] fail every request to OCSP responders, for testing integrations")
+	command.Flags().BoolVar(&opts.chaos.CorruptSignature, "chaos-corrupt-signature", false, "[Experimental] corrupt every signature envelope fetched from the registry, for testing integrations")
+	for _, name := range []string{"chaos-registry-latency", "chaos-ocsp-failure", "chaos-corrupt-signature"} {
+		command.Flags().MarkHidden(name)
+	}
 	command.MarkFlagsRequiredTogether("oci-layout", "scope")
-	experimental.HideFlags(command, "oci-layout", "scope")
+	command.MarkFlagsRequiredTogether("envelope", "descriptor")
+	for _, name := range []string{"oci-layout", "all-tags", "paranoid", "verification-marker", "keep-tag-reference"} {
+		command.MarkFlagsMutuallyExclusive("envelope", name)
+	}
+	for _, name := range []string{"envelope", "oci-layout", "all-tags", "paranoid", "verification-marker", "keep-tag-reference", "platform", "recursive", "dry-run"} {
+		command.MarkFlagsMutuallyExclusive("bundle", name)
+	}
+	for _, name := range []string{flagUsername.Name, flagPassword.Name, flagHeader.Name} {
+		command.MarkFlagsMutuallyExclusive(flagAnonymous.Name, name)
+	}
+	command.MarkFlagsMutuallyExclusive("oci-layout", "all-tags")
+	command.MarkFlagsMutuallyExclusive("evidence-out", "all-tags")
+	command.MarkFlagsMutuallyExclusive("trust-store", "verification-marker")
+	for _, name := range []string{"envelope", "all-tags", "verification-marker", "evidence-out", "paranoid"} {
+		command.MarkFlagsMutuallyExclusive("dry-run", name)
+	}
+	for _, name := range []string{"envelope", "all-tags", "keep-tag-reference"} {
+		command.MarkFlagsMutuallyExclusive("platform", name)
+	}
+	for _, name := range []string{"envelope", "all-tags", "platform", "keep-tag-reference", "dry-run", "verification-marker", "evidence-out"} {
+		command.MarkFlagsMutuallyExclusive("recursive", name)
+	}
+	experimental.HideFlags(command, "oci-layout", "scope", "verification-marker", "force", "all-tags", "checkpoint", "metrics-textfile", "qps", "paranoid", "evidence-out", "evidence-key", "envelope", "descriptor", "bundle", "event-socket", "event-sink", "trust-store", "platform", "policy-name", "clock-skew-tolerance", "concurrency", "dry-run", "recursive", "docker-archive", "refresh-crl", "revocation-timeout", "revocation-endpoint-timeout", "timestamp-timeout", "plugin-timeout", "revocation-bundle", "fetch-retries")
 	return command
 }
 
-func runVerify(command *cobra.Command, opts *verifyOpts) error {
+func runVerify(command *cobra.Command, opts *verifyOpts) (err error) {
 	// set log level
 	ctx := opts.LoggingFlagOpts.SetLoggerLevel(command.Context())
+	summary := trailer.FromContext(ctx)
+	if opts.summaryTrailer {
+		summary.Enable()
+	}
+
+	if opts.outputFormat != cmd.OutputJSON && opts.outputFormat != cmd.OutputSARIF && opts.outputFormat != cmd.OutputPlaintext && opts.outputFormat != cmd.OutputCSV {
+		return fmt.Errorf("unrecognized output format %s", opts.outputFormat)
+	}
+	if opts.outputFormat == cmd.OutputCSV && !opts.allTags {
+		return fmt.Errorf("output format %s requires flag \"--all-tags\"", cmd.OutputCSV)
+	}
+	if opts.outputFormat == cmd.OutputCSV && opts.EventSink == "-" {
+		return fmt.Errorf("events cannot be sent to stdout when the output format is %s", cmd.OutputCSV)
+	}
+	if isStructuredOutput(opts.outputFormat) && (opts.allTags || opts.evidenceOut != "") {
+		// the output of flags "--all-tags" and "--evidence-out" would break
+		// the JSON output
+		return fmt.Errorf("flags \"--all-tags\" and \"--evidence-out\" cannot be used when the output format is %s", opts.outputFormat)
+	}
+	if isStructuredOutput(opts.outputFormat) && opts.EventSink == "-" {
+		return fmt.Errorf("events cannot be sent to stdout when the output format is %s", opts.outputFormat)
+	}
+	if opts.outputFormat == cmd.OutputSARIF && opts.dryRun {
+		return fmt.Errorf("flag \"--dry-run\" cannot be used when the output format is %s", cmd.OutputSARIF)
+	}
+
+	// set up event streaming
+	ctx, emitter, err := opts.EventFlagOpts.OpenEventEmitter(ctx, "verify")
+	if err != nil {
+		return err
+	}
+	defer emitter.Close()
+	emitter.Emit(events.Event{Type: events.TypeStarted, Reference: opts.reference})
+	defer func() {
+		emitter.Completed(opts.reference, err)
+	}()
+	if opts.Anonymous {
+		defer func() {
+			err = anonymousAccessError(err)
+		}()
+	}
+
+	// set up failure injection
+	if opts.chaos.Enabled() {
+		fmt.Fprintf(os.Stderr, "%s chaos mode is enabled, failures are injected into the verification\n", color.Warning(os.Stderr, "Warning:"))
+		ctx = chaos.WithConfig(ctx, &opts.chaos)
+	}
 
 	// initialize
-	verifier, err := verifier.NewFromConfig()
+	if opts.reference, err = expandAlias(opts.inputType, opts.reference); err != nil {
+		return err
+	}
+	if opts.inputType == inputTypeOCILayout {
+		var closeLayout func(save bool) error
+		closeLayout, err = openOCILayoutTarball(opts.reference, opts.dockerArchive)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			// the verification markers are written back to the tarball
+			if closeErr := closeLayout(opts.useMarker && err == nil); closeErr != nil && err == nil {
+				err = closeErr
+			}
+		}()
+	}
+	var trustStoreOverrides []policy.TrustStoreOverride
+	for _, value := range opts.trustStores {
+		override, err := policy.ParseTrustStoreOverride(value)
+		if err != nil {
+			return err
+		}
+		trustStoreOverrides = append(trustStoreOverrides, override)
+	}
+	configOpts := policy.ConfigOptions{
+		Name:                opts.policyName,
+		TrustStoreOverrides: trustStoreOverrides,
+		CRLCache:            newCRLCache(ctx, opts.refreshCRL),
+	}
+	opts.timeouts.apply(&configOpts)
+	if opts.revocationBundle != "" {
+		if configOpts.RevocationBundle, err = revocation.LoadBundle(opts.revocationBundle); err != nil {
+			return err
+		}
+	}
+	if command.Flags().Changed("clock-skew-tolerance") {
+		configOpts.ClockSkewTolerance = &opts.clockSkew
+	}
+	policyVerifier, err := policy.NewVerifierFromConfigOptions(configOpts)
 	if err != nil {
 		return err
 	}
+	warnTrustPolicy(opts.policyName)
 
 	// set up verification plugin config.
 	configs, err := cmd.ParseFlagMap(opts.pluginConfig, cmd.PflagPluginConfig.Name)
 	if err != nil {
 		return err
 	}
+	warnPluginConfig(configs)
 
-	// set up user metadata
-	userMetadata, err := cmd.ParseFlagMap(opts.userMetadata, cmd.PflagUserMetadata.Name)
+	// set up user metadata assertions
+	assertions, err := metadata.ParseAssertions(opts.userMetadata, cmd.PflagUserMetadata.Name)
 	if err != nil {
 		return err
 	}
+	verifier := summary.Verifier(metadata.NewVerifier(policyVerifier, assertions))
+	maxAttempts, err := resolveMaxSignatureAttempts(opts.maxAttempts)
+	if err != nil {
+		return err
+	}
+
+	if opts.allTags {
+		return runVerifyAllTags(ctx, opts, verifier, policyVerifier, configs, maxAttempts)
+	}
+
+	// set up the signer of the verification evidence before verification, so
+	// that a misconfigured key fails early
+	var evidenceSigner notation.Signer
+	if opts.evidenceOut != "" {
+		evidenceSigner, err = cmd.GetSigner(ctx, &cmd.SignerFlagOpts{Key: opts.evidenceKey})
+		if err != nil {
+			return fmt.Errorf("failed to get the signer of the verification evidence: %w", err)
+		}
+	}
+
+	if opts.envelope != "" {
+		return runVerifyEnvelope(ctx, opts, verifier, policyVerifier, configs, evidenceSigner)
+	}
+	if opts.bundle != "" {
+		return runVerifyBundle(ctx, opts, verifier, policyVerifier, configs, maxAttempts, evidenceSigner)
+	}
 
 	// core verify process
 	reference := opts.reference
-	sigRepo, err := getRepository(ctx, opts.inputType, reference, &opts.SecureFlagOpts)
+	repo, err := getRepository(ctx, opts.inputType, reference, &opts.SecureFlagOpts)
 	if err != nil {
 		return err
 	}
+	repo = chaos.NewRepository(repo, chaos.FromContext(ctx))
+	var manifestFetcher content.Fetcher
+	if opts.paranoid || policyVerifier.UsesArtifactTypes() || policyVerifier.UsesSignatureAnnotations() {
+		manifestFetcher, err = getManifestFetcher(ctx, opts.inputType, reference, &opts.SecureFlagOpts)
+		if err != nil {
+			return err
+		}
+	}
+	if policyVerifier.UsesArtifactTypes() {
+		// trust policy statements are selected by the artifact type
+		repo = policy.NewRepository(repo, manifestFetcher)
+	}
+	if policyVerifier.UsesSignatureAnnotations() {
+		// trust policy statements have rules on the signature manifests
+		repo = policyVerifier.SignatureRepository(repo, manifestFetcher)
+	}
+	if !opts.paranoid {
+		manifestFetcher = nil
+	}
+	sigRepo := integrity.NewRepository(repo, manifestFetcher)
 	// resolve the given reference and set the digest
-	_, resolvedRef, err := resolveReference(ctx, opts.inputType, reference, sigRepo, func(ref string, manifestDesc ocispec.Descriptor) {
-		fmt.Fprintf(os.Stderr, "Warning: Always verify the artifact using digest(@sha256:...) rather than a tag(:%s) because resolved digest may not point to the same signed artifact, as tags are mutable.\n", ref)
+	manifestDesc, resolvedRef, err := resolveReference(ctx, opts.inputType, reference, sigRepo, func(ref string, manifestDesc ocispec.Descriptor) {
+		fmt.Fprintf(os.Stderr, "%s Always verify the artifact using digest(@sha256:...) rather than a tag(:%s) because resolved digest may not point to the same signed artifact, as tags are mutable.\n", color.Warning(os.Stderr, "Warning:"), ref)
 	})
 	if err != nil {
 		return err
 	}
+	if opts.platform != "" {
+		manifestDesc, resolvedRef, err = resolvePlatform(ctx, opts.inputType, reference, &opts.SecureFlagOpts, manifestDesc, resolvedRef, opts.platform)
+		if err != nil {
+			return err
+		}
+	}
+	emitter.Emit(events.Event{Type: events.TypeProgress, Stage: "resolved", Reference: resolvedRef, Digest: manifestDesc.Digest.String()})
+	if opts.dryRun {
+		intendedRef := resolveArtifactDigestReference(resolvedRef, opts.trustPolicyScope)
+		if opts.keepTagReference {
+			resolvedRef = keepTagReference(opts.inputType, reference, resolvedRef)
+		}
+		return runVerifyDryRun(opts, policyVerifier, manifestDesc, resolvedRef, intendedRef)
+	}
+	var marker ocilayout.Marker
+	if opts.useMarker {
+		var upToDate bool
+		marker, upToDate, err = checkVerificationMarker(ctx, opts, sigRepo, manifestDesc, configs)
+		if err != nil {
+			return err
+		}
+		if upToDate {
+			emitter.Emit(events.Event{Type: events.TypeResult, Reference: resolvedRef, Digest: manifestDesc.Digest.String(), Result: events.ResultSkipped})
+			if isStructuredOutput(opts.outputFormat) {
+				return printVerifyOutput(opts.outputFormat, newVerifyOutput(resolvedRef, manifestDesc, nil, nil, nil, nil))
+			}
+			fmt.Println("Skipped verification for", resolvedRef, "as it is unchanged since the last successful verification. Use flag \"--force\" to verify again")
+			return nil
+		}
+	}
 	intendedRef := resolveArtifactDigestReference(resolvedRef, opts.trustPolicyScope)
+	if opts.keepTagReference {
+		resolvedRef = keepTagReference(opts.inputType, reference, resolvedRef)
+	}
 	verifyOpts := notation.VerifyOptions{
-		ArtifactReference: intendedRef,
-		PluginConfig:      configs,
-		// TODO: need to change MaxSignatureAttempts as a user input flag or
-		// a field in config.json
-		MaxSignatureAttempts: maxSignatureAttempts,
-		UserMetadata:         userMetadata,
+		ArtifactReference:    intendedRef,
+		PluginConfig:         configs,
+		MaxSignatureAttempts: maxAttempts,
+	}
+	artifactDesc, outcomes, records, err := verifySignatures(ctx, opts, sigRepo.Verifier(verifier), sigRepo, verifyOpts)
+	if tamperErr := sigRepo.Err(); tamperErr != nil {
+		err = tamperErr
+	} else {
+		err = checkVerificationFailure(outcomes, resolvedRef, err)
+	}
+	if err == nil && !experimental.IsDisabled() {
+		// post-quantum signatures are validated when present
+		err = verifyPQSignatures(ctx, opts, manifestDesc, outcomes)
+	}
+	annotations := outputAnnotations(ctx, opts, manifestDesc)
+	emitVerificationResult(ctx, resolvedRef, manifestDesc.Digest.String(), annotations, outcomes, err)
+	clockSkew := policyVerifier.ClockSkewTolerance(manifestDesc, intendedRef)
+	if err == nil && !isStructuredOutput(opts.outputFormat) {
+		reportVerificationSuccess(outcomes, resolvedRef, clockSkew, verifiedTimestamp(policyVerifier, outcomes))
+	}
+	var platforms []platformVerification
+	var platformErr error
+	if opts.recursive && platform.IsIndex(manifestDesc) {
+		indexFetcher, fetcherErr := getManifestFetcher(ctx, opts.inputType, reference, &opts.SecureFlagOpts)
+		if fetcherErr != nil {
+			return fetcherErr
+		}
+		platforms, platformErr = verifyPlatformManifests(ctx, opts, verifier, repo, manifestFetcher, indexFetcher, manifestDesc, resolvedRef, intendedRef, verifyOpts)
+	}
+	if isStructuredOutput(opts.outputFormat) {
+		output := newVerifyOutput(resolvedRef, manifestDesc, annotations, records, outcomes, err)
+		output.setClockSkewTolerance(clockSkew)
+		output.setVerifiedTimestamps(records, policyVerifier)
+		for _, verification := range platforms {
+			output.Platforms = append(output.Platforms, verification.output(policyVerifier))
+		}
+		if printErr := printVerifyOutput(opts.outputFormat, output); printErr != nil {
+			return printErr
+		}
 	}
-	_, outcomes, err := notation.Verify(ctx, verifier, sigRepo, verifyOpts)
-	err = checkVerificationFailure(outcomes, resolvedRef, err)
 	if err != nil {
 		return err
 	}
-	reportVerificationSuccess(outcomes, resolvedRef)
+	if platformErr != nil {
+		return platformErr
+	}
+	if opts.useMarker {
+		if outcomes[0].VerificationLevel != nil {
+			marker.VerificationLevel = outcomes[0].VerificationLevel.Name
+		}
+		layoutPath, _, err := parseOCILayoutReference(reference)
+		if err != nil {
+			return err
+		}
+		key, err := loadMarkerKey()
+		if err != nil {
+			return err
+		}
+		marker.Sign(key, manifestDesc.Digest, time.Now())
+		if err := ocilayout.WriteMarker(ociLayoutDir(layoutPath), manifestDesc.Digest, marker); err != nil {
+			return fmt.Errorf("failed to record verification marker: %w", err)
+		}
+	}
+	if opts.evidenceOut != "" {
+		if err := writeVerificationEvidence(ctx, opts.evidenceOut, opts.policyName, evidenceSigner, resolvedRef, artifactDesc, outcomes[0]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verifySignatures verifies the signatures of the artifact of verifyOpts,
+// concurrently if flag "--concurrency" is set, and returns the signatures
+// verified alongside the results of notation.Verify. Signatures failing to be
+// fetched transiently are fetched again up to flag "--fetch-retries" times.
+func verifySignatures(ctx context.Context, opts *verifyOpts, verifier notation.Verifier, sigRepo notationregistry.Repository, verifyOpts notation.VerifyOptions) (ocispec.Descriptor, []*notation.VerificationOutcome, []verificationRecord, error) {
+	retryRepo := retry.NewRepository(sigRepo, opts.fetchRetries)
+	var artifactDesc ocispec.Descriptor
+	var outcomes []*notation.VerificationOutcome
+	var records []verificationRecord
+	var err error
+	if opts.concurrency <= 1 {
+		recorder := &verificationRecorder{}
+		artifactDesc, outcomes, err = notation.Verify(ctx, recorder.Verifier(verifier), recorder.Repository(retryRepo), verifyOpts)
+		records = recorder.records
+	} else {
+		var parallelRecords []parallel.Record
+		artifactDesc, outcomes, parallelRecords, err = parallel.Verify(ctx, verifier, retryRepo, parallel.Options{VerifyOptions: verifyOpts, Concurrency: opts.concurrency})
+		for _, record := range parallelRecords {
+			records = append(records, verificationRecord{
+				signatureDesc: record.SignatureManifest,
+				mediaType:     record.MediaType,
+				outcome:       record.Outcome,
+				err:           record.Err,
+			})
+		}
+	}
+	for i := range records {
+		records[i].fetchRetries = retryRepo.Retries(records[i].signatureDesc.Digest)
+	}
+	return artifactDesc, outcomes, records, err
+}
+
+// resolveMaxSignatureAttempts returns the maximum number of signatures
+// evaluated per artifact, i.e. flagValue if positive, otherwise
+// "maxSignatureAttempts" of config.json if set, otherwise unlimited.
+func resolveMaxSignatureAttempts(flagValue int) (int, error) {
+	if flagValue > 0 {
+		return flagValue, nil
+	}
+	cliConfig, err := configutil.LoadCLIConfigOnce()
+	if err != nil {
+		return 0, fmt.Errorf("failed to load config.json: %w", err)
+	}
+	if cliConfig.MaxSignatureAttempts > 0 {
+		return cliConfig.MaxSignatureAttempts, nil
+	}
+	return maxSignatureAttempts, nil
+}
+
+// writeVerificationEvidence writes the evidence of a successful verification
+// against the trust policy document named policyName to path.
+func writeVerificationEvidence(ctx context.Context, path, policyName string, signer notation.Signer, artifact string, artifactDesc ocispec.Descriptor, outcome *notation.VerificationOutcome) error {
+	if outcome.EnvelopeContent == nil {
+		fmt.Fprintln(os.Stderr, color.Warning(os.Stderr, "Warning:"), "Verification evidence is not written because signature verification is skipped for", artifact)
+		return nil
+	}
+	policyJSON, err := readTrustPolicy(policyName)
+	if err != nil {
+		return err
+	}
+	pkg, err := evidence.New(artifact, artifactDesc, outcome, policyJSON, "notation/"+version.GetVersion())
+	if err != nil {
+		return err
+	}
+	if err := pkg.Write(ctx, path, signer); err != nil {
+		return fmt.Errorf("failed to write verification evidence: %w", err)
+	}
+	fmt.Println("Wrote verification evidence to", path)
 	return nil
 }
 
+// readTrustPolicy reads the trust policy document named name, see
+// policy.DocumentPath.
+func readTrustPolicy(name string) ([]byte, error) {
+	relPath, err := policy.DocumentPath(name)
+	if err != nil {
+		return nil, err
+	}
+	policyPath, err := dir.ConfigFS().SysPath(relPath)
+	if err != nil {
+		return nil, err
+	}
+	policyJSON, err := os.ReadFile(policyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read trust policy: %w", err)
+	}
+	return policyJSON, nil
+}
+
+// checkVerificationMarker computes the verification marker of the artifact
+// in the OCI layout and reports whether an identical marker is already
+// recorded in the layout index, authenticated by the local marker key and
+// recorded within ocilayout.MarkerTTL. Markers of the layout are not trusted
+// otherwise, as the layout is the input being verified.
+func checkVerificationMarker(ctx context.Context, opts *verifyOpts, sigRepo notationregistry.Repository, manifestDesc ocispec.Descriptor, pluginConfig map[string]string) (ocilayout.Marker, bool, error) {
+	policyJSON, err := readTrustPolicy(opts.policyName)
+	if err != nil {
+		return ocilayout.Marker{}, false, err
+	}
+	trustStoreDigest, err := policy.DigestTrustStore()
+	if err != nil {
+		return ocilayout.Marker{}, false, fmt.Errorf("failed to read trust store: %w", err)
+	}
+	var signatures []ocispec.Descriptor
+	err = sigRepo.ListSignatures(ctx, manifestDesc, func(signatureManifests []ocispec.Descriptor) error {
+		signatures = append(signatures, signatureManifests...)
+		return nil
+	})
+	if err != nil {
+		return ocilayout.Marker{}, false, err
+	}
+	marker := ocilayout.NewMarker(ocilayout.MarkerInputs{
+		Policy:       policyJSON,
+		Scope:        opts.trustPolicyScope,
+		TrustStore:   trustStoreDigest,
+		UserMetadata: opts.userMetadata,
+		PluginConfig: pluginConfig,
+	}, signatures, "")
+	if opts.force {
+		return marker, false, nil
+	}
+	layoutPath, _, err := parseOCILayoutReference(opts.reference)
+	if err != nil {
+		return ocilayout.Marker{}, false, err
+	}
+	recorded, err := ocilayout.ReadMarker(ociLayoutDir(layoutPath), manifestDesc.Digest)
+	if err != nil || recorded == nil {
+		return marker, false, err
+	}
+	key, err := loadMarkerKey()
+	if err != nil {
+		return ocilayout.Marker{}, false, err
+	}
+	return marker, recorded.Valid(key, manifestDesc.Digest, time.Now()) && recorded.Matches(marker), nil
+}
+
+// loadMarkerKey returns the local secret authenticating the verification
+// markers.
+func loadMarkerKey() ([]byte, error) {
+	path, err := ocilayout.DefaultMarkerKeyPath()
+	if err != nil {
+		return nil, err
+	}
+	return ocilayout.LoadMarkerKey(path)
+}
+
 func checkVerificationFailure(outcomes []*notation.VerificationOutcome, printOut string, err error) error {
 	// write out on failure
 	if err != nil || len(outcomes) == 0 {
@@ -151,7 +751,7 @@ func checkVerificationFailure(outcomes []*notation.VerificationOutcome, printOut
 	return nil
 }
 
-func reportVerificationSuccess(outcomes []*notation.VerificationOutcome, printout string) {
+func reportVerificationSuccess(outcomes []*notation.VerificationOutcome, printout string, clockSkew time.Duration, timestamp *policy.Timestamp) {
 	// write out on success
 	outcome := outcomes[0]
 	// print out warning for any failed result with logged verification action
@@ -159,17 +759,94 @@ func reportVerificationSuccess(outcomes []*notation.VerificationOutcome, printou
 		if result.Error != nil {
 			// at this point, the verification action has to be logged and
 			// it's failed
-			fmt.Fprintf(os.Stderr, "Warning: %v was set to %q and failed with error: %v\n", result.Type, result.Action, result.Error)
+			fmt.Fprintf(os.Stderr, "%s %v was set to %q and failed with error: %v\n", color.Warning(os.Stderr, "Warning:"), result.Type, result.Action, result.Error)
 		}
 	}
 	if reflect.DeepEqual(outcome.VerificationLevel, trustpolicy.LevelSkip) {
 		fmt.Println("Trust policy is configured to skip signature verification for", printout)
 	} else {
-		fmt.Println("Successfully verified signature for", printout)
+		fmt.Println(color.Success(os.Stdout, "Successfully verified signature for"), printout)
+		if clockSkew > 0 {
+			fmt.Printf("Applied a clock skew tolerance of %v to the expiry and certificate validity checks\n", clockSkew)
+		}
+		if timestamp != nil {
+			fmt.Printf("Evaluated the certificate validity at the time of the timestamp %s issued by %q\n", timestamp.Time.Format(time.RFC3339), timestamp.Authority.Subject)
+		}
 		printMetadataIfPresent(outcome)
 	}
 }
 
+// verifiedTimestamp returns the timestamp of the signature verified
+// successfully, if verified against the timestamp trust stores of the trust
+// policy.
+func verifiedTimestamp(policyVerifier *policy.Verifier, outcomes []*notation.VerificationOutcome) *policy.Timestamp {
+	if len(outcomes) == 0 {
+		return nil
+	}
+	timestamp, ok := policyVerifier.VerifiedTimestamp(outcomes[0].RawSignature)
+	if !ok {
+		return nil
+	}
+	return &timestamp
+}
+
+// emitVerificationResult emits the result event of the verification of the
+// artifact, if an event socket is set.
+func emitVerificationResult(ctx context.Context, reference, digest string, annotations map[string]string, outcomes []*notation.VerificationOutcome, err error) {
+	event := events.Event{
+		Type:        events.TypeResult,
+		Reference:   reference,
+		Digest:      digest,
+		Annotations: annotations,
+	}
+	switch {
+	case err != nil:
+		event.Result = events.ResultFailure
+		event.Error = err.Error()
+	case reflect.DeepEqual(outcomes[0].VerificationLevel, trustpolicy.LevelSkip):
+		event.Result = events.ResultSkipped
+	default:
+		event.Result = events.ResultSuccess
+		if outcomes[0].VerificationLevel != nil {
+			event.VerificationLevel = outcomes[0].VerificationLevel.Name
+		}
+	}
+	events.FromContext(ctx).Emit(event)
+}
+
+// outputAnnotations returns the annotations of the subject manifest of
+// manifestDesc selected by the "outputAnnotations" of config.json, so that
+// deployment systems get build metadata from the result event without
+// fetching the manifest again. The annotations are also included in the JSON
+// output. Annotations are fetched only if they are output, and failures are
+// reported as warnings as they do not affect the verification.
+func outputAnnotations(ctx context.Context, opts *verifyOpts, manifestDesc ocispec.Descriptor) map[string]string {
+	cliConfig := outputAnnotationsConfig(ctx, opts)
+	if cliConfig == nil {
+		return nil
+	}
+	annotations, err := fetchManifestAnnotations(ctx, opts.inputType, opts.reference, &opts.SecureFlagOpts, manifestDesc)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s failed to fetch the annotations of %s: %v\n", color.Warning(os.Stderr, "Warning:"), manifestDesc.Digest, err)
+		return nil
+	}
+	return cliConfig.SelectOutputAnnotations(annotations)
+}
+
+// outputAnnotationsConfig returns the CLI config selecting the annotations
+// output in the result event or the JSON output, or nil if neither an event
+// socket nor the JSON output is set, or no annotation is selected.
+func outputAnnotationsConfig(ctx context.Context, opts *verifyOpts) *configutil.CLIConfig {
+	if events.FromContext(ctx) == nil && !isStructuredOutput(opts.outputFormat) {
+		return nil
+	}
+	cliConfig, err := configutil.LoadCLIConfigOnce()
+	if err != nil || len(cliConfig.OutputAnnotations) == 0 {
+		return nil
+	}
+	return cliConfig
+}
+
 func printMetadataIfPresent(outcome *notation.VerificationOutcome) {
 	// the signature envelope is parsed as part of verification.
 	// since user metadata is only printed on successful verification,
@@ -1,8 +1,18 @@
 package main
 
 import (
+	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/notaryproject/notation/internal/chaos"
+	"github.com/notaryproject/notation/internal/cmd"
+	"github.com/notaryproject/notation/internal/retry"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 )
 
 func TestVerifyCommand_BasicArgs(t *testing.T) {
@@ -15,6 +25,9 @@ func TestVerifyCommand_BasicArgs(t *testing.T) {
 			Password: "password",
 		},
 		pluginConfig: []string{"key1=val1"},
+		outputFormat: cmd.OutputPlaintext,
+		concurrency:  1,
+		timeouts:     defaultCheckTimeouts,
 	}
 	if err := command.ParseFlags([]string{
 		expected.reference,
@@ -31,6 +44,18 @@ func TestVerifyCommand_BasicArgs(t *testing.T) {
 	}
 }
 
+func TestVerifyCommand_FetchRetries(t *testing.T) {
+	t.Setenv("NOTATION_EXPERIMENTAL", "1")
+	opts := &verifyOpts{}
+	command := verifyCommand(opts)
+	if err := command.ParseFlags([]string{"ref"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if opts.fetchRetries != retry.DefaultMaxRetries {
+		t.Fatalf("expected %d fetch retries when experimental is enabled, got %d", retry.DefaultMaxRetries, opts.fetchRetries)
+	}
+}
+
 func TestVerifyCommand_MoreArgs(t *testing.T) {
 	opts := &verifyOpts{}
 	command := verifyCommand(opts)
@@ -40,10 +65,16 @@ func TestVerifyCommand_MoreArgs(t *testing.T) {
 			PlainHTTP: true,
 		},
 		pluginConfig: []string{"key1=val1", "key2=val2"},
+		outputFormat: cmd.OutputJSON,
+		maxAttempts:  50,
+		concurrency:  1,
+		timeouts:     defaultCheckTimeouts,
 	}
 	if err := command.ParseFlags([]string{
 		expected.reference,
 		"--plain-http",
+		"--output", "json",
+		"--max-signature-attempts", "50",
 		"--plugin-config", "key1=val1",
 		"--plugin-config", "key2=val2"}); err != nil {
 		t.Fatalf("Parse Flag failed: %v", err)
@@ -65,3 +96,205 @@ func TestVerifyCommand_MissingArgs(t *testing.T) {
 		t.Fatal("Parse Args expected error, but ok")
 	}
 }
+
+func TestVerifyCommand_EvidenceKeyRequired(t *testing.T) {
+	command := verifyCommand(nil)
+	if err := command.ParseFlags([]string{"ref", "--evidence-out", "evidence.zip"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := command.Args(command, command.Flags().Args()); err != nil {
+		t.Fatalf("Parse args failed: %v", err)
+	}
+	if err := command.PreRunE(command, command.Flags().Args()); err == nil || !strings.Contains(err.Error(), "--evidence-key") {
+		t.Fatalf("expected error requiring --evidence-key, got %v", err)
+	}
+}
+
+func TestVerifyCommand_Chaos(t *testing.T) {
+	t.Setenv("NOTATION_EXPERIMENTAL", "1")
+	opts := &verifyOpts{}
+	command := verifyCommand(opts)
+	if err := command.ParseFlags([]string{"ref",
+		"--chaos-registry-latency", "2s",
+		"--chaos-ocsp-failure",
+		"--chaos-corrupt-signature"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := command.PreRunE(command, command.Flags().Args()); err != nil {
+		t.Fatalf("PreRunE failed: %v", err)
+	}
+	expected := chaos.Config{RegistryLatency: 2 * time.Second, OCSPFailure: true, CorruptSignature: true}
+	if opts.chaos != expected {
+		t.Fatalf("Expect chaos config: %v, got: %v", expected, opts.chaos)
+	}
+	for _, name := range []string{"chaos-registry-latency", "chaos-ocsp-failure", "chaos-corrupt-signature"} {
+		if !command.Flags().Lookup(name).Hidden {
+			t.Fatalf("flag %q is not hidden", name)
+		}
+	}
+}
+
+func TestEnvelopeArtifactReference(t *testing.T) {
+	desc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    digest.FromString("manifest"),
+		Size:      8,
+	}
+	want := "localhost:5000/net-monitor@" + desc.Digest.String()
+	for _, reference := range []string{"localhost:5000/net-monitor", want} {
+		got, err := envelopeArtifactReference(reference, desc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Fatalf("envelopeArtifactReference(%q) = %q, want %q", reference, got, want)
+		}
+	}
+	for _, reference := range []string{
+		"localhost:5000/net-monitor:v1",
+		"localhost:5000/net-monitor@" + digest.FromString("other").String(),
+	} {
+		if _, err := envelopeArtifactReference(reference, desc); err == nil {
+			t.Fatalf("envelopeArtifactReference(%q) expected error, got nil", reference)
+		}
+	}
+}
+
+func TestReadDescriptor(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "desc.json")
+	for content, wantErr := range map[string]bool{
+		`{"mediaType":"application/vnd.oci.image.manifest.v1+json","digest":"sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855","size":2}`: false,
+		`{"digest":"sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855","size":2}`:                                                          true,
+		`{"mediaType":"application/vnd.oci.image.manifest.v1+json","digest":"sha256:invalid","size":2}`:                                                          true,
+		`{"mediaType":"application/vnd.oci.image.manifest.v1+json","digest":"sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"}`:          true,
+	} {
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := readDescriptor(path); (err != nil) != wantErr {
+			t.Fatalf("readDescriptor(%s) error = %v, wantErr %v", content, err, wantErr)
+		}
+	}
+}
+
+func TestVerifyCommand_ForceRequiresMarker(t *testing.T) {
+	command := verifyCommand(nil)
+	if err := command.ParseFlags([]string{"ref", "--force"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := command.Args(command, command.Flags().Args()); err != nil {
+		t.Fatalf("Parse args failed: %v", err)
+	}
+	if err := command.PreRunE(command, command.Flags().Args()); err == nil || !strings.Contains(err.Error(), "--verification-marker") {
+		t.Fatalf("expected error requiring --verification-marker, got %v", err)
+	}
+}
+
+func TestVerifyCommand_MetricsTextfile(t *testing.T) {
+	for _, args := range [][]string{
+		{"ref", "--metrics-textfile", "notation.prom"},
+		{"ref", "--all-tags", "--metrics-textfile", "notation.txt"},
+	} {
+		command := verifyCommand(nil)
+		if err := command.ParseFlags(args); err != nil {
+			t.Fatalf("Parse Flag failed: %v", err)
+		}
+		if err := command.Args(command, command.Flags().Args()); err != nil {
+			t.Fatalf("Parse args failed: %v", err)
+		}
+		if err := command.PreRunE(command, command.Flags().Args()); err == nil || !strings.Contains(err.Error(), "--metrics-textfile") {
+			t.Fatalf("%v: expected error of --metrics-textfile, got %v", args, err)
+		}
+	}
+}
+
+func TestVerifyCommand_TrustStores(t *testing.T) {
+	opts := &verifyOpts{}
+	command := verifyCommand(opts)
+	if err := command.ParseFlags([]string{
+		"ref",
+		"--trust-store", "ca:acme-rootcas=./roots",
+		"--trust-store", "signingAuthority:acme-signers=./signers"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if want := []string{"ca:acme-rootcas=./roots", "signingAuthority:acme-signers=./signers"}; !reflect.DeepEqual(opts.trustStores, want) {
+		t.Fatalf("Expect trust stores: %v, got: %v", want, opts.trustStores)
+	}
+}
+
+func TestVerifyCommand_Platform(t *testing.T) {
+	t.Setenv("NOTATION_EXPERIMENTAL", "1")
+	opts := &verifyOpts{}
+	command := verifyCommand(opts)
+	if err := command.ParseFlags([]string{"ref", "--platform", "linux/arm64"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := command.PreRunE(command, command.Flags().Args()); err != nil {
+		t.Fatalf("PreRunE failed: %v", err)
+	}
+	if opts.platform != "linux/arm64" {
+		t.Fatalf("Expect platform: %q, got: %q", "linux/arm64", opts.platform)
+	}
+
+	command = verifyCommand(nil)
+	if err := command.ParseFlags([]string{"ref", "--platform", "arm64"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := command.PreRunE(command, command.Flags().Args()); err == nil {
+		t.Fatal("expected error for invalid platform")
+	}
+}
+
+func TestVerifyCommand_PolicyName(t *testing.T) {
+	t.Setenv("NOTATION_EXPERIMENTAL", "1")
+	opts := &verifyOpts{}
+	command := verifyCommand(opts)
+	if err := command.ParseFlags([]string{"ref", "--policy-name", "prod"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if opts.policyName != "prod" {
+		t.Fatalf("Expect policy name: prod, got: %s", opts.policyName)
+	}
+}
+
+func TestVerifyCommand_ClockSkewTolerance(t *testing.T) {
+	t.Setenv("NOTATION_EXPERIMENTAL", "1")
+	opts := &verifyOpts{}
+	command := verifyCommand(opts)
+	if err := command.ParseFlags([]string{"ref", "--clock-skew-tolerance", "5m"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := command.PreRunE(command, command.Flags().Args()); err != nil {
+		t.Fatalf("PreRunE failed: %v", err)
+	}
+	if opts.clockSkew != 5*time.Minute {
+		t.Fatalf("Expect clock skew tolerance: 5m0s, got: %v", opts.clockSkew)
+	}
+
+	command = verifyCommand(nil)
+	if err := command.ParseFlags([]string{"ref", "--clock-skew-tolerance", "2h"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := command.PreRunE(command, command.Flags().Args()); err == nil || !strings.Contains(err.Error(), "--clock-skew-tolerance") {
+		t.Fatalf("expected error for a clock skew tolerance beyond the maximum, got %v", err)
+	}
+}
+
+func TestVerifyCommand_MaxSignatureAttempts(t *testing.T) {
+	command := verifyCommand(nil)
+	if err := command.ParseFlags([]string{"ref", "--max-signature-attempts", "-1"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := command.PreRunE(command, command.Flags().Args()); err == nil || !strings.Contains(err.Error(), "--max-signature-attempts") {
+		t.Fatalf("expected error for a negative maximum, got %v", err)
+	}
+
+	// the flag takes precedence over config.json
+	got, err := resolveMaxSignatureAttempts(50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != 50 {
+		t.Fatalf("resolveMaxSignatureAttempts() = %d, want 50", got)
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation/internal/offlinebundle"
+	"github.com/notaryproject/notation/internal/policy"
+	"oras.land/oras-go/v2/content"
+)
+
+// runVerifyBundle verifies the signatures of the bundle of opts.bundle without
+// accessing the registry. The trust policy statement is selected by the
+// repository of opts.reference if set, or else by the reference recorded in
+// the bundle.
+func runVerifyBundle(ctx context.Context, opts *verifyOpts, verifier notation.Verifier, policyVerifier *policy.Verifier, pluginConfig map[string]string, maxAttempts int, evidenceSigner notation.Signer) error {
+	bundle, artifactRef, err := openOfflineBundle(opts.bundle, opts.reference)
+	if err != nil {
+		return err
+	}
+
+	repo := bundle.Repository()
+	if policyVerifier.UsesArtifactTypes() {
+		// trust policy statements are selected by the artifact type
+		repo = policy.NewRepository(repo, bundle)
+	}
+	if policyVerifier.UsesSignatureAnnotations() {
+		// trust policy statements have rules on the signature manifests
+		repo = policyVerifier.SignatureRepository(repo, bundle)
+	}
+	verifyOpts := notation.VerifyOptions{
+		ArtifactReference:    artifactRef,
+		PluginConfig:         pluginConfig,
+		MaxSignatureAttempts: maxAttempts,
+	}
+	artifactDesc, outcomes, records, err := verifySignatures(ctx, opts, verifier, repo, verifyOpts)
+	err = checkVerificationFailure(outcomes, artifactRef, err)
+
+	// the manifest of the bundle is the source of annotations
+	var annotations map[string]string
+	if cliConfig := outputAnnotationsConfig(ctx, opts); cliConfig != nil {
+		annotations = cliConfig.SelectOutputAnnotations(bundleAnnotations(ctx, bundle))
+	}
+	emitVerificationResult(ctx, artifactRef, bundle.Subject.Digest.String(), annotations, outcomes, err)
+	clockSkew := policyVerifier.ClockSkewTolerance(bundle.Subject, artifactRef)
+	if isStructuredOutput(opts.outputFormat) {
+		output := newVerifyOutput(artifactRef, bundle.Subject, annotations, records, outcomes, err)
+		output.setClockSkewTolerance(clockSkew)
+		output.setVerifiedTimestamps(records, policyVerifier)
+		if printErr := printVerifyOutput(opts.outputFormat, output); printErr != nil {
+			return printErr
+		}
+	} else if err == nil {
+		reportVerificationSuccess(outcomes, artifactRef, clockSkew, verifiedTimestamp(policyVerifier, outcomes))
+	}
+	if err != nil {
+		return err
+	}
+	if opts.evidenceOut != "" {
+		return writeVerificationEvidence(ctx, opts.evidenceOut, opts.policyName, evidenceSigner, artifactRef, artifactDesc, outcomes[0])
+	}
+	return nil
+}
+
+// openOfflineBundle reads the bundle at path and returns it with the
+// reference of its artifact, which is the digest reference of reference if
+// set, or else the reference recorded in the bundle.
+func openOfflineBundle(path, reference string) (*offlinebundle.Bundle, string, error) {
+	bundle, err := readOfflineBundle(path)
+	if err != nil {
+		return nil, "", err
+	}
+	if reference == "" {
+		return bundle, bundle.Reference, nil
+	}
+	artifactRef, err := envelopeArtifactReference(reference, bundle.Subject)
+	if err != nil {
+		return nil, "", err
+	}
+	return bundle, artifactRef, nil
+}
+
+// readOfflineBundle reads the bundle exported by notation export-bundle from
+// the file at path.
+func readOfflineBundle(path string) (*offlinebundle.Bundle, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	bundle, err := offlinebundle.Read(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bundle %s: %w", path, err)
+	}
+	return bundle, nil
+}
+
+// bundleAnnotations returns the annotations of the artifact manifest of
+// bundle.
+func bundleAnnotations(ctx context.Context, bundle *offlinebundle.Bundle) map[string]string {
+	manifestJSON, err := content.FetchAll(ctx, bundle, bundle.Subject)
+	if err != nil {
+		return nil
+	}
+	// image manifests, image indexes and artifact manifests all have
+	// annotations at the top level
+	var manifest struct {
+		Annotations map[string]string `json:"annotations"`
+	}
+	if err := json.Unmarshal(manifestJSON, &manifest); err != nil {
+		return nil
+	}
+	return manifest.Annotations
+}
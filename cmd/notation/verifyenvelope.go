@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation/internal/envelope"
+	"github.com/notaryproject/notation/internal/policy"
+	"github.com/notaryproject/notation/internal/skipper"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/registry"
+)
+
+// runVerifyEnvelope verifies the raw signature envelope of opts.envelope
+// against the descriptor of opts.descriptor without accessing the registry.
+// The trust policy statement is selected by the repository of opts.reference.
+func runVerifyEnvelope(ctx context.Context, opts *verifyOpts, verifier notation.Verifier, policyVerifier *policy.Verifier, pluginConfig map[string]string, evidenceSigner notation.Signer) error {
+	desc, err := readDescriptor(opts.descriptor)
+	if err != nil {
+		return err
+	}
+	artifactRef, err := envelopeArtifactReference(opts.reference, desc)
+	if err != nil {
+		return err
+	}
+	sig, err := os.ReadFile(opts.envelope)
+	if err != nil {
+		return fmt.Errorf("failed to read signature envelope: %w", err)
+	}
+	mediaType, err := envelope.DetectEnvelopeMediaType(sig)
+	if err != nil {
+		return err
+	}
+	// the descriptor is the only source of annotations without accessing the
+	// registry
+	var annotations map[string]string
+	if cliConfig := outputAnnotationsConfig(ctx, opts); cliConfig != nil {
+		annotations = cliConfig.SelectOutputAnnotations(desc.Annotations)
+	}
+	verifierOpts := notation.VerifierVerifyOptions{
+		ArtifactReference:  artifactRef,
+		SignatureMediaType: mediaType,
+		PluginConfig:       pluginConfig,
+	}
+
+	skip, level, err := skipper.SkipVerify(ctx, verifier, verifierOpts)
+	if err != nil {
+		return fmt.Errorf("signature verification failed: %w", err)
+	}
+	if skip {
+		outcomes := []*notation.VerificationOutcome{{VerificationLevel: level}}
+		emitVerificationResult(ctx, artifactRef, desc.Digest.String(), annotations, outcomes, nil)
+		if isStructuredOutput(opts.outputFormat) {
+			return printVerifyOutput(opts.outputFormat, newVerifyOutput(artifactRef, desc, annotations, nil, outcomes, nil))
+		}
+		reportVerificationSuccess(outcomes, artifactRef, 0, nil)
+		return nil
+	}
+	clockSkew := policyVerifier.ClockSkewTolerance(desc, artifactRef)
+	outcome, err := verifier.Verify(ctx, desc, sig, verifierOpts)
+	record := verificationRecord{
+		// the envelope is identified by its own digest without a signature
+		// manifest
+		signatureDesc: ocispec.Descriptor{MediaType: mediaType, Digest: digest.FromBytes(sig), Size: int64(len(sig))},
+		mediaType:     mediaType,
+		outcome:       outcome,
+		err:           err,
+	}
+	if err != nil {
+		// the error is reported as is to help debugging the envelope
+		err = fmt.Errorf("signature verification failed: %w", err)
+		emitVerificationResult(ctx, artifactRef, desc.Digest.String(), annotations, nil, err)
+		if isStructuredOutput(opts.outputFormat) {
+			output := newVerifyOutput(artifactRef, desc, annotations, []verificationRecord{record}, nil, err)
+			output.setClockSkewTolerance(clockSkew)
+			if printErr := printVerifyOutput(opts.outputFormat, output); printErr != nil {
+				return printErr
+			}
+		}
+		return err
+	}
+	outcomes := []*notation.VerificationOutcome{outcome}
+	emitVerificationResult(ctx, artifactRef, desc.Digest.String(), annotations, outcomes, nil)
+	if isStructuredOutput(opts.outputFormat) {
+		output := newVerifyOutput(artifactRef, desc, annotations, []verificationRecord{record}, outcomes, nil)
+		output.setClockSkewTolerance(clockSkew)
+		output.setVerifiedTimestamps([]verificationRecord{record}, policyVerifier)
+		if err := printVerifyOutput(opts.outputFormat, output); err != nil {
+			return err
+		}
+	} else {
+		reportVerificationSuccess(outcomes, artifactRef, clockSkew, verifiedTimestamp(policyVerifier, outcomes))
+	}
+	if opts.evidenceOut != "" {
+		return writeVerificationEvidence(ctx, opts.evidenceOut, opts.policyName, evidenceSigner, artifactRef, desc, outcome)
+	}
+	return nil
+}
+
+// readDescriptor reads the OCI descriptor in JSON from the file at path.
+func readDescriptor(path string) (ocispec.Descriptor, error) {
+	descJSON, err := os.ReadFile(path)
+	if err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("failed to read descriptor: %w", err)
+	}
+	var desc ocispec.Descriptor
+	if err := json.Unmarshal(descJSON, &desc); err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("malformed descriptor: %w", err)
+	}
+	if desc.MediaType == "" {
+		return ocispec.Descriptor{}, errors.New("malformed descriptor: missing mediaType")
+	}
+	if err := desc.Digest.Validate(); err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("malformed descriptor: invalid digest: %w", err)
+	}
+	if desc.Size <= 0 {
+		return ocispec.Descriptor{}, errors.New("malformed descriptor: size must be positive")
+	}
+	return desc, nil
+}
+
+// envelopeArtifactReference returns the digest reference of the artifact
+// described by desc in the repository of reference, for verifying without
+// accessing the registry. If reference has a digest, it must be the digest of
+// desc.
+func envelopeArtifactReference(reference string, desc ocispec.Descriptor) (string, error) {
+	ref, err := registry.ParseReference(reference)
+	if err != nil {
+		return "", err
+	}
+	if ref.Reference != "" {
+		if err := ref.ValidateReferenceAsDigest(); err != nil {
+			return "", fmt.Errorf("reference %q must be a repository or a digest reference without accessing the registry", reference)
+		}
+		if ref.Reference != desc.Digest.String() {
+			return "", fmt.Errorf("digest of reference %q does not match the digest %s of the artifact", reference, desc.Digest)
+		}
+	}
+	ref.Reference = desc.Digest.String()
+	return ref.String(), nil
+}
@@ -0,0 +1,372 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"time"
+
+	"github.com/notaryproject/notation-go"
+	notationregistry "github.com/notaryproject/notation-go/registry"
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+	notationerrors "github.com/notaryproject/notation/cmd/notation/internal/errors"
+	"github.com/notaryproject/notation/internal/archive"
+	"github.com/notaryproject/notation/internal/cmd"
+	"github.com/notaryproject/notation/internal/events"
+	"github.com/notaryproject/notation/internal/ioutil"
+	"github.com/notaryproject/notation/internal/policy"
+	"github.com/notaryproject/notation/internal/skipper"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// verifyOutput is the output of notation verify in JSON.
+type verifyOutput struct {
+	Reference string `json:"reference"`
+	Digest    string `json:"digest"`
+	MediaType string `json:"mediaType,omitempty"`
+
+	// Result is "success", "failure" or "skipped".
+	Result string `json:"result"`
+	Error  string `json:"error,omitempty"`
+
+	// ErrorType is "tamperDetected" if the failure is caused by content
+	// that does not match its descriptor, e.g. a signature of which the
+	// subject is not the artifact.
+	ErrorType         string `json:"errorType,omitempty"`
+	VerificationLevel string `json:"verificationLevel,omitempty"`
+
+	// ClockSkewTolerance is the clock skew tolerance applied to the expiry and
+	// certificate validity checks, e.g. "5m0s", if any.
+	ClockSkewTolerance string `json:"clockSkewTolerance,omitempty"`
+
+	// Annotations are the annotations of the artifact selected by the
+	// "outputAnnotations" of config.json.
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// Signatures are the outcomes of the signatures verified, in the order
+	// of verification. The verification stops at the first signature
+	// verified successfully.
+	Signatures []signatureVerificationOutput `json:"signatures"`
+
+	// Platforms are the results of the manifests of the platforms of the
+	// image index verified with flag "--recursive".
+	Platforms []platformVerifyOutput `json:"platforms,omitempty"`
+
+	// WarningCount is the number of warnings printed to stderr, e.g. about
+	// registries accessed insecurely, and Warnings are their messages.
+	WarningCount int      `json:"warningCount"`
+	Warnings     []string `json:"warnings,omitempty"`
+}
+
+// signatureVerificationOutput is the outcome of verifying a signature.
+type signatureVerificationOutput struct {
+	// Digest is the digest of the signature manifest, or of the signature
+	// envelope if verified without a registry.
+	Digest    string `json:"digest"`
+	MediaType string `json:"mediaType"`
+
+	// Result is "success" or "failure".
+	Result            string                    `json:"result"`
+	Error             string                    `json:"error,omitempty"`
+	ErrorType         string                    `json:"errorType,omitempty"`
+	VerificationLevel string                    `json:"verificationLevel,omitempty"`
+	Checks            []verificationCheckOutput `json:"checks,omitempty"`
+	Certificates      []certificateOutput       `json:"certificates,omitempty"`
+	UserMetadata      map[string]string         `json:"userMetadata,omitempty"`
+
+	// Timestamp is the RFC 3161 timestamp countersignature of the signature,
+	// if timestamped.
+	Timestamp *signatureTimestampOutput `json:"timestamp,omitempty"`
+
+	// FetchRetries is the number of times the signature was fetched again
+	// after failing with a server error or a timeout, if any.
+	FetchRetries int `json:"fetchRetries,omitempty"`
+}
+
+// signatureTimestampOutput is the RFC 3161 timestamp countersignature of a
+// signature.
+type signatureTimestampOutput struct {
+	// Time is the time the timestamp authority issued the timestamp.
+	Time      string `json:"time,omitempty"`
+	Authority string `json:"authority,omitempty"`
+
+	// Verified is true if the timestamp is verified against the timestamp
+	// trust stores of the trust policy, and the certificate validity is
+	// evaluated at the time of the timestamp.
+	Verified bool `json:"verified"`
+
+	// Error is the reason why the timestamp cannot be parsed, if any.
+	Error string `json:"error,omitempty"`
+}
+
+// verificationCheckOutput is the result of a validation of the verification
+// level, e.g. "authenticity".
+type verificationCheckOutput struct {
+	Type string `json:"type"`
+
+	// Action is the action configured by the verification level, i.e.
+	// "enforce", "log" or "skip".
+	Action string `json:"action"`
+
+	// Result is "passed", "failed" or "skipped".
+	Result string `json:"result,omitempty"`
+
+	// Severity is "pass", "warn" if the check failed but is only logged, or
+	// "fail" if the check failed and is enforced.
+	Severity string `json:"severity,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// results of checks
+const (
+	checkResultPassed  = "passed"
+	checkResultFailed  = "failed"
+	checkResultSkipped = "skipped"
+)
+
+// severities of checks
+const (
+	checkSeverityPass = "pass"
+	checkSeverityWarn = "warn"
+	checkSeverityFail = "fail"
+)
+
+// newVerificationCheckOutput returns the output of a check with the action
+// configured and its error, if failed.
+func newVerificationCheckOutput(validationType trustpolicy.ValidationType, action trustpolicy.ValidationAction, err error) verificationCheckOutput {
+	check := verificationCheckOutput{
+		Type:     string(validationType),
+		Action:   string(action),
+		Result:   checkResultPassed,
+		Severity: checkSeverityPass,
+	}
+	switch {
+	case action == trustpolicy.ActionSkip:
+		// the error of a skipped check, if any, is informational
+		check.Result = checkResultSkipped
+	case err != nil && action == trustpolicy.ActionLog:
+		check.Result = checkResultFailed
+		check.Severity = checkSeverityWarn
+	case err != nil:
+		check.Result = checkResultFailed
+		check.Severity = checkSeverityFail
+	}
+	if err != nil {
+		check.Error = err.Error()
+	}
+	return check
+}
+
+// verificationRecord is a signature verified by notation.Verify.
+type verificationRecord struct {
+	signatureDesc ocispec.Descriptor
+	mediaType     string
+	outcome       *notation.VerificationOutcome
+	err           error
+
+	// fetchRetries is the number of times the signature was fetched again
+	// after failing transiently.
+	fetchRetries int
+}
+
+// verificationRecorder records the outcomes of all the signatures verified
+// by notation.Verify, which only returns the outcome of the signature
+// verified successfully. notation.Verify fetches and verifies the signatures
+// one by one, so the outcome of the verifier is paired with the signature
+// fetched last.
+type verificationRecorder struct {
+	signatureDesc ocispec.Descriptor
+	mediaType     string
+	records       []verificationRecord
+}
+
+// Repository wraps repo to record the signatures fetched.
+func (r *verificationRecorder) Repository(repo notationregistry.Repository) notationregistry.Repository {
+	return &recordingRepository{Repository: repo, recorder: r}
+}
+
+// Verifier wraps verifier to record the outcomes of the signatures.
+func (r *verificationRecorder) Verifier(verifier notation.Verifier) notation.Verifier {
+	return &recordingVerifier{base: verifier, recorder: r}
+}
+
+type recordingRepository struct {
+	notationregistry.Repository
+	recorder *verificationRecorder
+}
+
+// FetchSignatureBlob fetches the signature envelope of the signature manifest
+// and records the signature.
+func (r *recordingRepository) FetchSignatureBlob(ctx context.Context, desc ocispec.Descriptor) ([]byte, ocispec.Descriptor, error) {
+	blob, blobDesc, err := r.Repository.FetchSignatureBlob(ctx, desc)
+	if err == nil {
+		r.recorder.signatureDesc = desc
+		r.recorder.mediaType = blobDesc.MediaType
+	}
+	return blob, blobDesc, err
+}
+
+type recordingVerifier struct {
+	base     notation.Verifier
+	recorder *verificationRecorder
+}
+
+// SkipVerify validates whether the verification level is skip.
+func (v *recordingVerifier) SkipVerify(ctx context.Context, opts notation.VerifierVerifyOptions) (bool, *trustpolicy.VerificationLevel, error) {
+	return skipper.SkipVerify(ctx, v.base, opts)
+}
+
+// Verify verifies the signature with the wrapped verifier and records the
+// outcome.
+func (v *recordingVerifier) Verify(ctx context.Context, desc ocispec.Descriptor, signature []byte, opts notation.VerifierVerifyOptions) (*notation.VerificationOutcome, error) {
+	outcome, err := v.base.Verify(ctx, desc, signature, opts)
+	v.recorder.records = append(v.recorder.records, verificationRecord{
+		signatureDesc: v.recorder.signatureDesc,
+		mediaType:     v.recorder.mediaType,
+		outcome:       outcome,
+		err:           err,
+	})
+	return outcome, err
+}
+
+// newVerifyOutput returns the output of the verification of the artifact of
+// manifestDesc, where outcomes and err are the results of notation.Verify
+// and records are the signatures verified. The verification is skipped if
+// there is neither an outcome nor an error.
+func newVerifyOutput(reference string, manifestDesc ocispec.Descriptor, annotations map[string]string, records []verificationRecord, outcomes []*notation.VerificationOutcome, err error) verifyOutput {
+	output := verifyOutput{
+		Reference:   reference,
+		Digest:      manifestDesc.Digest.String(),
+		MediaType:   manifestDesc.MediaType,
+		Annotations: annotations,
+		Signatures:  []signatureVerificationOutput{},
+		Warnings:    printedWarnings.list(),
+	}
+	output.WarningCount = len(output.Warnings)
+	switch {
+	case err != nil:
+		output.Result = events.ResultFailure
+		output.Error = err.Error()
+		output.ErrorType = errorType(err)
+	case len(outcomes) == 0 || reflect.DeepEqual(outcomes[0].VerificationLevel, trustpolicy.LevelSkip):
+		// no signature is verified
+		output.Result = events.ResultSkipped
+	default:
+		output.Result = events.ResultSuccess
+	}
+	if len(outcomes) > 0 && outcomes[0].VerificationLevel != nil {
+		output.VerificationLevel = outcomes[0].VerificationLevel.Name
+	}
+	for _, record := range records {
+		output.Signatures = append(output.Signatures, newSignatureVerificationOutput(record))
+	}
+	return output
+}
+
+// error types of the output
+const errorTypeTamperDetected = "tamperDetected"
+
+// errorType returns the error type of err in the output, or an empty string
+// if err is a generic verification failure.
+func errorType(err error) string {
+	var tamperErr notationerrors.ErrorTamperDetected
+	if errors.As(err, &tamperErr) {
+		return errorTypeTamperDetected
+	}
+	return ""
+}
+
+// isStructuredOutput returns true if the result of notation verify is printed
+// as a document of outputFormat, i.e. in JSON or SARIF, instead of text.
+func isStructuredOutput(outputFormat string) bool {
+	return outputFormat == cmd.OutputJSON || outputFormat == cmd.OutputSARIF
+}
+
+// printVerifyOutput prints the output of notation verify in JSON, or in SARIF
+// if outputFormat is SARIF.
+func printVerifyOutput(outputFormat string, output verifyOutput) error {
+	if outputFormat == cmd.OutputSARIF {
+		return ioutil.PrintObjectAsJSON(newSARIFLog(output))
+	}
+	return ioutil.PrintObjectAsJSON(output)
+}
+
+// setClockSkewTolerance reports the clock skew tolerance applied, unless the
+// verification is skipped.
+func (o *verifyOutput) setClockSkewTolerance(tolerance time.Duration) {
+	if tolerance > 0 && o.Result != events.ResultSkipped {
+		o.ClockSkewTolerance = tolerance.String()
+	}
+}
+
+func newSignatureVerificationOutput(record verificationRecord) signatureVerificationOutput {
+	output := signatureVerificationOutput{
+		Digest:       record.signatureDesc.Digest.String(),
+		MediaType:    record.mediaType,
+		Result:       events.ResultSuccess,
+		FetchRetries: record.fetchRetries,
+	}
+	if record.err != nil {
+		output.Result = events.ResultFailure
+		output.Error = record.err.Error()
+		output.ErrorType = errorType(record.err)
+	}
+	outcome := record.outcome
+	if outcome == nil {
+		return output
+	}
+	if outcome.VerificationLevel != nil {
+		output.VerificationLevel = outcome.VerificationLevel.Name
+	}
+	reported := make(map[trustpolicy.ValidationType]bool)
+	for _, result := range outcome.VerificationResults {
+		output.Checks = append(output.Checks, newVerificationCheckOutput(result.Type, result.Action, result.Error))
+		reported[result.Type] = true
+	}
+	// checks skipped by the verification level are not reported by the
+	// verifier, but are listed so that they are not mistaken for passed
+	if level := outcome.VerificationLevel; level != nil && level.Name != trustpolicy.LevelSkip.Name {
+		for _, validationType := range trustpolicy.ValidationTypes {
+			if !reported[validationType] && level.Enforcement[validationType] == trustpolicy.ActionSkip {
+				output.Checks = append(output.Checks, newVerificationCheckOutput(validationType, trustpolicy.ActionSkip, nil))
+			}
+		}
+	}
+	if outcome.EnvelopeContent != nil {
+		output.Certificates = getCertificates(cmd.OutputJSON, outcome.EnvelopeContent)
+		if metadata, err := outcome.UserMetadata(); err == nil && len(metadata) > 0 {
+			output.UserMetadata = metadata
+		}
+		if token := outcome.EnvelopeContent.SignerInfo.UnsignedAttributes.TimestampSignature; len(token) > 0 {
+			output.Timestamp = newSignatureTimestampOutput(token)
+		}
+	}
+	return output
+}
+
+// newSignatureTimestampOutput returns the output of the timestamp token of a
+// signature, which is not verified.
+func newSignatureTimestampOutput(token []byte) *signatureTimestampOutput {
+	parsed, err := archive.ParseToken(token)
+	if err != nil {
+		return &signatureTimestampOutput{Error: err.Error()}
+	}
+	return &signatureTimestampOutput{Time: parsed.GenTime.Format(time.RFC3339)}
+}
+
+// setVerifiedTimestamps reports the timestamps of the signatures of records
+// verified by policyVerifier against the timestamp trust stores.
+func (o *verifyOutput) setVerifiedTimestamps(records []verificationRecord, policyVerifier *policy.Verifier) {
+	for i, record := range records {
+		if record.outcome == nil || i >= len(o.Signatures) {
+			continue
+		}
+		if timestamp, ok := policyVerifier.VerifiedTimestamp(record.outcome.RawSignature); ok {
+			o.Signatures[i].Timestamp = &signatureTimestampOutput{
+				Time:      timestamp.Time.Format(time.RFC3339),
+				Authority: timestamp.Authority.Subject.String(),
+				Verified:  true,
+			}
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/notation-core-go/signature"
+	"github.com/notaryproject/notation-go"
+	notationregistry "github.com/notaryproject/notation-go/registry"
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+	notationerrors "github.com/notaryproject/notation/cmd/notation/internal/errors"
+	"github.com/notaryproject/notation/internal/events"
+	"github.com/notaryproject/notation/internal/policy"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/registry/remote/errcode"
+)
+
+type verifyOutputRepository struct {
+	notationregistry.Repository
+	subject    ocispec.Descriptor
+	signatures []ocispec.Descriptor
+}
+
+func (r *verifyOutputRepository) Resolve(ctx context.Context, reference string) (ocispec.Descriptor, error) {
+	return r.subject, nil
+}
+
+func (r *verifyOutputRepository) ListSignatures(ctx context.Context, desc ocispec.Descriptor, fn func(signatureManifests []ocispec.Descriptor) error) error {
+	return fn(r.signatures)
+}
+
+func (r *verifyOutputRepository) FetchSignatureBlob(ctx context.Context, desc ocispec.Descriptor) ([]byte, ocispec.Descriptor, error) {
+	blob := []byte(desc.Digest)
+	return blob, ocispec.Descriptor{MediaType: "application/jose+json", Digest: digest.FromBytes(blob), Size: int64(len(blob))}, nil
+}
+
+// verifyOutputVerifier fails the signatures of the blobs in failures.
+type verifyOutputVerifier struct {
+	failures map[string]bool
+}
+
+func (v *verifyOutputVerifier) Verify(ctx context.Context, desc ocispec.Descriptor, signature []byte, opts notation.VerifierVerifyOptions) (*notation.VerificationOutcome, error) {
+	outcome := &notation.VerificationOutcome{
+		RawSignature:      signature,
+		VerificationLevel: trustpolicy.LevelStrict,
+		VerificationResults: []*notation.ValidationResult{
+			{Type: trustpolicy.TypeIntegrity, Action: trustpolicy.ActionEnforce},
+			{Type: trustpolicy.TypeExpiry, Action: trustpolicy.ActionLog, Error: errors.New("signature is expired")},
+		},
+	}
+	if v.failures[string(signature)] {
+		outcome.VerificationResults = append(outcome.VerificationResults, &notation.ValidationResult{Type: trustpolicy.TypeAuthenticity, Action: trustpolicy.ActionEnforce, Error: errors.New("untrusted signer")})
+		outcome.Error = errors.New("untrusted signer")
+		return outcome, outcome.Error
+	}
+	return outcome, nil
+}
+
+func TestVerificationRecorder(t *testing.T) {
+	subject := ocispec.Descriptor{MediaType: ocispec.MediaTypeImageManifest, Digest: digest.FromString("subject"), Size: 7}
+	untrusted := ocispec.Descriptor{MediaType: ocispec.MediaTypeImageManifest, Digest: digest.FromString("untrusted"), Size: 9}
+	trusted := ocispec.Descriptor{MediaType: ocispec.MediaTypeImageManifest, Digest: digest.FromString("trusted"), Size: 7}
+	repo := &verifyOutputRepository{subject: subject, signatures: []ocispec.Descriptor{untrusted, trusted}}
+	verifier := &verifyOutputVerifier{failures: map[string]bool{untrusted.Digest.String(): true}}
+
+	recorder := &verificationRecorder{}
+	reference := "localhost:5000/net-monitor@" + subject.Digest.String()
+	_, outcomes, err := notation.Verify(context.Background(), recorder.Verifier(verifier), recorder.Repository(repo), notation.VerifyOptions{
+		ArtifactReference:    reference,
+		MaxSignatureAttempts: 10,
+	})
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	output := newVerifyOutput(reference, subject, map[string]string{"org.opencontainers.image.revision": "abc"}, recorder.records, outcomes, nil)
+
+	if output.Result != events.ResultSuccess || output.VerificationLevel != trustpolicy.LevelStrict.Name || output.Digest != subject.Digest.String() {
+		t.Fatalf("unexpected verify output %+v", output)
+	}
+	if len(output.Signatures) != 2 {
+		t.Fatalf("expected 2 signatures, got %d", len(output.Signatures))
+	}
+	failed, verified := output.Signatures[0], output.Signatures[1]
+	if failed.Digest != untrusted.Digest.String() || failed.Result != events.ResultFailure || failed.Error != "untrusted signer" {
+		t.Fatalf("unexpected output of the untrusted signature %+v", failed)
+	}
+	if verified.Digest != trusted.Digest.String() || verified.Result != events.ResultSuccess || verified.MediaType != "application/jose+json" {
+		t.Fatalf("unexpected output of the trusted signature %+v", verified)
+	}
+	expectedChecks := []verificationCheckOutput{
+		{Type: "integrity", Action: "enforce", Result: "passed", Severity: "pass"},
+		{Type: "expiry", Action: "log", Result: "failed", Severity: "warn", Error: "signature is expired"},
+	}
+	if !reflect.DeepEqual(verified.Checks, expectedChecks) {
+		t.Fatalf("expected checks %+v, got %+v", expectedChecks, verified.Checks)
+	}
+}
+
+func TestNewSignatureVerificationOutput_Checks(t *testing.T) {
+	level := &trustpolicy.VerificationLevel{
+		Name: "strict",
+		Enforcement: map[trustpolicy.ValidationType]trustpolicy.ValidationAction{
+			trustpolicy.TypeIntegrity:          trustpolicy.ActionEnforce,
+			trustpolicy.TypeAuthenticity:       trustpolicy.ActionEnforce,
+			trustpolicy.TypeAuthenticTimestamp: trustpolicy.ActionSkip,
+			trustpolicy.TypeExpiry:             trustpolicy.ActionSkip,
+			trustpolicy.TypeRevocation:         trustpolicy.ActionSkip,
+		},
+	}
+	record := verificationRecord{
+		signatureDesc: ocispec.Descriptor{Digest: digest.FromString("signature")},
+		mediaType:     "application/jose+json",
+		outcome: &notation.VerificationOutcome{
+			VerificationLevel: level,
+			VerificationResults: []*notation.ValidationResult{
+				{Type: trustpolicy.TypeIntegrity, Action: trustpolicy.ActionEnforce},
+				{Type: trustpolicy.TypeAuthenticity, Action: trustpolicy.ActionEnforce, Error: errors.New("untrusted signer")},
+				{Type: trustpolicy.TypeExpiry, Action: trustpolicy.ActionSkip, Error: errors.New("signature is expired")},
+			},
+		},
+		err: errors.New("untrusted signer"),
+	}
+	// checks skipped by the verification level are reported even if the
+	// verifier does not report them
+	want := []verificationCheckOutput{
+		{Type: "integrity", Action: "enforce", Result: "passed", Severity: "pass"},
+		{Type: "authenticity", Action: "enforce", Result: "failed", Severity: "fail", Error: "untrusted signer"},
+		{Type: "expiry", Action: "skip", Result: "skipped", Severity: "pass", Error: "signature is expired"},
+		{Type: "authenticTimestamp", Action: "skip", Result: "skipped", Severity: "pass"},
+		{Type: "revocation", Action: "skip", Result: "skipped", Severity: "pass"},
+	}
+	if got := newSignatureVerificationOutput(record).Checks; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected checks %+v, got %+v", want, got)
+	}
+
+	// skipped verifications have no checks
+	record.outcome = &notation.VerificationOutcome{VerificationLevel: trustpolicy.LevelSkip}
+	record.err = nil
+	if got := newSignatureVerificationOutput(record).Checks; len(got) != 0 {
+		t.Fatalf("expected no checks, got %+v", got)
+	}
+}
+
+func TestNewVerifyOutput(t *testing.T) {
+	subject := ocispec.Descriptor{MediaType: ocispec.MediaTypeImageManifest, Digest: digest.FromString("subject"), Size: 7}
+
+	output := newVerifyOutput("ref", subject, nil, nil, nil, errors.New("signature verification failed"))
+	if output.Result != events.ResultFailure || output.Error != "signature verification failed" || output.ErrorType != "" || output.Signatures == nil {
+		t.Fatalf("unexpected failure output %+v", output)
+	}
+	tamperErr := notationerrors.ErrorTamperDetected{Msg: "subject of signature does not match the artifact"}
+	records := []verificationRecord{{err: tamperErr}}
+	output = newVerifyOutput("ref", subject, nil, records, nil, tamperErr)
+	if output.ErrorType != errorTypeTamperDetected || output.Signatures[0].ErrorType != errorTypeTamperDetected {
+		t.Fatalf("expected error type %q, got output %+v", errorTypeTamperDetected, output)
+	}
+	output.setClockSkewTolerance(5 * time.Minute)
+	if output.ClockSkewTolerance != "5m0s" {
+		t.Fatalf("expected clock skew tolerance %q, got %q", "5m0s", output.ClockSkewTolerance)
+	}
+
+	outcomes := []*notation.VerificationOutcome{{VerificationLevel: trustpolicy.LevelSkip}}
+	output = newVerifyOutput("ref", subject, nil, nil, outcomes, nil)
+	if output.Result != events.ResultSkipped || output.VerificationLevel != trustpolicy.LevelSkip.Name {
+		t.Fatalf("unexpected skipped output %+v", output)
+	}
+	if output.setClockSkewTolerance(5 * time.Minute); output.ClockSkewTolerance != "" {
+		t.Fatalf("expected no clock skew tolerance for a skipped verification, got %q", output.ClockSkewTolerance)
+	}
+
+	// skipped by the verification marker
+	output = newVerifyOutput("ref", subject, nil, nil, nil, nil)
+	if output.Result != events.ResultSkipped {
+		t.Fatalf("unexpected skipped output %+v", output)
+	}
+}
+
+func TestNewSignatureVerificationOutput_Timestamp(t *testing.T) {
+	record := verificationRecord{
+		signatureDesc: ocispec.Descriptor{Digest: digest.FromString("signature")},
+		mediaType:     "application/jose+json",
+		outcome: &notation.VerificationOutcome{
+			VerificationLevel: trustpolicy.LevelStrict,
+			EnvelopeContent:   &signature.EnvelopeContent{},
+		},
+	}
+	if got := newSignatureVerificationOutput(record).Timestamp; got != nil {
+		t.Fatalf("expected no timestamp for a signature without timestamp, got %+v", got)
+	}
+	record.outcome.EnvelopeContent.SignerInfo.UnsignedAttributes.TimestampSignature = []byte("malformed")
+	got := newSignatureVerificationOutput(record).Timestamp
+	if got == nil || got.Error == "" || got.Verified {
+		t.Fatalf("expected an unverified timestamp with error, got %+v", got)
+	}
+
+	// timestamps not verified by the trust policy are reported as is
+	output := verifyOutput{Signatures: []signatureVerificationOutput{newSignatureVerificationOutput(record)}}
+	output.setVerifiedTimestamps([]verificationRecord{record}, &policy.Verifier{})
+	if output.Signatures[0].Timestamp.Verified {
+		t.Fatal("expected the timestamp not to be verified")
+	}
+}
+
+// flakyRepository fails the first fetch of every signature blob with a
+// server error.
+type flakyRepository struct {
+	*verifyOutputRepository
+
+	mu      sync.Mutex
+	fetched map[digest.Digest]bool
+}
+
+func (r *flakyRepository) FetchSignatureBlob(ctx context.Context, desc ocispec.Descriptor) ([]byte, ocispec.Descriptor, error) {
+	r.mu.Lock()
+	fetched := r.fetched[desc.Digest]
+	r.fetched[desc.Digest] = true
+	r.mu.Unlock()
+	if !fetched {
+		return nil, ocispec.Descriptor{}, &errcode.ErrorResponse{Method: http.MethodGet, StatusCode: http.StatusServiceUnavailable}
+	}
+	return r.verifyOutputRepository.FetchSignatureBlob(ctx, desc)
+}
+
+func TestVerifySignatures_FetchRetries(t *testing.T) {
+	subject := ocispec.Descriptor{MediaType: ocispec.MediaTypeImageManifest, Digest: digest.FromString("subject"), Size: 7}
+	untrusted := ocispec.Descriptor{MediaType: ocispec.MediaTypeImageManifest, Digest: digest.FromString("untrusted"), Size: 9}
+	trusted := ocispec.Descriptor{MediaType: ocispec.MediaTypeImageManifest, Digest: digest.FromString("trusted"), Size: 7}
+	verifier := &verifyOutputVerifier{failures: map[string]bool{untrusted.Digest.String(): true}}
+	reference := "localhost:5000/net-monitor@" + subject.Digest.String()
+
+	for _, concurrency := range []int{1, 2} {
+		repo := &flakyRepository{
+			verifyOutputRepository: &verifyOutputRepository{subject: subject, signatures: []ocispec.Descriptor{untrusted, trusted}},
+			fetched:                make(map[digest.Digest]bool),
+		}
+		opts := &verifyOpts{concurrency: concurrency, fetchRetries: 1}
+		_, outcomes, records, err := verifySignatures(context.Background(), opts, verifier, repo, notation.VerifyOptions{
+			ArtifactReference:    reference,
+			MaxSignatureAttempts: 10,
+		})
+		if err != nil {
+			t.Fatalf("verifySignatures() with concurrency %d error = %v", concurrency, err)
+		}
+		output := newVerifyOutput(reference, subject, nil, records, outcomes, nil)
+		if len(output.Signatures) != 2 {
+			t.Fatalf("expected 2 signatures with concurrency %d, got %d", concurrency, len(output.Signatures))
+		}
+		for _, signature := range output.Signatures {
+			if signature.FetchRetries != 1 {
+				t.Fatalf("expected the signature %s to be fetched again once with concurrency %d, got %d", signature.Digest, concurrency, signature.FetchRetries)
+			}
+		}
+	}
+
+	// the signature is unverifiable if the retries are disabled
+	repo := &flakyRepository{
+		verifyOutputRepository: &verifyOutputRepository{subject: subject, signatures: []ocispec.Descriptor{trusted}},
+		fetched:                make(map[digest.Digest]bool),
+	}
+	_, _, _, err := verifySignatures(context.Background(), &verifyOpts{concurrency: 1}, verifier, repo, notation.VerifyOptions{
+		ArtifactReference:    reference,
+		MaxSignatureAttempts: 10,
+	})
+	if err == nil || !strings.Contains(err.Error(), "503") {
+		t.Fatalf("expected the server error without retries, got %v", err)
+	}
+}
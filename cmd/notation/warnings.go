@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/notaryproject/notation/internal/color"
+)
+
+// warningLog records the warnings printed by the command, so that the
+// structured outputs report them besides the messages on stderr.
+type warningLog struct {
+	mu       sync.Mutex
+	messages []string
+}
+
+// printedWarnings are the warnings printed by the command.
+var printedWarnings warningLog
+
+// warnf prints the warning to stderr and records it. Warnings already printed
+// are not printed again, e.g. for every repository of the same registry.
+func warnf(format string, a ...any) {
+	message := fmt.Sprintf(format, a...)
+	printedWarnings.mu.Lock()
+	defer printedWarnings.mu.Unlock()
+	for _, printed := range printedWarnings.messages {
+		if printed == message {
+			return
+		}
+	}
+	printedWarnings.messages = append(printedWarnings.messages, message)
+	fmt.Fprintln(os.Stderr, color.Warning(os.Stderr, "Warning:"), message)
+}
+
+// list returns the warnings printed so far.
+func (l *warningLog) list() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.messages...)
+}
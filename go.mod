@@ -4,6 +4,7 @@ go 1.20
 
 require (
 	github.com/docker/docker-credential-helpers v0.7.0
+	github.com/miekg/pkcs11 v1.1.1
 	github.com/notaryproject/notation-core-go v1.0.0-rc.2
 	github.com/notaryproject/notation-go v1.0.0-rc.3.0.20230419050135-cd1a135381c3
 	github.com/opencontainers/go-digest v1.0.0
@@ -11,6 +12,10 @@ require (
 	github.com/sirupsen/logrus v1.9.0
 	github.com/spf13/cobra v1.7.0
 	github.com/spf13/pflag v1.0.5
+	github.com/veraison/go-cose v1.0.0
+	golang.org/x/crypto v0.6.0
+	golang.org/x/mod v0.10.0
+	golang.org/x/sys v0.5.0
 	golang.org/x/term v0.5.0
 	oras.land/oras-go/v2 v2.0.2
 )
@@ -22,10 +27,6 @@ require (
 	github.com/go-ldap/ldap/v3 v3.4.4 // indirect
 	github.com/golang-jwt/jwt/v4 v4.4.3 // indirect
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
-	github.com/veraison/go-cose v1.0.0 // indirect
 	github.com/x448/float16 v0.8.4 // indirect
-	golang.org/x/crypto v0.6.0 // indirect
-	golang.org/x/mod v0.10.0 // indirect
 	golang.org/x/sync v0.1.0 // indirect
-	golang.org/x/sys v0.5.0 // indirect
 )
@@ -0,0 +1,401 @@
+// Package archive creates and verifies evidence records, which extend the
+// validity of signatures beyond the lifetime of their certificates and keys.
+//
+// An evidence record, modeled after the Evidence Record Syntax of RFC 4998,
+// wraps the signatures of an artifact with a chain of archive timestamps
+// obtained from RFC 3161 timestamp authorities:
+//
+//   - the initial archive timestamp covers the digests of the artifact
+//     descriptor and the signature envelopes, proving they existed at that
+//     time;
+//   - a timestamp renewal covers the previous archive timestamp, before the
+//     certificate of its timestamp authority expires or is revoked;
+//   - a hash-tree renewal covers the digests of the artifact descriptor, the
+//     signature envelopes and all previous archive timestamps with another
+//     hash algorithm, before the hash algorithm in use becomes weak.
+//
+// Renewals require neither the signing key nor the signing certificate, so
+// that the record can be renewed periodically for as long as needed.
+package archive
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/notaryproject/notation-core-go/signature"
+	nx509 "github.com/notaryproject/notation-core-go/x509"
+	"github.com/notaryproject/notation/internal/envelope"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// Version is the version of the evidence record format.
+const Version = "1.0"
+
+// MediaType is the media type of evidence records.
+const MediaType = "application/vnd.cncf.notary.x-evidence-record+json"
+
+// Renewal is the kind of an archive timestamp.
+type Renewal string
+
+const (
+	// RenewalInitial denotes the initial archive timestamp.
+	RenewalInitial Renewal = "initial"
+
+	// RenewalTimestamp denotes a timestamp renewal, covering the previous
+	// archive timestamp.
+	RenewalTimestamp Renewal = "timestamp"
+
+	// RenewalHashTree denotes a hash-tree renewal, covering the archived
+	// data and all previous archive timestamps with another hash algorithm.
+	RenewalHashTree Renewal = "hashtree"
+)
+
+// hashAlgorithms are the hash algorithms of archive timestamps by name.
+var hashAlgorithms = map[string]crypto.Hash{
+	"sha256": crypto.SHA256,
+	"sha384": crypto.SHA384,
+	"sha512": crypto.SHA512,
+}
+
+// Record is an evidence record of the signatures of an artifact.
+type Record struct {
+	// MediaType is the media type of the record, MediaType.
+	MediaType string `json:"mediaType"`
+
+	// Version is the version of the record format, Version.
+	Version string `json:"version"`
+
+	// Subject is the descriptor of the signed artifact.
+	Subject ocispec.Descriptor `json:"subject"`
+
+	// Signatures are the archived signatures of the artifact.
+	Signatures []Signature `json:"signatures"`
+
+	// ArchiveTimestamps are the archive timestamps, from the initial one to
+	// the latest renewal.
+	ArchiveTimestamps []ArchiveTimestamp `json:"archiveTimestamps"`
+}
+
+// Signature is an archived signature envelope.
+type Signature struct {
+	// MediaType is the media type of the envelope.
+	MediaType string `json:"mediaType"`
+
+	// Envelope is the signature envelope.
+	Envelope []byte `json:"envelope"`
+}
+
+// ArchiveTimestamp is an archive timestamp of the record.
+type ArchiveTimestamp struct {
+	// Renewal is the kind of the archive timestamp.
+	Renewal Renewal `json:"renewal"`
+
+	// HashAlgorithm is the name of the hash algorithm of the timestamped
+	// digest, e.g. "sha256".
+	HashAlgorithm string `json:"hashAlgorithm"`
+
+	// Time is the time of the timestamp. It is informative, the time in the
+	// token is authoritative.
+	Time time.Time `json:"time"`
+
+	// Token is the DER-encoded RFC 3161 timestamp token.
+	Token []byte `json:"token"`
+}
+
+// ParseHashAlgorithm returns the hash algorithm named name.
+func ParseHashAlgorithm(name string) (crypto.Hash, error) {
+	hash, ok := hashAlgorithms[name]
+	if !ok {
+		return 0, fmt.Errorf("hash algorithm %q not supported, supported hash algorithms are sha256, sha384 and sha512", name)
+	}
+	return hash, nil
+}
+
+// hashAlgorithmName returns the name of hash.
+func hashAlgorithmName(hash crypto.Hash) string {
+	for name, h := range hashAlgorithms {
+		if h == hash {
+			return name
+		}
+	}
+	return hash.String()
+}
+
+// New returns the evidence record of the signatures of the artifact described
+// by subject, with the initial archive timestamp of timestamper computed with
+// hash.
+func New(ctx context.Context, subject ocispec.Descriptor, signatures []Signature, timestamper Timestamper, hash crypto.Hash) (*Record, error) {
+	if len(signatures) == 0 {
+		return nil, errors.New("no signature to archive")
+	}
+	r := &Record{
+		MediaType:  MediaType,
+		Version:    Version,
+		Subject:    subject,
+		Signatures: signatures,
+	}
+	if err := r.timestamp(ctx, RenewalInitial, timestamper, hash); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Renew appends an archive timestamp of timestamper to the record. It is a
+// timestamp renewal if hash is the hash algorithm of the latest archive
+// timestamp, or a hash-tree renewal otherwise.
+func (r *Record) Renew(ctx context.Context, timestamper Timestamper, hash crypto.Hash) (Renewal, error) {
+	if len(r.ArchiveTimestamps) == 0 {
+		return "", errors.New("evidence record has no archive timestamp")
+	}
+	renewal := RenewalTimestamp
+	if r.ArchiveTimestamps[len(r.ArchiveTimestamps)-1].HashAlgorithm != hashAlgorithmName(hash) {
+		renewal = RenewalHashTree
+	}
+	return renewal, r.timestamp(ctx, renewal, timestamper, hash)
+}
+
+// timestamp appends an archive timestamp of kind renewal.
+func (r *Record) timestamp(ctx context.Context, renewal Renewal, timestamper Timestamper, hash crypto.Hash) error {
+	imprint, err := r.imprint(len(r.ArchiveTimestamps), renewal, hash)
+	if err != nil {
+		return err
+	}
+	tokenBytes, err := timestamper.Timestamp(ctx, hash, imprint)
+	if err != nil {
+		return err
+	}
+	token, err := ParseToken(tokenBytes)
+	if err != nil {
+		return fmt.Errorf("invalid timestamp token: %w", err)
+	}
+	if len(r.ArchiveTimestamps) > 0 {
+		if previous := r.ArchiveTimestamps[len(r.ArchiveTimestamps)-1]; !token.GenTime.After(previous.Time) {
+			return fmt.Errorf("timestamp %s is not later than the previous archive timestamp %s", token.GenTime.UTC().Format(time.RFC3339), previous.Time.UTC().Format(time.RFC3339))
+		}
+	}
+	r.ArchiveTimestamps = append(r.ArchiveTimestamps, ArchiveTimestamp{
+		Renewal:       renewal,
+		HashAlgorithm: hashAlgorithmName(hash),
+		Time:          token.GenTime.UTC(),
+		Token:         tokenBytes,
+	})
+	return nil
+}
+
+// imprint returns the digest covered by the i-th archive timestamp of kind
+// renewal.
+func (r *Record) imprint(i int, renewal Renewal, hash crypto.Hash) ([]byte, error) {
+	switch renewal {
+	case RenewalInitial:
+		if i != 0 {
+			return nil, fmt.Errorf("archive timestamp %d: only the first archive timestamp can be initial", i)
+		}
+		hashes, err := r.dataHashes(hash)
+		if err != nil {
+			return nil, err
+		}
+		return hashTree(hash, hashes), nil
+	case RenewalTimestamp:
+		if i == 0 {
+			return nil, errors.New("archive timestamp 0: the first archive timestamp must be initial")
+		}
+		previous := r.ArchiveTimestamps[i-1]
+		if previous.HashAlgorithm != hashAlgorithmName(hash) {
+			return nil, fmt.Errorf("archive timestamp %d: a timestamp renewal must use the hash algorithm %s of the previous archive timestamp", i, previous.HashAlgorithm)
+		}
+		return digestOf(hash, previous.Token), nil
+	case RenewalHashTree:
+		if i == 0 {
+			return nil, errors.New("archive timestamp 0: the first archive timestamp must be initial")
+		}
+		var tokens []byte
+		for _, ats := range r.ArchiveTimestamps[:i] {
+			tokens = append(tokens, ats.Token...)
+		}
+		chainHash := digestOf(hash, tokens)
+		hashes, err := r.dataHashes(hash)
+		if err != nil {
+			return nil, err
+		}
+		for j, h := range hashes {
+			hashes[j] = digestOf(hash, append(h, chainHash...))
+		}
+		return hashTree(hash, hashes), nil
+	default:
+		return nil, fmt.Errorf("archive timestamp %d: unknown renewal %q", i, renewal)
+	}
+}
+
+// dataHashes returns the digests of the archived data, i.e. the subject
+// descriptor and the signature envelopes.
+func (r *Record) dataHashes(hash crypto.Hash) ([][]byte, error) {
+	subjectJSON, err := json.Marshal(r.Subject)
+	if err != nil {
+		return nil, err
+	}
+	hashes := [][]byte{digestOf(hash, subjectJSON)}
+	for _, sig := range r.Signatures {
+		hashes = append(hashes, digestOf(hash, sig.Envelope))
+	}
+	return hashes, nil
+}
+
+// hashTree returns the digest of the sorted concatenation of hashes, i.e.
+// the root of a reduced hash tree of a single level.
+func hashTree(hash crypto.Hash, hashes [][]byte) []byte {
+	sorted := append([][]byte{}, hashes...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i], sorted[j]) < 0
+	})
+	return digestOf(hash, bytes.Join(sorted, nil))
+}
+
+func digestOf(hash crypto.Hash, data []byte) []byte {
+	h := hash.New()
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+// Verification is the result of verifying an evidence record.
+type Verification struct {
+	// ExistedAt is the time of the initial archive timestamp, at which the
+	// signatures are proven to have existed.
+	ExistedAt time.Time
+
+	// Signers are the subjects of the signing certificates.
+	Signers []string
+
+	// Timestamps are the verified archive timestamps.
+	Timestamps []VerifiedTimestamp
+}
+
+// VerifiedTimestamp is a verified archive timestamp.
+type VerifiedTimestamp struct {
+	ArchiveTimestamp
+
+	// Authority is the subject of the certificate of the timestamp
+	// authority.
+	Authority string
+
+	// NotAfter is the expiry of the certificate chain of the timestamp
+	// authority, before which the archive timestamp must be renewed.
+	NotAfter time.Time
+}
+
+// Verify verifies the evidence record at the time now.
+//
+// Each archive timestamp must cover the archived data or the previous archive
+// timestamps as declared, and its timestamp authority must chain to roots at
+// the time of the next archive timestamp, or at now for the latest one. The
+// signature envelopes must be intact, sign the subject and have signing
+// certificates valid at the time of the initial archive timestamp.
+func (r *Record) Verify(roots *x509.CertPool, now time.Time) (*Verification, error) {
+	if r.MediaType != MediaType {
+		return nil, fmt.Errorf("unsupported media type %q of evidence record", r.MediaType)
+	}
+	if len(r.ArchiveTimestamps) == 0 {
+		return nil, errors.New("evidence record has no archive timestamp")
+	}
+	tokens := make([]*Token, len(r.ArchiveTimestamps))
+	for i, ats := range r.ArchiveTimestamps {
+		token, err := ParseToken(ats.Token)
+		if err != nil {
+			return nil, fmt.Errorf("archive timestamp %d: %w", i, err)
+		}
+		if i > 0 && !token.GenTime.After(tokens[i-1].GenTime) {
+			return nil, fmt.Errorf("archive timestamp %d: time %s is not later than the previous archive timestamp", i, token.GenTime.UTC().Format(time.RFC3339))
+		}
+		tokens[i] = token
+	}
+
+	verification := &Verification{ExistedAt: tokens[0].GenTime}
+	for i, ats := range r.ArchiveTimestamps {
+		token := tokens[i]
+		hash, err := ParseHashAlgorithm(ats.HashAlgorithm)
+		if err != nil {
+			return nil, fmt.Errorf("archive timestamp %d: %w", i, err)
+		}
+		if token.Hash != hash {
+			return nil, fmt.Errorf("archive timestamp %d: token is computed with %v instead of %s", i, token.Hash, ats.HashAlgorithm)
+		}
+		imprint, err := r.imprint(i, ats.Renewal, hash)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(token.HashedMessage, imprint) {
+			if i == 0 || ats.Renewal == RenewalHashTree {
+				return nil, fmt.Errorf("archive timestamp %d: the archived signatures have been modified", i)
+			}
+			return nil, fmt.Errorf("archive timestamp %d: the previous archive timestamp has been modified", i)
+		}
+		at := now
+		if i+1 < len(tokens) {
+			// the archive timestamp must be valid when renewed
+			at = tokens[i+1].GenTime
+		}
+		chain, err := token.Verify(roots, at)
+		if err != nil {
+			return nil, fmt.Errorf("archive timestamp %d: %w", i, err)
+		}
+		verification.Timestamps = append(verification.Timestamps, VerifiedTimestamp{
+			ArchiveTimestamp: ats,
+			Authority:        chain[0].Subject.String(),
+			NotAfter:         notAfter(chain),
+		})
+	}
+
+	for i, sig := range r.Signatures {
+		signer, err := verifySignature(sig, r.Subject, verification.ExistedAt)
+		if err != nil {
+			return nil, fmt.Errorf("signature %d: %w", i, err)
+		}
+		verification.Signers = append(verification.Signers, signer)
+	}
+	return verification, nil
+}
+
+// verifySignature verifies the integrity of the signature envelope, that it
+// signs subject, and that its certificate chain was valid at the time at. It
+// returns the subject of the signing certificate.
+func verifySignature(sig Signature, subject ocispec.Descriptor, at time.Time) (string, error) {
+	sigEnv, err := signature.ParseEnvelope(sig.MediaType, sig.Envelope)
+	if err != nil {
+		return "", err
+	}
+	content, err := sigEnv.Verify()
+	if err != nil {
+		return "", err
+	}
+	desc, err := envelope.DescriptorFromSignaturePayload(&content.Payload)
+	if err != nil {
+		return "", err
+	}
+	if desc.Digest != subject.Digest || desc.Size != subject.Size || desc.MediaType != subject.MediaType {
+		return "", fmt.Errorf("the signature signs %s instead of the subject %s", desc.Digest, subject.Digest)
+	}
+	if content.SignerInfo.SignedAttributes.SigningTime.After(at) {
+		return "", fmt.Errorf("signing time %s is later than the initial archive timestamp %s", content.SignerInfo.SignedAttributes.SigningTime.UTC().Format(time.RFC3339), at.UTC().Format(time.RFC3339))
+	}
+	if err := nx509.ValidateCodeSigningCertChain(content.SignerInfo.CertificateChain, &at); err != nil {
+		return "", err
+	}
+	return content.SignerInfo.CertificateChain[0].Subject.String(), nil
+}
+
+// notAfter returns the earliest expiry of the certificates in chain.
+func notAfter(chain []*x509.Certificate) time.Time {
+	expiry := chain[0].NotAfter
+	for _, cert := range chain[1:] {
+		if cert.NotAfter.Before(expiry) {
+			expiry = cert.NotAfter
+		}
+	}
+	return expiry
+}
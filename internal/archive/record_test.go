@@ -0,0 +1,156 @@
+package archive
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/notation-core-go/testhelper"
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation-go/signer"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+const mediaTypeJWS = "application/jose+json"
+
+// testSubject returns an artifact descriptor and a signature of it.
+func testSubject(t *testing.T) (ocispec.Descriptor, Signature) {
+	t.Helper()
+	subject := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    digest.FromString("artifact"),
+		Size:      8,
+	}
+	leaf, root := testhelper.GetRSALeafCertificate(), testhelper.GetRSARootCertificate()
+	s, err := signer.New(leaf.PrivateKey, []*x509.Certificate{leaf.Cert, root.Cert})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, _, err := s.Sign(context.Background(), subject, notation.SignerSignOptions{SignatureMediaType: mediaTypeJWS})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return subject, Signature{MediaType: mediaTypeJWS, Envelope: sig}
+}
+
+func TestRecord_RenewAndVerify(t *testing.T) {
+	now := time.Now()
+	subject, sig := testSubject(t)
+	tsa := newTestTSA(t, now.Add(-time.Hour), now.Add(72*time.Hour))
+	tsa.clock = now.Add(time.Minute)
+	timestamper := newTestTimestamper(t, tsa)
+
+	record, err := New(context.Background(), subject, []Signature{sig}, timestamper, crypto.SHA256)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	// renewed after the signing certificate expires, before the timestamp
+	// authority of the initial archive timestamp expires
+	tsa.clock = now.Add(48 * time.Hour)
+	if renewal, err := record.Renew(context.Background(), timestamper, crypto.SHA256); err != nil || renewal != RenewalTimestamp {
+		t.Fatalf("Renew() = %q, %v, want timestamp renewal", renewal, err)
+	}
+
+	// renewed by another timestamp authority with another hash algorithm
+	nextTSA := newTestTSA(t, now.Add(24*time.Hour), now.Add(30*24*time.Hour))
+	nextTSA.clock = now.Add(60 * time.Hour)
+	if renewal, err := record.Renew(context.Background(), newTestTimestamper(t, nextTSA), crypto.SHA384); err != nil || renewal != RenewalHashTree {
+		t.Fatalf("Renew() = %q, %v, want hash-tree renewal", renewal, err)
+	}
+
+	// round trip
+	recordJSON, err := json.Marshal(record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Record
+	if err := json.Unmarshal(recordJSON, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	roots := tsa.roots()
+	roots.AddCert(nextTSA.root)
+	verification, err := got.Verify(roots, now.Add(10*24*time.Hour))
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if len(verification.Timestamps) != 3 || len(verification.Signers) != 1 || !verification.ExistedAt.Equal(record.ArchiveTimestamps[0].Time) {
+		t.Fatalf("unexpected verification: %+v", verification)
+	}
+
+	// the latest timestamp authority has expired
+	if _, err := got.Verify(roots, now.Add(31*24*time.Hour)); err == nil || !strings.Contains(err.Error(), "archive timestamp 2") {
+		t.Fatalf("expected expired archive timestamp 2, got %v", err)
+	}
+}
+
+func TestRecord_RenewTooLate(t *testing.T) {
+	now := time.Now()
+	subject, sig := testSubject(t)
+	tsa := newTestTSA(t, now.Add(-time.Hour), now.Add(time.Hour))
+	tsa.clock = now.Add(time.Minute)
+	timestamper := newTestTimestamper(t, tsa)
+	record, err := New(context.Background(), subject, []Signature{sig}, timestamper, crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// renewed after the timestamp authority of the initial archive timestamp
+	// expired, which breaks the chain of evidence
+	nextTSA := newTestTSA(t, now.Add(-time.Hour), now.Add(72*time.Hour))
+	nextTSA.clock = now.Add(2 * time.Hour)
+	if _, err := record.Renew(context.Background(), newTestTimestamper(t, nextTSA), crypto.SHA256); err != nil {
+		t.Fatal(err)
+	}
+	roots := tsa.roots()
+	roots.AddCert(nextTSA.root)
+	if _, err := record.Verify(roots, now.Add(3*time.Hour)); err == nil || !strings.Contains(err.Error(), "archive timestamp 0") {
+		t.Fatalf("expected archive timestamp 0 to be invalid at the renewal, got %v", err)
+	}
+}
+
+func TestRecord_VerifyTampered(t *testing.T) {
+	now := time.Now()
+	subject, sig := testSubject(t)
+	tsa := newTestTSA(t, now.Add(-time.Hour), now.Add(72*time.Hour))
+	tsa.clock = now.Add(time.Minute)
+	timestamper := newTestTimestamper(t, tsa)
+	record, err := New(context.Background(), subject, []Signature{sig}, timestamper, crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, otherSig := testSubject(t)
+	other, err := New(context.Background(), subject, []Signature{otherSig}, timestamper, crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tsa.clock = tsa.clock.Add(time.Hour)
+	if _, err := record.Renew(context.Background(), timestamper, crypto.SHA256); err != nil {
+		t.Fatal(err)
+	}
+
+	tampered := *record
+	tampered.Subject.Size++
+	if _, err := tampered.Verify(tsa.roots(), now.Add(2*time.Hour)); err == nil || !strings.Contains(err.Error(), "archived signatures have been modified") {
+		t.Fatalf("expected modified signatures, got %v", err)
+	}
+
+	tampered = *record
+	tampered.ArchiveTimestamps = append([]ArchiveTimestamp{}, record.ArchiveTimestamps...)
+	tampered.ArchiveTimestamps[0] = other.ArchiveTimestamps[0]
+	if _, err := tampered.Verify(tsa.roots(), now.Add(2*time.Hour)); err == nil || !strings.Contains(err.Error(), "archive timestamp 0") {
+		t.Fatalf("expected replaced archive timestamp 0, got %v", err)
+	}
+}
+
+func TestNew_NoSignature(t *testing.T) {
+	if _, err := New(context.Background(), ocispec.Descriptor{}, nil, nil, crypto.SHA256); err == nil {
+		t.Fatal("expected error of no signature")
+	}
+}
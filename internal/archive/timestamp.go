@@ -0,0 +1,462 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"time"
+)
+
+// maxResponseSize is the maximum size of timestamp responses.
+const maxResponseSize = 1024 * 1024
+
+// object identifiers of RFC 3161 and RFC 5652
+var (
+	oidSignedData    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidTSTInfo       = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 1, 4}
+	oidContentType   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+	oidMessageDigest = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+	oidRSAPSS        = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 10}
+)
+
+// hashOIDs are the object identifiers of the supported hash algorithms.
+var hashOIDs = map[crypto.Hash]asn1.ObjectIdentifier{
+	crypto.SHA256: {2, 16, 840, 1, 101, 3, 4, 2, 1},
+	crypto.SHA384: {2, 16, 840, 1, 101, 3, 4, 2, 2},
+	crypto.SHA512: {2, 16, 840, 1, 101, 3, 4, 2, 3},
+}
+
+// messageImprint is the hash of the data timestamped.
+type messageImprint struct {
+	HashAlgorithm pkix.AlgorithmIdentifier
+	HashedMessage []byte
+}
+
+// timeStampReq is a timestamp request of RFC 3161.
+type timeStampReq struct {
+	Version        int
+	MessageImprint messageImprint
+	Nonce          *big.Int `asn1:"optional"`
+	CertReq        bool     `asn1:"optional,default:false"`
+}
+
+// pkiStatusInfo is the status of a timestamp response.
+type pkiStatusInfo struct {
+	Status       int
+	StatusString []string       `asn1:"optional"`
+	FailInfo     asn1.BitString `asn1:"optional"`
+}
+
+// timeStampResp is a timestamp response of RFC 3161.
+type timeStampResp struct {
+	Status         pkiStatusInfo
+	TimeStampToken asn1.RawValue `asn1:"optional"`
+}
+
+// contentInfo is a CMS content info of RFC 5652.
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,tag:0"`
+}
+
+// rawCertificates is the certificate set of a signed data.
+type rawCertificates struct {
+	Raw asn1.RawContent
+}
+
+// signedData is a CMS signed data of RFC 5652.
+type signedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	EncapContentInfo encapsulatedContentInfo
+	Certificates     rawCertificates `asn1:"optional,tag:0"`
+	CRLs             []asn1.RawValue `asn1:"optional,tag:1"`
+	SignerInfos      []signerInfo    `asn1:"set"`
+}
+
+// encapsulatedContentInfo is the content of a signed data.
+type encapsulatedContentInfo struct {
+	EContentType asn1.ObjectIdentifier
+	EContent     []byte `asn1:"explicit,optional,tag:0"`
+}
+
+// signerInfo is a signer of a signed data. The signed attributes are
+// required for timestamp tokens.
+type signerInfo struct {
+	Version            int
+	SID                asn1.RawValue
+	DigestAlgorithm    pkix.AlgorithmIdentifier
+	SignedAttrs        asn1.RawValue
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          []byte
+}
+
+// issuerAndSerialNumber identifies a signer by its certificate.
+type issuerAndSerialNumber struct {
+	Issuer       asn1.RawValue
+	SerialNumber *big.Int
+}
+
+// attribute is a signed attribute.
+type attribute struct {
+	Type   asn1.ObjectIdentifier
+	Values asn1.RawValue
+}
+
+// accuracy is the accuracy of the time of a timestamp.
+type accuracy struct {
+	Seconds int `asn1:"optional"`
+	Millis  int `asn1:"optional,tag:0"`
+	Micros  int `asn1:"optional,tag:1"`
+}
+
+// tstInfo is the content of a timestamp token. The fields following the
+// nonce are not used.
+type tstInfo struct {
+	Version        int
+	Policy         asn1.ObjectIdentifier
+	MessageImprint messageImprint
+	SerialNumber   *big.Int
+	GenTime        time.Time `asn1:"generalized"`
+	Accuracy       accuracy  `asn1:"optional"`
+	Ordering       bool      `asn1:"optional,default:false"`
+	Nonce          *big.Int  `asn1:"optional"`
+}
+
+// Timestamper obtains RFC 3161 timestamp tokens of digests.
+type Timestamper interface {
+	// Timestamp returns the DER-encoded timestamp token of digest, computed
+	// with hash.
+	Timestamp(ctx context.Context, hash crypto.Hash, digest []byte) ([]byte, error)
+}
+
+// HTTPTimestamper requests timestamp tokens from a timestamp authority over
+// HTTP as specified by RFC 3161.
+type HTTPTimestamper struct {
+	// URL is the URL of the timestamp authority.
+	URL string
+
+	// HTTPClient is the client of the timestamp authority.
+	// http.DefaultClient is used if nil.
+	HTTPClient *http.Client
+}
+
+// Timestamp requests the timestamp token of digest from the timestamp
+// authority.
+func (t *HTTPTimestamper) Timestamp(ctx context.Context, hash crypto.Hash, digest []byte) ([]byte, error) {
+	hashOID, ok := hashOIDs[hash]
+	if !ok {
+		return nil, fmt.Errorf("hash algorithm %v not supported", hash)
+	}
+	nonce, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 64))
+	if err != nil {
+		return nil, err
+	}
+	req, err := asn1.Marshal(timeStampReq{
+		Version: 1,
+		MessageImprint: messageImprint{
+			HashAlgorithm: pkix.AlgorithmIdentifier{Algorithm: hashOID, Parameters: asn1.NullRawValue},
+			HashedMessage: digest,
+		},
+		Nonce:   nonce,
+		CertReq: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, t.URL, bytes.NewReader(req))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/timestamp-query")
+	client := t.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request timestamp from %s: %w", t.URL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to request timestamp from %s: %s", t.URL, resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read timestamp response from %s: %w", t.URL, err)
+	}
+	if len(body) > maxResponseSize {
+		return nil, fmt.Errorf("timestamp response from %s exceeds the limit of %d bytes", t.URL, maxResponseSize)
+	}
+	var tsResp timeStampResp
+	if _, err := asn1.Unmarshal(body, &tsResp); err != nil {
+		return nil, fmt.Errorf("malformed timestamp response from %s: %w", t.URL, err)
+	}
+	// 0: granted, 1: granted with modifications
+	if tsResp.Status.Status > 1 {
+		return nil, fmt.Errorf("timestamp request rejected by %s with status %d %v", t.URL, tsResp.Status.Status, tsResp.Status.StatusString)
+	}
+	if len(tsResp.TimeStampToken.FullBytes) == 0 {
+		return nil, fmt.Errorf("timestamp response from %s has no timestamp token", t.URL)
+	}
+	token, err := ParseToken(tsResp.TimeStampToken.FullBytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timestamp token from %s: %w", t.URL, err)
+	}
+	if token.nonce == nil || token.nonce.Cmp(nonce) != 0 {
+		return nil, fmt.Errorf("timestamp token from %s does not match the nonce of the request", t.URL)
+	}
+	if token.Hash != hash || !bytes.Equal(token.HashedMessage, digest) {
+		return nil, fmt.Errorf("timestamp token from %s does not match the digest of the request", t.URL)
+	}
+	return tsResp.TimeStampToken.FullBytes, nil
+}
+
+// Token is a parsed RFC 3161 timestamp token.
+type Token struct {
+	// GenTime is the time the timestamp authority issued the token.
+	GenTime time.Time
+
+	// Hash is the hash algorithm of the timestamped digest.
+	Hash crypto.Hash
+
+	// HashedMessage is the timestamped digest.
+	HashedMessage []byte
+
+	// Certificates are the certificates in the token.
+	Certificates []*x509.Certificate
+
+	nonce  *big.Int
+	signed signedData
+	info   []byte
+}
+
+// ParseToken parses a DER-encoded timestamp token without verifying it.
+func ParseToken(der []byte) (*Token, error) {
+	var ci contentInfo
+	if rest, err := asn1.Unmarshal(der, &ci); err != nil {
+		return nil, err
+	} else if len(rest) > 0 {
+		return nil, errors.New("trailing data after timestamp token")
+	}
+	if !ci.ContentType.Equal(oidSignedData) {
+		return nil, fmt.Errorf("unexpected content type %v of timestamp token", ci.ContentType)
+	}
+	var sd signedData
+	if _, err := asn1.Unmarshal(ci.Content.Bytes, &sd); err != nil {
+		return nil, fmt.Errorf("malformed signed data: %w", err)
+	}
+	if !sd.EncapContentInfo.EContentType.Equal(oidTSTInfo) {
+		return nil, fmt.Errorf("unexpected content type %v of signed data", sd.EncapContentInfo.EContentType)
+	}
+	if len(sd.SignerInfos) != 1 {
+		return nil, fmt.Errorf("expecting 1 signer of timestamp token, got %d", len(sd.SignerInfos))
+	}
+	var info tstInfo
+	if _, err := asn1.Unmarshal(sd.EncapContentInfo.EContent, &info); err != nil {
+		return nil, fmt.Errorf("malformed TSTInfo: %w", err)
+	}
+	hash, err := hashFromOID(info.MessageImprint.HashAlgorithm.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	token := &Token{
+		GenTime:       info.GenTime,
+		Hash:          hash,
+		HashedMessage: info.MessageImprint.HashedMessage,
+		nonce:         info.Nonce,
+		signed:        sd,
+		info:          sd.EncapContentInfo.EContent,
+	}
+	if len(sd.Certificates.Raw) > 0 {
+		var set asn1.RawValue
+		if _, err := asn1.Unmarshal(sd.Certificates.Raw, &set); err != nil {
+			return nil, fmt.Errorf("malformed certificates: %w", err)
+		}
+		token.Certificates, err = x509.ParseCertificates(set.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("malformed certificates: %w", err)
+		}
+	}
+	return token, nil
+}
+
+// Verify verifies the signature of the timestamp authority on the token and
+// returns the certificate chain of the timestamp authority, which is built
+// from the certificates of the token up to roots and valid at the time at.
+func (t *Token) Verify(roots *x509.CertPool, at time.Time) ([]*x509.Certificate, error) {
+	signer := t.signed.SignerInfos[0]
+	cert, err := t.signerCertificate(signer.SID)
+	if err != nil {
+		return nil, err
+	}
+
+	// the signed attributes bind the content to the signature
+	if signer.SignedAttrs.Class != asn1.ClassContextSpecific || signer.SignedAttrs.Tag != 0 {
+		return nil, errors.New("timestamp token has no signed attributes")
+	}
+	signedAttrs := append([]byte{}, signer.SignedAttrs.FullBytes...)
+	signedAttrs[0] = 0x31 // SET OF, as signed
+	var attrs []attribute
+	if _, err := asn1.UnmarshalWithParams(signedAttrs, &attrs, "set"); err != nil {
+		return nil, fmt.Errorf("malformed signed attributes: %w", err)
+	}
+	digestHash, err := hashFromOID(signer.DigestAlgorithm.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	var contentTypeOK, messageDigestOK bool
+	for _, attr := range attrs {
+		switch {
+		case attr.Type.Equal(oidContentType):
+			var contentType asn1.ObjectIdentifier
+			if _, err := asn1.Unmarshal(attr.Values.Bytes, &contentType); err != nil {
+				return nil, fmt.Errorf("malformed content type attribute: %w", err)
+			}
+			contentTypeOK = contentType.Equal(oidTSTInfo)
+		case attr.Type.Equal(oidMessageDigest):
+			var messageDigest []byte
+			if _, err := asn1.Unmarshal(attr.Values.Bytes, &messageDigest); err != nil {
+				return nil, fmt.Errorf("malformed message digest attribute: %w", err)
+			}
+			h := digestHash.New()
+			h.Write(t.info)
+			messageDigestOK = bytes.Equal(messageDigest, h.Sum(nil))
+		}
+	}
+	if !contentTypeOK {
+		return nil, errors.New("content type attribute of timestamp token is missing or mismatched")
+	}
+	if !messageDigestOK {
+		return nil, errors.New("message digest attribute of timestamp token is missing or mismatched")
+	}
+	sigAlg, err := signatureAlgorithm(cert, digestHash, signer.SignatureAlgorithm.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	if err := cert.CheckSignature(sigAlg, signedAttrs, signer.Signature); err != nil {
+		return nil, fmt.Errorf("invalid signature of timestamp token: %w", err)
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, c := range t.Certificates {
+		intermediates.AddCert(c)
+	}
+	chains, err := cert.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   at,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageTimeStamping},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("untrusted timestamp authority %q at %s: %w", cert.Subject, at.UTC().Format(time.RFC3339), err)
+	}
+	return chains[0], nil
+}
+
+// VerifyCountersignature parses the timestamp token der countersigning a
+// signature, whose value is signatureValue and whose signature algorithm uses
+// hash, and verifies it against roots. It returns the token and the
+// certificate chain of the timestamp authority, which must be valid at the
+// time of the token.
+func VerifyCountersignature(der []byte, hash crypto.Hash, signatureValue []byte, roots *x509.CertPool) (*Token, []*x509.Certificate, error) {
+	token, err := ParseToken(der)
+	if err != nil {
+		return nil, nil, fmt.Errorf("malformed timestamp token: %w", err)
+	}
+	if !hash.Available() {
+		return nil, nil, fmt.Errorf("hash algorithm %v of the signature is not available", hash)
+	}
+	h := hash.New()
+	h.Write(signatureValue)
+	if token.Hash != hash || !bytes.Equal(token.HashedMessage, h.Sum(nil)) {
+		return nil, nil, errors.New("timestamp token does not match the signature")
+	}
+	chain, err := token.Verify(roots, token.GenTime)
+	if err != nil {
+		return nil, nil, err
+	}
+	return token, chain, nil
+}
+
+// signerCertificate returns the certificate of the signer identified by sid.
+func (t *Token) signerCertificate(sid asn1.RawValue) (*x509.Certificate, error) {
+	switch {
+	case sid.Class == asn1.ClassUniversal && sid.Tag == asn1.TagSequence:
+		var ias issuerAndSerialNumber
+		if _, err := asn1.Unmarshal(sid.FullBytes, &ias); err != nil {
+			return nil, fmt.Errorf("malformed signer identifier: %w", err)
+		}
+		for _, cert := range t.Certificates {
+			if bytes.Equal(cert.RawIssuer, ias.Issuer.FullBytes) && cert.SerialNumber.Cmp(ias.SerialNumber) == 0 {
+				return cert, nil
+			}
+		}
+	case sid.Class == asn1.ClassContextSpecific && sid.Tag == 0:
+		for _, cert := range t.Certificates {
+			if bytes.Equal(cert.SubjectKeyId, sid.Bytes) {
+				return cert, nil
+			}
+		}
+	default:
+		return nil, errors.New("malformed signer identifier")
+	}
+	return nil, errors.New("certificate of the timestamp authority is not in the timestamp token")
+}
+
+// signatureAlgorithm returns the algorithm of a signature made by cert over
+// data hashed with hash.
+func signatureAlgorithm(cert *x509.Certificate, hash crypto.Hash, sigOID asn1.ObjectIdentifier) (x509.SignatureAlgorithm, error) {
+	switch cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		pss := sigOID.Equal(oidRSAPSS)
+		switch hash {
+		case crypto.SHA256:
+			if pss {
+				return x509.SHA256WithRSAPSS, nil
+			}
+			return x509.SHA256WithRSA, nil
+		case crypto.SHA384:
+			if pss {
+				return x509.SHA384WithRSAPSS, nil
+			}
+			return x509.SHA384WithRSA, nil
+		case crypto.SHA512:
+			if pss {
+				return x509.SHA512WithRSAPSS, nil
+			}
+			return x509.SHA512WithRSA, nil
+		}
+	case *ecdsa.PublicKey:
+		switch hash {
+		case crypto.SHA256:
+			return x509.ECDSAWithSHA256, nil
+		case crypto.SHA384:
+			return x509.ECDSAWithSHA384, nil
+		case crypto.SHA512:
+			return x509.ECDSAWithSHA512, nil
+		}
+	}
+	return x509.UnknownSignatureAlgorithm, fmt.Errorf("signature algorithm %v of timestamp token not supported", sigOID)
+}
+
+// hashFromOID returns the hash algorithm identified by oid.
+func hashFromOID(oid asn1.ObjectIdentifier) (crypto.Hash, error) {
+	for hash, hashOID := range hashOIDs {
+		if oid.Equal(hashOID) {
+			return hash, nil
+		}
+	}
+	return 0, fmt.Errorf("hash algorithm %v not supported", oid)
+}
@@ -0,0 +1,299 @@
+package archive
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testTSA is a timestamp authority issuing tokens at the time of its clock.
+type testTSA struct {
+	t     *testing.T
+	root  *x509.Certificate
+	cert  *x509.Certificate
+	key   *ecdsa.PrivateKey
+	clock time.Time
+}
+
+// newTestTSA returns a timestamp authority whose certificate, issued by a
+// new root, is valid from notBefore to notAfter.
+func newTestTSA(t *testing.T, notBefore, notAfter time.Time) *testTSA {
+	t.Helper()
+	rootKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rootTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test TSA Root"},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter.Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	rootDER, err := x509.CreateCertificate(rand.Reader, rootTemplate, rootTemplate, rootKey.Public(), rootKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := x509.ParseCertificate(rootDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "Test TSA"},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageTimeStamping},
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, root, key.Public(), rootKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(certDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testTSA{t: t, root: root, cert: cert, key: key, clock: notBefore.Add(time.Minute)}
+}
+
+// roots returns the pool of the root of the timestamp authority.
+func (a *testTSA) roots() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(a.root)
+	return pool
+}
+
+// token returns a timestamp token of the message imprint and the nonce.
+func (a *testTSA) token(imprint messageImprint, nonce *big.Int) []byte {
+	a.t.Helper()
+	info, err := asn1.Marshal(tstInfo{
+		Version:        1,
+		Policy:         asn1.ObjectIdentifier{1, 2, 3},
+		MessageImprint: imprint,
+		SerialNumber:   big.NewInt(a.clock.UnixNano()),
+		GenTime:        a.clock.UTC(),
+		Nonce:          nonce,
+	})
+	if err != nil {
+		a.t.Fatal(err)
+	}
+	contentType, err := asn1.Marshal(oidTSTInfo)
+	if err != nil {
+		a.t.Fatal(err)
+	}
+	infoDigest := sha256.Sum256(info)
+	messageDigest, err := asn1.Marshal(infoDigest[:])
+	if err != nil {
+		a.t.Fatal(err)
+	}
+	attrs, err := asn1.MarshalWithParams([]attribute{
+		{Type: oidContentType, Values: asn1.RawValue{Tag: asn1.TagSet, IsCompound: true, Bytes: contentType}},
+		{Type: oidMessageDigest, Values: asn1.RawValue{Tag: asn1.TagSet, IsCompound: true, Bytes: messageDigest}},
+	}, "set")
+	if err != nil {
+		a.t.Fatal(err)
+	}
+	attrsDigest := sha256.Sum256(attrs)
+	sig, err := a.key.Sign(rand.Reader, attrsDigest[:], crypto.SHA256)
+	if err != nil {
+		a.t.Fatal(err)
+	}
+	signedAttrs := append([]byte{}, attrs...)
+	signedAttrs[0] = 0xa0 // [0] IMPLICIT
+	sha256Alg := pkix.AlgorithmIdentifier{Algorithm: hashOIDs[crypto.SHA256]}
+	sd, err := asn1.Marshal(struct {
+		Version          int
+		DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+		EncapContentInfo encapsulatedContentInfo
+		Certificates     asn1.RawValue
+		SignerInfos      []signerInfo `asn1:"set"`
+	}{
+		Version:          3,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{sha256Alg},
+		EncapContentInfo: encapsulatedContentInfo{EContentType: oidTSTInfo, EContent: info},
+		Certificates:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: a.cert.Raw},
+		SignerInfos: []signerInfo{{
+			Version:            1,
+			SID:                asn1.RawValue{FullBytes: a.issuerAndSerialNumber()},
+			DigestAlgorithm:    sha256Alg,
+			SignedAttrs:        asn1.RawValue{FullBytes: signedAttrs},
+			SignatureAlgorithm: pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}},
+			Signature:          sig,
+		}},
+	})
+	if err != nil {
+		a.t.Fatal(err)
+	}
+	token, err := asn1.Marshal(contentInfo{
+		ContentType: oidSignedData,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: sd},
+	})
+	if err != nil {
+		a.t.Fatal(err)
+	}
+	return token
+}
+
+func (a *testTSA) issuerAndSerialNumber() []byte {
+	sid, err := asn1.Marshal(issuerAndSerialNumber{
+		Issuer:       asn1.RawValue{FullBytes: a.cert.RawIssuer},
+		SerialNumber: a.cert.SerialNumber,
+	})
+	if err != nil {
+		a.t.Fatal(err)
+	}
+	return sid
+}
+
+// ServeHTTP serves RFC 3161 timestamp requests.
+func (a *testTSA) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		a.t.Error(err)
+		return
+	}
+	var req timeStampReq
+	if _, err := asn1.Unmarshal(body, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	resp, err := asn1.Marshal(timeStampResp{
+		Status:         pkiStatusInfo{Status: 0},
+		TimeStampToken: asn1.RawValue{FullBytes: a.token(req.MessageImprint, req.Nonce)},
+	})
+	if err != nil {
+		a.t.Error(err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/timestamp-reply")
+	w.Write(resp)
+}
+
+// newTestTimestamper returns a timestamper of a test server of tsa.
+func newTestTimestamper(t *testing.T, tsa *testTSA) *HTTPTimestamper {
+	server := httptest.NewServer(tsa)
+	t.Cleanup(server.Close)
+	return &HTTPTimestamper{URL: server.URL}
+}
+
+func TestHTTPTimestamper(t *testing.T) {
+	now := time.Now()
+	tsa := newTestTSA(t, now.Add(-time.Hour), now.Add(time.Hour))
+	timestamper := newTestTimestamper(t, tsa)
+
+	digest := sha256.Sum256([]byte("data"))
+	tokenBytes, err := timestamper.Timestamp(context.Background(), crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatalf("Timestamp() error = %v", err)
+	}
+	token, err := ParseToken(tokenBytes)
+	if err != nil {
+		t.Fatalf("ParseToken() error = %v", err)
+	}
+	if !token.GenTime.Equal(tsa.clock.UTC().Truncate(time.Second)) || token.Hash != crypto.SHA256 || string(token.HashedMessage) != string(digest[:]) {
+		t.Fatalf("unexpected token: %+v", token)
+	}
+	chain, err := token.Verify(tsa.roots(), now)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if chain[0].Subject.CommonName != "Test TSA" {
+		t.Fatalf("unexpected chain: %v", chain[0].Subject)
+	}
+
+	if _, err := token.Verify(x509.NewCertPool(), now); err == nil || !strings.Contains(err.Error(), "untrusted timestamp authority") {
+		t.Fatalf("expected untrusted timestamp authority, got %v", err)
+	}
+	if _, err := token.Verify(tsa.roots(), now.Add(2*time.Hour)); err == nil {
+		t.Fatal("expected error of expired timestamp authority")
+	}
+}
+
+func TestHTTPTimestamper_Rejected(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp, err := asn1.Marshal(timeStampResp{Status: pkiStatusInfo{Status: 2, StatusString: []string{"bad request"}}})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		w.Write(resp)
+	}))
+	defer server.Close()
+
+	digest := sha256.Sum256([]byte("data"))
+	_, err := (&HTTPTimestamper{URL: server.URL}).Timestamp(context.Background(), crypto.SHA256, digest[:])
+	if err == nil || !strings.Contains(err.Error(), "rejected") {
+		t.Fatalf("expected rejected request, got %v", err)
+	}
+}
+
+func TestToken_VerifyTampered(t *testing.T) {
+	now := time.Now()
+	tsa := newTestTSA(t, now.Add(-time.Hour), now.Add(time.Hour))
+	digest := sha256.Sum256([]byte("data"))
+	tokenBytes := tsa.token(messageImprint{
+		HashAlgorithm: pkix.AlgorithmIdentifier{Algorithm: hashOIDs[crypto.SHA256]},
+		HashedMessage: digest[:],
+	}, nil)
+	token, err := ParseToken(tokenBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token.info = append([]byte{}, token.info...)
+	token.info[len(token.info)-1] ^= 0xff
+	if _, err := token.Verify(tsa.roots(), now); err == nil || !strings.Contains(err.Error(), "message digest") {
+		t.Fatalf("expected message digest mismatch, got %v", err)
+	}
+}
+
+func TestVerifyCountersignature(t *testing.T) {
+	now := time.Now()
+	tsa := newTestTSA(t, now.Add(-time.Hour), now.Add(time.Hour))
+	signatureValue := []byte("signature")
+	digest := sha256.Sum256(signatureValue)
+	tokenBytes := tsa.token(messageImprint{
+		HashAlgorithm: pkix.AlgorithmIdentifier{Algorithm: hashOIDs[crypto.SHA256]},
+		HashedMessage: digest[:],
+	}, nil)
+
+	token, chain, err := VerifyCountersignature(tokenBytes, crypto.SHA256, signatureValue, tsa.roots())
+	if err != nil {
+		t.Fatalf("VerifyCountersignature() error = %v", err)
+	}
+	if !token.GenTime.Equal(tsa.clock.UTC().Truncate(time.Second)) || chain[0].Subject.CommonName != "Test TSA" {
+		t.Fatalf("unexpected token %+v, chain %v", token, chain)
+	}
+
+	if _, _, err := VerifyCountersignature(tokenBytes, crypto.SHA256, []byte("other signature"), tsa.roots()); err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Fatalf("expected mismatched signature, got %v", err)
+	}
+	if _, _, err := VerifyCountersignature(tokenBytes, crypto.SHA384, signatureValue, tsa.roots()); err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Fatalf("expected mismatched hash algorithm, got %v", err)
+	}
+	if _, _, err := VerifyCountersignature(tokenBytes, crypto.SHA256, signatureValue, x509.NewCertPool()); err == nil || !strings.Contains(err.Error(), "untrusted timestamp authority") {
+		t.Fatalf("expected untrusted timestamp authority, got %v", err)
+	}
+	if _, _, err := VerifyCountersignature([]byte("token"), crypto.SHA256, signatureValue, tsa.roots()); err == nil || !strings.Contains(err.Error(), "malformed") {
+		t.Fatalf("expected malformed token, got %v", err)
+	}
+}
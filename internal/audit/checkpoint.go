@@ -0,0 +1,145 @@
+// Package audit provides building blocks for verifying every artifact of a
+// repository in a single, possibly long running, operation.
+package audit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/notaryproject/notation/internal/osutil"
+)
+
+// Result is the verification result of an artifact.
+type Result string
+
+const (
+	// ResultSuccess indicates that the artifact is successfully verified.
+	ResultSuccess Result = "success"
+
+	// ResultFailure indicates that the artifact failed verification.
+	ResultFailure Result = "failure"
+)
+
+// Entry is the verification result of a tag in a repository.
+type Entry struct {
+	// Tag is the tag of the artifact.
+	Tag string `json:"tag"`
+
+	// Digest is the digest the tag resolved to.
+	Digest string `json:"digest,omitempty"`
+
+	// Result is the verification result.
+	Result Result `json:"result"`
+
+	// Error is the reason of a failed verification.
+	Error string `json:"error,omitempty"`
+}
+
+// Checkpoint tracks the progress of a repository audit so that an
+// interrupted audit can resume where it left off.
+type Checkpoint struct {
+	// Repository is the repository being audited.
+	Repository string `json:"repository"`
+
+	// Entries are the latest results of the tags audited so far, one per
+	// tag.
+	Entries []Entry `json:"entries"`
+
+	path string
+
+	// index is the index of the entry of each tag in Entries.
+	index map[string]int
+}
+
+// LoadCheckpoint loads the checkpoint of the audit of repository from path.
+// A new checkpoint is returned if the file does not exist. If path is empty,
+// the checkpoint is kept in memory only.
+func LoadCheckpoint(path, repository string) (*Checkpoint, error) {
+	checkpoint := &Checkpoint{
+		Repository: repository,
+		path:       path,
+		index:      make(map[string]int),
+	}
+	if path == "" {
+		return checkpoint, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return checkpoint, nil
+		}
+		return nil, fmt.Errorf("failed to read checkpoint file: %w", err)
+	}
+	if err := json.Unmarshal(data, checkpoint); err != nil {
+		return nil, fmt.Errorf("malformed checkpoint file %s: %w", path, err)
+	}
+	if checkpoint.Repository != repository {
+		return nil, fmt.Errorf("checkpoint file %s was created for repository %q, not %q", path, checkpoint.Repository, repository)
+	}
+	entries := checkpoint.Entries
+	checkpoint.Entries = nil
+	for _, entry := range entries {
+		checkpoint.record(entry)
+	}
+	return checkpoint, nil
+}
+
+// Done returns true if the tag resolving to digest has already been verified
+// successfully. Failed tags and tags re-pushed to a new digest are verified
+// again.
+func (c *Checkpoint) Done(tag, digest string) bool {
+	i, ok := c.index[tag]
+	if !ok {
+		return false
+	}
+	entry := c.Entries[i]
+	return entry.Result == ResultSuccess && entry.Digest == digest
+}
+
+// Entry returns the latest result of the tag, if any.
+func (c *Checkpoint) Entry(tag string) (Entry, bool) {
+	i, ok := c.index[tag]
+	if !ok {
+		return Entry{}, false
+	}
+	return c.Entries[i], true
+}
+
+// Record records the result of a tag, replacing the previous result of the
+// tag, and persists the checkpoint.
+func (c *Checkpoint) Record(entry Entry) error {
+	c.record(entry)
+	if c.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(c, "", "    ")
+	if err != nil {
+		return err
+	}
+	return osutil.WriteFileAtomic(c.path, data, 0600)
+}
+
+// record records the result of a tag in memory.
+func (c *Checkpoint) record(entry Entry) {
+	if i, ok := c.index[entry.Tag]; ok {
+		c.Entries[i] = entry
+		return
+	}
+	c.index[entry.Tag] = len(c.Entries)
+	c.Entries = append(c.Entries, entry)
+}
+
+// Count returns the number of successful and failed entries.
+func (c *Checkpoint) Count() (succeeded, failed int) {
+	for _, entry := range c.Entries {
+		if entry.Result == ResultSuccess {
+			succeeded++
+		} else {
+			failed++
+		}
+	}
+	return succeeded, failed
+}
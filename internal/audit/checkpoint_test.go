@@ -0,0 +1,72 @@
+package audit
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckpoint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit", "checkpoint.json")
+	checkpoint, err := LoadCheckpoint(path, "localhost:5000/net-monitor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if checkpoint.Done("v1", "sha256:abc") {
+		t.Fatal("expected v1 not to be done")
+	}
+	if err := checkpoint.Record(Entry{Tag: "v1", Digest: "sha256:abc", Result: ResultSuccess}); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkpoint.Record(Entry{Tag: "v2", Result: ResultFailure, Error: "no signature"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// resume from the persisted checkpoint
+	resumed, err := LoadCheckpoint(path, "localhost:5000/net-monitor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resumed.Done("v1", "sha256:abc") || resumed.Done("v3", "sha256:abc") {
+		t.Fatalf("unexpected resumed entries: %+v", resumed.Entries)
+	}
+	// failed tags are verified again
+	if resumed.Done("v2", "") {
+		t.Fatal("expected failed v2 not to be done")
+	}
+	// a tag re-pushed to a new digest is verified again
+	if resumed.Done("v1", "sha256:def") {
+		t.Fatal("expected re-pushed v1 not to be done")
+	}
+	if succeeded, failed := resumed.Count(); succeeded != 1 || failed != 1 {
+		t.Fatalf("expected 1 succeeded and 1 failed, got %d and %d", succeeded, failed)
+	}
+
+	// the result of a retried tag replaces its previous result
+	if err := resumed.Record(Entry{Tag: "v2", Digest: "sha256:123", Result: ResultSuccess}); err != nil {
+		t.Fatal(err)
+	}
+	if !resumed.Done("v2", "sha256:123") {
+		t.Fatal("expected retried v2 to be done")
+	}
+	if succeeded, failed := resumed.Count(); succeeded != 2 || failed != 0 || len(resumed.Entries) != 2 {
+		t.Fatalf("expected 2 succeeded and 0 failed, got %d and %d", succeeded, failed)
+	}
+
+	// a checkpoint cannot be reused for another repository
+	if _, err := LoadCheckpoint(path, "localhost:5000/other"); err == nil {
+		t.Fatal("expected error for repository mismatch, got nil")
+	}
+}
+
+func TestCheckpoint_InMemory(t *testing.T) {
+	checkpoint, err := LoadCheckpoint("", "localhost:5000/net-monitor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := checkpoint.Record(Entry{Tag: "v1", Digest: "sha256:abc", Result: ResultSuccess}); err != nil {
+		t.Fatal(err)
+	}
+	if !checkpoint.Done("v1", "sha256:abc") {
+		t.Fatal("expected v1 to be done")
+	}
+}
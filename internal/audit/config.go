@@ -0,0 +1,191 @@
+package audit
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/notaryproject/notation/internal/ioutil"
+	"oras.land/oras-go/v2/registry"
+)
+
+// output formats of the merged results of the audits of a configuration
+const (
+	OutputFormatCSV  = "csv"
+	OutputFormatJSON = "json"
+)
+
+// Config is the configuration of the audits run by "notation audit run", in
+// YAML or JSON, e.g.
+//
+//	concurrency: 2
+//	audits:
+//	  - repository: registry.example.com/prod/app
+//	    policy: prod
+//	    schedule: 24h
+//	  - repository: registry.example.com/staging/app
+//	outputs:
+//	  - format: csv
+//	    path: audit.csv
+type Config struct {
+	// Concurrency is the maximum number of repositories audited at the same
+	// time, 1 if not set.
+	Concurrency int `json:"concurrency,omitempty"`
+
+	// StateFile is the file recording when each audit last ran, for the
+	// schedules of the audits. It defaults to the path of the configuration
+	// file with the extension ".state.json", and relative paths are relative
+	// to the directory of the configuration file.
+	StateFile string `json:"stateFile,omitempty"`
+
+	// Audits are the repositories to audit.
+	Audits []RepositoryConfig `json:"audits"`
+
+	// Outputs are the sinks of the merged results of the audits.
+	Outputs []OutputConfig `json:"outputs,omitempty"`
+}
+
+// RepositoryConfig is the configuration of the audit of every tagged artifact
+// of a repository.
+type RepositoryConfig struct {
+	// Name identifies the audit in the results and the state file, the
+	// repository if not set.
+	Name string `json:"name,omitempty"`
+
+	// Repository is the repository to audit, without tag or digest, e.g.
+	// "registry.example.com/prod/app".
+	Repository string `json:"repository"`
+
+	// Policy is the name of the trust policy document verifying the
+	// repository, the default trust policy document if not set.
+	Policy string `json:"policy,omitempty"`
+
+	// Schedule is the minimum interval between two runs of the audit, e.g.
+	// "24h", so that a cron job running frequently audits each repository
+	// at its own pace. The audit runs every time if not set.
+	Schedule string `json:"schedule,omitempty"`
+
+	// MaxSignatureAttempts is the maximum number of signatures evaluated per
+	// artifact, "maxSignatureAttempts" of config.json if not set.
+	MaxSignatureAttempts int `json:"maxSignatureAttempts,omitempty"`
+
+	// PluginConfig is the configuration of the verification plugins.
+	PluginConfig map[string]string `json:"pluginConfig,omitempty"`
+
+	interval time.Duration
+}
+
+// Interval returns the minimum interval between two runs of the audit, or 0
+// if the audit runs every time.
+func (c RepositoryConfig) Interval() time.Duration {
+	return c.interval
+}
+
+// OutputConfig is a sink of the merged results of the audits.
+type OutputConfig struct {
+	// Format is "csv" or "json".
+	Format string `json:"format"`
+
+	// Path is the file the results are written to, or "-" for stdout.
+	// Relative paths are relative to the directory of the configuration
+	// file.
+	Path string `json:"path"`
+}
+
+// LoadConfig loads and validates the audit configuration file of path.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit configuration: %w", err)
+	}
+	var config Config
+	if err := ioutil.UnmarshalYAML(data, &config); err != nil {
+		return nil, fmt.Errorf("malformed audit configuration %s: %w", path, err)
+	}
+	if err := config.validate(); err != nil {
+		return nil, fmt.Errorf("invalid audit configuration %s: %w", path, err)
+	}
+
+	// resolve the paths relative to the configuration file
+	baseDir := filepath.Dir(path)
+	if config.StateFile == "" {
+		config.StateFile = strings.TrimSuffix(path, filepath.Ext(path)) + ".state.json"
+	} else if !filepath.IsAbs(config.StateFile) {
+		config.StateFile = filepath.Join(baseDir, config.StateFile)
+	}
+	for i, output := range config.Outputs {
+		if output.Path != "-" && !filepath.IsAbs(output.Path) {
+			config.Outputs[i].Path = filepath.Join(baseDir, output.Path)
+		}
+	}
+	return &config, nil
+}
+
+// validate checks the configuration and sets the defaults.
+func (c *Config) validate() error {
+	if c.Concurrency < 0 {
+		return errors.New("concurrency must not be negative")
+	}
+	if c.Concurrency == 0 {
+		c.Concurrency = 1
+	}
+	if len(c.Audits) == 0 {
+		return errors.New("no audit is configured")
+	}
+	names := make(map[string]bool)
+	for i := range c.Audits {
+		audit := &c.Audits[i]
+		ref, err := registry.ParseReference(audit.Repository)
+		if err != nil {
+			return fmt.Errorf("audit %d: invalid repository %q: %w", i+1, audit.Repository, err)
+		}
+		if ref.Reference != "" {
+			return fmt.Errorf("audit %d: repository %q must not have a tag or digest", i+1, audit.Repository)
+		}
+		if audit.Name == "" {
+			audit.Name = audit.Repository
+		}
+		if names[audit.Name] {
+			return fmt.Errorf("audit %d: duplicate audit name %q, set distinct names to audit a repository with several policies", i+1, audit.Name)
+		}
+		names[audit.Name] = true
+		if audit.Schedule != "" {
+			if audit.interval, err = time.ParseDuration(audit.Schedule); err != nil || audit.interval <= 0 {
+				return fmt.Errorf("audit %q: schedule %q is not a positive duration, e.g. \"24h\"", audit.Name, audit.Schedule)
+			}
+		}
+		if audit.MaxSignatureAttempts < 0 {
+			return fmt.Errorf("audit %q: maxSignatureAttempts must not be negative", audit.Name)
+		}
+	}
+	var stdout bool
+	for i, output := range c.Outputs {
+		if output.Format != OutputFormatCSV && output.Format != OutputFormatJSON {
+			return fmt.Errorf("output %d: unknown format %q, options: %s, %s", i+1, output.Format, OutputFormatCSV, OutputFormatJSON)
+		}
+		if output.Path == "" {
+			return fmt.Errorf("output %d: path is required, use \"-\" for stdout", i+1)
+		}
+		if output.Path == "-" {
+			if stdout {
+				return errors.New("at most one output can be written to stdout")
+			}
+			stdout = true
+		}
+	}
+	return nil
+}
+
+// WritesToStdout reports whether an output of the configuration is written
+// to stdout.
+func (c *Config) WritesToStdout() bool {
+	for _, output := range c.Outputs {
+		if output.Path == "-" {
+			return true
+		}
+	}
+	return false
+}
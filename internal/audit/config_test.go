@@ -0,0 +1,134 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "audit.yaml")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := writeConfig(t, `
+audits:
+  - repository: registry.example.com/prod/app
+    policy: prod
+    schedule: 24h
+  - name: staging
+    repository: registry.example.com/staging/app
+    maxSignatureAttempts: 10
+    pluginConfig:
+      key: value
+outputs:
+  - format: csv
+    path: results/audit.csv
+  - format: json
+    path: "-"
+`)
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	baseDir := filepath.Dir(path)
+	if config.Concurrency != 1 || config.StateFile != filepath.Join(baseDir, "audit.state.json") {
+		t.Fatalf("unexpected defaults of config %+v", config)
+	}
+	prod, staging := config.Audits[0], config.Audits[1]
+	if prod.Name != "registry.example.com/prod/app" || prod.Policy != "prod" || prod.Interval() != 24*time.Hour {
+		t.Fatalf("unexpected audit %+v", prod)
+	}
+	if staging.Name != "staging" || staging.Interval() != 0 || staging.MaxSignatureAttempts != 10 || staging.PluginConfig["key"] != "value" {
+		t.Fatalf("unexpected audit %+v", staging)
+	}
+	if config.Outputs[0].Path != filepath.Join(baseDir, "results", "audit.csv") || config.Outputs[1].Path != "-" || !config.WritesToStdout() {
+		t.Fatalf("unexpected outputs %+v", config.Outputs)
+	}
+}
+
+func TestLoadConfig_Error(t *testing.T) {
+	for content, want := range map[string]string{
+		"audits: []": "no audit is configured",
+		"concurrency: -1\naudits:\n  - repository: example.com/app":                                                           "concurrency must not be negative",
+		"audits:\n  - repository: example.com/app:v1":                                                                         "must not have a tag or digest",
+		"audits:\n  - repository: not a repository":                                                                           "invalid repository",
+		"audits:\n  - repository: example.com/app\n    schedule: daily":                                                       "is not a positive duration",
+		"audits:\n  - repository: example.com/app\n  - repository: example.com/app":                                           "duplicate audit name",
+		"audits:\n  - repository: example.com/app\n    policies: [prod]":                                                      "unknown field",
+		"audits:\n  - repository: example.com/app\noutputs:\n  - format: xml\n    path: out.xml":                              "unknown format",
+		"audits:\n  - repository: example.com/app\noutputs:\n  - format: csv":                                                 "path is required",
+		"audits:\n  - repository: example.com/app\noutputs:\n  - {format: csv, path: \"-\"}\n  - {format: json, path: \"-\"}": "at most one output",
+	} {
+		if _, err := LoadConfig(writeConfig(t, content)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("LoadConfig(%q) error = %v, want %q", content, err, want)
+		}
+	}
+}
+
+func TestState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.state.json")
+	state, err := LoadState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	daily := RepositoryConfig{Name: "daily", interval: 24 * time.Hour}
+	always := RepositoryConfig{Name: "always"}
+	now := time.Date(2023, 4, 20, 8, 0, 0, 0, time.UTC)
+	if due, _ := state.Due(daily, now); !due {
+		t.Fatal("expected an audit never run to be due")
+	}
+	state.Record("daily", now)
+	state.Record("always", now)
+	if err := state.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	state, err = LoadState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if due, next := state.Due(daily, now.Add(time.Hour)); due || !next.Equal(now.Add(24*time.Hour)) {
+		t.Fatalf("Due() = %v, %v, want false, %v", due, next, now.Add(24*time.Hour))
+	}
+	if due, _ := state.Due(daily, now.Add(24*time.Hour)); !due {
+		t.Fatal("expected the audit to be due after its schedule")
+	}
+	if due, _ := state.Due(always, now); !due {
+		t.Fatal("expected an audit without schedule to be due")
+	}
+}
+
+func TestReport_WriteCSV(t *testing.T) {
+	report := &Report{}
+	report.Add(RepositoryReport{Name: "prod", Repository: "localhost:5000/prod", Result: ResultFailure, Succeeded: 1, Failed: 1, Entries: []Entry{
+		{Tag: "v1", Digest: "sha256:aaa", Result: ResultSuccess},
+		{Tag: "v2", Digest: "sha256:bbb", Result: ResultFailure, Error: "signature verification failed"},
+	}})
+	report.Add(RepositoryReport{Name: "staging", Repository: "localhost:5000/staging", Result: ResultSkipped})
+	report.Add(RepositoryReport{Name: "dev", Repository: "localhost:5000/dev", Result: ResultSuccess, Succeeded: 1, Entries: []Entry{
+		{Tag: "latest", Digest: "sha256:ccc", Result: ResultSuccess},
+	}})
+	if report.Succeeded != 2 || report.Failed != 1 || report.FailedAudits() != 1 {
+		t.Fatalf("unexpected counts of report %+v", report)
+	}
+	var buf strings.Builder
+	if err := report.WriteCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	expected := `repository,tag,digest,result,error
+localhost:5000/prod,v1,sha256:aaa,success,
+localhost:5000/prod,v2,sha256:bbb,failure,signature verification failed
+localhost:5000/dev,latest,sha256:ccc,success,
+`
+	if got := buf.String(); got != expected {
+		t.Fatalf("CSV output = %q, want %q", got, expected)
+	}
+}
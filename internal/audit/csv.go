@@ -0,0 +1,38 @@
+package audit
+
+import (
+	"encoding/csv"
+	"io"
+)
+
+// CSVHeader is the header of the CSV output of audits. The columns are stable,
+// new columns are only appended, so that spreadsheets and scripts consuming
+// the output can rely on the positions of the columns.
+var CSVHeader = []string{"repository", "tag", "digest", "result", "error"}
+
+// CSVWriter writes the results of an audit as CSV records.
+type CSVWriter struct {
+	w          *csv.Writer
+	repository string
+}
+
+// NewCSVWriter writes the header to w and returns a writer of the results of
+// the audit of repository.
+func NewCSVWriter(w io.Writer, repository string) (*CSVWriter, error) {
+	csvWriter := csv.NewWriter(w)
+	if err := csvWriter.Write(CSVHeader); err != nil {
+		return nil, err
+	}
+	csvWriter.Flush()
+	return &CSVWriter{w: csvWriter, repository: repository}, csvWriter.Error()
+}
+
+// Write writes the result of a tag. The record is flushed immediately, so that
+// the results of long running audits are streamed.
+func (w *CSVWriter) Write(entry Entry) error {
+	if err := w.w.Write([]string{w.repository, entry.Tag, entry.Digest, string(entry.Result), entry.Error}); err != nil {
+		return err
+	}
+	w.w.Flush()
+	return w.w.Error()
+}
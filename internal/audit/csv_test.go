@@ -0,0 +1,29 @@
+package audit
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCSVWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewCSVWriter(&buf, "localhost:5000/net-monitor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range []Entry{
+		{Tag: "v1", Digest: "sha256:aaa", Result: ResultSuccess},
+		{Tag: "v2", Result: ResultFailure, Error: `failed to resolve tag: "v2", not found`},
+	} {
+		if err := w.Write(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected := `repository,tag,digest,result,error
+localhost:5000/net-monitor,v1,sha256:aaa,success,
+localhost:5000/net-monitor,v2,,failure,"failed to resolve tag: ""v2"", not found"
+`
+	if got := buf.String(); got != expected {
+		t.Fatalf("CSV output = %q, want %q", got, expected)
+	}
+}
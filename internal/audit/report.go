@@ -0,0 +1,87 @@
+package audit
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// Result of an audit which is not due according to its schedule.
+const ResultSkipped Result = "skipped"
+
+// Report is the merged result of the audits of a configuration.
+type Report struct {
+	// Audits are the results of the audits, in the order of the
+	// configuration.
+	Audits []RepositoryReport `json:"audits"`
+
+	// Succeeded and Failed are the numbers of tags verified successfully and
+	// failing verification across the audits.
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+}
+
+// RepositoryReport is the result of the audit of a repository.
+type RepositoryReport struct {
+	Name       string `json:"name"`
+	Repository string `json:"repository"`
+	Policy     string `json:"policy,omitempty"`
+
+	// Result is "success" if all the tags are verified successfully,
+	// "failure" if any tag fails verification or the audit fails, or
+	// "skipped" if the audit is not due.
+	Result Result `json:"result"`
+
+	// Error is the reason the audit failed without verifying the tags,
+	// e.g. failing to list the tags.
+	Error string `json:"error,omitempty"`
+
+	// NextRun is the time the skipped audit is due, in RFC 3339.
+	NextRun string `json:"nextRun,omitempty"`
+
+	Succeeded int     `json:"succeeded"`
+	Failed    int     `json:"failed"`
+	Entries   []Entry `json:"entries,omitempty"`
+}
+
+// Add adds the result of the audit of a repository to the report.
+func (r *Report) Add(audit RepositoryReport) {
+	r.Audits = append(r.Audits, audit)
+	r.Succeeded += audit.Succeeded
+	r.Failed += audit.Failed
+}
+
+// FailedAudits returns the number of audits which failed.
+func (r *Report) FailedAudits() int {
+	var failed int
+	for _, audit := range r.Audits {
+		if audit.Result == ResultFailure {
+			failed++
+		}
+	}
+	return failed
+}
+
+// WriteCSV writes the results of the tags of all the audits as CSV, in the
+// columns of CSVHeader.
+func (r *Report) WriteCSV(w io.Writer) error {
+	csvWriter, err := NewCSVWriter(w, "")
+	if err != nil {
+		return err
+	}
+	for _, audit := range r.Audits {
+		csvWriter.repository = audit.Repository
+		for _, entry := range audit.Entries {
+			if err := csvWriter.Write(entry); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// WriteJSON writes the report as JSON.
+func (r *Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "    ")
+	return encoder.Encode(r)
+}
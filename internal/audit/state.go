@@ -0,0 +1,69 @@
+package audit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+
+	"github.com/notaryproject/notation/internal/osutil"
+)
+
+// State records when the audits of a configuration last ran, so that audits
+// with a schedule are only run when due.
+type State struct {
+	// LastRun is the time each audit last ran to completion, by name.
+	LastRun map[string]time.Time `json:"lastRun"`
+
+	path string
+}
+
+// LoadState loads the state of the audits from path. A new state is returned
+// if the file does not exist.
+func LoadState(path string) (*State, error) {
+	state := &State{
+		LastRun: make(map[string]time.Time),
+		path:    path,
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return state, nil
+		}
+		return nil, fmt.Errorf("failed to read audit state file: %w", err)
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("malformed audit state file %s: %w", path, err)
+	}
+	if state.LastRun == nil {
+		state.LastRun = make(map[string]time.Time)
+	}
+	return state, nil
+}
+
+// Due reports whether the audit is due at now, and if not, when it is due
+// next. Audits without a schedule are always due.
+func (s *State) Due(audit RepositoryConfig, now time.Time) (bool, time.Time) {
+	lastRun, ok := s.LastRun[audit.Name]
+	if !ok || audit.Interval() <= 0 {
+		return true, time.Time{}
+	}
+	next := lastRun.Add(audit.Interval())
+	return !now.Before(next), next
+}
+
+// Record records that the audit of name ran to completion at t.
+func (s *State) Record(name string, t time.Time) {
+	s.LastRun[name] = t.UTC()
+}
+
+// Save persists the state.
+func (s *State) Save() error {
+	data, err := json.MarshalIndent(s, "", "    ")
+	if err != nil {
+		return err
+	}
+	return osutil.WriteFileAtomic(s.path, data, 0600)
+}
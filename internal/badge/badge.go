@@ -0,0 +1,175 @@
+// Package badge renders the signature coverage of a repository as a badge,
+// which teams publish in dashboards or READMEs.
+package badge
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+)
+
+// Format is an output format of the badge.
+type Format string
+
+const (
+	// FormatSVG is a flat badge in SVG.
+	FormatSVG Format = "svg"
+
+	// FormatJSON is a JSON document with the fields of the shields.io
+	// endpoint schema and the signature counts.
+	FormatJSON Format = "json"
+)
+
+// Formats lists the supported output formats.
+var Formats = []Format{FormatSVG, FormatJSON}
+
+// DefaultLabel is the default text of the left part of the badge.
+const DefaultLabel = "notation"
+
+// colors maps the shields.io color names used by the badge to their values.
+var colors = map[string]string{
+	"brightgreen": "#4c1",
+	"yellow":      "#dfb317",
+	"red":         "#e05d44",
+	"lightgrey":   "#9f9f9f",
+}
+
+// Report is the signature coverage of the tagged artifacts of a repository.
+type Report struct {
+	// Repository is the repository the report is about.
+	Repository string `json:"repository"`
+
+	// Total is the number of tagged artifacts.
+	Total int `json:"total"`
+
+	// Signed is the number of artifacts with at least one signature.
+	Signed int `json:"signed"`
+
+	// Unsigned is the number of artifacts without signatures.
+	Unsigned int `json:"unsigned"`
+
+	// Compliant is the number of artifacts passing the verification against
+	// the trust policy.
+	Compliant int `json:"compliant"`
+
+	// NonCompliant is the number of artifacts failing the verification
+	// against the trust policy, including unsigned artifacts.
+	NonCompliant int `json:"nonCompliant"`
+}
+
+// Add records an artifact in the report. Unsigned artifacts are never
+// compliant.
+func (r *Report) Add(signed, compliant bool) {
+	r.Total++
+	if !signed {
+		r.Unsigned++
+		r.NonCompliant++
+		return
+	}
+	r.Signed++
+	if compliant {
+		r.Compliant++
+	} else {
+		r.NonCompliant++
+	}
+}
+
+// Message returns the text of the right part of the badge.
+func (r *Report) Message() string {
+	if r.Total == 0 {
+		return "no artifacts"
+	}
+	return fmt.Sprintf("%d/%d signed, %d compliant", r.Signed, r.Total, r.Compliant)
+}
+
+// Color returns the shields.io color name of the badge: bright green if all
+// artifacts are compliant, red if none is, and yellow otherwise.
+func (r *Report) Color() string {
+	switch {
+	case r.Total == 0:
+		return "lightgrey"
+	case r.Compliant == r.Total:
+		return "brightgreen"
+	case r.Compliant == 0:
+		return "red"
+	default:
+		return "yellow"
+	}
+}
+
+// document is the JSON badge.
+type document struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	Label         string `json:"label"`
+	Message       string `json:"message"`
+	Color         string `json:"color"`
+	*Report
+}
+
+// Write writes the badge of r with label in format to w.
+func Write(w io.Writer, r *Report, label string, format Format) error {
+	switch format {
+	case FormatSVG:
+		return WriteSVG(w, r, label)
+	case FormatJSON:
+		return WriteJSON(w, r, label)
+	default:
+		return fmt.Errorf("unsupported badge format %q", format)
+	}
+}
+
+// WriteJSON writes the badge of r with label to w as JSON.
+func WriteJSON(w io.Writer, r *Report, label string) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(document{
+		SchemaVersion: 1,
+		Label:         label,
+		Message:       r.Message(),
+		Color:         r.Color(),
+		Report:        r,
+	})
+}
+
+// WriteSVG writes the badge of r with label to w as a flat SVG badge.
+func WriteSVG(w io.Writer, r *Report, label string) error {
+	message := r.Message()
+	labelWidth := textWidth(label)
+	messageWidth := textWidth(message)
+	width := labelWidth + messageWidth
+	title := html.EscapeString(label + ": " + message)
+	_, err := fmt.Fprintf(w, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="20" role="img" aria-label="%s">
+  <title>%s</title>
+  <linearGradient id="s" x2="0" y2="100%%">
+    <stop offset="0" stop-color="#bbb" stop-opacity=".1"/>
+    <stop offset="1" stop-opacity=".1"/>
+  </linearGradient>
+  <clipPath id="r">
+    <rect width="%d" height="20" rx="3" fill="#fff"/>
+  </clipPath>
+  <g clip-path="url(#r)">
+    <rect width="%d" height="20" fill="#555"/>
+    <rect x="%d" width="%d" height="20" fill="%s"/>
+    <rect width="%d" height="20" fill="url(#s)"/>
+  </g>
+  <g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">
+    <text x="%d" y="14">%s</text>
+    <text x="%d" y="14">%s</text>
+  </g>
+</svg>
+`, width, title, title,
+		width,
+		labelWidth,
+		labelWidth, messageWidth, colors[r.Color()],
+		width,
+		labelWidth/2, html.EscapeString(label),
+		labelWidth+messageWidth/2, html.EscapeString(message))
+	return err
+}
+
+// textWidth estimates the width in pixels of text in 11px Verdana, with
+// padding on both sides.
+func textWidth(text string) int {
+	return len([]rune(text))*7 + 10
+}
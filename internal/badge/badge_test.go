@@ -0,0 +1,73 @@
+package badge
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestReport(t *testing.T) {
+	tests := []struct {
+		name        string
+		artifacts   [][2]bool
+		wantMessage string
+		wantColor   string
+	}{
+		{name: "empty", wantMessage: "no artifacts", wantColor: "lightgrey"},
+		{name: "all compliant", artifacts: [][2]bool{{true, true}, {true, true}}, wantMessage: "2/2 signed, 2 compliant", wantColor: "brightgreen"},
+		{name: "partially compliant", artifacts: [][2]bool{{true, true}, {true, false}, {false, false}}, wantMessage: "2/3 signed, 1 compliant", wantColor: "yellow"},
+		{name: "none compliant", artifacts: [][2]bool{{true, false}, {false, true}}, wantMessage: "1/2 signed, 0 compliant", wantColor: "red"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Report{}
+			for _, artifact := range tt.artifacts {
+				r.Add(artifact[0], artifact[1])
+			}
+			if r.Signed+r.Unsigned != r.Total || r.Compliant+r.NonCompliant != r.Total {
+				t.Fatalf("inconsistent counts: %+v", r)
+			}
+			if got := r.Message(); got != tt.wantMessage {
+				t.Fatalf("Message() = %q, want %q", got, tt.wantMessage)
+			}
+			if got := r.Color(); got != tt.wantColor {
+				t.Fatalf("Color() = %q, want %q", got, tt.wantColor)
+			}
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	r := &Report{Repository: "localhost:5000/net-monitor"}
+	r.Add(true, true)
+	r.Add(false, false)
+
+	var out bytes.Buffer
+	if err := Write(&out, r, "<signed>", FormatSVG); err != nil {
+		t.Fatal(err)
+	}
+	if err := xml.Unmarshal(out.Bytes(), new(struct{})); err != nil {
+		t.Fatalf("invalid SVG: %v\n%s", err, out.String())
+	}
+	if !strings.Contains(out.String(), "&lt;signed&gt;") || !strings.Contains(out.String(), colors["yellow"]) {
+		t.Fatalf("unexpected SVG:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := Write(&out, r, DefaultLabel, FormatJSON); err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded["schemaVersion"] != float64(1) || decoded["message"] != "1/2 signed, 1 compliant" || decoded["unsigned"] != float64(1) || decoded["repository"] != r.Repository {
+		t.Fatalf("unexpected JSON: %s", out.String())
+	}
+
+	if err := Write(&out, r, DefaultLabel, "png"); err == nil {
+		t.Fatal("expected error for unsupported format")
+	}
+}
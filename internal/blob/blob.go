@@ -0,0 +1,88 @@
+// Package blob signs arbitrary local files, such as tarballs, SBOM files or
+// binaries, producing detached signature envelopes stored next to them rather
+// than in a registry.
+//
+// The payload of a blob signature is the OCI descriptor of the blob, as for
+// artifacts in registries, so that the signature envelope formats, the keys
+// and the plugins are the same.
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/notaryproject/notation-go"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+const (
+	// DefaultMediaType is the default media type of a blob.
+	DefaultMediaType = "application/octet-stream"
+
+	// SignatureExtension is the extension of the signature files, following
+	// the format of the signature envelope, e.g. "app.tar.jws.sig".
+	SignatureExtension = ".sig"
+)
+
+// Descriptor computes the descriptor of the blob at path with the media type.
+func Descriptor(path, mediaType string) (ocispec.Descriptor, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	if !info.Mode().IsRegular() {
+		return ocispec.Descriptor{}, fmt.Errorf("%s is not a regular file", path)
+	}
+	digester := digest.Canonical.Digester()
+	size, err := io.Copy(digester.Hash(), file)
+	if err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return ocispec.Descriptor{
+		MediaType: mediaType,
+		Digest:    digester.Digest(),
+		Size:      size,
+	}, nil
+}
+
+// SignaturePath returns the path of the signature of the blob at blobPath in
+// the signature envelope format, e.g. "jws", in the directory dir.
+func SignaturePath(dir, blobPath, format string) string {
+	return filepath.Join(dir, filepath.Base(blobPath)+"."+format+SignatureExtension)
+}
+
+// Sign signs the descriptor of a blob with the user metadata, and returns the
+// signature envelope.
+func Sign(ctx context.Context, signer notation.Signer, desc ocispec.Descriptor, userMetadata map[string]string, opts notation.SignerSignOptions) ([]byte, error) {
+	if signer == nil {
+		return nil, errors.New("signer cannot be nil")
+	}
+	if opts.ExpiryDuration < 0 {
+		return nil, errors.New("expiry duration cannot be a negative value")
+	}
+	if opts.ExpiryDuration%time.Second != 0 {
+		return nil, errors.New("expiry duration supports minimum granularity of seconds")
+	}
+	if len(userMetadata) > 0 {
+		desc.Annotations = make(map[string]string, len(userMetadata))
+		for k, v := range userMetadata {
+			desc.Annotations[k] = v
+		}
+	}
+	sig, _, err := signer.Sign(ctx, desc, opts)
+	if err != nil {
+		return nil, err
+	}
+	return sig, nil
+}
@@ -0,0 +1,67 @@
+package blob
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/notation-core-go/signature"
+	"github.com/notaryproject/notation-go"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+func TestDescriptor(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.tar")
+	if err := os.WriteFile(path, []byte("hello"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	desc, err := Descriptor(path, DefaultMediaType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if desc.Digest != digest.FromString("hello") || desc.Size != 5 || desc.MediaType != DefaultMediaType {
+		t.Fatalf("unexpected descriptor: %+v", desc)
+	}
+	if _, err := Descriptor(filepath.Dir(path), DefaultMediaType); err == nil {
+		t.Fatal("expected error for a directory")
+	}
+	if _, err := Descriptor(filepath.Join(t.TempDir(), "missing"), DefaultMediaType); err == nil {
+		t.Fatal("expected error for a missing file")
+	}
+}
+
+func TestSignaturePath(t *testing.T) {
+	if got, want := SignaturePath("sigs", "dist/app.tar", "cose"), filepath.Join("sigs", "app.tar.cose.sig"); got != want {
+		t.Fatalf("SignaturePath() = %q, want %q", got, want)
+	}
+}
+
+// testSigner records the descriptor signed.
+type testSigner struct {
+	desc ocispec.Descriptor
+	opts notation.SignerSignOptions
+}
+
+func (s *testSigner) Sign(ctx context.Context, desc ocispec.Descriptor, opts notation.SignerSignOptions) ([]byte, *signature.SignerInfo, error) {
+	s.desc = desc
+	s.opts = opts
+	return []byte("signature"), &signature.SignerInfo{}, nil
+}
+
+func TestSign(t *testing.T) {
+	signer := &testSigner{}
+	desc := ocispec.Descriptor{MediaType: DefaultMediaType, Digest: digest.FromString("hello"), Size: 5}
+	sig, err := Sign(context.Background(), signer, desc, map[string]string{"buildId": "42"}, notation.SignerSignOptions{SignatureMediaType: "application/jose+json", ExpiryDuration: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(sig) != "signature" || signer.desc.Annotations["buildId"] != "42" || signer.desc.Digest != desc.Digest || signer.opts.ExpiryDuration != time.Hour {
+		t.Fatalf("unexpected signing of %+v with %+v", signer.desc, signer.opts)
+	}
+	if _, err := Sign(context.Background(), signer, desc, nil, notation.SignerSignOptions{ExpiryDuration: -time.Hour}); err == nil {
+		t.Fatal("expected error for a negative expiry duration")
+	}
+}
@@ -0,0 +1,281 @@
+package blob
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/notaryproject/notation-go/dir"
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+	"github.com/notaryproject/notation/internal/osutil"
+	"github.com/notaryproject/notation/internal/slices"
+)
+
+const (
+	// PathTrustPolicy is the path of the blob trust policy document, relative
+	// to the notation config directory.
+	PathTrustPolicy = "trustpolicy.blob.json"
+
+	// WildcardScope is the scope of a trust policy statement applying to all
+	// the blobs without a more specific statement.
+	WildcardScope = "*"
+)
+
+// PolicyDocument is the blob trust policy document. It has the same schema as
+// the trust policy document of artifacts in registries, except that the
+// statements are scoped by the paths of the blobs instead of registry scopes.
+type PolicyDocument struct {
+	// Version of the policy document.
+	Version string `json:"version"`
+
+	// TrustPolicies are the trust policy statements.
+	TrustPolicies []TrustPolicy `json:"trustPolicies"`
+}
+
+// TrustPolicy is a statement of the blob trust policy document.
+type TrustPolicy struct {
+	// Name of the policy statement.
+	Name string `json:"name"`
+
+	// Scopes are the path patterns of the blobs the statement applies to, in
+	// the syntax of path.Match with "/" separators, e.g. "dist/*.tar". Patterns
+	// without "/" also match the file names of the blobs, e.g. "*.tar". The
+	// scope "*" applies to all the blobs without a more specific statement.
+	Scopes []string `json:"scopes"`
+
+	// SignatureVerification is the signature verification setting.
+	SignatureVerification trustpolicy.SignatureVerification `json:"signatureVerification"`
+
+	// TrustStores the statement uses.
+	TrustStores []string `json:"trustStores,omitempty"`
+
+	// TrustedIdentities the statement pins.
+	TrustedIdentities []string `json:"trustedIdentities,omitempty"`
+}
+
+// LoadPolicyDocument loads the blob trust policy document from the notation
+// config directory. The document is not validated.
+func LoadPolicyDocument() (*PolicyDocument, error) {
+	policyPath, err := PolicyPath()
+	if err != nil {
+		return nil, err
+	}
+	policyJSON, err := os.ReadFile(policyPath)
+	if err != nil {
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			return nil, fmt.Errorf("blob trust policy is not present, please create blob trust policy at %s", policyPath)
+		case errors.Is(err, os.ErrPermission):
+			return nil, fmt.Errorf("unable to read blob trust policy due to file permissions, please verify the permissions of %s", policyPath)
+		}
+		return nil, err
+	}
+	return ParsePolicyDocument(policyJSON)
+}
+
+// PolicyPath returns the path of the blob trust policy document.
+func PolicyPath() (string, error) {
+	return dir.ConfigFS().SysPath(PathTrustPolicy)
+}
+
+// ParsePolicyDocument parses a blob trust policy document. The document is
+// not validated.
+func ParsePolicyDocument(policyJSON []byte) (*PolicyDocument, error) {
+	var doc PolicyDocument
+	if err := json.Unmarshal(policyJSON, &doc); err != nil {
+		return nil, fmt.Errorf("malformed blob trust policy: %w", err)
+	}
+	return &doc, nil
+}
+
+// Validate validates the blob trust policy document. The statements are
+// validated as statements of the trust policy document of artifacts in
+// registries, and their scopes must not overlap.
+func (doc *PolicyDocument) Validate() error {
+	if len(doc.TrustPolicies) == 0 {
+		return errors.New("blob trust policy document can not have zero trust policy statements")
+	}
+	names := make(map[string]struct{})
+	scopes := make(map[string]string)
+	for _, statement := range doc.TrustPolicies {
+		if statement.Name == "" {
+			return errors.New("a blob trust policy statement is missing a name, every statement requires a name")
+		}
+		if _, ok := names[statement.Name]; ok {
+			return fmt.Errorf("multiple blob trust policy statements use the same name %q, statement names must be unique", statement.Name)
+		}
+		names[statement.Name] = struct{}{}
+		if err := statement.validateScopes(); err != nil {
+			return err
+		}
+		for _, scope := range statement.Scopes {
+			if other, ok := scopes[scope]; ok {
+				return fmt.Errorf("blob trust policy statements %q and %q have overlapping scope %q, a scope must only be used by one statement", other, statement.Name, scope)
+			}
+			scopes[scope] = statement.Name
+		}
+		if err := doc.TrustPolicyDocument(&statement).Validate(); err != nil {
+			return fmt.Errorf("blob trust policy statement %q is invalid: %w", statement.Name, err)
+		}
+	}
+	return nil
+}
+
+// validateScopes validates the syntax of the scopes of the statement.
+func (statement *TrustPolicy) validateScopes() error {
+	if len(statement.Scopes) == 0 {
+		return fmt.Errorf("blob trust policy statement %q has zero scopes, it must specify scopes and can not be empty", statement.Name)
+	}
+	for _, scope := range statement.Scopes {
+		if err := ValidateScope(scope); err != nil {
+			return fmt.Errorf("blob trust policy statement %q: %w", statement.Name, err)
+		}
+		if scope == WildcardScope && len(statement.Scopes) > 1 {
+			return fmt.Errorf("blob trust policy statement %q uses wildcard scope '*', a wildcard scope cannot be used in conjunction with other scope values", statement.Name)
+		}
+	}
+	return nil
+}
+
+// ValidateScope validates the syntax of a scope of a blob trust policy
+// statement.
+func ValidateScope(scope string) error {
+	if scope == "" {
+		return errors.New("scope cannot be empty")
+	}
+	if strings.Contains(scope, `\`) {
+		return fmt.Errorf("scope %q is invalid, use '/' as path separator", scope)
+	}
+	if _, err := path.Match(scope, ""); err != nil {
+		return fmt.Errorf("scope %q is not a valid path pattern: %w", scope, err)
+	}
+	return nil
+}
+
+// Statement returns the trust policy statement of artifacts in registries
+// equivalent to the blob trust policy statement, applying to all artifacts.
+func (statement *TrustPolicy) Statement() *trustpolicy.TrustPolicy {
+	return &trustpolicy.TrustPolicy{
+		Name:                  statement.Name,
+		RegistryScopes:        []string{WildcardScope},
+		SignatureVerification: statement.SignatureVerification,
+		TrustStores:           statement.TrustStores,
+		TrustedIdentities:     statement.TrustedIdentities,
+	}
+}
+
+// Get returns the statement named name, or nil if absent.
+func (doc *PolicyDocument) Get(name string) *TrustPolicy {
+	for i, statement := range doc.TrustPolicies {
+		if statement.Name == name {
+			return &doc.TrustPolicies[i]
+		}
+	}
+	return nil
+}
+
+// ApplicableTrustPolicy returns the statement applicable to the blob at
+// blobPath. A statement with a scope matching the blob takes precedence over
+// the statement with the wildcard scope. The blob is rejected if the scopes
+// of multiple statements match it, rather than picking one of them.
+func (doc *PolicyDocument) ApplicableTrustPolicy(blobPath string) (*TrustPolicy, error) {
+	slashPath := filepath.ToSlash(filepath.Clean(blobPath))
+	var wildcard *TrustPolicy
+	var applicable []*TrustPolicy
+	for i, statement := range doc.TrustPolicies {
+		for _, scope := range statement.Scopes {
+			if scope == WildcardScope {
+				wildcard = &doc.TrustPolicies[i]
+				break
+			}
+			if matchScope(scope, slashPath) {
+				applicable = append(applicable, &doc.TrustPolicies[i])
+				break
+			}
+		}
+	}
+	switch len(applicable) {
+	case 0:
+		if wildcard == nil {
+			return nil, fmt.Errorf("blob %q has no applicable blob trust policy", blobPath)
+		}
+		return wildcard, nil
+	case 1:
+		return applicable[0], nil
+	}
+	names := make([]string, 0, len(applicable))
+	for _, statement := range applicable {
+		names = append(names, fmt.Sprintf("%q", statement.Name))
+	}
+	return nil, fmt.Errorf("blob %q matches the scopes of multiple blob trust policy statements %s, scopes must select a single statement", blobPath, strings.Join(names, ", "))
+}
+
+// matchScope returns true if the scope matches the slash separated path of a
+// blob. Scopes without "/" also match the file name of the blob.
+func matchScope(scope, slashPath string) bool {
+	if ok, _ := path.Match(scope, slashPath); ok {
+		return true
+	}
+	if !strings.Contains(scope, "/") {
+		ok, _ := path.Match(scope, path.Base(slashPath))
+		return ok
+	}
+	return false
+}
+
+// AddScopes adds the scopes to the statement named name. Scopes the statement
+// already has are ignored.
+func (doc *PolicyDocument) AddScopes(name string, scopes ...string) error {
+	statement := doc.Get(name)
+	if statement == nil {
+		return fmt.Errorf("blob trust policy statement %q does not exist", name)
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(statement.Scopes, scope) {
+			statement.Scopes = append(statement.Scopes, scope)
+		}
+	}
+	return nil
+}
+
+// RemoveScopes removes the scopes from the statement named name.
+func (doc *PolicyDocument) RemoveScopes(name string, scopes ...string) error {
+	statement := doc.Get(name)
+	if statement == nil {
+		return fmt.Errorf("blob trust policy statement %q does not exist", name)
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(statement.Scopes, scope) {
+			return fmt.Errorf("blob trust policy statement %q does not have scope %q", name, scope)
+		}
+	}
+	remaining := statement.Scopes[:0]
+	for _, scope := range statement.Scopes {
+		if !slices.Contains(scopes, scope) {
+			remaining = append(remaining, scope)
+		}
+	}
+	statement.Scopes = remaining
+	return nil
+}
+
+// SavePolicyDocument validates and writes the blob trust policy document to
+// the notation config directory.
+func SavePolicyDocument(doc *PolicyDocument) error {
+	if err := doc.Validate(); err != nil {
+		return err
+	}
+	policyJSON, err := json.MarshalIndent(doc, "", "    ")
+	if err != nil {
+		return err
+	}
+	policyPath, err := PolicyPath()
+	if err != nil {
+		return err
+	}
+	return osutil.WriteFile(policyPath, policyJSON)
+}
@@ -0,0 +1,120 @@
+package blob
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+)
+
+func testPolicyDocument() *PolicyDocument {
+	statement := func(name string, scopes ...string) TrustPolicy {
+		return TrustPolicy{
+			Name:                  name,
+			Scopes:                scopes,
+			SignatureVerification: trustpolicy.SignatureVerification{VerificationLevel: "strict"},
+			TrustStores:           []string{"ca:blob"},
+			TrustedIdentities:     []string{"*"},
+		}
+	}
+	return &PolicyDocument{
+		Version: "1.0",
+		TrustPolicies: []TrustPolicy{
+			statement("release", "dist/*.tar"),
+			statement("sbom", "*.spdx.json"),
+			statement("default", "*"),
+		},
+	}
+}
+
+func TestPolicyDocument_Validate(t *testing.T) {
+	if err := testPolicyDocument().Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	tests := []struct {
+		name    string
+		update  func(doc *PolicyDocument)
+		wantErr string
+	}{
+		{"no statements", func(doc *PolicyDocument) { doc.TrustPolicies = nil }, "zero trust policy statements"},
+		{"duplicate names", func(doc *PolicyDocument) { doc.TrustPolicies[1].Name = "release" }, "same name"},
+		{"no scopes", func(doc *PolicyDocument) { doc.TrustPolicies[0].Scopes = nil }, "zero scopes"},
+		{"overlapping scopes", func(doc *PolicyDocument) { doc.TrustPolicies[1].Scopes = []string{"dist/*.tar"} }, "overlapping scope"},
+		{"wildcard with other scopes", func(doc *PolicyDocument) { doc.TrustPolicies[2].Scopes = []string{"*", "a"} }, "wildcard scope"},
+		{"invalid pattern", func(doc *PolicyDocument) { doc.TrustPolicies[0].Scopes = []string{"dist/[.tar"} }, "not a valid path pattern"},
+		{"backslash", func(doc *PolicyDocument) { doc.TrustPolicies[0].Scopes = []string{`dist\app.tar`} }, "path separator"},
+		{"invalid level", func(doc *PolicyDocument) { doc.TrustPolicies[0].SignatureVerification.VerificationLevel = "lax" }, `"release" is invalid`},
+		{"unsupported version", func(doc *PolicyDocument) { doc.Version = "2.0" }, "version"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := testPolicyDocument()
+			tt.update(doc)
+			err := doc.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Validate() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPolicyDocument_ApplicableTrustPolicy(t *testing.T) {
+	doc := testPolicyDocument()
+	tests := []struct {
+		blobPath string
+		want     string
+	}{
+		{"dist/app.tar", "release"},
+		{"./dist/../dist/app.tar", "release"},
+		{"app.tar", "default"},
+		{"build/sbom.spdx.json", "sbom"},
+		{"dist/app.zip", "default"},
+	}
+	for _, tt := range tests {
+		statement, err := doc.ApplicableTrustPolicy(tt.blobPath)
+		if err != nil {
+			t.Fatalf("ApplicableTrustPolicy(%q) error = %v", tt.blobPath, err)
+		}
+		if statement.Name != tt.want {
+			t.Fatalf("ApplicableTrustPolicy(%q) = %q, want %q", tt.blobPath, statement.Name, tt.want)
+		}
+	}
+
+	// blobs matching multiple statements are rejected
+	if _, err := doc.ApplicableTrustPolicy("dist/app.spdx.json"); err != nil {
+		t.Fatalf("ApplicableTrustPolicy() error = %v", err)
+	}
+	doc.TrustPolicies[0].Scopes = []string{"dist/*"}
+	if _, err := doc.ApplicableTrustPolicy("dist/app.spdx.json"); err == nil || !strings.Contains(err.Error(), "multiple") {
+		t.Fatalf("expected error for multiple applicable statements, got %v", err)
+	}
+
+	// blobs without applicable statements are rejected
+	doc.TrustPolicies = doc.TrustPolicies[:2]
+	if _, err := doc.ApplicableTrustPolicy("app.tar"); err == nil {
+		t.Fatal("expected error for no applicable statement")
+	}
+}
+
+func TestPolicyDocument_Scopes(t *testing.T) {
+	doc := testPolicyDocument()
+	if err := doc.AddScopes("release", "dist/*.zip", "dist/*.tar"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := doc.Get("release").Scopes, []string{"dist/*.tar", "dist/*.zip"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("scopes = %v, want %v", got, want)
+	}
+	if err := doc.RemoveScopes("release", "dist/*.tar"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := doc.Get("release").Scopes, []string{"dist/*.zip"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("scopes = %v, want %v", got, want)
+	}
+	if err := doc.RemoveScopes("release", "dist/*.tar"); err == nil {
+		t.Fatal("expected error for removing a missing scope")
+	}
+	if err := doc.AddScopes("missing", "a"); err == nil {
+		t.Fatal("expected error for a missing statement")
+	}
+}
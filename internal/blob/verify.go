@@ -0,0 +1,70 @@
+package blob
+
+import (
+	"context"
+	"errors"
+
+	"github.com/notaryproject/notation-core-go/signature"
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+	"github.com/notaryproject/notation/internal/envelope"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// artifactRepository is the repository of the references of blobs passed to
+// the verifiers, as notation-go only verifies artifacts in registries. The
+// verifiers of blobs enforce a single statement applying to all artifacts,
+// see TrustPolicy.Statement, so that the repository selects nothing.
+const artifactRepository = "blob.notation.local/blob"
+
+// TrustPolicyDocument returns the trust policy document, defined by the
+// Notary Project specification, enforcing the statement of the blob trust
+// policy document. The verifiers of the document verify blobs with Verify.
+func (doc *PolicyDocument) TrustPolicyDocument(statement *TrustPolicy) *trustpolicy.Document {
+	return &trustpolicy.Document{
+		Version:       doc.Version,
+		TrustPolicies: []trustpolicy.TrustPolicy{*statement.Statement()},
+	}
+}
+
+// Verify verifies the signature envelope of the blob of desc with a verifier
+// of the trust policy document returned by PolicyDocument.TrustPolicyDocument.
+// The envelope format is detected from the encoding of the signature, and the
+// plugin config is sent to the verification plugins.
+func Verify(ctx context.Context, verifier notation.Verifier, desc ocispec.Descriptor, signature []byte, pluginConfig map[string]string) (*notation.VerificationOutcome, error) {
+	if verifier == nil {
+		return nil, errors.New("verifier cannot be nil")
+	}
+	mediaType, err := envelope.DetectEnvelopeMediaType(signature)
+	if err != nil {
+		return nil, err
+	}
+	return verifier.Verify(ctx, desc, signature, notation.VerifierVerifyOptions{
+		ArtifactReference:  artifactRepository + "@" + desc.Digest.String(),
+		SignatureMediaType: mediaType,
+		PluginConfig:       pluginConfig,
+	})
+}
+
+// SignedMediaType returns the media type of the blob signed by the signature
+// envelope. The media type is not trusted until the signature is verified
+// against a descriptor of the blob with it.
+func SignedMediaType(sigBlob []byte) (string, error) {
+	mediaType, err := envelope.DetectEnvelopeMediaType(sigBlob)
+	if err != nil {
+		return "", err
+	}
+	sigEnvelope, err := signature.ParseEnvelope(mediaType, sigBlob)
+	if err != nil {
+		return "", err
+	}
+	content, err := sigEnvelope.Content()
+	if err != nil {
+		return "", err
+	}
+	desc, err := envelope.DescriptorFromSignaturePayload(&content.Payload)
+	if err != nil {
+		return "", err
+	}
+	return desc.MediaType, nil
+}
@@ -0,0 +1,111 @@
+package blob
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/notation-core-go/signature/cose"
+	"github.com/notaryproject/notation-core-go/signature/jws"
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation-go/verifier"
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+	"github.com/notaryproject/notation-go/verifier/truststore"
+	"github.com/notaryproject/notation/internal/localsigner"
+)
+
+// testTrustStore is a trust store of a single certificate.
+type testTrustStore struct {
+	cert *x509.Certificate
+}
+
+func (s *testTrustStore) GetCertificates(ctx context.Context, storeType truststore.Type, namedStore string) ([]*x509.Certificate, error) {
+	return []*x509.Certificate{s.cert}, nil
+}
+
+func TestVerify(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "blob", Organization: []string{"Notary"}, Country: []string{"US"}, Province: []string{"WA"}, Locality: []string{"Seattle"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		BasicConstraintsValid: true,
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(certDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := localsigner.New(key, []*x509.Certificate{cert})
+	if err != nil {
+		t.Fatal(err)
+	}
+	blobPath := filepath.Join(t.TempDir(), "sbom.spdx.json")
+	if err := os.WriteFile(blobPath, []byte(`{"spdxVersion":"SPDX-2.3"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	desc, err := Descriptor(blobPath, "application/spdx+json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc := testPolicyDocument()
+	statement, err := doc.ApplicableTrustPolicy(blobPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := verifier.New(doc.TrustPolicyDocument(statement), &testTrustStore{cert: cert}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, mediaType := range []string{jws.MediaTypeEnvelope, cose.MediaTypeEnvelope} {
+		t.Run(mediaType, func(t *testing.T) {
+			sig, err := Sign(context.Background(), signer, desc, map[string]string{"buildId": "42"}, notation.SignerSignOptions{SignatureMediaType: mediaType})
+			if err != nil {
+				t.Fatal(err)
+			}
+			signedMediaType, err := SignedMediaType(sig)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if signedMediaType != desc.MediaType {
+				t.Fatalf("SignedMediaType() = %q, want %q", signedMediaType, desc.MediaType)
+			}
+			outcome, err := Verify(context.Background(), v, desc, sig, nil)
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if outcome.VerificationLevel != trustpolicy.LevelStrict {
+				t.Fatalf("unexpected verification level %v", outcome.VerificationLevel)
+			}
+			metadata, err := outcome.UserMetadata()
+			if err != nil || metadata["buildId"] != "42" {
+				t.Fatalf("unexpected user metadata %v: %v", metadata, err)
+			}
+
+			// signatures of other blobs are rejected
+			tampered := desc
+			tampered.Digest = "sha256:" + "0000000000000000000000000000000000000000000000000000000000000000"
+			if _, err := Verify(context.Background(), v, tampered, sig, nil); err == nil {
+				t.Fatal("expected error for a signature of another blob")
+			}
+		})
+	}
+}
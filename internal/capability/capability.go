@@ -0,0 +1,248 @@
+// Package capability caches the capabilities of registries probed while
+// talking to them, such as the support of the Referrers API, so that
+// repeated invocations do not probe them again.
+//
+// The cache is a hint only: failing to read or write it never fails an
+// operation, and entries expire after a TTL so that upgraded registries are
+// probed again.
+package capability
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/notaryproject/notation/internal/osutil"
+)
+
+const (
+	// DefaultTTL is the duration for which the capabilities of a registry
+	// are cached by default.
+	DefaultTTL = 24 * time.Hour
+
+	// FileName is the name of the cache file in the cache directory of
+	// notation.
+	FileName = "registry-capabilities.json"
+
+	// filtersAppliedAnnotation is the annotation of the referrers index
+	// listing the filters applied by the registry.
+	filtersAppliedAnnotation = "org.opencontainers.referrers.filtersApplied"
+
+	// maxReferrersIndexBytes is the maximum size of a referrers index
+	// inspected for the filters applied.
+	maxReferrersIndexBytes = 4 * 1024 * 1024
+)
+
+// referrersPath matches the paths of the Referrers API.
+var referrersPath = regexp.MustCompile(`^/v2/.+/referrers/[^/]+$`)
+
+// timeNow is the current time, for unit test.
+var timeNow = time.Now
+
+// Capabilities are the capabilities of a registry.
+type Capabilities struct {
+	// ReferrersAPI indicates whether the registry supports the Referrers API.
+	ReferrersAPI bool `json:"referrersAPI"`
+
+	// ArtifactTypeFilter indicates whether the Referrers API of the registry
+	// filters the referrers by artifact type on the server side.
+	ArtifactTypeFilter bool `json:"artifactTypeFilter,omitempty"`
+
+	// ProbedAt is the time the capabilities were probed.
+	ProbedAt time.Time `json:"probedAt"`
+}
+
+// file is the content of the cache file.
+type file struct {
+	// Registries are the capabilities of the registries, indexed by the
+	// registry hostnames.
+	Registries map[string]Capabilities `json:"registries"`
+}
+
+// Cache is the cache of the capabilities of registries, persisted in a file.
+// It is safe for concurrent use.
+type Cache struct {
+	path string
+	ttl  time.Duration
+
+	mu         sync.Mutex
+	registries map[string]Capabilities
+}
+
+// DefaultPath returns the path of the cache file in the user cache directory.
+func DefaultPath() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheDir, "notation", FileName), nil
+}
+
+// Load loads the cache from path. Entries older than ttl are ignored. A
+// missing or malformed cache file is treated as empty, and overwritten when
+// capabilities are recorded.
+func Load(path string, ttl time.Duration) (*Cache, error) {
+	registries, err := readFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Cache{
+		path:       path,
+		ttl:        ttl,
+		registries: registries,
+	}, nil
+}
+
+// Get returns the cached capabilities of registry, if not expired.
+func (c *Cache) Get(registry string) (Capabilities, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	caps, ok := c.registries[registry]
+	if !ok || timeNow().Sub(caps.ProbedAt) >= c.ttl {
+		return Capabilities{}, false
+	}
+	return caps, true
+}
+
+// Set records the capabilities of registry probed now, and persists them.
+// Entries recorded in the file by other processes meanwhile are kept.
+func (c *Cache) Set(registry string, caps Capabilities) error {
+	caps.ProbedAt = timeNow()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.registries[registry] = caps
+
+	registries, err := readFile(c.path)
+	if err != nil {
+		return err
+	}
+	registries[registry] = caps
+	for name, caps := range registries {
+		// drop the entries long expired, so that the file does not grow with
+		// every registry ever visited
+		if timeNow().Sub(caps.ProbedAt) >= 2*c.ttl {
+			delete(registries, name)
+		}
+	}
+	data, err := json.MarshalIndent(file{Registries: registries}, "", "    ")
+	if err != nil {
+		return err
+	}
+	return osutil.WriteFileAtomic(c.path, data, 0600)
+}
+
+// Transport returns a transport based on base, recording the capabilities of
+// registry observed in the responses of the Referrers API. The capabilities
+// are persisted only if they differ from the cached ones.
+func (c *Cache) Transport(base http.RoundTripper, registry string) http.RoundTripper {
+	return &transport{base: base, cache: c, registry: registry}
+}
+
+// transport observes the responses of the Referrers API.
+type transport struct {
+	base     http.RoundTripper
+	cache    *Cache
+	registry string
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || req.Method != http.MethodGet || !referrersPath.MatchString(req.URL.Path) {
+		return resp, err
+	}
+	caps, ok := t.observe(req, resp)
+	if !ok {
+		return resp, nil
+	}
+	if cached, found := t.cache.Get(t.registry); !found || cached.ReferrersAPI != caps.ReferrersAPI || cached.ArtifactTypeFilter != caps.ArtifactTypeFilter {
+		// best effort, the cache is a hint only
+		_ = t.cache.Set(t.registry, caps)
+	}
+	return resp, nil
+}
+
+// observe returns the capabilities observed in the response of the Referrers
+// API, restoring the response body read.
+func (t *transport) observe(req *http.Request, resp *http.Response) (Capabilities, bool) {
+	switch resp.StatusCode {
+	case http.StatusOK:
+		caps := Capabilities{ReferrersAPI: true}
+		if req.URL.Query().Get("artifactType") == "" {
+			// the filter is not probed, keep the cached value
+			cached, _ := t.cache.Get(t.registry)
+			caps.ArtifactTypeFilter = cached.ArtifactTypeFilter
+			return caps, true
+		}
+		body, ok := peekBody(resp)
+		if !ok {
+			return Capabilities{}, false
+		}
+		var index struct {
+			Annotations map[string]string `json:"annotations"`
+		}
+		if err := json.Unmarshal(body, &index); err != nil {
+			return Capabilities{}, false
+		}
+		for _, filter := range strings.Split(index.Annotations[filtersAppliedAnnotation], ",") {
+			if filter == "artifactType" {
+				caps.ArtifactTypeFilter = true
+			}
+		}
+		return caps, true
+	case http.StatusNotFound:
+		// a 404 indicates that the Referrers API is not supported, unless
+		// the repository is not found
+		body, ok := peekBody(resp)
+		if !ok {
+			return Capabilities{}, false
+		}
+		var errResp struct {
+			Errors []struct {
+				Code string `json:"code"`
+			} `json:"errors"`
+		}
+		if json.Unmarshal(body, &errResp) == nil {
+			for _, e := range errResp.Errors {
+				if e.Code == "NAME_UNKNOWN" {
+					return Capabilities{}, false
+				}
+			}
+		}
+		return Capabilities{ReferrersAPI: false}, true
+	default:
+		return Capabilities{}, false
+	}
+}
+
+// peekBody reads the response body and restores it for the caller.
+func peekBody(resp *http.Response) ([]byte, bool) {
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxReferrersIndexBytes+1))
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return body, err == nil && len(body) <= maxReferrersIndexBytes
+}
+
+// readFile reads the capabilities of the registries from path.
+func readFile(path string) (map[string]Capabilities, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return make(map[string]Capabilities), nil
+		}
+		return nil, err
+	}
+	var f file
+	if err := json.Unmarshal(data, &f); err != nil || f.Registries == nil {
+		return make(map[string]Capabilities), nil
+	}
+	return f.Registries, nil
+}
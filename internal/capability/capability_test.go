@@ -0,0 +1,161 @@
+package capability
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCache_GetSet(t *testing.T) {
+	defer func(old func() time.Time) { timeNow = old }(timeNow)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+
+	path := filepath.Join(t.TempDir(), "notation", FileName)
+	cache, err := Load(path, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cache.Get("registry.example.com"); ok {
+		t.Fatal("expected no cached capabilities")
+	}
+	if err := cache.Set("registry.example.com", Capabilities{ReferrersAPI: true, ArtifactTypeFilter: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the capabilities are persisted
+	cache, err = Load(path, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caps, ok := cache.Get("registry.example.com")
+	if !ok || !caps.ReferrersAPI || !caps.ArtifactTypeFilter || !caps.ProbedAt.Equal(now) {
+		t.Fatalf("unexpected cached capabilities: %+v", caps)
+	}
+
+	// entries recorded by other processes are kept
+	other, err := Load(path, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := other.Set("other.example.com", Capabilities{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.Set("registry.example.com", Capabilities{}); err != nil {
+		t.Fatal(err)
+	}
+	cache, err = Load(path, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cache.Get("other.example.com"); !ok {
+		t.Fatal("expected the capabilities recorded by another process to be kept")
+	}
+
+	// the capabilities expire
+	now = now.Add(time.Hour)
+	if _, ok := cache.Get("registry.example.com"); ok {
+		t.Fatal("expected the cached capabilities to expire")
+	}
+}
+
+func TestLoad_Malformed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	if err := os.WriteFile(path, []byte("{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cache, err := Load(path, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.Set("registry.example.com", Capabilities{ReferrersAPI: true}); err != nil {
+		t.Fatal(err)
+	}
+	if cache, err = Load(path, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cache.Get("registry.example.com"); !ok {
+		t.Fatal("expected the malformed cache file to be overwritten")
+	}
+}
+
+func TestTransport(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   string
+		status int
+		body   string
+		want   *Capabilities
+	}{
+		{
+			name:   "supported with filter",
+			path:   "/v2/test/referrers/sha256:abc?artifactType=application%2Fvnd.cncf.notary.signature",
+			status: http.StatusOK,
+			body:   `{"manifests":[],"annotations":{"org.opencontainers.referrers.filtersApplied":"artifactType"}}`,
+			want:   &Capabilities{ReferrersAPI: true, ArtifactTypeFilter: true},
+		},
+		{
+			name:   "supported without filter",
+			path:   "/v2/test/referrers/sha256:abc?artifactType=application%2Fvnd.cncf.notary.signature",
+			status: http.StatusOK,
+			body:   `{"manifests":[]}`,
+			want:   &Capabilities{ReferrersAPI: true},
+		},
+		{
+			name:   "not supported",
+			path:   "/v2/test/referrers/sha256:abc",
+			status: http.StatusNotFound,
+			body:   `{"errors":[{"code":"NOT_FOUND"}]}`,
+			want:   &Capabilities{},
+		},
+		{
+			name:   "repository not found",
+			path:   "/v2/test/referrers/sha256:abc",
+			status: http.StatusNotFound,
+			body:   `{"errors":[{"code":"NAME_UNKNOWN"}]}`,
+		},
+		{
+			name:   "not referrers",
+			path:   "/v2/test/manifests/sha256:abc",
+			status: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer ts.Close()
+			cache, err := Load(filepath.Join(t.TempDir(), FileName), time.Hour)
+			if err != nil {
+				t.Fatal(err)
+			}
+			client := &http.Client{Transport: cache.Transport(http.DefaultTransport, "registry.example.com")}
+			resp, err := client.Get(ts.URL + tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, err := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if err != nil || string(body) != tt.body {
+				t.Fatalf("expected the response body to be restored, got %q, %v", body, err)
+			}
+
+			caps, ok := cache.Get("registry.example.com")
+			if tt.want == nil {
+				if ok {
+					t.Fatalf("expected no capabilities recorded, got %+v", caps)
+				}
+				return
+			}
+			if !ok || caps.ReferrersAPI != tt.want.ReferrersAPI || caps.ArtifactTypeFilter != tt.want.ArtifactTypeFilter {
+				t.Fatalf("expected capabilities %+v, got %+v", tt.want, caps)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package ceremony
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Action is an action recorded in the audit trail.
+type Action string
+
+const (
+	// ActionConfigured records that the key ceremony of a key is configured.
+	ActionConfigured Action = "configured"
+
+	// ActionRemoved records that the key ceremony of a key is removed.
+	ActionRemoved Action = "removed"
+
+	// ActionRequested records that a signing session is requested.
+	ActionRequested Action = "requested"
+
+	// ActionApproved records the approval of a signing session by an
+	// approver.
+	ActionApproved Action = "approved"
+
+	// ActionOpened records that a signing session is opened.
+	ActionOpened Action = "opened"
+
+	// ActionSigned records that the key signs an artifact within a signing
+	// session.
+	ActionSigned Action = "signed"
+
+	// ActionClosed records that a signing session is closed.
+	ActionClosed Action = "closed"
+)
+
+// Record is a record of the audit trail. Each record includes the digest of
+// the previous record, so that removing or altering a record breaks the chain.
+type Record struct {
+	Time          time.Time  `json:"time"`
+	Action        Action     `json:"action"`
+	Key           string     `json:"key"`
+	SessionID     string     `json:"sessionId,omitempty"`
+	RequestDigest string     `json:"requestDigest,omitempty"`
+	Threshold     int        `json:"threshold,omitempty"`
+	Approver      string     `json:"approver,omitempty"`
+	Approvers     []string   `json:"approvers,omitempty"`
+	Reference     string     `json:"reference,omitempty"`
+	ExpiresAt     *time.Time `json:"expiresAt,omitempty"`
+
+	// Previous is the digest of the previous record, empty for the first
+	// record.
+	Previous string `json:"previous,omitempty"`
+}
+
+// ReadAuditTrail reads the records of the audit trail at path, verifying the
+// chain of the records.
+func ReadAuditTrail(path string) ([]Record, error) {
+	records, _, err := readAuditTrail(path)
+	return records, err
+}
+
+// appendRecord appends the record to the audit trail, chained to the last
+// record.
+func (s *Store) appendRecord(record Record) error {
+	path := s.AuditTrailPath()
+	_, last, err := readAuditTrail(path)
+	if err != nil {
+		return err
+	}
+	record.Time = record.Time.UTC()
+	record.Previous = last
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// readAuditTrail reads the records of the audit trail at path, and returns
+// them with the digest of the last record.
+func readAuditTrail(path string) ([]Record, string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, "", nil
+		}
+		return nil, "", err
+	}
+	var records []Record
+	var previous string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, 1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Bytes()
+		var record Record
+		if err := json.Unmarshal(line, &record); err != nil {
+			return nil, "", fmt.Errorf("malformed record %d of the audit trail %s: %w", n, path, err)
+		}
+		if record.Previous != previous {
+			return nil, "", fmt.Errorf("audit trail %s is tampered: record %d is not chained to the previous record", path, n)
+		}
+		records = append(records, record)
+		sum := sha256.Sum256(line)
+		previous = "sha256:" + hex.EncodeToString(sum[:])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, "", err
+	}
+	return records, previous, nil
+}
@@ -0,0 +1,301 @@
+// Package ceremony implements key ceremonies for high-value signing keys: a
+// key requiring a ceremony only signs within a signing session opened with the
+// approvals of M of N operators, and every step of the ceremony is recorded in
+// a tamper-evident audit trail.
+//
+// A ceremony goes through the following steps:
+//   - a signing session is requested for the key, producing a request file,
+//   - each operator approves the request by signing its approval payload with
+//     the private key of the operator, e.g. held in a hardware token,
+//   - the session is opened with the approvals of at least the threshold of
+//     distinct operators,
+//   - the key signs within the session until it is closed or expires.
+package ceremony
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+const (
+	// RequestVersion is the version of the format of the session requests.
+	RequestVersion = "1"
+
+	// DefaultSessionDuration is the default duration of a signing session.
+	DefaultSessionDuration = time.Hour
+
+	// MaxSessionDuration is the maximum duration of a signing session.
+	MaxSessionDuration = 24 * time.Hour
+
+	// payloadPrefix is the prefix of the approval payloads, so that an
+	// approval signature is never mistaken for a signature of anything else.
+	payloadPrefix = "notation-key-ceremony-approval:v1"
+)
+
+// Policy is the key ceremony required by a signing key.
+type Policy struct {
+	// Threshold is the number of distinct approvers required to open a
+	// signing session.
+	Threshold int
+
+	// Approvers are the certificates of the operators allowed to approve
+	// signing sessions, indexed by the names of the operators.
+	Approvers map[string]*x509.Certificate
+}
+
+// NewPolicy returns the policy requiring threshold of the approvers, whose
+// certificates are PEM-encoded. Approvers must not share a public key, as an
+// operator holding the key would count as several approvers.
+func NewPolicy(threshold int, approvers map[string]string) (*Policy, error) {
+	policy := &Policy{
+		Threshold: threshold,
+		Approvers: make(map[string]*x509.Certificate, len(approvers)),
+	}
+	keyOwners := make(map[string]string, len(approvers))
+	for _, name := range sortedNames(approvers) {
+		cert, err := ParseCertificate([]byte(approvers[name]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid certificate of approver %q: %w", name, err)
+		}
+		if owner, ok := keyOwners[string(cert.RawSubjectPublicKeyInfo)]; ok {
+			return nil, fmt.Errorf("approvers %q and %q share the same public key, each approver must hold a distinct key", owner, name)
+		}
+		keyOwners[string(cert.RawSubjectPublicKeyInfo)] = name
+		policy.Approvers[name] = cert
+	}
+	if threshold < 1 || threshold > len(policy.Approvers) {
+		return nil, fmt.Errorf("threshold must be between 1 and the number of approvers %d, got %d", len(policy.Approvers), threshold)
+	}
+	return policy, nil
+}
+
+// sortedNames returns the sorted names of the approvers, so that errors are
+// reported deterministically.
+func sortedNames(approvers map[string]string) []string {
+	names := make([]string, 0, len(approvers))
+	for name := range approvers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseCertificate parses the first PEM-encoded certificate of data, and
+// checks that its public key can verify approvals.
+func ParseCertificate(data []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, errors.New("no PEM-encoded certificate found")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := signatureAlgorithm(cert.PublicKey); err != nil {
+		return nil, err
+	}
+	return cert, nil
+}
+
+// Request is the request of a signing session of a key, to be approved by the
+// operators.
+type Request struct {
+	// Version is the version of the format, RequestVersion.
+	Version string `json:"version"`
+
+	// SessionID is the random identifier of the session.
+	SessionID string `json:"sessionId"`
+
+	// Key is the name of the signing key.
+	Key string `json:"key"`
+
+	// RequestedAt is the time the session is requested.
+	RequestedAt time.Time `json:"requestedAt"`
+
+	// ExpiresAt is the time the session expires, whether opened or not.
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// NewRequest returns the request of a signing session of key lasting
+// duration from now.
+func NewRequest(key string, duration time.Duration, now time.Time) (*Request, error) {
+	if duration <= 0 || duration > MaxSessionDuration {
+		return nil, fmt.Errorf("duration of a signing session must be positive and at most %s, got %s", MaxSessionDuration, duration)
+	}
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return nil, err
+	}
+	now = now.UTC().Truncate(time.Second)
+	return &Request{
+		Version:     RequestVersion,
+		SessionID:   hex.EncodeToString(id[:]),
+		Key:         key,
+		RequestedAt: now,
+		ExpiresAt:   now.Add(duration),
+	}, nil
+}
+
+// ParseRequest parses a request file.
+func ParseRequest(data []byte) (*Request, error) {
+	var request Request
+	if err := json.Unmarshal(data, &request); err != nil {
+		return nil, fmt.Errorf("malformed session request: %w", err)
+	}
+	if request.Version != RequestVersion {
+		return nil, fmt.Errorf("unsupported session request version %q", request.Version)
+	}
+	if request.SessionID == "" || request.Key == "" {
+		return nil, errors.New("malformed session request: missing session ID or key")
+	}
+	return &request, nil
+}
+
+// Digest returns the digest of the request, which the approvals sign.
+func (r *Request) Digest() (string, error) {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
+// Approval is the approval of a session request by an operator.
+type Approval struct {
+	// SessionID is the identifier of the session approved.
+	SessionID string `json:"sessionId"`
+
+	// RequestDigest is the digest of the request approved.
+	RequestDigest string `json:"requestDigest"`
+
+	// Approver is the name of the operator.
+	Approver string `json:"approver"`
+
+	// Signature is the signature of the approval payload by the operator.
+	Signature []byte `json:"signature"`
+}
+
+// Payload returns the payload the approver signs to approve the request of
+// digest requestDigest. Signatures are
+//   - ECDSA signatures in ASN.1 DER with SHA-256, SHA-384 or SHA-512 for the
+//     curves P-256, P-384 and P-521 respectively,
+//   - RSASSA-PSS signatures with SHA-256 and a salt length equal to the hash
+//     length, or
+//   - Ed25519 signatures.
+func Payload(requestDigest, approver string) []byte {
+	return []byte(payloadPrefix + "\n" + requestDigest + "\n" + approver + "\n")
+}
+
+// NewApproval returns the approval of request by approver with the signature
+// of the approval payload, e.g. produced by a hardware token.
+func NewApproval(request *Request, approver string, signature []byte) (*Approval, error) {
+	digest, err := request.Digest()
+	if err != nil {
+		return nil, err
+	}
+	return &Approval{
+		SessionID:     request.SessionID,
+		RequestDigest: digest,
+		Approver:      approver,
+		Signature:     signature,
+	}, nil
+}
+
+// Approve returns the approval of request by approver, signing the approval
+// payload with signer.
+func Approve(request *Request, approver string, signer crypto.Signer) (*Approval, error) {
+	digest, err := request.Digest()
+	if err != nil {
+		return nil, err
+	}
+	algorithm, err := signatureAlgorithm(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+	payload := Payload(digest, approver)
+	var signature []byte
+	switch algorithm {
+	case x509.PureEd25519:
+		signature, err = signer.Sign(rand.Reader, payload, crypto.Hash(0))
+	case x509.SHA256WithRSAPSS:
+		sum := sha256.Sum256(payload)
+		signature, err = signer.Sign(rand.Reader, sum[:], &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: crypto.SHA256})
+	default:
+		hash := hashOf(algorithm)
+		h := hash.New()
+		h.Write(payload)
+		signature, err = signer.Sign(rand.Reader, h.Sum(nil), hash)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign the approval: %w", err)
+	}
+	return &Approval{
+		SessionID:     request.SessionID,
+		RequestDigest: digest,
+		Approver:      approver,
+		Signature:     signature,
+	}, nil
+}
+
+// Verify verifies the signature of the approval with the certificate of the
+// approver, valid at now.
+func (a *Approval) Verify(cert *x509.Certificate, now time.Time) error {
+	if now.Before(cert.NotBefore) || now.After(cert.NotAfter) {
+		return fmt.Errorf("certificate of approver %q is not valid at %s", a.Approver, now.Format(time.RFC3339))
+	}
+	algorithm, err := signatureAlgorithm(cert.PublicKey)
+	if err != nil {
+		return err
+	}
+	if err := cert.CheckSignature(algorithm, Payload(a.RequestDigest, a.Approver), a.Signature); err != nil {
+		return fmt.Errorf("invalid approval signature of approver %q: %w", a.Approver, err)
+	}
+	return nil
+}
+
+// signatureAlgorithm returns the algorithm of the approval signatures by the
+// key.
+func signatureAlgorithm(key crypto.PublicKey) (x509.SignatureAlgorithm, error) {
+	switch key := key.(type) {
+	case ed25519.PublicKey:
+		return x509.PureEd25519, nil
+	case *rsa.PublicKey:
+		return x509.SHA256WithRSAPSS, nil
+	case *ecdsa.PublicKey:
+		switch key.Curve {
+		case elliptic.P256():
+			return x509.ECDSAWithSHA256, nil
+		case elliptic.P384():
+			return x509.ECDSAWithSHA384, nil
+		case elliptic.P521():
+			return x509.ECDSAWithSHA512, nil
+		}
+		return 0, fmt.Errorf("unsupported elliptic curve %s", key.Curve.Params().Name)
+	}
+	return 0, fmt.Errorf("unsupported key type %T", key)
+}
+
+// hashOf returns the hash of an ECDSA signature algorithm.
+func hashOf(algorithm x509.SignatureAlgorithm) crypto.Hash {
+	switch algorithm {
+	case x509.ECDSAWithSHA384:
+		return crypto.SHA384
+	case x509.ECDSAWithSHA512:
+		return crypto.SHA512
+	}
+	return crypto.SHA256
+}
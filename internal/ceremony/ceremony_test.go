@@ -0,0 +1,253 @@
+package ceremony
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testNow = time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+// newApprover returns a signer and its PEM-encoded self-signed certificate.
+func newApprover(t *testing.T, name string, signer crypto.Signer) (crypto.Signer, string) {
+	t.Helper()
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    testNow.Add(-time.Hour),
+		NotAfter:     testNow.Add(365 * 24 * time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, signer.Public(), signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer, string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+func newApprovers(t *testing.T) (map[string]crypto.Signer, map[string]string) {
+	t.Helper()
+	ecKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signers := make(map[string]crypto.Signer)
+	certs := make(map[string]string)
+	for name, key := range map[string]crypto.Signer{"alice": ecKey, "bob": edKey, "carol": rsaKey} {
+		signers[name], certs[name] = newApprover(t, name, key)
+	}
+	return signers, certs
+}
+
+func TestCeremony(t *testing.T) {
+	signers, certs := newApprovers(t)
+	policy, err := NewPolicy(2, certs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := NewStore(t.TempDir())
+	if err := store.Configured("release-key", policy, testNow); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Check("release-key", testNow); !errors.Is(err, ErrNoSession) {
+		t.Fatalf("expected no session, got %v", err)
+	}
+
+	request, err := store.Request("release-key", time.Hour, testNow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the request file is exchanged with the approvers
+	data, err := json.Marshal(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if request, err = ParseRequest(data); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Check("release-key", testNow); !errors.Is(err, ErrNoSession) {
+		t.Fatalf("expected the session not to be approved yet, got %v", err)
+	}
+
+	var approvals []*Approval
+	for _, name := range []string{"alice", "bob", "carol"} {
+		approval, err := Approve(request, name, signers[name])
+		if err != nil {
+			t.Fatal(err)
+		}
+		approvals = append(approvals, approval)
+	}
+
+	// a single approval does not reach the threshold
+	if _, err := store.Open("release-key", policy, approvals[:1], testNow); err == nil || !strings.Contains(err.Error(), "requires the approvals of 2 approvers") {
+		t.Fatalf("expected threshold error, got %v", err)
+	}
+	// duplicate approvals count once
+	if _, err := store.Open("release-key", policy, []*Approval{approvals[0], approvals[0]}, testNow); err == nil {
+		t.Fatal("expected error for duplicate approvals")
+	}
+	// an approval signed by another approver is rejected
+	forged := *approvals[1]
+	forged.Approver = "carol"
+	if _, err := store.Open("release-key", policy, []*Approval{approvals[0], &forged}, testNow); err == nil {
+		t.Fatal("expected error for a forged approval")
+	}
+
+	session, err := store.Open("release-key", policy, approvals[1:], testNow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(session.Approvers) != 2 || session.Approvers[0] != "bob" || session.Approvers[1] != "carol" {
+		t.Fatalf("unexpected approvers: %v", session.Approvers)
+	}
+	if err := store.RecordSigning("release-key", "localhost:5000/app@sha256:abc", testNow.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Check("release-key", testNow.Add(time.Hour)); !errors.Is(err, ErrSessionExpired) {
+		t.Fatalf("expected the session to expire, got %v", err)
+	}
+	if _, err := store.Close("release-key", testNow.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Check("release-key", testNow); !errors.Is(err, ErrNoSession) {
+		t.Fatalf("expected no session after closing, got %v", err)
+	}
+
+	records, err := ReadAuditTrail(store.AuditTrailPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var actions []string
+	for _, record := range records {
+		actions = append(actions, string(record.Action))
+	}
+	if got, want := strings.Join(actions, ","), "configured,requested,approved,approved,opened,signed,closed"; got != want {
+		t.Fatalf("expected actions %s, got %s", want, got)
+	}
+}
+
+func TestOpen_ApprovalOfAnotherSession(t *testing.T) {
+	signers, certs := newApprovers(t)
+	policy, err := NewPolicy(1, certs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := NewStore(t.TempDir())
+	old, err := store.Request("release-key", time.Hour, testNow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	approval, err := Approve(old, "alice", signers["alice"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a new request replaces the old one, invalidating its approvals
+	if _, err := store.Request("release-key", time.Hour, testNow); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Open("release-key", policy, []*Approval{approval}, testNow); err == nil {
+		t.Fatal("expected error for an approval of another session")
+	}
+	// the session expires before it is approved
+	if _, err := store.Open("release-key", policy, nil, testNow.Add(2*time.Hour)); !errors.Is(err, ErrSessionExpired) {
+		t.Fatalf("expected the session to expire, got %v", err)
+	}
+}
+
+func TestNewApproval_ExternalSignature(t *testing.T) {
+	signers, certs := newApprovers(t)
+	policy, err := NewPolicy(1, certs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request, err := NewRequest("release-key", time.Hour, testNow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest, err := request.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// e.g. a hardware token signing the payload with Ed25519
+	signature := ed25519.Sign(signers["bob"].(ed25519.PrivateKey), Payload(digest, "bob"))
+	approval, err := NewApproval(request, "bob", signature)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := approval.Verify(policy.Approvers["bob"], testNow); err != nil {
+		t.Fatal(err)
+	}
+	if err := approval.Verify(policy.Approvers["bob"], testNow.Add(2*365*24*time.Hour)); err == nil {
+		t.Fatal("expected error for an expired certificate")
+	}
+}
+
+func TestNewPolicy(t *testing.T) {
+	signers, certs := newApprovers(t)
+	if _, err := NewPolicy(4, certs); err == nil {
+		t.Fatal("expected error for an unreachable threshold")
+	}
+	if _, err := NewPolicy(1, map[string]string{"alice": "not a certificate"}); err == nil {
+		t.Fatal("expected error for an invalid certificate")
+	}
+	// one operator with one key cannot pose as two approvers
+	if _, err := NewPolicy(2, map[string]string{"alice": certs["alice"], "alice2": certs["alice"], "bob": certs["bob"]}); err == nil || !strings.Contains(err.Error(), "share the same public key") {
+		t.Fatalf("expected error for approvers sharing a key, got %v", err)
+	}
+	_, reissued := newApprover(t, "alice2", signers["alice"])
+	if _, err := NewPolicy(2, map[string]string{"alice": certs["alice"], "alice2": reissued}); err == nil {
+		t.Fatal("expected error for approvers with distinct certificates of the same key")
+	}
+	if _, err := NewRequest("release-key", 25*time.Hour, testNow); err == nil {
+		t.Fatal("expected error for a session too long")
+	}
+}
+
+func TestReadAuditTrail_Tampered(t *testing.T) {
+	store := NewStore(t.TempDir())
+	for i := 0; i < 3; i++ {
+		if _, err := store.Request("release-key", time.Hour, testNow); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data, err := os.ReadFile(store.AuditTrailPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// remove the second record
+	lines := strings.SplitAfter(string(data), "\n")
+	if err := os.WriteFile(store.AuditTrailPath(), []byte(lines[0]+lines[2]), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadAuditTrail(store.AuditTrailPath()); err == nil || !strings.Contains(err.Error(), "tampered") {
+		t.Fatalf("expected tampering error, got %v", err)
+	}
+	// no record is appended to a tampered audit trail
+	if _, err := store.Request("release-key", time.Hour, testNow); err == nil {
+		t.Fatal("expected error appending to a tampered audit trail")
+	}
+	if _, err := ReadAuditTrail(filepath.Join(t.TempDir(), "missing.jsonl")); err != nil {
+		t.Fatalf("expected an empty audit trail, got %v", err)
+	}
+}
@@ -0,0 +1,224 @@
+package ceremony
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/notaryproject/notation/internal/osutil"
+)
+
+var (
+	// ErrNoSession indicates that no signing session of the key is open.
+	ErrNoSession = errors.New("no signing session is open")
+
+	// ErrSessionExpired indicates that the signing session of the key has
+	// expired.
+	ErrSessionExpired = errors.New("signing session has expired")
+)
+
+// Session is a signing session of a key.
+type Session struct {
+	// Request is the request of the session.
+	Request Request `json:"request"`
+
+	// RequestDigest is the digest of the request, which the approvals sign.
+	RequestDigest string `json:"requestDigest"`
+
+	// Approvers are the names of the operators who approved the session,
+	// sorted.
+	Approvers []string `json:"approvers,omitempty"`
+
+	// OpenedAt is the time the session is opened, nil if the session is not
+	// approved yet.
+	OpenedAt *time.Time `json:"openedAt,omitempty"`
+}
+
+// Store stores the signing sessions of the keys and the audit trail of the
+// ceremonies in a directory.
+type Store struct {
+	root string
+}
+
+// NewStore returns the store in the directory root.
+func NewStore(root string) *Store {
+	return &Store{root: root}
+}
+
+// AuditTrailPath returns the path of the audit trail.
+func (s *Store) AuditTrailPath() string {
+	return filepath.Join(s.root, "audit.jsonl")
+}
+
+// Configured records in the audit trail that the key ceremony of key is
+// configured, or removed if policy is nil.
+func (s *Store) Configured(key string, policy *Policy, now time.Time) error {
+	record := Record{Time: now, Action: ActionConfigured, Key: key}
+	if policy == nil {
+		record.Action = ActionRemoved
+	} else {
+		record.Threshold = policy.Threshold
+		for name := range policy.Approvers {
+			record.Approvers = append(record.Approvers, name)
+		}
+		sort.Strings(record.Approvers)
+	}
+	return s.appendRecord(record)
+}
+
+// Request requests a signing session of key lasting duration from now,
+// replacing the session of the key, if any.
+func (s *Store) Request(key string, duration time.Duration, now time.Time) (*Request, error) {
+	request, err := NewRequest(key, duration, now)
+	if err != nil {
+		return nil, err
+	}
+	digest, err := request.Digest()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.appendRecord(Record{Time: now, Action: ActionRequested, Key: key, SessionID: request.SessionID, RequestDigest: digest, ExpiresAt: &request.ExpiresAt}); err != nil {
+		return nil, err
+	}
+	if err := s.save(&Session{Request: *request, RequestDigest: digest}); err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+// Open opens the requested signing session of key with the approvals, which
+// must include the approvals of at least policy.Threshold distinct approvers
+// of the policy. Each approval is recorded in the audit trail.
+func (s *Store) Open(key string, policy *Policy, approvals []*Approval, now time.Time) (*Session, error) {
+	session, err := s.load(key)
+	if err != nil {
+		return nil, err
+	}
+	if session.OpenedAt != nil {
+		return nil, fmt.Errorf("signing session %s of key %q is already open", session.Request.SessionID, key)
+	}
+	if !now.Before(session.Request.ExpiresAt) {
+		return nil, fmt.Errorf("%w: signing session %s of key %q expired at %s, request a new one", ErrSessionExpired, session.Request.SessionID, key, session.Request.ExpiresAt.Format(time.RFC3339))
+	}
+
+	approved := make(map[string]bool)
+	approvedKeys := make(map[string]bool)
+	for _, approval := range approvals {
+		if approval.SessionID != session.Request.SessionID || approval.RequestDigest != session.RequestDigest {
+			return nil, fmt.Errorf("approval of approver %q is for session %s, not for the requested session %s", approval.Approver, approval.SessionID, session.Request.SessionID)
+		}
+		cert, ok := policy.Approvers[approval.Approver]
+		if !ok {
+			return nil, fmt.Errorf("%q is not an approver of key %q", approval.Approver, key)
+		}
+		if approved[approval.Approver] {
+			return nil, fmt.Errorf("duplicate approvals of approver %q", approval.Approver)
+		}
+		if approvedKeys[string(cert.RawSubjectPublicKeyInfo)] {
+			return nil, fmt.Errorf("approver %q shares the public key of another approver of the session", approval.Approver)
+		}
+		if err := approval.Verify(cert, now); err != nil {
+			return nil, err
+		}
+		approved[approval.Approver] = true
+		approvedKeys[string(cert.RawSubjectPublicKeyInfo)] = true
+	}
+	if len(approved) < policy.Threshold {
+		return nil, fmt.Errorf("signing session of key %q requires the approvals of %d approvers, got %d", key, policy.Threshold, len(approved))
+	}
+
+	for name := range approved {
+		session.Approvers = append(session.Approvers, name)
+	}
+	sort.Strings(session.Approvers)
+	for _, name := range session.Approvers {
+		if err := s.appendRecord(Record{Time: now, Action: ActionApproved, Key: key, SessionID: session.Request.SessionID, RequestDigest: session.RequestDigest, Approver: name}); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.appendRecord(Record{Time: now, Action: ActionOpened, Key: key, SessionID: session.Request.SessionID, Approvers: session.Approvers, ExpiresAt: &session.Request.ExpiresAt}); err != nil {
+		return nil, err
+	}
+	session.OpenedAt = &now
+	if err := s.save(session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// Check returns the open signing session of key at now, or ErrNoSession or
+// ErrSessionExpired.
+func (s *Store) Check(key string, now time.Time) (*Session, error) {
+	session, err := s.load(key)
+	if err != nil {
+		return nil, err
+	}
+	if session.OpenedAt == nil {
+		return nil, fmt.Errorf("%w: signing session %s of key %q is not approved yet", ErrNoSession, session.Request.SessionID, key)
+	}
+	if !now.Before(session.Request.ExpiresAt) {
+		return nil, fmt.Errorf("%w: signing session %s of key %q expired at %s", ErrSessionExpired, session.Request.SessionID, key, session.Request.ExpiresAt.Format(time.RFC3339))
+	}
+	return session, nil
+}
+
+// RecordSigning records in the audit trail that key signs reference within
+// its open signing session.
+func (s *Store) RecordSigning(key, reference string, now time.Time) error {
+	session, err := s.Check(key, now)
+	if err != nil {
+		return err
+	}
+	return s.appendRecord(Record{Time: now, Action: ActionSigned, Key: key, SessionID: session.Request.SessionID, Reference: reference})
+}
+
+// Close closes the signing session of key, whether opened or not.
+func (s *Store) Close(key string, now time.Time) (*Session, error) {
+	session, err := s.load(key)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.appendRecord(Record{Time: now, Action: ActionClosed, Key: key, SessionID: session.Request.SessionID}); err != nil {
+		return nil, err
+	}
+	if err := os.Remove(s.sessionPath(key)); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// sessionPath returns the path of the session file of key.
+func (s *Store) sessionPath(key string) string {
+	return filepath.Join(s.root, "sessions", url.PathEscape(key)+".json")
+}
+
+// load loads the session of key.
+func (s *Store) load(key string) (*Session, error) {
+	data, err := os.ReadFile(s.sessionPath(key))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("%w: no signing session of key %q is requested", ErrNoSession, key)
+		}
+		return nil, err
+	}
+	var session Session
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, fmt.Errorf("malformed signing session of key %q: %w", key, err)
+	}
+	return &session, nil
+}
+
+// save saves the session of its key.
+func (s *Store) save(session *Session) error {
+	data, err := json.MarshalIndent(session, "", "    ")
+	if err != nil {
+		return err
+	}
+	return osutil.WriteFileAtomic(s.sessionPath(session.Request.Key), data, 0600)
+}
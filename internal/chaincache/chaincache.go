@@ -0,0 +1,186 @@
+// Package chaincache memoizes the results of validating certificate chains
+// within a process, so that artifacts signed by the same key thousands of
+// times do not redo identical X.509 work.
+//
+// Results are keyed by the thumbprint of the leaf certificate, the digest of
+// the whole chain, the digest of the trust store the chain is validated
+// against and the digest of any other input of the validation, e.g. the OCSP
+// responses stapled to a signature. Concurrent validations of the same chain wait for the first one in
+// flight rather than validating the chain again.
+package chaincache
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// Key identifies the validation of a certificate chain against a trust store.
+type Key struct {
+	// Leaf is the SHA-256 thumbprint of the leaf certificate.
+	Leaf string
+
+	// Chain is the SHA-256 digest of the whole chain, so that the same leaf
+	// certificate served with other intermediate certificates is validated
+	// again.
+	Chain string
+
+	// TrustStore is the digest of the trust store.
+	TrustStore string
+
+	// Extra is the SHA-256 digest of the other inputs of the validation, e.g.
+	// the OCSP responses stapled to a signature, or empty if none.
+	Extra string
+}
+
+// NewKey returns the key of validating chain, ordered from the leaf
+// certificate to the root certificate, against the trust store of digest
+// trustStore, with the other inputs extra.
+func NewKey(chain []*x509.Certificate, trustStore string, extra ...[]byte) Key {
+	key := Key{TrustStore: trustStore}
+	if len(chain) > 0 {
+		key.Leaf = Thumbprint(chain[0])
+	}
+	h := sha256.New()
+	for _, cert := range chain {
+		sum := sha256.Sum256(cert.Raw)
+		h.Write(sum[:])
+	}
+	key.Chain = hex.EncodeToString(h.Sum(nil))
+	if len(extra) > 0 {
+		h.Reset()
+		for _, input := range extra {
+			sum := sha256.Sum256(input)
+			h.Write(sum[:])
+		}
+		key.Extra = hex.EncodeToString(h.Sum(nil))
+	}
+	return key
+}
+
+// Thumbprint returns the hex-encoded SHA-256 thumbprint of cert.
+func Thumbprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return hex.EncodeToString(sum[:])
+}
+
+// Stats are the statistics of a Cache.
+type Stats struct {
+	// Hits is the number of validations served from the cache, including the
+	// ones waiting for a validation in flight.
+	Hits int64
+
+	// Misses is the number of validations done.
+	Misses int64
+}
+
+// entry is a memoized result. done is closed once the result is set.
+type entry[V any] struct {
+	done    chan struct{}
+	value   V
+	expires time.Time
+}
+
+// Cache memoizes the results of validating certificate chains against a trust
+// store. A Cache is safe for concurrent use.
+type Cache[V any] struct {
+	trustStore string
+	maxAge     time.Duration
+	now        func() time.Time
+
+	mu      sync.Mutex
+	entries map[Key]*entry[V]
+	stats   Stats
+}
+
+// New returns a Cache of the validations against the trust store of digest
+// trustStore. Results expire after maxAge, or never if maxAge is not
+// positive.
+func New[V any](trustStore string, maxAge time.Duration) *Cache[V] {
+	return &Cache[V]{
+		trustStore: trustStore,
+		maxAge:     maxAge,
+		now:        time.Now,
+		entries:    make(map[Key]*entry[V]),
+	}
+}
+
+// TrustStore returns the digest of the trust store of the cache.
+func (c *Cache[V]) TrustStore() string {
+	return c.trustStore
+}
+
+// Do returns the memoized result of validating chain, or validates chain
+// with validate otherwise. The result is memoized only if validate reports
+// it as cacheable, e.g. results of transient network failures are not.
+//
+// A nil Cache validates chain every time.
+func (c *Cache[V]) Do(chain []*x509.Certificate, validate func() (value V, cacheable bool)) V {
+	return c.DoWith(chain, nil, validate)
+}
+
+// DoWith is like Do, but the validation also depends on the other inputs
+// extra, so that the result is memoized per chain and extra.
+func (c *Cache[V]) DoWith(chain []*x509.Certificate, extra [][]byte, validate func() (value V, cacheable bool)) V {
+	if c == nil {
+		value, _ := validate()
+		return value
+	}
+	key := NewKey(chain, c.trustStore, extra...)
+	c.mu.Lock()
+	if e, ok := c.entries[key]; ok {
+		select {
+		case <-e.done:
+			if c.maxAge <= 0 || c.now().Before(e.expires) {
+				c.stats.Hits++
+				c.mu.Unlock()
+				return e.value
+			}
+			// expired
+		default:
+			// in flight
+			c.stats.Hits++
+			c.mu.Unlock()
+			<-e.done
+			return e.value
+		}
+	}
+	e := &entry[V]{done: make(chan struct{})}
+	c.entries[key] = e
+	c.stats.Misses++
+	c.mu.Unlock()
+
+	value, cacheable := validate()
+
+	c.mu.Lock()
+	e.value = value
+	e.expires = c.now().Add(c.maxAge)
+	if !cacheable && c.entries[key] == e {
+		delete(c.entries, key)
+	}
+	close(e.done)
+	c.mu.Unlock()
+	return value
+}
+
+// Stats returns the statistics of the cache.
+func (c *Cache[V]) Stats() Stats {
+	if c == nil {
+		return Stats{}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
+
+// Len returns the number of memoized results, including the ones in flight.
+func (c *Cache[V]) Len() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
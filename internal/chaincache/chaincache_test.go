@@ -0,0 +1,153 @@
+package chaincache
+
+import (
+	"crypto/x509"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func testChain(raws ...string) []*x509.Certificate {
+	var chain []*x509.Certificate
+	for _, raw := range raws {
+		chain = append(chain, &x509.Certificate{Raw: []byte(raw)})
+	}
+	return chain
+}
+
+func TestNewKey(t *testing.T) {
+	key := NewKey(testChain("leaf", "root"), "sha256:store")
+	if key.Leaf != Thumbprint(&x509.Certificate{Raw: []byte("leaf")}) || key.TrustStore != "sha256:store" {
+		t.Fatalf("unexpected key: %+v", key)
+	}
+	if other := NewKey(testChain("leaf", "other root"), "sha256:store"); other.Leaf != key.Leaf || other.Chain == key.Chain {
+		t.Fatalf("expected the same leaf and another chain, got %+v and %+v", key, other)
+	}
+	if other := NewKey(testChain("leaf", "root"), "sha256:other"); other == key {
+		t.Fatal("expected another key for another trust store")
+	}
+	if other := NewKey(testChain("leaf", "root"), "sha256:store", []byte("stapled")); other.Chain != key.Chain || other.Extra == key.Extra {
+		t.Fatalf("expected the same chain and other inputs, got %+v and %+v", key, other)
+	}
+}
+
+func TestCache_Do(t *testing.T) {
+	cache := New[string]("sha256:store", 0)
+	var calls int
+	validate := func() (string, bool) {
+		calls++
+		return "valid", true
+	}
+	for i := 0; i < 3; i++ {
+		if got := cache.Do(testChain("leaf", "root"), validate); got != "valid" {
+			t.Fatalf("Do() = %q, want valid", got)
+		}
+	}
+	cache.Do(testChain("other leaf", "root"), validate)
+	if calls != 2 {
+		t.Fatalf("expected 2 validations, got %d", calls)
+	}
+	if stats := cache.Stats(); stats.Hits != 2 || stats.Misses != 2 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestCache_DoWith(t *testing.T) {
+	cache := New[string]("sha256:store", 0)
+	var calls int
+	validate := func() (string, bool) {
+		calls++
+		return "valid", true
+	}
+	cache.Do(testChain("leaf"), validate)
+	cache.DoWith(testChain("leaf"), [][]byte{[]byte("stapled")}, validate)
+	cache.DoWith(testChain("leaf"), [][]byte{[]byte("stapled")}, validate)
+	cache.DoWith(testChain("leaf"), [][]byte{[]byte("other")}, validate)
+	if calls != 3 {
+		t.Fatalf("expected 3 validations, got %d", calls)
+	}
+}
+
+func TestCache_DoNotCacheable(t *testing.T) {
+	cache := New[string]("sha256:store", 0)
+	var calls int
+	for i := 0; i < 2; i++ {
+		cache.Do(testChain("leaf"), func() (string, bool) {
+			calls++
+			return "unknown", false
+		})
+	}
+	if calls != 2 || cache.Len() != 0 {
+		t.Fatalf("expected 2 validations and no memoized result, got %d and %d", calls, cache.Len())
+	}
+}
+
+func TestCache_DoExpired(t *testing.T) {
+	now := time.Now()
+	cache := New[string]("sha256:store", time.Minute)
+	cache.now = func() time.Time { return now }
+	var calls int
+	validate := func() (string, bool) {
+		calls++
+		return "valid", true
+	}
+	cache.Do(testChain("leaf"), validate)
+	now = now.Add(30 * time.Second)
+	cache.Do(testChain("leaf"), validate)
+	if calls != 1 {
+		t.Fatalf("expected 1 validation before expiry, got %d", calls)
+	}
+	now = now.Add(time.Minute)
+	cache.Do(testChain("leaf"), validate)
+	if calls != 2 {
+		t.Fatalf("expected 2 validations after expiry, got %d", calls)
+	}
+}
+
+func TestCache_DoConcurrent(t *testing.T) {
+	cache := New[string]("sha256:store", 0)
+	release := make(chan struct{})
+	var calls atomic.Int32
+	var wg sync.WaitGroup
+	results := make([]string, 8)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = cache.Do(testChain("leaf", "root"), func() (string, bool) {
+				calls.Add(1)
+				<-release
+				return "valid", true
+			})
+		}(i)
+	}
+	// wait until all but the validating goroutine are waiting
+	for cache.Stats().Hits+cache.Stats().Misses < int64(len(results)) {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+	if got := calls.Load(); got != 1 {
+		t.Fatalf("expected 1 validation, got %d", got)
+	}
+	for i, result := range results {
+		if result != "valid" {
+			t.Fatalf("result %d = %q, want valid", i, result)
+		}
+	}
+}
+
+func TestCache_Nil(t *testing.T) {
+	var cache *Cache[string]
+	var calls int
+	for i := 0; i < 2; i++ {
+		cache.Do(testChain("leaf"), func() (string, bool) {
+			calls++
+			return "valid", true
+		})
+	}
+	if calls != 2 || cache.Len() != 0 || cache.Stats() != (Stats{}) {
+		t.Fatalf("expected a nil cache to validate every time, got %d validations", calls)
+	}
+}
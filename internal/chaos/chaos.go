@@ -0,0 +1,151 @@
+// Package chaos injects failures into verifications, so that platform teams
+// can test that their admission controllers and pipelines handle the failures
+// of notation gracefully. Failures are only injected when configured by the
+// hidden experimental flags of notation verify.
+package chaos
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	notationregistry "github.com/notaryproject/notation-go/registry"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+)
+
+// ErrInjected is the error of failures injected by chaos mode.
+var ErrInjected = errors.New("failure injected by chaos mode")
+
+// Config configures the failures to inject. A nil Config injects no failure.
+type Config struct {
+	// RegistryLatency is the latency added to every registry request.
+	RegistryLatency time.Duration
+
+	// OCSPFailure fails every request to OCSP responders.
+	OCSPFailure bool
+
+	// CorruptSignature corrupts every signature envelope fetched from the
+	// registry.
+	CorruptSignature bool
+}
+
+// Enabled reports whether any failure is injected.
+func (c *Config) Enabled() bool {
+	return c != nil && (c.RegistryLatency > 0 || c.OCSPFailure || c.CorruptSignature)
+}
+
+// FailOCSP returns ErrInjected if requests to OCSP responders fail.
+func (c *Config) FailOCSP() error {
+	if c != nil && c.OCSPFailure {
+		return ErrInjected
+	}
+	return nil
+}
+
+// delay waits for the registry latency, or until ctx is done.
+func (c *Config) delay(ctx context.Context) error {
+	if c.RegistryLatency <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(c.RegistryLatency)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+type contextKey struct{}
+
+// WithConfig returns a context with the config.
+func WithConfig(ctx context.Context, c *Config) context.Context {
+	return context.WithValue(ctx, contextKey{}, c)
+}
+
+// FromContext returns the config of the context, or nil if not set.
+func FromContext(ctx context.Context) *Config {
+	c, _ := ctx.Value(contextKey{}).(*Config)
+	return c
+}
+
+// Repository wraps a notationregistry.Repository and injects the registry
+// latency and the corruption of signature envelopes.
+type Repository struct {
+	notationregistry.Repository
+	config *Config
+}
+
+// NewRepository returns a Repository injecting the failures of config into
+// repo, or repo itself if config injects no registry failure.
+func NewRepository(repo notationregistry.Repository, config *Config) notationregistry.Repository {
+	if config == nil || (config.RegistryLatency <= 0 && !config.CorruptSignature) {
+		return repo
+	}
+	return &Repository{
+		Repository: repo,
+		config:     config,
+	}
+}
+
+// Resolve resolves a reference to a manifest descriptor after the registry
+// latency.
+func (r *Repository) Resolve(ctx context.Context, reference string) (ocispec.Descriptor, error) {
+	if err := r.config.delay(ctx); err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	return r.Repository.Resolve(ctx, reference)
+}
+
+// ListSignatures lists the signature manifests of desc after the registry
+// latency.
+func (r *Repository) ListSignatures(ctx context.Context, desc ocispec.Descriptor, fn func(signatureManifests []ocispec.Descriptor) error) error {
+	if err := r.config.delay(ctx); err != nil {
+		return err
+	}
+	return r.Repository.ListSignatures(ctx, desc, fn)
+}
+
+// FetchSignatureBlob returns the signature envelope blob and descriptor of the
+// signature manifest desc after the registry latency, corrupting the blob if
+// configured.
+//
+// The descriptor of a corrupted blob matches the corrupted content, so that
+// the corruption is reported as an invalid signature rather than as tampering
+// by the registry.
+func (r *Repository) FetchSignatureBlob(ctx context.Context, desc ocispec.Descriptor) ([]byte, ocispec.Descriptor, error) {
+	if err := r.config.delay(ctx); err != nil {
+		return nil, ocispec.Descriptor{}, err
+	}
+	blob, blobDesc, err := r.Repository.FetchSignatureBlob(ctx, desc)
+	if err != nil || !r.config.CorruptSignature || len(blob) == 0 {
+		return blob, blobDesc, err
+	}
+	corrupted := Corrupt(blob)
+	corruptedDesc := content.NewDescriptorFromBytes(blobDesc.MediaType, corrupted)
+	corruptedDesc.Annotations = blobDesc.Annotations
+	return corrupted, corruptedDesc, nil
+}
+
+// PushSignature pushes a signature after the registry latency.
+func (r *Repository) PushSignature(ctx context.Context, mediaType string, blob []byte, subject ocispec.Descriptor, annotations map[string]string) (blobDesc, manifestDesc ocispec.Descriptor, err error) {
+	if err := r.config.delay(ctx); err != nil {
+		return ocispec.Descriptor{}, ocispec.Descriptor{}, err
+	}
+	return r.Repository.PushSignature(ctx, mediaType, blob, subject, annotations)
+}
+
+// Corrupt returns a copy of data with a bit of the middle byte flipped.
+func Corrupt(data []byte) []byte {
+	corrupted := make([]byte, len(data))
+	copy(corrupted, data)
+	if len(corrupted) > 0 {
+		corrupted[len(corrupted)/2] ^= 0x01
+	}
+	return corrupted
+}
+
+// compile time check
+var _ notationregistry.Repository = (*Repository)(nil)
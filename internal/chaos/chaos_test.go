@@ -0,0 +1,90 @@
+package chaos
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	notationregistry "github.com/notaryproject/notation-go/registry"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+)
+
+type mockRepository struct {
+	notationregistry.Repository
+	blob []byte
+}
+
+func (m *mockRepository) Resolve(ctx context.Context, reference string) (ocispec.Descriptor, error) {
+	return ocispec.Descriptor{}, nil
+}
+
+func (m *mockRepository) FetchSignatureBlob(ctx context.Context, desc ocispec.Descriptor) ([]byte, ocispec.Descriptor, error) {
+	return m.blob, content.NewDescriptorFromBytes("application/jose+json", m.blob), nil
+}
+
+func TestConfig_Nil(t *testing.T) {
+	var c *Config
+	if c.Enabled() {
+		t.Fatal("nil config is enabled")
+	}
+	if err := c.FailOCSP(); err != nil {
+		t.Fatalf("FailOCSP() = %v, want nil", err)
+	}
+	if FromContext(context.Background()) != nil {
+		t.Fatal("FromContext() of an empty context is not nil")
+	}
+	repo := &mockRepository{}
+	if got := NewRepository(repo, c); got != repo {
+		t.Fatal("NewRepository() wraps the repository without failures")
+	}
+	if got := NewRepository(repo, &Config{OCSPFailure: true}); got != repo {
+		t.Fatal("NewRepository() wraps the repository without registry failures")
+	}
+}
+
+func TestConfig_FailOCSP(t *testing.T) {
+	c := &Config{OCSPFailure: true}
+	if !c.Enabled() {
+		t.Fatal("config is not enabled")
+	}
+	ctx := WithConfig(context.Background(), c)
+	if err := FromContext(ctx).FailOCSP(); !errors.Is(err, ErrInjected) {
+		t.Fatalf("FailOCSP() = %v, want %v", err, ErrInjected)
+	}
+}
+
+func TestRepository_CorruptSignature(t *testing.T) {
+	blob := []byte(`{"payload":"eyJ0YXJnZXRBcnRpZmFjdCI6e319","signature":"c2lnbmF0dXJl"}`)
+	repo := NewRepository(&mockRepository{blob: blob}, &Config{CorruptSignature: true})
+	got, gotDesc, err := repo.FetchSignatureBlob(context.Background(), ocispec.Descriptor{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(got, blob) || len(got) != len(blob) {
+		t.Fatalf("FetchSignatureBlob() = %s, want a corrupted blob", got)
+	}
+	// the descriptor matches the corrupted content
+	if want := content.NewDescriptorFromBytes("application/jose+json", got); gotDesc.Digest != want.Digest || gotDesc.Size != want.Size {
+		t.Fatalf("FetchSignatureBlob() descriptor = %v, want %v", gotDesc, want)
+	}
+}
+
+func TestRepository_RegistryLatency(t *testing.T) {
+	repo := NewRepository(&mockRepository{}, &Config{RegistryLatency: 50 * time.Millisecond})
+	start := time.Now()
+	if _, err := repo.Resolve(context.Background(), "v1"); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Fatalf("Resolve() took %v, want at least 50ms", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := NewRepository(&mockRepository{}, &Config{RegistryLatency: time.Hour}).Resolve(ctx, "v1"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Resolve() error = %v, want %v", err, context.Canceled)
+	}
+}
@@ -14,6 +14,10 @@ import (
 const (
 	OutputPlaintext = "text"
 	OutputJSON      = "json"
+	OutputCSV       = "csv"
+	OutputSARIF     = "sarif"
+	OutputTree      = "tree"
+	OutputYAML      = "yaml"
 )
 
 var (
@@ -88,11 +92,19 @@ var (
 		Shorthand: "m",
 	}
 	PflagUserMetadataSignUsage   = "{key}={value} pairs that are added to the signature payload"
-	PflagUserMetadataVerifyUsage = "user defined {key}={value} pairs that must be present in the signature for successful verification if provided"
+	PflagUserMetadataVerifyUsage = "user defined assertions on {key}={value} pairs in the signature for successful verification if provided, in the format of {key}, {key}={value}, {key}!={value}, {key}~~{regexp}, {key}~{glob}, or {key}{op}{number} where {op} is one of >, >=, <, <="
 	SetPflagUserMetadata         = func(fs *pflag.FlagSet, p *[]string, usage string) {
 		fs.StringArrayVarP(p, PflagUserMetadata.Name, PflagUserMetadata.Shorthand, nil, usage)
 	}
 
+	PflagKeepTagReference = &pflag.Flag{
+		Name:  "keep-tag-reference",
+		Usage: "keep the tag of the reference alongside the resolved digest in the output, in the format of <repository>:<tag>@<digest>",
+	}
+	SetPflagKeepTagReference = func(fs *pflag.FlagSet, p *bool) {
+		fs.BoolVar(p, PflagKeepTagReference.Name, false, PflagKeepTagReference.Usage)
+	}
+
 	PflagOutput = &pflag.Flag{
 		Name:      "output",
 		Shorthand: "o",
@@ -2,7 +2,10 @@ package cmd
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
+	"github.com/notaryproject/notation/internal/events"
 	"github.com/notaryproject/notation/internal/trace"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -50,3 +53,38 @@ func (opts *LoggingFlagOpts) SetLoggerLevel(ctx context.Context) context.Context
 	}
 	return ctx
 }
+
+// EventFlagOpts option struct.
+type EventFlagOpts struct {
+	EventSocket string
+	EventSink   string
+}
+
+// ApplyFlags applies flags to a command flag set.
+func (opts *EventFlagOpts) ApplyFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&opts.EventSocket, "event-socket", "", "[Experimental] path of a Unix domain socket to stream progress and result events to as newline delimited JSON")
+	fs.StringVar(&opts.EventSink, "event-sink", "", "[Experimental] file to append, \"-\" for stdout, or HTTP(S) URL to post progress and result events to in the CloudEvents format")
+}
+
+// OpenEventEmitter connects to the event socket or opens the event sink, if
+// set, and returns a context with the emitter of the events of the command.
+// The returned emitter is nil if neither is set.
+func (opts *EventFlagOpts) OpenEventEmitter(ctx context.Context, command string) (context.Context, *events.Emitter, error) {
+	var emitter *events.Emitter
+	var err error
+	switch {
+	case opts.EventSocket != "" && opts.EventSink != "":
+		return nil, nil, errors.New("flags \"--event-socket\" and \"--event-sink\" cannot be used together")
+	case opts.EventSocket != "":
+		if emitter, err = events.Dial(opts.EventSocket, command); err != nil {
+			return nil, nil, fmt.Errorf("failed to connect to the event socket: %w", err)
+		}
+	case opts.EventSink != "":
+		if emitter, err = events.OpenCloudEvents(opts.EventSink, command); err != nil {
+			return nil, nil, fmt.Errorf("failed to open the event sink: %w", err)
+		}
+	default:
+		return ctx, nil, nil
+	}
+	return events.WithEmitter(ctx, emitter), emitter, nil
+}
@@ -2,13 +2,31 @@ package cmd
 
 import (
 	"context"
+	"crypto"
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
+	"fmt"
+	"os"
+	"time"
 
+	"github.com/notaryproject/notation-core-go/signature"
+	corex509 "github.com/notaryproject/notation-core-go/x509"
 	"github.com/notaryproject/notation-go"
 	"github.com/notaryproject/notation-go/dir"
-	"github.com/notaryproject/notation-go/plugin"
+	"github.com/notaryproject/notation-go/log"
+	"github.com/notaryproject/notation-go/plugin/proto"
 	"github.com/notaryproject/notation-go/signer"
+	"github.com/notaryproject/notation/internal/archive"
+	"github.com/notaryproject/notation/internal/envelope"
+	"github.com/notaryproject/notation/internal/keychain"
+	"github.com/notaryproject/notation/internal/kms"
+	"github.com/notaryproject/notation/internal/localsigner"
+	"github.com/notaryproject/notation/internal/pkcs11"
+	"github.com/notaryproject/notation/internal/pluginproto"
+	"github.com/notaryproject/notation/internal/revocation"
 	"github.com/notaryproject/notation/pkg/configutil"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 )
 
 // GetSigner returns a signer according to the CLI context.
@@ -16,7 +34,7 @@ func GetSigner(ctx context.Context, opts *SignerFlagOpts) (notation.Signer, erro
 	// Check if using on-demand key
 	if opts.KeyID != "" && opts.PluginName != "" && opts.Key == "" {
 		// Construct a signer from on-demand key
-		mgr := plugin.NewCLIManager(dir.PluginFS())
+		mgr := pluginproto.NewCLIManager(dir.PluginFS())
 		plugin, err := mgr.Get(ctx, opts.PluginName)
 		if err != nil {
 			return nil, err
@@ -33,10 +51,35 @@ func GetSigner(ctx context.Context, opts *SignerFlagOpts) (notation.Signer, erro
 	if key.X509KeyPair != nil {
 		return signer.NewFromFiles(key.X509KeyPair.KeyPath, key.X509KeyPair.CertificatePath)
 	}
+	// Construct a signer of the key in a PKCS #11 token, of which the session
+	// is closed when the process exits
+	pkcs11Key, err := configutil.LoadPKCS11Key(key.Name)
+	if err != nil {
+		return nil, err
+	}
+	if pkcs11Key != nil {
+		return NewPKCS11Signer(*pkcs11Key)
+	}
+	// Construct a signer of the identity of the macOS Keychain
+	keychainKey, err := configutil.LoadKeychainKey(key.Name)
+	if err != nil {
+		return nil, err
+	}
+	if keychainKey != nil {
+		return NewKeychainSigner(*keychainKey)
+	}
+	// Construct a signer of the key in a KMS
+	kmsKey, err := configutil.LoadKMSKey(key.Name)
+	if err != nil {
+		return nil, err
+	}
+	if kmsKey != nil {
+		return NewKMSSigner(ctx, *kmsKey)
+	}
 	// Construct a plugin signer if key name provided as the CLI argument
 	// corresponds to an external key
 	if key.ExternalKey != nil {
-		mgr := plugin.NewCLIManager(dir.PluginFS())
+		mgr := pluginproto.NewCLIManager(dir.PluginFS())
 		plugin, err := mgr.Get(ctx, key.PluginName)
 		if err != nil {
 			return nil, err
@@ -45,3 +88,234 @@ func GetSigner(ctx context.Context, opts *SignerFlagOpts) (notation.Signer, erro
 	}
 	return nil, errors.New("unsupported key, either provide a local key and certificate file paths, or a key name in config.json, check [DOC_PLACEHOLDER] for details")
 }
+
+// PKCS11Signer signs with a key in a PKCS #11 token.
+type PKCS11Signer struct {
+	*localsigner.Signer
+	key *pkcs11.Key
+}
+
+// NewPKCS11Signer returns a signer of the key in a PKCS #11 token, logged in
+// with the user PIN of the environment variable NOTATION_PKCS11_PIN. The
+// certificate chain is read from the certificate file of the key, or is the
+// certificate of the token with the label of the key.
+func NewPKCS11Signer(pkcs11Key configutil.PKCS11Key) (*PKCS11Signer, error) {
+	key, err := pkcs11.Open(pkcs11Key.Module, pkcs11Key.Slot, pkcs11Key.Label, os.Getenv(pkcs11.PINEnv))
+	if err != nil {
+		return nil, err
+	}
+	var certs []*x509.Certificate
+	if pkcs11Key.CertificatePath != "" {
+		certs, err = corex509.ReadCertificateFile(pkcs11Key.CertificatePath)
+		if err != nil {
+			key.Close()
+			return nil, fmt.Errorf("failed to read the certificate chain of PKCS #11 key %q: %w", pkcs11Key.Label, err)
+		}
+	} else if cert := key.Certificate(); cert != nil {
+		certs = []*x509.Certificate{cert}
+	} else {
+		key.Close()
+		return nil, fmt.Errorf("no certificate labeled %q found in the token, set the certificate chain file of the key", pkcs11Key.Label)
+	}
+	s, err := localsigner.NewFromCryptoSigner(key, certs)
+	if err != nil {
+		key.Close()
+		return nil, err
+	}
+	return &PKCS11Signer{Signer: s, key: key}, nil
+}
+
+// Close closes the session of the token.
+func (s *PKCS11Signer) Close() error {
+	return s.key.Close()
+}
+
+// KeychainSigner signs with a signing identity of the macOS Keychain.
+type KeychainSigner struct {
+	*localsigner.Signer
+	key *keychain.Key
+}
+
+// NewKeychainSigner returns a signer of the signing identity of the macOS
+// Keychain. The certificate chain is read from the certificate file of the
+// key, or is built from the certificates of the Keychain.
+func NewKeychainSigner(keychainKey configutil.KeychainKey) (*KeychainSigner, error) {
+	key, err := keychain.Open(keychainKey.Identity)
+	if err != nil {
+		return nil, err
+	}
+	certs := key.Certificates()
+	if keychainKey.CertificatePath != "" {
+		certs, err = corex509.ReadCertificateFile(keychainKey.CertificatePath)
+		if err != nil {
+			key.Close()
+			return nil, fmt.Errorf("failed to read the certificate chain of Keychain identity %q: %w", keychainKey.Identity, err)
+		}
+	}
+	s, err := localsigner.NewFromCryptoSigner(key, certs)
+	if err != nil {
+		key.Close()
+		return nil, err
+	}
+	return &KeychainSigner{Signer: s, key: key}, nil
+}
+
+// Close releases the private key of the identity.
+func (s *KeychainSigner) Close() error {
+	return s.key.Close()
+}
+
+// NewKMSSigner returns a signer of the key in a KMS, with the certificate
+// chain read from the file of the key, or fetched from the store of the KMS.
+func NewKMSSigner(ctx context.Context, kmsKey configutil.KMSKey) (*localsigner.Signer, error) {
+	certs, err := kms.FetchCertificateChain(ctx, kmsKey.CertificatePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the certificate chain of KMS key %q: %w", kmsKey.URI, err)
+	}
+	key, err := kms.Open(ctx, kmsKey.URI)
+	if err != nil {
+		return nil, err
+	}
+	return localsigner.NewFromCryptoSigner(key, certs)
+}
+
+// GetSigningPlugin returns the name and the version of the plugin of the
+// signing key of opts, or empty strings for local keys.
+func GetSigningPlugin(ctx context.Context, opts *SignerFlagOpts) (name, version string, err error) {
+	var pluginConfig map[string]string
+	if opts.KeyID != "" && opts.PluginName != "" && opts.Key == "" {
+		name = opts.PluginName
+	} else {
+		key, err := configutil.ResolveKey(opts.Key)
+		if err != nil {
+			return "", "", err
+		}
+		if key.ExternalKey == nil {
+			return "", "", nil
+		}
+		name, pluginConfig = key.PluginName, key.PluginConfig
+	}
+	mgr := pluginproto.NewCLIManager(dir.PluginFS())
+	pl, err := mgr.Get(ctx, name)
+	if err != nil {
+		return "", "", err
+	}
+	metadata, err := pl.GetMetadata(ctx, &proto.GetMetadataRequest{PluginConfig: pluginConfig})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get the metadata of plugin %q: %w", name, err)
+	}
+	return name, metadata.Version, nil
+}
+
+// GetOCSPStaplingSigner returns a signer of the local key of opts, which
+// staples the OCSP responses of the signing certificate chain fetched by
+// checker to the signature envelope.
+func GetOCSPStaplingSigner(ctx context.Context, opts *SignerFlagOpts, checker *revocation.Checker) (notation.Signer, error) {
+	if opts.KeyID != "" && opts.PluginName != "" && opts.Key == "" {
+		return nil, errors.New("OCSP stapling is not supported for on-demand keys of plugins")
+	}
+	key, err := configutil.ResolveKey(opts.Key)
+	if err != nil {
+		return nil, err
+	}
+	if key.X509KeyPair == nil {
+		return nil, fmt.Errorf("OCSP stapling is only supported for local keys, %q is not a local key", key.Name)
+	}
+	keyPair, err := tls.LoadX509KeyPair(key.X509KeyPair.CertificatePath, key.X509KeyPair.KeyPath)
+	if err != nil {
+		return nil, err
+	}
+	certs := make([]*x509.Certificate, len(keyPair.Certificate))
+	for i, der := range keyPair.Certificate {
+		if certs[i], err = x509.ParseCertificate(der); err != nil {
+			return nil, err
+		}
+	}
+	responses, err := checker.FetchOCSPResponses(ctx, certs)
+	if err != nil {
+		return nil, err
+	}
+	if len(responses) == 0 {
+		return nil, fmt.Errorf("no certificate of the chain of key %q has an OCSP server", key.Name)
+	}
+	log.GetLogger(ctx).Infof("Stapling %d OCSP responses to the signature", len(responses))
+	return localsigner.New(keyPair.PrivateKey, certs, revocation.StapledAttribute(responses))
+}
+
+// hashAlgorithmSigner rejects signatures whose payload is not hashed with the
+// required hash algorithm.
+type hashAlgorithmSigner struct {
+	notation.Signer
+	hash crypto.Hash
+}
+
+// NewHashAlgorithmSigner returns a signer failing the signing unless the
+// signature payload is hashed with hash. The hash algorithm is determined by
+// the signing key, including keys of plugins, so the signature is checked
+// before it is pushed.
+func NewHashAlgorithmSigner(s notation.Signer, hash crypto.Hash) notation.Signer {
+	return &hashAlgorithmSigner{Signer: s, hash: hash}
+}
+
+// Sign signs the artifact and checks the hash algorithm of the signature.
+func (s *hashAlgorithmSigner) Sign(ctx context.Context, desc ocispec.Descriptor, opts notation.SignerSignOptions) ([]byte, *signature.SignerInfo, error) {
+	sig, signerInfo, err := s.Signer.Sign(ctx, desc, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := envelope.CheckSignatureAlgorithm(signerInfo.SignatureAlgorithm, s.hash); err != nil {
+		return nil, nil, err
+	}
+	return sig, signerInfo, nil
+}
+
+// timestampSigner countersigns the signatures with RFC 3161 timestamps.
+type timestampSigner struct {
+	notation.Signer
+	timestamper archive.Timestamper
+	roots       *x509.CertPool
+}
+
+// NewTimestampSigner returns a signer embedding the RFC 3161 timestamp token
+// of each signature, obtained from timestamper, in the unprotected header of
+// the signature envelope. The timestamp authority must chain to roots, so that
+// signatures are never pushed with timestamps the verifiers cannot trust.
+func NewTimestampSigner(s notation.Signer, timestamper archive.Timestamper, roots *x509.CertPool) notation.Signer {
+	return &timestampSigner{Signer: s, timestamper: timestamper, roots: roots}
+}
+
+// Sign signs the artifact and timestamps the signature. The timestamped digest
+// is the hash of the signature value, computed with the hash algorithm of the
+// signature algorithm.
+func (s *timestampSigner) Sign(ctx context.Context, desc ocispec.Descriptor, opts notation.SignerSignOptions) ([]byte, *signature.SignerInfo, error) {
+	sig, signerInfo, err := s.Signer.Sign(ctx, desc, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	hash := signerInfo.SignatureAlgorithm.Hash()
+	if !hash.Available() {
+		return nil, nil, fmt.Errorf("hash algorithm of signature algorithm %v is not available for timestamping", signerInfo.SignatureAlgorithm)
+	}
+	h := hash.New()
+	h.Write(signerInfo.Signature)
+	tokenDER, err := s.timestamper.Timestamp(ctx, hash, h.Sum(nil))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to timestamp the signature: %w", err)
+	}
+	token, chain, err := archive.VerifyCountersignature(tokenDER, hash, signerInfo.Signature, s.roots)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to verify the timestamp token with the timestamp root certificates: %w", err)
+	}
+	if len(signerInfo.CertificateChain) > 0 {
+		signingCert := signerInfo.CertificateChain[0]
+		if token.GenTime.Before(signingCert.NotBefore) || token.GenTime.After(signingCert.NotAfter) {
+			return nil, nil, fmt.Errorf("timestamp %s is out of the validity period of the signing certificate, %s to %s", token.GenTime.Format(time.RFC3339), signingCert.NotBefore.Format(time.RFC3339), signingCert.NotAfter.Format(time.RFC3339))
+		}
+	}
+	if sig, err = envelope.SetTimestampSignature(sig, opts.SignatureMediaType, tokenDER); err != nil {
+		return nil, nil, fmt.Errorf("failed to embed the timestamp token in the signature envelope: %w", err)
+	}
+	signerInfo.UnsignedAttributes.TimestampSignature = tokenDER
+	log.GetLogger(ctx).Infof("Timestamped the signature at %s by %s", token.GenTime.Format(time.RFC3339), chain[0].Subject)
+	return sig, signerInfo, nil
+}
@@ -0,0 +1,86 @@
+// Package color colorizes the output of the notation CLI.
+//
+// Colors are enabled for terminals by default, and can be disabled by setting
+// the NO_COLOR environment variable (https://no-color.org) to a non-empty
+// value or by the "--color" flag.
+package color
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/term"
+)
+
+// supported color modes
+const (
+	ModeAuto   = "auto"
+	ModeAlways = "always"
+	ModeNever  = "never"
+)
+
+// ANSI escape codes
+const (
+	green  = "\x1b[32m"
+	red    = "\x1b[31m"
+	yellow = "\x1b[33m"
+	reset  = "\x1b[0m"
+)
+
+var mode = ModeAuto
+
+// SetMode sets the color mode, options: auto, always, never.
+func SetMode(m string) error {
+	switch m {
+	case ModeAuto, ModeAlways, ModeNever:
+		mode = m
+		return nil
+	default:
+		return fmt.Errorf("unsupported color mode %q, options: %s, %s, %s", m, ModeAuto, ModeAlways, ModeNever)
+	}
+}
+
+// Success colors s in green if colors are enabled for f.
+func Success(f *os.File, s string) string {
+	return colorize(f, green, s)
+}
+
+// Failure colors s in red if colors are enabled for f.
+func Failure(f *os.File, s string) string {
+	return colorize(f, red, s)
+}
+
+// Warning colors s in yellow if colors are enabled for f.
+func Warning(f *os.File, s string) string {
+	return colorize(f, yellow, s)
+}
+
+func colorize(f *os.File, code, s string) string {
+	if !enabled(f) {
+		return s
+	}
+	return code + s + reset
+}
+
+// enabled returns true if colors are enabled for output written to f.
+func enabled(f *os.File) bool {
+	switch mode {
+	case ModeAlways:
+		return true
+	case ModeNever:
+		return false
+	}
+	if noColor() {
+		return false
+	}
+	if os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	return f != nil && term.IsTerminal(int(f.Fd()))
+}
+
+// noColor returns true if the NO_COLOR environment variable is set to a
+// non-empty value, see https://no-color.org.
+func noColor() bool {
+	return os.Getenv("NO_COLOR") != ""
+}
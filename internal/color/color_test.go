@@ -0,0 +1,49 @@
+package color
+
+import (
+	"os"
+	"testing"
+)
+
+func TestColorize(t *testing.T) {
+	defer SetMode(ModeAuto)
+
+	if err := SetMode(ModeAlways); err != nil {
+		t.Fatal(err)
+	}
+	if got := Success(os.Stdout, "ok"); got != green+"ok"+reset {
+		t.Fatalf("expected colored output, got %q", got)
+	}
+
+	if err := SetMode(ModeNever); err != nil {
+		t.Fatal(err)
+	}
+	if got := Failure(os.Stdout, "failed"); got != "failed" {
+		t.Fatalf("expected plain output, got %q", got)
+	}
+
+	if err := SetMode(ModeAuto); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("NO_COLOR", "1")
+	if got := Warning(os.Stdout, "warning"); got != "warning" {
+		t.Fatalf("expected plain output with NO_COLOR set, got %q", got)
+	}
+}
+
+func TestNoColor(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	if noColor() {
+		t.Fatal("expected colors not to be disabled by an empty NO_COLOR")
+	}
+	t.Setenv("NO_COLOR", "1")
+	if !noColor() {
+		t.Fatal("expected colors to be disabled by NO_COLOR")
+	}
+}
+
+func TestSetMode_Unsupported(t *testing.T) {
+	if err := SetMode("sometimes"); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
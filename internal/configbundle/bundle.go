@@ -0,0 +1,401 @@
+// Package configbundle packages the configuration of notation, i.e. the trust
+// policy, the trust stores and the non-secret settings, with the list of the
+// installed plugins, so that build agents are provisioned identically.
+//
+// A bundle is a gzip-compressed tar archive of a manifest and the configuration
+// files at their paths relative to the notation config directory. Signing keys
+// and credentials are never bundled. Plugins are only listed with the SHA-256
+// digests of their executables, as they are specific to the platform.
+package configbundle
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/notaryproject/notation-go/dir"
+	"github.com/notaryproject/notation/internal/filelock"
+	"github.com/notaryproject/notation/internal/osutil"
+)
+
+// Version is the version of the bundle format.
+const Version = "1.0"
+
+// MediaType is the media type of a bundle published as the layer of an OCI
+// artifact, e.g. with "oras push", as the canonical configuration of hosts.
+const MediaType = "application/vnd.cncf.notary.x-config-bundle.v1.tar+gzip"
+
+// manifestName is the name of the manifest in the archive.
+const manifestName = "manifest.json"
+
+// maxBundleSize is the maximum size of the uncompressed content of a bundle.
+const maxBundleSize = 64 * 1024 * 1024
+
+// Manifest lists the content of a bundle.
+type Manifest struct {
+	// Version is the version of the bundle format.
+	Version string `json:"version"`
+
+	// CreatedAt is the time the bundle was exported.
+	CreatedAt time.Time `json:"createdAt"`
+
+	// Files are the configuration files of the bundle, sorted by path.
+	Files []File `json:"files"`
+
+	// Plugins are the plugins installed when the bundle was exported, sorted
+	// by name.
+	Plugins []Plugin `json:"plugins"`
+}
+
+// File is a configuration file.
+type File struct {
+	// Path is the slash-separated path of the file relative to the notation
+	// config directory, e.g. "truststore/x509/ca/acme/root.crt".
+	Path string `json:"path"`
+
+	// SHA256 is the hex-encoded SHA-256 digest of the file.
+	SHA256 string `json:"sha256"`
+}
+
+// Plugin is an installed plugin.
+type Plugin struct {
+	Name string `json:"name"`
+
+	// SHA256 is the hex-encoded SHA-256 digest of the plugin executable.
+	SHA256 string `json:"sha256"`
+}
+
+// Bundle is the configuration of a host.
+type Bundle struct {
+	Manifest
+
+	// content is the content of the files indexed by path.
+	content map[string][]byte
+}
+
+// Collect collects the configuration files of the notation config directory
+// configDir, i.e. config.json, the trust policy and the trust stores, and
+// lists the plugins.
+func Collect(configDir string, plugins []Plugin) (*Bundle, error) {
+	bundle := &Bundle{
+		Manifest: Manifest{Version: Version, Plugins: sortedPlugins(plugins)},
+		content:  make(map[string][]byte),
+	}
+	for _, name := range []string{dir.PathConfigFile, dir.PathTrustPolicy} {
+		data, err := os.ReadFile(filepath.Join(configDir, name))
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		bundle.add(name, data)
+	}
+	trustStoreDir := filepath.Join(configDir, dir.TrustStoreDir)
+	err := filepath.WalkDir(trustStoreDir, func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && filePath == trustStoreDir {
+				return nil
+			}
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(configDir, filePath)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(filePath)
+		if err != nil {
+			return err
+		}
+		bundle.add(filepath.ToSlash(rel), data)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	bundle.sortFiles()
+	return bundle, nil
+}
+
+// add adds the file at path with data to the bundle.
+func (b *Bundle) add(path string, data []byte) {
+	b.content[path] = data
+	b.Files = append(b.Files, File{Path: path, SHA256: digestOf(data)})
+}
+
+// sortFiles sorts the files of the bundle by path.
+func (b *Bundle) sortFiles() {
+	sort.Slice(b.Files, func(i, j int) bool { return b.Files[i].Path < b.Files[j].Path })
+}
+
+// Content returns the content of the file at path, if bundled.
+func (b *Bundle) Content(path string) ([]byte, bool) {
+	data, ok := b.content[path]
+	return data, ok
+}
+
+// Write writes the bundle as a gzip-compressed tar archive to w, created at
+// the time now.
+func (b *Bundle) Write(w io.Writer, now time.Time) error {
+	b.CreatedAt = now.UTC()
+	manifestJSON, err := json.MarshalIndent(b.Manifest, "", "    ")
+	if err != nil {
+		return err
+	}
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+	writeEntry := func(name string, data []byte) error {
+		if err := tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     name,
+			Mode:     0600,
+			Size:     int64(len(data)),
+			ModTime:  b.CreatedAt,
+		}); err != nil {
+			return err
+		}
+		_, err := tw.Write(data)
+		return err
+	}
+	// the manifest comes first so that readers learn the content upfront
+	if err := writeEntry(manifestName, manifestJSON); err != nil {
+		return err
+	}
+	for _, file := range b.Files {
+		if err := writeEntry(file.Path, b.content[file.Path]); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gw.Close()
+}
+
+// Read reads a bundle written by Write, checking the digests of the files
+// against the manifest.
+func Read(r io.Reader) (*Bundle, error) {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("bundle is not gzip-compressed: %w", err)
+	}
+	defer gr.Close()
+	tr := tar.NewReader(gr)
+	var manifest *Manifest
+	content := make(map[string][]byte)
+	var size int64
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("malformed bundle: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			return nil, fmt.Errorf("unexpected entry %q of bundle: not a regular file", header.Name)
+		}
+		size += header.Size
+		if size > maxBundleSize {
+			return nil, fmt.Errorf("bundle exceeds %d bytes", maxBundleSize)
+		}
+		data, err := io.ReadAll(io.LimitReader(tr, header.Size))
+		if err != nil {
+			return nil, fmt.Errorf("malformed bundle: %w", err)
+		}
+		if header.Name == manifestName {
+			manifest = &Manifest{}
+			if err := json.Unmarshal(data, manifest); err != nil {
+				return nil, fmt.Errorf("malformed manifest of bundle: %w", err)
+			}
+			continue
+		}
+		if err := validatePath(header.Name); err != nil {
+			return nil, err
+		}
+		if _, ok := content[header.Name]; ok {
+			return nil, fmt.Errorf("duplicate file %q in bundle", header.Name)
+		}
+		content[header.Name] = data
+	}
+	if manifest == nil {
+		return nil, errors.New("bundle has no manifest")
+	}
+	if manifest.Version != Version {
+		return nil, fmt.Errorf("unsupported bundle version %q, expecting %q", manifest.Version, Version)
+	}
+	listed := make(map[string]bool)
+	for _, file := range manifest.Files {
+		data, ok := content[file.Path]
+		if !ok {
+			return nil, fmt.Errorf("file %q of the manifest is missing in bundle", file.Path)
+		}
+		if digest := digestOf(data); digest != file.SHA256 {
+			return nil, fmt.Errorf("digest mismatch of file %q in bundle: expecting %s, got %s", file.Path, file.SHA256, digest)
+		}
+		listed[file.Path] = true
+	}
+	for name := range content {
+		if !listed[name] {
+			return nil, fmt.Errorf("file %q in bundle is not listed in the manifest", name)
+		}
+	}
+	bundle := &Bundle{Manifest: *manifest, content: content}
+	bundle.sortFiles()
+	bundle.Plugins = sortedPlugins(bundle.Plugins)
+	return bundle, nil
+}
+
+// validatePath returns an error if name is not the path of a configuration
+// file which may be bundled.
+func validatePath(name string) error {
+	switch {
+	case name == dir.PathConfigFile, name == dir.PathTrustPolicy:
+		return nil
+	case strings.HasPrefix(name, dir.TrustStoreDir+"/") && path.Clean(name) == name:
+		return nil
+	default:
+		return fmt.Errorf("unexpected file %q in bundle", name)
+	}
+}
+
+// Apply writes the configuration files of the bundle to the notation config
+// directory configDir, and removes the configuration files of configDir not in
+// the bundle, so that configDir is configured identically. config.json is
+// locked while the files are written, so that parallel notation processes do
+// not lose updates.
+func (b *Bundle) Apply(configDir string) error {
+	return filelock.WithLock(filepath.Join(configDir, dir.PathConfigFile), func() error {
+		return b.apply(configDir)
+	})
+}
+
+// apply applies the bundle to configDir, of which config.json is locked by the
+// caller.
+func (b *Bundle) apply(configDir string) error {
+	host, err := Collect(configDir, nil)
+	if err != nil {
+		return err
+	}
+	for _, file := range b.Files {
+		if err := osutil.WriteFileAtomic(filepath.Join(configDir, filepath.FromSlash(file.Path)), b.content[file.Path], 0600); err != nil {
+			return err
+		}
+	}
+	for _, file := range host.Files {
+		if _, ok := b.content[file.Path]; ok {
+			continue
+		}
+		if err := os.Remove(filepath.Join(configDir, filepath.FromSlash(file.Path))); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Drift is a difference of a host from a bundle.
+type Drift struct {
+	// Kind is "file" or "plugin".
+	Kind string `json:"kind"`
+
+	// Name is the path of the file or the name of the plugin.
+	Name string `json:"name"`
+
+	// Status is "missing" if the file or plugin of the bundle is missing on
+	// the host, "modified" if its content differs, or "unexpected" if the
+	// host has a file or plugin not in the bundle.
+	Status string `json:"status"`
+}
+
+// kinds of drifts
+const (
+	KindFile   = "file"
+	KindPlugin = "plugin"
+)
+
+// statuses of drifts
+const (
+	StatusMissing    = "missing"
+	StatusModified   = "modified"
+	StatusUnexpected = "unexpected"
+)
+
+// String returns the description of the drift.
+func (d Drift) String() string {
+	return fmt.Sprintf("%s %s is %s", d.Kind, d.Name, d.Status)
+}
+
+// Diff returns the differences of host from the bundle, sorted by kind and
+// name.
+func (b *Bundle) Diff(host *Bundle) []Drift {
+	drifts := []Drift{}
+	hostFiles := make(map[string]string)
+	for _, file := range host.Files {
+		hostFiles[file.Path] = file.SHA256
+	}
+	bundleFiles := make(map[string]string)
+	for _, file := range b.Files {
+		bundleFiles[file.Path] = file.SHA256
+	}
+	drifts = append(drifts, diff(KindFile, bundleFiles, hostFiles)...)
+
+	hostPlugins := make(map[string]string)
+	for _, plugin := range host.Plugins {
+		hostPlugins[plugin.Name] = plugin.SHA256
+	}
+	bundlePlugins := make(map[string]string)
+	for _, plugin := range b.Plugins {
+		bundlePlugins[plugin.Name] = plugin.SHA256
+	}
+	return append(drifts, diff(KindPlugin, bundlePlugins, hostPlugins)...)
+}
+
+// diff returns the drifts of the digests of host from want, indexed by names,
+// sorted by name.
+func diff(kind string, want, host map[string]string) []Drift {
+	var drifts []Drift
+	for name, digest := range want {
+		hostDigest, ok := host[name]
+		switch {
+		case !ok:
+			drifts = append(drifts, Drift{Kind: kind, Name: name, Status: StatusMissing})
+		case hostDigest != digest:
+			drifts = append(drifts, Drift{Kind: kind, Name: name, Status: StatusModified})
+		}
+	}
+	for name := range host {
+		if _, ok := want[name]; !ok {
+			drifts = append(drifts, Drift{Kind: kind, Name: name, Status: StatusUnexpected})
+		}
+	}
+	sort.Slice(drifts, func(i, j int) bool { return drifts[i].Name < drifts[j].Name })
+	return drifts
+}
+
+// sortedPlugins returns the plugins sorted by name.
+func sortedPlugins(plugins []Plugin) []Plugin {
+	sorted := append([]Plugin{}, plugins...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	return sorted
+}
+
+// digestOf returns the hex-encoded SHA-256 digest of data.
+func digestOf(data []byte) string {
+	h := sha256.Sum256(data)
+	return hex.EncodeToString(h[:])
+}
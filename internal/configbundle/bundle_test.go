@@ -0,0 +1,136 @@
+package configbundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestBundle(t *testing.T) {
+	source := t.TempDir()
+	writeFiles(t, source, map[string]string{
+		"config.json":                      `{"insecureRegistries":[]}`,
+		"trustpolicy.json":                 `{"version":"1.0","trustPolicies":[]}`,
+		"truststore/x509/ca/acme/root.crt": "root",
+		"signingkeys.json":                 `{"keys":[]}`,
+		"localkeys/test.key":               "secret",
+	})
+	plugins := []Plugin{{Name: "kms", SHA256: "aa"}, {Name: "csr", SHA256: "bb"}}
+	bundle, err := Collect(source, plugins)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := bundle.Write(&buf, time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+
+	read, err := Read(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// signing keys are never bundled
+	var paths []string
+	for _, file := range read.Files {
+		paths = append(paths, file.Path)
+	}
+	if want := []string{"config.json", "trustpolicy.json", "truststore/x509/ca/acme/root.crt"}; !reflect.DeepEqual(paths, want) {
+		t.Fatalf("bundled files = %v, want %v", paths, want)
+	}
+	if want := []Plugin{{Name: "csr", SHA256: "bb"}, {Name: "kms", SHA256: "aa"}}; !reflect.DeepEqual(read.Plugins, want) {
+		t.Fatalf("bundled plugins = %v, want %v", read.Plugins, want)
+	}
+
+	// a new host is configured identically
+	target := t.TempDir()
+	writeFiles(t, target, map[string]string{
+		"trustpolicy.json":                     `{}`,
+		"truststore/x509/ca/other/extra.crt":   "extra",
+		"truststore/x509/signingAuthority/a.c": "a",
+	})
+	host, err := Collect(target, []Plugin{{Name: "kms", SHA256: "cc"}, {Name: "extra", SHA256: "dd"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Drift{
+		{Kind: KindFile, Name: "config.json", Status: StatusMissing},
+		{Kind: KindFile, Name: "trustpolicy.json", Status: StatusModified},
+		{Kind: KindFile, Name: "truststore/x509/ca/acme/root.crt", Status: StatusMissing},
+		{Kind: KindFile, Name: "truststore/x509/ca/other/extra.crt", Status: StatusUnexpected},
+		{Kind: KindFile, Name: "truststore/x509/signingAuthority/a.c", Status: StatusUnexpected},
+		{Kind: KindPlugin, Name: "csr", Status: StatusMissing},
+		{Kind: KindPlugin, Name: "extra", Status: StatusUnexpected},
+		{Kind: KindPlugin, Name: "kms", Status: StatusModified},
+	}
+	if got := read.Diff(host); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Diff() = %v, want %v", got, want)
+	}
+
+	if err := read.Apply(target); err != nil {
+		t.Fatal(err)
+	}
+	host, err = Collect(target, read.Plugins)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := read.Diff(host); len(got) != 0 {
+		t.Fatalf("Diff() = %v after Apply(), want no drift", got)
+	}
+}
+
+func TestRead_Tampered(t *testing.T) {
+	newArchive := func(entries map[string]string, order ...string) []byte {
+		var buf bytes.Buffer
+		gw := gzip.NewWriter(&buf)
+		tw := tar.NewWriter(gw)
+		for _, name := range order {
+			data := entries[name]
+			if err := tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: name, Mode: 0600, Size: int64(len(data))}); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := tw.Write([]byte(data)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		tw.Close()
+		gw.Close()
+		return buf.Bytes()
+	}
+	manifest := `{"version":"1.0","files":[{"path":"trustpolicy.json","sha256":"44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a"}],"plugins":[]}`
+	tests := []struct {
+		name    string
+		archive []byte
+	}{
+		{name: "digest mismatch", archive: newArchive(map[string]string{"manifest.json": manifest, "trustpolicy.json": "{\"version\":\"1.0\"}"}, "manifest.json", "trustpolicy.json")},
+		{name: "missing file", archive: newArchive(map[string]string{"manifest.json": manifest}, "manifest.json")},
+		{name: "path traversal", archive: newArchive(map[string]string{"manifest.json": manifest, "truststore/../signingkeys.json": "{}"}, "manifest.json", "truststore/../signingkeys.json")},
+		{name: "unexpected file", archive: newArchive(map[string]string{"manifest.json": manifest, "localkeys/test.key": "secret"}, "manifest.json", "localkeys/test.key")},
+		{name: "no manifest", archive: newArchive(map[string]string{"trustpolicy.json": "{}"}, "trustpolicy.json")},
+		{name: "not gzip", archive: []byte("{}")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Read(bytes.NewReader(tt.archive)); err == nil {
+				t.Fatal("expected error, got nil")
+			}
+		})
+	}
+}
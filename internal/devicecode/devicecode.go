@@ -0,0 +1,255 @@
+// Package devicecode implements the OAuth 2.0 device authorization grant
+// (RFC 8628) for registries fronted by identity providers that disallow
+// password grants.
+package devicecode
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultScopes are the scopes requested by default. The "offline_access"
+// scope requests a refresh token, which is stored as the registry credential.
+var DefaultScopes = []string{"openid", "offline_access"}
+
+// defaultInterval is the polling interval if not set by the identity
+// provider, as defined by RFC 8628 section 3.2.
+const defaultInterval = 5 * time.Second
+
+// slowDownIncrement is the increment of the polling interval on a
+// "slow_down" error, as defined by RFC 8628 section 3.5.
+const slowDownIncrement = 5 * time.Second
+
+// ErrAccessDenied indicates that the user denied the authorization request.
+var ErrAccessDenied = errors.New("authorization request denied")
+
+// ErrExpired indicates that the device code expired before the user
+// authorized the request.
+var ErrExpired = errors.New("device code expired")
+
+// Client runs the device authorization grant against an identity provider.
+type Client struct {
+	// DeviceAuthorizationEndpoint is the device authorization endpoint of
+	// the identity provider.
+	DeviceAuthorizationEndpoint string
+
+	// TokenEndpoint is the token endpoint of the identity provider.
+	TokenEndpoint string
+
+	// ClientID is the identifier of the client registered with the identity
+	// provider.
+	ClientID string
+
+	// Scopes are the requested scopes.
+	Scopes []string
+
+	// HTTPClient is the client sending the requests. http.DefaultClient is
+	// used if nil.
+	HTTPClient *http.Client
+
+	// sleep waits for d or until ctx is done, replaced in tests.
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// Authorization is the device authorization response.
+type Authorization struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete,omitempty"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval,omitempty"`
+}
+
+// Token is the token response.
+type Token struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	ExpiresIn    int    `json:"expires_in,omitempty"`
+}
+
+// errorResponse is the error response of the token endpoint.
+type errorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+// Discover returns a client with the endpoints of the OpenID Connect
+// provider issuer, read from its discovery document.
+func Discover(ctx context.Context, httpClient *http.Client, issuer, clientID string) (*Client, error) {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	discoveryURL := strings.TrimSuffix(issuer, "/") + "/.well-known/openid-configuration"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, discoveryURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to discover the endpoints of %s: %s", issuer, resp.Status)
+	}
+	var doc struct {
+		DeviceAuthorizationEndpoint string `json:"device_authorization_endpoint"`
+		TokenEndpoint               string `json:"token_endpoint"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to parse the discovery document of %s: %w", issuer, err)
+	}
+	if doc.DeviceAuthorizationEndpoint == "" || doc.TokenEndpoint == "" {
+		return nil, fmt.Errorf("identity provider %s does not support the device authorization grant", issuer)
+	}
+	return &Client{
+		DeviceAuthorizationEndpoint: doc.DeviceAuthorizationEndpoint,
+		TokenEndpoint:               doc.TokenEndpoint,
+		ClientID:                    clientID,
+		Scopes:                      DefaultScopes,
+		HTTPClient:                  httpClient,
+	}, nil
+}
+
+// Authorize requests a device code and a user code.
+func (c *Client) Authorize(ctx context.Context) (*Authorization, error) {
+	form := url.Values{"client_id": {c.ClientID}}
+	if len(c.Scopes) > 0 {
+		form.Set("scope", strings.Join(c.Scopes, " "))
+	}
+	resp, err := c.post(ctx, c.DeviceAuthorizationEndpoint, form)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("device authorization request failed: %s", describeError(resp))
+	}
+	var authorization Authorization
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&authorization); err != nil {
+		return nil, fmt.Errorf("failed to parse the device authorization response: %w", err)
+	}
+	if authorization.DeviceCode == "" || authorization.UserCode == "" || authorization.VerificationURI == "" {
+		return nil, errors.New("invalid device authorization response: missing device code, user code or verification URI")
+	}
+	return &authorization, nil
+}
+
+// PollToken polls the token endpoint until the user authorizes the request,
+// denies it, or the device code expires.
+func (c *Client) PollToken(ctx context.Context, authorization *Authorization) (*Token, error) {
+	sleep := c.sleep
+	if sleep == nil {
+		sleep = sleepContext
+	}
+	interval := defaultInterval
+	if authorization.Interval > 0 {
+		interval = time.Duration(authorization.Interval) * time.Second
+	}
+	var deadline time.Time
+	if authorization.ExpiresIn > 0 {
+		deadline = time.Now().Add(time.Duration(authorization.ExpiresIn) * time.Second)
+	}
+	form := url.Values{
+		"grant_type":  {"urn:ietf:params:oauth:grant-type:device_code"},
+		"device_code": {authorization.DeviceCode},
+		"client_id":   {c.ClientID},
+	}
+	for {
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			return nil, ErrExpired
+		}
+		if err := sleep(ctx, interval); err != nil {
+			return nil, err
+		}
+		token, errCode, err := c.requestToken(ctx, form)
+		if err != nil {
+			return nil, err
+		}
+		switch errCode {
+		case "":
+			return token, nil
+		case "authorization_pending":
+		case "slow_down":
+			interval += slowDownIncrement
+		case "access_denied":
+			return nil, ErrAccessDenied
+		case "expired_token":
+			return nil, ErrExpired
+		default:
+			return nil, fmt.Errorf("token request failed: %s", errCode)
+		}
+	}
+}
+
+// requestToken sends a token request, returning either the token or the
+// error code of the token endpoint.
+func (c *Client) requestToken(ctx context.Context, form url.Values) (*Token, string, error) {
+	resp, err := c.post(ctx, c.TokenEndpoint, form)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var errResp errorResponse
+		if err := json.Unmarshal(body, &errResp); err != nil || errResp.Error == "" {
+			return nil, "", fmt.Errorf("token request failed: %s", resp.Status)
+		}
+		return nil, errResp.Error, nil
+	}
+	var token Token
+	if err := json.Unmarshal(body, &token); err != nil {
+		return nil, "", fmt.Errorf("failed to parse the token response: %w", err)
+	}
+	return &token, "", nil
+}
+
+func (c *Client) post(ctx context.Context, endpoint string, form url.Values) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return httpClient.Do(req)
+}
+
+// describeError returns the OAuth error of resp if any, or its status.
+func describeError(resp *http.Response) string {
+	var errResp errorResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&errResp); err == nil && errResp.Error != "" {
+		if errResp.ErrorDescription != "" {
+			return errResp.Error + ": " + errResp.ErrorDescription
+		}
+		return errResp.Error
+	}
+	return resp.Status
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,136 @@
+package devicecode
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newProvider returns a test identity provider answering token requests
+// with the error codes in order, then with a token.
+func newProvider(t *testing.T, errCodes ...string) *httptest.Server {
+	t.Helper()
+	var server *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                        server.URL,
+			"device_authorization_endpoint": server.URL + "/device",
+			"token_endpoint":                server.URL + "/token",
+		})
+	})
+	mux.HandleFunc("/device", func(w http.ResponseWriter, r *http.Request) {
+		if r.PostFormValue("client_id") != "notation" || r.PostFormValue("scope") != "openid offline_access" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(errorResponse{Error: "invalid_client"})
+			return
+		}
+		json.NewEncoder(w).Encode(Authorization{
+			DeviceCode:      "device-code",
+			UserCode:        "ABCD-EFGH",
+			VerificationURI: server.URL + "/activate",
+			ExpiresIn:       600,
+			Interval:        1,
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.PostFormValue("grant_type") != "urn:ietf:params:oauth:grant-type:device_code" || r.PostFormValue("device_code") != "device-code" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(errorResponse{Error: "invalid_grant"})
+			return
+		}
+		if len(errCodes) > 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(errorResponse{Error: errCodes[0]})
+			errCodes = errCodes[1:]
+			return
+		}
+		json.NewEncoder(w).Encode(Token{AccessToken: "access", TokenType: "Bearer", RefreshToken: "refresh"})
+	})
+	server = httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestDeviceCodeFlow(t *testing.T) {
+	ctx := context.Background()
+	server := newProvider(t, "authorization_pending", "slow_down", "authorization_pending")
+	client, err := Discover(ctx, server.Client(), server.URL+"/", "notation")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var intervals []time.Duration
+	client.sleep = func(ctx context.Context, d time.Duration) error {
+		intervals = append(intervals, d)
+		return nil
+	}
+
+	authorization, err := client.Authorize(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if authorization.UserCode != "ABCD-EFGH" {
+		t.Fatalf("unexpected authorization: %+v", authorization)
+	}
+	token, err := client.PollToken(ctx, authorization)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.RefreshToken != "refresh" {
+		t.Fatalf("unexpected token: %+v", token)
+	}
+	want := []time.Duration{time.Second, time.Second, 6 * time.Second, 6 * time.Second}
+	if len(intervals) != len(want) {
+		t.Fatalf("polled at intervals %v, want %v", intervals, want)
+	}
+	for i := range want {
+		if intervals[i] != want[i] {
+			t.Fatalf("polled at intervals %v, want %v", intervals, want)
+		}
+	}
+}
+
+func TestPollToken_Errors(t *testing.T) {
+	ctx := context.Background()
+	for errCode, wantErr := range map[string]error{
+		"access_denied": ErrAccessDenied,
+		"expired_token": ErrExpired,
+	} {
+		server := newProvider(t, errCode)
+		client, err := Discover(ctx, server.Client(), server.URL, "notation")
+		if err != nil {
+			t.Fatal(err)
+		}
+		client.sleep = func(ctx context.Context, d time.Duration) error { return nil }
+		authorization, err := client.Authorize(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := client.PollToken(ctx, authorization); !errors.Is(err, wantErr) {
+			t.Fatalf("PollToken() error = %v, want %v", err, wantErr)
+		}
+	}
+
+	server := newProvider(t)
+	client, err := Discover(ctx, server.Client(), server.URL, "other")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Authorize(ctx); err == nil {
+		t.Fatal("expected error for unknown client")
+	}
+}
+
+func TestDiscover_Unsupported(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"token_endpoint": "https://example.com/token"})
+	}))
+	defer server.Close()
+	if _, err := Discover(context.Background(), server.Client(), server.URL, "notation"); err == nil {
+		t.Fatal("expected error for identity provider without device authorization endpoint")
+	}
+}
@@ -1,6 +1,7 @@
 package envelope
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -20,6 +21,16 @@ const (
 	MediaTypePayloadV1 = "application/vnd.cncf.notary.payload.v1+json"
 )
 
+const (
+	// cborTagCOSESign1 is the first byte of a CBOR encoded COSE_Sign1 message
+	// with tag 18.
+	cborTagCOSESign1 = 0xd2
+
+	// cborArrayOfFour is the first byte of an untagged CBOR encoded
+	// COSE_Sign1 message, which is an array of four elements.
+	cborArrayOfFour = 0x84
+)
+
 // Payload describes the content that gets signed.
 type Payload struct {
 	TargetArtifact ocispec.Descriptor `json:"targetArtifact"`
@@ -36,6 +47,23 @@ func GetEnvelopeMediaType(sigFormat string) (string, error) {
 	return "", fmt.Errorf("signature format %q not supported", sigFormat)
 }
 
+// DetectEnvelopeMediaType returns the media type of the raw signature envelope
+// by its encoding. JWS envelopes are JSON objects and COSE envelopes are CBOR
+// encoded COSE_Sign1 messages, optionally tagged.
+func DetectEnvelopeMediaType(sig []byte) (string, error) {
+	trimmed := bytes.TrimLeft(sig, " \t\r\n")
+	if len(trimmed) == 0 {
+		return "", errors.New("empty signature envelope")
+	}
+	switch trimmed[0] {
+	case '{':
+		return jws.MediaTypeEnvelope, nil
+	case cborTagCOSESign1, cborArrayOfFour:
+		return cose.MediaTypeEnvelope, nil
+	}
+	return "", errors.New("unknown signature envelope format, expecting a JWS or COSE envelope")
+}
+
 // ValidatePayloadContentType validates signature payload's content type.
 func ValidatePayloadContentType(payload *signature.Payload) error {
 	switch payload.ContentType {
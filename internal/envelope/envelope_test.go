@@ -46,3 +46,29 @@ func TestGetEnvelopeMediaType(t *testing.T) {
 		})
 	}
 }
+
+func TestDetectEnvelopeMediaType(t *testing.T) {
+	tests := []struct {
+		name    string
+		sig     []byte
+		want    string
+		wantErr bool
+	}{
+		{name: "jws", sig: []byte(` {"payload":"e30"}`), want: "application/jose+json"},
+		{name: "tagged cose", sig: []byte{0xd2, 0x84}, want: "application/cose"},
+		{name: "untagged cose", sig: []byte{0x84, 0x40}, want: "application/cose"},
+		{name: "empty", sig: nil, wantErr: true},
+		{name: "unknown", sig: []byte("-----BEGIN"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DetectEnvelopeMediaType(tt.sig)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DetectEnvelopeMediaType() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("DetectEnvelopeMediaType() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package envelope
+
+import (
+	"crypto"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/notaryproject/notation-core-go/signature"
+)
+
+// hashAlgorithms are the hash algorithms of the signature payload by name.
+var hashAlgorithms = map[string]crypto.Hash{
+	"sha256": crypto.SHA256,
+	"sha384": crypto.SHA384,
+	"sha512": crypto.SHA512,
+}
+
+// keySpecs are the key specs allowed by the Notary Project signature
+// specification, each of which determines the hash algorithm of the
+// signature payload.
+//
+// Reference: https://github.com/notaryproject/notaryproject/blob/main/specs/signature-specification.md#algorithm-selection
+var keySpecs = []signature.KeySpec{
+	{Type: signature.KeyTypeRSA, Size: 2048},
+	{Type: signature.KeyTypeRSA, Size: 3072},
+	{Type: signature.KeyTypeRSA, Size: 4096},
+	{Type: signature.KeyTypeEC, Size: 256},
+	{Type: signature.KeyTypeEC, Size: 384},
+	{Type: signature.KeyTypeEC, Size: 521},
+}
+
+// HashAlgorithmNames returns the names of the supported hash algorithms of the
+// signature payload, sorted.
+func HashAlgorithmNames() []string {
+	names := make([]string, 0, len(hashAlgorithms))
+	for name := range hashAlgorithms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseHashAlgorithm returns the hash algorithm of the signature payload
+// named name.
+func ParseHashAlgorithm(name string) (crypto.Hash, error) {
+	hash, ok := hashAlgorithms[strings.ToLower(name)]
+	if !ok {
+		return 0, fmt.Errorf("hash algorithm %q not supported, supported hash algorithms are %s", name, strings.Join(HashAlgorithmNames(), ", "))
+	}
+	return hash, nil
+}
+
+// HashAlgorithmName returns the name of the hash algorithm of the signature
+// payload.
+func HashAlgorithmName(hash crypto.Hash) string {
+	for name, h := range hashAlgorithms {
+		if h == hash {
+			return name
+		}
+	}
+	return hash.String()
+}
+
+// CheckSignatureAlgorithm checks that the signature algorithm hashes the
+// signature payload with hash. The signature algorithm is determined by the
+// key spec of the signing key, so the error lists the key specs producing
+// signatures with hash.
+func CheckSignatureAlgorithm(alg signature.Algorithm, hash crypto.Hash) error {
+	if alg.Hash() == hash {
+		return nil
+	}
+	var allowed []string
+	for _, keySpec := range keySpecs {
+		if keySpec.SignatureAlgorithm().Hash() == hash {
+			allowed = append(allowed, keySpecName(keySpec))
+		}
+	}
+	return fmt.Errorf("the signing key hashes the signature payload with %s instead of %s, the hash algorithm is determined by the type and the size of the signing key, use a %s key", HashAlgorithmName(alg.Hash()), HashAlgorithmName(hash), strings.Join(allowed, " or "))
+}
+
+// keySpecName returns the name of keySpec, e.g. "RSA-3072" or "EC-384".
+func keySpecName(keySpec signature.KeySpec) string {
+	switch keySpec.Type {
+	case signature.KeyTypeRSA:
+		return fmt.Sprintf("RSA-%d", keySpec.Size)
+	case signature.KeyTypeEC:
+		return fmt.Sprintf("EC-%d", keySpec.Size)
+	}
+	return fmt.Sprintf("%d-%d", keySpec.Type, keySpec.Size)
+}
@@ -0,0 +1,44 @@
+package envelope
+
+import (
+	"crypto"
+	"strings"
+	"testing"
+
+	"github.com/notaryproject/notation-core-go/signature"
+)
+
+func TestParseHashAlgorithm(t *testing.T) {
+	for name, want := range map[string]crypto.Hash{
+		"sha256": crypto.SHA256,
+		"SHA384": crypto.SHA384,
+		"sha512": crypto.SHA512,
+	} {
+		got, err := ParseHashAlgorithm(name)
+		if err != nil {
+			t.Fatalf("ParseHashAlgorithm(%q) error = %v", name, err)
+		}
+		if got != want {
+			t.Fatalf("ParseHashAlgorithm(%q) = %v, want %v", name, got, want)
+		}
+	}
+	if _, err := ParseHashAlgorithm("sha1"); err == nil {
+		t.Fatal("expected error for unsupported hash algorithm")
+	}
+}
+
+func TestCheckSignatureAlgorithm(t *testing.T) {
+	if err := CheckSignatureAlgorithm(signature.AlgorithmPS384, crypto.SHA384); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckSignatureAlgorithm(signature.AlgorithmES384, crypto.SHA384); err != nil {
+		t.Fatal(err)
+	}
+	err := CheckSignatureAlgorithm(signature.AlgorithmES256, crypto.SHA384)
+	if err == nil {
+		t.Fatal("expected error for a signature algorithm with another hash algorithm")
+	}
+	if !strings.Contains(err.Error(), "RSA-3072 or EC-384") {
+		t.Fatalf("expected the key specs hashing with sha384 in the error, got %v", err)
+	}
+}
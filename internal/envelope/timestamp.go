@@ -0,0 +1,114 @@
+package envelope
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/notaryproject/notation-core-go/signature/cose"
+	"github.com/notaryproject/notation-core-go/signature/jws"
+	gocose "github.com/veraison/go-cose"
+)
+
+// HeaderTimestampSignature is the unprotected header of the signature
+// envelopes carrying the RFC 3161 timestamp countersignature of the signature.
+//
+// Reference: https://github.com/notaryproject/notaryproject/blob/main/specs/signature-envelope-jws.md#unsigned-attributes
+const HeaderTimestampSignature = "io.cncf.notary.timestampSignature"
+
+// SetTimestampSignature returns the signature envelope sig of mediaType with
+// the DER-encoded RFC 3161 timestamp token in its unprotected header. The
+// signed content of the envelope is kept as is, so the envelope still
+// verifies.
+func SetTimestampSignature(sig []byte, mediaType string, token []byte) ([]byte, error) {
+	if len(token) == 0 {
+		return nil, errors.New("empty timestamp token")
+	}
+	switch mediaType {
+	case jws.MediaTypeEnvelope:
+		return setJWSTimestampSignature(sig, token)
+	case cose.MediaTypeEnvelope:
+		return setCOSETimestampSignature(sig, token)
+	}
+	return nil, fmt.Errorf("signature envelope type %q not supported", mediaType)
+}
+
+// setJWSTimestampSignature sets the timestamp token in the unprotected header
+// of the JWS envelope sig in JSON serialization, base64 encoded.
+func setJWSTimestampSignature(sig []byte, token []byte) ([]byte, error) {
+	var env map[string]json.RawMessage
+	if err := json.Unmarshal(sig, &env); err != nil {
+		return nil, fmt.Errorf("malformed JWS envelope: %w", err)
+	}
+	header := make(map[string]json.RawMessage)
+	if raw, ok := env["header"]; ok {
+		if err := json.Unmarshal(raw, &header); err != nil {
+			return nil, fmt.Errorf("malformed unprotected header of JWS envelope: %w", err)
+		}
+	}
+	encodedToken, err := json.Marshal(token)
+	if err != nil {
+		return nil, err
+	}
+	header[HeaderTimestampSignature] = encodedToken
+	if env["header"], err = json.Marshal(header); err != nil {
+		return nil, err
+	}
+	return json.Marshal(env)
+}
+
+// setCOSETimestampSignature sets the timestamp token in the unprotected
+// header of the COSE_Sign1 envelope sig. The protected header is kept in its
+// original encoding.
+func setCOSETimestampSignature(sig []byte, token []byte) ([]byte, error) {
+	var msg gocose.Sign1Message
+	if err := msg.UnmarshalCBOR(sig); err != nil {
+		return nil, fmt.Errorf("malformed COSE envelope: %w", err)
+	}
+	if msg.Headers.Unprotected == nil {
+		msg.Headers.Unprotected = gocose.UnprotectedHeader{}
+	}
+	msg.Headers.Unprotected[HeaderTimestampSignature] = token
+	// re-encode the unprotected header with the timestamp token
+	msg.Headers.RawUnprotected = nil
+	return msg.MarshalCBOR()
+}
+
+// TimestampSignature returns the DER-encoded RFC 3161 timestamp token in the
+// unprotected header of the signature envelope sig of mediaType, or nil if
+// the signature is not timestamped. The envelope is not verified.
+func TimestampSignature(sig []byte, mediaType string) ([]byte, error) {
+	switch mediaType {
+	case jws.MediaTypeEnvelope:
+		var env struct {
+			Header map[string]json.RawMessage `json:"header"`
+		}
+		if err := json.Unmarshal(sig, &env); err != nil {
+			return nil, fmt.Errorf("malformed JWS envelope: %w", err)
+		}
+		raw, ok := env.Header[HeaderTimestampSignature]
+		if !ok {
+			return nil, nil
+		}
+		var token []byte
+		if err := json.Unmarshal(raw, &token); err != nil {
+			return nil, fmt.Errorf("malformed timestamp signature of JWS envelope: %w", err)
+		}
+		return token, nil
+	case cose.MediaTypeEnvelope:
+		var msg gocose.Sign1Message
+		if err := msg.UnmarshalCBOR(sig); err != nil {
+			return nil, fmt.Errorf("malformed COSE envelope: %w", err)
+		}
+		value, ok := msg.Headers.Unprotected[HeaderTimestampSignature]
+		if !ok {
+			return nil, nil
+		}
+		token, ok := value.([]byte)
+		if !ok {
+			return nil, fmt.Errorf("malformed timestamp signature of COSE envelope, expecting a byte string, got %T", value)
+		}
+		return token, nil
+	}
+	return nil, fmt.Errorf("signature envelope type %q not supported", mediaType)
+}
@@ -0,0 +1,124 @@
+package envelope
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/notation-core-go/signature"
+	"github.com/notaryproject/notation-core-go/signature/cose"
+	"github.com/notaryproject/notation-core-go/signature/jws"
+	gocose "github.com/veraison/go-cose"
+)
+
+// newTestEnvelope returns a signature envelope of mediaType signed by a self
+// signed test certificate.
+func newTestEnvelope(t *testing.T, mediaType string) []byte {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test", Organization: []string{"Notary"}, Country: []string{"US"}, Province: []string{"WA"}, Locality: []string{"Seattle"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		BasicConstraintsValid: true,
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(certDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := signature.NewLocalSigner([]*x509.Certificate{cert}, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env, err := signature.NewEnvelope(mediaType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := env.Sign(&signature.SignRequest{
+		Payload:       signature.Payload{ContentType: MediaTypePayloadV1, Content: []byte(`{"targetArtifact":{}}`)},
+		Signer:        signer,
+		SigningTime:   time.Now(),
+		SigningScheme: signature.SigningSchemeX509,
+		SigningAgent:  "notation/test",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sig
+}
+
+func TestSetTimestampSignature(t *testing.T) {
+	token := []byte("timestamp token")
+	for _, mediaType := range []string{jws.MediaTypeEnvelope, cose.MediaTypeEnvelope} {
+		t.Run(mediaType, func(t *testing.T) {
+			sig, err := SetTimestampSignature(newTestEnvelope(t, mediaType), mediaType, token)
+			if err != nil {
+				t.Fatal(err)
+			}
+			env, err := signature.ParseEnvelope(mediaType, sig)
+			if err != nil {
+				t.Fatal(err)
+			}
+			content, err := env.Verify()
+			if err != nil {
+				t.Fatalf("envelope with timestamp does not verify: %v", err)
+			}
+			if content.SignerInfo.UnsignedAttributes.SigningAgent != "notation/test" {
+				t.Fatalf("unsigned attributes are not kept, got %+v", content.SignerInfo.UnsignedAttributes)
+			}
+			got := content.SignerInfo.UnsignedAttributes.TimestampSignature
+			if mediaType == cose.MediaTypeEnvelope {
+				// the COSE envelope of notation-core-go does not parse the
+				// timestamp yet
+				var msg gocose.Sign1Message
+				if err := msg.UnmarshalCBOR(sig); err != nil {
+					t.Fatal(err)
+				}
+				got, _ = msg.Headers.Unprotected[HeaderTimestampSignature].([]byte)
+			}
+			if !bytes.Equal(got, token) {
+				t.Fatalf("timestamp signature = %q, want %q", got, token)
+			}
+		})
+	}
+}
+
+func TestSetTimestampSignature_Error(t *testing.T) {
+	if _, err := SetTimestampSignature(newTestEnvelope(t, jws.MediaTypeEnvelope), jws.MediaTypeEnvelope, nil); err == nil {
+		t.Error("expected error for empty timestamp token, got nil")
+	}
+	if _, err := SetTimestampSignature([]byte("not an envelope"), cose.MediaTypeEnvelope, []byte("token")); err == nil {
+		t.Error("expected error for malformed envelope, got nil")
+	}
+	if _, err := SetTimestampSignature([]byte("{}"), "application/unknown", []byte("token")); err == nil {
+		t.Error("expected error for unsupported envelope type, got nil")
+	}
+}
+
+func TestTimestampSignature_NotTimestamped(t *testing.T) {
+	for _, mediaType := range []string{jws.MediaTypeEnvelope, cose.MediaTypeEnvelope} {
+		token, err := TimestampSignature(newTestEnvelope(t, mediaType), mediaType)
+		if err != nil || token != nil {
+			t.Errorf("TimestampSignature() of %s = %q, %v, want nil", mediaType, token, err)
+		}
+	}
+	if _, err := TimestampSignature([]byte("{}"), "application/unknown"); err == nil {
+		t.Error("expected error for unsupported envelope type, got nil")
+	}
+}
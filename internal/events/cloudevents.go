@@ -0,0 +1,165 @@
+package events
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// CloudEventsSpecVersion is the version of the CloudEvents specification
+	// the events conform to.
+	CloudEventsSpecVersion = "1.0"
+
+	// CloudEventsContentType is the media type of an event in the structured
+	// content mode of the CloudEvents JSON format.
+	CloudEventsContentType = "application/cloudevents+json"
+
+	// CloudEventsTypePrefix is the prefix of the types of the events, followed
+	// by the command and the type of the event, e.g.
+	// "dev.notaryproject.notation.verify.result".
+	CloudEventsTypePrefix = "dev.notaryproject.notation."
+
+	// httpTimeout is the maximum duration to send an event to an HTTP sink.
+	httpTimeout = 5 * time.Second
+)
+
+// CloudEvent is an event in the structured content mode of the CloudEvents
+// JSON format, carrying an Event as data.
+type CloudEvent struct {
+	SpecVersion     string    `json:"specversion"`
+	ID              string    `json:"id"`
+	Source          string    `json:"source"`
+	Type            string    `json:"type"`
+	Subject         string    `json:"subject,omitempty"`
+	Time            time.Time `json:"time"`
+	DataContentType string    `json:"datacontenttype"`
+	Data            Event     `json:"data"`
+}
+
+// NewCloudEvent returns the CloudEvent of the event. The source is the
+// command, e.g. "/notation/sign", and the subject is the reference of the
+// artifact, if any.
+func NewCloudEvent(event Event) (CloudEvent, error) {
+	id, err := newEventID()
+	if err != nil {
+		return CloudEvent{}, err
+	}
+	return CloudEvent{
+		SpecVersion:     CloudEventsSpecVersion,
+		ID:              id,
+		Source:          "/notation/" + event.Command,
+		Type:            CloudEventsTypePrefix + event.Command + "." + string(event.Type),
+		Subject:         event.Reference,
+		Time:            event.Time,
+		DataContentType: "application/json",
+		Data:            event,
+	}, nil
+}
+
+// newEventID returns a random UUID, unique per event.
+func newEventID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40 // version 4
+	b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
+
+// OpenCloudEvents returns an emitter of the events of the command in the
+// CloudEvents format to target, which is
+//   - an HTTP or HTTPS URL, to which each event is POSTed in the structured
+//     content mode,
+//   - "-" for stdout, or
+//   - the path of a file, to which the events are appended,
+//
+// where events are written as newline delimited JSON to stdout and files.
+func OpenCloudEvents(target, command string) (*Emitter, error) {
+	var s sink
+	switch {
+	case strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://"):
+		s = &httpSink{url: target, client: &http.Client{Timeout: httpTimeout}}
+	case target == "-":
+		s = &writerSink{w: nopCloser{os.Stdout}}
+	default:
+		file, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+		if err != nil {
+			return nil, err
+		}
+		s = &writerSink{w: file}
+	}
+	return &Emitter{command: command, sink: s}, nil
+}
+
+// writerSink writes CloudEvents as newline delimited JSON.
+type writerSink struct {
+	w io.WriteCloser
+}
+
+func (s *writerSink) Send(event Event) error {
+	cloudEvent, err := NewCloudEvent(event)
+	if err != nil {
+		return err
+	}
+	// a single write per event, so that concurrent writers appending to the
+	// same file do not interleave events
+	line, err := json.Marshal(cloudEvent)
+	if err != nil {
+		return err
+	}
+	_, err = s.w.Write(append(line, '\n'))
+	return err
+}
+
+func (s *writerSink) Close() error {
+	return s.w.Close()
+}
+
+// nopCloser does not close the wrapped writer, e.g. stdout.
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
+
+// httpSink POSTs each CloudEvent to an HTTP endpoint.
+type httpSink struct {
+	url    string
+	client *http.Client
+}
+
+func (s *httpSink) Send(event Event) error {
+	cloudEvent, err := NewCloudEvent(event)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(cloudEvent)
+	if err != nil {
+		return err
+	}
+	resp, err := s.client.Post(s.url, CloudEventsContentType, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("event sink %s responded with status %s", s.url, resp.Status)
+	}
+	return nil
+}
+
+func (s *httpSink) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
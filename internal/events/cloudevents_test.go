@@ -0,0 +1,132 @@
+package events
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+func TestNewCloudEvent(t *testing.T) {
+	event := Event{Type: TypeResult, Command: "verify", Reference: "localhost:5000/app@sha256:abc", Result: ResultSuccess}
+	cloudEvent, err := NewCloudEvent(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cloudEvent.SpecVersion != "1.0" || cloudEvent.Source != "/notation/verify" || cloudEvent.Type != "dev.notaryproject.notation.verify.result" || cloudEvent.Subject != event.Reference {
+		t.Fatalf("unexpected cloud event: %+v", cloudEvent)
+	}
+	if !regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`).MatchString(cloudEvent.ID) {
+		t.Fatalf("expected a random UUID, got %q", cloudEvent.ID)
+	}
+	other, err := NewCloudEvent(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.ID == cloudEvent.ID {
+		t.Fatal("expected unique event IDs")
+	}
+}
+
+func TestOpenCloudEvents_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	for _, command := range []string{"sign", "verify"} {
+		emitter, err := OpenCloudEvents(path, command)
+		if err != nil {
+			t.Fatal(err)
+		}
+		emitter.Emit(Event{Type: TypeStarted, Reference: "localhost:5000/app:v1"})
+		emitter.Completed("localhost:5000/app:v1", errors.New("boom"))
+		if err := emitter.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	var types []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var cloudEvent CloudEvent
+		if err := json.Unmarshal(scanner.Bytes(), &cloudEvent); err != nil {
+			t.Fatal(err)
+		}
+		if cloudEvent.Time.IsZero() || !cloudEvent.Time.Equal(cloudEvent.Data.Time) {
+			t.Fatalf("expected the time of the event, got %+v", cloudEvent)
+		}
+		types = append(types, cloudEvent.Type)
+	}
+	// the events are appended
+	want := []string{
+		"dev.notaryproject.notation.sign.started",
+		"dev.notaryproject.notation.sign.completed",
+		"dev.notaryproject.notation.verify.started",
+		"dev.notaryproject.notation.verify.completed",
+	}
+	if len(types) != len(want) {
+		t.Fatalf("expected events %v, got %v", want, types)
+	}
+	for i := range want {
+		if types[i] != want[i] {
+			t.Fatalf("expected events %v, got %v", want, types)
+		}
+	}
+}
+
+func TestOpenCloudEvents_HTTP(t *testing.T) {
+	received := make(chan CloudEvent, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != CloudEventsContentType {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		var cloudEvent CloudEvent
+		if err := json.Unmarshal(body, &cloudEvent); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		received <- cloudEvent
+		if cloudEvent.Data.Type == TypeStarted {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	emitter, err := OpenCloudEvents(server.URL, "sign")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer emitter.Close()
+	emitter.Emit(Event{Type: TypeStarted, Reference: "localhost:5000/app:v1"})
+	if cloudEvent := <-received; cloudEvent.Type != "dev.notaryproject.notation.sign.started" || cloudEvent.Data.Command != "sign" {
+		t.Fatalf("unexpected cloud event: %+v", cloudEvent)
+	}
+
+	// the emitter stops emitting events once the sink fails
+	emitter.Emit(Event{Type: TypeProgress, Stage: "resolved"})
+	<-received
+	emitter.Emit(Event{Type: TypeProgress, Stage: "signed"})
+	select {
+	case cloudEvent := <-received:
+		t.Fatalf("expected no event after the sink failed, got %+v", cloudEvent)
+	default:
+	}
+}
+
+func TestOpenCloudEvents_InvalidFile(t *testing.T) {
+	if _, err := OpenCloudEvents(filepath.Join(t.TempDir(), "missing", "events.jsonl"), "sign"); err == nil {
+		t.Fatal("expected error for a file in a missing directory")
+	}
+}
@@ -0,0 +1,212 @@
+// Package events streams structured progress and result events of long
+// running operations to a Unix domain socket, so that GUIs and orchestrators
+// can show live status without parsing the human readable output.
+//
+// Events are written as newline delimited JSON. The socket is served by the
+// consumer and the CLI connects to it as a client.
+//
+// Alternatively, events are sent in the CloudEvents format to a file, stdout
+// or an HTTP endpoint, see OpenCloudEvents.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	// dialTimeout is the maximum duration to connect to the event socket.
+	dialTimeout = 5 * time.Second
+
+	// writeTimeout is the maximum duration to write an event, so that a
+	// consumer not reading events does not block the operation.
+	writeTimeout = time.Second
+)
+
+// Type is the type of an event.
+type Type string
+
+const (
+	// TypeStarted is emitted once when an operation starts.
+	TypeStarted Type = "started"
+
+	// TypeProgress is emitted when an operation reaches a stage, e.g. a tag is
+	// resolved to a digest.
+	TypeProgress Type = "progress"
+
+	// TypeResult is emitted with the result of an artifact being processed.
+	// Operations on multiple artifacts emit one result event per artifact.
+	TypeResult Type = "result"
+
+	// TypeCompleted is emitted once when an operation completes, successfully
+	// or not.
+	TypeCompleted Type = "completed"
+)
+
+// Result values of the result and completed events.
+const (
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+	ResultSkipped = "skipped"
+)
+
+// Event is a structured progress or result event.
+type Event struct {
+	// Type is the type of the event.
+	Type Type `json:"type"`
+
+	// Time is the time the event is emitted.
+	Time time.Time `json:"time"`
+
+	// Command is the notation command emitting the event, e.g. "sign".
+	Command string `json:"command"`
+
+	// Stage is the stage reached by a progress event, e.g. "resolved".
+	Stage string `json:"stage,omitempty"`
+
+	// Reference is the reference of the artifact being processed.
+	Reference string `json:"reference,omitempty"`
+
+	// Digest is the digest of the artifact being processed, if resolved.
+	Digest string `json:"digest,omitempty"`
+
+	// Result is the result of a result or completed event, one of
+	// ResultSuccess, ResultFailure and ResultSkipped.
+	Result string `json:"result,omitempty"`
+
+	// VerificationLevel is the verification level of a successful
+	// verification.
+	VerificationLevel string `json:"verificationLevel,omitempty"`
+
+	// Annotations are the annotations of the subject manifest selected by
+	// the "outputAnnotations" of config.json, e.g. build metadata.
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// Error is the error message of a failed operation.
+	Error string `json:"error,omitempty"`
+
+	// Completed is the number of artifacts processed by operations on
+	// multiple artifacts.
+	Completed int `json:"completed,omitempty"`
+
+	// Total is the total number of artifacts to process by operations on
+	// multiple artifacts.
+	Total int `json:"total,omitempty"`
+}
+
+// sink is the destination of the events of an Emitter.
+type sink interface {
+	// Send sends the event, failing if the event is not accepted in time.
+	Send(event Event) error
+
+	// Close closes the sink.
+	Close() error
+}
+
+// Emitter writes events to an event sink, e.g. an event socket. A nil Emitter
+// discards all events, so that callers do not need to check if an event sink
+// is set.
+type Emitter struct {
+	command string
+
+	mu   sync.Mutex
+	sink sink
+}
+
+// Dial connects to the event socket at path and returns an emitter of events
+// of the command.
+func Dial(path, command string) (*Emitter, error) {
+	conn, err := net.DialTimeout("unix", path, dialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	return &Emitter{
+		command: command,
+		sink:    &socketSink{conn: conn, encoder: json.NewEncoder(conn)},
+	}, nil
+}
+
+// socketSink writes events as newline delimited JSON to an event socket.
+type socketSink struct {
+	conn    net.Conn
+	encoder *json.Encoder
+}
+
+func (s *socketSink) Send(event Event) error {
+	if err := s.conn.SetWriteDeadline(time.Now().Add(writeTimeout)); err != nil {
+		return err
+	}
+	return s.encoder.Encode(event)
+}
+
+func (s *socketSink) Close() error {
+	return s.conn.Close()
+}
+
+// Emit sends the event to the event sink, setting the time and the command of
+// the event.
+// Events are best effort: if the consumer goes away or does not accept an
+// event in time, the emitter stops emitting events without failing the
+// operation.
+func (e *Emitter) Emit(event Event) {
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.sink == nil {
+		return
+	}
+	event.Time = time.Now().UTC()
+	event.Command = e.command
+	if err := e.sink.Send(event); err != nil {
+		e.sink.Close()
+		e.sink = nil
+	}
+}
+
+// Completed emits the completed event with the result of the operation
+// derived from err.
+func (e *Emitter) Completed(reference string, err error) {
+	event := Event{
+		Type:      TypeCompleted,
+		Reference: reference,
+		Result:    ResultSuccess,
+	}
+	if err != nil {
+		event.Result = ResultFailure
+		event.Error = err.Error()
+	}
+	e.Emit(event)
+}
+
+// Close closes the event sink, e.g. the connection to the event socket.
+func (e *Emitter) Close() error {
+	if e == nil {
+		return nil
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.sink == nil {
+		return nil
+	}
+	err := e.sink.Close()
+	e.sink = nil
+	return err
+}
+
+type contextKey struct{}
+
+// WithEmitter returns a context with the emitter.
+func WithEmitter(ctx context.Context, e *Emitter) context.Context {
+	return context.WithValue(ctx, contextKey{}, e)
+}
+
+// FromContext returns the emitter of the context, or nil if not set.
+func FromContext(ctx context.Context) *Emitter {
+	e, _ := ctx.Value(contextKey{}).(*Emitter)
+	return e
+}
@@ -0,0 +1,129 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEmitter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.sock")
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		t.Skipf("unix socket not supported: %v", err)
+	}
+	defer listener.Close()
+
+	received := make(chan []Event, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			received <- nil
+			return
+		}
+		defer conn.Close()
+		var events []Event
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			var event Event
+			if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+				break
+			}
+			events = append(events, event)
+		}
+		received <- events
+	}()
+
+	emitter, err := Dial(path, "sign")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := WithEmitter(context.Background(), emitter)
+	FromContext(ctx).Emit(Event{Type: TypeStarted, Reference: "localhost:5000/app:v1"})
+	FromContext(ctx).Completed("localhost:5000/app:v1", errors.New("boom"))
+	if err := emitter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// emitting after close is a no-op
+	emitter.Emit(Event{Type: TypeProgress})
+
+	events := <-received
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	if events[0].Type != TypeStarted || events[0].Command != "sign" || events[0].Time.IsZero() {
+		t.Fatalf("unexpected started event: %+v", events[0])
+	}
+	if events[1].Type != TypeCompleted || events[1].Result != ResultFailure || events[1].Error != "boom" {
+		t.Fatalf("unexpected completed event: %+v", events[1])
+	}
+}
+
+func TestEmitter_Nil(t *testing.T) {
+	// events are discarded without an event socket
+	emitter := FromContext(context.Background())
+	if emitter != nil {
+		t.Fatalf("expected nil emitter, got %+v", emitter)
+	}
+	emitter.Emit(Event{Type: TypeStarted})
+	emitter.Completed("", nil)
+	if err := emitter.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEmitter_StalledConsumer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.sock")
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		t.Skipf("unix socket not supported: %v", err)
+	}
+	defer listener.Close()
+
+	// the consumer accepts the connection but never reads
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			close(accepted)
+			return
+		}
+		accepted <- conn
+	}()
+
+	emitter, err := Dial(path, "verify")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer emitter.Close()
+	if conn, ok := <-accepted; ok {
+		defer conn.Close()
+	}
+
+	// fill the socket buffer until the emitter gives up
+	reference := strings.Repeat("a", 64*1024)
+	start := time.Now()
+	for i := 0; i < 1024; i++ {
+		emitter.Emit(Event{Type: TypeProgress, Reference: reference})
+		emitter.mu.Lock()
+		dropped := emitter.sink == nil
+		emitter.mu.Unlock()
+		if dropped {
+			break
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Fatalf("expected emitting to a stalled consumer to time out, took %v", elapsed)
+	}
+	emitter.mu.Lock()
+	defer emitter.mu.Unlock()
+	if emitter.sink != nil {
+		t.Fatal("expected the emitter to be dropped")
+	}
+}
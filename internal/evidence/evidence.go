@@ -0,0 +1,203 @@
+// Package evidence writes verification evidence packages, a portable proof
+// for auditors that an artifact was verified.
+//
+// An evidence package is a zip archive containing the signature envelope, the
+// certificate chain, the timestamp token if present, a snapshot of the trust
+// policy, and a summary of the verification listing the digests of all other
+// files. The summary is signed, binding the files to the signer of the
+// package.
+package evidence
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/notaryproject/notation-core-go/signature"
+	"github.com/notaryproject/notation-core-go/signature/cose"
+	"github.com/notaryproject/notation-core-go/signature/jws"
+	"github.com/notaryproject/notation-go"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// file names in the evidence package
+const (
+	FileCertificates     = "certificates.pem"
+	FileTimestamp        = "timestamp.tst"
+	FileTrustPolicy      = "trustpolicy.json"
+	FileSummary          = "summary.json"
+	FileSummarySignature = "summary.sig"
+)
+
+// MediaTypeSummary is the media type of the summary descriptor signed in the
+// evidence package.
+const MediaTypeSummary = "application/vnd.cncf.notary.x-verification-evidence+json"
+
+// SummaryVersion is the version of the summary format.
+const SummaryVersion = "1.0"
+
+// Summary is the summary of a verification.
+type Summary struct {
+	Version           string                   `json:"version"`
+	Artifact          string                   `json:"artifact"`
+	ArtifactDigest    digest.Digest            `json:"artifactDigest"`
+	VerifiedAt        time.Time                `json:"verifiedAt"`
+	Verifier          string                   `json:"verifier"`
+	VerificationLevel string                   `json:"verificationLevel,omitempty"`
+	Results           []Result                 `json:"results"`
+	Files             map[string]digest.Digest `json:"files"`
+}
+
+// Result is the result of a validation performed during the verification.
+type Result struct {
+	Type   string `json:"type"`
+	Action string `json:"action"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Package is a verification evidence package.
+type Package struct {
+	summary Summary
+	files   map[string][]byte
+}
+
+// New creates an evidence package for the successful verification outcome of
+// the artifact. verifier identifies the software that performed the
+// verification, and policy is the content of the trust policy applied.
+func New(artifact string, artifactDesc ocispec.Descriptor, outcome *notation.VerificationOutcome, policy []byte, verifier string) (*Package, error) {
+	if outcome == nil || outcome.EnvelopeContent == nil {
+		return nil, errors.New("no signature is verified")
+	}
+	p := &Package{
+		summary: Summary{
+			Version:        SummaryVersion,
+			Artifact:       artifact,
+			ArtifactDigest: artifactDesc.Digest,
+			VerifiedAt:     time.Now().UTC(),
+			Verifier:       verifier,
+			Results:        []Result{},
+			Files:          make(map[string]digest.Digest),
+		},
+		files: make(map[string][]byte),
+	}
+	if outcome.VerificationLevel != nil {
+		p.summary.VerificationLevel = outcome.VerificationLevel.Name
+	}
+	for _, result := range outcome.VerificationResults {
+		r := Result{
+			Type:   string(result.Type),
+			Action: string(result.Action),
+		}
+		if result.Error != nil {
+			r.Error = result.Error.Error()
+		}
+		p.summary.Results = append(p.summary.Results, r)
+	}
+
+	p.add(signatureFileName(outcome.RawSignature), outcome.RawSignature)
+	var certsPEM []byte
+	for _, cert := range outcome.EnvelopeContent.SignerInfo.CertificateChain {
+		certsPEM = append(certsPEM, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})...)
+	}
+	p.add(FileCertificates, certsPEM)
+	if token := outcome.EnvelopeContent.SignerInfo.UnsignedAttributes.TimestampSignature; len(token) > 0 {
+		p.add(FileTimestamp, token)
+	}
+	p.add(FileTrustPolicy, policy)
+	return p, nil
+}
+
+// Summary returns the summary of the verification.
+func (p *Package) Summary() Summary {
+	return p.summary
+}
+
+func (p *Package) add(name string, content []byte) {
+	p.files[name] = content
+	p.summary.Files[name] = digest.FromBytes(content)
+}
+
+// Write signs the summary with signer and writes the evidence package as a
+// zip archive to path.
+func (p *Package) Write(ctx context.Context, path string, signer notation.Signer) error {
+	summaryJSON, err := json.MarshalIndent(p.summary, "", "    ")
+	if err != nil {
+		return err
+	}
+	summaryDesc := ocispec.Descriptor{
+		MediaType: MediaTypeSummary,
+		Digest:    digest.FromBytes(summaryJSON),
+		Size:      int64(len(summaryJSON)),
+	}
+	summarySig, _, err := signer.Sign(ctx, summaryDesc, notation.SignerSignOptions{
+		SignatureMediaType: jws.MediaTypeEnvelope,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to sign evidence summary: %w", err)
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), ".evidence-*.zip")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if err := p.writeZip(f, summaryJSON, summarySig); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+func (p *Package) writeZip(f *os.File, summaryJSON, summarySig []byte) error {
+	zw := zip.NewWriter(f)
+	names := make([]string, 0, len(p.files))
+	for name := range p.files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := writeZipFile(zw, name, p.files[name], p.summary.VerifiedAt); err != nil {
+			return err
+		}
+	}
+	if err := writeZipFile(zw, FileSummary, summaryJSON, p.summary.VerifiedAt); err != nil {
+		return err
+	}
+	if err := writeZipFile(zw, FileSummarySignature, summarySig, p.summary.VerifiedAt); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+func writeZipFile(zw *zip.Writer, name string, content []byte, modified time.Time) error {
+	w, err := zw.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: modified,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(content)
+	return err
+}
+
+// signatureFileName returns the file name of the signature envelope by its
+// format.
+func signatureFileName(sig []byte) string {
+	if _, err := signature.ParseEnvelope(cose.MediaTypeEnvelope, sig); err == nil {
+		return "signature.cose"
+	}
+	return "signature.jws"
+}
@@ -0,0 +1,91 @@
+package evidence
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/x509"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/notaryproject/notation-core-go/signature"
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+type mockSigner struct {
+	desc ocispec.Descriptor
+}
+
+func (s *mockSigner) Sign(ctx context.Context, desc ocispec.Descriptor, opts notation.SignerSignOptions) ([]byte, *signature.SignerInfo, error) {
+	s.desc = desc
+	return []byte("summary signature"), &signature.SignerInfo{}, nil
+}
+
+func TestWrite(t *testing.T) {
+	outcome := &notation.VerificationOutcome{
+		RawSignature: []byte(`{"payload":"","protected":"","signature":""}`),
+		EnvelopeContent: &signature.EnvelopeContent{
+			SignerInfo: signature.SignerInfo{
+				CertificateChain: []*x509.Certificate{{Raw: []byte("cert")}},
+				UnsignedAttributes: signature.UnsignedAttributes{
+					TimestampSignature: []byte("token"),
+				},
+			},
+		},
+		VerificationLevel: trustpolicy.LevelStrict,
+		VerificationResults: []*notation.ValidationResult{
+			{Type: trustpolicy.TypeIntegrity, Action: trustpolicy.ActionEnforce},
+		},
+	}
+	artifactDesc := ocispec.Descriptor{Digest: digest.FromString("artifact")}
+	p, err := New("localhost:5000/net-monitor@"+artifactDesc.Digest.String(), artifactDesc, outcome, []byte("{}"), "notation/test")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "evidence.zip")
+	signer := &mockSigner{}
+	if err := p.Write(context.Background(), path, signer); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+	contents := map[string][]byte{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		contents[f.Name] = content
+	}
+	for _, name := range []string{"signature.jws", FileCertificates, FileTimestamp, FileTrustPolicy, FileSummary, FileSummarySignature} {
+		if _, ok := contents[name]; !ok {
+			t.Fatalf("expected %s in evidence package", name)
+		}
+	}
+	for name, dgst := range p.Summary().Files {
+		if digest.FromBytes(contents[name]) != dgst {
+			t.Fatalf("digest mismatch for %s", name)
+		}
+	}
+	if signer.desc.Digest != digest.FromBytes(contents[FileSummary]) || signer.desc.MediaType != MediaTypeSummary {
+		t.Fatalf("expected the summary to be signed, got descriptor %+v", signer.desc)
+	}
+}
+
+func TestNew_NoSignature(t *testing.T) {
+	if _, err := New("ref", ocispec.Descriptor{}, &notation.VerificationOutcome{}, nil, ""); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
@@ -0,0 +1,96 @@
+// Package filelock serializes the read-modify-write cycles of the files shared
+// by notation processes, e.g. config.json, signingkeys.json or the credentials
+// of the credential helpers, with advisory locks on lock files next to them.
+// Parallel invocations on the same host, common in CI matrices, otherwise
+// lose updates or read truncated files.
+//
+// Locks are released by the operating system when the process exits, so a
+// crashed process never leaves a stale lock behind. Lock files are never
+// removed, as removing them races with processes waiting for the lock.
+package filelock
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Timeout is the maximum duration to wait for a lock held by another process.
+var Timeout = 30 * time.Second
+
+// retry intervals of acquiring a lock held by another process, doubled after
+// every attempt
+var (
+	initialRetryInterval = 10 * time.Millisecond
+	maxRetryInterval     = 100 * time.Millisecond
+)
+
+// errLocked indicates that the lock is held by another process.
+var errLocked = errors.New("file is locked")
+
+// Lock is an exclusive lock of a file.
+type Lock struct {
+	file *os.File
+}
+
+// Acquire acquires the exclusive lock of the file of path, retrying while the
+// lock is held by another process until Timeout elapses. The lock is held on
+// the lock file path + ".lock", so that the file itself may be replaced
+// while locked. The lock file is kept after the lock is released, as removing
+// it would let another process lock a file of the same path which is no
+// longer the lock file.
+func Acquire(path string) (*Lock, error) {
+	lockPath := path + ".lock"
+	if err := os.MkdirAll(filepath.Dir(lockPath), 0700); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(lockPath, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+	deadline := time.Now().Add(Timeout)
+	interval := initialRetryInterval
+	for {
+		err := tryLock(file)
+		if err == nil {
+			return &Lock{file: file}, nil
+		}
+		if !errors.Is(err, errLocked) {
+			file.Close()
+			return nil, fmt.Errorf("failed to lock %s: %w", lockPath, err)
+		}
+		if time.Now().Add(interval).After(deadline) {
+			file.Close()
+			return nil, fmt.Errorf("timed out after %s waiting for %s, which is locked by another notation process", Timeout, lockPath)
+		}
+		time.Sleep(interval)
+		if interval *= 2; interval > maxRetryInterval {
+			interval = maxRetryInterval
+		}
+	}
+}
+
+// Release releases the lock.
+func (l *Lock) Release() error {
+	if err := unlock(l.file); err != nil {
+		l.file.Close()
+		return err
+	}
+	return l.file.Close()
+}
+
+// WithLock executes fn holding the exclusive lock of the file of path.
+func WithLock(path string, fn func() error) (err error) {
+	lock, err := Acquire(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if releaseErr := lock.Release(); err == nil {
+			err = releaseErr
+		}
+	}()
+	return fn()
+}
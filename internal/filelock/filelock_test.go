@@ -0,0 +1,104 @@
+package filelock
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAcquire_Timeout(t *testing.T) {
+	defer func(timeout time.Duration) {
+		Timeout = timeout
+	}(Timeout)
+	Timeout = 100 * time.Millisecond
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	lock, err := Acquire(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Acquire(path); err == nil || !strings.Contains(err.Error(), "locked by another notation process") {
+		t.Fatalf("expected timeout while the lock is held, got %v", err)
+	}
+	if err := lock.Release(); err != nil {
+		t.Fatal(err)
+	}
+	lock, err = Acquire(path)
+	if err != nil {
+		t.Fatalf("expected the released lock to be acquired, got %v", err)
+	}
+	if err := lock.Release(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAcquire_Retry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	lock, err := Acquire(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	released := make(chan struct{})
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		close(released)
+		lock.Release()
+	}()
+	second, err := Acquire(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer second.Release()
+	select {
+	case <-released:
+	default:
+		t.Fatal("expected the lock to be acquired after the holder released it")
+	}
+}
+
+func TestWithLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "counter")
+	if err := os.WriteFile(path, []byte("0"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	// concurrent read-modify-write cycles lose no update
+	increment := func() error {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		n, err := strconv.Atoi(string(content))
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(path, []byte(strconv.Itoa(n+1)), 0600)
+	}
+	const workers = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- WithLock(path, increment)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(content); got != strconv.Itoa(workers) {
+		t.Fatalf("counter = %s, want %d", got, workers)
+	}
+}
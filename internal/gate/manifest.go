@@ -0,0 +1,161 @@
+// Package gate extracts the image references of Kubernetes and Compose
+// manifests changed by a pull request and summarizes their verification for
+// the review of the pull request.
+package gate
+
+import (
+	"bufio"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// DefaultIncludes are the glob patterns of the manifests scanned for image
+// references by default.
+var DefaultIncludes = []string{"**/*.yaml", "**/*.yml", "**/*.json"}
+
+// Image is an image reference found in a manifest.
+type Image struct {
+	// Reference is the image reference as written in the manifest.
+	Reference string
+
+	// File is the path of the manifest.
+	File string
+
+	// Line is the line number of the reference in the manifest, starting at
+	// 1.
+	Line int
+}
+
+// imageLine matches the image field of Kubernetes containers and Compose
+// services in YAML, including list items and flow style JSON, e.g.
+//
+//	image: registry.example.com/app:v1
+//	- image: "registry.example.com/app:v1"
+//	"image": "registry.example.com/app:v1",
+var imageLine = regexp.MustCompile(`^\s*(?:-\s+)?["']?image["']?\s*:\s*(.*)$`)
+
+// ExtractImages returns the image references of the manifest read from r,
+// reported as found in file. Manifests are scanned line by line rather than
+// parsed, so that references are found in any kind of manifest, including
+// multi-document YAML and Helm templates.
+func ExtractImages(r io.Reader, file string) ([]Image, error) {
+	var images []Image
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		match := imageLine.FindStringSubmatch(scanner.Text())
+		if match == nil {
+			continue
+		}
+		if reference := parseValue(match[1]); reference != "" {
+			images = append(images, Image{Reference: reference, File: file, Line: line})
+		}
+	}
+	return images, scanner.Err()
+}
+
+// parseValue returns the scalar value of a YAML or JSON field, without quotes
+// and comments. Values spanning lines, e.g. block scalars and nested
+// objects, are not image references and are ignored.
+func parseValue(value string) string {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return ""
+	}
+	switch quote := value[0]; quote {
+	case '"', '\'':
+		end := strings.IndexByte(value[1:], quote)
+		if end < 0 {
+			return ""
+		}
+		return strings.TrimSpace(value[1 : end+1])
+	case '|', '>', '{', '[', '&', '*':
+		return ""
+	}
+	if i := strings.Index(value, " #"); i >= 0 {
+		value = value[:i]
+	}
+	return strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(value), ","))
+}
+
+// IsTemplated reports whether reference contains an unexpanded variable or
+// template expression, e.g. of Compose or Helm, which cannot be verified.
+func IsTemplated(reference string) bool {
+	return strings.Contains(reference, "${") || strings.Contains(reference, "{{") || strings.HasPrefix(reference, "$")
+}
+
+// Normalize returns the fully qualified form of reference, expanding the
+// short references of Docker Hub resolved by container runtimes, e.g.
+// "nginx:1.25" to "docker.io/library/nginx:1.25".
+func Normalize(reference string) string {
+	name, rest := reference, ""
+	if i := strings.IndexAny(reference, ":@"); i >= 0 {
+		// the colon of a registry port is followed by a slash
+		if j := strings.IndexByte(reference, '/'); reference[i] == '@' || j < 0 || i > j {
+			name, rest = reference[:i], reference[i:]
+		}
+	}
+	domain, remainder, found := strings.Cut(name, "/")
+	if found && (strings.ContainsAny(domain, ".:") || domain == "localhost") {
+		return reference
+	}
+	if !found {
+		remainder = "library/" + name
+	} else {
+		remainder = name
+	}
+	return "docker.io/" + remainder + rest
+}
+
+// Filter returns the files matching any of the include patterns and none of
+// the exclude patterns. Patterns are matched against slash separated paths,
+// where "**" matches any number of directories.
+func Filter(files, includes, excludes []string) []string {
+	var matched []string
+	for _, file := range files {
+		name := path.Clean(strings.ReplaceAll(file, "\\", "/"))
+		if matchAny(includes, name) && !matchAny(excludes, name) {
+			matched = append(matched, file)
+		}
+	}
+	return matched
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if Match(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Match reports whether the slash separated path name matches the glob
+// pattern. Besides the syntax of path.Match, "**" matches any number of
+// directories, including none.
+func Match(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, err := path.Match(pattern[0], name[0]); err != nil || !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
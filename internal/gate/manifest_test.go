@@ -0,0 +1,129 @@
+package gate
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExtractImages(t *testing.T) {
+	manifest := `apiVersion: apps/v1
+kind: Deployment
+spec:
+  template:
+    spec:
+      initContainers:
+        - name: init
+          image: "registry.example.com/init:v1" # pinned later
+      containers:
+        - image: registry.example.com/app@sha256:b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9
+          name: app
+        - name: sidecar
+          image: 'nginx:1.25'
+---
+services:
+  web:
+    image: localhost:5000/web:latest # comment
+    build:
+      image: |
+        not an image
+  proxy:
+    image: ${PROXY_IMAGE}
+  empty:
+    image:
+`
+	got, err := ExtractImages(strings.NewReader(manifest), "deploy.yaml")
+	if err != nil {
+		t.Fatalf("ExtractImages() error = %v", err)
+	}
+	want := []Image{
+		{Reference: "registry.example.com/init:v1", File: "deploy.yaml", Line: 8},
+		{Reference: "registry.example.com/app@sha256:b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9", File: "deploy.yaml", Line: 10},
+		{Reference: "nginx:1.25", File: "deploy.yaml", Line: 13},
+		{Reference: "localhost:5000/web:latest", File: "deploy.yaml", Line: 17},
+		{Reference: "${PROXY_IMAGE}", File: "deploy.yaml", Line: 22},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("ExtractImages() = %v, want %v", got, want)
+	}
+}
+
+func TestExtractImages_JSON(t *testing.T) {
+	manifest := `{"spec": {"containers": [
+  {
+    "name": "app",
+    "image": "registry.example.com/app:v1",
+    "imagePullPolicy": "Always"
+  }
+]}}`
+	got, err := ExtractImages(strings.NewReader(manifest), "pod.json")
+	if err != nil {
+		t.Fatalf("ExtractImages() error = %v", err)
+	}
+	want := []Image{{Reference: "registry.example.com/app:v1", File: "pod.json", Line: 4}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("ExtractImages() = %v, want %v", got, want)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := map[string]string{
+		"nginx":                   "docker.io/library/nginx",
+		"nginx:1.25":              "docker.io/library/nginx:1.25",
+		"nginx@sha256:abc":        "docker.io/library/nginx@sha256:abc",
+		"myorg/app:v1":            "docker.io/myorg/app:v1",
+		"docker.io/library/nginx": "docker.io/library/nginx",
+		"localhost/app":           "localhost/app",
+		"localhost:5000/app:v1":   "localhost:5000/app:v1",
+		"registry.example.com/team/app@sha256:abc": "registry.example.com/team/app@sha256:abc",
+	}
+	for reference, want := range tests {
+		if got := Normalize(reference); got != want {
+			t.Errorf("Normalize(%q) = %q, want %q", reference, got, want)
+		}
+	}
+}
+
+func TestIsTemplated(t *testing.T) {
+	for _, reference := range []string{"${IMAGE}", "$IMAGE", "registry.example.com/app:{{ .Values.tag }}", "registry.example.com/app:${TAG:-latest}"} {
+		if !IsTemplated(reference) {
+			t.Errorf("IsTemplated(%q) = false, want true", reference)
+		}
+	}
+	if IsTemplated("registry.example.com/app:v1") {
+		t.Error("IsTemplated() = true, want false")
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"**/*.yaml", "compose.yaml", true},
+		{"**/*.yaml", "deploy/prod/app.yaml", true},
+		{"**/*.yaml", "deploy/prod/app.yml", false},
+		{"deploy/**/*.yaml", "deploy/app.yaml", true},
+		{"deploy/**/*.yaml", "deploy/prod/eu/app.yaml", true},
+		{"deploy/**/*.yaml", "charts/app.yaml", false},
+		{"deploy/*.yaml", "deploy/prod/app.yaml", false},
+		{"deploy/**", "deploy/prod/app.yaml", true},
+		{"compose*.y*ml", "compose.prod.yaml", true},
+		{"[", "[", false},
+	}
+	for _, tt := range tests {
+		if got := Match(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("Match(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFilter(t *testing.T) {
+	files := []string{"compose.yaml", "deploy/app.yaml", "deploy/test/app.yaml", "README.md", "./charts/values.yml"}
+	got := Filter(files, DefaultIncludes, []string{"deploy/test/**"})
+	want := []string{"compose.yaml", "deploy/app.yaml", "./charts/values.yml"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Filter() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,128 @@
+package gate
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Verdict is the verdict of the verification of an image reference.
+type Verdict string
+
+const (
+	// VerdictPass means that the image passed the verification against the
+	// trust policy.
+	VerdictPass Verdict = "pass"
+
+	// VerdictFail means that the image failed the verification against the
+	// trust policy.
+	VerdictFail Verdict = "fail"
+
+	// VerdictSkipped means that the image was not verified, e.g. because its
+	// reference is templated.
+	VerdictSkipped Verdict = "skipped"
+)
+
+// Result is the result of verifying an image reference found in the changed
+// manifests.
+type Result struct {
+	// Reference is the image reference as written in the manifests.
+	Reference string
+
+	// Digest is the digest the reference resolved to, if resolved.
+	Digest string
+
+	// Locations are the manifests and lines the reference was found at, e.g.
+	// "deploy/app.yaml:12".
+	Locations []string
+
+	// Verdict is the verdict of the verification.
+	Verdict Verdict
+
+	// Detail explains a failed or skipped verification.
+	Detail string
+}
+
+// Group returns the unique image references of images in the order they are
+// first found, with their locations.
+func Group(images []Image) []*Result {
+	var results []*Result
+	index := make(map[string]*Result)
+	for _, image := range images {
+		result, ok := index[image.Reference]
+		if !ok {
+			result = &Result{Reference: image.Reference}
+			index[image.Reference] = result
+			results = append(results, result)
+		}
+		result.Locations = append(result.Locations, fmt.Sprintf("%s:%d", image.File, image.Line))
+	}
+	return results
+}
+
+// Count returns the number of results of each verdict.
+func Count(results []*Result) map[Verdict]int {
+	counts := make(map[Verdict]int)
+	for _, result := range results {
+		counts[result.Verdict]++
+	}
+	return counts
+}
+
+// WriteMarkdown writes the summary of results in GitHub flavored Markdown,
+// ready to be posted as a pull request comment or a job summary.
+func WriteMarkdown(w io.Writer, results []*Result) error {
+	counts := Count(results)
+	var b strings.Builder
+	b.WriteString("## Notation signature verification\n\n")
+	switch {
+	case len(results) == 0:
+		b.WriteString("No image references found in the changed manifests.\n")
+		_, err := io.WriteString(w, b.String())
+		return err
+	case counts[VerdictFail] > 0:
+		fmt.Fprintf(&b, ":x: %d of %d image references failed the verification.", counts[VerdictFail], len(results))
+	default:
+		fmt.Fprintf(&b, ":white_check_mark: %d of %d image references passed the verification.", counts[VerdictPass], len(results))
+	}
+	if counts[VerdictSkipped] > 0 {
+		fmt.Fprintf(&b, " %d skipped.", counts[VerdictSkipped])
+	}
+	b.WriteString("\n\n| Result | Image | Digest | Found in | Details |\n|---|---|---|---|---|\n")
+	for _, result := range results {
+		digest := ""
+		if result.Digest != "" {
+			digest = "`" + result.Digest + "`"
+		}
+		locations := make([]string, len(result.Locations))
+		for i, location := range result.Locations {
+			locations[i] = "`" + escapeCell(location) + "`"
+		}
+		fmt.Fprintf(&b, "| %s | `%s` | %s | %s | %s |\n",
+			verdictCell(result.Verdict),
+			escapeCell(result.Reference),
+			digest,
+			strings.Join(locations, "<br>"),
+			escapeCell(result.Detail),
+		)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func verdictCell(verdict Verdict) string {
+	switch verdict {
+	case VerdictPass:
+		return ":white_check_mark: pass"
+	case VerdictFail:
+		return ":x: fail"
+	default:
+		return ":fast_forward: " + string(verdict)
+	}
+}
+
+// escapeCell escapes text for a cell of a Markdown table.
+func escapeCell(text string) string {
+	text = strings.ReplaceAll(text, "|", "\\|")
+	return strings.Join(strings.Fields(text), " ")
+}
@@ -0,0 +1,56 @@
+package gate
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGroup(t *testing.T) {
+	results := Group([]Image{
+		{Reference: "localhost:5000/app:v1", File: "a.yaml", Line: 3},
+		{Reference: "localhost:5000/db:v1", File: "a.yaml", Line: 9},
+		{Reference: "localhost:5000/app:v1", File: "b.yaml", Line: 1},
+	})
+	if len(results) != 2 {
+		t.Fatalf("Group() returned %d results, want 2", len(results))
+	}
+	if results[0].Reference != "localhost:5000/app:v1" || strings.Join(results[0].Locations, ",") != "a.yaml:3,b.yaml:1" {
+		t.Fatalf("Group() = %+v", results[0])
+	}
+	if results[1].Reference != "localhost:5000/db:v1" {
+		t.Fatalf("Group() = %+v", results[1])
+	}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	results := []*Result{
+		{Reference: "localhost:5000/app:v1", Digest: "sha256:abc", Locations: []string{"a.yaml:3", "b.yaml:1"}, Verdict: VerdictPass},
+		{Reference: "localhost:5000/db:v1", Digest: "sha256:def", Locations: []string{"a.yaml:9"}, Verdict: VerdictFail, Detail: "no signature\nis | associated"},
+		{Reference: "${IMAGE}", Locations: []string{"c.yaml:2"}, Verdict: VerdictSkipped, Detail: "templated reference"},
+	}
+	var b strings.Builder
+	if err := WriteMarkdown(&b, results); err != nil {
+		t.Fatalf("WriteMarkdown() error = %v", err)
+	}
+	got := b.String()
+	for _, want := range []string{
+		":x: 1 of 3 image references failed the verification. 1 skipped.",
+		"| :white_check_mark: pass | `localhost:5000/app:v1` | `sha256:abc` | `a.yaml:3`<br>`b.yaml:1` |  |\n",
+		"| :x: fail | `localhost:5000/db:v1` | `sha256:def` | `a.yaml:9` | no signature is \\| associated |\n",
+		"| :fast_forward: skipped | `${IMAGE}` |  | `c.yaml:2` | templated reference |\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("WriteMarkdown() = %s, want to contain %q", got, want)
+		}
+	}
+}
+
+func TestWriteMarkdown_Empty(t *testing.T) {
+	var b strings.Builder
+	if err := WriteMarkdown(&b, nil); err != nil {
+		t.Fatalf("WriteMarkdown() error = %v", err)
+	}
+	if !strings.Contains(b.String(), "No image references found") {
+		t.Fatalf("WriteMarkdown() = %s", b.String())
+	}
+}
@@ -0,0 +1,162 @@
+// Package graph builds the graph of the artifacts of a repository and their
+// referrers, such as signatures, and exports it for visualization tools.
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+)
+
+// Format is an export format of the graph.
+type Format string
+
+const (
+	// FormatDOT is the DOT language of graphviz.
+	FormatDOT Format = "dot"
+
+	// FormatJSON is a JSON document with the nodes and edges of the graph.
+	FormatJSON Format = "json"
+)
+
+// Formats lists the supported export formats.
+var Formats = []Format{FormatDOT, FormatJSON}
+
+// Node is an artifact manifest in the graph.
+type Node struct {
+	Digest       digest.Digest     `json:"digest"`
+	MediaType    string            `json:"mediaType"`
+	ArtifactType string            `json:"artifactType,omitempty"`
+	Size         int64             `json:"size"`
+	Tags         []string          `json:"tags,omitempty"`
+	Annotations  map[string]string `json:"annotations,omitempty"`
+}
+
+// Edge is the relationship of a referrer to its subject.
+type Edge struct {
+	Referrer digest.Digest `json:"referrer"`
+	Subject  digest.Digest `json:"subject"`
+}
+
+// Graph is the graph of artifacts and their referrers.
+type Graph struct {
+	Nodes []*Node `json:"nodes"`
+	Edges []Edge  `json:"edges"`
+
+	index map[digest.Digest]*Node
+}
+
+// Build builds the graph of roots and their referrers found by finder,
+// recursively, so that referrers of referrers, such as signatures of an SBOM,
+// are included. tags maps the digests of the roots to their tags.
+func Build(ctx context.Context, finder content.PredecessorFinder, roots []ocispec.Descriptor, tags map[digest.Digest][]string) (*Graph, error) {
+	g := &Graph{
+		Nodes: []*Node{},
+		Edges: []Edge{},
+		index: map[digest.Digest]*Node{},
+	}
+	queue := make([]ocispec.Descriptor, 0, len(roots))
+	for _, root := range roots {
+		if _, ok := g.index[root.Digest]; ok {
+			continue
+		}
+		g.add(root)
+		queue = append(queue, root)
+	}
+	for len(queue) > 0 {
+		subject := queue[0]
+		queue = queue[1:]
+		referrers, err := finder.Predecessors(ctx, subject)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list the referrers of %s: %w", subject.Digest, err)
+		}
+		for _, referrer := range referrers {
+			g.Edges = append(g.Edges, Edge{Referrer: referrer.Digest, Subject: subject.Digest})
+			if _, ok := g.index[referrer.Digest]; ok {
+				continue
+			}
+			g.add(referrer)
+			queue = append(queue, referrer)
+		}
+	}
+	for dgst, nodeTags := range tags {
+		if node, ok := g.index[dgst]; ok {
+			node.Tags = append(node.Tags, nodeTags...)
+			sort.Strings(node.Tags)
+		}
+	}
+	return g, nil
+}
+
+func (g *Graph) add(desc ocispec.Descriptor) {
+	node := &Node{
+		Digest:       desc.Digest,
+		MediaType:    desc.MediaType,
+		ArtifactType: desc.ArtifactType,
+		Size:         desc.Size,
+		Annotations:  desc.Annotations,
+	}
+	g.Nodes = append(g.Nodes, node)
+	g.index[desc.Digest] = node
+}
+
+// Write exports the graph in format to w.
+func (g *Graph) Write(w io.Writer, format Format) error {
+	switch format {
+	case FormatDOT:
+		return g.WriteDOT(w)
+	case FormatJSON:
+		return g.WriteJSON(w)
+	default:
+		return fmt.Errorf("unsupported graph format %q", format)
+	}
+}
+
+// WriteJSON exports the graph as a JSON document.
+func (g *Graph) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(g)
+}
+
+// WriteDOT exports the graph in the DOT language of graphviz. The edges point
+// from the referrers to their subjects.
+func (g *Graph) WriteDOT(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("digraph referrers {\n")
+	b.WriteString("  rankdir=RL;\n")
+	b.WriteString("  node [shape=box];\n")
+	for _, node := range g.Nodes {
+		label := []string{node.Digest.String()}
+		if node.ArtifactType != "" {
+			label = append(label, node.ArtifactType)
+		} else {
+			label = append(label, node.MediaType)
+		}
+		for _, tag := range node.Tags {
+			label = append(label, "tag: "+tag)
+		}
+		fmt.Fprintf(&b, "  %s [label=%s];\n", quote(node.Digest.String()), quote(strings.Join(label, "\n")))
+	}
+	for _, edge := range g.Edges {
+		fmt.Fprintf(&b, "  %s -> %s;\n", quote(edge.Referrer.String()), quote(edge.Subject.String()))
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// quote returns s as a DOT quoted string.
+func quote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return `"` + s + `"`
+}
@@ -0,0 +1,87 @@
+package graph
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content/memory"
+)
+
+func TestBuild(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New()
+	pack := func(artifactType string, subject *ocispec.Descriptor) ocispec.Descriptor {
+		desc, err := oras.Pack(ctx, store, artifactType, nil, oras.PackOptions{Subject: subject, PackImageManifest: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return desc
+	}
+	image := pack("application/vnd.example.image", nil)
+	signature := pack("application/vnd.cncf.notary.signature", &image)
+	sbom := pack("application/vnd.example.sbom", &image)
+	sbomSignature := pack("application/vnd.cncf.notary.signature", &sbom)
+
+	g, err := Build(ctx, store, []ocispec.Descriptor{image, image}, map[digest.Digest][]string{image.Digest: {"v1", "latest"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g.Nodes) != 4 {
+		t.Fatalf("expected 4 nodes, got %d", len(g.Nodes))
+	}
+	if got := g.Nodes[0].Tags; len(got) != 2 || got[0] != "latest" || got[1] != "v1" {
+		t.Fatalf("unexpected tags of the root: %v", got)
+	}
+	wantEdges := map[Edge]bool{
+		{Referrer: signature.Digest, Subject: image.Digest}:    true,
+		{Referrer: sbom.Digest, Subject: image.Digest}:         true,
+		{Referrer: sbomSignature.Digest, Subject: sbom.Digest}: true,
+	}
+	if len(g.Edges) != len(wantEdges) {
+		t.Fatalf("expected %d edges, got %v", len(wantEdges), g.Edges)
+	}
+	for _, edge := range g.Edges {
+		if !wantEdges[edge] {
+			t.Fatalf("unexpected edge %v", edge)
+		}
+	}
+
+	var dot bytes.Buffer
+	if err := g.Write(&dot, FormatDOT); err != nil {
+		t.Fatal(err)
+	}
+	if want := `"` + sbomSignature.Digest.String() + `" -> "` + sbom.Digest.String() + `";`; !strings.Contains(dot.String(), want) {
+		t.Fatalf("expected DOT output to contain %s, got:\n%s", want, dot.String())
+	}
+	if !strings.HasPrefix(dot.String(), "digraph referrers {\n") {
+		t.Fatalf("unexpected DOT output:\n%s", dot.String())
+	}
+
+	var out bytes.Buffer
+	if err := g.Write(&out, FormatJSON); err != nil {
+		t.Fatal(err)
+	}
+	var decoded Graph
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded.Nodes) != 4 || len(decoded.Edges) != 3 || decoded.Nodes[1].ArtifactType == "" {
+		t.Fatalf("unexpected JSON output: %s", out.String())
+	}
+
+	if err := g.Write(&out, "yaml"); err == nil {
+		t.Fatal("expected error for unsupported format")
+	}
+}
+
+func TestQuote(t *testing.T) {
+	if got, want := quote("a\"b\\c\nd"), `"a\"b\\c\nd"`; got != want {
+		t.Fatalf("quote() = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,76 @@
+package httputil
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// reservedHeaders are the headers set by notation which must not be set by
+// users. A user-provided Authorization header would bypass the credentials of
+// the registry.
+var reservedHeaders = []string{"Authorization", "Host", "User-Agent"}
+
+// ParseHeader parses a header in the format of "{name}: {value}", e.g.
+// "X-Tenant-Id: contoso".
+func ParseHeader(header string) (name, value string, err error) {
+	name, value, ok := strings.Cut(header, ":")
+	if !ok {
+		return "", "", fmt.Errorf("header %q is not in the format of {name}: {value}", header)
+	}
+	name = strings.TrimSpace(name)
+	value = strings.TrimSpace(value)
+	if err := ValidateHeader(name, value); err != nil {
+		return "", "", err
+	}
+	return name, value, nil
+}
+
+// ValidateHeader returns an error if name is not a valid header name that may
+// be set by users, or value is not a valid header value.
+func ValidateHeader(name, value string) error {
+	if name == "" {
+		return fmt.Errorf("header name must not be empty")
+	}
+	for _, c := range name {
+		if !isTokenChar(c) {
+			return fmt.Errorf("header name %q contains invalid character %q", name, c)
+		}
+	}
+	for _, reserved := range reservedHeaders {
+		if strings.EqualFold(name, reserved) {
+			return fmt.Errorf("header %q is reserved and cannot be set", reserved)
+		}
+	}
+	if strings.ContainsAny(value, "\r\n\x00") {
+		return fmt.Errorf("value of header %q contains invalid characters", name)
+	}
+	return nil
+}
+
+// ValidateUserAgent returns an error if userAgent is not a valid value of the
+// User-Agent header.
+func ValidateUserAgent(userAgent string) error {
+	if strings.TrimSpace(userAgent) == "" || strings.ContainsAny(userAgent, "\r\n\x00") {
+		return fmt.Errorf("user agent %q is not a valid User-Agent header", userAgent)
+	}
+	return nil
+}
+
+// MergeHeader sets the headers of src to dst, replacing the headers of dst of
+// the same names.
+func MergeHeader(dst, src http.Header) {
+	for name, values := range src {
+		dst[http.CanonicalHeaderKey(name)] = append([]string(nil), values...)
+	}
+}
+
+// isTokenChar returns true if c is a token character of RFC 7230.
+func isTokenChar(c rune) bool {
+	switch {
+	case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		return true
+	default:
+		return strings.ContainsRune("!#$%&'*+-.^_`|~", c)
+	}
+}
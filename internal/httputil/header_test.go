@@ -0,0 +1,45 @@
+package httputil
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestParseHeader(t *testing.T) {
+	tests := []struct {
+		header  string
+		name    string
+		value   string
+		wantErr bool
+	}{
+		{header: "X-Tenant-Id: contoso", name: "X-Tenant-Id", value: "contoso"},
+		{header: "traceparent:00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01", name: "traceparent", value: "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"},
+		{header: "X-Empty:", name: "X-Empty", value: ""},
+		{header: "X-Tenant-Id", wantErr: true},
+		{header: ": contoso", wantErr: true},
+		{header: "X Tenant: contoso", wantErr: true},
+		{header: "authorization: Bearer token", wantErr: true},
+		{header: "User-Agent: acme", wantErr: true},
+		{header: "X-Tenant-Id: contoso\r\nX-Injected: true", wantErr: true},
+	}
+	for _, tt := range tests {
+		name, value, err := ParseHeader(tt.header)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseHeader(%q) error = %v, wantErr %v", tt.header, err, tt.wantErr)
+			continue
+		}
+		if name != tt.name || value != tt.value {
+			t.Errorf("ParseHeader(%q) = %q, %q, want %q, %q", tt.header, name, value, tt.name, tt.value)
+		}
+	}
+}
+
+func TestMergeHeader(t *testing.T) {
+	dst := http.Header{"User-Agent": {"notation/v1.0.0"}, "X-Tenant-Id": {"default"}}
+	MergeHeader(dst, http.Header{"x-tenant-id": {"contoso"}, "X-Trace-Id": {"build-42"}})
+	want := http.Header{"User-Agent": {"notation/v1.0.0"}, "X-Tenant-Id": {"contoso"}, "X-Trace-Id": {"build-42"}}
+	if !reflect.DeepEqual(dst, want) {
+		t.Fatalf("MergeHeader() = %v, want %v", dst, want)
+	}
+}
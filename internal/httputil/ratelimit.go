@@ -0,0 +1,65 @@
+// Package httputil provides HTTP client helpers for registry operations.
+package httputil
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Client is an HTTP client. It is compatible with the client interface of
+// oras-go remote registries and repositories.
+type Client interface {
+	// Do sends an HTTP request and returns an HTTP response.
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// rateLimitedClient spaces out requests sent through the base client so
+// that no more than qps requests are sent per second.
+type rateLimitedClient struct {
+	base     Client
+	interval time.Duration
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+// NewRateLimitedClient returns a client sending at most qps requests per
+// second through base. The base client is returned as is if qps is not
+// positive.
+func NewRateLimitedClient(base Client, qps float64) Client {
+	if qps <= 0 {
+		return base
+	}
+	return &rateLimitedClient{
+		base:     base,
+		interval: time.Duration(float64(time.Second) / qps),
+	}
+}
+
+// Do waits for the next available slot and sends the request.
+func (c *rateLimitedClient) Do(req *http.Request) (*http.Response, error) {
+	if wait := c.reserve(); wait > 0 {
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		}
+	}
+	return c.base.Do(req)
+}
+
+// reserve reserves the next slot and returns the duration to wait for it.
+func (c *rateLimitedClient) reserve() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	if c.next.Before(now) {
+		c.next = now
+	}
+	wait := c.next.Sub(now)
+	c.next = c.next.Add(c.interval)
+	return wait
+}
@@ -0,0 +1,69 @@
+package httputil
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+type countingClient struct {
+	count int
+}
+
+func (c *countingClient) Do(req *http.Request) (*http.Response, error) {
+	c.count++
+	return &http.Response{StatusCode: http.StatusOK}, nil
+}
+
+func TestNewRateLimitedClient(t *testing.T) {
+	t.Run("no limit", func(t *testing.T) {
+		base := &countingClient{}
+		if client := NewRateLimitedClient(base, 0); client != base {
+			t.Fatal("expected base client to be returned when qps is not positive")
+		}
+	})
+
+	t.Run("requests are spaced out", func(t *testing.T) {
+		base := &countingClient{}
+		client := NewRateLimitedClient(base, 50)
+		req, err := http.NewRequest(http.MethodGet, "https://registry.example/v2/", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		start := time.Now()
+		for i := 0; i < 5; i++ {
+			if _, err := client.Do(req); err != nil {
+				t.Fatal(err)
+			}
+		}
+		// the first request is sent immediately, the other 4 are spaced by
+		// 20ms each
+		if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+			t.Fatalf("expected requests to take at least 80ms, took %v", elapsed)
+		}
+		if base.count != 5 {
+			t.Fatalf("expected 5 requests, got %d", base.count)
+		}
+	})
+
+	t.Run("canceled while waiting", func(t *testing.T) {
+		base := &countingClient{}
+		client := NewRateLimitedClient(base, 0.1)
+		ctx, cancel := context.WithCancel(context.Background())
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://registry.example/v2/", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := client.Do(req); err != nil {
+			t.Fatal(err)
+		}
+		cancel()
+		if _, err := client.Do(req); err == nil {
+			t.Fatal("expected error for canceled request, got nil")
+		}
+		if base.count != 1 {
+			t.Fatalf("expected 1 request, got %d", base.count)
+		}
+	})
+}
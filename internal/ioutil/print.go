@@ -4,18 +4,25 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"sort"
 	"text/tabwriter"
+	"time"
 
 	"github.com/notaryproject/notation-go/config"
+	"github.com/notaryproject/notation/internal/ceremony"
+	"github.com/notaryproject/notation/pkg/configutil"
 )
 
 func newTabWriter(w io.Writer) *tabwriter.Writer {
 	return tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
 }
 
-func PrintKeyMap(w io.Writer, target *string, v []config.KeySuite) error {
+// PrintKeyMap prints the signing keys. The key path of keys in PKCS #11 tokens
+// is the PKCS #11 URI of the key, of keys in KMS the KMS key URI, and of keys
+// in the macOS Keychain the identity prefixed with "keychain:".
+func PrintKeyMap(w io.Writer, target *string, v []config.KeySuite, purposes map[string]string, validities map[string]configutil.KeyValidity, pkcs11Keys map[string]configutil.PKCS11Key, kmsKeys map[string]configutil.KMSKey, keychainKeys map[string]configutil.KeychainKey) error {
 	tw := newTabWriter(w)
-	fmt.Fprintln(tw, "NAME\tKEY PATH\tCERTIFICATE PATH\tID\tPLUGIN NAME\t")
+	fmt.Fprintln(tw, "NAME\tKEY PATH\tCERTIFICATE PATH\tID\tPLUGIN NAME\tPURPOSE\tNOT BEFORE\tNOT AFTER\t")
 	for _, key := range v {
 		name := key.Name
 		if target != nil && key.Name == *target {
@@ -24,12 +31,65 @@ func PrintKeyMap(w io.Writer, target *string, v []config.KeySuite) error {
 		kp := key.X509KeyPair
 		if kp == nil {
 			kp = &config.X509KeyPair{}
+			if pkcs11Key, ok := pkcs11Keys[key.Name]; ok {
+				kp = &config.X509KeyPair{KeyPath: pkcs11Key.URI(), CertificatePath: pkcs11Key.CertificatePath}
+			} else if kmsKey, ok := kmsKeys[key.Name]; ok {
+				kp = &config.X509KeyPair{KeyPath: kmsKey.URI, CertificatePath: kmsKey.CertificatePath}
+			} else if keychainKey, ok := keychainKeys[key.Name]; ok {
+				kp = &config.X509KeyPair{KeyPath: keychainKey.URI(), CertificatePath: keychainKey.CertificatePath}
+			}
 		}
 		ext := key.ExternalKey
 		if ext == nil {
 			ext = &config.ExternalKey{}
 		}
-		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t\n", name, kp.KeyPath, kp.CertificatePath, ext.ID, ext.PluginName)
+		validity := validities[key.Name]
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t\n", name, kp.KeyPath, kp.CertificatePath, ext.ID, ext.PluginName, purposes[key.Name], formatTime(validity.NotBefore), formatTime(validity.NotAfter))
+	}
+	return tw.Flush()
+}
+
+// formatTime formats t in RFC 3339 format, or returns an empty string if t is
+// nil.
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// PrintAuditTrail prints the records of the audit trail of key ceremonies.
+func PrintAuditTrail(w io.Writer, records []ceremony.Record) error {
+	tw := newTabWriter(w)
+	fmt.Fprintln(tw, "TIME	ACTION	KEY	SESSION	DETAILS	")
+	for _, record := range records {
+		var details string
+		switch record.Action {
+		case ceremony.ActionConfigured:
+			details = fmt.Sprintf("%d of %v", record.Threshold, record.Approvers)
+		case ceremony.ActionApproved:
+			details = record.Approver
+		case ceremony.ActionOpened:
+			details = fmt.Sprintf("approved by %v, expires at %s", record.Approvers, formatTime(record.ExpiresAt))
+		case ceremony.ActionSigned:
+			details = record.Reference
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t\n", record.Time.Format(time.RFC3339), record.Action, record.Key, record.SessionID, details)
+	}
+	return tw.Flush()
+}
+
+// PrintAliasMap prints the aliases of repositories sorted by name.
+func PrintAliasMap(w io.Writer, aliases map[string]string) error {
+	names := make([]string, 0, len(aliases))
+	for name := range aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	tw := newTabWriter(w)
+	fmt.Fprintln(tw, "NAME\tREPOSITORY\t")
+	for _, name := range names {
+		fmt.Fprintf(tw, "%s\t%s\t\n", name, aliases[name])
 	}
 	return tw.Flush()
 }
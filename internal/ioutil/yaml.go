@@ -0,0 +1,146 @@
+package ioutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// PrintObjectAsYAML prints i in YAML to stdout. The fields are named and
+// ordered as in JSON.
+func PrintObjectAsYAML(i interface{}) error {
+	return WriteObjectAsYAML(os.Stdout, i)
+}
+
+// WriteObjectAsYAML writes i in YAML to w. The fields are named and ordered as
+// in JSON.
+func WriteObjectAsYAML(w io.Writer, i interface{}) error {
+	jsonBytes, err := json.Marshal(i)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(jsonBytes))
+	decoder.UseNumber()
+	node, err := decodeYAMLNode(decoder)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	writeYAMLNode(&buf, node, 0)
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// yamlNode is a JSON value keeping the order of the fields of objects.
+type yamlNode struct {
+	// scalar is the YAML representation of a scalar value.
+	scalar string
+
+	// keys and values are the fields of an object, or values are the items
+	// of an array.
+	isObject bool
+	isArray  bool
+	keys     []string
+	values   []*yamlNode
+}
+
+// decodeYAMLNode decodes the next JSON value of decoder.
+func decodeYAMLNode(decoder *json.Decoder) (*yamlNode, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch token := token.(type) {
+	case json.Delim:
+		node := &yamlNode{isObject: token == '{', isArray: token == '['}
+		for decoder.More() {
+			if node.isObject {
+				key, err := decoder.Token()
+				if err != nil {
+					return nil, err
+				}
+				node.keys = append(node.keys, key.(string))
+			}
+			value, err := decodeYAMLNode(decoder)
+			if err != nil {
+				return nil, err
+			}
+			node.values = append(node.values, value)
+		}
+		// consume the closing delimiter
+		if _, err := decoder.Token(); err != nil {
+			return nil, err
+		}
+		return node, nil
+	case string:
+		return &yamlNode{scalar: yamlString(token)}, nil
+	case json.Number:
+		return &yamlNode{scalar: token.String()}, nil
+	case bool:
+		return &yamlNode{scalar: strconv.FormatBool(token)}, nil
+	case nil:
+		return &yamlNode{scalar: "null"}, nil
+	default:
+		return nil, errors.New("unexpected JSON token")
+	}
+}
+
+// writeYAMLNode writes the fields of an object or the items of an array in
+// block style, indented by indent spaces.
+func writeYAMLNode(buf *bytes.Buffer, node *yamlNode, indent int) {
+	prefix := strings.Repeat(" ", indent)
+	for i, value := range node.values {
+		if node.isObject {
+			fmt.Fprintf(buf, "%s%s:", prefix, yamlString(node.keys[i]))
+		} else {
+			fmt.Fprintf(buf, "%s-", prefix)
+		}
+		switch {
+		case value.isObject && len(value.values) > 0 && node.isArray:
+			// the first field of an object item follows the dash
+			var item bytes.Buffer
+			writeYAMLNode(&item, value, indent+2)
+			buf.WriteString(" ")
+			buf.Write(bytes.TrimPrefix(item.Bytes(), []byte(prefix+"  ")))
+		case (value.isObject || value.isArray) && len(value.values) > 0:
+			buf.WriteString("\n")
+			writeYAMLNode(buf, value, indent+2)
+		case value.isObject:
+			buf.WriteString(" {}\n")
+		case value.isArray:
+			buf.WriteString(" []\n")
+		default:
+			fmt.Fprintf(buf, " %s\n", value.scalar)
+		}
+	}
+}
+
+// yamlPlainString matches the strings which are written as plain scalars.
+var yamlPlainString = regexp.MustCompile(`^[A-Za-z_/.][A-Za-z0-9_./@+=,:-]*$`)
+
+// yamlReservedWords are the plain scalars which are not strings in YAML 1.1.
+var yamlReservedWords = []string{"true", "false", "yes", "no", "on", "off", "y", "n", "null", "~"}
+
+// yamlString returns s as a YAML scalar, quoted unless it is unambiguously a
+// string as a plain scalar.
+func yamlString(s string) string {
+	if !yamlPlainString.MatchString(s) || strings.HasSuffix(s, ":") {
+		// double-quoted scalars of YAML support the escapes of Go
+		return strconv.Quote(s)
+	}
+	for _, word := range yamlReservedWords {
+		if strings.EqualFold(s, word) {
+			return strconv.Quote(s)
+		}
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return strconv.Quote(s)
+	}
+	return s
+}
@@ -0,0 +1,63 @@
+package ioutil
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteObjectAsYAML(t *testing.T) {
+	type certificate struct {
+		Fingerprint string `json:"fingerprint"`
+		IssuedTo    string `json:"issuedTo"`
+	}
+	object := struct {
+		Reference    string            `json:"reference"`
+		Size         int64             `json:"size"`
+		Critical     bool              `json:"critical"`
+		Attributes   map[string]string `json:"attributes"`
+		Empty        map[string]string `json:"empty"`
+		Certificates []certificate     `json:"certificates"`
+		Tags         []string          `json:"tags"`
+		None         []string          `json:"none"`
+		Policy       *string           `json:"policy"`
+	}{
+		Reference:  "localhost:5000/net-monitor@sha256:b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9",
+		Size:       942,
+		Critical:   true,
+		Attributes: map[string]string{"signingTime": "2022-02-06T20:50:17Z", "version": "1.0", "enabled": "yes", "note": "a: b # c"},
+		Empty:      map[string]string{},
+		Certificates: []certificate{
+			{Fingerprint: "68d85f6ab5c5e7e1c7b3c1c6c2f2d6d4", IssuedTo: "CN=wabbit-networks.io,O=Notary,L=Seattle,ST=WA,C=US"},
+			{Fingerprint: "0d1f2e3c4b5a69788796a5b4c3d2e1f0", IssuedTo: "CN=Root CA, O=Notary"},
+		},
+		Tags: []string{"v1", "-latest"},
+		None: []string{},
+	}
+	want := `reference: localhost:5000/net-monitor@sha256:b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9
+size: 942
+critical: true
+attributes:
+  enabled: "yes"
+  note: "a: b # c"
+  signingTime: "2022-02-06T20:50:17Z"
+  version: "1.0"
+empty: {}
+certificates:
+  - fingerprint: "68d85f6ab5c5e7e1c7b3c1c6c2f2d6d4"
+    issuedTo: CN=wabbit-networks.io,O=Notary,L=Seattle,ST=WA,C=US
+  - fingerprint: "0d1f2e3c4b5a69788796a5b4c3d2e1f0"
+    issuedTo: "CN=Root CA, O=Notary"
+tags:
+  - v1
+  - "-latest"
+none: []
+policy: null
+`
+	var buf bytes.Buffer
+	if err := WriteObjectAsYAML(&buf, object); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != want {
+		t.Fatalf("WriteObjectAsYAML() =\n%s\nwant\n%s", got, want)
+	}
+}
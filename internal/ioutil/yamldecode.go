@@ -0,0 +1,375 @@
+package ioutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// UnmarshalYAML decodes the YAML document data into v, as encoding/json
+// decodes the equivalent JSON document into v. Fields of data unknown to v
+// are an error, so that typos in configuration files are reported.
+//
+// Only the subset of YAML written by hand in configuration files is
+// supported: block mappings and sequences, flow mappings and sequences of
+// scalars, plain and quoted scalars, and comments. Anchors, aliases, tags,
+// block scalars and multiple documents are rejected.
+func UnmarshalYAML(data []byte, v interface{}) error {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+		// JSON documents are YAML documents
+		decoder := json.NewDecoder(bytes.NewReader(trimmed))
+		decoder.DisallowUnknownFields()
+		return decoder.Decode(v)
+	}
+	lines, err := splitYAMLLines(data)
+	if err != nil {
+		return err
+	}
+	var value interface{}
+	if len(lines) > 0 {
+		p := &yamlParser{lines: lines}
+		if value, err = p.parseBlock(lines[0].indent); err != nil {
+			return err
+		}
+		if p.pos < len(lines) {
+			return p.errorf("unexpected indentation")
+		}
+	}
+	jsonBytes, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(jsonBytes))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(v)
+}
+
+// yamlLine is a line of a YAML document with content.
+type yamlLine struct {
+	number  int
+	indent  int
+	content string
+}
+
+// splitYAMLLines returns the lines of data with content, without comments and
+// trailing spaces.
+func splitYAMLLines(data []byte) ([]yamlLine, error) {
+	var lines []yamlLine
+	for i, line := range strings.Split(string(data), "\n") {
+		number := i + 1
+		line = strings.TrimRight(stripYAMLComment(line), " \t\r")
+		content := strings.TrimLeft(line, " ")
+		if content == "" || (len(lines) == 0 && content == "---") {
+			continue
+		}
+		if strings.HasPrefix(content, "\t") {
+			return nil, fmt.Errorf("yaml: line %d: tabs are not allowed as indentation", number)
+		}
+		if content == "---" || content == "..." {
+			return nil, fmt.Errorf("yaml: line %d: multiple documents are not supported", number)
+		}
+		lines = append(lines, yamlLine{number: number, indent: len(line) - len(content), content: content})
+	}
+	return lines, nil
+}
+
+// stripYAMLComment removes the comment of line, i.e. from a "#" at the start
+// of the line or after a space, outside quoted scalars. Quotes only start a
+// quoted scalar at the start of a key or a value, e.g. not in "it's".
+func stripYAMLComment(line string) string {
+	var quote byte
+	var previous byte = ' '
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote != 0:
+		case (c == '"' || c == '\'') && strings.IndexByte(" :-[{,", previous) >= 0:
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+		if c != ' ' && c != '\t' {
+			previous = c
+		}
+	}
+	return line
+}
+
+// yamlParser parses the block structure of the lines of a YAML document.
+type yamlParser struct {
+	lines []yamlLine
+	pos   int
+}
+
+func (p *yamlParser) errorf(format string, args ...interface{}) error {
+	number := p.lines[len(p.lines)-1].number
+	if p.pos < len(p.lines) {
+		number = p.lines[p.pos].number
+	}
+	return fmt.Errorf("yaml: line %d: %s", number, fmt.Sprintf(format, args...))
+}
+
+// parseBlock parses the mapping or sequence starting at the current line,
+// indented by indent.
+func (p *yamlParser) parseBlock(indent int) (interface{}, error) {
+	if isYAMLSequenceItem(p.lines[p.pos].content) {
+		return p.parseSequence(indent)
+	}
+	return p.parseMapping(indent)
+}
+
+// parseSequence parses the items of a block sequence indented by indent.
+func (p *yamlParser) parseSequence(indent int) (interface{}, error) {
+	items := []interface{}{}
+	for p.pos < len(p.lines) && p.lines[p.pos].indent == indent && isYAMLSequenceItem(p.lines[p.pos].content) {
+		line := p.lines[p.pos]
+		rest := strings.TrimLeft(line.content[1:], " ")
+		var item interface{}
+		var err error
+		switch {
+		case rest == "":
+			p.pos++
+			item, err = p.parseNested(indent)
+		case isYAMLSequenceItem(rest) || isYAMLMappingEntry(rest):
+			// the first line of a nested block follows the dash
+			p.lines[p.pos] = yamlLine{number: line.number, indent: indent + len(line.content) - len(rest), content: rest}
+			item, err = p.parseBlock(p.lines[p.pos].indent)
+		default:
+			item, err = parseYAMLValue(rest)
+			if err != nil {
+				err = p.errorf("%v", err)
+			}
+			p.pos++
+		}
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// parseMapping parses the entries of a block mapping indented by indent.
+func (p *yamlParser) parseMapping(indent int) (interface{}, error) {
+	mapping := make(map[string]interface{})
+	for p.pos < len(p.lines) && p.lines[p.pos].indent == indent && !isYAMLSequenceItem(p.lines[p.pos].content) {
+		key, rest, ok := splitYAMLMappingEntry(p.lines[p.pos].content)
+		if !ok {
+			return nil, p.errorf("expecting a mapping entry in the format of {key}: {value}")
+		}
+		name, err := parseYAMLKey(key)
+		if err != nil {
+			return nil, p.errorf("%v", err)
+		}
+		if _, ok := mapping[name]; ok {
+			return nil, p.errorf("duplicate key %q", name)
+		}
+		var value interface{}
+		if rest == "" {
+			p.pos++
+			value, err = p.parseNested(indent)
+		} else {
+			value, err = parseYAMLValue(rest)
+			if err != nil {
+				err = p.errorf("%v", err)
+			}
+			p.pos++
+		}
+		if err != nil {
+			return nil, err
+		}
+		mapping[name] = value
+	}
+	return mapping, nil
+}
+
+// parseNested parses the block nested in the entry or the item indented by
+// indent ending at the previous line, or returns nil if there is none. The
+// items of a sequence nested in a mapping entry may be indented as the entry.
+func (p *yamlParser) parseNested(indent int) (interface{}, error) {
+	if p.pos >= len(p.lines) {
+		return nil, nil
+	}
+	next := p.lines[p.pos]
+	if next.indent > indent || (next.indent == indent && isYAMLSequenceItem(next.content) && !p.inSequence(indent)) {
+		return p.parseBlock(next.indent)
+	}
+	return nil, nil
+}
+
+// inSequence reports whether the line before the current line is an item of
+// a sequence indented by indent, so that the current line at the same
+// indentation is the next item rather than nested in it.
+func (p *yamlParser) inSequence(indent int) bool {
+	previous := p.lines[p.pos-1]
+	return previous.indent == indent && isYAMLSequenceItem(previous.content)
+}
+
+// isYAMLSequenceItem reports whether content starts an item of a block
+// sequence.
+func isYAMLSequenceItem(content string) bool {
+	return content == "-" || strings.HasPrefix(content, "- ")
+}
+
+// isYAMLMappingEntry reports whether content is an entry of a block mapping.
+func isYAMLMappingEntry(content string) bool {
+	if strings.HasPrefix(content, "[") || strings.HasPrefix(content, "{") {
+		return false
+	}
+	_, _, ok := splitYAMLMappingEntry(content)
+	return ok
+}
+
+// splitYAMLMappingEntry splits the key and the value of a mapping entry at the
+// first ": " or the trailing ":" outside quotes.
+func splitYAMLMappingEntry(content string) (key, value string, ok bool) {
+	var quote byte
+	for i := 0; i < len(content); i++ {
+		c := content[i]
+		switch {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote != 0:
+		case (c == '"' || c == '\'') && i == 0:
+			quote = c
+		case c == ':' && (i == len(content)-1 || content[i+1] == ' '):
+			return strings.TrimSpace(content[:i]), strings.TrimSpace(content[i+1:]), true
+		}
+	}
+	return "", "", false
+}
+
+// parseYAMLKey returns the key of a mapping entry, which is a plain or quoted
+// string.
+func parseYAMLKey(key string) (string, error) {
+	if key == "" {
+		return "", errors.New("mapping keys must not be empty")
+	}
+	if key[0] != '"' && key[0] != '\'' {
+		return key, nil
+	}
+	value, err := parseYAMLScalar(key)
+	if err != nil {
+		return "", err
+	}
+	return value.(string), nil
+}
+
+// parseYAMLValue parses the value of a mapping entry or a sequence item on a
+// single line, i.e. a scalar, or a flow sequence or mapping of scalars.
+func parseYAMLValue(s string) (interface{}, error) {
+	switch s[0] {
+	case '[':
+		if !strings.HasSuffix(s, "]") {
+			return nil, errors.New("flow sequences must be on a single line")
+		}
+		items := []interface{}{}
+		for _, field := range splitYAMLFlow(s[1 : len(s)-1]) {
+			item, err := parseYAMLScalar(field)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	case '{':
+		if !strings.HasSuffix(s, "}") {
+			return nil, errors.New("flow mappings must be on a single line")
+		}
+		mapping := make(map[string]interface{})
+		for _, field := range splitYAMLFlow(s[1 : len(s)-1]) {
+			key, rest, ok := splitYAMLMappingEntry(field)
+			if !ok {
+				return nil, fmt.Errorf("expecting a mapping entry in the format of {key}: {value}, got %q", field)
+			}
+			name, err := parseYAMLKey(key)
+			if err != nil {
+				return nil, err
+			}
+			if mapping[name], err = parseYAMLScalar(rest); err != nil {
+				return nil, err
+			}
+		}
+		return mapping, nil
+	case '|', '>':
+		return nil, errors.New("block scalars are not supported")
+	}
+	return parseYAMLScalar(s)
+}
+
+// splitYAMLFlow splits the items of a flow collection at the commas outside
+// quotes.
+func splitYAMLFlow(s string) []string {
+	var fields []string
+	var quote byte
+	start := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote != 0:
+		case c == '"' || c == '\'':
+			quote = c
+		case c == ',':
+			fields = append(fields, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+	if last := strings.TrimSpace(s[start:]); last != "" || len(fields) > 0 {
+		fields = append(fields, last)
+	}
+	return fields
+}
+
+// parseYAMLScalar parses a plain or quoted scalar. Plain scalars are resolved
+// by the core schema of YAML 1.2, e.g. "true" is a boolean and "42" is an
+// integer.
+func parseYAMLScalar(s string) (interface{}, error) {
+	if s == "" {
+		return nil, nil
+	}
+	switch s[0] {
+	case '"':
+		value, err := strconv.Unquote(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid double-quoted scalar %s", s)
+		}
+		return value, nil
+	case '\'':
+		if len(s) < 2 || !strings.HasSuffix(s, "'") {
+			return nil, fmt.Errorf("invalid single-quoted scalar %s", s)
+		}
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'"), nil
+	case '&', '*', '!':
+		return nil, errors.New("anchors, aliases and tags are not supported")
+	case '[', '{':
+		return nil, errors.New("nested flow collections are not supported")
+	}
+	switch s {
+	case "null", "Null", "NULL", "~":
+		return nil, nil
+	case "true", "True", "TRUE":
+		return true, nil
+	case "false", "False", "FALSE":
+		return false, nil
+	}
+	if _, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return json.Number(s), nil
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil && strings.ContainsAny(s, "0123456789") && !strings.ContainsAny(s, "xXpP_") {
+		return json.Number(s), nil
+	}
+	return s, nil
+}
@@ -0,0 +1,145 @@
+package ioutil
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestUnmarshalYAML(t *testing.T) {
+	type audit struct {
+		Name         string            `json:"name"`
+		Repository   string            `json:"repository"`
+		Schedule     string            `json:"schedule"`
+		PluginConfig map[string]string `json:"pluginConfig"`
+	}
+	type output struct {
+		Format string `json:"format"`
+		Path   string `json:"path"`
+	}
+	type config struct {
+		Concurrency int      `json:"concurrency"`
+		Ratio       float64  `json:"ratio"`
+		Enabled     bool     `json:"enabled"`
+		Note        string   `json:"note"`
+		Quoted      string   `json:"quoted"`
+		Tags        []string `json:"tags"`
+		Empty       []string `json:"empty"`
+		Audits      []audit  `json:"audits"`
+		Outputs     []output `json:"outputs"`
+		Nothing     *string  `json:"nothing"`
+	}
+	data := `---
+# scheduled compliance scans
+concurrency: 4
+ratio: 0.5
+enabled: true
+note: it's a "plain" scalar # comment
+quoted: 'a: b # c'
+tags: [v1, "-latest", 'it''s']
+empty: []
+audits:
+- name: prod
+  repository: registry.example.com:5000/prod/app
+  schedule: 24h
+  pluginConfig: {key1: val1, "key 2": "val: 2"}
+-
+  name: "staging"
+  repository: registry.example.com/staging/app
+outputs:
+  - format: csv
+    path: results.csv
+
+  - format: json
+    path: "-"
+nothing: ~
+`
+	want := config{
+		Concurrency: 4,
+		Ratio:       0.5,
+		Enabled:     true,
+		Note:        `it's a "plain" scalar`,
+		Quoted:      "a: b # c",
+		Tags:        []string{"v1", "-latest", "it's"},
+		Empty:       []string{},
+		Audits: []audit{
+			{Name: "prod", Repository: "registry.example.com:5000/prod/app", Schedule: "24h", PluginConfig: map[string]string{"key1": "val1", "key 2": "val: 2"}},
+			{Name: "staging", Repository: "registry.example.com/staging/app"},
+		},
+		Outputs: []output{{Format: "csv", Path: "results.csv"}, {Format: "json", Path: "-"}},
+	}
+	var got config
+	if err := UnmarshalYAML([]byte(data), &got); err != nil {
+		t.Fatalf("UnmarshalYAML() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("UnmarshalYAML() = %+v, want %+v", got, want)
+	}
+
+	// JSON documents are YAML documents
+	got = config{}
+	if err := UnmarshalYAML([]byte(`{"concurrency": 2, "tags": ["v1"]}`), &got); err != nil || got.Concurrency != 2 || !reflect.DeepEqual(got.Tags, []string{"v1"}) {
+		t.Fatalf("UnmarshalYAML() of JSON = %+v, %v", got, err)
+	}
+}
+
+func TestUnmarshalYAML_RoundTrip(t *testing.T) {
+	type certificate struct {
+		Fingerprint string `json:"fingerprint"`
+		IssuedTo    string `json:"issuedTo"`
+	}
+	type object struct {
+		Reference    string            `json:"reference"`
+		Size         int64             `json:"size"`
+		Attributes   map[string]string `json:"attributes"`
+		Certificates []certificate     `json:"certificates"`
+		Tags         []string          `json:"tags"`
+	}
+	want := object{
+		Reference:  "localhost:5000/net-monitor@sha256:b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9",
+		Size:       942,
+		Attributes: map[string]string{"version": "1.0", "enabled": "yes", "note": "a: b # c"},
+		Certificates: []certificate{
+			{Fingerprint: "68d85f6ab5c5e7e1c7b3c1c6c2f2d6d4", IssuedTo: "CN=Root CA, O=Notary"},
+		},
+		Tags: []string{"v1", "-latest"},
+	}
+	var buf bytes.Buffer
+	if err := WriteObjectAsYAML(&buf, want); err != nil {
+		t.Fatal(err)
+	}
+	var got object
+	if err := UnmarshalYAML(buf.Bytes(), &got); err != nil {
+		t.Fatalf("UnmarshalYAML() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("UnmarshalYAML() = %+v, want %+v", got, want)
+	}
+}
+
+func TestUnmarshalYAML_Error(t *testing.T) {
+	var v struct {
+		Name  string   `json:"name"`
+		Items []string `json:"items"`
+	}
+	for data, want := range map[string]string{
+		"name: a\nunknown: b":        "unknown field",
+		"name: a\nname: b":           "line 2: duplicate key",
+		"name: a\n  items: [b]":      "line 2: unexpected indentation",
+		"name: |\n  text":            "block scalars are not supported",
+		"name: &anchor a":            "anchors, aliases and tags are not supported",
+		"name: a\n---\nname: b":      "line 2: multiple documents are not supported",
+		"name: a\n\titems: [b]":      "tabs are not allowed",
+		"items: [a, [b]]":            "nested flow collections are not supported",
+		"items: [a,\n  b]":           "flow sequences must be on a single line",
+		"name: \"unterminated":       "invalid double-quoted scalar",
+		"just a scalar":              "expecting a mapping entry",
+		"name: 42":                   "cannot unmarshal number",
+		"items:\n  - a\n  nested: b": "line 3: unexpected indentation",
+	} {
+		if err := UnmarshalYAML([]byte(data), &v); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("UnmarshalYAML(%q) error = %v, want %q", data, err, want)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+// Package keychain signs with signing identities of the macOS Keychain,
+// including keys in the Secure Enclave, without plugin, so that the private
+// keys of developers never live in files. The Security framework is called
+// with cgo, so Keychain identities are only supported by macOS builds with
+// cgo.
+package keychain
+
+import (
+	"crypto"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrUnsupported indicates that notation is not built for macOS with cgo,
+// which the Keychain requires.
+var ErrUnsupported = errors.New("Keychain identities are not supported by this build of notation, which requires macOS and cgo")
+
+// Key is the private key of a signing identity of the Keychain. It implements
+// crypto.Signer, and is closed with Close.
+type Key struct {
+	*identity
+	public crypto.PublicKey
+	chain  []*x509.Certificate
+}
+
+// Public returns the public key of the key.
+func (k *Key) Public() crypto.PublicKey {
+	return k.public
+}
+
+// Certificates returns the certificate chain of the identity built from the
+// certificates of the Keychain, starting with the signing certificate.
+func (k *Key) Certificates() []*x509.Certificate {
+	return k.chain
+}
+
+// fingerprint returns the SHA-1 fingerprint of cert in upper case hex, as
+// printed by "security find-identity".
+func fingerprint(cert *x509.Certificate) string {
+	sum := sha1.Sum(cert.Raw)
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// selectIdentity returns the index of the certificate of the identity with the
+// name, which is either the common name of the certificate or its SHA-1
+// fingerprint. The same identity may be found in more than one keychain, but
+// distinct identities of the same common name are ambiguous and must be
+// selected by fingerprint.
+func selectIdentity(name string, certs []*x509.Certificate) (int, error) {
+	index := -1
+	var matches []string
+	seen := make(map[string]bool)
+	for i, cert := range certs {
+		sum := fingerprint(cert)
+		if (cert.Subject.CommonName != name && !strings.EqualFold(sum, name)) || seen[sum] {
+			continue
+		}
+		seen[sum] = true
+		index = i
+		matches = append(matches, sum)
+	}
+	switch len(matches) {
+	case 0:
+		return -1, fmt.Errorf("no signing identity %q found in the Keychain", name)
+	case 1:
+		return index, nil
+	}
+	return -1, fmt.Errorf("%d signing identities named %q found in the Keychain, select one by its SHA-1 fingerprint: %s", len(matches), name, strings.Join(matches, ", "))
+}
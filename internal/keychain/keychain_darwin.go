@@ -0,0 +1,291 @@
+//go:build darwin && cgo
+
+package keychain
+
+/*
+#cgo CFLAGS: -mmacosx-version-min=10.15 -Wno-deprecated-declarations
+#cgo LDFLAGS: -framework CoreFoundation -framework Security
+#include <stdlib.h>
+#include <CoreFoundation/CoreFoundation.h>
+#include <Security/Security.h>
+
+// copyIdentities returns the signing identities of the file-based keychains,
+// e.g. the login keychain, or of the data protection keychain, which holds
+// the identities of keys in the Secure Enclave.
+static CFArrayRef copyIdentities(Boolean dataProtection, OSStatus *status) {
+	const void *keys[] = {kSecClass, kSecMatchLimit, kSecReturnRef, kSecUseDataProtectionKeychain};
+	const void *values[] = {kSecClassIdentity, kSecMatchLimitAll, kCFBooleanTrue, dataProtection ? kCFBooleanTrue : kCFBooleanFalse};
+	CFDictionaryRef query = CFDictionaryCreate(kCFAllocatorDefault, keys, values, 4, &kCFTypeDictionaryKeyCallBacks, &kCFTypeDictionaryValueCallBacks);
+	CFTypeRef result = NULL;
+	*status = SecItemCopyMatching(query, &result);
+	CFRelease(query);
+	return (CFArrayRef)result;
+}
+
+static SecIdentityRef identityAtIndex(CFArrayRef identities, CFIndex i) {
+	return (SecIdentityRef)CFArrayGetValueAtIndex(identities, i);
+}
+
+static SecCertificateRef certificateAtIndex(CFArrayRef certs, CFIndex i) {
+	return (SecCertificateRef)CFArrayGetValueAtIndex(certs, i);
+}
+
+// copyCertificateChain returns the certificate chain of leaf built from the
+// certificates of the keychains, whether or not the chain is trusted by the
+// system.
+static CFArrayRef copyCertificateChain(SecCertificateRef leaf, OSStatus *status) {
+	SecPolicyRef policy = SecPolicyCreateBasicX509();
+	SecTrustRef trust = NULL;
+	*status = SecTrustCreateWithCertificates(leaf, policy, &trust);
+	CFRelease(policy);
+	if (*status != errSecSuccess) {
+		return NULL;
+	}
+	SecTrustEvaluateWithError(trust, NULL);
+	CFIndex count = SecTrustGetCertificateCount(trust);
+	CFMutableArrayRef chain = CFArrayCreateMutable(kCFAllocatorDefault, count, &kCFTypeArrayCallBacks);
+	for (CFIndex i = 0; i < count; i++) {
+		CFArrayAppendValue(chain, SecTrustGetCertificateAtIndex(trust, i));
+	}
+	CFRelease(trust);
+	return chain;
+}
+
+// copyCString returns s in UTF-8, to be freed by the caller.
+static char *copyCString(CFStringRef s) {
+	if (s == NULL) {
+		return NULL;
+	}
+	CFIndex size = CFStringGetMaximumSizeForEncoding(CFStringGetLength(s), kCFStringEncodingUTF8) + 1;
+	char *buf = malloc(size);
+	if (!CFStringGetCString(s, buf, size, kCFStringEncodingUTF8)) {
+		buf[0] = '\0';
+	}
+	return buf;
+}
+
+static char *copyStatusMessage(OSStatus status) {
+	CFStringRef message = SecCopyErrorMessageString(status, NULL);
+	char *s = copyCString(message);
+	if (message != NULL) {
+		CFRelease(message);
+	}
+	return s;
+}
+
+static char *copyErrorDescription(CFErrorRef err) {
+	CFStringRef description = CFErrorCopyDescription(err);
+	char *s = copyCString(description);
+	CFRelease(description);
+	return s;
+}
+*/
+import "C"
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"unsafe"
+)
+
+// Supported reports whether Keychain identities are supported by this build
+// of notation, which requires macOS and cgo.
+func Supported() bool {
+	return true
+}
+
+// identity holds the private key of a signing identity until it is closed.
+// Signing may prompt the user to allow the access to the key, so signing is
+// serialized.
+type identity struct {
+	mu  sync.Mutex
+	key C.SecKeyRef
+}
+
+// Open finds the signing identity with the name, either the common name of
+// its certificate or the SHA-1 fingerprint of the certificate, in the
+// keychains of the user, and builds its certificate chain.
+func Open(name string) (*Key, error) {
+	var identities []C.SecIdentityRef
+	var certs []*x509.Certificate
+	for _, dataProtection := range []C.Boolean{0, 1} {
+		var status C.OSStatus
+		array := C.copyIdentities(dataProtection, &status)
+		if status == C.errSecItemNotFound {
+			continue
+		}
+		if status != C.errSecSuccess {
+			return nil, statusError("failed to search the signing identities of the Keychain", status)
+		}
+		defer C.CFRelease(C.CFTypeRef(array))
+		for i := C.CFIndex(0); i < C.CFArrayGetCount(array); i++ {
+			id := C.identityAtIndex(array, i)
+			var certRef C.SecCertificateRef
+			if status := C.SecIdentityCopyCertificate(id, &certRef); status != C.errSecSuccess {
+				continue
+			}
+			cert, err := parseCertificate(certRef)
+			C.CFRelease(C.CFTypeRef(certRef))
+			if err != nil {
+				continue
+			}
+			identities = append(identities, id)
+			certs = append(certs, cert)
+		}
+	}
+	i, err := selectIdentity(name, certs)
+	if err != nil {
+		return nil, err
+	}
+	chain, err := certificateChain(identities[i], certs[i])
+	if err != nil {
+		return nil, err
+	}
+	var key C.SecKeyRef
+	if status := C.SecIdentityCopyPrivateKey(identities[i], &key); status != C.errSecSuccess {
+		return nil, statusError(fmt.Sprintf("failed to access the private key of signing identity %q", name), status)
+	}
+	return &Key{
+		identity: &identity{key: key},
+		public:   certs[i].PublicKey,
+		chain:    chain,
+	}, nil
+}
+
+// certificateChain returns the certificate chain of the identity, starting
+// with cert.
+func certificateChain(id C.SecIdentityRef, cert *x509.Certificate) ([]*x509.Certificate, error) {
+	var certRef C.SecCertificateRef
+	if status := C.SecIdentityCopyCertificate(id, &certRef); status != C.errSecSuccess {
+		return nil, statusError("failed to read the certificate of the signing identity", status)
+	}
+	defer C.CFRelease(C.CFTypeRef(certRef))
+	var status C.OSStatus
+	array := C.copyCertificateChain(certRef, &status)
+	if status != C.errSecSuccess {
+		return nil, statusError("failed to build the certificate chain of the signing identity", status)
+	}
+	defer C.CFRelease(C.CFTypeRef(array))
+	chain := []*x509.Certificate{cert}
+	for i := C.CFIndex(1); i < C.CFArrayGetCount(array); i++ {
+		issuer, err := parseCertificate(C.certificateAtIndex(array, i))
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, issuer)
+	}
+	return chain, nil
+}
+
+// parseCertificate parses the DER encoding of the certificate.
+func parseCertificate(certRef C.SecCertificateRef) (*x509.Certificate, error) {
+	data := C.SecCertificateCopyData(certRef)
+	if data == 0 {
+		return nil, errors.New("failed to read the DER encoding of the certificate")
+	}
+	defer C.CFRelease(C.CFTypeRef(data))
+	der := C.GoBytes(unsafe.Pointer(C.CFDataGetBytePtr(data)), C.int(C.CFDataGetLength(data)))
+	return x509.ParseCertificate(der)
+}
+
+// Sign signs digest with the private key of the identity. RSA keys sign with
+// RSASSA-PSS, of which the salt length is the length of the hash, or with
+// RSASSA-PKCS1-v1_5, and ECDSA keys return ASN.1 DER encoded signatures.
+func (k *Key) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	algorithm, err := k.algorithm(opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(digest) != opts.HashFunc().Size() {
+		return nil, fmt.Errorf("digest of %d bytes does not match hash function %v", len(digest), opts.HashFunc())
+	}
+	data := C.CFDataCreate(C.kCFAllocatorDefault, (*C.UInt8)(unsafe.Pointer(&digest[0])), C.CFIndex(len(digest)))
+	defer C.CFRelease(C.CFTypeRef(data))
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.key == 0 {
+		return nil, errors.New("Keychain identity is closed")
+	}
+	var cfErr C.CFErrorRef
+	sig := C.SecKeyCreateSignature(k.key, algorithm, data, &cfErr)
+	if sig == 0 {
+		defer C.CFRelease(C.CFTypeRef(cfErr))
+		description := C.copyErrorDescription(cfErr)
+		defer C.free(unsafe.Pointer(description))
+		return nil, fmt.Errorf("failed to sign with the Keychain identity: %s", C.GoString(description))
+	}
+	defer C.CFRelease(C.CFTypeRef(sig))
+	return C.GoBytes(unsafe.Pointer(C.CFDataGetBytePtr(sig)), C.int(C.CFDataGetLength(sig))), nil
+}
+
+// algorithm returns the signature algorithm of the Security framework signing
+// digests of the hash function of opts with the key.
+func (k *Key) algorithm(opts crypto.SignerOpts) (C.SecKeyAlgorithm, error) {
+	hash := opts.HashFunc()
+	switch k.public.(type) {
+	case *rsa.PublicKey:
+		if pssOpts, ok := opts.(*rsa.PSSOptions); ok {
+			if pssOpts.SaltLength != rsa.PSSSaltLengthEqualsHash && pssOpts.SaltLength != hash.Size() {
+				return 0, fmt.Errorf("unsupported RSASSA-PSS salt length %d, Keychain keys only support salts of the length of the hash", pssOpts.SaltLength)
+			}
+			switch hash {
+			case crypto.SHA256:
+				return C.kSecKeyAlgorithmRSASignatureDigestPSSSHA256, nil
+			case crypto.SHA384:
+				return C.kSecKeyAlgorithmRSASignatureDigestPSSSHA384, nil
+			case crypto.SHA512:
+				return C.kSecKeyAlgorithmRSASignatureDigestPSSSHA512, nil
+			}
+		} else {
+			switch hash {
+			case crypto.SHA256:
+				return C.kSecKeyAlgorithmRSASignatureDigestPKCS1v15SHA256, nil
+			case crypto.SHA384:
+				return C.kSecKeyAlgorithmRSASignatureDigestPKCS1v15SHA384, nil
+			case crypto.SHA512:
+				return C.kSecKeyAlgorithmRSASignatureDigestPKCS1v15SHA512, nil
+			}
+		}
+	case *ecdsa.PublicKey:
+		switch hash {
+		case crypto.SHA256:
+			return C.kSecKeyAlgorithmECDSASignatureDigestX962SHA256, nil
+		case crypto.SHA384:
+			return C.kSecKeyAlgorithmECDSASignatureDigestX962SHA384, nil
+		case crypto.SHA512:
+			return C.kSecKeyAlgorithmECDSASignatureDigestX962SHA512, nil
+		}
+	default:
+		return 0, fmt.Errorf("unsupported public key type %T of the Keychain identity", k.public)
+	}
+	return 0, fmt.Errorf("unsupported hash function %v", hash)
+}
+
+// Close releases the private key of the identity.
+func (k *Key) Close() error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.key != 0 {
+		C.CFRelease(C.CFTypeRef(k.key))
+		k.key = 0
+	}
+	return nil
+}
+
+// statusError returns an error of the message with the description of the
+// status of the Security framework.
+func statusError(message string, status C.OSStatus) error {
+	description := C.copyStatusMessage(status)
+	defer C.free(unsafe.Pointer(description))
+	if description == nil || C.GoString(description) == "" {
+		return fmt.Errorf("%s: OSStatus %d", message, int(status))
+	}
+	return fmt.Errorf("%s: %s", message, C.GoString(description))
+}
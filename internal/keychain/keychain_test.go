@@ -0,0 +1,57 @@
+package keychain
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newCertificate(t *testing.T, commonName string, serial int64) *x509.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func TestSelectIdentity(t *testing.T) {
+	developer := newCertificate(t, "Developer", 1)
+	release := newCertificate(t, "Release", 2)
+	releaseRenewed := newCertificate(t, "Release", 3)
+	// the same identity found in the login and the data protection keychains
+	certs := []*x509.Certificate{developer, release, developer, releaseRenewed}
+
+	if i, err := selectIdentity("Developer", certs); err != nil || i != 0 {
+		t.Fatalf("selectIdentity() = %d, %v, want 0", i, err)
+	}
+	if i, err := selectIdentity(strings.ToLower(fingerprint(releaseRenewed)), certs); err != nil || i != 3 {
+		t.Fatalf("selectIdentity() = %d, %v, want 3", i, err)
+	}
+	if _, err := selectIdentity("Release", certs); err == nil || !strings.Contains(err.Error(), fingerprint(release)) || !strings.Contains(err.Error(), fingerprint(releaseRenewed)) {
+		t.Fatalf("expected ambiguity error listing the fingerprints, got %v", err)
+	}
+	if _, err := selectIdentity("Unknown", certs); err == nil || !strings.Contains(err.Error(), "no signing identity") {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+}
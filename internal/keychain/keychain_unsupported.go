@@ -0,0 +1,34 @@
+//go:build !darwin || !cgo
+
+package keychain
+
+import (
+	"crypto"
+	"io"
+)
+
+// Supported reports whether Keychain identities are supported by this build
+// of notation, which requires macOS and cgo.
+func Supported() bool {
+	return false
+}
+
+// identity is not available without the Security framework.
+type identity struct{}
+
+// Open finds the signing identity with the name, either the common name of
+// its certificate or the SHA-1 fingerprint of the certificate, in the
+// keychains of the user, and builds its certificate chain.
+func Open(name string) (*Key, error) {
+	return nil, ErrUnsupported
+}
+
+// Sign signs digest with the private key of the identity.
+func (k *Key) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	return nil, ErrUnsupported
+}
+
+// Close releases the private key of the identity.
+func (k *Key) Close() error {
+	return nil
+}
@@ -0,0 +1,136 @@
+// Package keyprobe checks signing keys against the signature envelope formats
+// by signing a probe artifact, so that keys incompatible with the formats
+// fail when they are added rather than at the first signing.
+package keyprobe
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/x509"
+	"fmt"
+	"time"
+
+	"github.com/notaryproject/notation-core-go/signature"
+	nx509 "github.com/notaryproject/notation-core-go/x509"
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation/internal/envelope"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// MediaTypeProbe is the media type of the probe artifact signed to check a
+// key. The artifact is a random nonce, so that the probe signature is not the
+// signature of any real artifact.
+const MediaTypeProbe = "application/vnd.cncf.notary.key-probe.v1"
+
+// Formats are the signature envelope formats keys are checked against.
+var Formats = []string{envelope.JWS, envelope.COSE}
+
+// Result is the result of checking a key against an envelope format.
+type Result struct {
+	// Format is the envelope format, e.g. "jws".
+	Format string
+
+	// SignatureAlgorithm is the signature algorithm of the probe signature,
+	// if produced.
+	SignatureAlgorithm signature.Algorithm
+
+	// Certificate is the signing certificate of the probe signature, if
+	// produced.
+	Certificate *x509.Certificate
+
+	// Err is the reason why the key cannot produce signatures of the
+	// envelope format, or nil if it can.
+	Err error
+}
+
+// Probe signs a probe artifact with signer in each of the envelope formats,
+// and checks that the signature is valid and that the certificate chain
+// complies with the certificate requirements of the Notary Project
+// signature specification.
+func Probe(ctx context.Context, signer notation.Signer, pluginConfig map[string]string, formats []string) ([]Result, error) {
+	desc, err := probeDescriptor()
+	if err != nil {
+		return nil, err
+	}
+	results := make([]Result, len(formats))
+	for i, format := range formats {
+		results[i] = probe(ctx, signer, pluginConfig, format, desc)
+	}
+	return results, nil
+}
+
+// Supported returns the envelope formats of the results the key can produce
+// signatures of.
+func Supported(results []Result) []string {
+	var formats []string
+	for _, result := range results {
+		if result.Err == nil {
+			formats = append(formats, result.Format)
+		}
+	}
+	return formats
+}
+
+func probe(ctx context.Context, signer notation.Signer, pluginConfig map[string]string, format string, desc ocispec.Descriptor) Result {
+	result := Result{Format: format}
+	mediaType, err := envelope.GetEnvelopeMediaType(format)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	sig, _, err := signer.Sign(ctx, desc, notation.SignerSignOptions{
+		SignatureMediaType: mediaType,
+		PluginConfig:       pluginConfig,
+	})
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	sigEnv, err := signature.ParseEnvelope(mediaType, sig)
+	if err != nil {
+		result.Err = fmt.Errorf("invalid signature envelope: %w", err)
+		return result
+	}
+	content, err := sigEnv.Verify()
+	if err != nil {
+		result.Err = fmt.Errorf("invalid signature: %w", err)
+		return result
+	}
+	signerInfo := content.SignerInfo
+	result.SignatureAlgorithm = signerInfo.SignatureAlgorithm
+	if len(signerInfo.CertificateChain) > 0 {
+		result.Certificate = signerInfo.CertificateChain[0]
+	}
+	signedDesc, err := envelope.DescriptorFromSignaturePayload(&content.Payload)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	if signedDesc.Digest != desc.Digest || signedDesc.Size != desc.Size {
+		result.Err = fmt.Errorf("the signature is of artifact %s instead of the probe artifact %s", signedDesc.Digest, desc.Digest)
+		return result
+	}
+	signingTime := signerInfo.SignedAttributes.SigningTime
+	if signingTime.IsZero() {
+		signingTime = time.Now()
+	}
+	if err := nx509.ValidateCodeSigningCertChain(signerInfo.CertificateChain, &signingTime); err != nil {
+		result.Err = fmt.Errorf("invalid certificate chain: %w", err)
+		return result
+	}
+	return result
+}
+
+// probeDescriptor returns the descriptor of a random probe artifact.
+func probeDescriptor() (ocispec.Descriptor, error) {
+	nonce := make([]byte, 32)
+	if _, err := rand.Read(nonce); err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	return ocispec.Descriptor{
+		MediaType: MediaTypeProbe,
+		Digest:    digest.FromBytes(nonce),
+		Size:      int64(len(nonce)),
+	}, nil
+}
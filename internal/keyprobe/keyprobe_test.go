@@ -0,0 +1,117 @@
+package keyprobe
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/notation-core-go/signature"
+	"github.com/notaryproject/notation-core-go/signature/cose"
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation/internal/localsigner"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// newSigner returns a signer of a self-signed certificate of template.
+func newSigner(t *testing.T, template *x509.Certificate) notation.Signer {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template.SerialNumber = big.NewInt(1)
+	template.Subject = pkix.Name{CommonName: "test", Organization: []string{"Notary"}, Country: []string{"US"}, Province: []string{"WA"}, Locality: []string{"Seattle"}}
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+	template.BasicConstraintsValid = true
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(certDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := localsigner.New(key, []*x509.Certificate{cert})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// noCOSESigner fails signing in the COSE envelope format.
+type noCOSESigner struct {
+	notation.Signer
+}
+
+func (s noCOSESigner) Sign(ctx context.Context, desc ocispec.Descriptor, opts notation.SignerSignOptions) ([]byte, *signature.SignerInfo, error) {
+	if opts.SignatureMediaType == cose.MediaTypeEnvelope {
+		return nil, nil, errors.New("unsupported envelope format")
+	}
+	return s.Signer.Sign(ctx, desc, opts)
+}
+
+func TestProbe(t *testing.T) {
+	s := newSigner(t, &x509.Certificate{
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	})
+	results, err := Probe(context.Background(), s, nil, Formats)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, result := range results {
+		if result.Err != nil {
+			t.Fatalf("format %s: unexpected error %v", result.Format, result.Err)
+		}
+		if result.SignatureAlgorithm != signature.AlgorithmES256 {
+			t.Fatalf("format %s: signature algorithm %v, want %v", result.Format, result.SignatureAlgorithm, signature.AlgorithmES256)
+		}
+		if result.Certificate == nil || result.Certificate.Subject.CommonName != "test" {
+			t.Fatalf("format %s: unexpected certificate %v", result.Format, result.Certificate)
+		}
+	}
+	if got := Supported(results); !reflect.DeepEqual(got, Formats) {
+		t.Fatalf("Supported() = %v, want %v", got, Formats)
+	}
+}
+
+func TestProbe_UnsupportedFormat(t *testing.T) {
+	s := newSigner(t, &x509.Certificate{
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	})
+	results, err := Probe(context.Background(), noCOSESigner{s}, nil, Formats)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := Supported(results); !reflect.DeepEqual(got, []string{"jws"}) {
+		t.Fatalf("Supported() = %v, want [jws]", got)
+	}
+	if results[1].Err == nil {
+		t.Fatal("expected error for COSE")
+	}
+}
+
+func TestProbe_InvalidCertificateProfile(t *testing.T) {
+	// certificates for server authentication cannot sign artifacts
+	s := newSigner(t, &x509.Certificate{
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	results, err := Probe(context.Background(), s, nil, Formats)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := Supported(results); len(got) != 0 {
+		t.Fatalf("Supported() = %v, want none", got)
+	}
+}
@@ -0,0 +1,539 @@
+//go:build !nokms && !nokms_awskms
+
+package kms
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// awsCredentials are the credentials of AWS requests.
+type awsCredentials struct {
+	accessKeyID     string
+	secretAccessKey string
+	sessionToken    string
+}
+
+// awsConfig is the configuration of AWS requests, resolved from the
+// environment and the shared configuration files by a subset of the default
+// credential chain of the AWS SDKs.
+type awsConfig struct {
+	region      string
+	credentials awsCredentials
+}
+
+// imdsTimeout bounds the requests to the instance metadata service, which is
+// not reachable outside of EC2.
+const imdsTimeout = time.Second
+
+// maxSourceProfileDepth bounds the chain of source profiles of roles.
+const maxSourceProfileDepth = 5
+
+// loadAWSConfig resolves the region and the credentials of AWS requests. The
+// region is region if not empty, e.g. the region of a key ARN, or the region
+// of the environment variable AWS_REGION or AWS_DEFAULT_REGION, or of the
+// profile, and is required. The credentials are resolved by the following
+// subset of the default credential chain of the AWS SDKs, in order:
+//
+//  1. the environment variables AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and
+//     AWS_SESSION_TOKEN;
+//  2. the profile of the environment variable AWS_PROFILE, or the default
+//     profile, in the shared credentials and config files: static
+//     credentials, roles assumed with a source profile, a credential source
+//     or a web identity token file, or a credential process;
+//  3. the web identity token file of the environment variable
+//     AWS_WEB_IDENTITY_TOKEN_FILE for the role of AWS_ROLE_ARN, e.g. on EKS;
+//  4. the credentials endpoint of ECS containers;
+//  5. the instance metadata service of EC2 with IMDSv2.
+//
+// Profiles of IAM Identity Center (SSO) and roles requiring an MFA token code
+// are rejected, as they need an interactive login which the SDKs prompt for.
+func loadAWSConfig(ctx context.Context, region string) (*awsConfig, error) {
+	profiles, err := loadAWSProfiles()
+	if err != nil {
+		return nil, err
+	}
+	profileName, explicitProfile := os.LookupEnv("AWS_PROFILE")
+	if !explicitProfile {
+		profileName = "default"
+	}
+	profile, ok := profiles[profileName]
+	if !ok && explicitProfile {
+		return nil, fmt.Errorf("AWS profile %q not found in the shared config and credentials files", profileName)
+	}
+
+	cfg := &awsConfig{region: region}
+	if cfg.region == "" {
+		cfg.region = firstEnv("AWS_REGION", "AWS_DEFAULT_REGION")
+	}
+	if cfg.region == "" {
+		cfg.region = profile["region"]
+	}
+	if cfg.region == "" {
+		return nil, fmt.Errorf("AWS region not found, set the environment variable AWS_REGION or the region of AWS profile %q", profileName)
+	}
+
+	if credentials, ok := awsEnvCredentials(); ok {
+		cfg.credentials = credentials
+		return cfg, nil
+	}
+	if profile != nil {
+		if cfg.credentials, err = profileCredentials(ctx, cfg.region, profiles, profileName, 0); err != nil {
+			return nil, err
+		}
+		if cfg.credentials.accessKeyID != "" {
+			return cfg, nil
+		}
+	}
+	if tokenFile := os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE"); tokenFile != "" {
+		if cfg.credentials, err = assumeRoleWithWebIdentity(ctx, cfg.region, os.Getenv("AWS_ROLE_ARN"), os.Getenv("AWS_ROLE_SESSION_NAME"), tokenFile); err != nil {
+			return nil, err
+		}
+		return cfg, nil
+	}
+	if ecsCredentialsConfigured() {
+		if cfg.credentials, err = ecsCredentials(ctx); err != nil {
+			return nil, err
+		}
+		return cfg, nil
+	}
+	if !strings.EqualFold(os.Getenv("AWS_EC2_METADATA_DISABLED"), "true") {
+		if cfg.credentials, err = imdsCredentials(ctx); err == nil {
+			return cfg, nil
+		}
+	}
+	return nil, errors.New("AWS credentials not found, set the environment variables AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY, configure a profile in the shared credentials file, or run with an IAM role")
+}
+
+// awsEnvCredentials returns the credentials of the environment variables, if
+// set.
+func awsEnvCredentials() (awsCredentials, bool) {
+	credentials := awsCredentials{
+		accessKeyID:     firstEnv("AWS_ACCESS_KEY_ID", "AWS_ACCESS_KEY"),
+		secretAccessKey: firstEnv("AWS_SECRET_ACCESS_KEY", "AWS_SECRET_KEY"),
+		sessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	return credentials, credentials.accessKeyID != "" && credentials.secretAccessKey != ""
+}
+
+// profileCredentials returns the credentials of the profile with the name,
+// which are empty if the profile has none, so that the next provider of the
+// chain is tried.
+func profileCredentials(ctx context.Context, region string, profiles map[string]map[string]string, name string, depth int) (awsCredentials, error) {
+	profile, ok := profiles[name]
+	if !ok {
+		return awsCredentials{}, fmt.Errorf("AWS profile %q not found in the shared config and credentials files", name)
+	}
+	if depth > maxSourceProfileDepth {
+		return awsCredentials{}, fmt.Errorf("too many source profiles of AWS profile %q", name)
+	}
+	if roleARN := profile["role_arn"]; roleARN != "" {
+		if profile["mfa_serial"] != "" {
+			return awsCredentials{}, fmt.Errorf("AWS profile %q of role %s requires an MFA token code, which is not supported, set credential_process = aws configure export-credentials --profile %s --format process in another profile instead", name, roleARN, name)
+		}
+		if tokenFile := profile["web_identity_token_file"]; tokenFile != "" {
+			return assumeRoleWithWebIdentity(ctx, region, roleARN, profile["role_session_name"], tokenFile)
+		}
+		var source awsCredentials
+		var err error
+		switch {
+		case profile["source_profile"] == name:
+			// a role of its own static credentials
+			source = awsCredentials{
+				accessKeyID:     profile["aws_access_key_id"],
+				secretAccessKey: profile["aws_secret_access_key"],
+				sessionToken:    profile["aws_session_token"],
+			}
+		case profile["source_profile"] != "":
+			source, err = profileCredentials(ctx, region, profiles, profile["source_profile"], depth+1)
+		case profile["credential_source"] == "Environment":
+			source, _ = awsEnvCredentials()
+		case profile["credential_source"] == "EcsContainer":
+			source, err = ecsCredentials(ctx)
+		case profile["credential_source"] == "Ec2InstanceMetadata":
+			source, err = imdsCredentials(ctx)
+		default:
+			return awsCredentials{}, fmt.Errorf("AWS profile %q of role %s has neither a source profile nor a credential source", name, roleARN)
+		}
+		if err != nil {
+			return awsCredentials{}, err
+		}
+		if source.accessKeyID == "" {
+			return awsCredentials{}, fmt.Errorf("no source credentials of AWS profile %q to assume role %s", name, roleARN)
+		}
+		return assumeRole(ctx, region, source, roleARN, profile["role_session_name"], profile["external_id"])
+	}
+	if profile["aws_access_key_id"] != "" && profile["aws_secret_access_key"] != "" {
+		return awsCredentials{
+			accessKeyID:     profile["aws_access_key_id"],
+			secretAccessKey: profile["aws_secret_access_key"],
+			sessionToken:    profile["aws_session_token"],
+		}, nil
+	}
+	if process := profile["credential_process"]; process != "" {
+		return processCredentials(ctx, process)
+	}
+	if profile["sso_session"] != "" || profile["sso_start_url"] != "" {
+		return awsCredentials{}, fmt.Errorf("IAM Identity Center profile %q is not supported, set credential_process = aws configure export-credentials --profile %s --format process in another profile instead", name, name)
+	}
+	return awsCredentials{}, nil
+}
+
+// loadAWSProfiles loads the profiles of the shared config file of the
+// environment variable AWS_CONFIG_FILE or ~/.aws/config, overridden by the
+// profiles of the shared credentials file of AWS_SHARED_CREDENTIALS_FILE or
+// ~/.aws/credentials. Missing files have no profiles.
+func loadAWSProfiles() (map[string]map[string]string, error) {
+	home, _ := os.UserHomeDir()
+	configFile := os.Getenv("AWS_CONFIG_FILE")
+	if configFile == "" {
+		configFile = filepath.Join(home, ".aws", "config")
+	}
+	credentialsFile := os.Getenv("AWS_SHARED_CREDENTIALS_FILE")
+	if credentialsFile == "" {
+		credentialsFile = filepath.Join(home, ".aws", "credentials")
+	}
+
+	profiles := make(map[string]map[string]string)
+	sections, err := parseINIFile(configFile)
+	if err != nil {
+		return nil, err
+	}
+	for section, values := range sections {
+		// profiles other than the default one are prefixed in the config
+		// file
+		name, ok := strings.CutPrefix(section, "profile ")
+		if !ok && section != "default" {
+			continue
+		}
+		profiles[strings.TrimSpace(name)] = values
+	}
+	if sections, err = parseINIFile(credentialsFile); err != nil {
+		return nil, err
+	}
+	for name, values := range sections {
+		profile, ok := profiles[name]
+		if !ok {
+			profile = make(map[string]string)
+			profiles[name] = profile
+		}
+		for key, value := range values {
+			profile[key] = value
+		}
+	}
+	return profiles, nil
+}
+
+// parseINIFile parses the sections of the INI file at path, which has no
+// sections if it does not exist.
+func parseINIFile(path string) (map[string]map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+	sections := make(map[string]map[string]string)
+	var section map[string]string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		if line[0] == '[' && line[len(line)-1] == ']' {
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			if section = sections[name]; section == nil {
+				section = make(map[string]string)
+				sections[name] = section
+			}
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok || section == nil {
+			// nested values, e.g. of s3 settings, are not used
+			continue
+		}
+		section[strings.ToLower(strings.TrimSpace(key))] = strings.TrimSpace(value)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return sections, nil
+}
+
+// processCredentials runs the credential process command and returns the
+// credentials of its output. The command is run by the shell as the AWS SDKs
+// do, so that quoted arguments are preserved.
+func processCredentials(ctx context.Context, command string) (awsCredentials, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd.exe", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return awsCredentials{}, fmt.Errorf("credential process %q failed: %w", command, err)
+	}
+	var output struct {
+		Version         int
+		AccessKeyID     string `json:"AccessKeyId"`
+		SecretAccessKey string
+		SessionToken    string
+	}
+	if err := json.Unmarshal(out, &output); err != nil {
+		return awsCredentials{}, fmt.Errorf("malformed output of credential process %q: %w", command, err)
+	}
+	if output.Version != 1 || output.AccessKeyID == "" || output.SecretAccessKey == "" {
+		return awsCredentials{}, fmt.Errorf("credential process %q returned no credentials of version 1", command)
+	}
+	return awsCredentials{
+		accessKeyID:     output.AccessKeyID,
+		secretAccessKey: output.SecretAccessKey,
+		sessionToken:    output.SessionToken,
+	}, nil
+}
+
+// stsResponse is the response of the STS actions assuming roles.
+type stsResponse struct {
+	Result struct {
+		Credentials struct {
+			AccessKeyID     string `xml:"AccessKeyId"`
+			SecretAccessKey string
+			SessionToken    string
+		}
+	} `xml:",any"`
+}
+
+// assumeRoleWithWebIdentity assumes the role with the web identity token in
+// tokenFile.
+func assumeRoleWithWebIdentity(ctx context.Context, region, roleARN, sessionName, tokenFile string) (awsCredentials, error) {
+	if roleARN == "" {
+		return awsCredentials{}, fmt.Errorf("role ARN of web identity token file %s is not set", tokenFile)
+	}
+	token, err := os.ReadFile(tokenFile)
+	if err != nil {
+		return awsCredentials{}, fmt.Errorf("failed to read the web identity token: %w", err)
+	}
+	return callSTS(ctx, region, nil, url.Values{
+		"Action":           {"AssumeRoleWithWebIdentity"},
+		"RoleArn":          {roleARN},
+		"RoleSessionName":  {roleSessionName(sessionName)},
+		"WebIdentityToken": {strings.TrimSpace(string(token))},
+	})
+}
+
+// assumeRole assumes the role with the source credentials.
+func assumeRole(ctx context.Context, region string, source awsCredentials, roleARN, sessionName, externalID string) (awsCredentials, error) {
+	params := url.Values{
+		"Action":          {"AssumeRole"},
+		"RoleArn":         {roleARN},
+		"RoleSessionName": {roleSessionName(sessionName)},
+	}
+	if externalID != "" {
+		params.Set("ExternalId", externalID)
+	}
+	return callSTS(ctx, region, &source, params)
+}
+
+// roleSessionName returns name, or a name of the current time if empty, as
+// the AWS SDKs do.
+func roleSessionName(name string) string {
+	if name != "" {
+		return name
+	}
+	return fmt.Sprintf("notation-%d", time.Now().UnixNano())
+}
+
+// callSTS calls the action of params of the STS query API, signed with the
+// credentials if not nil, and returns the credentials of the assumed role.
+func callSTS(ctx context.Context, region string, credentials *awsCredentials, params url.Values) (awsCredentials, error) {
+	params.Set("Version", "2011-06-15")
+	endpoint := awsEndpoint("sts", region)
+	body := []byte(params.Encode())
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(string(body)))
+	if err != nil {
+		return awsCredentials{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	if credentials != nil {
+		signAWSRequest(req, body, *credentials, region, "sts", time.Now())
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return awsCredentials{}, err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return awsCredentials{}, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return awsCredentials{}, fmt.Errorf("%s of role %s failed: %s: %s", params.Get("Action"), params.Get("RoleArn"), resp.Status, strings.TrimSpace(string(respBody)))
+	}
+	var result stsResponse
+	if err := xml.Unmarshal(respBody, &result); err != nil {
+		return awsCredentials{}, fmt.Errorf("malformed response of %s: %w", params.Get("Action"), err)
+	}
+	credentialsOut := result.Result.Credentials
+	if credentialsOut.AccessKeyID == "" {
+		return awsCredentials{}, fmt.Errorf("%s of role %s returned no credentials", params.Get("Action"), params.Get("RoleArn"))
+	}
+	return awsCredentials{
+		accessKeyID:     credentialsOut.AccessKeyID,
+		secretAccessKey: credentialsOut.SecretAccessKey,
+		sessionToken:    credentialsOut.SessionToken,
+	}, nil
+}
+
+// ecsCredentialsConfigured returns true if the credentials endpoint of ECS
+// containers is configured.
+func ecsCredentialsConfigured() bool {
+	return os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI") != "" || os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI") != ""
+}
+
+// ecsCredentials returns the credentials of the task role from the
+// credentials endpoint of ECS containers.
+func ecsCredentials(ctx context.Context) (awsCredentials, error) {
+	endpoint := os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI")
+	if relative := os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI"); relative != "" {
+		endpoint = "http://169.254.170.2" + relative
+	}
+	if endpoint == "" {
+		return awsCredentials{}, errors.New("credentials endpoint of ECS containers is not set")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return awsCredentials{}, err
+	}
+	token := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN")
+	if tokenFile := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE"); tokenFile != "" {
+		tokenBytes, err := os.ReadFile(tokenFile)
+		if err != nil {
+			return awsCredentials{}, fmt.Errorf("failed to read the authorization token of the ECS credentials endpoint: %w", err)
+		}
+		token = strings.TrimSpace(string(tokenBytes))
+	}
+	if token != "" {
+		req.Header.Set("Authorization", token)
+	}
+	return fetchRoleCredentials(req)
+}
+
+// imdsCredentials returns the credentials of the role of the EC2 instance from
+// the instance metadata service with IMDSv2. The endpoint is overridden by
+// the environment variable AWS_EC2_METADATA_SERVICE_ENDPOINT.
+func imdsCredentials(ctx context.Context) (awsCredentials, error) {
+	endpoint := strings.TrimSuffix(os.Getenv("AWS_EC2_METADATA_SERVICE_ENDPOINT"), "/")
+	if endpoint == "" {
+		endpoint = "http://169.254.169.254"
+	}
+	ctx, cancel := context.WithTimeout(ctx, imdsTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, endpoint+"/latest/api/token", nil)
+	if err != nil {
+		return awsCredentials{}, err
+	}
+	req.Header.Set("X-Aws-Ec2-Metadata-Token-Ttl-Seconds", "21600")
+	token, err := fetchText(req)
+	if err != nil {
+		return awsCredentials{}, fmt.Errorf("instance metadata service not available: %w", err)
+	}
+	get := func(path string) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+path, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("X-Aws-Ec2-Metadata-Token", token)
+		return req, nil
+	}
+	const credentialsPath = "/latest/meta-data/iam/security-credentials/"
+	if req, err = get(credentialsPath); err != nil {
+		return awsCredentials{}, err
+	}
+	role, err := fetchText(req)
+	if err != nil {
+		return awsCredentials{}, fmt.Errorf("no IAM role of the EC2 instance: %w", err)
+	}
+	role, _, _ = strings.Cut(strings.TrimSpace(role), "\n")
+	if req, err = get(credentialsPath + role); err != nil {
+		return awsCredentials{}, err
+	}
+	return fetchRoleCredentials(req)
+}
+
+// fetchRoleCredentials fetches the credentials of a role in the JSON format of
+// the ECS credentials endpoint and the instance metadata service.
+func fetchRoleCredentials(req *http.Request) (awsCredentials, error) {
+	var resp struct {
+		AccessKeyID     string `json:"AccessKeyId"`
+		SecretAccessKey string
+		Token           string
+	}
+	if err := doJSON(req, &resp); err != nil {
+		return awsCredentials{}, err
+	}
+	if resp.AccessKeyID == "" || resp.SecretAccessKey == "" {
+		return awsCredentials{}, fmt.Errorf("no credentials returned by %s", req.URL.Redacted())
+	}
+	return awsCredentials{
+		accessKeyID:     resp.AccessKeyID,
+		secretAccessKey: resp.SecretAccessKey,
+		sessionToken:    resp.Token,
+	}, nil
+}
+
+// fetchText sends req and returns the text of the response body.
+func fetchText(req *http.Request) (string, error) {
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s %s: %s", req.Method, req.URL.Redacted(), resp.Status)
+	}
+	return string(body), nil
+}
+
+// awsEndpoint returns the endpoint of the AWS service in region, overridden
+// by the environment variable AWS_ENDPOINT_URL_<SERVICE> or AWS_ENDPOINT_URL
+// as the AWS SDKs do, e.g. for VPC endpoints or local emulators.
+func awsEndpoint(service, region string) string {
+	if endpoint := firstEnv("AWS_ENDPOINT_URL_"+strings.ToUpper(service), "AWS_ENDPOINT_URL"); endpoint != "" {
+		return strings.TrimSuffix(endpoint, "/") + "/"
+	}
+	return fmt.Sprintf("https://%s.%s.amazonaws.com/", service, region)
+}
+
+// firstEnv returns the value of the first environment variable of names set.
+func firstEnv(names ...string) string {
+	for _, name := range names {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,331 @@
+//go:build !nokms && !nokms_awskms
+
+package kms
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+func init() {
+	register("awskms", openAWSKMS)
+	registerCertificateSource("s3", fetchS3Object)
+	registerCertificateSource("ssm", fetchSSMParameter)
+}
+
+// awsKMSKey is a key in AWS KMS.
+type awsKMSKey struct {
+	keyID       string
+	endpoint    string
+	region      string
+	credentials awsCredentials
+	public      crypto.PublicKey
+}
+
+// openAWSKMS opens the key of a URI in the format of
+// "awskms://[endpoint]/{key_id|alias/name|arn}", e.g.
+// "awskms:///alias/release". The region is the region of the key ARN, or the
+// region resolved by loadAWSConfig, and the credentials are resolved by the
+// default credential chain of the AWS SDKs. The endpoint is the endpoint of
+// the URI, or of the environment variable AWS_ENDPOINT_URL_KMS.
+func openAWSKMS(ctx context.Context, uri *url.URL) (crypto.Signer, error) {
+	key := &awsKMSKey{keyID: strings.TrimPrefix(uri.Path, "/")}
+	if key.keyID == "" {
+		return nil, fmt.Errorf("missing key ID in AWS KMS key URI %q, expecting awskms://[endpoint]/{key_id|alias/name|arn}", uri)
+	}
+	var region string
+	if arn := strings.Split(key.keyID, ":"); len(arn) >= 6 && arn[0] == "arn" {
+		region = arn[3]
+	}
+	cfg, err := loadAWSConfig(ctx, region)
+	if err != nil {
+		return nil, fmt.Errorf("AWS KMS key %q: %w", key.keyID, err)
+	}
+	key.region = cfg.region
+	key.credentials = cfg.credentials
+	key.endpoint = awsEndpoint("kms", key.region)
+	if uri.Host != "" {
+		key.endpoint = "https://" + uri.Host + "/"
+	}
+
+	var resp struct {
+		PublicKey []byte
+		KeyUsage  string
+	}
+	if err := key.call(ctx, "GetPublicKey", map[string]any{"KeyId": key.keyID}, &resp); err != nil {
+		return nil, fmt.Errorf("failed to get the public key of AWS KMS key %q: %w", key.keyID, err)
+	}
+	if resp.KeyUsage != "" && resp.KeyUsage != "SIGN_VERIFY" {
+		return nil, fmt.Errorf("AWS KMS key %q of key usage %s cannot sign, expecting SIGN_VERIFY", key.keyID, resp.KeyUsage)
+	}
+	public, err := x509.ParsePKIXPublicKey(resp.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the public key of AWS KMS key %q: %w", key.keyID, err)
+	}
+	switch public.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey:
+		key.public = public
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T of AWS KMS key %q, expecting an RSA or ECDSA key", public, key.keyID)
+	}
+	return key, nil
+}
+
+// Public returns the public key of the key.
+func (k *awsKMSKey) Public() crypto.PublicKey {
+	return k.public
+}
+
+// Sign signs digest with the key in AWS KMS.
+func (k *awsKMSKey) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	hash, err := checkSignerOpts(k.public, digest, opts)
+	if err != nil {
+		return nil, err
+	}
+	bits := strings.TrimPrefix(hash.String(), "SHA-")
+	algorithm := "ECDSA_SHA_" + bits
+	if _, ok := k.public.(*rsa.PublicKey); ok {
+		algorithm = "RSASSA_PSS_SHA_" + bits
+	}
+	var resp struct {
+		Signature []byte
+	}
+	if err := k.call(context.Background(), "Sign", map[string]any{
+		"KeyId":            k.keyID,
+		"Message":          digest,
+		"MessageType":      "DIGEST",
+		"SigningAlgorithm": algorithm,
+	}, &resp); err != nil {
+		return nil, fmt.Errorf("failed to sign with AWS KMS key %q: %w", k.keyID, err)
+	}
+	// ECDSA signatures are returned in ASN.1 DER
+	return resp.Signature, nil
+}
+
+// call calls the action of the AWS KMS API with the JSON input, decoding the
+// JSON output into v. Binary fields are base64-encoded in JSON, as []byte.
+func (k *awsKMSKey) call(ctx context.Context, action string, input map[string]any, v any) error {
+	body, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, k.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "TrentService."+action)
+	signAWSRequest(req, body, k.credentials, k.region, "kms", time.Now())
+	return doJSON(req, v)
+}
+
+// describe returns the properties of the key of DescribeKey.
+func (k *awsKMSKey) describe(ctx context.Context) ([]Property, error) {
+	var resp struct {
+		KeyMetadata struct {
+			Arn               string
+			KeySpec           string
+			KeyUsage          string
+			KeyState          string
+			SigningAlgorithms []string
+			Origin            string
+			CreationDate      float64
+			Description       string
+		}
+	}
+	if err := k.call(ctx, "DescribeKey", map[string]any{"KeyId": k.keyID}, &resp); err != nil {
+		return nil, fmt.Errorf("failed to describe AWS KMS key %q: %w", k.keyID, err)
+	}
+	metadata := resp.KeyMetadata
+	var created string
+	if metadata.CreationDate != 0 {
+		// timestamps are seconds since epoch in JSON
+		created = time.Unix(int64(metadata.CreationDate), 0).UTC().Format(time.RFC3339)
+	}
+	var properties []Property
+	for _, property := range []Property{
+		{Name: "Key ARN", Value: metadata.Arn},
+		{Name: "Key spec", Value: metadata.KeySpec},
+		{Name: "Key usage", Value: metadata.KeyUsage},
+		{Name: "Key state", Value: metadata.KeyState},
+		{Name: "Signing algorithms", Value: strings.Join(metadata.SigningAlgorithms, ", ")},
+		{Name: "Origin", Value: metadata.Origin},
+		{Name: "Creation date", Value: created},
+		{Name: "Description", Value: metadata.Description},
+	} {
+		if property.Value != "" {
+			properties = append(properties, property)
+		}
+	}
+	return properties, nil
+}
+
+// fetchS3Object fetches the object of a location in the format of
+// "s3://bucket/key" with GetObject. Objects are addressed in the
+// virtual-hosted style, or in the path style of the endpoint of the
+// environment variable AWS_ENDPOINT_URL_S3.
+func fetchS3Object(ctx context.Context, location *url.URL) ([]byte, error) {
+	bucket, key := location.Host, strings.TrimPrefix(location.Path, "/")
+	if bucket == "" || key == "" {
+		return nil, fmt.Errorf("invalid S3 location %q, expecting s3://bucket/key", location)
+	}
+	cfg, err := loadAWSConfig(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	objectURL := fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", bucket, cfg.region, key)
+	if endpoint := firstEnv("AWS_ENDPOINT_URL_S3", "AWS_ENDPOINT_URL"); endpoint != "" {
+		objectURL = strings.TrimSuffix(endpoint, "/") + "/" + bucket + "/" + key
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, objectURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	// S3 requires the hash of the payload, which is empty
+	emptyHash := sha256.Sum256(nil)
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(emptyHash[:]))
+	signAWSRequest(req, nil, cfg.credentials, cfg.region, "s3", time.Now())
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GetObject %s: %s: %s", location, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
+
+// fetchSSMParameter fetches the value of the parameter of a location in the
+// format of "ssm://name" or "ssm:///path/name" with GetParameter, decrypting
+// SecureString parameters.
+func fetchSSMParameter(ctx context.Context, location *url.URL) ([]byte, error) {
+	name := location.Host + location.Path
+	if name == "" {
+		return nil, fmt.Errorf("invalid SSM location %q, expecting ssm://name or ssm:///path/name", location)
+	}
+	cfg, err := loadAWSConfig(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(map[string]any{"Name": name, "WithDecryption": true})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, awsEndpoint("ssm", cfg.region), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "AmazonSSM.GetParameter")
+	signAWSRequest(req, body, cfg.credentials, cfg.region, "ssm", time.Now())
+	var resp struct {
+		Parameter struct {
+			Value string
+		}
+	}
+	if err := doJSON(req, &resp); err != nil {
+		return nil, fmt.Errorf("GetParameter %s: %w", name, err)
+	}
+	return []byte(resp.Parameter.Value), nil
+}
+
+// signAWSRequest signs req with body by AWS Signature Version 4, signing the
+// headers Host, Content-Type and X-Amz-*.
+func signAWSRequest(req *http.Request, body []byte, credentials awsCredentials, region, service string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	if credentials.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", credentials.sessionToken)
+	}
+	bodyHash := sha256.Sum256(body)
+
+	// canonical request
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		if name := strings.ToLower(name); name == "content-type" || strings.HasPrefix(name, "x-amz-") {
+			headers[name] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	query := req.URL.Query()
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var canonicalQuery []string
+	for _, key := range keys {
+		values := query[key]
+		sort.Strings(values)
+		for _, value := range values {
+			canonicalQuery = append(canonicalQuery, awsEscape(key)+"="+awsEscape(value))
+		}
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		strings.Join(canonicalQuery, "&"),
+		canonicalHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(bodyHash[:]),
+	}, "\n")
+
+	// string to sign
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	canonicalHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(canonicalHash[:])
+
+	// signature
+	signingKey := hmacSHA256([]byte("AWS4"+credentials.secretAccessKey), date)
+	signingKey = hmacSHA256(signingKey, region)
+	signingKey = hmacSHA256(signingKey, service)
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", credentials.accessKeyID, scope, signedHeaders, signature))
+}
+
+// awsEscape escapes s as URI encoded by AWS Signature Version 4.
+func awsEscape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+// hmacSHA256 returns the HMAC-SHA256 of data with key.
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
@@ -0,0 +1,405 @@
+//go:build !nokms && !nokms_awskms
+
+package kms
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignAWSRequest(t *testing.T) {
+	// example of the AWS Signature Version 4 documentation
+	req, err := http.NewRequest(http.MethodGet, "https://iam.amazonaws.com/?Action=ListUsers&Version=2010-05-08", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	credentials := awsCredentials{accessKeyID: "AKIDEXAMPLE", secretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+	signAWSRequest(req, nil, credentials, "us-east-1", "iam", time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/iam/aws4_request, SignedHeaders=content-type;host;x-amz-date, Signature=5d672d79c15b13162d9279b0855cfba6789a8edb4c82c400e06b5924a6f2b5d7"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Fatalf("Authorization = %q, want %q", got, want)
+	}
+}
+
+func TestOpenAWSKMS(t *testing.T) {
+	for _, privateKey := range []crypto.Signer{mustGenerateRSAKey(t), mustGenerateECKey(t)} {
+		publicKey, err := x509.MarshalPKIXPublicKey(privateKey.Public())
+		if err != nil {
+			t.Fatal(err)
+		}
+		server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/") {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			var input struct {
+				KeyId            string
+				Message          []byte
+				SigningAlgorithm string
+			}
+			if err := json.NewDecoder(r.Body).Decode(&input); err != nil || input.KeyId != "alias/release" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			switch r.Header.Get("X-Amz-Target") {
+			case "TrentService.GetPublicKey":
+				json.NewEncoder(w).Encode(map[string]any{"PublicKey": publicKey, "KeyUsage": "SIGN_VERIFY"})
+			case "TrentService.Sign":
+				if input.SigningAlgorithm != "RSASSA_PSS_SHA_256" && input.SigningAlgorithm != "ECDSA_SHA_256" {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				json.NewEncoder(w).Encode(map[string]any{"Signature": signDigest(t, privateKey, input.Message)})
+			default:
+				w.WriteHeader(http.StatusBadRequest)
+			}
+		})
+		setAWSTestEnv(t, "", "")
+		t.Setenv("AWS_REGION", "us-east-1")
+		t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+		t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+		key, err := Open(context.Background(), "awskms://"+server.Listener.Addr().String()+"/alias/release")
+		if err != nil {
+			t.Fatalf("Open() error = %v", err)
+		}
+		testSign(t, key)
+	}
+}
+
+func TestOpenAWSKMS_MissingRegion(t *testing.T) {
+	setAWSTestEnv(t, "", "")
+	if _, err := Open(context.Background(), "awskms:///alias/release"); err == nil || !strings.Contains(err.Error(), "region") {
+		t.Fatalf("Open() expects region error, got %v", err)
+	}
+}
+
+// setAWSTestEnv isolates the test from the AWS environment of the host, with
+// the shared config and credentials files of the test.
+func setAWSTestEnv(t *testing.T, config, credentials string) {
+	t.Helper()
+	for _, name := range []string{
+		"AWS_PROFILE", "AWS_REGION", "AWS_DEFAULT_REGION",
+		"AWS_ACCESS_KEY_ID", "AWS_ACCESS_KEY", "AWS_SECRET_ACCESS_KEY", "AWS_SECRET_KEY", "AWS_SESSION_TOKEN",
+		"AWS_WEB_IDENTITY_TOKEN_FILE", "AWS_ROLE_ARN", "AWS_ROLE_SESSION_NAME",
+		"AWS_CONTAINER_CREDENTIALS_RELATIVE_URI", "AWS_CONTAINER_CREDENTIALS_FULL_URI",
+		"AWS_CONTAINER_AUTHORIZATION_TOKEN", "AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE",
+		"AWS_ENDPOINT_URL", "AWS_ENDPOINT_URL_STS", "AWS_ENDPOINT_URL_S3", "AWS_ENDPOINT_URL_SSM",
+	} {
+		t.Setenv(name, "")
+		os.Unsetenv(name)
+	}
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config")
+	credentialsFile := filepath.Join(dir, "credentials")
+	if err := os.WriteFile(configFile, []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(credentialsFile, []byte(credentials), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("AWS_CONFIG_FILE", configFile)
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", credentialsFile)
+}
+
+// stsCredentialsResponse is a response of the STS action assuming a role.
+const stsCredentialsResponse = `<%[1]sResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <%[1]sResult>
+    <Credentials>
+      <AccessKeyId>ASIAROLE</AccessKeyId>
+      <SecretAccessKey>role-secret</SecretAccessKey>
+      <SessionToken>role-token</SessionToken>
+    </Credentials>
+  </%[1]sResult>
+  <ResponseMetadata><RequestId>1</RequestId></ResponseMetadata>
+</%[1]sResponse>`
+
+func TestLoadAWSConfig_Profile(t *testing.T) {
+	setAWSTestEnv(t, `
+[default]
+region = us-east-1
+
+[profile dev]
+region = eu-west-1
+`, `
+[dev]
+aws_access_key_id = AKIADEV
+aws_secret_access_key = dev-secret
+`)
+	t.Setenv("AWS_PROFILE", "dev")
+	cfg, err := loadAWSConfig(context.Background(), "")
+	if err != nil {
+		t.Fatalf("loadAWSConfig() error = %v", err)
+	}
+	want := &awsConfig{region: "eu-west-1", credentials: awsCredentials{accessKeyID: "AKIADEV", secretAccessKey: "dev-secret"}}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Fatalf("loadAWSConfig() = %+v, want %+v", cfg, want)
+	}
+
+	// environment variables take precedence
+	t.Setenv("AWS_REGION", "us-west-2")
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIAENV")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "env-secret")
+	if cfg, err = loadAWSConfig(context.Background(), ""); err != nil {
+		t.Fatalf("loadAWSConfig() error = %v", err)
+	}
+	want = &awsConfig{region: "us-west-2", credentials: awsCredentials{accessKeyID: "AKIAENV", secretAccessKey: "env-secret"}}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Fatalf("loadAWSConfig() = %+v, want %+v", cfg, want)
+	}
+
+	t.Setenv("AWS_PROFILE", "missing")
+	if _, err := loadAWSConfig(context.Background(), ""); err == nil {
+		t.Fatal("loadAWSConfig() expects error of missing profile")
+	}
+}
+
+func TestLoadAWSConfig_AssumeRole(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil || r.Form.Get("RoleArn") != "arn:aws:iam::123456789012:role/signer" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		action := r.Form.Get("Action")
+		switch action {
+		case "AssumeRole":
+			if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKIASOURCE/") || r.Form.Get("ExternalId") != "build" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+		case "AssumeRoleWithWebIdentity":
+			if r.Form.Get("WebIdentityToken") != "web-token" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		fmt.Fprintf(w, stsCredentialsResponse, action)
+	})
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("web-token\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	want := awsCredentials{accessKeyID: "ASIAROLE", secretAccessKey: "role-secret", sessionToken: "role-token"}
+
+	// role of a source profile
+	setAWSTestEnv(t, `
+[profile signer]
+role_arn = arn:aws:iam::123456789012:role/signer
+source_profile = source
+external_id = build
+region = us-east-1
+`, `
+[source]
+aws_access_key_id = AKIASOURCE
+aws_secret_access_key = source-secret
+`)
+	t.Setenv("AWS_ENDPOINT_URL_STS", server.URL)
+	t.Setenv("AWS_PROFILE", "signer")
+	cfg, err := loadAWSConfig(context.Background(), "")
+	if err != nil {
+		t.Fatalf("loadAWSConfig() error = %v", err)
+	}
+	if cfg.credentials != want {
+		t.Fatalf("loadAWSConfig() credentials = %+v, want %+v", cfg.credentials, want)
+	}
+
+	// role of a web identity token file, e.g. on EKS
+	setAWSTestEnv(t, "", "")
+	t.Setenv("AWS_ENDPOINT_URL_STS", server.URL)
+	t.Setenv("AWS_REGION", "us-east-1")
+	t.Setenv("AWS_WEB_IDENTITY_TOKEN_FILE", tokenFile)
+	t.Setenv("AWS_ROLE_ARN", "arn:aws:iam::123456789012:role/signer")
+	if cfg, err = loadAWSConfig(context.Background(), ""); err != nil {
+		t.Fatalf("loadAWSConfig() error = %v", err)
+	}
+	if cfg.credentials != want {
+		t.Fatalf("loadAWSConfig() credentials = %+v, want %+v", cfg.credentials, want)
+	}
+}
+
+func TestLoadAWSConfig_CredentialProcess(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the credential process is run by cmd.exe on Windows")
+	}
+	// the quoted argument of the command has spaces
+	setAWSTestEnv(t, `
+[default]
+region = us-east-1
+credential_process = printf '%s' '{"Version": 1, "AccessKeyId": "AKIAPROCESS", "SecretAccessKey": "process secret"}'
+`, "")
+	cfg, err := loadAWSConfig(context.Background(), "")
+	if err != nil {
+		t.Fatalf("loadAWSConfig() error = %v", err)
+	}
+	want := awsCredentials{accessKeyID: "AKIAPROCESS", secretAccessKey: "process secret"}
+	if cfg.credentials != want {
+		t.Fatalf("loadAWSConfig() credentials = %+v, want %+v", cfg.credentials, want)
+	}
+}
+
+func TestLoadAWSConfig_Unsupported(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		profile string
+		want    string
+	}{
+		{name: "MFA", profile: "role_arn = arn:aws:iam::123456789012:role/signer\nsource_profile = default\nmfa_serial = arn:aws:iam::123456789012:mfa/user", want: "MFA token code"},
+		{name: "SSO", profile: "sso_session = corp\nsso_account_id = 123456789012\nsso_role_name = signer", want: "IAM Identity Center"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			setAWSTestEnv(t, "[default]\nregion = us-east-1\n"+tt.profile+"\n", "")
+			if _, err := loadAWSConfig(context.Background(), ""); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("loadAWSConfig() expects error of %s, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestLoadAWSConfig_ContainerAndInstance(t *testing.T) {
+	roleCredentials := `{"AccessKeyId":"ASIAROLE","SecretAccessKey":"role-secret","Token":"role-token"}`
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/ecs" && r.Header.Get("Authorization") == "ecs-token":
+			fmt.Fprint(w, roleCredentials)
+		case r.Method == http.MethodPut && r.URL.Path == "/latest/api/token":
+			fmt.Fprint(w, "imds-token")
+		case r.Header.Get("X-Aws-Ec2-Metadata-Token") != "imds-token":
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.URL.Path == "/latest/meta-data/iam/security-credentials/":
+			fmt.Fprint(w, "signer")
+		case r.URL.Path == "/latest/meta-data/iam/security-credentials/signer":
+			fmt.Fprint(w, roleCredentials)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	want := awsCredentials{accessKeyID: "ASIAROLE", secretAccessKey: "role-secret", sessionToken: "role-token"}
+
+	setAWSTestEnv(t, "", "")
+	t.Setenv("AWS_REGION", "us-east-1")
+	t.Setenv("AWS_CONTAINER_CREDENTIALS_FULL_URI", server.URL+"/ecs")
+	t.Setenv("AWS_CONTAINER_AUTHORIZATION_TOKEN", "ecs-token")
+	cfg, err := loadAWSConfig(context.Background(), "")
+	if err != nil {
+		t.Fatalf("loadAWSConfig() error = %v", err)
+	}
+	if cfg.credentials != want {
+		t.Fatalf("loadAWSConfig() credentials = %+v, want %+v", cfg.credentials, want)
+	}
+
+	setAWSTestEnv(t, "", "")
+	t.Setenv("AWS_REGION", "us-east-1")
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "false")
+	t.Setenv("AWS_EC2_METADATA_SERVICE_ENDPOINT", server.URL)
+	if cfg, err = loadAWSConfig(context.Background(), ""); err != nil {
+		t.Fatalf("loadAWSConfig() error = %v", err)
+	}
+	if cfg.credentials != want {
+		t.Fatalf("loadAWSConfig() credentials = %+v, want %+v", cfg.credentials, want)
+	}
+}
+
+func TestLoadAWSConfig_NoCredentials(t *testing.T) {
+	setAWSTestEnv(t, "[default]\nregion = us-east-1\n", "")
+	if _, err := loadAWSConfig(context.Background(), ""); err == nil || !strings.Contains(err.Error(), "credentials not found") {
+		t.Fatalf("loadAWSConfig() expects credentials error, got %v", err)
+	}
+}
+
+func TestFetchCertificateChain_AWS(t *testing.T) {
+	certs, _ := newTestCertificateChain(t)
+	var chain []byte
+	for _, cert := range certs {
+		chain = append(chain, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})...)
+	}
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/certs/chain.pem" && r.Header.Get("X-Amz-Content-Sha256") != "":
+			w.Write(chain)
+		case r.Header.Get("X-Amz-Target") == "AmazonSSM.GetParameter":
+			var input struct {
+				Name           string
+				WithDecryption bool
+			}
+			if err := json.NewDecoder(r.Body).Decode(&input); err != nil || input.Name != "/notation/chain" || !input.WithDecryption {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]any{"Parameter": map[string]any{"Value": string(chain)}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	setAWSTestEnv(t, "", "")
+	t.Setenv("AWS_REGION", "us-east-1")
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_ENDPOINT_URL", server.URL)
+	for _, location := range []string{"s3://certs/chain.pem", "ssm:///notation/chain"} {
+		got, err := FetchCertificateChain(context.Background(), location)
+		if err != nil {
+			t.Fatalf("FetchCertificateChain(%q) error = %v", location, err)
+		}
+		if len(got) != len(certs) || !got[0].Equal(certs[0]) {
+			t.Fatalf("FetchCertificateChain(%q) = %d certificates, want the chain", location, len(got))
+		}
+	}
+	if _, err := FetchCertificateChain(context.Background(), "s3://certs/missing.pem"); err == nil {
+		t.Fatal("FetchCertificateChain() expects error of missing object")
+	}
+}
+
+func TestAWSKMSKey_Describe(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Amz-Target") != "TrentService.DescribeKey" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"KeyMetadata": map[string]any{
+			"Arn":               "arn:aws:kms:us-east-1:123456789012:key/1234",
+			"KeySpec":           "ECC_NIST_P256",
+			"KeyUsage":          "SIGN_VERIFY",
+			"KeyState":          "Enabled",
+			"SigningAlgorithms": []string{"ECDSA_SHA_256"},
+			"Origin":            "AWS_KMS",
+			"CreationDate":      1.7e9,
+		}})
+	})
+	key := &awsKMSKey{keyID: "alias/release", endpoint: server.URL + "/", region: "us-east-1", credentials: awsCredentials{accessKeyID: "AKIDEXAMPLE", secretAccessKey: "secret"}}
+	properties, err := Describe(context.Background(), key)
+	if err != nil {
+		t.Fatalf("Describe() error = %v", err)
+	}
+	want := []Property{
+		{Name: "Key ARN", Value: "arn:aws:kms:us-east-1:123456789012:key/1234"},
+		{Name: "Key spec", Value: "ECC_NIST_P256"},
+		{Name: "Key usage", Value: "SIGN_VERIFY"},
+		{Name: "Key state", Value: "Enabled"},
+		{Name: "Signing algorithms", Value: "ECDSA_SHA_256"},
+		{Name: "Origin", Value: "AWS_KMS"},
+		{Name: "Creation date", Value: "2023-11-14T22:13:20Z"},
+	}
+	if !reflect.DeepEqual(properties, want) {
+		t.Fatalf("Describe() = %v, want %v", properties, want)
+	}
+}
@@ -0,0 +1,190 @@
+//go:build !nokms && !nokms_azurekv
+
+package kms
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// azureIMDSTimeout bounds the requests to the instance metadata service,
+// which is not reachable outside of Azure.
+const azureIMDSTimeout = time.Second
+
+// azureToken requests an access token of scope with the credential chain of
+// DefaultAzureCredential of the Azure SDKs, in order:
+//
+//  1. the client secret of the service principal of the environment
+//     variables AZURE_TENANT_ID, AZURE_CLIENT_ID and AZURE_CLIENT_SECRET;
+//  2. the workload identity of the federated token file of the environment
+//     variable AZURE_FEDERATED_TOKEN_FILE, e.g. on AKS;
+//  3. the managed identity of the App Service or the virtual machine, the
+//     user-assigned identity of AZURE_CLIENT_ID if set;
+//  4. the account logged in with the Azure CLI.
+func azureToken(ctx context.Context, scope string) (string, error) {
+	if os.Getenv("AZURE_CLIENT_SECRET") != "" {
+		return azureClientSecretToken(ctx, scope)
+	}
+	if os.Getenv("AZURE_FEDERATED_TOKEN_FILE") != "" {
+		return azureWorkloadIdentityToken(ctx, scope)
+	}
+	if token, err := azureManagedIdentityToken(ctx, scope); err == nil {
+		return token, nil
+	} else if os.Getenv("IDENTITY_ENDPOINT") != "" {
+		// the managed identity of the App Service is configured
+		return "", err
+	}
+	if token, err := azureCLIToken(ctx, scope); err == nil {
+		return token, nil
+	} else if !errors.Is(err, exec.ErrNotFound) {
+		return "", err
+	}
+	return "", errors.New("Azure credentials not found, set the environment variables AZURE_TENANT_ID, AZURE_CLIENT_ID and AZURE_CLIENT_SECRET, run with a managed identity, or log in with the Azure CLI")
+}
+
+// azureClientSecretToken requests an access token of scope with the client
+// secret of the service principal of the environment variables
+// AZURE_TENANT_ID, AZURE_CLIENT_ID and AZURE_CLIENT_SECRET.
+func azureClientSecretToken(ctx context.Context, scope string) (string, error) {
+	tenantID, clientID, clientSecret := os.Getenv("AZURE_TENANT_ID"), os.Getenv("AZURE_CLIENT_ID"), os.Getenv("AZURE_CLIENT_SECRET")
+	if tenantID == "" || clientID == "" || clientSecret == "" {
+		return "", errors.New("Azure credentials not found, set the environment variables AZURE_TENANT_ID, AZURE_CLIENT_ID and AZURE_CLIENT_SECRET")
+	}
+	return requestAzureToken(ctx, tenantID, url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {clientID},
+		"client_secret": {clientSecret},
+		"scope":         {scope},
+	})
+}
+
+// azureWorkloadIdentityToken requests an access token of scope with the
+// federated token of the file of the environment variable
+// AZURE_FEDERATED_TOKEN_FILE, for the application of AZURE_TENANT_ID and
+// AZURE_CLIENT_ID.
+func azureWorkloadIdentityToken(ctx context.Context, scope string) (string, error) {
+	tenantID, clientID := os.Getenv("AZURE_TENANT_ID"), os.Getenv("AZURE_CLIENT_ID")
+	if tenantID == "" || clientID == "" {
+		return "", errors.New("Azure workload identity requires the environment variables AZURE_TENANT_ID and AZURE_CLIENT_ID")
+	}
+	assertion, err := os.ReadFile(os.Getenv("AZURE_FEDERATED_TOKEN_FILE"))
+	if err != nil {
+		return "", fmt.Errorf("failed to read the federated token file of Azure workload identity: %w", err)
+	}
+	return requestAzureToken(ctx, tenantID, url.Values{
+		"grant_type":            {"client_credentials"},
+		"client_id":             {clientID},
+		"client_assertion_type": {"urn:ietf:params:oauth:client-assertion-type:jwt-bearer"},
+		"client_assertion":      {strings.TrimSpace(string(assertion))},
+		"scope":                 {scope},
+	})
+}
+
+// requestAzureToken requests an access token of the tenant with the form,
+// from the authority host of the environment variable AZURE_AUTHORITY_HOST,
+// or the Azure public cloud if not set.
+func requestAzureToken(ctx context.Context, tenantID string, form url.Values) (string, error) {
+	authorityHost := os.Getenv("AZURE_AUTHORITY_HOST")
+	if authorityHost == "" {
+		authorityHost = "https://login.microsoftonline.com/"
+	}
+	tokenURL := strings.TrimSuffix(authorityHost, "/") + "/" + url.PathEscape(tenantID) + "/oauth2/v2.0/token"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return fetchAzureToken(req)
+}
+
+// azureManagedIdentityToken requests an access token of scope for the managed
+// identity from the identity endpoint of the App Service of the environment
+// variables IDENTITY_ENDPOINT and IDENTITY_HEADER, or from the instance
+// metadata service, of which the endpoint is overridden by the environment
+// variable AZURE_POD_IDENTITY_AUTHORITY_HOST.
+func azureManagedIdentityToken(ctx context.Context, scope string) (string, error) {
+	query := url.Values{"resource": {strings.TrimSuffix(scope, "/.default")}}
+	if clientID := os.Getenv("AZURE_CLIENT_ID"); clientID != "" {
+		query.Set("client_id", clientID)
+	}
+	if endpoint := os.Getenv("IDENTITY_ENDPOINT"); endpoint != "" {
+		query.Set("api-version", "2019-08-01")
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"?"+query.Encode(), nil)
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("X-IDENTITY-HEADER", os.Getenv("IDENTITY_HEADER"))
+		token, err := fetchAzureToken(req)
+		if err != nil {
+			return "", fmt.Errorf("failed to request an access token of the managed identity: %w", err)
+		}
+		return token, nil
+	}
+
+	endpoint := strings.TrimSuffix(os.Getenv("AZURE_POD_IDENTITY_AUTHORITY_HOST"), "/")
+	if endpoint == "" {
+		endpoint = "http://169.254.169.254"
+	}
+	ctx, cancel := context.WithTimeout(ctx, azureIMDSTimeout)
+	defer cancel()
+	query.Set("api-version", "2018-02-01")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"/metadata/identity/oauth2/token?"+query.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata", "true")
+	token, err := fetchAzureToken(req)
+	if err != nil {
+		return "", fmt.Errorf("instance metadata service not available: %w", err)
+	}
+	return token, nil
+}
+
+// azureCLIToken requests an access token of scope for the account logged in
+// with the Azure CLI. The error wraps exec.ErrNotFound if the Azure CLI is not
+// installed.
+func azureCLIToken(ctx context.Context, scope string) (string, error) {
+	path, err := exec.LookPath("az")
+	if err != nil {
+		return "", err
+	}
+	var stdout, stderr bytes.Buffer
+	command := exec.CommandContext(ctx, path, "account", "get-access-token", "--output", "json", "--resource", strings.TrimSuffix(scope, "/.default"))
+	command.Stdout, command.Stderr = &stdout, &stderr
+	if err := command.Run(); err != nil {
+		return "", fmt.Errorf("failed to request an Azure access token with the Azure CLI, log in with \"az login\": %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	var resp struct {
+		AccessToken string `json:"accessToken"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil {
+		return "", fmt.Errorf("failed to decode the access token of the Azure CLI: %w", err)
+	}
+	if resp.AccessToken == "" {
+		return "", errors.New("failed to request an Azure access token with the Azure CLI: empty access token")
+	}
+	return resp.AccessToken, nil
+}
+
+// fetchAzureToken sends req and returns the access token of the response.
+func fetchAzureToken(req *http.Request) (string, error) {
+	var resp struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := doJSON(req, &resp); err != nil {
+		return "", fmt.Errorf("failed to request an Azure access token: %w", err)
+	}
+	if resp.AccessToken == "" {
+		return "", errors.New("failed to request an Azure access token: empty access token")
+	}
+	return resp.AccessToken, nil
+}
@@ -0,0 +1,360 @@
+//go:build !nokms && !nokms_azurekv
+
+package kms
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/pkcs12"
+)
+
+func init() {
+	register("azurekv", openAzureKeyVault)
+	registerCertificateSource("azurekv", fetchAzureKeyVaultCertificate)
+	registerLister("azurekv", listAzureKeyVault)
+}
+
+// azureKeyVaultAPIVersion is the version of the Key Vault REST API.
+const azureKeyVaultAPIVersion = "7.4"
+
+// azureKeyVaultScope is the OAuth 2.0 scope of access tokens of Key Vault.
+const azureKeyVaultScope = "https://vault.azure.net/.default"
+
+// azureKeyVaultKey is a key in Azure Key Vault.
+type azureKeyVaultKey struct {
+	// kid is the URL of the key version, e.g.
+	// "https://myvault.vault.azure.net/keys/release/0123456789abcdef".
+	kid    string
+	token  string
+	public crypto.PublicKey
+}
+
+// azureJWK is a JSON web key of Key Vault.
+type azureJWK struct {
+	KID string `json:"kid"`
+	KTY string `json:"kty"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	CRV string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// openAzureKeyVault opens the key of a URI in the format of
+// "azurekv://{vault_host}/{key_name}[/{key_version}]", e.g.
+// "azurekv://myvault.vault.azure.net/release". The latest version of the key
+// is opened if the version is not set. The access token is requested with the
+// credential chain of azureToken.
+func openAzureKeyVault(ctx context.Context, uri *url.URL) (crypto.Signer, error) {
+	name := strings.Trim(uri.Path, "/")
+	if uri.Host == "" || name == "" || strings.Count(name, "/") > 1 {
+		return nil, fmt.Errorf("invalid Azure Key Vault key URI %q, expecting azurekv://{vault_host}/{key_name}[/{key_version}]", uri)
+	}
+	token, err := azureToken(ctx, azureKeyVaultScope)
+	if err != nil {
+		return nil, err
+	}
+	key := &azureKeyVaultKey{token: token}
+
+	var resp struct {
+		Key azureJWK `json:"key"`
+	}
+	if err := getAzureKeyVault(ctx, token, fmt.Sprintf("https://%s/keys/%s", uri.Host, name), &resp); err != nil {
+		return nil, fmt.Errorf("failed to get Azure Key Vault key %q: %w", name, err)
+	}
+	// the key version is pinned, so that the key signs with the version of
+	// the public key
+	key.kid = resp.Key.KID
+	if key.public, err = resp.Key.publicKey(); err != nil {
+		return nil, fmt.Errorf("failed to parse Azure Key Vault key %q: %w", name, err)
+	}
+	return key, nil
+}
+
+// publicKey returns the public key of the JSON web key.
+func (k azureJWK) publicKey() (crypto.PublicKey, error) {
+	decode := base64.RawURLEncoding.DecodeString
+	switch strings.TrimSuffix(k.KTY, "-HSM") {
+	case "RSA":
+		n, err := decode(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("malformed modulus: %w", err)
+		}
+		e, err := decode(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("malformed public exponent: %w", err)
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.CRV {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.CRV)
+		}
+		x, err := decode(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("malformed x coordinate: %w", err)
+		}
+		y, err := decode(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("malformed y coordinate: %w", err)
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q, expecting an RSA or EC key", k.KTY)
+}
+
+// Public returns the public key of the key.
+func (k *azureKeyVaultKey) Public() crypto.PublicKey {
+	return k.public
+}
+
+// Sign signs digest with the key in Azure Key Vault.
+func (k *azureKeyVaultKey) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	hash, err := checkSignerOpts(k.public, digest, opts)
+	if err != nil {
+		return nil, err
+	}
+	alg, err := algorithmName(k.public, hash)
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(map[string]string{
+		"alg":   alg,
+		"value": base64.RawURLEncoding.EncodeToString(digest),
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, k.kid+"/sign?api-version="+azureKeyVaultAPIVersion, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+k.token)
+	req.Header.Set("Content-Type", "application/json")
+	var resp struct {
+		Value string `json:"value"`
+	}
+	if err := doJSON(req, &resp); err != nil {
+		return nil, fmt.Errorf("failed to sign with Azure Key Vault key %q: %w", k.kid, err)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(resp.Value)
+	if err != nil {
+		return nil, fmt.Errorf("malformed signature of Azure Key Vault key %q: %w", k.kid, err)
+	}
+	if _, ok := k.public.(*ecdsa.PublicKey); ok {
+		// ECDSA signatures are returned as the concatenation of r and s
+		return marshalECDSASignature(sig)
+	}
+	return sig, nil
+}
+
+// describe returns the properties of the key version.
+func (k *azureKeyVaultKey) describe(ctx context.Context) ([]Property, error) {
+	var resp struct {
+		Key        azureJWK `json:"key"`
+		Attributes struct {
+			Enabled       bool   `json:"enabled"`
+			Created       int64  `json:"created"`
+			NotBefore     int64  `json:"nbf"`
+			Expires       int64  `json:"exp"`
+			RecoveryLevel string `json:"recoveryLevel"`
+		} `json:"attributes"`
+		Managed bool `json:"managed"`
+	}
+	if err := getAzureKeyVault(ctx, k.token, k.kid, &resp); err != nil {
+		return nil, fmt.Errorf("failed to describe Azure Key Vault key %q: %w", k.kid, err)
+	}
+	attributes := resp.Attributes
+	properties := []Property{
+		{Name: "Key ID", Value: resp.Key.KID},
+		{Name: "Key type", Value: resp.Key.KTY},
+		{Name: "Enabled", Value: strconv.FormatBool(attributes.Enabled)},
+		{Name: "Managed by certificate", Value: strconv.FormatBool(resp.Managed)},
+	}
+	// timestamps are in seconds since the epoch
+	for _, timestamp := range []struct {
+		name  string
+		value int64
+	}{
+		{"Created", attributes.Created},
+		{"Not before", attributes.NotBefore},
+		{"Expires", attributes.Expires},
+	} {
+		if timestamp.value != 0 {
+			properties = append(properties, Property{Name: timestamp.name, Value: time.Unix(timestamp.value, 0).UTC().Format(time.RFC3339)})
+		}
+	}
+	if attributes.RecoveryLevel != "" {
+		properties = append(properties, Property{Name: "Recovery level", Value: attributes.RecoveryLevel})
+	}
+	return properties, nil
+}
+
+// fetchAzureKeyVaultCertificate fetches the certificate chain of a location in
+// the format of "azurekv://{vault_host}/certificates/{name}[/{version}]". The
+// chain is read from the secret backing the certificate, which holds the
+// chain of certificates imported or merged with their issuers. The signing
+// certificate alone is fetched if the secret cannot be read, e.g. without
+// the permission to get secrets.
+func fetchAzureKeyVaultCertificate(ctx context.Context, location *url.URL) ([]byte, error) {
+	name, ok := strings.CutPrefix(strings.Trim(location.Path, "/"), "certificates/")
+	if location.Host == "" || !ok || name == "" || strings.Count(name, "/") > 1 {
+		return nil, fmt.Errorf("invalid Azure Key Vault certificate location %q, expecting azurekv://{vault_host}/certificates/{name}[/{version}]", location)
+	}
+	token, err := azureToken(ctx, azureKeyVaultScope)
+	if err != nil {
+		return nil, err
+	}
+	var secret struct {
+		Value       string `json:"value"`
+		ContentType string `json:"contentType"`
+	}
+	secretErr := getAzureKeyVault(ctx, token, fmt.Sprintf("https://%s/secrets/%s", location.Host, name), &secret)
+	if secretErr == nil {
+		chain, err := azureSecretCertificates(secret.Value, secret.ContentType)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the secret of Azure Key Vault certificate %q: %w", name, err)
+		}
+		return chain, nil
+	}
+	var certificate struct {
+		CER []byte `json:"cer"`
+	}
+	if err := getAzureKeyVault(ctx, token, fmt.Sprintf("https://%s/certificates/%s", location.Host, name), &certificate); err != nil {
+		return nil, fmt.Errorf("failed to get Azure Key Vault certificate %q: %w", name, errors.Join(secretErr, err))
+	}
+	return certificate.CER, nil
+}
+
+// azureSecretCertificates returns the PEM certificates of the value of the
+// secret backing a certificate, of content type "application/x-pem-file", or
+// "application/x-pkcs12" of a base64-encoded PKCS #12 file without password.
+func azureSecretCertificates(value, contentType string) ([]byte, error) {
+	if contentType != "application/x-pkcs12" {
+		return []byte(value), nil
+	}
+	data, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	blocks, err := pkcs12.ToPEM(data, "")
+	if err != nil {
+		return nil, err
+	}
+	var chain []byte
+	for _, block := range blocks {
+		if block.Type == "CERTIFICATE" {
+			chain = append(chain, pem.EncodeToMemory(block)...)
+		}
+	}
+	return chain, nil
+}
+
+// listAzureKeyVault lists the certificates and the keys not managed by
+// certificates in the vault of a store in the format of
+// "azurekv://{vault_host}".
+func listAzureKeyVault(ctx context.Context, store *url.URL) ([]RemoteKey, error) {
+	if store.Host == "" || strings.Trim(store.Path, "/") != "" {
+		return nil, fmt.Errorf("invalid Azure Key Vault %q, expecting azurekv://{vault_host}", store)
+	}
+	token, err := azureToken(ctx, azureKeyVaultScope)
+	if err != nil {
+		return nil, err
+	}
+	type item struct {
+		ID         string `json:"id"`
+		KID        string `json:"kid"`
+		Managed    bool   `json:"managed"`
+		Attributes struct {
+			Enabled bool `json:"enabled"`
+		} `json:"attributes"`
+	}
+	// list pages through the items of the collection
+	list := func(collection string) ([]item, error) {
+		var items []item
+		next := fmt.Sprintf("https://%s/%s", store.Host, collection)
+		for next != "" {
+			var page struct {
+				Value    []item `json:"value"`
+				NextLink string `json:"nextLink"`
+			}
+			if err := getAzureKeyVault(ctx, token, next, &page); err != nil {
+				return nil, fmt.Errorf("failed to list the %s of Azure Key Vault %s: %w", collection, store.Host, err)
+			}
+			items = append(items, page.Value...)
+			next = page.NextLink
+		}
+		return items, nil
+	}
+
+	certificates, err := list("certificates")
+	if err != nil {
+		return nil, err
+	}
+	keys, err := list("keys")
+	if err != nil {
+		return nil, err
+	}
+	var remoteKeys []RemoteKey
+	for _, certificate := range certificates {
+		name := path.Base(certificate.ID)
+		remoteKeys = append(remoteKeys, RemoteKey{
+			Name:                name,
+			URI:                 fmt.Sprintf("azurekv://%s/%s", store.Host, name),
+			CertificateLocation: fmt.Sprintf("azurekv://%s/certificates/%s", store.Host, name),
+			Enabled:             certificate.Attributes.Enabled,
+		})
+	}
+	for _, key := range keys {
+		if key.Managed {
+			// listed as certificates
+			continue
+		}
+		name := path.Base(key.KID)
+		remoteKeys = append(remoteKeys, RemoteKey{
+			Name:    name,
+			URI:     fmt.Sprintf("azurekv://%s/%s", store.Host, name),
+			Enabled: key.Attributes.Enabled,
+		})
+	}
+	return remoteKeys, nil
+}
+
+// getAzureKeyVault gets the JSON of the resource URL of Key Vault with token,
+// decoding it into v. Next links of lists have the API version already.
+func getAzureKeyVault(ctx context.Context, token, resourceURL string, v any) error {
+	if !strings.Contains(resourceURL, "api-version=") {
+		resourceURL += "?api-version=" + azureKeyVaultAPIVersion
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, resourceURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return doJSON(req, v)
+}
@@ -0,0 +1,253 @@
+//go:build !nokms && !nokms_gcpkms
+
+package kms
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+func init() {
+	register("gcpkms", openGCPKMS)
+}
+
+// gcpKMSEndpoint is the endpoint of the Cloud KMS API, for unit test.
+var gcpKMSEndpoint = "https://cloudkms.googleapis.com/v1/"
+
+// gcpKMSScope is the OAuth 2.0 scope of access tokens of Cloud KMS.
+const gcpKMSScope = "https://www.googleapis.com/auth/cloudkms"
+
+// gcpKMSKey is a key version in Google Cloud KMS.
+type gcpKMSKey struct {
+	// name is the resource name of the key version.
+	name   string
+	token  string
+	hash   crypto.Hash
+	public crypto.PublicKey
+}
+
+// openGCPKMS opens the key version of a URI in the format of
+// "gcpkms://projects/{project}/locations/{location}/keyRings/{key_ring}/cryptoKeys/{key}/cryptoKeyVersions/{version}".
+// The access token is read from the environment variable
+// GOOGLE_OAUTH_ACCESS_TOKEN, or requested with the service account key file
+// of the environment variable GOOGLE_APPLICATION_CREDENTIALS, or from the
+// metadata server of the Compute Engine instance otherwise.
+func openGCPKMS(ctx context.Context, uri *url.URL) (crypto.Signer, error) {
+	name := uri.Host + uri.Path
+	if segments := strings.Split(name, "/"); len(segments) != 10 ||
+		segments[0] != "projects" || segments[2] != "locations" || segments[4] != "keyRings" || segments[6] != "cryptoKeys" || segments[8] != "cryptoKeyVersions" {
+		return nil, fmt.Errorf("invalid Google Cloud KMS key URI %q, expecting gcpkms://projects/{project}/locations/{location}/keyRings/{key_ring}/cryptoKeys/{key}/cryptoKeyVersions/{version}", uri)
+	}
+	token, err := gcpAccessToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+	key := &gcpKMSKey{name: name, token: token}
+
+	var resp struct {
+		PEM       string `json:"pem"`
+		Algorithm string `json:"algorithm"`
+	}
+	if err := key.call(ctx, http.MethodGet, "/publicKey", nil, &resp); err != nil {
+		return nil, fmt.Errorf("failed to get the public key of Google Cloud KMS key %q: %w", name, err)
+	}
+	switch {
+	case strings.HasPrefix(resp.Algorithm, "RSA_SIGN_PSS_"), strings.HasPrefix(resp.Algorithm, "EC_SIGN_P"):
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %s of Google Cloud KMS key %q, expecting an RSA_SIGN_PSS or EC_SIGN algorithm", resp.Algorithm, name)
+	}
+	switch {
+	case strings.HasSuffix(resp.Algorithm, "_SHA256"):
+		key.hash = crypto.SHA256
+	case strings.HasSuffix(resp.Algorithm, "_SHA384"):
+		key.hash = crypto.SHA384
+	case strings.HasSuffix(resp.Algorithm, "_SHA512"):
+		key.hash = crypto.SHA512
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %s of Google Cloud KMS key %q", resp.Algorithm, name)
+	}
+	block, _ := pem.Decode([]byte(resp.PEM))
+	if block == nil {
+		return nil, fmt.Errorf("malformed public key of Google Cloud KMS key %q", name)
+	}
+	public, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the public key of Google Cloud KMS key %q: %w", name, err)
+	}
+	switch public.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey:
+		key.public = public
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T of Google Cloud KMS key %q", public, name)
+	}
+	return key, nil
+}
+
+// Public returns the public key of the key.
+func (k *gcpKMSKey) Public() crypto.PublicKey {
+	return k.public
+}
+
+// Sign signs digest with the key in Google Cloud KMS.
+func (k *gcpKMSKey) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	hash, err := checkSignerOpts(k.public, digest, opts)
+	if err != nil {
+		return nil, err
+	}
+	// the hash algorithm is fixed by the algorithm of the key version
+	if hash != k.hash {
+		return nil, fmt.Errorf("Google Cloud KMS key %q signs %v digests, not %v digests, expecting a key version of an algorithm with the hash of the key spec of the certificate", k.name, k.hash, hash)
+	}
+	field := strings.ToLower(strings.ReplaceAll(hash.String(), "-", ""))
+	var resp struct {
+		Signature []byte `json:"signature"`
+	}
+	if err := k.call(context.Background(), http.MethodPost, ":asymmetricSign", map[string]any{
+		"digest": map[string][]byte{field: digest},
+	}, &resp); err != nil {
+		return nil, fmt.Errorf("failed to sign with Google Cloud KMS key %q: %w", k.name, err)
+	}
+	// ECDSA signatures are returned in ASN.1 DER
+	return resp.Signature, nil
+}
+
+// call calls the method of the key version with the JSON input, decoding the
+// JSON output into v.
+func (k *gcpKMSKey) call(ctx context.Context, method, suffix string, input any, v any) error {
+	var body io.Reader
+	if input != nil {
+		data, err := json.Marshal(input)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, gcpKMSEndpoint+k.name+suffix, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+k.token)
+	if input != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return doJSON(req, v)
+}
+
+// gcpAccessToken returns an access token of Cloud KMS from the environment
+// variable GOOGLE_OAUTH_ACCESS_TOKEN, the service account key file of the
+// environment variable GOOGLE_APPLICATION_CREDENTIALS, or the metadata server.
+func gcpAccessToken(ctx context.Context) (string, error) {
+	if token := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN"); token != "" {
+		return token, nil
+	}
+	if path := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS"); path != "" {
+		return gcpServiceAccountToken(ctx, path)
+	}
+	metadataHost := os.Getenv("GCE_METADATA_HOST")
+	if metadataHost == "" {
+		metadataHost = "metadata.google.internal"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+metadataHost+"/computeMetadata/v1/instance/service-accounts/default/token?scopes="+url.QueryEscape(gcpKMSScope), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	var resp struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := doJSON(req, &resp); err != nil {
+		return "", fmt.Errorf("Google Cloud credentials not found, set the environment variable GOOGLE_APPLICATION_CREDENTIALS or GOOGLE_OAUTH_ACCESS_TOKEN: failed to request an access token from the metadata server: %w", err)
+	}
+	return resp.AccessToken, nil
+}
+
+// gcpServiceAccountToken requests an access token of Cloud KMS with a JWT
+// signed by the key of the service account key file of path.
+func gcpServiceAccountToken(ctx context.Context, path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the Google Cloud credentials: %w", err)
+	}
+	var serviceAccount struct {
+		Type         string `json:"type"`
+		ClientEmail  string `json:"client_email"`
+		PrivateKeyID string `json:"private_key_id"`
+		PrivateKey   string `json:"private_key"`
+		TokenURI     string `json:"token_uri"`
+	}
+	if err := json.Unmarshal(data, &serviceAccount); err != nil {
+		return "", fmt.Errorf("failed to parse the Google Cloud credentials %s: %w", path, err)
+	}
+	if serviceAccount.Type != "service_account" {
+		return "", fmt.Errorf("unsupported type %q of the Google Cloud credentials %s, expecting a service account key file", serviceAccount.Type, path)
+	}
+	if serviceAccount.TokenURI == "" {
+		serviceAccount.TokenURI = "https://oauth2.googleapis.com/token"
+	}
+	block, _ := pem.Decode([]byte(serviceAccount.PrivateKey))
+	if block == nil {
+		return "", fmt.Errorf("no private key found in the Google Cloud credentials %s", path)
+	}
+	parsedKey, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse the private key of the Google Cloud credentials %s: %w", path, err)
+	}
+	privateKey, ok := parsedKey.(*rsa.PrivateKey)
+	if !ok {
+		return "", fmt.Errorf("unsupported private key type %T of the Google Cloud credentials %s", parsedKey, path)
+	}
+
+	// JWT of RFC 7523 signed with RS256
+	encode := base64.RawURLEncoding.EncodeToString
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": serviceAccount.PrivateKeyID})
+	now := time.Now()
+	claims, _ := json.Marshal(map[string]any{
+		"iss":   serviceAccount.ClientEmail,
+		"scope": gcpKMSScope,
+		"aud":   serviceAccount.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	signingInput := encode(header) + "." + encode(claims)
+	digest := sha256.Sum256([]byte(signingInput))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, privateKey, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {signingInput + "." + encode(sig)},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, serviceAccount.TokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	var resp struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := doJSON(req, &resp); err != nil {
+		return "", fmt.Errorf("failed to request a Google Cloud access token: %w", err)
+	}
+	if resp.AccessToken == "" {
+		return "", errors.New("failed to request a Google Cloud access token: empty access token")
+	}
+	return resp.AccessToken, nil
+}
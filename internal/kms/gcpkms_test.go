@@ -0,0 +1,67 @@
+//go:build !nokms && !nokms_gcpkms
+
+package kms
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestOpenGCPKMS(t *testing.T) {
+	const name = "projects/p/locations/global/keyRings/r/cryptoKeys/release/cryptoKeyVersions/1"
+	for _, privateKey := range []crypto.Signer{mustGenerateRSAKey(t), mustGenerateECKey(t)} {
+		publicKey, err := x509.MarshalPKIXPublicKey(privateKey.Public())
+		if err != nil {
+			t.Fatal(err)
+		}
+		algorithm := "EC_SIGN_P256_SHA256"
+		if _, ok := privateKey.(*rsa.PrivateKey); ok {
+			algorithm = "RSA_SIGN_PSS_2048_SHA256"
+		}
+		server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case r.Header.Get("Authorization") != "Bearer token":
+				w.WriteHeader(http.StatusUnauthorized)
+			case r.Method == http.MethodGet && r.URL.Path == "/v1/"+name+"/publicKey":
+				json.NewEncoder(w).Encode(map[string]string{
+					"pem":       string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKey})),
+					"algorithm": algorithm,
+				})
+			case r.Method == http.MethodPost && r.URL.Path == "/v1/"+name+":asymmetricSign":
+				var input struct {
+					Digest struct {
+						SHA256 []byte `json:"sha256"`
+					} `json:"digest"`
+				}
+				if err := json.NewDecoder(r.Body).Decode(&input); err != nil || len(input.Digest.SHA256) != 32 {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				json.NewEncoder(w).Encode(map[string]any{"signature": signDigest(t, privateKey, input.Digest.SHA256)})
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		})
+		defer func(old string) { gcpKMSEndpoint = old }(gcpKMSEndpoint)
+		gcpKMSEndpoint = server.URL + "/v1/"
+		t.Setenv("GOOGLE_OAUTH_ACCESS_TOKEN", "token")
+		key, err := Open(context.Background(), "gcpkms://"+name)
+		if err != nil {
+			t.Fatalf("Open() error = %v", err)
+		}
+		testSign(t, key)
+	}
+}
+
+func TestOpenGCPKMS_InvalidURI(t *testing.T) {
+	if _, err := Open(context.Background(), "gcpkms://projects/p/locations/global/keyRings/r/cryptoKeys/release"); err == nil || !strings.Contains(err.Error(), "cryptoKeyVersions") {
+		t.Fatalf("Open() expects invalid URI error, got %v", err)
+	}
+}
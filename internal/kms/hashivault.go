@@ -0,0 +1,254 @@
+//go:build !nokms && !nokms_hashivault
+
+package kms
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	register("hashivault", openVaultTransit)
+}
+
+// vaultTransitKey is a key of the Transit secrets engine of HashiCorp Vault.
+type vaultTransitKey struct {
+	addr      string
+	mount     string
+	name      string
+	token     string
+	namespace string
+	version   int
+	public    crypto.PublicKey
+}
+
+// vaultParameters are the query parameters of the key URIs of HashiCorp Vault,
+// which override the environment variables.
+var vaultParameters = map[string]string{
+	"addr":      "VAULT_ADDR",
+	"namespace": "VAULT_NAMESPACE",
+	"mount":     "TRANSIT_SECRET_ENGINE_PATH",
+	"role_id":   "VAULT_ROLE_ID",
+}
+
+// openVaultTransit opens the key of a URI in the format of
+// "hashivault://{key_name}[?addr={address}&namespace={namespace}&mount={path}&role_id={role_id}]",
+// e.g. "hashivault://release?addr=https://vault.example.com:8200". The address
+// and the namespace of Vault, the path of the Transit secrets engine and the
+// role ID of AppRole are read from the query parameters, or from the
+// environment variables VAULT_ADDR, VAULT_NAMESPACE,
+// TRANSIT_SECRET_ENGINE_PATH and VAULT_ROLE_ID if not set. The engine is
+// mounted at "transit" by default. The latest version of the key is opened.
+func openVaultTransit(ctx context.Context, uri *url.URL) (crypto.Signer, error) {
+	name := uri.Host + uri.Path
+	if name == "" || strings.Contains(name, "/") {
+		return nil, fmt.Errorf("invalid HashiCorp Vault key URI %q, expecting hashivault://{key_name}", uri)
+	}
+	query := uri.Query()
+	for parameter := range query {
+		if _, ok := vaultParameters[parameter]; !ok {
+			return nil, fmt.Errorf("unsupported parameter %q of HashiCorp Vault key URI %q, options: addr, namespace, mount, role_id", parameter, uri)
+		}
+	}
+	setting := func(parameter string) string {
+		if value := query.Get(parameter); value != "" {
+			return value
+		}
+		return os.Getenv(vaultParameters[parameter])
+	}
+	key := &vaultTransitKey{
+		addr:      strings.TrimSuffix(setting("addr"), "/"),
+		mount:     strings.Trim(setting("mount"), "/"),
+		name:      name,
+		namespace: setting("namespace"),
+	}
+	if key.addr == "" {
+		return nil, errors.New("HashiCorp Vault not configured, set the address of Vault with the environment variable VAULT_ADDR")
+	}
+	if key.mount == "" {
+		key.mount = "transit"
+	}
+	token, err := vaultToken(ctx, key.addr, key.namespace, setting("role_id"))
+	if err != nil {
+		return nil, err
+	}
+	key.token = token
+
+	var resp struct {
+		Data struct {
+			Type          string `json:"type"`
+			LatestVersion int    `json:"latest_version"`
+			Keys          map[string]struct {
+				PublicKey string `json:"public_key"`
+			} `json:"keys"`
+		} `json:"data"`
+	}
+	if err := key.call(ctx, http.MethodGet, "keys/"+url.PathEscape(name), nil, &resp); err != nil {
+		return nil, fmt.Errorf("failed to read HashiCorp Vault key %q: %w", name, err)
+	}
+	if !strings.HasPrefix(resp.Data.Type, "rsa-") && !strings.HasPrefix(resp.Data.Type, "ecdsa-") {
+		return nil, fmt.Errorf("unsupported type %q of HashiCorp Vault key %q, expecting an RSA or ECDSA key", resp.Data.Type, name)
+	}
+	// the key version is pinned, so that the key signs with the version of
+	// the public key
+	key.version = resp.Data.LatestVersion
+	block, _ := pem.Decode([]byte(resp.Data.Keys[strconv.Itoa(key.version)].PublicKey))
+	if block == nil {
+		return nil, fmt.Errorf("public key of version %d of HashiCorp Vault key %q not found", key.version, name)
+	}
+	public, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the public key of HashiCorp Vault key %q: %w", name, err)
+	}
+	switch public.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey:
+		key.public = public
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T of HashiCorp Vault key %q", public, name)
+	}
+	return key, nil
+}
+
+// Public returns the public key of the key.
+func (k *vaultTransitKey) Public() crypto.PublicKey {
+	return k.public
+}
+
+// Sign signs digest with the key in HashiCorp Vault.
+func (k *vaultTransitKey) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	hash, err := checkSignerOpts(k.public, digest, opts)
+	if err != nil {
+		return nil, err
+	}
+	input := map[string]any{
+		"input":                base64.StdEncoding.EncodeToString(digest),
+		"prehashed":            true,
+		"key_version":          k.version,
+		"marshaling_algorithm": "asn1",
+	}
+	if _, ok := k.public.(*rsa.PublicKey); ok {
+		input["signature_algorithm"] = "pss"
+		input["salt_length"] = "hash"
+	}
+	var resp struct {
+		Data struct {
+			Signature string `json:"signature"`
+		} `json:"data"`
+	}
+	algorithm := strings.ToLower(strings.ReplaceAll(hash.String(), "-", "2-"))
+	if err := k.call(context.Background(), http.MethodPost, "sign/"+url.PathEscape(k.name)+"/"+algorithm, input, &resp); err != nil {
+		return nil, fmt.Errorf("failed to sign with HashiCorp Vault key %q: %w", k.name, err)
+	}
+	// the signature is in the format of "vault:v{version}:{base64}", and
+	// ECDSA signatures are in ASN.1 DER
+	parts := strings.SplitN(resp.Data.Signature, ":", 3)
+	if len(parts) != 3 || parts[0] != "vault" {
+		return nil, fmt.Errorf("malformed signature of HashiCorp Vault key %q", k.name)
+	}
+	sig, err := base64.StdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("malformed signature of HashiCorp Vault key %q: %w", k.name, err)
+	}
+	return sig, nil
+}
+
+// vaultToken returns the token authenticating to Vault at addr, in order:
+//
+//  1. a token of AppRole logged in with roleID, or the role ID of the
+//     environment variable VAULT_ROLE_ID if empty, and the secret ID of
+//     VAULT_SECRET_ID, if a role ID is set;
+//  2. the token of the environment variable VAULT_TOKEN;
+//  3. the token of the Vault CLI, stored in ~/.vault-token by "vault login".
+func vaultToken(ctx context.Context, addr, namespace, roleID string) (string, error) {
+	if roleID != "" {
+		return vaultAppRoleLogin(ctx, addr, namespace, roleID, os.Getenv("VAULT_SECRET_ID"))
+	}
+	if token := os.Getenv("VAULT_TOKEN"); token != "" {
+		return token, nil
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		if token, err := os.ReadFile(filepath.Join(home, ".vault-token")); err == nil && len(bytes.TrimSpace(token)) > 0 {
+			return string(bytes.TrimSpace(token)), nil
+		}
+	}
+	return "", errors.New("HashiCorp Vault credentials not found, set the environment variable VAULT_TOKEN, the role ID and the secret ID of AppRole with VAULT_ROLE_ID and VAULT_SECRET_ID, or log in with \"vault login\"")
+}
+
+// vaultAppRoleLogin logs in to Vault at addr with the role ID and the secret
+// ID of AppRole, mounted at the path of the environment variable
+// VAULT_APPROLE_PATH, or "approle" if not set, and returns the client token.
+func vaultAppRoleLogin(ctx context.Context, addr, namespace, roleID, secretID string) (string, error) {
+	mount := strings.Trim(os.Getenv("VAULT_APPROLE_PATH"), "/")
+	if mount == "" {
+		mount = "approle"
+	}
+	input := map[string]string{"role_id": roleID}
+	if secretID != "" {
+		input["secret_id"] = secretID
+	}
+	data, err := json.Marshal(input)
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, addr+"/v1/auth/"+mount+"/login", bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if namespace != "" {
+		req.Header.Set("X-Vault-Namespace", namespace)
+	}
+	var resp struct {
+		Auth struct {
+			ClientToken string `json:"client_token"`
+		} `json:"auth"`
+	}
+	if err := doJSON(req, &resp); err != nil {
+		return "", fmt.Errorf("failed to log in to HashiCorp Vault with AppRole: %w", err)
+	}
+	if resp.Auth.ClientToken == "" {
+		return "", errors.New("failed to log in to HashiCorp Vault with AppRole: empty client token")
+	}
+	return resp.Auth.ClientToken, nil
+}
+
+// call calls the path of the Transit secrets engine with the JSON input,
+// decoding the JSON output into v.
+func (k *vaultTransitKey) call(ctx context.Context, method, path string, input any, v any) error {
+	var body io.Reader
+	if input != nil {
+		data, err := json.Marshal(input)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, k.addr+"/v1/"+k.mount+"/"+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Vault-Token", k.token)
+	if k.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", k.namespace)
+	}
+	if input != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return doJSON(req, v)
+}
@@ -0,0 +1,135 @@
+//go:build !nokms && !nokms_hashivault
+
+package kms
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestOpenVaultTransit(t *testing.T) {
+	for _, privateKey := range []crypto.Signer{mustGenerateRSAKey(t), mustGenerateECKey(t)} {
+		publicKey, err := x509.MarshalPKIXPublicKey(privateKey.Public())
+		if err != nil {
+			t.Fatal(err)
+		}
+		keyType := "ecdsa-p256"
+		if _, ok := privateKey.(*rsa.PrivateKey); ok {
+			keyType = "rsa-2048"
+		}
+		server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case r.Header.Get("X-Vault-Token") != "token" || r.Header.Get("X-Vault-Namespace") != "team":
+				w.WriteHeader(http.StatusForbidden)
+			case r.Method == http.MethodGet && r.URL.Path == "/v1/signing/keys/release":
+				json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{
+					"type":           keyType,
+					"latest_version": 2,
+					"keys": map[string]any{
+						"2": map[string]string{"public_key": string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKey}))},
+					},
+				}})
+			case r.Method == http.MethodPost && r.URL.Path == "/v1/signing/sign/release/sha2-256":
+				var input struct {
+					Input      string `json:"input"`
+					Prehashed  bool   `json:"prehashed"`
+					KeyVersion int    `json:"key_version"`
+				}
+				json.NewDecoder(r.Body).Decode(&input)
+				digest, err := base64.StdEncoding.DecodeString(input.Input)
+				if err != nil || !input.Prehashed || input.KeyVersion != 2 {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				sig := signDigest(t, privateKey, digest)
+				json.NewEncoder(w).Encode(map[string]any{"data": map[string]string{"signature": "vault:v2:" + base64.StdEncoding.EncodeToString(sig)}})
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		})
+		t.Setenv("VAULT_ADDR", server.URL)
+		t.Setenv("VAULT_TOKEN", "token")
+		t.Setenv("VAULT_NAMESPACE", "team")
+		t.Setenv("TRANSIT_SECRET_ENGINE_PATH", "signing")
+		key, err := Open(context.Background(), "hashivault://release")
+		if err != nil {
+			t.Fatalf("Open() error = %v", err)
+		}
+		testSign(t, key)
+	}
+}
+
+func TestOpenVaultTransit_AppRole(t *testing.T) {
+	privateKey := mustGenerateECKey(t)
+	publicKey, err := x509.MarshalPKIXPublicKey(privateKey.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Header.Get("X-Vault-Namespace") != "team":
+			w.WriteHeader(http.StatusForbidden)
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/auth/approle/login":
+			var input struct {
+				RoleID   string `json:"role_id"`
+				SecretID string `json:"secret_id"`
+			}
+			json.NewDecoder(r.Body).Decode(&input)
+			if input.RoleID != "role" || input.SecretID != "secret" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]any{"auth": map[string]string{"client_token": "approle-token"}})
+		case r.Header.Get("X-Vault-Token") != "approle-token":
+			w.WriteHeader(http.StatusForbidden)
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/transit/keys/release":
+			json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{
+				"type":           "ecdsa-p256",
+				"latest_version": 1,
+				"keys": map[string]any{
+					"1": map[string]string{"public_key": string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKey}))},
+				},
+			}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	// the settings of the URI override the environment
+	t.Setenv("VAULT_ADDR", "https://vault.invalid")
+	t.Setenv("VAULT_TOKEN", "token")
+	t.Setenv("VAULT_SECRET_ID", "secret")
+	uri := "hashivault://release?" + url.Values{"addr": {server.URL}, "namespace": {"team"}, "role_id": {"role"}}.Encode()
+	key, err := Open(context.Background(), uri)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if !key.Public().(*ecdsa.PublicKey).Equal(privateKey.Public()) {
+		t.Fatal("Open() returned an unexpected public key")
+	}
+}
+
+func TestOpenVaultTransit_UnsupportedParameter(t *testing.T) {
+	if _, err := Open(context.Background(), "hashivault://release?token=secret"); err == nil || !strings.Contains(err.Error(), "unsupported parameter") {
+		t.Fatalf("Open() expects unsupported parameter error, got %v", err)
+	}
+}
+
+func TestOpenVaultTransit_MissingToken(t *testing.T) {
+	t.Setenv("VAULT_ADDR", "https://vault.example.com")
+	t.Setenv("VAULT_TOKEN", "")
+	t.Setenv("VAULT_ROLE_ID", "")
+	t.Setenv("HOME", t.TempDir())
+	if _, err := Open(context.Background(), "hashivault://release"); err == nil || !strings.Contains(err.Error(), "VAULT_TOKEN") {
+		t.Fatalf("Open() expects configuration error, got %v", err)
+	}
+}
@@ -0,0 +1,350 @@
+// Package kms signs with keys in key management services, e.g. AWS KMS, Azure
+// Key Vault, Google Cloud KMS and the Transit secrets engine of HashiCorp
+// Vault, without plugin. Keys are identified by URIs of which the scheme
+// selects the provider, e.g. "awskms:///alias/release". The certificate
+// chains of the keys are read from files, or fetched from the stores of the
+// providers, e.g. "s3://bucket/chain.pem".
+//
+// The providers are built in by default. A provider is excluded by the build
+// tag "nokms_<scheme>", e.g. "nokms_awskms", and all the providers by the
+// build tag "nokms".
+package kms
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	corex509 "github.com/notaryproject/notation-core-go/x509"
+)
+
+// Schemes are the URI schemes of all the providers, built in or not.
+var Schemes = []string{"awskms", "azurekv", "gcpkms", "hashivault"}
+
+// ErrUnsupported indicates that the provider of the scheme of a key URI is
+// excluded from this build of notation by build tags.
+var ErrUnsupported = errors.New("KMS provider is not supported by this build of notation")
+
+// opener opens the key of a URI of the scheme of a provider.
+type opener func(ctx context.Context, uri *url.URL) (crypto.Signer, error)
+
+// providers are the openers of the providers built in, by scheme.
+var providers = map[string]opener{}
+
+// register registers the provider of scheme. It is called by the init
+// functions of the providers, which are excluded by build tags.
+func register(scheme string, open opener) {
+	providers[scheme] = open
+}
+
+// Supported returns the schemes of the providers built in, sorted.
+func Supported() []string {
+	schemes := make([]string, 0, len(providers))
+	for scheme := range providers {
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes)
+	return schemes
+}
+
+// ParseURI parses the URI of a key and checks that its provider is built in.
+func ParseURI(uri string) (*url.URL, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("invalid KMS key URI %q: %w", uri, err)
+	}
+	if _, ok := providers[u.Scheme]; !ok {
+		for _, scheme := range Schemes {
+			if u.Scheme == scheme {
+				return nil, fmt.Errorf("%w: %s", ErrUnsupported, scheme)
+			}
+		}
+		return nil, fmt.Errorf("unsupported scheme of KMS key URI %q, options: %s", uri, strings.Join(Schemes, ", "))
+	}
+	return u, nil
+}
+
+// Open opens the key of the URI. The public key of the key is fetched, so
+// that a key which is not accessible fails early. The key implements
+// crypto.Signer, signing RSA keys with RSASSA-PSS and returning ECDSA
+// signatures in ASN.1 DER, as notation signs.
+func Open(ctx context.Context, uri string) (crypto.Signer, error) {
+	u, err := ParseURI(uri)
+	if err != nil {
+		return nil, err
+	}
+	return providers[u.Scheme](ctx, u)
+}
+
+// CertificateSchemes are the schemes of the certificate chain locations of
+// all the providers, built in or not, e.g. "s3" of AWS KMS.
+var CertificateSchemes = []string{"azurekv", "s3", "ssm"}
+
+// certificateSource fetches the PEM certificate chain at a location of the
+// scheme of a provider.
+type certificateSource func(ctx context.Context, location *url.URL) ([]byte, error)
+
+// certificateSources are the certificate sources of the providers built in,
+// by scheme.
+var certificateSources = map[string]certificateSource{}
+
+// registerCertificateSource registers the certificate source of scheme. It is
+// called by the init functions of the providers, which are excluded by build
+// tags.
+func registerCertificateSource(scheme string, fetch certificateSource) {
+	certificateSources[scheme] = fetch
+}
+
+// parseCertificateLocation parses the location of a certificate chain, which
+// is nil for file paths.
+func parseCertificateLocation(location string) (*url.URL, error) {
+	if !strings.Contains(location, "://") {
+		return nil, nil
+	}
+	u, err := url.Parse(location)
+	if err != nil {
+		return nil, fmt.Errorf("invalid certificate chain location %q: %w", location, err)
+	}
+	if _, ok := certificateSources[u.Scheme]; !ok {
+		for _, scheme := range CertificateSchemes {
+			if u.Scheme == scheme {
+				return nil, fmt.Errorf("%w: %s", ErrUnsupported, scheme)
+			}
+		}
+		return nil, fmt.Errorf("unsupported scheme of certificate chain location %q, options: %s, or a file path", location, strings.Join(CertificateSchemes, ", "))
+	}
+	return u, nil
+}
+
+// ValidateCertificateLocation checks that the certificate chain location is a
+// file path, or a location of which the provider is built in.
+func ValidateCertificateLocation(location string) error {
+	_, err := parseCertificateLocation(location)
+	return err
+}
+
+// FetchCertificateChain returns the PEM certificate chain at location, which
+// is a file path or a location in the store of a provider, e.g.
+// "s3://bucket/chain.pem". Remote chains are fetched on every call, so that
+// renewed certificates are picked up without updating the key.
+func FetchCertificateChain(ctx context.Context, location string) ([]*x509.Certificate, error) {
+	u, err := parseCertificateLocation(location)
+	if err != nil {
+		return nil, err
+	}
+	if u == nil {
+		return corex509.ReadCertificateFile(location)
+	}
+	chain, err := certificateSources[u.Scheme](ctx, u)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch the certificate chain at %s: %w", location, err)
+	}
+	certs, err := parseCertificates(chain)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the certificate chain at %s: %w", location, err)
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no certificates found at %s", location)
+	}
+	return certs, nil
+}
+
+// parseCertificates parses the certificates of a PEM file, or of
+// concatenated DER certificates.
+func parseCertificates(data []byte) ([]*x509.Certificate, error) {
+	block, rest := pem.Decode(data)
+	if block == nil {
+		return x509.ParseCertificates(data)
+	}
+	var certs []*x509.Certificate
+	for ; block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	return certs, nil
+}
+
+// RemoteKey is a key in the store of a KMS, which can be added as a signing
+// key.
+type RemoteKey struct {
+	// Name is the name of the key in the store.
+	Name string `json:"name"`
+
+	// URI is the URI of the key opened by Open.
+	URI string `json:"uri"`
+
+	// CertificateLocation is the location of the certificate chain of the
+	// key in the store, or empty if the store has no certificate of the key.
+	CertificateLocation string `json:"certificateLocation,omitempty"`
+
+	// Enabled is true if the key is enabled to sign.
+	Enabled bool `json:"enabled"`
+}
+
+// lister lists the keys in a store of the scheme of a provider, e.g. a vault
+// of Azure Key Vault.
+type lister func(ctx context.Context, store *url.URL) ([]RemoteKey, error)
+
+// listers are the listers of the providers built in listing keys, by scheme.
+var listers = map[string]lister{}
+
+// registerLister registers the lister of scheme. It is called by the init
+// functions of the providers, which are excluded by build tags.
+func registerLister(scheme string, list lister) {
+	listers[scheme] = list
+}
+
+// List returns the keys in the store of a provider, sorted by name, e.g. the
+// keys and the certificates in the vault of "azurekv://myvault.vault.azure.net".
+func List(ctx context.Context, store string) ([]RemoteKey, error) {
+	u, err := ParseURI(store)
+	if err != nil {
+		return nil, err
+	}
+	list, ok := listers[u.Scheme]
+	if !ok {
+		return nil, fmt.Errorf("KMS provider %s does not list keys", u.Scheme)
+	}
+	keys, err := list(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].Name < keys[j].Name
+	})
+	return keys, nil
+}
+
+// Property is a property of a key in a KMS, e.g. its key state.
+type Property struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// describer is implemented by the keys of the providers describing keys.
+type describer interface {
+	describe(ctx context.Context) ([]Property, error)
+}
+
+// Describe returns the properties of key opened by Open, as described by its
+// KMS, or nil if the provider does not describe keys.
+func Describe(ctx context.Context, key crypto.Signer) ([]Property, error) {
+	if d, ok := key.(describer); ok {
+		return d.describe(ctx)
+	}
+	return nil, nil
+}
+
+// requestTimeout is the timeout of a single request to a KMS. Signing has no
+// context, so the requests are bounded by the timeout.
+const requestTimeout = 30 * time.Second
+
+// httpClient is the HTTP client of the requests to KMS, for unit test.
+var httpClient = &http.Client{Timeout: requestTimeout}
+
+// doJSON sends req and decodes the JSON response body into v, returning an
+// error with the response body if the status code is not 2xx.
+func doJSON(req *http.Request, v any) error {
+	if req.Header.Get("Accept") == "" {
+		req.Header.Set("Accept", "application/json")
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s %s: %s: %s", req.Method, req.URL.Redacted(), resp.Status, strings.TrimSpace(string(body)))
+	}
+	if v == nil {
+		return nil
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("%s %s: failed to decode response: %w", req.Method, req.URL.Redacted(), err)
+	}
+	return nil
+}
+
+// checkSignerOpts checks that digest of opts can be signed by public as
+// notation signs, and returns the hash algorithm of digest.
+func checkSignerOpts(public crypto.PublicKey, digest []byte, opts crypto.SignerOpts) (crypto.Hash, error) {
+	hash := opts.HashFunc()
+	if len(digest) != hash.Size() {
+		return 0, fmt.Errorf("digest of %d bytes does not match hash algorithm %v", len(digest), hash)
+	}
+	switch hash {
+	case crypto.SHA256, crypto.SHA384, crypto.SHA512:
+	default:
+		return 0, fmt.Errorf("unsupported hash algorithm %v", hash)
+	}
+	if _, ok := public.(*rsa.PublicKey); ok {
+		pssOpts, ok := opts.(*rsa.PSSOptions)
+		if !ok || (pssOpts.SaltLength != rsa.PSSSaltLengthEqualsHash && pssOpts.SaltLength != hash.Size()) {
+			return 0, errors.New("RSA keys in KMS only sign with RSASSA-PSS with a salt of the hash length")
+		}
+	}
+	return hash, nil
+}
+
+// algorithmName returns the name of the signature algorithm of public and
+// hash, e.g. "PS256" for RSA keys and SHA-256, and "ES384" for ECDSA keys and
+// SHA-384.
+func algorithmName(public crypto.PublicKey, hash crypto.Hash) (string, error) {
+	var bits string
+	switch hash {
+	case crypto.SHA256:
+		bits = "256"
+	case crypto.SHA384:
+		bits = "384"
+	case crypto.SHA512:
+		bits = "512"
+	default:
+		return "", fmt.Errorf("unsupported hash algorithm %v", hash)
+	}
+	switch public.(type) {
+	case *rsa.PublicKey:
+		return "PS" + bits, nil
+	case *ecdsa.PublicKey:
+		return "ES" + bits, nil
+	}
+	return "", fmt.Errorf("unsupported public key type %T", public)
+}
+
+// marshalECDSASignature encodes the concatenation of r and s in ASN.1 DER, as
+// crypto.Signer returns ECDSA signatures.
+func marshalECDSASignature(raw []byte) ([]byte, error) {
+	if len(raw) == 0 || len(raw)%2 != 0 {
+		return nil, fmt.Errorf("malformed ECDSA signature of %d bytes", len(raw))
+	}
+	size := len(raw) / 2
+	return asn1.Marshal(struct {
+		R, S *big.Int
+	}{
+		R: new(big.Int).SetBytes(raw[:size]),
+		S: new(big.Int).SetBytes(raw[size:]),
+	})
+}
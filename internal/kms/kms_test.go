@@ -0,0 +1,224 @@
+package kms
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestServer starts a TLS server of handler, which the KMS requests are
+// sent to during the test.
+func newTestServer(t *testing.T, handler http.HandlerFunc) *httptest.Server {
+	t.Helper()
+	server := httptest.NewTLSServer(handler)
+	t.Cleanup(server.Close)
+	oldClient := httpClient
+	httpClient = server.Client()
+	t.Cleanup(func() { httpClient = oldClient })
+	return server
+}
+
+// testSign signs a digest with key as notation signs and verifies the
+// signature.
+func testSign(t *testing.T, key crypto.Signer) {
+	t.Helper()
+	digest := sha256.Sum256([]byte("payload"))
+	var opts crypto.SignerOpts = crypto.SHA256
+	if _, ok := key.Public().(*rsa.PublicKey); ok {
+		opts = &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: crypto.SHA256}
+	}
+	sig, err := key.Sign(rand.Reader, digest[:], opts)
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	switch public := key.Public().(type) {
+	case *rsa.PublicKey:
+		err = rsa.VerifyPSS(public, crypto.SHA256, digest[:], sig, opts.(*rsa.PSSOptions))
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(public, digest[:], sig) {
+			err = errors.New("invalid ECDSA signature")
+		}
+	}
+	if err != nil {
+		t.Fatalf("signature verification failed: %v", err)
+	}
+}
+
+// signDigest signs digest with key as the KMS does, returning ECDSA
+// signatures in ASN.1 DER.
+func signDigest(t *testing.T, key crypto.Signer, digest []byte) []byte {
+	t.Helper()
+	var opts crypto.SignerOpts = crypto.SHA256
+	if _, ok := key.(*rsa.PrivateKey); ok {
+		opts = &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: crypto.SHA256}
+	}
+	sig, err := key.Sign(rand.Reader, digest, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sig
+}
+
+func mustGenerateRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func mustGenerateECKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// newTestCertificateChain returns a self-signed certificate and its key.
+func newTestCertificateChain(t *testing.T) ([]*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key := mustGenerateECKey(t)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "notation test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return []*x509.Certificate{cert}, key
+}
+
+func TestParseURI(t *testing.T) {
+	if _, err := ParseURI("pkcs11:object=release"); err == nil || !strings.Contains(err.Error(), "unsupported scheme") {
+		t.Fatalf("ParseURI() expects unsupported scheme error, got %v", err)
+	}
+
+	// a provider excluded by build tags
+	defer func(old map[string]opener) { providers = old }(providers)
+	providers = map[string]opener{}
+	if _, err := ParseURI("awskms:///alias/release"); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("ParseURI() error = %v, want ErrUnsupported", err)
+	}
+}
+
+func TestCheckSignerOpts(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := sha256.Sum256([]byte("payload"))
+	if _, err := checkSignerOpts(rsaKey.Public(), digest[:], crypto.SHA256); err == nil {
+		t.Fatal("checkSignerOpts() expects error of RSASSA-PKCS1-v1_5")
+	}
+	if _, err := checkSignerOpts(rsaKey.Public(), digest[:], &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthAuto, Hash: crypto.SHA256}); err == nil {
+		t.Fatal("checkSignerOpts() expects error of automatic salt length")
+	}
+	hash, err := checkSignerOpts(rsaKey.Public(), digest[:], &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: crypto.SHA256})
+	if err != nil || hash != crypto.SHA256 {
+		t.Fatalf("checkSignerOpts() = %v, %v", hash, err)
+	}
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := checkSignerOpts(ecKey.Public(), digest[:], crypto.SHA384); err == nil {
+		t.Fatal("checkSignerOpts() expects error of digest size")
+	}
+	if name, err := algorithmName(ecKey.Public(), crypto.SHA384); err != nil || name != "ES384" {
+		t.Fatalf("algorithmName() = %q, %v", name, err)
+	}
+}
+
+func TestMarshalECDSASignature(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := sha256.Sum256([]byte("payload"))
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw := make([]byte, 64)
+	r.FillBytes(raw[:32])
+	s.FillBytes(raw[32:])
+	sig, err := marshalECDSASignature(raw)
+	if err != nil {
+		t.Fatalf("marshalECDSASignature() error = %v", err)
+	}
+	if !ecdsa.VerifyASN1(&key.PublicKey, digest[:], sig) {
+		t.Fatal("invalid signature")
+	}
+	if _, err := marshalECDSASignature(raw[:63]); err == nil {
+		t.Fatal("marshalECDSASignature() expects error of odd length")
+	}
+}
+
+func TestFetchCertificateChain(t *testing.T) {
+	certs, _ := newTestCertificateChain(t)
+	path := filepath.Join(t.TempDir(), "chain.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certs[0].Raw}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	got, err := FetchCertificateChain(context.Background(), path)
+	if err != nil {
+		t.Fatalf("FetchCertificateChain() error = %v", err)
+	}
+	if len(got) != 1 || !got[0].Equal(certs[0]) {
+		t.Fatalf("FetchCertificateChain() = %v, want the certificate of the file", got)
+	}
+
+	if err := ValidateCertificateLocation("https://example.com/chain.pem"); err == nil || !strings.Contains(err.Error(), "unsupported scheme") {
+		t.Fatalf("ValidateCertificateLocation() expects unsupported scheme error, got %v", err)
+	}
+
+	// a certificate source excluded by build tags
+	defer func(old map[string]certificateSource) { certificateSources = old }(certificateSources)
+	certificateSources = map[string]certificateSource{}
+	if _, err := FetchCertificateChain(context.Background(), "s3://bucket/chain.pem"); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("FetchCertificateChain() error = %v, want ErrUnsupported", err)
+	}
+}
+
+func TestParseCertificates(t *testing.T) {
+	certs, _ := newTestCertificateChain(t)
+	// DER
+	got, err := parseCertificates(certs[0].Raw)
+	if err != nil || len(got) != 1 {
+		t.Fatalf("parseCertificates() = %v, %v, want the DER certificate", got, err)
+	}
+	// PEM with other blocks
+	data := append(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: []byte("key")}), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certs[0].Raw})...)
+	if got, err = parseCertificates(data); err != nil || len(got) != 1 {
+		t.Fatalf("parseCertificates() = %v, %v, want the PEM certificate", got, err)
+	}
+}
@@ -0,0 +1,137 @@
+package lint
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/notaryproject/notation-core-go/signature"
+	"github.com/notaryproject/notation/internal/envelope"
+	gocose "github.com/veraison/go-cose"
+)
+
+// coseAlgorithms are the COSE algorithms allowed by the signature
+// specification.
+var coseAlgorithms = map[gocose.Algorithm]signature.Algorithm{
+	gocose.AlgorithmPS256: signature.AlgorithmPS256,
+	gocose.AlgorithmPS384: signature.AlgorithmPS384,
+	gocose.AlgorithmPS512: signature.AlgorithmPS512,
+	gocose.AlgorithmES256: signature.AlgorithmES256,
+	gocose.AlgorithmES384: signature.AlgorithmES384,
+	gocose.AlgorithmES512: signature.AlgorithmES512,
+}
+
+// coseHeaderNames are the names of the COSE header labels, which are
+// reported by name as the JWS headers.
+var coseHeaderNames = map[int64]string{
+	gocose.HeaderLabelAlgorithm:   "alg",
+	gocose.HeaderLabelCritical:    "crit",
+	gocose.HeaderLabelContentType: "cty",
+	gocose.HeaderLabelX5Chain:     "x5chain",
+}
+
+// decodeCOSE decodes the COSE_Sign1_Tagged envelope sig. It returns nil if
+// the envelope cannot be decoded.
+func (l *linter) decodeCOSE(sig []byte) *decoded {
+	var msg gocose.Sign1Message
+	if err := msg.UnmarshalCBOR(sig); err != nil {
+		l.report(RuleEncoding, "", "malformed COSE_Sign1_Tagged envelope: %v", err)
+		return nil
+	}
+
+	d := &decoded{
+		protected: make(map[string]bool, len(msg.Headers.Protected)),
+		times:     make(map[string]time.Time),
+		payload:   msg.Payload,
+		signature: msg.Signature,
+	}
+	for label, value := range msg.Headers.Protected {
+		name := coseHeaderName(label)
+		d.protected[name] = true
+		switch name {
+		case "alg":
+			if alg, err := msg.Headers.Protected.Algorithm(); err != nil {
+				l.report(RuleSignedAttributes, name, "value must be an integer")
+			} else if d.algorithm = coseAlgorithms[alg]; d.algorithm == 0 {
+				l.report(RuleSignedAttributes, name, "algorithm %d is not one of PS256, PS384, PS512, ES256, ES384, ES512", alg)
+			}
+		case "cty":
+			var ok bool
+			if d.contentType, ok = value.(string); !ok {
+				l.report(RuleSignedAttributes, name, "value must be a text string")
+			}
+		case "crit":
+			labels, ok := value.([]any)
+			if !ok {
+				l.report(RuleCriticalHeaders, name, "value must be an array of header labels")
+				continue
+			}
+			for _, label := range labels {
+				d.critical = append(d.critical, coseHeaderName(label))
+			}
+		case headerSigningScheme:
+			var ok bool
+			if d.signingScheme, ok = value.(string); !ok {
+				l.report(RuleSignedAttributes, name, "value must be a text string")
+			}
+		case headerSigningTime, headerAuthenticSigningTime, headerExpiry:
+			t, ok := value.(time.Time)
+			if !ok {
+				l.report(RuleSignedAttributes, name, "value must be a tagged date time")
+				continue
+			}
+			d.times[name] = t
+		}
+	}
+
+	for label, value := range msg.Headers.Unprotected {
+		name := coseHeaderName(label)
+		switch name {
+		case "x5chain":
+			certs, ok := value.([]any)
+			if !ok {
+				l.report(RuleCertificateChain, name, "value must be an array of certificates")
+				continue
+			}
+			for i, cert := range certs {
+				raw, ok := cert.([]byte)
+				if !ok {
+					l.report(RuleCertificateChain, name, "certificate %d must be a byte string", i)
+					d.certChain = nil
+					break
+				}
+				d.certChain = append(d.certChain, raw)
+			}
+		case headerSigningAgent:
+			if _, ok := value.(string); !ok {
+				l.report(RuleUnsignedAttributes, name, "value must be a text string")
+			}
+		case envelope.HeaderTimestampSignature:
+			token, ok := value.([]byte)
+			if !ok || len(token) == 0 {
+				l.report(RuleUnsignedAttributes, name, "value must be a byte string of timestamp token")
+				continue
+			}
+			d.timestampSignature = token
+		default:
+			if d.protected[name] {
+				l.report(RuleUnsignedAttributes, name, "header must not be present in both protected and unprotected headers")
+			} else {
+				l.report(RuleUnsignedAttributes, name, "unprotected header is not allowed, extended attributes must be protected")
+			}
+		}
+	}
+	return d
+}
+
+// coseHeaderName returns the name of the COSE header label.
+func coseHeaderName(label any) string {
+	switch label := label.(type) {
+	case string:
+		return label
+	case int64:
+		if name, ok := coseHeaderNames[label]; ok {
+			return name
+		}
+	}
+	return fmt.Sprint(label)
+}
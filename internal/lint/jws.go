@@ -0,0 +1,152 @@
+package lint
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"github.com/notaryproject/notation-core-go/signature"
+	"github.com/notaryproject/notation/internal/envelope"
+)
+
+// jwsAlgorithms are the JWS algorithms allowed by the signature
+// specification.
+var jwsAlgorithms = map[string]signature.Algorithm{
+	"PS256": signature.AlgorithmPS256,
+	"PS384": signature.AlgorithmPS384,
+	"PS512": signature.AlgorithmPS512,
+	"ES256": signature.AlgorithmES256,
+	"ES384": signature.AlgorithmES384,
+	"ES512": signature.AlgorithmES512,
+}
+
+// jwsRegisteredHeaders are the headers defined by RFC 7515, which must not be
+// marked critical.
+var jwsRegisteredHeaders = map[string]bool{
+	"alg": true, "jku": true, "jwk": true, "kid": true, "x5u": true, "x5c": true,
+	"x5t": true, "x5t#S256": true, "typ": true, "cty": true, "crit": true,
+}
+
+// jwsUnprotectedHeaders are the only unprotected headers allowed in a JWS
+// envelope.
+var jwsUnprotectedHeaders = map[string]bool{
+	"x5c":                             true,
+	headerSigningAgent:                true,
+	envelope.HeaderTimestampSignature: true,
+}
+
+// decodeJWS decodes the JWS envelope sig in JSON serialization. It returns
+// nil if the envelope cannot be decoded.
+func (l *linter) decodeJWS(sig []byte) *decoded {
+	var env struct {
+		Payload   *string                    `json:"payload"`
+		Protected *string                    `json:"protected"`
+		Header    map[string]json.RawMessage `json:"header"`
+		Signature *string                    `json:"signature"`
+	}
+	if err := json.Unmarshal(sig, &env); err != nil {
+		l.report(RuleEncoding, "", "malformed JWS envelope in JSON serialization: %v", err)
+		return nil
+	}
+	if env.Protected == nil {
+		l.report(RuleEncoding, "protected", "required member is missing")
+		return nil
+	}
+	rawProtected, err := base64.RawURLEncoding.DecodeString(*env.Protected)
+	if err != nil {
+		l.report(RuleEncoding, "protected", "protected header is not base64url encoded: %v", err)
+		return nil
+	}
+	var protected map[string]json.RawMessage
+	if err := json.Unmarshal(rawProtected, &protected); err != nil {
+		l.report(RuleEncoding, "protected", "malformed protected header: %v", err)
+		return nil
+	}
+
+	d := &decoded{
+		protected: make(map[string]bool, len(protected)),
+		times:     make(map[string]time.Time),
+	}
+	for name, value := range protected {
+		d.protected[name] = true
+		switch name {
+		case "alg":
+			var alg string
+			if err := json.Unmarshal(value, &alg); err != nil {
+				l.report(RuleSignedAttributes, name, "value must be a string")
+			} else if d.algorithm = jwsAlgorithms[alg]; d.algorithm == 0 {
+				l.report(RuleSignedAttributes, name, "algorithm %q is not one of PS256, PS384, PS512, ES256, ES384, ES512", alg)
+			}
+		case "cty":
+			if err := json.Unmarshal(value, &d.contentType); err != nil {
+				l.report(RuleSignedAttributes, name, "value must be a string")
+			}
+		case "crit":
+			if err := json.Unmarshal(value, &d.critical); err != nil {
+				l.report(RuleCriticalHeaders, name, "value must be an array of strings")
+			} else if len(d.critical) == 0 {
+				l.report(RuleCriticalHeaders, name, "value must not be empty")
+			}
+		case headerSigningScheme:
+			if err := json.Unmarshal(value, &d.signingScheme); err != nil {
+				l.report(RuleSignedAttributes, name, "value must be a string")
+			}
+		case headerSigningTime, headerAuthenticSigningTime, headerExpiry:
+			var s string
+			if err := json.Unmarshal(value, &s); err != nil {
+				l.report(RuleSignedAttributes, name, "value must be a string of RFC 3339 date time")
+				continue
+			}
+			t, err := time.Parse(time.RFC3339, s)
+			if err != nil {
+				l.report(RuleSignedAttributes, name, "value must be a string of RFC 3339 date time: %v", err)
+				continue
+			}
+			d.times[name] = t
+		}
+	}
+	for _, name := range d.critical {
+		if jwsRegisteredHeaders[name] {
+			l.report(RuleCriticalHeaders, name, "header defined by RFC 7515 must not be marked critical")
+		}
+	}
+
+	for name, value := range env.Header {
+		switch name {
+		case "x5c":
+			if err := json.Unmarshal(value, &d.certChain); err != nil {
+				l.report(RuleCertificateChain, name, "value must be an array of base64 encoded certificates")
+			}
+		case headerSigningAgent:
+			var agent string
+			if err := json.Unmarshal(value, &agent); err != nil {
+				l.report(RuleUnsignedAttributes, name, "value must be a string")
+			}
+		case envelope.HeaderTimestampSignature:
+			if err := json.Unmarshal(value, &d.timestampSignature); err != nil || len(d.timestampSignature) == 0 {
+				d.timestampSignature = nil
+				l.report(RuleUnsignedAttributes, name, "value must be a base64 encoded timestamp token")
+			}
+		}
+		if !jwsUnprotectedHeaders[name] {
+			if d.protected[name] {
+				l.report(RuleUnsignedAttributes, name, "header must not be present in both protected and unprotected headers")
+			} else {
+				l.report(RuleUnsignedAttributes, name, "unprotected header is not allowed, extended attributes must be protected")
+			}
+		}
+	}
+
+	if env.Payload != nil {
+		if d.payload, err = base64.RawURLEncoding.DecodeString(*env.Payload); err != nil {
+			d.payload = nil
+			l.report(RuleEncoding, "payload", "payload is not base64url encoded: %v", err)
+		}
+	}
+	if env.Signature == nil {
+		l.report(RuleEncoding, "signature", "required member is missing")
+	} else if d.signature, err = base64.RawURLEncoding.DecodeString(*env.Signature); err != nil {
+		l.report(RuleEncoding, "signature", "signature is not base64url encoded: %v", err)
+	}
+	return d
+}
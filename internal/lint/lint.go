@@ -0,0 +1,349 @@
+// Package lint checks signature envelopes against the Notary Project
+// signature specification and reports every violation found, rather than
+// failing at the first one as parsing an envelope does.
+//
+// Reference: https://github.com/notaryproject/notaryproject/blob/main/specs/signature-specification.md
+package lint
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/notaryproject/notation-core-go/signature"
+	"github.com/notaryproject/notation-core-go/signature/cose"
+	"github.com/notaryproject/notation-core-go/signature/jws"
+	corex509 "github.com/notaryproject/notation-core-go/x509"
+	"github.com/notaryproject/notation/internal/archive"
+	"github.com/notaryproject/notation/internal/envelope"
+	"github.com/opencontainers/go-digest"
+)
+
+// Rules of the signature specification an envelope is checked against.
+const (
+	// RuleEncoding is the encoding of the envelope in its format.
+	RuleEncoding = "encoding"
+
+	// RuleSignedAttributes are the required protected headers and their
+	// values.
+	RuleSignedAttributes = "signed-attributes"
+
+	// RuleCriticalHeaders is the list of protected headers marked critical.
+	RuleCriticalHeaders = "critical-headers"
+
+	// RuleUnsignedAttributes are the allowed unprotected headers, except the
+	// certificate chain.
+	RuleUnsignedAttributes = "unsigned-attributes"
+
+	// RuleCertificateChain is the certificate chain, ordered from the signing
+	// certificate to the root.
+	RuleCertificateChain = "certificate-chain"
+
+	// RulePayload is the payload describing the signed artifact.
+	RulePayload = "payload"
+
+	// RuleSignature is the signature value over the protected headers and
+	// the payload.
+	RuleSignature = "signature"
+)
+
+// headers of the Notary Project signature specification
+const (
+	headerSigningScheme        = "io.cncf.notary.signingScheme"
+	headerSigningTime          = "io.cncf.notary.signingTime"
+	headerAuthenticSigningTime = "io.cncf.notary.authenticSigningTime"
+	headerExpiry               = "io.cncf.notary.expiry"
+	headerSigningAgent         = "io.cncf.notary.signingAgent"
+)
+
+// Violation is a violation of the signature specification.
+type Violation struct {
+	// Rule is the rule violated, e.g. RuleCriticalHeaders.
+	Rule string `json:"rule"`
+
+	// Attribute is the header or field violating the rule, if any.
+	Attribute string `json:"attribute,omitempty"`
+
+	Message string `json:"message"`
+}
+
+// String returns the violation in the form of "[rule] attribute: message".
+func (v Violation) String() string {
+	if v.Attribute == "" {
+		return fmt.Sprintf("[%s] %s", v.Rule, v.Message)
+	}
+	return fmt.Sprintf("[%s] %s: %s", v.Rule, v.Attribute, v.Message)
+}
+
+// decoded is a signature envelope decoded without validation, which is checked
+// by the rules shared by the envelope formats. The format specific rules are
+// checked while decoding.
+type decoded struct {
+	// protected are the names of the protected headers.
+	protected map[string]bool
+
+	// algorithm is zero if the algorithm is missing or not supported.
+	algorithm     signature.Algorithm
+	contentType   string
+	signingScheme string
+
+	// times are the valid time values of the protected headers by name.
+	times    map[string]time.Time
+	critical []string
+
+	certChain          [][]byte
+	timestampSignature []byte
+	payload            []byte
+	signature          []byte
+}
+
+// linter collects the violations of an envelope.
+type linter struct {
+	violations []Violation
+}
+
+func (l *linter) report(rule, attribute, format string, args ...any) {
+	l.violations = append(l.violations, Violation{
+		Rule:      rule,
+		Attribute: attribute,
+		Message:   fmt.Sprintf(format, args...),
+	})
+}
+
+// Envelope checks the signature envelope sig of mediaType against the
+// signature specification and returns the violations found, nil if the
+// envelope complies. The signature value is only verified against the signing
+// certificate if no other violation is found. The certificate chain is not
+// verified against any trust store, and neither is the timestamp
+// countersignature.
+func Envelope(sig []byte, mediaType string) ([]Violation, error) {
+	l := &linter{}
+	var env *decoded
+	switch mediaType {
+	case jws.MediaTypeEnvelope:
+		env = l.decodeJWS(sig)
+	case cose.MediaTypeEnvelope:
+		env = l.decodeCOSE(sig)
+	default:
+		return nil, fmt.Errorf("signature envelope type %q not supported", mediaType)
+	}
+	if env == nil {
+		// nothing else can be checked in an envelope not decoded
+		return l.violations, nil
+	}
+	l.checkSignedAttributes(env)
+	l.checkCriticalHeaders(env)
+	l.checkCertificateChain(env)
+	l.checkTimestamp(env)
+	l.checkPayload(env)
+	if len(l.violations) == 0 {
+		l.checkSignature(sig, mediaType)
+	}
+	// the headers are decoded in no particular order
+	sort.SliceStable(l.violations, func(i, j int) bool {
+		if l.violations[i].Rule != l.violations[j].Rule {
+			return l.violations[i].Rule < l.violations[j].Rule
+		}
+		return l.violations[i].Attribute < l.violations[j].Attribute
+	})
+	return l.violations, nil
+}
+
+// checkSignedAttributes checks the required protected headers and the
+// headers of the signing scheme.
+func (l *linter) checkSignedAttributes(env *decoded) {
+	if !env.protected["alg"] {
+		l.report(RuleSignedAttributes, "alg", "required header is missing")
+	}
+	if !env.protected["cty"] {
+		l.report(RuleSignedAttributes, "cty", "required header is missing")
+	} else if env.contentType != envelope.MediaTypePayloadV1 {
+		l.report(RuleSignedAttributes, "cty", "content type %q is not %q", env.contentType, envelope.MediaTypePayloadV1)
+	}
+
+	var timeHeader, otherTimeHeader string
+	switch signature.SigningScheme(env.signingScheme) {
+	case signature.SigningSchemeX509:
+		timeHeader, otherTimeHeader = headerSigningTime, headerAuthenticSigningTime
+	case signature.SigningSchemeX509SigningAuthority:
+		timeHeader, otherTimeHeader = headerAuthenticSigningTime, headerSigningTime
+	default:
+		if !env.protected[headerSigningScheme] {
+			l.report(RuleSignedAttributes, headerSigningScheme, "required header is missing")
+		} else {
+			l.report(RuleSignedAttributes, headerSigningScheme, "signing scheme %q is not one of %q, %q", env.signingScheme, signature.SigningSchemeX509, signature.SigningSchemeX509SigningAuthority)
+		}
+		return
+	}
+	if !env.protected[timeHeader] {
+		l.report(RuleSignedAttributes, timeHeader, "header is required by signing scheme %q", env.signingScheme)
+	}
+	if env.protected[otherTimeHeader] {
+		l.report(RuleSignedAttributes, otherTimeHeader, "header must not be present for signing scheme %q", env.signingScheme)
+	}
+	signingTime, hasSigningTime := env.times[timeHeader]
+	if expiry, ok := env.times[headerExpiry]; ok && hasSigningTime && !expiry.After(signingTime) {
+		l.report(RuleSignedAttributes, headerExpiry, "expiry %s is not after the signing time %s", expiry.Format(time.RFC3339), signingTime.Format(time.RFC3339))
+	}
+}
+
+// checkCriticalHeaders checks that the headers of the specification are
+// marked critical as required, and that every critical header is present.
+func (l *linter) checkCriticalHeaders(env *decoded) {
+	if !env.protected["crit"] {
+		l.report(RuleCriticalHeaders, "crit", "required header is missing")
+		return
+	}
+	critical := make(map[string]bool, len(env.critical))
+	for _, name := range env.critical {
+		if critical[name] {
+			l.report(RuleCriticalHeaders, name, "header is marked critical more than once")
+		}
+		critical[name] = true
+		if !env.protected[name] {
+			l.report(RuleCriticalHeaders, name, "header is marked critical but not present")
+		}
+	}
+	required := []string{headerSigningScheme}
+	if signature.SigningScheme(env.signingScheme) == signature.SigningSchemeX509SigningAuthority {
+		required = append(required, headerAuthenticSigningTime)
+	}
+	if env.protected[headerExpiry] {
+		required = append(required, headerExpiry)
+	}
+	for _, name := range required {
+		if env.protected[name] && !critical[name] {
+			l.report(RuleCriticalHeaders, name, "header must be marked critical")
+		}
+	}
+}
+
+// checkCertificateChain checks that the certificate chain starts with the
+// signing certificate, and that each certificate is issued by the next one.
+// The certificates are checked against the requirements of code signing
+// certificates at the signing time, and the key of the signing certificate
+// against the signature algorithm.
+func (l *linter) checkCertificateChain(env *decoded) {
+	if len(env.certChain) == 0 {
+		l.report(RuleCertificateChain, "", "certificate chain is missing")
+		return
+	}
+	certs := make([]*x509.Certificate, 0, len(env.certChain))
+	for i, raw := range env.certChain {
+		cert, err := x509.ParseCertificate(raw)
+		if err != nil {
+			l.report(RuleCertificateChain, fmt.Sprintf("certificate %d", i), "malformed certificate: %v", err)
+			return
+		}
+		certs = append(certs, cert)
+	}
+	ordered := true
+	for i := 0; i < len(certs)-1; i++ {
+		if !bytes.Equal(certs[i].RawIssuer, certs[i+1].RawSubject) || certs[i].CheckSignatureFrom(certs[i+1]) != nil {
+			l.report(RuleCertificateChain, fmt.Sprintf("certificate %d", i), "certificate %q is not issued by the next certificate %q, the chain must be ordered from the signing certificate to the root", certs[i].Subject, certs[i+1].Subject)
+			ordered = false
+		}
+	}
+	if ordered {
+		var signingTime *time.Time
+		for _, name := range []string{headerSigningTime, headerAuthenticSigningTime} {
+			if t, ok := env.times[name]; ok {
+				signingTime = &t
+			}
+		}
+		if err := corex509.ValidateCodeSigningCertChain(certs, signingTime); err != nil {
+			l.report(RuleCertificateChain, "", "%v", err)
+		}
+	}
+	if env.algorithm == 0 {
+		return
+	}
+	keySpec, err := signature.ExtractKeySpec(certs[0])
+	if err != nil {
+		l.report(RuleCertificateChain, "certificate 0", "%v", err)
+		return
+	}
+	if alg := keySpec.SignatureAlgorithm(); alg != env.algorithm {
+		l.report(RuleCertificateChain, "certificate 0", "key of the signing certificate requires algorithm %s, the envelope uses %s", algorithmName(alg), algorithmName(env.algorithm))
+	}
+}
+
+// checkTimestamp checks that the timestamp countersignature, if any, is a
+// timestamp token of the signature value.
+func (l *linter) checkTimestamp(env *decoded) {
+	if env.timestampSignature == nil {
+		return
+	}
+	token, err := archive.ParseToken(env.timestampSignature)
+	if err != nil {
+		l.report(RuleUnsignedAttributes, envelope.HeaderTimestampSignature, "malformed timestamp token: %v", err)
+		return
+	}
+	if !token.Hash.Available() {
+		l.report(RuleUnsignedAttributes, envelope.HeaderTimestampSignature, "hash algorithm %v of timestamp token is not available", token.Hash)
+		return
+	}
+	h := token.Hash.New()
+	h.Write(env.signature)
+	if !bytes.Equal(h.Sum(nil), token.HashedMessage) {
+		l.report(RuleUnsignedAttributes, envelope.HeaderTimestampSignature, "timestamp token is not issued for the signature value")
+	}
+}
+
+// checkPayload checks that the payload describes the signed artifact.
+func (l *linter) checkPayload(env *decoded) {
+	if env.payload == nil {
+		l.report(RulePayload, "", "payload is missing")
+		return
+	}
+	var payload struct {
+		TargetArtifact *struct {
+			MediaType string        `json:"mediaType"`
+			Digest    digest.Digest `json:"digest"`
+			Size      int64         `json:"size"`
+		} `json:"targetArtifact"`
+	}
+	if err := json.Unmarshal(env.payload, &payload); err != nil {
+		l.report(RulePayload, "", "malformed payload: %v", err)
+		return
+	}
+	target := payload.TargetArtifact
+	if target == nil {
+		l.report(RulePayload, "targetArtifact", "required field is missing")
+		return
+	}
+	if target.MediaType == "" {
+		l.report(RulePayload, "targetArtifact.mediaType", "required field is missing")
+	}
+	if err := target.Digest.Validate(); err != nil {
+		l.report(RulePayload, "targetArtifact.digest", "invalid digest: %v", err)
+	}
+	if target.Size <= 0 {
+		l.report(RulePayload, "targetArtifact.size", "size must be positive")
+	}
+}
+
+// checkSignature verifies the signature value against the signing
+// certificate.
+func (l *linter) checkSignature(sig []byte, mediaType string) {
+	env, err := signature.ParseEnvelope(mediaType, sig)
+	if err == nil {
+		_, err = env.Verify()
+	}
+	if err != nil {
+		l.report(RuleSignature, "", "%v", err)
+	}
+}
+
+// algorithmName returns the JWS name of alg, e.g. "PS256".
+func algorithmName(alg signature.Algorithm) string {
+	for name, a := range jwsAlgorithms {
+		if a == alg {
+			return name
+		}
+	}
+	return fmt.Sprintf("unknown algorithm %d", alg)
+}
@@ -0,0 +1,281 @@
+package lint
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/notation-core-go/signature"
+	"github.com/notaryproject/notation-core-go/signature/cose"
+	"github.com/notaryproject/notation-core-go/signature/jws"
+	"github.com/notaryproject/notation/internal/envelope"
+	gocose "github.com/veraison/go-cose"
+)
+
+const testPayload = `{"targetArtifact":{"mediaType":"application/vnd.oci.image.manifest.v1+json","digest":"sha256:73c803930ea3ba1e54bc25c2bdc53edd0284c62ed651fe7b00369da519a3c333","size":16724}}`
+
+// newTestChain returns a certificate chain of a signing certificate issued by
+// a root, and the key of the signing certificate.
+func newTestChain(t *testing.T) ([]*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	rootKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rootTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "root", Organization: []string{"Notary"}, Country: []string{"US"}, Province: []string{"WA"}, Locality: []string{"Seattle"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	rootDER, err := x509.CreateCertificate(rand.Reader, rootTemplate, rootTemplate, &rootKey.PublicKey, rootKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := x509.ParseCertificate(rootDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "leaf", Organization: []string{"Notary"}, Country: []string{"US"}, Province: []string{"WA"}, Locality: []string{"Seattle"}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	}
+	leafDER, err := x509.CreateCertificate(rand.Reader, template, root, &key.PublicKey, rootKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(leafDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return []*x509.Certificate{leaf, root}, key
+}
+
+// newTestEnvelope returns a signature envelope of mediaType signed by a
+// certificate chain of a signing certificate and a root.
+func newTestEnvelope(t *testing.T, mediaType string) []byte {
+	t.Helper()
+	certs, key := newTestChain(t)
+	signer, err := signature.NewLocalSigner(certs, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env, err := signature.NewEnvelope(mediaType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := env.Sign(&signature.SignRequest{
+		Payload:       signature.Payload{ContentType: envelope.MediaTypePayloadV1, Content: []byte(testPayload)},
+		Signer:        signer,
+		SigningTime:   time.Now(),
+		Expiry:        time.Now().Add(time.Hour),
+		SigningScheme: signature.SigningSchemeX509,
+		SigningAgent:  "notation/test",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sig
+}
+
+// editJWS returns the JWS envelope sig with its protected header, unprotected
+// header and payload edited by edit.
+func editJWS(t *testing.T, sig []byte, edit func(protected, header map[string]any)) []byte {
+	t.Helper()
+	var env map[string]any
+	if err := json.Unmarshal(sig, &env); err != nil {
+		t.Fatal(err)
+	}
+	rawProtected, err := base64.RawURLEncoding.DecodeString(env["protected"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var protected map[string]any
+	if err := json.Unmarshal(rawProtected, &protected); err != nil {
+		t.Fatal(err)
+	}
+	header := env["header"].(map[string]any)
+	edit(protected, header)
+	if rawProtected, err = json.Marshal(protected); err != nil {
+		t.Fatal(err)
+	}
+	env["protected"] = base64.RawURLEncoding.EncodeToString(rawProtected)
+	edited, err := json.Marshal(env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return edited
+}
+
+// assertViolations checks that violations are exactly want, by rule and
+// attribute.
+func assertViolations(t *testing.T, violations []Violation, want ...Violation) {
+	t.Helper()
+	got := make(map[Violation]bool, len(violations))
+	for _, v := range violations {
+		got[Violation{Rule: v.Rule, Attribute: v.Attribute}] = true
+	}
+	for _, w := range want {
+		if !got[w] {
+			t.Errorf("missing violation [%s] %s, got %v", w.Rule, w.Attribute, violations)
+		}
+	}
+	if len(violations) != len(want) {
+		t.Errorf("got %d violations, want %d: %v", len(violations), len(want), violations)
+	}
+}
+
+func TestEnvelope(t *testing.T) {
+	for _, mediaType := range []string{jws.MediaTypeEnvelope, cose.MediaTypeEnvelope} {
+		t.Run(mediaType, func(t *testing.T) {
+			violations, err := Envelope(newTestEnvelope(t, mediaType), mediaType)
+			if err != nil {
+				t.Fatalf("Envelope() error = %v", err)
+			}
+			assertViolations(t, violations)
+		})
+	}
+}
+
+func TestEnvelope_UnsupportedMediaType(t *testing.T) {
+	if _, err := Envelope([]byte("{}"), "application/json"); err == nil {
+		t.Fatal("Envelope() expects error of unsupported media type")
+	}
+}
+
+func TestEnvelope_JWSSignedAttributes(t *testing.T) {
+	sig := editJWS(t, newTestEnvelope(t, jws.MediaTypeEnvelope), func(protected, header map[string]any) {
+		protected["alg"] = "RS256"
+		protected["cty"] = "application/json"
+		protected[headerAuthenticSigningTime] = protected[headerSigningTime]
+		protected[headerExpiry] = "2000-01-01T00:00:00Z"
+	})
+	violations, err := Envelope(sig, jws.MediaTypeEnvelope)
+	if err != nil {
+		t.Fatalf("Envelope() error = %v", err)
+	}
+	assertViolations(t, violations,
+		Violation{Rule: RuleSignedAttributes, Attribute: "alg"},
+		Violation{Rule: RuleSignedAttributes, Attribute: "cty"},
+		Violation{Rule: RuleSignedAttributes, Attribute: headerAuthenticSigningTime},
+		Violation{Rule: RuleSignedAttributes, Attribute: headerExpiry},
+	)
+}
+
+func TestEnvelope_JWSCriticalHeaders(t *testing.T) {
+	sig := editJWS(t, newTestEnvelope(t, jws.MediaTypeEnvelope), func(protected, header map[string]any) {
+		// the expiry is not marked critical
+		protected["crit"] = []string{headerSigningScheme, headerSigningScheme, "alg", "io.wabbit-networks.buildId"}
+		header["io.wabbit-networks.buildId"] = "123"
+	})
+	violations, err := Envelope(sig, jws.MediaTypeEnvelope)
+	if err != nil {
+		t.Fatalf("Envelope() error = %v", err)
+	}
+	assertViolations(t, violations,
+		Violation{Rule: RuleCriticalHeaders, Attribute: headerSigningScheme},
+		Violation{Rule: RuleCriticalHeaders, Attribute: "alg"},
+		Violation{Rule: RuleCriticalHeaders, Attribute: "io.wabbit-networks.buildId"},
+		Violation{Rule: RuleCriticalHeaders, Attribute: headerExpiry},
+		Violation{Rule: RuleUnsignedAttributes, Attribute: "io.wabbit-networks.buildId"},
+	)
+}
+
+func TestEnvelope_JWSCertificateChain(t *testing.T) {
+	sig := editJWS(t, newTestEnvelope(t, jws.MediaTypeEnvelope), func(protected, header map[string]any) {
+		// root first
+		x5c := header["x5c"].([]any)
+		x5c[0], x5c[1] = x5c[1], x5c[0]
+	})
+	violations, err := Envelope(sig, jws.MediaTypeEnvelope)
+	if err != nil {
+		t.Fatalf("Envelope() error = %v", err)
+	}
+	assertViolations(t, violations, Violation{Rule: RuleCertificateChain, Attribute: "certificate 0"})
+
+	sig = editJWS(t, newTestEnvelope(t, jws.MediaTypeEnvelope), func(protected, header map[string]any) {
+		delete(header, "x5c")
+	})
+	if violations, err = Envelope(sig, jws.MediaTypeEnvelope); err != nil {
+		t.Fatalf("Envelope() error = %v", err)
+	}
+	assertViolations(t, violations, Violation{Rule: RuleCertificateChain})
+}
+
+func TestEnvelope_Signature(t *testing.T) {
+	sig := editJWS(t, newTestEnvelope(t, jws.MediaTypeEnvelope), func(protected, header map[string]any) {
+		protected["io.wabbit-networks.buildId"] = "123"
+	})
+	violations, err := Envelope(sig, jws.MediaTypeEnvelope)
+	if err != nil {
+		t.Fatalf("Envelope() error = %v", err)
+	}
+	assertViolations(t, violations, Violation{Rule: RuleSignature})
+}
+
+func TestEnvelope_Payload(t *testing.T) {
+	var env map[string]any
+	if err := json.Unmarshal(newTestEnvelope(t, jws.MediaTypeEnvelope), &env); err != nil {
+		t.Fatal(err)
+	}
+	env["payload"] = base64.RawURLEncoding.EncodeToString([]byte(`{"targetArtifact":{"digest":"sha256:123"}}`))
+	sig, err := json.Marshal(env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	violations, err := Envelope(sig, jws.MediaTypeEnvelope)
+	if err != nil {
+		t.Fatalf("Envelope() error = %v", err)
+	}
+	assertViolations(t, violations,
+		Violation{Rule: RulePayload, Attribute: "targetArtifact.mediaType"},
+		Violation{Rule: RulePayload, Attribute: "targetArtifact.digest"},
+		Violation{Rule: RulePayload, Attribute: "targetArtifact.size"},
+	)
+}
+
+func TestEnvelope_COSE(t *testing.T) {
+	var msg gocose.Sign1Message
+	if err := msg.UnmarshalCBOR(newTestEnvelope(t, cose.MediaTypeEnvelope)); err != nil {
+		t.Fatal(err)
+	}
+	msg.Headers.Unprotected["io.wabbit-networks.buildId"] = "123"
+	msg.Headers.Unprotected[envelope.HeaderTimestampSignature] = []byte("not a timestamp token")
+	msg.Headers.RawUnprotected = nil
+	sig, err := msg.MarshalCBOR()
+	if err != nil {
+		t.Fatal(err)
+	}
+	violations, err := Envelope(sig, cose.MediaTypeEnvelope)
+	if err != nil {
+		t.Fatalf("Envelope() error = %v", err)
+	}
+	assertViolations(t, violations,
+		Violation{Rule: RuleUnsignedAttributes, Attribute: "io.wabbit-networks.buildId"},
+		Violation{Rule: RuleUnsignedAttributes, Attribute: envelope.HeaderTimestampSignature},
+	)
+
+	// COSE_Sign1 without tag
+	if violations, err = Envelope(sig[1:], cose.MediaTypeEnvelope); err != nil {
+		t.Fatalf("Envelope() error = %v", err)
+	}
+	assertViolations(t, violations, Violation{Rule: RuleEncoding})
+}
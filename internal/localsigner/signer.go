@@ -0,0 +1,187 @@
+// Package localsigner signs artifacts with local keys like the generic signer
+// of notation-go, adding extended signed attributes to the signature
+// envelope. Keys of which the private key is not accessible, e.g. keys in
+// hardware security modules, sign through their crypto.Signer.
+package localsigner
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/notaryproject/notation-core-go/signature"
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation-go/log"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// signingAgent is the signing agent of the signatures, same as the generic
+// signer of notation-go.
+const signingAgent = "Notation/1.0.0"
+
+// mediaTypePayloadV1 is the media type of the signature payload.
+const mediaTypePayloadV1 = "application/vnd.cncf.notary.payload.v1+json"
+
+// payload is the signature payload.
+type payload struct {
+	TargetArtifact ocispec.Descriptor `json:"targetArtifact"`
+}
+
+// Signer signs artifacts with a local key and adds the extended signed
+// attributes to the signature envelope.
+type Signer struct {
+	signer     signature.Signer
+	certs      []*x509.Certificate
+	attributes []signature.Attribute
+}
+
+// New returns a Signer signing with key and the certificate chain certs,
+// ordered from the signing certificate to the root certificate.
+func New(key crypto.PrivateKey, certs []*x509.Certificate, attributes ...signature.Attribute) (*Signer, error) {
+	localSigner, err := signature.NewLocalSigner(certs, key)
+	if err != nil {
+		return nil, err
+	}
+	return &Signer{
+		signer:     localSigner,
+		certs:      certs,
+		attributes: attributes,
+	}, nil
+}
+
+// NewFromCryptoSigner returns a Signer signing with key, of which the private
+// key is not accessible, and the certificate chain certs, ordered from the
+// signing certificate to the root certificate.
+func NewFromCryptoSigner(key crypto.Signer, certs []*x509.Certificate, attributes ...signature.Attribute) (*Signer, error) {
+	if len(certs) == 0 {
+		return nil, errors.New("empty certificate chain")
+	}
+	keySpec, err := signature.ExtractKeySpec(certs[0])
+	if err != nil {
+		return nil, err
+	}
+	publicKey, ok := key.Public().(interface{ Equal(crypto.PublicKey) bool })
+	if !ok || !publicKey.Equal(certs[0].PublicKey) {
+		return nil, fmt.Errorf("the key does not match the signing certificate %q", certs[0].Subject)
+	}
+	return &Signer{
+		signer:     &cryptoSigner{key: key, keySpec: keySpec, certs: certs},
+		certs:      certs,
+		attributes: attributes,
+	}, nil
+}
+
+// CertificateChain returns the certificate chain of the signer.
+func (s *Signer) CertificateChain() ([]*x509.Certificate, error) {
+	return s.certs, nil
+}
+
+// Sign signs the artifact described by its descriptor and returns the
+// marshalled envelope.
+func (s *Signer) Sign(ctx context.Context, desc ocispec.Descriptor, opts notation.SignerSignOptions) ([]byte, *signature.SignerInfo, error) {
+	logger := log.GetLogger(ctx)
+	logger.Debugf("Local signing for %v in signature media type %v", desc.Digest, opts.SignatureMediaType)
+	payloadBytes, err := json.Marshal(payload{TargetArtifact: ocispec.Descriptor{
+		MediaType:   desc.MediaType,
+		Digest:      desc.Digest,
+		Size:        desc.Size,
+		Annotations: desc.Annotations,
+	}})
+	if err != nil {
+		return nil, nil, fmt.Errorf("envelope payload can't be marshalled: %w", err)
+	}
+	agent := opts.SigningAgent
+	if agent == "" {
+		agent = signingAgent
+	}
+	signReq := &signature.SignRequest{
+		Payload: signature.Payload{
+			ContentType: mediaTypePayloadV1,
+			Content:     payloadBytes,
+		},
+		Signer:                   s.signer,
+		SigningTime:              time.Now(),
+		SigningScheme:            signature.SigningSchemeX509,
+		SigningAgent:             agent,
+		ExtendedSignedAttributes: s.attributes,
+	}
+	if opts.ExpiryDuration != 0 {
+		signReq.Expiry = signReq.SigningTime.Add(opts.ExpiryDuration)
+	}
+
+	sigEnv, err := signature.NewEnvelope(opts.SignatureMediaType)
+	if err != nil {
+		return nil, nil, err
+	}
+	sig, err := sigEnv.Sign(signReq)
+	if err != nil {
+		return nil, nil, err
+	}
+	envContent, err := sigEnv.Verify()
+	if err != nil {
+		return nil, nil, fmt.Errorf("generated signature failed verification: %v", err)
+	}
+	if envContent.Payload.ContentType != mediaTypePayloadV1 {
+		return nil, nil, errors.New("generated signature has an unexpected payload content type")
+	}
+	return sig, &envContent.SignerInfo, nil
+}
+
+// cryptoSigner is a signature.Signer signing with a crypto.Signer. The
+// signature envelopes hand the payload to sign to signers without private
+// key, which return the raw signature: RSASSA-PSS for RSA keys, and the
+// concatenation of r and s for ECDSA keys.
+type cryptoSigner struct {
+	key     crypto.Signer
+	keySpec signature.KeySpec
+	certs   []*x509.Certificate
+}
+
+// Sign hashes and signs payload.
+func (s *cryptoSigner) Sign(payload []byte) ([]byte, []*x509.Certificate, error) {
+	hash := s.keySpec.SignatureAlgorithm().Hash()
+	if !hash.Available() {
+		return nil, nil, fmt.Errorf("hash algorithm of key spec %v is not available", s.keySpec)
+	}
+	h := hash.New()
+	h.Write(payload)
+	digest := h.Sum(nil)
+	switch s.keySpec.Type {
+	case signature.KeyTypeRSA:
+		sig, err := s.key.Sign(rand.Reader, digest, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: hash})
+		if err != nil {
+			return nil, nil, err
+		}
+		return sig, s.certs, nil
+	case signature.KeyTypeEC:
+		der, err := s.key.Sign(rand.Reader, digest, hash)
+		if err != nil {
+			return nil, nil, err
+		}
+		var sig struct {
+			R, S *big.Int
+		}
+		if _, err := asn1.Unmarshal(der, &sig); err != nil {
+			return nil, nil, fmt.Errorf("malformed ECDSA signature: %w", err)
+		}
+		size := (s.keySpec.Size + 7) / 8
+		raw := make([]byte, 2*size)
+		sig.R.FillBytes(raw[:size])
+		sig.S.FillBytes(raw[size:])
+		return raw, s.certs, nil
+	}
+	return nil, nil, fmt.Errorf("unsupported key type %v", s.keySpec.Type)
+}
+
+// KeySpec returns the key spec of the signing certificate.
+func (s *cryptoSigner) KeySpec() (signature.KeySpec, error) {
+	return s.keySpec, nil
+}
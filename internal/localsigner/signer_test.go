@@ -0,0 +1,243 @@
+package localsigner
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/notation-core-go/signature"
+	"github.com/notaryproject/notation-core-go/signature/cose"
+	"github.com/notaryproject/notation-core-go/signature/jws"
+	"github.com/notaryproject/notation-go"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+func TestSigner_ExtendedAttributes(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test", Organization: []string{"Notary"}, Country: []string{"US"}, Province: []string{"WA"}, Locality: []string{"Seattle"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		BasicConstraintsValid: true,
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(certDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	attr := signature.Attribute{Key: "io.cncf.notary.x-test", Value: []string{"a", "b"}}
+	s, err := New(key, []*x509.Certificate{cert}, attr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	desc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    digest.FromString("artifact"),
+		Size:      8,
+	}
+	for _, mediaType := range []string{jws.MediaTypeEnvelope, cose.MediaTypeEnvelope} {
+		t.Run(mediaType, func(t *testing.T) {
+			sig, signerInfo, err := s.Sign(context.Background(), desc, notation.SignerSignOptions{SignatureMediaType: mediaType, ExpiryDuration: time.Hour})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if signerInfo.UnsignedAttributes.SigningAgent != signingAgent {
+				t.Fatalf("expected signing agent %q, got %q", signingAgent, signerInfo.UnsignedAttributes.SigningAgent)
+			}
+			env, err := signature.ParseEnvelope(mediaType, sig)
+			if err != nil {
+				t.Fatal(err)
+			}
+			content, err := env.Verify()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var found bool
+			for _, got := range content.SignerInfo.SignedAttributes.ExtendedAttributes {
+				if got.Key == attr.Key {
+					found = true
+					if !reflect.DeepEqual(got.Value, []interface{}{"a", "b"}) {
+						t.Fatalf("unexpected attribute value %#v", got.Value)
+					}
+				}
+			}
+			if !found {
+				t.Fatalf("attribute %q not found in %+v", attr.Key, content.SignerInfo.SignedAttributes)
+			}
+		})
+	}
+}
+
+// TestSigner_HashAlgorithms tests that signatures hashing the payload with
+// each hash algorithm allowed by the signature specification are produced and
+// verified in both envelope formats.
+func TestSigner_HashAlgorithms(t *testing.T) {
+	ec := func(curve elliptic.Curve) func() (crypto.Signer, error) {
+		return func() (crypto.Signer, error) { return ecdsa.GenerateKey(curve, rand.Reader) }
+	}
+	tests := []struct {
+		name     string
+		generate func() (crypto.Signer, error)
+		want     crypto.Hash
+	}{
+		{name: "EC-256", generate: ec(elliptic.P256()), want: crypto.SHA256},
+		{name: "EC-384", generate: ec(elliptic.P384()), want: crypto.SHA384},
+		{name: "EC-521", generate: ec(elliptic.P521()), want: crypto.SHA512},
+		{name: "RSA-3072", generate: func() (crypto.Signer, error) { return rsa.GenerateKey(rand.Reader, 3072) }, want: crypto.SHA384},
+	}
+	desc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    digest.FromString("artifact"),
+		Size:      8,
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := tt.generate()
+			if err != nil {
+				t.Fatal(err)
+			}
+			template := &x509.Certificate{
+				SerialNumber:          big.NewInt(1),
+				Subject:               pkix.Name{CommonName: "test", Organization: []string{"Notary"}, Country: []string{"US"}, Province: []string{"WA"}, Locality: []string{"Seattle"}},
+				NotBefore:             time.Now().Add(-time.Hour),
+				NotAfter:              time.Now().Add(time.Hour),
+				KeyUsage:              x509.KeyUsageDigitalSignature,
+				ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+				BasicConstraintsValid: true,
+			}
+			certDER, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cert, err := x509.ParseCertificate(certDER)
+			if err != nil {
+				t.Fatal(err)
+			}
+			s, err := New(key, []*x509.Certificate{cert})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, mediaType := range []string{jws.MediaTypeEnvelope, cose.MediaTypeEnvelope} {
+				sig, _, err := s.Sign(context.Background(), desc, notation.SignerSignOptions{SignatureMediaType: mediaType})
+				if err != nil {
+					t.Fatal(err)
+				}
+				env, err := signature.ParseEnvelope(mediaType, sig)
+				if err != nil {
+					t.Fatal(err)
+				}
+				content, err := env.Verify()
+				if err != nil {
+					t.Fatalf("%s: %v", mediaType, err)
+				}
+				if got := content.SignerInfo.SignatureAlgorithm.Hash(); got != tt.want {
+					t.Fatalf("%s: expected payload hashed with %v, got %v", mediaType, tt.want, got)
+				}
+			}
+		})
+	}
+}
+
+// opaqueSigner hides the private key of the crypto.Signer, as keys in
+// hardware security modules do.
+type opaqueSigner struct {
+	key crypto.Signer
+}
+
+func (s *opaqueSigner) Public() crypto.PublicKey {
+	return s.key.Public()
+}
+
+func (s *opaqueSigner) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	return s.key.Sign(rand, digest, opts)
+}
+
+func TestNewFromCryptoSigner(t *testing.T) {
+	ec := func(curve elliptic.Curve) func() (crypto.Signer, error) {
+		return func() (crypto.Signer, error) { return ecdsa.GenerateKey(curve, rand.Reader) }
+	}
+	tests := []struct {
+		name     string
+		generate func() (crypto.Signer, error)
+	}{
+		{name: "EC-256", generate: ec(elliptic.P256())},
+		{name: "EC-521", generate: ec(elliptic.P521())},
+		{name: "RSA-2048", generate: func() (crypto.Signer, error) { return rsa.GenerateKey(rand.Reader, 2048) }},
+	}
+	desc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    digest.FromString("artifact"),
+		Size:      8,
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := tt.generate()
+			if err != nil {
+				t.Fatal(err)
+			}
+			template := &x509.Certificate{
+				SerialNumber:          big.NewInt(1),
+				Subject:               pkix.Name{CommonName: "test", Organization: []string{"Notary"}, Country: []string{"US"}, Province: []string{"WA"}, Locality: []string{"Seattle"}},
+				NotBefore:             time.Now().Add(-time.Hour),
+				NotAfter:              time.Now().Add(time.Hour),
+				KeyUsage:              x509.KeyUsageDigitalSignature,
+				ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+				BasicConstraintsValid: true,
+			}
+			certDER, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cert, err := x509.ParseCertificate(certDER)
+			if err != nil {
+				t.Fatal(err)
+			}
+			s, err := NewFromCryptoSigner(&opaqueSigner{key: key}, []*x509.Certificate{cert})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, mediaType := range []string{jws.MediaTypeEnvelope, cose.MediaTypeEnvelope} {
+				sig, _, err := s.Sign(context.Background(), desc, notation.SignerSignOptions{SignatureMediaType: mediaType})
+				if err != nil {
+					t.Fatalf("%s: %v", mediaType, err)
+				}
+				env, err := signature.ParseEnvelope(mediaType, sig)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if _, err := env.Verify(); err != nil {
+					t.Fatalf("%s: %v", mediaType, err)
+				}
+			}
+
+			// the key must match the signing certificate
+			other, err := tt.generate()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := NewFromCryptoSigner(&opaqueSigner{key: other}, []*x509.Certificate{cert}); err == nil {
+				t.Fatal("expected error for a key not matching the signing certificate")
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+// Package metadata evaluates assertions on the user metadata of signatures.
+package metadata
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Operator is the operator of an assertion.
+type Operator string
+
+// supported operators, two-character operators are matched first.
+// Operators starting with "=" are not supported, as {key}={value} has always
+// been an exact match of the value, e.g. "key=~value" matches "~value".
+const (
+	OperatorPresent      Operator = ""
+	OperatorEqual        Operator = "="
+	OperatorNotEqual     Operator = "!="
+	OperatorRegexp       Operator = "~~"
+	OperatorGlob         Operator = "~"
+	OperatorGreater      Operator = ">"
+	OperatorGreaterEqual Operator = ">="
+	OperatorLess         Operator = "<"
+	OperatorLessEqual    Operator = "<="
+)
+
+// operators in the order they are matched
+var operators = []Operator{
+	OperatorGreaterEqual,
+	OperatorLessEqual,
+	OperatorNotEqual,
+	OperatorRegexp,
+	OperatorEqual,
+	OperatorGlob,
+	OperatorGreater,
+	OperatorLess,
+}
+
+// Assertion is an assertion on the value of a user metadata key.
+type Assertion struct {
+	Key      string
+	Operator Operator
+	Value    string
+
+	re     *regexp.Regexp
+	number float64
+}
+
+// ParseAssertion parses an assertion in the format of {key}, {key}={value},
+// {key}!={value}, {key}~~{regexp}, {key}~{glob}, or {key}{op}{number} where
+// {op} is one of >, >=, <, <=.
+func ParseAssertion(s string) (Assertion, error) {
+	i := strings.IndexAny(s, "=!~<>")
+	if i == -1 {
+		if s == "" {
+			return Assertion{}, fmt.Errorf("empty user metadata assertion")
+		}
+		return Assertion{Key: s, Operator: OperatorPresent}, nil
+	}
+	a := Assertion{Key: s[:i]}
+	if a.Key == "" {
+		return Assertion{}, fmt.Errorf("malformed user metadata assertion %q: missing key", s)
+	}
+	rest := s[i:]
+	for _, op := range operators {
+		if strings.HasPrefix(rest, string(op)) {
+			a.Operator = op
+			a.Value = rest[len(op):]
+			break
+		}
+	}
+	if a.Operator == OperatorPresent {
+		return Assertion{}, fmt.Errorf("malformed user metadata assertion %q: unknown operator", s)
+	}
+	if a.Value == "" {
+		return Assertion{}, fmt.Errorf("malformed user metadata assertion %q: missing value", s)
+	}
+	var err error
+	switch a.Operator {
+	case OperatorRegexp:
+		if a.re, err = regexp.Compile("^(?:" + a.Value + ")$"); err != nil {
+			return Assertion{}, fmt.Errorf("malformed user metadata assertion %q: %w", s, err)
+		}
+	case OperatorGlob:
+		if _, err = path.Match(a.Value, ""); err != nil {
+			return Assertion{}, fmt.Errorf("malformed user metadata assertion %q: %w", s, err)
+		}
+	case OperatorGreater, OperatorGreaterEqual, OperatorLess, OperatorLessEqual:
+		if a.number, err = strconv.ParseFloat(a.Value, 64); err != nil {
+			return Assertion{}, fmt.Errorf("malformed user metadata assertion %q: %q is not a number", s, a.Value)
+		}
+	}
+	return a, nil
+}
+
+// ParseAssertions parses the assertions given by the flag with flagName.
+func ParseAssertions(values []string, flagName string) ([]Assertion, error) {
+	assertions := make([]Assertion, 0, len(values))
+	for _, value := range values {
+		a, err := ParseAssertion(value)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse flag %s: %w", flagName, err)
+		}
+		assertions = append(assertions, a)
+	}
+	return assertions, nil
+}
+
+// String returns the assertion in the format it is parsed from.
+func (a Assertion) String() string {
+	return a.Key + string(a.Operator) + a.Value
+}
+
+// Check checks the assertion against the user metadata of a signature.
+func (a Assertion) Check(metadata map[string]string) error {
+	value, ok := metadata[a.Key]
+	if !ok {
+		return fmt.Errorf("user metadata assertion %q failed: key %q is not present in the signature", a, a.Key)
+	}
+	var matched bool
+	switch a.Operator {
+	case OperatorPresent:
+		matched = true
+	case OperatorEqual:
+		matched = value == a.Value
+	case OperatorNotEqual:
+		matched = value != a.Value
+	case OperatorRegexp:
+		matched = a.re.MatchString(value)
+	case OperatorGlob:
+		matched, _ = path.Match(a.Value, value)
+	default:
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("user metadata assertion %q failed: value %q of key %q is not a number", a, value, a.Key)
+		}
+		switch a.Operator {
+		case OperatorGreater:
+			matched = number > a.number
+		case OperatorGreaterEqual:
+			matched = number >= a.number
+		case OperatorLess:
+			matched = number < a.number
+		case OperatorLessEqual:
+			matched = number <= a.number
+		}
+	}
+	if !matched {
+		return fmt.Errorf("user metadata assertion %q failed: the value of key %q in the signature is %q", a, a.Key, value)
+	}
+	return nil
+}
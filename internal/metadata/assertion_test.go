@@ -0,0 +1,69 @@
+package metadata
+
+import "testing"
+
+func TestParseAssertion(t *testing.T) {
+	tests := map[string]Assertion{
+		"team":                   {Key: "team", Operator: OperatorPresent},
+		"team=payments":          {Key: "team", Operator: OperatorEqual, Value: "payments"},
+		"team!=payments":         {Key: "team", Operator: OperatorNotEqual, Value: "payments"},
+		"branch~~release/.*":     {Key: "branch", Operator: OperatorRegexp, Value: "release/.*"},
+		"branch=~release":        {Key: "branch", Operator: OperatorEqual, Value: "~release"},
+		"branch~release/*":       {Key: "branch", Operator: OperatorGlob, Value: "release/*"},
+		"buildEpoch>=1700000000": {Key: "buildEpoch", Operator: OperatorGreaterEqual, Value: "1700000000"},
+		"buildEpoch<2":           {Key: "buildEpoch", Operator: OperatorLess, Value: "2"},
+		"url=https://a.io/?x=1":  {Key: "url", Operator: OperatorEqual, Value: "https://a.io/?x=1"},
+	}
+	for s, expected := range tests {
+		a, err := ParseAssertion(s)
+		if err != nil {
+			t.Fatalf("ParseAssertion(%q): unexpected error: %v", s, err)
+		}
+		if a.Key != expected.Key || a.Operator != expected.Operator || a.Value != expected.Value {
+			t.Fatalf("ParseAssertion(%q) = %+v, expected %+v", s, a, expected)
+		}
+		if a.String() != s {
+			t.Fatalf("expected String() to be %q, got %q", s, a.String())
+		}
+	}
+
+	for _, s := range []string{"", "=value", "key=", "key>abc", "key~~(", "key~[", "key!value"} {
+		if _, err := ParseAssertion(s); err == nil {
+			t.Fatalf("ParseAssertion(%q): expected error, got nil", s)
+		}
+	}
+}
+
+func TestAssertionCheck(t *testing.T) {
+	metadata := map[string]string{
+		"team":       "payments",
+		"branch":     "release/1.2",
+		"buildEpoch": "1700000001",
+		"tilde":      "~release",
+	}
+	tests := map[string]bool{
+		"team":                    true,
+		"owner":                   false,
+		"team=payments":           true,
+		"team=billing":            false,
+		"team!=billing":           true,
+		"branch~~release/[0-9.]+": true,
+		"branch~~release":         false,
+		"tilde=~release":          true,
+		"tilde=~release/.*":       false,
+		"branch~release/*":        true,
+		"branch~main":             false,
+		"buildEpoch>=1700000000":  true,
+		"buildEpoch<1700000000":   false,
+		"team>1":                  false,
+	}
+	for s, expected := range tests {
+		a, err := ParseAssertion(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := a.Check(metadata); (err == nil) != expected {
+			t.Fatalf("Check(%q): expected match %v, got error %v", s, expected, err)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package metadata
+
+import (
+	"context"
+
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+	"github.com/notaryproject/notation/internal/skipper"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// Verifier wraps a notation.Verifier and checks the user metadata assertions
+// against each signature verified by the wrapped verifier.
+type Verifier struct {
+	base       notation.Verifier
+	assertions []Assertion
+}
+
+// NewVerifier returns a Verifier checking assertions. base is returned if
+// there is no assertion.
+func NewVerifier(base notation.Verifier, assertions []Assertion) notation.Verifier {
+	if len(assertions) == 0 {
+		return base
+	}
+	return &Verifier{
+		base:       base,
+		assertions: assertions,
+	}
+}
+
+// SkipVerify validates whether the verification level is skip.
+func (v *Verifier) SkipVerify(ctx context.Context, opts notation.VerifierVerifyOptions) (bool, *trustpolicy.VerificationLevel, error) {
+	return skipper.SkipVerify(ctx, v.base, opts)
+}
+
+// Verify verifies the signature with the wrapped verifier and then checks the
+// assertions against the user metadata of the signature.
+//
+// A failed assertion is reported as notation.ErrorUserMetadataVerificationFailed
+// so that it is returned by notation.Verify if no signature is verified.
+func (v *Verifier) Verify(ctx context.Context, desc ocispec.Descriptor, signature []byte, opts notation.VerifierVerifyOptions) (*notation.VerificationOutcome, error) {
+	outcome, err := v.base.Verify(ctx, desc, signature, opts)
+	if err != nil || outcome == nil || outcome.EnvelopeContent == nil {
+		return outcome, err
+	}
+	metadata, err := outcome.UserMetadata()
+	if err != nil {
+		outcome.Error = err
+		return outcome, err
+	}
+	for _, a := range v.assertions {
+		if err := a.Check(metadata); err != nil {
+			outcome.Error = notation.ErrorUserMetadataVerificationFailed{Msg: err.Error()}
+			return outcome, outcome.Error
+		}
+	}
+	return outcome, nil
+}
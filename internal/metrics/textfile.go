@@ -0,0 +1,159 @@
+// Package metrics writes the outcomes of batch verifications as metrics in the
+// text format of Prometheus, to be exposed by the textfile collector of the
+// node exporter, for monitoring without an OpenTelemetry collector.
+package metrics
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/notaryproject/notation/internal/osutil"
+)
+
+// TextfileExt is the file extension the textfile collector of the node
+// exporter reads metrics from, ignoring any other file.
+const TextfileExt = ".prom"
+
+// Operation is the batch operation reporting the results.
+type Operation string
+
+const (
+	// OperationVerifyAllTags is "notation verify --all-tags".
+	OperationVerifyAllTags Operation = "verify_all_tags"
+
+	// OperationAuditRun is "notation audit run".
+	OperationAuditRun Operation = "audit_run"
+)
+
+// Result is the outcome of the verification of a repository by a batch
+// operation.
+type Result struct {
+	// Name identifies the result among the results of the same repository,
+	// e.g. the name of an audit, and is the label "audit" if not empty.
+	Name string
+
+	// Repository is the repository verified.
+	Repository string
+
+	// Verified is the number of artifacts verified successfully.
+	Verified int
+
+	// Failed is the number of artifacts failing verification.
+	Failed int
+
+	// Duration is the time taken to verify the repository.
+	Duration time.Duration
+
+	// Err is the error aborting the verification of the repository, if any.
+	Err error
+}
+
+// family is a metric family of the results.
+type family struct {
+	name  string
+	help  string
+	typ   string
+	value func(Result) string
+}
+
+var families = []family{
+	{
+		name:  "notation_verified_total",
+		help:  "Number of artifacts verified successfully by the last run of the batch operation.",
+		typ:   "counter",
+		value: func(r Result) string { return strconv.Itoa(r.Verified) },
+	},
+	{
+		name:  "notation_failed_total",
+		help:  "Number of artifacts failing verification in the last run of the batch operation.",
+		typ:   "counter",
+		value: func(r Result) string { return strconv.Itoa(r.Failed) },
+	},
+	{
+		name:  "notation_duration_seconds",
+		help:  "Duration of the last run of the batch operation in seconds.",
+		typ:   "gauge",
+		value: func(r Result) string { return strconv.FormatFloat(r.Duration.Seconds(), 'f', -1, 64) },
+	},
+	{
+		name: "notation_success",
+		help: "Whether the last run of the batch operation completed with every artifact verified successfully (1) or not (0).",
+		typ:  "gauge",
+		value: func(r Result) string {
+			if r.Err == nil && r.Failed == 0 {
+				return "1"
+			}
+			return "0"
+		},
+	},
+}
+
+// Format formats the results of the batch operation, finished at timestamp,
+// in the text format of Prometheus. The samples are labeled with the
+// operation, the name if any, and the repository, and sorted by repository
+// and name.
+func Format(operation Operation, results []Result, timestamp time.Time) []byte {
+	results = append([]Result(nil), results...)
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Repository != results[j].Repository {
+			return results[i].Repository < results[j].Repository
+		}
+		return results[i].Name < results[j].Name
+	})
+	var buf bytes.Buffer
+	for _, f := range families {
+		fmt.Fprintf(&buf, "# HELP %s %s\n", f.name, f.help)
+		fmt.Fprintf(&buf, "# TYPE %s %s\n", f.name, f.typ)
+		for _, result := range results {
+			fmt.Fprintf(&buf, "%s{%s} %s\n", f.name, labels(operation, result), f.value(result))
+		}
+	}
+	buf.WriteString("# HELP notation_last_run_timestamp_seconds Unix time the last run of the batch operation finished.\n")
+	buf.WriteString("# TYPE notation_last_run_timestamp_seconds gauge\n")
+	fmt.Fprintf(&buf, "notation_last_run_timestamp_seconds{operation=\"%s\"} %d\n", escapeLabelValue(string(operation)), timestamp.Unix())
+	return buf.Bytes()
+}
+
+// WriteTextfile writes the results of the batch operation to path, replacing
+// the file atomically, so that the node exporter never reads a partial file.
+func WriteTextfile(path string, operation Operation, results []Result) error {
+	if err := ValidateTextfilePath(path); err != nil {
+		return err
+	}
+	data := Format(operation, results, time.Now())
+	// the node exporter usually runs as another user
+	return osutil.WriteFileAtomic(path, data, 0644)
+}
+
+// ValidateTextfilePath validates that path has the file extension read by the
+// textfile collector.
+func ValidateTextfilePath(path string) error {
+	if path == "" {
+		return errors.New("metrics textfile path is empty")
+	}
+	if filepath.Ext(path) != TextfileExt {
+		return fmt.Errorf("metrics textfile %s must have the extension %q to be read by the textfile collector of the node exporter", path, TextfileExt)
+	}
+	return nil
+}
+
+// labels returns the labels of the samples of the result.
+func labels(operation Operation, result Result) string {
+	labels := fmt.Sprintf("operation=\"%s\"", escapeLabelValue(string(operation)))
+	if result.Name != "" {
+		labels += fmt.Sprintf(",audit=\"%s\"", escapeLabelValue(result.Name))
+	}
+	return labels + fmt.Sprintf(",repository=\"%s\"", escapeLabelValue(result.Repository))
+}
+
+// escapeLabelValue escapes the backslashes, double quotes and line feeds of a
+// label value.
+func escapeLabelValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
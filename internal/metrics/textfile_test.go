@@ -0,0 +1,95 @@
+package metrics
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormat(t *testing.T) {
+	results := []Result{
+		{Repository: "registry.example.com/b", Verified: 2, Failed: 1, Duration: 1500 * time.Millisecond},
+		{Name: "staging", Repository: "registry.example.com/a", Verified: 1, Duration: time.Second},
+		{Repository: "registry.example.com/a", Verified: 3, Duration: 2 * time.Second},
+		{Repository: `registry.example.com/"c"`, Err: errors.New("failed to list tags")},
+	}
+	got := string(Format(OperationAuditRun, results, time.Unix(1700000000, 0)))
+	want := `# HELP notation_verified_total Number of artifacts verified successfully by the last run of the batch operation.
+# TYPE notation_verified_total counter
+notation_verified_total{operation="audit_run",repository="registry.example.com/\"c\""} 0
+notation_verified_total{operation="audit_run",repository="registry.example.com/a"} 3
+notation_verified_total{operation="audit_run",audit="staging",repository="registry.example.com/a"} 1
+notation_verified_total{operation="audit_run",repository="registry.example.com/b"} 2
+# HELP notation_failed_total Number of artifacts failing verification in the last run of the batch operation.
+# TYPE notation_failed_total counter
+notation_failed_total{operation="audit_run",repository="registry.example.com/\"c\""} 0
+notation_failed_total{operation="audit_run",repository="registry.example.com/a"} 0
+notation_failed_total{operation="audit_run",audit="staging",repository="registry.example.com/a"} 0
+notation_failed_total{operation="audit_run",repository="registry.example.com/b"} 1
+# HELP notation_duration_seconds Duration of the last run of the batch operation in seconds.
+# TYPE notation_duration_seconds gauge
+notation_duration_seconds{operation="audit_run",repository="registry.example.com/\"c\""} 0
+notation_duration_seconds{operation="audit_run",repository="registry.example.com/a"} 2
+notation_duration_seconds{operation="audit_run",audit="staging",repository="registry.example.com/a"} 1
+notation_duration_seconds{operation="audit_run",repository="registry.example.com/b"} 1.5
+# HELP notation_success Whether the last run of the batch operation completed with every artifact verified successfully (1) or not (0).
+# TYPE notation_success gauge
+notation_success{operation="audit_run",repository="registry.example.com/\"c\""} 0
+notation_success{operation="audit_run",repository="registry.example.com/a"} 1
+notation_success{operation="audit_run",audit="staging",repository="registry.example.com/a"} 1
+notation_success{operation="audit_run",repository="registry.example.com/b"} 0
+# HELP notation_last_run_timestamp_seconds Unix time the last run of the batch operation finished.
+# TYPE notation_last_run_timestamp_seconds gauge
+notation_last_run_timestamp_seconds{operation="audit_run"} 1700000000
+`
+	if got != want {
+		t.Fatalf("Format() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestWriteTextfile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "notation.prom")
+	if err := os.WriteFile(path, []byte("stale"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	results := []Result{{Repository: "registry.example.com/a", Verified: 1}}
+	if err := WriteTextfile(path, OperationVerifyAllTags, results); err != nil {
+		t.Fatalf("WriteTextfile() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `notation_verified_total{operation="verify_all_tags",repository="registry.example.com/a"} 1`) {
+		t.Fatalf("unexpected textfile:\n%s", data)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0644 {
+		t.Fatalf("textfile permission = %v, want 0644", perm)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("temporary files are left in %s: %v", dir, entries)
+	}
+}
+
+func TestValidateTextfilePath(t *testing.T) {
+	for _, path := range []string{"", "notation.txt", "notation.prom.tmp"} {
+		if err := ValidateTextfilePath(path); err == nil {
+			t.Errorf("ValidateTextfilePath(%q) expects error", path)
+		}
+	}
+	if err := ValidateTextfilePath("/var/lib/node_exporter/notation.prom"); err != nil {
+		t.Errorf("ValidateTextfilePath() error = %v", err)
+	}
+}
@@ -0,0 +1,236 @@
+package ocilayout
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// dockerManifestFile is the file listing the images of a docker save archive.
+const dockerManifestFile = "manifest.json"
+
+// blobsDir is the directory of the blobs in an OCI layout.
+const blobsDir = "blobs"
+
+// IsDockerArchive reports whether dir is an extracted docker save archive
+// without an OCI layout, i.e. created by Docker before 25.
+func IsDockerArchive(dir string) (bool, error) {
+	if _, err := os.Stat(filepath.Join(dir, indexFile)); err == nil {
+		return false, nil
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return false, err
+	}
+	if _, err := os.Stat(filepath.Join(dir, dockerManifestFile)); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// ConvertDockerArchive converts the extracted docker save archive in dir,
+// created by Docker before 25 without an OCI layout, in place to the format of
+// docker save of Docker 25 and later, which is an OCI layout still loaded by
+// docker load:
+//   - the configs and the layers of the images are moved to blobs/sha256,
+//     where manifest.json refers to them;
+//   - an OCI image manifest is created for every image, listed in index.json
+//     with the tags of the image as "org.opencontainers.image.ref.name".
+//
+// The layers are uncompressed, so the manifest digests differ from the
+// digests of the images pushed by docker push.
+func ConvertDockerArchive(dir string) error {
+	manifestPath := filepath.Join(dir, dockerManifestFile)
+	manifestJSON, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return err
+	}
+	// unknown fields of the images are kept
+	var images []map[string]json.RawMessage
+	if err := json.Unmarshal(manifestJSON, &images); err != nil {
+		return fmt.Errorf("malformed %s: %w", dockerManifestFile, err)
+	}
+	if len(images) == 0 {
+		return fmt.Errorf("no image found in %s", dockerManifestFile)
+	}
+
+	// blobs are shared by images, e.g. base layers
+	moved := make(map[string]ocispec.Descriptor)
+	moveBlob := func(name, mediaType string) (ocispec.Descriptor, error) {
+		name = path.Clean(name)
+		if desc, ok := moved[name]; ok {
+			return desc, nil
+		}
+		if !fs.ValidPath(name) {
+			return ocispec.Descriptor{}, fmt.Errorf("invalid path %q in %s", name, dockerManifestFile)
+		}
+		desc, err := moveToBlobs(dir, filepath.FromSlash(name), mediaType)
+		if err != nil {
+			return ocispec.Descriptor{}, err
+		}
+		moved[name] = desc
+		return desc, nil
+	}
+
+	index := ocispec.Index{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: ocispec.MediaTypeImageIndex,
+		Manifests: []ocispec.Descriptor{},
+	}
+	for i, image := range images {
+		var configName string
+		var layerNames, repoTags []string
+		if err := json.Unmarshal(image["Config"], &configName); err != nil || configName == "" {
+			return fmt.Errorf("image %d of %s has no config", i, dockerManifestFile)
+		}
+		if err := json.Unmarshal(image["Layers"], &layerNames); err != nil {
+			return fmt.Errorf("malformed layers of image %d of %s: %w", i, dockerManifestFile, err)
+		}
+		if raw, ok := image["RepoTags"]; ok {
+			if err := json.Unmarshal(raw, &repoTags); err != nil {
+				return fmt.Errorf("malformed tags of image %d of %s: %w", i, dockerManifestFile, err)
+			}
+		}
+
+		config, err := moveBlob(configName, ocispec.MediaTypeImageConfig)
+		if err != nil {
+			return err
+		}
+		manifest := ocispec.Manifest{
+			Versioned: specs.Versioned{SchemaVersion: 2},
+			MediaType: ocispec.MediaTypeImageManifest,
+			Config:    config,
+			Layers:    make([]ocispec.Descriptor, 0, len(layerNames)),
+		}
+		blobNames := make([]string, 0, len(layerNames))
+		for _, layerName := range layerNames {
+			layer, err := moveBlob(layerName, ocispec.MediaTypeImageLayer)
+			if err != nil {
+				return err
+			}
+			manifest.Layers = append(manifest.Layers, layer)
+			blobNames = append(blobNames, blobName(layer.Digest))
+		}
+		manifestDesc, err := writeJSONBlob(dir, ocispec.MediaTypeImageManifest, manifest)
+		if err != nil {
+			return err
+		}
+
+		if len(repoTags) == 0 {
+			index.Manifests = append(index.Manifests, manifestDesc)
+		}
+		for _, repoTag := range repoTags {
+			desc := manifestDesc
+			desc.Annotations = map[string]string{ocispec.AnnotationRefName: tagOf(repoTag)}
+			index.Manifests = append(index.Manifests, desc)
+		}
+
+		if image["Config"], err = json.Marshal(blobName(config.Digest)); err != nil {
+			return err
+		}
+		if image["Layers"], err = json.Marshal(blobNames); err != nil {
+			return err
+		}
+	}
+
+	// the directories of the layers of the legacy format hold their
+	// metadata only after the layers are moved
+	for name := range moved {
+		if layerDir := path.Dir(name); layerDir != "." {
+			if err := os.RemoveAll(filepath.Join(dir, filepath.FromSlash(layerDir))); err != nil {
+				return err
+			}
+		}
+	}
+
+	if manifestJSON, err = json.Marshal(images); err != nil {
+		return err
+	}
+	if err := os.WriteFile(manifestPath, manifestJSON, 0644); err != nil {
+		return err
+	}
+	indexJSON, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, indexFile), indexJSON, 0644); err != nil {
+		return err
+	}
+	layoutJSON, err := json.Marshal(ocispec.ImageLayout{Version: ocispec.ImageLayoutVersion})
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, ocispec.ImageLayoutFile), layoutJSON, 0644)
+}
+
+// moveToBlobs moves the file at name in dir to the blobs of the OCI layout,
+// and returns its descriptor.
+func moveToBlobs(dir, name, mediaType string) (ocispec.Descriptor, error) {
+	src := filepath.Join(dir, name)
+	f, err := os.Open(src)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	digester := digest.Canonical.Digester()
+	size, err := io.Copy(digester.Hash(), f)
+	f.Close()
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	desc := ocispec.Descriptor{
+		MediaType: mediaType,
+		Digest:    digester.Digest(),
+		Size:      size,
+	}
+	dst := filepath.Join(dir, filepath.FromSlash(blobName(desc.Digest)))
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	if err := os.Rename(src, dst); err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	return desc, nil
+}
+
+// writeJSONBlob writes v in JSON as a blob of the OCI layout in dir.
+func writeJSONBlob(dir, mediaType string, v any) (ocispec.Descriptor, error) {
+	content, err := json.Marshal(v)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	desc := ocispec.Descriptor{
+		MediaType: mediaType,
+		Digest:    digest.FromBytes(content),
+		Size:      int64(len(content)),
+	}
+	dst := filepath.Join(dir, filepath.FromSlash(blobName(desc.Digest)))
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	return desc, os.WriteFile(dst, content, 0644)
+}
+
+// blobName returns the path of the blob of dgst in an OCI layout.
+func blobName(dgst digest.Digest) string {
+	return path.Join(blobsDir, dgst.Algorithm().String(), dgst.Encoded())
+}
+
+// tagOf returns the tag of the docker image reference repoTag, e.g. "v1" of
+// "localhost:5000/hello-world:v1".
+func tagOf(repoTag string) string {
+	if i := strings.LastIndex(repoTag, ":"); i != -1 && !strings.Contains(repoTag[i+1:], "/") {
+		return repoTag[i+1:]
+	}
+	return "latest"
+}
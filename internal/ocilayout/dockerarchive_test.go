@@ -0,0 +1,145 @@
+package ocilayout
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/content/oci"
+)
+
+func TestConvertDockerArchive(t *testing.T) {
+	dir := t.TempDir()
+	config := `{"architecture":"amd64","os":"linux","rootfs":{"type":"layers","diff_ids":[]}}`
+	configName := digest.FromString(config).Encoded() + ".json"
+	files := map[string]string{
+		configName:            config,
+		"layer1/layer.tar":    "layer1",
+		"layer1/json":         "{}",
+		"layer1/VERSION":      "1.0",
+		"layer2/layer.tar":    "layer2",
+		"repositories":        `{"hello-world":{"v1":"layer2"}}`,
+		dockerManifestFile:    `[{"Config":"` + configName + `","RepoTags":["localhost:5000/hello-world:v1","hello-world:latest"],"Layers":["layer1/layer.tar","layer2/layer.tar"],"LayerSources":{}}]`,
+		"unrelated/file.json": "{}",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if ok, err := IsDockerArchive(dir); err != nil || !ok {
+		t.Fatalf("IsDockerArchive() = %v, %v, want true", ok, err)
+	}
+	if err := ConvertDockerArchive(dir); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := IsDockerArchive(dir); err != nil || ok {
+		t.Fatalf("IsDockerArchive() after conversion = %v, %v, want false", ok, err)
+	}
+
+	// the images are resolved by their tags in the OCI layout
+	ctx := context.Background()
+	store, err := oci.NewFromFS(ctx, os.DirFS(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	v1, err := store.Resolve(ctx, "v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	latest, err := store.Resolve(ctx, "latest")
+	if err != nil || latest.Digest != v1.Digest {
+		t.Fatalf("Resolve(latest) = %v, %v, want %v", latest, err, v1.Digest)
+	}
+	manifestJSON, err := content.FetchAll(ctx, store, v1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var manifest ocispec.Manifest
+	if err := json.Unmarshal(manifestJSON, &manifest); err != nil {
+		t.Fatal(err)
+	}
+	if manifest.Config.Digest != digest.FromString(config) || len(manifest.Layers) != 2 || manifest.Layers[1].Digest != digest.FromString("layer2") {
+		t.Fatalf("unexpected manifest %s", manifestJSON)
+	}
+	for _, desc := range append(manifest.Layers, manifest.Config) {
+		if _, err := content.FetchAll(ctx, store, desc); err != nil {
+			t.Fatalf("failed to fetch %s: %v", desc.Digest, err)
+		}
+	}
+
+	// manifest.json refers to the blobs for docker load
+	dockerManifestJSON, err := os.ReadFile(filepath.Join(dir, dockerManifestFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var images []struct {
+		Config       string
+		RepoTags     []string
+		Layers       []string
+		LayerSources map[string]any
+	}
+	if err := json.Unmarshal(dockerManifestJSON, &images); err != nil {
+		t.Fatal(err)
+	}
+	if len(images) != 1 || images[0].Config != blobName(manifest.Config.Digest) || images[0].Layers[0] != blobName(manifest.Layers[0].Digest) || len(images[0].RepoTags) != 2 || images[0].LayerSources == nil {
+		t.Fatalf("unexpected %s: %s", dockerManifestFile, dockerManifestJSON)
+	}
+	for _, name := range []string{"layer1", "layer2", configName} {
+		if _, err := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+			t.Fatalf("expected %s to be removed, got %v", name, err)
+		}
+	}
+	for _, name := range []string{"repositories", "unrelated/file.json", ocispec.ImageLayoutFile} {
+		if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(name))); err != nil {
+			t.Fatalf("expected %s to exist: %v", name, err)
+		}
+	}
+}
+
+func TestConvertDockerArchive_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"malformed":     `{}`,
+		"no images":     `[]`,
+		"no config":     `[{"Layers":[]}]`,
+		"missing layer": `[{"Config":"config.json","Layers":["layer/layer.tar"]}]`,
+		"escaping path": `[{"Config":"../config.json","Layers":[]}]`,
+	}
+	for name, dockerManifest := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte("{}"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(dir, dockerManifestFile), []byte(dockerManifest), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := ConvertDockerArchive(dir); err == nil {
+				t.Fatal("expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestTagOf(t *testing.T) {
+	tests := map[string]string{
+		"hello-world:v1":                "v1",
+		"localhost:5000/hello-world:v1": "v1",
+		"localhost:5000/hello-world":    "latest",
+		"hello-world":                   "latest",
+	}
+	for repoTag, want := range tests {
+		if got := tagOf(repoTag); got != want {
+			t.Errorf("tagOf(%q) = %q, want %q", repoTag, got, want)
+		}
+	}
+}
@@ -0,0 +1,285 @@
+// Package ocilayout provides notation specific helpers for content stored as
+// OCI image layout.
+package ocilayout
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/notaryproject/notation/internal/osutil"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// markerAnnotationPrefix is the prefix of the index annotation keys recording
+// verification markers. The key is suffixed with the artifact digest.
+const markerAnnotationPrefix = "io.cncf.notary.x-verification-marker."
+
+// indexFile is the file name of the index in an OCI layout.
+const indexFile = "index.json"
+
+// MarkerTTL is the duration for which a verification marker skips the
+// verification, so that certificates revoked or expired since the
+// verification are checked again.
+const MarkerTTL = 24 * time.Hour
+
+// markerKeySize is the size of the local secret authenticating the markers.
+const markerKeySize = 32
+
+// Marker records a successful verification of an artifact in an OCI layout.
+// An artifact is unchanged since the last verification if the verification
+// inputs and the set of signatures are the same.
+type Marker struct {
+	// PolicyDigest is the digest of the trust policy, the trust store and
+	// the verification options applied.
+	PolicyDigest digest.Digest `json:"policyDigest"`
+
+	// SignaturesDigest is the digest of the signature manifests associated
+	// with the artifact.
+	SignaturesDigest digest.Digest `json:"signaturesDigest"`
+
+	// VerificationLevel is the verification level of the outcome.
+	VerificationLevel string `json:"verificationLevel"`
+
+	// VerifiedAt is the time of the verification.
+	VerifiedAt time.Time `json:"verifiedAt"`
+
+	// MAC is the hex encoded HMAC-SHA256 of the marker and the artifact with
+	// the local secret of the verifier, as the OCI layout recording the
+	// marker is not trusted.
+	MAC string `json:"mac"`
+}
+
+// MarkerInputs are the inputs of a verification affecting its outcome,
+// besides the signatures.
+type MarkerInputs struct {
+	// Policy is the content of the trust policy.
+	Policy []byte
+
+	// Scope is the trust policy scope of the artifact.
+	Scope string
+
+	// TrustStore is the digest of the trust store content.
+	TrustStore digest.Digest
+
+	// UserMetadata are the user metadata assertions of the verification.
+	UserMetadata []string
+
+	// PluginConfig is the configuration passed to verification plugins.
+	PluginConfig map[string]string
+}
+
+// NewMarker creates a marker from the verification inputs and the signature
+// manifests associated with the artifact.
+func NewMarker(inputs MarkerInputs, signatures []ocispec.Descriptor, verificationLevel string) Marker {
+	policyDigester := digest.SHA256.Digester()
+	h := policyDigester.Hash()
+	h.Write(inputs.Policy)
+	fmt.Fprintf(h, "\nscope:%q\ntruststore:%s\n", inputs.Scope, inputs.TrustStore)
+	userMetadata := append([]string(nil), inputs.UserMetadata...)
+	sort.Strings(userMetadata)
+	for _, assertion := range userMetadata {
+		fmt.Fprintf(h, "metadata:%q\n", assertion)
+	}
+	keys := make([]string, 0, len(inputs.PluginConfig))
+	for key := range inputs.PluginConfig {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(h, "plugin-config:%q=%q\n", key, inputs.PluginConfig[key])
+	}
+
+	signaturesDigester := digest.SHA256.Digester()
+	for _, sig := range sortedDigests(signatures) {
+		signaturesDigester.Hash().Write([]byte(sig + "\n"))
+	}
+	return Marker{
+		PolicyDigest:      policyDigester.Digest(),
+		SignaturesDigest:  signaturesDigester.Digest(),
+		VerificationLevel: verificationLevel,
+	}
+}
+
+// Matches returns true if the marker m records the same verification inputs
+// and signatures as other.
+func (m Marker) Matches(other Marker) bool {
+	return m.PolicyDigest == other.PolicyDigest && m.SignaturesDigest == other.SignaturesDigest
+}
+
+// Sign authenticates the marker of the artifact with key, recording
+// verifiedAt as the time of the verification.
+func (m *Marker) Sign(key []byte, artifact digest.Digest, verifiedAt time.Time) {
+	m.VerifiedAt = verifiedAt.UTC()
+	m.MAC = hex.EncodeToString(m.mac(key, artifact))
+}
+
+// Valid returns true if the marker of the artifact is authenticated by key
+// and was recorded within MarkerTTL before now.
+func (m Marker) Valid(key []byte, artifact digest.Digest, now time.Time) bool {
+	mac, err := hex.DecodeString(m.MAC)
+	if err != nil || !hmac.Equal(mac, m.mac(key, artifact)) {
+		return false
+	}
+	return !m.VerifiedAt.After(now) && now.Sub(m.VerifiedAt) < MarkerTTL
+}
+
+func (m Marker) mac(key []byte, artifact digest.Digest) []byte {
+	h := hmac.New(sha256.New, key)
+	fmt.Fprintf(h, "artifact:%s\npolicy:%s\nsignatures:%s\nlevel:%q\nverifiedAt:%s\n", artifact, m.PolicyDigest, m.SignaturesDigest, m.VerificationLevel, m.VerifiedAt.UTC().Format(time.RFC3339Nano))
+	return h.Sum(nil)
+}
+
+// DefaultMarkerKeyPath returns the path of the local secret authenticating
+// the verification markers in the user cache directory.
+func DefaultMarkerKeyPath() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheDir, "notation", "verification-marker.key"), nil
+}
+
+// LoadMarkerKey reads the local secret authenticating the verification
+// markers from path, creating a random secret if it does not exist.
+func LoadMarkerKey(path string) ([]byte, error) {
+	key, err := os.ReadFile(path)
+	if err == nil {
+		if len(key) != markerKeySize {
+			return nil, fmt.Errorf("malformed verification marker key %s", path)
+		}
+		return key, nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read verification marker key: %w", err)
+	}
+	key = make([]byte, markerKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".verification-marker-*.key")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(key); err != nil {
+		tmp.Close()
+		return nil, err
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, err
+	}
+	// the key is linked rather than renamed, so that the key created by a
+	// parallel process is never replaced
+	if err := os.Link(tmp.Name(), path); err != nil {
+		if errors.Is(err, fs.ErrExist) {
+			return LoadMarkerKey(path)
+		}
+		return nil, err
+	}
+	return key, nil
+}
+
+// ReadMarker reads the verification marker of the artifact from the index of
+// the OCI layout at layoutPath. It returns nil if no marker is recorded.
+func ReadMarker(layoutPath string, artifact digest.Digest) (*Marker, error) {
+	index, err := readIndex(layoutPath)
+	if err != nil {
+		return nil, err
+	}
+	var annotations map[string]string
+	if raw, ok := index["annotations"]; ok {
+		if err := json.Unmarshal(raw, &annotations); err != nil {
+			return nil, fmt.Errorf("malformed annotations in OCI layout index: %w", err)
+		}
+	}
+	value, ok := annotations[markerAnnotationPrefix+artifact.String()]
+	if !ok {
+		return nil, nil
+	}
+	var marker Marker
+	if err := json.Unmarshal([]byte(value), &marker); err != nil {
+		// a malformed marker is treated as absent so that the artifact is
+		// verified again
+		return nil, nil
+	}
+	return &marker, nil
+}
+
+// WriteMarker records the verification marker of the artifact in the index of
+// the OCI layout at layoutPath. Other content of the index is preserved.
+func WriteMarker(layoutPath string, artifact digest.Digest, marker Marker) error {
+	index, err := readIndex(layoutPath)
+	if err != nil {
+		return err
+	}
+	annotations := map[string]string{}
+	if raw, ok := index["annotations"]; ok {
+		if err := json.Unmarshal(raw, &annotations); err != nil {
+			return fmt.Errorf("malformed annotations in OCI layout index: %w", err)
+		}
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+	}
+	markerJSON, err := json.Marshal(marker)
+	if err != nil {
+		return err
+	}
+	annotations[markerAnnotationPrefix+artifact.String()] = string(markerJSON)
+	if index["annotations"], err = json.Marshal(annotations); err != nil {
+		return err
+	}
+	indexJSON, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(layoutPath, indexFile), indexJSON)
+}
+
+func readIndex(layoutPath string) (map[string]json.RawMessage, error) {
+	indexJSON, err := os.ReadFile(filepath.Join(layoutPath, indexFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OCI layout index: %w", err)
+	}
+	var index map[string]json.RawMessage
+	if err := json.Unmarshal(indexJSON, &index); err != nil {
+		return nil, fmt.Errorf("malformed OCI layout index: %w", err)
+	}
+	if index == nil {
+		return nil, errors.New("malformed OCI layout index: empty document")
+	}
+	return index, nil
+}
+
+// writeFileAtomic writes data to path atomically, keeping the permissions of
+// the existing file, so that readers never observe a partial index.
+func writeFileAtomic(path string, data []byte) error {
+	perm := fs.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		perm = info.Mode().Perm()
+	}
+	return osutil.WriteFileAtomic(path, data, perm)
+}
+
+func sortedDigests(descs []ocispec.Descriptor) []string {
+	digests := make([]string, 0, len(descs))
+	for _, desc := range descs {
+		digests = append(digests, desc.Digest.String())
+	}
+	sort.Strings(digests)
+	return digests
+}
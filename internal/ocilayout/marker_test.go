@@ -0,0 +1,150 @@
+package ocilayout
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+const testIndex = `{"schemaVersion":2,"manifests":[{"mediaType":"application/vnd.oci.image.manifest.v1+json","digest":"sha256:0d10372ee4448bf319929720bc465c1a6242cc68e82e12c152bc10d541cce578","size":582}]}`
+
+func TestMarker(t *testing.T) {
+	layoutPath := t.TempDir()
+	if err := os.WriteFile(filepath.Join(layoutPath, indexFile), []byte(testIndex), 0644); err != nil {
+		t.Fatal(err)
+	}
+	artifact := digest.FromString("artifact")
+	signatures := []ocispec.Descriptor{
+		{Digest: digest.FromString("sig2")},
+		{Digest: digest.FromString("sig1")},
+	}
+	inputs := MarkerInputs{
+		Policy:       []byte("policy"),
+		Scope:        "local/hello-world",
+		TrustStore:   digest.FromString("truststore"),
+		UserMetadata: []string{"a=1", "b=2"},
+		PluginConfig: map[string]string{"x": "1", "y": "2"},
+	}
+	marker := NewMarker(inputs, signatures, "strict")
+
+	recorded, err := ReadMarker(layoutPath, artifact)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recorded != nil {
+		t.Fatalf("expected no marker, got %+v", recorded)
+	}
+
+	if err := WriteMarker(layoutPath, artifact, marker); err != nil {
+		t.Fatal(err)
+	}
+	recorded, err = ReadMarker(layoutPath, artifact)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recorded == nil || *recorded != marker {
+		t.Fatalf("expected marker %+v, got %+v", marker, recorded)
+	}
+
+	// the order of signatures and user metadata does not matter
+	reorderedInputs := inputs
+	reorderedInputs.UserMetadata = []string{"b=2", "a=1"}
+	reordered := NewMarker(reorderedInputs, []ocispec.Descriptor{signatures[1], signatures[0]}, "")
+	if !recorded.Matches(reordered) {
+		t.Fatal("expected marker to match reordered signatures")
+	}
+	// changed verification inputs or signature set do not match
+	changedInputs := func(change func(*MarkerInputs)) MarkerInputs {
+		changed := inputs
+		change(&changed)
+		return changed
+	}
+	for _, changed := range []Marker{
+		NewMarker(changedInputs(func(in *MarkerInputs) { in.Policy = []byte("new policy") }), signatures, ""),
+		NewMarker(changedInputs(func(in *MarkerInputs) { in.Scope = "local/other" }), signatures, ""),
+		NewMarker(changedInputs(func(in *MarkerInputs) { in.TrustStore = digest.FromString("rotated") }), signatures, ""),
+		NewMarker(changedInputs(func(in *MarkerInputs) { in.UserMetadata = []string{"a=1"} }), signatures, ""),
+		NewMarker(changedInputs(func(in *MarkerInputs) { in.PluginConfig = map[string]string{"x": "1", "y": "3"} }), signatures, ""),
+		NewMarker(inputs, signatures[:1], ""),
+	} {
+		if recorded.Matches(changed) {
+			t.Fatalf("expected marker %+v not to match %+v", recorded, changed)
+		}
+	}
+
+	// the manifests of the index are preserved
+	indexJSON, err := os.ReadFile(filepath.Join(layoutPath, indexFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var index ocispec.Index
+	if err := json.Unmarshal(indexJSON, &index); err != nil {
+		t.Fatal(err)
+	}
+	if len(index.Manifests) != 1 || index.SchemaVersion != 2 {
+		t.Fatalf("unexpected index content: %s", indexJSON)
+	}
+}
+
+func TestMarker_Valid(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+	artifact := digest.FromString("artifact")
+	now := time.Now()
+	marker := NewMarker(MarkerInputs{Policy: []byte("policy")}, []ocispec.Descriptor{{Digest: digest.FromString("sig")}}, "strict")
+	marker.Sign(key, artifact, now)
+	if !marker.Valid(key, artifact, now.Add(time.Minute)) {
+		t.Fatal("expected the signed marker to be valid")
+	}
+
+	// markers precomputed without the local key are not trusted
+	unsigned := marker
+	unsigned.MAC = ""
+	tampered := marker
+	tampered.SignaturesDigest = digest.FromString("other signatures")
+	for name, m := range map[string]Marker{"unsigned": unsigned, "tampered": tampered} {
+		if m.Valid(key, artifact, now) {
+			t.Fatalf("expected the %s marker to be invalid", name)
+		}
+	}
+	if marker.Valid([]byte("another key of thirty-two bytes!"), artifact, now) {
+		t.Fatal("expected the marker to be invalid with another key")
+	}
+	if marker.Valid(key, digest.FromString("other artifact"), now) {
+		t.Fatal("expected the marker to be invalid for another artifact")
+	}
+	if marker.Valid(key, artifact, now.Add(MarkerTTL)) {
+		t.Fatal("expected the expired marker to be invalid")
+	}
+	if marker.Valid(key, artifact, now.Add(-time.Minute)) {
+		t.Fatal("expected the marker recorded in the future to be invalid")
+	}
+}
+
+func TestLoadMarkerKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notation", "verification-marker.key")
+	key, err := LoadMarkerKey(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(key) != markerKeySize {
+		t.Fatalf("expected a key of %d bytes, got %d", markerKeySize, len(key))
+	}
+	loaded, err := LoadMarkerKey(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(loaded) != string(key) {
+		t.Fatal("expected the created key to be loaded again")
+	}
+	if err := os.WriteFile(path, []byte("short"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadMarkerKey(path); err == nil {
+		t.Fatal("expected error for a malformed key")
+	}
+}
@@ -0,0 +1,230 @@
+package ocilayout
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Compression is the compression of a tarball of an OCI layout.
+type Compression int
+
+const (
+	// CompressionNone is an uncompressed tarball, e.g. layout.tar.
+	CompressionNone Compression = iota
+
+	// CompressionGzip is a gzip-compressed tarball, e.g. layout.tar.gz.
+	CompressionGzip
+)
+
+// tarMagicOffset is the offset of the magic "ustar" in the header of a tar
+// archive.
+const tarMagicOffset = 257
+
+// ingestDir is the directory of the OCI layout where the OCI store of
+// oras-go writes the content being pushed.
+const ingestDir = "ingest"
+
+// gzipMagic is the magic number of gzip streams.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// DetectTarball reports whether the file at path is a tarball, and its
+// compression detected from its content regardless of the file extension.
+// Directories are not tarballs.
+func DetectTarball(path string) (bool, Compression, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, CompressionNone, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return false, CompressionNone, err
+	}
+	if info.IsDir() {
+		return false, CompressionNone, nil
+	}
+
+	r := bufio.NewReader(f)
+	compression := CompressionNone
+	if magic, err := r.Peek(len(gzipMagic)); err == nil && bytes.Equal(magic, gzipMagic) {
+		gr, err := gzip.NewReader(r)
+		if err != nil {
+			return false, CompressionNone, nil
+		}
+		defer gr.Close()
+		r = bufio.NewReader(gr)
+		compression = CompressionGzip
+	}
+	header, err := r.Peek(tarMagicOffset + 5)
+	if err != nil {
+		// too short for a tar header
+		return false, CompressionNone, nil
+	}
+	if string(header[tarMagicOffset:]) != "ustar" {
+		return false, CompressionNone, nil
+	}
+	return true, compression, nil
+}
+
+// Extract extracts the tarball at tarballPath with the compression to the
+// directory dir. Only regular files and directories are extracted, and
+// entries escaping dir are rejected.
+func Extract(tarballPath string, compression Compression, dir string) error {
+	f, err := os.Open(tarballPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var r io.Reader = f
+	if compression == CompressionGzip {
+		gr, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer gr.Close()
+		r = gr
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		name := path.Clean(strings.TrimPrefix(header.Name, "./"))
+		if name == "." {
+			continue
+		}
+		if !fs.ValidPath(name) {
+			return fmt.Errorf("invalid entry %q in the tarball", header.Name)
+		}
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := extractFile(tr, target); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unsupported entry %q in the tarball, only regular files and directories are supported", header.Name)
+		}
+	}
+}
+
+// extractFile writes the content of r to the file at target.
+func extractFile(r io.Reader, target string) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Pack writes the directory dir as a tarball with the compression to path.
+// The tarball is written to a temporary file in the same directory and
+// renamed to path, so that the tarball is never observed partially written.
+func Pack(dir string, path string, compression Compression) error {
+	perm := fs.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		perm = info.Mode().Perm()
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".layout-*.tar")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := writeTarball(tmp, dir, compression); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// writeTarball writes the files of dir to w as a tarball with the
+// compression, in lexical order.
+func writeTarball(w io.Writer, dir string, compression Compression) error {
+	var gw *gzip.Writer
+	if compression == CompressionGzip {
+		gw = gzip.NewWriter(w)
+		w = gw
+	}
+	tw := tar.NewWriter(w)
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil || rel == "." {
+			return err
+		}
+		if rel == ingestDir && d.IsDir() {
+			// temporary files of pushes in progress are not packed
+			return filepath.SkipDir
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		if d.IsDir() {
+			header.Name += "/"
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if gw != nil {
+		return gw.Close()
+	}
+	return nil
+}
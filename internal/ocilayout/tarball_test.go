@@ -0,0 +1,87 @@
+package ocilayout
+
+import (
+	"archive/tar"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTarball(t *testing.T) {
+	layoutPath := t.TempDir()
+	files := map[string]string{
+		indexFile:             testIndex,
+		"oci-layout":          `{"imageLayoutVersion":"1.0.0"}`,
+		"blobs/sha256/abc123": "blob",
+	}
+	for name, content := range files {
+		path := filepath.Join(layoutPath, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, compression := range []Compression{CompressionNone, CompressionGzip} {
+		// the compression is detected regardless of the file extension
+		tarball := filepath.Join(t.TempDir(), "layout")
+		if err := Pack(layoutPath, tarball, compression); err != nil {
+			t.Fatal(err)
+		}
+		ok, detected, err := DetectTarball(tarball)
+		if err != nil || !ok || detected != compression {
+			t.Fatalf("DetectTarball() = %v, %v, %v, want true, %v", ok, detected, err, compression)
+		}
+		dir := t.TempDir()
+		if err := Extract(tarball, detected, dir); err != nil {
+			t.Fatal(err)
+		}
+		for name, content := range files {
+			got, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != content {
+				t.Fatalf("extracted %s = %q, want %q", name, got, content)
+			}
+		}
+	}
+
+	if ok, _, err := DetectTarball(layoutPath); err != nil || ok {
+		t.Fatalf("DetectTarball() of a directory = %v, %v, want false", ok, err)
+	}
+	if ok, _, err := DetectTarball(filepath.Join(layoutPath, indexFile)); err != nil || ok {
+		t.Fatalf("DetectTarball() of a JSON file = %v, %v, want false", ok, err)
+	}
+}
+
+func TestExtract_Invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		header tar.Header
+	}{
+		{name: "parent directory", header: tar.Header{Typeflag: tar.TypeReg, Name: "../index.json"}},
+		{name: "absolute path", header: tar.Header{Typeflag: tar.TypeReg, Name: "/index.json"}},
+		{name: "symlink", header: tar.Header{Typeflag: tar.TypeSymlink, Name: "index.json", Linkname: "/etc/passwd"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tarball := filepath.Join(t.TempDir(), "layout.tar")
+			f, err := os.Create(tarball)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tw := tar.NewWriter(f)
+			if err := tw.WriteHeader(&tt.header); err != nil {
+				t.Fatal(err)
+			}
+			tw.Close()
+			f.Close()
+			if err := Extract(tarball, CompressionNone, t.TempDir()); err == nil {
+				t.Fatal("expected error, got nil")
+			}
+		})
+	}
+}
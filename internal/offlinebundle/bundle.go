@@ -0,0 +1,318 @@
+// Package offlinebundle packages an artifact descriptor, the manifest of the
+// artifact, its signature manifests and signature envelopes, and the
+// certificates of the signatures into a portable archive, so that the
+// signatures are verified in air-gapped environments without registry access.
+//
+// A bundle is a gzip-compressed tar archive of an index and the blobs at
+// "blobs/{algorithm}/{encoded}", like an OCI image layout. Blobs are checked
+// against their digests when the bundle is read.
+package offlinebundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/notaryproject/notation-core-go/signature"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/errdef"
+	"oras.land/oras-go/v2/registry"
+)
+
+// Version is the version of the bundle format.
+const Version = "1.0"
+
+// MediaTypeCertificate is the media type of the DER-encoded certificates of
+// the signatures.
+const MediaTypeCertificate = "application/pkix-cert"
+
+// indexName is the name of the index in the archive.
+const indexName = "index.json"
+
+// maxBundleSize is the maximum size of the uncompressed content of a bundle.
+const maxBundleSize = 256 * 1024 * 1024
+
+// Index lists the content of a bundle.
+type Index struct {
+	// Version is the version of the bundle format.
+	Version string `json:"version"`
+
+	// CreatedAt is the time the bundle was exported.
+	CreatedAt time.Time `json:"createdAt"`
+
+	// Reference is the digest reference of the artifact in the registry it
+	// was exported from, e.g. "localhost:5000/net-monitor@sha256:...".
+	Reference string `json:"reference"`
+
+	// Subject is the descriptor of the artifact manifest.
+	Subject ocispec.Descriptor `json:"subject"`
+
+	// Signatures are the signatures of the artifact in the order of listing.
+	Signatures []Signature `json:"signatures"`
+}
+
+// Signature is a signature of the artifact.
+type Signature struct {
+	// Manifest is the descriptor of the signature manifest.
+	Manifest ocispec.Descriptor `json:"manifest"`
+
+	// Envelope is the descriptor of the signature envelope.
+	Envelope ocispec.Descriptor `json:"envelope"`
+
+	// Certificates are the descriptors of the certificate chain of the
+	// signature, leaf first. They are empty if the envelope is malformed.
+	Certificates []ocispec.Descriptor `json:"certificates,omitempty"`
+}
+
+// Bundle is an artifact with its signatures.
+type Bundle struct {
+	Index
+
+	// blobs are the blobs indexed by digest.
+	blobs map[digest.Digest][]byte
+}
+
+// New returns a bundle of the artifact of reference described by subject with
+// the manifest of the artifact, without signatures.
+func New(reference string, subject ocispec.Descriptor, manifest []byte) (*Bundle, error) {
+	ref, err := registry.ParseReference(reference)
+	if err != nil {
+		return nil, err
+	}
+	ref.Reference = subject.Digest.String()
+	b := &Bundle{
+		Index: Index{
+			Version:    Version,
+			Reference:  ref.String(),
+			Subject:    subject,
+			Signatures: []Signature{},
+		},
+		blobs: make(map[digest.Digest][]byte),
+	}
+	if err := b.add(subject, manifest); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// AddSignature adds the signature manifest and the signature envelope of a
+// signature, and the certificate chain of the envelope.
+func (b *Bundle) AddSignature(manifestDesc ocispec.Descriptor, manifest []byte, envelopeDesc ocispec.Descriptor, envelope []byte) error {
+	if err := b.add(manifestDesc, manifest); err != nil {
+		return err
+	}
+	if err := b.add(envelopeDesc, envelope); err != nil {
+		return err
+	}
+	sig := Signature{Manifest: manifestDesc, Envelope: envelopeDesc}
+	// a malformed envelope is bundled as is, so that the verification
+	// reports it
+	if env, err := signature.ParseEnvelope(envelopeDesc.MediaType, envelope); err == nil {
+		if content, err := env.Content(); err == nil {
+			for _, cert := range content.SignerInfo.CertificateChain {
+				certDesc := ocispec.Descriptor{
+					MediaType: MediaTypeCertificate,
+					Digest:    digest.FromBytes(cert.Raw),
+					Size:      int64(len(cert.Raw)),
+				}
+				if err := b.add(certDesc, cert.Raw); err != nil {
+					return err
+				}
+				sig.Certificates = append(sig.Certificates, certDesc)
+			}
+		}
+	}
+	b.Signatures = append(b.Signatures, sig)
+	return nil
+}
+
+// add adds the blob data described by desc.
+func (b *Bundle) add(desc ocispec.Descriptor, data []byte) error {
+	if err := verifyBlob(desc, data); err != nil {
+		return err
+	}
+	b.blobs[desc.Digest] = data
+	return nil
+}
+
+// verifyBlob returns an error if data does not match desc.
+func verifyBlob(desc ocispec.Descriptor, data []byte) error {
+	if err := desc.Digest.Validate(); err != nil {
+		return fmt.Errorf("invalid digest %q: %w", desc.Digest, err)
+	}
+	if int64(len(data)) != desc.Size || desc.Digest.Algorithm().FromBytes(data) != desc.Digest {
+		return fmt.Errorf("content of %s does not match its descriptor", desc.Digest)
+	}
+	return nil
+}
+
+// blobPath returns the path of the blob of d in the archive.
+func blobPath(d digest.Digest) string {
+	return path.Join("blobs", d.Algorithm().String(), d.Encoded())
+}
+
+// Write writes the bundle as a gzip-compressed tar archive to w, created at
+// the time now.
+func (b *Bundle) Write(w io.Writer, now time.Time) error {
+	b.CreatedAt = now.UTC()
+	indexJSON, err := json.MarshalIndent(b.Index, "", "    ")
+	if err != nil {
+		return err
+	}
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+	writeEntry := func(name string, data []byte) error {
+		if err := tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     name,
+			Mode:     0644,
+			Size:     int64(len(data)),
+			ModTime:  b.CreatedAt,
+		}); err != nil {
+			return err
+		}
+		_, err := tw.Write(data)
+		return err
+	}
+	// the index comes first so that readers learn the content upfront
+	if err := writeEntry(indexName, indexJSON); err != nil {
+		return err
+	}
+	digests := make([]string, 0, len(b.blobs))
+	for d := range b.blobs {
+		digests = append(digests, d.String())
+	}
+	sort.Strings(digests)
+	for _, d := range digests {
+		if err := writeEntry(blobPath(digest.Digest(d)), b.blobs[digest.Digest(d)]); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gw.Close()
+}
+
+// Read reads a bundle written by Write, checking the blobs against their
+// descriptors in the index.
+func Read(r io.Reader) (*Bundle, error) {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("bundle is not gzip-compressed: %w", err)
+	}
+	defer gr.Close()
+	tr := tar.NewReader(gr)
+	var index *Index
+	content := make(map[string][]byte)
+	var size int64
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("malformed bundle: %w", err)
+		}
+		if header.Typeflag == tar.TypeDir {
+			continue
+		}
+		if header.Typeflag != tar.TypeReg {
+			return nil, fmt.Errorf("unexpected entry %q of bundle: not a regular file", header.Name)
+		}
+		size += header.Size
+		if size > maxBundleSize {
+			return nil, fmt.Errorf("bundle exceeds %d bytes", maxBundleSize)
+		}
+		data, err := io.ReadAll(io.LimitReader(tr, header.Size))
+		if err != nil {
+			return nil, fmt.Errorf("malformed bundle: %w", err)
+		}
+		if header.Name == indexName {
+			index = &Index{}
+			if err := json.Unmarshal(data, index); err != nil {
+				return nil, fmt.Errorf("malformed index of bundle: %w", err)
+			}
+			continue
+		}
+		if !strings.HasPrefix(header.Name, "blobs/") {
+			return nil, fmt.Errorf("unexpected file %q in bundle", header.Name)
+		}
+		content[header.Name] = data
+	}
+	if index == nil {
+		return nil, errors.New("bundle has no index")
+	}
+	if index.Version != Version {
+		return nil, fmt.Errorf("unsupported bundle version %q, expecting %q", index.Version, Version)
+	}
+	ref, err := registry.ParseReference(index.Reference)
+	if err != nil {
+		return nil, fmt.Errorf("malformed index of bundle: %w", err)
+	}
+	if ref.Reference != index.Subject.Digest.String() {
+		return nil, fmt.Errorf("malformed index of bundle: reference %q is not the digest reference of the subject %s", index.Reference, index.Subject.Digest)
+	}
+
+	b := &Bundle{Index: *index, blobs: make(map[digest.Digest][]byte)}
+	descs := []ocispec.Descriptor{index.Subject}
+	for _, sig := range index.Signatures {
+		descs = append(descs, sig.Manifest, sig.Envelope)
+		descs = append(descs, sig.Certificates...)
+	}
+	for _, desc := range descs {
+		if err := desc.Digest.Validate(); err != nil {
+			return nil, fmt.Errorf("malformed index of bundle: invalid digest %q: %w", desc.Digest, err)
+		}
+		data, ok := content[blobPath(desc.Digest)]
+		if !ok {
+			return nil, fmt.Errorf("blob %s of the index is missing in bundle", desc.Digest)
+		}
+		if err := b.add(desc, data); err != nil {
+			return nil, fmt.Errorf("tampered bundle: %w", err)
+		}
+	}
+	for name := range content {
+		if d, err := digest.Parse(strings.Replace(strings.TrimPrefix(name, "blobs/"), "/", ":", 1)); err != nil || b.blobs[d] == nil {
+			return nil, fmt.Errorf("file %q in bundle is not listed in the index", name)
+		}
+	}
+	if b.Signatures == nil {
+		b.Signatures = []Signature{}
+	}
+	return b, nil
+}
+
+// Fetch fetches the blob described by target, e.g. the artifact manifest or
+// a signature manifest.
+func (b *Bundle) Fetch(ctx context.Context, target ocispec.Descriptor) (io.ReadCloser, error) {
+	data, ok := b.blobs[target.Digest]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", target.Digest, errdef.ErrNotFound)
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// Certificates returns the certificate chain of sig, leaf first.
+func (b *Bundle) Certificates(sig Signature) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for _, desc := range sig.Certificates {
+		cert, err := x509.ParseCertificate(b.blobs[desc.Digest])
+		if err != nil {
+			return nil, fmt.Errorf("malformed certificate %s: %w", desc.Digest, err)
+		}
+		certs = append(certs, cert)
+	}
+	return certs, nil
+}
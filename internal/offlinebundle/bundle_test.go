@@ -0,0 +1,192 @@
+package offlinebundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/x509"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/notation-core-go/signature/jws"
+	"github.com/notaryproject/notation-core-go/testhelper"
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation-go/signer"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+)
+
+// testBundle returns a bundle of an artifact with a signature and a
+// malformed signature.
+func testBundle(t *testing.T) (*Bundle, []*x509.Certificate) {
+	t.Helper()
+	manifest := []byte(`{"schemaVersion":2,"mediaType":"application/vnd.oci.image.manifest.v1+json","annotations":{"org.opencontainers.image.revision":"abc"}}`)
+	subject := content.NewDescriptorFromBytes(ocispec.MediaTypeImageManifest, manifest)
+	b, err := New("localhost:5000/net-monitor:v1", subject, manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	leaf, root := testhelper.GetRSALeafCertificate(), testhelper.GetRSARootCertificate()
+	chain := []*x509.Certificate{leaf.Cert, root.Cert}
+	s, err := signer.New(leaf.PrivateKey, chain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	envelope, _, err := s.Sign(context.Background(), subject, notation.SignerSignOptions{SignatureMediaType: jws.MediaTypeEnvelope})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, blob := range [][]byte{envelope, []byte("malformed")} {
+		envelopeDesc := content.NewDescriptorFromBytes(jws.MediaTypeEnvelope, blob)
+		sigManifest := []byte(`{"schemaVersion":2,"mediaType":"application/vnd.oci.image.manifest.v1+json","layers":[{"digest":"` + envelopeDesc.Digest.String() + `"}]}`)
+		sigManifestDesc := content.NewDescriptorFromBytes(ocispec.MediaTypeImageManifest, sigManifest)
+		if err := b.AddSignature(sigManifestDesc, sigManifest, envelopeDesc, blob); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return b, chain
+}
+
+func TestBundle(t *testing.T) {
+	b, chain := testBundle(t)
+	if want := "localhost:5000/net-monitor@" + b.Subject.Digest.String(); b.Reference != want {
+		t.Fatalf("Reference = %q, want %q", b.Reference, want)
+	}
+	var buf bytes.Buffer
+	if err := b.Write(&buf, time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+	read, err := Read(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(read.Index, b.Index) {
+		t.Fatalf("Read() index = %+v, want %+v", read.Index, b.Index)
+	}
+	if len(read.Signatures) != 2 || len(read.Signatures[1].Certificates) != 0 {
+		t.Fatalf("unexpected signatures %+v", read.Signatures)
+	}
+	certs, err := read.Certificates(read.Signatures[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(certs) != len(chain) || !certs[0].Equal(chain[0]) || !certs[1].Equal(chain[1]) {
+		t.Fatalf("Certificates() = %v, want %v", certs, chain)
+	}
+
+	// the signatures are served by the repository of the bundle
+	ctx := context.Background()
+	repo := read.Repository()
+	if _, err := repo.Resolve(ctx, "v1"); err == nil {
+		t.Fatal("expected error resolving a tag")
+	}
+	subject, err := repo.Resolve(ctx, read.Subject.Digest.String())
+	if err != nil || !reflect.DeepEqual(subject, read.Subject) {
+		t.Fatalf("Resolve() = %v, %v, want %v", subject, err, read.Subject)
+	}
+	var manifests []ocispec.Descriptor
+	if err := repo.ListSignatures(ctx, subject, func(signatureManifests []ocispec.Descriptor) error {
+		manifests = append(manifests, signatureManifests...)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(manifests) != 2 || manifests[0].Digest != read.Signatures[0].Manifest.Digest {
+		t.Fatalf("ListSignatures() = %v", manifests)
+	}
+	envelope, envelopeDesc, err := repo.FetchSignatureBlob(ctx, manifests[1])
+	if err != nil || string(envelope) != "malformed" || envelopeDesc.MediaType != jws.MediaTypeEnvelope {
+		t.Fatalf("FetchSignatureBlob() = %q, %v, %v", envelope, envelopeDesc, err)
+	}
+	if _, err := content.FetchAll(ctx, read, manifests[0]); err != nil {
+		t.Fatalf("Fetch() of the signature manifest error = %v", err)
+	}
+}
+
+func TestRead_Tampered(t *testing.T) {
+	b, _ := testBundle(t)
+	var buf bytes.Buffer
+	if err := b.Write(&buf, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	// rewrite rewrites the entries of the bundle with fn
+	rewrite := func(fn func(name string, data []byte) (string, []byte)) []byte {
+		gr, err := gzip.NewReader(bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		tr := tar.NewReader(gr)
+		var out bytes.Buffer
+		gw := gzip.NewWriter(&out)
+		tw := tar.NewWriter(gw)
+		for {
+			header, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := io.ReadAll(tr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			name, data := fn(header.Name, data)
+			if name == "" {
+				continue
+			}
+			if err := tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: name, Mode: 0644, Size: int64(len(data))}); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := tw.Write(data); err != nil {
+				t.Fatal(err)
+			}
+		}
+		tw.Close()
+		gw.Close()
+		return out.Bytes()
+	}
+	envelopePath := blobPath(b.Signatures[0].Envelope.Digest)
+	tests := []struct {
+		name   string
+		bundle []byte
+	}{
+		{name: "tampered envelope", bundle: rewrite(func(name string, data []byte) (string, []byte) {
+			if name == envelopePath {
+				data = append([]byte{}, data...)
+				data[len(data)-2] ^= 1
+			}
+			return name, data
+		})},
+		{name: "missing envelope", bundle: rewrite(func(name string, data []byte) (string, []byte) {
+			if name == envelopePath {
+				return "", nil
+			}
+			return name, data
+		})},
+		{name: "unexpected file", bundle: rewrite(func(name string, data []byte) (string, []byte) {
+			if name == envelopePath {
+				return "blobs/sha256/" + string(bytes.Repeat([]byte("0"), 64)), data
+			}
+			return name, data
+		})},
+		{name: "no index", bundle: rewrite(func(name string, data []byte) (string, []byte) {
+			if name == indexName {
+				return "", nil
+			}
+			return name, data
+		})},
+		{name: "not gzip", bundle: []byte("{}")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Read(bytes.NewReader(tt.bundle)); err == nil {
+				t.Fatal("expected error, got nil")
+			}
+		})
+	}
+}
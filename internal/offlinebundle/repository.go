@@ -0,0 +1,61 @@
+package offlinebundle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	notationregistry "github.com/notaryproject/notation-go/registry"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/errdef"
+)
+
+// repository is a read-only notationregistry.Repository of the artifact and
+// the signatures of a bundle.
+type repository struct {
+	bundle *Bundle
+}
+
+// Repository returns a read-only repository of the artifact and the
+// signatures of the bundle, so that the signatures are verified by
+// notation.Verify without registry access. The artifact is resolved by its
+// digest only.
+func (b *Bundle) Repository() notationregistry.Repository {
+	return &repository{bundle: b}
+}
+
+// Resolve resolves the digest of the artifact of the bundle.
+func (r *repository) Resolve(ctx context.Context, reference string) (ocispec.Descriptor, error) {
+	if reference != r.bundle.Subject.Digest.String() {
+		return ocispec.Descriptor{}, fmt.Errorf("%s: %w, the bundle only contains %s", reference, errdef.ErrNotFound, r.bundle.Subject.Digest)
+	}
+	return r.bundle.Subject, nil
+}
+
+// ListSignatures lists the signature manifests of the artifact of the bundle.
+func (r *repository) ListSignatures(ctx context.Context, desc ocispec.Descriptor, fn func(signatureManifests []ocispec.Descriptor) error) error {
+	if desc.Digest != r.bundle.Subject.Digest || len(r.bundle.Signatures) == 0 {
+		return nil
+	}
+	manifests := make([]ocispec.Descriptor, 0, len(r.bundle.Signatures))
+	for _, sig := range r.bundle.Signatures {
+		manifests = append(manifests, sig.Manifest)
+	}
+	return fn(manifests)
+}
+
+// FetchSignatureBlob returns the signature envelope of the signature manifest
+// desc.
+func (r *repository) FetchSignatureBlob(ctx context.Context, desc ocispec.Descriptor) ([]byte, ocispec.Descriptor, error) {
+	for _, sig := range r.bundle.Signatures {
+		if sig.Manifest.Digest == desc.Digest {
+			return r.bundle.blobs[sig.Envelope.Digest], sig.Envelope, nil
+		}
+	}
+	return nil, ocispec.Descriptor{}, fmt.Errorf("signature manifest %s: %w", desc.Digest, errdef.ErrNotFound)
+}
+
+// PushSignature fails as the bundle is read-only.
+func (r *repository) PushSignature(ctx context.Context, mediaType string, blob []byte, subject ocispec.Descriptor, annotations map[string]string) (ocispec.Descriptor, ocispec.Descriptor, error) {
+	return ocispec.Descriptor{}, ocispec.Descriptor{}, errors.New("signatures cannot be pushed to a bundle")
+}
@@ -16,6 +16,38 @@ func WriteFile(path string, data []byte) error {
 	return os.WriteFile(path, data, 0600)
 }
 
+// WriteFileAtomic writes to a path with all parent directories created, by
+// renaming a temporary file of the same directory to path, so that concurrent
+// readers never read a truncated file.
+func WriteFileAtomic(path string, data []byte, perm fs.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := file.Name()
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
 // WriteFileWithPermission writes to a path with all parent directories created.
 func WriteFileWithPermission(path string, data []byte, perm fs.FileMode, overwrite bool) error {
 	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
@@ -52,6 +52,24 @@ func TestWriteFile(t *testing.T) {
 	})
 }
 
+func TestWriteFileAtomic(t *testing.T) {
+	tempDir := t.TempDir()
+	filename := filepath.Join(tempDir, "a", "file.txt")
+	for _, data := range [][]byte{[]byte("data"), []byte("new")} {
+		if err := WriteFileAtomic(filename, data, 0600); err != nil {
+			t.Fatal(err)
+		}
+		validFileContent(t, filename, data)
+	}
+	entries, err := os.ReadDir(filepath.Dir(filename))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected no temporary file left behind, got %d files", len(entries))
+	}
+}
+
 func TestWriteFileWithPermission(t *testing.T) {
 	t.Run("write without override", func(t *testing.T) {
 		tempDir := t.TempDir()
@@ -0,0 +1,216 @@
+// Package parallel verifies the signatures of an artifact concurrently, for
+// artifacts with many signatures where verifying them one by one is slow.
+package parallel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation-go/log"
+	notationregistry "github.com/notaryproject/notation-go/registry"
+	"github.com/notaryproject/notation/internal/skipper"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/registry"
+)
+
+// errDoneListing stops listing signatures once enough are listed.
+var errDoneListing = errors.New("done listing signatures")
+
+// Options are the options of Verify.
+type Options struct {
+	notation.VerifyOptions
+
+	// Concurrency is the maximum number of signatures fetched and verified at
+	// the same time. The signatures are verified one by one if less than 2.
+	Concurrency int
+}
+
+// Record is a signature verified by Verify.
+type Record struct {
+	// SignatureManifest is the descriptor of the signature manifest.
+	SignatureManifest ocispec.Descriptor
+
+	// MediaType is the media type of the signature envelope.
+	MediaType string
+
+	// Outcome and Err are the results of the verifier.
+	Outcome *notation.VerificationOutcome
+	Err     error
+}
+
+// result is the result of fetching and verifying a signature.
+type result struct {
+	Record
+
+	// fetchErr is the error fetching the signature envelope.
+	fetchErr error
+}
+
+// Verify is like notation.Verify, but fetches and verifies up to
+// opts.Concurrency signatures at the same time.
+//
+// The result is deterministic: it is the result notation.Verify returns for
+// the same signatures, i.e. the outcome of the first signature in the listing
+// order verified successfully. Signatures listed after it are not verified if
+// not started yet, and their results are discarded otherwise. The returned
+// records are the signatures notation.Verify would verify, in the listing
+// order, up to and including the signature verified successfully.
+//
+// Unlike notation.Verify, all signatures up to opts.MaxSignatureAttempts are
+// listed before they are verified.
+func Verify(ctx context.Context, verifier notation.Verifier, repo notationregistry.Repository, opts Options) (ocispec.Descriptor, []*notation.VerificationOutcome, []Record, error) {
+	logger := log.GetLogger(ctx)
+	if opts.MaxSignatureAttempts <= 0 {
+		return ocispec.Descriptor{}, nil, nil, notation.ErrorSignatureRetrievalFailed{Msg: fmt.Sprintf("verifyOptions.MaxSignatureAttempts expects a positive number, got %d", opts.MaxSignatureAttempts)}
+	}
+	verifierOpts := notation.VerifierVerifyOptions{
+		ArtifactReference: opts.ArtifactReference,
+		PluginConfig:      opts.PluginConfig,
+		UserMetadata:      opts.UserMetadata,
+	}
+	skip, level, err := skipper.SkipVerify(ctx, verifier, verifierOpts)
+	if err != nil {
+		return ocispec.Descriptor{}, nil, nil, err
+	}
+	if skip {
+		logger.Infoln("Verification skipped for", opts.ArtifactReference)
+		return ocispec.Descriptor{}, []*notation.VerificationOutcome{{VerificationLevel: level}}, nil, nil
+	}
+
+	// resolve the artifact
+	artifactRef := opts.ArtifactReference
+	ref, err := registry.ParseReference(artifactRef)
+	if err != nil {
+		return ocispec.Descriptor{}, nil, nil, notation.ErrorSignatureRetrievalFailed{Msg: err.Error()}
+	}
+	if ref.Reference == "" {
+		return ocispec.Descriptor{}, nil, nil, notation.ErrorSignatureRetrievalFailed{Msg: "reference is missing digest or tag"}
+	}
+	artifactDesc, err := repo.Resolve(ctx, ref.Reference)
+	if err != nil {
+		return ocispec.Descriptor{}, nil, nil, notation.ErrorSignatureRetrievalFailed{Msg: err.Error()}
+	}
+
+	// list the signatures
+	var signatures []ocispec.Descriptor
+	err = repo.ListSignatures(ctx, artifactDesc, func(signatureManifests []ocispec.Descriptor) error {
+		for _, desc := range signatureManifests {
+			if len(signatures) >= opts.MaxSignatureAttempts {
+				return errDoneListing
+			}
+			signatures = append(signatures, desc)
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDoneListing) {
+		return ocispec.Descriptor{}, nil, nil, err
+	}
+	if len(signatures) == 0 {
+		return ocispec.Descriptor{}, nil, nil, notation.ErrorSignatureRetrievalFailed{Msg: fmt.Sprintf("no signature is associated with %q, make sure the artifact was signed successfully", artifactRef)}
+	}
+
+	results := verifySignatures(ctx, verifier, repo, artifactDesc, signatures, verifierOpts, opts.Concurrency)
+
+	// report the results in the listing order
+	var records []Record
+	var verificationFailedErr error = notation.ErrorVerificationFailed{}
+	for _, result := range results {
+		if result.fetchErr != nil {
+			return ocispec.Descriptor{}, nil, records, notation.ErrorSignatureRetrievalFailed{Msg: fmt.Sprintf("unable to retrieve digital signature with digest %q associated with %q from the Repository, error : %v", result.SignatureManifest.Digest, artifactRef, result.fetchErr.Error())}
+		}
+		records = append(records, result.Record)
+		if result.Err == nil {
+			logger.Debugf("Signature verification succeeded for artifact %v with signature digest %v", artifactDesc.Digest, result.SignatureManifest.Digest)
+			return artifactDesc, []*notation.VerificationOutcome{result.Outcome}, records, nil
+		}
+		logger.Warnf("Signature %v failed verification with error: %v", result.SignatureManifest.Digest, result.Err)
+		if result.Outcome == nil {
+			logger.Error("Got nil outcome. Expecting non-nil outcome on verification failure")
+			return ocispec.Descriptor{}, nil, records, result.Err
+		}
+		if _, ok := result.Outcome.Error.(notation.ErrorUserMetadataVerificationFailed); ok {
+			verificationFailedErr = result.Outcome.Error
+		}
+	}
+	if len(signatures) >= opts.MaxSignatureAttempts {
+		return ocispec.Descriptor{}, nil, records, notation.ErrorVerificationFailed{Msg: fmt.Sprintf("total number of signatures associated with an artifact should be less than: %d", opts.MaxSignatureAttempts)}
+	}
+	logger.Debugf("Signature verification failed for all the signatures associated with artifact %v", artifactDesc.Digest)
+	return ocispec.Descriptor{}, nil, records, verificationFailedErr
+}
+
+// verifySignatures fetches and verifies the signatures with concurrency
+// workers. Signatures listed after a signature verified successfully are not
+// verified if not started yet. The results are in the listing order, up to
+// and including the first signature verified successfully or failing to be
+// fetched.
+func verifySignatures(ctx context.Context, verifier notation.Verifier, repo notationregistry.Repository, artifactDesc ocispec.Descriptor, signatures []ocispec.Descriptor, opts notation.VerifierVerifyOptions, concurrency int) []result {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	if concurrency > len(signatures) {
+		concurrency = len(signatures)
+	}
+	results := make([]result, len(signatures))
+
+	// stop is the index of the first signature verified successfully or
+	// failing to be fetched so far, signatures after it are not verified
+	var stop atomic.Int64
+	stop.Store(int64(len(signatures)))
+	lowerStop := func(i int) {
+		for {
+			current := stop.Load()
+			if int64(i) >= current || stop.CompareAndSwap(current, int64(i)) {
+				return
+			}
+		}
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if int64(i) > stop.Load() {
+					continue
+				}
+				results[i] = verifySignature(ctx, verifier, repo, artifactDesc, signatures[i], opts)
+				if results[i].fetchErr != nil || results[i].Err == nil || results[i].Outcome == nil {
+					lowerStop(i)
+				}
+			}
+		}()
+	}
+	for i := range signatures {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	end := int(stop.Load())
+	if end < len(results) {
+		results = results[:end+1]
+	}
+	return results
+}
+
+// verifySignature fetches and verifies a signature.
+func verifySignature(ctx context.Context, verifier notation.Verifier, repo notationregistry.Repository, artifactDesc, signatureManifest ocispec.Descriptor, opts notation.VerifierVerifyOptions) result {
+	log.GetLogger(ctx).Infof("Processing signature with manifest mediaType: %v and digest: %v", signatureManifest.MediaType, signatureManifest.Digest)
+	r := result{Record: Record{SignatureManifest: signatureManifest}}
+	blob, blobDesc, err := repo.FetchSignatureBlob(ctx, signatureManifest)
+	if err != nil {
+		r.fetchErr = err
+		return r
+	}
+	r.MediaType = blobDesc.MediaType
+	opts.SignatureMediaType = blobDesc.MediaType
+	r.Outcome, r.Err = verifier.Verify(ctx, artifactDesc, blob, opts)
+	return r
+}
@@ -0,0 +1,219 @@
+package parallel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/notation-go"
+	notationregistry "github.com/notaryproject/notation-go/registry"
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+var subject = ocispec.Descriptor{MediaType: ocispec.MediaTypeImageManifest, Digest: digest.FromString("subject"), Size: 7}
+
+// testRepository lists the signatures in pages of 3.
+type testRepository struct {
+	notationregistry.Repository
+	signatures   []ocispec.Descriptor
+	fetchFailure map[digest.Digest]bool
+}
+
+func newTestRepository(n int) *testRepository {
+	repo := &testRepository{fetchFailure: make(map[digest.Digest]bool)}
+	for i := 0; i < n; i++ {
+		repo.signatures = append(repo.signatures, ocispec.Descriptor{MediaType: ocispec.MediaTypeImageManifest, Digest: digest.FromString(fmt.Sprint("signature", i)), Size: 10})
+	}
+	return repo
+}
+
+func (r *testRepository) Resolve(ctx context.Context, reference string) (ocispec.Descriptor, error) {
+	return subject, nil
+}
+
+func (r *testRepository) ListSignatures(ctx context.Context, desc ocispec.Descriptor, fn func(signatureManifests []ocispec.Descriptor) error) error {
+	for i := 0; i < len(r.signatures); i += 3 {
+		end := i + 3
+		if end > len(r.signatures) {
+			end = len(r.signatures)
+		}
+		if err := fn(r.signatures[i:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *testRepository) FetchSignatureBlob(ctx context.Context, desc ocispec.Descriptor) ([]byte, ocispec.Descriptor, error) {
+	if r.fetchFailure[desc.Digest] {
+		return nil, ocispec.Descriptor{}, errors.New("not found")
+	}
+	blob := []byte(desc.Digest)
+	return blob, ocispec.Descriptor{MediaType: "application/jose+json", Digest: digest.FromBytes(blob), Size: int64(len(blob))}, nil
+}
+
+// testVerifier verifies the signatures of the blobs in trusted successfully,
+// taking a random time, and tracks the number of concurrent verifications.
+type testVerifier struct {
+	trusted map[string]bool
+
+	mu            sync.Mutex
+	verified      map[string]bool
+	running       atomic.Int32
+	maxConcurrent atomic.Int32
+}
+
+func (v *testVerifier) Verify(ctx context.Context, desc ocispec.Descriptor, signature []byte, opts notation.VerifierVerifyOptions) (*notation.VerificationOutcome, error) {
+	running := v.running.Add(1)
+	defer v.running.Add(-1)
+	for {
+		max := v.maxConcurrent.Load()
+		if running <= max || v.maxConcurrent.CompareAndSwap(max, running) {
+			break
+		}
+	}
+	time.Sleep(time.Duration(rand.Intn(5)) * time.Millisecond)
+	v.mu.Lock()
+	if v.verified == nil {
+		v.verified = make(map[string]bool)
+	}
+	v.verified[string(signature)] = true
+	v.mu.Unlock()
+
+	outcome := &notation.VerificationOutcome{RawSignature: signature, VerificationLevel: trustpolicy.LevelStrict}
+	if !v.trusted[string(signature)] {
+		outcome.Error = notation.ErrorVerificationFailed{Msg: "untrusted signer"}
+		return outcome, outcome.Error
+	}
+	return outcome, nil
+}
+
+func verifyOptions(maxAttempts int) notation.VerifyOptions {
+	return notation.VerifyOptions{
+		ArtifactReference:    "localhost:5000/net-monitor@" + subject.Digest.String(),
+		MaxSignatureAttempts: maxAttempts,
+	}
+}
+
+func TestVerify_FirstSuccess(t *testing.T) {
+	repo := newTestRepository(20)
+	trusted := map[string]bool{
+		repo.signatures[7].Digest.String():  true,
+		repo.signatures[12].Digest.String(): true,
+		repo.signatures[18].Digest.String(): true,
+	}
+	serialVerifier := &testVerifier{trusted: trusted}
+	wantDesc, wantOutcomes, err := notation.Verify(context.Background(), serialVerifier, repo, verifyOptions(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 10; i++ {
+		verifier := &testVerifier{trusted: trusted}
+		desc, outcomes, records, err := Verify(context.Background(), verifier, repo, Options{VerifyOptions: verifyOptions(100), Concurrency: 4})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if desc.Digest != wantDesc.Digest || len(outcomes) != 1 || string(outcomes[0].RawSignature) != string(wantOutcomes[0].RawSignature) {
+			t.Fatalf("expected the outcome of signature 7, got %s", outcomes[0].RawSignature)
+		}
+		if len(records) != 8 {
+			t.Fatalf("expected the records of signatures 0 to 7, got %d records", len(records))
+		}
+		for j, record := range records {
+			if record.SignatureManifest.Digest != repo.signatures[j].Digest || record.MediaType != "application/jose+json" {
+				t.Fatalf("expected record %d of signature %s, got %+v", j, repo.signatures[j].Digest, record)
+			}
+			if (record.Err == nil) != (j == 7) {
+				t.Fatalf("unexpected result of record %d: %v", j, record.Err)
+			}
+		}
+		if max := verifier.maxConcurrent.Load(); max > 4 {
+			t.Fatalf("expected at most 4 concurrent verifications, got %d", max)
+		}
+		// the signatures listed after the first success are not all verified
+		if verifier.verified[repo.signatures[19].Digest.String()] && verifier.verified[repo.signatures[18].Digest.String()] && verifier.verified[repo.signatures[17].Digest.String()] {
+			t.Fatal("expected the verification to stop after the first success")
+		}
+	}
+}
+
+func TestVerify_Failure(t *testing.T) {
+	repo := newTestRepository(10)
+	verifier := &testVerifier{}
+	_, outcomes, records, err := Verify(context.Background(), verifier, repo, Options{VerifyOptions: verifyOptions(100), Concurrency: 3})
+	var verificationErr notation.ErrorVerificationFailed
+	if !errors.As(err, &verificationErr) || len(outcomes) != 0 {
+		t.Fatalf("expected verification failure, got %v", err)
+	}
+	if len(records) != 10 {
+		t.Fatalf("expected 10 records, got %d", len(records))
+	}
+
+	// the number of signatures is bounded
+	_, _, records, err = Verify(context.Background(), verifier, repo, Options{VerifyOptions: verifyOptions(4), Concurrency: 3})
+	_, serialErr := notationVerifyErr(repo, verifyOptions(4))
+	if err == nil || serialErr == nil || err.Error() != serialErr.Error() {
+		t.Fatalf("expected error %v, got %v", serialErr, err)
+	}
+	if len(records) != 4 {
+		t.Fatalf("expected 4 records, got %d", len(records))
+	}
+
+	// no signature
+	_, _, _, err = Verify(context.Background(), verifier, newTestRepository(0), Options{VerifyOptions: verifyOptions(100), Concurrency: 3})
+	var retrievalErr notation.ErrorSignatureRetrievalFailed
+	if !errors.As(err, &retrievalErr) {
+		t.Fatalf("expected signature retrieval failure, got %v", err)
+	}
+}
+
+func TestVerify_FetchFailure(t *testing.T) {
+	repo := newTestRepository(10)
+	verifier := &testVerifier{trusted: map[string]bool{repo.signatures[5].Digest.String(): true}}
+
+	// a fetch failure before the first success fails the verification
+	repo.fetchFailure[repo.signatures[2].Digest] = true
+	_, _, records, err := Verify(context.Background(), verifier, repo, Options{VerifyOptions: verifyOptions(100), Concurrency: 4})
+	var retrievalErr notation.ErrorSignatureRetrievalFailed
+	if !errors.As(err, &retrievalErr) || len(records) != 2 {
+		t.Fatalf("expected signature retrieval failure after 2 records, got %v with %d records", err, len(records))
+	}
+
+	// a fetch failure after the first success is ignored
+	repo.fetchFailure = map[digest.Digest]bool{repo.signatures[8].Digest: true}
+	if _, _, _, err := Verify(context.Background(), verifier, repo, Options{VerifyOptions: verifyOptions(100), Concurrency: 4}); err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+}
+
+func TestVerify_Skip(t *testing.T) {
+	verifier := &skipVerifier{}
+	_, outcomes, records, err := Verify(context.Background(), verifier, newTestRepository(3), Options{VerifyOptions: verifyOptions(100), Concurrency: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(outcomes) != 1 || outcomes[0].VerificationLevel != trustpolicy.LevelSkip || len(records) != 0 {
+		t.Fatalf("expected the verification to be skipped, got %v", outcomes)
+	}
+}
+
+type skipVerifier struct {
+	testVerifier
+}
+
+func (v *skipVerifier) SkipVerify(ctx context.Context, opts notation.VerifierVerifyOptions) (bool, *trustpolicy.VerificationLevel, error) {
+	return true, trustpolicy.LevelSkip, nil
+}
+
+func notationVerifyErr(repo notationregistry.Repository, opts notation.VerifyOptions) ([]*notation.VerificationOutcome, error) {
+	_, outcomes, err := notation.Verify(context.Background(), &testVerifier{}, repo, opts)
+	return outcomes, err
+}
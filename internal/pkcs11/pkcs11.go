@@ -0,0 +1,89 @@
+// Package pkcs11 signs with private keys in PKCS #11 tokens, e.g. hardware
+// security modules, without plugin. The PKCS #11 module of the token is loaded
+// with cgo, so PKCS #11 keys are not supported by builds without cgo.
+package pkcs11
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/x509"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// PINEnv is the environment variable of the user PIN of the token. The PIN is
+// never recorded in signingkeys.json.
+const PINEnv = "NOTATION_PKCS11_PIN"
+
+// ErrUnsupported indicates that notation is built without cgo, which loading
+// PKCS #11 modules requires.
+var ErrUnsupported = errors.New("PKCS #11 keys are not supported by this build of notation, which is built without cgo")
+
+// Key is a private key in a PKCS #11 token. It implements crypto.Signer, and
+// is closed with Close.
+type Key struct {
+	*session
+	public      crypto.PublicKey
+	certificate *x509.Certificate
+}
+
+// Public returns the public key of the key.
+func (k *Key) Public() crypto.PublicKey {
+	return k.public
+}
+
+// Certificate returns the certificate object of the token with the label of
+// the key, or nil if there is none.
+func (k *Key) Certificate() *x509.Certificate {
+	return k.certificate
+}
+
+// curveOIDs are the object identifiers of the named curves of ECDSA keys, as
+// encoded in the CKA_EC_PARAMS attribute.
+var curveOIDs = map[string]elliptic.Curve{
+	asn1.ObjectIdentifier{1, 2, 840, 10045, 3, 1, 7}.String(): elliptic.P256(),
+	asn1.ObjectIdentifier{1, 3, 132, 0, 34}.String():          elliptic.P384(),
+	asn1.ObjectIdentifier{1, 3, 132, 0, 35}.String():          elliptic.P521(),
+}
+
+// parseECPublicKey parses the ECDSA public key of the CKA_EC_PARAMS and the
+// CKA_EC_POINT attributes of a public key object.
+func parseECPublicKey(params, point []byte) (*ecdsa.PublicKey, error) {
+	var oid asn1.ObjectIdentifier
+	if _, err := asn1.Unmarshal(params, &oid); err != nil {
+		return nil, fmt.Errorf("unsupported EC parameters, expecting a named curve: %w", err)
+	}
+	curve, ok := curveOIDs[oid.String()]
+	if !ok {
+		return nil, fmt.Errorf("unsupported curve %s", oid)
+	}
+	// the point is DER-encoded as an octet string by conforming tokens, and
+	// raw by some others
+	var raw []byte
+	if rest, err := asn1.Unmarshal(point, &raw); err != nil || len(rest) > 0 {
+		raw = point
+	}
+	x, y := elliptic.Unmarshal(curve, raw)
+	if x == nil {
+		return nil, errors.New("malformed EC point")
+	}
+	return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+}
+
+// marshalECDSASignature encodes the concatenation of r and s returned by the
+// CKM_ECDSA mechanism in ASN.1 DER, as crypto.Signer returns ECDSA signatures.
+func marshalECDSASignature(raw []byte) ([]byte, error) {
+	if len(raw) == 0 || len(raw)%2 != 0 {
+		return nil, fmt.Errorf("malformed ECDSA signature of %d bytes", len(raw))
+	}
+	size := len(raw) / 2
+	return asn1.Marshal(struct {
+		R, S *big.Int
+	}{
+		R: new(big.Int).SetBytes(raw[:size]),
+		S: new(big.Int).SetBytes(raw[size:]),
+	})
+}
@@ -0,0 +1,81 @@
+package pkcs11
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/asn1"
+	"testing"
+)
+
+func TestParseECPublicKey(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	params, err := asn1.Marshal(asn1.ObjectIdentifier{1, 3, 132, 0, 34})
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw := elliptic.Marshal(elliptic.P384(), key.X, key.Y)
+	point, err := asn1.Marshal(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, point := range map[string][]byte{"DER": point, "raw": raw} {
+		got, err := parseECPublicKey(params, point)
+		if err != nil {
+			t.Fatalf("%s: parseECPublicKey() error = %v", name, err)
+		}
+		if !got.Equal(&key.PublicKey) {
+			t.Fatalf("%s: parseECPublicKey() = %v, want %v", name, got, key.PublicKey)
+		}
+	}
+
+	unknownCurve, err := asn1.Marshal(asn1.ObjectIdentifier{1, 3, 132, 0, 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parseECPublicKey(unknownCurve, point); err == nil {
+		t.Fatal("expected error for an unsupported curve")
+	}
+	if _, err := parseECPublicKey(params, []byte{4, 1, 2}); err == nil {
+		t.Fatal("expected error for a malformed point")
+	}
+}
+
+func TestMarshalECDSASignature(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := sha256.Sum256([]byte("payload"))
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	// CKM_ECDSA returns r and s padded to the size of the curve
+	raw := make([]byte, 64)
+	r.FillBytes(raw[:32])
+	s.FillBytes(raw[32:])
+	der, err := marshalECDSASignature(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ecdsa.VerifyASN1(&key.PublicKey, digest[:], der) {
+		t.Fatal("expected the DER-encoded signature to verify")
+	}
+	if _, err := marshalECDSASignature(raw[:63]); err == nil {
+		t.Fatal("expected error for a signature of odd length")
+	}
+}
+
+func TestOpen_InvalidModule(t *testing.T) {
+	if _, err := Open("/nonexistent/libpkcs11.so", 0, "release", ""); err == nil {
+		t.Fatal("expected error for a module which does not exist")
+	}
+}
+
+var _ crypto.Signer = (*Key)(nil)
@@ -0,0 +1,254 @@
+//go:build cgo
+
+package pkcs11
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rsa"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"sync"
+
+	p11 "github.com/miekg/pkcs11"
+)
+
+// Supported reports whether PKCS #11 keys are supported by this build of
+// notation, which requires cgo.
+func Supported() bool {
+	return true
+}
+
+// session is a logged in session of a PKCS #11 token, with the handle of the
+// private key object. Sessions are not safe for concurrent use, so signing is
+// serialized.
+type session struct {
+	mu          sync.Mutex
+	ctx         *p11.Ctx
+	handle      p11.SessionHandle
+	key         p11.ObjectHandle
+	initialized bool
+}
+
+// Open loads the PKCS #11 module, opens a session of the token in the slot,
+// logs in with the user PIN if not empty, and finds the private key with the
+// label. The public key is read from the certificate with the same label, or
+// from the key objects if there is no certificate.
+func Open(module string, slot uint, label, pin string) (*Key, error) {
+	ctx := p11.New(module)
+	if ctx == nil {
+		return nil, fmt.Errorf("failed to load PKCS #11 module %s", module)
+	}
+	s := &session{ctx: ctx}
+	if err := ctx.Initialize(); err != nil {
+		if !errors.Is(err, p11.Error(p11.CKR_CRYPTOKI_ALREADY_INITIALIZED)) {
+			ctx.Destroy()
+			return nil, fmt.Errorf("failed to initialize PKCS #11 module %s: %w", module, err)
+		}
+	} else {
+		s.initialized = true
+	}
+	handle, err := ctx.OpenSession(slot, p11.CKF_SERIAL_SESSION)
+	if err != nil {
+		s.finalize()
+		return nil, fmt.Errorf("failed to open a session of the token in slot %d: %w", slot, err)
+	}
+	s.handle = handle
+	key := &Key{session: s}
+	if err := key.load(slot, label, pin); err != nil {
+		key.Close()
+		return nil, err
+	}
+	return key, nil
+}
+
+// load logs in and loads the private key, the certificate and the public key
+// with the label.
+func (k *Key) load(slot uint, label, pin string) error {
+	if pin != "" {
+		if err := k.ctx.Login(k.handle, p11.CKU_USER, pin); err != nil && !errors.Is(err, p11.Error(p11.CKR_USER_ALREADY_LOGGED_IN)) {
+			return fmt.Errorf("failed to log in to the token in slot %d: %w", slot, err)
+		}
+	}
+	keys, err := k.findObjects(p11.CKO_PRIVATE_KEY, label)
+	if err != nil {
+		return err
+	}
+	switch len(keys) {
+	case 0:
+		if pin == "" {
+			return fmt.Errorf("private key %q not found in slot %d, set the user PIN of the token in the environment variable %s", label, slot, PINEnv)
+		}
+		return fmt.Errorf("private key %q not found in slot %d", label, slot)
+	case 1:
+		k.key = keys[0]
+	default:
+		return fmt.Errorf("%d private keys labeled %q found in slot %d, the label must identify one key", len(keys), label, slot)
+	}
+
+	certs, err := k.findObjects(p11.CKO_CERTIFICATE, label)
+	if err != nil {
+		return err
+	}
+	if len(certs) > 0 {
+		attrs, err := k.ctx.GetAttributeValue(k.handle, certs[0], []*p11.Attribute{p11.NewAttribute(p11.CKA_VALUE, nil)})
+		if err != nil {
+			return fmt.Errorf("failed to read certificate %q: %w", label, err)
+		}
+		if k.certificate, err = x509.ParseCertificate(attrs[0].Value); err != nil {
+			return fmt.Errorf("failed to parse certificate %q: %w", label, err)
+		}
+		k.public = k.certificate.PublicKey
+		return nil
+	}
+	k.public, err = k.readPublicKey(label)
+	return err
+}
+
+// readPublicKey reads the public key of the private key from the modulus and
+// the public exponent of RSA private keys, or from the public key object with
+// the label of ECDSA keys.
+func (k *Key) readPublicKey(label string) (crypto.PublicKey, error) {
+	attrs, err := k.ctx.GetAttributeValue(k.handle, k.key, []*p11.Attribute{p11.NewAttribute(p11.CKA_KEY_TYPE, nil)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the type of key %q: %w", label, err)
+	}
+	switch keyType := attrs[0].Value; {
+	case bytes.Equal(keyType, p11.NewAttribute(p11.CKA_KEY_TYPE, p11.CKK_RSA).Value):
+		attrs, err := k.ctx.GetAttributeValue(k.handle, k.key, []*p11.Attribute{
+			p11.NewAttribute(p11.CKA_MODULUS, nil),
+			p11.NewAttribute(p11.CKA_PUBLIC_EXPONENT, nil),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the public key of key %q: %w", label, err)
+		}
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(attrs[0].Value),
+			E: int(new(big.Int).SetBytes(attrs[1].Value).Int64()),
+		}, nil
+	case bytes.Equal(keyType, p11.NewAttribute(p11.CKA_KEY_TYPE, p11.CKK_EC).Value):
+		publicKeys, err := k.findObjects(p11.CKO_PUBLIC_KEY, label)
+		if err != nil {
+			return nil, err
+		}
+		if len(publicKeys) == 0 {
+			return nil, fmt.Errorf("neither certificate nor public key %q found in the token", label)
+		}
+		attrs, err := k.ctx.GetAttributeValue(k.handle, publicKeys[0], []*p11.Attribute{
+			p11.NewAttribute(p11.CKA_EC_PARAMS, nil),
+			p11.NewAttribute(p11.CKA_EC_POINT, nil),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read public key %q: %w", label, err)
+		}
+		return parseECPublicKey(attrs[0].Value, attrs[1].Value)
+	}
+	return nil, fmt.Errorf("unsupported type of key %q, expecting an RSA or EC key", label)
+}
+
+// findObjects returns the objects of the class with the label.
+func (k *Key) findObjects(class uint, label string) ([]p11.ObjectHandle, error) {
+	template := []*p11.Attribute{
+		p11.NewAttribute(p11.CKA_CLASS, class),
+		p11.NewAttribute(p11.CKA_LABEL, label),
+	}
+	if err := k.ctx.FindObjectsInit(k.handle, template); err != nil {
+		return nil, fmt.Errorf("failed to find objects labeled %q: %w", label, err)
+	}
+	defer k.ctx.FindObjectsFinal(k.handle)
+	objects, _, err := k.ctx.FindObjects(k.handle, 2)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find objects labeled %q: %w", label, err)
+	}
+	return objects, nil
+}
+
+// pssMechanisms are the hash algorithms and the mask generation functions of
+// the CKM_RSA_PKCS_PSS mechanism by hash.
+var pssMechanisms = map[crypto.Hash][2]uint{
+	crypto.SHA256: {p11.CKM_SHA256, p11.CKG_MGF1_SHA256},
+	crypto.SHA384: {p11.CKM_SHA384, p11.CKG_MGF1_SHA384},
+	crypto.SHA512: {p11.CKM_SHA512, p11.CKG_MGF1_SHA512},
+}
+
+// pkcs1Prefixes are the DER-encoded DigestInfo prefixes of the digests signed
+// with the CKM_RSA_PKCS mechanism by hash.
+var pkcs1Prefixes = map[crypto.Hash][]byte{
+	crypto.SHA256: {0x30, 0x31, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x01, 0x05, 0x00, 0x04, 0x20},
+	crypto.SHA384: {0x30, 0x41, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x02, 0x05, 0x00, 0x04, 0x30},
+	crypto.SHA512: {0x30, 0x51, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x03, 0x05, 0x00, 0x04, 0x40},
+}
+
+// Sign signs digest with the private key in the token. RSA keys sign with
+// RSASSA-PSS if opts is *rsa.PSSOptions, and with RSASSA-PKCS1-v1_5
+// otherwise. ECDSA signatures are encoded in ASN.1 DER.
+func (k *Key) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	hash := opts.HashFunc()
+	if len(digest) != hash.Size() {
+		return nil, fmt.Errorf("digest of %d bytes does not match hash algorithm %v", len(digest), hash)
+	}
+	var mechanism *p11.Mechanism
+	data := digest
+	switch public := k.public.(type) {
+	case *rsa.PublicKey:
+		if pssOpts, ok := opts.(*rsa.PSSOptions); ok {
+			mechanisms, ok := pssMechanisms[hash]
+			if !ok {
+				return nil, fmt.Errorf("unsupported hash algorithm %v", hash)
+			}
+			saltLength := pssOpts.SaltLength
+			switch saltLength {
+			case rsa.PSSSaltLengthEqualsHash:
+				saltLength = hash.Size()
+			case rsa.PSSSaltLengthAuto:
+				saltLength = (public.N.BitLen()-1+7)/8 - 2 - hash.Size()
+			}
+			mechanism = p11.NewMechanism(p11.CKM_RSA_PKCS_PSS, p11.NewPSSParams(mechanisms[0], mechanisms[1], uint(saltLength)))
+		} else {
+			prefix, ok := pkcs1Prefixes[hash]
+			if !ok {
+				return nil, fmt.Errorf("unsupported hash algorithm %v", hash)
+			}
+			mechanism = p11.NewMechanism(p11.CKM_RSA_PKCS, nil)
+			data = append(append([]byte{}, prefix...), digest...)
+		}
+	default:
+		mechanism = p11.NewMechanism(p11.CKM_ECDSA, nil)
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if err := k.ctx.SignInit(k.handle, []*p11.Mechanism{mechanism}, k.key); err != nil {
+		return nil, fmt.Errorf("failed to sign with the PKCS #11 key: %w", err)
+	}
+	sig, err := k.ctx.Sign(k.handle, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign with the PKCS #11 key: %w", err)
+	}
+	if mechanism.Mechanism == p11.CKM_ECDSA {
+		return marshalECDSASignature(sig)
+	}
+	return sig, nil
+}
+
+// Close logs out, closes the session and unloads the PKCS #11 module.
+func (k *Key) Close() error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.ctx.Logout(k.handle)
+	err := k.ctx.CloseSession(k.handle)
+	k.finalize()
+	return err
+}
+
+// finalize finalizes the module if initialized by the session, and unloads
+// it.
+func (s *session) finalize() {
+	if s.initialized {
+		s.ctx.Finalize()
+	}
+	s.ctx.Destroy()
+}
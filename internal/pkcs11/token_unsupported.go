@@ -0,0 +1,34 @@
+//go:build !cgo
+
+package pkcs11
+
+import (
+	"crypto"
+	"io"
+)
+
+// Supported reports whether PKCS #11 keys are supported by this build of
+// notation, which requires cgo.
+func Supported() bool {
+	return false
+}
+
+// session is not available without cgo.
+type session struct{}
+
+// Open loads the PKCS #11 module, opens a session of the token in the slot,
+// logs in with the user PIN if not empty, and finds the private key with the
+// label.
+func Open(module string, slot uint, label, pin string) (*Key, error) {
+	return nil, ErrUnsupported
+}
+
+// Sign signs digest with the private key in the token.
+func (k *Key) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	return nil, ErrUnsupported
+}
+
+// Close logs out, closes the session and unloads the PKCS #11 module.
+func (k *Key) Close() error {
+	return nil
+}
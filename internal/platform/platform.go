@@ -0,0 +1,166 @@
+// Package platform selects the manifest of a platform in an image index.
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+)
+
+// mediaTypeDockerManifestList is the media type of Docker manifest lists,
+// the predecessor of OCI image indexes.
+const mediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+
+// annotationDockerReferenceType annotates the manifests of image indexes built
+// by Docker which are not images of a platform, e.g. attestation manifests of
+// the type dockerReferenceTypeAttestation.
+const (
+	annotationDockerReferenceType  = "vnd.docker.reference.type"
+	dockerReferenceTypeAttestation = "attestation-manifest"
+)
+
+// maxIndexSize is the maximum size of image indexes read.
+const maxIndexSize = 4 * 1024 * 1024
+
+// ErrNoMatch indicates that no manifest of the image index matches the
+// platform.
+var ErrNoMatch = errors.New("no manifest matches the platform")
+
+// archAliases normalizes the architecture names used by uname and others to
+// the names of the OCI image specification.
+var archAliases = map[string]string{
+	"x86_64":  "amd64",
+	"x86-64":  "amd64",
+	"aarch64": "arm64",
+	"armhf":   "arm",
+	"armel":   "arm",
+	"i386":    "386",
+}
+
+// Parse parses a platform in the format of "os/arch[/variant]", e.g.
+// "linux/arm64" or "linux/arm/v7".
+func Parse(s string) (ocispec.Platform, error) {
+	parts := strings.Split(s, "/")
+	if len(parts) < 2 || len(parts) > 3 {
+		return ocispec.Platform{}, fmt.Errorf("invalid platform %q, expecting os/arch[/variant], e.g. linux/arm64", s)
+	}
+	for _, part := range parts {
+		if part == "" {
+			return ocispec.Platform{}, fmt.Errorf("invalid platform %q, expecting os/arch[/variant], e.g. linux/arm64", s)
+		}
+	}
+	p := ocispec.Platform{
+		OS:           strings.ToLower(parts[0]),
+		Architecture: normalizeArch(parts[1]),
+	}
+	if len(parts) == 3 {
+		p.Variant = strings.ToLower(parts[2])
+	}
+	return p, nil
+}
+
+// String returns the platform in the format of "os/arch[/variant]".
+func String(p ocispec.Platform) string {
+	s := p.OS + "/" + p.Architecture
+	if p.Variant != "" {
+		s += "/" + p.Variant
+	}
+	return s
+}
+
+// IsIndex returns true if desc describes an OCI image index or a Docker
+// manifest list.
+func IsIndex(desc ocispec.Descriptor) bool {
+	return desc.MediaType == ocispec.MediaTypeImageIndex || desc.MediaType == mediaTypeDockerManifestList
+}
+
+// Match returns true if got matches want. An empty variant of want matches
+// any variant, and the variant "v8" of arm64 is the same as no variant.
+func Match(want, got ocispec.Platform) bool {
+	if !strings.EqualFold(want.OS, got.OS) || want.Architecture != normalizeArch(got.Architecture) {
+		return false
+	}
+	if want.Variant == "" {
+		return true
+	}
+	return normalizeVariant(want.Architecture, want.Variant) == normalizeVariant(want.Architecture, strings.ToLower(got.Variant))
+}
+
+// Select returns the descriptor of the manifest of the platform in the image
+// index described by indexDesc. If several manifests match, the first one is
+// selected.
+func Select(ctx context.Context, fetcher content.Fetcher, indexDesc ocispec.Descriptor, want ocispec.Platform) (ocispec.Descriptor, error) {
+	index, err := fetchIndex(ctx, fetcher, indexDesc)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	var available []string
+	for _, manifest := range index.Manifests {
+		if manifest.Platform == nil {
+			continue
+		}
+		if Match(want, *manifest.Platform) {
+			return manifest, nil
+		}
+		available = append(available, String(*manifest.Platform))
+	}
+	return ocispec.Descriptor{}, fmt.Errorf("%w %s in image index %s, available platforms: %s", ErrNoMatch, String(want), indexDesc.Digest, strings.Join(available, ", "))
+}
+
+// List returns the descriptors of the manifests of the platforms in the image
+// index described by indexDesc, in the order of the index. Manifests without
+// platform and attestation manifests, which are not images of a platform, are
+// not listed.
+func List(ctx context.Context, fetcher content.Fetcher, indexDesc ocispec.Descriptor) ([]ocispec.Descriptor, error) {
+	index, err := fetchIndex(ctx, fetcher, indexDesc)
+	if err != nil {
+		return nil, err
+	}
+	var manifests []ocispec.Descriptor
+	for _, manifest := range index.Manifests {
+		if manifest.Platform == nil || manifest.Annotations[annotationDockerReferenceType] == dockerReferenceTypeAttestation {
+			continue
+		}
+		manifests = append(manifests, manifest)
+	}
+	return manifests, nil
+}
+
+// fetchIndex fetches the image index described by indexDesc.
+func fetchIndex(ctx context.Context, fetcher content.Fetcher, indexDesc ocispec.Descriptor) (*ocispec.Index, error) {
+	if !IsIndex(indexDesc) {
+		return nil, fmt.Errorf("%s is a manifest of media type %q rather than an image index, which has no platforms to select", indexDesc.Digest, indexDesc.MediaType)
+	}
+	if indexDesc.Size > maxIndexSize {
+		return nil, fmt.Errorf("image index %s of size %d exceeds the limit of %d bytes", indexDesc.Digest, indexDesc.Size, maxIndexSize)
+	}
+	indexJSON, err := content.FetchAll(ctx, fetcher, indexDesc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch image index %s: %w", indexDesc.Digest, err)
+	}
+	var index ocispec.Index
+	if err := json.Unmarshal(indexJSON, &index); err != nil {
+		return nil, fmt.Errorf("malformed image index %s: %w", indexDesc.Digest, err)
+	}
+	return &index, nil
+}
+
+func normalizeArch(arch string) string {
+	arch = strings.ToLower(arch)
+	if alias, ok := archAliases[arch]; ok {
+		return alias
+	}
+	return arch
+}
+
+func normalizeVariant(arch, variant string) string {
+	if arch == "arm64" && variant == "v8" {
+		return ""
+	}
+	return variant
+}
@@ -0,0 +1,130 @@
+package platform
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/content/memory"
+)
+
+func TestParse(t *testing.T) {
+	tests := map[string]ocispec.Platform{
+		"linux/arm64":    {OS: "linux", Architecture: "arm64"},
+		"linux/x86_64":   {OS: "linux", Architecture: "amd64"},
+		"Linux/arm/v7":   {OS: "linux", Architecture: "arm", Variant: "v7"},
+		"windows/amd64":  {OS: "windows", Architecture: "amd64"},
+		"linux/aarch64/": {},
+		"linux":          {},
+		"linux/arm/v7/x": {},
+	}
+	for s, want := range tests {
+		got, err := Parse(s)
+		if want.OS == "" {
+			if err == nil {
+				t.Fatalf("Parse(%q) expected error, got %+v", s, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", s, err)
+		}
+		if got.OS != want.OS || got.Architecture != want.Architecture || got.Variant != want.Variant {
+			t.Fatalf("Parse(%q) = %+v, want %+v", s, got, want)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	arm64 := ocispec.Platform{OS: "linux", Architecture: "arm64"}
+	if !Match(arm64, ocispec.Platform{OS: "linux", Architecture: "arm64", Variant: "v8"}) {
+		t.Fatal("expected linux/arm64 to match linux/arm64/v8")
+	}
+	if !Match(ocispec.Platform{OS: "linux", Architecture: "arm64", Variant: "v8"}, arm64) {
+		t.Fatal("expected linux/arm64/v8 to match linux/arm64")
+	}
+	if Match(ocispec.Platform{OS: "linux", Architecture: "arm", Variant: "v7"}, ocispec.Platform{OS: "linux", Architecture: "arm", Variant: "v6"}) {
+		t.Fatal("expected linux/arm/v7 not to match linux/arm/v6")
+	}
+	if Match(arm64, ocispec.Platform{OS: "linux", Architecture: "amd64"}) {
+		t.Fatal("expected linux/arm64 not to match linux/amd64")
+	}
+}
+
+func TestSelect(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New()
+	amd64 := ocispec.Descriptor{MediaType: ocispec.MediaTypeImageManifest, Digest: digest.FromString("amd64"), Size: 5, Platform: &ocispec.Platform{OS: "linux", Architecture: "amd64"}}
+	arm64 := ocispec.Descriptor{MediaType: ocispec.MediaTypeImageManifest, Digest: digest.FromString("arm64"), Size: 5, Platform: &ocispec.Platform{OS: "linux", Architecture: "arm64", Variant: "v8"}}
+	attestation := ocispec.Descriptor{MediaType: ocispec.MediaTypeImageManifest, Digest: digest.FromString("attestation"), Size: 11, Platform: &ocispec.Platform{OS: "unknown", Architecture: "unknown"}}
+	indexJSON, err := json.Marshal(ocispec.Index{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: ocispec.MediaTypeImageIndex,
+		Manifests: []ocispec.Descriptor{amd64, arm64, attestation},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	indexDesc := content.NewDescriptorFromBytes(ocispec.MediaTypeImageIndex, indexJSON)
+	if err := store.Push(ctx, indexDesc, bytes.NewReader(indexJSON)); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Select(ctx, store, indexDesc, ocispec.Platform{OS: "linux", Architecture: "arm64"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Digest != arm64.Digest {
+		t.Fatalf("Select() = %v, want %v", got.Digest, arm64.Digest)
+	}
+	if _, err := Select(ctx, store, indexDesc, ocispec.Platform{OS: "windows", Architecture: "amd64"}); !errors.Is(err, ErrNoMatch) {
+		t.Fatalf("Select() error = %v, want %v", err, ErrNoMatch)
+	}
+	if _, err := Select(ctx, store, amd64, ocispec.Platform{OS: "linux", Architecture: "amd64"}); err == nil {
+		t.Fatal("expected error for a manifest that is not an image index")
+	}
+}
+
+func TestList(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New()
+	amd64 := ocispec.Descriptor{MediaType: ocispec.MediaTypeImageManifest, Digest: digest.FromString("amd64"), Size: 5, Platform: &ocispec.Platform{OS: "linux", Architecture: "amd64"}}
+	arm64 := ocispec.Descriptor{MediaType: ocispec.MediaTypeImageManifest, Digest: digest.FromString("arm64"), Size: 5, Platform: &ocispec.Platform{OS: "linux", Architecture: "arm64"}}
+	attestation := ocispec.Descriptor{
+		MediaType:   ocispec.MediaTypeImageManifest,
+		Digest:      digest.FromString("attestation"),
+		Size:        11,
+		Platform:    &ocispec.Platform{OS: "unknown", Architecture: "unknown"},
+		Annotations: map[string]string{"vnd.docker.reference.type": "attestation-manifest"},
+	}
+	noPlatform := ocispec.Descriptor{MediaType: ocispec.MediaTypeImageManifest, Digest: digest.FromString("none"), Size: 4}
+	indexJSON, err := json.Marshal(ocispec.Index{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: ocispec.MediaTypeImageIndex,
+		Manifests: []ocispec.Descriptor{amd64, attestation, noPlatform, arm64},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	indexDesc := content.NewDescriptorFromBytes(ocispec.MediaTypeImageIndex, indexJSON)
+	if err := store.Push(ctx, indexDesc, bytes.NewReader(indexJSON)); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := List(ctx, store, indexDesc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].Digest != amd64.Digest || got[1].Digest != arm64.Digest {
+		t.Fatalf("List() = %v, want the manifests of linux/amd64 and linux/arm64", got)
+	}
+	if _, err := List(ctx, store, amd64); err == nil {
+		t.Fatal("expected error for a manifest that is not an image index")
+	}
+}
@@ -0,0 +1,310 @@
+// Package pluginproto runs notation plugins, validating their responses
+// strictly against the schemas of the plugin protocol. Invalid responses are
+// reported with the locations of the invalid values instead of the bare
+// decoding errors, and the values of known older versions of the protocol are
+// converted to the current values.
+package pluginproto
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/notaryproject/notation-go/dir"
+	"github.com/notaryproject/notation-go/log"
+	"github.com/notaryproject/notation-go/plugin"
+	"github.com/notaryproject/notation-go/plugin/proto"
+	"github.com/notaryproject/notation/internal/slices"
+)
+
+// executor runs the plugin commands, replaced in tests.
+var executor commander = execCommander{}
+
+// ResponseError is returned if a plugin responds with a response not
+// compliant with the plugin protocol.
+type ResponseError struct {
+	// Plugin is the name of the plugin.
+	Plugin string
+
+	// Command is the plugin command responded to.
+	Command proto.Command
+
+	// ErrorResponse is set if the invalid response is the error response
+	// written to stderr by the plugin failing the command.
+	ErrorResponse bool
+
+	// Line and Column are the 1-based location of the invalid value in the
+	// response.
+	Line   int
+	Column int
+
+	// Path is the JSON path of the invalid value, e.g.
+	// "$.certificateChain[1]", or empty if the response is not valid JSON.
+	Path string
+
+	// Msg describes the violation.
+	Msg string
+}
+
+func (e *ResponseError) Error() string {
+	response := "response"
+	if e.ErrorResponse {
+		response = "error response"
+	}
+	location := fmt.Sprintf("line %d, column %d", e.Line, e.Column)
+	if e.Path != "" {
+		location += " (" + e.Path + ")"
+	}
+	return fmt.Sprintf("plugin %q returned an invalid %s to command %q at %s: %s", e.Plugin, response, e.Command, location, e.Msg)
+}
+
+// Unwrap returns plugin.ErrNotCompliant.
+func (e *ResponseError) Unwrap() error {
+	return plugin.ErrNotCompliant
+}
+
+// CLIManager implements plugin.Manager for the plugins installed in the
+// plugin directory, returning plugins validating their responses strictly.
+type CLIManager struct {
+	*plugin.CLIManager
+	pluginFS dir.SysFS
+
+	// VerifyTimeout is the timeout of the "verify-signature" command of the
+	// plugins, unlimited if not positive.
+	VerifyTimeout time.Duration
+}
+
+// NewCLIManager returns a CLIManager of the plugins in pluginFS.
+func NewCLIManager(pluginFS dir.SysFS) *CLIManager {
+	return &CLIManager{
+		CLIManager: plugin.NewCLIManager(pluginFS),
+		pluginFS:   pluginFS,
+	}
+}
+
+// Get returns the plugin of the name. If the plugin is not found, the error
+// is of type os.ErrNotExist.
+func (m *CLIManager) Get(ctx context.Context, name string) (plugin.Plugin, error) {
+	binPath, err := m.pluginFS.SysPath(path.Join(name, BinaryName(name)))
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(binPath)
+	if err != nil {
+		return nil, err
+	}
+	if !info.Mode().IsRegular() {
+		return nil, plugin.ErrNotRegularFile
+	}
+	return &CLIPlugin{name: name, path: binPath, verifyTimeout: m.VerifyTimeout}, nil
+}
+
+// BinaryName returns the file name of the executable of the plugin.
+func BinaryName(name string) string {
+	if runtime.GOOS == "windows" {
+		return proto.Prefix + name + ".exe"
+	}
+	return proto.Prefix + name
+}
+
+// CLIPlugin implements plugin.Plugin for the executables of plugins.
+type CLIPlugin struct {
+	name          string
+	path          string
+	verifyTimeout time.Duration
+}
+
+// GetMetadata returns the metadata of the plugin, which must support the
+// current contract version.
+func (p *CLIPlugin) GetMetadata(ctx context.Context, req *proto.GetMetadataRequest) (*proto.GetMetadataResponse, error) {
+	var metadata proto.GetMetadataResponse
+	if err := p.run(ctx, req, &metadata); err != nil {
+		return nil, err
+	}
+	if !slices.Contains(metadata.SupportedContractVersions, proto.ContractVersion) {
+		return nil, fmt.Errorf("invalid metadata: contract version %q is not in the list of the plugin supported versions %v", proto.ContractVersion, metadata.SupportedContractVersions)
+	}
+	if metadata.Name != p.name {
+		return nil, fmt.Errorf("executable name must be %q instead of %q", BinaryName(metadata.Name), filepath.Base(p.path))
+	}
+	return &metadata, nil
+}
+
+// DescribeKey returns the key spec of a key. The contract version of req is
+// set if empty.
+func (p *CLIPlugin) DescribeKey(ctx context.Context, req *proto.DescribeKeyRequest) (*proto.DescribeKeyResponse, error) {
+	if req.ContractVersion == "" {
+		req.ContractVersion = proto.ContractVersion
+	}
+	var resp proto.DescribeKeyResponse
+	if err := p.run(ctx, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GenerateSignature generates the raw signature of the payload of req. The
+// contract version of req is set if empty.
+func (p *CLIPlugin) GenerateSignature(ctx context.Context, req *proto.GenerateSignatureRequest) (*proto.GenerateSignatureResponse, error) {
+	if req.ContractVersion == "" {
+		req.ContractVersion = proto.ContractVersion
+	}
+	var resp proto.GenerateSignatureResponse
+	if err := p.run(ctx, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GenerateEnvelope generates the signature envelope of the payload of req.
+// The contract version of req is set if empty.
+func (p *CLIPlugin) GenerateEnvelope(ctx context.Context, req *proto.GenerateEnvelopeRequest) (*proto.GenerateEnvelopeResponse, error) {
+	if req.ContractVersion == "" {
+		req.ContractVersion = proto.ContractVersion
+	}
+	var resp proto.GenerateEnvelopeResponse
+	if err := p.run(ctx, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// VerifySignature verifies the signature of req. The contract version of req
+// is set if empty. The plugin is killed if it does not respond within the
+// verify timeout.
+func (p *CLIPlugin) VerifySignature(ctx context.Context, req *proto.VerifySignatureRequest) (*proto.VerifySignatureResponse, error) {
+	if req.ContractVersion == "" {
+		req.ContractVersion = proto.ContractVersion
+	}
+	runCtx := ctx
+	if p.verifyTimeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, p.verifyTimeout)
+		defer cancel()
+	}
+	var resp proto.VerifySignatureResponse
+	if err := p.run(runCtx, req, &resp); err != nil {
+		if errors.Is(runCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+			log.GetLogger(ctx).Warnf("Plugin %q did not respond to command %q within %v", p.name, req.Command(), p.verifyTimeout)
+			return nil, proto.RequestError{
+				Code: proto.ErrorCodeTimeout,
+				Err:  fmt.Errorf("plugin %q did not respond to command %q within %v", p.name, req.Command(), p.verifyTimeout),
+			}
+		}
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// run runs the command of req, decoding the response to resp after it is
+// validated against the schema of the command.
+func (p *CLIPlugin) run(ctx context.Context, req proto.Request, resp any) error {
+	logger := log.GetLogger(ctx)
+	data, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("%s: failed to marshal request object: %w", p.name, err)
+	}
+	logger.Debugf("Plugin %s request: %s", req.Command(), string(data))
+	stdout, stderr, err := executor.Output(ctx, p.path, req.Command(), data)
+	if err != nil {
+		logger.Debugf("plugin %s execution status: %v", req.Command(), err)
+		logger.Debugf("Plugin %s returned error: %s", req.Command(), string(stderr))
+		return p.requestError(ctx, req.Command(), err, stderr)
+	}
+	logger.Debugf("Plugin %s response: %s", req.Command(), string(stdout))
+	return p.decode(ctx, req.Command(), false, responseSchemas[req.Command()], stdout, resp)
+}
+
+// requestError returns the error of the plugin failing the command with the
+// error response stderr.
+func (p *CLIPlugin) requestError(ctx context.Context, command proto.Command, err error, stderr []byte) error {
+	if !json.Valid(stderr) {
+		return proto.RequestError{
+			Code: proto.ErrorCodeGeneric,
+			Err:  fmt.Errorf("response is not in JSON format. error: %v, stderr: %s", err, string(stderr)),
+		}
+	}
+	var errResp struct {
+		Code     proto.ErrorCode   `json:"errorCode"`
+		Message  string            `json:"errorMessage"`
+		Metadata map[string]string `json:"errorMetadata"`
+	}
+	if err := p.decode(ctx, command, true, errorSchema, stderr, &errResp); err != nil {
+		return err
+	}
+	reqErr := proto.RequestError{Code: errResp.Code, Metadata: errResp.Metadata}
+	if errResp.Message != "" {
+		reqErr.Err = errors.New(errResp.Message)
+	}
+	return reqErr
+}
+
+// decode validates the response data of the command against s, and decodes
+// it to v after converting the values of older versions of the protocol.
+func (p *CLIPlugin) decode(ctx context.Context, command proto.Command, errorResponse bool, s schema, data []byte, v any) error {
+	responseError := func(err error) error {
+		var located *locatedError
+		if !errors.As(err, &located) {
+			return err
+		}
+		line, column := lineColumn(data, located.offset)
+		return &ResponseError{
+			Plugin:        p.name,
+			Command:       command,
+			ErrorResponse: errorResponse,
+			Line:          line,
+			Column:        column,
+			Path:          located.path,
+			Msg:           located.msg,
+		}
+	}
+	n, err := parse(data)
+	if err != nil {
+		return responseError(err)
+	}
+	c := &checker{}
+	if err := s.check(c, n, "$"); err != nil {
+		return responseError(err)
+	}
+	for _, shim := range c.shims {
+		log.GetLogger(ctx).Warnf("Plugin %q responded to command %q with a value of an older version of the plugin protocol, %s. Upgrade the plugin", p.name, command, shim)
+	}
+	converted, err := json.Marshal(n.value())
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(converted, v); err != nil {
+		return fmt.Errorf("plugin %q returned an invalid response to command %q: %v: %w", p.name, command, err, plugin.ErrNotCompliant)
+	}
+	return nil
+}
+
+// commander runs the plugin commands.
+type commander interface {
+	// Output runs the command, passing req to its stdin. It returns stdout
+	// if err is nil, or stderr if err is not nil.
+	Output(ctx context.Context, path string, command proto.Command, req []byte) (stdout []byte, stderr []byte, err error)
+}
+
+// execCommander implements commander with the executables of the plugins.
+type execCommander struct{}
+
+func (execCommander) Output(ctx context.Context, name string, command proto.Command, req []byte) ([]byte, []byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, string(command))
+	cmd.Stdin = bytes.NewReader(req)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, stderr.Bytes(), err
+	}
+	return stdout.Bytes(), nil, nil
+}
@@ -0,0 +1,271 @@
+package pluginproto
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/notation-go/dir"
+	"github.com/notaryproject/notation-go/plugin"
+	"github.com/notaryproject/notation-go/plugin/proto"
+)
+
+// mockCommander responds to the plugin commands with the responses.
+type mockCommander struct {
+	stdout string
+	stderr string
+	err    error
+}
+
+func (c mockCommander) Output(context.Context, string, proto.Command, []byte) ([]byte, []byte, error) {
+	if c.err != nil {
+		return nil, []byte(c.stderr), c.err
+	}
+	return []byte(c.stdout), nil, nil
+}
+
+// mockResponse sets the responses of the plugin commands in the test.
+func mockResponse(t *testing.T, c mockCommander) {
+	t.Helper()
+	original := executor
+	executor = c
+	t.Cleanup(func() { executor = original })
+}
+
+const validMetadata = `{
+  "name": "foo",
+  "description": "foo plugin",
+  "version": "1.0.0",
+  "url": "https://example.com/foo",
+  "supportedContractVersions": ["1.0"],
+  "capabilities": ["SIGNATURE_GENERATOR.RAW"]
+}`
+
+func TestGetMetadata(t *testing.T) {
+	p := &CLIPlugin{name: "foo", path: "notation-foo"}
+	mockResponse(t, mockCommander{stdout: validMetadata})
+	metadata, err := p.GetMetadata(context.Background(), &proto.GetMetadataRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if metadata.Version != "1.0.0" || !reflect.DeepEqual(metadata.Capabilities, []proto.Capability{proto.CapabilitySignatureGenerator}) {
+		t.Fatalf("unexpected metadata %+v", metadata)
+	}
+
+	// the executable is named after the plugin
+	p.name = "bar"
+	if _, err := p.GetMetadata(context.Background(), &proto.GetMetadataRequest{}); err == nil || !strings.Contains(err.Error(), BinaryName("foo")) {
+		t.Fatalf("GetMetadata() error = %v, want error of the executable name", err)
+	}
+
+	mockResponse(t, mockCommander{stdout: strings.Replace(validMetadata, `"1.0"`, `"2.0"`, 1)})
+	p.name = "foo"
+	if _, err := p.GetMetadata(context.Background(), &proto.GetMetadataRequest{}); err == nil || !strings.Contains(err.Error(), "contract version") {
+		t.Fatalf("GetMetadata() error = %v, want error of the contract version", err)
+	}
+}
+
+func TestRun_InvalidResponse(t *testing.T) {
+	tests := []struct {
+		name     string
+		command  proto.Command
+		response string
+		want     string
+	}{
+		{
+			name:     "missing field",
+			command:  proto.CommandGetMetadata,
+			response: strings.Replace(validMetadata, `"version": "1.0.0",`, "", 1),
+			want:     `at line 1, column 1 ($): missing required field "version"`,
+		},
+		{
+			name:     "wrong type",
+			command:  proto.CommandGetMetadata,
+			response: strings.Replace(validMetadata, `["1.0"]`, `"1.0"`, 1),
+			want:     `at line 6, column 32 ($.supportedContractVersions): expected array, got string`,
+		},
+		{
+			name:     "unsupported capability",
+			command:  proto.CommandGetMetadata,
+			response: strings.Replace(validMetadata, `SIGNATURE_GENERATOR.RAW`, `SIGNATURE_SIGNER`, 1),
+			want:     `at line 7, column 20 ($.capabilities[0]): unsupported value "SIGNATURE_SIGNER"`,
+		},
+		{
+			name:     "invalid JSON",
+			command:  proto.CommandDescribeKey,
+			response: "{\n  \"keyId\": \"key\",\n  \"keySpec\": RSA-2048\n}",
+			want:     "at line 3, column 14: invalid JSON",
+		},
+		{
+			name:     "duplicate field",
+			command:  proto.CommandDescribeKey,
+			response: `{"keyId": "key", "keySpec": "RSA-2048", "keyId": "other"}`,
+			want:     `at line 1, column 41: duplicate field "keyId"`,
+		},
+		{
+			name:     "trailing data",
+			command:  proto.CommandDescribeKey,
+			response: `{"keyId": "key", "keySpec": "RSA-2048"} {}`,
+			want:     "at line 1, column 41: unexpected data after the JSON value",
+		},
+		{
+			name:     "truncated",
+			command:  proto.CommandDescribeKey,
+			response: `{"keyId": "key", "keySpec": "RSA-2048"`,
+			want:     "invalid JSON",
+		},
+		{
+			name:     "invalid base64",
+			command:  proto.CommandGenerateSignature,
+			response: `{"keyId": "key", "signature": "c2ln", "signingAlgorithm": "RSASSA-PSS-SHA-256", "certificateChain": ["Y2VydA==", "not base64"]}`,
+			want:     `($.certificateChain[1]): must be base64 encoded`,
+		},
+		{
+			name:     "unsupported verification result",
+			command:  proto.CommandVerifySignature,
+			response: `{"verificationResults": {"SIGNATURE_VERIFIER.EXPIRY": {"success": true}}}`,
+			want:     `at line 1, column 26 ($.verificationResults): unsupported key "SIGNATURE_VERIFIER.EXPIRY"`,
+		},
+		{
+			name:     "nested field",
+			command:  proto.CommandVerifySignature,
+			response: `{"verificationResults": {"SIGNATURE_VERIFIER.REVOCATION_CHECK": {"success": "true"}}}`,
+			want:     `($.verificationResults["SIGNATURE_VERIFIER.REVOCATION_CHECK"].success): expected boolean, got string`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockResponse(t, mockCommander{stdout: tt.response})
+			p := &CLIPlugin{name: "foo", path: "notation-foo"}
+			var err error
+			switch tt.command {
+			case proto.CommandGetMetadata:
+				_, err = p.GetMetadata(context.Background(), &proto.GetMetadataRequest{})
+			case proto.CommandDescribeKey:
+				_, err = p.DescribeKey(context.Background(), &proto.DescribeKeyRequest{KeyID: "key"})
+			case proto.CommandGenerateSignature:
+				_, err = p.GenerateSignature(context.Background(), &proto.GenerateSignatureRequest{KeyID: "key"})
+			case proto.CommandVerifySignature:
+				_, err = p.VerifySignature(context.Background(), &proto.VerifySignatureRequest{})
+			}
+			var respErr *ResponseError
+			if !errors.As(err, &respErr) || !errors.Is(err, plugin.ErrNotCompliant) {
+				t.Fatalf("expected ResponseError, got %v", err)
+			}
+			if !strings.Contains(err.Error(), tt.want) || !strings.Contains(err.Error(), string(tt.command)) {
+				t.Fatalf("error = %q, want containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRun_LegacyValues(t *testing.T) {
+	p := &CLIPlugin{name: "foo", path: "notation-foo"}
+
+	mockResponse(t, mockCommander{stdout: `{"keyId": "key", "keySpec": "EC_384"}`})
+	key, err := p.DescribeKey(context.Background(), &proto.DescribeKeyRequest{KeyID: "key"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key.KeySpec != proto.KeySpecEC384 {
+		t.Fatalf("KeySpec = %q, want %q", key.KeySpec, proto.KeySpecEC384)
+	}
+
+	mockResponse(t, mockCommander{stdout: `{"keyId": "key", "signature": "c2ln", "signingAlgorithm": "RSASSA_PSS_SHA_256", "certificateChain": ["Y2VydA=="]}`})
+	sig, err := p.GenerateSignature(context.Background(), &proto.GenerateSignatureRequest{KeyID: "key"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sig.SigningAlgorithm != string(proto.SignatureAlgorithmRSASSA_PSS_SHA256) || string(sig.Signature) != "sig" || string(sig.CertificateChain[0]) != "cert" {
+		t.Fatalf("unexpected response %+v", sig)
+	}
+
+	mockResponse(t, mockCommander{stdout: strings.Replace(validMetadata, `"SIGNATURE_GENERATOR.RAW"`, `"SIGNATURE_GENERATOR", "SIGNATURE_ENVELOPE_GENERATOR"`, 1)})
+	metadata, err := p.GetMetadata(context.Background(), &proto.GetMetadataRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []proto.Capability{proto.CapabilitySignatureGenerator, proto.CapabilityEnvelopeGenerator}; !reflect.DeepEqual(metadata.Capabilities, want) {
+		t.Fatalf("Capabilities = %v, want %v", metadata.Capabilities, want)
+	}
+}
+
+func TestRun_ErrorResponse(t *testing.T) {
+	p := &CLIPlugin{name: "foo", path: "notation-foo"}
+	exitErr := errors.New("exit status 1")
+
+	mockResponse(t, mockCommander{stderr: `{"errorCode": "ACCESS_DENIED", "errorMessage": "key is disabled", "errorMetadata": {"keyId": "key"}}`, err: exitErr})
+	_, err := p.DescribeKey(context.Background(), &proto.DescribeKeyRequest{KeyID: "key"})
+	var reqErr proto.RequestError
+	if !errors.As(err, &reqErr) || reqErr.Code != proto.ErrorCodeAccessDenied || reqErr.Err.Error() != "key is disabled" || reqErr.Metadata["keyId"] != "key" {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	mockResponse(t, mockCommander{stderr: `{"errorCode": "DENIED"}`, err: exitErr})
+	_, err = p.DescribeKey(context.Background(), &proto.DescribeKeyRequest{KeyID: "key"})
+	var respErr *ResponseError
+	if !errors.As(err, &respErr) || !respErr.ErrorResponse || respErr.Path != "$.errorCode" {
+		t.Fatalf("expected ResponseError of the error response, got %v", err)
+	}
+
+	mockResponse(t, mockCommander{stderr: "panic: oops", err: exitErr})
+	_, err = p.DescribeKey(context.Background(), &proto.DescribeKeyRequest{KeyID: "key"})
+	if !errors.As(err, &reqErr) || reqErr.Code != proto.ErrorCodeGeneric || !strings.Contains(err.Error(), "panic: oops") {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestCLIManager_Get(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "foo"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "foo", BinaryName("foo")), nil, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, "bar", BinaryName("bar")), 0700); err != nil {
+		t.Fatal(err)
+	}
+	mgr := NewCLIManager(dir.NewSysFS(root))
+
+	names, err := mgr.List(context.Background())
+	if err != nil || !reflect.DeepEqual(names, []string{"bar", "foo"}) {
+		t.Fatalf("List() = %v, %v, want [bar foo]", names, err)
+	}
+	if _, err := mgr.Get(context.Background(), "foo"); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if _, err := mgr.Get(context.Background(), "bar"); !errors.Is(err, plugin.ErrNotRegularFile) {
+		t.Fatalf("Get() error = %v, want %v", err, plugin.ErrNotRegularFile)
+	}
+	if _, err := mgr.Get(context.Background(), "baz"); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("Get() error = %v, want %v", err, os.ErrNotExist)
+	}
+}
+
+// hangingCommander never responds to the plugin commands until the context
+// is done, as the executable is killed.
+type hangingCommander struct{}
+
+func (hangingCommander) Output(ctx context.Context, _ string, _ proto.Command, _ []byte) ([]byte, []byte, error) {
+	<-ctx.Done()
+	return nil, nil, ctx.Err()
+}
+
+func TestVerifySignature_Timeout(t *testing.T) {
+	original := executor
+	executor = hangingCommander{}
+	t.Cleanup(func() { executor = original })
+
+	p := &CLIPlugin{name: "foo", path: "notation-foo", verifyTimeout: 10 * time.Millisecond}
+	_, err := p.VerifySignature(context.Background(), &proto.VerifySignatureRequest{})
+	var reqErr proto.RequestError
+	if !errors.As(err, &reqErr) || reqErr.Code != proto.ErrorCodeTimeout || !strings.Contains(err.Error(), "within 10ms") {
+		t.Fatalf("VerifySignature() error = %v, want timeout error", err)
+	}
+}
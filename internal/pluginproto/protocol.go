@@ -0,0 +1,124 @@
+package pluginproto
+
+import (
+	"github.com/notaryproject/notation-go/plugin/proto"
+)
+
+// legacyKeySpecs maps the key specs spelled with underscores by plugins of
+// the pre-release drafts of the plugin contract to the current key specs.
+var legacyKeySpecs = map[string]string{
+	"RSA_2048": string(proto.KeySpecRSA2048),
+	"RSA_3072": string(proto.KeySpecRSA3072),
+	"RSA_4096": string(proto.KeySpecRSA4096),
+	"EC_256":   string(proto.KeySpecEC256),
+	"EC_384":   string(proto.KeySpecEC384),
+	"EC_521":   string(proto.KeySpecEC521),
+}
+
+// legacySigningAlgorithms maps the signing algorithms spelled with
+// underscores by plugins of the pre-release drafts of the plugin contract to
+// the current signing algorithms.
+var legacySigningAlgorithms = map[string]string{
+	"RSASSA_PSS_SHA_256": string(proto.SignatureAlgorithmRSASSA_PSS_SHA256),
+	"RSASSA_PSS_SHA_384": string(proto.SignatureAlgorithmRSASSA_PSS_SHA384),
+	"RSASSA_PSS_SHA_512": string(proto.SignatureAlgorithmRSASSA_PSS_SHA512),
+	"ECDSA_SHA_256":      string(proto.SignatureAlgorithmECDSA_SHA256),
+	"ECDSA_SHA_384":      string(proto.SignatureAlgorithmECDSA_SHA384),
+	"ECDSA_SHA_512":      string(proto.SignatureAlgorithmECDSA_SHA512),
+}
+
+// legacyCapabilities maps the capabilities of plugins of the pre-release
+// drafts of the plugin contract, which had no sub-capabilities of signature
+// generation, to the current capabilities.
+var legacyCapabilities = map[string]string{
+	"SIGNATURE_GENERATOR":          string(proto.CapabilitySignatureGenerator),
+	"SIGNATURE_ENVELOPE_GENERATOR": string(proto.CapabilityEnvelopeGenerator),
+}
+
+var (
+	capabilities = []string{
+		string(proto.CapabilitySignatureGenerator),
+		string(proto.CapabilityEnvelopeGenerator),
+		string(proto.CapabilityTrustedIdentityVerifier),
+		string(proto.CapabilityRevocationCheckVerifier),
+	}
+	verifierCapabilities = []string{
+		string(proto.CapabilityTrustedIdentityVerifier),
+		string(proto.CapabilityRevocationCheckVerifier),
+	}
+	keySpecs = []string{
+		string(proto.KeySpecRSA2048),
+		string(proto.KeySpecRSA3072),
+		string(proto.KeySpecRSA4096),
+		string(proto.KeySpecEC256),
+		string(proto.KeySpecEC384),
+		string(proto.KeySpecEC521),
+	}
+	signingAlgorithms = []string{
+		string(proto.SignatureAlgorithmRSASSA_PSS_SHA256),
+		string(proto.SignatureAlgorithmRSASSA_PSS_SHA384),
+		string(proto.SignatureAlgorithmRSASSA_PSS_SHA512),
+		string(proto.SignatureAlgorithmECDSA_SHA256),
+		string(proto.SignatureAlgorithmECDSA_SHA384),
+		string(proto.SignatureAlgorithmECDSA_SHA512),
+	}
+	errorCodes = []string{
+		string(proto.ErrorCodeValidation),
+		string(proto.ErrorCodeUnsupportedContractVersion),
+		string(proto.ErrorCodeAccessDenied),
+		string(proto.ErrorCodeTimeout),
+		string(proto.ErrorCodeThrottled),
+		string(proto.ErrorCodeGeneric),
+	}
+)
+
+var (
+	nonEmptyString = stringSchema{nonEmpty: true}
+	base64String   = stringSchema{nonEmpty: true, base64: true}
+)
+
+// responseSchemas are the schemas of the responses of the plugin commands.
+var responseSchemas = map[proto.Command]schema{
+	proto.CommandGetMetadata: objectSchema{
+		{name: "name", required: true, schema: nonEmptyString},
+		{name: "description", required: true, schema: nonEmptyString},
+		{name: "version", required: true, schema: nonEmptyString},
+		{name: "url", required: true, schema: nonEmptyString},
+		{name: "supportedContractVersions", required: true, schema: arraySchema{elem: nonEmptyString, nonEmpty: true}},
+		{name: "capabilities", required: true, schema: arraySchema{elem: stringSchema{enum: capabilities, legacy: legacyCapabilities}, nonEmpty: true}},
+	},
+	proto.CommandDescribeKey: objectSchema{
+		{name: "keyId", required: true, schema: nonEmptyString},
+		{name: "keySpec", required: true, schema: stringSchema{enum: keySpecs, legacy: legacyKeySpecs}},
+	},
+	proto.CommandGenerateSignature: objectSchema{
+		{name: "keyId", required: true, schema: nonEmptyString},
+		{name: "signature", required: true, schema: base64String},
+		{name: "signingAlgorithm", required: true, schema: stringSchema{enum: signingAlgorithms, legacy: legacySigningAlgorithms}},
+		{name: "certificateChain", required: true, schema: arraySchema{elem: base64String, nonEmpty: true}},
+	},
+	proto.CommandGenerateEnvelope: objectSchema{
+		{name: "signatureEnvelope", required: true, schema: base64String},
+		{name: "signatureEnvelopeType", required: true, schema: nonEmptyString},
+		{name: "annotations", schema: mapSchema{value: stringSchema{}}},
+	},
+	proto.CommandVerifySignature: objectSchema{
+		{name: "verificationResults", required: true, schema: mapSchema{
+			keys: verifierCapabilities,
+			value: objectSchema{
+				{name: "success", required: true, schema: boolSchema{}},
+				{name: "reason", schema: stringSchema{}},
+			},
+		}},
+		// plugins processing no critical attributes may omit it
+		{name: "processedAttributes", schema: arraySchema{elem: anySchema{}}},
+	},
+}
+
+// errorSchema is the schema of the error responses written to stderr by the
+// plugins failing the commands.
+var errorSchema = objectSchema{
+	{name: "errorCode", required: true, schema: stringSchema{enum: errorCodes}},
+	{name: "errorMessage", schema: stringSchema{}},
+	{name: "errorMetadata", schema: mapSchema{value: stringSchema{}}},
+}
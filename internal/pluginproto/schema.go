@@ -0,0 +1,382 @@
+package pluginproto
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/notaryproject/notation/internal/slices"
+)
+
+// kind is the kind of a JSON value.
+type kind int
+
+const (
+	kindNull kind = iota
+	kindBool
+	kindNumber
+	kindString
+	kindArray
+	kindObject
+)
+
+// String returns the name of the kind in the error messages.
+func (k kind) String() string {
+	switch k {
+	case kindBool:
+		return "boolean"
+	case kindNumber:
+		return "number"
+	case kindString:
+		return "string"
+	case kindArray:
+		return "array"
+	case kindObject:
+		return "object"
+	default:
+		return "null"
+	}
+}
+
+// node is a JSON value parsed with its offset in the response, so that
+// errors are reported at their locations.
+type node struct {
+	kind   kind
+	offset int64
+
+	// boolean, number or string value
+	scalar any
+
+	// elements of arrays
+	elems []*node
+
+	// fields of objects, in the order of the response
+	keys       []string
+	fields     map[string]*node
+	keyOffsets map[string]int64
+}
+
+// locatedError is an error at an offset of the response, and at a JSON path
+// if the response is parsed.
+type locatedError struct {
+	offset int64
+	path   string
+	msg    string
+}
+
+func (e *locatedError) Error() string {
+	if e.path == "" {
+		return e.msg
+	}
+	return e.path + ": " + e.msg
+}
+
+// parse parses the JSON value of data. Duplicate fields of objects and data
+// after the value are rejected.
+func parse(data []byte) (*node, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	n, err := parseValue(dec, data)
+	if err != nil {
+		return nil, err
+	}
+	offset := skipSeparators(data, dec.InputOffset())
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return nil, &locatedError{offset: offset, msg: "unexpected data after the JSON value"}
+	}
+	return n, nil
+}
+
+// parseValue parses the next JSON value of dec decoding data.
+func parseValue(dec *json.Decoder, data []byte) (*node, error) {
+	offset := skipSeparators(data, dec.InputOffset())
+	token, err := dec.Token()
+	if err != nil {
+		return nil, syntaxError(err, offset)
+	}
+	n := &node{offset: offset}
+	switch token := token.(type) {
+	case json.Delim:
+		switch token {
+		case '{':
+			n.kind = kindObject
+			n.fields = make(map[string]*node)
+			n.keyOffsets = make(map[string]int64)
+			for dec.More() {
+				keyOffset := skipSeparators(data, dec.InputOffset())
+				keyToken, err := dec.Token()
+				if err != nil {
+					return nil, syntaxError(err, keyOffset)
+				}
+				key, _ := keyToken.(string)
+				if _, ok := n.fields[key]; ok {
+					return nil, &locatedError{offset: keyOffset, msg: fmt.Sprintf("duplicate field %q", key)}
+				}
+				value, err := parseValue(dec, data)
+				if err != nil {
+					return nil, err
+				}
+				n.keys = append(n.keys, key)
+				n.fields[key] = value
+				n.keyOffsets[key] = keyOffset
+			}
+		case '[':
+			n.kind = kindArray
+			n.elems = []*node{}
+			for dec.More() {
+				elem, err := parseValue(dec, data)
+				if err != nil {
+					return nil, err
+				}
+				n.elems = append(n.elems, elem)
+			}
+		default:
+			return nil, &locatedError{offset: offset, msg: fmt.Sprintf("unexpected %q", rune(token))}
+		}
+		// the closing delimiter
+		endOffset := skipSeparators(data, dec.InputOffset())
+		if _, err := dec.Token(); err != nil {
+			return nil, syntaxError(err, endOffset)
+		}
+	case string:
+		n.kind, n.scalar = kindString, token
+	case json.Number:
+		n.kind, n.scalar = kindNumber, token
+	case bool:
+		n.kind, n.scalar = kindBool, token
+	case nil:
+		n.kind = kindNull
+	}
+	return n, nil
+}
+
+// syntaxError returns the error of decoding the token at offset, located at
+// the offset of the syntax error if any.
+func syntaxError(err error, offset int64) error {
+	var errSyntax *json.SyntaxError
+	if errors.As(err, &errSyntax) {
+		// the offset of json.SyntaxError is after the invalid character
+		offset := errSyntax.Offset - 1
+		if offset < 0 {
+			offset = 0
+		}
+		return &locatedError{offset: offset, msg: "invalid JSON: " + errSyntax.Error()}
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return &locatedError{offset: offset, msg: "invalid JSON: unexpected end of the response"}
+	}
+	return &locatedError{offset: offset, msg: "invalid JSON: " + err.Error()}
+}
+
+// skipSeparators returns the offset of the next token in data after offset,
+// skipping white spaces and the separators consumed by json.Decoder with
+// the next token.
+func skipSeparators(data []byte, offset int64) int64 {
+	for offset < int64(len(data)) && strings.IndexByte(" \t\r\n:,", data[offset]) != -1 {
+		offset++
+	}
+	return offset
+}
+
+// value returns the value of n for encoding/json.
+func (n *node) value() any {
+	switch n.kind {
+	case kindArray:
+		elems := make([]any, len(n.elems))
+		for i, elem := range n.elems {
+			elems[i] = elem.value()
+		}
+		return elems
+	case kindObject:
+		fields := make(map[string]any, len(n.fields))
+		for key, field := range n.fields {
+			fields[key] = field.value()
+		}
+		return fields
+	default:
+		return n.scalar
+	}
+}
+
+// checker validates the nodes of a response, recording the compatibility
+// shims applied.
+type checker struct {
+	shims []string
+}
+
+// schema is the schema of a JSON value of the plugin protocol.
+type schema interface {
+	// check validates n at the JSON path, rewriting the values of older
+	// versions of the protocol in place.
+	check(c *checker, n *node, path string) error
+}
+
+// stringSchema is the schema of strings.
+type stringSchema struct {
+	// nonEmpty rejects empty strings.
+	nonEmpty bool
+
+	// base64 requires strings of base64 encoded bytes.
+	base64 bool
+
+	// enum is the allowed values, any value if empty.
+	enum []string
+
+	// legacy maps the values of older versions of the protocol to the
+	// current values.
+	legacy map[string]string
+}
+
+func (s stringSchema) check(c *checker, n *node, path string) error {
+	if n.kind != kindString {
+		return typeError(n, path, kindString)
+	}
+	value := n.scalar.(string)
+	if s.nonEmpty && value == "" {
+		return &locatedError{offset: n.offset, path: path, msg: "must not be empty"}
+	}
+	if s.base64 {
+		if _, err := base64.StdEncoding.DecodeString(value); err != nil {
+			return &locatedError{offset: n.offset, path: path, msg: fmt.Sprintf("must be base64 encoded: %v", err)}
+		}
+	}
+	if len(s.enum) == 0 || slices.Contains(s.enum, value) {
+		return nil
+	}
+	if current, ok := s.legacy[value]; ok {
+		c.shims = append(c.shims, fmt.Sprintf("%s: converted legacy value %q to %q", path, value, current))
+		n.scalar = current
+		return nil
+	}
+	return &locatedError{offset: n.offset, path: path, msg: fmt.Sprintf("unsupported value %q, expected one of %s", value, quoteAll(s.enum))}
+}
+
+// boolSchema is the schema of booleans.
+type boolSchema struct{}
+
+func (boolSchema) check(c *checker, n *node, path string) error {
+	if n.kind != kindBool {
+		return typeError(n, path, kindBool)
+	}
+	return nil
+}
+
+// anySchema is the schema of values of any kind.
+type anySchema struct{}
+
+func (anySchema) check(*checker, *node, string) error {
+	return nil
+}
+
+// arraySchema is the schema of arrays.
+type arraySchema struct {
+	elem     schema
+	nonEmpty bool
+}
+
+func (s arraySchema) check(c *checker, n *node, path string) error {
+	if n.kind != kindArray {
+		return typeError(n, path, kindArray)
+	}
+	if s.nonEmpty && len(n.elems) == 0 {
+		return &locatedError{offset: n.offset, path: path, msg: "must not be empty"}
+	}
+	for i, elem := range n.elems {
+		if err := s.elem.check(c, elem, path+"["+strconv.Itoa(i)+"]"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mapSchema is the schema of objects of arbitrary keys.
+type mapSchema struct {
+	// keys is the allowed keys, any key if empty.
+	keys  []string
+	value schema
+}
+
+func (s mapSchema) check(c *checker, n *node, path string) error {
+	if n.kind != kindObject {
+		return typeError(n, path, kindObject)
+	}
+	for _, key := range n.keys {
+		if len(s.keys) != 0 && !slices.Contains(s.keys, key) {
+			return &locatedError{offset: n.keyOffsets[key], path: path, msg: fmt.Sprintf("unsupported key %q, expected one of %s", key, quoteAll(s.keys))}
+		}
+		if err := s.value.check(c, n.fields[key], fieldPath(path, key)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// field is a field of an objectSchema.
+type field struct {
+	name     string
+	required bool
+	schema   schema
+}
+
+// objectSchema is the schema of objects of known fields. Unknown fields are
+// ignored for the forward compatibility of the protocol, and null fields are
+// regarded as missing.
+type objectSchema []field
+
+func (s objectSchema) check(c *checker, n *node, path string) error {
+	if n.kind != kindObject {
+		return typeError(n, path, kindObject)
+	}
+	for _, f := range s {
+		value, ok := n.fields[f.name]
+		if !ok || value.kind == kindNull {
+			if f.required {
+				return &locatedError{offset: n.offset, path: path, msg: fmt.Sprintf("missing required field %q", f.name)}
+			}
+			continue
+		}
+		if err := f.schema.check(c, value, fieldPath(path, f.name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// typeError returns the error of n not being of the kind want.
+func typeError(n *node, path string, want kind) error {
+	return &locatedError{offset: n.offset, path: path, msg: fmt.Sprintf("expected %s, got %s", want, n.kind)}
+}
+
+// fieldPath returns the JSON path of the field key of the object at path.
+func fieldPath(path, key string) string {
+	for _, c := range key {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_') {
+			return path + "[" + strconv.Quote(key) + "]"
+		}
+	}
+	return path + "." + key
+}
+
+// lineColumn returns the 1-based line and column of offset in data.
+func lineColumn(data []byte, offset int64) (line, column int) {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	before := data[:offset]
+	line = bytes.Count(before, []byte("\n")) + 1
+	column = len(before) - bytes.LastIndexByte(before, '\n')
+	return line, column
+}
+
+func quoteAll(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = strconv.Quote(v)
+	}
+	return strings.Join(quoted, ", ")
+}
@@ -0,0 +1,121 @@
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	notationregistry "github.com/notaryproject/notation-go/registry"
+	"github.com/notaryproject/notation/internal/metadata"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+)
+
+func validateSignatureAnnotations(statement TrustPolicy) error {
+	for _, rule := range statement.SignatureAnnotations {
+		if _, err := metadata.ParseAssertion(rule); err != nil {
+			return fmt.Errorf("trust policy statement %q has an invalid signature annotation rule: %w", statement.Name, err)
+		}
+	}
+	return nil
+}
+
+// verifySignatureAnnotations verifies that the annotations of a signature
+// manifest satisfy the rules, in the format of the user metadata assertions.
+func verifySignatureAnnotations(rules []string, annotations map[string]string) error {
+	for _, rule := range rules {
+		// the rules are validated with the trust policy document
+		assertion, err := metadata.ParseAssertion(rule)
+		if err != nil {
+			return err
+		}
+		value, ok := annotations[assertion.Key]
+		if !ok {
+			return fmt.Errorf("signature manifest annotation %q required by the trust policy is not present", assertion.Key)
+		}
+		if err := assertion.Check(annotations); err != nil {
+			return fmt.Errorf("signature manifest annotation %q with value %q does not satisfy rule %q of the trust policy", assertion.Key, value, rule)
+		}
+	}
+	return nil
+}
+
+// signatureAnnotations are the annotations of the signature manifests indexed
+// by the digests of their signature envelopes, as verifiers only get the
+// signature envelopes.
+type signatureAnnotations struct {
+	mu          sync.Mutex
+	annotations map[digest.Digest]map[string]string
+}
+
+func (s *signatureAnnotations) set(envelopeDigest digest.Digest, annotations map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.annotations == nil {
+		s.annotations = make(map[digest.Digest]map[string]string)
+	}
+	s.annotations[envelopeDigest] = annotations
+}
+
+func (s *signatureAnnotations) get(envelope []byte) (map[string]string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	annotations, ok := s.annotations[digest.FromBytes(envelope)]
+	return annotations, ok
+}
+
+// UsesSignatureAnnotations returns true if trust policy statements have rules
+// on the annotations of the signature manifests, i.e. the repository of the
+// signatures must be wrapped by SignatureRepository.
+func (v *Verifier) UsesSignatureAnnotations() bool {
+	for _, statement := range v.extDoc.TrustPolicies {
+		if len(statement.SignatureAnnotations) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// SignatureRepository wraps repo to record the annotations of the signature
+// manifests fetched, which the Verifier checks against the rules of the trust
+// policy statements. The signature manifests are fetched by manifestFetcher,
+// as the listings of the signatures do not always carry their annotations.
+func (v *Verifier) SignatureRepository(repo notationregistry.Repository, manifestFetcher content.Fetcher) notationregistry.Repository {
+	return &annotationRepository{
+		Repository:      repo,
+		manifestFetcher: manifestFetcher,
+		annotations:     &v.signatureAnnotations,
+	}
+}
+
+// annotationRepository records the annotations of the signature manifests.
+type annotationRepository struct {
+	notationregistry.Repository
+	manifestFetcher content.Fetcher
+	annotations     *signatureAnnotations
+}
+
+// FetchSignatureBlob returns the signature envelope blob and descriptor for
+// the given signature manifest descriptor, and records the annotations of the
+// signature manifest.
+func (r *annotationRepository) FetchSignatureBlob(ctx context.Context, desc ocispec.Descriptor) ([]byte, ocispec.Descriptor, error) {
+	manifestJSON, err := content.FetchAll(ctx, r.manifestFetcher, desc)
+	if err != nil {
+		return nil, ocispec.Descriptor{}, err
+	}
+	// image manifests and artifact manifests have the same annotations field
+	var manifest struct {
+		Annotations map[string]string `json:"annotations"`
+	}
+	if err := json.Unmarshal(manifestJSON, &manifest); err != nil {
+		return nil, ocispec.Descriptor{}, fmt.Errorf("failed to parse signature manifest %s: %w", desc.Digest, err)
+	}
+	blob, blobDesc, err := r.Repository.FetchSignatureBlob(ctx, desc)
+	if err != nil {
+		return nil, ocispec.Descriptor{}, err
+	}
+	r.annotations.set(digest.FromBytes(blob), manifest.Annotations)
+	return blob, blobDesc, nil
+}
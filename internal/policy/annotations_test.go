@@ -0,0 +1,147 @@
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/notaryproject/notation-core-go/signature"
+	"github.com/notaryproject/notation-go"
+	notationregistry "github.com/notaryproject/notation-go/registry"
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/content/memory"
+)
+
+const testAnnotationsReference = "registry.example.com/build@sha256:0000000000000000000000000000000000000000000000000000000000000000"
+
+func newAnnotationDocuments() (*trustpolicy.Document, *Document) {
+	policyDoc, _ := newClockSkewDocuments()
+	extDoc := &Document{
+		TrustPolicies: []TrustPolicy{{Name: "build", SignatureAnnotations: []string{"io.cncf.notary.x-pipeline-id", "io.cncf.notary.x-pipeline~release-*"}}},
+	}
+	return policyDoc, extDoc
+}
+
+// envelopeVerifier is a base verifier verifying every signature.
+type envelopeVerifier struct{}
+
+func (v *envelopeVerifier) Verify(ctx context.Context, desc ocispec.Descriptor, sig []byte, opts notation.VerifierVerifyOptions) (*notation.VerificationOutcome, error) {
+	return &notation.VerificationOutcome{
+		RawSignature:      sig,
+		VerificationLevel: trustpolicy.LevelStrict,
+		EnvelopeContent:   &signature.EnvelopeContent{},
+	}, nil
+}
+
+// blobRepository returns the signature envelopes of the signature manifests.
+type blobRepository struct {
+	notationregistry.Repository
+	blobs map[digest.Digest][]byte
+}
+
+func (r *blobRepository) FetchSignatureBlob(ctx context.Context, desc ocispec.Descriptor) ([]byte, ocispec.Descriptor, error) {
+	blob := r.blobs[desc.Digest]
+	return blob, content.NewDescriptorFromBytes("application/jose+json", blob), nil
+}
+
+func TestParseDocument_SignatureAnnotations(t *testing.T) {
+	if _, err := ParseDocument([]byte(`{"trustPolicies":[{"name":"build","signatureAnnotations":["io.cncf.notary.x-pipeline-id","io.cncf.notary.x-pipeline~~release-.*"]}]}`)); err != nil {
+		t.Fatalf("ParseDocument() error = %v", err)
+	}
+	for _, rule := range []string{"", "=value", "io.cncf.notary.x-pipeline~~("} {
+		_, err := ParseDocument([]byte(`{"trustPolicies":[{"name":"build","signatureAnnotations":["` + rule + `"]}]}`))
+		if err == nil || !strings.Contains(err.Error(), "invalid signature annotation rule") {
+			t.Fatalf("expected error for rule %q, got %v", rule, err)
+		}
+	}
+}
+
+func TestVerifySignatureAnnotations(t *testing.T) {
+	rules := []string{"io.cncf.notary.x-pipeline-id", "io.cncf.notary.x-pipeline~release-*"}
+	if err := verifySignatureAnnotations(rules, map[string]string{"io.cncf.notary.x-pipeline-id": "42", "io.cncf.notary.x-pipeline": "release-main"}); err != nil {
+		t.Fatalf("verifySignatureAnnotations() error = %v", err)
+	}
+	if err := verifySignatureAnnotations(rules, map[string]string{"io.cncf.notary.x-pipeline": "release-main"}); err == nil || !strings.Contains(err.Error(), "is not present") {
+		t.Fatalf("expected error for a missing annotation, got %v", err)
+	}
+	if err := verifySignatureAnnotations(rules, map[string]string{"io.cncf.notary.x-pipeline-id": "42", "io.cncf.notary.x-pipeline": "dev"}); err == nil || !strings.Contains(err.Error(), "does not satisfy") {
+		t.Fatalf("expected error for a mismatching annotation, got %v", err)
+	}
+}
+
+func TestVerifier_SignatureAnnotations(t *testing.T) {
+	t.Setenv("NOTATION_EXPERIMENTAL", "1")
+	policyDoc, extDoc := newAnnotationDocuments()
+	v, err := NewVerifier(policyDoc, extDoc, func(policyDoc *trustpolicy.Document) (notation.Verifier, error) {
+		return &envelopeVerifier{}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !v.UsesSignatureAnnotations() {
+		t.Fatal("expected the verifier to use signature annotations")
+	}
+
+	// signature manifests with and without the annotations
+	ctx := context.Background()
+	store := memory.New()
+	repo := &blobRepository{blobs: make(map[digest.Digest][]byte)}
+	pushSignature := func(envelope string, annotations map[string]string) ocispec.Descriptor {
+		manifestJSON, err := json.Marshal(ocispec.Manifest{MediaType: ocispec.MediaTypeImageManifest, Annotations: annotations})
+		if err != nil {
+			t.Fatal(err)
+		}
+		desc := content.NewDescriptorFromBytes(ocispec.MediaTypeImageManifest, manifestJSON)
+		if err := store.Push(ctx, desc, strings.NewReader(string(manifestJSON))); err != nil {
+			t.Fatal(err)
+		}
+		repo.blobs[desc.Digest] = []byte(envelope)
+		return desc
+	}
+	pipelineSig := pushSignature("pipeline", map[string]string{"io.cncf.notary.x-pipeline-id": "42", "io.cncf.notary.x-pipeline": "release-main"})
+	manualSig := pushSignature("manual", map[string]string{"io.cncf.notary.x-pipeline": "release-main"})
+	sigRepo := v.SignatureRepository(repo, store)
+
+	opts := notation.VerifierVerifyOptions{ArtifactReference: testAnnotationsReference}
+	blob, _, err := sigRepo.FetchSignatureBlob(ctx, pipelineSig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := v.Verify(ctx, ocispec.Descriptor{}, blob, opts); err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	blob, _, err = sigRepo.FetchSignatureBlob(ctx, manualSig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = v.Verify(ctx, ocispec.Descriptor{}, blob, opts)
+	var verificationErr notation.ErrorVerificationFailed
+	if !errors.As(err, &verificationErr) || !strings.Contains(err.Error(), "io.cncf.notary.x-pipeline-id") {
+		t.Fatalf("expected the signature without pipeline id to fail, got %v", err)
+	}
+
+	// signatures not fetched through the repository fail closed
+	if _, err := v.Verify(ctx, ocispec.Descriptor{}, []byte("unknown"), opts); !errors.As(err, &verificationErr) {
+		t.Fatalf("expected the signature with unknown manifest to fail, got %v", err)
+	}
+	// statements without rules are not affected
+	if _, err := v.Verify(ctx, ocispec.Descriptor{}, []byte("unknown"), notation.VerifierVerifyOptions{ArtifactReference: "registry.example.com/prod@sha256:0000000000000000000000000000000000000000000000000000000000000000"}); err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+}
+
+func TestNewVerifier_SignatureAnnotationsExperimental(t *testing.T) {
+	t.Setenv("NOTATION_EXPERIMENTAL", "")
+	policyDoc, extDoc := newAnnotationDocuments()
+	_, err := NewVerifier(policyDoc, extDoc, func(policyDoc *trustpolicy.Document) (notation.Verifier, error) {
+		return &envelopeVerifier{}, nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "signatureAnnotations") {
+		t.Fatalf("expected experimental error, got %v", err)
+	}
+}
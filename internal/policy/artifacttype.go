@@ -0,0 +1,157 @@
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/notaryproject/notation-go/log"
+	notationregistry "github.com/notaryproject/notation-go/registry"
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+	"github.com/notaryproject/notation/internal/slices"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+)
+
+// HasArtifactTypes returns true if any trust policy statement is scoped by
+// artifact types.
+func (doc *Document) HasArtifactTypes() bool {
+	return len(doc.artifactTypes()) > 0
+}
+
+// artifactTypes returns the artifact types scoping the trust policy
+// statements, in the order of appearance.
+func (doc *Document) artifactTypes() []string {
+	if doc == nil {
+		return nil
+	}
+	var types []string
+	seen := make(map[string]bool)
+	for _, statement := range doc.TrustPolicies {
+		for _, artifactType := range statement.ArtifactTypes {
+			if !seen[artifactType] {
+				seen[artifactType] = true
+				types = append(types, artifactType)
+			}
+		}
+	}
+	return types
+}
+
+// ForArtifactType returns the trust policy document applicable to artifacts
+// of the artifact type.
+//
+// Statements scoped by other artifact types are removed. Statements without
+// artifact types apply to all artifact types, except for the registry scopes
+// of statements scoped by the artifact type. The returned document is not
+// validated.
+func (doc *Document) ForArtifactType(policyDoc *trustpolicy.Document, artifactType string) *trustpolicy.Document {
+	typedScopes := make(map[string]bool)
+	var typed, untyped []trustpolicy.TrustPolicy
+	for _, statement := range policyDoc.TrustPolicies {
+		types := doc.Get(statement.Name).ArtifactTypes
+		switch {
+		case len(types) == 0:
+			untyped = append(untyped, statement)
+		case slices.Contains(types, artifactType):
+			typed = append(typed, statement)
+			for _, scope := range statement.RegistryScopes {
+				typedScopes[scope] = true
+			}
+		}
+	}
+
+	filtered := &trustpolicy.Document{
+		Version: policyDoc.Version,
+	}
+	for _, statement := range untyped {
+		var scopes []string
+		for _, scope := range statement.RegistryScopes {
+			if !typedScopes[scope] {
+				scopes = append(scopes, scope)
+			}
+		}
+		if len(scopes) == 0 {
+			continue
+		}
+		statement.RegistryScopes = scopes
+		filtered.TrustPolicies = append(filtered.TrustPolicies, statement)
+	}
+	filtered.TrustPolicies = append(filtered.TrustPolicies, typed...)
+	return filtered
+}
+
+// ArtifactTypeOf returns the artifact type of the manifest content. The
+// artifactType field takes precedence over the media type of the config, so
+// that images are identified by their config media type, e.g.
+// "application/vnd.oci.image.config.v1+json".
+func ArtifactTypeOf(manifestJSON []byte) (string, error) {
+	var manifest struct {
+		MediaType    string              `json:"mediaType"`
+		ArtifactType string              `json:"artifactType"`
+		Config       *ocispec.Descriptor `json:"config"`
+	}
+	if err := json.Unmarshal(manifestJSON, &manifest); err != nil {
+		return "", fmt.Errorf("malformed manifest: %w", err)
+	}
+	if manifest.ArtifactType != "" {
+		return manifest.ArtifactType, nil
+	}
+	if manifest.Config != nil {
+		return manifest.Config.MediaType, nil
+	}
+	return manifest.MediaType, nil
+}
+
+// Repository wraps a notationregistry.Repository and sets the artifact type of
+// the resolved manifest descriptors, so that the trust policy statements
+// scoped by artifact types can be applied.
+type Repository struct {
+	notationregistry.Repository
+	manifestFetcher content.Fetcher
+
+	mu            sync.Mutex
+	artifactTypes map[digest.Digest]string
+}
+
+// NewRepository returns a Repository fetching the manifests resolved by repo
+// with manifestFetcher to read their artifact types.
+func NewRepository(repo notationregistry.Repository, manifestFetcher content.Fetcher) *Repository {
+	return &Repository{
+		Repository:      repo,
+		manifestFetcher: manifestFetcher,
+		artifactTypes:   make(map[digest.Digest]string),
+	}
+}
+
+// Resolve resolves a reference to a manifest descriptor with the artifact
+// type set.
+func (r *Repository) Resolve(ctx context.Context, reference string) (ocispec.Descriptor, error) {
+	desc, err := r.Repository.Resolve(ctx, reference)
+	if err != nil || desc.ArtifactType != "" {
+		return desc, err
+	}
+	r.mu.Lock()
+	artifactType, ok := r.artifactTypes[desc.Digest]
+	r.mu.Unlock()
+	if !ok {
+		manifestJSON, err := content.FetchAll(ctx, r.manifestFetcher, desc)
+		if err != nil {
+			return ocispec.Descriptor{}, fmt.Errorf("failed to fetch manifest %s to read its artifact type: %w", desc.Digest, err)
+		}
+		if artifactType, err = ArtifactTypeOf(manifestJSON); err != nil {
+			return ocispec.Descriptor{}, err
+		}
+		r.mu.Lock()
+		r.artifactTypes[desc.Digest] = artifactType
+		r.mu.Unlock()
+		log.GetLogger(ctx).Infof("Manifest %s has artifact type %q", desc.Digest, artifactType)
+	}
+	desc.ArtifactType = artifactType
+	return desc, nil
+}
+
+// compile time check
+var _ notationregistry.Repository = (*Repository)(nil)
@@ -0,0 +1,233 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+const (
+	imageType = "application/vnd.oci.image.config.v1+json"
+	sbomType  = "application/spdx+json"
+)
+
+func newTestDocuments() (*trustpolicy.Document, *Document) {
+	statement := func(name string, level string, scopes ...string) trustpolicy.TrustPolicy {
+		return trustpolicy.TrustPolicy{
+			Name:                  name,
+			RegistryScopes:        scopes,
+			SignatureVerification: trustpolicy.SignatureVerification{VerificationLevel: level},
+			TrustStores:           []string{"ca:acme"},
+			TrustedIdentities:     []string{"*"},
+		}
+	}
+	policyDoc := &trustpolicy.Document{
+		Version: "1.0",
+		TrustPolicies: []trustpolicy.TrustPolicy{
+			statement("default", "strict", "registry.example.com/app", "registry.example.com/other"),
+			statement("images", "strict", "registry.example.com/app"),
+			statement("reports", "audit", "registry.example.com/app"),
+		},
+	}
+	extDoc := &Document{
+		TrustPolicies: []TrustPolicy{
+			{Name: "images", ArtifactTypes: []string{imageType}},
+			{Name: "reports", ArtifactTypes: []string{sbomType, "application/sarif+json"}},
+		},
+	}
+	return policyDoc, extDoc
+}
+
+func statementScopes(doc *trustpolicy.Document) map[string][]string {
+	scopes := make(map[string][]string)
+	for _, statement := range doc.TrustPolicies {
+		scopes[statement.Name] = statement.RegistryScopes
+	}
+	return scopes
+}
+
+func TestForArtifactType(t *testing.T) {
+	policyDoc, extDoc := newTestDocuments()
+	tests := []struct {
+		artifactType string
+		want         map[string][]string
+	}{
+		{
+			artifactType: imageType,
+			want: map[string][]string{
+				"default": {"registry.example.com/other"},
+				"images":  {"registry.example.com/app"},
+			},
+		},
+		{
+			artifactType: sbomType,
+			want: map[string][]string{
+				"default": {"registry.example.com/other"},
+				"reports": {"registry.example.com/app"},
+			},
+		},
+		{
+			artifactType: "",
+			want: map[string][]string{
+				"default": {"registry.example.com/app", "registry.example.com/other"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.artifactType, func(t *testing.T) {
+			got := extDoc.ForArtifactType(policyDoc, tt.artifactType)
+			if !reflect.DeepEqual(statementScopes(got), tt.want) {
+				t.Fatalf("ForArtifactType() = %v, want %v", statementScopes(got), tt.want)
+			}
+			if err := got.Validate(); err != nil {
+				t.Fatalf("expected a valid document, got %v", err)
+			}
+		})
+	}
+	// the original document is unchanged
+	if scopes := policyDoc.TrustPolicies[0].RegistryScopes; len(scopes) != 2 {
+		t.Fatalf("expected the original statement to be unchanged, got %v", scopes)
+	}
+}
+
+func TestArtifactTypeOf(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		want     string
+	}{
+		{
+			name:     "artifact type",
+			manifest: `{"mediaType":"application/vnd.oci.image.manifest.v1+json","artifactType":"application/spdx+json","config":{"mediaType":"application/vnd.oci.empty.v1+json"}}`,
+			want:     sbomType,
+		},
+		{
+			name:     "config media type",
+			manifest: `{"mediaType":"application/vnd.oci.image.manifest.v1+json","config":{"mediaType":"application/vnd.oci.image.config.v1+json"}}`,
+			want:     imageType,
+		},
+		{
+			name:     "index",
+			manifest: `{"mediaType":"application/vnd.oci.image.index.v1+json","manifests":[]}`,
+			want:     ocispec.MediaTypeImageIndex,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ArtifactTypeOf([]byte(tt.manifest))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("ArtifactTypeOf() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// levelVerifier is a base verifier reporting the verification level of the
+// applicable statement of its trust policy document.
+type levelVerifier struct {
+	policyDoc *trustpolicy.Document
+}
+
+func (v *levelVerifier) level(reference string) (*trustpolicy.VerificationLevel, error) {
+	statement, err := v.policyDoc.GetApplicableTrustPolicy(reference)
+	if err != nil {
+		return nil, notation.ErrorNoApplicableTrustPolicy{Msg: err.Error()}
+	}
+	return statement.SignatureVerification.GetVerificationLevel()
+}
+
+func (v *levelVerifier) SkipVerify(ctx context.Context, opts notation.VerifierVerifyOptions) (bool, *trustpolicy.VerificationLevel, error) {
+	level, err := v.level(opts.ArtifactReference)
+	if err != nil {
+		return false, nil, err
+	}
+	return level.Name == trustpolicy.LevelSkip.Name, level, nil
+}
+
+func (v *levelVerifier) Verify(ctx context.Context, desc ocispec.Descriptor, signature []byte, opts notation.VerifierVerifyOptions) (*notation.VerificationOutcome, error) {
+	level, err := v.level(opts.ArtifactReference)
+	if err != nil {
+		return nil, err
+	}
+	return &notation.VerificationOutcome{VerificationLevel: level}, nil
+}
+
+func TestVerifier_ArtifactTypes(t *testing.T) {
+	t.Setenv("NOTATION_EXPERIMENTAL", "1")
+	policyDoc, extDoc := newTestDocuments()
+	v, err := NewVerifier(policyDoc, extDoc, func(policyDoc *trustpolicy.Document) (notation.Verifier, error) {
+		if err := policyDoc.Validate(); err != nil {
+			return nil, err
+		}
+		return &levelVerifier{policyDoc: policyDoc}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !v.UsesArtifactTypes() {
+		t.Fatal("expected the verifier to use artifact types")
+	}
+
+	opts := notation.VerifierVerifyOptions{ArtifactReference: "registry.example.com/app@sha256:0000000000000000000000000000000000000000000000000000000000000000"}
+	for artifactType, want := range map[string]string{
+		imageType:                    "strict",
+		sbomType:                     "audit",
+		"application/vnd.unknown.v1": "strict",
+	} {
+		outcome, err := v.Verify(context.Background(), ocispec.Descriptor{ArtifactType: artifactType}, nil, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if outcome.VerificationLevel.Name != want {
+			t.Fatalf("expected verification level %q for artifact type %q, got %q", want, artifactType, outcome.VerificationLevel.Name)
+		}
+	}
+	if skip, _, err := v.SkipVerify(context.Background(), opts); err != nil || skip {
+		t.Fatalf("expected no skip, got skip %v, error %v", skip, err)
+	}
+}
+
+func TestVerifier_ArtifactTypesSkip(t *testing.T) {
+	t.Setenv("NOTATION_EXPERIMENTAL", "1")
+	policyDoc := &trustpolicy.Document{
+		Version: "1.0",
+		TrustPolicies: []trustpolicy.TrustPolicy{
+			{Name: "reports", RegistryScopes: []string{"*"}, SignatureVerification: trustpolicy.SignatureVerification{VerificationLevel: "skip"}},
+		},
+	}
+	extDoc := &Document{TrustPolicies: []TrustPolicy{{Name: "reports", ArtifactTypes: []string{sbomType}}}}
+	v, err := NewVerifier(policyDoc, extDoc, func(policyDoc *trustpolicy.Document) (notation.Verifier, error) {
+		return &levelVerifier{policyDoc: policyDoc}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := notation.VerifierVerifyOptions{ArtifactReference: "registry.example.com/app@sha256:0000000000000000000000000000000000000000000000000000000000000000"}
+	if skip, _, err := v.SkipVerify(context.Background(), opts); err != nil || !skip {
+		t.Fatalf("expected skip, got skip %v, error %v", skip, err)
+	}
+	// artifacts of other artifact types have no applicable statement
+	_, err = v.Verify(context.Background(), ocispec.Descriptor{ArtifactType: imageType}, nil, opts)
+	if !errors.As(err, &notation.ErrorNoApplicableTrustPolicy{}) {
+		t.Fatalf("expected ErrorNoApplicableTrustPolicy, got %v", err)
+	}
+}
+
+func TestNewVerifier_ArtifactTypesExperimental(t *testing.T) {
+	t.Setenv("NOTATION_EXPERIMENTAL", "")
+	policyDoc, extDoc := newTestDocuments()
+	_, err := NewVerifier(policyDoc, extDoc, func(policyDoc *trustpolicy.Document) (notation.Verifier, error) {
+		return &levelVerifier{policyDoc: policyDoc}, nil
+	})
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
@@ -0,0 +1,250 @@
+package policy
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/notaryproject/notation-go/dir"
+	"github.com/notaryproject/notation-go/log"
+	"github.com/notaryproject/notation-go/verifier/truststore"
+	"github.com/notaryproject/notation/internal/slices"
+)
+
+const (
+	// trustBundleFileName is the name of the certificate file of a trust
+	// bundle in its cache directory.
+	trustBundleFileName = "bundle.pem"
+
+	// maxTrustBundleSize is the maximum size of a trust bundle fetched.
+	maxTrustBundleSize = 4 << 20
+
+	// trustBundleFetchTimeout is the timeout of fetching a trust bundle.
+	trustBundleFetchTimeout = 30 * time.Second
+)
+
+var (
+	// trustBundleNamePattern is the pattern of the names of trust bundles,
+	// the same as the names of the named trust stores.
+	trustBundleNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_.-]+$`)
+
+	// sha256HexPattern is the pattern of lowercase hex-encoded SHA-256
+	// digests.
+	sha256HexPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+)
+
+// TrustBundle is an experimental named x509 trust store whose certificates
+// are published at an HTTPS URL, e.g. the root certificates of a vendor. The
+// statements reference it in their trust stores as "{type}:{name}" like the
+// trust stores in the notation config directory. The bundle is fetched on
+// verification and cached by its pinned digest.
+type TrustBundle struct {
+	// Name is the name of the trust store.
+	Name string `json:"name"`
+
+	// Type is the type of the trust store, e.g. "ca".
+	Type truststore.Type `json:"type"`
+
+	// URL is the HTTPS URL of the certificates in PEM or DER.
+	URL string `json:"url"`
+
+	// SHA256 is the hex-encoded SHA-256 digest of the content at URL. Content
+	// with another digest is rejected.
+	SHA256 string `json:"sha256"`
+}
+
+func validateTrustBundles(bundles []TrustBundle) error {
+	seen := make(map[string]bool)
+	for _, bundle := range bundles {
+		if !trustBundleNamePattern.MatchString(bundle.Name) {
+			return fmt.Errorf("trust bundle name %q is invalid, it must only contain letters, digits, '_', '.' and '-'", bundle.Name)
+		}
+		if !slices.Contains(truststore.Types, bundle.Type) {
+			return fmt.Errorf("trust bundle %q has unsupported type %q, supported values are %q", bundle.Name, bundle.Type, truststore.Types)
+		}
+		store := string(bundle.Type) + ":" + bundle.Name
+		if seen[store] {
+			return fmt.Errorf("trust bundle %q is defined more than once", store)
+		}
+		seen[store] = true
+		u, err := url.Parse(bundle.URL)
+		if err != nil || u.Scheme != "https" || u.Host == "" {
+			return fmt.Errorf("trust bundle %q has invalid URL %q, an HTTPS URL is required", bundle.Name, bundle.URL)
+		}
+		if !sha256HexPattern.MatchString(bundle.SHA256) {
+			return fmt.Errorf("trust bundle %q has invalid sha256 %q, a lowercase hex-encoded SHA-256 digest is required", bundle.Name, bundle.SHA256)
+		}
+	}
+	return nil
+}
+
+// TrustBundleCacheDir returns the directory caching the trust bundles in the
+// user cache directory.
+func TrustBundleCacheDir() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheDir, "notation", "trustbundles"), nil
+}
+
+// ResolveTrustBundles returns the trust store overrides serving the trust
+// bundles from cacheDir. Bundles not cached yet are fetched with client and
+// cached by their digests, so that bundles are only fetched again if their
+// pinned digests change. A bundle must not have the name of a trust store in
+// the notation config directory configFS.
+func ResolveTrustBundles(ctx context.Context, bundles []TrustBundle, configFS dir.SysFS, client *http.Client, cacheDir string) ([]TrustStoreOverride, error) {
+	var overrides []TrustStoreOverride
+	for _, bundle := range bundles {
+		storePath, err := configFS.SysPath(dir.X509TrustStoreDir(string(bundle.Type), bundle.Name))
+		if err != nil {
+			return nil, err
+		}
+		if _, err := os.Stat(storePath); err == nil {
+			return nil, fmt.Errorf("trust bundle \"%s:%s\" conflicts with the trust store of the same name in the notation config directory, rename one of them", bundle.Type, bundle.Name)
+		}
+		bundleDir := filepath.Join(cacheDir, bundle.SHA256)
+		cached, err := isTrustBundleCached(bundleDir, bundle.SHA256)
+		if err != nil {
+			return nil, err
+		}
+		if !cached {
+			if err := fetchTrustBundle(ctx, client, bundle, bundleDir); err != nil {
+				return nil, err
+			}
+		}
+		overrides = append(overrides, TrustStoreOverride{
+			Type: bundle.Type,
+			Name: bundle.Name,
+			Dir:  bundleDir,
+		})
+	}
+	return overrides, nil
+}
+
+// cachedTrustBundles returns the trust store overrides serving the trust
+// bundles already cached in cacheDir, without fetching the others.
+func cachedTrustBundles(bundles []TrustBundle, cacheDir string) []TrustStoreOverride {
+	var overrides []TrustStoreOverride
+	for _, bundle := range bundles {
+		bundleDir := filepath.Join(cacheDir, bundle.SHA256)
+		if cached, err := isTrustBundleCached(bundleDir, bundle.SHA256); err == nil && cached {
+			overrides = append(overrides, TrustStoreOverride{
+				Type: bundle.Type,
+				Name: bundle.Name,
+				Dir:  bundleDir,
+			})
+		}
+	}
+	return overrides
+}
+
+// isTrustBundleCached returns true if the cache directory of a trust bundle
+// has the bundle with the digest. Tampered bundles are treated as missing and
+// fetched again.
+func isTrustBundleCached(bundleDir, sha256Hex string) (bool, error) {
+	content, err := os.ReadFile(filepath.Join(bundleDir, trustBundleFileName))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to read cached trust bundle: %w", err)
+	}
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:]) == sha256Hex, nil
+}
+
+// fetchTrustBundle fetches the trust bundle, checks it against its digest and
+// writes it to bundleDir.
+func fetchTrustBundle(ctx context.Context, client *http.Client, bundle TrustBundle, bundleDir string) error {
+	log.GetLogger(ctx).Infof("Fetching trust bundle %s:%s from %s", bundle.Type, bundle.Name, bundle.URL)
+	ctx, cancel := context.WithTimeout(ctx, trustBundleFetchTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, bundle.URL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to fetch trust bundle \"%s:%s\": %w", bundle.Type, bundle.Name, err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch trust bundle \"%s:%s\": %w", bundle.Type, bundle.Name, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch trust bundle \"%s:%s\" from %s: %s", bundle.Type, bundle.Name, bundle.URL, resp.Status)
+	}
+	content, err := io.ReadAll(io.LimitReader(resp.Body, maxTrustBundleSize+1))
+	if err != nil {
+		return fmt.Errorf("failed to fetch trust bundle \"%s:%s\": %w", bundle.Type, bundle.Name, err)
+	}
+	if len(content) > maxTrustBundleSize {
+		return fmt.Errorf("trust bundle \"%s:%s\" fetched from %s exceeds %d bytes", bundle.Type, bundle.Name, bundle.URL, maxTrustBundleSize)
+	}
+	sum := sha256.Sum256(content)
+	if got := hex.EncodeToString(sum[:]); got != bundle.SHA256 {
+		return fmt.Errorf("trust bundle \"%s:%s\" fetched from %s has sha256 %s, but the trust policy pins sha256 %s", bundle.Type, bundle.Name, bundle.URL, got, bundle.SHA256)
+	}
+	certs, err := parseTrustBundle(content)
+	if err != nil {
+		return fmt.Errorf("trust bundle \"%s:%s\" fetched from %s is invalid: %w", bundle.Type, bundle.Name, bundle.URL, err)
+	}
+	if len(certs) == 0 {
+		return fmt.Errorf("trust bundle \"%s:%s\" fetched from %s has no certificate", bundle.Type, bundle.Name, bundle.URL)
+	}
+	return writeTrustBundle(bundleDir, content)
+}
+
+// parseTrustBundle parses the certificates of a trust bundle in PEM or DER,
+// like the certificate files of the trust stores.
+func parseTrustBundle(content []byte) ([]*x509.Certificate, error) {
+	block, rest := pem.Decode(content)
+	if block == nil {
+		return x509.ParseCertificates(content)
+	}
+	var certs []*x509.Certificate
+	for ; block != nil; block, rest = pem.Decode(rest) {
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	return certs, nil
+}
+
+// writeTrustBundle writes the content of a trust bundle to its cache
+// directory atomically, so that concurrent verifications never read a partial
+// bundle. The temporary file is written next to the cache directory, as all
+// the files in the cache directory are read as certificate files.
+func writeTrustBundle(bundleDir string, content []byte) error {
+	if err := os.MkdirAll(bundleDir, 0700); err != nil {
+		return fmt.Errorf("failed to cache trust bundle: %w", err)
+	}
+	f, err := os.CreateTemp(filepath.Dir(bundleDir), ".bundle-*")
+	if err != nil {
+		return fmt.Errorf("failed to cache trust bundle: %w", err)
+	}
+	defer os.Remove(f.Name())
+	_, err = f.Write(content)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), filepath.Join(bundleDir, trustBundleFileName))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to cache trust bundle: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package policy
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/notation-go/dir"
+)
+
+func TestValidateTrustBundles(t *testing.T) {
+	digest := strings.Repeat("ab", 32)
+	tests := []struct {
+		name    string
+		bundles []TrustBundle
+		wantErr string
+	}{
+		{
+			name:    "valid",
+			bundles: []TrustBundle{{Name: "vendor", Type: "ca", URL: "https://vendor.example.com/roots.pem", SHA256: digest}},
+		},
+		{
+			name:    "invalid name",
+			bundles: []TrustBundle{{Name: "vendor/roots", Type: "ca", URL: "https://vendor.example.com/roots.pem", SHA256: digest}},
+			wantErr: "name",
+		},
+		{
+			name:    "unsupported type",
+			bundles: []TrustBundle{{Name: "vendor", Type: "x509", URL: "https://vendor.example.com/roots.pem", SHA256: digest}},
+			wantErr: "unsupported type",
+		},
+		{
+			name: "duplicate",
+			bundles: []TrustBundle{
+				{Name: "vendor", Type: "ca", URL: "https://vendor.example.com/roots.pem", SHA256: digest},
+				{Name: "vendor", Type: "ca", URL: "https://vendor.example.com/roots2.pem", SHA256: digest},
+			},
+			wantErr: "more than once",
+		},
+		{
+			name:    "plain HTTP",
+			bundles: []TrustBundle{{Name: "vendor", Type: "ca", URL: "http://vendor.example.com/roots.pem", SHA256: digest}},
+			wantErr: "HTTPS URL is required",
+		},
+		{
+			name:    "uppercase digest",
+			bundles: []TrustBundle{{Name: "vendor", Type: "ca", URL: "https://vendor.example.com/roots.pem", SHA256: strings.ToUpper(digest)}},
+			wantErr: "invalid sha256",
+		},
+		{
+			name:    "missing digest",
+			bundles: []TrustBundle{{Name: "vendor", Type: "ca", URL: "https://vendor.example.com/roots.pem"}},
+			wantErr: "invalid sha256",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateTrustBundles(tt.bundles)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("validateTrustBundles() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("validateTrustBundles() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestResolveTrustBundles(t *testing.T) {
+	configDir := t.TempDir()
+	writeTrustStoreCert(t, configDir, "vendor", "Vendor Root", time.Now().Add(24*time.Hour))
+	bundlePEM, err := os.ReadFile(filepath.Join(configDir, dir.X509TrustStoreDir("ca", "vendor"), "Vendor Root.pem"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.RemoveAll(filepath.Join(configDir, dir.X509TrustStoreDir("ca", "vendor"))); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(bundlePEM)
+	bundleDigest := hex.EncodeToString(sum[:])
+
+	var requests int
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write(bundlePEM)
+	}))
+	defer server.Close()
+
+	configFS := dir.NewSysFS(configDir)
+	cacheDir := t.TempDir()
+	bundles := []TrustBundle{{Name: "vendor", Type: "ca", URL: server.URL + "/roots.pem", SHA256: bundleDigest}}
+	for i := 0; i < 2; i++ {
+		overrides, err := ResolveTrustBundles(context.Background(), bundles, configFS, server.Client(), cacheDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := TrustStoreOverride{Type: "ca", Name: "vendor", Dir: filepath.Join(cacheDir, bundleDigest)}
+		if len(overrides) != 1 || overrides[0] != want {
+			t.Fatalf("ResolveTrustBundles() = %v, want %v", overrides, []TrustStoreOverride{want})
+		}
+	}
+	// the bundle is fetched once and then read from the cache
+	if requests != 1 {
+		t.Fatalf("bundle fetched %d times, want 1", requests)
+	}
+	if got := cachedTrustBundles(bundles, cacheDir); len(got) != 1 {
+		t.Fatalf("cachedTrustBundles() = %v, want the bundle", got)
+	}
+
+	// tampered caches are fetched again
+	if err := os.WriteFile(filepath.Join(cacheDir, bundleDigest, trustBundleFileName), []byte("tampered"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ResolveTrustBundles(context.Background(), bundles, configFS, server.Client(), cacheDir); err != nil {
+		t.Fatal(err)
+	}
+	if requests != 2 {
+		t.Fatalf("bundle fetched %d times, want 2", requests)
+	}
+
+	// content other than the pinned digest is rejected
+	pinned := []TrustBundle{{Name: "vendor", Type: "ca", URL: server.URL + "/roots.pem", SHA256: strings.Repeat("0", 64)}}
+	if _, err := ResolveTrustBundles(context.Background(), pinned, configFS, server.Client(), cacheDir); err == nil || !strings.Contains(err.Error(), "pins sha256") {
+		t.Fatalf("ResolveTrustBundles() error = %v, want digest mismatch", err)
+	}
+	if _, err := os.Stat(filepath.Join(cacheDir, strings.Repeat("0", 64))); !os.IsNotExist(err) {
+		t.Fatalf("rejected bundle is cached: %v", err)
+	}
+
+	// bundles must not shadow the trust stores of the config directory
+	writeTrustStoreCert(t, configDir, "vendor", "Local Root", time.Now().Add(24*time.Hour))
+	if _, err := ResolveTrustBundles(context.Background(), bundles, configFS, server.Client(), cacheDir); err == nil || !strings.Contains(err.Error(), "conflicts") {
+		t.Fatalf("ResolveTrustBundles() error = %v, want conflict", err)
+	}
+}
@@ -0,0 +1,228 @@
+package policy
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"time"
+
+	"github.com/notaryproject/notation-core-go/signature"
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation-go/log"
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+)
+
+// MaxClockSkewTolerance is the maximum clock skew tolerance, so that the
+// tolerance does not disable the expiry and certificate validity checks.
+const MaxClockSkewTolerance = time.Hour
+
+// clockSkewChecks are the checks the clock skew tolerance applies to.
+var clockSkewChecks = []trustpolicy.ValidationType{
+	trustpolicy.TypeExpiry,
+	trustpolicy.TypeAuthenticTimestamp,
+}
+
+// ValidateClockSkewTolerance validates a clock skew tolerance.
+func ValidateClockSkewTolerance(tolerance time.Duration) error {
+	if tolerance < 0 {
+		return fmt.Errorf("clock skew tolerance %v must not be negative", tolerance)
+	}
+	if tolerance > MaxClockSkewTolerance {
+		return fmt.Errorf("clock skew tolerance %v must not exceed %v", tolerance, MaxClockSkewTolerance)
+	}
+	return nil
+}
+
+func validateClockSkewTolerance(statement TrustPolicy) error {
+	if statement.ClockSkewTolerance == "" {
+		return nil
+	}
+	tolerance, err := time.ParseDuration(statement.ClockSkewTolerance)
+	if err != nil {
+		return fmt.Errorf("trust policy statement %q has an invalid clock skew tolerance %q, e.g. \"5m\" is expected", statement.Name, statement.ClockSkewTolerance)
+	}
+	if err := ValidateClockSkewTolerance(tolerance); err != nil {
+		return fmt.Errorf("trust policy statement %q: %w", statement.Name, err)
+	}
+	return nil
+}
+
+// OverrideClockSkewTolerance sets the clock skew tolerance of all the
+// statements to tolerance, overriding the tolerances of the statements.
+func (doc *Document) OverrideClockSkewTolerance(tolerance time.Duration) {
+	doc.clockSkewOverride = &tolerance
+}
+
+// clockSkewTolerance returns the clock skew tolerance of the statement.
+func (doc *Document) clockSkewTolerance(name string) time.Duration {
+	if doc.clockSkewOverride != nil {
+		return *doc.clockSkewOverride
+	}
+	// the tolerance is validated on load
+	tolerance, _ := time.ParseDuration(doc.Get(name).ClockSkewTolerance)
+	return tolerance
+}
+
+// clockSkew is the clock skew tolerance of a statement, and the checks
+// enforced by the statement it applies to.
+type clockSkew struct {
+	tolerance time.Duration
+	enforced  map[trustpolicy.ValidationType]bool
+
+	// levelName is the name of the verification level of the statement, as
+	// the wrapped verifier reports levels with overrides as "custom".
+	levelName string
+}
+
+// ResolveClockSkewTolerances returns a copy of policyDoc, in which the checks
+// the clock skew tolerance of a statement applies to are logged rather than
+// enforced, so that Verifier checks them with the tolerance instead of the
+// wrapped verifier. It also returns the clock skew tolerances indexed by the
+// names of the statements with a tolerance. policyDoc is returned as is if no
+// statement has a tolerance.
+func (doc *Document) ResolveClockSkewTolerances(policyDoc *trustpolicy.Document) (*trustpolicy.Document, map[string]clockSkew) {
+	skews := make(map[string]clockSkew)
+	resolved := &trustpolicy.Document{
+		Version:       policyDoc.Version,
+		TrustPolicies: make([]trustpolicy.TrustPolicy, len(policyDoc.TrustPolicies)),
+	}
+	for i, statement := range policyDoc.TrustPolicies {
+		resolved.TrustPolicies[i] = statement
+		tolerance := doc.clockSkewTolerance(statement.Name)
+		if tolerance == 0 {
+			continue
+		}
+		level, err := statement.SignatureVerification.GetVerificationLevel()
+		if err != nil || level.Name == trustpolicy.LevelSkip.Name {
+			// invalid statements are reported by the wrapped verifier
+			continue
+		}
+		skew := clockSkew{
+			tolerance: tolerance,
+			enforced:  make(map[trustpolicy.ValidationType]bool),
+			levelName: level.Name,
+		}
+		override := make(map[trustpolicy.ValidationType]trustpolicy.ValidationAction)
+		for check, action := range statement.SignatureVerification.Override {
+			override[check] = action
+		}
+		for _, check := range clockSkewChecks {
+			if level.Enforcement[check] == trustpolicy.ActionEnforce {
+				skew.enforced[check] = true
+				override[check] = trustpolicy.ActionLog
+			}
+		}
+		statement.SignatureVerification = trustpolicy.SignatureVerification{
+			VerificationLevel: statement.SignatureVerification.VerificationLevel,
+			Override:          override,
+		}
+		resolved.TrustPolicies[i] = statement
+		skews[statement.Name] = skew
+	}
+	if len(skews) == 0 {
+		return policyDoc, nil
+	}
+	return resolved, skews
+}
+
+// verify checks the expiry and the certificate validity of the signature of
+// outcome with the clock skew tolerance, replacing the results of the wrapped
+// verifier. The enforced checks are reported as enforced again, and the
+// first enforced check failing is returned.
+func (skew clockSkew) verify(ctx context.Context, outcome *notation.VerificationOutcome, now time.Time) error {
+	logger := log.GetLogger(ctx)
+	var firstErr error
+	enforcement := make(map[trustpolicy.ValidationType]trustpolicy.ValidationAction)
+	if outcome.VerificationLevel != nil {
+		for check, action := range outcome.VerificationLevel.Enforcement {
+			enforcement[check] = action
+		}
+	}
+	for _, result := range outcome.VerificationResults {
+		var err error
+		switch result.Type {
+		case trustpolicy.TypeExpiry:
+			err = verifyExpiry(outcome.EnvelopeContent, now, skew.tolerance)
+		case trustpolicy.TypeAuthenticTimestamp:
+			err = verifyCertificateValidity(outcome.EnvelopeContent, now, skew.tolerance)
+		default:
+			continue
+		}
+		if result.Error != nil && err == nil {
+			logger.Warnf("%s check passed within the clock skew tolerance of %v: %v", result.Type, skew.tolerance, result.Error)
+		}
+		result.Error = err
+		if skew.enforced[result.Type] {
+			result.Action = trustpolicy.ActionEnforce
+			enforcement[result.Type] = trustpolicy.ActionEnforce
+			if err != nil && firstErr == nil {
+				firstErr = notation.ErrorVerificationFailed{Msg: err.Error()}
+			}
+		}
+	}
+	if outcome.VerificationLevel != nil {
+		level := *outcome.VerificationLevel
+		level.Enforcement = enforcement
+		outcome.VerificationLevel = &level
+	}
+	return firstErr
+}
+
+// verifyExpiry verifies that the signature is not expired at now, tolerating
+// the clock skew.
+func verifyExpiry(content *signature.EnvelopeContent, now time.Time, tolerance time.Duration) error {
+	expiry := content.SignerInfo.SignedAttributes.Expiry
+	if !expiry.IsZero() && !now.Add(-tolerance).Before(expiry) {
+		return fmt.Errorf("digital signature has expired on %q, beyond the clock skew tolerance of %v", expiry.Format(time.RFC1123Z), tolerance)
+	}
+	return nil
+}
+
+// verifyCertificateValidity verifies that the certificates of the signature
+// are valid at now for the notary.x509 signing scheme, or at the signing time
+// for the notary.x509.signingAuthority signing scheme, tolerating the clock
+// skew.
+func verifyCertificateValidity(content *signature.EnvelopeContent, now time.Time, tolerance time.Duration) error {
+	signerInfo := content.SignerInfo
+	switch signerInfo.SignedAttributes.SigningScheme {
+	case signature.SigningSchemeX509:
+		if len(signerInfo.UnsignedAttributes.TimestampSignature) != 0 {
+			// timestamped signatures are checked by Verifier.verifyTimestamp
+			return nil
+		}
+		return verifyChainValidity(signerInfo.CertificateChain, now, tolerance)
+	case signature.SigningSchemeX509SigningAuthority:
+		signingTime := signerInfo.SignedAttributes.SigningTime
+		for _, cert := range signerInfo.CertificateChain {
+			if !validWithin(cert, signingTime, tolerance) {
+				return fmt.Errorf("certificate %q was not valid when the digital signature was produced at %q, beyond the clock skew tolerance of %v", cert.Subject, signingTime.Format(time.RFC1123Z), tolerance)
+			}
+		}
+	}
+	return nil
+}
+
+// verifyChainValidity verifies that the certificates are valid at now,
+// tolerating the clock skew.
+func verifyChainValidity(chain []*x509.Certificate, now time.Time, tolerance time.Duration) error {
+	for _, cert := range chain {
+		var err error
+		if now.Add(tolerance).Before(cert.NotBefore) {
+			err = fmt.Errorf("certificate %q is not valid yet, it will be valid from %q", cert.Subject, cert.NotBefore.Format(time.RFC1123Z))
+		} else if now.Add(-tolerance).After(cert.NotAfter) {
+			err = fmt.Errorf("certificate %q is not valid anymore, it was expired at %q", cert.Subject, cert.NotAfter.Format(time.RFC1123Z))
+		}
+		if err != nil && tolerance > 0 {
+			return fmt.Errorf("%w, beyond the clock skew tolerance of %v", err, tolerance)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validWithin reports whether cert is valid at t, tolerating the clock skew.
+func validWithin(cert *x509.Certificate, t time.Time, tolerance time.Duration) bool {
+	return !t.Add(tolerance).Before(cert.NotBefore) && !t.Add(-tolerance).After(cert.NotAfter)
+}
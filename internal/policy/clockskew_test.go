@@ -0,0 +1,255 @@
+package policy
+
+import (
+	"context"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/notation-core-go/signature"
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+func newClockSkewDocuments() (*trustpolicy.Document, *Document) {
+	policyDoc := &trustpolicy.Document{
+		Version: "1.0",
+		TrustPolicies: []trustpolicy.TrustPolicy{
+			{
+				Name:                  "build",
+				RegistryScopes:        []string{"registry.example.com/build"},
+				SignatureVerification: trustpolicy.SignatureVerification{VerificationLevel: "strict"},
+				TrustStores:           []string{"ca:acme"},
+				TrustedIdentities:     []string{"*"},
+			},
+			{
+				Name:                  "prod",
+				RegistryScopes:        []string{"registry.example.com/prod"},
+				SignatureVerification: trustpolicy.SignatureVerification{VerificationLevel: "permissive"},
+				TrustStores:           []string{"ca:acme"},
+				TrustedIdentities:     []string{"*"},
+			},
+		},
+	}
+	extDoc := &Document{
+		TrustPolicies: []TrustPolicy{{Name: "build", ClockSkewTolerance: "5m"}},
+	}
+	return policyDoc, extDoc
+}
+
+func TestParseDocument_ClockSkewTolerance(t *testing.T) {
+	tests := []struct {
+		tolerance string
+		wantErr   string
+	}{
+		{tolerance: "5m"},
+		{tolerance: "1h"},
+		{tolerance: "5", wantErr: "invalid clock skew tolerance"},
+		{tolerance: "-1m", wantErr: "must not be negative"},
+		{tolerance: "2h", wantErr: "must not exceed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tolerance, func(t *testing.T) {
+			_, err := ParseDocument([]byte(`{"trustPolicies":[{"name":"build","clockSkewTolerance":"` + tt.tolerance + `"}]}`))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("ParseDocument() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("ParseDocument() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestResolveClockSkewTolerances(t *testing.T) {
+	policyDoc, extDoc := newClockSkewDocuments()
+	resolved, skews := extDoc.ResolveClockSkewTolerances(policyDoc)
+	if len(skews) != 1 {
+		t.Fatalf("expected 1 clock skew tolerance, got %v", skews)
+	}
+	skew := skews["build"]
+	if skew.tolerance != 5*time.Minute || skew.levelName != "strict" {
+		t.Fatalf("unexpected clock skew tolerance %+v", skew)
+	}
+	if !skew.enforced[trustpolicy.TypeExpiry] || !skew.enforced[trustpolicy.TypeAuthenticTimestamp] {
+		t.Fatalf("expected the expiry and authentic timestamp checks to be enforced, got %v", skew.enforced)
+	}
+	override := resolved.TrustPolicies[0].SignatureVerification.Override
+	if override[trustpolicy.TypeExpiry] != trustpolicy.ActionLog || override[trustpolicy.TypeAuthenticTimestamp] != trustpolicy.ActionLog {
+		t.Fatalf("expected the checks to be logged by the wrapped verifier, got %v", override)
+	}
+	if len(policyDoc.TrustPolicies[0].SignatureVerification.Override) != 0 {
+		t.Fatal("expected the trust policy document to be left unchanged")
+	}
+	if resolved.TrustPolicies[1].SignatureVerification.Override != nil {
+		t.Fatal("expected the statement without a tolerance to be left unchanged")
+	}
+
+	// the flag overrides the tolerances of all statements
+	extDoc.OverrideClockSkewTolerance(time.Minute)
+	_, skews = extDoc.ResolveClockSkewTolerances(policyDoc)
+	if len(skews) != 2 || skews["build"].tolerance != time.Minute {
+		t.Fatalf("expected the override to apply to all statements, got %v", skews)
+	}
+	if enforced := skews["prod"].enforced; len(enforced) != 0 {
+		t.Fatalf("expected no check to be enforced by the permissive level, got %v", enforced)
+	}
+
+	extDoc.OverrideClockSkewTolerance(0)
+	if resolved, skews := extDoc.ResolveClockSkewTolerances(policyDoc); resolved != policyDoc || len(skews) != 0 {
+		t.Fatal("expected no clock skew tolerance to be applied")
+	}
+}
+
+func newClockSkewOutcome(now time.Time, expiry time.Time, cert *x509.Certificate) *notation.VerificationOutcome {
+	return &notation.VerificationOutcome{
+		VerificationLevel: &trustpolicy.VerificationLevel{
+			Name: "strict",
+			Enforcement: map[trustpolicy.ValidationType]trustpolicy.ValidationAction{
+				trustpolicy.TypeExpiry:             trustpolicy.ActionLog,
+				trustpolicy.TypeAuthenticTimestamp: trustpolicy.ActionLog,
+			},
+		},
+		VerificationResults: []*notation.ValidationResult{
+			{Type: trustpolicy.TypeAuthenticTimestamp, Action: trustpolicy.ActionLog, Error: errors.New("certificate is not valid yet")},
+			{Type: trustpolicy.TypeExpiry, Action: trustpolicy.ActionLog, Error: errors.New("digital signature has expired")},
+		},
+		EnvelopeContent: &signature.EnvelopeContent{
+			SignerInfo: signature.SignerInfo{
+				SignedAttributes: signature.SignedAttributes{
+					SigningScheme: signature.SigningSchemeX509,
+					SigningTime:   now,
+					Expiry:        expiry,
+				},
+				CertificateChain: []*x509.Certificate{cert},
+			},
+		},
+	}
+}
+
+func TestClockSkew_Verify(t *testing.T) {
+	now := time.Now()
+	// the signature was produced by a host whose clock is 2 minutes ahead
+	cert := &x509.Certificate{
+		Subject:   pkix.Name{CommonName: "build"},
+		NotBefore: now.Add(2 * time.Minute),
+		NotAfter:  now.Add(time.Hour),
+	}
+	expiry := now.Add(-2 * time.Minute)
+	skew := clockSkew{
+		tolerance: 5 * time.Minute,
+		enforced: map[trustpolicy.ValidationType]bool{
+			trustpolicy.TypeExpiry:             true,
+			trustpolicy.TypeAuthenticTimestamp: true,
+		},
+	}
+
+	outcome := newClockSkewOutcome(now, expiry, cert)
+	if err := skew.verify(context.Background(), outcome, now); err != nil {
+		t.Fatalf("verify() error = %v", err)
+	}
+	for _, result := range outcome.VerificationResults {
+		if result.Error != nil || result.Action != trustpolicy.ActionEnforce {
+			t.Fatalf("expected the %s check to pass and be enforced, got %v with action %q", result.Type, result.Error, result.Action)
+		}
+	}
+	if action := outcome.VerificationLevel.Enforcement[trustpolicy.TypeExpiry]; action != trustpolicy.ActionEnforce {
+		t.Fatalf("expected the expiry check to be reported as enforced, got %q", action)
+	}
+
+	skew.tolerance = time.Minute
+	outcome = newClockSkewOutcome(now, expiry, cert)
+	err := skew.verify(context.Background(), outcome, now)
+	var verificationErr notation.ErrorVerificationFailed
+	if !errors.As(err, &verificationErr) || !strings.Contains(err.Error(), "not valid yet") {
+		t.Fatalf("expected the certificate validity check to fail, got %v", err)
+	}
+	if outcome.VerificationResults[1].Error == nil {
+		t.Fatal("expected the expiry check to fail beyond the tolerance")
+	}
+
+	// checks logged by the trust policy are still logged
+	delete(skew.enforced, trustpolicy.TypeAuthenticTimestamp)
+	delete(skew.enforced, trustpolicy.TypeExpiry)
+	outcome = newClockSkewOutcome(now, expiry, cert)
+	if err := skew.verify(context.Background(), outcome, now); err != nil {
+		t.Fatalf("verify() error = %v", err)
+	}
+	if result := outcome.VerificationResults[0]; result.Error == nil || result.Action != trustpolicy.ActionLog {
+		t.Fatalf("expected the logged check to fail, got %v with action %q", result.Error, result.Action)
+	}
+}
+
+func TestVerifyCertificateValidity_SigningAuthority(t *testing.T) {
+	signingTime := time.Date(2023, 4, 1, 0, 0, 0, 0, time.UTC)
+	cert := &x509.Certificate{
+		Subject:   pkix.Name{CommonName: "build"},
+		NotBefore: signingTime.Add(time.Minute),
+		NotAfter:  signingTime.Add(time.Hour),
+	}
+	content := &signature.EnvelopeContent{
+		SignerInfo: signature.SignerInfo{
+			SignedAttributes: signature.SignedAttributes{
+				SigningScheme: signature.SigningSchemeX509SigningAuthority,
+				SigningTime:   signingTime,
+			},
+			CertificateChain: []*x509.Certificate{cert},
+		},
+	}
+	// the signing time is checked rather than the current time
+	now := signingTime.Add(48 * time.Hour)
+	if err := verifyCertificateValidity(content, now, 2*time.Minute); err != nil {
+		t.Fatalf("verifyCertificateValidity() error = %v", err)
+	}
+	if err := verifyCertificateValidity(content, now, 30*time.Second); err == nil {
+		t.Fatal("expected the certificate to be invalid at the signing time")
+	}
+}
+
+func TestVerifier_ClockSkewTolerance(t *testing.T) {
+	t.Setenv("NOTATION_EXPERIMENTAL", "1")
+	policyDoc, extDoc := newClockSkewDocuments()
+	v, err := NewVerifier(policyDoc, extDoc, func(policyDoc *trustpolicy.Document) (notation.Verifier, error) {
+		if err := policyDoc.Validate(); err != nil {
+			return nil, err
+		}
+		return &levelVerifier{policyDoc: policyDoc}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for reference, want := range map[string]time.Duration{
+		"registry.example.com/build@sha256:0000000000000000000000000000000000000000000000000000000000000000": 5 * time.Minute,
+		"registry.example.com/prod@sha256:0000000000000000000000000000000000000000000000000000000000000000":  0,
+	} {
+		if got := v.ClockSkewTolerance(ocispec.Descriptor{}, reference); got != want {
+			t.Fatalf("ClockSkewTolerance(%s) = %v, want %v", reference, got, want)
+		}
+	}
+	outcome, err := v.Verify(context.Background(), ocispec.Descriptor{}, nil, notation.VerifierVerifyOptions{ArtifactReference: "registry.example.com/build@sha256:0000000000000000000000000000000000000000000000000000000000000000"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the wrapped verifier reports the level with overrides as "custom"
+	if outcome.VerificationLevel.Name != "strict" {
+		t.Fatalf("expected verification level %q, got %q", "strict", outcome.VerificationLevel.Name)
+	}
+}
+
+func TestNewVerifier_ClockSkewToleranceExperimental(t *testing.T) {
+	t.Setenv("NOTATION_EXPERIMENTAL", "")
+	policyDoc, extDoc := newClockSkewDocuments()
+	_, err := NewVerifier(policyDoc, extDoc, func(policyDoc *trustpolicy.Document) (notation.Verifier, error) {
+		return &levelVerifier{policyDoc: policyDoc}, nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "clockSkewTolerance") {
+		t.Fatalf("expected experimental error, got %v", err)
+	}
+}
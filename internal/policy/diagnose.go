@@ -0,0 +1,136 @@
+package policy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+)
+
+// Diagnostic is a problem found in a trust policy document.
+type Diagnostic struct {
+	// Index is the index of the statement in trustPolicies, or -1 if the
+	// problem is with the document as a whole.
+	Index int
+
+	// Statement is the name of the statement, if any.
+	Statement string
+
+	// Err is the problem.
+	Err error
+}
+
+// String returns the location and the problem of the diagnostic.
+func (d Diagnostic) String() string {
+	switch {
+	case d.Index < 0:
+		return fmt.Sprintf("document: %v", d.Err)
+	case d.Statement == "":
+		return fmt.Sprintf("trustPolicies[%d]: %v", d.Index, d.Err)
+	}
+	return fmt.Sprintf("trustPolicies[%d] %q: %v", d.Index, d.Statement, d.Err)
+}
+
+// Diagnose validates the trust policy document policyJSON with its extension
+// fields statement by statement, so that all the malformed statements are
+// reported rather than the first one. Registry scopes overlapping with the
+// scopes of previous statements are reported for the statements, and other
+// problems across statements, e.g. duplicate names, for the document as a
+// whole. Diagnose returns nil if the document is valid.
+func Diagnose(policyJSON []byte) []Diagnostic {
+	var policyDoc trustpolicy.Document
+	if err := json.Unmarshal(policyJSON, &policyDoc); err != nil {
+		return []Diagnostic{{Index: -1, Err: fmt.Errorf("malformed trust policy: %w", err)}}
+	}
+	// the version is validated with a valid statement, so that it is not
+	// reported for every statement
+	versionDoc := &trustpolicy.Document{
+		Version: policyDoc.Version,
+		TrustPolicies: []trustpolicy.TrustPolicy{{
+			Name:                  "version",
+			RegistryScopes:        []string{"*"},
+			SignatureVerification: trustpolicy.SignatureVerification{VerificationLevel: trustpolicy.LevelSkip.Name},
+		}},
+	}
+	if err := versionDoc.Validate(); err != nil {
+		return []Diagnostic{{Index: -1, Err: err}}
+	}
+	if len(policyDoc.TrustPolicies) == 0 {
+		return []Diagnostic{{Index: -1, Err: errors.New("trust policy document can not have zero trust policy statements")}}
+	}
+
+	var diagnostics []Diagnostic
+	extDoc, err := ParseDocument(policyJSON)
+	if err != nil {
+		diagnostics = append(diagnostics, Diagnostic{Index: -1, Err: err})
+		extDoc = nil
+	}
+	for i, statement := range policyDoc.TrustPolicies {
+		statementDoc := &trustpolicy.Document{
+			Version:       policyDoc.Version,
+			TrustPolicies: []trustpolicy.TrustPolicy{statement},
+		}
+		if extDoc != nil {
+			statementDoc = extDoc.ResolveVerificationLevels(statementDoc)
+		}
+		if err := statementDoc.Validate(); err != nil {
+			diagnostics = append(diagnostics, Diagnostic{Index: i, Statement: statement.Name, Err: err})
+		}
+	}
+	// the artifact types scoping the statements are read even if other
+	// extension fields are invalid
+	var scopeExtDoc Document
+	_ = json.Unmarshal(policyJSON, &scopeExtDoc)
+	diagnostics = append(diagnostics, diagnoseScopes(&policyDoc, &scopeExtDoc)...)
+	if len(diagnostics) > 0 {
+		return diagnostics
+	}
+	if err := ValidateDocument(&policyDoc, policyJSON); err != nil {
+		return []Diagnostic{{Index: -1, Err: err}}
+	}
+	return nil
+}
+
+// diagnoseScopes reports the registry scopes of statements which are already
+// used by previous statements for the same artifact types. A statement all of
+// whose registry scopes are used by previous statements is unreachable.
+func diagnoseScopes(policyDoc *trustpolicy.Document, extDoc *Document) []Diagnostic {
+	type scopeKey struct {
+		scope        string
+		artifactType string
+	}
+	owners := make(map[scopeKey]string)
+	var diagnostics []Diagnostic
+	for i, statement := range policyDoc.TrustPolicies {
+		artifactTypes := extDoc.Get(statement.Name).ArtifactTypes
+		if len(artifactTypes) == 0 {
+			artifactTypes = []string{""}
+		}
+		var overlaps []string
+		for _, scope := range statement.RegistryScopes {
+			var owner string
+			for _, artifactType := range artifactTypes {
+				key := scopeKey{scope: scope, artifactType: artifactType}
+				if other, ok := owners[key]; ok {
+					owner = other
+					continue
+				}
+				owners[key] = statement.Name
+			}
+			if owner != "" {
+				overlaps = append(overlaps, fmt.Sprintf("registry scope %q is also used by statement %q", scope, owner))
+			}
+		}
+		switch {
+		case len(overlaps) == 0:
+			continue
+		case len(overlaps) == len(statement.RegistryScopes):
+			diagnostics = append(diagnostics, Diagnostic{Index: i, Statement: statement.Name, Err: fmt.Errorf("trust policy statement is unreachable, all its registry scopes are used by previous statements: %s", strings.Join(overlaps, ", "))})
+		default:
+			diagnostics = append(diagnostics, Diagnostic{Index: i, Statement: statement.Name, Err: fmt.Errorf("%s, one registry scope value can only be associated with one statement", strings.Join(overlaps, ", "))})
+		}
+	}
+	return diagnostics
+}
@@ -0,0 +1,78 @@
+package policy
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiagnose(t *testing.T) {
+	for _, valid := range []string{
+		`{"version":"1.0","trustPolicies":[{"name":"a","registryScopes":["*"],"signatureVerification":{"level":"skip"}}]}`,
+		// statements of different artifact types share registry scopes
+		`{"version":"1.0","trustPolicies":[{"name":"a","registryScopes":["registry.example.com/a"],"signatureVerification":{"level":"skip"}},{"name":"b","registryScopes":["registry.example.com/a"],"artifactTypes":["application/vnd.example.sbom"],"signatureVerification":{"level":"skip"}}]}`,
+	} {
+		if diagnostics := Diagnose([]byte(valid)); diagnostics != nil {
+			t.Fatalf("Diagnose(%s) = %v, want nil", valid, diagnostics)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		policyJSON string
+		want       []string
+	}{
+		{
+			name:       "malformed",
+			policyJSON: `{"version":`,
+			want:       []string{"document: malformed trust policy"},
+		},
+		{
+			name:       "unsupported version",
+			policyJSON: `{"version":"2.0","trustPolicies":[{"name":"a"},{"name":"b"}]}`,
+			want:       []string{`document: trust policy document uses unsupported version "2.0"`},
+		},
+		{
+			name:       "no statements",
+			policyJSON: `{"version":"1.0","trustPolicies":[]}`,
+			want:       []string{"document: trust policy document can not have zero trust policy statements"},
+		},
+		{
+			name:       "malformed statements",
+			policyJSON: `{"version":"1.0","trustPolicies":[{"name":"a","registryScopes":["*"],"signatureVerification":{"level":"lax"}},{"name":"ok","registryScopes":["registry.example.com/ok"],"signatureVerification":{"level":"skip"}},{"registryScopes":["registry.example.com/b"],"signatureVerification":{"level":"strict"}}]}`,
+			want:       []string{`trustPolicies[0] "a": trust policy statement "a" has invalid signatureVerification`, "trustPolicies[2]: a trust policy statement is missing a name"},
+		},
+		{
+			name:       "duplicate names",
+			policyJSON: `{"version":"1.0","trustPolicies":[{"name":"a","registryScopes":["registry.example.com/a"],"signatureVerification":{"level":"skip"}},{"name":"a","registryScopes":["registry.example.com/b"],"signatureVerification":{"level":"skip"}}]}`,
+			want:       []string{`document: multiple trust policy statements use the same name "a"`},
+		},
+		{
+			name:       "overlapping scopes",
+			policyJSON: `{"version":"1.0","trustPolicies":[{"name":"a","registryScopes":["registry.example.com/a","registry.example.com/b"],"signatureVerification":{"level":"skip"}},{"name":"b","registryScopes":["registry.example.com/b","registry.example.com/c"],"signatureVerification":{"level":"skip"}},{"name":"c","registryScopes":["registry.example.com/a"],"signatureVerification":{"level":"skip"}}]}`,
+			want:       []string{`trustPolicies[1] "b": registry scope "registry.example.com/b" is also used by statement "a", one registry scope`, `trustPolicies[2] "c": trust policy statement is unreachable`},
+		},
+		{
+			name:       "overlapping scopes of artifact types",
+			policyJSON: `{"version":"1.0","trustPolicies":[{"name":"a","registryScopes":["registry.example.com/a"],"signatureVerification":{"level":"skip"}},{"name":"b","registryScopes":["registry.example.com/a"],"artifactTypes":["application/vnd.example.sbom"],"signatureVerification":{"level":"skip"}},{"name":"c","registryScopes":["registry.example.com/a"],"artifactTypes":["application/vnd.example.sbom"],"signatureVerification":{"level":"skip"}}]}`,
+			want:       []string{`trustPolicies[2] "c": trust policy statement is unreachable, all its registry scopes are used by previous statements: registry scope "registry.example.com/a" is also used by statement "b"`},
+		},
+		{
+			name:       "invalid extension",
+			policyJSON: `{"version":"1.0","trustPolicies":[{"name":"a","registryScopes":["*"],"signatureVerification":{"level":"skip"},"clockSkewTolerance":"2h"}]}`,
+			want:       []string{`document: trust policy statement "a": clock skew tolerance 2h0m0s must not exceed`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diagnostics := Diagnose([]byte(tt.policyJSON))
+			if len(diagnostics) != len(tt.want) {
+				t.Fatalf("Diagnose() = %v, want %d diagnostics", diagnostics, len(tt.want))
+			}
+			for i, want := range tt.want {
+				if got := diagnostics[i].String(); !strings.HasPrefix(got, want) {
+					t.Fatalf("diagnostic %d = %q, want prefix %q", i, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package policy
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+	"github.com/notaryproject/notation/internal/slices"
+)
+
+// validateExcludeScopes validates the syntax of the excluded scopes of the
+// statement. An excluded scope is a repository, e.g.
+// "registry.example.com/org/app", or all repositories under a namespace or a
+// registry, e.g. "registry.example.com/org/sandbox/*".
+func validateExcludeScopes(statement TrustPolicy) error {
+	for _, scope := range statement.ExcludeScopes {
+		namespace, isNamespace := strings.CutSuffix(scope, "/*")
+		if namespace == "" || strings.Contains(namespace, "*") || (!isNamespace && !strings.Contains(scope, "/")) {
+			return fmt.Errorf("trust policy statement %q has an invalid excluded scope %q, it must be a repository in the format of {registry}/{repository}, or end with \"/*\" to exclude all repositories under a namespace, e.g. \"registry.example.com/org/sandbox/*\"", statement.Name, scope)
+		}
+	}
+	return nil
+}
+
+// validateExcludeScopeStatements checks that only the statements of the
+// wildcard registry scope exclude scopes, as the repositories of the other
+// statements are listed explicitly.
+func (doc *Document) validateExcludeScopeStatements(policyDoc *trustpolicy.Document) error {
+	for _, statement := range policyDoc.TrustPolicies {
+		if len(doc.Get(statement.Name).ExcludeScopes) > 0 && !slices.Contains(statement.RegistryScopes, "*") {
+			return fmt.Errorf("trust policy statement %q uses \"excludeScopes\" without the wildcard registry scope %q, only statements of the wildcard registry scope may exclude scopes", statement.Name, "*")
+		}
+	}
+	return nil
+}
+
+// excludedScope returns the excluded scope of the statement matching the
+// artifact reference, in the format of {registry}/{repository}@{digest}, if
+// any.
+func excludedScope(statement *TrustPolicy, artifactReference string) (string, bool) {
+	repository, _, _ := strings.Cut(artifactReference, "@")
+	for _, scope := range statement.ExcludeScopes {
+		if namespace, ok := strings.CutSuffix(scope, "/*"); ok {
+			if strings.HasPrefix(repository, namespace+"/") {
+				return scope, true
+			}
+		} else if scope == repository {
+			return scope, true
+		}
+	}
+	return "", false
+}
+
+// applicableStatement returns the statement of policyDoc applicable to the
+// artifact reference. Artifacts in the excluded scopes of the wildcard
+// statement have no applicable statement, as statements of their repositories
+// would have been selected before the wildcard statement.
+func (v *Verifier) applicableStatement(policyDoc *trustpolicy.Document, artifactReference string) (*trustpolicy.TrustPolicy, error) {
+	statement, err := policyDoc.GetApplicableTrustPolicy(artifactReference)
+	if err != nil {
+		return nil, notation.ErrorNoApplicableTrustPolicy{Msg: err.Error()}
+	}
+	if scope, ok := excludedScope(v.extDoc.Get(statement.Name), artifactReference); ok {
+		return nil, notation.ErrorNoApplicableTrustPolicy{Msg: fmt.Sprintf("artifact %q is excluded from trust policy statement %q by the excluded scope %q, and no other statement applies to it", artifactReference, statement.Name, scope)}
+	}
+	return statement, nil
+}
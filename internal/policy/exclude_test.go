@@ -0,0 +1,151 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+const testDigest = "@sha256:0000000000000000000000000000000000000000000000000000000000000000"
+
+// newExcludeScopeDocuments returns trust policy documents trusting all
+// repositories except the sandbox repositories and a legacy repository, with
+// a statement of its own for a sandbox repository.
+func newExcludeScopeDocuments() (*trustpolicy.Document, *Document) {
+	policyDoc := &trustpolicy.Document{
+		Version: "1.0",
+		TrustPolicies: []trustpolicy.TrustPolicy{
+			{
+				Name:                  "org",
+				RegistryScopes:        []string{"*"},
+				SignatureVerification: trustpolicy.SignatureVerification{VerificationLevel: "strict"},
+				TrustStores:           []string{"ca:acme"},
+				TrustedIdentities:     []string{"*"},
+			},
+			{
+				Name:                  "sandbox-demo",
+				RegistryScopes:        []string{"registry.example.com/org/sandbox/demo"},
+				SignatureVerification: trustpolicy.SignatureVerification{VerificationLevel: "audit"},
+				TrustStores:           []string{"ca:acme"},
+				TrustedIdentities:     []string{"*"},
+			},
+		},
+	}
+	extDoc := &Document{TrustPolicies: []TrustPolicy{{
+		Name:          "org",
+		ExcludeScopes: []string{"registry.example.com/org/sandbox/*", "registry.example.com/org/legacy"},
+	}}}
+	return policyDoc, extDoc
+}
+
+func TestVerifier_ExcludeScopes(t *testing.T) {
+	t.Setenv("NOTATION_EXPERIMENTAL", "1")
+	policyDoc, extDoc := newExcludeScopeDocuments()
+	v, err := NewVerifier(policyDoc, extDoc, func(policyDoc *trustpolicy.Document) (notation.Verifier, error) {
+		if err := policyDoc.Validate(); err != nil {
+			return nil, err
+		}
+		return &levelVerifier{policyDoc: policyDoc}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for repository, want := range map[string]string{
+		"registry.example.com/org/app":            "strict",
+		"registry.example.com/org/legacy-tools":   "strict",
+		"registry.example.com/org/sandboxed":      "strict",
+		"registry.example.com/org/sandbox/demo":   "audit",
+		"registry.example.com/org/sandbox/other":  "",
+		"registry.example.com/org/sandbox/a/b":    "",
+		"registry.example.com/org/legacy":         "",
+		"registry.example.com/org/legacy/nested":  "strict",
+		"other.example.com/org/sandbox/something": "strict",
+	} {
+		opts := notation.VerifierVerifyOptions{ArtifactReference: repository + testDigest}
+		outcome, err := v.Verify(context.Background(), ocispec.Descriptor{}, nil, opts)
+		if want == "" {
+			if !errors.As(err, &notation.ErrorNoApplicableTrustPolicy{}) || !strings.Contains(err.Error(), "excluded") {
+				t.Errorf("expected %s to be excluded, got error %v", repository, err)
+			}
+			if _, _, err := v.SkipVerify(context.Background(), opts); !errors.As(err, &notation.ErrorNoApplicableTrustPolicy{}) {
+				t.Errorf("expected SkipVerify() of %s to fail with ErrorNoApplicableTrustPolicy, got %v", repository, err)
+			}
+			if _, err := v.Explain(ocispec.Descriptor{}, opts.ArtifactReference); !errors.As(err, &notation.ErrorNoApplicableTrustPolicy{}) {
+				t.Errorf("expected Explain() of %s to fail with ErrorNoApplicableTrustPolicy, got %v", repository, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Verify() of %s error = %v", repository, err)
+			continue
+		}
+		if outcome.VerificationLevel.Name != want {
+			t.Errorf("expected verification level %q for %s, got %q", want, repository, outcome.VerificationLevel.Name)
+		}
+	}
+}
+
+func TestVerifier_ExcludeScopesSkip(t *testing.T) {
+	t.Setenv("NOTATION_EXPERIMENTAL", "1")
+	policyDoc := &trustpolicy.Document{
+		Version: "1.0",
+		TrustPolicies: []trustpolicy.TrustPolicy{
+			{Name: "unsigned", RegistryScopes: []string{"*"}, SignatureVerification: trustpolicy.SignatureVerification{VerificationLevel: "skip"}},
+		},
+	}
+	extDoc := &Document{TrustPolicies: []TrustPolicy{{Name: "unsigned", ExcludeScopes: []string{"registry.example.com/prod/*"}}}}
+	v, err := NewVerifier(policyDoc, extDoc, func(policyDoc *trustpolicy.Document) (notation.Verifier, error) {
+		return &levelVerifier{policyDoc: policyDoc}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the verification of excluded artifacts is never skipped
+	opts := notation.VerifierVerifyOptions{ArtifactReference: "registry.example.com/prod/app" + testDigest}
+	if skip, _, err := v.SkipVerify(context.Background(), opts); skip || !errors.As(err, &notation.ErrorNoApplicableTrustPolicy{}) {
+		t.Fatalf("expected no skip with ErrorNoApplicableTrustPolicy, got skip %v, error %v", skip, err)
+	}
+	opts.ArtifactReference = "registry.example.com/dev/app" + testDigest
+	if skip, _, err := v.SkipVerify(context.Background(), opts); err != nil || !skip {
+		t.Fatalf("expected skip, got skip %v, error %v", skip, err)
+	}
+}
+
+func TestNewVerifier_ExcludeScopesInvalid(t *testing.T) {
+	t.Setenv("NOTATION_EXPERIMENTAL", "1")
+	newBase := func(policyDoc *trustpolicy.Document) (notation.Verifier, error) {
+		return &levelVerifier{policyDoc: policyDoc}, nil
+	}
+
+	// only wildcard statements may exclude scopes
+	policyDoc, extDoc := newExcludeScopeDocuments()
+	extDoc.TrustPolicies[0].Name = "sandbox-demo"
+	if _, err := NewVerifier(policyDoc, extDoc, newBase); err == nil || !strings.Contains(err.Error(), "wildcard") {
+		t.Fatalf("expected error of the wildcard registry scope, got %v", err)
+	}
+
+	t.Setenv("NOTATION_EXPERIMENTAL", "")
+	policyDoc, extDoc = newExcludeScopeDocuments()
+	if _, err := NewVerifier(policyDoc, extDoc, newBase); err == nil || !strings.Contains(err.Error(), "experimental") {
+		t.Fatalf("expected experimental error, got %v", err)
+	}
+}
+
+func TestValidateExcludeScopes(t *testing.T) {
+	for _, scope := range []string{"registry.example.com/org/app", "registry.example.com/org/*", "registry.example.com/*", "localhost:5000/org/sandbox/*"} {
+		if err := validateExcludeScopes(TrustPolicy{Name: "org", ExcludeScopes: []string{scope}}); err != nil {
+			t.Errorf("validateExcludeScopes(%q) error = %v", scope, err)
+		}
+	}
+	for _, scope := range []string{"", "*", "/*", "registry.example.com", "registry.example.com/org/app-*", "registry.example.com/*/app"} {
+		if err := validateExcludeScopes(TrustPolicy{Name: "org", ExcludeScopes: []string{scope}}); err == nil {
+			t.Errorf("expected error for excluded scope %q, got nil", scope)
+		}
+	}
+}
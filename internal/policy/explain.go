@@ -0,0 +1,121 @@
+package policy
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// Explanation is how an artifact would be verified, i.e. the trust policy
+// statement applicable to it and the settings of the statement.
+type Explanation struct {
+	// Statement is the name of the applicable trust policy statement.
+	Statement string
+
+	// MatchedScope is the registry scope of the statement matching the
+	// artifact, i.e. its repository or "*".
+	MatchedScope string
+
+	// VerificationLevel is the name of the verification level, including
+	// custom verification levels.
+	VerificationLevel string
+
+	// Checks are the validations of the verification level and their
+	// actions, in the order of trustpolicy.ValidationTypes. The checks are
+	// empty if the verification is skipped.
+	Checks []CheckAction
+
+	// TrustStores are the trust stores of the statement.
+	TrustStores []string
+
+	// TrustedIdentities are the trusted identities of the statement.
+	TrustedIdentities []string
+
+	// ClockSkewTolerance is the clock skew tolerance of the statement, or 0.
+	ClockSkewTolerance time.Duration
+
+	// Extension are the extension fields of the statement, e.g. the artifact
+	// types it is scoped by.
+	Extension TrustPolicy
+}
+
+// CheckAction is the action of a validation of a verification level.
+type CheckAction struct {
+	Type   trustpolicy.ValidationType
+	Action trustpolicy.ValidationAction
+}
+
+// Skipped returns true if the verification is skipped.
+func (e *Explanation) Skipped() bool {
+	return len(e.Checks) == 0
+}
+
+// Explain returns how the artifact of desc referenced by artifactReference,
+// in the format of {registry}/{repository}@{digest}, would be verified,
+// without fetching or verifying any signature. The artifact type of desc
+// selects the statements if they are scoped by artifact types.
+func (v *Verifier) Explain(desc ocispec.Descriptor, artifactReference string) (*Explanation, error) {
+	typed, ok := v.verifiers[desc.ArtifactType]
+	if !ok {
+		typed, ok = v.verifiers[""]
+	}
+	if !ok {
+		return nil, notation.ErrorNoApplicableTrustPolicy{Msg: fmt.Sprintf("no trust policy statement applies to artifacts of artifact type %q", desc.ArtifactType)}
+	}
+	statement, err := v.applicableStatement(typed.policyDoc, artifactReference)
+	if err != nil {
+		return nil, err
+	}
+	level, err := statement.SignatureVerification.GetVerificationLevel()
+	if err != nil {
+		return nil, err
+	}
+	ext := v.extDoc.Get(statement.Name)
+	// the level is named after the statement, as levels with overrides are
+	// named "custom" by notation-go
+	explanation := &Explanation{
+		Statement:         statement.Name,
+		MatchedScope:      matchedScope(statement, artifactReference),
+		VerificationLevel: statement.SignatureVerification.VerificationLevel,
+		TrustStores:       statement.TrustStores,
+		TrustedIdentities: statement.TrustedIdentities,
+		Extension:         *ext,
+	}
+	if name, ok := v.levelNames[statement.Name]; ok {
+		explanation.VerificationLevel = name
+	}
+	if level.Name == trustpolicy.LevelSkip.Name {
+		return explanation, nil
+	}
+	skew, hasSkew := v.clockSkews[statement.Name]
+	if hasSkew {
+		explanation.ClockSkewTolerance = skew.tolerance
+	}
+	for _, check := range trustpolicy.ValidationTypes {
+		action := level.Enforcement[check]
+		if hasSkew && skew.enforced[check] {
+			// the checks with a clock skew tolerance are enforced by the
+			// Verifier instead of the wrapped verifier
+			action = trustpolicy.ActionEnforce
+		}
+		explanation.Checks = append(explanation.Checks, CheckAction{Type: check, Action: action})
+	}
+	return explanation, nil
+}
+
+// matchedScope returns the registry scope of the statement matching the
+// artifact reference, i.e. the repository of the artifact or the wildcard
+// scope.
+func matchedScope(statement *trustpolicy.TrustPolicy, artifactReference string) string {
+	repository, _, _ := strings.Cut(artifactReference, "@")
+	for _, scope := range statement.RegistryScopes {
+		if scope == repository {
+			return scope
+		}
+	}
+	return "*"
+}
@@ -0,0 +1,119 @@
+package policy
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+func TestVerifier_Explain(t *testing.T) {
+	t.Setenv("NOTATION_EXPERIMENTAL", "1")
+	policyDoc, extDoc := newClockSkewDocuments()
+	policyDoc.TrustPolicies = append(policyDoc.TrustPolicies, trustpolicy.TrustPolicy{
+		Name:                  "unsigned",
+		RegistryScopes:        []string{"*"},
+		SignatureVerification: trustpolicy.SignatureVerification{VerificationLevel: "skip"},
+	})
+	v, err := NewVerifier(policyDoc, extDoc, func(policyDoc *trustpolicy.Document) (notation.Verifier, error) {
+		if err := policyDoc.Validate(); err != nil {
+			return nil, err
+		}
+		return &levelVerifier{policyDoc: policyDoc}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	explanation, err := v.Explain(ocispec.Descriptor{}, "registry.example.com/build@sha256:0000000000000000000000000000000000000000000000000000000000000000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Explanation{
+		Statement:         "build",
+		MatchedScope:      "registry.example.com/build",
+		VerificationLevel: "strict",
+		// the checks with a clock skew tolerance are still enforced
+		Checks: []CheckAction{
+			{Type: trustpolicy.TypeIntegrity, Action: trustpolicy.ActionEnforce},
+			{Type: trustpolicy.TypeAuthenticity, Action: trustpolicy.ActionEnforce},
+			{Type: trustpolicy.TypeAuthenticTimestamp, Action: trustpolicy.ActionEnforce},
+			{Type: trustpolicy.TypeExpiry, Action: trustpolicy.ActionEnforce},
+			{Type: trustpolicy.TypeRevocation, Action: trustpolicy.ActionEnforce},
+		},
+		TrustStores:        []string{"ca:acme"},
+		TrustedIdentities:  []string{"*"},
+		ClockSkewTolerance: 5 * time.Minute,
+		Extension:          TrustPolicy{Name: "build", ClockSkewTolerance: "5m"},
+	}
+	if !reflect.DeepEqual(explanation, want) {
+		t.Fatalf("Explain() = %+v, want %+v", explanation, want)
+	}
+
+	explanation, err = v.Explain(ocispec.Descriptor{}, "registry.example.com/other@sha256:0000000000000000000000000000000000000000000000000000000000000000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if explanation.Statement != "unsigned" || explanation.MatchedScope != "*" || !explanation.Skipped() {
+		t.Fatalf("Explain() = %+v, want skipped statement %q matched by %q", explanation, "unsigned", "*")
+	}
+}
+
+func TestVerifier_ExplainCustomLevel(t *testing.T) {
+	t.Setenv("NOTATION_EXPERIMENTAL", "1")
+	policyDoc, extDoc := newLevelDocuments()
+	v, err := NewVerifier(policyDoc, extDoc, func(policyDoc *trustpolicy.Document) (notation.Verifier, error) {
+		return &levelVerifier{policyDoc: policyDoc}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	explanation, err := v.Explain(ocispec.Descriptor{}, "registry.example.com/dev@sha256:0000000000000000000000000000000000000000000000000000000000000000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if explanation.VerificationLevel != "relaxed" {
+		t.Fatalf("VerificationLevel = %q, want %q", explanation.VerificationLevel, "relaxed")
+	}
+	wantChecks := []CheckAction{
+		{Type: trustpolicy.TypeIntegrity, Action: trustpolicy.ActionEnforce},
+		{Type: trustpolicy.TypeAuthenticity, Action: trustpolicy.ActionLog},
+		{Type: trustpolicy.TypeAuthenticTimestamp, Action: trustpolicy.ActionEnforce},
+		{Type: trustpolicy.TypeExpiry, Action: trustpolicy.ActionEnforce},
+		{Type: trustpolicy.TypeRevocation, Action: trustpolicy.ActionSkip},
+	}
+	if !reflect.DeepEqual(explanation.Checks, wantChecks) {
+		t.Fatalf("Checks = %v, want %v", explanation.Checks, wantChecks)
+	}
+
+	if _, err := v.Explain(ocispec.Descriptor{}, "registry.example.com/other@sha256:0000000000000000000000000000000000000000000000000000000000000000"); err == nil {
+		t.Fatal("expected error for artifact without applicable trust policy")
+	}
+}
+
+func TestVerifier_ExplainOverride(t *testing.T) {
+	policyDoc, extDoc := newLevelDocuments()
+	policyDoc.TrustPolicies[1].SignatureVerification.Override = map[trustpolicy.ValidationType]trustpolicy.ValidationAction{
+		trustpolicy.TypeRevocation: trustpolicy.ActionLog,
+	}
+	policyDoc.TrustPolicies = policyDoc.TrustPolicies[1:]
+	v, err := NewVerifier(policyDoc, extDoc, func(policyDoc *trustpolicy.Document) (notation.Verifier, error) {
+		return &levelVerifier{policyDoc: policyDoc}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	explanation, err := v.Explain(ocispec.Descriptor{}, "registry.example.com/prod@sha256:0000000000000000000000000000000000000000000000000000000000000000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if explanation.VerificationLevel != "strict" {
+		t.Fatalf("VerificationLevel = %q, want %q", explanation.VerificationLevel, "strict")
+	}
+	if got := explanation.Checks[len(explanation.Checks)-1]; got.Type != trustpolicy.TypeRevocation || got.Action != trustpolicy.ActionLog {
+		t.Fatalf("revocation check = %v, want %q", got, trustpolicy.ActionLog)
+	}
+}
@@ -0,0 +1,87 @@
+package policy
+
+import (
+	"crypto/x509"
+	"encoding/asn1"
+	"fmt"
+)
+
+var (
+	// oidIssuer is the legacy Fulcio extension holding the OIDC issuer as a
+	// raw string.
+	oidIssuer = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 1}
+
+	// oidIssuerV2 is the Fulcio extension holding the OIDC issuer as a DER
+	// encoded UTF8String.
+	oidIssuerV2 = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 8}
+)
+
+// KeylessIdentity is an OIDC issuer and subject combination trusted to sign
+// artifacts with ephemeral certificates issued by a Fulcio-style certificate
+// authority.
+type KeylessIdentity struct {
+	// Issuer is the OIDC issuer recorded in the certificate extensions.
+	Issuer string `json:"issuer"`
+
+	// Subject is the OIDC subject recorded in the certificate subject
+	// alternative names as an email address or URI.
+	Subject string `json:"subject"`
+}
+
+func validateKeylessIdentities(statement TrustPolicy) error {
+	for _, identity := range statement.KeylessIdentities {
+		if identity.Issuer == "" || identity.Subject == "" {
+			return fmt.Errorf("trust policy statement %q has a keyless identity with empty issuer or subject", statement.Name)
+		}
+	}
+	return nil
+}
+
+// verifyKeylessIdentity verifies that the signing certificate carries one of
+// the trusted OIDC issuer and subject combinations.
+func verifyKeylessIdentity(cert *x509.Certificate, identities []KeylessIdentity) error {
+	issuer, err := keylessIssuer(cert)
+	if err != nil {
+		return err
+	}
+	subjects := keylessSubjects(cert)
+	for _, identity := range identities {
+		if identity.Issuer != issuer {
+			continue
+		}
+		for _, subject := range subjects {
+			if identity.Subject == subject {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("signing certificate with OIDC issuer %q and subjects %q does not match any trusted keyless identity", issuer, subjects)
+}
+
+// keylessIssuer returns the OIDC issuer recorded in the certificate.
+func keylessIssuer(cert *x509.Certificate) (string, error) {
+	for _, ext := range cert.Extensions {
+		if ext.Id.Equal(oidIssuerV2) {
+			var issuer string
+			if _, err := asn1.UnmarshalWithParams(ext.Value, &issuer, "utf8"); err != nil {
+				return "", fmt.Errorf("malformed OIDC issuer extension: %w", err)
+			}
+			return issuer, nil
+		}
+	}
+	for _, ext := range cert.Extensions {
+		if ext.Id.Equal(oidIssuer) {
+			return string(ext.Value), nil
+		}
+	}
+	return "", fmt.Errorf("signing certificate %q has no OIDC issuer extension", cert.Subject)
+}
+
+// keylessSubjects returns the OIDC subjects recorded in the certificate.
+func keylessSubjects(cert *x509.Certificate) []string {
+	subjects := append([]string{}, cert.EmailAddresses...)
+	for _, uri := range cert.URIs {
+		subjects = append(subjects, uri.String())
+	}
+	return subjects
+}
@@ -0,0 +1,167 @@
+package policy
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"math/big"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/notation-core-go/signature"
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+func newKeylessCert(t *testing.T, issuerExt pkix.Extension, email string, uri string) *x509.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:    big.NewInt(1),
+		NotBefore:       time.Now(),
+		NotAfter:        time.Now().Add(10 * time.Minute),
+		ExtraExtensions: []pkix.Extension{issuerExt},
+	}
+	if email != "" {
+		template.EmailAddresses = []string{email}
+	}
+	if uri != "" {
+		u, err := url.Parse(uri)
+		if err != nil {
+			t.Fatal(err)
+		}
+		template.URIs = []*url.URL{u}
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(certDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func TestVerifyKeylessIdentity(t *testing.T) {
+	issuer := "https://token.actions.githubusercontent.com"
+	issuerV2, err := asn1.MarshalWithParams(issuer, "utf8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	workflow := "https://github.com/acme/app/.github/workflows/release.yml@refs/heads/main"
+	identities := []KeylessIdentity{{Issuer: issuer, Subject: workflow}}
+
+	t.Run("v2 issuer extension with URI subject", func(t *testing.T) {
+		cert := newKeylessCert(t, pkix.Extension{Id: oidIssuerV2, Value: issuerV2}, "", workflow)
+		if err := verifyKeylessIdentity(cert, identities); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	})
+
+	t.Run("legacy issuer extension with email subject", func(t *testing.T) {
+		cert := newKeylessCert(t, pkix.Extension{Id: oidIssuer, Value: []byte("https://accounts.google.com")}, "dev@acme.io", "")
+		err := verifyKeylessIdentity(cert, []KeylessIdentity{{Issuer: "https://accounts.google.com", Subject: "dev@acme.io"}})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	})
+
+	t.Run("subject mismatch", func(t *testing.T) {
+		cert := newKeylessCert(t, pkix.Extension{Id: oidIssuerV2, Value: issuerV2}, "", "https://github.com/evil/app/.github/workflows/release.yml@refs/heads/main")
+		if err := verifyKeylessIdentity(cert, identities); err == nil {
+			t.Fatal("expected error, got nil")
+		}
+	})
+
+	t.Run("issuer mismatch", func(t *testing.T) {
+		cert := newKeylessCert(t, pkix.Extension{Id: oidIssuer, Value: []byte("https://evil.example")}, "", workflow)
+		if err := verifyKeylessIdentity(cert, identities); err == nil {
+			t.Fatal("expected error, got nil")
+		}
+	})
+}
+
+func TestParseDocument_InvalidKeylessIdentity(t *testing.T) {
+	policyJSON := []byte(`{"version":"1.0","trustPolicies":[{"name":"keyless","keylessIdentities":[{"issuer":"https://accounts.google.com"}]}]}`)
+	if _, err := ParseDocument(policyJSON); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+// certChainVerifier is a base verifier verifying every signature, signed with
+// the certificate chain of the signature.
+type certChainVerifier struct {
+	chains map[string][]*x509.Certificate
+}
+
+func (v *certChainVerifier) Verify(ctx context.Context, desc ocispec.Descriptor, sig []byte, opts notation.VerifierVerifyOptions) (*notation.VerificationOutcome, error) {
+	return &notation.VerificationOutcome{
+		RawSignature:      sig,
+		VerificationLevel: trustpolicy.LevelStrict,
+		EnvelopeContent: &signature.EnvelopeContent{
+			SignerInfo: signature.SignerInfo{CertificateChain: v.chains[string(sig)]},
+		},
+	}, nil
+}
+
+func TestVerifier_KeylessIdentities(t *testing.T) {
+	issuer := "https://token.actions.githubusercontent.com"
+	issuerV2, err := asn1.MarshalWithParams(issuer, "utf8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	workflow := "https://github.com/acme/app/.github/workflows/release.yml@refs/heads/main"
+	base := &certChainVerifier{chains: map[string][]*x509.Certificate{
+		"trusted":   {newKeylessCert(t, pkix.Extension{Id: oidIssuerV2, Value: issuerV2}, "", workflow)},
+		"untrusted": {newKeylessCert(t, pkix.Extension{Id: oidIssuerV2, Value: issuerV2}, "", "https://github.com/evil/app/.github/workflows/release.yml@refs/heads/main")},
+	}}
+	policyDoc, _ := newClockSkewDocuments()
+	extDoc := &Document{
+		TrustPolicies: []TrustPolicy{{Name: "build", KeylessIdentities: []KeylessIdentity{{Issuer: issuer, Subject: workflow}}}},
+	}
+	newVerifier := func() *Verifier {
+		v, err := NewVerifier(policyDoc, extDoc, func(policyDoc *trustpolicy.Document) (notation.Verifier, error) {
+			return base, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+	ctx := context.Background()
+	opts := notation.VerifierVerifyOptions{ArtifactReference: "registry.example.com/build@sha256:0000000000000000000000000000000000000000000000000000000000000000"}
+
+	t.Setenv("NOTATION_EXPERIMENTAL", "1")
+	v := newVerifier()
+	if _, err := v.Verify(ctx, ocispec.Descriptor{}, []byte("trusted"), opts); err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	var verificationErr notation.ErrorVerificationFailed
+	if _, err := v.Verify(ctx, ocispec.Descriptor{}, []byte("untrusted"), opts); !errors.As(err, &verificationErr) || !strings.Contains(err.Error(), "keyless identity") {
+		t.Fatalf("expected the signature of an untrusted identity to fail, got %v", err)
+	}
+	if _, err := v.Verify(ctx, ocispec.Descriptor{}, []byte("no certificate chain"), opts); !errors.As(err, &verificationErr) {
+		t.Fatalf("expected the signature without certificate chain to fail, got %v", err)
+	}
+	// statements without keyless identities are not affected
+	if _, err := v.Verify(ctx, ocispec.Descriptor{}, []byte("untrusted"), notation.VerifierVerifyOptions{ArtifactReference: "registry.example.com/prod@sha256:0000000000000000000000000000000000000000000000000000000000000000"}); err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+
+	t.Setenv("NOTATION_EXPERIMENTAL", "")
+	if _, err := newVerifier().Verify(ctx, ocispec.Descriptor{}, []byte("trusted"), opts); err == nil || !strings.Contains(err.Error(), "keylessIdentities") {
+		t.Fatalf("expected keyless identities to require experimental, got %v", err)
+	}
+}
@@ -0,0 +1,128 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+	"github.com/notaryproject/notation/internal/slices"
+)
+
+// VerificationLevel is a custom verification level composed of the actions
+// of the verification checks, referenced by name from the level of the
+// signature verification of trust policy statements.
+//
+// A custom level is enforced as the strict level with the actions of the
+// checks overriding it, so that checks not listed are enforced. The integrity
+// check is always enforced, and only the revocation check can be skipped.
+type VerificationLevel struct {
+	// Name is the name of the verification level.
+	Name string `json:"name"`
+
+	// Checks are the actions of the verification checks, e.g.
+	// {"expiry": "log", "revocation": "skip"}.
+	Checks map[trustpolicy.ValidationType]trustpolicy.ValidationAction `json:"checks"`
+}
+
+// getVerificationLevel returns the custom verification level with the given
+// name, or nil if not found.
+func (doc *Document) getVerificationLevel(name string) *VerificationLevel {
+	if doc != nil {
+		for i, level := range doc.VerificationLevels {
+			if level.Name == name {
+				return &doc.VerificationLevels[i]
+			}
+		}
+	}
+	return nil
+}
+
+func validateVerificationLevels(levels []VerificationLevel) error {
+	seen := make(map[string]bool)
+	for _, level := range levels {
+		if level.Name == "" {
+			return errors.New("custom verification level name is empty")
+		}
+		if seen[level.Name] {
+			return fmt.Errorf("custom verification level %q is defined more than once", level.Name)
+		}
+		seen[level.Name] = true
+		for _, builtin := range trustpolicy.VerificationLevels {
+			if builtin.Name == level.Name {
+				return fmt.Errorf("custom verification level %q conflicts with the built-in verification level", level.Name)
+			}
+		}
+		for check, action := range level.Checks {
+			if !slices.Contains(trustpolicy.ValidationTypes, check) {
+				return fmt.Errorf("custom verification level %q has unsupported check %q, supported values are %q", level.Name, check, trustpolicy.ValidationTypes)
+			}
+			if !slices.Contains(trustpolicy.ValidationActions, action) {
+				return fmt.Errorf("custom verification level %q has unsupported action %q for check %q, supported values are %q", level.Name, action, check, trustpolicy.ValidationActions)
+			}
+			switch {
+			case check == trustpolicy.TypeIntegrity && action != trustpolicy.ActionEnforce:
+				return fmt.Errorf("custom verification level %q must enforce the %q check", level.Name, check)
+			case check != trustpolicy.TypeRevocation && action == trustpolicy.ActionSkip:
+				return fmt.Errorf("custom verification level %q cannot skip the %q check, only the %q check can be skipped", level.Name, check, trustpolicy.TypeRevocation)
+			}
+		}
+	}
+	return nil
+}
+
+// usesVerificationLevels returns true if any statement of policyDoc
+// references a custom verification level.
+func (doc *Document) usesVerificationLevels(policyDoc *trustpolicy.Document) bool {
+	for _, statement := range policyDoc.TrustPolicies {
+		if doc.getVerificationLevel(statement.SignatureVerification.VerificationLevel) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolveVerificationLevels returns a copy of policyDoc, in which the custom
+// verification levels referenced by the statements are replaced by the
+// strict level with the actions of the checks as overrides. The overrides of
+// a statement take precedence over the actions of its custom level. policyDoc
+// is returned as is if no statement references a custom level.
+func (doc *Document) ResolveVerificationLevels(policyDoc *trustpolicy.Document) *trustpolicy.Document {
+	if !doc.usesVerificationLevels(policyDoc) {
+		return policyDoc
+	}
+	resolved := &trustpolicy.Document{
+		Version:       policyDoc.Version,
+		TrustPolicies: make([]trustpolicy.TrustPolicy, len(policyDoc.TrustPolicies)),
+	}
+	for i, statement := range policyDoc.TrustPolicies {
+		if level := doc.getVerificationLevel(statement.SignatureVerification.VerificationLevel); level != nil {
+			override := make(map[trustpolicy.ValidationType]trustpolicy.ValidationAction)
+			for check, action := range level.Checks {
+				if action != trustpolicy.ActionEnforce {
+					override[check] = action
+				}
+			}
+			for check, action := range statement.SignatureVerification.Override {
+				override[check] = action
+			}
+			statement.SignatureVerification = trustpolicy.SignatureVerification{
+				VerificationLevel: trustpolicy.LevelStrict.Name,
+				Override:          override,
+			}
+		}
+		resolved.TrustPolicies[i] = statement
+	}
+	return resolved
+}
+
+// verificationLevelNames returns the names of the custom verification levels
+// indexed by the names of the statements of policyDoc referencing them.
+func (doc *Document) verificationLevelNames(policyDoc *trustpolicy.Document) map[string]string {
+	names := make(map[string]string)
+	for _, statement := range policyDoc.TrustPolicies {
+		if level := doc.getVerificationLevel(statement.SignatureVerification.VerificationLevel); level != nil {
+			names[statement.Name] = level.Name
+		}
+	}
+	return names
+}
@@ -0,0 +1,188 @@
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+func newLevelDocuments() (*trustpolicy.Document, *Document) {
+	policyDoc := &trustpolicy.Document{
+		Version: "1.0",
+		TrustPolicies: []trustpolicy.TrustPolicy{
+			{
+				Name:                  "dev",
+				RegistryScopes:        []string{"registry.example.com/dev"},
+				SignatureVerification: trustpolicy.SignatureVerification{VerificationLevel: "relaxed", Override: map[trustpolicy.ValidationType]trustpolicy.ValidationAction{trustpolicy.TypeExpiry: trustpolicy.ActionEnforce}},
+				TrustStores:           []string{"ca:acme"},
+				TrustedIdentities:     []string{"*"},
+			},
+			{
+				Name:                  "prod",
+				RegistryScopes:        []string{"registry.example.com/prod"},
+				SignatureVerification: trustpolicy.SignatureVerification{VerificationLevel: "strict"},
+				TrustStores:           []string{"ca:acme"},
+				TrustedIdentities:     []string{"*"},
+			},
+		},
+	}
+	extDoc := &Document{
+		VerificationLevels: []VerificationLevel{{
+			Name: "relaxed",
+			Checks: map[trustpolicy.ValidationType]trustpolicy.ValidationAction{
+				trustpolicy.TypeIntegrity:    trustpolicy.ActionEnforce,
+				trustpolicy.TypeAuthenticity: trustpolicy.ActionLog,
+				trustpolicy.TypeExpiry:       trustpolicy.ActionLog,
+				trustpolicy.TypeRevocation:   trustpolicy.ActionSkip,
+			},
+		}},
+	}
+	return policyDoc, extDoc
+}
+
+func TestValidateVerificationLevels(t *testing.T) {
+	tests := []struct {
+		name   string
+		levels string
+		errMsg string
+	}{
+		{
+			name:   "valid",
+			levels: `[{"name":"relaxed","checks":{"authenticity":"log","expiry":"log","revocation":"skip"}}]`,
+		},
+		{
+			name:   "empty name",
+			levels: `[{"checks":{"expiry":"log"}}]`,
+			errMsg: "name is empty",
+		},
+		{
+			name:   "duplicate",
+			levels: `[{"name":"relaxed"},{"name":"relaxed"}]`,
+			errMsg: "defined more than once",
+		},
+		{
+			name:   "built-in",
+			levels: `[{"name":"audit"}]`,
+			errMsg: "conflicts with the built-in verification level",
+		},
+		{
+			name:   "unknown check",
+			levels: `[{"name":"relaxed","checks":{"freshness":"log"}}]`,
+			errMsg: `unsupported check "freshness"`,
+		},
+		{
+			name:   "unknown action",
+			levels: `[{"name":"relaxed","checks":{"expiry":"warn"}}]`,
+			errMsg: `unsupported action "warn"`,
+		},
+		{
+			name:   "integrity not enforced",
+			levels: `[{"name":"relaxed","checks":{"integrity":"log"}}]`,
+			errMsg: `must enforce the "integrity" check`,
+		},
+		{
+			name:   "authenticity skipped",
+			levels: `[{"name":"relaxed","checks":{"authenticity":"skip"}}]`,
+			errMsg: `cannot skip the "authenticity" check`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseDocument([]byte(`{"trustPolicies":[],"verificationLevels":` + tt.levels + `}`))
+			if tt.errMsg == "" {
+				if err != nil {
+					t.Fatalf("ParseDocument() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Fatalf("ParseDocument() error = %v, want error containing %q", err, tt.errMsg)
+			}
+		})
+	}
+}
+
+func TestResolveVerificationLevels(t *testing.T) {
+	policyDoc, extDoc := newLevelDocuments()
+	resolved := extDoc.ResolveVerificationLevels(policyDoc)
+	if err := resolved.Validate(); err != nil {
+		t.Fatalf("resolved trust policy is invalid: %v", err)
+	}
+	want := trustpolicy.SignatureVerification{
+		VerificationLevel: "strict",
+		Override: map[trustpolicy.ValidationType]trustpolicy.ValidationAction{
+			trustpolicy.TypeAuthenticity: trustpolicy.ActionLog,
+			trustpolicy.TypeExpiry:       trustpolicy.ActionEnforce,
+			trustpolicy.TypeRevocation:   trustpolicy.ActionSkip,
+		},
+	}
+	if got := resolved.TrustPolicies[0].SignatureVerification; !reflect.DeepEqual(got, want) {
+		t.Fatalf("resolved signature verification = %v, want %v", got, want)
+	}
+	if got := resolved.TrustPolicies[1].SignatureVerification; got.VerificationLevel != "strict" || len(got.Override) != 0 {
+		t.Fatalf("expected the built-in level to be kept, got %v", got)
+	}
+	if policyDoc.TrustPolicies[0].SignatureVerification.VerificationLevel != "relaxed" {
+		t.Fatal("expected the trust policy document to be left unchanged")
+	}
+}
+
+func TestVerifier_VerificationLevels(t *testing.T) {
+	t.Setenv("NOTATION_EXPERIMENTAL", "1")
+	policyDoc, extDoc := newLevelDocuments()
+	v, err := NewVerifier(policyDoc, extDoc, func(policyDoc *trustpolicy.Document) (notation.Verifier, error) {
+		if err := policyDoc.Validate(); err != nil {
+			return nil, err
+		}
+		return &levelVerifier{policyDoc: policyDoc}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for reference, want := range map[string]string{
+		"registry.example.com/dev@sha256:0000000000000000000000000000000000000000000000000000000000000000":  "relaxed",
+		"registry.example.com/prod@sha256:0000000000000000000000000000000000000000000000000000000000000000": "strict",
+	} {
+		outcome, err := v.Verify(context.Background(), ocispec.Descriptor{}, nil, notation.VerifierVerifyOptions{ArtifactReference: reference})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if outcome.VerificationLevel.Name != want {
+			t.Fatalf("expected verification level %q for %s, got %q", want, reference, outcome.VerificationLevel.Name)
+		}
+	}
+}
+
+func TestNewVerifier_VerificationLevelsExperimental(t *testing.T) {
+	t.Setenv("NOTATION_EXPERIMENTAL", "")
+	policyDoc, extDoc := newLevelDocuments()
+	_, err := NewVerifier(policyDoc, extDoc, func(policyDoc *trustpolicy.Document) (notation.Verifier, error) {
+		return &levelVerifier{policyDoc: policyDoc}, nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "verificationLevels") {
+		t.Fatalf("expected experimental error, got %v", err)
+	}
+}
+
+func TestValidateDocument_VerificationLevels(t *testing.T) {
+	policyDoc, extDoc := newLevelDocuments()
+	policyJSON, err := json.Marshal(struct {
+		*trustpolicy.Document
+		VerificationLevels []VerificationLevel `json:"verificationLevels"`
+	}{policyDoc, extDoc.VerificationLevels})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateDocument(policyDoc, policyJSON); err != nil {
+		t.Fatalf("ValidateDocument() error = %v", err)
+	}
+	if err := policyDoc.Validate(); err == nil {
+		t.Fatal("expected the custom verification level to be unknown to the trust policy specification")
+	}
+}
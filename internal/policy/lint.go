@@ -0,0 +1,111 @@
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/notaryproject/notation-go/dir"
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+	"github.com/notaryproject/notation-go/verifier/truststore"
+	"github.com/notaryproject/notation/internal/slices"
+)
+
+// Lint validates the trust policy document policyJSON like Diagnose, and
+// checks the statements against the trust stores in the notation config
+// directory configFS at time now: trust stores which do not exist, trust
+// stores without valid certificates, expired certificates, and custom
+// verification levels no statement uses. Trust bundles are checked only if
+// already cached. Lint returns nil if no problem is found.
+func Lint(ctx context.Context, policyJSON []byte, configFS dir.SysFS, now time.Time) []Diagnostic {
+	diagnostics := Diagnose(policyJSON)
+	var policyDoc trustpolicy.Document
+	if err := json.Unmarshal(policyJSON, &policyDoc); err != nil {
+		// reported by Diagnose
+		return diagnostics
+	}
+	// the custom verification levels are not validated, as invalid levels
+	// are reported by Diagnose
+	var extDoc Document
+	_ = json.Unmarshal(policyJSON, &extDoc)
+
+	// trust bundles are checked if cached, and fetched on verification
+	// otherwise
+	bundles := make(map[string]bool)
+	for _, bundle := range extDoc.TrustBundles {
+		bundles[string(bundle.Type)+":"+bundle.Name] = true
+	}
+	if cacheDir, err := TrustBundleCacheDir(); err == nil {
+		configFS = newOverrideFS(configFS, cachedTrustBundles(extDoc.TrustBundles, cacheDir))
+	}
+
+	// trust stores shared by statements are checked once
+	trustStoreProblems := make(map[string][]error)
+	x509TrustStore := truststore.NewX509TrustStore(configFS)
+	for i, statement := range policyDoc.TrustPolicies {
+		for _, trustStore := range statement.TrustStores {
+			problems, ok := trustStoreProblems[trustStore]
+			if !ok {
+				problems = lintTrustStore(ctx, x509TrustStore, configFS, trustStore, bundles[trustStore], now)
+				trustStoreProblems[trustStore] = problems
+			}
+			for _, problem := range problems {
+				diagnostics = append(diagnostics, Diagnostic{Index: i, Statement: statement.Name, Err: problem})
+			}
+		}
+	}
+
+	levels := make(map[string]bool)
+	for _, statement := range policyDoc.TrustPolicies {
+		levels[statement.SignatureVerification.VerificationLevel] = true
+	}
+	for _, level := range extDoc.VerificationLevels {
+		if !levels[level.Name] {
+			diagnostics = append(diagnostics, Diagnostic{Index: -1, Err: fmt.Errorf("custom verification level %q is not used by any trust policy statement, remove it or reference it in field \"signatureVerification.level\"", level.Name)})
+		}
+	}
+	// problems of the document first, then in the order of the statements
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		return diagnostics[i].Index < diagnostics[j].Index
+	})
+	return diagnostics
+}
+
+// lintTrustStore returns the problems of the trust store, in the format of
+// {type}:{name}, e.g. "ca:acme-rootcas". bundle is true if the trust store is
+// a trust bundle.
+func lintTrustStore(ctx context.Context, x509TrustStore truststore.X509TrustStore, configFS dir.SysFS, trustStore string, bundle bool, now time.Time) []error {
+	storeType, name, ok := strings.Cut(trustStore, ":")
+	if !ok || !slices.Contains(truststore.Types, truststore.Type(storeType)) {
+		// reported by Diagnose
+		return nil
+	}
+	path, err := configFS.SysPath(dir.X509TrustStoreDir(storeType, name))
+	if err != nil {
+		return []error{err}
+	}
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		if bundle {
+			// not fetched yet
+			return nil
+		}
+		return []error{fmt.Errorf("trust store %q does not exist, add certificates to it via `notation cert add --type %s --store %s <cert_path>`", trustStore, storeType, name)}
+	}
+	certs, err := x509TrustStore.GetCertificates(ctx, truststore.Type(storeType), name)
+	if err != nil {
+		return []error{fmt.Errorf("trust store %q is unusable, signatures can not be verified against it: %w", trustStore, err)}
+	}
+	var problems []error
+	for _, cert := range certs {
+		if now.After(cert.NotAfter) {
+			problems = append(problems, fmt.Errorf("certificate %q in trust store %q expired at %s, remove or replace it via `notation cert`", cert.Subject, trustStore, cert.NotAfter.Format(time.RFC3339)))
+		}
+	}
+	return problems
+}
@@ -0,0 +1,92 @@
+package policy
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/notation-go/dir"
+)
+
+// writeTrustStoreCert writes a self-signed CA certificate valid until
+// notAfter to the named trust store of type "ca" in the config directory.
+func writeTrustStoreCert(t *testing.T, configDir, store, commonName string, notAfter time.Time) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             notAfter.Add(-24 * time.Hour),
+		NotAfter:              notAfter,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	storeDir := filepath.Join(configDir, dir.X509TrustStoreDir("ca", store))
+	if err := os.MkdirAll(storeDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(storeDir, commonName+".pem"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLint(t *testing.T) {
+	now := time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC)
+	configDir := t.TempDir()
+	configFS := dir.NewSysFS(configDir)
+	writeTrustStoreCert(t, configDir, "acme", "acme root", now.Add(time.Hour))
+	writeTrustStoreCert(t, configDir, "legacy", "legacy root", now.Add(-time.Hour))
+	if err := os.MkdirAll(filepath.Join(configDir, dir.X509TrustStoreDir("ca", "empty")), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	valid := `{"version":"1.0","trustPolicies":[{"name":"a","registryScopes":["*"],"signatureVerification":{"level":"strict"},"trustStores":["ca:acme"],"trustedIdentities":["*"]}]}`
+	if diagnostics := Lint(context.Background(), []byte(valid), configFS, now); diagnostics != nil {
+		t.Fatalf("Lint() = %v, want nil", diagnostics)
+	}
+
+	policyJSON := `{
+		"version": "1.0",
+		"trustPolicies": [
+			{"name": "a", "registryScopes": ["registry.example.com/a"], "signatureVerification": {"level": "strict"}, "trustStores": ["ca:acme", "ca:missing"], "trustedIdentities": ["*"]},
+			{"name": "b", "registryScopes": ["registry.example.com/b"], "signatureVerification": {"level": "strict"}, "trustStores": ["ca:legacy", "ca:empty"], "trustedIdentities": ["*"]},
+			{"name": "c", "registryScopes": ["registry.example.com/c"], "signatureVerification": {"level": "strict"}, "trustStores": ["ca:missing"], "trustedIdentities": ["*"]}
+		],
+		"verificationLevels": [{"name": "unused", "checks": {"revocation": "log"}}]
+	}`
+	want := []string{
+		`document: custom verification level "unused" is not used by any trust policy statement`,
+		`trustPolicies[0] "a": trust store "ca:missing" does not exist, add certificates to it via ` + "`notation cert add --type ca --store missing <cert_path>`",
+		`trustPolicies[1] "b": certificate "CN=legacy root" in trust store "ca:legacy" expired at 2024-05-31T23:00:00Z`,
+		`trustPolicies[1] "b": trust store "ca:empty" is unusable, signatures can not be verified against it`,
+		`trustPolicies[2] "c": trust store "ca:missing" does not exist`,
+	}
+	diagnostics := Lint(context.Background(), []byte(policyJSON), configFS, now)
+	if len(diagnostics) != len(want) {
+		t.Fatalf("Lint() = %v, want %d diagnostics", diagnostics, len(want))
+	}
+	for i, want := range want {
+		if got := diagnostics[i].String(); !strings.HasPrefix(got, want) {
+			t.Fatalf("diagnostic %d = %q, want prefix %q", i, got, want)
+		}
+	}
+
+}
@@ -0,0 +1,62 @@
+package policy
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/notaryproject/notation-core-go/signature"
+	"github.com/notaryproject/notation-go/plugin/proto"
+	"github.com/notaryproject/notation-go/verifier"
+	"golang.org/x/mod/semver"
+)
+
+func validatePluginMinVersions(statement TrustPolicy) error {
+	for name, version := range statement.PluginMinVersions {
+		if name == "" {
+			return fmt.Errorf("trust policy statement %q has a minimum plugin version without plugin name", statement.Name)
+		}
+		if !isSemVer(version) {
+			return fmt.Errorf("trust policy statement %q has an invalid minimum version %q of plugin %q, it must be a SemVer version without \"v\" prefix, e.g. 1.2.0", statement.Name, version, name)
+		}
+	}
+	return nil
+}
+
+// isSemVer returns true if version is a full SemVer version without "v"
+// prefix, e.g. 1.2.0 rather than 1.2.
+func isSemVer(version string) bool {
+	return !strings.HasPrefix(version, "v") && semver.IsValid("v"+version) && semver.Canonical("v"+version) == "v"+version
+}
+
+// verifyPluginMinVersion verifies that the installed verification plugin
+// required by the signature, if any, is at least the minimum version of the
+// plugin in minVersions. Signatures not requiring a verification plugin and
+// plugins without a minimum version are not affected.
+func (v *Verifier) verifyPluginMinVersion(ctx context.Context, policyName string, minVersions map[string]string, signerInfo *signature.SignerInfo) error {
+	attr, err := signerInfo.ExtendedAttribute(verifier.HeaderVerificationPlugin)
+	if err != nil {
+		// no verification plugin required
+		return nil
+	}
+	name, _ := attr.Value.(string)
+	minVersion, ok := minVersions[name]
+	if !ok {
+		return nil
+	}
+	installed, err := v.pluginManager.Get(ctx, name)
+	if err != nil {
+		return fmt.Errorf("failed to get verification plugin %q to check its version against trust policy %q: %w", name, policyName, err)
+	}
+	metadata, err := installed.GetMetadata(ctx, &proto.GetMetadataRequest{})
+	if err != nil {
+		return fmt.Errorf("failed to get the version of verification plugin %q: %w", name, err)
+	}
+	if !semver.IsValid("v" + metadata.Version) {
+		return fmt.Errorf("verification plugin %q has an invalid version %q, trust policy %q requires at least version %s", name, metadata.Version, policyName, minVersion)
+	}
+	if semver.Compare("v"+metadata.Version, "v"+minVersion) < 0 {
+		return fmt.Errorf("verification plugin %q of version %s is outdated, trust policy %q requires at least version %s, please upgrade the plugin", name, metadata.Version, policyName, minVersion)
+	}
+	return nil
+}
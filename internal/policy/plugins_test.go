@@ -0,0 +1,133 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/notaryproject/notation-core-go/signature"
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation-go/plugin"
+	"github.com/notaryproject/notation-go/plugin/proto"
+	"github.com/notaryproject/notation-go/verifier"
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// versionedPlugin is an installed plugin of a version.
+type versionedPlugin struct {
+	plugin.Plugin
+	version string
+}
+
+func (p *versionedPlugin) GetMetadata(ctx context.Context, req *proto.GetMetadataRequest) (*proto.GetMetadataResponse, error) {
+	return &proto.GetMetadataResponse{Name: "com.example.plugin", Version: p.version}, nil
+}
+
+// versionedPluginManager manages a single installed plugin.
+type versionedPluginManager struct {
+	plugin.Manager
+	version string
+}
+
+func (m *versionedPluginManager) Get(ctx context.Context, name string) (plugin.Plugin, error) {
+	if name != "com.example.plugin" {
+		return nil, errors.New("plugin not found")
+	}
+	return &versionedPlugin{version: m.version}, nil
+}
+
+// pluginVerifier is a base verifier verifying every signature, requiring the
+// verification plugin named by the signature.
+type pluginVerifier struct{}
+
+func (v *pluginVerifier) Verify(ctx context.Context, desc ocispec.Descriptor, sig []byte, opts notation.VerifierVerifyOptions) (*notation.VerificationOutcome, error) {
+	content := &signature.EnvelopeContent{}
+	if len(sig) > 0 {
+		content.SignerInfo.SignedAttributes.ExtendedAttributes = []signature.Attribute{
+			{Key: verifier.HeaderVerificationPlugin, Critical: true, Value: string(sig)},
+		}
+	}
+	return &notation.VerificationOutcome{
+		RawSignature:      sig,
+		VerificationLevel: trustpolicy.LevelStrict,
+		EnvelopeContent:   content,
+	}, nil
+}
+
+func newPluginMinVersionDocuments() (*trustpolicy.Document, *Document) {
+	policyDoc, _ := newClockSkewDocuments()
+	extDoc := &Document{
+		TrustPolicies: []TrustPolicy{{Name: "build", PluginMinVersions: map[string]string{"com.example.plugin": "1.2.0"}}},
+	}
+	return policyDoc, extDoc
+}
+
+func TestParseDocument_PluginMinVersions(t *testing.T) {
+	if _, err := ParseDocument([]byte(`{"trustPolicies":[{"name":"build","pluginMinVersions":{"com.example.plugin":"1.2.0-rc.1"}}]}`)); err != nil {
+		t.Fatalf("ParseDocument() error = %v", err)
+	}
+	for _, version := range []string{"", "v1.2.0", "1.2", "latest"} {
+		_, err := ParseDocument([]byte(`{"trustPolicies":[{"name":"build","pluginMinVersions":{"com.example.plugin":"` + version + `"}}]}`))
+		if err == nil || !strings.Contains(err.Error(), "invalid minimum version") {
+			t.Fatalf("expected error for version %q, got %v", version, err)
+		}
+	}
+	if _, err := ParseDocument([]byte(`{"trustPolicies":[{"name":"build","pluginMinVersions":{"":"1.2.0"}}]}`)); err == nil {
+		t.Fatal("expected error for empty plugin name")
+	}
+}
+
+func TestVerifier_PluginMinVersions(t *testing.T) {
+	t.Setenv("NOTATION_EXPERIMENTAL", "1")
+	policyDoc, extDoc := newPluginMinVersionDocuments()
+	v, err := NewVerifier(policyDoc, extDoc, func(policyDoc *trustpolicy.Document) (notation.Verifier, error) {
+		return &pluginVerifier{}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	opts := notation.VerifierVerifyOptions{ArtifactReference: testAnnotationsReference}
+
+	for _, version := range []string{"1.2.0", "1.10.0", "2.0.0"} {
+		v.pluginManager = &versionedPluginManager{version: version}
+		if _, err := v.Verify(ctx, ocispec.Descriptor{}, []byte("com.example.plugin"), opts); err != nil {
+			t.Fatalf("Verify() with plugin version %s error = %v", version, err)
+		}
+	}
+	for _, version := range []string{"1.1.9", "1.2.0-rc.1", "unknown"} {
+		v.pluginManager = &versionedPluginManager{version: version}
+		_, err := v.Verify(ctx, ocispec.Descriptor{}, []byte("com.example.plugin"), opts)
+		var verificationErr notation.ErrorVerificationFailed
+		if !errors.As(err, &verificationErr) || !strings.Contains(err.Error(), "1.2.0") {
+			t.Fatalf("expected plugin version %s to fail, got %v", version, err)
+		}
+	}
+
+	// signatures without verification plugins, or with other plugins, and
+	// statements without minimum versions are not affected
+	v.pluginManager = &versionedPluginManager{version: "1.0.0"}
+	if _, err := v.Verify(ctx, ocispec.Descriptor{}, nil, opts); err != nil {
+		t.Fatalf("Verify() without plugin error = %v", err)
+	}
+	if _, err := v.Verify(ctx, ocispec.Descriptor{}, []byte("com.example.other"), opts); err != nil {
+		t.Fatalf("Verify() with other plugin error = %v", err)
+	}
+	prodOpts := notation.VerifierVerifyOptions{ArtifactReference: "registry.example.com/prod@sha256:0000000000000000000000000000000000000000000000000000000000000000"}
+	if _, err := v.Verify(ctx, ocispec.Descriptor{}, []byte("com.example.plugin"), prodOpts); err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+}
+
+func TestNewVerifier_PluginMinVersionsExperimental(t *testing.T) {
+	t.Setenv("NOTATION_EXPERIMENTAL", "")
+	policyDoc, extDoc := newPluginMinVersionDocuments()
+	_, err := NewVerifier(policyDoc, extDoc, func(policyDoc *trustpolicy.Document) (notation.Verifier, error) {
+		return &pluginVerifier{}, nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "pluginMinVersions") {
+		t.Fatalf("expected experimental error, got %v", err)
+	}
+}
@@ -0,0 +1,303 @@
+// Package policy provides notation CLI extensions to the trust policy
+// document. The extension fields live side by side with the fields defined by
+// the Notary Project specification in trustpolicy.json and are ignored by
+// notation-go.
+package policy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"time"
+
+	"github.com/notaryproject/notation-go/dir"
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+)
+
+// Document is the notation CLI view of the trust policy document, containing
+// only the extension fields of each trust policy statement.
+type Document struct {
+	// TrustPolicies are the extended trust policy statements.
+	TrustPolicies []TrustPolicy `json:"trustPolicies"`
+
+	// VerificationLevels is an experimental list of custom verification
+	// levels, which the statements reference by name.
+	VerificationLevels []VerificationLevel `json:"verificationLevels,omitempty"`
+
+	// TrustBundles is an experimental list of named trust stores fetched from
+	// HTTPS URLs, which the statements reference like the trust stores in
+	// the notation config directory.
+	TrustBundles []TrustBundle `json:"trustBundles,omitempty"`
+
+	// clockSkewOverride is the clock skew tolerance of all the statements,
+	// overriding the tolerances of the statements if set.
+	clockSkewOverride *time.Duration
+}
+
+// TrustPolicy contains the extension fields of a trust policy statement.
+type TrustPolicy struct {
+	// Name is the name of the trust policy statement being extended.
+	Name string `json:"name"`
+
+	// KeylessIdentities is an experimental list of OIDC issuer and subject
+	// combinations trusted to sign with ephemeral certificates.
+	KeylessIdentities []KeylessIdentity `json:"keylessIdentities,omitempty"`
+
+	// ArtifactTypes is an experimental list of artifact types the statement
+	// applies to, e.g. "application/vnd.oci.image.config.v1+json" for images.
+	// Statements with artifact types take precedence over statements without
+	// for the same registry scope. If empty, the statement applies to all
+	// artifact types.
+	ArtifactTypes []string `json:"artifactTypes,omitempty"`
+
+	// ClockSkewTolerance is an experimental duration, e.g. "5m", by which the
+	// clock of the verifier may be off when checking the expiry of the
+	// signatures and the validity of their certificates. At most 1h.
+	ClockSkewTolerance string `json:"clockSkewTolerance,omitempty"`
+
+	// SignatureAnnotations is an experimental list of rules on the annotations
+	// of the signature manifests, in the format of the user metadata
+	// assertions, e.g. "io.cncf.notary.x-pipeline-id" requires the annotation
+	// to be present. Signatures whose manifests do not satisfy the rules fail
+	// verification.
+	SignatureAnnotations []string `json:"signatureAnnotations,omitempty"`
+
+	// PluginMinVersions is an experimental map from the names of verification
+	// plugins to their minimum versions, e.g. {"com.example.plugin": "1.2.0"}.
+	// Signatures requiring a verification plugin older than its minimum
+	// version fail verification.
+	PluginMinVersions map[string]string `json:"pluginMinVersions,omitempty"`
+
+	// ExcludeScopes is an experimental list of repositories, e.g.
+	// "registry.example.com/org/app", or namespaces ending with "/*", e.g.
+	// "registry.example.com/org/sandbox/*", which the statement of the
+	// wildcard registry scope does not apply to. Artifacts in the excluded
+	// scopes have no applicable statement unless their repositories are the
+	// registry scopes of other statements.
+	ExcludeScopes []string `json:"excludeScopes,omitempty"`
+
+	// TimestampTrustStores is an experimental list of trust stores of the
+	// root certificates of RFC 3161 timestamp authorities, in the format of
+	// tsa:{name}, e.g. "tsa:acme-tsa". If set, the timestamps of the
+	// signatures must be issued by the timestamp authorities, and the
+	// certificates of timestamped signatures are evaluated at the time of the
+	// timestamp rather than the current time.
+	TimestampTrustStores []string `json:"timestampTrustStores,omitempty"`
+}
+
+// LoadDocument loads the extension fields of the trust policy document from
+// the notation config directory.
+func LoadDocument() (*Document, error) {
+	path, err := dir.ConfigFS().SysPath(dir.PathTrustPolicy)
+	if err != nil {
+		return nil, err
+	}
+	return LoadDocumentFromFile(path)
+}
+
+// PathNamedTrustPolicies is the directory of the named trust policy
+// documents, relative to the notation config directory.
+const PathNamedTrustPolicies = "trustpolicy.d"
+
+// namePattern matches the names of named trust policy documents, which are
+// used as file names.
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// DocumentPath returns the path of the trust policy document named name,
+// relative to the notation config directory. The trust policy document
+// trustpolicy.json is returned if name is empty, otherwise the document
+// {name}.json in the trustpolicy.d directory, e.g. trustpolicy.d/prod.json.
+func DocumentPath(name string) (string, error) {
+	if name == "" {
+		return dir.PathTrustPolicy, nil
+	}
+	if !namePattern.MatchString(name) {
+		return "", fmt.Errorf("invalid trust policy name %q, only letters, digits, '.', '_' and '-' are allowed, starting with a letter or digit", name)
+	}
+	return path.Join(PathNamedTrustPolicies, name+".json"), nil
+}
+
+// LoadDocuments loads the trust policy document and its extension fields from
+// the notation config directory.
+//
+// Statements scoped by artifact types may share registry scopes with other
+// statements, so the trust policy document is only validated if no statement
+// is scoped by artifact types. Otherwise, it is validated per artifact type,
+// see Document.ForArtifactType.
+func LoadDocuments() (*trustpolicy.Document, *Document, error) {
+	return LoadNamedDocuments("")
+}
+
+// LoadNamedDocuments loads the trust policy document named name and its
+// extension fields from the notation config directory, see DocumentPath. The
+// trust policy document trustpolicy.json is loaded if name is empty.
+func LoadNamedDocuments(name string) (*trustpolicy.Document, *Document, error) {
+	relPath, err := DocumentPath(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	path, err := dir.ConfigFS().SysPath(relPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	policyJSON, err := os.ReadFile(path)
+	if err != nil {
+		if name != "" {
+			switch {
+			case errors.Is(err, os.ErrNotExist):
+				return nil, nil, fmt.Errorf("trust policy %q is not present, please create trust policy at %s", name, path)
+			case errors.Is(err, os.ErrPermission):
+				return nil, nil, fmt.Errorf("unable to read trust policy %q due to file permissions, please verify the permissions of %s", name, path)
+			}
+			return nil, nil, err
+		}
+		// trustpolicy.LoadDocument reports a missing or inaccessible trust
+		// policy in a user friendly way
+		if _, loadErr := trustpolicy.LoadDocument(); loadErr != nil {
+			return nil, nil, loadErr
+		}
+		return nil, nil, err
+	}
+	extDoc, err := ParseDocument(policyJSON)
+	if err != nil {
+		return nil, nil, err
+	}
+	if name == "" && !extDoc.HasArtifactTypes() {
+		policyDoc, err := trustpolicy.LoadDocument()
+		if err != nil {
+			return nil, nil, err
+		}
+		return policyDoc, extDoc, nil
+	}
+	var policyDoc trustpolicy.Document
+	if err := json.Unmarshal(policyJSON, &policyDoc); err != nil {
+		return nil, nil, fmt.Errorf("malformed trust policy: %w", err)
+	}
+	return &policyDoc, extDoc, nil
+}
+
+// ValidateDocument validates the trust policy document policyDoc parsed from
+// policyJSON together with its extension fields. The custom verification
+// levels are resolved before the statements are validated.
+func ValidateDocument(policyDoc *trustpolicy.Document, policyJSON []byte) error {
+	extDoc, err := ParseDocument(policyJSON)
+	if err != nil {
+		return err
+	}
+	return extDoc.validateStatements(extDoc.ResolveVerificationLevels(policyDoc))
+}
+
+// validateStatements validates the statements of policyDoc. If statements are
+// scoped by artifact types, they are validated per artifact type like the
+// verifiers of NewVerifier, as statements of different artifact types may
+// share registry scopes.
+func (doc *Document) validateStatements(policyDoc *trustpolicy.Document) error {
+	if err := doc.validateExcludeScopeStatements(policyDoc); err != nil {
+		return err
+	}
+	artifactTypes := doc.artifactTypes()
+	if len(artifactTypes) == 0 {
+		return policyDoc.Validate()
+	}
+	// statement names identify the statements across artifact types
+	names := make(map[string]bool)
+	for _, statement := range policyDoc.TrustPolicies {
+		if names[statement.Name] {
+			return fmt.Errorf("multiple trust policy statements use the same name %q, statement names must be unique", statement.Name)
+		}
+		names[statement.Name] = true
+	}
+	for _, artifactType := range append([]string{""}, artifactTypes...) {
+		typedDoc := doc.ForArtifactType(policyDoc, artifactType)
+		if len(typedDoc.TrustPolicies) == 0 {
+			continue
+		}
+		if err := typedDoc.Validate(); err != nil {
+			if artifactType == "" {
+				return fmt.Errorf("invalid trust policy for artifacts of any other artifact type: %w", err)
+			}
+			return fmt.Errorf("invalid trust policy for artifacts of artifact type %q: %w", artifactType, err)
+		}
+	}
+	return nil
+}
+
+// LoadDocumentFromFile loads the extension fields of the trust policy
+// document at path.
+func LoadDocumentFromFile(path string) (*Document, error) {
+	policyJSON, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseDocument(policyJSON)
+}
+
+// ParseDocument parses the extension fields of a trust policy document.
+func ParseDocument(policyJSON []byte) (*Document, error) {
+	var doc Document
+	if err := json.Unmarshal(policyJSON, &doc); err != nil {
+		return nil, fmt.Errorf("malformed trust policy: %w", err)
+	}
+	if err := doc.Validate(); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// Validate validates the extension fields of the trust policy document.
+func (doc *Document) Validate() error {
+	if err := validateVerificationLevels(doc.VerificationLevels); err != nil {
+		return err
+	}
+	if err := validateTrustBundles(doc.TrustBundles); err != nil {
+		return err
+	}
+	for _, statement := range doc.TrustPolicies {
+		if err := validateKeylessIdentities(statement); err != nil {
+			return err
+		}
+		if err := validateClockSkewTolerance(statement); err != nil {
+			return err
+		}
+		if err := validateSignatureAnnotations(statement); err != nil {
+			return err
+		}
+		if err := validatePluginMinVersions(statement); err != nil {
+			return err
+		}
+		if err := validateExcludeScopes(statement); err != nil {
+			return err
+		}
+		if err := validateTimestampTrustStores(statement); err != nil {
+			return err
+		}
+		for _, artifactType := range statement.ArtifactTypes {
+			if artifactType == "" {
+				return fmt.Errorf("trust policy statement %q has an empty artifact type", statement.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// Get returns the extended trust policy statement with the given name, or an
+// empty statement if the statement has no extension fields.
+func (doc *Document) Get(name string) *TrustPolicy {
+	if doc != nil {
+		for i, statement := range doc.TrustPolicies {
+			if statement.Name == name {
+				return &doc.TrustPolicies[i]
+			}
+		}
+	}
+	return &TrustPolicy{Name: name}
+}
+
+// errorExperimental returns an error indicating that the extension field is
+// used while experimental features are disabled.
+func errorExperimental(policyName, field string) error {
+	return fmt.Errorf("trust policy statement %q uses %q which is experimental and not enabled by default. To use, please set NOTATION_EXPERIMENTAL=1 environment variable", policyName, field)
+}
@@ -0,0 +1,77 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/notaryproject/notation-go/dir"
+)
+
+func TestDocumentPath(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{name: "", want: "trustpolicy.json"},
+		{name: "prod", want: "trustpolicy.d/prod.json"},
+		{name: "eu-west_1.stage", want: "trustpolicy.d/eu-west_1.stage.json"},
+		{name: "../trustpolicy", wantErr: true},
+		{name: "prod/eu", wantErr: true},
+		{name: ".hidden", wantErr: true},
+		{name: "prod eu", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DocumentPath(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DocumentPath() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("DocumentPath() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadNamedDocuments(t *testing.T) {
+	defer func(old string) { dir.UserConfigDir = old }(dir.UserConfigDir)
+	dir.UserConfigDir = t.TempDir()
+
+	writePolicy := func(path, statement string) {
+		t.Helper()
+		path = filepath.Join(dir.UserConfigDir, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		policyJSON := `{"version":"1.0","trustPolicies":[{"name":"` + statement + `","registryScopes":["*"],"signatureVerification":{"level":"skip"}}]}`
+		if err := os.WriteFile(path, []byte(policyJSON), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writePolicy("trustpolicy.json", "default")
+	writePolicy("trustpolicy.d/prod.json", "prod")
+
+	for name, want := range map[string]string{"": "default", "prod": "prod"} {
+		policyDoc, extDoc, err := LoadNamedDocuments(name)
+		if err != nil {
+			t.Fatalf("LoadNamedDocuments(%q) error = %v", name, err)
+		}
+		if got := policyDoc.TrustPolicies[0].Name; got != want {
+			t.Fatalf("LoadNamedDocuments(%q) loaded statement %q, want %q", name, got, want)
+		}
+		if extDoc == nil {
+			t.Fatalf("LoadNamedDocuments(%q) returned no extension document", name)
+		}
+	}
+
+	_, _, err := LoadNamedDocuments("stage")
+	if err == nil || !strings.Contains(err.Error(), `trust policy "stage" is not present`) {
+		t.Fatalf("expected error reporting the missing trust policy, got %v", err)
+	}
+	if _, _, err := LoadNamedDocuments("../trustpolicy"); err == nil {
+		t.Fatal("expected error for an invalid trust policy name")
+	}
+}
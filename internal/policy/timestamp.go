@@ -0,0 +1,350 @@
+package policy
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/notaryproject/notation-core-go/signature"
+	"github.com/notaryproject/notation-core-go/signature/cose"
+	corex509 "github.com/notaryproject/notation-core-go/x509"
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation-go/dir"
+	"github.com/notaryproject/notation-go/log"
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+	"github.com/notaryproject/notation-go/verifier/truststore"
+	"github.com/notaryproject/notation/internal/archive"
+	"github.com/notaryproject/notation/internal/envelope"
+	"github.com/opencontainers/go-digest"
+)
+
+// TrustStoreTypeTSA is the experimental type of the x509 trust stores of the
+// root certificates of RFC 3161 timestamp authorities, which the statements
+// reference in "timestampTrustStores". The type is not supported by the trust
+// store of notation-go.
+const TrustStoreTypeTSA truststore.Type = "tsa"
+
+// DefaultTimestampTimeout is the default timeout of validating a timestamp
+// against the timestamp trust stores.
+const DefaultTimestampTimeout = 10 * time.Second
+
+// TrustStoreTypes are the types of the x509 trust stores in the notation
+// config directory, including the trust stores of timestamp authorities.
+var TrustStoreTypes = append(append([]truststore.Type{}, truststore.Types...), TrustStoreTypeTSA)
+
+// namedStorePattern matches the names of the named trust stores.
+var namedStorePattern = regexp.MustCompile(`^[a-zA-Z0-9_.-]+$`)
+
+func validateTimestampTrustStores(statement TrustPolicy) error {
+	for _, trustStore := range statement.TimestampTrustStores {
+		storeType, name, ok := strings.Cut(trustStore, ":")
+		if !ok || storeType != string(TrustStoreTypeTSA) || !namedStorePattern.MatchString(name) {
+			return fmt.Errorf("trust policy statement %q has an invalid timestamp trust store %q, it must be in the format of %s:{name}, e.g. \"%s:acme-tsa\"", statement.Name, trustStore, TrustStoreTypeTSA, TrustStoreTypeTSA)
+		}
+	}
+	return nil
+}
+
+// timestampPolicy is the timestamp trust stores of a statement, and whether
+// the statement enforces the authentic timestamp check.
+type timestampPolicy struct {
+	// trustStores are the names of the tsa trust stores.
+	trustStores []string
+	enforced    bool
+
+	// levelName is the name of the verification level of the statement, as
+	// the wrapped verifier reports levels with overrides as "custom".
+	levelName string
+}
+
+// ResolveTimestampTrustStores returns a copy of policyDoc, in which the
+// authentic timestamp check of the statements with timestamp trust stores is
+// logged rather than enforced, so that Verifier checks the certificate
+// validity at the time of the verified timestamp instead of the wrapped
+// verifier. It also returns the timestamp policies indexed by the names of
+// the statements with timestamp trust stores. policyDoc is returned as is if
+// no statement has timestamp trust stores.
+func (doc *Document) ResolveTimestampTrustStores(policyDoc *trustpolicy.Document) (*trustpolicy.Document, map[string]timestampPolicy) {
+	policies := make(map[string]timestampPolicy)
+	resolved := &trustpolicy.Document{
+		Version:       policyDoc.Version,
+		TrustPolicies: make([]trustpolicy.TrustPolicy, len(policyDoc.TrustPolicies)),
+	}
+	for i, statement := range policyDoc.TrustPolicies {
+		resolved.TrustPolicies[i] = statement
+		trustStores := doc.Get(statement.Name).TimestampTrustStores
+		if len(trustStores) == 0 {
+			continue
+		}
+		level, err := statement.SignatureVerification.GetVerificationLevel()
+		if err != nil || level.Name == trustpolicy.LevelSkip.Name {
+			// invalid statements are reported by the wrapped verifier
+			continue
+		}
+		policy := timestampPolicy{levelName: level.Name}
+		for _, trustStore := range trustStores {
+			// the trust stores are validated on load
+			_, name, _ := strings.Cut(trustStore, ":")
+			policy.trustStores = append(policy.trustStores, name)
+		}
+		if level.Enforcement[trustpolicy.TypeAuthenticTimestamp] == trustpolicy.ActionEnforce {
+			policy.enforced = true
+			override := make(map[trustpolicy.ValidationType]trustpolicy.ValidationAction)
+			for check, action := range statement.SignatureVerification.Override {
+				override[check] = action
+			}
+			override[trustpolicy.TypeAuthenticTimestamp] = trustpolicy.ActionLog
+			statement.SignatureVerification = trustpolicy.SignatureVerification{
+				VerificationLevel: statement.SignatureVerification.VerificationLevel,
+				Override:          override,
+			}
+		}
+		resolved.TrustPolicies[i] = statement
+		policies[statement.Name] = policy
+	}
+	if len(policies) == 0 {
+		return policyDoc, nil
+	}
+	return resolved, policies
+}
+
+// tsaTrustStore is the x509 trust store of the tsa trust stores in a notation
+// config directory.
+type tsaTrustStore struct {
+	configFS dir.SysFS
+}
+
+// GetCertificates returns the certificates of the named tsa trust store.
+func (s tsaTrustStore) GetCertificates(ctx context.Context, storeType truststore.Type, namedStore string) ([]*x509.Certificate, error) {
+	if storeType != TrustStoreTypeTSA {
+		return nil, fmt.Errorf("unsupported store type: %s", storeType)
+	}
+	if !namedStorePattern.MatchString(namedStore) {
+		return nil, errors.New("named store name needs to follow [a-zA-Z0-9_.-]+ format")
+	}
+	path, err := s.configFS.SysPath(dir.X509TrustStoreDir(string(storeType), namedStore))
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("%q does not exist", path)
+		}
+		return nil, err
+	}
+	var certs []*x509.Certificate
+	for _, entry := range entries {
+		certPath := filepath.Join(path, entry.Name())
+		if !entry.Type().IsRegular() {
+			return nil, fmt.Errorf("%q is not a regular file (directories or symlinks are not supported)", certPath)
+		}
+		fileCerts, err := corex509.ReadCertificateFile(certPath)
+		if err != nil {
+			return nil, fmt.Errorf("error while reading certificates from %q: %w", certPath, err)
+		}
+		if len(fileCerts) == 0 {
+			return nil, fmt.Errorf("could not parse a certificate from %q, every file in a trust store must have a PEM or DER certificate in it", certPath)
+		}
+		certs = append(certs, fileCerts...)
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("trust store %q has no x509 certificates", path)
+	}
+	return certs, nil
+}
+
+// Timestamp is an RFC 3161 timestamp countersignature of a signature,
+// verified against the timestamp trust stores of a trust policy statement.
+type Timestamp struct {
+	// Time is the time the timestamp authority issued the timestamp.
+	Time time.Time
+
+	// Authority is the certificate of the timestamp authority.
+	Authority *x509.Certificate
+}
+
+// verifiedTimestamps are the verified timestamps indexed by the digests of
+// the signature envelopes.
+type verifiedTimestamps struct {
+	mu         sync.Mutex
+	timestamps map[digest.Digest]Timestamp
+}
+
+func (s *verifiedTimestamps) set(signatureEnvelope []byte, timestamp Timestamp) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.timestamps == nil {
+		s.timestamps = make(map[digest.Digest]Timestamp)
+	}
+	s.timestamps[digest.FromBytes(signatureEnvelope)] = timestamp
+}
+
+func (s *verifiedTimestamps) get(signatureEnvelope []byte) (Timestamp, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	timestamp, ok := s.timestamps[digest.FromBytes(signatureEnvelope)]
+	return timestamp, ok
+}
+
+// VerifiedTimestamp returns the timestamp of signatureEnvelope verified by
+// Verify against the timestamp trust stores of the applicable statement, if
+// any.
+func (v *Verifier) VerifiedTimestamp(signatureEnvelope []byte) (Timestamp, bool) {
+	return v.timestamps.get(signatureEnvelope)
+}
+
+// setTimestampSignature sets the timestamp token of a COSE signature envelope
+// in outcome, as the COSE envelope of notation-core-go does not parse it.
+func setTimestampSignature(outcome *notation.VerificationOutcome) error {
+	unsigned := &outcome.EnvelopeContent.SignerInfo.UnsignedAttributes
+	if len(unsigned.TimestampSignature) != 0 {
+		return nil
+	}
+	mediaType, err := envelope.DetectEnvelopeMediaType(outcome.RawSignature)
+	if err != nil || mediaType != cose.MediaTypeEnvelope {
+		// the envelope is parsed by the wrapped verifier already
+		return nil
+	}
+	token, err := envelope.TimestampSignature(outcome.RawSignature, mediaType)
+	if err != nil {
+		return err
+	}
+	unsigned.TimestampSignature = token
+	return nil
+}
+
+// verifyTimestamp evaluates the certificate validity of the signature of
+// outcome, replacing the result of the authentic timestamp check of the
+// wrapped verifier.
+//
+// If the applicable statement has timestamp trust stores, the timestamp of
+// the signature must be issued by a timestamp authority of the trust stores,
+// and the certificates must be valid at the time of the timestamp. Otherwise,
+// the timestamp is not trusted and the certificates of timestamped signatures
+// must be valid now, as the wrapped verifier does not check the certificates
+// of timestamped signatures at all. The first enforced failure is returned.
+func (v *Verifier) verifyTimestamp(ctx context.Context, statementName string, outcome *notation.VerificationOutcome, now time.Time) error {
+	logger := log.GetLogger(ctx)
+	signerInfo := &outcome.EnvelopeContent.SignerInfo
+	if signerInfo.SignedAttributes.SigningScheme != signature.SigningSchemeX509 {
+		// signing authorities vouch for the signing time
+		return nil
+	}
+	var result *notation.ValidationResult
+	for _, r := range outcome.VerificationResults {
+		if r.Type == trustpolicy.TypeAuthenticTimestamp {
+			result = r
+		}
+	}
+	if result == nil {
+		// skipped by the verification level
+		return nil
+	}
+	policy, ok := v.timestampPolicies[statementName]
+	token := signerInfo.UnsignedAttributes.TimestampSignature
+	if len(token) == 0 && !ok {
+		return nil
+	}
+	tolerance := v.clockSkews[statementName].tolerance
+	var err error
+	switch {
+	case len(token) == 0:
+		err = verifyChainValidity(signerInfo.CertificateChain, now, tolerance)
+	case !ok:
+		if err = verifyChainValidity(signerInfo.CertificateChain, now, tolerance); err != nil {
+			err = fmt.Errorf("%w, and the timestamp of the signature is not trusted as trust policy statement %q has no timestamp trust stores", err, statementName)
+		}
+	default:
+		var timestamp Timestamp
+		timestamp, err = v.verifyTimestampTokenWithTimeout(ctx, policy.trustStores, signerInfo, tolerance)
+		if err == nil {
+			if result.Error != nil {
+				logger.Warnf("%s check passed at the time of the timestamp of the signature: %v", result.Type, result.Error)
+			}
+			v.timestamps.set(outcome.RawSignature, timestamp)
+			logger.Infof("Verified the timestamp of the signature issued at %s by %q", timestamp.Time.Format(time.RFC3339), timestamp.Authority.Subject)
+		}
+	}
+	result.Error = err
+	if policy.enforced {
+		result.Action = trustpolicy.ActionEnforce
+		if outcome.VerificationLevel != nil {
+			level := *outcome.VerificationLevel
+			level.Enforcement = make(map[trustpolicy.ValidationType]trustpolicy.ValidationAction)
+			for check, action := range outcome.VerificationLevel.Enforcement {
+				level.Enforcement[check] = action
+			}
+			level.Enforcement[trustpolicy.TypeAuthenticTimestamp] = trustpolicy.ActionEnforce
+			outcome.VerificationLevel = &level
+		}
+	}
+	if err != nil && result.Action == trustpolicy.ActionEnforce {
+		return notation.ErrorVerificationFailed{Msg: err.Error()}
+	}
+	return nil
+}
+
+// verifyTimestampTokenWithTimeout is verifyTimestampToken bounded by the
+// timestamp timeout, so that slow trust stores, e.g. on network file systems,
+// do not consume the time of the other checks.
+func (v *Verifier) verifyTimestampTokenWithTimeout(ctx context.Context, trustStores []string, signerInfo *signature.SignerInfo, tolerance time.Duration) (Timestamp, error) {
+	if v.timestampTimeout <= 0 {
+		return v.verifyTimestampToken(ctx, trustStores, signerInfo, tolerance)
+	}
+	timeoutCtx, cancel := context.WithTimeout(ctx, v.timestampTimeout)
+	defer cancel()
+	type result struct {
+		timestamp Timestamp
+		err       error
+	}
+	done := make(chan result, 1)
+	go func() {
+		timestamp, err := v.verifyTimestampToken(timeoutCtx, trustStores, signerInfo, tolerance)
+		done <- result{timestamp: timestamp, err: err}
+	}()
+	select {
+	case r := <-done:
+		return r.timestamp, r.err
+	case <-timeoutCtx.Done():
+		if ctx.Err() != nil {
+			return Timestamp{}, ctx.Err()
+		}
+		log.GetLogger(ctx).Warnf("Timestamp validation timed out after %v", v.timestampTimeout)
+		return Timestamp{}, fmt.Errorf("timestamp validation did not complete within %v", v.timestampTimeout)
+	}
+}
+
+// verifyTimestampToken verifies the timestamp of the signature against the
+// named tsa trust stores, and that the certificates of the signature are
+// valid at the time of the timestamp, tolerating the clock skew.
+func (v *Verifier) verifyTimestampToken(ctx context.Context, trustStores []string, signerInfo *signature.SignerInfo, tolerance time.Duration) (Timestamp, error) {
+	roots := x509.NewCertPool()
+	for _, name := range trustStores {
+		certs, err := v.timestampTrustStore.GetCertificates(ctx, TrustStoreTypeTSA, name)
+		if err != nil {
+			return Timestamp{}, fmt.Errorf("failed to read timestamp trust store %q: %w", string(TrustStoreTypeTSA)+":"+name, err)
+		}
+		for _, cert := range certs {
+			roots.AddCert(cert)
+		}
+	}
+	token, chain, err := archive.VerifyCountersignature(signerInfo.UnsignedAttributes.TimestampSignature, signerInfo.SignatureAlgorithm.Hash(), signerInfo.Signature, roots)
+	if err != nil {
+		return Timestamp{}, fmt.Errorf("failed to verify the timestamp of the signature: %w", err)
+	}
+	for _, cert := range signerInfo.CertificateChain {
+		if !validWithin(cert, token.GenTime, tolerance) {
+			return Timestamp{}, fmt.Errorf("certificate %q was not valid when the signature was timestamped at %q, beyond the clock skew tolerance of %v", cert.Subject, token.GenTime.Format(time.RFC1123Z), tolerance)
+		}
+	}
+	return Timestamp{Time: token.GenTime, Authority: chain[0]}, nil
+}
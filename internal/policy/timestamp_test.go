@@ -0,0 +1,262 @@
+package policy
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/notation-core-go/signature"
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation-go/dir"
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+	"github.com/notaryproject/notation-go/verifier/truststore"
+)
+
+func newTimestampDocuments() (*trustpolicy.Document, *Document) {
+	policyDoc := &trustpolicy.Document{
+		Version: "1.0",
+		TrustPolicies: []trustpolicy.TrustPolicy{
+			{
+				Name:                  "release",
+				RegistryScopes:        []string{"registry.example.com/release"},
+				SignatureVerification: trustpolicy.SignatureVerification{VerificationLevel: "strict"},
+				TrustStores:           []string{"ca:acme"},
+				TrustedIdentities:     []string{"*"},
+			},
+			{
+				Name:                  "dev",
+				RegistryScopes:        []string{"registry.example.com/dev"},
+				SignatureVerification: trustpolicy.SignatureVerification{VerificationLevel: "strict"},
+				TrustStores:           []string{"ca:acme"},
+				TrustedIdentities:     []string{"*"},
+			},
+		},
+	}
+	extDoc := &Document{
+		TrustPolicies: []TrustPolicy{{Name: "release", TimestampTrustStores: []string{"tsa:acme-tsa"}}},
+	}
+	return policyDoc, extDoc
+}
+
+func TestParseDocument_TimestampTrustStores(t *testing.T) {
+	if _, err := ParseDocument([]byte(`{"trustPolicies":[{"name":"release","timestampTrustStores":["tsa:acme-tsa"]}]}`)); err != nil {
+		t.Fatalf("ParseDocument() error = %v", err)
+	}
+	for _, trustStore := range []string{"acme-tsa", "ca:acme-tsa", "tsa:", "tsa:acme/tsa"} {
+		_, err := ParseDocument([]byte(`{"trustPolicies":[{"name":"release","timestampTrustStores":["` + trustStore + `"]}]}`))
+		if err == nil || !strings.Contains(err.Error(), "invalid timestamp trust store") {
+			t.Errorf("ParseDocument() of %q error = %v, want invalid timestamp trust store", trustStore, err)
+		}
+	}
+}
+
+func TestResolveTimestampTrustStores(t *testing.T) {
+	policyDoc, extDoc := newTimestampDocuments()
+	resolved, policies := extDoc.ResolveTimestampTrustStores(policyDoc)
+	if len(policies) != 1 {
+		t.Fatalf("expected 1 timestamp policy, got %v", policies)
+	}
+	policy := policies["release"]
+	if !policy.enforced || policy.levelName != "strict" || len(policy.trustStores) != 1 || policy.trustStores[0] != "acme-tsa" {
+		t.Fatalf("unexpected timestamp policy %+v", policy)
+	}
+	if action := resolved.TrustPolicies[0].SignatureVerification.Override[trustpolicy.TypeAuthenticTimestamp]; action != trustpolicy.ActionLog {
+		t.Fatalf("expected the authentic timestamp check to be logged by the wrapped verifier, got %q", action)
+	}
+	if len(policyDoc.TrustPolicies[0].SignatureVerification.Override) != 0 {
+		t.Fatal("expected the trust policy document to be left unchanged")
+	}
+	if resolved.TrustPolicies[1].SignatureVerification.Override != nil {
+		t.Fatal("expected the statement without timestamp trust stores to be left unchanged")
+	}
+
+	extDoc.TrustPolicies = nil
+	if resolved, policies := extDoc.ResolveTimestampTrustStores(policyDoc); resolved != policyDoc || len(policies) != 0 {
+		t.Fatal("expected no timestamp policy to be applied")
+	}
+}
+
+func newTimestampOutcome(cert *x509.Certificate, token []byte) *notation.VerificationOutcome {
+	return &notation.VerificationOutcome{
+		VerificationLevel: &trustpolicy.VerificationLevel{
+			Name: "strict",
+			Enforcement: map[trustpolicy.ValidationType]trustpolicy.ValidationAction{
+				trustpolicy.TypeAuthenticTimestamp: trustpolicy.ActionLog,
+			},
+		},
+		VerificationResults: []*notation.ValidationResult{
+			{Type: trustpolicy.TypeAuthenticTimestamp, Action: trustpolicy.ActionLog},
+		},
+		EnvelopeContent: &signature.EnvelopeContent{
+			SignerInfo: signature.SignerInfo{
+				SignedAttributes:   signature.SignedAttributes{SigningScheme: signature.SigningSchemeX509},
+				UnsignedAttributes: signature.UnsignedAttributes{TimestampSignature: token},
+				SignatureAlgorithm: signature.AlgorithmES256,
+				Signature:          []byte("signature"),
+				CertificateChain:   []*x509.Certificate{cert},
+			},
+		},
+	}
+}
+
+func TestVerifier_VerifyTimestamp(t *testing.T) {
+	now := time.Now()
+	expired := &x509.Certificate{
+		Subject:   pkix.Name{CommonName: "release"},
+		NotBefore: now.Add(-48 * time.Hour),
+		NotAfter:  now.Add(-24 * time.Hour),
+	}
+	valid := &x509.Certificate{
+		Subject:   pkix.Name{CommonName: "release"},
+		NotBefore: now.Add(-time.Hour),
+		NotAfter:  now.Add(time.Hour),
+	}
+	v := &Verifier{
+		timestampPolicies: map[string]timestampPolicy{
+			"release": {trustStores: []string{"acme-tsa"}, enforced: true},
+		},
+		timestampTrustStore: tsaTrustStore{configFS: dir.NewSysFS(t.TempDir())},
+	}
+	tests := []struct {
+		name      string
+		statement string
+		cert      *x509.Certificate
+		token     []byte
+		wantErr   string
+	}{
+		{name: "not timestamped", statement: "release", cert: valid},
+		{name: "not timestamped and expired", statement: "release", cert: expired, wantErr: "not valid anymore"},
+		{name: "untrusted timestamp", statement: "release", cert: valid, token: []byte("token"), wantErr: "failed to read timestamp trust store \"tsa:acme-tsa\""},
+		{name: "no timestamp trust stores", statement: "dev", cert: valid, token: []byte("token")},
+		{name: "no timestamp trust stores and expired", statement: "dev", cert: expired, token: []byte("token"), wantErr: "timestamp of the signature is not trusted"},
+		{name: "no timestamp trust stores and not timestamped", statement: "dev", cert: expired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outcome := newTimestampOutcome(tt.cert, tt.token)
+			if tt.statement == "dev" {
+				// the checks of the statements without timestamp trust stores
+				// keep the actions of their verification levels
+				outcome.VerificationResults[0].Action = trustpolicy.ActionEnforce
+			}
+			err := v.verifyTimestamp(context.Background(), tt.statement, outcome, now)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("verifyTimestamp() error = %v", err)
+				}
+				return
+			}
+			var verificationErr notation.ErrorVerificationFailed
+			if !errors.As(err, &verificationErr) || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("verifyTimestamp() error = %v, want %q", err, tt.wantErr)
+			}
+			result := outcome.VerificationResults[0]
+			if result.Error == nil || result.Action != trustpolicy.ActionEnforce {
+				t.Fatalf("expected the authentic timestamp check to fail and be enforced, got %v with action %q", result.Error, result.Action)
+			}
+		})
+	}
+
+	// the timestamp is not verified by the statements logging the check
+	v.timestampPolicies["release"] = timestampPolicy{trustStores: []string{"acme-tsa"}}
+	outcome := newTimestampOutcome(valid, []byte("token"))
+	if err := v.verifyTimestamp(context.Background(), "release", outcome, now); err != nil {
+		t.Fatalf("verifyTimestamp() error = %v", err)
+	}
+	if result := outcome.VerificationResults[0]; result.Error == nil || result.Action != trustpolicy.ActionLog {
+		t.Fatalf("expected the logged check to fail, got %v with action %q", result.Error, result.Action)
+	}
+	if _, ok := v.VerifiedTimestamp(outcome.RawSignature); ok {
+		t.Fatal("expected no verified timestamp")
+	}
+}
+
+func TestTSATrustStore(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "TSA root"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := t.TempDir()
+	storeDir := filepath.Join(root, dir.X509TrustStoreDir(string(TrustStoreTypeTSA), "acme-tsa"))
+	if err := os.MkdirAll(storeDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(storeDir, "root.pem"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	store := tsaTrustStore{configFS: dir.NewSysFS(root)}
+	certs, err := store.GetCertificates(context.Background(), TrustStoreTypeTSA, "acme-tsa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(certs) != 1 || certs[0].Subject.CommonName != "TSA root" {
+		t.Fatalf("unexpected certificates: %v", certs)
+	}
+	if _, err := store.GetCertificates(context.Background(), TrustStoreTypeTSA, "other"); err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Fatalf("expected missing trust store error, got %v", err)
+	}
+	if _, err := store.GetCertificates(context.Background(), truststore.TypeCA, "acme-tsa"); err == nil {
+		t.Fatal("expected error of unsupported store type")
+	}
+}
+
+func TestNewVerifier_TimestampTrustStoresExperimental(t *testing.T) {
+	t.Setenv("NOTATION_EXPERIMENTAL", "")
+	policyDoc, extDoc := newTimestampDocuments()
+	_, err := NewVerifier(policyDoc, extDoc, func(policyDoc *trustpolicy.Document) (notation.Verifier, error) {
+		return &levelVerifier{policyDoc: policyDoc}, nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "timestampTrustStores") {
+		t.Fatalf("expected experimental error, got %v", err)
+	}
+}
+
+// hangingTrustStore never returns the certificates until the context is done.
+type hangingTrustStore struct{}
+
+func (hangingTrustStore) GetCertificates(ctx context.Context, _ truststore.Type, _ string) ([]*x509.Certificate, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestVerifier_VerifyTimestampTimeout(t *testing.T) {
+	now := time.Now()
+	v := &Verifier{
+		timestampPolicies: map[string]timestampPolicy{
+			"release": {trustStores: []string{"acme-tsa"}, enforced: true},
+		},
+		timestampTrustStore: hangingTrustStore{},
+		timestampTimeout:    10 * time.Millisecond,
+	}
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "release"}, NotBefore: now.Add(-time.Hour), NotAfter: now.Add(time.Hour)}
+	outcome := newTimestampOutcome(cert, []byte("token"))
+	err := v.verifyTimestamp(context.Background(), "release", outcome, now)
+	if err == nil || !strings.Contains(err.Error(), "did not complete within 10ms") {
+		t.Fatalf("verifyTimestamp() error = %v, want timeout error", err)
+	}
+}
@@ -0,0 +1,185 @@
+package policy
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"hash"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/notaryproject/notation-go/dir"
+	"github.com/notaryproject/notation-go/verifier/truststore"
+	"github.com/notaryproject/notation/internal/slices"
+	"github.com/opencontainers/go-digest"
+)
+
+// TrustStoreOverride points a named x509 trust store to an ad-hoc directory of
+// certificates for a single verification, e.g. to test candidate roots
+// without editing the trust store in the notation config directory.
+type TrustStoreOverride struct {
+	// Type is the type of the trust store.
+	Type truststore.Type
+
+	// Name is the name of the trust store as referenced by the trust policy.
+	Name string
+
+	// Dir is the directory of the certificates.
+	Dir string
+}
+
+// ParseTrustStoreOverride parses a trust store override in the format
+// "{type}:{name}={dir}", e.g. "ca:acme-rootcas=./candidate-roots".
+func ParseTrustStoreOverride(value string) (TrustStoreOverride, error) {
+	store, storeDir, ok := strings.Cut(value, "=")
+	storeType, name, ok2 := strings.Cut(store, ":")
+	if !ok || !ok2 || name == "" || storeDir == "" {
+		return TrustStoreOverride{}, fmt.Errorf("invalid trust store override %q, expecting {type}:{name}={dir}", value)
+	}
+	if !slices.Contains(truststore.Types, truststore.Type(storeType)) {
+		return TrustStoreOverride{}, fmt.Errorf("invalid trust store override %q, unsupported trust store type %q", value, storeType)
+	}
+	storeDir, err := filepath.Abs(storeDir)
+	if err != nil {
+		return TrustStoreOverride{}, err
+	}
+	return TrustStoreOverride{
+		Type: truststore.Type(storeType),
+		Name: name,
+		Dir:  storeDir,
+	}, nil
+}
+
+// overrideFS is a notation config directory serving the named x509 trust
+// stores of overrides from their directories, so that the certificates are
+// read and validated by the x509 trust store of notation-go as usual.
+type overrideFS struct {
+	dir.SysFS
+
+	// dirs maps the trust store paths relative to the config directory to the
+	// override directories.
+	dirs map[string]string
+}
+
+// newOverrideFS returns configFS with the trust stores overridden by
+// overrides. configFS is returned as is if there is no override.
+func newOverrideFS(configFS dir.SysFS, overrides []TrustStoreOverride) dir.SysFS {
+	if len(overrides) == 0 {
+		return configFS
+	}
+	dirs := make(map[string]string, len(overrides))
+	for _, override := range overrides {
+		dirs[dir.X509TrustStoreDir(string(override.Type), override.Name)] = override.Dir
+	}
+	return &overrideFS{SysFS: configFS, dirs: dirs}
+}
+
+// SysPath returns the override directory of a named trust store, or the path
+// in the config directory otherwise.
+func (f *overrideFS) SysPath(items ...string) (string, error) {
+	if storeDir, ok := f.dirs[filepath.ToSlash(filepath.Join(items...))]; ok {
+		return storeDir, nil
+	}
+	return f.SysFS.SysPath(items...)
+}
+
+// DigestTrustStore returns the digest of the names and contents of the files
+// in the trust store of the notation config directory and in the directories
+// of overrides, so that added, removed or rotated certificates are detected.
+func DigestTrustStore(overrides ...TrustStoreOverride) (digest.Digest, error) {
+	root, err := dir.ConfigFS().SysPath(dir.TrustStoreDir)
+	if err != nil {
+		return "", err
+	}
+	digester := digest.SHA256.Digester()
+	h := digester.Hash()
+	if err := digestDir(h, root); err != nil {
+		return "", err
+	}
+	for _, override := range overrides {
+		fmt.Fprintf(h, "override:%q:%q\n", override.Type, override.Name)
+		if err := digestDir(h, override.Dir); err != nil {
+			return "", err
+		}
+	}
+	return digester.Digest(), nil
+}
+
+// digestDir writes the names and the digests of the contents of the files in
+// root to h. A missing root is the same as an empty root.
+func digestDir(h hash.Hash, root string) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root && errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		switch {
+		case d.IsDir():
+			fmt.Fprintf(h, "dir:%q\n", rel)
+		case d.Type().IsRegular():
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(h, "file:%q:%s\n", rel, digest.FromBytes(content))
+		default:
+			// symlinks and other special files are not read by the trust
+			// store, only their presence is recorded
+			fmt.Fprintf(h, "other:%q\n", rel)
+		}
+		return nil
+	})
+}
+
+// memoTrustStore is an x509 trust store reading, parsing and validating the
+// certificates of each named store once, rather than once per signature
+// verified.
+type memoTrustStore struct {
+	base truststore.X509TrustStore
+
+	mu     sync.Mutex
+	stores map[string]*memoStore
+}
+
+// memoStore is the memoized result of reading a named store.
+type memoStore struct {
+	once  sync.Once
+	certs []*x509.Certificate
+	err   error
+}
+
+// newMemoTrustStore returns a trust store memoizing the named stores of base.
+func newMemoTrustStore(base truststore.X509TrustStore) *memoTrustStore {
+	return &memoTrustStore{
+		base:   base,
+		stores: make(map[string]*memoStore),
+	}
+}
+
+// GetCertificates returns the certificates of the named store, reading them
+// from base on first use.
+func (s *memoTrustStore) GetCertificates(ctx context.Context, storeType truststore.Type, namedStore string) ([]*x509.Certificate, error) {
+	key := string(storeType) + "/" + namedStore
+	s.mu.Lock()
+	store, ok := s.stores[key]
+	if !ok {
+		store = &memoStore{}
+		s.stores[key] = store
+	}
+	s.mu.Unlock()
+	store.once.Do(func() {
+		store.certs, store.err = s.base.GetCertificates(ctx, storeType, namedStore)
+	})
+	return store.certs, store.err
+}
@@ -0,0 +1,164 @@
+package policy
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/notation-go/dir"
+	"github.com/notaryproject/notation-go/verifier/truststore"
+)
+
+func TestParseTrustStoreOverride(t *testing.T) {
+	override, err := ParseTrustStoreOverride("ca:acme-rootcas=candidate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if override.Type != truststore.TypeCA || override.Name != "acme-rootcas" || !filepath.IsAbs(override.Dir) || filepath.Base(override.Dir) != "candidate" {
+		t.Fatalf("unexpected override: %+v", override)
+	}
+	for _, value := range []string{"ca:acme-rootcas", "acme-rootcas=dir", "ca:=dir", "ca:acme-rootcas=", "tsa:acme-rootcas=dir"} {
+		if _, err := ParseTrustStoreOverride(value); err == nil {
+			t.Fatalf("expected error for %q", value)
+		}
+	}
+}
+
+func TestOverrideFS(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "candidate root"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	storeDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(storeDir, "root.pem"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	configFS := dir.NewSysFS(t.TempDir())
+	if got := newOverrideFS(configFS, nil); got != configFS {
+		t.Fatal("expected the config directory to be returned without overrides")
+	}
+	trustStore := truststore.NewX509TrustStore(newOverrideFS(configFS, []TrustStoreOverride{{Type: truststore.TypeCA, Name: "acme-rootcas", Dir: storeDir}}))
+	certs, err := trustStore.GetCertificates(context.Background(), truststore.TypeCA, "acme-rootcas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(certs) != 1 || certs[0].Subject.CommonName != "candidate root" {
+		t.Fatalf("unexpected certificates: %v", certs)
+	}
+
+	// other trust stores are read from the config directory
+	if _, err := trustStore.GetCertificates(context.Background(), truststore.TypeSigningAuthority, "acme-rootcas"); err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Fatalf("expected missing trust store error, got %v", err)
+	}
+}
+
+// countingTrustStore counts the reads of named stores.
+type countingTrustStore struct {
+	reads int
+}
+
+func (s *countingTrustStore) GetCertificates(ctx context.Context, storeType truststore.Type, namedStore string) ([]*x509.Certificate, error) {
+	s.reads++
+	return []*x509.Certificate{{Raw: []byte(namedStore)}}, nil
+}
+
+func TestMemoTrustStore(t *testing.T) {
+	base := &countingTrustStore{}
+	store := newMemoTrustStore(base)
+	for i := 0; i < 3; i++ {
+		for _, name := range []string{"acme", "wabbit"} {
+			certs, err := store.GetCertificates(context.Background(), truststore.TypeCA, name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(certs) != 1 || string(certs[0].Raw) != name {
+				t.Fatalf("unexpected certificates of %s: %v", name, certs)
+			}
+		}
+	}
+	if base.reads != 2 {
+		t.Fatalf("expected each named store to be read once, got %d reads", base.reads)
+	}
+}
+
+func TestDigestTrustStore(t *testing.T) {
+	defer func(old string) { dir.UserConfigDir = old }(dir.UserConfigDir)
+	dir.UserConfigDir = t.TempDir()
+
+	empty, err := DigestTrustStore()
+	if err != nil {
+		t.Fatalf("DigestTrustStore() of missing trust store failed: %v", err)
+	}
+	storeDir := filepath.Join(dir.UserConfigDir, "truststore", "x509", "ca", "acme")
+	if err := os.MkdirAll(storeDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	certPath := filepath.Join(storeDir, "root.crt")
+	if err := os.WriteFile(certPath, []byte("v1"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	v1, err := DigestTrustStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(certPath, []byte("v2"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	v2, err := DigestTrustStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if empty == v1 || v1 == v2 {
+		t.Fatalf("expected distinct digests, got %s, %s and %s", empty, v1, v2)
+	}
+}
+
+func TestDigestTrustStore_Overrides(t *testing.T) {
+	defer func(old string) { dir.UserConfigDir = old }(dir.UserConfigDir)
+	dir.UserConfigDir = t.TempDir()
+
+	overrideDir := t.TempDir()
+	override := TrustStoreOverride{Type: truststore.TypeCA, Name: "acme", Dir: overrideDir}
+	base, err := DigestTrustStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	empty, err := DigestTrustStore(override)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(overrideDir, "root.crt"), []byte("v1"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	v1, err := DigestTrustStore(override)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if base == empty || empty == v1 {
+		t.Fatalf("expected distinct digests, got %s, %s and %s", base, empty, v1)
+	}
+}
@@ -0,0 +1,455 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation-go/dir"
+	"github.com/notaryproject/notation-go/log"
+	"github.com/notaryproject/notation-go/plugin"
+	"github.com/notaryproject/notation-go/verifier"
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+	"github.com/notaryproject/notation-go/verifier/truststore"
+	"github.com/notaryproject/notation/internal/chaincache"
+	"github.com/notaryproject/notation/internal/experimental"
+	"github.com/notaryproject/notation/internal/pluginproto"
+	"github.com/notaryproject/notation/internal/revocation"
+	"github.com/notaryproject/notation/internal/skipper"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// BaseVerifierFunc returns the notation.Verifier enforcing the statements of
+// the trust policy document defined by the Notary Project specification.
+type BaseVerifierFunc func(policyDoc *trustpolicy.Document) (notation.Verifier, error)
+
+// typedVerifier is the verifier of the artifacts of an artifact type.
+type typedVerifier struct {
+	base      notation.Verifier
+	policyDoc *trustpolicy.Document
+}
+
+// Verifier wraps a notation.Verifier and enforces the trust policy extensions
+// on top of the outcome of the wrapped verifier.
+//
+// If trust policy statements are scoped by artifact types, a wrapped verifier
+// is created per artifact type and the artifact type of the manifest
+// descriptor being verified selects the verifier. See Repository for setting
+// the artifact types of resolved manifest descriptors.
+type Verifier struct {
+	extDoc *Document
+
+	// levelNames are the names of the custom verification levels indexed by
+	// the names of the statements referencing them.
+	levelNames map[string]string
+
+	// clockSkews are the clock skew tolerances indexed by the names of the
+	// statements with a tolerance.
+	clockSkews map[string]clockSkew
+
+	// timestampPolicies are the timestamp trust stores indexed by the names
+	// of the statements with timestamp trust stores, which are read from
+	// timestampTrustStore.
+	timestampPolicies   map[string]timestampPolicy
+	timestampTrustStore truststore.X509TrustStore
+
+	// timestampTimeout bounds the validation of a timestamp against the
+	// timestamp trust stores.
+	timestampTimeout time.Duration
+
+	// timestamps are the timestamps of the signatures verified against the
+	// timestamp trust stores.
+	timestamps verifiedTimestamps
+
+	// verifiers are the verifiers indexed by artifact type. The verifier of
+	// the empty artifact type applies to all other artifact types.
+	verifiers map[string]typedVerifier
+
+	// signatureAnnotations are the annotations of the signature manifests
+	// recorded by the repositories returned by SignatureRepository.
+	signatureAnnotations signatureAnnotations
+
+	// pluginManager gets the installed verification plugins to check their
+	// versions against the minimum versions of the statements.
+	pluginManager plugin.Manager
+}
+
+// NewVerifier returns a Verifier enforcing the extensions in extDoc for the
+// statements of policyDoc. The wrapped verifiers are created by newBase.
+func NewVerifier(policyDoc *trustpolicy.Document, extDoc *Document, newBase BaseVerifierFunc) (*Verifier, error) {
+	if extDoc.usesVerificationLevels(policyDoc) && experimental.IsDisabled() {
+		for _, statement := range policyDoc.TrustPolicies {
+			if extDoc.getVerificationLevel(statement.SignatureVerification.VerificationLevel) != nil {
+				return nil, errorExperimental(statement.Name, "verificationLevels")
+			}
+		}
+	}
+	if experimental.IsDisabled() {
+		for _, statement := range extDoc.TrustPolicies {
+			if statement.ClockSkewTolerance != "" {
+				return nil, errorExperimental(statement.Name, "clockSkewTolerance")
+			}
+			if len(statement.SignatureAnnotations) > 0 {
+				return nil, errorExperimental(statement.Name, "signatureAnnotations")
+			}
+			if len(statement.PluginMinVersions) > 0 {
+				return nil, errorExperimental(statement.Name, "pluginMinVersions")
+			}
+			if len(statement.ExcludeScopes) > 0 {
+				return nil, errorExperimental(statement.Name, "excludeScopes")
+			}
+			if len(statement.TimestampTrustStores) > 0 {
+				return nil, errorExperimental(statement.Name, "timestampTrustStores")
+			}
+		}
+	}
+	if err := extDoc.validateExcludeScopeStatements(policyDoc); err != nil {
+		return nil, err
+	}
+	v := &Verifier{
+		extDoc:              extDoc,
+		levelNames:          extDoc.verificationLevelNames(policyDoc),
+		verifiers:           make(map[string]typedVerifier),
+		timestampTrustStore: tsaTrustStore{configFS: dir.ConfigFS()},
+		timestampTimeout:    DefaultTimestampTimeout,
+		pluginManager:       pluginproto.NewCLIManager(dir.PluginFS()),
+	}
+	policyDoc = extDoc.ResolveVerificationLevels(policyDoc)
+	// the timestamp trust stores are resolved first, so that the authentic
+	// timestamp check is not enforced with the clock skew tolerance but with
+	// the timestamp
+	policyDoc, v.timestampPolicies = extDoc.ResolveTimestampTrustStores(policyDoc)
+	policyDoc, v.clockSkews = extDoc.ResolveClockSkewTolerances(policyDoc)
+	for name, policy := range v.timestampPolicies {
+		if _, ok := v.levelNames[name]; !ok {
+			v.levelNames[name] = policy.levelName
+		}
+	}
+	for name, skew := range v.clockSkews {
+		if _, ok := v.levelNames[name]; !ok {
+			v.levelNames[name] = skew.levelName
+		}
+	}
+	artifactTypes := extDoc.artifactTypes()
+	if len(artifactTypes) == 0 {
+		base, err := newBase(policyDoc)
+		if err != nil {
+			return nil, err
+		}
+		v.verifiers[""] = typedVerifier{base: base, policyDoc: policyDoc}
+		return v, nil
+	}
+	if experimental.IsDisabled() {
+		for _, statement := range extDoc.TrustPolicies {
+			if len(statement.ArtifactTypes) > 0 {
+				return nil, errorExperimental(statement.Name, "artifactTypes")
+			}
+		}
+	}
+	for _, artifactType := range append([]string{""}, artifactTypes...) {
+		typedDoc := extDoc.ForArtifactType(policyDoc, artifactType)
+		if len(typedDoc.TrustPolicies) == 0 {
+			// no statement applies to the artifact type
+			continue
+		}
+		base, err := newBase(typedDoc)
+		if err != nil {
+			if artifactType == "" {
+				return nil, fmt.Errorf("invalid trust policy for artifacts of any other artifact type: %w", err)
+			}
+			return nil, fmt.Errorf("invalid trust policy for artifacts of artifact type %q: %w", artifactType, err)
+		}
+		v.verifiers[artifactType] = typedVerifier{base: base, policyDoc: typedDoc}
+	}
+	return v, nil
+}
+
+// ConfigOptions are the options of NewVerifierFromConfigOptions.
+type ConfigOptions struct {
+	// Name is the name of the trust policy document, see DocumentPath.
+	Name string
+
+	// TrustStoreOverrides are the named trust stores read from their
+	// directories instead of the config directory.
+	TrustStoreOverrides []TrustStoreOverride
+
+	// ClockSkewTolerance overrides the clock skew tolerances of the trust
+	// policy statements if not nil.
+	ClockSkewTolerance *time.Duration
+
+	// CRLCache caches the CRLs fetched by the revocation checks, if not nil.
+	CRLCache *revocation.CRLCache
+
+	// RevocationTimeout is the deadline of the revocation check of a
+	// certificate chain. revocation.DefaultTimeout is used if not positive.
+	RevocationTimeout time.Duration
+
+	// RevocationEndpointTimeout is the timeout of a single OCSP or CRL
+	// request. revocation.DefaultEndpointTimeout is used if not positive.
+	RevocationEndpointTimeout time.Duration
+
+	// TimestampTimeout bounds the validation of a timestamp against the
+	// timestamp trust stores. DefaultTimestampTimeout is used if not
+	// positive.
+	TimestampTimeout time.Duration
+
+	// PluginTimeout is the timeout of a verification plugin verifying a
+	// signature, unlimited if not positive.
+	PluginTimeout time.Duration
+
+	// RevocationBundle determines the revocation status of the certificates
+	// instead of their OCSP servers and CRL distribution points, if not nil.
+	RevocationBundle *revocation.Bundle
+}
+
+// NewVerifierFromConfig returns a Verifier enforcing the trust policy document
+// in the notation config directory with its extensions. The named trust stores
+// in overrides are read from their directories instead of the config
+// directory.
+func NewVerifierFromConfig(overrides ...TrustStoreOverride) (*Verifier, error) {
+	return NewVerifierFromConfigOptions(ConfigOptions{TrustStoreOverrides: overrides})
+}
+
+// NewNamedVerifierFromConfig is like NewVerifierFromConfig, but enforces the
+// trust policy document named name, see DocumentPath.
+func NewNamedVerifierFromConfig(name string, overrides ...TrustStoreOverride) (*Verifier, error) {
+	return NewVerifierFromConfigOptions(ConfigOptions{Name: name, TrustStoreOverrides: overrides})
+}
+
+// NewVerifierFromConfigOptions is like NewVerifierFromConfig with options.
+func NewVerifierFromConfigOptions(opts ConfigOptions) (*Verifier, error) {
+	policyDoc, extDoc, err := LoadNamedDocuments(opts.Name)
+	if err != nil {
+		return nil, err
+	}
+	if opts.ClockSkewTolerance != nil {
+		extDoc.OverrideClockSkewTolerance(*opts.ClockSkewTolerance)
+	}
+	var overrides []TrustStoreOverride
+	if len(extDoc.TrustBundles) > 0 {
+		if experimental.IsDisabled() {
+			return nil, errors.New("trust policy uses \"trustBundles\" which is experimental and not enabled by default. To use, please set NOTATION_EXPERIMENTAL=1 environment variable")
+		}
+		cacheDir, err := TrustBundleCacheDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to obtain the cache directory of trust bundles: %w", err)
+		}
+		overrides, err = ResolveTrustBundles(context.Background(), extDoc.TrustBundles, dir.ConfigFS(), http.DefaultClient, cacheDir)
+		if err != nil {
+			return nil, err
+		}
+	}
+	// the trust stores overridden for the verification take precedence over
+	// the trust bundles
+	overrides = append(overrides, opts.TrustStoreOverrides...)
+	trustStoreDigest, err := DigestTrustStore(overrides...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read trust store: %w", err)
+	}
+	// the trust store and the chains do not change within a run, so that
+	// they are read and checked once however many signatures are verified
+	configFS := newOverrideFS(dir.ConfigFS(), overrides)
+	trustStore := newMemoTrustStore(truststore.NewX509TrustStore(configFS))
+	pluginManager := pluginproto.NewCLIManager(dir.PluginFS())
+	pluginManager.VerifyTimeout = opts.PluginTimeout
+	revocationChecker := revocation.NewChecker(revocation.Options{
+		EndpointTimeout: opts.RevocationEndpointTimeout,
+		Timeout:         opts.RevocationTimeout,
+		CRLCache:        opts.CRLCache,
+		Bundle:          opts.RevocationBundle,
+	})
+	chainCache := chaincache.New[[]revocation.Result](trustStoreDigest.String(), 0)
+	v, err := NewVerifier(policyDoc, extDoc, func(policyDoc *trustpolicy.Document) (notation.Verifier, error) {
+		base, err := verifier.New(policyDoc, trustStore, pluginManager)
+		if err != nil {
+			return nil, err
+		}
+		if experimental.IsDisabled() {
+			// the revocation checks are experimental
+			return base, nil
+		}
+		return revocation.NewVerifier(base, revocationChecker, chainCache), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	v.timestampTrustStore = newMemoTrustStore(tsaTrustStore{configFS: configFS})
+	if opts.TimestampTimeout > 0 {
+		v.timestampTimeout = opts.TimestampTimeout
+	}
+	return v, nil
+}
+
+// UsesArtifactTypes returns true if the trust policy statements are scoped by
+// artifact types, i.e. the artifact types of the manifest descriptors being
+// verified must be set.
+func (v *Verifier) UsesArtifactTypes() bool {
+	return v.extDoc.HasArtifactTypes()
+}
+
+// SkipVerify validates whether the verification level is skip.
+//
+// If trust policy statements are scoped by artifact types, the artifact type
+// is not known yet and verification is only skipped if it is skipped for all
+// artifact types. Otherwise, Verify skips the verification per artifact type.
+func (v *Verifier) SkipVerify(ctx context.Context, opts notation.VerifierVerifyOptions) (bool, *trustpolicy.VerificationLevel, error) {
+	var level *trustpolicy.VerificationLevel
+	var firstErr error
+	for _, typed := range v.verifiers {
+		typedSkipper, ok := typed.base.(skipper.VerifySkipper)
+		if !ok {
+			return false, nil, nil
+		}
+		skip, typedLevel, err := typedSkipper.SkipVerify(ctx, opts)
+		if err == nil {
+			// the wrapped verifier selects statements regardless of the
+			// excluded scopes
+			_, err = v.applicableStatement(typed.policyDoc, opts.ArtifactReference)
+		}
+		if err != nil {
+			if len(v.verifiers) == 1 {
+				return false, nil, err
+			}
+			// no statement of the artifact type applies to the artifact
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if !skip {
+			return false, typedLevel, nil
+		}
+		level = typedLevel
+	}
+	if level == nil {
+		if firstErr == nil {
+			firstErr = notation.ErrorNoApplicableTrustPolicy{Msg: "no trust policy statement is found"}
+		}
+		return false, nil, firstErr
+	}
+	return true, level, nil
+}
+
+// Verify verifies the signature with the wrapped verifier and then checks the
+// trust policy extensions applicable to the artifact.
+func (v *Verifier) Verify(ctx context.Context, desc ocispec.Descriptor, signature []byte, opts notation.VerifierVerifyOptions) (*notation.VerificationOutcome, error) {
+	typed, ok := v.verifiers[desc.ArtifactType]
+	if !ok {
+		typed, ok = v.verifiers[""]
+	}
+	if !ok {
+		err := notation.ErrorNoApplicableTrustPolicy{Msg: fmt.Sprintf("no trust policy statement applies to artifacts of artifact type %q", desc.ArtifactType)}
+		return &notation.VerificationOutcome{RawSignature: signature, Error: err}, err
+	}
+	if v.UsesArtifactTypes() {
+		log.GetLogger(ctx).Infof("Verifying artifact %s of artifact type %q", desc.Digest, desc.ArtifactType)
+	}
+	statement, err := v.applicableStatement(typed.policyDoc, opts.ArtifactReference)
+	if err != nil {
+		return &notation.VerificationOutcome{RawSignature: signature, Error: err}, err
+	}
+	outcome, err := typed.base.Verify(ctx, desc, signature, opts)
+	if outcome != nil {
+		v.nameVerificationLevel(typed.policyDoc, opts.ArtifactReference, outcome)
+	}
+	if err != nil || outcome == nil || outcome.EnvelopeContent == nil {
+		return outcome, err
+	}
+	if err := setTimestampSignature(outcome); err != nil {
+		err = notation.ErrorVerificationFailed{Msg: fmt.Sprintf("malformed signature envelope: %v", err)}
+		outcome.Error = err
+		return outcome, err
+	}
+	now := time.Now()
+	if skew, ok := v.clockSkews[statement.Name]; ok {
+		if err := skew.verify(ctx, outcome, now); err != nil {
+			outcome.Error = err
+			return outcome, err
+		}
+	}
+	if err := v.verifyTimestamp(ctx, statement.Name, outcome, now); err != nil {
+		outcome.Error = err
+		return outcome, err
+	}
+	if err := v.verifyExtensions(ctx, v.extDoc.Get(statement.Name), outcome); err != nil {
+		outcome.Error = err
+		return outcome, err
+	}
+	return outcome, nil
+}
+
+// ClockSkewTolerance returns the clock skew tolerance of the statement
+// applicable to the artifact of desc, or 0 if none applies.
+func (v *Verifier) ClockSkewTolerance(desc ocispec.Descriptor, artifactReference string) time.Duration {
+	typed, ok := v.verifiers[desc.ArtifactType]
+	if !ok {
+		typed, ok = v.verifiers[""]
+	}
+	if !ok {
+		return 0
+	}
+	statement, err := v.applicableStatement(typed.policyDoc, artifactReference)
+	if err != nil {
+		return 0
+	}
+	return v.clockSkews[statement.Name].tolerance
+}
+
+// nameVerificationLevel names the verification level of outcome after the
+// custom verification level of the statement applicable to the artifact, as
+// the wrapped verifier reports custom levels as "custom".
+func (v *Verifier) nameVerificationLevel(policyDoc *trustpolicy.Document, artifactReference string, outcome *notation.VerificationOutcome) {
+	if len(v.levelNames) == 0 || outcome.VerificationLevel == nil {
+		return
+	}
+	statement, err := policyDoc.GetApplicableTrustPolicy(artifactReference)
+	if err != nil {
+		return
+	}
+	if name, ok := v.levelNames[statement.Name]; ok {
+		level := *outcome.VerificationLevel
+		level.Name = name
+		outcome.VerificationLevel = &level
+	}
+}
+
+// verifyExtensions checks the extension fields of the trust policy statement
+// against a successful verification outcome.
+func (v *Verifier) verifyExtensions(ctx context.Context, statement *TrustPolicy, outcome *notation.VerificationOutcome) error {
+	logger := log.GetLogger(ctx)
+
+	if len(statement.KeylessIdentities) > 0 {
+		if experimental.IsDisabled() {
+			return errorExperimental(statement.Name, "keylessIdentities")
+		}
+		certChain := outcome.EnvelopeContent.SignerInfo.CertificateChain
+		if len(certChain) == 0 {
+			return notation.ErrorVerificationFailed{Msg: "signature has no certificate chain"}
+		}
+		if err := verifyKeylessIdentity(certChain[0], statement.KeylessIdentities); err != nil {
+			return notation.ErrorVerificationFailed{Msg: err.Error()}
+		}
+		logger.Infof("Signing certificate matched a keyless identity of trust policy %q", statement.Name)
+	}
+
+	if len(statement.SignatureAnnotations) > 0 {
+		annotations, ok := v.signatureAnnotations.get(outcome.RawSignature)
+		if !ok {
+			return notation.ErrorVerificationFailed{Msg: fmt.Sprintf("trust policy %q has rules on the annotations of the signature manifests, but the signature manifest is unknown", statement.Name)}
+		}
+		if err := verifySignatureAnnotations(statement.SignatureAnnotations, annotations); err != nil {
+			return notation.ErrorVerificationFailed{Msg: err.Error()}
+		}
+		logger.Infof("Signature manifest annotations satisfied the rules of trust policy %q", statement.Name)
+	}
+
+	if len(statement.PluginMinVersions) > 0 {
+		if err := v.verifyPluginMinVersion(ctx, statement.Name, statement.PluginMinVersions, &outcome.EnvelopeContent.SignerInfo); err != nil {
+			return notation.ErrorVerificationFailed{Msg: err.Error()}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+//go:build go1.26
+
+package pqsig
+
+import (
+	"crypto/mldsa"
+	"fmt"
+)
+
+// GenerateKey generates an ML-DSA private key of the parameter set params.
+func GenerateKey(params string) (*PrivateKey, error) {
+	p, err := mldsaParameters(params)
+	if err != nil {
+		return nil, err
+	}
+	key, err := mldsa.GenerateKey(p)
+	if err != nil {
+		return nil, err
+	}
+	return &PrivateKey{Parameters: params, Seed: key.Bytes()}, nil
+}
+
+// Public returns the public key of key.
+func (key *PrivateKey) Public() (*PublicKey, error) {
+	sk, err := key.mldsaKey()
+	if err != nil {
+		return nil, err
+	}
+	return &PublicKey{Parameters: key.Parameters, Key: sk.PublicKey().Bytes()}, nil
+}
+
+// sign signs payload with key.
+func (key *PrivateKey) sign(payload []byte) ([]byte, error) {
+	sk, err := key.mldsaKey()
+	if err != nil {
+		return nil, err
+	}
+	return sk.Sign(nil, payload, &mldsa.Options{Context: signatureContext})
+}
+
+// verify verifies the signature of payload with key.
+func (key *PublicKey) verify(payload, signature []byte) error {
+	p, err := mldsaParameters(key.Parameters)
+	if err != nil {
+		return err
+	}
+	pk, err := mldsa.NewPublicKey(p, key.Key)
+	if err != nil {
+		return fmt.Errorf("invalid ML-DSA public key: %w", err)
+	}
+	return mldsa.Verify(pk, payload, signature, &mldsa.Options{Context: signatureContext})
+}
+
+func (key *PrivateKey) mldsaKey() (*mldsa.PrivateKey, error) {
+	p, err := mldsaParameters(key.Parameters)
+	if err != nil {
+		return nil, err
+	}
+	sk, err := mldsa.NewPrivateKey(p, key.Seed)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ML-DSA private key: %w", err)
+	}
+	return sk, nil
+}
+
+func mldsaParameters(params string) (mldsa.Parameters, error) {
+	switch params {
+	case "ML-DSA-44":
+		return mldsa.MLDSA44(), nil
+	case "ML-DSA-65":
+		return mldsa.MLDSA65(), nil
+	case "ML-DSA-87":
+		return mldsa.MLDSA87(), nil
+	}
+	return mldsa.Parameters{}, validateParameters(params)
+}
@@ -0,0 +1,23 @@
+//go:build !go1.26
+
+package pqsig
+
+// GenerateKey generates an ML-DSA private key of the parameter set params.
+func GenerateKey(params string) (*PrivateKey, error) {
+	return nil, ErrUnsupported
+}
+
+// Public returns the public key of key.
+func (key *PrivateKey) Public() (*PublicKey, error) {
+	return nil, ErrUnsupported
+}
+
+// sign signs payload with key.
+func (key *PrivateKey) sign(payload []byte) ([]byte, error) {
+	return nil, ErrUnsupported
+}
+
+// verify verifies the signature of payload with key.
+func (key *PublicKey) verify(payload, signature []byte) error {
+	return ErrUnsupported
+}
@@ -0,0 +1,150 @@
+// Package pqsig provides experimental post-quantum ML-DSA signatures, which
+// are produced alongside the classical signatures of notation. An ML-DSA
+// signature signs the same payload as the classical signature envelope and
+// is stored as a referrer of the signed artifact, so that existing verifiers
+// are not affected by it.
+package pqsig
+
+import (
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	"github.com/opencontainers/go-digest"
+)
+
+const (
+	// ArtifactType is the artifact type of the manifests of ML-DSA
+	// signatures.
+	ArtifactType = "application/vnd.cncf.notary.x-signature.mldsa"
+
+	// MediaTypePayload is the media type of the signed payload, which is the
+	// payload of the accompanying classical signature envelope.
+	MediaTypePayload = "application/vnd.cncf.notary.payload.v1+json"
+
+	// MediaTypeSignature is the media type of the raw ML-DSA signature.
+	MediaTypeSignature = "application/vnd.cncf.notary.x-mldsa-signature"
+
+	// AnnotationParameters is the manifest annotation key of the ML-DSA
+	// parameter set of the signature.
+	AnnotationParameters = "io.cncf.notary.x-mldsa.parameters"
+
+	// AnnotationKeyID is the manifest annotation key of the identifier of the
+	// public key verifying the signature.
+	AnnotationKeyID = "io.cncf.notary.x-mldsa.keyID"
+
+	// DefaultParameters is the default ML-DSA parameter set.
+	DefaultParameters = "ML-DSA-65"
+
+	// KeyExtension is the file extension of ML-DSA private keys.
+	KeyExtension = ".mldsa.pem"
+
+	// PublicKeyExtension is the file extension of ML-DSA public keys.
+	PublicKeyExtension = ".mldsa.pub.pem"
+)
+
+// signatureContext is the ML-DSA context string separating notation
+// signatures from signatures created for other purposes.
+const signatureContext = "notation"
+
+// PEM block types of the keys.
+const (
+	pemTypePrivateKey = "ML-DSA PRIVATE KEY"
+	pemTypePublicKey  = "ML-DSA PUBLIC KEY"
+	pemHeaderParams   = "Parameters"
+)
+
+// ErrUnsupported is returned if notation is built without ML-DSA support,
+// which requires Go 1.26 or later.
+var ErrUnsupported = errors.New("ML-DSA signatures are not supported by this build of notation")
+
+// ErrUntrustedKey is returned if a signature is not signed by a trusted key.
+var ErrUntrustedKey = errors.New("ML-DSA signature is not signed by a trusted key")
+
+// ErrMissingSignature is returned if a verified classical signature is not
+// accompanied by an ML-DSA signature.
+var ErrMissingSignature = errors.New("no ML-DSA signature accompanies the verified signature")
+
+// Parameters lists the supported ML-DSA parameter sets.
+var Parameters = []string{"ML-DSA-44", "ML-DSA-65", "ML-DSA-87"}
+
+// PrivateKey is an ML-DSA private key.
+type PrivateKey struct {
+	// Parameters is the name of the parameter set, such as "ML-DSA-65".
+	Parameters string
+
+	// Seed is the seed the key is derived from.
+	Seed []byte
+}
+
+// PublicKey is an ML-DSA public key.
+type PublicKey struct {
+	// Parameters is the name of the parameter set, such as "ML-DSA-65".
+	Parameters string
+
+	// Key is the encoding of the public key.
+	Key []byte
+}
+
+// ID returns the identifier of the public key, which is the digest of its
+// parameter set and encoding.
+func (k *PublicKey) ID() string {
+	return digest.FromBytes(append([]byte(k.Parameters+"\n"), k.Key...)).String()
+}
+
+// MarshalPrivateKey encodes key as PEM.
+func MarshalPrivateKey(key *PrivateKey) []byte {
+	return pem.EncodeToMemory(&pem.Block{
+		Type:    pemTypePrivateKey,
+		Headers: map[string]string{pemHeaderParams: key.Parameters},
+		Bytes:   key.Seed,
+	})
+}
+
+// ParsePrivateKey decodes a PEM encoded private key.
+func ParsePrivateKey(data []byte) (*PrivateKey, error) {
+	params, seed, err := decodePEM(data, pemTypePrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	return &PrivateKey{Parameters: params, Seed: seed}, nil
+}
+
+// MarshalPublicKey encodes key as PEM.
+func MarshalPublicKey(key *PublicKey) []byte {
+	return pem.EncodeToMemory(&pem.Block{
+		Type:    pemTypePublicKey,
+		Headers: map[string]string{pemHeaderParams: key.Parameters},
+		Bytes:   key.Key,
+	})
+}
+
+// ParsePublicKey decodes a PEM encoded public key.
+func ParsePublicKey(data []byte) (*PublicKey, error) {
+	params, key, err := decodePEM(data, pemTypePublicKey)
+	if err != nil {
+		return nil, err
+	}
+	return &PublicKey{Parameters: params, Key: key}, nil
+}
+
+func decodePEM(data []byte, blockType string) (string, []byte, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != blockType {
+		return "", nil, fmt.Errorf("no %s PEM block found", blockType)
+	}
+	params := block.Headers[pemHeaderParams]
+	if err := validateParameters(params); err != nil {
+		return "", nil, err
+	}
+	return params, block.Bytes, nil
+}
+
+func validateParameters(params string) error {
+	for _, p := range Parameters {
+		if p == params {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported ML-DSA parameter set %q, supported: %v", params, Parameters)
+}
@@ -0,0 +1,204 @@
+//go:build go1.26
+
+package pqsig
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/notation-core-go/signature/jws"
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation-go/signer"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/content/memory"
+)
+
+func newClassicalSigner(t *testing.T) notation.Signer {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test", Organization: []string{"Notary"}, Country: []string{"US"}, Province: []string{"WA"}, Locality: []string{"Seattle"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		BasicConstraintsValid: true,
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(certDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := signer.New(key, []*x509.Certificate{cert})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestKeyPEM(t *testing.T) {
+	key, err := GenerateKey(DefaultParameters)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := ParsePrivateKey(MarshalPrivateKey(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Parameters != key.Parameters || string(parsed.Seed) != string(key.Seed) {
+		t.Fatalf("ParsePrivateKey() = %+v, want %+v", parsed, key)
+	}
+	pub, err := key.Public()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsedPub, err := ParsePublicKey(MarshalPublicKey(pub))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsedPub.ID() != pub.ID() {
+		t.Fatalf("ParsePublicKey() ID = %s, want %s", parsedPub.ID(), pub.ID())
+	}
+	if _, err := ParsePrivateKey(MarshalPublicKey(pub)); err == nil {
+		t.Fatal("expected error parsing a public key as private key")
+	}
+	if _, err := GenerateKey("ML-DSA-1"); err == nil {
+		t.Fatal("expected error for unsupported parameter set")
+	}
+}
+
+func TestSignPushFetchVerify(t *testing.T) {
+	ctx := context.Background()
+	key, err := GenerateKey("ML-DSA-44")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := key.Public()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pqSigner, err := NewSigner(newClassicalSigner(t), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := memory.New()
+	subject := content.NewDescriptorFromBytes(ocispec.MediaTypeImageManifest, []byte(`{"schemaVersion":2}`))
+	opts := notation.SignerSignOptions{
+		SignatureMediaType: jws.MediaTypeEnvelope,
+		ExpiryDuration:     time.Hour,
+	}
+	if _, _, err := pqSigner.Sign(ctx, subject, opts); err != nil {
+		t.Fatal(err)
+	}
+	sig := pqSigner.Signature()
+	if sig == nil {
+		t.Fatal("expected ML-DSA signature, got nil")
+	}
+	if _, err := Push(ctx, store, subject, sig); err != nil {
+		t.Fatal(err)
+	}
+
+	signatures, err := Fetch(ctx, store, subject)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(signatures) != 1 {
+		t.Fatalf("expected 1 signature, got %d", len(signatures))
+	}
+	fetched := signatures[0]
+	if string(fetched.Payload) != string(sig.Payload) || fetched.KeyID != pub.ID() {
+		t.Fatalf("fetched signature %+v does not match pushed signature %+v", fetched, sig)
+	}
+	if err := fetched.Verify([]*PublicKey{pub}); err != nil {
+		t.Fatalf("Verify() failed: %v", err)
+	}
+
+	other, err := GenerateKey("ML-DSA-44")
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherPub, err := other.Public()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fetched.Verify([]*PublicKey{otherPub}); !errors.Is(err, ErrUntrustedKey) {
+		t.Fatalf("expected ErrUntrustedKey, got %v", err)
+	}
+
+	fetched.Payload = append([]byte(nil), fetched.Payload...)
+	fetched.Payload[0] ^= 0xff
+	if err := fetched.Verify([]*PublicKey{pub}); err == nil || errors.Is(err, ErrUntrustedKey) {
+		t.Fatalf("expected verification failure of tampered payload, got %v", err)
+	}
+}
+
+func TestVerifyPayloads(t *testing.T) {
+	key, err := GenerateKey("ML-DSA-44")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := key.Public()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := GenerateKey("ML-DSA-44")
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherPub, err := other.Public()
+	if err != nil {
+		t.Fatal(err)
+	}
+	newSignature := func(key *PrivateKey, payload []byte) *Signature {
+		pqSigner, err := NewSigner(newClassicalSigner(t), key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		subject := content.NewDescriptorFromBytes(ocispec.MediaTypeImageManifest, payload)
+		opts := notation.SignerSignOptions{
+			SignatureMediaType: jws.MediaTypeEnvelope,
+			ExpiryDuration:     time.Hour,
+		}
+		if _, _, err := pqSigner.Sign(context.Background(), subject, opts); err != nil {
+			t.Fatal(err)
+		}
+		return pqSigner.Signature()
+	}
+	sig := newSignature(key, []byte(`{"schemaVersion":2}`))
+	otherSig := newSignature(key, []byte(`{"schemaVersion":2,"mediaType":"other"}`))
+	payloads := [][]byte{sig.Payload}
+
+	verified, err := VerifyPayloads([]*Signature{otherSig, sig}, []*PublicKey{pub}, payloads)
+	if err != nil {
+		t.Fatalf("VerifyPayloads() error = %v", err)
+	}
+	if len(verified) != 1 || verified[0] != sig {
+		t.Fatalf("expected the signature of the payload to be verified, got %v", verified)
+	}
+
+	// the ML-DSA signature is stripped
+	if _, err := VerifyPayloads([]*Signature{otherSig}, []*PublicKey{pub}, payloads); !errors.Is(err, ErrMissingSignature) {
+		t.Fatalf("expected ErrMissingSignature, got %v", err)
+	}
+
+	// the ML-DSA signature is signed by another key
+	if _, err := VerifyPayloads([]*Signature{sig}, []*PublicKey{otherPub}, payloads); !errors.Is(err, ErrUntrustedKey) {
+		t.Fatalf("expected ErrUntrustedKey, got %v", err)
+	}
+}
@@ -0,0 +1,187 @@
+package pqsig
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/errdef"
+)
+
+// maxBlobSize is the maximum size of the manifests, payloads and signatures
+// fetched from storage.
+const maxBlobSize = 4 * 1024 * 1024
+
+// Storage is the storage of the artifacts and their ML-DSA signatures, such as
+// a remote repository or an OCI layout.
+type Storage interface {
+	content.Storage
+	content.PredecessorFinder
+}
+
+// Signature is an ML-DSA signature of the payload of a classical signature
+// envelope.
+type Signature struct {
+	// Descriptor is the descriptor of the signature manifest. It is set for
+	// signatures fetched from storage.
+	Descriptor ocispec.Descriptor
+
+	// Parameters is the name of the parameter set of the signing key.
+	Parameters string
+
+	// KeyID is the identifier of the public key verifying the signature.
+	KeyID string
+
+	// Payload is the signed payload.
+	Payload []byte
+
+	// Signature is the raw ML-DSA signature.
+	Signature []byte
+}
+
+// Verify verifies the signature with the key of trustedKeys matching its key
+// identifier. ErrUntrustedKey is returned if no trusted key matches.
+func (sig *Signature) Verify(trustedKeys []*PublicKey) error {
+	for _, key := range trustedKeys {
+		if key.ID() != sig.KeyID || key.Parameters != sig.Parameters {
+			continue
+		}
+		if err := key.verify(sig.Payload, sig.Signature); err != nil {
+			return fmt.Errorf("ML-DSA signature verification failed: %w", err)
+		}
+		return nil
+	}
+	return ErrUntrustedKey
+}
+
+// VerifyPayloads verifies that each of payloads, the payloads of verified
+// classical signatures, is accompanied by a signature of signatures signed by
+// a key of trustedKeys, and returns the signatures verified. Signatures of
+// other payloads are ignored. ErrMissingSignature is returned if a payload has
+// no signature, and ErrUntrustedKey if a signature of a payload is not signed
+// by a trusted key, so that stripping or replacing the ML-DSA signature of a
+// payload fails the verification.
+func VerifyPayloads(signatures []*Signature, trustedKeys []*PublicKey, payloads [][]byte) ([]*Signature, error) {
+	var verified []*Signature
+	for _, payload := range payloads {
+		var found bool
+		for _, sig := range signatures {
+			if !bytes.Equal(sig.Payload, payload) {
+				continue
+			}
+			if err := sig.Verify(trustedKeys); err != nil {
+				if errors.Is(err, ErrUntrustedKey) {
+					return nil, fmt.Errorf("%w: %s of key %s", err, sig.Descriptor.Digest, sig.KeyID)
+				}
+				return nil, fmt.Errorf("%s: %w", sig.Descriptor.Digest, err)
+			}
+			verified = append(verified, sig)
+			found = true
+		}
+		if !found {
+			return nil, ErrMissingSignature
+		}
+	}
+	return verified, nil
+}
+
+// Push pushes sig to storage as a referrer of subject.
+func Push(ctx context.Context, storage content.Pusher, subject ocispec.Descriptor, sig *Signature) (ocispec.Descriptor, error) {
+	payloadDesc := content.NewDescriptorFromBytes(MediaTypePayload, sig.Payload)
+	signatureDesc := content.NewDescriptorFromBytes(MediaTypeSignature, sig.Signature)
+	for _, blob := range []struct {
+		desc ocispec.Descriptor
+		data []byte
+	}{
+		{payloadDesc, sig.Payload},
+		{signatureDesc, sig.Signature},
+	} {
+		if err := storage.Push(ctx, blob.desc, bytes.NewReader(blob.data)); err != nil && !errors.Is(err, errdef.ErrAlreadyExists) {
+			return ocispec.Descriptor{}, fmt.Errorf("failed to push ML-DSA signature: %w", err)
+		}
+	}
+	desc, err := oras.Pack(ctx, storage, ArtifactType, []ocispec.Descriptor{payloadDesc, signatureDesc}, oras.PackOptions{
+		Subject: &subject,
+		ManifestAnnotations: map[string]string{
+			AnnotationParameters: sig.Parameters,
+			AnnotationKeyID:      sig.KeyID,
+		},
+		PackImageManifest: true,
+	})
+	if err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("failed to push ML-DSA signature: %w", err)
+	}
+	return desc, nil
+}
+
+// Fetch fetches the ML-DSA signatures stored as referrers of subject.
+func Fetch(ctx context.Context, storage Storage, subject ocispec.Descriptor) ([]*Signature, error) {
+	referrers, err := storage.Predecessors(ctx, subject)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the referrers of %s: %w", subject.Digest, err)
+	}
+	var signatures []*Signature
+	for _, desc := range referrers {
+		if desc.MediaType != ocispec.MediaTypeImageManifest || (desc.ArtifactType != "" && desc.ArtifactType != ArtifactType) {
+			continue
+		}
+		sig, err := fetchSignature(ctx, storage, subject, desc)
+		if err != nil {
+			return nil, err
+		}
+		if sig != nil {
+			signatures = append(signatures, sig)
+		}
+	}
+	return signatures, nil
+}
+
+// fetchSignature fetches the ML-DSA signature of the manifest desc. It returns
+// nil if desc is not the manifest of an ML-DSA signature of subject.
+func fetchSignature(ctx context.Context, storage content.Fetcher, subject, desc ocispec.Descriptor) (*Signature, error) {
+	manifestJSON, err := fetchAll(ctx, storage, desc)
+	if err != nil {
+		return nil, err
+	}
+	var manifest ocispec.Manifest
+	if err := json.Unmarshal(manifestJSON, &manifest); err != nil {
+		return nil, fmt.Errorf("malformed ML-DSA signature manifest %s: %w", desc.Digest, err)
+	}
+	if manifest.Config.MediaType != ArtifactType || manifest.Subject == nil || manifest.Subject.Digest != subject.Digest {
+		return nil, nil
+	}
+	if len(manifest.Layers) != 2 || manifest.Layers[0].MediaType != MediaTypePayload || manifest.Layers[1].MediaType != MediaTypeSignature {
+		return nil, fmt.Errorf("malformed ML-DSA signature manifest %s: unexpected layers", desc.Digest)
+	}
+	payload, err := fetchAll(ctx, storage, manifest.Layers[0])
+	if err != nil {
+		return nil, err
+	}
+	signature, err := fetchAll(ctx, storage, manifest.Layers[1])
+	if err != nil {
+		return nil, err
+	}
+	return &Signature{
+		Descriptor: desc,
+		Parameters: manifest.Annotations[AnnotationParameters],
+		KeyID:      manifest.Annotations[AnnotationKeyID],
+		Payload:    payload,
+		Signature:  signature,
+	}, nil
+}
+
+func fetchAll(ctx context.Context, storage content.Fetcher, desc ocispec.Descriptor) ([]byte, error) {
+	if desc.Size > maxBlobSize {
+		return nil, fmt.Errorf("content %s of size %d exceeds the limit of %d bytes", desc.Digest, desc.Size, maxBlobSize)
+	}
+	data, err := content.FetchAll(ctx, storage, desc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", desc.Digest, err)
+	}
+	return data, nil
+}
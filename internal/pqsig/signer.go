@@ -0,0 +1,75 @@
+package pqsig
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/notaryproject/notation-core-go/signature"
+	"github.com/notaryproject/notation-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// Signer wraps a notation.Signer and signs the payload of the signature
+// envelope produced by the wrapped signer with an ML-DSA key.
+type Signer struct {
+	base notation.Signer
+	key  *PrivateKey
+	pub  *PublicKey
+
+	mu        sync.Mutex
+	signature *Signature
+}
+
+// NewSigner returns a Signer producing ML-DSA signatures with key alongside
+// the signatures of base.
+func NewSigner(base notation.Signer, key *PrivateKey) (*Signer, error) {
+	pub, err := key.Public()
+	if err != nil {
+		return nil, err
+	}
+	return &Signer{
+		base: base,
+		key:  key,
+		pub:  pub,
+	}, nil
+}
+
+// Sign signs the artifact with the wrapped signer and then signs the payload
+// of the returned envelope with the ML-DSA key.
+func (s *Signer) Sign(ctx context.Context, desc ocispec.Descriptor, opts notation.SignerSignOptions) ([]byte, *signature.SignerInfo, error) {
+	sig, signerInfo, err := s.base.Sign(ctx, desc, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	env, err := signature.ParseEnvelope(opts.SignatureMediaType, sig)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse the classical signature envelope: %w", err)
+	}
+	content, err := env.Content()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse the classical signature envelope: %w", err)
+	}
+	payload := content.Payload.Content
+	pqSig, err := s.key.sign(payload)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate ML-DSA signature: %w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.signature = &Signature{
+		Parameters: s.key.Parameters,
+		KeyID:      s.pub.ID(),
+		Payload:    payload,
+		Signature:  pqSig,
+	}
+	return sig, signerInfo, nil
+}
+
+// Signature returns the ML-DSA signature produced by the last call of Sign,
+// or nil if Sign has not succeeded.
+func (s *Signer) Signature() *Signature {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.signature
+}
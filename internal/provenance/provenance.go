@@ -0,0 +1,177 @@
+// Package provenance records how a signature was produced, i.e. the notation
+// version, the signing plugin and the CI environment, in the signed payload of
+// the signature to support forensic analysis.
+package provenance
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/notaryproject/notation/internal/version"
+)
+
+// AnnotationPrefix is the prefix of the keys of the provenance annotations in
+// the signed payload. The keys are outside of the "io.cncf.notary" prefix
+// reserved by notation-go for user metadata.
+const AnnotationPrefix = "org.notaryproject.notation.provenance."
+
+// Annotation keys of the provenance.
+const (
+	AnnotationVersion       = AnnotationPrefix + "version"
+	AnnotationCommit        = AnnotationPrefix + "commit"
+	AnnotationGoVersion     = AnnotationPrefix + "goVersion"
+	AnnotationPlugin        = AnnotationPrefix + "plugin"
+	AnnotationPluginVersion = AnnotationPrefix + "pluginVersion"
+	AnnotationCI            = AnnotationPrefix + "ci"
+	AnnotationCIFingerprint = AnnotationPrefix + "ciFingerprint"
+)
+
+// ciSystem is a CI system detected by an environment variable, and
+// fingerprinted by the variables identifying the pipeline run.
+type ciSystem struct {
+	name     string
+	detect   string
+	identity []string
+}
+
+// ciSystems lists the detected CI systems in the order of precedence.
+var ciSystems = []ciSystem{
+	{name: "github-actions", detect: "GITHUB_ACTIONS", identity: []string{"GITHUB_SERVER_URL", "GITHUB_REPOSITORY", "GITHUB_WORKFLOW_REF", "GITHUB_RUN_ID", "GITHUB_RUN_ATTEMPT", "GITHUB_SHA"}},
+	{name: "gitlab-ci", detect: "GITLAB_CI", identity: []string{"CI_SERVER_URL", "CI_PROJECT_PATH", "CI_PIPELINE_ID", "CI_JOB_ID", "CI_COMMIT_SHA"}},
+	{name: "azure-pipelines", detect: "TF_BUILD", identity: []string{"SYSTEM_COLLECTIONURI", "BUILD_REPOSITORY_NAME", "BUILD_BUILDID", "BUILD_SOURCEVERSION"}},
+	{name: "jenkins", detect: "JENKINS_URL", identity: []string{"JENKINS_URL", "JOB_NAME", "BUILD_NUMBER", "GIT_COMMIT"}},
+	{name: "circleci", detect: "CIRCLECI", identity: []string{"CIRCLE_PROJECT_USERNAME", "CIRCLE_PROJECT_REPONAME", "CIRCLE_WORKFLOW_ID", "CIRCLE_BUILD_NUM", "CIRCLE_SHA1"}},
+	{name: "generic", detect: "CI"},
+}
+
+// Provenance is the toolchain a signature is produced by.
+type Provenance struct {
+	// Version is the notation version.
+	Version string
+
+	// Commit is the git commit notation is built from, if known.
+	Commit string
+
+	// GoVersion is the version of the Go toolchain notation is built with.
+	GoVersion string
+
+	// Plugin is the name of the signing plugin, empty for local keys.
+	Plugin string
+
+	// PluginVersion is the version of the signing plugin.
+	PluginVersion string
+
+	// CI is the name of the detected CI system, empty outside of CI.
+	CI string
+
+	// CIFingerprint is the SHA-256 digest of the environment variables
+	// identifying the CI pipeline run, so that the run is matched without
+	// disclosing its details in the signature.
+	CIFingerprint string
+}
+
+// Collect returns the provenance of the running notation with the signing
+// plugin. lookupEnv looks up environment variables to detect the CI system,
+// e.g. os.LookupEnv.
+func Collect(plugin, pluginVersion string, lookupEnv func(string) (string, bool)) Provenance {
+	p := Provenance{
+		Version:       version.GetVersion(),
+		Commit:        version.GitCommit,
+		GoVersion:     runtime.Version(),
+		Plugin:        plugin,
+		PluginVersion: pluginVersion,
+	}
+	for _, system := range ciSystems {
+		if value, ok := lookupEnv(system.detect); !ok || value == "" || strings.EqualFold(value, "false") {
+			continue
+		}
+		p.CI = system.name
+		if len(system.identity) > 0 {
+			h := sha256.New()
+			for _, name := range system.identity {
+				value, _ := lookupEnv(name)
+				fmt.Fprintf(h, "%s=%s\n", name, value)
+			}
+			p.CIFingerprint = "sha256:" + hex.EncodeToString(h.Sum(nil))
+		}
+		break
+	}
+	return p
+}
+
+// Annotations returns the provenance as annotations of the signed payload.
+// Empty fields are omitted.
+func (p Provenance) Annotations() map[string]string {
+	annotations := make(map[string]string)
+	for key, value := range map[string]string{
+		AnnotationVersion:       p.Version,
+		AnnotationCommit:        p.Commit,
+		AnnotationGoVersion:     p.GoVersion,
+		AnnotationPlugin:        p.Plugin,
+		AnnotationPluginVersion: p.PluginVersion,
+		AnnotationCI:            p.CI,
+		AnnotationCIFingerprint: p.CIFingerprint,
+	} {
+		if value != "" {
+			annotations[key] = value
+		}
+	}
+	return annotations
+}
+
+// CheckUserMetadata returns an error if the user metadata sets any key with
+// AnnotationPrefix, so that provenance annotations are only recorded by
+// notation itself.
+func CheckUserMetadata(userMetadata map[string]string) error {
+	var conflicts []string
+	for key := range userMetadata {
+		if strings.HasPrefix(key, AnnotationPrefix) {
+			conflicts = append(conflicts, key)
+		}
+	}
+	if len(conflicts) > 0 {
+		sort.Strings(conflicts)
+		return fmt.Errorf("user metadata keys %s have the prefix %q reserved for the provenance", strings.Join(conflicts, ", "), AnnotationPrefix)
+	}
+	return nil
+}
+
+// Merge adds the provenance annotations to the user metadata, which is
+// checked by CheckUserMetadata.
+func Merge(userMetadata map[string]string, p Provenance) (map[string]string, error) {
+	if err := CheckUserMetadata(userMetadata); err != nil {
+		return nil, err
+	}
+	merged := make(map[string]string, len(userMetadata))
+	for key, value := range userMetadata {
+		merged[key] = value
+	}
+	for key, value := range p.Annotations() {
+		merged[key] = value
+	}
+	return merged, nil
+}
+
+// Split splits the annotations of a signed payload into the provenance
+// annotations and the other user defined annotations. nil is returned for an
+// empty part.
+func Split(annotations map[string]string) (provenance, userDefined map[string]string) {
+	for key, value := range annotations {
+		if strings.HasPrefix(key, AnnotationPrefix) {
+			if provenance == nil {
+				provenance = make(map[string]string)
+			}
+			provenance[key] = value
+			continue
+		}
+		if userDefined == nil {
+			userDefined = make(map[string]string)
+		}
+		userDefined[key] = value
+	}
+	return provenance, userDefined
+}
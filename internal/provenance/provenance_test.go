@@ -0,0 +1,71 @@
+package provenance
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func lookupEnv(env map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+}
+
+func TestCollect(t *testing.T) {
+	p := Collect("", "", lookupEnv(nil))
+	if p.Version == "" || p.GoVersion != runtime.Version() || p.CI != "" || p.CIFingerprint != "" {
+		t.Fatalf("unexpected provenance outside of CI: %+v", p)
+	}
+
+	env := map[string]string{
+		"CI":                "true",
+		"GITHUB_ACTIONS":    "true",
+		"GITHUB_REPOSITORY": "wabbit-networks/net-monitor",
+		"GITHUB_RUN_ID":     "42",
+	}
+	p = Collect("com.example.plugin", "1.2.3", lookupEnv(env))
+	if p.CI != "github-actions" || !strings.HasPrefix(p.CIFingerprint, "sha256:") || p.Plugin != "com.example.plugin" || p.PluginVersion != "1.2.3" {
+		t.Fatalf("unexpected provenance in GitHub Actions: %+v", p)
+	}
+	env["GITHUB_RUN_ID"] = "43"
+	if other := Collect("", "", lookupEnv(env)); other.CIFingerprint == p.CIFingerprint {
+		t.Fatal("expected the fingerprint to change with the run")
+	}
+
+	p = Collect("", "", lookupEnv(map[string]string{"CI": "1"}))
+	if p.CI != "generic" || p.CIFingerprint != "" {
+		t.Fatalf("unexpected provenance in a generic CI: %+v", p)
+	}
+	p = Collect("", "", lookupEnv(map[string]string{"CI": "false"}))
+	if p.CI != "" {
+		t.Fatalf("unexpected provenance with CI disabled: %+v", p)
+	}
+}
+
+func TestMergeSplit(t *testing.T) {
+	p := Provenance{Version: "v1.0.0", GoVersion: "go1.20", CI: "gitlab-ci"}
+	merged, err := Merge(map[string]string{"buildId": "101"}, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(merged) != 4 || merged[AnnotationVersion] != "v1.0.0" || merged[AnnotationCI] != "gitlab-ci" {
+		t.Fatalf("unexpected merged metadata: %v", merged)
+	}
+	if _, ok := merged[AnnotationPlugin]; ok {
+		t.Fatal("expected empty fields to be omitted")
+	}
+
+	provenance, userDefined := Split(merged)
+	if len(provenance) != 3 || len(userDefined) != 1 || userDefined["buildId"] != "101" {
+		t.Fatalf("unexpected split: %v, %v", provenance, userDefined)
+	}
+	if provenance, _ := Split(map[string]string{"buildId": "101"}); provenance != nil {
+		t.Fatalf("expected no provenance, got %v", provenance)
+	}
+
+	if _, err := Merge(map[string]string{AnnotationCI: "forged"}, p); err == nil {
+		t.Fatal("expected error for user metadata with the provenance prefix")
+	}
+}
@@ -0,0 +1,104 @@
+// Package retry retries fetching signatures failing transiently, so that a
+// single flaky response of a registry does not fail an otherwise healthy
+// verification.
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/notaryproject/notation-go/log"
+	notationregistry "github.com/notaryproject/notation-go/registry"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/registry/remote/errcode"
+)
+
+// DefaultMaxRetries is the number of times a signature is fetched again by
+// default after failing transiently.
+const DefaultMaxRetries = 2
+
+// backoff is the wait before the first retry, doubled for every retry after
+// it, for unit test.
+var backoff = 500 * time.Millisecond
+
+// Repository wraps a notationregistry.Repository and fetches the signature
+// manifests and blobs again when they fail transiently, see IsTransient.
+type Repository struct {
+	notationregistry.Repository
+	maxRetries int
+
+	mu      sync.Mutex
+	retries map[digest.Digest]int
+}
+
+// NewRepository returns a Repository fetching a signature up to maxRetries
+// times again after failing transiently. The signatures are fetched once if
+// maxRetries is not positive.
+func NewRepository(repo notationregistry.Repository, maxRetries int) *Repository {
+	return &Repository{
+		Repository: repo,
+		maxRetries: maxRetries,
+		retries:    make(map[digest.Digest]int),
+	}
+}
+
+// Retries returns the number of times the signature of the signature manifest
+// of dgst was fetched again.
+func (r *Repository) Retries(dgst digest.Digest) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.retries[dgst]
+}
+
+// FetchSignatureBlob returns the signature envelope blob and descriptor of the
+// signature manifest desc, fetching them again with an exponential backoff
+// while they fail transiently.
+func (r *Repository) FetchSignatureBlob(ctx context.Context, desc ocispec.Descriptor) ([]byte, ocispec.Descriptor, error) {
+	logger := log.GetLogger(ctx)
+	wait := backoff
+	for attempt := 0; ; attempt++ {
+		blob, blobDesc, err := r.Repository.FetchSignatureBlob(ctx, desc)
+		if err == nil || attempt >= r.maxRetries || ctx.Err() != nil || !IsTransient(err) {
+			if err != nil && attempt > 0 {
+				err = fmt.Errorf("failed after %d retries: %w", attempt, err)
+			}
+			return blob, blobDesc, err
+		}
+		logger.Debugf("Fetching signature %v failed transiently, retrying in %v (%d/%d): %v", desc.Digest, wait, attempt+1, r.maxRetries, err)
+		r.mu.Lock()
+		r.retries[desc.Digest]++
+		r.mu.Unlock()
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ocispec.Descriptor{}, fmt.Errorf("failed after %d retries: %w", attempt, err)
+		}
+		wait *= 2
+	}
+}
+
+// IsTransient reports whether err is a server error or a timeout, which may
+// not recur when the request is sent again.
+func IsTransient(err error) bool {
+	var errResp *errcode.ErrorResponse
+	if errors.As(err, &errResp) {
+		return errResp.StatusCode >= http.StatusInternalServerError
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, os.ErrDeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// compile time check
+var _ notationregistry.Repository = (*Repository)(nil)
@@ -0,0 +1,96 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	notationregistry "github.com/notaryproject/notation-go/registry"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/registry/remote/errcode"
+)
+
+var signatureManifest = ocispec.Descriptor{MediaType: ocispec.MediaTypeImageManifest, Digest: digest.FromString("signature"), Size: 9}
+
+// flakyRepository fails fetching the signature blob with the errors in order,
+// then succeeds.
+type flakyRepository struct {
+	notationregistry.Repository
+	errs    []error
+	fetches int
+}
+
+func (r *flakyRepository) FetchSignatureBlob(ctx context.Context, desc ocispec.Descriptor) ([]byte, ocispec.Descriptor, error) {
+	r.fetches++
+	if r.fetches <= len(r.errs) {
+		return nil, ocispec.Descriptor{}, r.errs[r.fetches-1]
+	}
+	blob := []byte("envelope")
+	return blob, ocispec.Descriptor{MediaType: "application/jose+json", Digest: digest.FromBytes(blob), Size: int64(len(blob))}, nil
+}
+
+func serverError(statusCode int) error {
+	return fmt.Errorf("failed to fetch blob: %w", &errcode.ErrorResponse{Method: http.MethodGet, StatusCode: statusCode})
+}
+
+func TestFetchSignatureBlob(t *testing.T) {
+	defer func(oldBackoff time.Duration) { backoff = oldBackoff }(backoff)
+	backoff = time.Millisecond
+
+	tests := []struct {
+		name        string
+		maxRetries  int
+		errs        []error
+		wantFetches int
+		wantRetries int
+		wantErr     string
+	}{
+		{name: "no failure", maxRetries: 2, wantFetches: 1},
+		{name: "server errors recovered", maxRetries: 2, errs: []error{serverError(http.StatusBadGateway), os.ErrDeadlineExceeded}, wantFetches: 3, wantRetries: 2},
+		{name: "server errors exhausted", maxRetries: 2, errs: []error{serverError(http.StatusServiceUnavailable), serverError(http.StatusServiceUnavailable), serverError(http.StatusServiceUnavailable)}, wantFetches: 3, wantRetries: 2, wantErr: "failed after 2 retries"},
+		{name: "client error not retried", maxRetries: 2, errs: []error{serverError(http.StatusNotFound)}, wantFetches: 1, wantErr: "404"},
+		{name: "other error not retried", maxRetries: 2, errs: []error{errors.New("digest mismatch")}, wantFetches: 1, wantErr: "digest mismatch"},
+		{name: "retries disabled", errs: []error{serverError(http.StatusInternalServerError)}, wantFetches: 1, wantErr: "500"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := &flakyRepository{errs: tt.errs}
+			repo := NewRepository(base, tt.maxRetries)
+			_, _, err := repo.FetchSignatureBlob(context.Background(), signatureManifest)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("FetchSignatureBlob() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("FetchSignatureBlob() error = %v, want %q", err, tt.wantErr)
+			}
+			if base.fetches != tt.wantFetches {
+				t.Errorf("fetched %d times, want %d", base.fetches, tt.wantFetches)
+			}
+			if got := repo.Retries(signatureManifest.Digest); got != tt.wantRetries {
+				t.Errorf("Retries() = %d, want %d", got, tt.wantRetries)
+			}
+		})
+	}
+}
+
+func TestFetchSignatureBlob_Canceled(t *testing.T) {
+	defer func(oldBackoff time.Duration) { backoff = oldBackoff }(backoff)
+	backoff = time.Hour
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	base := &flakyRepository{errs: []error{serverError(http.StatusBadGateway)}}
+	_, _, err := NewRepository(base, 2).FetchSignatureBlob(ctx, signatureManifest)
+	if err == nil || !strings.Contains(err.Error(), "502") {
+		t.Fatalf("expected the server error, got %v", err)
+	}
+	if base.fetches != 1 {
+		t.Fatalf("expected no retry after the context is done, fetched %d times", base.fetches)
+	}
+}
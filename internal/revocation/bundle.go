@@ -0,0 +1,142 @@
+package revocation
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+const (
+	// BundleCRLExt is the extension of the CRL files of a revocation bundle,
+	// in DER or PEM.
+	BundleCRLExt = ".crl"
+
+	// BundleOCSPExt is the extension of the OCSP response files of a
+	// revocation bundle, in DER.
+	BundleOCSPExt = ".ocsp"
+)
+
+// Bundle is a set of CRLs and OCSP responses downloaded in advance, which
+// determines the revocation status of certificates instead of their OCSP
+// servers and CRL distribution points, so that revocation is checked in
+// network-isolated environments without any outbound request.
+type Bundle struct {
+	dir           string
+	crls          []bundleCRL
+	ocspResponses []bundleOCSPResponse
+}
+
+// bundleCRL is a CRL of a revocation bundle.
+type bundleCRL struct {
+	name string
+	crl  *x509.RevocationList
+}
+
+// bundleOCSPResponse is an OCSP response of a revocation bundle.
+type bundleOCSPResponse struct {
+	name string
+	raw  []byte
+}
+
+// LoadBundle loads the revocation bundle in dir, whose files with the
+// extension ".crl" are CRLs in DER or PEM, and whose files with the extension
+// ".ocsp" are DER-encoded OCSP responses. Other files are ignored. The CRLs
+// and OCSP responses are checked against the issuers of the certificates when
+// used.
+func LoadBundle(dir string) (*Bundle, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read revocation bundle: %w", err)
+	}
+	b := &Bundle{dir: dir}
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		name := entry.Name()
+		ext := strings.ToLower(filepath.Ext(name))
+		if ext != BundleCRLExt && ext != BundleOCSPExt {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read revocation bundle: %w", err)
+		}
+		switch ext {
+		case BundleCRLExt:
+			if block, _ := pem.Decode(data); block != nil {
+				data = block.Bytes
+			}
+			crl, err := x509.ParseRevocationList(data)
+			if err != nil {
+				return nil, fmt.Errorf("invalid CRL %s of revocation bundle: %w", name, err)
+			}
+			b.crls = append(b.crls, bundleCRL{name: name, crl: crl})
+		case BundleOCSPExt:
+			if _, err := ocsp.ParseResponse(data, nil); err != nil {
+				return nil, fmt.Errorf("invalid OCSP response %s of revocation bundle: %w", name, err)
+			}
+			b.ocspResponses = append(b.ocspResponses, bundleOCSPResponse{name: name, raw: data})
+		}
+	}
+	if len(b.crls) == 0 && len(b.ocspResponses) == 0 {
+		return nil, fmt.Errorf("revocation bundle %s has no CRL or OCSP response, expecting files with the extension %q or %q", dir, BundleCRLExt, BundleOCSPExt)
+	}
+	return b, nil
+}
+
+// status returns the revocation status of cert issued by issuer determined by
+// the OCSP responses of the bundle, falling back to its CRLs, and the file
+// which determined it. An error is returned if the status is not determined.
+func (b *Bundle) status(cert, issuer *x509.Certificate) (Status, string, error) {
+	var errs []error
+	for _, response := range b.ocspResponses {
+		resp, err := ocsp.ParseResponseForCert(response.raw, cert, issuer)
+		if err != nil {
+			// the response is of another certificate
+			continue
+		}
+		status, err := ocspStatus(resp)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("OCSP response %s: %w", response.name, err))
+			continue
+		}
+		return status, b.source(response.name), nil
+	}
+	for _, entry := range b.crls {
+		crl := entry.crl
+		if !bytes.Equal(crl.RawIssuer, issuer.RawSubject) {
+			continue
+		}
+		if err := crl.CheckSignatureFrom(issuer); err != nil {
+			errs = append(errs, fmt.Errorf("CRL %s: invalid CRL signature: %w", entry.name, err))
+			continue
+		}
+		if !crl.NextUpdate.IsZero() && time.Now().After(crl.NextUpdate) {
+			errs = append(errs, fmt.Errorf("CRL %s: expired CRL, next update %s", entry.name, crl.NextUpdate))
+			continue
+		}
+		for _, revoked := range crl.RevokedCertificates {
+			if revoked.SerialNumber.Cmp(cert.SerialNumber) == 0 {
+				return StatusRevoked, b.source(entry.name), nil
+			}
+		}
+		return StatusGood, b.source(entry.name), nil
+	}
+	errs = append(errs, fmt.Errorf("revocation bundle %s has no valid CRL or OCSP response of the certificate", b.dir))
+	return StatusUnknown, "", errors.Join(errs...)
+}
+
+// source returns the source of the statuses determined by the file of the
+// bundle.
+func (b *Bundle) source(name string) string {
+	return "revocation bundle " + filepath.Join(b.dir, name)
+}
@@ -0,0 +1,136 @@
+package revocation
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+// writeBundleCRL writes the CRL of ca revoking the serial numbers to the file
+// of dir, in PEM.
+func writeBundleCRL(t *testing.T, dir, name string, ca testCA, nextUpdate time.Time, revoked ...int64) {
+	t.Helper()
+	var entries []pkix.RevokedCertificate
+	for _, serial := range revoked {
+		entries = append(entries, pkix.RevokedCertificate{SerialNumber: big.NewInt(serial), RevocationTime: time.Now().Add(-time.Minute)})
+	}
+	crl, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:              big.NewInt(1),
+		ThisUpdate:          time.Now().Add(-time.Hour),
+		NextUpdate:          nextUpdate,
+		RevokedCertificates: entries,
+	}, ca.cert, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: crl}), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// writeBundleOCSPResponse writes the OCSP response of ca for cert to the file
+// of dir.
+func writeBundleOCSPResponse(t *testing.T, dir, name string, ca testCA, cert *x509.Certificate, status int) {
+	t.Helper()
+	resp, err := ocsp.CreateResponse(ca.cert, ca.cert, ocsp.Response{
+		Status:       status,
+		SerialNumber: cert.SerialNumber,
+		ThisUpdate:   time.Now().Add(-time.Minute),
+		NextUpdate:   time.Now().Add(time.Hour),
+		RevokedAt:    time.Now().Add(-time.Minute),
+	}, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), resp, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheck_Bundle(t *testing.T) {
+	responder := &testResponder{}
+	_, chain := newTestServer(t, 3, responder, true, true)
+	cas := responder.cas
+
+	// the leaf certificate, of serial number 3, is revoked by the CRL of the
+	// intermediate certificate, and the intermediate certificate is good
+	// according to the OCSP response of the root certificate
+	bundleDir := t.TempDir()
+	writeBundleCRL(t, bundleDir, "intermediate.crl", cas[1], time.Now().Add(time.Hour), 3)
+	writeBundleOCSPResponse(t, bundleDir, "intermediate.ocsp", cas[0], chain[1], ocsp.Good)
+	if err := os.WriteFile(filepath.Join(bundleDir, "README.md"), []byte("ignored"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	bundle, err := LoadBundle(bundleDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := NewChecker(Options{Bundle: bundle}).Check(context.Background(), chain)
+	if results[0].Status != StatusRevoked || results[0].Source != "revocation bundle "+filepath.Join(bundleDir, "intermediate.crl") {
+		t.Fatalf("expected the leaf certificate to be revoked by the bundle, got %v from %s", results[0].Status, results[0].Source)
+	}
+	if results[1].Status != StatusGood || !strings.HasSuffix(results[1].Source, "intermediate.ocsp") {
+		t.Fatalf("expected the intermediate certificate to be good by the bundle, got %v from %s", results[1].Status, results[1].Source)
+	}
+	if got := responder.requests.Load(); got != 0 {
+		t.Fatalf("expected no request with a revocation bundle, got %d", got)
+	}
+}
+
+func TestCheck_BundleNotCovered(t *testing.T) {
+	responder := &testResponder{}
+	_, chain := newTestServer(t, 3, responder, false, true)
+	cas := responder.cas
+
+	// the CRL of the intermediate certificate is expired, and the bundle has
+	// no status of the intermediate certificate
+	bundleDir := t.TempDir()
+	writeBundleCRL(t, bundleDir, "intermediate.crl", cas[1], time.Now().Add(-time.Minute))
+	bundle, err := LoadBundle(bundleDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := NewChecker(Options{Bundle: bundle}).Check(context.Background(), chain)
+	for i, result := range results[:2] {
+		if result.Status != StatusUnknown || !strings.Contains(result.Error.Error(), "has no valid CRL or OCSP response of the certificate") {
+			t.Fatalf("expected the status of certificate %d to be unknown, got %v: %v", i, result.Status, result.Error)
+		}
+	}
+	if !strings.Contains(results[0].Error.Error(), "expired CRL") {
+		t.Fatalf("expected the expired CRL to be reported, got %v", results[0].Error)
+	}
+	if got := responder.requests.Load(); got != 0 {
+		t.Fatalf("expected no request with a revocation bundle, got %d", got)
+	}
+}
+
+func TestLoadBundle_Error(t *testing.T) {
+	if _, err := LoadBundle(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("expected error of a missing directory")
+	}
+	bundleDir := t.TempDir()
+	if _, err := LoadBundle(bundleDir); err == nil || !strings.Contains(err.Error(), "has no CRL or OCSP response") {
+		t.Errorf("expected error of an empty bundle, got %v", err)
+	}
+	for name, content := range map[string]string{"bad.crl": "not a CRL", "bad.ocsp": "not an OCSP response"} {
+		bundleDir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(bundleDir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadBundle(bundleDir); err == nil || !strings.Contains(err.Error(), name) {
+			t.Errorf("expected error of %s, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package revocation
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/notaryproject/notation/internal/osutil"
+)
+
+const (
+	// DefaultCRLCacheTTL is the duration for which fetched CRLs are cached
+	// by default.
+	DefaultCRLCacheTTL = 24 * time.Hour
+
+	// DefaultCRLCacheMaxSize is the maximum total size in bytes of the cached
+	// CRLs by default.
+	DefaultCRLCacheMaxSize = 128 * 1024 * 1024
+
+	// CRLCacheDirName is the name of the CRL cache directory in the cache
+	// directory of notation.
+	CRLCacheDirName = "crl"
+
+	// crlFileExt is the extension of the cached CRL files.
+	crlFileExt = ".crl"
+)
+
+// timeNow is the current time, for unit test.
+var timeNow = time.Now
+
+// CRLCacheOptions configures a CRLCache.
+type CRLCacheOptions struct {
+	// TTL is the duration for which a fetched CRL is used without fetching
+	// it again. DefaultCRLCacheTTL is used if not positive.
+	TTL time.Duration
+
+	// MaxSize is the maximum total size in bytes of the cached CRLs, the
+	// least recently fetched CRLs are evicted beyond it.
+	// DefaultCRLCacheMaxSize is used if not positive.
+	MaxSize int64
+
+	// Refresh fetches every CRL again instead of using the cached CRLs,
+	// caching the CRLs fetched.
+	Refresh bool
+}
+
+// CRLCache caches the CRLs fetched in a directory, so that repeated
+// invocations do not download the CRLs again. A cached CRL is used until it
+// is older than the TTL or past its next update, and is checked against its
+// issuer as a fetched CRL.
+//
+// The cache is a hint only: failing to read or write it never fails a
+// revocation check. It is safe for concurrent use.
+type CRLCache struct {
+	dir     string
+	ttl     time.Duration
+	maxSize int64
+	refresh bool
+
+	// mu serializes the writes and the evictions of the process.
+	mu sync.Mutex
+}
+
+// DefaultCRLCacheDir returns the CRL cache directory in the user cache
+// directory.
+func DefaultCRLCacheDir() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheDir, "notation", CRLCacheDirName), nil
+}
+
+// NewCRLCache returns a CRLCache caching the CRLs in dir.
+func NewCRLCache(dir string, opts CRLCacheOptions) *CRLCache {
+	c := &CRLCache{
+		dir:     dir,
+		ttl:     opts.TTL,
+		maxSize: opts.MaxSize,
+		refresh: opts.Refresh,
+	}
+	if c.ttl <= 0 {
+		c.ttl = DefaultCRLCacheTTL
+	}
+	if c.maxSize <= 0 {
+		c.maxSize = DefaultCRLCacheMaxSize
+	}
+	return c
+}
+
+// get returns the CRL of url fetched within the TTL, if cached and not
+// refreshed.
+func (c *CRLCache) get(url string) ([]byte, bool) {
+	if c.refresh {
+		return nil, false
+	}
+	path := c.path(url)
+	info, err := os.Stat(path)
+	if err != nil || timeNow().Sub(info.ModTime()) >= c.ttl {
+		return nil, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// set caches the CRL of url fetched now, evicting the least recently fetched
+// CRLs beyond the maximum size. A CRL larger than the maximum size is not
+// cached.
+func (c *CRLCache) set(url string, crl []byte) error {
+	if int64(len(crl)) > c.maxSize {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	path := c.path(url)
+	if err := osutil.WriteFileAtomic(path, crl, 0600); err != nil {
+		return err
+	}
+	now := timeNow()
+	if err := os.Chtimes(path, now, now); err != nil {
+		return err
+	}
+	return c.evict(path)
+}
+
+// evict removes the least recently fetched CRLs other than keep until the
+// total size of the cached CRLs is within the maximum size.
+func (c *CRLCache) evict(keep string) error {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return err
+	}
+	type cachedFile struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+	var files []cachedFile
+	var total int64
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || !strings.HasSuffix(entry.Name(), crlFileExt) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			// removed by another process meanwhile
+			continue
+		}
+		files = append(files, cachedFile{path: filepath.Join(c.dir, entry.Name()), size: info.Size(), modTime: info.ModTime()})
+		total += info.Size()
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modTime.Before(files[j].modTime)
+	})
+	for _, file := range files {
+		if total <= c.maxSize {
+			break
+		}
+		if file.path == keep {
+			continue
+		}
+		if err := os.Remove(file.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		total -= file.size
+	}
+	return nil
+}
+
+// path returns the path of the cached CRL of url.
+func (c *CRLCache) path(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+crlFileExt)
+}
+
+// ClearCRLCache removes the cached CRLs in dir, and returns the number of
+// CRLs removed. A missing directory is an empty cache.
+func ClearCRLCache(dir string) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	var removed int
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.Type().IsRegular() || (!strings.HasSuffix(name, crlFileExt) && !strings.HasSuffix(name, ".tmp")) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, name)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return removed, err
+		}
+		if strings.HasSuffix(name, crlFileExt) {
+			removed++
+		}
+	}
+	return removed, nil
+}
@@ -0,0 +1,122 @@
+package revocation
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheck_CRLCache(t *testing.T) {
+	responder := &testResponder{}
+	server, chain := newTestServer(t, 3, responder, false, true)
+	cacheDir := t.TempDir()
+
+	check := func(opts CRLCacheOptions) {
+		t.Helper()
+		checker := NewChecker(Options{CRLCache: NewCRLCache(cacheDir, opts)})
+		results := checker.Check(context.Background(), chain)
+		if err := Err(results); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if results[0].Status != StatusGood || !strings.HasPrefix(results[0].Source, server.URL+"/crl/") {
+			t.Fatalf("expected good status from CRL, got %v from %s", results[0].Status, results[0].Source)
+		}
+	}
+	check(CRLCacheOptions{})
+	if got := responder.requests.Load(); got != 2 {
+		t.Fatalf("expected 2 CRL requests, got %d", got)
+	}
+	// the cached CRLs are used
+	check(CRLCacheOptions{})
+	if got := responder.requests.Load(); got != 2 {
+		t.Fatalf("expected the cached CRLs to be used, got %d CRL requests", got)
+	}
+	// the cached CRLs are refreshed
+	check(CRLCacheOptions{Refresh: true})
+	if got := responder.requests.Load(); got != 4 {
+		t.Fatalf("expected the CRLs to be fetched again, got %d CRL requests", got)
+	}
+	// the cached CRLs expire after the TTL
+	defer func(oldNow func() time.Time) { timeNow = oldNow }(timeNow)
+	timeNow = func() time.Time { return time.Now().Add(time.Hour) }
+	check(CRLCacheOptions{TTL: time.Minute})
+	if got := responder.requests.Load(); got != 6 {
+		t.Fatalf("expected the expired CRLs to be fetched again, got %d CRL requests", got)
+	}
+}
+
+func TestCheck_CRLCacheInvalid(t *testing.T) {
+	responder := &testResponder{}
+	server, chain := newTestServer(t, 2, responder, false, true)
+	cache := NewCRLCache(t.TempDir(), CRLCacheOptions{})
+	// a cached CRL which does not parse is fetched again
+	if err := cache.set(server.URL+"/crl/0", []byte("not a CRL")); err != nil {
+		t.Fatal(err)
+	}
+	results := NewChecker(Options{CRLCache: cache}).Check(context.Background(), chain)
+	if results[0].Status != StatusGood || responder.requests.Load() != 1 {
+		t.Fatalf("expected the CRL to be fetched again, got %v with %d CRL requests", results[0].Status, responder.requests.Load())
+	}
+	if body, ok := cache.get(server.URL + "/crl/0"); !ok || string(body) == "not a CRL" {
+		t.Fatal("expected the fetched CRL to replace the cached CRL")
+	}
+}
+
+func TestCRLCache_Evict(t *testing.T) {
+	cacheDir := t.TempDir()
+	cache := NewCRLCache(cacheDir, CRLCacheOptions{MaxSize: 10})
+	defer func(oldNow func() time.Time) { timeNow = oldNow }(timeNow)
+	now := time.Now()
+	for i, url := range []string{"http://example.com/a.crl", "http://example.com/b.crl", "http://example.com/c.crl"} {
+		timeNow = func() time.Time { return now.Add(time.Duration(i) * time.Second) }
+		if err := cache.set(url, []byte("crl-"+string(rune('a'+i)))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	timeNow = func() time.Time { return now.Add(time.Minute) }
+	if _, ok := cache.get("http://example.com/a.crl"); ok {
+		t.Fatal("expected the least recently fetched CRL to be evicted")
+	}
+	for _, url := range []string{"http://example.com/b.crl", "http://example.com/c.crl"} {
+		if _, ok := cache.get(url); !ok {
+			t.Fatalf("expected the CRL of %s to be cached", url)
+		}
+	}
+	// CRLs larger than the maximum size are not cached
+	if err := cache.set("http://example.com/large.crl", make([]byte, 11)); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cache.get("http://example.com/large.crl"); ok {
+		t.Fatal("expected the large CRL not to be cached")
+	}
+}
+
+func TestClearCRLCache(t *testing.T) {
+	cacheDir := filepath.Join(t.TempDir(), "crl")
+	if removed, err := ClearCRLCache(cacheDir); err != nil || removed != 0 {
+		t.Fatalf("ClearCRLCache() of a missing directory = %d, %v", removed, err)
+	}
+	cache := NewCRLCache(cacheDir, CRLCacheOptions{})
+	for _, url := range []string{"http://example.com/a.crl", "http://example.com/b.crl"} {
+		if err := cache.set(url, []byte("crl")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	other := filepath.Join(cacheDir, "README")
+	if err := os.WriteFile(other, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	removed, err := ClearCRLCache(cacheDir)
+	if err != nil || removed != 2 {
+		t.Fatalf("ClearCRLCache() = %d, %v, want 2", removed, err)
+	}
+	if _, ok := cache.get("http://example.com/a.crl"); ok {
+		t.Fatal("expected the cached CRL to be removed")
+	}
+	if _, err := os.Stat(other); err != nil {
+		t.Fatalf("expected other files to be kept, got %v", err)
+	}
+}
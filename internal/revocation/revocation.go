@@ -0,0 +1,462 @@
+// Package revocation checks the revocation status of certificate chains with
+// OCSP and CRL. The certificates of a chain are checked concurrently with a
+// bounded fan-out, each endpoint is bounded by a timeout and the whole check
+// is bounded by a global deadline.
+package revocation
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/notaryproject/notation-go/log"
+	"github.com/notaryproject/notation/internal/chaos"
+	"golang.org/x/crypto/ocsp"
+)
+
+const (
+	// DefaultMaxConcurrency is the default maximum number of certificates
+	// checked concurrently.
+	DefaultMaxConcurrency = 4
+
+	// DefaultEndpointTimeout is the default timeout of a single OCSP or CRL
+	// request.
+	DefaultEndpointTimeout = 2 * time.Second
+
+	// DefaultTimeout is the default deadline of checking a certificate chain.
+	DefaultTimeout = 10 * time.Second
+
+	// DefaultStapledMaxAge is the default maximum age of a stapled OCSP
+	// response since its this update time.
+	DefaultStapledMaxAge = 7 * 24 * time.Hour
+
+	// maxResponseSize is the maximum size of OCSP responses and CRLs.
+	maxResponseSize = 32 * 1024 * 1024
+)
+
+// Status is the revocation status of a certificate.
+type Status int
+
+const (
+	// StatusUnknown indicates the revocation status cannot be determined, e.g.
+	// all the endpoints are unreachable.
+	StatusUnknown Status = iota
+
+	// StatusGood indicates the certificate is not revoked.
+	StatusGood
+
+	// StatusRevoked indicates the certificate is revoked.
+	StatusRevoked
+
+	// StatusNonRevocable indicates the certificate has neither OCSP nor CRL
+	// endpoints, or its issuer is not in the chain.
+	StatusNonRevocable
+)
+
+// String returns the string representation of the status.
+func (s Status) String() string {
+	switch s {
+	case StatusGood:
+		return "good"
+	case StatusRevoked:
+		return "revoked"
+	case StatusNonRevocable:
+		return "non-revocable"
+	default:
+		return "unknown"
+	}
+}
+
+// Result is the revocation status of a certificate in the chain.
+type Result struct {
+	// Certificate is the certificate checked.
+	Certificate *x509.Certificate
+
+	// Status is the revocation status of the certificate.
+	Status Status
+
+	// Source is the endpoint which determined the status, if any.
+	Source string
+
+	// Error is the error of the endpoints if the status is unknown.
+	Error error
+}
+
+// Options configures a Checker.
+type Options struct {
+	// HTTPClient is the client fetching OCSP responses and CRLs.
+	// http.DefaultClient is used if nil.
+	HTTPClient *http.Client
+
+	// MaxConcurrency is the maximum number of certificates checked
+	// concurrently. DefaultMaxConcurrency is used if not positive.
+	MaxConcurrency int
+
+	// EndpointTimeout is the timeout of a single OCSP or CRL request.
+	// DefaultEndpointTimeout is used if not positive.
+	EndpointTimeout time.Duration
+
+	// Timeout is the deadline of checking a certificate chain.
+	// DefaultTimeout is used if not positive.
+	Timeout time.Duration
+
+	// StapledMaxAge is the maximum age of a stapled OCSP response since its
+	// this update time, after which the endpoints are checked instead.
+	// DefaultStapledMaxAge is used if not positive.
+	StapledMaxAge time.Duration
+
+	// CRLCache caches the CRLs fetched, if not nil.
+	CRLCache *CRLCache
+
+	// Bundle determines the revocation status of the certificates instead of
+	// their OCSP servers and CRL distribution points, if not nil. No request
+	// is made, and the status of a certificate not covered by the bundle is
+	// unknown.
+	Bundle *Bundle
+}
+
+// Checker checks the revocation status of certificate chains.
+type Checker struct {
+	client          *http.Client
+	maxConcurrency  int
+	endpointTimeout time.Duration
+	timeout         time.Duration
+	stapledMaxAge   time.Duration
+	crlCache        *CRLCache
+	bundle          *Bundle
+}
+
+// NewChecker returns a Checker with the options.
+func NewChecker(opts Options) *Checker {
+	c := &Checker{
+		client:          opts.HTTPClient,
+		maxConcurrency:  opts.MaxConcurrency,
+		endpointTimeout: opts.EndpointTimeout,
+		timeout:         opts.Timeout,
+		stapledMaxAge:   opts.StapledMaxAge,
+		crlCache:        opts.CRLCache,
+		bundle:          opts.Bundle,
+	}
+	if c.client == nil {
+		c.client = http.DefaultClient
+	}
+	if c.maxConcurrency <= 0 {
+		c.maxConcurrency = DefaultMaxConcurrency
+	}
+	if c.endpointTimeout <= 0 {
+		c.endpointTimeout = DefaultEndpointTimeout
+	}
+	if c.timeout <= 0 {
+		c.timeout = DefaultTimeout
+	}
+	if c.stapledMaxAge <= 0 {
+		c.stapledMaxAge = DefaultStapledMaxAge
+	}
+	return c
+}
+
+// Check returns the revocation status of each certificate in chain, ordered
+// from the leaf certificate to the root certificate. Each certificate is
+// checked against the next certificate in the chain as its issuer. OCSP
+// endpoints are tried first, falling back to CRL distribution points.
+func (c *Checker) Check(ctx context.Context, chain []*x509.Certificate) []Result {
+	return c.CheckWithStapled(ctx, chain, nil)
+}
+
+// CheckWithStapled is like Check, but the OCSP responses stapled to the
+// signature are tried before any endpoint, so that no outbound request is
+// made if they determine the status of the chain.
+func (c *Checker) CheckWithStapled(ctx context.Context, chain []*x509.Certificate, stapled [][]byte) []Result {
+	parent := ctx
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	results := make([]Result, len(chain))
+	sem := make(chan struct{}, c.maxConcurrency)
+	var wg sync.WaitGroup
+	for i, cert := range chain {
+		results[i] = Result{Certificate: cert, Status: StatusNonRevocable}
+		if i == len(chain)-1 {
+			// the issuer of the last certificate is not in the chain
+			continue
+		}
+		if len(cert.OCSPServer) == 0 && len(cert.CRLDistributionPoints) == 0 && len(stapled) == 0 {
+			continue
+		}
+		wg.Add(1)
+		go func(result *Result, issuer *x509.Certificate) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				result.Status = StatusUnknown
+				result.Error = ctx.Err()
+				return
+			}
+			c.checkCertificate(ctx, result, issuer, stapled)
+		}(&results[i], chain[i+1])
+	}
+	wg.Wait()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) && parent.Err() == nil {
+		log.GetLogger(ctx).Warnf("Revocation check of the certificate chain of %q timed out after %v", chain[0].Subject, c.timeout)
+	}
+	return results
+}
+
+// checkCertificate determines the status of result.Certificate issued by
+// issuer, from the stapled OCSP responses, then from the revocation bundle if
+// set, or from the endpoints of the certificate otherwise.
+func (c *Checker) checkCertificate(ctx context.Context, result *Result, issuer *x509.Certificate, stapled [][]byte) {
+	cert := result.Certificate
+	var errs []error
+	for _, raw := range stapled {
+		resp, err := ocsp.ParseResponseForCert(raw, cert, issuer)
+		if err != nil {
+			// the response is of another certificate in the chain
+			continue
+		}
+		status, err := stapledStatus(resp, c.stapledMaxAge)
+		if err != nil {
+			// fall back to the endpoints of the certificate
+			errs = append(errs, fmt.Errorf("stapled OCSP response: %w", err))
+			continue
+		}
+		result.Status, result.Source = status, sourceStapled
+		return
+	}
+	if len(cert.OCSPServer) == 0 && len(cert.CRLDistributionPoints) == 0 {
+		if len(errs) == 0 {
+			result.Status = StatusNonRevocable
+			return
+		}
+		result.Status = StatusUnknown
+		result.Error = errors.Join(errs...)
+		return
+	}
+	if c.bundle != nil {
+		status, source, err := c.bundle.status(cert, issuer)
+		if err != nil {
+			result.Status = StatusUnknown
+			result.Error = errors.Join(append(errs, err)...)
+			return
+		}
+		result.Status, result.Source = status, source
+		return
+	}
+	for _, server := range cert.OCSPServer {
+		status, err := c.checkOCSP(ctx, cert, issuer, server)
+		if err != nil {
+			c.logTimeout(ctx, "OCSP", server, err)
+			errs = append(errs, fmt.Errorf("OCSP %s: %w", server, err))
+			continue
+		}
+		result.Status, result.Source = status, server
+		return
+	}
+	for _, url := range cert.CRLDistributionPoints {
+		status, err := c.checkCRL(ctx, cert, issuer, url)
+		if err != nil {
+			c.logTimeout(ctx, "CRL", url, err)
+			errs = append(errs, fmt.Errorf("CRL %s: %w", url, err))
+			continue
+		}
+		result.Status, result.Source = status, url
+		return
+	}
+	result.Status = StatusUnknown
+	result.Error = errors.Join(errs...)
+}
+
+// logTimeout logs the request to the endpoint failed with err if it timed out
+// before the deadline of the chain.
+func (c *Checker) logTimeout(ctx context.Context, kind, endpoint string, err error) {
+	if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+		log.GetLogger(ctx).Warnf("%s request to %s timed out after %v", kind, endpoint, c.endpointTimeout)
+	}
+}
+
+// checkOCSP queries the OCSP server for the status of cert.
+func (c *Checker) checkOCSP(ctx context.Context, cert, issuer *x509.Certificate, server string) (Status, error) {
+	if err := chaos.FromContext(ctx).FailOCSP(); err != nil {
+		return StatusUnknown, err
+	}
+	ocspRequest, err := ocsp.CreateRequest(cert, issuer, nil)
+	if err != nil {
+		return StatusUnknown, err
+	}
+	body, err := c.fetch(ctx, http.MethodPost, server, "application/ocsp-request", ocspRequest)
+	if err != nil {
+		return StatusUnknown, err
+	}
+	resp, err := ocsp.ParseResponseForCert(body, cert, issuer)
+	if err != nil {
+		return StatusUnknown, err
+	}
+	return ocspStatus(resp)
+}
+
+// ocspStatus returns the status of the certificate of the OCSP response resp.
+func ocspStatus(resp *ocsp.Response) (Status, error) {
+	if !resp.NextUpdate.IsZero() && time.Now().After(resp.NextUpdate) {
+		return StatusUnknown, fmt.Errorf("expired OCSP response, next update %s", resp.NextUpdate)
+	}
+	switch resp.Status {
+	case ocsp.Good:
+		return StatusGood, nil
+	case ocsp.Revoked:
+		return StatusRevoked, nil
+	default:
+		return StatusUnknown, errors.New("OCSP responder does not know the certificate")
+	}
+}
+
+// stapledStatus is like ocspStatus, but the stapled OCSP response resp must
+// not be issued in the future nor be older than maxAge, as a stapled response
+// without a next update time would otherwise determine the status forever.
+func stapledStatus(resp *ocsp.Response, maxAge time.Duration) (Status, error) {
+	now := time.Now()
+	if resp.ThisUpdate.After(now) {
+		return StatusUnknown, fmt.Errorf("OCSP response issued in the future, this update %s", resp.ThisUpdate)
+	}
+	if now.Sub(resp.ThisUpdate) > maxAge {
+		return StatusUnknown, fmt.Errorf("OCSP response older than %v, this update %s", maxAge, resp.ThisUpdate)
+	}
+	return ocspStatus(resp)
+}
+
+// checkCRL fetches the CRL at url, or reads it from the CRL cache, and looks
+// up cert in it.
+func (c *Checker) checkCRL(ctx context.Context, cert, issuer *x509.Certificate, url string) (Status, error) {
+	crl := c.cachedCRL(ctx, issuer, url)
+	if crl == nil {
+		var err error
+		crl, err = c.fetchCRL(ctx, issuer, url)
+		if err != nil {
+			return StatusUnknown, err
+		}
+	}
+	for _, revoked := range crl.RevokedCertificates {
+		if revoked.SerialNumber.Cmp(cert.SerialNumber) == 0 {
+			return StatusRevoked, nil
+		}
+	}
+	return StatusGood, nil
+}
+
+// cachedCRL returns the cached CRL at url issued by issuer, or nil if not
+// cached or no longer valid.
+func (c *Checker) cachedCRL(ctx context.Context, issuer *x509.Certificate, url string) *x509.RevocationList {
+	if c.crlCache == nil {
+		return nil
+	}
+	body, ok := c.crlCache.get(url)
+	if !ok {
+		return nil
+	}
+	crl, err := parseCRL(body, issuer)
+	if err != nil {
+		log.GetLogger(ctx).Debugf("Ignoring the cached CRL of %s: %v", url, err)
+		return nil
+	}
+	log.GetLogger(ctx).Debugf("Using the cached CRL of %s", url)
+	return crl
+}
+
+// fetchCRL fetches the CRL at url issued by issuer, and caches it if valid.
+func (c *Checker) fetchCRL(ctx context.Context, issuer *x509.Certificate, url string) (*x509.RevocationList, error) {
+	body, err := c.fetch(ctx, http.MethodGet, url, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	crl, err := parseCRL(body, issuer)
+	if err != nil {
+		return nil, err
+	}
+	if c.crlCache != nil {
+		if err := c.crlCache.set(url, body); err != nil {
+			// best effort, the cache is a hint only
+			log.GetLogger(ctx).Debugf("Failed to cache the CRL of %s: %v", url, err)
+		}
+	}
+	return crl, nil
+}
+
+// parseCRL parses the CRL issued by issuer, and checks that it is not
+// expired.
+func parseCRL(body []byte, issuer *x509.Certificate) (*x509.RevocationList, error) {
+	crl, err := x509.ParseRevocationList(body)
+	if err != nil {
+		return nil, err
+	}
+	if err := crl.CheckSignatureFrom(issuer); err != nil {
+		return nil, fmt.Errorf("invalid CRL signature: %w", err)
+	}
+	if !crl.NextUpdate.IsZero() && time.Now().After(crl.NextUpdate) {
+		return nil, fmt.Errorf("expired CRL, next update %s", crl.NextUpdate)
+	}
+	return crl, nil
+}
+
+// fetch sends a request to the endpoint bounded by the endpoint timeout and
+// returns the response body.
+func (c *Checker) fetch(ctx context.Context, method, url, contentType string, body []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.endpointTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxResponseSize {
+		return nil, fmt.Errorf("response exceeds %d bytes", maxResponseSize)
+	}
+	return data, nil
+}
+
+// Err returns an error if any certificate in the results is revoked or of
+// unknown status.
+func Err(results []Result) error {
+	return statusErr(results, true)
+}
+
+// RevokedErr returns an error if any certificate in the results is revoked,
+// ignoring the certificates of unknown status.
+func RevokedErr(results []Result) error {
+	return statusErr(results, false)
+}
+
+func statusErr(results []Result, unknown bool) error {
+	var errs []error
+	for _, result := range results {
+		switch result.Status {
+		case StatusRevoked:
+			errs = append(errs, fmt.Errorf("certificate %q is revoked according to %s", result.Certificate.Subject, result.Source))
+		case StatusUnknown:
+			if unknown {
+				errs = append(errs, fmt.Errorf("revocation status of certificate %q is unknown: %w", result.Certificate.Subject, result.Error))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
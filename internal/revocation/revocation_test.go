@@ -0,0 +1,425 @@
+package revocation
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/notation-core-go/signature"
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+	"github.com/notaryproject/notation/internal/chaincache"
+	"github.com/notaryproject/notation/internal/chaos"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"golang.org/x/crypto/ocsp"
+)
+
+// testCA is a certificate with its key, issuing the next certificate in the
+// test chain.
+type testCA struct {
+	cert *x509.Certificate
+	key  crypto.Signer
+}
+
+// newTestChain returns a chain of n certificates from the leaf to the root.
+// The OCSP server and the CRL distribution point of the certificate issued by
+// the i-th certificate, counted from the root, are at "/ocsp/i" and "/crl/i"
+// of baseURL, if ocspEnabled and crlEnabled respectively.
+func newTestChain(t *testing.T, n int, baseURL string, ocspEnabled, crlEnabled bool) ([]*x509.Certificate, []testCA) {
+	t.Helper()
+	var cas []testCA
+	var chain []*x509.Certificate
+	for i := 0; i < n; i++ {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		template := &x509.Certificate{
+			SerialNumber:          big.NewInt(int64(i + 1)),
+			Subject:               pkix.Name{CommonName: "cert " + strconv.Itoa(i)},
+			NotBefore:             time.Now().Add(-time.Hour),
+			NotAfter:              time.Now().Add(time.Hour),
+			IsCA:                  i < n-1,
+			BasicConstraintsValid: true,
+			KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		}
+		parent, signer := template, crypto.Signer(key)
+		if i > 0 {
+			issuer := cas[i-1]
+			parent, signer = issuer.cert, issuer.key
+			if ocspEnabled {
+				template.OCSPServer = []string{baseURL + "/ocsp/" + strconv.Itoa(i-1)}
+			}
+			if crlEnabled {
+				template.CRLDistributionPoints = []string{baseURL + "/crl/" + strconv.Itoa(i-1)}
+			}
+		}
+		certDER, err := x509.CreateCertificate(rand.Reader, template, parent, key.Public(), signer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cert, err := x509.ParseCertificate(certDER)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cas = append(cas, testCA{cert: cert, key: key})
+		chain = append([]*x509.Certificate{cert}, chain...)
+	}
+	return chain, cas
+}
+
+// testResponder serves OCSP responses and CRLs of the test chain.
+type testResponder struct {
+	t       *testing.T
+	cas     []testCA
+	revoked map[int64]bool
+	delay   time.Duration
+
+	// release is closed to release hanging requests, if not nil.
+	release chan struct{}
+
+	inFlight    atomic.Int32
+	maxInFlight atomic.Int32
+	requests    atomic.Int32
+}
+
+func (r *testResponder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.requests.Add(1)
+	current := r.inFlight.Add(1)
+	defer r.inFlight.Add(-1)
+	for {
+		max := r.maxInFlight.Load()
+		if current <= max || r.maxInFlight.CompareAndSwap(max, current) {
+			break
+		}
+	}
+	if r.release != nil {
+		io.Copy(io.Discard, req.Body)
+		select {
+		case <-r.release:
+		case <-req.Context().Done():
+		}
+		return
+	}
+	time.Sleep(r.delay)
+
+	kind, index, _ := strings.Cut(strings.TrimPrefix(req.URL.Path, "/"), "/")
+	i, err := strconv.Atoi(index)
+	if err != nil || i >= len(r.cas) {
+		http.NotFound(w, req)
+		return
+	}
+	issuer := r.cas[i]
+	now := time.Now()
+	switch kind {
+	case "ocsp":
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			r.t.Error(err)
+			return
+		}
+		ocspReq, err := ocsp.ParseRequest(body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		template := ocsp.Response{
+			Status:       ocsp.Good,
+			SerialNumber: ocspReq.SerialNumber,
+			ThisUpdate:   now.Add(-time.Minute),
+			NextUpdate:   now.Add(time.Hour),
+		}
+		if r.revoked[ocspReq.SerialNumber.Int64()] {
+			template.Status = ocsp.Revoked
+			template.RevokedAt = now.Add(-time.Minute)
+		}
+		resp, err := ocsp.CreateResponse(issuer.cert, issuer.cert, template, issuer.key)
+		if err != nil {
+			r.t.Error(err)
+			return
+		}
+		w.Write(resp)
+	case "crl":
+		var revoked []pkix.RevokedCertificate
+		for serial := range r.revoked {
+			revoked = append(revoked, pkix.RevokedCertificate{SerialNumber: big.NewInt(serial), RevocationTime: now.Add(-time.Minute)})
+		}
+		crl, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+			Number:              big.NewInt(1),
+			ThisUpdate:          now.Add(-time.Minute),
+			NextUpdate:          now.Add(time.Hour),
+			RevokedCertificates: revoked,
+		}, issuer.cert, issuer.key)
+		if err != nil {
+			r.t.Error(err)
+			return
+		}
+		w.Write(crl)
+	default:
+		http.NotFound(w, req)
+	}
+}
+
+// newTestServer returns a test server of responder and its chain of n
+// certificates.
+func newTestServer(t *testing.T, n int, responder *testResponder, ocspEnabled, crlEnabled bool) (*httptest.Server, []*x509.Certificate) {
+	t.Helper()
+	responder.t = t
+	server := httptest.NewServer(responder)
+	t.Cleanup(func() {
+		if responder.release != nil {
+			close(responder.release)
+		}
+		server.CloseClientConnections()
+		server.Close()
+	})
+	chain, cas := newTestChain(t, n, server.URL, ocspEnabled, crlEnabled)
+	responder.cas = cas
+	return server, chain
+}
+
+func TestCheck_Concurrent(t *testing.T) {
+	responder := &testResponder{delay: 100 * time.Millisecond}
+	_, chain := newTestServer(t, 5, responder, true, false)
+
+	checker := NewChecker(Options{MaxConcurrency: 2})
+	results := checker.Check(context.Background(), chain)
+	if err := Err(results); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	for i, result := range results[:len(results)-1] {
+		if result.Status != StatusGood {
+			t.Fatalf("expected certificate %d to be good, got %v", i, result.Status)
+		}
+	}
+	if status := results[len(results)-1].Status; status != StatusNonRevocable {
+		t.Fatalf("expected root certificate to be non-revocable, got %v", status)
+	}
+	if got := responder.requests.Load(); got != 4 {
+		t.Fatalf("expected 4 OCSP requests, got %d", got)
+	}
+	if got := responder.maxInFlight.Load(); got != 2 {
+		t.Fatalf("expected 2 concurrent requests at most, got %d", got)
+	}
+}
+
+func TestCheck_Revoked(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		ocsp bool
+		crl  bool
+	}{
+		{name: "OCSP", ocsp: true},
+		{name: "CRL", crl: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			// the serial number of the leaf certificate is 3
+			responder := &testResponder{revoked: map[int64]bool{3: true}}
+			_, chain := newTestServer(t, 3, responder, tt.ocsp, tt.crl)
+
+			results := NewChecker(Options{}).Check(context.Background(), chain)
+			if results[0].Status != StatusRevoked {
+				t.Fatalf("expected leaf certificate to be revoked, got %v", results[0].Status)
+			}
+			if results[1].Status != StatusGood {
+				t.Fatalf("expected intermediate certificate to be good, got %v", results[1].Status)
+			}
+			if err := Err(results); err == nil || !strings.Contains(err.Error(), "revoked") {
+				t.Fatalf("expected revoked error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestCheck_FallbackToCRL(t *testing.T) {
+	responder := &testResponder{}
+	server, chain := newTestServer(t, 2, responder, true, true)
+	// the OCSP server is unreachable
+	chain[0].OCSPServer = []string{server.URL + "/unknown/0"}
+
+	results := NewChecker(Options{}).Check(context.Background(), chain)
+	if results[0].Status != StatusGood || !strings.HasPrefix(results[0].Source, server.URL+"/crl/") {
+		t.Fatalf("expected good status from CRL, got %v from %s", results[0].Status, results[0].Source)
+	}
+}
+
+func TestCheck_ChaosOCSPFailure(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		crl  bool
+		want Status
+	}{
+		{name: "OCSP only", want: StatusUnknown},
+		{name: "fallback to CRL", crl: true, want: StatusGood},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			responder := &testResponder{}
+			_, chain := newTestServer(t, 2, responder, true, tt.crl)
+
+			ctx := chaos.WithConfig(context.Background(), &chaos.Config{OCSPFailure: true})
+			results := NewChecker(Options{}).Check(ctx, chain)
+			if results[0].Status != tt.want {
+				t.Fatalf("expected status %v, got %v", tt.want, results[0].Status)
+			}
+			if tt.want == StatusUnknown && !errors.Is(results[0].Error, chaos.ErrInjected) {
+				t.Fatalf("expected injected error, got %v", results[0].Error)
+			}
+			if !tt.crl && responder.requests.Load() != 0 {
+				t.Fatalf("expected no OCSP request, got %d", responder.requests.Load())
+			}
+		})
+	}
+}
+
+func TestCheck_Timeouts(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		opts Options
+	}{
+		{name: "endpoint timeout", opts: Options{EndpointTimeout: 50 * time.Millisecond}},
+		{name: "global deadline", opts: Options{EndpointTimeout: time.Minute, Timeout: 50 * time.Millisecond}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			responder := &testResponder{release: make(chan struct{})}
+			_, chain := newTestServer(t, 3, responder, true, false)
+
+			start := time.Now()
+			results := NewChecker(tt.opts).Check(context.Background(), chain)
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Fatalf("expected check to time out, took %v", elapsed)
+			}
+			for _, result := range results[:len(results)-1] {
+				if result.Status != StatusUnknown || result.Error == nil {
+					t.Fatalf("expected unknown status with error, got %v, %v", result.Status, result.Error)
+				}
+			}
+			if err := Err(results); err == nil {
+				t.Fatal("expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestCheck_NonRevocable(t *testing.T) {
+	chain, _ := newTestChain(t, 2, "", false, false)
+	results := NewChecker(Options{}).Check(context.Background(), chain)
+	for _, result := range results {
+		if result.Status != StatusNonRevocable {
+			t.Fatalf("expected non-revocable status, got %v", result.Status)
+		}
+	}
+	if err := Err(results); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestVerifier_CheckMemoized(t *testing.T) {
+	responder := &testResponder{}
+	_, chain := newTestServer(t, 3, responder, true, false)
+
+	v := NewVerifier(nil, NewChecker(Options{}), chaincache.New[[]Result]("sha256:store", 0))
+	for i := 0; i < 3; i++ {
+		if err := Err(v.check(context.Background(), chain, nil)); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+	if got := responder.requests.Load(); got != 2 {
+		t.Fatalf("expected 2 OCSP requests for the first check only, got %d", got)
+	}
+}
+
+func TestVerifier_CheckUnknownNotMemoized(t *testing.T) {
+	responder := &testResponder{}
+	server, chain := newTestServer(t, 2, responder, true, false)
+	server.Close()
+
+	v := NewVerifier(nil, NewChecker(Options{}), chaincache.New[[]Result]("sha256:store", 0))
+	for i := 0; i < 2; i++ {
+		if err := Err(v.check(context.Background(), chain, nil)); err == nil {
+			t.Fatal("expected error of unreachable endpoint")
+		}
+	}
+	if stats := v.cache.Stats(); stats.Misses != 2 {
+		t.Fatalf("expected 2 checks of the unknown status, got %+v", stats)
+	}
+}
+
+func TestVerifier_CheckMemoizedPerStapled(t *testing.T) {
+	// the serial number of the leaf certificate is 2
+	responder := &testResponder{revoked: map[int64]bool{2: true}}
+	_, chain := newTestServer(t, 2, responder, true, false)
+	issuer := responder.cas[0]
+	stapled, err := ocsp.CreateResponse(issuer.cert, issuer.cert, ocsp.Response{
+		Status:       ocsp.Good,
+		SerialNumber: chain[0].SerialNumber,
+		ThisUpdate:   time.Now().Add(-time.Minute),
+		NextUpdate:   time.Now().Add(time.Hour),
+	}, issuer.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v := NewVerifier(nil, NewChecker(Options{}), chaincache.New[[]Result]("sha256:store", 0))
+	if err := Err(v.check(context.Background(), chain, [][]byte{stapled})); err != nil {
+		t.Fatalf("expected good status from stapled response, got %v", err)
+	}
+	// the result of the stapled response is not served without it
+	if results := v.check(context.Background(), chain, nil); results[0].Status != StatusRevoked {
+		t.Fatalf("expected leaf certificate to be revoked, got %v", results[0].Status)
+	}
+	if stats := v.cache.Stats(); stats.Misses != 2 {
+		t.Fatalf("expected 2 checks, got %+v", stats)
+	}
+}
+
+// chainVerifier is a base verifier verifying every signature at strict level,
+// signed with chain.
+type chainVerifier struct {
+	chain []*x509.Certificate
+}
+
+func (v *chainVerifier) Verify(ctx context.Context, desc ocispec.Descriptor, sig []byte, opts notation.VerifierVerifyOptions) (*notation.VerificationOutcome, error) {
+	return &notation.VerificationOutcome{
+		RawSignature:      sig,
+		VerificationLevel: trustpolicy.LevelStrict,
+		EnvelopeContent: &signature.EnvelopeContent{
+			SignerInfo: signature.SignerInfo{CertificateChain: v.chain},
+		},
+	}, nil
+}
+
+func TestVerifier_UnknownLogged(t *testing.T) {
+	responder := &testResponder{revoked: map[int64]bool{2: true}}
+	server, chain := newTestServer(t, 2, responder, true, false)
+
+	// revoked certificates fail the verification at strict level
+	v := NewVerifier(&chainVerifier{chain: chain}, NewChecker(Options{}), nil)
+	if _, err := v.Verify(context.Background(), ocispec.Descriptor{}, nil, notation.VerifierVerifyOptions{}); err == nil || !strings.Contains(err.Error(), "revoked") {
+		t.Fatalf("expected revoked error, got %v", err)
+	}
+
+	// an unknown status is logged but not enforced
+	server.Close()
+	outcome, err := v.Verify(context.Background(), ocispec.Descriptor{}, nil, notation.VerifierVerifyOptions{})
+	if err != nil {
+		t.Fatalf("expected the unknown status not to be enforced, got %v", err)
+	}
+	result := outcome.VerificationResults[len(outcome.VerificationResults)-1]
+	if result.Type != trustpolicy.TypeRevocation || result.Action != trustpolicy.ActionLog || result.Error == nil {
+		t.Fatalf("expected a logged revocation failure, got %+v", result)
+	}
+}
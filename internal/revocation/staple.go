@@ -0,0 +1,118 @@
+package revocation
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/notaryproject/notation-core-go/signature"
+	"golang.org/x/crypto/ocsp"
+)
+
+// AttributeOCSPResponses is the key of the extended signed attribute of the
+// signature envelope stapling the OCSP responses of the signing certificate
+// chain, which are fetched at signing time.
+const AttributeOCSPResponses = "io.cncf.notary.x-ocspResponses"
+
+// sourceStapled is the source of the statuses determined by stapled OCSP
+// responses.
+const sourceStapled = "stapled OCSP response"
+
+// FetchOCSPResponses fetches an OCSP response for each certificate in chain
+// but the root, to be stapled to a signature. Certificates without OCSP
+// servers are skipped. An error is returned if no OCSP server of a
+// certificate responds with its status.
+func (c *Checker) FetchOCSPResponses(ctx context.Context, chain []*x509.Certificate) ([][]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	var responses [][]byte
+	for i := 0; i < len(chain)-1; i++ {
+		cert, issuer := chain[i], chain[i+1]
+		if len(cert.OCSPServer) == 0 {
+			continue
+		}
+		resp, err := c.fetchOCSPResponse(ctx, cert, issuer)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch OCSP response of certificate %q: %w", cert.Subject, err)
+		}
+		responses = append(responses, resp)
+	}
+	return responses, nil
+}
+
+// fetchOCSPResponse returns the first valid OCSP response of cert from its
+// OCSP servers.
+func (c *Checker) fetchOCSPResponse(ctx context.Context, cert, issuer *x509.Certificate) ([]byte, error) {
+	ocspRequest, err := ocsp.CreateRequest(cert, issuer, nil)
+	if err != nil {
+		return nil, err
+	}
+	var errs []error
+	for _, server := range cert.OCSPServer {
+		body, err := c.fetch(ctx, http.MethodPost, server, "application/ocsp-request", ocspRequest)
+		if err == nil {
+			var resp *ocsp.Response
+			if resp, err = ocsp.ParseResponseForCert(body, cert, issuer); err == nil {
+				if _, err = ocspStatus(resp); err == nil {
+					return body, nil
+				}
+			}
+		}
+		errs = append(errs, fmt.Errorf("OCSP %s: %w", server, err))
+	}
+	return nil, errors.Join(errs...)
+}
+
+// StapledAttribute returns the extended signed attribute stapling the OCSP
+// responses to a signature.
+func StapledAttribute(responses [][]byte) signature.Attribute {
+	value := make([]string, 0, len(responses))
+	for _, resp := range responses {
+		value = append(value, base64.StdEncoding.EncodeToString(resp))
+	}
+	return signature.Attribute{
+		Key:   AttributeOCSPResponses,
+		Value: value,
+	}
+}
+
+// StapledResponses returns the OCSP responses stapled to the signature of
+// signerInfo, if any.
+func StapledResponses(signerInfo *signature.SignerInfo) ([][]byte, error) {
+	for _, attr := range signerInfo.SignedAttributes.ExtendedAttributes {
+		if attr.Key != AttributeOCSPResponses {
+			continue
+		}
+		// the value is decoded from JSON or CBOR depending on the envelope
+		// format
+		var encoded []string
+		switch value := attr.Value.(type) {
+		case []string:
+			encoded = value
+		case []interface{}:
+			for _, item := range value {
+				s, ok := item.(string)
+				if !ok {
+					return nil, fmt.Errorf("malformed %s attribute", AttributeOCSPResponses)
+				}
+				encoded = append(encoded, s)
+			}
+		default:
+			return nil, fmt.Errorf("malformed %s attribute", AttributeOCSPResponses)
+		}
+		responses := make([][]byte, 0, len(encoded))
+		for _, s := range encoded {
+			resp, err := base64.StdEncoding.DecodeString(s)
+			if err != nil {
+				return nil, fmt.Errorf("malformed %s attribute: %w", AttributeOCSPResponses, err)
+			}
+			responses = append(responses, resp)
+		}
+		return responses, nil
+	}
+	return nil, nil
+}
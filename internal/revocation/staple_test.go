@@ -0,0 +1,108 @@
+package revocation
+
+import (
+	"context"
+	"crypto/x509"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/notation-core-go/signature"
+	"golang.org/x/crypto/ocsp"
+)
+
+func TestFetchOCSPResponses_Stapled(t *testing.T) {
+	responder := &testResponder{revoked: map[int64]bool{}}
+	server, chain := newTestServer(t, 3, responder, true, false)
+	checker := NewChecker(Options{})
+	responses, err := checker.FetchOCSPResponses(context.Background(), chain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(responses) != 2 {
+		t.Fatalf("expected 2 OCSP responses, got %d", len(responses))
+	}
+
+	// the stapled responses survive the round trip through the envelope
+	signerInfo := &signature.SignerInfo{}
+	signerInfo.SignedAttributes.ExtendedAttributes = []signature.Attribute{StapledAttribute(responses)}
+	// JSON and CBOR decode the value as a generic slice
+	signerInfo.SignedAttributes.ExtendedAttributes[0].Value = []interface{}{
+		StapledAttribute(responses).Value.([]string)[0],
+		StapledAttribute(responses).Value.([]string)[1],
+	}
+	stapled, err := StapledResponses(signerInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// no outbound request is made with the stapled responses
+	server.Close()
+	requests := responder.requests.Load()
+	results := checker.CheckWithStapled(context.Background(), chain, stapled)
+	if err := Err(results); err != nil {
+		t.Fatalf("expected good status from stapled responses, got %v", err)
+	}
+	for _, result := range results[:2] {
+		if result.Status != StatusGood || result.Source != sourceStapled {
+			t.Fatalf("expected good status from stapled response, got %s from %q", result.Status, result.Source)
+		}
+	}
+	if got := responder.requests.Load(); got != requests {
+		t.Fatalf("expected no request, got %d", got-requests)
+	}
+
+	// the status is unknown without stapled responses
+	if err := Err(checker.Check(context.Background(), chain)); err == nil {
+		t.Fatal("expected unknown status without stapled responses")
+	}
+}
+
+func TestCheckWithStapled_MaxAge(t *testing.T) {
+	responder := &testResponder{}
+	server, chain := newTestServer(t, 2, responder, true, false)
+	issuer := responder.cas[0]
+	for _, tt := range []struct {
+		name       string
+		thisUpdate time.Time
+	}{
+		{name: "too old", thisUpdate: time.Now().Add(-DefaultStapledMaxAge - time.Hour)},
+		{name: "future", thisUpdate: time.Now().Add(time.Hour)},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			// a good response without next update time
+			stapled, err := ocsp.CreateResponse(issuer.cert, issuer.cert, ocsp.Response{
+				Status:       ocsp.Good,
+				SerialNumber: chain[0].SerialNumber,
+				ThisUpdate:   tt.thisUpdate,
+			}, issuer.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// the OCSP server is checked instead
+			results := NewChecker(Options{}).CheckWithStapled(context.Background(), chain, [][]byte{stapled})
+			if results[0].Status != StatusGood || !strings.HasPrefix(results[0].Source, server.URL+"/ocsp/") {
+				t.Fatalf("expected good status from OCSP server, got %s from %q", results[0].Status, results[0].Source)
+			}
+
+			// the status is unknown without endpoints
+			leaf := *chain[0]
+			leaf.OCSPServer = nil
+			results = NewChecker(Options{}).CheckWithStapled(context.Background(), []*x509.Certificate{&leaf, chain[1]}, [][]byte{stapled})
+			if results[0].Status != StatusUnknown || results[0].Error == nil {
+				t.Fatalf("expected unknown status, got %s", results[0].Status)
+			}
+		})
+	}
+}
+
+func TestStapledResponses_Malformed(t *testing.T) {
+	for _, value := range []interface{}{"not a list", []interface{}{1}, []string{"!not base64"}} {
+		signerInfo := &signature.SignerInfo{}
+		signerInfo.SignedAttributes.ExtendedAttributes = []signature.Attribute{{Key: AttributeOCSPResponses, Value: value}}
+		if _, err := StapledResponses(signerInfo); err == nil {
+			t.Fatalf("expected error for value %v", value)
+		}
+	}
+}
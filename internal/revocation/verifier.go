@@ -0,0 +1,114 @@
+package revocation
+
+import (
+	"context"
+	"crypto/x509"
+
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation-go/log"
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+	"github.com/notaryproject/notation/internal/chaincache"
+	"github.com/notaryproject/notation/internal/skipper"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// Verifier wraps a notation.Verifier and checks the revocation status of the
+// certificate chain of each signature verified by the wrapped verifier, as
+// enforced by the verification level of the trust policy.
+type Verifier struct {
+	base    notation.Verifier
+	checker *Checker
+	cache   *chaincache.Cache[[]Result]
+}
+
+// NewVerifier returns a Verifier checking the revocation status with checker.
+// The results of the chains checked are memoized in cache, if not nil, so
+// that a chain shared by many signatures is checked once.
+func NewVerifier(base notation.Verifier, checker *Checker, cache *chaincache.Cache[[]Result]) *Verifier {
+	return &Verifier{
+		base:    base,
+		checker: checker,
+		cache:   cache,
+	}
+}
+
+// SkipVerify validates whether the verification level is skip.
+func (v *Verifier) SkipVerify(ctx context.Context, opts notation.VerifierVerifyOptions) (bool, *trustpolicy.VerificationLevel, error) {
+	return skipper.SkipVerify(ctx, v.base, opts)
+}
+
+// Verify verifies the signature with the wrapped verifier and then checks the
+// revocation status of the certificate chain of the signature.
+//
+// The check is skipped if the verification level skips the revocation
+// validation or a verification plugin has already checked the revocation
+// status. The outcome of the check is appended to the verification results.
+// A status which cannot be determined, e.g. as the OCSP servers and CRL
+// distribution points are unreachable, is logged rather than enforced, so
+// that hosts without access to them still verify signatures.
+func (v *Verifier) Verify(ctx context.Context, desc ocispec.Descriptor, signature []byte, opts notation.VerifierVerifyOptions) (*notation.VerificationOutcome, error) {
+	outcome, err := v.base.Verify(ctx, desc, signature, opts)
+	if err != nil || outcome == nil || outcome.EnvelopeContent == nil || outcome.VerificationLevel == nil {
+		return outcome, err
+	}
+	action := outcome.VerificationLevel.Enforcement[trustpolicy.TypeRevocation]
+	if action == trustpolicy.ActionSkip {
+		return outcome, nil
+	}
+	for _, result := range outcome.VerificationResults {
+		if result.Type == trustpolicy.TypeRevocation {
+			// checked by a verification plugin
+			return outcome, nil
+		}
+	}
+
+	logger := log.GetLogger(ctx)
+	logger.Debug("Validating revocation")
+	result := &notation.ValidationResult{
+		Type:   trustpolicy.TypeRevocation,
+		Action: action,
+	}
+	signerInfo := &outcome.EnvelopeContent.SignerInfo
+	stapled, err := StapledResponses(signerInfo)
+	if err != nil {
+		result.Error = notation.ErrorVerificationFailed{Msg: err.Error()}
+	} else {
+		results := v.check(ctx, signerInfo.CertificateChain, stapled)
+		if err := RevokedErr(results); err != nil {
+			result.Error = notation.ErrorVerificationFailed{Msg: err.Error()}
+		} else if err := Err(results); err != nil {
+			result.Action = trustpolicy.ActionLog
+			result.Error = notation.ErrorVerificationFailed{Msg: err.Error()}
+		}
+	}
+	outcome.VerificationResults = append(outcome.VerificationResults, result)
+	if result.Error != nil {
+		if result.Action == trustpolicy.ActionEnforce {
+			outcome.Error = result.Error
+			return outcome, result.Error
+		}
+		logger.Warnf("%v validation failed with validation action set to %q. Failure reason: %v", result.Type, result.Action, result.Error)
+	}
+	return outcome, nil
+}
+
+// check checks the revocation status of chain, or returns the memoized
+// results of chain with the same stapled OCSP responses. Results with an unknown status, e.g. due to unreachable
+// endpoints, are not memoized so that the next signature tries again.
+func (v *Verifier) check(ctx context.Context, chain []*x509.Certificate, stapled [][]byte) []Result {
+	hit := true
+	results := v.cache.DoWith(chain, stapled, func() ([]Result, bool) {
+		hit = false
+		results := v.checker.CheckWithStapled(ctx, chain, stapled)
+		for _, result := range results {
+			if result.Status == StatusUnknown {
+				return results, false
+			}
+		}
+		return results, true
+	})
+	if hit && len(chain) > 0 {
+		log.GetLogger(ctx).Debugf("Using the memoized revocation status of the certificate chain of leaf certificate %s", chaincache.Thumbprint(chain[0]))
+	}
+	return results
+}
@@ -0,0 +1,192 @@
+// Package sanity detects values that should not appear in configuration
+// files, e.g. embedded secrets, absolute paths in home directories and URLs
+// with insecure or invalid schemes.
+package sanity
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/notaryproject/notation/internal/color"
+)
+
+// Kind is the kind of a finding.
+type Kind string
+
+const (
+	// KindSecret denotes an embedded secret, e.g. a token or a private key.
+	KindSecret Kind = "secret"
+
+	// KindHomePath denotes an absolute path in a home directory, which does
+	// not work for other users or on other machines.
+	KindHomePath Kind = "home-path"
+
+	// KindURLScheme denotes a URL with an insecure or invalid scheme.
+	KindURLScheme Kind = "url-scheme"
+)
+
+// Finding is a suspicious value.
+type Finding struct {
+	// Path is the location of the value, e.g. "trustPolicies[0].name" or the
+	// key of a plugin config.
+	Path string
+
+	// Kind is the kind of the finding.
+	Kind Kind
+
+	// Message describes the finding without disclosing the value.
+	Message string
+}
+
+// String returns the finding as "<path>: <message>".
+func (f Finding) String() string {
+	return f.Path + ": " + f.Message
+}
+
+// secretKeyPattern matches the names of fields holding secrets, after
+// removing the separators.
+var secretKeyPattern = regexp.MustCompile(`(?i)(password|passwd|secret|token|apikey|accesskey|privatekey|credential)`)
+
+// referenceKeyPattern matches the names of fields referring to secrets rather
+// than holding them, e.g. "tokenEndpoint" or "credentialFile", after removing
+// the separators.
+var referenceKeyPattern = regexp.MustCompile(`(?i)(url|uri|endpoint|path|file|dir|name|type|id)$`)
+
+// secretValuePatterns match well-known formats of secrets.
+var secretValuePatterns = []struct {
+	name    string
+	pattern *regexp.Regexp
+}{
+	{"private key", regexp.MustCompile(`-----BEGIN ([A-Z0-9]+ )*PRIVATE KEY-----`)},
+	{"JSON web token", regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]+`)},
+	{"AWS access key", regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"GitHub token", regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{36}|github_pat_[A-Za-z0-9_]{22,})\b`)},
+	{"Slack token", regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`)},
+	{"bearer token", regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9._~+/=-]{16,}`)},
+}
+
+// homePathPattern matches absolute paths in home directories on Linux, macOS
+// and Windows, and paths relative to the home directory of the current user.
+var homePathPattern = regexp.MustCompile(`^(~[/\\]|/home/[^/]+/|/Users/[^/]+/|/root/|[A-Za-z]:[/\\]Users[/\\][^/\\]+[/\\])`)
+
+// urlSchemePattern matches values starting with a URL scheme.
+var urlSchemePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9+.-]*://`)
+
+// ScanValue returns the findings of the value at path. key is the name of the
+// field holding the value, if any.
+func ScanValue(path, key, value string) []Finding {
+	if value == "" {
+		return nil
+	}
+	var findings []Finding
+	normalizedKey := strings.NewReplacer("_", "", "-", "", ".", "").Replace(key)
+	switch {
+	case key != "" && secretKeyPattern.MatchString(normalizedKey) && !referenceKeyPattern.MatchString(normalizedKey):
+		findings = append(findings, Finding{Path: path, Kind: KindSecret, Message: fmt.Sprintf("field %q looks like it holds a plaintext secret", key)})
+	default:
+		for _, p := range secretValuePatterns {
+			if p.pattern.MatchString(value) {
+				findings = append(findings, Finding{Path: path, Kind: KindSecret, Message: "value looks like a plaintext " + p.name})
+				break
+			}
+		}
+	}
+	if homePathPattern.MatchString(value) {
+		findings = append(findings, Finding{Path: path, Kind: KindHomePath, Message: "value is a path in a home directory, which does not work for other users or on other machines"})
+	}
+	if urlSchemePattern.MatchString(value) {
+		if u, err := url.Parse(value); err != nil {
+			findings = append(findings, Finding{Path: path, Kind: KindURLScheme, Message: "value is not a valid URL"})
+		} else {
+			switch strings.ToLower(u.Scheme) {
+			case "https":
+			case "http":
+				findings = append(findings, Finding{Path: path, Kind: KindURLScheme, Message: "URL uses the insecure scheme \"http\", use \"https\""})
+			default:
+				findings = append(findings, Finding{Path: path, Kind: KindURLScheme, Message: fmt.Sprintf("URL uses the invalid scheme %q, use \"https\"", u.Scheme)})
+			}
+			if u.User != nil {
+				if _, ok := u.User.Password(); ok {
+					findings = append(findings, Finding{Path: path, Kind: KindSecret, Message: "URL embeds a plaintext password"})
+				}
+			}
+		}
+	}
+	return findings
+}
+
+// ScanMap returns the findings of the values of m, e.g. a plugin config,
+// sorted by key. The path of a finding is prefix followed by the key.
+func ScanMap(prefix string, m map[string]string) []Finding {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var findings []Finding
+	for _, key := range keys {
+		findings = append(findings, ScanValue(prefix+key, key, m[key])...)
+	}
+	return findings
+}
+
+// ScanJSON returns the findings of the string values of a JSON document, in
+// document order.
+func ScanJSON(data []byte) ([]Finding, error) {
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	var findings []Finding
+	walk("", "", doc, &findings)
+	return findings, nil
+}
+
+// walk scans value at path recursively.
+func walk(path, key string, value any, findings *[]Finding) {
+	switch v := value.(type) {
+	case string:
+		*findings = append(*findings, ScanValue(path, key, v)...)
+	case []any:
+		for i, item := range v {
+			walk(fmt.Sprintf("%s[%d]", path, i), key, item, findings)
+		}
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			childPath := k
+			if path != "" {
+				childPath = path + "." + k
+			}
+			walk(childPath, k, v[k], findings)
+		}
+	}
+}
+
+// Secrets returns the findings of kind KindSecret.
+func Secrets(findings []Finding) []Finding {
+	var secrets []Finding
+	for _, f := range findings {
+		if f.Kind == KindSecret {
+			secrets = append(secrets, f)
+		}
+	}
+	return secrets
+}
+
+// PrintWarnings prints the findings in source, e.g. "trust policy", as
+// warnings to f.
+func PrintWarnings(f *os.File, source string, findings []Finding) {
+	for _, finding := range findings {
+		fmt.Fprintf(f, "%s %s %s\n", color.Warning(f, "Warning:"), source, finding)
+	}
+}
@@ -0,0 +1,128 @@
+package selfupdate
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// ExtractBinary returns the notation binary in the release archive of the
+// given name, a gzip compressed tarball or a zip archive.
+func ExtractBinary(archiveName string, archive []byte) ([]byte, error) {
+	if strings.HasSuffix(archiveName, ".zip") {
+		return extractZip(archive, "notation.exe")
+	}
+	return extractTarGz(archive, "notation")
+}
+
+func extractTarGz(archive []byte, binary string) ([]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, fmt.Errorf("malformed release archive: %w", err)
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, fmt.Errorf("release archive has no %s binary", binary)
+			}
+			return nil, fmt.Errorf("malformed release archive: %w", err)
+		}
+		if header.Typeflag == tar.TypeReg && path.Clean(header.Name) == binary {
+			return readBinary(tr, header.Size)
+		}
+	}
+}
+
+func extractZip(archive []byte, binary string) ([]byte, error) {
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		return nil, fmt.Errorf("malformed release archive: %w", err)
+	}
+	for _, file := range zr.File {
+		if path.Clean(file.Name) != binary || file.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := file.Open()
+		if err != nil {
+			return nil, fmt.Errorf("malformed release archive: %w", err)
+		}
+		defer rc.Close()
+		return readBinary(rc, int64(file.UncompressedSize64))
+	}
+	return nil, fmt.Errorf("release archive has no %s binary", binary)
+}
+
+func readBinary(r io.Reader, size int64) ([]byte, error) {
+	if size > maxAssetSize {
+		return nil, fmt.Errorf("binary in the release archive exceeds %d bytes", maxAssetSize)
+	}
+	data, err := io.ReadAll(io.LimitReader(r, maxAssetSize))
+	if err != nil {
+		return nil, fmt.Errorf("malformed release archive: %w", err)
+	}
+	return data, nil
+}
+
+// Replace atomically replaces the binary at path with binary, keeping its
+// file mode. The new binary is written to a temporary file in the same
+// directory and renamed over path, so that path is either the old or the new
+// binary at any time. On Windows, where a running binary cannot be replaced,
+// the old binary is moved aside to path with the ".old" suffix first.
+func Replace(path string, binary []byte) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".new-*")
+	if err != nil {
+		return fmt.Errorf("failed to write the new binary: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+	if _, err := tmp.Write(binary); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write the new binary: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write the new binary: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write the new binary: %w", err)
+	}
+	if err := os.Chmod(tmpPath, info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to write the new binary: %w", err)
+	}
+
+	if runtime.GOOS == "windows" {
+		oldPath := path + ".old"
+		os.Remove(oldPath)
+		if err := os.Rename(path, oldPath); err != nil {
+			return fmt.Errorf("failed to move the old binary aside: %w", err)
+		}
+		if err := os.Rename(tmpPath, path); err != nil {
+			// the old binary is put back
+			if restoreErr := os.Rename(oldPath, path); restoreErr != nil {
+				return fmt.Errorf("failed to replace the binary: %w, and failed to restore the old binary from %s: %v", err, oldPath, restoreErr)
+			}
+			return fmt.Errorf("failed to replace the binary: %w", err)
+		}
+		return nil
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to replace the binary: %w", err)
+	}
+	return nil
+}
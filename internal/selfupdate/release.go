@@ -0,0 +1,183 @@
+// Package selfupdate downloads notation releases, verifies them against the
+// release trust anchors embedded in the binary and replaces the running
+// binary.
+package selfupdate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/notaryproject/notation/internal/httputil"
+	"golang.org/x/mod/semver"
+)
+
+const (
+	// DefaultBaseURL is the base URL of the GitHub REST API.
+	DefaultBaseURL = "https://api.github.com"
+
+	// DefaultRepository is the GitHub repository publishing notation
+	// releases.
+	DefaultRepository = "notaryproject/notation"
+
+	// maxAssetSize is the maximum size of a release asset to download.
+	maxAssetSize = 256 * 1024 * 1024
+)
+
+// Release is a notation release.
+type Release struct {
+	// Version is the version of the release without the "v" prefix, e.g.
+	// "1.0.0".
+	Version string
+
+	// Assets are the download URLs of the release assets indexed by their
+	// names.
+	Assets map[string]string
+}
+
+// Client fetches notation releases from GitHub.
+type Client struct {
+	// HTTPClient sends the requests. http.DefaultClient is used if nil.
+	HTTPClient httputil.Client
+
+	// BaseURL is the base URL of the GitHub REST API. DefaultBaseURL is used
+	// if empty.
+	BaseURL string
+
+	// Repository is the GitHub repository publishing the releases.
+	// DefaultRepository is used if empty.
+	Repository string
+
+	// UserAgent is the user agent of the requests.
+	UserAgent string
+}
+
+// Release fetches the release of the version, e.g. "1.0.0", or the latest
+// release if version is empty.
+func (c *Client) Release(ctx context.Context, version string) (*Release, error) {
+	baseURL, repository := c.BaseURL, c.Repository
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	if repository == "" {
+		repository = DefaultRepository
+	}
+	endpoint := fmt.Sprintf("%s/repos/%s/releases/latest", strings.TrimSuffix(baseURL, "/"), repository)
+	if version != "" {
+		tag := "v" + strings.TrimPrefix(version, "v")
+		if !semver.IsValid(tag) {
+			return nil, fmt.Errorf("invalid release version %q", version)
+		}
+		endpoint = fmt.Sprintf("%s/repos/%s/releases/tags/%s", strings.TrimSuffix(baseURL, "/"), repository, url.PathEscape(tag))
+	}
+	resp, err := c.get(ctx, endpoint, "application/vnd.github+json")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		if version == "" {
+			return nil, errors.New("no release found")
+		}
+		return nil, fmt.Errorf("release %s not found", version)
+	default:
+		return nil, fmt.Errorf("failed to fetch release: %s %q: unexpected status code %d", resp.Request.Method, resp.Request.URL, resp.StatusCode)
+	}
+
+	var result struct {
+		TagName string `json:"tag_name"`
+		Assets  []struct {
+			Name               string `json:"name"`
+			BrowserDownloadURL string `json:"browser_download_url"`
+		} `json:"assets"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxAssetSize)).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode release: %w", err)
+	}
+	if !semver.IsValid(result.TagName) {
+		return nil, fmt.Errorf("release has an invalid tag %q", result.TagName)
+	}
+	release := &Release{
+		Version: strings.TrimPrefix(result.TagName, "v"),
+		Assets:  make(map[string]string),
+	}
+	for _, asset := range result.Assets {
+		release.Assets[asset.Name] = asset.BrowserDownloadURL
+	}
+	return release, nil
+}
+
+// Download downloads the release asset of the given name.
+func (c *Client) Download(ctx context.Context, release *Release, name string) ([]byte, error) {
+	assetURL, ok := release.Assets[name]
+	if !ok {
+		return nil, fmt.Errorf("release %s has no asset %s", release.Version, name)
+	}
+	resp, err := c.get(ctx, assetURL, "application/octet-stream")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %s: %s %q: unexpected status code %d", name, resp.Request.Method, resp.Request.URL, resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxAssetSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", name, err)
+	}
+	if len(data) > maxAssetSize {
+		return nil, fmt.Errorf("failed to download %s: asset exceeds %d bytes", name, maxAssetSize)
+	}
+	return data, nil
+}
+
+func (c *Client) get(ctx context.Context, endpoint, accept string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", accept)
+	if c.UserAgent != "" {
+		req.Header.Set("User-Agent", c.UserAgent)
+	}
+	var client httputil.Client = http.DefaultClient
+	if c.HTTPClient != nil {
+		client = c.HTTPClient
+	}
+	return client.Do(req)
+}
+
+// IsNewer reports whether the release version is newer than the current
+// version, e.g. "v1.0.0-rc.3". Versions are compared by semantic versioning,
+// ignoring build metadata.
+func IsNewer(release, current string) bool {
+	return semver.Compare("v"+strings.TrimPrefix(release, "v"), "v"+strings.TrimPrefix(current, "v")) > 0
+}
+
+// ArchiveName returns the name of the release archive of the platform, as
+// published by the release pipeline.
+func ArchiveName(version, goos, goarch string) string {
+	name := fmt.Sprintf("notation_%s_%s_%s", version, goos, goarch)
+	if goos == "windows" {
+		return name + ".zip"
+	}
+	return name + ".tar.gz"
+}
+
+// ChecksumsName returns the name of the checksums file of the release.
+func ChecksumsName(version string) string {
+	return fmt.Sprintf("notation_%s_checksums.txt", version)
+}
+
+// SignatureName returns the name of the signature of the checksums file of
+// the release.
+func SignatureName(version string) string {
+	return ChecksumsName(version) + ".sig"
+}
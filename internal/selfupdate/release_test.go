@@ -0,0 +1,15 @@
+//go:build release
+
+package selfupdate
+
+import "testing"
+
+// TestTrustAnchors_Release fails release builds without trust anchors, run by
+// "make check-trust-anchors".
+func TestTrustAnchors_Release(t *testing.T) {
+	anchors, err := TrustAnchors()
+	if err != nil {
+		t.Fatalf("release builds require trust anchors: %v", err)
+	}
+	t.Logf("%d release trust anchors", len(anchors))
+}
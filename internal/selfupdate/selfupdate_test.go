@@ -0,0 +1,282 @@
+package selfupdate
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newTarGz(t *testing.T, files map[string][]byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0755, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func newZip(t *testing.T, files map[string][]byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write(content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func checksumLine(name string, content []byte) string {
+	digest := sha256.Sum256(content)
+	return fmt.Sprintf("%s  %s\n", hex.EncodeToString(digest[:]), name)
+}
+
+func TestRelease(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/notaryproject/notation/releases/latest", "/repos/notaryproject/notation/releases/tags/v1.1.0":
+			json.NewEncoder(w).Encode(map[string]any{
+				"tag_name": "v1.1.0",
+				"assets": []map[string]string{
+					{"name": "notation_1.1.0_checksums.txt", "browser_download_url": "http://" + r.Host + "/download/checksums"},
+				},
+			})
+		case "/download/checksums":
+			w.Write([]byte("checksums"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	client := &Client{BaseURL: server.URL, HTTPClient: server.Client()}
+
+	for _, version := range []string{"", "1.1.0", "v1.1.0"} {
+		release, err := client.Release(context.Background(), version)
+		if err != nil {
+			t.Fatalf("Release(%q) error = %v", version, err)
+		}
+		if release.Version != "1.1.0" {
+			t.Fatalf("Release(%q) version = %q, want 1.1.0", version, release.Version)
+		}
+	}
+	release, _ := client.Release(context.Background(), "")
+	data, err := client.Download(context.Background(), release, ChecksumsName(release.Version))
+	if err != nil || string(data) != "checksums" {
+		t.Fatalf("Download() = %q, %v", data, err)
+	}
+	if _, err := client.Download(context.Background(), release, ArchiveName("1.1.0", "linux", "amd64")); err == nil {
+		t.Fatal("expected error downloading a missing asset")
+	}
+	if _, err := client.Release(context.Background(), "1.2.0"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected release not found, got %v", err)
+	}
+	if _, err := client.Release(context.Background(), "latest"); err == nil {
+		t.Fatal("expected error for an invalid version")
+	}
+}
+
+func TestIsNewer(t *testing.T) {
+	tests := []struct {
+		release, current string
+		want             bool
+	}{
+		{"1.0.0", "v1.0.0-rc.3", true},
+		{"1.0.0", "v1.0.0", false},
+		{"1.0.0", "1.1.0", false},
+		{"1.1.0", "v1.0.0", true},
+	}
+	for _, tt := range tests {
+		if got := IsNewer(tt.release, tt.current); got != tt.want {
+			t.Errorf("IsNewer(%q, %q) = %v, want %v", tt.release, tt.current, got, tt.want)
+		}
+	}
+}
+
+func TestVerifyChecksums(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checksums := []byte(checksumLine("notation_1.1.0_linux_amd64.tar.gz", []byte("archive")))
+	digest := sha256.Sum256(checksums)
+	ecSignature, err := ecdsa.SignASN1(rand.Reader, ecKey, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaSignature, err := rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var anchorsPEM bytes.Buffer
+	anchorsPEM.WriteString("release signing keys\n")
+	for _, key := range []crypto.PublicKey{&ecKey.PublicKey, &rsaKey.PublicKey} {
+		der, err := x509.MarshalPKIXPublicKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pem.Encode(&anchorsPEM, &pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	}
+	anchors, err := ParseTrustAnchors(anchorsPEM.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(anchors) != 2 {
+		t.Fatalf("expected 2 trust anchors, got %d", len(anchors))
+	}
+
+	for _, signature := range [][]byte{ecSignature, rsaSignature} {
+		if err := VerifyChecksums(checksums, signature, anchors); err != nil {
+			t.Fatalf("VerifyChecksums() error = %v", err)
+		}
+	}
+	if err := VerifyChecksums(append(checksums, '\n'), ecSignature, anchors); err == nil {
+		t.Fatal("expected error for tampered checksums")
+	}
+	if err := VerifyChecksums(checksums, ecSignature, anchors[1:]); err == nil {
+		t.Fatal("expected error for an untrusted signing key")
+	}
+	if err := VerifyChecksums(checksums, ecSignature, nil); !errors.Is(err, ErrNoTrustAnchors) {
+		t.Fatalf("expected ErrNoTrustAnchors, got %v", err)
+	}
+}
+
+func TestTrustAnchors_Development(t *testing.T) {
+	if releaseTrustAnchors != "" {
+		t.Skip("release trust anchors are injected")
+	}
+	// development builds embed no trust anchors
+	if _, err := TrustAnchors(); !errors.Is(err, ErrNoTrustAnchors) {
+		t.Fatalf("expected ErrNoTrustAnchors, got %v", err)
+	}
+}
+
+func TestTrustAnchors_Injected(t *testing.T) {
+	defer func(old string) {
+		releaseTrustAnchors = old
+	}(releaseTrustAnchors)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	releaseTrustAnchors = base64.StdEncoding.EncodeToString(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	anchors, err := TrustAnchors()
+	if err != nil {
+		t.Fatalf("TrustAnchors() error = %v", err)
+	}
+	if len(anchors) != 1 || !key.PublicKey.Equal(anchors[0]) {
+		t.Fatalf("TrustAnchors() = %v, want the injected key", anchors)
+	}
+
+	releaseTrustAnchors = "not base64"
+	if _, err := TrustAnchors(); err == nil {
+		t.Fatal("expected error for invalid injected trust anchors")
+	}
+}
+
+func TestVerifyAsset(t *testing.T) {
+	checksums := []byte(checksumLine("notation_1.1.0_linux_amd64.tar.gz", []byte("archive")) + checksumLine("notation_1.1.0_windows_amd64.zip", []byte("zip")))
+	if err := VerifyAsset(checksums, "notation_1.1.0_windows_amd64.zip", []byte("zip")); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyAsset(checksums, "notation_1.1.0_linux_amd64.tar.gz", []byte("tampered")); err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Fatalf("expected checksum mismatch, got %v", err)
+	}
+	if err := VerifyAsset(checksums, "notation_1.1.0_linux_arm64.tar.gz", nil); err == nil || !strings.Contains(err.Error(), "do not list") {
+		t.Fatalf("expected unlisted asset, got %v", err)
+	}
+}
+
+func TestExtractBinary(t *testing.T) {
+	files := map[string][]byte{"LICENSE": []byte("license"), "notation": []byte("binary")}
+	binary, err := ExtractBinary("notation_1.1.0_linux_amd64.tar.gz", newTarGz(t, files))
+	if err != nil || string(binary) != "binary" {
+		t.Fatalf("ExtractBinary() = %q, %v", binary, err)
+	}
+	binary, err = ExtractBinary("notation_1.1.0_windows_amd64.zip", newZip(t, map[string][]byte{"notation.exe": []byte("exe")}))
+	if err != nil || string(binary) != "exe" {
+		t.Fatalf("ExtractBinary() = %q, %v", binary, err)
+	}
+	if _, err := ExtractBinary("notation_1.1.0_linux_amd64.tar.gz", newTarGz(t, map[string][]byte{"LICENSE": nil})); err == nil {
+		t.Fatal("expected error for an archive without the binary")
+	}
+}
+
+func TestReplace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notation")
+	if err := os.WriteFile(path, []byte("old"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := Replace(path, []byte("new")); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "new" {
+		t.Fatalf("expected the new binary, got %q, %v", data, err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0755 {
+		t.Fatalf("expected mode 0755, got %v", info.Mode().Perm())
+	}
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected no temporary files left, got %d entries", len(entries))
+	}
+}
@@ -0,0 +1,130 @@
+package selfupdate
+
+import (
+	"bufio"
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	_ "embed"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// trustAnchorsPEM are the public keys of the release signing keys in PEM,
+// embedded at build time. The file is kept without keys in the repository.
+//
+//go:embed trustanchors.pem
+var trustAnchorsPEM []byte
+
+// releaseTrustAnchors are the public keys of the release signing keys in
+// base64 encoded PEM, injected by release builds with the linker flag
+// "-X github.com/notaryproject/notation/internal/selfupdate.releaseTrustAnchors=<base64>",
+// so that the release pipeline builds from a clean checkout.
+var releaseTrustAnchors string
+
+// ErrNoTrustAnchors is returned if the binary has no release trust anchors
+// embedded, e.g. for development builds.
+var ErrNoTrustAnchors = errors.New("no release trust anchors are embedded in this build of notation")
+
+// TrustAnchors returns the release trust anchors embedded in the binary.
+func TrustAnchors() ([]crypto.PublicKey, error) {
+	data := trustAnchorsPEM
+	if releaseTrustAnchors != "" {
+		injected, err := base64.StdEncoding.DecodeString(releaseTrustAnchors)
+		if err != nil {
+			return nil, fmt.Errorf("invalid release trust anchors injected at build time: %w", err)
+		}
+		data = append(append([]byte{}, trustAnchorsPEM...), injected...)
+	}
+	anchors, err := ParseTrustAnchors(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(anchors) == 0 {
+		return nil, ErrNoTrustAnchors
+	}
+	return anchors, nil
+}
+
+// ParseTrustAnchors parses the ECDSA and RSA public keys of the "PUBLIC KEY"
+// PEM blocks in data. Text outside of the PEM blocks is ignored.
+func ParseTrustAnchors(data []byte) ([]crypto.PublicKey, error) {
+	var anchors []crypto.PublicKey
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return anchors, nil
+		}
+		if block.Type != "PUBLIC KEY" {
+			continue
+		}
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid release trust anchor: %w", err)
+		}
+		switch key.(type) {
+		case *ecdsa.PublicKey, *rsa.PublicKey:
+			anchors = append(anchors, key)
+		default:
+			return nil, fmt.Errorf("unsupported release trust anchor of type %T", key)
+		}
+	}
+}
+
+// VerifyChecksums verifies the signature of the checksums file against the
+// trust anchors. The signature is the SHA-256 signature of the file produced
+// by "openssl dgst -sha256 -sign", i.e. an ASN.1 encoded ECDSA signature or
+// an RSASSA-PKCS1-v1_5 signature.
+func VerifyChecksums(checksums, signature []byte, anchors []crypto.PublicKey) error {
+	if len(anchors) == 0 {
+		return ErrNoTrustAnchors
+	}
+	digest := sha256.Sum256(checksums)
+	for _, anchor := range anchors {
+		switch key := anchor.(type) {
+		case *ecdsa.PublicKey:
+			if ecdsa.VerifyASN1(key, digest[:], signature) {
+				return nil
+			}
+		case *rsa.PublicKey:
+			if rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) == nil {
+				return nil
+			}
+		}
+	}
+	return errors.New("signature of the release checksums does not match any release trust anchor")
+}
+
+// VerifyAsset verifies the content of the asset of the given name against
+// its SHA-256 checksum listed in the checksums file, in the format of
+// sha256sum.
+func VerifyAsset(checksums []byte, name string, content []byte) error {
+	var expected string
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			expected = strings.ToLower(fields[0])
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if expected == "" {
+		return fmt.Errorf("release checksums do not list %s", name)
+	}
+	digest := sha256.Sum256(content)
+	if actual := hex.EncodeToString(digest[:]); actual != expected {
+		return fmt.Errorf("checksum of %s does not match, expected sha256:%s, got sha256:%s", name, expected, actual)
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+// Package signjob tracks the progress of signing multiple artifacts in a single
+// job, so that a failed job can resume without signing an artifact twice.
+package signjob
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/notaryproject/notation/internal/osutil"
+)
+
+// Result is the signing result of an artifact.
+type Result string
+
+const (
+	// ResultSuccess indicates that the artifact is successfully signed.
+	ResultSuccess Result = "success"
+
+	// ResultFailure indicates that the artifact failed to be signed.
+	ResultFailure Result = "failure"
+)
+
+// Entry is the signing result of a reference of the job.
+type Entry struct {
+	// Reference is the reference of the artifact as given to the job.
+	Reference string `json:"reference"`
+
+	// Digest is the digest the reference resolved to.
+	Digest string `json:"digest,omitempty"`
+
+	// Result is the signing result.
+	Result Result `json:"result"`
+
+	// Signature is the digest of the signature manifest pushed, if known.
+	Signature string `json:"signature,omitempty"`
+
+	// Error is the reason of a failed signing.
+	Error string `json:"error,omitempty"`
+}
+
+// Job is the state of a signing job.
+type Job struct {
+	// Key identifies the signing key of the job, i.e. the key name or the key
+	// ID of an on-demand key.
+	Key string `json:"key"`
+
+	// Entries are the latest results of the references of the job, one per
+	// reference.
+	Entries []Entry `json:"entries"`
+
+	path string
+
+	// index is the index of the entry of each reference in Entries.
+	index map[string]int
+}
+
+// Load loads the state of the job signing with key from path. A new job is
+// returned if the file does not exist. If path is empty, the state is kept in
+// memory only.
+func Load(path, key string) (*Job, error) {
+	job := &Job{
+		Key:   key,
+		path:  path,
+		index: make(map[string]int),
+	}
+	if path == "" {
+		return job, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return job, nil
+		}
+		return nil, fmt.Errorf("failed to read job state file: %w", err)
+	}
+	if err := json.Unmarshal(data, job); err != nil {
+		return nil, fmt.Errorf("malformed job state file %s: %w", path, err)
+	}
+	if job.Key != key {
+		// signatures of another key must not be mistaken for signatures of
+		// this key
+		return nil, fmt.Errorf("job state file %s was created for signing key %q, not %q", path, job.Key, key)
+	}
+	entries := job.Entries
+	job.Entries = nil
+	for _, entry := range entries {
+		job.record(entry)
+	}
+	return job, nil
+}
+
+// Done returns true if the reference resolving to digest has already been
+// signed successfully. Failed references and tags re-pushed to a new digest
+// are signed again.
+func (j *Job) Done(reference, digest string) bool {
+	entry, ok := j.Entry(reference)
+	return ok && entry.Result == ResultSuccess && entry.Digest == digest
+}
+
+// Entry returns the latest result of the reference, if any.
+func (j *Job) Entry(reference string) (Entry, bool) {
+	i, ok := j.index[reference]
+	if !ok {
+		return Entry{}, false
+	}
+	return j.Entries[i], true
+}
+
+// Record records the result of a reference, replacing its previous result,
+// and persists the state of the job.
+func (j *Job) Record(entry Entry) error {
+	j.record(entry)
+	if j.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(j, "", "    ")
+	if err != nil {
+		return err
+	}
+	return osutil.WriteFileAtomic(j.path, data, 0600)
+}
+
+// record records the result of a reference in memory.
+func (j *Job) record(entry Entry) {
+	if i, ok := j.index[entry.Reference]; ok {
+		j.Entries[i] = entry
+		return
+	}
+	j.index[entry.Reference] = len(j.Entries)
+	j.Entries = append(j.Entries, entry)
+}
+
+// Count returns the number of successful and failed entries.
+func (j *Job) Count() (succeeded, failed int) {
+	for _, entry := range j.Entries {
+		if entry.Result == ResultSuccess {
+			succeeded++
+		} else {
+			failed++
+		}
+	}
+	return succeeded, failed
+}
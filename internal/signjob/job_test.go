@@ -0,0 +1,72 @@
+package signjob
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestJob(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs", "job.json")
+	job, err := Load(path, "release-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Done("localhost:5000/net-monitor:v1", "sha256:abc") {
+		t.Fatal("expected v1 not to be done")
+	}
+	if err := job.Record(Entry{Reference: "localhost:5000/net-monitor:v1", Digest: "sha256:abc", Result: ResultSuccess, Signature: "sha256:sig"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := job.Record(Entry{Reference: "localhost:5000/net-logger:v1", Result: ResultFailure, Error: "timeout"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// resume from the persisted state
+	resumed, err := Load(path, "release-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resumed.Done("localhost:5000/net-monitor:v1", "sha256:abc") {
+		t.Fatalf("unexpected resumed entries: %+v", resumed.Entries)
+	}
+	if entry, _ := resumed.Entry("localhost:5000/net-monitor:v1"); entry.Signature != "sha256:sig" {
+		t.Fatalf("expected signature sha256:sig, got %q", entry.Signature)
+	}
+	// failed references are signed again
+	if resumed.Done("localhost:5000/net-logger:v1", "") {
+		t.Fatal("expected failed net-logger:v1 not to be done")
+	}
+	// a tag re-pushed to a new digest is signed again
+	if resumed.Done("localhost:5000/net-monitor:v1", "sha256:def") {
+		t.Fatal("expected re-pushed net-monitor:v1 not to be done")
+	}
+	if succeeded, failed := resumed.Count(); succeeded != 1 || failed != 1 {
+		t.Fatalf("expected 1 succeeded and 1 failed, got %d and %d", succeeded, failed)
+	}
+
+	// the result of a retried reference replaces its previous result
+	if err := resumed.Record(Entry{Reference: "localhost:5000/net-logger:v1", Digest: "sha256:123", Result: ResultSuccess}); err != nil {
+		t.Fatal(err)
+	}
+	if succeeded, failed := resumed.Count(); succeeded != 2 || failed != 0 || len(resumed.Entries) != 2 {
+		t.Fatalf("expected 2 succeeded and 0 failed, got %d and %d", succeeded, failed)
+	}
+
+	// a job cannot be resumed with another signing key
+	if _, err := Load(path, "test-key"); err == nil {
+		t.Fatal("expected error for key mismatch, got nil")
+	}
+}
+
+func TestJob_InMemory(t *testing.T) {
+	job, err := Load("", "release-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := job.Record(Entry{Reference: "localhost:5000/net-monitor:v1", Digest: "sha256:abc", Result: ResultSuccess}); err != nil {
+		t.Fatal(err)
+	}
+	if !job.Done("localhost:5000/net-monitor:v1", "sha256:abc") {
+		t.Fatal("expected v1 to be done")
+	}
+}
@@ -0,0 +1,25 @@
+// Package skipper provides the skip check of the verifiers wrapping a
+// notation.Verifier.
+package skipper
+
+import (
+	"context"
+
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+)
+
+// VerifySkipper is implemented by verifiers that can tell whether the
+// verification level of an artifact is skip.
+type VerifySkipper interface {
+	SkipVerify(ctx context.Context, opts notation.VerifierVerifyOptions) (bool, *trustpolicy.VerificationLevel, error)
+}
+
+// SkipVerify validates whether the verification level is skip with verifier.
+// The verification is not skipped if verifier is not a VerifySkipper.
+func SkipVerify(ctx context.Context, verifier notation.Verifier, opts notation.VerifierVerifyOptions) (bool, *trustpolicy.VerificationLevel, error) {
+	if skipper, ok := verifier.(VerifySkipper); ok {
+		return skipper.SkipVerify(ctx, opts)
+	}
+	return false, nil, nil
+}
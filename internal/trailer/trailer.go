@@ -0,0 +1,125 @@
+// Package trailer writes the summary trailer of notation sign and notation
+// verify, a final line on stderr in a fixed format for log scrapers which
+// cannot consume the JSON output, e.g.
+//
+//	notation: result=success signatures=3 duration=1.2s
+//
+// The format is stable across releases: fields are only appended, and are
+// never renamed, reordered or removed.
+package trailer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+	"github.com/notaryproject/notation/internal/skipper"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// results of the trailer
+const (
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+)
+
+// Summary is the summary of a command, written as the trailer if enabled. A
+// nil Summary discards the summary, so that callers do not need to check if
+// the trailer is enabled.
+type Summary struct {
+	start time.Time
+
+	mu         sync.Mutex
+	enabled    bool
+	signatures int
+}
+
+// New returns a summary of a command started at start.
+func New(start time.Time) *Summary {
+	return &Summary{start: start}
+}
+
+type contextKey struct{}
+
+// WithSummary returns a context carrying the summary.
+func WithSummary(ctx context.Context, s *Summary) context.Context {
+	return context.WithValue(ctx, contextKey{}, s)
+}
+
+// FromContext returns the summary of ctx, or nil if there is none.
+func FromContext(ctx context.Context) *Summary {
+	s, _ := ctx.Value(contextKey{}).(*Summary)
+	return s
+}
+
+// Enable enables the trailer, which is opt-in.
+func (s *Summary) Enable() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.enabled = true
+}
+
+// AddSignatures adds n to the number of signatures created or evaluated.
+func (s *Summary) AddSignatures(n int) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.signatures += n
+}
+
+// Write writes the trailer of the command failed with err, or succeeded if
+// err is nil, to w at the time now, if enabled.
+func (s *Summary) Write(w io.Writer, err error, now time.Time) error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.enabled {
+		return nil
+	}
+	result := ResultSuccess
+	if err != nil {
+		result = ResultFailure
+	}
+	_, writeErr := fmt.Fprintln(w, Format(result, s.signatures, now.Sub(s.start)))
+	return writeErr
+}
+
+// Format returns the trailer line of the result, the number of signatures
+// and the duration of a command, e.g.
+// "notation: result=success signatures=3 duration=1.2s". The duration is in
+// seconds with one decimal, regardless of its magnitude.
+func Format(result string, signatures int, duration time.Duration) string {
+	return fmt.Sprintf("notation: result=%s signatures=%d duration=%.1fs", result, signatures, duration.Seconds())
+}
+
+// Verifier wraps verifier to count the signatures evaluated in the summary.
+func (s *Summary) Verifier(verifier notation.Verifier) notation.Verifier {
+	return &countingVerifier{base: verifier, summary: s}
+}
+
+type countingVerifier struct {
+	base    notation.Verifier
+	summary *Summary
+}
+
+// SkipVerify validates whether the verification level is skip.
+func (v *countingVerifier) SkipVerify(ctx context.Context, opts notation.VerifierVerifyOptions) (bool, *trustpolicy.VerificationLevel, error) {
+	return skipper.SkipVerify(ctx, v.base, opts)
+}
+
+// Verify verifies the signature with the wrapped verifier and counts it.
+func (v *countingVerifier) Verify(ctx context.Context, desc ocispec.Descriptor, signature []byte, opts notation.VerifierVerifyOptions) (*notation.VerificationOutcome, error) {
+	v.summary.AddSignatures(1)
+	return v.base.Verify(ctx, desc, signature, opts)
+}
@@ -0,0 +1,70 @@
+package trailer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/notation-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+type mockVerifier struct{}
+
+func (mockVerifier) Verify(ctx context.Context, desc ocispec.Descriptor, signature []byte, opts notation.VerifierVerifyOptions) (*notation.VerificationOutcome, error) {
+	return &notation.VerificationOutcome{}, nil
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		result     string
+		signatures int
+		duration   time.Duration
+		want       string
+	}{
+		{ResultSuccess, 3, 1234 * time.Millisecond, "notation: result=success signatures=3 duration=1.2s"},
+		{ResultFailure, 0, 20 * time.Millisecond, "notation: result=failure signatures=0 duration=0.0s"},
+		{ResultSuccess, 1, 95 * time.Second, "notation: result=success signatures=1 duration=95.0s"},
+	}
+	for _, tt := range tests {
+		if got := Format(tt.result, tt.signatures, tt.duration); got != tt.want {
+			t.Errorf("Format() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestSummary(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	summary := New(start)
+	ctx := WithSummary(context.Background(), summary)
+	verifier := FromContext(ctx).Verifier(mockVerifier{})
+	for i := 0; i < 2; i++ {
+		if _, err := verifier.Verify(ctx, ocispec.Descriptor{}, nil, notation.VerifierVerifyOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := summary.Write(&buf, nil, start.Add(time.Second)); err != nil || buf.Len() != 0 {
+		t.Fatalf("expected no trailer unless enabled, got %q, %v", buf.String(), err)
+	}
+	summary.Enable()
+	if err := summary.Write(&buf, errors.New("failed"), start.Add(1500*time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+	if want := "notation: result=failure signatures=2 duration=1.5s\n"; buf.String() != want {
+		t.Fatalf("Write() = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestSummary_Nil(t *testing.T) {
+	summary := FromContext(context.Background())
+	summary.Enable()
+	summary.AddSignatures(1)
+	var buf bytes.Buffer
+	if err := summary.Write(&buf, nil, time.Now()); err != nil || buf.Len() != 0 {
+		t.Fatalf("expected a nil summary to write nothing, got %q, %v", buf.String(), err)
+	}
+}
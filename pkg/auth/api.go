@@ -11,3 +11,12 @@ type CredentialStore interface {
 	// Get retrieves credentials from the store for the given server
 	Get(serverAddress string) (auth.Credential, error)
 }
+
+// CredentialRotator is the interface that any credential rotator must
+// implement.
+type CredentialRotator interface {
+	// Rotate exchanges the credentials of the server for new ones
+	Rotate(serverAddress string, current auth.Credential) (auth.Credential, error)
+	// Revoke revokes the credentials of the server
+	Revoke(serverAddress string, cred auth.Credential) error
+}
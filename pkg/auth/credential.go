@@ -73,3 +73,18 @@ func newCredentialFromDockerCreds(dockerCreds *credentials.Credentials) auth.Cre
 	}
 	return credsConf
 }
+
+// newDockerCredsFromCredential creates new docker-cli credentials of the
+// server from an auth.Credential
+func newDockerCredsFromCredential(serverAddress string, authCreds auth.Credential) *credentials.Credentials {
+	creds := &credentials.Credentials{
+		ServerURL: serverAddress,
+		Username:  authCreds.Username,
+		Secret:    authCreds.Password,
+	}
+	if authCreds.RefreshToken != "" {
+		creds.Username = tokenUsername
+		creds.Secret = authCreds.RefreshToken
+	}
+	return creds
+}
@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/notaryproject/notation/pkg/configutil"
+	"oras.land/oras-go/v2/registry/remote/auth"
+)
+
+// dockerHubServerAddress is the server address under which Docker stores the
+// credentials of Docker Hub.
+const dockerHubServerAddress = "https://index.docker.io/v1/"
+
+// DockerServerAddress returns the server address under which Docker stores the
+// credentials of the registry.
+func DockerServerAddress(registry string) string {
+	if registry == "docker.io" || registry == "index.docker.io" || registry == "registry-1.docker.io" {
+		return dockerHubServerAddress
+	}
+	return registry
+}
+
+// GetDockerCredential returns the credential of the registry saved by the
+// Docker CLI, using the credential helpers configured in the Docker config
+// file or the credentials stored in the file itself.
+// It returns an empty credential if no credential is saved.
+func GetDockerCredential(ctx context.Context, registry string) (auth.Credential, error) {
+	dockerConfig, err := loadDockerConfig()
+	if err != nil {
+		return auth.EmptyCredential, err
+	}
+	serverAddress := DockerServerAddress(registry)
+	if helper, ok := dockerConfig.CredentialHelpers[serverAddress]; ok {
+		return newNativeAuthStore(ctx, helper).Get(serverAddress)
+	}
+	if dockerConfig.CredentialsStore != "" {
+		return newNativeAuthStore(ctx, dockerConfig.CredentialsStore).Get(serverAddress)
+	}
+	authConfig, ok := dockerConfig.AuthConfigs[serverAddress]
+	if !ok {
+		return auth.EmptyCredential, nil
+	}
+	return newCredentialFromDockerAuthConfig(authConfig)
+}
+
+// newCredentialFromDockerAuthConfig creates a new auth.Credential from the
+// credentials stored in the Docker config file
+func newCredentialFromDockerAuthConfig(authConfig configutil.DockerAuthConfig) (auth.Credential, error) {
+	if authConfig.IdentityToken != "" {
+		return auth.Credential{RefreshToken: authConfig.IdentityToken}, nil
+	}
+	if authConfig.Auth == "" {
+		return auth.EmptyCredential, nil
+	}
+	decoded, err := base64.StdEncoding.DecodeString(authConfig.Auth)
+	if err != nil {
+		return auth.EmptyCredential, fmt.Errorf("malformed credentials in Docker config file: %w", err)
+	}
+	username, password, ok := strings.Cut(string(decoded), ":")
+	if !ok {
+		return auth.EmptyCredential, errors.New("malformed credentials in Docker config file: expecting the format of username:password")
+	}
+	return auth.Credential{
+		Username: username,
+		Password: password,
+	}, nil
+}
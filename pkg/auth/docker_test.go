@@ -0,0 +1,44 @@
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"github.com/notaryproject/notation/pkg/configutil"
+	"oras.land/oras-go/v2/registry/remote/auth"
+)
+
+func TestGetDockerCredential_AuthConfigs(t *testing.T) {
+	loadDockerConfig = func() (*configutil.DockerConfigFile, error) {
+		return &configutil.DockerConfigFile{
+			AuthConfigs: map[string]configutil.DockerAuthConfig{
+				dockerHubServerAddress: {Auth: base64.StdEncoding.EncodeToString([]byte("user:pass:word"))},
+				"localhost:5000":       {IdentityToken: "token"},
+				"malformed.io":         {Auth: base64.StdEncoding.EncodeToString([]byte("user"))},
+			},
+		}, nil
+	}
+	defer func() { loadDockerConfig = configutil.LoadDockerConfig }()
+
+	tests := []struct {
+		registry string
+		expected auth.Credential
+	}{
+		{"docker.io", auth.Credential{Username: "user", Password: "pass:word"}},
+		{"localhost:5000", auth.Credential{RefreshToken: "token"}},
+		{"unknown.io", auth.EmptyCredential},
+	}
+	for _, tt := range tests {
+		cred, err := GetDockerCredential(context.Background(), tt.registry)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.registry, err)
+		}
+		if cred != tt.expected {
+			t.Fatalf("%s: expected credential %+v, got %+v", tt.registry, tt.expected, cred)
+		}
+	}
+	if _, err := GetDockerCredential(context.Background(), "malformed.io"); err == nil {
+		t.Fatal("expected error for malformed credentials, got nil")
+	}
+}
@@ -7,13 +7,19 @@ import (
 	"github.com/docker/docker-credential-helpers/client"
 	"github.com/docker/docker-credential-helpers/credentials"
 	"github.com/notaryproject/notation-go/config"
+	"github.com/notaryproject/notation-go/dir"
 	"github.com/notaryproject/notation-go/log"
+	"github.com/notaryproject/notation/internal/filelock"
 	"oras.land/oras-go/v2/registry/remote/auth"
 )
 
 const (
 	remoteCredentialsPrefix = "docker-credential-"
 	tokenUsername           = "<token>"
+
+	// credentialsLockPath is the path of the lock file of the credentials
+	// in the notation config directory, without the ".lock" extension.
+	credentialsLockPath = "credentials"
 )
 
 // var for unit testing.
@@ -64,18 +70,9 @@ func getConfiguredCredentialStore(c *config.Config, registryHostname string) str
 
 // Store saves credentials into the native store
 func (s *nativeAuthStore) Store(serverAddress string, authCreds auth.Credential) error {
-	creds := &credentials.Credentials{
-		ServerURL: serverAddress,
-		Username:  authCreds.Username,
-		Secret:    authCreds.Password,
-	}
-
-	if authCreds.RefreshToken != "" {
-		creds.Username = tokenUsername
-		creds.Secret = authCreds.RefreshToken
-	}
-
-	return client.Store(s.programFunc, creds)
+	return withCredentialsLock(func() error {
+		return client.Store(s.programFunc, newDockerCredsFromCredential(serverAddress, authCreds))
+	})
 }
 
 // Get retrieves credentials from the store for the given server
@@ -93,5 +90,25 @@ func (s *nativeAuthStore) Get(serverAddress string) (auth.Credential, error) {
 
 // Erase removes credentials from the store for the given server
 func (s *nativeAuthStore) Erase(serverAddress string) error {
-	return client.Erase(s.programFunc, serverAddress)
+	return withCredentialsLock(func() error {
+		return client.Erase(s.programFunc, serverAddress)
+	})
+}
+
+// List lists the server addresses and user names of the credentials saved in
+// the native store
+func (s *nativeAuthStore) List() (map[string]string, error) {
+	return client.List(s.programFunc)
+}
+
+// withCredentialsLock executes fn holding the lock of the credentials, so that
+// parallel notation processes do not store or erase credentials with the
+// credential helpers at once, as helpers may rewrite a shared store without
+// locking.
+func withCredentialsLock(fn func() error) error {
+	path, err := dir.ConfigFS().SysPath(credentialsLockPath)
+	if err != nil {
+		return err
+	}
+	return filelock.WithLock(path, fn)
 }
@@ -5,12 +5,16 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/docker/docker-credential-helpers/client"
 	"github.com/docker/docker-credential-helpers/credentials"
 	"github.com/notaryproject/notation-go/config"
+	"github.com/notaryproject/notation-go/dir"
+	"github.com/notaryproject/notation/internal/filelock"
 	"oras.land/oras-go/v2/registry/remote/auth"
 )
 
@@ -94,6 +98,11 @@ func mockCommandFn(args ...string) client.Program {
 }
 
 func TestNativeStore_StoreGetErase(t *testing.T) {
+	defer func(oldDir string) {
+		dir.UserConfigDir = oldDir
+	}(dir.UserConfigDir)
+	dir.UserConfigDir = t.TempDir()
+
 	creds := auth.Credential{
 		Username: validUsername,
 		Password: validPassword,
@@ -132,6 +141,11 @@ func TestNativeStore_StoreGetErase(t *testing.T) {
 }
 
 func TestNativeStore_StoreIdentityToken(t *testing.T) {
+	defer func(oldDir string) {
+		dir.UserConfigDir = oldDir
+	}(dir.UserConfigDir)
+	dir.UserConfigDir = t.TempDir()
+
 	creds := auth.Credential{
 		RefreshToken: validIdentityToken,
 	}
@@ -198,3 +212,27 @@ func TestNativeStore_GetCredentialsStore_HelperSet(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 }
+
+func TestNativeStore_StoreLocked(t *testing.T) {
+	defer func(oldDir string) {
+		dir.UserConfigDir = oldDir
+	}(dir.UserConfigDir)
+	dir.UserConfigDir = t.TempDir()
+	defer func(timeout time.Duration) {
+		filelock.Timeout = timeout
+	}(filelock.Timeout)
+	filelock.Timeout = 50 * time.Millisecond
+
+	// credentials held by another notation process are not stored
+	lock, err := filelock.Acquire(filepath.Join(dir.UserConfigDir, credentialsLockPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lock.Release()
+	s := &nativeAuthStore{
+		programFunc: mockCommandFn,
+	}
+	if err := s.Store(validServerAddress, auth.Credential{Username: validUsername, Password: validPassword}); err == nil || !strings.Contains(err.Error(), "locked by another notation process") {
+		t.Fatalf("expected lock timeout, got %v", err)
+	}
+}
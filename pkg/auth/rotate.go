@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/docker/docker-credential-helpers/client"
+	"github.com/docker/docker-credential-helpers/credentials"
+	"github.com/notaryproject/notation-go/log"
+	"oras.land/oras-go/v2/registry/remote/auth"
+)
+
+const credentialRotatorPrefix = "notation-credential-rotator-"
+
+// Rotator exchanges the credentials of a registry for new ones by calling
+// the registry specific APIs through a credential rotator program.
+//
+// Credential rotators follow the protocol of docker credential helpers. The
+// program named "notation-credential-rotator-{name}" is run with the action
+// as the argument, and the credentials in JSON, i.e. {"ServerURL": "...",
+// "Username": "...", "Secret": "..."}, on stdin. The actions are:
+//   - rotate: print the new credentials in JSON on stdout.
+//   - revoke: revoke the credentials, which are no longer used. Rotators of
+//     registries revoking the credentials on rotation exit successfully.
+type Rotator struct {
+	programFunc client.ProgramFunc
+}
+
+// NewRotator returns a Rotator running the credential rotator program of the
+// given name.
+func NewRotator(ctx context.Context, name string) *Rotator {
+	program := credentialRotatorPrefix + name
+	log.GetLogger(ctx).Infoln("Executing credential rotator program:", program)
+	return &Rotator{
+		programFunc: client.NewShellProgramFunc(program),
+	}
+}
+
+// compile time check
+var _ CredentialRotator = (*Rotator)(nil)
+
+// Rotate exchanges the credentials of the server for new ones.
+func (r *Rotator) Rotate(serverAddress string, current auth.Credential) (auth.Credential, error) {
+	out, err := r.run("rotate", serverAddress, current)
+	if err != nil {
+		return auth.EmptyCredential, err
+	}
+	var creds credentials.Credentials
+	if err := json.NewDecoder(bytes.NewReader(out)).Decode(&creds); err != nil {
+		return auth.EmptyCredential, fmt.Errorf("credential rotator returned malformed credentials: %w", err)
+	}
+	if creds.Secret == "" {
+		return auth.EmptyCredential, errors.New("credential rotator returned no secret")
+	}
+	return newCredentialFromDockerCreds(&creds), nil
+}
+
+// Revoke revokes the credentials of the server.
+func (r *Rotator) Revoke(serverAddress string, cred auth.Credential) error {
+	_, err := r.run("revoke", serverAddress, cred)
+	return err
+}
+
+func (r *Rotator) run(action, serverAddress string, cred auth.Credential) ([]byte, error) {
+	input, err := json.Marshal(newDockerCredsFromCredential(serverAddress, cred))
+	if err != nil {
+		return nil, err
+	}
+	program := r.programFunc(action)
+	program.Input(bytes.NewReader(input))
+	out, err := program.Output()
+	if err != nil {
+		if message := strings.TrimSpace(string(out)); message != "" {
+			return nil, fmt.Errorf("credential rotator failed to %s the credentials: %w: %s", action, err, message)
+		}
+		return nil, fmt.Errorf("credential rotator failed to %s the credentials: %w", action, err)
+	}
+	return out, nil
+}
@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/docker/docker-credential-helpers/client"
+	"github.com/docker/docker-credential-helpers/credentials"
+	"oras.land/oras-go/v2/registry/remote/auth"
+)
+
+// mockRotatorCommand simulates a credential rotator issuing a new secret for
+// the username and recording the revoked credentials.
+type mockRotatorCommand struct {
+	arg     string
+	input   io.Reader
+	revoked *[]credentials.Credentials
+}
+
+func (m *mockRotatorCommand) Output() ([]byte, error) {
+	var creds credentials.Credentials
+	if err := json.NewDecoder(m.input).Decode(&creds); err != nil {
+		return []byte("malformed input"), errCommandExited
+	}
+	switch m.arg {
+	case "rotate":
+		if creds.Secret == "" {
+			return []byte("no secret"), errCommandExited
+		}
+		if creds.Username == tokenUsername {
+			return json.Marshal(credentials.Credentials{Username: tokenUsername, Secret: creds.Secret + "-rotated"})
+		}
+		return json.Marshal(credentials.Credentials{Username: creds.Username, Secret: creds.Secret + "-rotated"})
+	case "revoke":
+		*m.revoked = append(*m.revoked, creds)
+		return nil, nil
+	}
+	return []byte("unknown action"), errCommandExited
+}
+
+func (m *mockRotatorCommand) Input(in io.Reader) {
+	m.input = in
+}
+
+func TestRotator(t *testing.T) {
+	var revoked []credentials.Credentials
+	rotator := &Rotator{programFunc: func(args ...string) client.Program {
+		return &mockRotatorCommand{arg: args[0], revoked: &revoked}
+	}}
+
+	rotated, err := rotator.Rotate(validServerAddress, auth.Credential{Username: validUsername, Password: validPassword})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (auth.Credential{Username: validUsername, Password: validPassword + "-rotated"}); rotated != want {
+		t.Fatalf("Rotate() = %v, want %v", rotated, want)
+	}
+	rotated, err = rotator.Rotate(validServerAddress, auth.Credential{RefreshToken: validIdentityToken})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (auth.Credential{RefreshToken: validIdentityToken + "-rotated"}); rotated != want {
+		t.Fatalf("Rotate() = %v, want %v", rotated, want)
+	}
+	if _, err := rotator.Rotate(validServerAddress, auth.EmptyCredential); err == nil {
+		t.Fatal("expected error for a failed rotation, got nil")
+	}
+
+	if err := rotator.Revoke(validServerAddress, auth.Credential{Username: validUsername, Password: validPassword}); err != nil {
+		t.Fatal(err)
+	}
+	want := credentials.Credentials{ServerURL: validServerAddress, Username: validUsername, Secret: validPassword}
+	if len(revoked) != 1 || revoked[0] != want {
+		t.Fatalf("expected revoked credentials %v, got %v", want, revoked)
+	}
+}
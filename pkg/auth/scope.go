@@ -0,0 +1,98 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+
+	"oras.land/oras-go/v2/registry"
+	"oras.land/oras-go/v2/registry/remote/auth"
+)
+
+// credentialLister is implemented by credential stores which are able to list
+// the server addresses with saved credentials.
+type credentialLister interface {
+	List() (map[string]string, error)
+}
+
+// SplitServerAddress splits a login server address into the registry and an
+// optional repository namespace, e.g. "registry.example.com/team-a" is split
+// into "registry.example.com" and "team-a".
+func SplitServerAddress(serverAddress string) (string, string, error) {
+	registryName, namespace, found := strings.Cut(serverAddress, "/")
+	if !found {
+		return registryName, "", nil
+	}
+	namespace = strings.TrimSuffix(namespace, "/*")
+	ref := registry.Reference{
+		Registry:   registryName,
+		Repository: namespace,
+	}
+	if err := ref.ValidateRegistry(); err != nil {
+		return "", "", err
+	}
+	if err := ref.ValidateRepository(); err != nil {
+		return "", "", fmt.Errorf("invalid namespace %q of server address %q: %w", namespace, serverAddress, err)
+	}
+	return registryName, namespace, nil
+}
+
+// CredentialKey returns the server address under which the credentials of a
+// login to the registry, optionally scoped to the repository namespace, are
+// saved in credential stores. It is the form looked up by GetScopedCredential,
+// e.g. "registry.example.com/team-a" for a login to
+// "registry.example.com/team-a/*".
+func CredentialKey(registryName, namespace string) string {
+	if namespace == "" {
+		return registryName
+	}
+	return registryName + "/" + namespace
+}
+
+// ScopedServerAddresses returns the server addresses under which the
+// credentials for the repository may be saved, ordered from the narrowest
+// namespace to the registry itself.
+// For example, for repository "team-a/app" in "registry.example.com", the
+// addresses are "registry.example.com/team-a/app",
+// "registry.example.com/team-a" and "registry.example.com".
+func ScopedServerAddresses(registryName, repository string) []string {
+	var addresses []string
+	for repository != "" {
+		addresses = append(addresses, registryName+"/"+repository)
+		index := strings.LastIndex(repository, "/")
+		if index < 0 {
+			break
+		}
+		repository = repository[:index]
+	}
+	return append(addresses, registryName)
+}
+
+// GetScopedCredential retrieves the credentials with the narrowest namespace
+// covering the repository from the store, falling back to the credentials of
+// the registry.
+// If the store is able to list the saved server addresses, only the saved
+// namespaces are queried.
+func GetScopedCredential(store CredentialStore, registryName, repository string) (auth.Credential, error) {
+	addresses := ScopedServerAddresses(registryName, repository)
+	if lister, ok := store.(credentialLister); ok && len(addresses) > 1 {
+		if saved, err := lister.List(); err == nil {
+			var listed []string
+			for _, address := range addresses[:len(addresses)-1] {
+				if _, ok := saved[address]; ok {
+					listed = append(listed, address)
+				}
+			}
+			addresses = append(listed, registryName)
+		}
+	}
+	for _, address := range addresses {
+		cred, err := store.Get(address)
+		if err != nil {
+			return auth.EmptyCredential, err
+		}
+		if cred != auth.EmptyCredential {
+			return cred, nil
+		}
+	}
+	return auth.EmptyCredential, nil
+}
@@ -0,0 +1,192 @@
+package auth
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"oras.land/oras-go/v2/registry/remote/auth"
+)
+
+type mapStore struct {
+	creds   map[string]auth.Credential
+	queried []string
+}
+
+func (s *mapStore) Store(serverAddress string, cred auth.Credential) error {
+	s.creds[serverAddress] = cred
+	return nil
+}
+
+func (s *mapStore) Erase(serverAddress string) error {
+	delete(s.creds, serverAddress)
+	return nil
+}
+
+func (s *mapStore) Get(serverAddress string) (auth.Credential, error) {
+	s.queried = append(s.queried, serverAddress)
+	return s.creds[serverAddress], nil
+}
+
+type listingMapStore struct {
+	mapStore
+	listErr error
+}
+
+func (s *listingMapStore) List() (map[string]string, error) {
+	if s.listErr != nil {
+		return nil, s.listErr
+	}
+	saved := make(map[string]string)
+	for address, cred := range s.creds {
+		saved[address] = cred.Username
+	}
+	return saved, nil
+}
+
+func TestSplitServerAddress(t *testing.T) {
+	tests := []struct {
+		address   string
+		registry  string
+		namespace string
+		wantErr   bool
+	}{
+		{address: "registry.example.com", registry: "registry.example.com"},
+		{address: "localhost:5000", registry: "localhost:5000"},
+		{address: "registry.example.com/team-a", registry: "registry.example.com", namespace: "team-a"},
+		{address: "registry.example.com/team-a/*", registry: "registry.example.com", namespace: "team-a"},
+		{address: "registry.example.com/team-a/app", registry: "registry.example.com", namespace: "team-a/app"},
+		{address: "registry.example.com/Team-A", wantErr: true},
+		{address: "registry.example.com/", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			registry, namespace, err := SplitServerAddress(tt.address)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SplitServerAddress() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if registry != tt.registry || namespace != tt.namespace {
+				t.Fatalf("SplitServerAddress() = %q, %q, want %q, %q", registry, namespace, tt.registry, tt.namespace)
+			}
+		})
+	}
+}
+
+func TestScopedServerAddresses(t *testing.T) {
+	got := ScopedServerAddresses("registry.example.com", "team-a/app/web")
+	want := []string{
+		"registry.example.com/team-a/app/web",
+		"registry.example.com/team-a/app",
+		"registry.example.com/team-a",
+		"registry.example.com",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("ScopedServerAddresses() = %v, want %v", got, want)
+	}
+	if got := ScopedServerAddresses("registry.example.com", ""); !reflect.DeepEqual(got, []string{"registry.example.com"}) {
+		t.Fatalf("ScopedServerAddresses() = %v, want only the registry", got)
+	}
+}
+
+func TestGetScopedCredential(t *testing.T) {
+	registryCred := auth.Credential{Username: "registry", Password: "secret"}
+	teamCred := auth.Credential{Username: "team-a", Password: "secret"}
+	newCreds := func() map[string]auth.Credential {
+		return map[string]auth.Credential{
+			"registry.example.com":        registryCred,
+			"registry.example.com/team-a": teamCred,
+		}
+	}
+
+	t.Run("narrowest namespace", func(t *testing.T) {
+		store := &mapStore{creds: newCreds()}
+		cred, err := GetScopedCredential(store, "registry.example.com", "team-a/app")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cred != teamCred {
+			t.Fatalf("expected %+v, got %+v", teamCred, cred)
+		}
+	})
+
+	t.Run("fall back to registry", func(t *testing.T) {
+		store := &mapStore{creds: newCreds()}
+		cred, err := GetScopedCredential(store, "registry.example.com", "team-b/app")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cred != registryCred {
+			t.Fatalf("expected %+v, got %+v", registryCred, cred)
+		}
+	})
+
+	t.Run("query saved namespaces only", func(t *testing.T) {
+		store := &listingMapStore{mapStore: mapStore{creds: newCreds()}}
+		cred, err := GetScopedCredential(store, "registry.example.com", "team-a/app/web")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cred != teamCred {
+			t.Fatalf("expected %+v, got %+v", teamCred, cred)
+		}
+		if want := []string{"registry.example.com/team-a"}; !reflect.DeepEqual(store.queried, want) {
+			t.Fatalf("expected queries %v, got %v", want, store.queried)
+		}
+	})
+
+	t.Run("list failure", func(t *testing.T) {
+		store := &listingMapStore{mapStore: mapStore{creds: newCreds()}, listErr: errors.New("list not supported")}
+		cred, err := GetScopedCredential(store, "registry.example.com", "team-a/app")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cred != teamCred {
+			t.Fatalf("expected %+v, got %+v", teamCred, cred)
+		}
+	})
+}
+
+func TestScopedCredential_RoundTrip(t *testing.T) {
+	store := &listingMapStore{mapStore: mapStore{creds: map[string]auth.Credential{}}}
+	cred := auth.Credential{Username: "team-a", Password: "secret"}
+
+	for _, serverAddress := range []string{"registry.example.com/team-a/*", "registry.example.com/team-a"} {
+		// login
+		registryName, namespace, err := SplitServerAddress(serverAddress)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := store.Store(CredentialKey(registryName, namespace), cred); err != nil {
+			t.Fatal(err)
+		}
+
+		// the credentials are found for repositories in the namespace only
+		got, err := GetScopedCredential(store, "registry.example.com", "team-a/app")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != cred {
+			t.Fatalf("%s: expected scoped credentials, got %+v", serverAddress, got)
+		}
+		if got, _ := GetScopedCredential(store, "registry.example.com", "team-b/app"); got != auth.EmptyCredential {
+			t.Fatalf("%s: expected no credentials for another namespace, got %+v", serverAddress, got)
+		}
+
+		// logout
+		if err := store.Erase(CredentialKey(registryName, namespace)); err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := GetScopedCredential(store, "registry.example.com", "team-a/app"); got != auth.EmptyCredential {
+			t.Fatalf("%s: expected credentials to be erased, got %+v", serverAddress, got)
+		}
+	}
+}
+
+func TestCredentialKey(t *testing.T) {
+	if got := CredentialKey("registry.example.com", ""); got != "registry.example.com" {
+		t.Fatalf("unexpected key %q", got)
+	}
+	if got := CredentialKey("registry.example.com", "team-a"); got != "registry.example.com/team-a" {
+		t.Fatalf("unexpected key %q", got)
+	}
+}
@@ -0,0 +1,135 @@
+package configutil
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/notaryproject/notation-go/dir"
+	"github.com/notaryproject/notation/internal/filelock"
+	"github.com/notaryproject/notation/internal/osutil"
+	"oras.land/oras-go/v2/registry"
+)
+
+// aliasNamePattern is the pattern of alias names. Alias names never contain
+// "/", so that they are not mistaken for registry references.
+var aliasNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// ErrAliasNotFound indicates that the alias is not found.
+var ErrAliasNotFound = errors.New("alias not found")
+
+// ValidateAlias validates the name of an alias and the repository it stands
+// for, e.g. "prod-api" for "registry.example.com/team/api".
+func ValidateAlias(name, repository string) error {
+	if !aliasNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid alias name %q, alias names start with a letter or a digit, followed by letters, digits, \".\", \"_\" or \"-\"", name)
+	}
+	ref, err := registry.ParseReference(repository)
+	if err != nil {
+		return fmt.Errorf("invalid repository %q of alias %q: %w", repository, name, err)
+	}
+	if ref.Reference != "" {
+		return fmt.Errorf("invalid repository %q of alias %q: expecting a repository without tag or digest", repository, name)
+	}
+	return nil
+}
+
+// ExpandAlias replaces the alias at the beginning of reference by its
+// repository, keeping the tag or the digest, e.g. "prod-api:v1" expands to
+// "registry.example.com/team/api:v1". reference is returned unchanged if it
+// does not start with an alias.
+func ExpandAlias(reference string) (string, error) {
+	name, suffix := reference, ""
+	if i := strings.IndexAny(reference, ":@"); i >= 0 {
+		name, suffix = reference[:i], reference[i:]
+	}
+	if strings.Contains(name, "/") {
+		return reference, nil
+	}
+	cliConfig, err := LoadCLIConfigOnce()
+	if err != nil {
+		return "", err
+	}
+	repository, ok := cliConfig.Aliases[name]
+	if !ok {
+		return reference, nil
+	}
+	return repository + suffix, nil
+}
+
+// SetAlias adds or updates the alias in config.json.
+func SetAlias(name, repository string) error {
+	if err := ValidateAlias(name, repository); err != nil {
+		return err
+	}
+	return updateAliases(func(aliases map[string]string) error {
+		aliases[name] = repository
+		return nil
+	})
+}
+
+// RemoveAlias removes the alias from config.json.
+func RemoveAlias(name string) error {
+	return updateAliases(func(aliases map[string]string) error {
+		if _, ok := aliases[name]; !ok {
+			return fmt.Errorf("%w: %s", ErrAliasNotFound, name)
+		}
+		delete(aliases, name)
+		return nil
+	})
+}
+
+// updateAliases executes fn on the aliases of config.json and saves them,
+// keeping the other fields of config.json as is. config.json is locked until
+// saved, so that parallel notation processes do not lose updates.
+func updateAliases(fn func(aliases map[string]string) error) error {
+	path, err := dir.ConfigFS().SysPath(dir.PathConfigFile)
+	if err != nil {
+		return err
+	}
+	return filelock.WithLock(path, func() error {
+		return updateAliasesLocked(path, fn)
+	})
+}
+
+// updateAliasesLocked updates the aliases of config.json of path, which is
+// locked by the caller.
+func updateAliasesLocked(path string, fn func(aliases map[string]string) error) error {
+	fields := make(map[string]json.RawMessage)
+	content, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(content, &fields); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	case !errors.Is(err, fs.ErrNotExist):
+		return err
+	}
+	aliases := make(map[string]string)
+	if raw, ok := fields["aliases"]; ok {
+		if err := json.Unmarshal(raw, &aliases); err != nil {
+			return fmt.Errorf("failed to parse the aliases in %s: %w", path, err)
+		}
+	}
+	if err := fn(aliases); err != nil {
+		return err
+	}
+	if len(aliases) == 0 {
+		delete(fields, "aliases")
+	} else {
+		raw, err := json.Marshal(aliases)
+		if err != nil {
+			return err
+		}
+		fields["aliases"] = raw
+	}
+	content, err = json.MarshalIndent(fields, "", "    ")
+	if err != nil {
+		return err
+	}
+	return osutil.WriteFileAtomic(path, append(content, '\n'), 0600)
+}
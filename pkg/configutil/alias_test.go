@@ -0,0 +1,91 @@
+package configutil
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/notaryproject/notation-go/dir"
+)
+
+func TestAliases(t *testing.T) {
+	defer func(oldDir string) {
+		dir.UserConfigDir = oldDir
+		cliConfigOnce = sync.Once{}
+	}(dir.UserConfigDir)
+	dir.UserConfigDir = t.TempDir()
+	configPath := filepath.Join(dir.UserConfigDir, dir.PathConfigFile)
+	if err := os.WriteFile(configPath, []byte(`{"insecureRegistries":["localhost:5000"]}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SetAlias("prod-api", "registry.example.com/team/api"); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetAlias("local", "localhost:5000/net-monitor"); err != nil {
+		t.Fatal(err)
+	}
+	cliConfigOnce = sync.Once{}
+	for reference, want := range map[string]string{
+		"prod-api":                         "registry.example.com/team/api",
+		"prod-api:v1":                      "registry.example.com/team/api:v1",
+		"local@sha256:abc":                 "localhost:5000/net-monitor@sha256:abc",
+		"unknown:v1":                       "unknown:v1",
+		"registry.example.com/prod-api:v1": "registry.example.com/prod-api:v1",
+		"localhost:5000/net-monitor:v1":    "localhost:5000/net-monitor:v1",
+	} {
+		got, err := ExpandAlias(reference)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Fatalf("ExpandAlias(%q) = %q, want %q", reference, got, want)
+		}
+	}
+
+	// the other fields of config.json are kept
+	content, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(content, &fields); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := fields["insecureRegistries"]; !ok {
+		t.Fatalf("insecureRegistries dropped from config.json: %s", content)
+	}
+
+	if err := RemoveAlias("prod-api"); err != nil {
+		t.Fatal(err)
+	}
+	if err := RemoveAlias("prod-api"); !errors.Is(err, ErrAliasNotFound) {
+		t.Fatalf("RemoveAlias() error = %v, want %v", err, ErrAliasNotFound)
+	}
+	config, err := LoadCLIConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(config.Aliases) != 1 || config.Aliases["local"] != "localhost:5000/net-monitor" {
+		t.Fatalf("unexpected aliases: %v", config.Aliases)
+	}
+}
+
+func TestValidateAlias(t *testing.T) {
+	for _, tt := range []struct {
+		name, repository string
+	}{
+		{"team/api", "registry.example.com/team/api"},
+		{"api:v1", "registry.example.com/team/api"},
+		{"-api", "registry.example.com/team/api"},
+		{"api", "api"},
+		{"api", "registry.example.com/team/api:v1"},
+	} {
+		if err := ValidateAlias(tt.name, tt.repository); err == nil {
+			t.Fatalf("expected error for alias %q of %q", tt.name, tt.repository)
+		}
+	}
+}
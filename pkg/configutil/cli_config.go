@@ -0,0 +1,251 @@
+package configutil
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/notaryproject/notation-go/dir"
+	"github.com/notaryproject/notation/internal/httputil"
+)
+
+var (
+	// cliConfigInfo is the notation CLI extension fields of the config.json
+	// data
+	cliConfigInfo *CLIConfig
+	cliConfigOnce sync.Once
+)
+
+// CLIConfig contains the notation CLI extension fields of config.json, which
+// live side by side with the fields defined by notation-go and are ignored by
+// notation-go.
+type CLIConfig struct {
+	// ProductionRegistries are the registries or repository namespaces, e.g.
+	// "registry.example.com" or "registry.example.com/prod", whose artifacts
+	// must not be signed with test signing keys.
+	ProductionRegistries []string `json:"productionRegistries,omitempty"`
+
+	// Aliases are the short names of repositories, e.g. "prod-api" for
+	// "registry.example.com/team/api", usable in place of the repository in
+	// references.
+	Aliases map[string]string `json:"aliases,omitempty"`
+
+	// OutputAnnotations are the annotation keys of the subject manifests
+	// whose values are echoed in the structured results of verifications,
+	// e.g. "org.opencontainers.image.revision". A key ending with "*"
+	// matches all keys with the prefix before it.
+	OutputAnnotations []string `json:"outputAnnotations,omitempty"`
+
+	// CredentialRotators are the names of the credential rotators exchanging
+	// the credentials of registries for new ones, indexed by the registry
+	// hostnames, e.g. {"registry.example.com": "acme"} for the program
+	// "notation-credential-rotator-acme".
+	CredentialRotators map[string]string `json:"credentialRotators,omitempty"`
+
+	// MaxSignatureAttempts is the maximum number of signatures fetched and
+	// evaluated per artifact by verifications. Unlimited if 0.
+	MaxSignatureAttempts int `json:"maxSignatureAttempts,omitempty"`
+
+	// RegistryCapabilityCacheTTL is the duration for which the capabilities
+	// of registries probed, such as the support of the Referrers API, are
+	// cached, e.g. "24h". The capabilities are not cached if "0s".
+	RegistryCapabilityCacheTTL string `json:"registryCapabilityCacheTTL,omitempty"`
+
+	// InsecureRegistryAllowList are the registries, e.g.
+	// "registry.example.com" or "registry.example.com:5000", which may be
+	// accessed via plain HTTP or without verifying their TLS certificates when
+	// requested by flags. A registry without port allows all ports of the
+	// host.
+	InsecureRegistryAllowList []string `json:"insecureRegistryAllowList,omitempty"`
+
+	// UserAgent is the User-Agent header of the requests to registries, in
+	// place of "notation/{version}".
+	UserAgent string `json:"userAgent,omitempty"`
+
+	// RegistryHeaders are the extra headers of the requests to registries,
+	// e.g. tenant IDs or tracing headers, indexed by the registries, e.g.
+	// {"registry.example.com": {"X-Tenant-Id": "contoso"}}. The headers of
+	// registry "*" are sent to all registries. A registry without port matches
+	// all ports of the host.
+	RegistryHeaders map[string]map[string]string `json:"registryHeaders,omitempty"`
+
+	// TimestampURL is the URL of the RFC 3161 timestamp authority
+	// countersigning the signatures of "notation sign" when flag
+	// "--timestamp-url" is not set.
+	TimestampURL string `json:"timestampURL,omitempty"`
+
+	// TimestampRootCert is the path of the PEM or DER file of the root
+	// certificates of the timestamp authority of TimestampURL, used when flag
+	// "--timestamp-root-cert" is not set.
+	TimestampRootCert string `json:"timestampRootCert,omitempty"`
+
+	// CRLCacheTTL is the duration for which the CRLs fetched by revocation
+	// checks are cached, e.g. "24h". The CRLs are not cached if "0s".
+	CRLCacheTTL string `json:"crlCacheTTL,omitempty"`
+
+	// CRLCacheMaxSize is the maximum total size in bytes of the cached CRLs,
+	// beyond which the least recently fetched CRLs are evicted. 128 MiB if 0.
+	CRLCacheMaxSize int64 `json:"crlCacheMaxSize,omitempty"`
+}
+
+// LoadCLIConfig reads the notation CLI extension fields of config.json, or
+// returns an empty config if not found.
+func LoadCLIConfig() (*CLIConfig, error) {
+	file, err := dir.ConfigFS().Open(dir.PathConfigFile)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return &CLIConfig{}, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+	var config CLIConfig
+	if err := json.NewDecoder(file).Decode(&config); err != nil {
+		return nil, err
+	}
+	if config.MaxSignatureAttempts < 0 {
+		return nil, fmt.Errorf("maxSignatureAttempts of %s must not be negative, got %d", dir.PathConfigFile, config.MaxSignatureAttempts)
+	}
+	if config.RegistryCapabilityCacheTTL != "" {
+		ttl, err := time.ParseDuration(config.RegistryCapabilityCacheTTL)
+		if err != nil {
+			return nil, fmt.Errorf("registryCapabilityCacheTTL of %s is not a valid duration: %w", dir.PathConfigFile, err)
+		}
+		if ttl < 0 {
+			return nil, fmt.Errorf("registryCapabilityCacheTTL of %s must not be negative, got %s", dir.PathConfigFile, config.RegistryCapabilityCacheTTL)
+		}
+	}
+	if config.CRLCacheTTL != "" {
+		ttl, err := time.ParseDuration(config.CRLCacheTTL)
+		if err != nil {
+			return nil, fmt.Errorf("crlCacheTTL of %s is not a valid duration: %w", dir.PathConfigFile, err)
+		}
+		if ttl < 0 {
+			return nil, fmt.Errorf("crlCacheTTL of %s must not be negative, got %s", dir.PathConfigFile, config.CRLCacheTTL)
+		}
+	}
+	if config.CRLCacheMaxSize < 0 {
+		return nil, fmt.Errorf("crlCacheMaxSize of %s must not be negative, got %d", dir.PathConfigFile, config.CRLCacheMaxSize)
+	}
+	if config.UserAgent != "" {
+		if err := httputil.ValidateUserAgent(config.UserAgent); err != nil {
+			return nil, fmt.Errorf("userAgent of %s is invalid: %w", dir.PathConfigFile, err)
+		}
+	}
+	for registry, headers := range config.RegistryHeaders {
+		for name, value := range headers {
+			if err := httputil.ValidateHeader(name, value); err != nil {
+				return nil, fmt.Errorf("registryHeaders of registry %q of %s are invalid: %w", registry, dir.PathConfigFile, err)
+			}
+		}
+	}
+	if config.TimestampURL != "" {
+		if err := ValidateTimestampURL(config.TimestampURL); err != nil {
+			return nil, fmt.Errorf("timestampURL of %s is invalid: %w", dir.PathConfigFile, err)
+		}
+	}
+	return &config, nil
+}
+
+// ValidateTimestampURL checks that rawURL is an absolute HTTP or HTTPS URL of
+// a timestamp authority.
+func ValidateTimestampURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%q is not an HTTP or HTTPS URL", rawURL)
+	}
+	return nil
+}
+
+// LoadCLIConfigOnce returns the previously read notation CLI extension fields
+// of config.json, reading them on the first call.
+// The returned config is only suitable for read only scenarios for short-lived
+// processes.
+func LoadCLIConfigOnce() (*CLIConfig, error) {
+	var err error
+	cliConfigOnce.Do(func() {
+		cliConfigInfo, err = LoadCLIConfig()
+	})
+	return cliConfigInfo, err
+}
+
+// MatchProductionRegistry returns the production registry containing
+// reference, if any.
+func (c *CLIConfig) MatchProductionRegistry(reference string) (string, bool) {
+	for _, registry := range c.ProductionRegistries {
+		if matchRepositoryPrefix(registry, reference) {
+			return registry, true
+		}
+	}
+	return "", false
+}
+
+// SelectOutputAnnotations returns the annotations whose keys match the output
+// annotation keys, or nil if none matches.
+func (c *CLIConfig) SelectOutputAnnotations(annotations map[string]string) map[string]string {
+	var selected map[string]string
+	for key, value := range annotations {
+		for _, pattern := range c.OutputAnnotations {
+			if key == pattern || (strings.HasSuffix(pattern, "*") && strings.HasPrefix(key, strings.TrimSuffix(pattern, "*"))) {
+				if selected == nil {
+					selected = make(map[string]string)
+				}
+				selected[key] = value
+				break
+			}
+		}
+	}
+	return selected
+}
+
+// AllowsInsecureRegistry returns true if the registry, in the format of
+// host[:port], is in the insecure registry allow-list.
+func (c *CLIConfig) AllowsInsecureRegistry(registry string) bool {
+	host := registry
+	if h, _, err := net.SplitHostPort(registry); err == nil {
+		host = h
+	}
+	for _, allowed := range c.InsecureRegistryAllowList {
+		if strings.EqualFold(allowed, registry) || strings.EqualFold(allowed, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// RegistryHeadersOf returns the extra headers of the requests to the registry,
+// in the format of host[:port]. The headers of the registry with port take
+// precedence over the headers of its host, which take precedence over the
+// headers of all registries.
+func (c *CLIConfig) RegistryHeadersOf(registry string) http.Header {
+	header := http.Header{}
+	host := registry
+	if h, _, err := net.SplitHostPort(registry); err == nil {
+		host = h
+	}
+	keys := []string{"*", host}
+	if registry != host {
+		keys = append(keys, registry)
+	}
+	for _, key := range keys {
+		for configured, headers := range c.RegistryHeaders {
+			if !strings.EqualFold(configured, key) {
+				continue
+			}
+			for name, value := range headers {
+				header.Set(name, value)
+			}
+		}
+	}
+	return header
+}
@@ -0,0 +1,222 @@
+package configutil
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/notaryproject/notation-go/dir"
+)
+
+func TestSelectOutputAnnotations(t *testing.T) {
+	config := &CLIConfig{
+		OutputAnnotations: []string{"org.opencontainers.image.revision", "com.example.build.*"},
+	}
+	annotations := map[string]string{
+		"org.opencontainers.image.revision": "3f8a2c1",
+		"org.opencontainers.image.created":  "2023-04-20T08:00:00Z",
+		"com.example.build.id":              "1234",
+		"com.example.build.pipeline":        "release",
+		"com.example.team":                  "web",
+	}
+	want := map[string]string{
+		"org.opencontainers.image.revision": "3f8a2c1",
+		"com.example.build.id":              "1234",
+		"com.example.build.pipeline":        "release",
+	}
+	if got := config.SelectOutputAnnotations(annotations); !reflect.DeepEqual(got, want) {
+		t.Fatalf("SelectOutputAnnotations() = %v, want %v", got, want)
+	}
+	if got := config.SelectOutputAnnotations(map[string]string{"com.example.team": "web"}); got != nil {
+		t.Fatalf("SelectOutputAnnotations() = %v, want nil", got)
+	}
+	if got := (&CLIConfig{}).SelectOutputAnnotations(annotations); got != nil {
+		t.Fatalf("SelectOutputAnnotations() = %v, want nil", got)
+	}
+}
+
+func TestLoadCLIConfig_MaxSignatureAttempts(t *testing.T) {
+	defer func(oldDir string) { dir.UserConfigDir = oldDir }(dir.UserConfigDir)
+	dir.UserConfigDir = t.TempDir()
+	configPath := filepath.Join(dir.UserConfigDir, dir.PathConfigFile)
+	if err := os.WriteFile(configPath, []byte(`{"maxSignatureAttempts":50}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	config, err := LoadCLIConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.MaxSignatureAttempts != 50 {
+		t.Fatalf("MaxSignatureAttempts = %d, want 50", config.MaxSignatureAttempts)
+	}
+
+	if err := os.WriteFile(configPath, []byte(`{"maxSignatureAttempts":-1}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadCLIConfig(); err == nil || !strings.Contains(err.Error(), "maxSignatureAttempts") {
+		t.Fatalf("expected error for a negative maxSignatureAttempts, got %v", err)
+	}
+}
+
+func TestLoadCLIConfig_RegistryCapabilityCacheTTL(t *testing.T) {
+	defer func(oldDir string) { dir.UserConfigDir = oldDir }(dir.UserConfigDir)
+	dir.UserConfigDir = t.TempDir()
+	configPath := filepath.Join(dir.UserConfigDir, dir.PathConfigFile)
+	for _, ttl := range []string{"1h", "0s"} {
+		if err := os.WriteFile(configPath, []byte(`{"registryCapabilityCacheTTL":"`+ttl+`"}`), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadCLIConfig(); err != nil {
+			t.Fatalf("LoadCLIConfig() error = %v for %q", err, ttl)
+		}
+	}
+	for _, ttl := range []string{"1 day", "-1h"} {
+		if err := os.WriteFile(configPath, []byte(`{"registryCapabilityCacheTTL":"`+ttl+`"}`), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadCLIConfig(); err == nil || !strings.Contains(err.Error(), "registryCapabilityCacheTTL") {
+			t.Fatalf("expected error for registryCapabilityCacheTTL %q, got %v", ttl, err)
+		}
+	}
+}
+
+func TestLoadCLIConfig_CRLCache(t *testing.T) {
+	defer func(oldDir string) { dir.UserConfigDir = oldDir }(dir.UserConfigDir)
+	dir.UserConfigDir = t.TempDir()
+	configPath := filepath.Join(dir.UserConfigDir, dir.PathConfigFile)
+	if err := os.WriteFile(configPath, []byte(`{"crlCacheTTL":"12h","crlCacheMaxSize":1048576}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	config, err := LoadCLIConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.CRLCacheTTL != "12h" || config.CRLCacheMaxSize != 1048576 {
+		t.Fatalf("unexpected CRL cache config %q, %d", config.CRLCacheTTL, config.CRLCacheMaxSize)
+	}
+	for _, content := range []string{`{"crlCacheTTL":"1 day"}`, `{"crlCacheTTL":"-1h"}`, `{"crlCacheMaxSize":-1}`} {
+		if err := os.WriteFile(configPath, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadCLIConfig(); err == nil || !strings.Contains(err.Error(), "crlCache") {
+			t.Fatalf("expected error for %s, got %v", content, err)
+		}
+	}
+}
+
+func TestAllowsInsecureRegistry(t *testing.T) {
+	config := &CLIConfig{
+		InsecureRegistryAllowList: []string{"registry.example.com", "build.example.com:5000"},
+	}
+	tests := []struct {
+		registry string
+		want     bool
+	}{
+		{registry: "registry.example.com", want: true},
+		{registry: "registry.example.com:8443", want: true},
+		{registry: "Registry.Example.com", want: true},
+		{registry: "build.example.com:5000", want: true},
+		{registry: "build.example.com", want: false},
+		{registry: "build.example.com:5001", want: false},
+		{registry: "example.com", want: false},
+	}
+	for _, tt := range tests {
+		if got := config.AllowsInsecureRegistry(tt.registry); got != tt.want {
+			t.Errorf("AllowsInsecureRegistry(%q) = %v, want %v", tt.registry, got, tt.want)
+		}
+	}
+	if (&CLIConfig{}).AllowsInsecureRegistry("registry.example.com") {
+		t.Error("AllowsInsecureRegistry() = true with an empty allow-list, want false")
+	}
+}
+
+func TestRegistryHeadersOf(t *testing.T) {
+	config := &CLIConfig{
+		RegistryHeaders: map[string]map[string]string{
+			"*":                         {"X-Trace-Id": "build-42", "X-Tenant-Id": "default"},
+			"registry.example.com":      {"X-Tenant-Id": "contoso"},
+			"registry.example.com:5000": {"X-Tenant-Id": "fabrikam"},
+		},
+	}
+	tests := []struct {
+		registry string
+		tenant   string
+	}{
+		{registry: "registry.example.com", tenant: "contoso"},
+		{registry: "registry.example.com:443", tenant: "contoso"},
+		{registry: "registry.example.com:5000", tenant: "fabrikam"},
+		{registry: "mirror.example.com", tenant: "default"},
+	}
+	for _, tt := range tests {
+		header := config.RegistryHeadersOf(tt.registry)
+		if got := header.Get("X-Tenant-Id"); got != tt.tenant {
+			t.Errorf("RegistryHeadersOf(%q) has X-Tenant-Id %q, want %q", tt.registry, got, tt.tenant)
+		}
+		if got := header.Get("X-Trace-Id"); got != "build-42" {
+			t.Errorf("RegistryHeadersOf(%q) has X-Trace-Id %q, want %q", tt.registry, got, "build-42")
+		}
+	}
+	if got := (&CLIConfig{}).RegistryHeadersOf("registry.example.com"); len(got) != 0 {
+		t.Fatalf("RegistryHeadersOf() = %v, want no headers", got)
+	}
+}
+
+func TestLoadCLIConfig_RegistryHeaders(t *testing.T) {
+	defer func(oldDir string) { dir.UserConfigDir = oldDir }(dir.UserConfigDir)
+	dir.UserConfigDir = t.TempDir()
+	configPath := filepath.Join(dir.UserConfigDir, dir.PathConfigFile)
+	if err := os.WriteFile(configPath, []byte(`{"userAgent":"acme-ci/1.0","registryHeaders":{"*":{"X-Tenant-Id":"contoso"}}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	config, err := LoadCLIConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.UserAgent != "acme-ci/1.0" || config.RegistryHeaders["*"]["X-Tenant-Id"] != "contoso" {
+		t.Fatalf("unexpected config %+v", config)
+	}
+
+	for _, data := range []string{
+		`{"registryHeaders":{"*":{"Authorization":"Bearer token"}}}`,
+		`{"registryHeaders":{"*":{"X Tenant":"contoso"}}}`,
+		`{"userAgent":"acme\r\nX-Injected: true"}`,
+	} {
+		if err := os.WriteFile(configPath, []byte(data), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadCLIConfig(); err == nil {
+			t.Errorf("expected error for config %s, got nil", data)
+		}
+	}
+}
+
+func TestLoadCLIConfig_TimestampURL(t *testing.T) {
+	defer func(oldDir string) { dir.UserConfigDir = oldDir }(dir.UserConfigDir)
+	dir.UserConfigDir = t.TempDir()
+	configPath := filepath.Join(dir.UserConfigDir, dir.PathConfigFile)
+	if err := os.WriteFile(configPath, []byte(`{"timestampURL":"http://timestamp.example.com","timestampRootCert":"/etc/tsa-root.pem"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	config, err := LoadCLIConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.TimestampURL != "http://timestamp.example.com" || config.TimestampRootCert != "/etc/tsa-root.pem" {
+		t.Fatalf("unexpected config %+v", config)
+	}
+
+	for _, data := range []string{
+		`{"timestampURL":"timestamp.example.com"}`,
+		`{"timestampURL":"ftp://timestamp.example.com"}`,
+		`{"timestampURL":"https://"}`,
+	} {
+		if err := os.WriteFile(configPath, []byte(data), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadCLIConfig(); err == nil {
+			t.Errorf("expected error for config %s, got nil", data)
+		}
+	}
+}
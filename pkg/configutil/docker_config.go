@@ -18,8 +18,20 @@ const (
 // DockerConfigFile is the minimized configuration of the Docker daemon, only
 // credentails store related configs are included
 type DockerConfigFile struct {
-	CredentialsStore  string            `json:"credsStore,omitempty"`
-	CredentialHelpers map[string]string `json:"credHelpers,omitempty"`
+	AuthConfigs       map[string]DockerAuthConfig `json:"auths,omitempty"`
+	CredentialsStore  string                      `json:"credsStore,omitempty"`
+	CredentialHelpers map[string]string           `json:"credHelpers,omitempty"`
+}
+
+// DockerAuthConfig contains the credentials of a registry stored in the
+// Docker config file when no credentials store is configured
+type DockerAuthConfig struct {
+	// Auth is the base64 encoded "username:password"
+	Auth string `json:"auth,omitempty"`
+
+	// IdentityToken is used to authenticate the user and get an access token
+	// for the registry
+	IdentityToken string `json:"identitytoken,omitempty"`
 }
 
 // Load reads the configuration files in the given directory, and sets up
@@ -0,0 +1,91 @@
+package configutil
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/notaryproject/notation/internal/ceremony"
+)
+
+// KeyCeremony is the key ceremony required before a high-value signing key
+// signs: a signing session of the key is only opened with the approvals of
+// Threshold of the Approvers.
+type KeyCeremony struct {
+	// Threshold is the number of distinct approvers required to open a
+	// signing session.
+	Threshold int `json:"threshold"`
+
+	// Approvers are the PEM-encoded certificates of the operators allowed to
+	// approve signing sessions, indexed by the names of the operators.
+	Approvers map[string]string `json:"approvers"`
+}
+
+// Validate validates that the threshold is reachable, and that the approvers
+// hold valid certificates of distinct public keys.
+func (c KeyCeremony) Validate() error {
+	if c.Threshold < 1 {
+		return fmt.Errorf("threshold of the key ceremony must be at least 1, got %d", c.Threshold)
+	}
+	if c.Threshold > len(c.Approvers) {
+		return fmt.Errorf("threshold of the key ceremony is %d, but only %d approvers are configured", c.Threshold, len(c.Approvers))
+	}
+	if _, err := ceremony.NewPolicy(c.Threshold, c.Approvers); err != nil {
+		return fmt.Errorf("invalid key ceremony: %w", err)
+	}
+	return nil
+}
+
+// ApproverNames returns the sorted names of the approvers.
+func (c KeyCeremony) ApproverNames() []string {
+	names := make([]string, 0, len(c.Approvers))
+	for name := range c.Approvers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LoadKeyCeremony returns the key ceremony required by the signing key with
+// the name, or nil if none is required. The default signing key is loaded if
+// name is empty.
+func LoadKeyCeremony(name string) (*KeyCeremony, error) {
+	keys, err := loadSigningKeys()
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		if keys.Default == nil {
+			return nil, nil
+		}
+		name = *keys.Default
+	}
+	for _, key := range keys.Keys {
+		if key.Name == name {
+			return key.Ceremony, nil
+		}
+	}
+	return nil, nil
+}
+
+// SetKeyCeremony sets the key ceremony required by the signing key with the
+// name. The requirement is removed if ceremony is nil.
+func SetKeyCeremony(name string, ceremony *KeyCeremony) error {
+	if ceremony != nil {
+		if err := ceremony.Validate(); err != nil {
+			return err
+		}
+	}
+	return withSigningKeysLock(func() error {
+		keys, err := loadSigningKeys()
+		if err != nil {
+			return err
+		}
+		for i, key := range keys.Keys {
+			if key.Name == name {
+				keys.Keys[i].Ceremony = ceremony
+				return saveSigningKeys(keys)
+			}
+		}
+		return fmt.Errorf("signing key %q not found", name)
+	})
+}
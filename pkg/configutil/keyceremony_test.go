@@ -0,0 +1,101 @@
+package configutil
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/notation-go/config"
+	"github.com/notaryproject/notation-go/dir"
+)
+
+// newApproverCert returns a PEM-encoded self-signed certificate of key.
+func newApproverCert(t *testing.T, name string, key crypto.Signer) string {
+	t.Helper()
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+func TestKeyCeremony(t *testing.T) {
+	defer func(oldDir string) {
+		dir.UserConfigDir = oldDir
+	}(dir.UserConfigDir)
+	dir.UserConfigDir = t.TempDir()
+
+	signingKeysJSON := `{"default":"release-key","keys":[{"name":"release-key","keyPath":"r.key","certPath":"r.crt"},{"name":"plain-key","keyPath":"p.key","certPath":"p.crt"}]}`
+	if err := os.WriteFile(filepath.Join(dir.UserConfigDir, dir.PathSigningKeys), []byte(signingKeysJSON), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	certs := make(map[string]string)
+	for _, name := range []string{"alice", "bob", "carol"} {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		certs[name] = newApproverCert(t, name, key)
+	}
+	if err := SetKeyCeremony("release-key", &KeyCeremony{Threshold: 3, Approvers: map[string]string{"alice": certs["alice"], "bob": certs["bob"]}}); err == nil {
+		t.Fatal("expected error for an unreachable threshold")
+	}
+	if err := SetKeyCeremony("release-key", &KeyCeremony{Threshold: 1, Approvers: map[string]string{"alice": "a"}}); err == nil {
+		t.Fatal("expected error for an invalid certificate")
+	}
+	if err := SetKeyCeremony("release-key", &KeyCeremony{Threshold: 2, Approvers: map[string]string{"alice": certs["alice"], "alice2": certs["alice"], "bob": certs["bob"]}}); err == nil || !strings.Contains(err.Error(), "share the same public key") {
+		t.Fatalf("expected error for approvers sharing a key, got %v", err)
+	}
+	if err := SetKeyCeremony("unknown-key", &KeyCeremony{Threshold: 1, Approvers: map[string]string{"alice": certs["alice"]}}); err == nil {
+		t.Fatal("expected error for an unknown key")
+	}
+	if err := SetKeyCeremony("release-key", &KeyCeremony{Threshold: 2, Approvers: certs}); err != nil {
+		t.Fatal(err)
+	}
+
+	// ceremonies are preserved by notation-go operations
+	updateDefault := func(s *config.SigningKeys) error {
+		return s.UpdateDefault("plain-key")
+	}
+	if err := LoadExecSaveSigningKeys(updateDefault, nil); err != nil {
+		t.Fatal(err)
+	}
+	ceremony, err := LoadKeyCeremony("release-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ceremony == nil || ceremony.Threshold != 2 || len(ceremony.Approvers) != 3 {
+		t.Fatalf("unexpected ceremony: %+v", ceremony)
+	}
+	if names := ceremony.ApproverNames(); names[0] != "alice" || names[2] != "carol" {
+		t.Fatalf("unexpected approver names: %v", names)
+	}
+	if ceremony, err := LoadKeyCeremony(""); err != nil || ceremony != nil {
+		t.Fatalf("expected no ceremony for the default key, got %+v, %v", ceremony, err)
+	}
+
+	// the requirement is removed
+	if err := SetKeyCeremony("release-key", nil); err != nil {
+		t.Fatal(err)
+	}
+	if ceremony, err := LoadKeyCeremony("release-key"); err != nil || ceremony != nil {
+		t.Fatalf("expected no ceremony, got %+v, %v", ceremony, err)
+	}
+}
@@ -0,0 +1,83 @@
+package configutil
+
+import (
+	"errors"
+
+	"github.com/notaryproject/notation-go/config"
+)
+
+// KeychainKey is a signing identity of the macOS Keychain, e.g. a key in the
+// login keychain or in the Secure Enclave, signing without a plugin.
+type KeychainKey struct {
+	// Identity is the common name of the certificate of the identity, or the
+	// SHA-1 fingerprint of the certificate if the common name is ambiguous.
+	Identity string `json:"identity"`
+
+	// CertificatePath is the path of the PEM file of the certificate chain of
+	// the identity, starting with the signing certificate, if the chain is not
+	// stored in the Keychain.
+	CertificatePath string `json:"certPath,omitempty"`
+}
+
+// Validate validates that the key identifies a signing identity.
+func (k KeychainKey) Validate() error {
+	if k.Identity == "" {
+		return errors.New("Keychain identity is required")
+	}
+	return nil
+}
+
+// URI returns the location of the key shown as its key path, e.g.
+// "keychain:Developer ID Application: Example".
+func (k KeychainKey) URI() string {
+	return "keychain:" + k.Identity
+}
+
+// LoadKeychainKeys returns the Keychain signing keys in signingkeys.json
+// indexed by key name.
+func LoadKeychainKeys() (map[string]KeychainKey, error) {
+	keys, err := loadSigningKeys()
+	if err != nil {
+		return nil, err
+	}
+	keychainKeys := make(map[string]KeychainKey)
+	for _, key := range keys.Keys {
+		if key.Keychain != nil {
+			keychainKeys[key.Name] = *key.Keychain
+		}
+	}
+	return keychainKeys, nil
+}
+
+// LoadKeychainKey returns the Keychain identity of the signing key with the
+// name, or nil if the key is not in the Keychain. The default signing key is
+// loaded if name is empty.
+func LoadKeychainKey(name string) (*KeychainKey, error) {
+	key, err := findSigningKey(name)
+	if err != nil || key == nil {
+		return nil, err
+	}
+	return key.Keychain, nil
+}
+
+// AddKeychainKey adds the signing key with the name of a Keychain identity,
+// with the purpose if not empty, and marks it as default if markDefault is
+// true.
+func AddKeychainKey(name string, keychainKey KeychainKey, purpose string, markDefault bool) error {
+	if name == "" {
+		return errors.New("key name cannot be empty")
+	}
+	if err := keychainKey.Validate(); err != nil {
+		return err
+	}
+	if purpose != "" {
+		if err := ValidateKeyPurpose(purpose); err != nil {
+			return err
+		}
+	}
+	return addSigningKey(keySuite{
+		KeySuite: config.KeySuite{Name: name},
+		Purpose:  purpose,
+		Keychain: &keychainKey,
+	}, markDefault)
+}
@@ -0,0 +1,58 @@
+package configutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/notaryproject/notation-go/config"
+	"github.com/notaryproject/notation-go/dir"
+)
+
+func TestKeychainKey(t *testing.T) {
+	defer func(oldDir string) {
+		dir.UserConfigDir = oldDir
+	}(dir.UserConfigDir)
+	dir.UserConfigDir = t.TempDir()
+
+	signingKeysJSON := `{"default":"file-key","keys":[{"name":"file-key","keyPath":"f.key","certPath":"f.crt"}]}`
+	if err := os.WriteFile(filepath.Join(dir.UserConfigDir, dir.PathSigningKeys), []byte(signingKeysJSON), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	keychainKey := KeychainKey{Identity: "Developer ID Application: Example"}
+	if err := AddKeychainKey("keychain-key", KeychainKey{CertificatePath: "chain.pem"}, "", false); err == nil {
+		t.Fatal("expected error for a key without identity")
+	}
+	if err := AddKeychainKey("file-key", keychainKey, "", false); err == nil {
+		t.Fatal("expected error for a duplicate key name")
+	}
+	if err := AddKeychainKey("keychain-key", keychainKey, KeyPurposeTest, false); err != nil {
+		t.Fatal(err)
+	}
+
+	// Keychain keys are preserved by notation-go operations
+	updateDefault := func(s *config.SigningKeys) error {
+		return s.UpdateDefault("keychain-key")
+	}
+	if err := LoadExecSaveSigningKeys(updateDefault, nil); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadKeychainKey("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got == nil || *got != keychainKey {
+		t.Fatalf("LoadKeychainKey() = %+v, want %+v", got, keychainKey)
+	}
+	if got, err := LoadKeychainKey("file-key"); err != nil || got != nil {
+		t.Fatalf("expected no Keychain key for a local key, got %+v, %v", got, err)
+	}
+	keys, err := LoadKeychainKeys()
+	if err != nil || len(keys) != 1 || keys["keychain-key"] != keychainKey {
+		t.Fatalf("LoadKeychainKeys() = %+v, %v", keys, err)
+	}
+	if want := "keychain:Developer ID Application: Example"; keychainKey.URI() != want {
+		t.Fatalf("URI() = %q, want %q", keychainKey.URI(), want)
+	}
+}
@@ -0,0 +1,84 @@
+package configutil
+
+import (
+	"errors"
+
+	"github.com/notaryproject/notation-go/config"
+	"github.com/notaryproject/notation/internal/kms"
+)
+
+// KMSKey is a signing key in a key management service, e.g. AWS KMS, signing
+// without a plugin.
+type KMSKey struct {
+	// URI is the URI of the key, of which the scheme selects the KMS, e.g.
+	// "awskms:///alias/release".
+	URI string `json:"uri"`
+
+	// CertificatePath is the location of the PEM certificate chain of the
+	// key, starting with the signing certificate. It is a file path, or a
+	// location in the store of a KMS fetched at signing, e.g.
+	// "s3://bucket/chain.pem" or "ssm:///notation/chain".
+	CertificatePath string `json:"certPath"`
+}
+
+// Validate validates that the key URI and the certificate chain location are
+// supported by this build.
+func (k KMSKey) Validate() error {
+	if k.URI == "" {
+		return errors.New("KMS key URI is required")
+	}
+	if _, err := kms.ParseURI(k.URI); err != nil {
+		return err
+	}
+	if k.CertificatePath == "" {
+		return errors.New("certificate chain of the KMS key is required")
+	}
+	return kms.ValidateCertificateLocation(k.CertificatePath)
+}
+
+// LoadKMSKeys returns the KMS signing keys in signingkeys.json indexed by key
+// name.
+func LoadKMSKeys() (map[string]KMSKey, error) {
+	keys, err := loadSigningKeys()
+	if err != nil {
+		return nil, err
+	}
+	kmsKeys := make(map[string]KMSKey)
+	for _, key := range keys.Keys {
+		if key.KMS != nil {
+			kmsKeys[key.Name] = *key.KMS
+		}
+	}
+	return kmsKeys, nil
+}
+
+// LoadKMSKey returns the KMS key of the signing key with the name, or nil if
+// the key is not in a KMS. The default signing key is loaded if name is empty.
+func LoadKMSKey(name string) (*KMSKey, error) {
+	key, err := findSigningKey(name)
+	if err != nil || key == nil {
+		return nil, err
+	}
+	return key.KMS, nil
+}
+
+// AddKMSKey adds the signing key with the name in a KMS, with the purpose if
+// not empty, and marks it as default if markDefault is true.
+func AddKMSKey(name string, kmsKey KMSKey, purpose string, markDefault bool) error {
+	if name == "" {
+		return errors.New("key name cannot be empty")
+	}
+	if err := kmsKey.Validate(); err != nil {
+		return err
+	}
+	if purpose != "" {
+		if err := ValidateKeyPurpose(purpose); err != nil {
+			return err
+		}
+	}
+	return addSigningKey(keySuite{
+		KeySuite: config.KeySuite{Name: name},
+		Purpose:  purpose,
+		KMS:      &kmsKey,
+	}, markDefault)
+}
@@ -0,0 +1,58 @@
+package configutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/notaryproject/notation-go/config"
+	"github.com/notaryproject/notation-go/dir"
+)
+
+func TestKMSKey(t *testing.T) {
+	defer func(oldDir string) {
+		dir.UserConfigDir = oldDir
+	}(dir.UserConfigDir)
+	dir.UserConfigDir = t.TempDir()
+
+	signingKeysJSON := `{"default":"file-key","keys":[{"name":"file-key","keyPath":"f.key","certPath":"f.crt"}]}`
+	if err := os.WriteFile(filepath.Join(dir.UserConfigDir, dir.PathSigningKeys), []byte(signingKeysJSON), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	kmsKey := KMSKey{URI: "awskms:///alias/release", CertificatePath: "release.crt"}
+	if err := AddKMSKey("kms-key", KMSKey{URI: kmsKey.URI}, "", false); err == nil {
+		t.Fatal("expected error for a key without certificate chain")
+	}
+	if err := AddKMSKey("kms-key", KMSKey{URI: "pkcs11:object=release", CertificatePath: "release.crt"}, "", false); err == nil {
+		t.Fatal("expected error for an unsupported key URI")
+	}
+	if err := AddKMSKey("file-key", kmsKey, "", false); err == nil {
+		t.Fatal("expected error for a duplicate key name")
+	}
+	if err := AddKMSKey("kms-key", kmsKey, KeyPurposeProduction, false); err != nil {
+		t.Fatal(err)
+	}
+
+	// KMS keys are preserved by notation-go operations
+	updateDefault := func(s *config.SigningKeys) error {
+		return s.UpdateDefault("kms-key")
+	}
+	if err := LoadExecSaveSigningKeys(updateDefault, nil); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadKMSKey("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got == nil || *got != kmsKey {
+		t.Fatalf("LoadKMSKey() = %+v, want %+v", got, kmsKey)
+	}
+	if got, err := LoadKMSKey("file-key"); err != nil || got != nil {
+		t.Fatalf("expected no KMS key for a local key, got %+v, %v", got, err)
+	}
+	keys, err := LoadKMSKeys()
+	if err != nil || len(keys) != 1 || keys["kms-key"] != kmsKey {
+		t.Fatalf("LoadKMSKeys() = %+v, %v", keys, err)
+	}
+}
@@ -0,0 +1,98 @@
+package configutil
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/notaryproject/notation-go/config"
+)
+
+// PKCS11Key is a signing key in a PKCS #11 token, e.g. a hardware security
+// module, signing without a plugin.
+type PKCS11Key struct {
+	// Module is the path of the PKCS #11 module library of the token, e.g.
+	// "/usr/lib/softhsm/libsofthsm2.so".
+	Module string `json:"module"`
+
+	// Slot is the ID of the slot of the token.
+	Slot uint `json:"slot"`
+
+	// Label is the label of the private key object in the token. The
+	// signing certificate is the certificate object with the same label,
+	// unless CertificatePath is set.
+	Label string `json:"label"`
+
+	// CertificatePath is the path of the PEM file of the certificate chain of
+	// the key, starting with the signing certificate, if the chain is not
+	// stored in the token.
+	CertificatePath string `json:"certPath,omitempty"`
+}
+
+// Validate validates that the key identifies a private key object.
+func (k PKCS11Key) Validate() error {
+	if k.Module == "" {
+		return errors.New("PKCS #11 module is required")
+	}
+	if k.Label == "" {
+		return errors.New("PKCS #11 key label is required")
+	}
+	return nil
+}
+
+// URI returns the PKCS #11 URI of the key defined by RFC 7512, e.g.
+// "pkcs11:slot-id=0;object=release;type=private?module-path=/usr/lib/softhsm/libsofthsm2.so".
+func (k PKCS11Key) URI() string {
+	module := strings.NewReplacer("%2F", "/", "&", "%26").Replace(url.PathEscape(k.Module))
+	return fmt.Sprintf("pkcs11:slot-id=%s;object=%s;type=private?module-path=%s", strconv.FormatUint(uint64(k.Slot), 10), url.PathEscape(k.Label), module)
+}
+
+// LoadPKCS11Keys returns the PKCS #11 signing keys in signingkeys.json
+// indexed by key name.
+func LoadPKCS11Keys() (map[string]PKCS11Key, error) {
+	keys, err := loadSigningKeys()
+	if err != nil {
+		return nil, err
+	}
+	pkcs11Keys := make(map[string]PKCS11Key)
+	for _, key := range keys.Keys {
+		if key.PKCS11 != nil {
+			pkcs11Keys[key.Name] = *key.PKCS11
+		}
+	}
+	return pkcs11Keys, nil
+}
+
+// LoadPKCS11Key returns the PKCS #11 key of the signing key with the name, or
+// nil if the key is not in a PKCS #11 token. The default signing key is
+// loaded if name is empty.
+func LoadPKCS11Key(name string) (*PKCS11Key, error) {
+	key, err := findSigningKey(name)
+	if err != nil || key == nil {
+		return nil, err
+	}
+	return key.PKCS11, nil
+}
+
+// AddPKCS11Key adds the signing key with the name in a PKCS #11 token, with
+// the purpose if not empty, and marks it as default if markDefault is true.
+func AddPKCS11Key(name string, pkcs11Key PKCS11Key, purpose string, markDefault bool) error {
+	if name == "" {
+		return errors.New("key name cannot be empty")
+	}
+	if err := pkcs11Key.Validate(); err != nil {
+		return err
+	}
+	if purpose != "" {
+		if err := ValidateKeyPurpose(purpose); err != nil {
+			return err
+		}
+	}
+	return addSigningKey(keySuite{
+		KeySuite: config.KeySuite{Name: name},
+		Purpose:  purpose,
+		PKCS11:   &pkcs11Key,
+	}, markDefault)
+}
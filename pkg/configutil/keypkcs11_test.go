@@ -0,0 +1,70 @@
+package configutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/notaryproject/notation-go/config"
+	"github.com/notaryproject/notation-go/dir"
+)
+
+func TestPKCS11Key(t *testing.T) {
+	defer func(oldDir string) {
+		dir.UserConfigDir = oldDir
+	}(dir.UserConfigDir)
+	dir.UserConfigDir = t.TempDir()
+
+	signingKeysJSON := `{"default":"file-key","keys":[{"name":"file-key","keyPath":"f.key","certPath":"f.crt"}]}`
+	if err := os.WriteFile(filepath.Join(dir.UserConfigDir, dir.PathSigningKeys), []byte(signingKeysJSON), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	hsmKey := PKCS11Key{Module: "/usr/lib/softhsm/libsofthsm2.so", Slot: 1, Label: "release"}
+	if err := AddPKCS11Key("hsm-key", PKCS11Key{Module: hsmKey.Module}, "", false); err == nil {
+		t.Fatal("expected error for a key without label")
+	}
+	if err := AddPKCS11Key("file-key", hsmKey, "", false); err == nil {
+		t.Fatal("expected error for a duplicate key name")
+	}
+	if err := AddPKCS11Key("hsm-key", hsmKey, KeyPurposeProduction, true); err != nil {
+		t.Fatal(err)
+	}
+
+	// PKCS #11 keys are preserved by notation-go operations
+	updateDefault := func(s *config.SigningKeys) error {
+		return s.UpdateDefault("hsm-key")
+	}
+	if err := LoadExecSaveSigningKeys(updateDefault, nil); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadPKCS11Key("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got == nil || *got != hsmKey {
+		t.Fatalf("LoadPKCS11Key() = %+v, want %+v", got, hsmKey)
+	}
+	if got, err := LoadPKCS11Key("file-key"); err != nil || got != nil {
+		t.Fatalf("expected no PKCS #11 key for a local key, got %+v, %v", got, err)
+	}
+	keys, err := LoadPKCS11Keys()
+	if err != nil || len(keys) != 1 || keys["hsm-key"] != hsmKey {
+		t.Fatalf("LoadPKCS11Keys() = %+v, %v", keys, err)
+	}
+	purposes, err := LoadKeyPurposes()
+	if err != nil || purposes["hsm-key"] != KeyPurposeProduction {
+		t.Fatalf("expected the purpose of the key to be set, got %v, %v", purposes, err)
+	}
+	if key, err := ResolveKey("hsm-key"); err != nil || key.X509KeyPair != nil || key.ExternalKey != nil {
+		t.Fatalf("ResolveKey() = %+v, %v", key, err)
+	}
+}
+
+func TestPKCS11Key_URI(t *testing.T) {
+	key := PKCS11Key{Module: "/usr/lib/softhsm/libsofthsm2.so", Slot: 2, Label: "release key;v2"}
+	want := "pkcs11:slot-id=2;object=release%20key%3Bv2;type=private?module-path=/usr/lib/softhsm/libsofthsm2.so"
+	if got := key.URI(); got != want {
+		t.Fatalf("URI() = %q, want %q", got, want)
+	}
+}
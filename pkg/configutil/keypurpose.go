@@ -0,0 +1,294 @@
+package configutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"github.com/notaryproject/notation-go/config"
+	"github.com/notaryproject/notation-go/dir"
+	"github.com/notaryproject/notation/internal/filelock"
+	"github.com/notaryproject/notation/internal/osutil"
+)
+
+const (
+	// KeyPurposeProduction denotes a signing key for production artifacts.
+	KeyPurposeProduction = "production"
+
+	// KeyPurposeTest denotes a signing key for test artifacts only, e.g. a
+	// key generated by "notation cert generate-test".
+	KeyPurposeTest = "test"
+)
+
+// ErrTestKeyForProduction indicates that a test key is used to sign an
+// artifact in a production registry.
+var ErrTestKeyForProduction = errors.New("test signing key cannot sign artifacts in production registries")
+
+// keySuite is a config.KeySuite with the notation CLI extension fields.
+type keySuite struct {
+	config.KeySuite
+
+	// Purpose is the purpose of the key, either KeyPurposeProduction or
+	// KeyPurposeTest. Keys without purpose are treated as production keys.
+	Purpose string `json:"purpose,omitempty"`
+
+	KeyValidity
+
+	// Ceremony is the key ceremony required before the key signs, if any.
+	Ceremony *KeyCeremony `json:"ceremony,omitempty"`
+
+	// PKCS11 is the key in a PKCS #11 token, for keys signing without a
+	// plugin in hardware security modules.
+	PKCS11 *PKCS11Key `json:"pkcs11,omitempty"`
+
+	// KMS is the key in a key management service, for keys signing without a
+	// plugin in cloud KMS.
+	KMS *KMSKey `json:"kms,omitempty"`
+
+	// Keychain is the signing identity of the macOS Keychain, for keys
+	// signing without a plugin on developer machines.
+	Keychain *KeychainKey `json:"keychain,omitempty"`
+}
+
+// signingKeys reflects the signingkeys.json file with the notation CLI
+// extension fields.
+type signingKeys struct {
+	Default *string    `json:"default,omitempty"`
+	Keys    []keySuite `json:"keys"`
+}
+
+// ValidateKeyPurpose validates the key purpose.
+func ValidateKeyPurpose(purpose string) error {
+	switch purpose {
+	case KeyPurposeProduction, KeyPurposeTest:
+		return nil
+	}
+	return fmt.Errorf("unsupported key purpose %q, options: %q, %q", purpose, KeyPurposeProduction, KeyPurposeTest)
+}
+
+// LoadKeyPurposes returns the purposes of the signing keys in signingkeys.json
+// indexed by key name. Keys without purpose are not included.
+func LoadKeyPurposes() (map[string]string, error) {
+	keys, err := loadSigningKeys()
+	if err != nil {
+		return nil, err
+	}
+	purposes := make(map[string]string)
+	for _, key := range keys.Keys {
+		if key.Purpose != "" {
+			purposes[key.Name] = key.Purpose
+		}
+	}
+	return purposes, nil
+}
+
+// LoadExecSaveSigningKeys is config.LoadExecSaveSigningKeys preserving the
+// purposes, the validity windows, the key ceremonies, the PKCS #11 keys, the
+// KMS keys and the Keychain keys of the signing keys, which are unknown to
+// notation-go.
+// purposes sets the purposes of the keys by name after fn is executed, the
+// purpose of a key is removed if set to empty. signingkeys.json is locked
+// until saved, so that parallel notation processes do not lose updates, and
+// is replaced atomically rather than saved by notation-go, which truncates
+// the file before writing it.
+func LoadExecSaveSigningKeys(fn func(keys *config.SigningKeys) error, purposes map[string]string) error {
+	return withSigningKeysLock(func() error {
+		existing, err := loadSigningKeys()
+		if err != nil {
+			return err
+		}
+		extensions := make(map[string]keySuite, len(existing.Keys))
+		for _, key := range existing.Keys {
+			extensions[key.Name] = key
+		}
+		signingKeysConfig, err := config.LoadSigningKeys()
+		if err != nil {
+			return err
+		}
+		if err := fn(signingKeysConfig); err != nil {
+			return err
+		}
+		if err := validateSigningKeys(signingKeysConfig); err != nil {
+			return err
+		}
+		keys := &signingKeys{
+			Default: signingKeysConfig.Default,
+			Keys:    make([]keySuite, 0, len(signingKeysConfig.Keys)),
+		}
+		for _, key := range signingKeysConfig.Keys {
+			ext := extensions[key.Name]
+			ext.KeySuite = key
+			if purpose, ok := purposes[key.Name]; ok {
+				ext.Purpose = purpose
+			}
+			keys.Keys = append(keys.Keys, ext)
+		}
+		return saveSigningKeys(keys)
+	})
+}
+
+// validateSigningKeys validates the signing keys updated by notation-go as
+// config.SigningKeys.Save does, i.e. that the key names are unique and not
+// empty, and that the default key exists.
+func validateSigningKeys(keys *config.SigningKeys) error {
+	names := make(map[string]bool, len(keys.Keys))
+	for _, key := range keys.Keys {
+		if key.Name == "" {
+			return fmt.Errorf("malformed %s: key name cannot be empty", dir.PathSigningKeys)
+		}
+		if names[key.Name] {
+			return fmt.Errorf("malformed %s: multiple keys with name '%s' found", dir.PathSigningKeys, key.Name)
+		}
+		names[key.Name] = true
+	}
+	if keys.Default != nil && !names[*keys.Default] {
+		return fmt.Errorf("malformed %s: default key '%s' not found", dir.PathSigningKeys, *keys.Default)
+	}
+	return nil
+}
+
+// CheckKeyPurpose returns ErrTestKeyForProduction if the signing key with the
+// name is a test key and reference is in one of the production registries
+// configured in config.json. The default signing key is checked if name is
+// empty.
+func CheckKeyPurpose(name, reference string) error {
+	keys, err := loadSigningKeys()
+	if err != nil {
+		return err
+	}
+	if name == "" {
+		if keys.Default == nil {
+			return nil
+		}
+		name = *keys.Default
+	}
+	var purpose string
+	for _, key := range keys.Keys {
+		if key.Name == name {
+			purpose = key.Purpose
+			break
+		}
+	}
+	if purpose != KeyPurposeTest {
+		return nil
+	}
+	cliConfig, err := LoadCLIConfigOnce()
+	if err != nil {
+		return err
+	}
+	if registry, ok := cliConfig.MatchProductionRegistry(reference); ok {
+		return fmt.Errorf("%w: key %q is a test key and %q is in the production registry %q configured in config.json", ErrTestKeyForProduction, name, reference, registry)
+	}
+	return nil
+}
+
+// loadSigningKeys reads the signingkeys.json file with the notation CLI
+// extension fields, or returns empty signing keys if not found.
+func loadSigningKeys() (*signingKeys, error) {
+	file, err := dir.ConfigFS().Open(dir.PathSigningKeys)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return &signingKeys{Keys: []keySuite{}}, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+	var keys signingKeys
+	if err := json.NewDecoder(file).Decode(&keys); err != nil {
+		return nil, err
+	}
+	return &keys, nil
+}
+
+// saveSigningKeys writes the signingkeys.json file in the same format as
+// notation-go. The file is replaced atomically, so that parallel notation
+// processes never read a truncated file.
+func saveSigningKeys(keys *signingKeys) error {
+	path, err := dir.ConfigFS().SysPath(dir.PathSigningKeys)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetIndent("", "    ")
+	if err := encoder.Encode(keys); err != nil {
+		return err
+	}
+	return osutil.WriteFileAtomic(path, buf.Bytes(), 0600)
+}
+
+// withSigningKeysLock executes fn holding the lock of signingkeys.json, so
+// that the updates of parallel notation processes are not lost.
+func withSigningKeysLock(fn func() error) error {
+	path, err := dir.ConfigFS().SysPath(dir.PathSigningKeys)
+	if err != nil {
+		return err
+	}
+	return filelock.WithLock(path, fn)
+}
+
+// addSigningKey adds the signing key without key pair or plugin to
+// signingkeys.json, and marks it as default if markDefault is true.
+func addSigningKey(key keySuite, markDefault bool) error {
+	return withSigningKeysLock(func() error {
+		keys, err := loadSigningKeys()
+		if err != nil {
+			return err
+		}
+		for _, existing := range keys.Keys {
+			if existing.Name == key.Name {
+				return fmt.Errorf("signing key with name %q already exists", key.Name)
+			}
+		}
+		keys.Keys = append(keys.Keys, key)
+		if markDefault {
+			keys.Default = &key.Name
+		}
+		return saveSigningKeys(keys)
+	})
+}
+
+// findSigningKey returns the signing key with the name in signingkeys.json,
+// or nil if not found. The default signing key is returned if name is empty.
+func findSigningKey(name string) (*keySuite, error) {
+	keys, err := loadSigningKeys()
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		if keys.Default == nil {
+			return nil, nil
+		}
+		name = *keys.Default
+	}
+	for _, key := range keys.Keys {
+		if key.Name == name {
+			return &key, nil
+		}
+	}
+	return nil, nil
+}
+
+// matchRepositoryPrefix returns true if reference is in the registry or the
+// repository namespace of prefix, e.g. prefix "registry.example.com/prod"
+// matches "registry.example.com/prod/app:v1" but not
+// "registry.example.com/production/app:v1".
+func matchRepositoryPrefix(prefix, reference string) bool {
+	prefix = strings.TrimSuffix(strings.TrimSuffix(prefix, "/*"), "/")
+	registry, namespace, _ := strings.Cut(prefix, "/")
+	refRegistry, refRepository, _ := strings.Cut(reference, "/")
+	if !strings.EqualFold(registry, refRegistry) {
+		return false
+	}
+	if namespace == "" {
+		return true
+	}
+	// strip the tag or digest
+	if i := strings.IndexAny(refRepository, ":@"); i >= 0 {
+		refRepository = refRepository[:i]
+	}
+	return refRepository == namespace || strings.HasPrefix(refRepository, namespace+"/")
+}
@@ -0,0 +1,162 @@
+package configutil
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/notaryproject/notation-go/config"
+	"github.com/notaryproject/notation-go/dir"
+)
+
+func TestLoadExecSaveSigningKeys_PreservesPurpose(t *testing.T) {
+	defer func(oldDir string) {
+		dir.UserConfigDir = oldDir
+	}(dir.UserConfigDir)
+	dir.UserConfigDir = t.TempDir()
+
+	signingKeysJSON := `{"keys":[{"name":"test-key","keyPath":"t.key","certPath":"t.crt","purpose":"test"},{"name":"prod-key","keyPath":"p.key","certPath":"p.crt"}]}`
+	if err := os.WriteFile(filepath.Join(dir.UserConfigDir, dir.PathSigningKeys), []byte(signingKeysJSON), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// notation-go operations keep the purposes
+	updateDefault := func(s *config.SigningKeys) error {
+		return s.UpdateDefault("prod-key")
+	}
+	if err := LoadExecSaveSigningKeys(updateDefault, map[string]string{"prod-key": KeyPurposeProduction}); err != nil {
+		t.Fatal(err)
+	}
+	purposes, err := LoadKeyPurposes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(purposes) != 2 || purposes["test-key"] != KeyPurposeTest || purposes["prod-key"] != KeyPurposeProduction {
+		t.Fatalf("unexpected purposes: %v", purposes)
+	}
+
+	// the signing keys are still readable by notation-go
+	keys, err := config.LoadSigningKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys.Keys) != 2 || keys.Default == nil || *keys.Default != "prod-key" {
+		t.Fatalf("unexpected signing keys: %+v", keys)
+	}
+
+	// purposes of removed keys are dropped
+	remove := func(s *config.SigningKeys) error {
+		_, err := s.Remove("test-key")
+		return err
+	}
+	if err := LoadExecSaveSigningKeys(remove, nil); err != nil {
+		t.Fatal(err)
+	}
+	if purposes, err = LoadKeyPurposes(); err != nil {
+		t.Fatal(err)
+	}
+	if len(purposes) != 1 {
+		t.Fatalf("unexpected purposes: %v", purposes)
+	}
+}
+
+func TestCheckKeyPurpose(t *testing.T) {
+	cliConfigOnce = sync.Once{}
+	defer func(oldDir string) {
+		dir.UserConfigDir = oldDir
+		cliConfigOnce = sync.Once{}
+	}(dir.UserConfigDir)
+	dir.UserConfigDir = t.TempDir()
+
+	configJSON := `{"productionRegistries":["prod.example.com","shared.example.com/release/*"]}`
+	if err := os.WriteFile(filepath.Join(dir.UserConfigDir, dir.PathConfigFile), []byte(configJSON), 0600); err != nil {
+		t.Fatal(err)
+	}
+	signingKeysJSON := `{"default":"test-key","keys":[{"name":"test-key","keyPath":"t.key","certPath":"t.crt","purpose":"test"},{"name":"prod-key","keyPath":"p.key","certPath":"p.crt","purpose":"production"},{"name":"plain-key","keyPath":"k.key","certPath":"k.crt"}]}`
+	if err := os.WriteFile(filepath.Join(dir.UserConfigDir, dir.PathSigningKeys), []byte(signingKeysJSON), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		key       string
+		reference string
+		wantErr   bool
+	}{
+		{key: "test-key", reference: "prod.example.com/app:v1", wantErr: true},
+		{key: "", reference: "PROD.example.com/app@sha256:abc", wantErr: true},
+		{key: "test-key", reference: "shared.example.com/release/app:v1", wantErr: true},
+		{key: "test-key", reference: "shared.example.com/release:v1", wantErr: true},
+		{key: "test-key", reference: "shared.example.com/releases/app:v1", wantErr: false},
+		{key: "test-key", reference: "dev.example.com/app:v1", wantErr: false},
+		{key: "prod-key", reference: "prod.example.com/app:v1", wantErr: false},
+		{key: "plain-key", reference: "prod.example.com/app:v1", wantErr: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key+" "+tt.reference, func(t *testing.T) {
+			err := CheckKeyPurpose(tt.key, tt.reference)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CheckKeyPurpose() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && (!errors.Is(err, ErrTestKeyForProduction) || !strings.Contains(err.Error(), "config.json")) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateKeyPurpose(t *testing.T) {
+	for _, purpose := range []string{KeyPurposeProduction, KeyPurposeTest} {
+		if err := ValidateKeyPurpose(purpose); err != nil {
+			t.Fatalf("expected purpose %q to be valid, got %v", purpose, err)
+		}
+	}
+	if err := ValidateKeyPurpose("staging"); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestSigningKeys_ConcurrentUpdates(t *testing.T) {
+	defer func(oldDir string) {
+		dir.UserConfigDir = oldDir
+	}(dir.UserConfigDir)
+	dir.UserConfigDir = t.TempDir()
+
+	// keys added by parallel processes, e.g. jobs of a CI matrix, are not lost
+	const workers = 10
+	var wg sync.WaitGroup
+	errs := make(chan error, 2*workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(2)
+		name := fmt.Sprintf("key-%d", i)
+		go func() {
+			defer wg.Done()
+			errs <- AddPKCS11Key(name, PKCS11Key{Module: "/usr/lib/softhsm/libsofthsm2.so", Label: name}, "", false)
+		}()
+		go func() {
+			defer wg.Done()
+			errs <- AddKeychainKey(name+"-keychain", KeychainKey{Identity: name}, KeyPurposeTest, false)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	keys, err := loadSigningKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys.Keys) != 2*workers {
+		t.Fatalf("expected %d signing keys, got %d", 2*workers, len(keys.Keys))
+	}
+	purposes, err := LoadKeyPurposes()
+	if err != nil || len(purposes) != workers {
+		t.Fatalf("expected %d key purposes, got %d, %v", workers, len(purposes), err)
+	}
+}
@@ -0,0 +1,116 @@
+package configutil
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// KeyExpiryWarningPeriod is the period before the end of the validity window
+// of a signing key in which signing warns about the upcoming expiry.
+const KeyExpiryWarningPeriod = 30 * 24 * time.Hour
+
+var (
+	// ErrKeyNotYetValid indicates that a signing key is used before the start
+	// of its validity window.
+	ErrKeyNotYetValid = errors.New("signing key is not yet valid")
+
+	// ErrKeyExpired indicates that a signing key is used after the end of its
+	// validity window.
+	ErrKeyExpired = errors.New("signing key has expired")
+)
+
+// KeyValidity is the validity window of a signing key, in which the key is
+// allowed to sign. It enforces planned key rotation schedules on the client
+// side, and is independent of the validity of the signing certificate.
+type KeyValidity struct {
+	// NotBefore is the time the key is activated. The key is valid from any
+	// time if not set.
+	NotBefore *time.Time `json:"notBefore,omitempty"`
+
+	// NotAfter is the time the key expires. The key is valid to any time if
+	// not set.
+	NotAfter *time.Time `json:"notAfter,omitempty"`
+}
+
+// Validate validates that the window is not empty.
+func (v KeyValidity) Validate() error {
+	if v.NotBefore != nil && v.NotAfter != nil && !v.NotBefore.Before(*v.NotAfter) {
+		return fmt.Errorf("notBefore %s must be before notAfter %s", v.NotBefore.Format(time.RFC3339), v.NotAfter.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// ExpiresWithin returns true if the key expires within d after now.
+func (v KeyValidity) ExpiresWithin(now time.Time, d time.Duration) bool {
+	return v.NotAfter != nil && v.NotAfter.Before(now.Add(d))
+}
+
+// LoadKeyValidities returns the validity windows of the signing keys in
+// signingkeys.json indexed by key name. Keys without validity window are not
+// included.
+func LoadKeyValidities() (map[string]KeyValidity, error) {
+	keys, err := loadSigningKeys()
+	if err != nil {
+		return nil, err
+	}
+	validities := make(map[string]KeyValidity)
+	for _, key := range keys.Keys {
+		if key.NotBefore != nil || key.NotAfter != nil {
+			validities[key.Name] = key.KeyValidity
+		}
+	}
+	return validities, nil
+}
+
+// SetKeyValidity sets the validity window of the signing key with the name.
+// The window is removed if validity is empty.
+func SetKeyValidity(name string, validity KeyValidity) error {
+	if err := validity.Validate(); err != nil {
+		return err
+	}
+	return withSigningKeysLock(func() error {
+		keys, err := loadSigningKeys()
+		if err != nil {
+			return err
+		}
+		for i, key := range keys.Keys {
+			if key.Name == name {
+				keys.Keys[i].KeyValidity = validity
+				return saveSigningKeys(keys)
+			}
+		}
+		return fmt.Errorf("signing key %q not found", name)
+	})
+}
+
+// CheckKeyValidity returns ErrKeyNotYetValid or ErrKeyExpired if now is
+// outside the validity window of the signing key with the name. The default
+// signing key is checked if name is empty. The validity window of the key is
+// returned, so that the caller can warn about an upcoming expiry.
+func CheckKeyValidity(name string, now time.Time) (KeyValidity, error) {
+	keys, err := loadSigningKeys()
+	if err != nil {
+		return KeyValidity{}, err
+	}
+	if name == "" {
+		if keys.Default == nil {
+			return KeyValidity{}, nil
+		}
+		name = *keys.Default
+	}
+	var validity KeyValidity
+	for _, key := range keys.Keys {
+		if key.Name == name {
+			validity = key.KeyValidity
+			break
+		}
+	}
+	if validity.NotBefore != nil && now.Before(*validity.NotBefore) {
+		return validity, fmt.Errorf("%w: key %q is valid from %s", ErrKeyNotYetValid, name, validity.NotBefore.Format(time.RFC3339))
+	}
+	if validity.NotAfter != nil && now.After(*validity.NotAfter) {
+		return validity, fmt.Errorf("%w: key %q expired at %s", ErrKeyExpired, name, validity.NotAfter.Format(time.RFC3339))
+	}
+	return validity, nil
+}
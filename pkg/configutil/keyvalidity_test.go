@@ -0,0 +1,91 @@
+package configutil
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/notation-go/config"
+	"github.com/notaryproject/notation-go/dir"
+)
+
+func TestKeyValidity(t *testing.T) {
+	defer func(oldDir string) {
+		dir.UserConfigDir = oldDir
+	}(dir.UserConfigDir)
+	dir.UserConfigDir = t.TempDir()
+
+	signingKeysJSON := `{"default":"next-key","keys":[{"name":"old-key","keyPath":"o.key","certPath":"o.crt","purpose":"test","notAfter":"2026-01-01T00:00:00Z"},{"name":"next-key","keyPath":"n.key","certPath":"n.crt","notBefore":"2026-06-01T00:00:00Z"},{"name":"plain-key","keyPath":"p.key","certPath":"p.crt"}]}`
+	if err := os.WriteFile(filepath.Join(dir.UserConfigDir, dir.PathSigningKeys), []byte(signingKeysJSON), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		key     string
+		wantErr error
+	}{
+		{key: "old-key", wantErr: ErrKeyExpired},
+		{key: "", wantErr: ErrKeyNotYetValid},
+		{key: "next-key", wantErr: ErrKeyNotYetValid},
+		{key: "plain-key"},
+		{key: "unknown-key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			_, err := CheckKeyValidity(tt.key, now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CheckKeyValidity() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// windows are preserved by notation-go operations
+	updateDefault := func(s *config.SigningKeys) error {
+		return s.UpdateDefault("plain-key")
+	}
+	if err := LoadExecSaveSigningKeys(updateDefault, nil); err != nil {
+		t.Fatal(err)
+	}
+	validities, err := LoadKeyValidities()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(validities) != 2 || validities["old-key"].NotAfter == nil || validities["next-key"].NotBefore == nil {
+		t.Fatalf("unexpected validities: %v", validities)
+	}
+	purposes, err := LoadKeyPurposes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if purposes["old-key"] != KeyPurposeTest {
+		t.Fatalf("unexpected purposes: %v", purposes)
+	}
+
+	// set and remove windows
+	notAfter := now.Add(KeyExpiryWarningPeriod / 2)
+	if err := SetKeyValidity("plain-key", KeyValidity{NotAfter: &notAfter}); err != nil {
+		t.Fatal(err)
+	}
+	validity, err := CheckKeyValidity("", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !validity.ExpiresWithin(now, KeyExpiryWarningPeriod) {
+		t.Fatalf("expected key to expire within %v: %v", KeyExpiryWarningPeriod, validity)
+	}
+	if err := SetKeyValidity("next-key", KeyValidity{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CheckKeyValidity("next-key", now); err != nil {
+		t.Fatalf("expected window to be removed, got %v", err)
+	}
+	if err := SetKeyValidity("unknown-key", KeyValidity{}); err == nil {
+		t.Fatal("expected error for unknown key")
+	}
+	if err := SetKeyValidity("plain-key", KeyValidity{NotBefore: &notAfter, NotAfter: &now}); err == nil {
+		t.Fatal("expected error for empty window")
+	}
+}
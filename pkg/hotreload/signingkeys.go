@@ -0,0 +1,54 @@
+package hotreload
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/notaryproject/notation-go/config"
+	"github.com/notaryproject/notation-go/dir"
+)
+
+// SigningKeys holds the signing keys configured in signingkeys.json of the
+// notation config directory, and is swapped atomically on reload.
+type SigningKeys struct {
+	current atomic.Pointer[config.SigningKeys]
+}
+
+// NewSigningKeys returns SigningKeys loaded from the notation config
+// directory.
+func NewSigningKeys() (*SigningKeys, error) {
+	s := &SigningKeys{}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Get returns the signing keys as of the last reload. The returned value must
+// not be modified.
+func (s *SigningKeys) Get() *config.SigningKeys {
+	return s.current.Load()
+}
+
+// Reload loads the signing keys from the notation config directory. The
+// previous signing keys are kept if signingkeys.json is invalid.
+func (s *SigningKeys) Reload() error {
+	keys, err := config.LoadSigningKeys()
+	if err != nil {
+		return err
+	}
+	s.current.Store(keys)
+	return nil
+}
+
+// Watch reloads the signing keys when signingkeys.json changes until ctx is
+// done.
+func (s *SigningKeys) Watch(ctx context.Context, interval time.Duration) error {
+	path, err := dir.ConfigFS().SysPath(dir.PathSigningKeys)
+	if err != nil {
+		return err
+	}
+	NewWatcher(interval, s.Reload, path).Run(ctx)
+	return nil
+}
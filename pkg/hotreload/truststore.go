@@ -0,0 +1,104 @@
+package hotreload
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+
+	"github.com/notaryproject/notation-go/dir"
+	"github.com/notaryproject/notation-go/verifier/truststore"
+)
+
+// snapshotTrustStore is an in-memory copy of the x509 trust stores, so that
+// a verifier observes the trust stores as of the last reload even if the
+// certificate files are being rotated.
+type snapshotTrustStore struct {
+	certs map[string][]*x509.Certificate
+	errs  map[string]error
+
+	// digest is the digest of the certificates of the snapshot.
+	digest string
+}
+
+// loadTrustStore reads all named stores of all store types in the x509 trust
+// store of the notation config directory.
+func loadTrustStore(ctx context.Context) (*snapshotTrustStore, error) {
+	configFS := dir.ConfigFS()
+	base := truststore.NewX509TrustStore(configFS)
+	snapshot := &snapshotTrustStore{
+		certs: make(map[string][]*x509.Certificate),
+		errs:  make(map[string]error),
+	}
+	for _, storeType := range truststore.Types {
+		typePath, err := configFS.SysPath(dir.TrustStoreDir, "x509", string(storeType))
+		if err != nil {
+			return nil, err
+		}
+		entries, err := os.ReadDir(typePath)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				continue
+			}
+			key := snapshotKey(storeType, entry.Name())
+			certs, err := base.GetCertificates(ctx, storeType, entry.Name())
+			if err != nil {
+				// the error is reported when the named store is used, as
+				// done by the x509 trust store
+				snapshot.errs[key] = err
+				continue
+			}
+			snapshot.certs[key] = certs
+		}
+	}
+	snapshot.digest = snapshot.digestCertificates()
+	return snapshot, nil
+}
+
+// GetCertificates returns the certificates of the named store as of the last
+// reload.
+func (s *snapshotTrustStore) GetCertificates(ctx context.Context, storeType truststore.Type, namedStore string) ([]*x509.Certificate, error) {
+	key := snapshotKey(storeType, namedStore)
+	if err, ok := s.errs[key]; ok {
+		return nil, err
+	}
+	certs, ok := s.certs[key]
+	if !ok {
+		return nil, fmt.Errorf("trust store %q of type %q does not exist", namedStore, storeType)
+	}
+	return certs, nil
+}
+
+func snapshotKey(storeType truststore.Type, namedStore string) string {
+	return string(storeType) + "/" + namedStore
+}
+
+// digestCertificates returns the digest of the names of the stores and the
+// certificates in them.
+func (s *snapshotTrustStore) digestCertificates() string {
+	keys := make([]string, 0, len(s.certs))
+	for key := range s.certs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	h := sha256.New()
+	for _, key := range keys {
+		fmt.Fprintf(h, "store:%q\n", key)
+		for _, cert := range s.certs[key] {
+			sum := sha256.Sum256(cert.Raw)
+			fmt.Fprintf(h, "cert:%x\n", sum)
+		}
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil))
+}
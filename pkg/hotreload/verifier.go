@@ -0,0 +1,138 @@
+package hotreload
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation-go/dir"
+	"github.com/notaryproject/notation-go/verifier"
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+	"github.com/notaryproject/notation/internal/chaincache"
+	"github.com/notaryproject/notation/internal/experimental"
+	"github.com/notaryproject/notation/internal/pluginproto"
+	"github.com/notaryproject/notation/internal/policy"
+	"github.com/notaryproject/notation/internal/revocation"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// Verifier is a notation.Verifier built from the trust policy and the trust
+// stores in the notation config directory. The configuration is swapped
+// atomically on reload, so that each verification sees either the old or the
+// new configuration as a whole.
+//
+// If trust policy statements are scoped by artifact types, the artifact type
+// of the manifest descriptors being verified must be set. The trust stores are
+// read from the notation config directory, so trust store directories
+// overridden by flag "--trust-store" of "notation verify" are not supported.
+type Verifier struct {
+	// name is the name of the trust policy document, see policy.DocumentPath.
+	name string
+
+	current atomic.Pointer[policy.Verifier]
+
+	// chainCache memoizes the revocation status of the certificate chains
+	// checked against the current trust store. It is kept on reloads not
+	// changing the trust store.
+	chainCache atomic.Pointer[chaincache.Cache[[]revocation.Result]]
+}
+
+// chainCacheMaxAge is the duration the revocation status of a certificate
+// chain is memoized for, so that a long-running process notices revoked
+// certificates.
+const chainCacheMaxAge = 10 * time.Minute
+
+// NewVerifier returns a Verifier built from the notation config directory.
+func NewVerifier(ctx context.Context) (*Verifier, error) {
+	return NewNamedVerifier(ctx, "")
+}
+
+// NewNamedVerifier is like NewVerifier, but enforces the trust policy document
+// named name, see policy.DocumentPath, as flag "--policy-name" of "notation
+// verify" does.
+func NewNamedVerifier(ctx context.Context, name string) (*Verifier, error) {
+	v := &Verifier{name: name}
+	if err := v.Reload(ctx); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// Reload rebuilds the verifier from the notation config directory. The
+// previous configuration is kept if the new configuration is invalid.
+func (v *Verifier) Reload(ctx context.Context) error {
+	policyDoc, extDoc, err := policy.LoadNamedDocuments(v.name)
+	if err != nil {
+		return err
+	}
+	trustStore, err := loadTrustStore(ctx)
+	if err != nil {
+		return err
+	}
+	pluginManager := pluginproto.NewCLIManager(dir.PluginFS())
+	revocationChecker := revocation.NewChecker(revocation.Options{})
+	chainCache := v.chainCache.Load()
+	if chainCache == nil || chainCache.TrustStore() != trustStore.digest {
+		chainCache = chaincache.New[[]revocation.Result](trustStore.digest, chainCacheMaxAge)
+	}
+	current, err := policy.NewVerifier(policyDoc, extDoc, func(policyDoc *trustpolicy.Document) (notation.Verifier, error) {
+		base, err := verifier.New(policyDoc, trustStore, pluginManager)
+		if err != nil {
+			return nil, err
+		}
+		if experimental.IsDisabled() {
+			// the revocation checks are experimental
+			return base, nil
+		}
+		return revocation.NewVerifier(base, revocationChecker, chainCache), nil
+	})
+	if err != nil {
+		return err
+	}
+	v.current.Store(current)
+	v.chainCache.Store(chainCache)
+	return nil
+}
+
+// Watch reloads the verifier when the trust policy document in use or the
+// trust stores change until ctx is done.
+func (v *Verifier) Watch(ctx context.Context, interval time.Duration) error {
+	paths, err := v.watchPaths()
+	if err != nil {
+		return err
+	}
+	NewWatcher(interval, func() error {
+		return v.Reload(ctx)
+	}, paths...).Run(ctx)
+	return nil
+}
+
+// watchPaths returns the paths of the trust policy document in use and of the
+// trust stores of all types.
+func (v *Verifier) watchPaths() ([]string, error) {
+	configFS := dir.ConfigFS()
+	relPath, err := policy.DocumentPath(v.name)
+	if err != nil {
+		return nil, err
+	}
+	policyPath, err := configFS.SysPath(relPath)
+	if err != nil {
+		return nil, err
+	}
+	trustStorePath, err := configFS.SysPath(dir.TrustStoreDir)
+	if err != nil {
+		return nil, err
+	}
+	return []string{policyPath, trustStorePath}, nil
+}
+
+// SkipVerify validates whether the verification level is skip.
+func (v *Verifier) SkipVerify(ctx context.Context, opts notation.VerifierVerifyOptions) (bool, *trustpolicy.VerificationLevel, error) {
+	return v.current.Load().SkipVerify(ctx, opts)
+}
+
+// Verify verifies the signature with the current configuration.
+func (v *Verifier) Verify(ctx context.Context, desc ocispec.Descriptor, signature []byte, opts notation.VerifierVerifyOptions) (*notation.VerificationOutcome, error) {
+	return v.current.Load().Verify(ctx, desc, signature, opts)
+}
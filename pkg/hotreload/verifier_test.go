@@ -0,0 +1,84 @@
+package hotreload
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/notaryproject/notation-go/dir"
+)
+
+func TestVerifierReload(t *testing.T) {
+	configDir := t.TempDir()
+	defer func(old string) { dir.UserConfigDir = old }(dir.UserConfigDir)
+	dir.UserConfigDir = configDir
+	policyPath := filepath.Join(configDir, dir.PathTrustPolicy)
+	writePolicy := func(content string) {
+		if err := os.WriteFile(policyPath, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writePolicy(`{"version":"1.0","trustPolicies":[{"name":"skip","registryScopes":["*"],"signatureVerification":{"level":"skip"}}]}`)
+	ctx := context.Background()
+	v, err := NewVerifier(ctx)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	first := v.current.Load()
+
+	// an invalid trust policy keeps the previous configuration
+	writePolicy(`{"version":"1.0","trustPolicies":[]}`)
+	if err := v.Reload(ctx); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if v.current.Load() != first {
+		t.Fatal("expected previous configuration to be kept")
+	}
+
+	writePolicy(`{"version":"1.0","trustPolicies":[{"name":"rotated","registryScopes":["*"],"signatureVerification":{"level":"skip"}}]}`)
+	if err := v.Reload(ctx); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if v.current.Load() == first {
+		t.Fatal("expected configuration to be reloaded")
+	}
+}
+
+func TestNamedVerifierReload(t *testing.T) {
+	configDir := t.TempDir()
+	defer func(old string) { dir.UserConfigDir = old }(dir.UserConfigDir)
+	dir.UserConfigDir = configDir
+	policyPath := filepath.Join(configDir, "trustpolicy.d", "prod.json")
+	if err := os.MkdirAll(filepath.Dir(policyPath), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(policyPath, []byte(`{"version":"1.0","trustPolicies":[{"name":"skip","registryScopes":["*"],"signatureVerification":{"level":"skip"}}]}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// trustpolicy.json is not present
+	ctx := context.Background()
+	v, err := NewNamedVerifier(ctx, "prod")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	paths, err := v.watchPaths()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 2 || paths[0] != policyPath || paths[1] != filepath.Join(configDir, dir.TrustStoreDir) {
+		t.Fatalf("expected the named trust policy and the trust stores to be watched, got %v", paths)
+	}
+
+	if err := os.WriteFile(policyPath, []byte(`{"version":"1.0","trustPolicies":[]}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Reload(ctx); err == nil {
+		t.Fatal("expected error of the named trust policy, got nil")
+	}
+	if _, err := NewNamedVerifier(ctx, "missing"); err == nil {
+		t.Fatal("expected error of missing trust policy, got nil")
+	}
+}
@@ -0,0 +1,155 @@
+// Package hotreload provides notation components for long-running processes,
+// such as admission webhooks using notation as a library. The components
+// reload the notation configuration when it changes on disk, so that rotated
+// roots and keys are picked up without restarts.
+package hotreload
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/notaryproject/notation-go/log"
+)
+
+// DefaultInterval is the default interval of checking the watched paths for
+// changes.
+const DefaultInterval = 5 * time.Second
+
+// Watcher polls files and directories for changes and calls a reload function
+// when any of them changes.
+//
+// Polling is used instead of file system notifications, so that changes made
+// through symlink swaps, such as updates of Kubernetes ConfigMap and Secret
+// volumes, are detected as well.
+type Watcher struct {
+	paths       []string
+	interval    time.Duration
+	reload      func() error
+	fingerprint string
+}
+
+// NewWatcher returns a Watcher calling reload when any of paths changes. The
+// paths are checked every interval, or every DefaultInterval if interval is
+// not positive.
+func NewWatcher(interval time.Duration, reload func() error, paths ...string) *Watcher {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	return &Watcher{
+		paths:    paths,
+		interval: interval,
+		reload:   reload,
+	}
+}
+
+// Run watches the paths until ctx is done. Reload errors are logged and the
+// reload is retried on the next check.
+func (w *Watcher) Run(ctx context.Context) {
+	logger := log.GetLogger(ctx)
+	if fingerprint, err := w.computeFingerprint(); err == nil {
+		w.fingerprint = fingerprint
+	}
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			changed, err := w.check()
+			if err != nil {
+				logger.Warnf("failed to check %v for changes: %v", w.paths, err)
+				continue
+			}
+			if changed {
+				logger.Infof("reloaded %v", w.paths)
+			}
+		}
+	}
+}
+
+// check calls the reload function if any of the paths has changed since the
+// last successful reload.
+func (w *Watcher) check() (bool, error) {
+	fingerprint, err := w.computeFingerprint()
+	if err != nil {
+		return false, err
+	}
+	if fingerprint == w.fingerprint {
+		return false, nil
+	}
+	if err := w.reload(); err != nil {
+		return false, err
+	}
+	w.fingerprint = fingerprint
+	return true, nil
+}
+
+// computeFingerprint hashes the names and contents of the files in the paths.
+// Missing paths are part of the fingerprint, so that creation and removal
+// are detected. Symlinks are followed, so that the contents of Kubernetes
+// volumes, whose entries link into a swapped "..data" directory, are hashed.
+func (w *Watcher) computeFingerprint() (string, error) {
+	h := sha256.New()
+	for _, path := range w.paths {
+		if err := fingerprintPath(h, path, make(map[string]bool)); err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// fingerprintPath writes the name and contents of name to h, descending into
+// directories. ancestors holds the resolved directories being walked, so that
+// symlink cycles are not followed.
+func fingerprintPath(h hash.Hash, name string, ancestors map[string]bool) error {
+	info, err := os.Stat(name)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			io.WriteString(h, "missing:"+name+"\n")
+			return nil
+		}
+		return err
+	}
+	if !info.IsDir() {
+		io.WriteString(h, "file:"+name+"\n")
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(h, f)
+		return err
+	}
+
+	resolved, err := filepath.EvalSymlinks(name)
+	if err != nil {
+		return err
+	}
+	if ancestors[resolved] {
+		io.WriteString(h, "cycle:"+name+"\n")
+		return nil
+	}
+	ancestors[resolved] = true
+	defer delete(ancestors, resolved)
+
+	io.WriteString(h, "dir:"+name+"\n")
+	entries, err := os.ReadDir(name)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := fingerprintPath(h, filepath.Join(name, entry.Name()), ancestors); err != nil {
+			return err
+		}
+	}
+	return nil
+}
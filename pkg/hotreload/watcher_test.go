@@ -0,0 +1,112 @@
+package hotreload
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWatcherCheck(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "trustpolicy.json")
+	if err := os.WriteFile(path, []byte("v1"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	reloads := 0
+	var reloadErr error
+	w := NewWatcher(0, func() error {
+		reloads++
+		return reloadErr
+	}, path, filepath.Join(root, "truststore"))
+	fingerprint, err := w.computeFingerprint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.fingerprint = fingerprint
+
+	if changed, err := w.check(); err != nil || changed {
+		t.Fatalf("expected no change, got changed=%v, err=%v", changed, err)
+	}
+
+	// a failed reload is retried on the next check
+	if err := os.WriteFile(path, []byte("v2"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	reloadErr = errors.New("invalid trust policy")
+	if _, err := w.check(); err == nil {
+		t.Fatal("expected reload error, got nil")
+	}
+	reloadErr = nil
+	if changed, err := w.check(); err != nil || !changed {
+		t.Fatalf("expected change, got changed=%v, err=%v", changed, err)
+	}
+
+	// creation of a watched directory is detected
+	if err := os.MkdirAll(filepath.Join(root, "truststore", "x509"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if changed, err := w.check(); err != nil || !changed {
+		t.Fatalf("expected change, got changed=%v, err=%v", changed, err)
+	}
+	if reloads != 3 {
+		t.Fatalf("expected 3 reloads, got %d", reloads)
+	}
+}
+
+func TestWatcherCheck_SymlinkedData(t *testing.T) {
+	// layout of a Kubernetes ConfigMap volume: the entries link into the
+	// "..data" directory, which is swapped atomically on updates.
+	root := t.TempDir()
+	writeVersion := func(version, content string) {
+		dir := filepath.Join(root, version, "x509", "ca", "acme")
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "root.crt"), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	swapData := func(version string) {
+		tmp := filepath.Join(root, "..data_tmp")
+		if err := os.Symlink(version, tmp); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Rename(tmp, filepath.Join(root, "..data")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeVersion("..2024_01", "v1")
+	swapData("..2024_01")
+	if err := os.Symlink(filepath.Join("..data", "x509"), filepath.Join(root, "x509")); err != nil {
+		t.Fatal(err)
+	}
+	// a symlink cycle is not followed
+	if err := os.Symlink(".", filepath.Join(root, "..2024_01", "x509", "ca", "self")); err != nil {
+		t.Fatal(err)
+	}
+
+	reloads := 0
+	w := NewWatcher(0, func() error {
+		reloads++
+		return nil
+	}, filepath.Join(root, "x509"))
+	fingerprint, err := w.computeFingerprint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.fingerprint = fingerprint
+
+	if changed, err := w.check(); err != nil || changed {
+		t.Fatalf("expected no change, got changed=%v, err=%v", changed, err)
+	}
+
+	writeVersion("..2024_02", "v2")
+	swapData("..2024_02")
+	if changed, err := w.check(); err != nil || !changed {
+		t.Fatalf("expected change, got changed=%v, err=%v", changed, err)
+	}
+	if reloads != 1 {
+		t.Fatalf("expected 1 reload, got %d", reloads)
+	}
+}